@@ -0,0 +1,103 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+/**
+ * db233 命令行工具
+ *
+ * 用法：
+ *   db233 doctor -host 127.0.0.1 -port 3306 -user root -password xxx -database game -max-open 50
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "doctor":
+		os.Exit(runDoctor(os.Args[2:]))
+	case "-h", "--help", "help":
+		printUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", os.Args[1])
+		printUsage()
+		os.Exit(2)
+	}
+}
+
+/**
+ * 打印用法
+ */
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "用法: db233 <command> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "命令:")
+	fmt.Fprintln(os.Stderr, "  doctor    启动自检（连通性、权限、字符集/时区、连接数、主键索引）")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "使用 db233 <command> -h 查看命令参数")
+}
+
+/**
+ * 执行 doctor 命令，存在失败项时返回 1
+ */
+func runDoctor(args []string) int {
+	flags := flag.NewFlagSet("doctor", flag.ExitOnError)
+	dbType := flags.String("type", string(db233.EnumDatabaseTypeMySQL), "数据库类型（mysql / postgresql）")
+	host := flags.String("host", "127.0.0.1", "主机地址")
+	port := flags.Int("port", 3306, "端口号")
+	user := flags.String("user", "root", "用户名")
+	password := flags.String("password", "", "密码（也可通过环境变量 DB233_PASSWORD 提供）")
+	database := flags.String("database", "", "数据库名")
+	maxOpen := flags.Int("max-open", 0, "应用实际使用的连接池上限，用于与 max_connections 比较（0 表示使用默认配置）")
+	timeout := flags.Duration("timeout", 5*time.Second, "单项检查超时")
+	_ = flags.Parse(args)
+
+	if *password == "" {
+		*password = os.Getenv("DB233_PASSWORD")
+	}
+
+	var config *db233.DbConnectionConfig
+	if db233.EnumDatabaseType(*dbType) == db233.EnumDatabaseTypePostgreSQL {
+		config = db233.NewDefaultPostgreSQLConfig(*host, *port, *user, *password, *database)
+	} else {
+		config = db233.NewDefaultMySQLConfig(*host, *port, *user, *password, *database)
+	}
+	config.ConnectTimeout = *timeout
+	if *maxOpen > 0 {
+		config.MaxOpenConns = *maxOpen
+	}
+
+	// 不使用 CreateDb：连接失败时仍交给 Doctor 输出完整报告
+	driverName := "mysql"
+	if config.DatabaseType == db233.EnumDatabaseTypePostgreSQL {
+		driverName = "postgres"
+	}
+	dataSource, err := sql.Open(driverName, config.BuildDSN())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "打开数据库连接失败: %v\n", err)
+		return 1
+	}
+	defer dataSource.Close()
+	if config.MaxOpenConns > 0 {
+		dataSource.SetMaxOpenConns(config.MaxOpenConns)
+	}
+	db := db233.NewDbWithType(dataSource, 0, nil, config.DatabaseType)
+
+	report := db233.NewDbDoctor(db).SetTimeout(*timeout).SetOutput(os.Stdout).Run()
+	if report.HasFailures() {
+		return 1
+	}
+	return 0
+}
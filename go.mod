@@ -2,4 +2,8 @@ module github.com/neko233-com/db233-go
 
 go 1.21
 
-require github.com/go-sql-driver/mysql v1.7.1
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/go-sql-driver/mysql v1.7.1
+	gopkg.in/yaml.v3 v3.0.1
+)
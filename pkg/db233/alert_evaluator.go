@@ -0,0 +1,434 @@
+package db233
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * AlertEvaluator - 告警规则自动评估器
+ *
+ * 订阅 MetricsCollector / MetricsAggregator / MetricsDataSource，按间隔取各指标的最新值，
+ * 对 AlertManager 中所有启用的规则调用 CheckMetric，自动触发与解决告警，不再需要手动调用 CheckMetric：
+ *
+ *   evaluator := db233.NewAlertEvaluator("main", alertManager).
+ *       AddCollector(collector).
+ *       AddAggregator(aggregator).
+ *       SetEvaluationInterval(15 * time.Second)
+ *   evaluator.Start()
+ *
+ * 规则的 Metric 可以是指标名，也可以是窗口表达式 <函数>(<指标>[<窗口>])：
+ *
+ *   pool.active_connections              最新值
+ *   rate(perf.total_queries[1m])         每秒增长速率（计数器重置时按重置后的值计算）
+ *   delta(perf.failed_queries[5m])       窗口内的增量
+ *   avg / min / max / sum / count / p95 / p99(perf.avg_response_ms[5m])   窗口聚合
+ *
+ * 表达式规则（见 AddExpressionRule）引用的指标同样按上述方式取值
+ *
+ * 窗口数据优先取 MetricsCollector 的历史，其余来源（聚合器、数据源）使用评估器自己保留的采样
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type AlertEvaluator struct {
+	name         string
+	alertManager *AlertManager
+
+	collectors  []*MetricsCollector
+	aggregators []*MetricsAggregator
+	dataSources []MetricsDataSource
+
+	evaluationInterval time.Duration
+
+	// 聚合器、数据源指标的采样（收集器指标使用收集器自身的历史）
+	samples          map[string][]MetricPoint
+	historyRetention time.Duration
+
+	lastValues     map[string]float64
+	lastEvaluation time.Time
+	evaluations    int64
+
+	mu       sync.RWMutex
+	running  bool
+	stopChan chan bool
+}
+
+/**
+ * 窗口表达式：<函数>(<指标>[<窗口>])
+ */
+var alertMetricExpressionPattern = regexp.MustCompile(`^(\w+)\(\s*([^\[\]\s]+)\s*\[(\w+)\]\s*\)$`)
+
+/**
+ * 解析后的窗口表达式
+ */
+type alertMetricExpression struct {
+	function string
+	metric   string
+	window   time.Duration
+}
+
+/**
+ * 创建告警评估器（默认 30 秒评估一次，采样保留 1 小时）
+ */
+func NewAlertEvaluator(name string, alertManager *AlertManager) *AlertEvaluator {
+	return &AlertEvaluator{
+		name:               name,
+		alertManager:       alertManager,
+		evaluationInterval: 30 * time.Second,
+		samples:            make(map[string][]MetricPoint),
+		historyRetention:   time.Hour,
+		lastValues:         make(map[string]float64),
+	}
+}
+
+/**
+ * 订阅监控数据收集器（指标名为收集器中的 <数据源>.<指标>）
+ */
+func (ae *AlertEvaluator) AddCollector(collector *MetricsCollector) *AlertEvaluator {
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+	ae.collectors = append(ae.collectors, collector)
+	return ae
+}
+
+/**
+ * 订阅指标聚合器（指标名为聚合规则名，每次评估前刷新）
+ */
+func (ae *AlertEvaluator) AddAggregator(aggregator *MetricsAggregator) *AlertEvaluator {
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+	ae.aggregators = append(ae.aggregators, aggregator)
+	return ae
+}
+
+/**
+ * 直接订阅数据源（指标名为 <数据源>.<指标>，与收集器一致）
+ */
+func (ae *AlertEvaluator) AddDataSource(source MetricsDataSource) *AlertEvaluator {
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+	ae.dataSources = append(ae.dataSources, source)
+	return ae
+}
+
+/**
+ * 设置评估间隔
+ */
+func (ae *AlertEvaluator) SetEvaluationInterval(interval time.Duration) *AlertEvaluator {
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+	if interval > 0 {
+		ae.evaluationInterval = interval
+	}
+	return ae
+}
+
+/**
+ * 设置采样保留时长（应不小于规则中最长的窗口）
+ */
+func (ae *AlertEvaluator) SetHistoryRetention(retention time.Duration) *AlertEvaluator {
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+	if retention > 0 {
+		ae.historyRetention = retention
+	}
+	return ae
+}
+
+/**
+ * 启动定期评估
+ */
+func (ae *AlertEvaluator) Start() {
+	ae.mu.Lock()
+	if ae.running {
+		ae.mu.Unlock()
+		return
+	}
+	ae.running = true
+	ae.stopChan = make(chan bool)
+	interval := ae.evaluationInterval
+	stopChan := ae.stopChan
+	ae.mu.Unlock()
+
+	LogInfo("告警评估器启动: %s, 间隔: %v", ae.name, interval)
+
+	goSupervised("alert_evaluator:"+ae.name, stopChan, func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		ae.Evaluate(time.Now())
+		for {
+			select {
+			case now := <-ticker.C:
+				ae.Evaluate(now)
+			case <-stopChan:
+				LogInfo("告警评估器停止: %s", ae.name)
+				return
+			}
+		}
+	})
+}
+
+/**
+ * 停止定期评估
+ */
+func (ae *AlertEvaluator) Stop() {
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+	if !ae.running {
+		return
+	}
+	ae.running = false
+	close(ae.stopChan)
+}
+
+/**
+ * 评估一次所有启用的规则，返回本次取到的指标值（以规则的 Metric 或表达式中的指标为键）
+ *
+ * 没有数据的指标不送入，已触发的告警保持原状态
+ */
+func (ae *AlertEvaluator) Evaluate(now time.Time) map[string]float64 {
+	ae.mu.Lock()
+	collectors := append([]*MetricsCollector(nil), ae.collectors...)
+	aggregators := append([]*MetricsAggregator(nil), ae.aggregators...)
+	dataSources := append([]MetricsDataSource(nil), ae.dataSources...)
+	ae.mu.Unlock()
+
+	// 收集最新值（不持有评估器的锁，避免与数据源互相等待）
+	latest := make(map[string]interface{})
+	sampled := make(map[string]interface{})
+	for _, collector := range collectors {
+		for name, point := range collector.GetLatestMetrics() {
+			latest[name] = point.Value
+		}
+	}
+	for _, aggregator := range aggregators {
+		if err := aggregator.RefreshMetrics(); err != nil {
+			LogWarn("告警评估器刷新聚合指标失败: %s, 错误=%v", ae.name, err)
+		}
+		for name, metric := range aggregator.GetAllAggregatedMetrics() {
+			latest[name] = metric.Value
+			sampled[name] = metric.Value
+		}
+	}
+	for _, source := range dataSources {
+		sourceName := source.GetName()
+		for metricName, value := range source.GetMetrics() {
+			name := fmt.Sprintf("%s.%s", sourceName, metricName)
+			latest[name] = value
+			sampled[name] = value
+		}
+	}
+
+	ae.mu.Lock()
+	ae.recordSamples(sampled, now)
+	ae.mu.Unlock()
+
+	values := make(map[string]float64)
+	for _, rule := range ae.alertManager.GetAlertRules() {
+		if !rule.Enabled || rule.Expression != "" {
+			continue
+		}
+		if _, evaluated := values[rule.Metric]; evaluated {
+			continue
+		}
+		if value, ok := ae.resolveMetric(rule.Metric, latest, collectors, now); ok {
+			values[rule.Metric] = value
+		}
+	}
+
+	for metric, value := range values {
+		ae.alertManager.CheckMetric(metric, value)
+	}
+	ae.alertManager.EvaluateExpressionRules(func(metric string) (float64, bool) {
+		if value, evaluated := values[metric]; evaluated {
+			return value, true
+		}
+		value, ok := ae.resolveMetric(metric, latest, collectors, now)
+		if ok {
+			values[metric] = value
+		}
+		return value, ok
+	}, now)
+
+	ae.mu.Lock()
+	ae.lastValues = values
+	ae.lastEvaluation = now
+	ae.evaluations++
+	ae.mu.Unlock()
+	return values
+}
+
+/**
+ * 记录聚合器、数据源指标的采样并清理超过保留时长的数据
+ */
+func (ae *AlertEvaluator) recordSamples(values map[string]interface{}, now time.Time) {
+	cutoff := now.Add(-ae.historyRetention)
+	for name, value := range values {
+		if _, ok := toFloat64(value); !ok {
+			continue
+		}
+		points := append(ae.samples[name], MetricPoint{Timestamp: now, Name: name, Value: value})
+		for len(points) > 1 && points[0].Timestamp.Before(cutoff) {
+			points = points[1:]
+		}
+		ae.samples[name] = points
+	}
+}
+
+/**
+ * 计算规则 Metric 对应的值（指标名或窗口表达式）
+ */
+func (ae *AlertEvaluator) resolveMetric(metric string, latest map[string]interface{}, collectors []*MetricsCollector, now time.Time) (float64, bool) {
+	expression, isExpression := parseAlertMetricExpression(metric)
+	if !isExpression {
+		value, exists := latest[metric]
+		if !exists {
+			return 0, false
+		}
+		return toFloat64(value)
+	}
+
+	values, timestamps := ae.windowValues(expression.metric, expression.window, collectors, now)
+	if len(values) == 0 {
+		return 0, false
+	}
+	switch expression.function {
+	case "rate", "delta":
+		if len(values) < 2 {
+			return 0, false
+		}
+		delta := 0.0
+		for i := 1; i < len(values); i++ {
+			if values[i] >= values[i-1] {
+				delta += values[i] - values[i-1]
+			} else {
+				// 计数器重置
+				delta += values[i]
+			}
+		}
+		if expression.function == "delta" {
+			return delta, true
+		}
+		elapsed := timestamps[len(timestamps)-1].Sub(timestamps[0]).Seconds()
+		if elapsed <= 0 {
+			return 0, false
+		}
+		return delta / elapsed, true
+	case "count":
+		return float64(len(values)), true
+	case "sum":
+		sum := 0.0
+		for _, value := range values {
+			sum += value
+		}
+		return sum, true
+	}
+
+	min, max, avg, p95, p99 := calculateMetricStats(values)
+	switch expression.function {
+	case "avg":
+		return avg, true
+	case "min":
+		return min, true
+	case "max":
+		return max, true
+	case "p95":
+		return p95, true
+	case "p99":
+		return p99, true
+	}
+	LogWarn("告警规则使用了未知的函数: %s", metric)
+	return 0, false
+}
+
+/**
+ * 获取窗口内的数值与时间（按时间排序）
+ */
+func (ae *AlertEvaluator) windowValues(metric string, window time.Duration, collectors []*MetricsCollector, now time.Time) ([]float64, []time.Time) {
+	cutoff := now.Add(-window)
+	var points []MetricPoint
+	for _, collector := range collectors {
+		if history := collector.GetMetricHistory(metric, time.Since(cutoff)); len(history) > 0 {
+			points = history
+			break
+		}
+	}
+	if points == nil {
+		ae.mu.RLock()
+		for _, point := range ae.samples[metric] {
+			if !point.Timestamp.Before(cutoff) {
+				points = append(points, point)
+			}
+		}
+		ae.mu.RUnlock()
+	}
+
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Timestamp.Before(points[j].Timestamp) })
+	values := make([]float64, 0, len(points))
+	timestamps := make([]time.Time, 0, len(points))
+	for _, point := range points {
+		if point.Timestamp.After(now) {
+			continue
+		}
+		if value, ok := toFloat64(point.Value); ok {
+			values = append(values, value)
+			timestamps = append(timestamps, point.Timestamp)
+		}
+	}
+	return values, timestamps
+}
+
+/**
+ * 解析窗口表达式，不是表达式时返回 false
+ */
+func parseAlertMetricExpression(metric string) (alertMetricExpression, bool) {
+	matches := alertMetricExpressionPattern.FindStringSubmatch(strings.TrimSpace(metric))
+	if matches == nil {
+		return alertMetricExpression{}, false
+	}
+	window, err := time.ParseDuration(matches[3])
+	if err != nil || window <= 0 {
+		return alertMetricExpression{}, false
+	}
+	return alertMetricExpression{function: strings.ToLower(matches[1]), metric: matches[2], window: window}, true
+}
+
+/**
+ * 获取最近一次评估的值
+ */
+func (ae *AlertEvaluator) GetLastValues() map[string]float64 {
+	ae.mu.RLock()
+	defer ae.mu.RUnlock()
+
+	result := make(map[string]float64, len(ae.lastValues))
+	for k, v := range ae.lastValues {
+		result[k] = v
+	}
+	return result
+}
+
+/**
+ * 获取评估器状态
+ */
+func (ae *AlertEvaluator) GetStatus() map[string]interface{} {
+	ae.mu.RLock()
+	defer ae.mu.RUnlock()
+
+	return map[string]interface{}{
+		"name":                ae.name,
+		"running":             ae.running,
+		"evaluation_interval": ae.evaluationInterval.String(),
+		"collectors":          len(ae.collectors),
+		"aggregators":         len(ae.aggregators),
+		"data_sources":        len(ae.dataSources),
+		"evaluations":         ae.evaluations,
+		"last_evaluation":     ae.lastEvaluation,
+		"last_values":         ae.lastValues,
+	}
+}
@@ -0,0 +1,535 @@
+package db233
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+ * 表达式告警规则
+ *
+ * 单指标规则只能比较一个阈值；表达式规则可以组合多个指标：
+ *
+ *   am.AddExpressionRule(db233.AlertRule{
+ *       ID: "error_burst", Name: "错误突增", Severity: db233.Error, Enabled: true,
+ *       Expression: "perf.error_rate > 0.05 && rate(perf.total_queries[1m]) > 100",
+ *   })
+ *   am.AddExpressionRule(db233.AlertRule{
+ *       ID: "slow_p99", Name: "P99 持续偏高", Enabled: true,
+ *       Expression: "perf.p99_ms > 500 for 5m",   // 持续满足 5 分钟才触发
+ *   })
+ *
+ * 语法：数字、指标名（可含 . 与 :）、窗口表达式 <函数>(<指标>[<窗口>])（见 AlertEvaluator），
+ * 算术 + - * /，比较 > >= < <= == !=，逻辑 && || !，括号；末尾的 "for <时长>" 等同于设置 AlertRule.For
+ *
+ * 由 AlertEvaluator 自动评估，也可以手动调用 CheckExpressions 传入指标值；
+ * 引用的指标任一缺失时跳过本次评估，告警保持原状态
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type alertExpressionNode interface {
+	eval(resolve func(metric string) (float64, bool), operands map[string]float64) (float64, bool)
+}
+
+/**
+ * 末尾的 for <时长>
+ */
+var alertExpressionForPattern = regexp.MustCompile(`^(.*\S)\s+for\s+(\w+)\s*$`)
+
+/**
+ * 常量
+ */
+type alertNumberNode struct {
+	value float64
+}
+
+func (n *alertNumberNode) eval(func(string) (float64, bool), map[string]float64) (float64, bool) {
+	return n.value, true
+}
+
+/**
+ * 指标引用（含窗口表达式）
+ */
+type alertMetricNode struct {
+	metric string
+}
+
+func (n *alertMetricNode) eval(resolve func(string) (float64, bool), operands map[string]float64) (float64, bool) {
+	value, ok := resolve(n.metric)
+	if ok {
+		operands[n.metric] = value
+	}
+	return value, ok
+}
+
+/**
+ * 一元运算 ! -
+ */
+type alertUnaryNode struct {
+	op      string
+	operand alertExpressionNode
+}
+
+func (n *alertUnaryNode) eval(resolve func(string) (float64, bool), operands map[string]float64) (float64, bool) {
+	value, ok := n.operand.eval(resolve, operands)
+	if !ok {
+		return 0, false
+	}
+	if n.op == "!" {
+		return boolToFloat(value == 0), true
+	}
+	return -value, true
+}
+
+/**
+ * 二元运算
+ */
+type alertBinaryNode struct {
+	op          string
+	left, right alertExpressionNode
+}
+
+func (n *alertBinaryNode) eval(resolve func(string) (float64, bool), operands map[string]float64) (float64, bool) {
+	// 两侧都求值，保证引用的指标全部记录到 operands
+	left, leftOk := n.left.eval(resolve, operands)
+	right, rightOk := n.right.eval(resolve, operands)
+	if !leftOk || !rightOk {
+		return 0, false
+	}
+	switch n.op {
+	case "+":
+		return left + right, true
+	case "-":
+		return left - right, true
+	case "*":
+		return left * right, true
+	case "/":
+		if right == 0 {
+			return 0, false
+		}
+		return left / right, true
+	case ">":
+		return boolToFloat(left > right), true
+	case ">=":
+		return boolToFloat(left >= right), true
+	case "<":
+		return boolToFloat(left < right), true
+	case "<=":
+		return boolToFloat(left <= right), true
+	case "==":
+		return boolToFloat(left == right), true
+	case "!=":
+		return boolToFloat(left != right), true
+	case "&&":
+		return boolToFloat(left != 0 && right != 0), true
+	case "||":
+		return boolToFloat(left != 0 || right != 0), true
+	}
+	return 0, false
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+/**
+ * 已编译的表达式
+ */
+type compiledAlertExpression struct {
+	root alertExpressionNode
+	For  time.Duration
+}
+
+/**
+ * 编译表达式（含末尾的 for <时长>）
+ */
+func compileAlertExpression(expression string) (*compiledAlertExpression, error) {
+	text := strings.TrimSpace(expression)
+	compiled := &compiledAlertExpression{}
+	if matches := alertExpressionForPattern.FindStringSubmatch(text); matches != nil {
+		duration, err := time.ParseDuration(matches[2])
+		if err != nil || duration <= 0 {
+			return nil, NewValidationException(fmt.Sprintf("告警表达式的持续时间无效: %s", matches[2]))
+		}
+		text = matches[1]
+		compiled.For = duration
+	}
+
+	tokens, err := tokenizeAlertExpression(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, NewValidationException("告警表达式不能为空")
+	}
+	parser := &alertExpressionParser{tokens: tokens}
+	root, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos < len(tokens) {
+		return nil, NewValidationException(fmt.Sprintf("告警表达式存在多余内容: %s", tokens[parser.pos].text))
+	}
+	compiled.root = root
+	return compiled, nil
+}
+
+/**
+ * 校验告警表达式
+ */
+func ValidateAlertExpression(expression string) error {
+	_, err := compileAlertExpression(expression)
+	return err
+}
+
+/**
+ * 词法单元
+ */
+type alertExpressionToken struct {
+	kind string // number / metric / op
+	text string
+}
+
+/**
+ * 拆分词法单元（窗口表达式作为一个指标）
+ */
+func tokenizeAlertExpression(text string) ([]alertExpressionToken, error) {
+	tokens := make([]alertExpressionToken, 0)
+	isMetricChar := func(c byte) bool {
+		return c == '_' || c == '.' || c == ':' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+	}
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(text) && (text[i] >= '0' && text[i] <= '9' || text[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, alertExpressionToken{kind: "number", text: text[start:i]})
+		case isMetricChar(c):
+			start := i
+			for i < len(text) && isMetricChar(text[i]) {
+				i++
+			}
+			// 窗口表达式：<函数>(<指标>[<窗口>])
+			if i < len(text) && text[i] == '(' {
+				end := strings.IndexByte(text[i:], ')')
+				if end < 0 {
+					return nil, NewValidationException(fmt.Sprintf("告警表达式缺少右括号: %s", text[start:]))
+				}
+				call := strings.Join(strings.Fields(text[start:i+end+1]), "")
+				if _, ok := parseAlertMetricExpression(call); !ok {
+					return nil, NewValidationException(fmt.Sprintf("无效的窗口表达式: %s", call))
+				}
+				tokens = append(tokens, alertExpressionToken{kind: "metric", text: call})
+				i += end + 1
+				continue
+			}
+			tokens = append(tokens, alertExpressionToken{kind: "metric", text: text[start:i]})
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", ">=", "<=", "==", "!=", ">", "<", "!", "+", "-", "*", "/", "(", ")"} {
+				if strings.HasPrefix(text[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, NewValidationException(fmt.Sprintf("告警表达式包含无法识别的字符: %q", c))
+			}
+			tokens = append(tokens, alertExpressionToken{kind: "op", text: op})
+			i += len(op)
+		}
+	}
+	return tokens, nil
+}
+
+/**
+ * 递归下降解析器
+ *
+ * or := and ('||' and)*；and := not ('&&' not)*；not := '!' not | cmp；
+ * cmp := sum (比较 sum)?；sum := prod (('+'|'-') prod)*；prod := unary (('*'|'/') unary)*；
+ * unary := '-' unary | primary；primary := 数字 | 指标 | '(' or ')'
+ */
+type alertExpressionParser struct {
+	tokens []alertExpressionToken
+	pos    int
+}
+
+func (p *alertExpressionParser) peekOp(ops ...string) (string, bool) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != "op" {
+		return "", false
+	}
+	for _, op := range ops {
+		if p.tokens[p.pos].text == op {
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *alertExpressionParser) parseBinary(next func() (alertExpressionNode, error), ops ...string) (alertExpressionNode, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.peekOp(ops...)
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = &alertBinaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *alertExpressionParser) parseOr() (alertExpressionNode, error) {
+	return p.parseBinary(p.parseAnd, "||")
+}
+
+func (p *alertExpressionParser) parseAnd() (alertExpressionNode, error) {
+	return p.parseBinary(p.parseNot, "&&")
+}
+
+func (p *alertExpressionParser) parseNot() (alertExpressionNode, error) {
+	if _, ok := p.peekOp("!"); ok {
+		p.pos++
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &alertUnaryNode{op: "!", operand: operand}, nil
+	}
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if op, ok := p.peekOp(">=", "<=", "==", "!=", ">", "<"); ok {
+		p.pos++
+		right, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		return &alertBinaryNode{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *alertExpressionParser) parseSum() (alertExpressionNode, error) {
+	return p.parseBinary(p.parseProduct, "+", "-")
+}
+
+func (p *alertExpressionParser) parseProduct() (alertExpressionNode, error) {
+	return p.parseBinary(p.parseUnary, "*", "/")
+}
+
+func (p *alertExpressionParser) parseUnary() (alertExpressionNode, error) {
+	if _, ok := p.peekOp("-"); ok {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &alertUnaryNode{op: "-", operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *alertExpressionParser) parsePrimary() (alertExpressionNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, NewValidationException("告警表达式不完整")
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	switch token.kind {
+	case "number":
+		value, err := strconv.ParseFloat(token.text, 64)
+		if err != nil {
+			return nil, NewValidationException(fmt.Sprintf("告警表达式中的数字无效: %s", token.text))
+		}
+		return &alertNumberNode{value: value}, nil
+	case "metric":
+		return &alertMetricNode{metric: token.text}, nil
+	}
+	if token.text == "(" {
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.peekOp(")"); !ok {
+			return nil, NewValidationException("告警表达式缺少右括号")
+		}
+		p.pos++
+		return node, nil
+	}
+	return nil, NewValidationException(fmt.Sprintf("告警表达式在 %s 处不完整", token.text))
+}
+
+/**
+ * 添加表达式规则（表达式无效时返回错误；末尾的 for <时长> 在未设置 For 时生效）
+ */
+func (am *AlertManager) AddExpressionRule(rule AlertRule) error {
+	if rule.ID == "" {
+		return NewValidationException("告警规则 ID 不能为空")
+	}
+	compiled, err := compileAlertExpression(rule.Expression)
+	if err != nil {
+		return err
+	}
+	if rule.For <= 0 {
+		rule.For = compiled.For
+	}
+
+	am.mu.Lock()
+	if am.compiledExpressions == nil {
+		am.compiledExpressions = make(map[string]*compiledAlertExpression)
+	}
+	am.compiledExpressions[rule.Expression] = compiled
+	am.mu.Unlock()
+
+	am.AddAlertRule(rule)
+	return nil
+}
+
+/**
+ * 按给定的指标值评估表达式规则（值中没有的指标视为缺失）
+ */
+func (am *AlertManager) CheckExpressions(values map[string]float64) {
+	am.EvaluateExpressionRules(func(metric string) (float64, bool) {
+		value, exists := values[metric]
+		return value, exists
+	}, time.Now())
+}
+
+/**
+ * 评估所有启用的表达式规则，resolve 返回指标（或窗口表达式）的当前值
+ *
+ * @return 各规则本次的条件结果（跳过的规则不包含在内）
+ */
+func (am *AlertManager) EvaluateExpressionRules(resolve func(metric string) (float64, bool), now time.Time) map[string]bool {
+	results := make(map[string]bool)
+	if !am.enabled {
+		return results
+	}
+
+	type evaluation struct {
+		rule     AlertRule
+		matched  bool
+		operands map[string]float64
+	}
+	evaluations := make([]evaluation, 0)
+	// 在锁外求值，resolve 可能访问其他组件
+	for _, rule := range am.GetAlertRules() {
+		if !rule.Enabled || rule.Expression == "" || rule.MaintenanceDb.IsInMaintenance() {
+			continue
+		}
+		compiled, err := am.getCompiledExpression(rule.Expression)
+		if err != nil {
+			LogWarn("告警表达式无效，跳过规则 %s: %v", rule.ID, err)
+			continue
+		}
+		operands := make(map[string]float64)
+		value, ok := compiled.root.eval(resolve, operands)
+		if !ok {
+			continue
+		}
+		results[rule.ID] = value != 0
+		evaluations = append(evaluations, evaluation{rule: rule, matched: value != 0, operands: operands})
+	}
+
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	for _, e := range evaluations {
+		rule := e.rule
+		alertID := rule.ID
+		if lastAlert, exists := am.activeAlerts[alertID]; exists && now.Sub(lastAlert.Timestamp) < rule.Cooldown {
+			continue
+		}
+		if !e.matched {
+			delete(am.pendingSince, alertID)
+			if activeAlert, exists := am.activeAlerts[alertID]; exists {
+				am.resolveAlert(activeAlert, now)
+			}
+			continue
+		}
+		if am.conditionHeld(alertID, rule.For, now) {
+			am.fireAlert(&rule, &Alert{
+				ID:          alertID,
+				RuleID:      rule.ID,
+				Name:        rule.Name,
+				Description: rule.Description,
+				Severity:    rule.Severity,
+				Metric:      rule.Expression,
+				Value:       e.operands,
+				Condition:   rule.Expression,
+				Timestamp:   now,
+				Status:      Active,
+			})
+		}
+	}
+	return results
+}
+
+/**
+ * 获取已编译的表达式（通过 AddAlertRule 直接添加的表达式规则在首次评估时编译）
+ */
+func (am *AlertManager) getCompiledExpression(expression string) (*compiledAlertExpression, error) {
+	am.mu.RLock()
+	compiled, exists := am.compiledExpressions[expression]
+	am.mu.RUnlock()
+	if exists {
+		return compiled, nil
+	}
+	compiled, err := compileAlertExpression(expression)
+	if err != nil {
+		return nil, err
+	}
+	am.mu.Lock()
+	if am.compiledExpressions == nil {
+		am.compiledExpressions = make(map[string]*compiledAlertExpression)
+	}
+	am.compiledExpressions[expression] = compiled
+	am.mu.Unlock()
+	return compiled, nil
+}
+
+/**
+ * 条件是否已持续满足 For 时长（调用方持有 am.mu）
+ *
+ * 首次满足时进入等待状态并返回 false；告警已触发时直接返回 true
+ */
+func (am *AlertManager) conditionHeld(alertID string, forDuration time.Duration, now time.Time) bool {
+	if forDuration <= 0 {
+		return true
+	}
+	if _, active := am.activeAlerts[alertID]; active {
+		return true
+	}
+	if am.pendingSince == nil {
+		am.pendingSince = make(map[string]time.Time)
+	}
+	since, pending := am.pendingSince[alertID]
+	if !pending {
+		am.pendingSince[alertID] = now
+		return false
+	}
+	if now.Sub(since) < forDuration {
+		return false
+	}
+	delete(am.pendingSince, alertID)
+	return true
+}
@@ -0,0 +1,275 @@
+package db233
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+/**
+ * 告警分组通知
+ *
+ * 多个目标（如同一 DbGroup 的 20 个库）同时越过阈值时，按分组键合并为一次通知：
+ *
+ *   am.SetGrouping(&db233.AlertGroupingConfig{
+ *       GroupBy:       []db233.EnumAlertGroupKey{db233.AlertGroupBySeverity, db233.AlertGroupByDbGroup},
+ *       GroupWait:     30 * time.Second,  // 组内首个告警后等待收集同组告警
+ *       GroupInterval: 5 * time.Minute,   // 同组再次通知的最小间隔
+ *   })
+ *
+ * 实现 GroupedAlertNotifier 的通知器收到 AlertGroupNotification（含受影响目标列表），
+ * 其他通知器收到一条汇总告警（描述中列出受影响目标）。同组等待期间同一告警重复触发只保留最新一次
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type EnumAlertGroupKey string
+
+const (
+	AlertGroupByRule     EnumAlertGroupKey = "rule"
+	AlertGroupBySeverity EnumAlertGroupKey = "severity"
+	AlertGroupByDbGroup  EnumAlertGroupKey = "db_group"
+)
+
+/**
+ * AlertGroupingConfig - 分组配置
+ */
+type AlertGroupingConfig struct {
+	// 分组键，为空时所有告警归为一组
+	GroupBy []EnumAlertGroupKey
+
+	// 组内首个告警后等待的时间，0 为立即通知
+	GroupWait time.Duration
+
+	// 同组两次通知的最小间隔
+	GroupInterval time.Duration
+}
+
+/**
+ * AlertGroupNotification - 分组通知
+ */
+type AlertGroupNotification struct {
+	// 分组键，如 severity=critical,db_group=orders
+	Key    string
+	Labels map[string]string
+
+	// 本次通知包含的告警（同一告警只保留最新一次）
+	Alerts []*Alert
+
+	// 受影响的目标（排序去重）
+	Targets []string
+
+	// 组内最高严重程度
+	Severity AlertSeverity
+}
+
+/**
+ * GroupedAlertNotifier - 支持分组通知的通知器
+ */
+type GroupedAlertNotifier interface {
+	AlertNotifier
+	NotifyGroup(group *AlertGroupNotification) error
+}
+
+/**
+ * 分组状态
+ */
+type alertGroupState struct {
+	labels   map[string]string
+	pending  []*Alert
+	lastSent time.Time
+	timer    *time.Timer
+}
+
+/**
+ * 设置分组通知，nil 时恢复逐条通知（等待中的分组立即发送）
+ */
+func (am *AlertManager) SetGrouping(config *AlertGroupingConfig) {
+	if config == nil {
+		am.FlushAlertGroups()
+	}
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.grouping = config
+	if am.alertGroups == nil {
+		am.alertGroups = make(map[string]*alertGroupState)
+	}
+}
+
+/**
+ * 立即发送所有等待中的分组
+ */
+func (am *AlertManager) FlushAlertGroups() {
+	am.mu.Lock()
+	keys := make([]string, 0, len(am.alertGroups))
+	for key, group := range am.alertGroups {
+		if len(group.pending) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	am.mu.Unlock()
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		am.flushAlertGroup(key)
+	}
+}
+
+/**
+ * 告警的目标与 DbGroup（规则未关联 Db 时目标为指标名）
+ */
+func fillAlertTarget(alert *Alert, rule *AlertRule) {
+	alert.Target = rule.Target
+	if db := rule.MaintenanceDb; db != nil {
+		if db.DbGroup != nil {
+			alert.DbGroup = db.DbGroup.GroupName
+		}
+		if alert.Target == "" {
+			alert.Target = fmt.Sprintf("db-%d", db.DbId)
+			if alert.DbGroup != "" {
+				alert.Target = fmt.Sprintf("%s/%d", alert.DbGroup, db.DbId)
+			}
+		}
+	}
+	if alert.Target == "" {
+		alert.Target = alert.Metric
+	}
+}
+
+/**
+ * 计算分组键
+ */
+func (am *AlertManager) alertGroupKey(alert *Alert) (string, map[string]string) {
+	labels := make(map[string]string)
+	parts := make([]string, 0, len(am.grouping.GroupBy))
+	for _, groupKey := range am.grouping.GroupBy {
+		value := ""
+		switch groupKey {
+		case AlertGroupByRule:
+			value = alert.RuleID
+		case AlertGroupBySeverity:
+			value = am.severityToString(alert.Severity)
+		case AlertGroupByDbGroup:
+			value = alert.DbGroup
+		}
+		labels[string(groupKey)] = value
+		parts = append(parts, fmt.Sprintf("%s=%s", groupKey, value))
+	}
+	return strings.Join(parts, ","), labels
+}
+
+/**
+ * 加入分组并按 GroupWait / GroupInterval 安排发送（调用方持有 am.mu）
+ */
+func (am *AlertManager) enqueueGroupedAlert(alert *Alert) {
+	key, labels := am.alertGroupKey(alert)
+	group, exists := am.alertGroups[key]
+	if !exists {
+		group = &alertGroupState{labels: labels}
+		am.alertGroups[key] = group
+	}
+
+	replaced := false
+	for i, pending := range group.pending {
+		if pending.ID == alert.ID {
+			group.pending[i] = alert
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		group.pending = append(group.pending, alert)
+	}
+	if group.timer != nil {
+		return
+	}
+
+	delay := am.grouping.GroupWait
+	if !group.lastSent.IsZero() {
+		if next := time.Until(group.lastSent.Add(am.grouping.GroupInterval)); next > delay {
+			delay = next
+		}
+	}
+	group.timer = time.AfterFunc(delay, func() { am.flushAlertGroup(key) })
+}
+
+/**
+ * 发送一个分组
+ */
+func (am *AlertManager) flushAlertGroup(key string) {
+	am.mu.Lock()
+	group, exists := am.alertGroups[key]
+	if !exists || len(group.pending) == 0 {
+		am.mu.Unlock()
+		return
+	}
+	if group.timer != nil {
+		group.timer.Stop()
+		group.timer = nil
+	}
+	alerts := group.pending
+	group.pending = nil
+	group.lastSent = time.Now()
+	notifiers := append([]AlertNotifier(nil), am.notifiers...)
+	labels := make(map[string]string, len(group.labels))
+	for k, v := range group.labels {
+		labels[k] = v
+	}
+	am.mu.Unlock()
+
+	notification := &AlertGroupNotification{Key: key, Labels: labels, Alerts: alerts, Severity: alerts[0].Severity}
+	targetSet := make(map[string]bool)
+	for _, alert := range alerts {
+		if !targetSet[alert.Target] {
+			targetSet[alert.Target] = true
+			notification.Targets = append(notification.Targets, alert.Target)
+		}
+		if alert.Severity > notification.Severity {
+			notification.Severity = alert.Severity
+		}
+	}
+	sort.Strings(notification.Targets)
+
+	summary := notification.summaryAlert()
+	for _, notifier := range notifiers {
+		var err error
+		if grouped, ok := notifier.(GroupedAlertNotifier); ok {
+			err = grouped.NotifyGroup(notification)
+		} else {
+			err = notifier.Notify(summary)
+		}
+		if err != nil {
+			LogError("告警分组通知失败 [%s]: %v", notifier.GetName(), err)
+		}
+	}
+	LogWarn("告警分组通知: %s, 告警数=%d, 目标=%v", key, len(alerts), notification.Targets)
+}
+
+/**
+ * 供不支持分组的通知器使用的汇总告警（只有一条时为原告警）
+ */
+func (n *AlertGroupNotification) summaryAlert() *Alert {
+	if len(n.Alerts) == 1 {
+		return n.Alerts[0]
+	}
+	summary := *n.Alerts[0]
+	summary.Severity = n.Severity
+	summary.Name = fmt.Sprintf("%s 等 %d 个告警", summary.Name, len(n.Alerts))
+	summary.Description = fmt.Sprintf("分组 %s, 受影响目标: %s", n.Key, strings.Join(n.Targets, ", "))
+	summary.Value = n.Targets
+	return &summary
+}
+
+/**
+ * 等待发送的分组数（调用方持有 am.mu）
+ */
+func (am *AlertManager) pendingGroupCount() int {
+	count := 0
+	for _, group := range am.alertGroups {
+		if len(group.pending) > 0 {
+			count++
+		}
+	}
+	return count
+}
@@ -2,6 +2,7 @@ package db233
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 )
@@ -29,6 +30,20 @@ type AlertManager struct {
 	// 通知器
 	notifiers []AlertNotifier
 
+	// 条件开始满足的时间（规则设置了 For 时使用），键为告警 ID
+	pendingSince map[string]time.Time
+
+	// 已编译的表达式，键为表达式文本
+	compiledExpressions map[string]*compiledAlertExpression
+
+	// 静默与维护窗口（见 alert_silence.go）
+	silences           map[string]*AlertSilence
+	maintenanceWindows []AlertMaintenanceWindow
+
+	// 分组通知（见 alert_grouping.go），grouping 为 nil 时逐条通知
+	grouping    *AlertGroupingConfig
+	alertGroups map[string]*alertGroupState
+
 	// 配置
 	maxHistorySize int
 	cooldownPeriod time.Duration
@@ -39,6 +54,7 @@ type AlertManager struct {
 	// 控制
 	enabled  bool
 	stopChan chan bool
+	stopOnce sync.Once
 }
 
 /**
@@ -54,6 +70,18 @@ type AlertRule struct {
 	Severity    AlertSeverity
 	Cooldown    time.Duration
 	Enabled     bool
+
+	// 关联的 Db（可选），该 Db 处于维护模式时暂停评估本规则
+	MaintenanceDb *Db
+
+	// 表达式规则（见 AddExpressionRule），设置后忽略 Metric / Condition / Threshold
+	Expression string
+
+	// 条件需持续满足的时长，期间告警处于等待状态，避免指标抖动反复通知
+	For time.Duration
+
+	// 告警目标（可选），为空时取 MaintenanceDb 或指标名，用于分组通知列出受影响目标
+	Target string
 }
 
 /**
@@ -99,6 +127,20 @@ type Alert struct {
 	Status      AlertStatus
 	ResolvedAt  *time.Time
 	Duration    *time.Duration
+
+	// 人工处理信息
+	AcknowledgedBy   string
+	AcknowledgedAt   *time.Time
+	AckNote          string
+	ResolvedManually bool
+
+	// 触发时被静默或处于维护窗口（照常记录，不发送通知）
+	Silenced   bool
+	SilencedBy string
+
+	// 受影响的目标与所属 DbGroup（见 AlertRule.Target）
+	Target  string
+	DbGroup string
 }
 
 /**
@@ -109,6 +151,7 @@ type AlertStatus int
 const (
 	Active AlertStatus = iota
 	Resolved
+	Acknowledged
 )
 
 /**
@@ -119,6 +162,14 @@ type AlertNotifier interface {
 	GetName() string
 }
 
+/**
+ * ResolvedAlertNotifier - 需要接收告警解决通知的通知器（如 PagerDuty / OpsGenie 自动关闭事件）
+ */
+type ResolvedAlertNotifier interface {
+	AlertNotifier
+	NotifyResolved(alert *Alert) error
+}
+
 /**
  * 创建告警管理器
  */
@@ -129,6 +180,8 @@ func NewAlertManager(name string) *AlertManager {
 		activeAlerts:   make(map[string]*Alert),
 		alertHistory:   make([]*Alert, 0),
 		notifiers:      make([]AlertNotifier, 0),
+		pendingSince:   make(map[string]time.Time),
+		silences:       make(map[string]*AlertSilence),
 		maxHistorySize: 1000,
 		cooldownPeriod: 5 * time.Minute,
 		enabled:        true,
@@ -144,10 +197,10 @@ func (am *AlertManager) AddAlertRule(rule AlertRule) {
 	defer am.mu.Unlock()
 
 	// 检查规则ID是否已存在
-	for _, existing := range am.alertRules {
+	for i, existing := range am.alertRules {
 		if existing.ID == rule.ID {
 			LogWarn("告警规则ID已存在，将被替换: %s", rule.ID)
-			am.RemoveAlertRule(rule.ID)
+			am.alertRules = append(am.alertRules[:i], am.alertRules[i+1:]...)
 			break
 		}
 	}
@@ -170,6 +223,11 @@ func (am *AlertManager) RemoveAlertRule(ruleID string) {
 			break
 		}
 	}
+	for alertID := range am.pendingSince {
+		if alertID == ruleID || strings.HasPrefix(alertID, ruleID+"_") {
+			delete(am.pendingSince, alertID)
+		}
+	}
 }
 
 /**
@@ -238,7 +296,12 @@ func (am *AlertManager) CheckMetric(metricName string, value interface{}) {
 			continue
 		}
 
-		if rule.Metric != metricName {
+		if rule.Expression != "" || rule.Metric != metricName {
+			continue
+		}
+
+		// 关联 Db 维护中，暂停评估
+		if rule.MaintenanceDb.IsInMaintenance() {
 			continue
 		}
 
@@ -252,9 +315,12 @@ func (am *AlertManager) CheckMetric(metricName string, value interface{}) {
 
 		// 评估条件
 		if am.evaluateCondition(value, rule.Condition, rule.Threshold) {
-			am.triggerAlert(&rule, metricName, value, now)
+			if am.conditionHeld(alertID, rule.For, now) {
+				am.triggerAlert(&rule, metricName, value, now)
+			}
 		} else {
 			// 检查是否需要解决现有告警
+			delete(am.pendingSince, alertID)
 			if activeAlert, exists := am.activeAlerts[alertID]; exists {
 				am.resolveAlert(activeAlert, now)
 			}
@@ -327,6 +393,17 @@ func (am *AlertManager) compareValues(a, b interface{}) int {
 			return 0
 		}
 	}
+
+	// 类型不同的数值（如 float64 指标与 int 阈值）按 float64 比较
+	if fa, ok := toFloat64(a); ok {
+		if fb, ok := toFloat64(b); ok {
+			if fa > fb {
+				return 1
+			} else if fa < fb {
+				return -1
+			}
+		}
+	}
 	return 0
 }
 
@@ -334,10 +411,8 @@ func (am *AlertManager) compareValues(a, b interface{}) int {
  * 触发告警
  */
 func (am *AlertManager) triggerAlert(rule *AlertRule, metricName string, value interface{}, timestamp time.Time) {
-	alertID := fmt.Sprintf("%s_%s", rule.ID, metricName)
-
-	alert := &Alert{
-		ID:          alertID,
+	am.fireAlert(rule, &Alert{
+		ID:          fmt.Sprintf("%s_%s", rule.ID, metricName),
 		RuleID:      rule.ID,
 		Name:        rule.Name,
 		Description: rule.Description,
@@ -348,12 +423,36 @@ func (am *AlertManager) triggerAlert(rule *AlertRule, metricName string, value i
 		Condition:   am.conditionToString(rule.Condition),
 		Timestamp:   timestamp,
 		Status:      Active,
+	})
+}
+
+/**
+ * 记录并通知告警
+ */
+func (am *AlertManager) fireAlert(rule *AlertRule, alert *Alert) {
+	// 已确认的告警仍在处理中，只刷新当前值，不重复通知
+	if existing, exists := am.activeAlerts[alert.ID]; exists && existing.Status == Acknowledged {
+		existing.Value = alert.Value
+		return
 	}
 
-	am.activeAlerts[alertID] = alert
+	fillAlertTarget(alert, rule)
+	am.activeAlerts[alert.ID] = alert
 	am.addToHistory(alert)
 
+	if silencedBy := am.suppressionFor(alert, alert.Timestamp); silencedBy != "" {
+		alert.Silenced = true
+		alert.SilencedBy = silencedBy
+		LogInfo("告警触发（已静默 %s）: %s - %s (值: %v)", silencedBy, alert.Name, alert.Metric, alert.Value)
+		return
+	}
+
 	// 发送通知
+	if am.grouping != nil {
+		am.enqueueGroupedAlert(alert)
+		LogWarn("告警触发（等待分组通知）: %s - %s (值: %v, 阈值: %v)", alert.Name, alert.Metric, alert.Value, alert.Threshold)
+		return
+	}
 	for _, notifier := range am.notifiers {
 		go func(notifier AlertNotifier, alert *Alert) {
 			if err := notifier.Notify(alert); err != nil {
@@ -377,9 +476,67 @@ func (am *AlertManager) resolveAlert(alert *Alert, resolvedAt time.Time) {
 
 	delete(am.activeAlerts, alert.ID)
 
+	// 静默的告警未发送过通知，也无需通知解决
+	if !alert.Silenced {
+		for _, notifier := range am.notifiers {
+			if resolvedNotifier, ok := notifier.(ResolvedAlertNotifier); ok {
+				go func(notifier ResolvedAlertNotifier, alert *Alert) {
+					if err := notifier.NotifyResolved(alert); err != nil {
+						LogError("告警解决通知失败 [%s]: %v", notifier.GetName(), err)
+					}
+				}(resolvedNotifier, alert)
+			}
+		}
+	}
+
 	LogInfo("告警已解决: %s - 持续时间: %v", alert.Name, duration)
 }
 
+/**
+ * 确认告警（标记为处理中，告警保持活跃直到解决）
+ *
+ * @param alertID 告警ID
+ * @param user 确认人
+ * @param note 备注
+ */
+func (am *AlertManager) Ack(alertID string, user string, note string) error {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	alert, exists := am.activeAlerts[alertID]
+	if !exists {
+		return NewValidationException(fmt.Sprintf("活跃告警不存在: %s", alertID))
+	}
+
+	now := time.Now()
+	alert.Status = Acknowledged
+	alert.AcknowledgedBy = user
+	alert.AcknowledgedAt = &now
+	alert.AckNote = note
+
+	LogInfo("告警已确认: %s - 确认人: %s, 备注: %s", alert.Name, user, note)
+	return nil
+}
+
+/**
+ * 手动解决告警
+ *
+ * @param alertID 告警ID
+ */
+func (am *AlertManager) ResolveManually(alertID string) error {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	alert, exists := am.activeAlerts[alertID]
+	if !exists {
+		return NewValidationException(fmt.Sprintf("活跃告警不存在: %s", alertID))
+	}
+
+	alert.ResolvedManually = true
+	am.resolveAlert(alert, time.Now())
+	return nil
+}
+
 /**
  * 将告警添加到历史记录
  */
@@ -468,6 +625,38 @@ func (am *AlertManager) GetAlertStats() map[string]interface{} {
 	}
 	stats["active_by_severity"] = severityCount
 
+	acknowledged := 0
+	for _, alert := range am.activeAlerts {
+		if alert.Status == Acknowledged {
+			acknowledged++
+		}
+	}
+	stats["acknowledged_alerts"] = acknowledged
+
+	silenced := 0
+	for _, alert := range am.activeAlerts {
+		if alert.Silenced {
+			silenced++
+		}
+	}
+	stats["silenced_alerts"] = silenced
+
+	now := time.Now()
+	activeSilences := 0
+	for _, silence := range am.silences {
+		if now.Before(silence.EndsAt) {
+			activeSilences++
+		}
+	}
+	stats["active_silences"] = activeSilences
+	activeWindows := make([]string, 0)
+	for _, window := range am.maintenanceWindows {
+		if window.IsActive(now) {
+			activeWindows = append(activeWindows, window.Name)
+		}
+	}
+	stats["active_maintenance_windows"] = activeWindows
+
 	return stats
 }
 
@@ -506,7 +695,10 @@ func (am *AlertManager) GetAlertRules() []AlertRule {
  * 停止告警管理器
  */
 func (am *AlertManager) Stop() {
-	am.stopChan <- true
+	am.stopOnce.Do(func() {
+		close(am.stopChan)
+	})
+	am.FlushAlertGroups()
 }
 
 /**
@@ -521,6 +713,8 @@ func (am *AlertManager) GetStatus() map[string]interface{} {
 		"enabled":         am.enabled,
 		"rules_count":     len(am.alertRules),
 		"active_alerts":   len(am.activeAlerts),
+		"pending_alerts":  len(am.pendingSince),
+		"pending_groups":  am.pendingGroupCount(),
 		"history_size":    len(am.alertHistory),
 		"max_history":     am.maxHistorySize,
 		"cooldown_period": am.cooldownPeriod.String(),
@@ -553,6 +747,16 @@ func (am *AlertManager) GetMetrics() map[string]interface{} {
 		}
 	}
 
+	// 已确认告警数量
+	if val, ok := stats["acknowledged_alerts"].(int); ok {
+		metrics["acknowledged_alerts"] = val
+	}
+
+	// 已静默告警数量
+	if val, ok := stats["silenced_alerts"].(int); ok {
+		metrics["silenced_alerts"] = val
+	}
+
 	// 历史告警数量
 	if val, ok := stats["total_history"].(int); ok {
 		metrics["total_alerts_history"] = val
@@ -0,0 +1,352 @@
+package db233
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+/**
+ * 第三方告警平台通知器
+ *
+ * 以 Alert.ID 作为去重键，同一告警重复触发合并为一个事件，告警解决时自动关闭：
+ *
+ *   am.AddNotifier(db233.NewPagerDutyAlertNotifier(routingKey))
+ *   am.AddNotifier(db233.NewOpsGenieAlertNotifier(apiKey).
+ *       SetEndpoint(db233.OpsGenieEuEndpoint).
+ *       SetProxy(proxyUrl).
+ *       SetTimeout(5 * time.Second))
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+const (
+	PagerDutyEventsEndpoint = "https://events.pagerduty.com/v2/enqueue"
+	OpsGenieEndpoint        = "https://api.opsgenie.com/v2/alerts"
+	OpsGenieEuEndpoint      = "https://api.eu.opsgenie.com/v2/alerts"
+)
+
+/**
+ * PagerDutyAlertNotifier - PagerDuty Events API v2
+ */
+type PagerDutyAlertNotifier struct {
+	RoutingKey string
+	Endpoint   string
+
+	// 事件来源，为空时使用 Alert.Target
+	Source string
+
+	Client *http.Client
+}
+
+/**
+ * 创建 PagerDuty 通知器
+ */
+func NewPagerDutyAlertNotifier(routingKey string) *PagerDutyAlertNotifier {
+	return &PagerDutyAlertNotifier{
+		RoutingKey: routingKey,
+		Endpoint:   PagerDutyEventsEndpoint,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+/**
+ * 设置接口地址
+ */
+func (n *PagerDutyAlertNotifier) SetEndpoint(endpoint string) *PagerDutyAlertNotifier {
+	n.Endpoint = endpoint
+	return n
+}
+
+/**
+ * 设置事件来源
+ */
+func (n *PagerDutyAlertNotifier) SetSource(source string) *PagerDutyAlertNotifier {
+	n.Source = source
+	return n
+}
+
+/**
+ * 设置请求超时
+ */
+func (n *PagerDutyAlertNotifier) SetTimeout(timeout time.Duration) *PagerDutyAlertNotifier {
+	n.Client.Timeout = timeout
+	return n
+}
+
+/**
+ * 设置 HTTP 代理
+ */
+func (n *PagerDutyAlertNotifier) SetProxy(proxy *url.URL) *PagerDutyAlertNotifier {
+	setAlertHttpProxy(n.Client, proxy)
+	return n
+}
+
+/**
+ * 获取通知器名称
+ */
+func (n *PagerDutyAlertNotifier) GetName() string {
+	return "pagerduty"
+}
+
+/**
+ * 发送触发事件（已解决的告警发送 resolve 事件）
+ */
+func (n *PagerDutyAlertNotifier) Notify(alert *Alert) error {
+	if alert.Status == Resolved {
+		return n.NotifyResolved(alert)
+	}
+
+	source := n.Source
+	if source == "" {
+		source = alert.Target
+	}
+	payload := map[string]interface{}{
+		"summary":   truncateAlertText(fmt.Sprintf("%s: %s", alert.Name, alert.Description), 1024),
+		"source":    source,
+		"severity":  pagerDutySeverity(alert.Severity),
+		"timestamp": alert.Timestamp.Format(time.RFC3339),
+		"component": alert.Metric,
+		"class":     alert.RuleID,
+		"custom_details": map[string]interface{}{
+			"value":     fmt.Sprintf("%v", alert.Value),
+			"threshold": fmt.Sprintf("%v", alert.Threshold),
+			"condition": alert.Condition,
+		},
+	}
+	if alert.DbGroup != "" {
+		payload["group"] = alert.DbGroup
+	}
+	return n.sendEvent("trigger", alert, payload)
+}
+
+/**
+ * 发送 resolve 事件
+ */
+func (n *PagerDutyAlertNotifier) NotifyResolved(alert *Alert) error {
+	return n.sendEvent("resolve", alert, nil)
+}
+
+func (n *PagerDutyAlertNotifier) sendEvent(action string, alert *Alert, payload map[string]interface{}) error {
+	event := map[string]interface{}{
+		"routing_key":  n.RoutingKey,
+		"event_action": action,
+		"dedup_key":    truncateAlertText(alert.ID, 255),
+	}
+	if payload != nil {
+		event["payload"] = payload
+	}
+	return postAlertJson(n.Client, n.Endpoint, nil, event)
+}
+
+/**
+ * 严重程度映射：critical / error / warning / info
+ */
+func pagerDutySeverity(severity AlertSeverity) string {
+	switch severity {
+	case Critical:
+		return "critical"
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+/**
+ * OpsGenieAlertNotifier - OpsGenie Alert API
+ */
+type OpsGenieAlertNotifier struct {
+	ApiKey   string
+	Endpoint string
+
+	// 附加的标签与负责团队（可选）
+	Tags       []string
+	Responders []string
+
+	Client *http.Client
+}
+
+/**
+ * 创建 OpsGenie 通知器
+ */
+func NewOpsGenieAlertNotifier(apiKey string) *OpsGenieAlertNotifier {
+	return &OpsGenieAlertNotifier{
+		ApiKey:   apiKey,
+		Endpoint: OpsGenieEndpoint,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+/**
+ * 设置接口地址（如 OpsGenieEuEndpoint）
+ */
+func (n *OpsGenieAlertNotifier) SetEndpoint(endpoint string) *OpsGenieAlertNotifier {
+	n.Endpoint = endpoint
+	return n
+}
+
+/**
+ * 设置标签
+ */
+func (n *OpsGenieAlertNotifier) SetTags(tags ...string) *OpsGenieAlertNotifier {
+	n.Tags = tags
+	return n
+}
+
+/**
+ * 设置负责团队
+ */
+func (n *OpsGenieAlertNotifier) SetResponders(teams ...string) *OpsGenieAlertNotifier {
+	n.Responders = teams
+	return n
+}
+
+/**
+ * 设置请求超时
+ */
+func (n *OpsGenieAlertNotifier) SetTimeout(timeout time.Duration) *OpsGenieAlertNotifier {
+	n.Client.Timeout = timeout
+	return n
+}
+
+/**
+ * 设置 HTTP 代理
+ */
+func (n *OpsGenieAlertNotifier) SetProxy(proxy *url.URL) *OpsGenieAlertNotifier {
+	setAlertHttpProxy(n.Client, proxy)
+	return n
+}
+
+/**
+ * 获取通知器名称
+ */
+func (n *OpsGenieAlertNotifier) GetName() string {
+	return "opsgenie"
+}
+
+/**
+ * 创建告警（已解决的告警关闭对应告警）
+ */
+func (n *OpsGenieAlertNotifier) Notify(alert *Alert) error {
+	if alert.Status == Resolved {
+		return n.NotifyResolved(alert)
+	}
+
+	body := map[string]interface{}{
+		"message":     truncateAlertText(alert.Name, 130),
+		"alias":       truncateAlertText(alert.ID, 512),
+		"description": truncateAlertText(alert.Description, 15000),
+		"priority":    opsGeniePriority(alert.Severity),
+		"source":      "db233",
+		"entity":      alert.Target,
+		"details": map[string]string{
+			"metric":    alert.Metric,
+			"value":     fmt.Sprintf("%v", alert.Value),
+			"threshold": fmt.Sprintf("%v", alert.Threshold),
+			"condition": alert.Condition,
+			"db_group":  alert.DbGroup,
+		},
+	}
+	if len(n.Tags) > 0 {
+		body["tags"] = n.Tags
+	}
+	if len(n.Responders) > 0 {
+		responders := make([]map[string]string, 0, len(n.Responders))
+		for _, team := range n.Responders {
+			responders = append(responders, map[string]string{"name": team, "type": "team"})
+		}
+		body["responders"] = responders
+	}
+	return postAlertJson(n.Client, n.Endpoint, n.headers(), body)
+}
+
+/**
+ * 按别名关闭告警
+ */
+func (n *OpsGenieAlertNotifier) NotifyResolved(alert *Alert) error {
+	target := fmt.Sprintf("%s/%s/close?identifierType=alias", n.Endpoint, url.PathEscape(truncateAlertText(alert.ID, 512)))
+	body := map[string]interface{}{"source": "db233", "note": "告警已解决"}
+	return postAlertJson(n.Client, target, n.headers(), body)
+}
+
+func (n *OpsGenieAlertNotifier) headers() map[string]string {
+	return map[string]string{"Authorization": "GenieKey " + n.ApiKey}
+}
+
+/**
+ * 严重程度映射：Critical→P1, Error→P2, Warning→P3, Info→P5
+ */
+func opsGeniePriority(severity AlertSeverity) string {
+	switch severity {
+	case Critical:
+		return "P1"
+	case Error:
+		return "P2"
+	case Warning:
+		return "P3"
+	default:
+		return "P5"
+	}
+}
+
+/**
+ * 设置代理（保留客户端原有的 Transport 配置）
+ */
+func setAlertHttpProxy(client *http.Client, proxy *url.URL) {
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok || transport == nil {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	} else {
+		transport = transport.Clone()
+	}
+	transport.Proxy = http.ProxyURL(proxy)
+	client.Transport = transport
+}
+
+/**
+ * POST JSON，非 2xx 响应视为失败
+ */
+func postAlertJson(client *http.Client, target string, headers map[string]string, body interface{}) error {
+	content, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("告警平台返回状态码 %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+/**
+ * 按字符截断（平台对字段长度有限制）
+ */
+func truncateAlertText(text string, maxLength int) string {
+	runes := []rune(text)
+	if len(runes) <= maxLength {
+		return text
+	}
+	return string(runes[:maxLength])
+}
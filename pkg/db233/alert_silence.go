@@ -0,0 +1,285 @@
+package db233
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+/**
+ * 告警静默与维护窗口
+ *
+ * 被静默的告警照常记录到活跃告警与历史（Alert.Silenced 为 true），只是不发送通知：
+ *
+ *   am.Silence("slow_query", time.Now().Add(2*time.Hour))                     // 按规则 ID 静默
+ *   am.SilenceMatching(db233.AlertMatcher{Metric: "pool.*"}, until, "alice", "扩容中")
+ *
+ *   am.AddMaintenanceWindow(db233.AlertMaintenanceWindow{                    // 每周日 02:00 起 2 小时
+ *       Name: "weekly_backup", Weekdays: []time.Weekday{time.Sunday},
+ *       DailyStart: 2 * time.Hour, Duration: 2 * time.Hour,
+ *   })
+ *
+ * 静默状态包含在 GetAlertStats 与监控报告中
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type AlertMatcher struct {
+	// 规则 ID，支持 * ? 通配符，为空时匹配任意规则
+	RuleID string
+
+	// 指标名（表达式规则为表达式文本），支持通配符，为空时匹配任意指标
+	Metric string
+
+	// 严重程度，为空时匹配任意严重程度
+	Severities []AlertSeverity
+}
+
+/**
+ * AlertSilence - 静默（创建后立即生效，到 EndsAt 自动失效）
+ */
+type AlertSilence struct {
+	ID        string
+	Matcher   AlertMatcher
+	StartsAt  time.Time
+	EndsAt    time.Time
+	CreatedBy string
+	Comment   string
+}
+
+/**
+ * AlertMaintenanceWindow - 计划维护窗口
+ *
+ * 一次性窗口设置 StartsAt / EndsAt；周期窗口设置 DailyStart（距当天零点）与 Duration，
+ * Weekdays 为空时每天生效
+ */
+type AlertMaintenanceWindow struct {
+	Name    string
+	Matcher AlertMatcher
+
+	// 一次性窗口
+	StartsAt time.Time
+	EndsAt   time.Time
+
+	// 周期窗口
+	Weekdays   []time.Weekday
+	DailyStart time.Duration
+	Duration   time.Duration
+
+	// 周期窗口使用的时区，为 nil 时使用本地时区
+	Location *time.Location
+}
+
+var alertSilenceSeq int64
+
+/**
+ * 匹配告警
+ */
+func (m AlertMatcher) Matches(alert *Alert) bool {
+	if m.RuleID != "" && !matchAlertPattern(m.RuleID, alert.RuleID) {
+		return false
+	}
+	if m.Metric != "" && !matchAlertPattern(m.Metric, alert.Metric) {
+		return false
+	}
+	if len(m.Severities) > 0 {
+		for _, severity := range m.Severities {
+			if severity == alert.Severity {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+/**
+ * 通配符匹配（模式无效时按字面比较）
+ */
+func matchAlertPattern(pattern string, value string) bool {
+	if matched, err := path.Match(pattern, value); err == nil {
+		return matched
+	}
+	return pattern == value
+}
+
+/**
+ * 维护窗口在 now 时是否生效
+ */
+func (w AlertMaintenanceWindow) IsActive(now time.Time) bool {
+	if w.Duration <= 0 {
+		return !w.StartsAt.IsZero() && !now.Before(w.StartsAt) && now.Before(w.EndsAt)
+	}
+
+	location := w.Location
+	if location == nil {
+		location = time.Local
+	}
+	local := now.In(location)
+	// 窗口可能跨零点，检查今天与前几天开始的窗口
+	for daysBack := 0; time.Duration(daysBack)*24*time.Hour < w.DailyStart+w.Duration; daysBack++ {
+		day := local.AddDate(0, 0, -daysBack)
+		if len(w.Weekdays) > 0 && !containsWeekday(w.Weekdays, day.Weekday()) {
+			continue
+		}
+		start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, location).Add(w.DailyStart)
+		if !local.Before(start) && local.Before(start.Add(w.Duration)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsWeekday(weekdays []time.Weekday, weekday time.Weekday) bool {
+	for _, w := range weekdays {
+		if w == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * 按规则 ID 静默到 until，返回静默 ID
+ */
+func (am *AlertManager) Silence(ruleID string, until time.Time) string {
+	return am.SilenceMatching(AlertMatcher{RuleID: ruleID}, until, "", "")
+}
+
+/**
+ * 按匹配条件静默到 until，返回静默 ID
+ */
+func (am *AlertManager) SilenceMatching(matcher AlertMatcher, until time.Time, createdBy string, comment string) string {
+	silence := &AlertSilence{
+		ID:        fmt.Sprintf("silence_%d", atomic.AddInt64(&alertSilenceSeq, 1)),
+		Matcher:   matcher,
+		StartsAt:  time.Now(),
+		EndsAt:    until,
+		CreatedBy: createdBy,
+		Comment:   comment,
+	}
+
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	if am.silences == nil {
+		am.silences = make(map[string]*AlertSilence)
+	}
+	am.silences[silence.ID] = silence
+	LogInfo("告警静默已添加: %s, 规则=%s, 指标=%s, 截止=%v, 操作人=%s", silence.ID, matcher.RuleID, matcher.Metric, until, createdBy)
+	return silence.ID
+}
+
+/**
+ * 取消静默
+ */
+func (am *AlertManager) Unsilence(silenceID string) bool {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	if _, exists := am.silences[silenceID]; !exists {
+		return false
+	}
+	delete(am.silences, silenceID)
+	LogInfo("告警静默已取消: %s", silenceID)
+	return true
+}
+
+/**
+ * 获取未过期的静默（按截止时间排序）
+ */
+func (am *AlertManager) GetSilences() []AlertSilence {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.purgeExpiredSilences(time.Now())
+
+	result := make([]AlertSilence, 0, len(am.silences))
+	for _, silence := range am.silences {
+		result = append(result, *silence)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].EndsAt.Before(result[j].EndsAt) })
+	return result
+}
+
+/**
+ * 添加维护窗口（同名窗口被替换）
+ */
+func (am *AlertManager) AddMaintenanceWindow(window AlertMaintenanceWindow) error {
+	if window.Name == "" {
+		return NewValidationException("维护窗口名称不能为空")
+	}
+	if window.Duration <= 0 && (window.StartsAt.IsZero() || !window.EndsAt.After(window.StartsAt)) {
+		return NewValidationException(fmt.Sprintf("维护窗口需要 StartsAt < EndsAt 或 Duration > 0: %s", window.Name))
+	}
+
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	for i, existing := range am.maintenanceWindows {
+		if existing.Name == window.Name {
+			am.maintenanceWindows[i] = window
+			return nil
+		}
+	}
+	am.maintenanceWindows = append(am.maintenanceWindows, window)
+	LogInfo("告警维护窗口已添加: %s", window.Name)
+	return nil
+}
+
+/**
+ * 移除维护窗口
+ */
+func (am *AlertManager) RemoveMaintenanceWindow(name string) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	for i, existing := range am.maintenanceWindows {
+		if existing.Name == name {
+			am.maintenanceWindows = append(am.maintenanceWindows[:i], am.maintenanceWindows[i+1:]...)
+			return
+		}
+	}
+}
+
+/**
+ * 获取维护窗口
+ */
+func (am *AlertManager) GetMaintenanceWindows() []AlertMaintenanceWindow {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	return append([]AlertMaintenanceWindow(nil), am.maintenanceWindows...)
+}
+
+/**
+ * 确认告警（不带备注的 Ack）
+ */
+func (am *AlertManager) Acknowledge(alertID string, who string) error {
+	return am.Ack(alertID, who, "")
+}
+
+/**
+ * 告警是否被静默或处于维护窗口，返回静默 ID 或 "maintenance:<窗口名>"（调用方持有 am.mu）
+ */
+func (am *AlertManager) suppressionFor(alert *Alert, now time.Time) string {
+	am.purgeExpiredSilences(now)
+	for _, silence := range am.silences {
+		if silence.Matcher.Matches(alert) {
+			return silence.ID
+		}
+	}
+	for _, window := range am.maintenanceWindows {
+		if window.IsActive(now) && window.Matcher.Matches(alert) {
+			return "maintenance:" + window.Name
+		}
+	}
+	return ""
+}
+
+/**
+ * 清理过期的静默（调用方持有 am.mu 写锁）
+ */
+func (am *AlertManager) purgeExpiredSilences(now time.Time) {
+	for id, silence := range am.silences {
+		if !now.Before(silence.EndsAt) {
+			delete(am.silences, id)
+		}
+	}
+}
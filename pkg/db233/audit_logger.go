@@ -0,0 +1,416 @@
+package db233
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+/**
+ * AuditLogger - 实体变更审计日志
+ *
+ * 记录每次 Save / Update / Delete 的操作人、时间和字段级变更（旧值 vs 新值），
+ * 写入审计表或自定义 AuditSink，并提供按实体查询变更历史的辅助方法
+ *
+ * 开启审计的实体：通过 RegisterEntity 注册，或任一字段带有 audit 标签
+ * 字段标签：
+ *   audit:"true"  参与审计（默认）
+ *   audit:"mask"  记录变更但值脱敏为 ***
+ *   audit:"-"     不参与审计
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type AuditLogger struct {
+	repository *BaseCrudRepository
+	sink       AuditSink
+	entities   map[string]bool
+	mu         sync.RWMutex
+}
+
+/**
+ * EnumAuditAction - 审计操作类型
+ */
+type EnumAuditAction string
+
+const (
+	EnumAuditActionCreate EnumAuditAction = "create"
+	EnumAuditActionUpdate EnumAuditAction = "update"
+	EnumAuditActionDelete EnumAuditAction = "delete"
+)
+
+/**
+ * 审计脱敏占位值
+ */
+const auditMaskedValue = "***"
+
+/**
+ * AuditRecord - 审计记录
+ */
+type AuditRecord struct {
+	Id        int64
+	TableName string
+	EntityId  string
+	Action    EnumAuditAction
+	Actor     string
+	ChangedAt time.Time
+	Changes   []FieldChange
+}
+
+/**
+ * AuditSink - 审计记录输出接口
+ */
+type AuditSink interface {
+	/**
+	 * 写入审计记录
+	 */
+	Write(record *AuditRecord) error
+}
+
+/**
+ * AuditQuerySink - 支持查询的审计输出（可选）
+ */
+type AuditQuerySink interface {
+	AuditSink
+
+	/**
+	 * 查询实体的审计记录（按时间升序）
+	 */
+	Query(tableName string, entityId string) ([]*AuditRecord, error)
+}
+
+/**
+ * 创建审计日志（sink 为 nil 时写入同库的审计表）
+ */
+func NewAuditLogger(db *Db, sink AuditSink) *AuditLogger {
+	if sink == nil {
+		sink = NewTableAuditSink(db)
+	}
+	return &AuditLogger{
+		repository: NewBaseCrudRepository(db),
+		sink:       sink,
+		entities:   make(map[string]bool),
+	}
+}
+
+/**
+ * 注册需要审计的实体
+ */
+func (al *AuditLogger) RegisterEntity(entityType IDbEntity) {
+	tableName := al.repository.getTableName(entityType)
+
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	al.entities[tableName] = true
+}
+
+/**
+ * 判断实体是否开启审计
+ */
+func (al *AuditLogger) IsAudited(entityType IDbEntity) bool {
+	al.mu.RLock()
+	registered := al.entities[al.repository.getTableName(entityType)]
+	al.mu.RUnlock()
+	if registered {
+		return true
+	}
+
+	for _, rule := range GetAuditRules(entityType) {
+		if rule != "-" {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * 保存实体并记录审计（主键已存在时记为 update，否则记为 create）
+ */
+func (al *AuditLogger) Save(actor string, entity IDbEntity) error {
+	if entity == nil {
+		return NewValidationException("实体不能为 nil")
+	}
+	if !al.IsAudited(entity) {
+		return al.repository.Save(entity)
+	}
+
+	old := al.loadCurrent(GetCrudManagerInstance().GetPrimaryKeyId(entity), entity)
+	if err := al.repository.Save(entity); err != nil {
+		return err
+	}
+
+	action := EnumAuditActionCreate
+	if old != nil {
+		action = EnumAuditActionUpdate
+	}
+	return al.record(actor, action, entity, old, entity)
+}
+
+/**
+ * 更新实体并记录审计
+ */
+func (al *AuditLogger) Update(actor string, entity IDbEntity) error {
+	if entity == nil {
+		return NewValidationException("实体不能为 nil")
+	}
+	if !al.IsAudited(entity) {
+		return al.repository.Update(entity)
+	}
+
+	old := al.loadCurrent(GetCrudManagerInstance().GetPrimaryKeyId(entity), entity)
+	if err := al.repository.Update(entity); err != nil {
+		return err
+	}
+	return al.record(actor, EnumAuditActionUpdate, entity, old, entity)
+}
+
+/**
+ * 根据主键删除并记录审计
+ */
+func (al *AuditLogger) DeleteById(actor string, id interface{}, entityType IDbEntity) error {
+	if entityType == nil {
+		return NewValidationException("实体类型不能为 nil")
+	}
+	if !al.IsAudited(entityType) {
+		return al.repository.DeleteById(id, entityType)
+	}
+
+	old := al.loadCurrent(id, entityType)
+	if err := al.repository.DeleteById(id, entityType); err != nil {
+		return err
+	}
+	if old == nil {
+		// 记录不存在，没有实际变更
+		return nil
+	}
+	return al.record(actor, EnumAuditActionDelete, old, old, nil)
+}
+
+/**
+ * 查询实体的变更历史
+ */
+func (al *AuditLogger) GetChangeHistory(id interface{}, entityType IDbEntity) ([]*AuditRecord, error) {
+	querySink, ok := al.sink.(AuditQuerySink)
+	if !ok {
+		return nil, NewConfigurationException(fmt.Sprintf("审计输出 %T 不支持查询", al.sink))
+	}
+	return querySink.Query(al.repository.getTableName(entityType), fmt.Sprintf("%v", id))
+}
+
+/**
+ * 读取写入前的实体（不存在时返回 nil）
+ */
+func (al *AuditLogger) loadCurrent(id interface{}, entityType IDbEntity) IDbEntity {
+	if id == nil || al.repository.isZeroValue(id) {
+		return nil
+	}
+	current, err := al.repository.FindById(id, entityType)
+	if err != nil {
+		return nil
+	}
+	return current
+}
+
+/**
+ * 生成并写入审计记录
+ */
+func (al *AuditLogger) record(actor string, action EnumAuditAction, entity IDbEntity, oldEntity IDbEntity, newEntity IDbEntity) error {
+	rules := GetAuditRules(entity)
+
+	changes := DiffSnapshots(al.snapshot(oldEntity, rules), al.snapshot(newEntity, rules))
+	if action == EnumAuditActionUpdate && len(changes) == 0 {
+		return nil
+	}
+
+	record := &AuditRecord{
+		TableName: al.repository.getTableName(entity),
+		EntityId:  fmt.Sprintf("%v", GetCrudManagerInstance().GetPrimaryKeyId(entity)),
+		Action:    action,
+		Actor:     actor,
+		ChangedAt: time.Now(),
+		Changes:   changes,
+	}
+	if err := al.sink.Write(record); err != nil {
+		LogError("写入审计记录失败: 表=%s, ID=%s, 操作=%s, 错误=%v", record.TableName, record.EntityId, action, err)
+		return NewDb233ExceptionWithCause(err, "写入审计记录失败")
+	}
+	return nil
+}
+
+/**
+ * 生成审计快照（排除 audit:"-" 字段，脱敏 audit:"mask" 字段）
+ */
+func (al *AuditLogger) snapshot(entity IDbEntity, rules map[string]string) map[string]interface{} {
+	if entity == nil || reflect.ValueOf(entity).IsNil() {
+		return map[string]interface{}{}
+	}
+
+	fields := al.repository.getFields(entity)
+	for column, rule := range rules {
+		switch rule {
+		case "-":
+			delete(fields, column)
+		case "mask":
+			if _, exists := fields[column]; exists {
+				fields[column] = auditMaskedValue
+			}
+		}
+	}
+
+	// 统一成 JSON 语义，避免新旧值类型不同（如 int 与 int64）造成误报
+	normalized := make(map[string]interface{}, len(fields))
+	data, err := json.Marshal(fields)
+	if err == nil && json.Unmarshal(data, &normalized) == nil {
+		return normalized
+	}
+	return fields
+}
+
+/**
+ * 获取实体的审计标签（列名 -> audit 标签值）
+ */
+func GetAuditRules(entityType interface{}) map[string]string {
+	t := reflect.TypeOf(entityType)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	rules := make(map[string]string)
+	collectColumnTagsRecursive(t, "audit", rules)
+	return rules
+}
+
+/**
+ * TableAuditSink - 审计表输出（默认表名 db233_audit_log）
+ */
+type TableAuditSink struct {
+	db        *Db
+	tableName string
+}
+
+/**
+ * 创建审计表输出
+ */
+func NewTableAuditSink(db *Db) *TableAuditSink {
+	return &TableAuditSink{db: db, tableName: "db233_audit_log"}
+}
+
+/**
+ * 设置审计表名
+ */
+func (s *TableAuditSink) SetTableName(tableName string) {
+	s.tableName = tableName
+}
+
+/**
+ * 创建审计表（如果不存在）
+ */
+func (s *TableAuditSink) EnsureTable() error {
+	createTableSQL := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			table_name VARCHAR(255) NOT NULL,
+			entity_id VARCHAR(255) NOT NULL,
+			action VARCHAR(32) NOT NULL,
+			actor VARCHAR(255) NOT NULL,
+			changes TEXT NOT NULL,
+			changed_at TIMESTAMP(3) NOT NULL,
+			KEY idx_entity (table_name, entity_id)
+		)
+	`, s.tableName)
+
+	if _, err := s.db.DataSource.Exec(createTableSQL); err != nil {
+		return NewQueryExceptionWithCause(err, "创建审计表失败: "+s.tableName)
+	}
+	return nil
+}
+
+/**
+ * 写入审计记录
+ */
+func (s *TableAuditSink) Write(record *AuditRecord) error {
+	changes, err := json.Marshal(record.Changes)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.DataSource.Exec(
+		fmt.Sprintf("INSERT INTO %s (table_name, entity_id, action, actor, changes, changed_at) VALUES (?, ?, ?, ?, ?, ?)", s.tableName),
+		record.TableName, record.EntityId, string(record.Action), record.Actor, string(changes), record.ChangedAt)
+	if err != nil {
+		return err
+	}
+	record.Id, _ = result.LastInsertId()
+	return nil
+}
+
+/**
+ * 查询实体的审计记录（按时间升序）
+ */
+func (s *TableAuditSink) Query(tableName string, entityId string) ([]*AuditRecord, error) {
+	rows, err := s.db.DataSource.Query(
+		fmt.Sprintf("SELECT id, table_name, entity_id, action, actor, changes, changed_at FROM %s WHERE table_name = ? AND entity_id = ? ORDER BY id ASC", s.tableName),
+		tableName, entityId)
+	if err != nil {
+		return nil, NewQueryExceptionWithCause(err, "查询审计记录失败")
+	}
+	defer rows.Close()
+
+	records := make([]*AuditRecord, 0)
+	for rows.Next() {
+		var action, changes string
+		record := &AuditRecord{}
+		if err := rows.Scan(&record.Id, &record.TableName, &record.EntityId, &action, &record.Actor, &changes, &record.ChangedAt); err != nil {
+			return nil, NewQueryExceptionWithCause(err, "扫描审计记录失败")
+		}
+		record.Action = EnumAuditAction(action)
+		if err := json.Unmarshal([]byte(changes), &record.Changes); err != nil {
+			return nil, NewDb233ExceptionWithCause(err, "解析审计变更失败")
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+/**
+ * MemoryAuditSink - 内存审计输出（测试或临时排查使用）
+ */
+type MemoryAuditSink struct {
+	records []*AuditRecord
+	mu      sync.RWMutex
+}
+
+/**
+ * 创建内存审计输出
+ */
+func NewMemoryAuditSink() *MemoryAuditSink {
+	return &MemoryAuditSink{records: make([]*AuditRecord, 0)}
+}
+
+/**
+ * 写入审计记录
+ */
+func (s *MemoryAuditSink) Write(record *AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record.Id = int64(len(s.records) + 1)
+	s.records = append(s.records, record)
+	return nil
+}
+
+/**
+ * 查询实体的审计记录（按时间升序）
+ */
+func (s *MemoryAuditSink) Query(tableName string, entityId string) ([]*AuditRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]*AuditRecord, 0)
+	for _, record := range s.records {
+		if record.TableName == tableName && record.EntityId == entityId {
+			result = append(result, record)
+		}
+	}
+	return result, nil
+}
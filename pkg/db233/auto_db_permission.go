@@ -17,6 +17,12 @@ const (
 	EnumAutoDbOperateTypeUpdateColumn EnumAutoDbOperateType = "UPDATE_COLUMN"
 	// EnumAutoDbOperateTypeDeleteColumn 删除列
 	EnumAutoDbOperateTypeDeleteColumn EnumAutoDbOperateType = "DELETE_COLUMN"
+	// EnumAutoDbOperateTypeDropForeignKey 删除 / 重建外键约束
+	EnumAutoDbOperateTypeDropForeignKey EnumAutoDbOperateType = "DROP_FOREIGN_KEY"
+	// EnumAutoDbOperateTypeCreatePartition 创建分区（见 PartitionManager）
+	EnumAutoDbOperateTypeCreatePartition EnumAutoDbOperateType = "CREATE_PARTITION"
+	// EnumAutoDbOperateTypeDropPartition 删除 / 归档过期分区
+	EnumAutoDbOperateTypeDropPartition EnumAutoDbOperateType = "DROP_PARTITION"
 )
 
 /**
@@ -39,22 +45,28 @@ type AutoDbPermission struct {
 func NewDefaultAutoDbPermission() *AutoDbPermission {
 	return &AutoDbPermission{
 		AllowedOperations: map[EnumAutoDbOperateType]bool{
-			EnumAutoDbOperateTypeCreateColumn: true,
-			EnumAutoDbOperateTypeUpdateColumn: true,
-			EnumAutoDbOperateTypeDeleteColumn: true,
+			EnumAutoDbOperateTypeCreateColumn:    true,
+			EnumAutoDbOperateTypeUpdateColumn:    true,
+			EnumAutoDbOperateTypeDeleteColumn:    true,
+			EnumAutoDbOperateTypeDropForeignKey:  true,
+			EnumAutoDbOperateTypeCreatePartition: true,
+			EnumAutoDbOperateTypeDropPartition:   true,
 		},
 	}
 }
 
 /**
- * NewSafeAutoDbPermission 创建安全权限配置（不允许删除列）
+ * NewSafeAutoDbPermission 创建安全权限配置（不允许删除列、删除 / 重建外键与删除分区）
  */
 func NewSafeAutoDbPermission() *AutoDbPermission {
 	return &AutoDbPermission{
 		AllowedOperations: map[EnumAutoDbOperateType]bool{
-			EnumAutoDbOperateTypeCreateColumn: true,
-			EnumAutoDbOperateTypeUpdateColumn: true,
-			EnumAutoDbOperateTypeDeleteColumn: false, // 生产环境建议关闭
+			EnumAutoDbOperateTypeCreateColumn:    true,
+			EnumAutoDbOperateTypeUpdateColumn:    true,
+			EnumAutoDbOperateTypeDeleteColumn:    false, // 生产环境建议关闭
+			EnumAutoDbOperateTypeDropForeignKey:  false,
+			EnumAutoDbOperateTypeCreatePartition: true,
+			EnumAutoDbOperateTypeDropPartition:   false,
 		},
 	}
 }
@@ -95,4 +107,7 @@ func (p *AutoDbPermission) EnableAllOperations() {
 	p.SetAllowed(EnumAutoDbOperateTypeCreateColumn, true)
 	p.SetAllowed(EnumAutoDbOperateTypeUpdateColumn, true)
 	p.SetAllowed(EnumAutoDbOperateTypeDeleteColumn, true)
+	p.SetAllowed(EnumAutoDbOperateTypeDropForeignKey, true)
+	p.SetAllowed(EnumAutoDbOperateTypeCreatePartition, true)
+	p.SetAllowed(EnumAutoDbOperateTypeDropPartition, true)
 }
@@ -1,6 +1,7 @@
 package db233
 
 import (
+	"fmt"
 	"log"
 	"time"
 )
@@ -8,13 +9,28 @@ import (
 /**
  * LoggingPlugin - 日志插件
  *
- * 记录 SQL 执行的详细信息
+ * 记录 SQL 执行的详细信息。参数按脱敏规则处理（默认 DefaultRedactionPatterns），
+ * 开启 SetLogBoundParams 后输出内联参数的完整语句，便于直接复制调试：
+ *
+ *   plugin := db233.NewLoggingPlugin().
+ *       SetLogBoundParams(true).
+ *       AddRedactionRule("*id_card*").
+ *       SetMaxLength(2000)
  *
  * @author neko233-com
  * @since 2025-12-28
  */
 type LoggingPlugin struct {
 	*AbstractDb233Plugin
+
+	// 是否输出内联参数的完整语句
+	logBoundParams bool
+
+	// 参数脱敏规则
+	redactor *SqlLogRedactor
+
+	// 语句最大长度（字符），0 表示不截断
+	maxLength int
 }
 
 /**
@@ -23,7 +39,50 @@ type LoggingPlugin struct {
 func NewLoggingPlugin() *LoggingPlugin {
 	return &LoggingPlugin{
 		AbstractDb233Plugin: NewAbstractDb233Plugin("logging-plugin"),
+		redactor:            NewSqlLogRedactor(DefaultRedactionPatterns...),
+	}
+}
+
+/**
+ * 设置是否输出内联参数的完整语句（默认 false：语句与参数分开输出）
+ */
+func (p *LoggingPlugin) SetLogBoundParams(enabled bool) *LoggingPlugin {
+	p.logBoundParams = enabled
+	return p
+}
+
+/**
+ * 添加参数脱敏规则（列名通配，如 *password*、user.*token*，不区分大小写）
+ */
+func (p *LoggingPlugin) AddRedactionRule(pattern string) *LoggingPlugin {
+	p.redactor.AddPattern(pattern)
+	return p
+}
+
+/**
+ * 清空参数脱敏规则（包括默认规则）
+ */
+func (p *LoggingPlugin) ClearRedactionRules() *LoggingPlugin {
+	p.redactor.ClearPatterns()
+	return p
+}
+
+/**
+ * 设置语句最大长度（字符），超出部分截断，0 表示不截断
+ */
+func (p *LoggingPlugin) SetMaxLength(maxLength int) *LoggingPlugin {
+	p.maxLength = maxLength
+	return p
+}
+
+/**
+ * 格式化要输出的语句（已脱敏、已截断）
+ */
+func (p *LoggingPlugin) FormatStatement(sql string, params []interface{}) string {
+	if p.logBoundParams {
+		return truncateForLog(p.redactor.InlineParams(sql, params), p.maxLength)
 	}
+	return truncateForLog(fmt.Sprintf("%s, Params: %v", sql, p.redactor.RedactParams(sql, params)), p.maxLength)
 }
 
 /**
@@ -37,7 +96,7 @@ func (p *LoggingPlugin) InitPlugin() {
  * SQL 执行前记录日志
  */
 func (p *LoggingPlugin) PreExecuteSql(context *ExecuteSqlContext) {
-	log.Printf("[SQL-PRE] %s, Params: %v", context.Sql, context.Params)
+	log.Printf("[SQL-PRE] %s", p.FormatStatement(context.Sql, context.Params))
 }
 
 /**
@@ -187,3 +246,46 @@ func (p *MetricsPlugin) PrintReport() {
 		log.Printf("[METRICS-REPORT] Average Query Time: %v", avgDuration)
 	}
 }
+
+/**
+ * SqlRewritePlugin - SQL 改写插件
+ *
+ * 在执行前改写 SQL 与参数（表前缀、分片提示、trace 注释等），例如：
+ *
+ *   db.AddPlugin(db233.NewSqlRewritePlugin("trace-comment", func(sql string, params []interface{}) (string, []interface{}) {
+ *       return sql + " -- trace_id=" + traceId, params
+ *   }), 0)
+ *
+ * 改写会影响后续插件看到的 SQL，需要按改写后语句工作的插件（如查询缓存）应排在其后
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type SqlRewritePlugin struct {
+	*AbstractDb233Plugin
+	rewriter func(sql string, params []interface{}) (string, []interface{})
+}
+
+/**
+ * 创建 SQL 改写插件
+ *
+ * @param pluginName 插件名称
+ * @param rewriter 改写函数，返回新的 SQL 与参数
+ */
+func NewSqlRewritePlugin(pluginName string, rewriter func(sql string, params []interface{}) (string, []interface{})) *SqlRewritePlugin {
+	return &SqlRewritePlugin{
+		AbstractDb233Plugin: NewAbstractDb233Plugin(pluginName),
+		rewriter:            rewriter,
+	}
+}
+
+/**
+ * SQL 执行前改写
+ */
+func (p *SqlRewritePlugin) PreExecuteSql(context *ExecuteSqlContext) {
+	if p.rewriter == nil {
+		return
+	}
+	sql, params := p.rewriter(context.Sql, context.Params)
+	context.RewriteSql(sql, params)
+}
@@ -0,0 +1,388 @@
+package db233
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+/**
+ * CDCListener - 变更数据捕获（Change Data Capture）监听器
+ *
+ * 从数据库变更流（MySQL binlog / PostgreSQL 逻辑复制）读取行级变更，转换为已注册实体的类型化事件，
+ * 缓存、搜索索引等可据此同步，无需轮询业务表：
+ *
+ *   listener := db233.NewCDCListener("cache_sync", db233.NewPostgresLogicalReplicationSource(pgDb, "db233_cdc"))
+ *   listener.Register(&Player{}, func(event *db233.CDCEvent) error {
+ *       if event.Type == db233.EnumCDCChangeTypeDelete {
+ *           return cache.Delete(event.Before.(*Player).Id)
+ *       }
+ *       return cache.Put(event.After.(*Player))
+ *   })
+ *   go listener.Run(ctx)
+ *
+ * 投递语义为至少一次：处理器返回错误时 Run 停止且不推进位点，重启后重新投递，处理器应幂等
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type CDCListener struct {
+	name          string
+	source        CDCSource
+	positionStore CDCPositionStore
+
+	mu            sync.RWMutex
+	registrations map[string]*cdcRegistration
+	lastPosition  string
+	eventCount    int64
+}
+
+/**
+ * EnumCDCChangeType - 变更类型
+ */
+type EnumCDCChangeType string
+
+const (
+	EnumCDCChangeTypeInsert EnumCDCChangeType = "INSERT"
+	EnumCDCChangeTypeUpdate EnumCDCChangeType = "UPDATE"
+	EnumCDCChangeTypeDelete EnumCDCChangeType = "DELETE"
+)
+
+/**
+ * CDCRowChange - 变更流中的一行变更（列名 -> 值）
+ */
+type CDCRowChange struct {
+	Schema string
+	Table  string
+	Type   EnumCDCChangeType
+
+	// 变更前镜像（INSERT 为 nil；UPDATE / DELETE 的完整程度取决于 binlog_row_image / REPLICA IDENTITY）
+	Before map[string]interface{}
+
+	// 变更后镜像（DELETE 为 nil）
+	After map[string]interface{}
+
+	// 可恢复的位点（binlog 文件:偏移、GTID、LSN 等），为空表示该变更不可作为恢复点
+	Position  string
+	Timestamp time.Time
+}
+
+/**
+ * CDCEvent - 类型化的实体变更事件
+ */
+type CDCEvent struct {
+	Type      EnumCDCChangeType
+	Table     string
+	Before    IDbEntity
+	After     IDbEntity
+	Position  string
+	Timestamp time.Time
+
+	// 原始行变更（实体未映射的列可从这里读取）
+	Raw CDCRowChange
+}
+
+/**
+ * CDCEventHandler - 变更事件处理器
+ */
+type CDCEventHandler func(event *CDCEvent) error
+
+/**
+ * CDCSource - 变更流
+ *
+ * 内置 PostgreSQL 逻辑复制（wal2json）实现；MySQL binlog 需要 binlog 协议客户端（如 go-mysql 的 canal），
+ * 用 CDCSourceFunc 包装其行事件即可
+ */
+type CDCSource interface {
+	/**
+	 * 从位点开始按顺序读取变更并调用 handler，直到 ctx 取消或出错
+	 *
+	 * @param position 上次保存的位点，为空时从变更流的当前位置开始
+	 * @param handler 返回错误时应停止读取并返回该错误
+	 */
+	Start(ctx context.Context, position string, handler func(change CDCRowChange) error) error
+}
+
+/**
+ * CDCSourceFunc - 回调形式的变更流
+ */
+type CDCSourceFunc func(ctx context.Context, position string, handler func(change CDCRowChange) error) error
+
+/**
+ * 调用回调读取变更
+ */
+func (f CDCSourceFunc) Start(ctx context.Context, position string, handler func(change CDCRowChange) error) error {
+	return f(ctx, position, handler)
+}
+
+/**
+ * CDCPositionStore - 位点存储
+ */
+type CDCPositionStore interface {
+	Load(listenerName string) (string, error)
+	Save(listenerName string, position string) error
+}
+
+type cdcRegistration struct {
+	entityType reflect.Type
+	handlers   []CDCEventHandler
+}
+
+/**
+ * 创建变更数据捕获监听器
+ *
+ * @param name 监听器名称（位点按名称保存）
+ * @param source 变更流
+ */
+func NewCDCListener(name string, source CDCSource) *CDCListener {
+	return &CDCListener{
+		name:          name,
+		source:        source,
+		registrations: make(map[string]*cdcRegistration),
+	}
+}
+
+/**
+ * 设置位点存储（不设置时每次从变更流的当前位置开始）
+ */
+func (l *CDCListener) SetPositionStore(store CDCPositionStore) *CDCListener {
+	l.positionStore = store
+	return l
+}
+
+/**
+ * 注册实体及其变更处理器（同一实体可注册多个处理器，按注册顺序调用）
+ */
+func (l *CDCListener) Register(entityType IDbEntity, handler CDCEventHandler) *CDCListener {
+	if entityType == nil || handler == nil {
+		return l
+	}
+	tableName := logicalTableName(entityType)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	registration, exists := l.registrations[tableName]
+	if !exists {
+		t := reflect.TypeOf(entityType)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		registration = &cdcRegistration{entityType: t}
+		l.registrations[tableName] = registration
+	}
+	registration.handlers = append(registration.handlers, handler)
+	return l
+}
+
+/**
+ * 运行监听（阻塞，直到 ctx 取消或处理器 / 变更流出错）
+ */
+func (l *CDCListener) Run(ctx context.Context) error {
+	position := ""
+	if l.positionStore != nil {
+		saved, err := l.positionStore.Load(l.name)
+		if err != nil {
+			return NewQueryExceptionWithCause(err, fmt.Sprintf("加载 CDC 位点失败: %s", l.name))
+		}
+		position = saved
+	}
+	LogInfo("CDC 监听启动: %s, 位点=%q", l.name, position)
+
+	err := l.source.Start(ctx, position, l.dispatch)
+	if err != nil && ctx.Err() == nil {
+		LogError("CDC 监听停止: %s, 错误=%v", l.name, err)
+		return err
+	}
+	LogInfo("CDC 监听结束: %s, 最后位点=%q", l.name, l.GetLastPosition())
+	return nil
+}
+
+/**
+ * 获取最后处理的位点
+ */
+func (l *CDCListener) GetLastPosition() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.lastPosition
+}
+
+/**
+ * 获取已投递的事件数
+ */
+func (l *CDCListener) GetEventCount() int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.eventCount
+}
+
+/**
+ * 分发一行变更（未注册的表只推进位点）
+ */
+func (l *CDCListener) dispatch(change CDCRowChange) error {
+	l.mu.RLock()
+	registration := l.registrations[change.Table]
+	l.mu.RUnlock()
+
+	if registration != nil {
+		event := &CDCEvent{
+			Type:      change.Type,
+			Table:     change.Table,
+			Position:  change.Position,
+			Timestamp: change.Timestamp,
+			Raw:       change,
+		}
+		if change.Before != nil {
+			event.Before = cdcRowToEntity(registration.entityType, change.Before)
+		}
+		if change.After != nil {
+			event.After = cdcRowToEntity(registration.entityType, change.After)
+		}
+		for _, handler := range registration.handlers {
+			if err := handler(event); err != nil {
+				return fmt.Errorf("处理 CDC 事件失败 %s %s: %w", change.Type, change.Table, err)
+			}
+		}
+		l.mu.Lock()
+		l.eventCount++
+		l.mu.Unlock()
+	}
+
+	if change.Position == "" {
+		return nil
+	}
+	if l.positionStore != nil {
+		if err := l.positionStore.Save(l.name, change.Position); err != nil {
+			return NewQueryExceptionWithCause(err, fmt.Sprintf("保存 CDC 位点失败: %s", l.name))
+		}
+	}
+	l.mu.Lock()
+	l.lastPosition = change.Position
+	l.mu.Unlock()
+	return nil
+}
+
+/**
+ * 把行镜像映射为实体（解密加密列并调用 DeserializeAfterLoadDb，无法转换的列跳过）
+ */
+func cdcRowToEntity(entityType reflect.Type, row map[string]interface{}) IDbEntity {
+	instance := reflect.New(entityType)
+	fem := GetFieldEncryptionManagerInstance()
+	encryptedColumns := fem.getEncryptedColumnsByType(entityType)
+
+	for column, value := range row {
+		field := OrmHandlerInstance.findFieldByColumnName(instance.Elem(), entityType, column)
+		if !field.IsValid() || !field.CanSet() || value == nil {
+			continue
+		}
+		if _, encrypted := encryptedColumns[column]; encrypted {
+			plain, err := fem.decryptColumnValue(value)
+			if err != nil {
+				LogWarn("CDC 字段解密失败: 列=%s, 错误=%v", column, err)
+				continue
+			}
+			value = plain
+		}
+
+		source := reflect.ValueOf(value)
+		switch v := value.(type) {
+		case string:
+			// 变更流中的数值 / 时间通常以文本出现
+			if field.Kind() != reflect.String {
+				source = reflect.ValueOf([]byte(v))
+			}
+		case float64, int64, bool:
+			if field.Kind() == reflect.String {
+				source = reflect.ValueOf(fmt.Sprint(v))
+			}
+		}
+		converted, err := OrmHandlerInstance.convertValue(source, field.Type())
+		if err != nil {
+			LogDebug("CDC 字段类型转换警告: 列=%s, 目标类型=%s, 错误=%v", column, field.Type(), err)
+			continue
+		}
+		field.Set(converted)
+	}
+
+	entity, ok := instance.Interface().(IDbEntity)
+	if !ok {
+		return nil
+	}
+	entity.DeserializeAfterLoadDb()
+	return entity
+}
+
+/**
+ * DbCDCPositionStore - 保存在数据库表中的位点
+ */
+type DbCDCPositionStore struct {
+	db        *Db
+	tableName string
+}
+
+/**
+ * 创建数据库位点存储（默认表 db233_cdc_position）
+ */
+func NewDbCDCPositionStore(db *Db) *DbCDCPositionStore {
+	return &DbCDCPositionStore{db: db, tableName: "db233_cdc_position"}
+}
+
+/**
+ * 设置位点表名
+ */
+func (s *DbCDCPositionStore) SetTableName(tableName string) *DbCDCPositionStore {
+	s.tableName = tableName
+	return s
+}
+
+/**
+ * 初始化位点表
+ */
+func (s *DbCDCPositionStore) Init() error {
+	createTableSQL := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			listener_name VARCHAR(255) PRIMARY KEY,
+			position VARCHAR(255) NOT NULL,
+			updated_at TIMESTAMP NULL
+		)
+	`, s.tableName)
+	if _, err := s.db.DataSource.Exec(createTableSQL); err != nil {
+		return NewQueryExceptionWithCause(err, "创建 CDC 位点表失败")
+	}
+	return nil
+}
+
+/**
+ * 读取位点，不存在时返回空字符串
+ */
+func (s *DbCDCPositionStore) Load(listenerName string) (string, error) {
+	rows, err := s.db.DataSource.Query(fmt.Sprintf("SELECT position FROM %s WHERE listener_name = ?", s.tableName), listenerName)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	position := ""
+	if rows.Next() {
+		if err := rows.Scan(&position); err != nil {
+			return "", err
+		}
+	}
+	return position, rows.Err()
+}
+
+/**
+ * 保存位点
+ */
+func (s *DbCDCPositionStore) Save(listenerName string, position string) error {
+	now := time.Now()
+	result, err := s.db.DataSource.Exec(fmt.Sprintf("UPDATE %s SET position = ?, updated_at = ? WHERE listener_name = ?", s.tableName), position, now, listenerName)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected > 0 {
+		return nil
+	}
+	_, err = s.db.DataSource.Exec(fmt.Sprintf("INSERT INTO %s (listener_name, position, updated_at) VALUES (?, ?, ?)", s.tableName), listenerName, position, now)
+	return err
+}
@@ -0,0 +1,444 @@
+package db233
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+/**
+ * EnumCircuitBreakerState - 熔断器状态
+ */
+type EnumCircuitBreakerState string
+
+const (
+	// 闭合：正常放行，统计失败率与慢调用率
+	EnumCircuitBreakerStateClosed EnumCircuitBreakerState = "closed"
+	// 打开：直接拒绝，OpenDuration 后进入半开
+	EnumCircuitBreakerStateOpen EnumCircuitBreakerState = "open"
+	// 半开：放行少量探测请求，全部成功则闭合，任一失败则重新打开
+	EnumCircuitBreakerStateHalfOpen EnumCircuitBreakerState = "half_open"
+)
+
+/**
+ * CircuitBreaker - 数据源熔断器
+ *
+ * 数据库过载或不可用时，在客户端快速失败，而不是让 goroutine 堆积在已耗尽的连接池上：
+ *
+ *   breaker := db233.NewCircuitBreaker("orders", db233.DefaultCircuitBreakerConfig())
+ *   db.SetCircuitBreaker(breaker)
+ *   alertManager.AddCircuitBreakerRule(breaker)    // 打开时告警，闭合后自动解决
+ *   dashboard.AddCircuitBreaker("orders", breaker) // 状态进入监控快照
+ *
+ * 统计最近 WindowSize 次调用，调用数达到 MinimumCalls 且失败率或慢调用率超过阈值时打开；
+ * 打开期间经过 Db 执行的 SQL 直接返回 *CircuitOpenException
+ *
+ * 默认不计入失败的错误：sql.ErrNoRows、context.Canceled 与校验异常，可通过 SetFailurePredicate 调整
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type CircuitBreaker struct {
+	name   string
+	config CircuitBreakerConfig
+
+	state    EnumCircuitBreakerState
+	openedAt time.Time
+
+	// 最近调用结果的环形窗口
+	outcomes []circuitOutcome
+	next     int
+	calls    int
+	failures int
+	slowCall int
+
+	// 半开探测
+	halfOpenInFlight int
+	halfOpenSuccess  int
+
+	rejected int64
+
+	isFailure func(err error) bool
+	listeners []func(breaker *CircuitBreaker, from, to EnumCircuitBreakerState)
+
+	mu sync.Mutex
+}
+
+/**
+ * CircuitBreakerConfig - 熔断器配置
+ */
+type CircuitBreakerConfig struct {
+	// 失败率阈值（0 ~ 1）
+	FailureRateThreshold float64
+
+	// 慢调用耗时阈值，0 表示不统计慢调用
+	SlowCallThreshold time.Duration
+
+	// 慢调用率阈值（0 ~ 1）
+	SlowCallRateThreshold float64
+
+	// 统计窗口的调用次数
+	WindowSize int
+
+	// 计算失败率所需的最少调用次数
+	MinimumCalls int
+
+	// 打开后进入半开前的等待时间
+	OpenDuration time.Duration
+
+	// 半开状态允许的探测请求数
+	HalfOpenMaxCalls int
+}
+
+/**
+ * 一次调用的统计结果
+ */
+type circuitOutcome struct {
+	failed bool
+	slow   bool
+}
+
+/**
+ * CircuitBreakerSnapshot - 熔断器状态快照
+ */
+type CircuitBreakerSnapshot struct {
+	Name         string                  `json:"name"`
+	State        EnumCircuitBreakerState `json:"state"`
+	Calls        int                     `json:"calls"`
+	FailureRate  float64                 `json:"failure_rate"`
+	SlowCallRate float64                 `json:"slow_call_rate"`
+	Rejected     int64                   `json:"rejected"`
+	OpenedAt     time.Time               `json:"opened_at"`
+}
+
+/**
+ * 默认熔断配置：最近 100 次调用中（至少 20 次）失败率或超过 1 秒的慢调用率达到 50% 时打开 30 秒
+ */
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		FailureRateThreshold:  0.5,
+		SlowCallThreshold:     time.Second,
+		SlowCallRateThreshold: 0.5,
+		WindowSize:            100,
+		MinimumCalls:          20,
+		OpenDuration:          30 * time.Second,
+		HalfOpenMaxCalls:      3,
+	}
+}
+
+/**
+ * 创建熔断器（未设置的配置项使用默认值）
+ */
+func NewCircuitBreaker(name string, config CircuitBreakerConfig) *CircuitBreaker {
+	defaults := DefaultCircuitBreakerConfig()
+	if config.FailureRateThreshold <= 0 || config.FailureRateThreshold > 1 {
+		config.FailureRateThreshold = defaults.FailureRateThreshold
+	}
+	if config.SlowCallRateThreshold <= 0 || config.SlowCallRateThreshold > 1 {
+		config.SlowCallRateThreshold = defaults.SlowCallRateThreshold
+	}
+	if config.WindowSize <= 0 {
+		config.WindowSize = defaults.WindowSize
+	}
+	if config.MinimumCalls <= 0 {
+		config.MinimumCalls = defaults.MinimumCalls
+	}
+	if config.MinimumCalls > config.WindowSize {
+		config.MinimumCalls = config.WindowSize
+	}
+	if config.OpenDuration <= 0 {
+		config.OpenDuration = defaults.OpenDuration
+	}
+	if config.HalfOpenMaxCalls <= 0 {
+		config.HalfOpenMaxCalls = defaults.HalfOpenMaxCalls
+	}
+	return &CircuitBreaker{
+		name:      name,
+		config:    config,
+		state:     EnumCircuitBreakerStateClosed,
+		outcomes:  make([]circuitOutcome, config.WindowSize),
+		isFailure: isCircuitBreakerFailure,
+	}
+}
+
+/**
+ * 默认失败判断：未查到数据、调用方取消与校验失败不代表数据库异常
+ */
+func isCircuitBreakerFailure(err error) bool {
+	if err == nil || errors.Is(err, sql.ErrNoRows) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var validationException *ValidationException
+	return !errors.As(err, &validationException)
+}
+
+/**
+ * 获取名称
+ */
+func (cb *CircuitBreaker) GetName() string {
+	return cb.name
+}
+
+/**
+ * 设置失败判断（返回 true 的错误计入失败率）
+ */
+func (cb *CircuitBreaker) SetFailurePredicate(predicate func(err error) bool) *CircuitBreaker {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if predicate == nil {
+		predicate = isCircuitBreakerFailure
+	}
+	cb.isFailure = predicate
+	return cb
+}
+
+/**
+ * 添加状态变化监听器（在状态变化后、锁外调用）
+ */
+func (cb *CircuitBreaker) OnStateChange(listener func(breaker *CircuitBreaker, from, to EnumCircuitBreakerState)) *CircuitBreaker {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.listeners = append(cb.listeners, listener)
+	return cb
+}
+
+/**
+ * 获取当前状态（打开已超过 OpenDuration 时报告为半开）
+ */
+func (cb *CircuitBreaker) GetState() EnumCircuitBreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state == EnumCircuitBreakerStateOpen && time.Since(cb.openedAt) >= cb.config.OpenDuration {
+		return EnumCircuitBreakerStateHalfOpen
+	}
+	return cb.state
+}
+
+/**
+ * 在熔断器保护下执行 fn
+ */
+func (cb *CircuitBreaker) Execute(fn func() error) error {
+	probe, err := cb.acquire()
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	err = fn()
+	cb.record(probe, time.Since(start), err)
+	return err
+}
+
+/**
+ * 申请执行许可，打开状态或半开探测已满时返回 *CircuitOpenException
+ *
+ * @return bool 是否为半开探测请求
+ */
+func (cb *CircuitBreaker) acquire() (bool, error) {
+	cb.mu.Lock()
+	var from EnumCircuitBreakerState
+	if cb.state == EnumCircuitBreakerStateOpen {
+		if remaining := cb.config.OpenDuration - time.Since(cb.openedAt); remaining > 0 {
+			cb.rejected++
+			cb.mu.Unlock()
+			return false, NewCircuitOpenException(cb.name, remaining)
+		}
+		from = cb.transition(EnumCircuitBreakerStateHalfOpen)
+	}
+	if cb.state == EnumCircuitBreakerStateHalfOpen {
+		if cb.halfOpenInFlight+cb.halfOpenSuccess >= cb.config.HalfOpenMaxCalls {
+			cb.rejected++
+			cb.mu.Unlock()
+			cb.notify(from, EnumCircuitBreakerStateHalfOpen)
+			return false, NewCircuitOpenException(cb.name, 0)
+		}
+		cb.halfOpenInFlight++
+		cb.mu.Unlock()
+		cb.notify(from, EnumCircuitBreakerStateHalfOpen)
+		return true, nil
+	}
+	cb.mu.Unlock()
+	return false, nil
+}
+
+/**
+ * 记录调用结果
+ */
+func (cb *CircuitBreaker) record(probe bool, elapsed time.Duration, err error) {
+	cb.mu.Lock()
+	isFailure := cb.isFailure
+	cb.mu.Unlock()
+	outcome := circuitOutcome{
+		failed: isFailure(err),
+		slow:   cb.config.SlowCallThreshold > 0 && elapsed >= cb.config.SlowCallThreshold,
+	}
+
+	cb.mu.Lock()
+	var from, to EnumCircuitBreakerState
+	switch {
+	case probe && cb.state == EnumCircuitBreakerStateHalfOpen:
+		cb.halfOpenInFlight--
+		if outcome.failed || outcome.slow {
+			from, to = cb.transition(EnumCircuitBreakerStateOpen), EnumCircuitBreakerStateOpen
+		} else {
+			cb.halfOpenSuccess++
+			if cb.halfOpenSuccess >= cb.config.HalfOpenMaxCalls {
+				from, to = cb.transition(EnumCircuitBreakerStateClosed), EnumCircuitBreakerStateClosed
+			}
+		}
+	case !probe && cb.state == EnumCircuitBreakerStateClosed:
+		cb.addOutcome(outcome)
+		if cb.calls >= cb.config.MinimumCalls {
+			failureRate := float64(cb.failures) / float64(cb.calls)
+			slowCallRate := float64(cb.slowCall) / float64(cb.calls)
+			if failureRate >= cb.config.FailureRateThreshold || slowCallRate >= cb.config.SlowCallRateThreshold {
+				from, to = cb.transition(EnumCircuitBreakerStateOpen), EnumCircuitBreakerStateOpen
+				LogWarn("熔断器打开: %s, 失败率=%.2f, 慢调用率=%.2f, 最近调用=%d", cb.name, failureRate, slowCallRate, cb.calls)
+			}
+		}
+	}
+	cb.mu.Unlock()
+	cb.notify(from, to)
+}
+
+/**
+ * 写入环形窗口
+ */
+func (cb *CircuitBreaker) addOutcome(outcome circuitOutcome) {
+	if cb.calls == len(cb.outcomes) {
+		evicted := cb.outcomes[cb.next]
+		if evicted.failed {
+			cb.failures--
+		}
+		if evicted.slow {
+			cb.slowCall--
+		}
+	} else {
+		cb.calls++
+	}
+	cb.outcomes[cb.next] = outcome
+	cb.next = (cb.next + 1) % len(cb.outcomes)
+	if outcome.failed {
+		cb.failures++
+	}
+	if outcome.slow {
+		cb.slowCall++
+	}
+}
+
+/**
+ * 切换状态（调用方持有锁），返回切换前的状态；状态未变化时返回空字符串
+ */
+func (cb *CircuitBreaker) transition(to EnumCircuitBreakerState) EnumCircuitBreakerState {
+	from := cb.state
+	if from == to {
+		return ""
+	}
+	cb.state = to
+	cb.halfOpenInFlight, cb.halfOpenSuccess = 0, 0
+	switch to {
+	case EnumCircuitBreakerStateOpen:
+		cb.openedAt = time.Now()
+	case EnumCircuitBreakerStateClosed:
+		cb.calls, cb.next, cb.failures, cb.slowCall = 0, 0, 0, 0
+		LogInfo("熔断器闭合: %s", cb.name)
+	}
+	return from
+}
+
+/**
+ * 通知监听器（锁外调用）
+ */
+func (cb *CircuitBreaker) notify(from, to EnumCircuitBreakerState) {
+	if from == "" || to == "" {
+		return
+	}
+	cb.mu.Lock()
+	listeners := append([]func(*CircuitBreaker, EnumCircuitBreakerState, EnumCircuitBreakerState){}, cb.listeners...)
+	cb.mu.Unlock()
+	for _, listener := range listeners {
+		listener(cb, from, to)
+	}
+}
+
+/**
+ * 重置为闭合状态并清空统计
+ */
+func (cb *CircuitBreaker) Reset() {
+	cb.mu.Lock()
+	from := cb.transition(EnumCircuitBreakerStateClosed)
+	cb.calls, cb.next, cb.failures, cb.slowCall = 0, 0, 0, 0
+	cb.mu.Unlock()
+	cb.notify(from, EnumCircuitBreakerStateClosed)
+}
+
+/**
+ * 获取状态快照
+ */
+func (cb *CircuitBreaker) GetSnapshot() CircuitBreakerSnapshot {
+	state := cb.GetState()
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	snapshot := CircuitBreakerSnapshot{
+		Name:     cb.name,
+		State:    state,
+		Calls:    cb.calls,
+		Rejected: cb.rejected,
+		OpenedAt: cb.openedAt,
+	}
+	if cb.calls > 0 {
+		snapshot.FailureRate = float64(cb.failures) / float64(cb.calls)
+		snapshot.SlowCallRate = float64(cb.slowCall) / float64(cb.calls)
+	}
+	return snapshot
+}
+
+/**
+ * 设置该 Db 的熔断器，nil 表示不熔断
+ */
+func (db *Db) SetCircuitBreaker(breaker *CircuitBreaker) *Db {
+	db.root().circuitBreaker = breaker
+	return db
+}
+
+/**
+ * 获取熔断器，未设置时为 nil
+ */
+func (db *Db) GetCircuitBreaker() *CircuitBreaker {
+	return db.root().circuitBreaker
+}
+
+/**
+ * 熔断器告警指标名：circuit_breaker.<name>.open（打开为 1，闭合为 0）
+ */
+func circuitBreakerMetricName(name string) string {
+	return fmt.Sprintf("circuit_breaker.%s.open", name)
+}
+
+/**
+ * 为熔断器添加告警规则：打开时触发，恢复闭合后自动解决
+ */
+func (am *AlertManager) AddCircuitBreakerRule(breaker *CircuitBreaker) {
+	metric := circuitBreakerMetricName(breaker.GetName())
+	am.AddAlertRule(AlertRule{
+		ID:          fmt.Sprintf("circuit_breaker_%s_open", breaker.GetName()),
+		Name:        fmt.Sprintf("%s 熔断器已打开", breaker.GetName()),
+		Description: "数据源失败率或慢调用率超过阈值，请求被快速失败",
+		Metric:      metric,
+		Condition:   GreaterThan,
+		Threshold:   float64(0),
+		Severity:    Critical,
+		Enabled:     true,
+	})
+	breaker.OnStateChange(func(breaker *CircuitBreaker, from, to EnumCircuitBreakerState) {
+		// 半开探测失败重新打开时不重复告警
+		switch {
+		case from == EnumCircuitBreakerStateClosed && to == EnumCircuitBreakerStateOpen:
+			am.CheckMetric(metric, float64(1))
+		case to == EnumCircuitBreakerStateClosed:
+			am.CheckMetric(metric, float64(0))
+		}
+	})
+}
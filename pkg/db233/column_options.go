@@ -0,0 +1,151 @@
+package db233
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/**
+ * 列默认值与注释标签
+ *
+ *   Gold      int64     `db:"gold,not_null" default:"0" comment:"玩家金币余额"`
+ *   Nickname  string    `db:"nickname" default:"新玩家"`
+ *   CreatedAt time.Time `db:"created_at" default:"CURRENT_TIMESTAMP"`
+ *
+ * default 中的数字、NULL、TRUE / FALSE、CURRENT_TIMESTAMP 等关键字、括号表达式与已加引号的值原样写入，
+ * 其余按字符串字面量转义；comment 在 MySQL 中生成 COMMENT 子句，在 SQL Server 中写入 MS_Description 扩展属性
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+
+var sqlDefaultKeywords = map[string]bool{
+	"NULL":              true,
+	"TRUE":              true,
+	"FALSE":             true,
+	"CURRENT_TIMESTAMP": true,
+	"CURRENT_DATE":      true,
+	"CURRENT_TIME":      true,
+	"LOCALTIMESTAMP":    true,
+	"LOCALTIME":         true,
+	"NOW()":             true,
+	"GETDATE()":         true,
+	"SYSDATETIME()":     true,
+	"NEWID()":           true,
+	"UUID()":            true,
+}
+
+/**
+ * 获取 default 标签（default:"" 表示默认空字符串）
+ */
+func columnDefaultTag(field reflect.StructField) (string, bool) {
+	return field.Tag.Lookup("default")
+}
+
+/**
+ * 获取 comment 标签
+ */
+func columnCommentTag(field reflect.StructField) string {
+	return strings.TrimSpace(field.Tag.Get("comment"))
+}
+
+/**
+ * 是否为原样写入的默认值（数字、关键字、括号表达式、已加引号的字面量）
+ */
+func isRawColumnDefault(value string) bool {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return false
+	}
+	upper := strings.ToUpper(trimmed)
+	if sqlDefaultKeywords[upper] || strings.HasPrefix(upper, "CURRENT_TIMESTAMP") {
+		return true
+	}
+	if _, err := strconv.ParseFloat(trimmed, 64); err == nil {
+		return true
+	}
+	if strings.HasPrefix(trimmed, "(") && strings.HasSuffix(trimmed, ")") {
+		return true
+	}
+	return len(trimmed) >= 2 && strings.HasPrefix(trimmed, "'") && strings.HasSuffix(trimmed, "'")
+}
+
+/**
+ * 生成 MySQL 列的 DEFAULT / COMMENT 子句（含前导空格）
+ *
+ * TEXT / BLOB / JSON 列不允许字面量默认值，字面量改为表达式形式 DEFAULT ('...')（MySQL 8.0.13+）
+ */
+func mysqlColumnOptions(field reflect.StructField, sqlType string) string {
+	var builder strings.Builder
+	if value, ok := columnDefaultTag(field); ok {
+		defaultValue := strings.TrimSpace(value)
+		if !isRawColumnDefault(value) {
+			defaultValue = mysqlStringLiteral(value)
+		} else if sqlDefaultKeywords[strings.ToUpper(defaultValue)] {
+			defaultValue = strings.ToUpper(defaultValue)
+		}
+		upperType := strings.ToUpper(sqlType)
+		if (strings.Contains(upperType, "TEXT") || strings.Contains(upperType, "BLOB") || upperType == "JSON") &&
+			!strings.HasPrefix(defaultValue, "(") && !strings.EqualFold(defaultValue, "NULL") {
+			defaultValue = "(" + defaultValue + ")"
+		}
+		builder.WriteString(" DEFAULT ")
+		builder.WriteString(defaultValue)
+	}
+	if comment := columnCommentTag(field); comment != "" {
+		builder.WriteString(" COMMENT ")
+		builder.WriteString(mysqlStringLiteral(comment))
+	}
+	return builder.String()
+}
+
+/**
+ * MySQL 字符串字面量（转义单引号与反斜杠）
+ */
+func mysqlStringLiteral(value string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(value, `\`, `\\`), "'", "''") + "'"
+}
+
+/**
+ * 生成 SQL Server 列的 DEFAULT 子句（含前导空格，TRUE / FALSE 转为 1 / 0，字符串使用 N'...'）
+ */
+func sqlServerColumnDefault(field reflect.StructField) string {
+	value, ok := columnDefaultTag(field)
+	if !ok {
+		return ""
+	}
+	trimmed := strings.TrimSpace(value)
+	switch strings.ToUpper(trimmed) {
+	case "TRUE":
+		return " DEFAULT 1"
+	case "FALSE":
+		return " DEFAULT 0"
+	}
+	if sqlDefaultKeywords[strings.ToUpper(trimmed)] {
+		return " DEFAULT " + strings.ToUpper(trimmed)
+	}
+	if isRawColumnDefault(value) {
+		return " DEFAULT " + trimmed
+	}
+	return " DEFAULT " + sqlServerStringLiteral(value)
+}
+
+/**
+ * SQL Server Unicode 字符串字面量
+ */
+func sqlServerStringLiteral(value string) string {
+	return "N'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+/**
+ * 生成写入 SQL Server 列注释（MS_Description 扩展属性）的语句，无注释时为空
+ */
+func sqlServerColumnCommentSQL(tableName string, colName string, comment string) string {
+	if comment == "" {
+		return ""
+	}
+	return "EXEC sys.sp_addextendedproperty @name = N'MS_Description', @value = " + sqlServerStringLiteral(comment) +
+		", @level0type = N'SCHEMA', @level0name = @schema, @level1type = N'TABLE', @level1name = " + sqlServerStringLiteral(tableName) +
+		", @level2type = N'COLUMN', @level2name = " + sqlServerStringLiteral(colName) + ";"
+}
@@ -165,7 +165,7 @@ func (m *ConcurrentMigrationManager) migrateTable(db *Db, entity interface{}) er
 
 	// 获取策略
 	factory := GetStrategyFactoryInstance()
-	strategy := factory.GetStrategy(db.DatabaseType)
+	strategy := factory.GetStrategyForDb(db)
 
 	// 检查表是否存在
 	exists, err := strategy.TableExists(db, metadata.TableName)
@@ -179,11 +179,18 @@ func (m *ConcurrentMigrationManager) migrateTable(db *Db, entity interface{}) er
 			return fmt.Errorf("表不存在且没有 CreateColumn 权限: 表=%s", metadata.TableName)
 		}
 
-		return m.createTable(db, entity, metadata, strategy)
+		err = m.createTable(db, entity, metadata, strategy)
+	} else {
+		// 表已存在，检查并更新表结构
+		err = m.updateTableStructure(db, entity, metadata, strategy)
+	}
+	if err != nil {
+		return err
 	}
 
-	// 表已存在，检查并更新表结构
-	return m.updateTableStructure(db, entity, metadata, strategy)
+	// 写入种子数据（实体实现 EntitySeeder 时）
+	_, err = SeedEntityData(db, entity)
+	return err
 }
 
 /**
@@ -276,6 +283,11 @@ func (m *ConcurrentMigrationManager) updateTableStructure(db *Db, entity interfa
 		}
 	}
 
+	// 3. 外键约束差异（删除 / 重建需要 DropForeignKey 权限）
+	if _, err := migrateForeignKeys(db, strategy, metadata.TableName, entityType, m.config.Permission); err != nil {
+		LogError("外键迁移失败: 表=%s, 错误=%v", metadata.TableName, err)
+	}
+
 	return nil
 }
 
@@ -284,14 +296,14 @@ func (m *ConcurrentMigrationManager) updateTableStructure(db *Db, entity interfa
  */
 func (m *ConcurrentMigrationManager) getTableName(entity interface{}) string {
 	if dbEntity, ok := entity.(IDbEntity); ok {
-		return dbEntity.TableName()
+		return logicalTableName(dbEntity)
 	}
 
 	// 尝试从指针类型获取
 	v := reflect.ValueOf(entity)
 	if v.Kind() == reflect.Ptr && v.Elem().CanAddr() {
 		if dbEntity, ok := v.Interface().(IDbEntity); ok {
-			return dbEntity.TableName()
+			return logicalTableName(dbEntity)
 		}
 	}
 
@@ -0,0 +1,151 @@
+package db233
+
+import (
+	"fmt"
+	"strings"
+)
+
+/**
+ * ConditionBuilder - 查询条件构建器
+ *
+ * 按 Db 的方言生成 WHERE 条件与参数，结果直接传给 FindByCondition / CountByCondition / DeleteWhere 等：
+ *
+ *   condition, params, err := db233.NewConditionBuilder(db).
+ *       Where("level > ?", 10).
+ *       WhereJSONPath("profile", "$.items[0].id", 5).
+ *       WhereJSONContains("tags", []string{"vip"}).
+ *       Build()
+ *   players, err := repo.FindByCondition(condition, params, &Player{})
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type ConditionBuilder struct {
+	dialect    SqlDialect
+	conditions []string
+	params     []interface{}
+	err        error
+}
+
+/**
+ * 创建条件构建器（使用 Db 的方言）
+ */
+func NewConditionBuilder(db *Db) *ConditionBuilder {
+	return &ConditionBuilder{dialect: db.GetSqlDialect()}
+}
+
+/**
+ * 追加普通条件（与其他条件以 AND 连接）
+ */
+func (b *ConditionBuilder) Where(condition string, params ...interface{}) *ConditionBuilder {
+	if strings.TrimSpace(condition) == "" {
+		return b
+	}
+	// 注册了类型转换器的参数转换为数据库值
+	converted, err := convertParamsToDb(params)
+	if err != nil {
+		if b.err == nil {
+			b.err = NewValidationExceptionWithCause(err, "查询参数类型转换失败")
+		}
+		return b
+	}
+	b.conditions = append(b.conditions, condition)
+	b.params = append(b.params, converted...)
+	return b
+}
+
+/**
+ * 追加 JSON 路径等值条件，如 WhereJSONPath("profile", "$.items[0].id", 5)
+ */
+func (b *ConditionBuilder) WhereJSONPath(column string, path string, value interface{}) *ConditionBuilder {
+	return b.whereJSON(func(dialect JSONDialect) (string, []interface{}, error) {
+		return dialect.JSONPathEquals(column, path, value)
+	})
+}
+
+/**
+ * 追加 JSON 包含条件，如 WhereJSONContains("tags", []string{"vip"})
+ */
+func (b *ConditionBuilder) WhereJSONContains(column string, value interface{}) *ConditionBuilder {
+	return b.whereJSON(func(dialect JSONDialect) (string, []interface{}, error) {
+		return dialect.JSONContains(column, value)
+	})
+}
+
+/**
+ * 追加数组元素条件 ? = ANY(column)，如 WhereArrayAny("tags", "vip")（仅 PostgreSQL）
+ */
+func (b *ConditionBuilder) WhereArrayAny(column string, value interface{}) *ConditionBuilder {
+	return b.whereArray(func(dialect ArrayDialect) (string, []interface{}, error) {
+		return dialect.ArrayAny(column, value)
+	})
+}
+
+/**
+ * 追加数组包含条件 column @> ?，如 WhereArrayContains("tags", []string{"vip", "pvp"})（仅 PostgreSQL）
+ */
+func (b *ConditionBuilder) WhereArrayContains(column string, values interface{}) *ConditionBuilder {
+	return b.whereArray(func(dialect ArrayDialect) (string, []interface{}, error) {
+		return dialect.ArrayContains(column, values)
+	})
+}
+
+/**
+ * 追加数组重叠条件 column && ?，如 WhereArrayOverlaps("tags", []string{"vip", "gm"})（仅 PostgreSQL）
+ */
+func (b *ConditionBuilder) WhereArrayOverlaps(column string, values interface{}) *ConditionBuilder {
+	return b.whereArray(func(dialect ArrayDialect) (string, []interface{}, error) {
+		return dialect.ArrayOverlaps(column, values)
+	})
+}
+
+func (b *ConditionBuilder) whereArray(build func(dialect ArrayDialect) (string, []interface{}, error)) *ConditionBuilder {
+	if b.err != nil {
+		return b
+	}
+	dialect, ok := b.dialect.(ArrayDialect)
+	if !ok {
+		b.err = NewConfigurationException(fmt.Sprintf("数据库类型 %s 不支持数组查询", b.dialect.GetDatabaseType()))
+		return b
+	}
+	condition, params, err := build(dialect)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	return b.Where(condition, params...)
+}
+
+func (b *ConditionBuilder) whereJSON(build func(dialect JSONDialect) (string, []interface{}, error)) *ConditionBuilder {
+	if b.err != nil {
+		return b
+	}
+	dialect, ok := b.dialect.(JSONDialect)
+	if !ok {
+		b.err = NewConfigurationException(fmt.Sprintf("数据库类型 %s 不支持 JSON 查询", b.dialect.GetDatabaseType()))
+		return b
+	}
+	condition, params, err := build(dialect)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	return b.Where(condition, params...)
+}
+
+/**
+ * 生成条件与参数（没有条件时返回空字符串）
+ */
+func (b *ConditionBuilder) Build() (string, []interface{}, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	if len(b.conditions) == 1 {
+		return b.conditions[0], b.params, nil
+	}
+	parts := make([]string, len(b.conditions))
+	for i, condition := range b.conditions {
+		parts[i] = "(" + condition + ")"
+	}
+	return strings.Join(parts, " AND "), b.params, nil
+}
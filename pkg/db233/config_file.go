@@ -0,0 +1,592 @@
+package db233
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+/**
+ * Db233FileConfig - 配置文件结构
+ *
+ * 支持 yaml / yml / json / toml（按扩展名识别），键名与 json 标签一致（不区分大小写）。
+ * 任意键都可被 DB233_* 环境变量覆盖（见 ConfigEnvName），字符串中的 ${secret:名称} / ${env:变量名}
+ * 引用在加载时解析（见 SecretProvider）：
+ *
+ *   databases:
+ *     main:
+ *       databaseType: mysql
+ *       host: 127.0.0.1
+ *       port: 3306
+ *       maxOpenConns: 100
+ *       connMaxLifetime: 1h
+ *   monitoring:
+ *     slowQueryThreshold: 200ms
+ *       password: ${secret:main_password}
+ *   settings:
+ *     feature.flag: true
+ *
+ * 时长字段支持 "30s"、"1h" 等字符串，数字按纳秒处理（与 encoding/json 一致）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type Db233FileConfig struct {
+	// 数据库连接配置（连接名 -> 配置）
+	Databases map[string]*DbConnectionConfig `json:"databases"`
+
+	// 监控配置
+	Monitoring MonitoringConfig `json:"monitoring"`
+
+	// 其他键值，合并进 ConfigManager
+	Settings map[string]interface{} `json:"settings"`
+}
+
+/**
+ * MonitoringConfig - 监控阈值配置
+ *
+ * 慢查询阈值会自动应用到 BindPerformanceMonitor 绑定的监控器，
+ * 其余项通过 GetFileConfig 或配置变更事件读取
+ */
+type MonitoringConfig struct {
+	SlowQueryThreshold     time.Duration `json:"slowQueryThreshold"`
+	VerySlowQueryThreshold time.Duration `json:"verySlowQueryThreshold"`
+	CollectionInterval     time.Duration `json:"collectionInterval"`
+	HealthCheckTimeout     time.Duration `json:"healthCheckTimeout"`
+	AlertCooldownPeriod    time.Duration `json:"alertCooldownPeriod"`
+}
+
+/**
+ * ConfigChangeEvent - 配置变更事件
+ */
+type ConfigChangeEvent struct {
+	// 配置文件路径
+	Path string
+
+	// 变更的键（点分路径，如 databases.main.maxOpenConns）
+	Key string
+
+	// 旧值，新增时为 nil
+	OldValue interface{}
+
+	// 新值，删除时为 nil
+	NewValue interface{}
+}
+
+/**
+ * 配置变更监听器
+ */
+type ConfigChangeListener func(event ConfigChangeEvent)
+
+/**
+ * 配置文件热加载状态（挂在 ConfigManager 上）
+ */
+type configFileState struct {
+	fileConfig *Db233FileConfig
+	rawConfig  map[string]interface{}
+	listeners  []ConfigChangeListener
+	boundDbs   map[string]*Db
+	monitors   []*PerformanceMonitor
+
+	// 密钥提供者（见 config_secret.go）
+	secretProviders []SecretProvider
+
+	mu sync.RWMutex
+}
+
+/**
+ * 从 yaml / json / toml 文件加载配置
+ *
+ * Settings 中的键值合并进 ConfigManager；已绑定的 Db 与性能监控器立即应用新的连接池大小与阈值；
+ * 与上次加载相比发生变化的键逐个触发 ConfigChangeEvent
+ *
+ * @param path 配置文件路径
+ * @return *Db233FileConfig 解析后的配置
+ */
+func (cm *ConfigManager) LoadFile(path string) (*Db233FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, NewConfigurationException(fmt.Sprintf("读取配置文件失败: %s, 错误=%v", path, err))
+	}
+	return cm.loadFileData(path, data)
+}
+
+/**
+ * 解析并应用配置文件内容
+ */
+func (cm *ConfigManager) loadFileData(path string, data []byte) (*Db233FileConfig, error) {
+	raw, err := parseConfigFile(path, data)
+	if err != nil {
+		return nil, err
+	}
+	if overridden := applyEnvOverrides(raw); len(overridden) > 0 {
+		LogInfo("配置已被环境变量覆盖: %v", overridden)
+	}
+
+	// 解析密钥引用后再解码；变更比较使用解析前的内容，避免密钥进入事件
+	resolved := deepCopyValue(reflect.ValueOf(raw)).Interface().(map[string]interface{})
+	if err := cm.resolveSecretRefsInMap(resolved); err != nil {
+		return nil, err
+	}
+
+	fileConfig := &Db233FileConfig{}
+	if err := decodeConfigValue(resolved, reflect.ValueOf(fileConfig).Elem(), ""); err != nil {
+		return nil, NewConfigurationException(fmt.Sprintf("配置文件 %s 格式错误: %v", path, err))
+	}
+
+	state := cm.getFileState()
+	state.mu.Lock()
+	oldRaw := state.rawConfig
+	state.fileConfig = fileConfig
+	state.rawConfig = raw
+	listeners := append([]ConfigChangeListener(nil), state.listeners...)
+	state.mu.Unlock()
+
+	for key, value := range fileConfig.Settings {
+		cm.Set(key, value)
+	}
+	cm.applyFileConfig(fileConfig)
+
+	// 首次加载不产生变更事件
+	events := make([]ConfigChangeEvent, 0)
+	if oldRaw != nil {
+		events = diffConfigMaps(path, flattenConfigMap("", oldRaw), flattenConfigMap("", raw))
+	}
+	for _, event := range events {
+		if isSensitiveConfigKey(event.Key) {
+			event.OldValue, event.NewValue = "******", "******"
+		}
+		LogInfo("配置已变更: %s, %v -> %v", event.Key, event.OldValue, event.NewValue)
+		for _, listener := range listeners {
+			listener(event)
+		}
+	}
+
+	LogInfo("配置已从文件加载: %s, 数据库数=%d, 变更项=%d", path, len(fileConfig.Databases), len(events))
+	return fileConfig, nil
+}
+
+/**
+ * 获取最近一次加载的配置文件内容（未加载时为 nil）
+ */
+func (cm *ConfigManager) GetFileConfig() *Db233FileConfig {
+	state := cm.getFileState()
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+	return state.fileConfig
+}
+
+/**
+ * 添加配置变更监听器
+ */
+func (cm *ConfigManager) AddChangeListener(listener ConfigChangeListener) {
+	state := cm.getFileState()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.listeners = append(state.listeners, listener)
+}
+
+/**
+ * 绑定 Db，配置文件中同名连接的连接池参数变化时自动应用
+ *
+ * @param name databases 下的连接名
+ * @param db 数据库实例
+ */
+func (cm *ConfigManager) BindDb(name string, db *Db) {
+	state := cm.getFileState()
+	state.mu.Lock()
+	state.boundDbs[name] = db
+	fileConfig := state.fileConfig
+	state.mu.Unlock()
+
+	if fileConfig != nil {
+		applyPoolConfig(name, db, fileConfig.Databases[name])
+	}
+}
+
+/**
+ * 绑定性能监控器，monitoring 下的慢查询阈值变化时自动应用
+ */
+func (cm *ConfigManager) BindPerformanceMonitor(monitor *PerformanceMonitor) {
+	state := cm.getFileState()
+	state.mu.Lock()
+	state.monitors = append(state.monitors, monitor)
+	fileConfig := state.fileConfig
+	state.mu.Unlock()
+
+	if fileConfig != nil {
+		applyMonitoringConfig(monitor, fileConfig.Monitoring)
+	}
+}
+
+/**
+ * 应用配置到已绑定的 Db 与监控器
+ */
+func (cm *ConfigManager) applyFileConfig(fileConfig *Db233FileConfig) {
+	state := cm.getFileState()
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	for name, db := range state.boundDbs {
+		applyPoolConfig(name, db, fileConfig.Databases[name])
+	}
+	for _, monitor := range state.monitors {
+		applyMonitoringConfig(monitor, fileConfig.Monitoring)
+	}
+}
+
+/**
+ * 获取配置文件状态（懒初始化）
+ */
+func (cm *ConfigManager) getFileState() *configFileState {
+	cm.fileStateOnce.Do(func() {
+		cm.fileState = &configFileState{boundDbs: make(map[string]*Db)}
+	})
+	return cm.fileState
+}
+
+/**
+ * 应用连接池参数（零值表示不修改）
+ */
+func applyPoolConfig(name string, db *Db, config *DbConnectionConfig) {
+	if db == nil || db.DataSource == nil || config == nil {
+		return
+	}
+	if config.MaxOpenConns > 0 {
+		db.DataSource.SetMaxOpenConns(config.MaxOpenConns)
+	}
+	if config.MaxIdleConns > 0 {
+		db.DataSource.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		db.DataSource.SetConnMaxLifetime(config.ConnMaxLifetime)
+	}
+	if config.ConnMaxIdleTime > 0 {
+		db.DataSource.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	}
+	LogDebug("连接池参数已应用: 连接=%s, MaxOpenConns=%d, MaxIdleConns=%d", name, config.MaxOpenConns, config.MaxIdleConns)
+}
+
+/**
+ * 应用监控阈值（零值表示不修改）
+ */
+func applyMonitoringConfig(monitor *PerformanceMonitor, config MonitoringConfig) {
+	if config.SlowQueryThreshold > 0 {
+		monitor.SetSlowQueryThreshold(config.SlowQueryThreshold)
+	}
+	if config.VerySlowQueryThreshold > 0 {
+		monitor.SetVerySlowQueryThreshold(config.VerySlowQueryThreshold)
+	}
+}
+
+/**
+ * ConfigFileWatcher - 配置文件监视器（轮询文件内容，变化时热加载）
+ */
+type ConfigFileWatcher struct {
+	manager  *ConfigManager
+	path     string
+	interval time.Duration
+	lastHash [sha256.Size]byte
+	stopChan chan bool
+	stopOnce sync.Once
+}
+
+/**
+ * 加载配置文件并开始监视，文件内容变化后自动重新加载
+ *
+ * 重新加载失败（如文件写到一半）时保留上一次的配置并记录错误
+ *
+ * @param path 配置文件路径
+ * @param interval 轮询间隔
+ * @return *ConfigFileWatcher 监视器，调用 Stop 停止
+ */
+func (cm *ConfigManager) WatchFile(path string, interval time.Duration) (*ConfigFileWatcher, error) {
+	if interval <= 0 {
+		return nil, NewValidationException("配置文件轮询间隔必须大于 0")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, NewConfigurationException(fmt.Sprintf("读取配置文件失败: %s, 错误=%v", path, err))
+	}
+	if _, err := cm.loadFileData(path, data); err != nil {
+		return nil, err
+	}
+
+	watcher := &ConfigFileWatcher{
+		manager:  cm,
+		path:     path,
+		interval: interval,
+		lastHash: sha256.Sum256(data),
+		stopChan: make(chan bool),
+	}
+	goSupervised("config_file_watcher:"+path, watcher.stopChan, watcher.run)
+	LogInfo("配置文件监视已启动: %s, 轮询间隔=%v", path, interval)
+	return watcher, nil
+}
+
+/**
+ * 轮询循环
+ */
+func (w *ConfigFileWatcher) run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.checkOnce()
+		case <-w.stopChan:
+			LogInfo("配置文件监视已停止: %s", w.path)
+			return
+		}
+	}
+}
+
+/**
+ * 检查一次文件内容
+ */
+func (w *ConfigFileWatcher) checkOnce() {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		LogWarn("读取配置文件失败，保留当前配置: %s, 错误=%v", w.path, err)
+		return
+	}
+	hash := sha256.Sum256(data)
+	if hash == w.lastHash {
+		return
+	}
+	if _, err := w.manager.loadFileData(w.path, data); err != nil {
+		LogError("配置文件热加载失败，保留当前配置: %v", err)
+		return
+	}
+	w.lastHash = hash
+}
+
+/**
+ * 停止监视
+ */
+func (w *ConfigFileWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopChan)
+	})
+}
+
+/**
+ * 按扩展名解析配置文件为通用 map
+ */
+func parseConfigFile(path string, data []byte) (map[string]interface{}, error) {
+	raw := make(map[string]interface{})
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".json":
+		err = json.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, NewConfigurationException(fmt.Sprintf("不支持的配置文件格式: %s（支持 yaml / yml / json / toml）", path))
+	}
+	if err != nil {
+		return nil, NewConfigurationException(fmt.Sprintf("解析配置文件失败: %s, 错误=%v", path, err))
+	}
+	return raw, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+/**
+ * 把通用值解码到目标字段（结构体按 json 标签匹配，不区分大小写）
+ */
+func decodeConfigValue(src interface{}, dst reflect.Value, path string) error {
+	if src == nil {
+		return nil
+	}
+
+	if dst.Type() == durationType {
+		duration, err := toDuration(src)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		dst.SetInt(int64(duration))
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Ptr:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return decodeConfigValue(src, dst.Elem(), path)
+	case reflect.Struct:
+		values, ok := src.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: 期望对象, 得到 %T", path, src)
+		}
+		for i := 0; i < dst.NumField(); i++ {
+			field := dst.Type().Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			for key, value := range values {
+				if strings.EqualFold(key, name) {
+					if err := decodeConfigValue(value, dst.Field(i), joinConfigKey(path, key)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	case reflect.Map:
+		values, ok := src.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: 期望对象, 得到 %T", path, src)
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMapWithSize(dst.Type(), len(values)))
+		}
+		for key, value := range values {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if err := decodeConfigValue(value, elem, joinConfigKey(path, key)); err != nil {
+				return err
+			}
+			dst.SetMapIndex(reflect.ValueOf(key).Convert(dst.Type().Key()), elem)
+		}
+		return nil
+	case reflect.Interface:
+		dst.Set(reflect.ValueOf(src))
+		return nil
+	case reflect.String:
+		dst.SetString(fmt.Sprint(src))
+		return nil
+	case reflect.Bool:
+		switch v := src.(type) {
+		case bool:
+			dst.SetBool(v)
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("%s: %v", path, err)
+			}
+			dst.SetBool(b)
+		default:
+			return fmt.Errorf("%s: 期望布尔值, 得到 %T", path, src)
+		}
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, err := toFloat(src)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		dst.SetInt(int64(number))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		number, err := toFloat(src)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		dst.SetFloat(number)
+		return nil
+	default:
+		return fmt.Errorf("%s: 不支持的字段类型 %s", path, dst.Type())
+	}
+}
+
+/**
+ * 转换为时长（字符串按 time.ParseDuration 解析，数字按纳秒）
+ */
+func toDuration(src interface{}) (time.Duration, error) {
+	if text, ok := src.(string); ok {
+		return time.ParseDuration(text)
+	}
+	number, err := toFloat(src)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(number), nil
+}
+
+/**
+ * 转换为数字
+ */
+func toFloat(src interface{}) (float64, error) {
+	switch v := src.(type) {
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, fmt.Errorf("期望数字, 得到 %T", src)
+	}
+}
+
+/**
+ * 拼接点分键
+ */
+func joinConfigKey(prefix string, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+/**
+ * 把嵌套 map 展开为点分键
+ */
+func flattenConfigMap(prefix string, values map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+	for key, value := range values {
+		fullKey := joinConfigKey(prefix, key)
+		if nested, ok := value.(map[string]interface{}); ok {
+			for nestedKey, nestedValue := range flattenConfigMap(fullKey, nested) {
+				flat[nestedKey] = nestedValue
+			}
+			continue
+		}
+		flat[fullKey] = value
+	}
+	return flat
+}
+
+/**
+ * 比较两次加载的展开结果，生成按键排序的变更事件
+ */
+func diffConfigMaps(path string, oldValues map[string]interface{}, newValues map[string]interface{}) []ConfigChangeEvent {
+	keys := make([]string, 0, len(newValues))
+	for key := range newValues {
+		keys = append(keys, key)
+	}
+	for key := range oldValues {
+		if _, exists := newValues[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	events := make([]ConfigChangeEvent, 0)
+	for _, key := range keys {
+		oldValue, newValue := oldValues[key], newValues[key]
+		if !reflect.DeepEqual(oldValue, newValue) {
+			events = append(events, ConfigChangeEvent{Path: path, Key: key, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	return events
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 )
 
@@ -19,6 +21,10 @@ import (
 type ConfigManager struct {
 	configs map[string]interface{}
 	mu      sync.RWMutex
+
+	// 配置文件加载与热更新状态（见 config_file.go）
+	fileState     *configFileState
+	fileStateOnce sync.Once
 }
 
 var configManagerInstance *ConfigManager
@@ -69,25 +75,27 @@ func (cm *ConfigManager) LoadFromEnv(prefix string) {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
-	envVars := os.Environ()
-	for _, envVar := range envVars {
-		if len(prefix) > 0 && len(envVar) > len(prefix) && envVar[:len(prefix)] == prefix {
-			// 解析环境变量
-			key := envVar[len(prefix)+1:] // 跳过前缀和等号
-			value := os.Getenv(prefix + "_" + key)
-			if value != "" {
-				cm.configs[key] = value
-			}
+	for _, envVar := range os.Environ() {
+		name, value, found := strings.Cut(envVar, "=")
+		if !found || len(prefix) == 0 || !strings.HasPrefix(name, prefix) || value == "" {
+			continue
 		}
+		// DB233_FEATURE_FLAG -> feature.flag
+		key := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(name, prefix), "_", "."))
+		cm.configs[key] = value
 	}
 
 	LogInfo("配置已从环境变量加载，前缀: %s", prefix)
 }
 
 /**
- * 获取字符串配置值
+ * 获取字符串配置值（DB233_* 环境变量优先，见 ConfigEnvName）
  */
 func (cm *ConfigManager) GetString(key string, defaultValue string) string {
+	if envValue, exists := os.LookupEnv(ConfigEnvName(key)); exists {
+		return envValue
+	}
+
 	cm.mu.RLock()
 	defer cm.mu.RUnlock()
 
@@ -103,6 +111,13 @@ func (cm *ConfigManager) GetString(key string, defaultValue string) string {
  * 获取整数配置值
  */
 func (cm *ConfigManager) GetInt(key string, defaultValue int) int {
+	if envValue, exists := os.LookupEnv(ConfigEnvName(key)); exists {
+		if parsed, err := strconv.Atoi(strings.TrimSpace(envValue)); err == nil {
+			return parsed
+		}
+		LogWarn("环境变量 %s 不是整数，已忽略", ConfigEnvName(key))
+	}
+
 	cm.mu.RLock()
 	defer cm.mu.RUnlock()
 
@@ -123,6 +138,13 @@ func (cm *ConfigManager) GetInt(key string, defaultValue int) int {
  * 获取布尔配置值
  */
 func (cm *ConfigManager) GetBool(key string, defaultValue bool) bool {
+	if envValue, exists := os.LookupEnv(ConfigEnvName(key)); exists {
+		if parsed, err := strconv.ParseBool(strings.TrimSpace(envValue)); err == nil {
+			return parsed
+		}
+		LogWarn("环境变量 %s 不是布尔值，已忽略", ConfigEnvName(key))
+	}
+
 	cm.mu.RLock()
 	defer cm.mu.RUnlock()
 
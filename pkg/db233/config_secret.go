@@ -0,0 +1,323 @@
+package db233
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+/**
+ * SecretProvider - 密钥提供者
+ *
+ * 配置中的 ${secret:名称} 引用（配置文件中的任意字符串、DbConnectionConfig.Password）
+ * 在使用时通过已注册的提供者解析，密码不需要写在代码或明文配置里：
+ *
+ *   cm := db233.GetConfigManager()
+ *   cm.AddSecretProvider(db233.NewFileSecretProvider("/run/secrets"))  // Docker / K8s secret 挂载目录
+ *   cm.AddSecretProvider(db233.NewEnvSecretProvider("DB233_SECRET_"))  // DB233_SECRET_MAIN_PASSWORD
+ *
+ *   config.Password = "${secret:main_password}"
+ *
+ * 接入 Vault、AWS Secrets Manager 等只需实现该接口
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type SecretProvider interface {
+	/**
+	 * 获取提供者名称
+	 */
+	GetName() string
+
+	/**
+	 * 获取密钥
+	 *
+	 * @param name 密钥名称
+	 * @return string 密钥值
+	 * @return bool 是否存在（不存在时尝试下一个提供者）
+	 * @return error 读取错误
+	 */
+	GetSecret(name string) (string, bool, error)
+}
+
+/**
+ * EnvSecretProvider - 从环境变量读取密钥
+ *
+ * 名称转换为大写、非字母数字转为下划线后加前缀，如 main.password -> DB233_SECRET_MAIN_PASSWORD
+ */
+type EnvSecretProvider struct {
+	prefix string
+}
+
+/**
+ * 创建环境变量密钥提供者
+ */
+func NewEnvSecretProvider(prefix string) *EnvSecretProvider {
+	return &EnvSecretProvider{prefix: prefix}
+}
+
+/**
+ * 获取提供者名称
+ */
+func (p *EnvSecretProvider) GetName() string {
+	return "env"
+}
+
+/**
+ * 获取密钥
+ */
+func (p *EnvSecretProvider) GetSecret(name string) (string, bool, error) {
+	value, exists := os.LookupEnv(p.prefix + toEnvName(name))
+	return value, exists, nil
+}
+
+/**
+ * FileSecretProvider - 从目录下的文件读取密钥（文件名即密钥名，去掉末尾换行）
+ */
+type FileSecretProvider struct {
+	dir string
+}
+
+/**
+ * 创建文件密钥提供者
+ *
+ * @param dir 密钥目录，如 /run/secrets
+ */
+func NewFileSecretProvider(dir string) *FileSecretProvider {
+	return &FileSecretProvider{dir: dir}
+}
+
+/**
+ * 获取提供者名称
+ */
+func (p *FileSecretProvider) GetName() string {
+	return "file"
+}
+
+/**
+ * 获取密钥
+ */
+func (p *FileSecretProvider) GetSecret(name string) (string, bool, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", false, fmt.Errorf("非法的密钥名称: %q", name)
+	}
+	data, err := os.ReadFile(filepath.Join(p.dir, name))
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+/**
+ * 注册密钥提供者（按注册顺序查找）
+ */
+func (cm *ConfigManager) AddSecretProvider(provider SecretProvider) {
+	state := cm.getFileState()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.secretProviders = append(state.secretProviders, provider)
+	LogInfo("密钥提供者已注册: %s", provider.GetName())
+}
+
+/**
+ * 获取密钥（依次询问已注册的提供者）
+ */
+func (cm *ConfigManager) GetSecret(name string) (string, error) {
+	state := cm.getFileState()
+	state.mu.RLock()
+	providers := append([]SecretProvider(nil), state.secretProviders...)
+	state.mu.RUnlock()
+
+	for _, provider := range providers {
+		value, exists, err := provider.GetSecret(name)
+		if err != nil {
+			return "", NewConfigurationException(fmt.Sprintf("密钥提供者 %s 读取 %s 失败: %v", provider.GetName(), name, err))
+		}
+		if exists {
+			return value, nil
+		}
+	}
+	return "", NewConfigurationException(fmt.Sprintf("未找到密钥: %s（已注册提供者 %d 个）", name, len(providers)))
+}
+
+var secretRefPattern = regexp.MustCompile(`\$\{(secret|env):([^}]+)\}`)
+
+/**
+ * 解析字符串中的 ${secret:名称} 与 ${env:变量名} 引用，没有引用时原样返回
+ */
+func (cm *ConfigManager) ResolveSecretRefs(value string) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+
+	var resolveErr error
+	resolved := secretRefPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		match := secretRefPattern.FindStringSubmatch(ref)
+		if match[1] == "env" {
+			envValue, exists := os.LookupEnv(match[2])
+			if !exists && resolveErr == nil {
+				resolveErr = NewConfigurationException(fmt.Sprintf("环境变量未设置: %s", match[2]))
+			}
+			return envValue
+		}
+		secret, err := cm.GetSecret(match[2])
+		if err != nil && resolveErr == nil {
+			resolveErr = err
+		}
+		return secret
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return resolved, nil
+}
+
+/**
+ * 解析通用配置中所有字符串值的密钥引用
+ */
+func (cm *ConfigManager) resolveSecretRefsInMap(values map[string]interface{}) error {
+	for key, value := range values {
+		switch v := value.(type) {
+		case string:
+			resolved, err := cm.ResolveSecretRefs(v)
+			if err != nil {
+				return err
+			}
+			values[key] = resolved
+		case map[string]interface{}:
+			if err := cm.resolveSecretRefsInMap(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/**
+ * 环境变量覆盖的前缀（DB233_DATABASES_MAIN_PASSWORD 覆盖 databases.main.password）
+ */
+const ConfigEnvPrefix = "DB233_"
+
+/**
+ * 配置键对应的环境变量名，如 databases.main.maxOpenConns -> DB233_DATABASES_MAIN_MAX_OPEN_CONNS
+ */
+func ConfigEnvName(key string) string {
+	return ConfigEnvPrefix + toEnvName(key)
+}
+
+/**
+ * 转换为环境变量风格：驼峰拆分、非字母数字转下划线、大写
+ */
+func toEnvName(key string) string {
+	var builder strings.Builder
+	runes := []rune(key)
+	for i, r := range runes {
+		switch {
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])):
+			builder.WriteByte('_')
+			builder.WriteRune(r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			builder.WriteRune(unicode.ToUpper(r))
+		default:
+			builder.WriteByte('_')
+		}
+	}
+	return builder.String()
+}
+
+/**
+ * 用 DB233_* 环境变量覆盖配置文件中的值
+ *
+ * 候选键为文件中已有的键，以及每个数据库连接、监控配置的全部字段（文件中未写也可由环境变量提供）
+ */
+func applyEnvOverrides(raw map[string]interface{}) []string {
+	candidates := flattenConfigMap("", raw)
+	if databases, ok := raw["databases"].(map[string]interface{}); ok {
+		for name := range databases {
+			for _, field := range configFieldNames(DbConnectionConfig{}) {
+				candidates[joinConfigKey("databases."+name, field)] = nil
+			}
+		}
+	}
+	for _, field := range configFieldNames(MonitoringConfig{}) {
+		candidates[joinConfigKey("monitoring", field)] = nil
+	}
+
+	overridden := make([]string, 0)
+	for key := range candidates {
+		value, exists := os.LookupEnv(ConfigEnvName(key))
+		if !exists {
+			continue
+		}
+		setNestedConfigValue(raw, strings.Split(key, "."), value)
+		overridden = append(overridden, key)
+	}
+	return overridden
+}
+
+/**
+ * 获取结构体的 json 字段名
+ */
+func configFieldNames(value interface{}) []string {
+	t := reflect.TypeOf(value)
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+/**
+ * 按路径设置嵌套 map 的值
+ *
+ * 路径段与已有键不区分大小写匹配，并优先匹配本身含点的键（如 settings 下的 feature.flag）
+ */
+func setNestedConfigValue(values map[string]interface{}, path []string, value interface{}) {
+	for k := len(path); k >= 1; k-- {
+		candidate := strings.Join(path[:k], ".")
+		for existing := range values {
+			if !strings.EqualFold(existing, candidate) {
+				continue
+			}
+			if k == len(path) {
+				values[existing] = value
+				return
+			}
+			if nested, ok := values[existing].(map[string]interface{}); ok {
+				setNestedConfigValue(nested, path[k:], value)
+				return
+			}
+		}
+	}
+
+	if len(path) == 1 {
+		values[path[0]] = value
+		return
+	}
+	nested := make(map[string]interface{})
+	values[path[0]] = nested
+	setNestedConfigValue(nested, path[1:], value)
+}
+
+/**
+ * 是否为敏感配置键（日志与变更事件中隐藏值）
+ */
+func isSensitiveConfigKey(key string) bool {
+	lower := strings.ToLower(key)
+	for _, word := range []string{"password", "secret", "token", "credential"} {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -26,6 +26,11 @@ type ConnectionPoolMonitor struct {
 	maxConnections     int64
 	minConnections     int64
 
+	// 连接池事件计数（见 OnPoolEvent）
+	connectionsCreated int64
+	connectionsClosed  int64
+	maxReachedCount    int64
+
 	// 性能指标
 	connectionWaitTime time.Duration
 	queryExecutionTime time.Duration
@@ -161,6 +166,66 @@ func (cpm *ConnectionPoolMonitor) UpdatePoolStats(total, active, idle, waiting,
 	cpm.minConnections = min
 }
 
+/**
+ * 处理连接池事件（实现 PoolEventListener 接口）
+ *
+ * 采样事件更新连接数，包装层的等待事件维护正在等待的连接数，不再需要手动调用 UpdatePoolStats
+ */
+func (cpm *ConnectionPoolMonitor) OnPoolEvent(event PoolEvent) {
+	if !cpm.enabled {
+		return
+	}
+
+	switch event.Type {
+	case PoolEventStatsSampled:
+		cpm.mu.RLock()
+		waiting, min := cpm.waitingConnections, cpm.minConnections
+		cpm.mu.RUnlock()
+		stats := event.Stats
+		cpm.UpdatePoolStats(int64(stats.OpenConnections), int64(stats.InUse), int64(stats.Idle), waiting, int64(stats.MaxOpenConnections), min)
+		return
+	case PoolEventWaitEnded:
+		cpm.mu.Lock()
+		cpm.connectionWaitTime += event.WaitDuration
+		cpm.mu.Unlock()
+	case PoolEventMaxReached:
+		LogWarn("连接池已满: %s, 连接数: %d", cpm.dbGroupName, event.Count)
+	}
+
+	cpm.mu.Lock()
+	defer cpm.mu.Unlock()
+
+	switch event.Type {
+	case PoolEventConnectionCreated:
+		cpm.connectionsCreated += event.Count
+	case PoolEventConnectionClosed:
+		cpm.connectionsClosed += event.Count
+	case PoolEventMaxReached:
+		cpm.maxReachedCount++
+	case PoolEventWaitStarted:
+		if event.Source == "wrapper" {
+			cpm.waitingConnections++
+		}
+	case PoolEventWaitEnded:
+		if event.Source == "wrapper" && cpm.waitingConnections > 0 {
+			cpm.waitingConnections--
+		}
+	}
+}
+
+/**
+ * 创建并启动连接池事件采样，监控器作为监听器自动更新统计（需要创建监控器时传入 Db）
+ */
+func (cpm *ConnectionPoolMonitor) StartPoolSampling(interval time.Duration) *PoolEventSampler {
+	if cpm.db == nil {
+		LogWarn("连接池监控未关联 Db，无法采样: %s", cpm.dbGroupName)
+		return nil
+	}
+	sampler := NewPoolEventSampler(cpm.dbGroupName, cpm.db, interval).AddListener(cpm)
+	sampler.Start()
+	return sampler
+}
+
 /**
  * 获取监控报告
  */
@@ -178,6 +243,9 @@ func (cpm *ConnectionPoolMonitor) GetReport() map[string]interface{} {
 	report["waiting_connections"] = cpm.waitingConnections
 	report["max_connections"] = cpm.maxConnections
 	report["min_connections"] = cpm.minConnections
+	report["connections_created"] = cpm.connectionsCreated
+	report["connections_closed"] = cpm.connectionsClosed
+	report["max_reached_count"] = cpm.maxReachedCount
 
 	// 性能指标
 	report["total_queries"] = cpm.totalQueries
@@ -196,6 +264,13 @@ func (cpm *ConnectionPoolMonitor) GetReport() map[string]interface{} {
 
 	report["enabled"] = cpm.enabled
 
+	// 按优先级分类的连接等待时间
+	if cpm.db != nil {
+		if gate := cpm.db.GetPriorityGate(); gate != nil {
+			report["priority_wait"] = gate.GetWaitStats()
+		}
+	}
+
 	return report
 }
 
@@ -211,6 +286,9 @@ func (cpm *ConnectionPoolMonitor) Reset() {
 	cpm.totalQueries = 0
 	cpm.failedQueries = 0
 	cpm.slowQueries = 0
+	cpm.connectionsCreated = 0
+	cpm.connectionsClosed = 0
+	cpm.maxReachedCount = 0
 
 	LogInfo("连接池监控统计已重置: %s", cpm.dbGroupName)
 }
@@ -239,6 +317,9 @@ func (cpm *ConnectionPoolMonitor) GetMetrics() map[string]interface{} {
 	if val, ok := report["max_connections"].(int64); ok {
 		metrics["max_connections"] = val
 	}
+	for _, key := range []string{"connections_created", "connections_closed", "max_reached_count"} {
+		metrics[key] = report[key]
+	}
 
 	// 性能指标
 	if val, ok := report["total_queries"].(int64); ok {
@@ -264,6 +345,15 @@ func (cpm *ConnectionPoolMonitor) GetMetrics() map[string]interface{} {
 		}
 	}
 
+	if priorityWait, ok := report["priority_wait"].(map[string]map[string]interface{}); ok {
+		for priority, stats := range priorityWait {
+			if avgWait, err := time.ParseDuration(fmt.Sprint(stats["avg_wait"])); err == nil {
+				metrics[fmt.Sprintf("priority_%s_avg_wait_ms", priority)] = float64(avgWait.Nanoseconds()) / 1000000.0
+			}
+			metrics[fmt.Sprintf("priority_%s_waiting", priority)] = stats["waiting"]
+		}
+	}
+
 	// 计算连接利用率
 	if total, ok := report["total_connections"].(int64); ok && total > 0 {
 		if active, ok := report["active_connections"].(int64); ok {
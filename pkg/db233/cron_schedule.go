@@ -0,0 +1,197 @@
+package db233
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+ * CronSchedule - 类 cron 调度表达式
+ *
+ * 支持标准 5 段格式：分 时 日 月 周（周日为 0）
+ * 每段支持 *、数字、列表（1,2）、范围（1-5）、步长（0-30/10；星号加 /15 表示每 15 个单位）
+ * 另支持 @hourly / @daily / @weekly / @monthly 及 @every <duration>（如 @every 10m）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type CronSchedule struct {
+	expression string
+
+	minutes  map[int]bool
+	hours    map[int]bool
+	days     map[int]bool
+	months   map[int]bool
+	weekdays map[int]bool
+
+	// 日、周是否都做了限制（都限制时按 cron 惯例取并集）
+	daysRestricted     bool
+	weekdaysRestricted bool
+
+	// @every 固定间隔，> 0 时忽略其他字段
+	every time.Duration
+}
+
+/**
+ * cron 字段定义
+ */
+type cronField struct {
+	name string
+	min  int
+	max  int
+}
+
+var cronFields = []cronField{
+	{"分钟", 0, 59},
+	{"小时", 0, 23},
+	{"日", 1, 31},
+	{"月", 1, 12},
+	{"周", 0, 6},
+}
+
+var cronMacros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+/**
+ * 解析 cron 表达式
+ */
+func ParseCronExpression(expression string) (*CronSchedule, error) {
+	expr := strings.TrimSpace(expression)
+	if strings.HasPrefix(expr, "@every ") {
+		every, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil || every <= 0 {
+			return nil, NewValidationException(fmt.Sprintf("无效的 @every 间隔: %s", expression))
+		}
+		return &CronSchedule{expression: expression, every: every}, nil
+	}
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, NewValidationException(fmt.Sprintf("cron 表达式需要 5 段（分 时 日 月 周）: %s", expression))
+	}
+
+	values := make([]map[int]bool, len(cronFields))
+	for i, part := range parts {
+		set, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, NewValidationExceptionWithCause(err, fmt.Sprintf("无效的 cron 表达式: %s", expression))
+		}
+		values[i] = set
+	}
+
+	return &CronSchedule{
+		expression:         expression,
+		minutes:            values[0],
+		hours:              values[1],
+		days:               values[2],
+		months:             values[3],
+		weekdays:           values[4],
+		daysRestricted:     parts[2] != "*",
+		weekdaysRestricted: parts[4] != "*",
+	}, nil
+}
+
+/**
+ * 解析单个字段
+ */
+func parseCronField(part string, field cronField) (map[int]bool, error) {
+	set := make(map[int]bool)
+	for _, item := range strings.Split(part, ",") {
+		step := 1
+		if idx := strings.Index(item, "/"); idx >= 0 {
+			s, err := strconv.Atoi(item[idx+1:])
+			if err != nil || s <= 0 {
+				return nil, fmt.Errorf("%s字段步长无效: %s", field.name, item)
+			}
+			step = s
+			item = item[:idx]
+		}
+
+		low, high := field.min, field.max
+		if item != "*" {
+			bounds := strings.SplitN(item, "-", 2)
+			var err error
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("%s字段无效: %s", field.name, item)
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("%s字段无效: %s", field.name, item)
+				}
+			} else if step > 1 {
+				// 形如 5/15：从 5 开始到最大值
+				high = field.max
+			}
+		}
+		if low < field.min || high > field.max || low > high {
+			return nil, fmt.Errorf("%s字段超出范围 [%d, %d]: %s", field.name, field.min, field.max, item)
+		}
+
+		for v := low; v <= high; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+/**
+ * 获取原始表达式
+ */
+func (cs *CronSchedule) String() string {
+	return cs.expression
+}
+
+/**
+ * 计算 after 之后的下一次触发时间（精度到分钟，@every 除外）
+ */
+func (cs *CronSchedule) Next(after time.Time) time.Time {
+	if cs.every > 0 {
+		return after.Add(cs.every)
+	}
+
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	// 最多向后查找 5 年，避免不可能的表达式（如 2 月 31 日）死循环
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !cs.months[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !cs.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !cs.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !cs.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+/**
+ * 判断日期是否匹配日/周字段
+ */
+func (cs *CronSchedule) matchDay(t time.Time) bool {
+	dayMatch := cs.days[t.Day()]
+	weekdayMatch := cs.weekdays[int(t.Weekday())]
+	if cs.daysRestricted && cs.weekdaysRestricted {
+		return dayMatch || weekdayMatch
+	}
+	return dayMatch && weekdayMatch
+}
@@ -0,0 +1,258 @@
+package db233
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+/**
+ * 条件计数、存在性判断与聚合
+ *
+ * 简单的统计无需再手写原生 SQL：
+ *
+ *   count, err := repo.CountByCondition(&Order{}, "status = ?", []interface{}{"paid"})
+ *   exists, err := repo.ExistsById(1001, &User{})
+ *   total, err := repo.SumColumn(&Order{}, "amount", "user_id = ?", []interface{}{uid})
+ *   latest, err := repo.MaxColumn(&Order{}, "CreatedAt", "", nil)
+ *
+ * 条件与 FindByCondition 一样经过 SQL 白名单检查，并与作用域、行级安全策略谓词合并；
+ * 条件为空时统计全表（仍受作用域约束）。聚合列只允许实体已映射的列名或结构体字段名
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+
+/**
+ * 按条件统计记录数
+ *
+ * @param entityType 实体类型
+ * @param condition WHERE 条件（占位符 ?），为空时统计全表
+ * @param params 条件参数
+ * @return int64 记录数
+ */
+func (r *BaseCrudRepository) CountByCondition(entityType IDbEntity, condition string, params []interface{}) (int64, error) {
+	var count int64
+	if err := r.queryAggregate(entityType, "COUNT(*)", condition, params, &count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+/**
+ * 按主键判断记录是否存在（支持复合主键，传参同 FindById）
+ *
+ * @param id 主键值
+ * @param entityType 实体类型
+ */
+func (r *BaseCrudRepository) ExistsById(id interface{}, entityType IDbEntity) (bool, error) {
+	if entityType == nil {
+		return false, NewValidationException("实体类型不能为 nil")
+	}
+	if id == nil {
+		return false, NewValidationException("查询ID不能为 nil")
+	}
+	tableName := r.getTableName(entityType)
+	if tableName == "" {
+		return false, NewValidationException("无法获取表名，请确保实体实现了 TableName() 方法并返回非空字符串")
+	}
+
+	pkCondition, pkParams, err := r.buildPrimaryKeyCondition(id, entityType)
+	if err != nil {
+		return false, err
+	}
+	where, params, err := r.applyScope(entityType, pkCondition, pkParams)
+	if err != nil {
+		return false, err
+	}
+	return r.queryExists(tableName, where, params)
+}
+
+/**
+ * 判断是否存在满足条件的记录（找到第一行即返回，比 CountByCondition > 0 更省）
+ *
+ * @param entityType 实体类型
+ * @param condition WHERE 条件（占位符 ?），为空时判断表中是否有记录
+ * @param params 条件参数
+ */
+func (r *BaseCrudRepository) ExistsByCondition(entityType IDbEntity, condition string, params []interface{}) (bool, error) {
+	tableName, where, whereParams, err := r.prepareAggregate(entityType, condition, params)
+	if err != nil {
+		return false, err
+	}
+	return r.queryExists(tableName, where, whereParams)
+}
+
+/**
+ * 对列求和，没有匹配行时返回 0
+ *
+ * @param entityType 实体类型
+ * @param column 列名或结构体字段名
+ * @param condition WHERE 条件，为空时统计全表
+ * @param params 条件参数
+ */
+func (r *BaseCrudRepository) SumColumn(entityType IDbEntity, column string, condition string, params []interface{}) (float64, error) {
+	return r.aggregateFloat(entityType, "SUM", column, condition, params)
+}
+
+/**
+ * 对列求平均值，没有匹配行时返回 0
+ *
+ * @param entityType 实体类型
+ * @param column 列名或结构体字段名
+ * @param condition WHERE 条件，为空时统计全表
+ * @param params 条件参数
+ */
+func (r *BaseCrudRepository) AvgColumn(entityType IDbEntity, column string, condition string, params []interface{}) (float64, error) {
+	return r.aggregateFloat(entityType, "AVG", column, condition, params)
+}
+
+/**
+ * 求列最大值，没有匹配行时返回 nil
+ *
+ * 返回值类型取决于驱动（如 int64、float64、time.Time），MySQL 驱动返回的 []byte 会转换为 string
+ *
+ * @param entityType 实体类型
+ * @param column 列名或结构体字段名
+ * @param condition WHERE 条件，为空时统计全表
+ * @param params 条件参数
+ */
+func (r *BaseCrudRepository) MaxColumn(entityType IDbEntity, column string, condition string, params []interface{}) (interface{}, error) {
+	return r.aggregateValue(entityType, "MAX", column, condition, params)
+}
+
+/**
+ * 求列最小值，没有匹配行时返回 nil（返回值类型同 MaxColumn）
+ *
+ * @param entityType 实体类型
+ * @param column 列名或结构体字段名
+ * @param condition WHERE 条件，为空时统计全表
+ * @param params 条件参数
+ */
+func (r *BaseCrudRepository) MinColumn(entityType IDbEntity, column string, condition string, params []interface{}) (interface{}, error) {
+	return r.aggregateValue(entityType, "MIN", column, condition, params)
+}
+
+/**
+ * 执行返回浮点数的聚合
+ */
+func (r *BaseCrudRepository) aggregateFloat(entityType IDbEntity, function string, column string, condition string, params []interface{}) (float64, error) {
+	resolved, err := r.resolveEntityColumn(entityType, column)
+	if err != nil {
+		return 0, err
+	}
+	var value sql.NullFloat64
+	if err := r.queryAggregate(entityType, function+"("+resolved+")", condition, params, &value); err != nil {
+		return 0, err
+	}
+	return value.Float64, nil
+}
+
+/**
+ * 执行保留原始类型的聚合
+ */
+func (r *BaseCrudRepository) aggregateValue(entityType IDbEntity, function string, column string, condition string, params []interface{}) (interface{}, error) {
+	resolved, err := r.resolveEntityColumn(entityType, column)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := r.queryAggregate(entityType, function+"("+resolved+")", condition, params, &value); err != nil {
+		return nil, err
+	}
+	if bytes, ok := value.([]byte); ok {
+		return string(bytes), nil
+	}
+	return value, nil
+}
+
+/**
+ * 执行 SELECT <expression> FROM ... WHERE ... 并扫描单个结果
+ */
+func (r *BaseCrudRepository) queryAggregate(entityType IDbEntity, expression string, condition string, params []interface{}, dest interface{}) error {
+	tableName, where, whereParams, err := r.prepareAggregate(entityType, condition, params)
+	if err != nil {
+		return err
+	}
+
+	sql := "SELECT " + expression + " FROM " + tableName
+	if where != "" {
+		sql += " WHERE " + where
+	}
+	LogDebug("执行聚合查询: 表=%s, 条件=%s, SQL=%s", tableName, condition, sql)
+
+	if err := r.db.executeScalarOnce(sql, whereParams, r.db.statementTimeout(), dest); err != nil {
+		LogError("聚合查询失败: 表=%s, 错误=%v, SQL=%s", tableName, err, sql)
+		return NewQueryExceptionWithCause(err, fmt.Sprintf("统计表 %s 失败: %s", tableName, expression))
+	}
+	return nil
+}
+
+/**
+ * 执行 SELECT 1 ... LIMIT 1 判断是否存在
+ */
+func (r *BaseCrudRepository) queryExists(tableName string, where string, params []interface{}) (bool, error) {
+	query := "SELECT 1 FROM " + tableName
+	if where != "" {
+		query += " WHERE " + where
+	}
+	query = r.db.GetSqlDialect().ApplyLimitOffset(query, 1, 0)
+	LogDebug("执行存在性查询: 表=%s, SQL=%s", tableName, query)
+
+	var one int
+	err := r.db.executeScalarOnce(query, params, r.db.statementTimeout(), &one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		LogError("存在性查询失败: 表=%s, 错误=%v, SQL=%s", tableName, err, query)
+		return false, NewQueryExceptionWithCause(err, fmt.Sprintf("查询表 %s 的记录是否存在失败", tableName))
+	}
+	return true, nil
+}
+
+/**
+ * 校验条件并与作用域合并，返回表名、WHERE 子句与参数
+ */
+func (r *BaseCrudRepository) prepareAggregate(entityType IDbEntity, condition string, params []interface{}) (string, string, []interface{}, error) {
+	if entityType == nil {
+		return "", "", nil, NewValidationException("实体类型不能为 nil")
+	}
+	tableName := r.getTableName(entityType)
+	if tableName == "" {
+		return "", "", nil, NewValidationException("无法获取表名，请确保实体实现了 TableName() 方法并返回非空字符串")
+	}
+
+	if condition != "" {
+		// 条件由调用方提供，属于原生 SQL，需经过白名单检查
+		if err := r.db.checkQueryAllowed(condition); err != nil {
+			return "", "", nil, err
+		}
+		condition = "(" + condition + ")"
+	}
+	where, whereParams, err := r.applyScope(entityType, condition, params)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return tableName, where, whereParams, nil
+}
+
+/**
+ * 把列名或结构体字段名解析为已映射的列名，避免拼入任意 SQL
+ */
+func (r *BaseCrudRepository) resolveEntityColumn(entityType IDbEntity, column string) (string, error) {
+	if entityType == nil {
+		return "", NewValidationException("实体类型不能为 nil")
+	}
+	metadata, err := GetEntityMetadataCacheInstance().GetOrBuild(entityType)
+	if err != nil {
+		return "", err
+	}
+	if containsColumn(metadata.AllColumns, column) {
+		return column, nil
+	}
+	if resolved := metadata.FieldNameToColumn[column]; resolved != "" && containsColumn(metadata.AllColumns, resolved) {
+		return resolved, nil
+	}
+	return "", NewValidationException(fmt.Sprintf("实体 %T 没有列或字段 %s", entityType, column))
+}
@@ -0,0 +1,167 @@
+package db233
+
+import (
+	"fmt"
+	"sort"
+)
+
+/**
+ * 按条件批量更新 / 删除
+ *
+ * 批量修改状态时无需先把所有实体加载到内存：
+ *
+ *   affected, err := repo.UpdateWhere(&Order{}, map[string]interface{}{"status": "expired"},
+ *       "status = ? AND created_at < ?", []interface{}{"pending", deadline})
+ *   affected, err = repo.DeleteWhere(&Session{}, "expire_at < ?", []interface{}{time.Now()})
+ *
+ * 条件与 FindByCondition 一样经过 SQL 白名单检查，并与作用域、行级安全策略谓词合并；
+ * 条件不能为空（确实需要全表操作时显式传 "1 = 1"）。不调用实体生命周期钩子
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+
+/**
+ * 按条件批量更新
+ *
+ * @param entityType 实体类型
+ * @param setMap 要更新的列（列名或结构体字段名）及新值
+ * @param condition WHERE 条件（占位符 ?）
+ * @param params 条件参数
+ * @return int64 影响行数
+ */
+func (r *BaseCrudRepository) UpdateWhere(entityType IDbEntity, setMap map[string]interface{}, condition string, params []interface{}) (int64, error) {
+	if err := r.checkWritable("UpdateWhere", entityType); err != nil {
+		return 0, err
+	}
+	tableName, err := r.prepareBulk(entityType, condition)
+	if err != nil {
+		return 0, err
+	}
+	if len(setMap) == 0 {
+		return 0, NewValidationException("UpdateWhere 至少需要更新一列")
+	}
+
+	fields, err := r.resolveSetColumns(entityType, setMap)
+	if err != nil {
+		return 0, err
+	}
+	// 作用域的强制列不允许被改成其他值
+	if r.scope != nil {
+		for column, value := range r.scope.ForcedColumns {
+			if _, exists := fields[column]; exists {
+				fields[column] = value
+			}
+		}
+	}
+	if err := GetFieldEncryptionManagerInstance().encryptFields(entityType, fields); err != nil {
+		return 0, err
+	}
+
+	columns := make([]string, 0, len(fields))
+	for column := range fields {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	setParts := make([]string, len(columns))
+	values := make([]interface{}, 0, len(columns)+len(params))
+	for i, column := range columns {
+		setParts[i] = column + " = ?"
+		values = append(values, fields[column])
+	}
+
+	where, whereParams, err := r.applyScope(entityType, "("+condition+")", params)
+	if err != nil {
+		return 0, err
+	}
+	values = append(values, whereParams...)
+
+	sql := "UPDATE " + tableName + " SET " + StringUtilsInstance.Join(setParts, ", ") + " WHERE " + where
+	LogDebug("执行条件更新: 表=%s, 条件=%s, 更新字段数=%d, SQL=%s", tableName, condition, len(setParts), sql)
+
+	affected, err := r.db.executeUpdateOnce(sql, values, r.db.statementTimeout())
+	if err != nil {
+		LogError("条件更新失败: 表=%s, 错误=%v, SQL=%s", tableName, err, sql)
+		return 0, NewQueryExceptionWithCause(err, fmt.Sprintf("按条件更新表 %s 失败", tableName))
+	}
+	LogDebug("条件更新完成: 表=%s, 影响行数=%d", tableName, affected)
+	return affected, nil
+}
+
+/**
+ * 按条件批量删除
+ *
+ * @param entityType 实体类型
+ * @param condition WHERE 条件（占位符 ?）
+ * @param params 条件参数
+ * @return int64 影响行数
+ */
+func (r *BaseCrudRepository) DeleteWhere(entityType IDbEntity, condition string, params []interface{}) (int64, error) {
+	if err := r.checkWritable("DeleteWhere", entityType); err != nil {
+		return 0, err
+	}
+	tableName, err := r.prepareBulk(entityType, condition)
+	if err != nil {
+		return 0, err
+	}
+
+	where, whereParams, err := r.applyScope(entityType, "("+condition+")", params)
+	if err != nil {
+		return 0, err
+	}
+	sql := "DELETE FROM " + tableName + " WHERE " + where
+	LogDebug("执行条件删除: 表=%s, 条件=%s, SQL=%s", tableName, condition, sql)
+
+	affected, err := r.db.executeUpdateOnce(sql, whereParams, r.db.statementTimeout())
+	if err != nil {
+		LogError("条件删除失败: 表=%s, 错误=%v, SQL=%s", tableName, err, sql)
+		return 0, NewQueryExceptionWithCause(err, fmt.Sprintf("按条件删除表 %s 失败", tableName))
+	}
+	LogDebug("条件删除完成: 表=%s, 影响行数=%d", tableName, affected)
+	return affected, nil
+}
+
+/**
+ * 校验批量操作参数并返回表名
+ */
+func (r *BaseCrudRepository) prepareBulk(entityType IDbEntity, condition string) (string, error) {
+	if entityType == nil {
+		return "", NewValidationException("实体类型不能为 nil")
+	}
+	if condition == "" {
+		return "", NewValidationException("批量操作条件不能为空，全表操作请显式传入 \"1 = 1\"")
+	}
+	// 条件由调用方提供，属于原生 SQL，需经过白名单检查
+	if err := r.db.checkQueryAllowed(condition); err != nil {
+		return "", err
+	}
+
+	tableName := r.getTableName(entityType)
+	if tableName == "" {
+		return "", NewValidationException("无法获取表名，请确保实体实现了 TableName() 方法并返回非空字符串")
+	}
+	return tableName, nil
+}
+
+/**
+ * 把 setMap 的键解析为列名（只允许实体已映射的列，避免拼入任意 SQL）
+ */
+func (r *BaseCrudRepository) resolveSetColumns(entityType IDbEntity, setMap map[string]interface{}) (map[string]interface{}, error) {
+	metadata, err := GetEntityMetadataCacheInstance().GetOrBuild(entityType)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]interface{}, len(setMap))
+	for name, value := range setMap {
+		column := name
+		if !containsColumn(metadata.AllColumns, column) {
+			column = metadata.FieldNameToColumn[name]
+		}
+		if column == "" || !containsColumn(metadata.AllColumns, column) {
+			return nil, NewValidationException(fmt.Sprintf("实体 %T 没有列或字段 %s", entityType, name))
+		}
+		fields[column] = value
+	}
+	return fields, nil
+}
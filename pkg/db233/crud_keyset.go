@@ -0,0 +1,283 @@
+package db233
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+/**
+ * 游标（keyset）分页
+ *
+ * LIMIT/OFFSET 翻到深页时数据库仍需扫描并丢弃前面所有行；游标分页记住上一页最后一行的排序键，
+ * 下一页直接从该位置继续，适合无限滚动接口：
+ *
+ *   page, err := repo.FindPageAfter(&Post{}, "", 20, "created_at DESC")
+ *   // 返回给客户端 page.NextCursor，下一次请求带回
+ *   page, err = repo.FindPageAfter(&Post{}, page.NextCursor, 20, "created_at DESC")
+ *
+ * 生成 WHERE (created_at, id) < (?, ?) ORDER BY created_at DESC, id DESC LIMIT n，
+ * 主键作为排序的第二键保证顺序稳定（SQL Server 不支持行值比较，展开为 OR / AND 条件）。
+ * 排序列需建立（排序列, 主键）联合索引才能发挥效果
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type KeysetPage struct {
+	// 本页实体
+	Items []IDbEntity
+	// 下一页游标（不透明字符串），没有下一页时为空
+	NextCursor string
+	// 是否还有下一页
+	HasMore bool
+}
+
+/**
+ * 游标内容（编码后对调用方不透明）
+ */
+type keysetCursor struct {
+	// 生成游标时的排序，防止换了排序仍使用旧游标
+	OrderBy string `json:"o"`
+	// 排序键取值：排序列（如有）+ 主键列
+	Values []interface{} `json:"v"`
+}
+
+/**
+ * 游标中 time.Time 的编码形式（JSON 解码后无法区分字符串与时间）
+ */
+const keysetTimeKey = "$time"
+
+/**
+ * 解析后的排序
+ */
+type keysetOrder struct {
+	columns    []string
+	descending bool
+	normalized string
+}
+
+/**
+ * 游标分页查询
+ *
+ * @param entityType 实体类型
+ * @param cursor 上一页返回的 NextCursor，首页传空字符串
+ * @param pageSize 每页条数
+ * @param orderBy 排序列（列名或结构体字段名），可带 ASC / DESC，为空时按主键升序
+ */
+func (r *BaseCrudRepository) FindPageAfter(entityType IDbEntity, cursor string, pageSize int, orderBy string) (*KeysetPage, error) {
+	return r.FindPageAfterByCondition("", nil, entityType, cursor, pageSize, orderBy)
+}
+
+/**
+ * 带过滤条件的游标分页查询（同一游标的前后请求需使用相同条件）
+ *
+ * @param condition WHERE 条件（占位符 ?），可为空
+ * @param params 条件参数
+ * @param entityType 实体类型
+ * @param cursor 上一页返回的 NextCursor，首页传空字符串
+ * @param pageSize 每页条数
+ * @param orderBy 排序列，可带 ASC / DESC，为空时按主键升序
+ */
+func (r *BaseCrudRepository) FindPageAfterByCondition(condition string, params []interface{}, entityType IDbEntity, cursor string, pageSize int, orderBy string) (*KeysetPage, error) {
+	pageSize = r.resolvePageSize(pageSize)
+	if entityType == nil {
+		return nil, NewValidationException("实体类型不能为 nil")
+	}
+	if pageSize < 1 {
+		return nil, NewValidationException(fmt.Sprintf("每页条数必须大于 0: pageSize=%d", pageSize))
+	}
+	tableName := r.getTableName(entityType)
+	if tableName == "" {
+		return nil, NewValidationException("无法获取表名，请确保实体实现了 TableName() 方法并返回非空字符串")
+	}
+
+	order, err := r.parseKeysetOrder(entityType, orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := make([]string, 0, 2)
+	var whereParams []interface{}
+	if condition != "" {
+		// 条件由调用方提供，属于原生 SQL，需经过白名单检查
+		if err := r.db.checkQueryAllowed(condition); err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, "("+condition+")")
+		whereParams = append(whereParams, params...)
+	}
+	if cursor != "" {
+		values, err := decodeKeysetCursor(cursor, order)
+		if err != nil {
+			return nil, err
+		}
+		operator := ">"
+		if order.descending {
+			operator = "<"
+		}
+		comparison, comparisonParams := rowValueCompare(r.db.GetSqlDialect(), order.columns, operator, values)
+		conditions = append(conditions, comparison)
+		whereParams = append(whereParams, comparisonParams...)
+	}
+
+	where, whereParams, err := r.applyScope(entityType, strings.Join(conditions, " AND "), whereParams)
+	if err != nil {
+		return nil, err
+	}
+	sql := "SELECT * FROM " + tableName
+	if where != "" {
+		sql += " WHERE " + where
+	}
+	direction := ""
+	if order.descending {
+		direction = " DESC"
+	}
+	sql += " ORDER BY " + strings.Join(order.columns, direction+", ") + direction
+	// 多取一行判断是否还有下一页
+	sql = r.db.GetSqlDialect().ApplyLimitOffset(sql, pageSize+1, 0)
+	LogDebug("执行游标分页查询: 表=%s, 排序=%s, 每页=%d, SQL=%s", tableName, order.normalized, pageSize, sql)
+
+	results, err := r.db.executeQueryBatchE(sql, [][]interface{}{whereParams}, entityType)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &KeysetPage{Items: make([]IDbEntity, 0, len(results))}
+	for i, result := range results {
+		dbEntity, ok := result.(IDbEntity)
+		if !ok {
+			LogWarn("查询结果类型错误: 表=%s, 索引=%d, 结果类型=%T, 未实现 IDbEntity 接口", tableName, i, result)
+			continue
+		}
+		if len(page.Items) == pageSize {
+			page.HasMore = true
+			break
+		}
+		dbEntity.DeserializeAfterLoadDb()
+		r.callAfterFind(dbEntity)
+		page.Items = append(page.Items, dbEntity)
+	}
+
+	if page.HasMore {
+		page.NextCursor, err = r.encodeKeysetCursor(page.Items[len(page.Items)-1], order)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	LogDebug("游标分页查询完成: 表=%s, 找到记录数=%d, 还有下一页=%v", tableName, len(page.Items), page.HasMore)
+	page.Items, err = r.preloadRelations(page.Items)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+/**
+ * 解析排序：排序列 + 主键列，方向统一
+ */
+func (r *BaseCrudRepository) parseKeysetOrder(entityType IDbEntity, orderBy string) (*keysetOrder, error) {
+	pkColumns := GetCrudManagerInstance().GetPrimaryKeyColumnNames(entityType)
+	if len(pkColumns) == 0 {
+		return nil, NewValidationException(fmt.Sprintf("实体 %T 没有主键，无法使用游标分页", entityType))
+	}
+
+	order := &keysetOrder{}
+	parts := strings.Fields(orderBy)
+	if len(parts) > 2 {
+		return nil, NewValidationException(fmt.Sprintf("游标分页只支持单个排序列: %s", orderBy))
+	}
+	if len(parts) == 2 {
+		switch strings.ToUpper(parts[1]) {
+		case "ASC":
+		case "DESC":
+			order.descending = true
+		default:
+			return nil, NewValidationException(fmt.Sprintf("无效的排序方向: %s", parts[1]))
+		}
+	}
+
+	if len(parts) > 0 {
+		column, err := r.resolveEntityColumn(entityType, strings.TrimSuffix(parts[0], ","))
+		if err != nil {
+			return nil, err
+		}
+		if !containsColumn(pkColumns, column) {
+			order.columns = append(order.columns, column)
+		}
+	}
+	order.columns = append(order.columns, pkColumns...)
+
+	order.normalized = strings.Join(order.columns, ",")
+	if order.descending {
+		order.normalized += " DESC"
+	}
+	return order, nil
+}
+
+/**
+ * 由本页最后一个实体生成下一页游标
+ */
+func (r *BaseCrudRepository) encodeKeysetCursor(last IDbEntity, order *keysetOrder) (string, error) {
+	fields := r.getFields(last)
+	values := make([]interface{}, len(order.columns))
+	for i, column := range order.columns {
+		value := fields[column]
+		if t, ok := value.(time.Time); ok {
+			value = map[string]string{keysetTimeKey: t.Format(time.RFC3339Nano)}
+		}
+		values[i] = value
+	}
+
+	data, err := json.Marshal(keysetCursor{OrderBy: order.normalized, Values: values})
+	if err != nil {
+		return "", NewDb233ExceptionWithCause(err, "生成分页游标失败")
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+/**
+ * 解析游标，返回排序键取值
+ */
+func decodeKeysetCursor(cursor string, order *keysetOrder) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, NewValidationException("无效的分页游标")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var decoded keysetCursor
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, NewValidationException("无效的分页游标")
+	}
+	if decoded.OrderBy != order.normalized || len(decoded.Values) != len(order.columns) {
+		return nil, NewValidationException(fmt.Sprintf("分页游标与排序不匹配: 游标=%s, 当前=%s", decoded.OrderBy, order.normalized))
+	}
+
+	values := make([]interface{}, len(decoded.Values))
+	for i, value := range decoded.Values {
+		switch v := value.(type) {
+		case json.Number:
+			if n, err := v.Int64(); err == nil {
+				values[i] = n
+			} else if f, err := v.Float64(); err == nil {
+				values[i] = f
+			} else {
+				return nil, NewValidationException("无效的分页游标")
+			}
+		case map[string]interface{}:
+			text, _ := v[keysetTimeKey].(string)
+			t, err := time.Parse(time.RFC3339Nano, text)
+			if err != nil {
+				return nil, NewValidationException("无效的分页游标")
+			}
+			values[i] = t
+		default:
+			values[i] = v
+		}
+	}
+	return values, nil
+}
@@ -63,6 +63,14 @@ type CrudManager struct {
 	// 类型到主键列名的缓存（优化性能）
 	typeToPrimaryKeyColumnCache map[reflect.Type]string
 
+	// 类型到全部主键列名的缓存（复合主键）
+	typeToPrimaryKeyColumnsCache map[reflect.Type][]string
+
+	// 命名策略（全局与按实体），见 SetNamingStrategy
+	namingStrategy         *NamingStrategy
+	entityNamingStrategies map[reflect.Type]*NamingStrategy
+	namingMu               sync.RWMutex
+
 	// 锁（保证并发安全）
 	mu sync.RWMutex
 }
@@ -76,11 +84,12 @@ var crudManagerOnce sync.Once
 func GetCrudManagerInstance() *CrudManager {
 	crudManagerOnce.Do(func() {
 		crudManagerInstance = &CrudManager{
-			tableNamePkColNameListMap:   make(map[string][]string),
-			tableNameToColNameMap:       make(map[string][]string),
-			tableToPkToColValueMap:      make(map[string]map[interface{}]map[string]interface{}),
-			metadataClassSet:            make(map[reflect.Type]bool),
-			typeToPrimaryKeyColumnCache: make(map[reflect.Type]string),
+			tableNamePkColNameListMap:    make(map[string][]string),
+			tableNameToColNameMap:        make(map[string][]string),
+			tableToPkToColValueMap:       make(map[string]map[interface{}]map[string]interface{}),
+			metadataClassSet:             make(map[reflect.Type]bool),
+			typeToPrimaryKeyColumnCache:  make(map[reflect.Type]string),
+			typeToPrimaryKeyColumnsCache: make(map[reflect.Type][]string),
 		}
 	})
 	return crudManagerInstance
@@ -334,8 +343,8 @@ func (cm *CrudManager) GetTableName(t reflect.Type) string {
 			}
 		}
 	}
-	// 默认使用类型名转换为 snake_case（向后兼容）
-	return StringUtilsInstance.CamelToSnake(t.Name())
+	// 按命名策略生成（默认为类型名转换为 snake_case）
+	return cm.strategyTableName(t)
 }
 
 /**
@@ -366,8 +375,8 @@ func (cm *CrudManager) GetColumnName(field reflect.StructField) string {
 
 		return columnName
 	}
-	// 没有 db 标签，返回空字符串（要求必须显式声明 db 标签）
-	return ""
+	// 没有 db 标签时按命名策略生成，默认返回空字符串（要求必须显式声明 db 标签）
+	return cm.strategyColumnName(field)
 }
 
 /**
@@ -408,7 +417,8 @@ func (cm *CrudManager) IsAutoIncrement(field reflect.StructField) bool {
 	if field.Tag.Get("auto_increment") == "true" {
 		return true
 	}
-	return false
+	// TiDB AUTO_RANDOM 同样由数据库生成主键（非 TiDB 时建表为 AUTO_INCREMENT）
+	return cm.IsAutoRandom(field)
 }
 
 /** GetPrimaryKeyColumnName
@@ -511,6 +521,102 @@ func (cm *CrudManager) findPrimaryKeyColumnRecursive(t reflect.Type) string {
 	return ""
 }
 
+/**
+ * 获取实体的全部主键列名（支持复合主键与嵌入结构体，带缓存）
+ *
+ * @param entity 实体实例
+ * @return []string 主键列名（按字段声明顺序），未找到时返回 ["id"]
+ */
+func (cm *CrudManager) GetPrimaryKeyColumnNames(entity interface{}) []string {
+	t := reflect.TypeOf(entity)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	cm.mu.RLock()
+	if cached, exists := cm.typeToPrimaryKeyColumnsCache[t]; exists {
+		cm.mu.RUnlock()
+		return cached
+	}
+	cm.mu.RUnlock()
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if cached, exists := cm.typeToPrimaryKeyColumnsCache[t]; exists {
+		return cached
+	}
+
+	pkList := make([]string, 0)
+	cm.collectPrimaryKeysRecursive(t, &pkList)
+	if len(pkList) == 0 {
+		pkList = append(pkList, "id")
+	}
+	cm.typeToPrimaryKeyColumnsCache[t] = pkList
+	return pkList
+}
+
+/**
+ * 是否为复合主键实体
+ */
+func (cm *CrudManager) IsCompositePrimaryKey(entity interface{}) bool {
+	return len(cm.GetPrimaryKeyColumnNames(entity)) > 1
+}
+
+/**
+ * 获取实体的主键标识（可直接传给 FindById / DeleteById）
+ *
+ * @param entity 实体实例
+ * @return interface{} 单主键返回主键值，复合主键返回 map[列名]值
+ */
+func (cm *CrudManager) GetPrimaryKeyId(entity interface{}) interface{} {
+	if !cm.IsCompositePrimaryKey(entity) {
+		return cm.GetPrimaryKeyValue(entity)
+	}
+
+	v := reflect.ValueOf(entity)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	key := make(map[string]interface{})
+	cm.collectPrimaryKeyValuesRecursive(v, v.Type(), key)
+	return key
+}
+
+/**
+ * 递归收集主键列值（支持嵌入结构体）
+ */
+func (cm *CrudManager) collectPrimaryKeyValuesRecursive(v reflect.Value, t reflect.Type, key map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldValue := v.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous {
+			embeddedType := field.Type
+			embeddedValue := fieldValue
+			if embeddedType.Kind() == reflect.Ptr {
+				if embeddedValue.IsNil() {
+					continue
+				}
+				embeddedValue = embeddedValue.Elem()
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				cm.collectPrimaryKeyValuesRecursive(embeddedValue, embeddedType, key)
+				continue
+			}
+		}
+
+		colName := cm.GetColumnName(field)
+		if colName != "" && cm.IsPrimaryKey(field) {
+			key[colName] = fieldValue.Interface()
+		}
+	}
+}
+
 /**
  * 获取实体的主键值（自动从 struct 字段读取，支持嵌入结构体）
  *
@@ -588,6 +694,7 @@ func (cm *CrudManager) ClearPrimaryKeyCache() {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 	cm.typeToPrimaryKeyColumnCache = make(map[reflect.Type]string)
+	cm.typeToPrimaryKeyColumnsCache = make(map[reflect.Type][]string)
 }
 
 /**
@@ -595,8 +702,18 @@ func (cm *CrudManager) ClearPrimaryKeyCache() {
  */
 func (cm *CrudManager) AutoCreateTable(db *Db, entityType interface{}) error {
 	cm.mu.Lock()
-	defer cm.mu.Unlock()
+	err := cm.createTableLocked(db, entityType)
+	cm.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return cm.seedAfterSchema(db, entityType)
+}
 
+/**
+ * 创建表（调用方需持有写锁）
+ */
+func (cm *CrudManager) createTableLocked(db *Db, entityType interface{}) error {
 	t := reflect.TypeOf(entityType)
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
@@ -608,7 +725,7 @@ func (cm *CrudManager) AutoCreateTable(db *Db, entityType interface{}) error {
 	}
 
 	// 获取建表策略
-	strategy := GetStrategyFactoryInstance().GetStrategy(db.DatabaseType)
+	strategy := GetStrategyFactoryInstance().GetStrategyForDb(db)
 
 	// 检查表是否已存在
 	exists, err := strategy.TableExists(db, tableName)
@@ -657,7 +774,7 @@ func (cm *CrudManager) AutoCreateTable(db *Db, entityType interface{}) error {
  * @deprecated 使用 ITableCreationStrategy.TableExists 代替
  */
 func (cm *CrudManager) tableExists(db *Db, tableName string) (bool, error) {
-	strategy := GetStrategyFactoryInstance().GetStrategy(db.DatabaseType)
+	strategy := GetStrategyFactoryInstance().GetStrategyForDb(db)
 	return strategy.TableExists(db, tableName)
 }
 
@@ -696,7 +813,7 @@ func (cm *CrudManager) AutoMigrateTableSimple(db *Db, entityType interface{}) er
 	}
 
 	// 获取建表策略
-	strategy := GetStrategyFactoryInstance().GetStrategy(db.DatabaseType)
+	strategy := GetStrategyFactoryInstance().GetStrategyForDb(db)
 
 	// 检查表是否已存在
 	exists, err := strategy.TableExists(db, tableName)
@@ -711,8 +828,12 @@ func (cm *CrudManager) AutoMigrateTableSimple(db *Db, entityType interface{}) er
 
 	// 表存在，获取锁后检查并添加缺失的列
 	cm.mu.Lock()
-	defer cm.mu.Unlock()
-	return cm.alterTableAddMissingColumns(db, t)
+	err = cm.alterTableAddMissingColumns(db, t)
+	cm.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return cm.seedAfterSchema(db, entityType)
 }
 
 /**
@@ -725,7 +846,7 @@ func (cm *CrudManager) alterTableAddMissingColumns(db *Db, t reflect.Type) error
 	}
 
 	// 获取建表策略
-	strategy := GetStrategyFactoryInstance().GetStrategy(db.DatabaseType)
+	strategy := GetStrategyFactoryInstance().GetStrategyForDb(db)
 
 	// 获取现有列
 	existingColumns, err := strategy.GetExistingColumns(db, tableName)
@@ -802,7 +923,7 @@ func (cm *CrudManager) alterTableAddMissingColumns(db *Db, t reflect.Type) error
  * @deprecated 使用 ITableCreationStrategy.GetExistingColumns 代替
  */
 func (cm *CrudManager) getExistingColumns(db *Db, tableName string) (map[string]bool, error) {
-	strategy := GetStrategyFactoryInstance().GetStrategy(db.DatabaseType)
+	strategy := GetStrategyFactoryInstance().GetStrategyForDb(db)
 	return strategy.GetExistingColumns(db, tableName)
 }
 
@@ -827,6 +948,16 @@ func (cm *CrudManager) AutoCreateTableWithPermissions(db *Db, entityType interfa
  * AutoMigrateTable 自动迁移表（支持创建列、更新列、删除列）
  */
 func (cm *CrudManager) AutoMigrateTable(db *Db, entityType interface{}, permissions *AutoDbPermission) error {
+	if err := cm.migrateTable(db, entityType, permissions); err != nil {
+		return err
+	}
+	return cm.seedAfterSchema(db, entityType)
+}
+
+/**
+ * 迁移表结构（不含种子数据）
+ */
+func (cm *CrudManager) migrateTable(db *Db, entityType interface{}, permissions *AutoDbPermission) error {
 	if permissions == nil {
 		permissions = NewDefaultAutoDbPermission()
 	}
@@ -844,7 +975,7 @@ func (cm *CrudManager) AutoMigrateTable(db *Db, entityType interface{}, permissi
 		return NewDb233Exception("无法获取表名")
 	}
 
-	strategy := GetStrategyFactoryInstance().GetStrategy(db.DatabaseType)
+	strategy := GetStrategyFactoryInstance().GetStrategyForDb(db)
 
 	// 检查表是否存在
 	exists, err := strategy.TableExists(db, tableName)
@@ -858,7 +989,7 @@ func (cm *CrudManager) AutoMigrateTable(db *Db, entityType interface{}, permissi
 			LogWarn("创建表操作被禁用: 表=%s", tableName)
 			return nil
 		}
-		return cm.AutoCreateTable(db, entityType)
+		return cm.createTableLocked(db, entityType)
 	}
 
 	// 表已存在，检查列差异
@@ -925,7 +1056,13 @@ func (cm *CrudManager) AutoMigrateTable(db *Db, entityType interface{}, permissi
 		}
 	}
 
-	LogInfo("表迁移完成: 表=%s, 添加列=%d, 删除列=%d", tableName, len(columnsToAdd), len(columnsToDelete))
+	// 外键约束差异（缺失的添加，变更 / 未声明的需 DropForeignKey 权限）
+	foreignKeyChanges, err := migrateForeignKeys(db, strategy, tableName, t, permissions)
+	if err != nil {
+		LogError("外键迁移失败: 表=%s, 错误=%v", tableName, err)
+	}
+
+	LogInfo("表迁移完成: 表=%s, 添加列=%d, 删除列=%d, 外键变更=%d", tableName, len(columnsToAdd), len(columnsToDelete), foreignKeyChanges)
 	return nil
 }
 
@@ -939,8 +1076,16 @@ func (cm *CrudManager) getEntityColumns(t reflect.Type) map[string]reflect.Struc
 		field := t.Field(i)
 		tag := field.Tag.Get("db")
 
-		// 跳过没有 db 标签或标记为忽略的字段
-		if tag == "" || tag == "-" {
+		// 没有 db 标签时按命名策略生成列名（默认跳过）
+		if tag == "" {
+			if columnName := cm.strategyColumnName(field); columnName != "" {
+				columns[columnName] = field
+			}
+			continue
+		}
+
+		// 跳过标记为忽略的字段
+		if tag == "-" {
 			continue
 		}
 
@@ -0,0 +1,123 @@
+package db233
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+/**
+ * 部分更新
+ *
+ * Update 会写入所有映射列，只改了一个字段时会把其他列覆盖成实体上的值（常见为零值）；
+ * 部分更新只 SET 指定列或非零值列：
+ *
+ *   repo.UpdateColumns(user, "age", "email") // 列名或结构体字段名
+ *   repo.UpdateNonZero(user)                 // 跳过零值字段
+ *
+ * 主键列始终作为 WHERE 条件，作用域的强制列（ForcedColumns）始终写入；validate 标签只校验被更新的列
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type updateColumnSelector func(entity IDbEntity, fields map[string]interface{}, pkColumns []string) ([]string, error)
+
+/**
+ * 按主键只更新指定列
+ *
+ * @param entity 实体
+ * @param columns 列名或结构体字段名
+ */
+func (r *BaseCrudRepository) UpdateColumns(entity IDbEntity, columns ...string) error {
+	if err := r.checkWritable("UpdateColumns", entity); err != nil {
+		return err
+	}
+	return r.update(entity, func(entity IDbEntity, fields map[string]interface{}, pkColumns []string) ([]string, error) {
+		if len(columns) == 0 {
+			return nil, NewValidationException("UpdateColumns 至少需要指定一列")
+		}
+
+		var fieldNameToColumn map[string]string
+		if metadata, err := GetEntityMetadataCacheInstance().GetOrBuild(entity); err == nil {
+			fieldNameToColumn = metadata.FieldNameToColumn
+		}
+
+		selected := make([]string, 0, len(columns))
+		for _, name := range columns {
+			column := name
+			if _, exists := fields[column]; !exists {
+				column = fieldNameToColumn[name]
+			}
+			if _, exists := fields[column]; !exists || column == "" {
+				return nil, NewValidationException(fmt.Sprintf("实体 %T 没有列或字段 %s", entity, name))
+			}
+			if containsColumn(pkColumns, column) {
+				return nil, NewValidationException(fmt.Sprintf("主键列 %s 不能被更新", column))
+			}
+			if !containsColumn(selected, column) {
+				selected = append(selected, column)
+			}
+		}
+		return r.appendForcedColumns(selected, pkColumns), nil
+	})
+}
+
+/**
+ * 按主键只更新非零值字段（0、空字符串、false、nil 均视为未修改）
+ *
+ * @param entity 实体
+ */
+func (r *BaseCrudRepository) UpdateNonZero(entity IDbEntity) error {
+	if err := r.checkWritable("UpdateNonZero", entity); err != nil {
+		return err
+	}
+	return r.update(entity, func(entity IDbEntity, fields map[string]interface{}, pkColumns []string) ([]string, error) {
+		var selected []string
+		if metadata, err := GetEntityMetadataCacheInstance().GetOrBuild(entity); err == nil {
+			// 按字段顺序生成 SET，便于排查
+			for _, column := range metadata.AllColumns {
+				if value, exists := fields[column]; exists && !containsColumn(pkColumns, column) && !r.isUnsetValue(value) {
+					selected = append(selected, column)
+				}
+			}
+		}
+		for column, value := range fields {
+			if !containsColumn(selected, column) && !containsColumn(pkColumns, column) && !r.isUnsetValue(value) {
+				selected = append(selected, column)
+			}
+		}
+		selected = r.appendForcedColumns(selected, pkColumns)
+		if len(selected) == 0 {
+			return nil, NewValidationException(fmt.Sprintf("实体 %s 没有非零值字段可更新", reflect.TypeOf(entity)))
+		}
+		return selected, nil
+	})
+}
+
+/**
+ * 字段值是否视为未修改（time.Time 只有未导出字段，需单独判断）
+ */
+func (r *BaseCrudRepository) isUnsetValue(value interface{}) bool {
+	switch v := value.(type) {
+	case time.Time:
+		return v.IsZero()
+	case *time.Time:
+		return v == nil || v.IsZero()
+	}
+	return r.isZeroValue(value)
+}
+
+/**
+ * 追加作用域强制写入的列
+ */
+func (r *BaseCrudRepository) appendForcedColumns(selected []string, pkColumns []string) []string {
+	if r.scope == nil {
+		return selected
+	}
+	for column := range r.scope.ForcedColumns {
+		if !containsColumn(selected, column) && !containsColumn(pkColumns, column) {
+			selected = append(selected, column)
+		}
+	}
+	return selected
+}
@@ -1,6 +1,7 @@
 package db233
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -80,6 +81,22 @@ type CrudRepository interface {
  */
 type BaseCrudRepository struct {
 	db *Db
+
+	// 作用域（多租户等），nil 表示不限制
+	scope *RepositoryScope
+
+	// 传给实体生命周期钩子的 context，nil 时使用 context.Background()
+	ctx context.Context
+
+	// 查询时预加载的关联字段
+	relations []string
+
+	// 仅作用于该存储库的拦截器（见 WithInterceptors）
+	interceptors []RepositoryInterceptor
+
+	// 只读副本拒绝写操作，及未指定分页大小时使用的默认值（见 ReadOnly）
+	readOnly  bool
+	fetchSize int
 }
 
 /**
@@ -104,64 +121,122 @@ func (r *BaseCrudRepository) GetDb() *Db {
 }
 
 /**
- * 保存实体
+ * 写入计划：实体经钩子、主键生成、校验、加密后的待插入列
  */
-func (r *BaseCrudRepository) Save(entity IDbEntity) error {
+type insertPlan struct {
+	tableName    string
+	pkColumns    []string
+	uidValue     interface{}
+	columns      []string
+	placeholders []string
+	values       []interface{}
+
+	// 因零值跳过、由数据库生成的自增主键列
+	skippedAutoIncrementColumn string
+
+	// 作用域谓词，非空时按 scopedUpsert 保存
+	scopePredicates []ScopePredicate
+}
+
+/**
+ * 生成单行普通 INSERT
+ */
+func (plan *insertPlan) insertSql() string {
+	return "INSERT INTO " + plan.tableName + " (" + StringUtilsInstance.Join(plan.columns, ",") + ") VALUES (" + StringUtilsInstance.Join(plan.placeholders, ",") + ")"
+}
+
+/**
+ * 准备插入（Save / Upsert 共用）：调用保存前钩子、序列化、生成主键、校验、加密并收集插入列
+ */
+func (r *BaseCrudRepository) prepareInsert(entity IDbEntity) (*insertPlan, error) {
 	// 参数验证
 	if entity == nil {
-		return NewValidationException("实体不能为 nil")
+		return nil, NewValidationException("实体不能为 nil")
+	}
+
+	// 作用域与行级安全策略谓词（策略拒绝时不调用任何钩子）
+	scopePredicates, err := r.scopePredicates(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	// 调用保存前的生命周期钩子（可否决本次写入）
+	if err := r.callBeforeSave(entity); err != nil {
+		return nil, err
 	}
 
 	// 调用保存前的序列化钩子
 	entity.SerializeBeforeSaveDb()
 
+	// 为 gen 选项声明的空主键生成值（uuidv4 / uuidv7 / ulid 等）
+	if err := GetKeyGeneratorRegistryInstance().PopulateKeys(entity); err != nil {
+		return nil, err
+	}
+
+	// 写库前校验 validate 标签
+	if err := GetValidatorInstance().Validate(entity); err != nil {
+		return nil, err
+	}
+
 	// 获取表名
 	tableName := r.getTableName(entity)
 	if tableName == "" {
-		return NewValidationException("无法获取表名，请确保实体实现了 TableName() 方法并返回非空字符串")
+		return nil, NewValidationException("无法获取表名，请确保实体实现了 TableName() 方法并返回非空字符串")
 	}
 
 	// 获取字段
 	fields := r.getFields(entity)
 	if len(fields) == 0 {
-		return NewValidationException(fmt.Sprintf("实体 %T 没有可映射的字段，请检查字段是否包含 db 标签", entity))
+		return nil, NewValidationException(fmt.Sprintf("实体 %T 没有可映射的字段，请检查字段是否包含 db 标签", entity))
 	}
-
-	// 获取唯一ID列名（自动扫描 struct tag）
-	cm := GetCrudManagerInstance()
-	uidColumn := cm.GetPrimaryKeyColumnName(entity)
-	if uidColumn == "" {
-		uidColumn = "id"
+	r.applyForcedColumns(fields)
+	if err := GetFieldEncryptionManagerInstance().encryptFields(entity, fields); err != nil {
+		return nil, err
 	}
 
-	// 获取主键值（自动从 struct 字段读取）
-	uidValue := cm.GetPrimaryKeyValue(entity)
+	// 获取主键列名（自动扫描 struct tag，支持复合主键）
+	cm := GetCrudManagerInstance()
+	pkColumns := cm.GetPrimaryKeyColumnNames(entity)
 
-	// 构建 INSERT 语句
-	columns := make([]string, 0, len(fields))
-	placeholders := make([]string, 0, len(fields))
-	values := make([]interface{}, 0, len(fields))
+	plan := &insertPlan{
+		tableName:    tableName,
+		pkColumns:    pkColumns,
+		uidValue:     cm.GetPrimaryKeyId(entity), // 获取主键值（自动从 struct 字段读取）
+		columns:      make([]string, 0, len(fields)),
+		placeholders: make([]string, 0, len(fields)),
+		values:       make([]interface{}, 0, len(fields)),
 
-	// 检查主键是否为自增主键
-	isAutoIncrement := r.isAutoIncrementPrimaryKey(entity, uidColumn)
+		scopePredicates: scopePredicates,
+	}
 
+	omitEmpty := omitEmptyColumns(reflect.TypeOf(entity))
 	for name, value := range fields {
+		// omitempty：零值不写入，由数据库默认值填充
+		if omitEmpty[name] && !containsColumn(pkColumns, name) && r.isZeroValue(value) {
+			LogDebug("跳过 omitempty 零值字段: 表=%s, 列=%s", tableName, name)
+			continue
+		}
+
 		// 主键字段的特殊处理
-		if name == uidColumn {
+		if containsColumn(pkColumns, name) {
+			// 检查主键列是否为自增
+			isAutoIncrement := r.isAutoIncrementPrimaryKey(entity, name)
+
 			// 检查值是否为零值
 			if r.isZeroValue(value) {
 				if isAutoIncrement {
 					// 自增主键：零值时跳过，由数据库自动生成
-					LogDebug("跳过自增主键字段: 表=%s, 主键列=%s (值为零值，将由数据库自动生成)", tableName, uidColumn)
+					LogDebug("跳过自增主键字段: 表=%s, 主键列=%s (值为零值，将由数据库自动生成)", tableName, name)
+					plan.skippedAutoIncrementColumn = name
 					continue
 				} else {
 					// 非自增主键：零值时报错（业务主键必须提供有效值）
-					LogError("非自增主键字段值为零值: 表=%s, 主键列=%s", tableName, uidColumn)
-					return NewValidationException(fmt.Sprintf("主键字段 %s 不能为零值（0 或空字符串），请设置有效的主键值", uidColumn))
+					LogError("非自增主键字段值为零值: 表=%s, 主键列=%s", tableName, name)
+					return nil, NewValidationException(fmt.Sprintf("主键字段 %s 不能为零值（0 或空字符串），请设置有效的主键值", name))
 				}
 			}
 			// 主键有值，正常包含
-			LogDebug("包含主键字段: 表=%s, 主键列=%s, 主键值=%v, 自增=%v", tableName, uidColumn, value, isAutoIncrement)
+			LogDebug("包含主键字段: 表=%s, 主键列=%s, 主键值=%v, 自增=%v", tableName, name, value, isAutoIncrement)
 		}
 
 		// 对于非主键字段，即使值为空也要包含（让数据库处理 NOT NULL 约束）
@@ -171,65 +246,36 @@ func (r *BaseCrudRepository) Save(entity IDbEntity) error {
 			LogDebug("为字段提供默认值: 表=%s, 字段=%s, 原值=%v, 默认值=%v", tableName, name, value, finalValue)
 		}
 
-		columns = append(columns, name)
-		placeholders = append(placeholders, "?")
-		values = append(values, finalValue)
+		plan.columns = append(plan.columns, name)
+		plan.placeholders = append(plan.placeholders, "?")
+		plan.values = append(plan.values, finalValue)
 	}
 
-	if len(columns) == 0 {
-		return NewValidationException(fmt.Sprintf("表 %s 没有可插入的字段（所有字段都为空或已跳过）", tableName))
+	if len(plan.columns) == 0 {
+		return nil, NewValidationException(fmt.Sprintf("表 %s 没有可插入的字段（所有字段都为空或已跳过）", tableName))
 	}
 
-	// ========== UPSERT 逻辑：自动处理 INSERT 或 UPDATE ==========
-	// 检查主键是否在 columns 中（用于判断是否需要 upsert）
-	hasPrimaryKey := false
-	for _, col := range columns {
-		if col == uidColumn {
-			hasPrimaryKey = true
-			break
-		}
-	}
-
-	// 强制使用 INSERT ... ON DUPLICATE KEY UPDATE（UPSERT 语法）
-	// 优点：
-	// 1. 避免主键冲突错误（Error 1062: Duplicate entry）
-	// 2. 自动判断是 INSERT 还是 UPDATE
-	// 3. 减少业务代码复杂度，无需手动判断记录是否存在
-	var sql string
-	var finalValues []interface{}
-
-	if hasPrimaryKey {
-		// 有主键值，强制使用 INSERT ... ON DUPLICATE KEY UPDATE（UPSERT）
-		// 相当于：如果主键不存在则插入，如果主键已存在则更新其他字段
-		updateParts := make([]string, 0)
-		for _, col := range columns {
-			if col != uidColumn {
-				// 只更新非主键字段（主键不能修改）
-				updateParts = append(updateParts, col+" = VALUES("+col+")")
-			}
-		}
+	return plan, nil
+}
 
-		if len(updateParts) > 0 {
-			// 使用 ON DUPLICATE KEY UPDATE（强制 UPSERT）
-			// MySQL 语法：INSERT INTO ... VALUES ... ON DUPLICATE KEY UPDATE ...
-			sql = "INSERT INTO " + tableName + " (" + StringUtilsInstance.Join(columns, ",") + ") VALUES (" + StringUtilsInstance.Join(placeholders, ",") + ") ON DUPLICATE KEY UPDATE " + StringUtilsInstance.Join(updateParts, ", ")
-			finalValues = values
-			LogDebug("执行 UPSERT (强制): 表=%s, 主键列=%s, 主键值=%v, 字段数=%d", tableName, uidColumn, uidValue, len(columns))
-		} else {
-			// 只有主键字段，使用普通 INSERT IGNORE（避免重复错误）
-			sql = "INSERT IGNORE INTO " + tableName + " (" + StringUtilsInstance.Join(columns, ",") + ") VALUES (" + StringUtilsInstance.Join(placeholders, ",") + ")"
-			finalValues = values
-			LogDebug("执行 INSERT IGNORE (仅主键): 表=%s, 主键列=%s, 主键值=%v", tableName, uidColumn, uidValue)
-		}
-	} else {
-		// 没有主键值（自增主键），使用普通 INSERT
-		// 场景：id 为 0 或 nil，由数据库自动生成主键
-		sql = "INSERT INTO " + tableName + " (" + StringUtilsInstance.Join(columns, ",") + ") VALUES (" + StringUtilsInstance.Join(placeholders, ",") + ")"
-		finalValues = values
-		LogDebug("执行 INSERT (自增主键): 表=%s, 字段数=%d", tableName, len(columns))
+/**
+ * 保存实体
+ */
+func (r *BaseCrudRepository) Save(entity IDbEntity) error {
+	if err := r.checkWritable("Save", entity); err != nil {
+		return err
 	}
+	plan, err := r.prepareInsert(entity)
+	if err != nil {
+		return err
+	}
+	tableName, pkColumns := plan.tableName, plan.pkColumns
 
-	result, err := r.db.DataSource.Exec(sql, finalValues...)
+	result, sql, scoped, err := r.scopedUpsert(r.db, plan, pkColumns, r.saveUpdateColumns(plan))
+	if !scoped {
+		sql = r.buildSaveSql(plan, 1)
+		result, err = r.db.execInsert(sql, plan.values, plan.skippedAutoIncrementColumn)
+	}
 	if err != nil {
 		// 友好的错误提示
 		if isConnectionError(err) {
@@ -245,7 +291,7 @@ func (r *BaseCrudRepository) Save(entity IDbEntity) error {
 	lastInsertId, err := result.LastInsertId()
 	if err == nil && lastInsertId > 0 {
 		r.setPrimaryKeyValue(entity, lastInsertId)
-		LogDebug("自增主键已设置: 表=%s, 主键列=%s, 值=%d", tableName, uidColumn, lastInsertId)
+		LogDebug("自增主键已设置: 表=%s, 主键列=%v, 值=%d", tableName, pkColumns, lastInsertId)
 	}
 
 	rowsAffected, _ := result.RowsAffected()
@@ -257,9 +303,81 @@ func (r *BaseCrudRepository) Save(entity IDbEntity) error {
 		LogDebug("保存完成: 表=%s, 影响行数=%d", tableName, rowsAffected)
 	}
 
+	r.callAfterSave(entity)
 	return nil
 }
 
+/**
+ * 生成保存 SQL（Save / UnitOfWork 共用），rows > 1 时生成多行 VALUES（各行列相同）
+ *
+ * 有主键值时强制使用 INSERT ... ON DUPLICATE KEY UPDATE（UPSERT 语法，其他数据库经由 SqlDialect.ApplyUpsert）：
+ * 1. 避免主键冲突错误（Error 1062: Duplicate entry）
+ * 2. 自动判断是 INSERT 还是 UPDATE
+ * 3. 减少业务代码复杂度，无需手动判断记录是否存在
+ */
+func (r *BaseCrudRepository) buildSaveSql(plan *insertPlan, rows int) string {
+	columns, placeholders, pkColumns := plan.columns, plan.placeholders, plan.pkColumns
+	valueGroup := "(" + StringUtilsInstance.Join(placeholders, ",") + ")"
+	valueGroups := make([]string, rows)
+	for i := range valueGroups {
+		valueGroups[i] = valueGroup
+	}
+	valuesSql := StringUtilsInstance.Join(valueGroups, ",")
+
+	// 检查主键是否在 columns 中（用于判断是否需要 upsert）
+	hasPrimaryKey := false
+	for _, col := range columns {
+		if containsColumn(pkColumns, col) {
+			hasPrimaryKey = true
+			break
+		}
+	}
+
+	if !hasPrimaryKey {
+		// 没有主键值（自增主键），使用普通 INSERT
+		// 场景：id 为 0 或 nil，由数据库自动生成主键
+		LogDebug("执行 INSERT (自增主键): 表=%s, 字段数=%d, 行数=%d", plan.tableName, len(columns), rows)
+		return "INSERT INTO " + plan.tableName + " (" + StringUtilsInstance.Join(columns, ",") + ") VALUES " + valuesSql
+	}
+
+	// 其他数据库按方言生成冲突处理（PostgreSQL ON CONFLICT、SQL Server MERGE），只有主键字段时不更新
+	updateColumns := r.saveUpdateColumns(plan)
+	if dialect := r.db.GetSqlDialect(); dialect.GetDatabaseType() != EnumDatabaseTypeMySQL {
+		LogDebug("执行 UPSERT (%s): 表=%s, 主键列=%v, 主键值=%v, 字段数=%d, 行数=%d", dialect.GetDatabaseType(), plan.tableName, pkColumns, plan.uidValue, len(columns), rows)
+		insertSql := "INSERT INTO " + plan.tableName + " (" + StringUtilsInstance.Join(columns, ",") + ") VALUES " + valuesSql
+		return dialect.ApplyUpsert(insertSql, UpsertClause{ConflictColumns: pkColumns, UpdateColumns: updateColumns, DoNothing: len(updateColumns) == 0})
+	}
+
+	// 有主键值，相当于：如果主键不存在则插入，如果主键已存在则更新其他字段
+	updateParts := make([]string, 0)
+	for _, col := range updateColumns {
+		updateParts = append(updateParts, col+" = VALUES("+col+")")
+	}
+
+	if len(updateParts) == 0 {
+		// 只有主键字段，使用普通 INSERT IGNORE（避免重复错误）
+		LogDebug("执行 INSERT IGNORE (仅主键): 表=%s, 主键列=%v, 主键值=%v, 行数=%d", plan.tableName, pkColumns, plan.uidValue, rows)
+		return "INSERT IGNORE INTO " + plan.tableName + " (" + StringUtilsInstance.Join(columns, ",") + ") VALUES " + valuesSql
+	}
+
+	// MySQL 语法：INSERT INTO ... VALUES ... ON DUPLICATE KEY UPDATE ...
+	LogDebug("执行 UPSERT (强制): 表=%s, 主键列=%v, 主键值=%v, 字段数=%d, 行数=%d", plan.tableName, pkColumns, plan.uidValue, len(columns), rows)
+	return "INSERT INTO " + plan.tableName + " (" + StringUtilsInstance.Join(columns, ",") + ") VALUES " + valuesSql + " ON DUPLICATE KEY UPDATE " + StringUtilsInstance.Join(updateParts, ", ")
+}
+
+/**
+ * Save 主键冲突时更新的列：主键不能修改（复合主键的所有列共同作为冲突目标），作用域强制列不更新
+ */
+func (r *BaseCrudRepository) saveUpdateColumns(plan *insertPlan) []string {
+	columns := make([]string, 0, len(plan.columns))
+	for _, col := range plan.columns {
+		if !containsColumn(plan.pkColumns, col) && !r.isForcedColumn(col) {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
 /**
  * 设置主键值（支持嵌入结构体和多种主键标签方式）
  */
@@ -269,14 +387,16 @@ func (r *BaseCrudRepository) setPrimaryKeyValue(entity interface{}, id int64) {
 		v = v.Elem()
 	}
 
+	// 复合主键时只回填自增列，避免写入其他业务主键列
 	cm := GetCrudManagerInstance()
-	r.setPrimaryKeyValueRecursive(v, v.Type(), id, cm)
+	autoIncrementOnly := cm.IsCompositePrimaryKey(entity)
+	r.setPrimaryKeyValueRecursive(v, v.Type(), id, cm, autoIncrementOnly)
 }
 
 /**
  * 递归设置主键值
  */
-func (r *BaseCrudRepository) setPrimaryKeyValueRecursive(v reflect.Value, t reflect.Type, id int64, cm *CrudManager) bool {
+func (r *BaseCrudRepository) setPrimaryKeyValueRecursive(v reflect.Value, t reflect.Type, id int64, cm *CrudManager, autoIncrementOnly bool) bool {
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
 		fieldValue := v.Field(i)
@@ -296,7 +416,7 @@ func (r *BaseCrudRepository) setPrimaryKeyValueRecursive(v reflect.Value, t refl
 
 			if embeddedType.Kind() == reflect.Struct {
 				// 递归查找嵌入结构体中的主键字段
-				if r.setPrimaryKeyValueRecursive(embeddedValue, embeddedType, id, cm) {
+				if r.setPrimaryKeyValueRecursive(embeddedValue, embeddedType, id, cm, autoIncrementOnly) {
 					return true
 				}
 			}
@@ -304,7 +424,7 @@ func (r *BaseCrudRepository) setPrimaryKeyValueRecursive(v reflect.Value, t refl
 		}
 
 		// 检查是否为主键或自增字段
-		if cm.IsPrimaryKey(field) || cm.IsAutoIncrement(field) {
+		if (cm.IsPrimaryKey(field) && !autoIncrementOnly) || cm.IsAutoIncrement(field) {
 			if fieldValue.CanSet() {
 				switch fieldValue.Kind() {
 				case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
@@ -329,18 +449,34 @@ func (r *BaseCrudRepository) setPrimaryKeyValueRecursive(v reflect.Value, t refl
  * @return string 表名
  */
 func (r *BaseCrudRepository) getTableName(entity IDbEntity) string {
-	// 直接调用 TableName() 方法
-	tableName := entity.TableName()
-	if tableName != "" {
-		return tableName
+	return r.mapTableName(logicalTableName(entity))
+}
+
+/**
+ * 按作用域改写逻辑表名
+ */
+func (r *BaseCrudRepository) mapTableName(tableName string) string {
+	if r.scope != nil && r.scope.TableNameMapper != nil {
+		return r.scope.TableNameMapper(tableName)
 	}
+	return tableName
+}
 
-	// 如果 TableName() 返回空字符串，使用类型名转换为 snake_case（向后兼容）
-	t := reflect.TypeOf(entity)
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
+/**
+ * 获取实体的逻辑表名（不经过作用域改写）
+ */
+func logicalTableName(entity IDbEntity) string {
+	// 直接调用 TableName() 方法
+	tableName := entity.TableName()
+	if tableName == "" {
+		// 如果 TableName() 返回空字符串，按命名策略生成（默认为类型名转换为 snake_case）
+		t := reflect.TypeOf(entity)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		tableName = GetCrudManagerInstance().strategyTableName(t)
 	}
-	return StringUtilsInstance.CamelToSnake(t.Name())
+	return tableName
 }
 
 /**
@@ -426,9 +562,9 @@ func (r *BaseCrudRepository) scanFieldsRecursive(v reflect.Value, t reflect.Type
 					break
 				}
 			}
-		} else {
-			// 如果没有 db 标签（tag == ""），跳过该字段
-			// 要求必须显式声明 db 标签才会被处理
+		} else if columnName = GetCrudManagerInstance().strategyColumnName(field); columnName == "" {
+			// 如果没有 db 标签（tag == ""）且命名策略未生成列名，跳过该字段
+			// 默认要求必须显式声明 db 标签才会被处理
 			LogDebug("跳过字段（无db标签）: 实体=%s, 字段=%s", entityTypeName, field.Name)
 			continue
 		}
@@ -445,6 +581,37 @@ func (r *BaseCrudRepository) scanFieldsRecursive(v reflect.Value, t reflect.Type
 		fieldType := fieldValue.Type()
 		kind := fieldType.Kind()
 
+		// 注册了类型转换器的字段由转换器生成数据库值
+		if converter, exists := GetTypeConverterRegistryInstance().Get(fieldType); exists {
+			dbValue, err := converter.toDb(value)
+			if err != nil {
+				LogWarn("跳过字段（类型转换失败）: 实体=%s, 字段=%s, 列名=%s, 错误=%v", entityTypeName, field.Name, columnName, err)
+				continue
+			}
+			fields[columnName] = dbValue
+			continue
+		}
+
+		// decimal 标签：Decimal 按列的小数位数四舍五入
+		value = roundDecimalForColumn(field, value)
+
+		// 时间字段按时间策略转换时区、截断精度，零值写为 NULL
+		if isTimeFieldType(fieldType) {
+			fields[columnName] = r.db.GetTimePolicy().toStorage(value, timeColumnPrecision(field))
+			continue
+		}
+
+		// PostgreSQL 数组列（db_type 以 [] 结尾）按数组字面量写入
+		if r.db != nil && r.db.DatabaseType == EnumDatabaseTypePostgreSQL && isPostgreSQLArrayColumn(field) {
+			arrayValue, err := encodePostgreSQLArray(fieldValue)
+			if err != nil {
+				LogWarn("跳过数组字段（编码失败）: 实体=%s, 字段=%s, 列名=%s, 错误=%v", entityTypeName, field.Name, columnName, err)
+				continue
+			}
+			fields[columnName] = arrayValue
+			continue
+		}
+
 		// 处理复杂类型（map、slice、array等）
 		if r.isComplexType(kind, fieldType) {
 			// 尝试序列化为 JSON
@@ -467,6 +634,10 @@ func (r *BaseCrudRepository) scanFieldsRecursive(v reflect.Value, t reflect.Type
  * 判断是否为复杂类型（需要序列化）
  */
 func (r *BaseCrudRepository) isComplexType(kind reflect.Kind, fieldType reflect.Type) bool {
+	// sql.NullString 等实现 driver.Valuer 的类型交给驱动转换
+	if isDriverValuerType(fieldType) {
+		return false
+	}
 	switch kind {
 	case reflect.Map, reflect.Slice, reflect.Array:
 		return true
@@ -526,9 +697,8 @@ func (r *BaseCrudRepository) serializeComplexType(value interface{}, fieldType r
  */
 func (r *BaseCrudRepository) getDefaultValueIfEmpty(value interface{}, fieldName string) interface{} {
 	if value == nil {
-		// nil 值，返回空字符串（数据库可以处理）
-		LogDebug("字段值为 nil，使用空字符串作为默认值: 字段=%s", fieldName)
-		return ""
+		// nil 值（nil 指针、类型转换器返回 nil）写为 NULL
+		return nil
 	}
 
 	v := reflect.ValueOf(value)
@@ -536,9 +706,8 @@ func (r *BaseCrudRepository) getDefaultValueIfEmpty(value interface{}, fieldName
 	// 处理指针类型
 	if v.Kind() == reflect.Ptr {
 		if v.IsNil() {
-			// nil 指针，返回空字符串
-			LogDebug("字段值为 nil 指针，使用空字符串作为默认值: 字段=%s", fieldName)
-			return ""
+			// nil 指针写为 NULL，读取时还原为 nil
+			return nil
 		}
 		// 解引用指针，检查指向的值
 		v = v.Elem()
@@ -634,6 +803,83 @@ func (r *BaseCrudRepository) findAutoIncrementFieldRecursive(t reflect.Type, tar
 	return false
 }
 
+/**
+ * 构建主键 WHERE 条件（支持复合主键）
+ *
+ * 单主键时 id 为主键值；复合主键时 id 可以是 map[列名]值，或带 db 标签的结构体（如实体本身）
+ *
+ * @param id 主键标识
+ * @param entityType 实体类型
+ * @return string 条件 SQL，如 "region = ? AND player_id = ?"
+ * @return []interface{} 条件参数
+ */
+func (r *BaseCrudRepository) buildPrimaryKeyCondition(id interface{}, entityType IDbEntity) (string, []interface{}, error) {
+	pkColumns := GetCrudManagerInstance().GetPrimaryKeyColumnNames(entityType)
+
+	keyValues := r.extractKeyValues(id)
+	if keyValues == nil {
+		if len(pkColumns) > 1 {
+			return "", nil, NewValidationException(fmt.Sprintf("实体 %T 为复合主键 %v，主键需传入 map[string]interface{} 或结构体", entityType, pkColumns))
+		}
+		return pkColumns[0] + " = ?", []interface{}{id}, nil
+	}
+
+	conditions := make([]string, 0, len(pkColumns))
+	params := make([]interface{}, 0, len(pkColumns))
+	for _, pkColumn := range pkColumns {
+		value, exists := keyValues[pkColumn]
+		if !exists {
+			return "", nil, NewValidationException(fmt.Sprintf("主键缺少列 %s（实体 %T 的主键为 %v）", pkColumn, entityType, pkColumns))
+		}
+		conditions = append(conditions, pkColumn+" = ?")
+		params = append(params, value)
+	}
+	return strings.Join(conditions, " AND "), params, nil
+}
+
+/**
+ * 从 map 或结构体中提取主键列值，标量返回 nil
+ */
+func (r *BaseCrudRepository) extractKeyValues(id interface{}) map[string]interface{} {
+	if keyValues, ok := id.(map[string]interface{}); ok {
+		return keyValues
+	}
+
+	v := reflect.ValueOf(id)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		keyValues := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			keyValues[iter.Key().String()] = iter.Value().Interface()
+		}
+		return keyValues
+	case v.Kind() == reflect.Struct && v.Type() != reflect.TypeOf(time.Time{}):
+		return r.getFields(v.Interface())
+	default:
+		return nil
+	}
+}
+
+/**
+ * 列名是否在列表中
+ */
+func containsColumn(columns []string, column string) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
 /**
  * 判断值是否为零值
  */
@@ -642,6 +888,15 @@ func (r *BaseCrudRepository) isZeroValue(value interface{}) bool {
 		return true
 	}
 
+	// time.Time、Decimal 等自带 IsZero 的类型（字段未导出，无法逐字段判断）
+	if zeroChecker, ok := value.(interface{ IsZero() bool }); ok {
+		v := reflect.ValueOf(value)
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return true
+		}
+		return zeroChecker.IsZero()
+	}
+
 	v := reflect.ValueOf(value)
 
 	// 处理指针类型
@@ -695,6 +950,9 @@ func (r *BaseCrudRepository) isZeroValue(value interface{}) bool {
  * 其他方法的简化实现
  */
 func (r *BaseCrudRepository) SaveBatch(entities []IDbEntity) error {
+	if err := r.checkWritable("SaveBatch", nil); err != nil {
+		return err
+	}
 	// 参数验证
 	if entities == nil {
 		return NewValidationException("实体列表不能为 nil")
@@ -724,6 +982,9 @@ func (r *BaseCrudRepository) SaveBatch(entities []IDbEntity) error {
 }
 
 func (r *BaseCrudRepository) DeleteById(id interface{}, entityType IDbEntity) error {
+	if err := r.checkWritable("DeleteById", entityType); err != nil {
+		return err
+	}
 	// 参数验证
 	if entityType == nil {
 		return NewValidationException("实体类型不能为 nil")
@@ -737,17 +998,29 @@ func (r *BaseCrudRepository) DeleteById(id interface{}, entityType IDbEntity) er
 		return NewValidationException("无法获取表名，请确保实体实现了 TableName() 方法并返回非空字符串")
 	}
 
-	// 使用自动扫描获取唯一ID列名
-	cm := GetCrudManagerInstance()
-	uidColumn := cm.GetPrimaryKeyColumnName(entityType)
-	if uidColumn == "" {
-		uidColumn = "id"
+	// 使用自动扫描获取主键条件（支持复合主键）
+	pkCondition, pkParams, err := r.buildPrimaryKeyCondition(id, entityType)
+	if err != nil {
+		return err
 	}
 
-	sql := "DELETE FROM " + tableName + " WHERE " + uidColumn + " = ?"
-	LogDebug("执行 DELETE: 表=%s, 主键列=%s, ID=%v, SQL=%s", tableName, uidColumn, id, sql)
+	// 调用删除前的生命周期钩子（可否决本次删除）
+	if err := r.callBeforeDelete(id, entityType); err != nil {
+		return err
+	}
 
-	affectedRows := r.db.ExecuteOriginalUpdate(sql, [][]interface{}{{id}})
+	where, params, err := r.applyScope(entityType, pkCondition, pkParams)
+	if err != nil {
+		return err
+	}
+	sql := "DELETE FROM " + tableName + " WHERE " + where
+	LogDebug("执行 DELETE: 表=%s, 主键条件=%s, ID=%v, SQL=%s", tableName, pkCondition, id, sql)
+
+	affectedRows, err := r.db.executeUpdateBatchE(sql, [][]interface{}{params})
+	if err != nil {
+		LogError("删除实体失败: 表=%s, ID=%v, 错误=%v, SQL=%s", tableName, id, err, sql)
+		return err
+	}
 	if affectedRows == 0 {
 		LogWarn("删除无影响: 表=%s, ID=%v, 可能记录不存在", tableName, id)
 	} else {
@@ -771,17 +1044,23 @@ func (r *BaseCrudRepository) FindById(id interface{}, entityType IDbEntity) (IDb
 		return nil, NewValidationException("无法获取表名，请确保实体实现了 TableName() 方法并返回非空字符串")
 	}
 
-	// 使用自动扫描获取唯一ID列名
-	cm := GetCrudManagerInstance()
-	uidColumn := cm.GetPrimaryKeyColumnName(entityType)
-	if uidColumn == "" {
-		uidColumn = "id"
+	// 使用自动扫描获取主键条件（支持复合主键）
+	pkCondition, pkParams, err := r.buildPrimaryKeyCondition(id, entityType)
+	if err != nil {
+		return nil, err
 	}
 
-	sql := "SELECT * FROM " + tableName + " WHERE " + uidColumn + " = ?"
-	LogDebug("执行查询: 表=%s, 主键列=%s, ID=%v, SQL=%s", tableName, uidColumn, id, sql)
+	where, params, err := r.applyScope(entityType, pkCondition, pkParams)
+	if err != nil {
+		return nil, err
+	}
+	sql := "SELECT * FROM " + tableName + " WHERE " + where
+	LogDebug("执行查询: 表=%s, 主键条件=%s, ID=%v, SQL=%s", tableName, pkCondition, id, sql)
 
-	results := r.db.ExecuteQuery(sql, [][]interface{}{{id}}, entityType)
+	results, err := r.db.executeQueryBatchE(sql, [][]interface{}{params}, entityType)
+	if err != nil {
+		return nil, err
+	}
 	if len(results) > 0 {
 		// 返回指针类型
 		result := results[0]
@@ -796,6 +1075,10 @@ func (r *BaseCrudRepository) FindById(id interface{}, entityType IDbEntity) (IDb
 		if dbEntity, ok := result.(IDbEntity); ok {
 			// 调用加载后的反序列化钩子
 			dbEntity.DeserializeAfterLoadDb()
+			r.callAfterFind(dbEntity)
+			if err := r.LoadRelations(dbEntity, r.relations...); err != nil {
+				return nil, err
+			}
 			LogDebug("查询成功: 表=%s, ID=%v, 找到记录", tableName, id)
 			return dbEntity, nil
 		}
@@ -807,6 +1090,179 @@ func (r *BaseCrudRepository) FindById(id interface{}, entityType IDbEntity) (IDb
 	return nil, nil
 }
 
+/**
+ * 根据多个主键批量查找（一次 IN 查询），结果顺序与 ids 一致，不存在的主键跳过
+ *
+ * 复合主键的 id 传入 map[string]interface{} 或结构体，生成 (a, b) IN ((?, ?), ...)（SQL Server 展开为 OR / AND 条件）
+ *
+ * @param ids 主键列表
+ * @param entityType 实体类型
+ */
+func (r *BaseCrudRepository) FindByIds(ids []interface{}, entityType IDbEntity) ([]IDbEntity, error) {
+	keys, found, err := r.findByIds(ids, entityType)
+	if err != nil {
+		return nil, err
+	}
+
+	entities := make([]IDbEntity, 0, len(found))
+	for _, key := range keys {
+		if entity, exists := found[key]; exists {
+			entities = append(entities, entity)
+		}
+	}
+	return entities, nil
+}
+
+/**
+ * 根据多个主键批量查找，返回 主键 -> 实体（仅支持单列主键）
+ *
+ * @param ids 主键列表
+ * @param entityType 实体类型
+ */
+func (r *BaseCrudRepository) FindByIdsAsMap(ids []interface{}, entityType IDbEntity) (map[interface{}]IDbEntity, error) {
+	for _, id := range ids {
+		if id == nil || !reflect.TypeOf(id).Comparable() || r.extractKeyValues(id) != nil {
+			return nil, NewValidationException(fmt.Sprintf("FindByIdsAsMap 仅支持单列标量主键，得到 %T", id))
+		}
+	}
+
+	keys, found, err := r.findByIds(ids, entityType)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[interface{}]IDbEntity, len(found))
+	for i, key := range keys {
+		if entity, exists := found[key]; exists {
+			result[ids[i]] = entity
+		}
+	}
+	return result, nil
+}
+
+/**
+ * 批量查找的公共实现
+ *
+ * @return []string 与 ids 一一对应的主键匹配键
+ * @return map[string]IDbEntity 匹配键 -> 实体
+ */
+func (r *BaseCrudRepository) findByIds(ids []interface{}, entityType IDbEntity) ([]string, map[string]IDbEntity, error) {
+	// 参数验证
+	if entityType == nil {
+		return nil, nil, NewValidationException("实体类型不能为 nil")
+	}
+
+	tableName := r.getTableName(entityType)
+	if tableName == "" {
+		return nil, nil, NewValidationException("无法获取表名，请确保实体实现了 TableName() 方法并返回非空字符串")
+	}
+
+	pkColumns := GetCrudManagerInstance().GetPrimaryKeyColumnNames(entityType)
+	keys := make([]string, len(ids))
+	seen := make(map[string]bool, len(ids))
+	params := make([]interface{}, 0, len(ids)*len(pkColumns))
+
+	for i, id := range ids {
+		if id == nil {
+			return nil, nil, NewValidationException(fmt.Sprintf("第 %d 个主键为 nil", i))
+		}
+		values, err := r.primaryKeyValues(id, pkColumns, entityType)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys[i] = primaryKeyMatchKey(values)
+		if !seen[keys[i]] {
+			seen[keys[i]] = true
+			params = append(params, values...)
+		}
+	}
+
+	found := make(map[string]IDbEntity, len(seen))
+	if len(seen) == 0 {
+		return keys, found, nil
+	}
+
+	condition := pkColumns[0] + " IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(params)), ", ") + ")"
+	if len(pkColumns) > 1 {
+		condition = rowValueIn(r.db.GetSqlDialect(), pkColumns, params)
+	}
+	where, params, err := r.applyScope(entityType, condition, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	sql := "SELECT * FROM " + tableName + " WHERE " + where
+	LogDebug("执行批量主键查询: 表=%s, 主键数=%d, SQL=%s", tableName, len(seen), sql)
+
+	results, err := r.db.executeQueryBatchE(sql, [][]interface{}{params}, entityType)
+	if err != nil {
+		return nil, nil, err
+	}
+	entities := make([]IDbEntity, 0, len(results))
+	for i, result := range results {
+		dbEntity, ok := toEntityPointer(result).Interface().(IDbEntity)
+		if !ok {
+			LogWarn("查询结果类型错误: 表=%s, 索引=%d, 结果类型=%T, 未实现 IDbEntity 接口", tableName, i, result)
+			continue
+		}
+		dbEntity.DeserializeAfterLoadDb()
+		r.callAfterFind(dbEntity)
+		entities = append(entities, dbEntity)
+	}
+
+	entities, err = r.preloadRelations(entities)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, entity := range entities {
+		fields := r.getFields(entity)
+		values := make([]interface{}, len(pkColumns))
+		for i, pkColumn := range pkColumns {
+			values[i] = fields[pkColumn]
+		}
+		found[primaryKeyMatchKey(values)] = entity
+	}
+
+	LogDebug("批量主键查询完成: 表=%s, 请求数=%d, 找到记录数=%d", tableName, len(seen), len(found))
+	return keys, found, nil
+}
+
+/**
+ * 按主键列顺序提取主键值
+ */
+func (r *BaseCrudRepository) primaryKeyValues(id interface{}, pkColumns []string, entityType IDbEntity) ([]interface{}, error) {
+	keyValues := r.extractKeyValues(id)
+	if keyValues == nil {
+		if len(pkColumns) > 1 {
+			return nil, NewValidationException(fmt.Sprintf("实体 %T 为复合主键 %v，主键需传入 map[string]interface{} 或结构体", entityType, pkColumns))
+		}
+		return []interface{}{id}, nil
+	}
+
+	values := make([]interface{}, len(pkColumns))
+	for i, pkColumn := range pkColumns {
+		value, exists := keyValues[pkColumn]
+		if !exists {
+			return nil, NewValidationException(fmt.Sprintf("主键缺少列 %s（实体 %T 的主键为 %v）", pkColumn, entityType, pkColumns))
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+/**
+ * 主键匹配键（忽略 int / int64 等类型差异）
+ */
+func primaryKeyMatchKey(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		if bytes, ok := value.([]byte); ok {
+			value = string(bytes)
+		}
+		parts[i] = fmt.Sprint(value)
+	}
+	return strings.Join(parts, "\x00")
+}
+
 func (r *BaseCrudRepository) FindAll(entityType IDbEntity) ([]IDbEntity, error) {
 	// 参数验证
 	if entityType == nil {
@@ -819,9 +1275,20 @@ func (r *BaseCrudRepository) FindAll(entityType IDbEntity) ([]IDbEntity, error)
 	}
 
 	sql := "SELECT * FROM " + tableName
+	where, params, err := r.applyScope(entityType, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if where != "" {
+		sql += " WHERE " + where
+	}
 	LogDebug("执行查询所有: 表=%s, SQL=%s", tableName, sql)
 
-	results := r.db.ExecuteQuery(sql, [][]interface{}{}, entityType)
+	// 无条件时也要执行一次（参数为空）
+	results, err := r.db.executeQueryBatchE(sql, [][]interface{}{params}, entityType)
+	if err != nil {
+		return nil, err
+	}
 
 	// 转换为 IDbEntity 切片并调用反序列化钩子
 	entities := make([]IDbEntity, 0, len(results))
@@ -829,6 +1296,7 @@ func (r *BaseCrudRepository) FindAll(entityType IDbEntity) ([]IDbEntity, error)
 		if dbEntity, ok := result.(IDbEntity); ok {
 			// 调用加载后的反序列化钩子
 			dbEntity.DeserializeAfterLoadDb()
+			r.callAfterFind(dbEntity)
 			entities = append(entities, dbEntity)
 		} else {
 			LogWarn("查询结果类型错误: 表=%s, 索引=%d, 结果类型=%T, 未实现 IDbEntity 接口", tableName, i, result)
@@ -836,7 +1304,7 @@ func (r *BaseCrudRepository) FindAll(entityType IDbEntity) ([]IDbEntity, error)
 	}
 
 	LogDebug("查询所有完成: 表=%s, 找到记录数=%d", tableName, len(entities))
-	return entities, nil
+	return r.preloadRelations(entities)
 }
 
 func (r *BaseCrudRepository) FindByCondition(condition string, params []interface{}, entityType IDbEntity) ([]IDbEntity, error) {
@@ -847,16 +1315,27 @@ func (r *BaseCrudRepository) FindByCondition(condition string, params []interfac
 	if condition == "" {
 		return nil, NewValidationException("查询条件不能为空")
 	}
+	// 条件由调用方提供，属于原生 SQL，需经过白名单检查
+	if err := r.db.checkQueryAllowed(condition); err != nil {
+		return nil, err
+	}
 
 	tableName := r.getTableName(entityType)
 	if tableName == "" {
 		return nil, NewValidationException("无法获取表名，请确保实体实现了 TableName() 方法并返回非空字符串")
 	}
 
-	sql := "SELECT * FROM " + tableName + " WHERE " + condition
+	where, params, err := r.applyScope(entityType, "("+condition+")", params)
+	if err != nil {
+		return nil, err
+	}
+	sql := "SELECT * FROM " + tableName + " WHERE " + where
 	LogDebug("执行条件查询: 表=%s, 条件=%s, 参数数=%d, SQL=%s", tableName, condition, len(params), sql)
 
-	results := r.db.ExecuteQuery(sql, [][]interface{}{params}, entityType)
+	results, err := r.db.executeQueryBatchE(sql, [][]interface{}{params}, entityType)
+	if err != nil {
+		return nil, err
+	}
 
 	// 转换为 IDbEntity 切片并调用反序列化钩子
 	entities := make([]IDbEntity, 0, len(results))
@@ -864,6 +1343,7 @@ func (r *BaseCrudRepository) FindByCondition(condition string, params []interfac
 		if dbEntity, ok := result.(IDbEntity); ok {
 			// 调用加载后的反序列化钩子
 			dbEntity.DeserializeAfterLoadDb()
+			r.callAfterFind(dbEntity)
 			entities = append(entities, dbEntity)
 		} else {
 			LogWarn("查询结果类型错误: 表=%s, 索引=%d, 结果类型=%T, 未实现 IDbEntity 接口", tableName, i, result)
@@ -871,84 +1351,225 @@ func (r *BaseCrudRepository) FindByCondition(condition string, params []interfac
 	}
 
 	LogDebug("条件查询完成: 表=%s, 找到记录数=%d", tableName, len(entities))
-	return entities, nil
+	return r.preloadRelations(entities)
+}
+
+/**
+ * 分页查询（按主键排序，分页子句由 Db 的 SQL 方言生成）
+ *
+ * @param condition 查询条件，空字符串表示不限制
+ * @param params 条件参数
+ * @param pageNo 页码，从 1 开始
+ * @param pageSize 每页条数
+ * @param entityType 实体类型
+ */
+func (r *BaseCrudRepository) FindPage(condition string, params []interface{}, pageNo int, pageSize int, entityType IDbEntity) ([]IDbEntity, error) {
+	pageSize = r.resolvePageSize(pageSize)
+	// 参数验证
+	if entityType == nil {
+		return nil, NewValidationException("实体类型不能为 nil")
+	}
+	if pageNo < 1 || pageSize < 1 {
+		return nil, NewValidationException(fmt.Sprintf("页码和每页条数必须大于 0: pageNo=%d, pageSize=%d", pageNo, pageSize))
+	}
+
+	tableName := r.getTableName(entityType)
+	if tableName == "" {
+		return nil, NewValidationException("无法获取表名，请确保实体实现了 TableName() 方法并返回非空字符串")
+	}
+
+	if condition != "" {
+		// 条件由调用方提供，属于原生 SQL，需经过白名单检查
+		if err := r.db.checkQueryAllowed(condition); err != nil {
+			return nil, err
+		}
+		condition = "(" + condition + ")"
+	}
+
+	sql := "SELECT * FROM " + tableName
+	where, params, err := r.applyScope(entityType, condition, params)
+	if err != nil {
+		return nil, err
+	}
+	if where != "" {
+		sql += " WHERE " + where
+	}
+	sql += " ORDER BY " + strings.Join(GetCrudManagerInstance().GetPrimaryKeyColumnNames(entityType), ", ")
+	sql = r.db.GetSqlDialect().ApplyLimitOffset(sql, pageSize, (pageNo-1)*pageSize)
+	LogDebug("执行分页查询: 表=%s, 页码=%d, 每页=%d, SQL=%s", tableName, pageNo, pageSize, sql)
+
+	results, err := r.db.executeQueryBatchE(sql, [][]interface{}{params}, entityType)
+	if err != nil {
+		return nil, err
+	}
+
+	// 转换为 IDbEntity 切片并调用反序列化钩子
+	entities := make([]IDbEntity, 0, len(results))
+	for i, result := range results {
+		if dbEntity, ok := result.(IDbEntity); ok {
+			dbEntity.DeserializeAfterLoadDb()
+			r.callAfterFind(dbEntity)
+			entities = append(entities, dbEntity)
+		} else {
+			LogWarn("查询结果类型错误: 表=%s, 索引=%d, 结果类型=%T, 未实现 IDbEntity 接口", tableName, i, result)
+		}
+	}
+
+	LogDebug("分页查询完成: 表=%s, 页码=%d, 找到记录数=%d", tableName, pageNo, len(entities))
+	return r.preloadRelations(entities)
 }
 
 func (r *BaseCrudRepository) Update(entity IDbEntity) error {
+	if err := r.checkWritable("Update", entity); err != nil {
+		return err
+	}
+	return r.update(entity, nil)
+}
+
+/**
+ * 更新计划：按主键更新的 SQL 与参数
+ */
+type updatePlan struct {
+	tableName string
+	id        interface{}
+	sql       string
+	values    []interface{}
+}
+
+/**
+ * 按主键更新，selector 为 nil 时更新全部非主键列，否则只更新 selector 选出的列
+ */
+func (r *BaseCrudRepository) update(entity IDbEntity, selector updateColumnSelector) error {
+	plan, err := r.prepareUpdate(entity, selector)
+	if err != nil {
+		return err
+	}
+	tableName, id := plan.tableName, plan.id
+
+	result, err := r.db.execGenerated(plan.sql, plan.values)
+	if err != nil {
+		LogError("更新实体失败: 表=%s, ID=%v, 错误=%v, SQL=%s", tableName, id, err, plan.sql)
+		return NewQueryExceptionWithCause(err, fmt.Sprintf("更新表 %s 中 ID=%v 的记录失败", tableName, id))
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		LogWarn("更新无影响: 表=%s, ID=%v, 可能记录不存在", tableName, id)
+	} else {
+		LogDebug("更新成功: 表=%s, ID=%v, 影响行数=%d", tableName, id, rowsAffected)
+	}
+
+	r.callAfterSave(entity)
+	return nil
+}
+
+/**
+ * 准备更新（Update / UnitOfWork 共用）：调用保存前钩子、序列化、校验、加密并生成 UPDATE 语句
+ */
+func (r *BaseCrudRepository) prepareUpdate(entity IDbEntity, selector updateColumnSelector) (*updatePlan, error) {
 	// 参数验证
 	if entity == nil {
-		return NewValidationException("实体不能为 nil")
+		return nil, NewValidationException("实体不能为 nil")
+	}
+
+	// 调用保存前的生命周期钩子（可否决本次写入）
+	if err := r.callBeforeSave(entity); err != nil {
+		return nil, err
 	}
 
 	// 调用保存前的序列化钩子
 	entity.SerializeBeforeSaveDb()
 
+	// 写库前校验 validate 标签（部分更新只校验被更新的列）
+	if selector == nil {
+		if err := GetValidatorInstance().Validate(entity); err != nil {
+			return nil, err
+		}
+	}
+
 	// 获取表名
 	tableName := r.getTableName(entity)
 	if tableName == "" {
-		return NewValidationException("无法获取表名，请确保实体实现了 TableName() 方法并返回非空字符串")
+		return nil, NewValidationException("无法获取表名，请确保实体实现了 TableName() 方法并返回非空字符串")
 	}
 
 	// 获取字段
 	fields := r.getFields(entity)
 	if len(fields) == 0 {
-		return NewValidationException(fmt.Sprintf("实体 %T 没有可映射的字段", entity))
+		return nil, NewValidationException(fmt.Sprintf("实体 %T 没有可映射的字段", entity))
 	}
+	r.applyForcedColumns(fields)
 
-	// 使用自动扫描获取唯一ID列名
+	// 使用自动扫描获取主键列名（支持复合主键）
 	cm := GetCrudManagerInstance()
-	uidColumn := cm.GetPrimaryKeyColumnName(entity)
-	if uidColumn == "" {
-		uidColumn = "id"
+	pkColumns := cm.GetPrimaryKeyColumnNames(entity)
+
+	// 选出要更新的列（加密前判断，避免空值加密后被视为非零值）
+	var setColumns []string
+	if selector != nil {
+		var err error
+		if setColumns, err = selector(entity, fields, pkColumns); err != nil {
+			return nil, err
+		}
+		if err := GetValidatorInstance().ValidateColumns(entity, setColumns); err != nil {
+			return nil, err
+		}
+	} else {
+		for name := range fields {
+			if !containsColumn(pkColumns, name) {
+				setColumns = append(setColumns, name)
+			}
+		}
 	}
 
-	// 获取唯一ID值
-	id, exists := fields[uidColumn]
-	if !exists {
-		return NewValidationException(fmt.Sprintf("实体缺少唯一ID字段 %s，无法执行更新操作", uidColumn))
+	if err := GetFieldEncryptionManagerInstance().encryptFields(entity, fields); err != nil {
+		return nil, err
 	}
 
-	// 检查ID是否为空
-	if r.isZeroValue(id) {
-		return NewValidationException(fmt.Sprintf("实体的唯一ID字段 %s 为空，无法执行更新操作", uidColumn))
+	// 获取并检查每个主键列的值
+	pkConditions := make([]string, 0, len(pkColumns))
+	pkValues := make([]interface{}, 0, len(pkColumns))
+	for _, pkColumn := range pkColumns {
+		value, exists := fields[pkColumn]
+		if !exists {
+			return nil, NewValidationException(fmt.Sprintf("实体缺少唯一ID字段 %s，无法执行更新操作", pkColumn))
+		}
+		if r.isZeroValue(value) {
+			return nil, NewValidationException(fmt.Sprintf("实体的唯一ID字段 %s 为空，无法执行更新操作", pkColumn))
+		}
+		pkConditions = append(pkConditions, pkColumn+" = ?")
+		pkValues = append(pkValues, value)
 	}
+	id := cm.GetPrimaryKeyId(entity)
 
-	setParts := make([]string, 0)
-	values := make([]interface{}, 0)
+	setParts := make([]string, 0, len(setColumns))
+	values := make([]interface{}, 0, len(setColumns))
 
-	for name, value := range fields {
-		if name != uidColumn {
-			setParts = append(setParts, name+" = ?")
-			values = append(values, value)
-		}
+	for _, name := range setColumns {
+		setParts = append(setParts, name+" = ?")
+		values = append(values, fields[name])
 	}
 
 	if len(setParts) == 0 {
-		return NewValidationException(fmt.Sprintf("没有可更新的字段（除了主键 %s）", uidColumn))
+		return nil, NewValidationException(fmt.Sprintf("没有可更新的字段（除了主键 %v）", pkColumns))
 	}
 
-	values = append(values, id)
-
-	sql := "UPDATE " + tableName + " SET " + StringUtilsInstance.Join(setParts, ", ") + " WHERE " + uidColumn + " = ?"
-	LogDebug("执行 UPDATE: 表=%s, 主键列=%s, ID=%v, 更新字段数=%d, SQL=%s", tableName, uidColumn, id, len(setParts), sql)
-
-	result, err := r.db.DataSource.Exec(sql, values...)
+	where, whereParams, err := r.applyScope(entity, strings.Join(pkConditions, " AND "), pkValues)
 	if err != nil {
-		LogError("更新实体失败: 表=%s, ID=%v, 错误=%v, SQL=%s", tableName, id, err, sql)
-		return NewQueryExceptionWithCause(err, fmt.Sprintf("更新表 %s 中 ID=%v 的记录失败", tableName, id))
+		return nil, err
 	}
+	values = append(values, whereParams...)
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		LogWarn("更新无影响: 表=%s, ID=%v, 可能记录不存在", tableName, id)
-	} else {
-		LogDebug("更新成功: 表=%s, ID=%v, 影响行数=%d", tableName, id, rowsAffected)
-	}
+	sql := "UPDATE " + tableName + " SET " + StringUtilsInstance.Join(setParts, ", ") + " WHERE " + where
+	LogDebug("执行 UPDATE: 表=%s, 主键列=%v, ID=%v, 更新字段数=%d, SQL=%s", tableName, pkColumns, id, len(setParts), sql)
 
-	return nil
+	return &updatePlan{tableName: tableName, id: id, sql: sql, values: values}, nil
 }
 
 func (r *BaseCrudRepository) UpdateBatch(entities []IDbEntity) error {
+	if err := r.checkWritable("UpdateBatch", nil); err != nil {
+		return err
+	}
 	// 参数验证
 	if entities == nil {
 		return NewValidationException("实体列表不能为 nil")
@@ -989,10 +1610,17 @@ func (r *BaseCrudRepository) Count(entityType IDbEntity) (int64, error) {
 	}
 
 	sql := "SELECT COUNT(*) FROM " + tableName
+	where, params, err := r.applyScope(entityType, "", nil)
+	if err != nil {
+		return 0, err
+	}
+	if where != "" {
+		sql += " WHERE " + where
+	}
 	LogDebug("执行计数查询: 表=%s, SQL=%s", tableName, sql)
 
 	var count int64
-	err := r.db.DataSource.QueryRow(sql).Scan(&count)
+	err = r.db.executeScalarOnce(sql, params, r.db.statementTimeout(), &count)
 	if err != nil {
 		LogError("计数查询失败: 表=%s, 错误=%v, SQL=%s", tableName, err, sql)
 		return 0, NewQueryExceptionWithCause(err, fmt.Sprintf("统计表 %s 的记录数失败", tableName))
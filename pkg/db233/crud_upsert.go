@@ -0,0 +1,175 @@
+package db233
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+/**
+ * 显式 UPSERT
+ *
+ * Save 按主键隐式 UPSERT；Upsert 可指定冲突列与冲突时更新的列，按方言生成
+ * ON DUPLICATE KEY UPDATE（MySQL）或 ON CONFLICT ... DO UPDATE / DO NOTHING（PostgreSQL）：
+ *
+ *   repo.Upsert(user, db233.OnConflict("email"), db233.DoUpdate("age", "updated_at"))
+ *   repo.Upsert(user, db233.OnConflict("email"), db233.DoNothing())
+ *
+ * 默认冲突列为主键，默认更新除冲突列外的所有插入列
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type UpsertOption func(options *upsertOptions)
+
+/**
+ * Upsert 选项
+ */
+type upsertOptions struct {
+	conflictColumns []string
+	updateColumns   []string
+	doNothing       bool
+}
+
+/**
+ * 指定冲突列（需有唯一约束）
+ */
+func OnConflict(columns ...string) UpsertOption {
+	return func(options *upsertOptions) {
+		options.conflictColumns = append(options.conflictColumns, columns...)
+	}
+}
+
+/**
+ * 冲突时只更新指定列（值取自本次插入的值）
+ */
+func DoUpdate(columns ...string) UpsertOption {
+	return func(options *upsertOptions) {
+		options.updateColumns = append(options.updateColumns, columns...)
+		options.doNothing = false
+	}
+}
+
+/**
+ * 冲突时保留已有行，不做任何修改
+ */
+func DoNothing() UpsertOption {
+	return func(options *upsertOptions) {
+		options.updateColumns = nil
+		options.doNothing = true
+	}
+}
+
+/**
+ * 插入实体，冲突时按选项更新或忽略
+ *
+ * @param entity 实体
+ * @param options OnConflict / DoUpdate / DoNothing
+ */
+func (r *BaseCrudRepository) Upsert(entity IDbEntity, options ...UpsertOption) error {
+	if err := r.checkWritable("Upsert", entity); err != nil {
+		return err
+	}
+	upsert := &upsertOptions{}
+	for _, option := range options {
+		option(upsert)
+	}
+
+	plan, err := r.prepareInsert(entity)
+	if err != nil {
+		return err
+	}
+
+	clause, err := buildUpsertClause(plan, upsert)
+	if err != nil {
+		return err
+	}
+	clause.UpdateColumns = r.withoutForcedColumns(clause.UpdateColumns)
+
+	upsertSql := r.db.GetSqlDialect().ApplyUpsert(plan.insertSql(), clause)
+	LogDebug("执行 UPSERT: 表=%s, 冲突列=%v, 更新列=%v, DoNothing=%v", plan.tableName, clause.ConflictColumns, clause.UpdateColumns, clause.DoNothing)
+
+	// DoNothing 不修改已有行，带作用域谓词时仍可直接使用冲突处理子句
+	var result sql.Result
+	scoped := false
+	if !clause.DoNothing {
+		var executed string
+		result, executed, scoped, err = r.scopedUpsert(r.db, plan, clause.ConflictColumns, clause.UpdateColumns)
+		if scoped {
+			upsertSql = executed
+		}
+	}
+	if !scoped {
+		result, err = r.db.execInsert(upsertSql, plan.values, plan.skippedAutoIncrementColumn)
+	}
+	if err != nil {
+		if isConnectionError(err) {
+			LogWarn("数据库连接已关闭或不可用: 表=%s, 错误=%v", plan.tableName, err)
+			return NewQueryExceptionWithCause(err, "数据库连接已关闭或不可用，请检查网络连接")
+		}
+		LogError("UPSERT 实体失败: 表=%s, 错误=%v, SQL=%s", plan.tableName, err, upsertSql)
+		return NewQueryExceptionWithCause(err, fmt.Sprintf("UPSERT 实体到表 %s 失败", plan.tableName))
+	}
+
+	if plan.skippedAutoIncrementColumn != "" && result != nil {
+		if lastInsertId, err := result.LastInsertId(); err == nil && lastInsertId > 0 {
+			r.setPrimaryKeyValue(entity, lastInsertId)
+		}
+	}
+
+	r.callAfterSave(entity)
+	return nil
+}
+
+/**
+ * 去掉作用域强制列（冲突时不更新）
+ */
+func (r *BaseCrudRepository) withoutForcedColumns(columns []string) []string {
+	kept := make([]string, 0, len(columns))
+	for _, col := range columns {
+		if !r.isForcedColumn(col) {
+			kept = append(kept, col)
+		}
+	}
+	return kept
+}
+
+/**
+ * 根据选项与插入列生成冲突处理子句
+ */
+func buildUpsertClause(plan *insertPlan, upsert *upsertOptions) (UpsertClause, error) {
+	clause := UpsertClause{
+		ConflictColumns:     upsert.conflictColumns,
+		DoNothing:           upsert.doNothing,
+		AutoIncrementColumn: plan.skippedAutoIncrementColumn,
+	}
+	if len(clause.ConflictColumns) == 0 {
+		clause.ConflictColumns = plan.pkColumns
+	}
+	if len(clause.ConflictColumns) == 0 {
+		return clause, NewValidationException(fmt.Sprintf("表 %s 没有主键，UPSERT 需通过 OnConflict 指定冲突列", plan.tableName))
+	}
+	for _, col := range clause.ConflictColumns {
+		if !containsColumn(plan.columns, col) && col != plan.skippedAutoIncrementColumn {
+			return clause, NewValidationException(fmt.Sprintf("冲突列 %s 不在表 %s 的插入列中", col, plan.tableName))
+		}
+	}
+	if clause.DoNothing {
+		return clause, nil
+	}
+
+	if len(upsert.updateColumns) == 0 {
+		for _, col := range plan.columns {
+			if !containsColumn(clause.ConflictColumns, col) && !containsColumn(plan.pkColumns, col) {
+				clause.UpdateColumns = append(clause.UpdateColumns, col)
+			}
+		}
+		return clause, nil
+	}
+	for _, col := range upsert.updateColumns {
+		if !containsColumn(plan.columns, col) {
+			return clause, NewValidationException(fmt.Sprintf("更新列 %s 不在表 %s 的插入列中", col, plan.tableName))
+		}
+	}
+	clause.UpdateColumns = upsert.updateColumns
+	return clause, nil
+}
@@ -0,0 +1,168 @@
+package db233
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * DashboardServer - 监控仪表板 HTTP 服务
+ *
+ * 路由：
+ *   GET /dashboard/snapshot        仪表板快照（JSON）
+ *   GET /dashboard/status          仪表板状态（JSON）
+ *   GET /metrics/{collector}       监控数据收集器快照（可被 MetricsFederation 抓取）
+ *   GET /livez /readyz /healthz     健康检查（需设置 Health，见 HealthEndpoint）
+ *   GET /debug/pprof/...           pprof 性能分析端点（需开启 EnablePprof）
+ *
+ * pprof 会暴露堆栈等运行时细节，默认关闭，建议只在内网地址开启
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type DashboardServer struct {
+	dashboard *MonitoringDashboard
+	options   DashboardServerOptions
+
+	server   *http.Server
+	listener net.Listener
+	mu       sync.Mutex
+}
+
+/**
+ * DashboardServerOptions - 仪表板服务选项
+ */
+type DashboardServerOptions struct {
+	// 监听地址，如 ":9233"
+	Addr string
+
+	// 是否暴露 /debug/pprof 端点
+	EnablePprof bool
+
+	// 健康检查端点（可选），挂载 /livez、/readyz、/healthz
+	Health *HealthEndpoint
+}
+
+/**
+ * 创建仪表板服务
+ */
+func NewDashboardServer(dashboard *MonitoringDashboard, options DashboardServerOptions) *DashboardServer {
+	return &DashboardServer{dashboard: dashboard, options: options}
+}
+
+/**
+ * 构造路由（可挂载到已有的 HTTP 服务）
+ */
+func (ds *DashboardServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/dashboard/snapshot", func(w http.ResponseWriter, r *http.Request) {
+		writeDashboardJson(w, ds.dashboard.GetCurrentSnapshot())
+	})
+	mux.HandleFunc("/dashboard/status", func(w http.ResponseWriter, r *http.Request) {
+		writeDashboardJson(w, ds.dashboard.GetStatus())
+	})
+	mux.HandleFunc("/metrics/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/metrics/")
+		ds.dashboard.mu.RLock()
+		collector, exists := ds.dashboard.metricsCollectors[name]
+		ds.dashboard.mu.RUnlock()
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		collector.SnapshotHandler().ServeHTTP(w, r)
+	})
+
+	if ds.options.Health != nil {
+		mux.Handle("/livez", ds.options.Health.LivenessHandler())
+		mux.Handle("/readyz", ds.options.Health.ReadinessHandler())
+		mux.Handle("/healthz", ds.options.Health.ReadinessHandler())
+	}
+
+	if ds.options.EnablePprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	return mux
+}
+
+/**
+ * 启动服务（非阻塞）
+ */
+func (ds *DashboardServer) Start() error {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.server != nil {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", ds.options.Addr)
+	if err != nil {
+		return NewConfigurationExceptionWithCause(err, "仪表板服务监听失败: "+ds.options.Addr)
+	}
+	ds.listener = listener
+	ds.server = &http.Server{
+		Handler:           ds.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	server := ds.server
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			LogError("仪表板服务异常退出: %v", err)
+		}
+	}()
+	LogInfo("仪表板服务启动: %s, pprof=%v", listener.Addr(), ds.options.EnablePprof)
+	return nil
+}
+
+/**
+ * 停止服务
+ */
+func (ds *DashboardServer) Stop() error {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := ds.server.Shutdown(ctx)
+	ds.server = nil
+	ds.listener = nil
+	LogInfo("仪表板服务停止")
+	return err
+}
+
+/**
+ * 实际监听地址（Addr 为 ":0" 时用于获取随机端口）
+ */
+func (ds *DashboardServer) GetAddr() string {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.listener == nil {
+		return ds.options.Addr
+	}
+	return ds.listener.Addr().String()
+}
+
+/**
+ * 写出 JSON 响应
+ */
+func writeDashboardJson(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		LogError("仪表板响应编码失败: %v", err)
+	}
+}
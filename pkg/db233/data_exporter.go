@@ -0,0 +1,427 @@
+package db233
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * DataExporter - 实体表数据导出器
+ *
+ * 将实体表导出为 CSV 或 JSON Lines，
+ * 开启脱敏模式时按字段的 pii 标签应用脱敏器，便于把生产数据安全地复制到预发环境
+ *
+ * 标签示例：
+ *   Email string `db:"email" pii:"faker:email"`
+ *   Phone string `db:"phone" pii:"hash"`
+ *   Note  string `db:"note" pii:"nullify"`
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type DataExporter struct {
+	db          *Db
+	repository  *BaseCrudRepository
+	anonymizers map[string]FieldAnonymizer
+	mu          sync.RWMutex
+}
+
+/**
+ * EnumExportFormat - 导出格式
+ */
+type EnumExportFormat string
+
+const (
+	// EnumExportFormatCSV CSV 格式（首行为列名）
+	EnumExportFormatCSV EnumExportFormat = "csv"
+	// EnumExportFormatJSONL JSON Lines 格式（每行一个 JSON 对象）
+	EnumExportFormatJSONL EnumExportFormat = "jsonl"
+)
+
+/**
+ * ExportOptions - 导出选项
+ */
+type ExportOptions struct {
+	// 导出格式，默认 CSV
+	Format EnumExportFormat
+
+	// 是否按 pii 标签脱敏
+	Anonymize bool
+}
+
+/**
+ * FieldAnonymizer - 字段脱敏器接口
+ */
+type FieldAnonymizer interface {
+	/**
+	 * 脱敏字段值
+	 *
+	 * @param column 列名
+	 * @param value 原始值（可能为 nil）
+	 * @param arg pii 标签参数，如 pii:"faker:email" 中的 email
+	 * @return interface{} 脱敏后的值
+	 */
+	Anonymize(column string, value interface{}, arg string) interface{}
+
+	/**
+	 * 获取脱敏器名称（与 pii 标签名对应）
+	 */
+	GetName() string
+}
+
+/**
+ * 创建数据导出器（内置 hash / faker / nullify 脱敏器）
+ */
+func NewDataExporter(db *Db) *DataExporter {
+	exporter := &DataExporter{
+		db:          db,
+		repository:  NewBaseCrudRepository(db),
+		anonymizers: make(map[string]FieldAnonymizer),
+	}
+	exporter.RegisterAnonymizer(NewHashAnonymizer(""))
+	exporter.RegisterAnonymizer(NewFakerAnonymizer())
+	exporter.RegisterAnonymizer(NewNullifyAnonymizer())
+	return exporter
+}
+
+/**
+ * 注册脱敏器（同名覆盖）
+ */
+func (e *DataExporter) RegisterAnonymizer(anonymizer FieldAnonymizer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.anonymizers[anonymizer.GetName()] = anonymizer
+}
+
+/**
+ * 导出实体表
+ *
+ * @param w 输出目标
+ * @param entityType 实体类型
+ * @param options 导出选项
+ * @return int 导出行数
+ * @return error 导出错误
+ */
+func (e *DataExporter) Export(w io.Writer, entityType IDbEntity, options ExportOptions) (int, error) {
+	if entityType == nil {
+		return 0, NewValidationException("实体类型不能为 nil")
+	}
+
+	tableName := e.repository.getTableName(entityType)
+	return e.exportQuery(w, entityType, "SELECT * FROM "+tableName, nil, options)
+}
+
+/**
+ * 执行查询并流式写出
+ */
+func (e *DataExporter) exportQuery(w io.Writer, entityType IDbEntity, query string, params []interface{}, options ExportOptions) (int, error) {
+	rows, err := e.db.DataSource.Query(query, params...)
+	if err != nil {
+		return 0, NewQueryExceptionWithCause(err, "导出查询失败: "+query)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, NewQueryExceptionWithCause(err, "获取导出列失败")
+	}
+
+	var piiRules map[string]string
+	if options.Anonymize {
+		piiRules = GetPiiRules(entityType)
+	}
+
+	writer, err := newRowWriter(w, options.Format, columns)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		scanTargets := make([]interface{}, len(columns))
+		for i := range values {
+			scanTargets[i] = &values[i]
+		}
+		if err := rows.Scan(scanTargets...); err != nil {
+			return count, NewQueryExceptionWithCause(err, "扫描导出行失败")
+		}
+
+		for i, col := range columns {
+			values[i] = normalizeExportValue(values[i])
+			if rule, ok := piiRules[col]; ok {
+				values[i] = e.anonymize(col, values[i], rule)
+			}
+		}
+
+		if err := writer.writeRow(values); err != nil {
+			return count, NewDb233ExceptionWithCause(err, "写出导出行失败")
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, NewQueryExceptionWithCause(err, "遍历导出结果失败")
+	}
+	if err := writer.flush(); err != nil {
+		return count, NewDb233ExceptionWithCause(err, "刷新导出输出失败")
+	}
+
+	LogInfo("数据导出完成: 表=%s, 格式=%s, 行数=%d, 脱敏=%v", e.repository.getTableName(entityType), writer.format, count, options.Anonymize)
+	return count, nil
+}
+
+/**
+ * 按规则脱敏单个值，规则格式：name 或 name:arg
+ */
+func (e *DataExporter) anonymize(column string, value interface{}, rule string) interface{} {
+	name, arg := rule, ""
+	if idx := strings.Index(rule, ":"); idx >= 0 {
+		name, arg = rule[:idx], rule[idx+1:]
+	}
+
+	e.mu.RLock()
+	anonymizer, exists := e.anonymizers[name]
+	e.mu.RUnlock()
+	if !exists {
+		// 未知规则时宁可置空，也不导出原始 PII
+		LogWarn("未找到脱敏器，字段将被置空: 列=%s, 规则=%s", column, rule)
+		return nil
+	}
+	return anonymizer.Anonymize(column, value, arg)
+}
+
+/**
+ * 获取实体的 PII 规则（列名 -> pii 标签值，支持嵌入结构体）
+ */
+func GetPiiRules(entityType interface{}) map[string]string {
+	t := reflect.TypeOf(entityType)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	rules := make(map[string]string)
+	collectColumnTagsRecursive(t, "pii", rules)
+	return rules
+}
+
+/**
+ * 递归收集字段上指定标签的值（列名 -> 标签值，支持嵌入结构体）
+ */
+func collectColumnTagsRecursive(t reflect.Type, tagName string, rules map[string]string) {
+	cm := GetCrudManagerInstance()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				collectColumnTagsRecursive(embeddedType, tagName, rules)
+				continue
+			}
+		}
+
+		rule := strings.TrimSpace(field.Tag.Get(tagName))
+		if rule == "" {
+			continue
+		}
+		if colName := cm.GetColumnName(field); colName != "" {
+			rules[colName] = rule
+		}
+	}
+}
+
+/**
+ * 将驱动返回的值转换为便于导出的类型
+ */
+func normalizeExportValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return v
+	}
+}
+
+/**
+ * rowWriter - 按格式写出行
+ */
+type rowWriter struct {
+	format    EnumExportFormat
+	columns   []string
+	csvWriter *csv.Writer
+	encoder   *json.Encoder
+}
+
+/**
+ * 创建行写出器，CSV 格式会先写出列名
+ */
+func newRowWriter(w io.Writer, format EnumExportFormat, columns []string) (*rowWriter, error) {
+	if format == "" {
+		format = EnumExportFormatCSV
+	}
+
+	rw := &rowWriter{format: format, columns: columns}
+	switch format {
+	case EnumExportFormatCSV:
+		rw.csvWriter = csv.NewWriter(w)
+		if err := rw.csvWriter.Write(columns); err != nil {
+			return nil, NewDb233ExceptionWithCause(err, "写出 CSV 表头失败")
+		}
+	case EnumExportFormatJSONL:
+		rw.encoder = json.NewEncoder(w)
+	default:
+		return nil, NewValidationException(fmt.Sprintf("不支持的导出格式: %s", format))
+	}
+	return rw, nil
+}
+
+/**
+ * 写出一行
+ */
+func (rw *rowWriter) writeRow(values []interface{}) error {
+	if rw.csvWriter != nil {
+		record := make([]string, len(values))
+		for i, v := range values {
+			if v != nil {
+				record[i] = fmt.Sprintf("%v", v)
+			}
+		}
+		return rw.csvWriter.Write(record)
+	}
+
+	row := make(map[string]interface{}, len(values))
+	for i, col := range rw.columns {
+		row[col] = values[i]
+	}
+	return rw.encoder.Encode(row)
+}
+
+/**
+ * 刷新缓冲
+ */
+func (rw *rowWriter) flush() error {
+	if rw.csvWriter != nil {
+		rw.csvWriter.Flush()
+		return rw.csvWriter.Error()
+	}
+	return nil
+}
+
+/**
+ * HashAnonymizer - 哈希脱敏器（SHA-256，可加盐）
+ *
+ * 相同输入得到相同输出，可保留关联关系
+ */
+type HashAnonymizer struct {
+	salt string
+}
+
+/**
+ * 创建哈希脱敏器
+ */
+func NewHashAnonymizer(salt string) *HashAnonymizer {
+	return &HashAnonymizer{salt: salt}
+}
+
+/**
+ * 脱敏字段值
+ */
+func (a *HashAnonymizer) Anonymize(column string, value interface{}, arg string) interface{} {
+	if value == nil {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(a.salt + fmt.Sprintf("%v", value)))
+	return hex.EncodeToString(sum[:])
+}
+
+/**
+ * 获取脱敏器名称
+ */
+func (a *HashAnonymizer) GetName() string {
+	return "hash"
+}
+
+/**
+ * FakerAnonymizer - 伪造数据脱敏器
+ *
+ * 根据原值确定性地生成假数据，参数指定类型：email / name / phone，其他类型生成通用占位值
+ */
+type FakerAnonymizer struct{}
+
+/**
+ * 创建伪造数据脱敏器
+ */
+func NewFakerAnonymizer() *FakerAnonymizer {
+	return &FakerAnonymizer{}
+}
+
+/**
+ * 脱敏字段值
+ */
+func (a *FakerAnonymizer) Anonymize(column string, value interface{}, arg string) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(fmt.Sprintf("%s:%v", column, value)))
+	seed := h.Sum64()
+
+	switch arg {
+	case "email":
+		return fmt.Sprintf("user_%08x@example.com", uint32(seed))
+	case "name":
+		return fmt.Sprintf("User %06X", seed%0xFFFFFF)
+	case "phone":
+		return fmt.Sprintf("1%010d", seed%10000000000)
+	default:
+		return fmt.Sprintf("anon_%08x", uint32(seed))
+	}
+}
+
+/**
+ * 获取脱敏器名称
+ */
+func (a *FakerAnonymizer) GetName() string {
+	return "faker"
+}
+
+/**
+ * NullifyAnonymizer - 置空脱敏器
+ */
+type NullifyAnonymizer struct{}
+
+/**
+ * 创建置空脱敏器
+ */
+func NewNullifyAnonymizer() *NullifyAnonymizer {
+	return &NullifyAnonymizer{}
+}
+
+/**
+ * 脱敏字段值
+ */
+func (a *NullifyAnonymizer) Anonymize(column string, value interface{}, arg string) interface{} {
+	return nil
+}
+
+/**
+ * 获取脱敏器名称
+ */
+func (a *NullifyAnonymizer) GetName() string {
+	return "nullify"
+}
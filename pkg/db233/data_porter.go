@@ -0,0 +1,379 @@
+package db233
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+/**
+ * DataPorter - 实体表导入导出工具
+ *
+ * 导出：在 DataExporter 基础上支持列选择和 WHERE 过滤
+ * 导入：流式读取 CSV / JSON Lines，分批插入，支持约束冲突策略（跳过/中止/覆盖）和进度回调
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type DataPorter struct {
+	db         *Db
+	exporter   *DataExporter
+	repository *BaseCrudRepository
+}
+
+/**
+ * PortExportOptions - 导出选项
+ */
+type PortExportOptions struct {
+	ExportOptions
+
+	// 导出的列，为空时导出全部列
+	Columns []string
+
+	// WHERE 条件（不含 WHERE 关键字），为空时不过滤
+	Where string
+
+	// WHERE 条件参数
+	Params []interface{}
+}
+
+/**
+ * EnumImportConflictPolicy - 导入约束冲突策略
+ */
+type EnumImportConflictPolicy string
+
+const (
+	// EnumImportConflictPolicyAbort 遇到冲突中止导入（默认）
+	EnumImportConflictPolicyAbort EnumImportConflictPolicy = "abort"
+	// EnumImportConflictPolicySkip 跳过冲突行
+	EnumImportConflictPolicySkip EnumImportConflictPolicy = "skip"
+	// EnumImportConflictPolicyUpsert 冲突时覆盖已有行
+	EnumImportConflictPolicyUpsert EnumImportConflictPolicy = "upsert"
+)
+
+/**
+ * ImportOptions - 导入选项
+ */
+type ImportOptions struct {
+	// 输入格式，默认 CSV
+	Format EnumExportFormat
+
+	// 每批插入行数，默认 500
+	BatchSize int
+
+	// 约束冲突策略，默认中止
+	ConflictPolicy EnumImportConflictPolicy
+
+	// 进度回调，每批完成后调用
+	Progress func(result *ImportResult)
+}
+
+/**
+ * ImportResult - 导入结果
+ */
+type ImportResult struct {
+	// 已读取行数
+	Total int64
+
+	// 实际写入行数（含覆盖）
+	Imported int64
+
+	// 因冲突跳过的行数
+	Skipped int64
+
+	// 已完成批次数
+	Batches int
+}
+
+/**
+ * 创建导入导出工具
+ */
+func NewDataPorter(db *Db) *DataPorter {
+	return &DataPorter{
+		db:         db,
+		exporter:   NewDataExporter(db),
+		repository: NewBaseCrudRepository(db),
+	}
+}
+
+/**
+ * 获取内部导出器（可注册自定义脱敏器）
+ */
+func (p *DataPorter) GetExporter() *DataExporter {
+	return p.exporter
+}
+
+/**
+ * 导出实体表
+ *
+ * @param w 输出目标
+ * @param entityType 实体类型
+ * @param options 导出选项
+ * @return int 导出行数
+ */
+func (p *DataPorter) Export(w io.Writer, entityType IDbEntity, options PortExportOptions) (int, error) {
+	if entityType == nil {
+		return 0, NewValidationException("实体类型不能为 nil")
+	}
+
+	selectColumns := "*"
+	if len(options.Columns) > 0 {
+		if err := p.validateColumns(options.Columns, entityType); err != nil {
+			return 0, err
+		}
+		selectColumns = strings.Join(options.Columns, ", ")
+	}
+
+	query := "SELECT " + selectColumns + " FROM " + p.repository.getTableName(entityType)
+	if options.Where != "" {
+		query += " WHERE " + options.Where
+	}
+	return p.exporter.exportQuery(w, entityType, query, options.Params, options.ExportOptions)
+}
+
+/**
+ * 导入数据到实体表
+ *
+ * 中止策略下，已完成的批次不会回滚；当前批次在事务中执行，失败时整体回滚
+ *
+ * @param r 输入源
+ * @param entityType 实体类型
+ * @param options 导入选项
+ * @return *ImportResult 导入结果（出错时为已完成部分）
+ */
+func (p *DataPorter) Import(r io.Reader, entityType IDbEntity, options ImportOptions) (*ImportResult, error) {
+	result := &ImportResult{}
+	if entityType == nil {
+		return result, NewValidationException("实体类型不能为 nil")
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = 500
+	}
+	if options.ConflictPolicy == "" {
+		options.ConflictPolicy = EnumImportConflictPolicyAbort
+	}
+
+	reader, err := newRowReader(r, options.Format)
+	if err != nil {
+		return result, err
+	}
+
+	tableName := p.repository.getTableName(entityType)
+	var columns []string
+	batch := make([][]interface{}, 0, options.BatchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := p.insertBatch(tableName, columns, batch, options.ConflictPolicy, result); err != nil {
+			return err
+		}
+		batch = batch[:0]
+		if options.Progress != nil {
+			options.Progress(result)
+		}
+		return nil
+	}
+
+	for {
+		row, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return result, NewValidationExceptionWithCause(err, fmt.Sprintf("解析第 %d 行失败", result.Total+1))
+		}
+
+		if columns == nil {
+			columns = reader.columns(row)
+			if err := p.validateColumns(columns, entityType); err != nil {
+				return result, err
+			}
+		}
+
+		values := make([]interface{}, len(columns))
+		for i, col := range columns {
+			values[i] = row[col]
+		}
+		batch = append(batch, values)
+		result.Total++
+
+		if len(batch) >= options.BatchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return result, err
+	}
+
+	LogInfo("数据导入完成: 表=%s, 读取=%d, 写入=%d, 跳过=%d, 批次=%d", tableName, result.Total, result.Imported, result.Skipped, result.Batches)
+	return result, nil
+}
+
+/**
+ * 批量插入一批数据
+ */
+func (p *DataPorter) insertBatch(tableName string, columns []string, batch [][]interface{}, policy EnumImportConflictPolicy, result *ImportResult) error {
+	rowPlaceholder := "(" + strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",") + ")"
+	placeholders := make([]string, len(batch))
+	params := make([]interface{}, 0, len(batch)*len(columns))
+	for i, values := range batch {
+		placeholders[i] = rowPlaceholder
+		params = append(params, values...)
+	}
+
+	insert := "INSERT INTO "
+	suffix := ""
+	switch policy {
+	case EnumImportConflictPolicySkip:
+		insert = "INSERT IGNORE INTO "
+	case EnumImportConflictPolicyUpsert:
+		updateParts := make([]string, len(columns))
+		for i, col := range columns {
+			updateParts[i] = col + " = VALUES(" + col + ")"
+		}
+		suffix = " ON DUPLICATE KEY UPDATE " + strings.Join(updateParts, ", ")
+	}
+	query := insert + tableName + " (" + strings.Join(columns, ", ") + ") VALUES " + strings.Join(placeholders, ", ") + suffix
+
+	var affected int64
+	err := WithTransaction(p.db, func(tm *TransactionManager) error {
+		res, err := tm.Exec(query, params...)
+		if err != nil {
+			return err
+		}
+		affected, _ = res.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		LogError("批量导入失败: 表=%s, 批次=%d, 错误=%v", tableName, result.Batches+1, err)
+		return NewQueryExceptionWithCause(err, fmt.Sprintf("导入第 %d 批数据到表 %s 失败", result.Batches+1, tableName))
+	}
+
+	rows := int64(len(batch))
+	if policy == EnumImportConflictPolicySkip {
+		result.Imported += affected
+		result.Skipped += rows - affected
+	} else {
+		// UPSERT 的影响行数对更新行计 2，按批次行数统计
+		result.Imported += rows
+	}
+	result.Batches++
+	return nil
+}
+
+/**
+ * 校验列名均为实体字段，防止拼接任意 SQL
+ */
+func (p *DataPorter) validateColumns(columns []string, entityType IDbEntity) error {
+	t := reflect.TypeOf(entityType)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	colList := make([]string, 0)
+	GetCrudManagerInstance().collectColumnsRecursive(t, &colList)
+
+	known := make(map[string]bool, len(colList))
+	for _, col := range colList {
+		known[col] = true
+	}
+	for _, col := range columns {
+		if !known[col] {
+			return NewValidationException(fmt.Sprintf("实体 %T 不包含列: %s", entityType, col))
+		}
+	}
+	return nil
+}
+
+/**
+ * rowReader - 按格式读取行
+ */
+type rowReader struct {
+	csvReader *csv.Reader
+	header    []string
+	decoder   *json.Decoder
+}
+
+/**
+ * 创建行读取器，CSV 格式会先读取表头
+ */
+func newRowReader(r io.Reader, format EnumExportFormat) (*rowReader, error) {
+	if format == "" {
+		format = EnumExportFormatCSV
+	}
+
+	switch format {
+	case EnumExportFormatCSV:
+		reader := &rowReader{csvReader: csv.NewReader(r)}
+		header, err := reader.csvReader.Read()
+		if err == io.EOF {
+			return reader, nil
+		}
+		if err != nil {
+			return nil, NewValidationExceptionWithCause(err, "读取 CSV 表头失败")
+		}
+		reader.header = header
+		return reader, nil
+	case EnumExportFormatJSONL:
+		decoder := json.NewDecoder(bufio.NewReader(r))
+		decoder.UseNumber()
+		return &rowReader{decoder: decoder}, nil
+	default:
+		return nil, NewValidationException(fmt.Sprintf("不支持的导入格式: %s", format))
+	}
+}
+
+/**
+ * 读取下一行（列名 -> 值），结束时返回 io.EOF
+ *
+ * CSV 空单元格视为 NULL，与导出时 NULL 写为空串对应
+ */
+func (rr *rowReader) next() (map[string]interface{}, error) {
+	if rr.decoder != nil {
+		row := make(map[string]interface{})
+		if err := rr.decoder.Decode(&row); err != nil {
+			return nil, err
+		}
+		return row, nil
+	}
+
+	if rr.header == nil {
+		return nil, io.EOF
+	}
+	record, err := rr.csvReader.Read()
+	if err != nil {
+		return nil, err
+	}
+	row := make(map[string]interface{}, len(rr.header))
+	for i, col := range rr.header {
+		if i < len(record) && record[i] != "" {
+			row[col] = record[i]
+		} else {
+			row[col] = nil
+		}
+	}
+	return row, nil
+}
+
+/**
+ * 确定导入列：CSV 使用表头，JSONL 使用首行的键（排序后）
+ */
+func (rr *rowReader) columns(firstRow map[string]interface{}) []string {
+	if rr.header != nil {
+		return rr.header
+	}
+	columns := make([]string, 0, len(firstRow))
+	for col := range firstRow {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+	return columns
+}
@@ -13,6 +13,8 @@ const (
 	EnumDatabaseTypeMySQL EnumDatabaseType = "mysql"
 	// EnumDatabaseTypePostgreSQL PostgreSQL 数据库
 	EnumDatabaseTypePostgreSQL EnumDatabaseType = "postgresql"
+	// EnumDatabaseTypeSQLServer SQL Server 数据库
+	EnumDatabaseTypeSQLServer EnumDatabaseType = "sqlserver"
 )
 
 /**
@@ -26,5 +28,5 @@ func (dt EnumDatabaseType) String() string {
  * 判断是否为有效的数据库类型
  */
 func (dt EnumDatabaseType) IsValid() bool {
-	return dt == EnumDatabaseTypeMySQL || dt == EnumDatabaseTypePostgreSQL
+	return dt == EnumDatabaseTypeMySQL || dt == EnumDatabaseTypePostgreSQL || dt == EnumDatabaseTypeSQLServer
 }
@@ -1,8 +1,13 @@
 package db233
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"log"
+	"sync"
+	"time"
 )
 
 /**
@@ -78,6 +83,74 @@ type Db struct {
 	DbId         int
 	DbGroup      *DbGroup
 	DatabaseType EnumDatabaseType // 数据库类型，默认为 MySQL
+
+	// CompatibilityMode MySQL 兼容模式（TiDB / Vitess），影响建表策略与 IsRetryableError
+	CompatibilityMode EnumMySQLCompatibilityMode
+
+	// StatementTimeout 默认语句超时时间，0 表示不限制
+	StatementTimeout time.Duration
+
+	// 视图是否通过 WithStatementTimeout 覆盖了默认超时，未覆盖时执行时读取原 Db 的 StatementTimeout
+	statementTimeoutOverridden bool
+
+	// 性能监控器（可选），用于记录超时等事件
+	performanceMonitor *PerformanceMonitor
+
+	// 驱动连接 -> 服务端连接 ID 缓存，用于超时后 KILL QUERY，见 lookupConnectionId
+	connectionIds   map[driver.Conn]int64
+	connectionIdsMu sync.Mutex
+
+	// 维护模式状态，nil 表示不在维护中
+	maintenance   *dbMaintenanceState
+	maintenanceMu sync.RWMutex
+
+	// SQL 白名单（可选），nil 表示不限制原生 SQL
+	queryAllowlist *QueryAllowlist
+
+	// 仅作用于该 Db 的插件
+	plugins PluginChain
+
+	// 锁诊断采集器（可选），死锁 / 锁等待超时时自动采集
+	diagnosticsCollector *DiagnosticsCollector
+
+	// 只使用该 Db 自己的插件（不合并全局与 DbGroup 插件），见 NewMockDb
+	isolatedPlugins bool
+
+	// 时间策略（可选），nil 表示使用全局默认策略，见 SetTimePolicy
+	timePolicy *TimePolicy
+
+	// 熔断器（可选），打开时 SQL 直接返回 *CircuitOpenException
+	circuitBreaker *CircuitBreaker
+
+	// 语句限流器（可选），超出限制时返回 *QueryThrottledException
+	queryThrottle *QueryThrottle
+
+	// 优先级闸门（可选），连接池饱和时按优先级分配连接，见 SetPriorityGate
+	priorityGate *PriorityConnectionGate
+
+	// 获取连接的优先级，及视图对应的原 Db（见 WithPriority / WithContext）
+	priority     EnumQueryPriority
+	priorityRoot *Db
+
+	// 绑定的事务，非 nil 时语句在该事务中执行（见 ContextWithTx）
+	tx *TransactionManager
+
+	// 是否自动记录语句与事务到性能监控器，见 NewInstrumentedDb
+	instrumented bool
+
+	// 连接池事件采样器，见 NewPoolEventSampler
+	poolEventSampler *PoolEventSampler
+
+	// 连接与事务泄漏检测器，见 SetLeakDetector
+	leakDetector *LeakDetector
+
+	// 查询标签（见 WithQueryTag），及是否关闭标签注释
+	queryTag                string
+	queryTagCommentDisabled bool
+
+	// 执行中的语句数与排空标记，见 Drain
+	inFlight int64
+	draining int32
 }
 
 /**
@@ -118,6 +191,25 @@ func NewDbWithType(dataSource *sql.DB, dbId int, dbGroup *DbGroup, dbType EnumDa
 	}
 }
 
+/**
+ * 创建便于 sqlmock 等模拟驱动使用的 Db
+ *
+ * 不继承全局插件与所属 DbGroup，也不设置语句超时（不会额外执行 SELECT CONNECTION_ID() 等语句），
+ * 模拟驱动只会收到存储库生成的 SQL：
+ *
+ *   sqlDb, mock, _ := sqlmock.New()
+ *   db := db233.NewMockDb(sqlDb, db233.EnumDatabaseTypeMySQL)
+ *   mock.ExpectExec("INSERT INTO player").WillReturnResult(sqlmock.NewResult(1, 1))
+ *
+ * @param dataSource 模拟数据源
+ * @param dbType 数据库类型（决定生成的方言），为空时为 MySQL
+ */
+func NewMockDb(dataSource *sql.DB, dbType EnumDatabaseType) *Db {
+	db := NewDbWithType(dataSource, 0, nil, dbType)
+	db.isolatedPlugins = true
+	return db
+}
+
 /**
  * 获取数据源
  *
@@ -136,21 +228,28 @@ func (db *Db) GetDataSource() *sql.DB {
  * @return []interface{} 结果列表
  */
 func (db *Db) ExecuteQuery(sql string, paramsArray [][]interface{}, returnType interface{}) []interface{} {
+	if err := db.checkQueryAllowed(sql); err != nil {
+		return nil
+	}
+	return db.executeQueryBatch(sql, paramsArray, returnType)
+}
+
+/**
+ * 执行查询（批量参数，不经过 SQL 白名单，供库内部生成的 SQL 使用）
+ */
+func (db *Db) executeQueryBatch(sql string, paramsArray [][]interface{}, returnType interface{}) []interface{} {
 	var results []interface{}
 	for _, params := range paramsArray {
-		rows, err := db.DataSource.Query(sql, params...)
+		batchResults, err := db.executeQueryOnce(sql, params, returnType, db.statementTimeout())
 		if err != nil {
 			// 友好的错误提示
 			if isConnectionError(err) {
-				LogWarn("数据库连接已关闭或不可用: %v (SQL: %s)", err, sql)
+				LogFields(WARN, "数据库连接已关闭或不可用", "error", err, "sql", sql)
 			} else {
-				LogError("查询执行失败: %v (SQL: %s)", err, sql)
+				LogFields(ERROR, "查询执行失败", "error", err, "sql", sql)
 			}
 			continue
 		}
-
-		// 使用 ORM 映射
-		batchResults := OrmHandlerInstance.OrmBatch(rows, returnType)
 		results = append(results, batchResults...)
 	}
 	return results
@@ -167,8 +266,8 @@ func (db *Db) ExecuteQueryByStatement(statement *SqlStatement) []interface{} {
 	if !statement.IsQuery {
 		return nil
 	}
-	// 简化：假设单条 SQL，无参数
-	return db.ExecuteQuery(statement.SqlList[0], [][]interface{}{}, statement.ReturnType)
+	// 简化：假设单条 SQL
+	return db.ExecuteQuery(statement.SqlList[0], [][]interface{}{statement.Params}, statement.ReturnType)
 }
 
 // ExecuteUpdateByStatement 使用 SqlStatement 执行更新
@@ -184,12 +283,14 @@ func (db *Db) ExecuteUpdateByStatement(statement *SqlStatement) int {
 	}
 	totalAffected := 0
 	for _, sql := range statement.SqlList {
-		result, err := db.DataSource.Exec(sql)
+		if err := db.checkQueryAllowed(sql); err != nil {
+			continue
+		}
+		affected, err := db.executeUpdateOnce(sql, statement.Params, db.statementTimeout())
 		if err != nil {
 			log.Printf("ExecuteUpdate error: %v", err)
 			continue
 		}
-		affected, _ := result.RowsAffected()
 		totalAffected += int(affected)
 	}
 	return totalAffected
@@ -204,19 +305,147 @@ func (db *Db) ExecuteUpdateByStatement(statement *SqlStatement) int {
  * @return int 影响行数
  */
 func (db *Db) ExecuteOriginalUpdate(sql string, multiRowParams [][]interface{}) int {
+	if err := db.checkQueryAllowed(sql); err != nil {
+		return 0
+	}
+	return db.executeUpdateBatch(sql, multiRowParams)
+}
+
+/**
+ * 执行批量更新（不经过 SQL 白名单，供库内部生成的 SQL 使用）
+ */
+func (db *Db) executeUpdateBatch(sql string, multiRowParams [][]interface{}) int {
 	totalAffected := 0
 	for _, params := range multiRowParams {
-		result, err := db.DataSource.Exec(sql, params...)
+		affected, err := db.executeUpdateOnce(sql, params, db.statementTimeout())
 		if err != nil {
 			log.Printf("ExecuteOriginalUpdate error: %v", err)
 			continue
 		}
-		affected, _ := result.RowsAffected()
 		totalAffected += int(affected)
 	}
 	return totalAffected
 }
 
+/**
+ * 执行查询（批量参数），失败时返回错误
+ *
+ * 与 ExecuteQuery 相同，但 SQL 白名单拒绝或任一批执行失败时返回错误，不再执行后续批次
+ *
+ * @param sql SQL 语句
+ * @param paramsArray 参数数组
+ * @param returnType 返回类型
+ * @return []interface{} 结果列表
+ * @return error 白名单拒绝返回 *QueryNotAllowedException，熔断、限流、超时等异常原样返回
+ */
+func (db *Db) ExecuteQueryE(sql string, paramsArray [][]interface{}, returnType interface{}) ([]interface{}, error) {
+	if err := db.checkQueryAllowed(sql); err != nil {
+		return nil, err
+	}
+	return db.executeQueryBatchE(sql, paramsArray, returnType)
+}
+
+/**
+ * 使用 SqlStatement 执行查询，失败时返回错误
+ *
+ * @param statement SQL 语句对象
+ * @return []interface{} 结果列表
+ * @return error 执行错误，同 ExecuteQueryE
+ */
+func (db *Db) ExecuteQueryByStatementE(statement *SqlStatement) ([]interface{}, error) {
+	if !statement.IsQuery {
+		return nil, NewValidationException("SqlStatement 不是查询语句")
+	}
+	return db.ExecuteQueryE(statement.SqlList[0], [][]interface{}{statement.Params}, statement.ReturnType)
+}
+
+/**
+ * 使用 SqlStatement 执行更新，失败时返回错误
+ *
+ * 所有语句先经过 SQL 白名单，任一被拒绝时不执行任何语句
+ *
+ * @param statement SQL 语句对象
+ * @return int 影响行数
+ * @return error 执行错误，同 ExecuteOriginalUpdateE
+ */
+func (db *Db) ExecuteUpdateByStatementE(statement *SqlStatement) (int, error) {
+	if statement.IsQuery {
+		return 0, NewValidationException("SqlStatement 是查询语句，不能作为更新执行")
+	}
+	for _, sql := range statement.SqlList {
+		if err := db.checkQueryAllowed(sql); err != nil {
+			return 0, err
+		}
+	}
+	totalAffected := 0
+	for _, sql := range statement.SqlList {
+		affected, err := db.executeUpdateBatchE(sql, [][]interface{}{statement.Params})
+		totalAffected += affected
+		if err != nil {
+			return totalAffected, err
+		}
+	}
+	return totalAffected, nil
+}
+
+/**
+ * 执行批量更新，失败时返回错误
+ *
+ * 与 ExecuteOriginalUpdate 相同，但 SQL 白名单拒绝或任一行执行失败时返回错误，不再执行后续行
+ *
+ * @param sql SQL 语句
+ * @param multiRowParams 多行参数
+ * @return int 失败前已影响的行数
+ * @return error 白名单拒绝返回 *QueryNotAllowedException，熔断、限流、超时等异常原样返回
+ */
+func (db *Db) ExecuteOriginalUpdateE(sql string, multiRowParams [][]interface{}) (int, error) {
+	if err := db.checkQueryAllowed(sql); err != nil {
+		return 0, err
+	}
+	return db.executeUpdateBatchE(sql, multiRowParams)
+}
+
+/**
+ * 执行查询（批量参数，不经过 SQL 白名单），遇到第一个错误即返回
+ */
+func (db *Db) executeQueryBatchE(sql string, paramsArray [][]interface{}, returnType interface{}) ([]interface{}, error) {
+	var results []interface{}
+	for _, params := range paramsArray {
+		batchResults, err := db.executeQueryOnce(sql, params, returnType, db.statementTimeout())
+		if err != nil {
+			return nil, wrapExecuteError(err, "查询执行失败: "+sql)
+		}
+		results = append(results, batchResults...)
+	}
+	return results, nil
+}
+
+/**
+ * 执行批量更新（不经过 SQL 白名单），遇到第一个错误即返回
+ */
+func (db *Db) executeUpdateBatchE(sql string, multiRowParams [][]interface{}) (int, error) {
+	totalAffected := 0
+	for _, params := range multiRowParams {
+		affected, err := db.executeUpdateOnce(sql, params, db.statementTimeout())
+		if err != nil {
+			return totalAffected, wrapExecuteError(err, "更新执行失败: "+sql)
+		}
+		totalAffected += int(affected)
+	}
+	return totalAffected, nil
+}
+
+/**
+ * 包装执行错误：库自身的异常（熔断、限流、排空、语句超时等）原样返回，便于调用方按类型判断；
+ * 驱动返回的错误包装为 *QueryException
+ */
+func wrapExecuteError(err error, message string) error {
+	if _, ok := err.(interface{ GetCode() string }); ok {
+		return err
+	}
+	return NewQueryExceptionWithCause(err, message)
+}
+
 // ExecuteWithConnection 提供连接回调
 /**
  * 提供直接使用 Connection 的回调入口
@@ -225,12 +454,16 @@ func (db *Db) ExecuteOriginalUpdate(sql string, multiRowParams [][]interface{})
  * @return error 执行错误
  */
 func (db *Db) ExecuteWithConnection(fn func(*sql.Conn) error) error {
-	conn, err := db.DataSource.Conn(nil)
-	if err != nil {
-		return err
-	}
-	defer conn.Close()
-	return fn(conn)
+	return db.withPriorityGate(func() error {
+		conn, err := db.DataSource.Conn(context.Background())
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		detector := db.GetLeakDetector()
+		defer detector.untrack(detector.track(LeakKindConnection, db.DbId))
+		return fn(conn)
+	})
 }
 
 // ExecuteQuerySingle 单行查询
@@ -297,3 +530,364 @@ func getDefaultValue(t interface{}) interface{} {
 		return nil
 	}
 }
+
+/**
+ * 执行单次查询并完成 ORM 映射
+ *
+ * @param query SQL 语句
+ * @param params 参数
+ * @param returnType 返回类型
+ * @param timeout 语句超时，0 表示不限制
+ * @return []interface{} 结果列表
+ * @return error 执行错误
+ */
+func (db *Db) executeQueryOnce(query string, params []interface{}, returnType interface{}, timeout time.Duration) ([]interface{}, error) {
+	result, err := db.executeWithPlugins(query, params, returnType, func(query string, params []interface{}) (interface{}, int, error) {
+		results, err := db.queryOnce(query, params, returnType, timeout)
+		return results, len(results), err
+	})
+	results, _ := result.([]interface{})
+	return results, err
+}
+
+/**
+ * 执行单次查询（不经过插件）
+ */
+func (db *Db) queryOnce(query string, params []interface{}, returnType interface{}, timeout time.Duration) ([]interface{}, error) {
+	var results []interface{}
+	err := db.queryRows(query, params, timeout, func(rows *sql.Rows) error {
+		results = OrmHandlerInstance.ormBatch(rows, returnType, db.GetTimePolicy())
+		return rows.Err()
+	})
+	return results, err
+}
+
+/**
+ * 执行查询并把结果集交给 consume 读取（绑定事务、不限时与带超时三种执行路径）
+ *
+ * consume 负责关闭 rows
+ */
+func (db *Db) queryRows(query string, params []interface{}, timeout time.Duration, consume func(rows *sql.Rows) error) error {
+	if bound, err := db.runInBoundTx(query, timeout, func(ctx context.Context, tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, params...)
+		if err != nil {
+			return err
+		}
+		return consume(rows)
+	}); bound {
+		return err
+	}
+
+	if timeout <= 0 && !db.acquiresConnExplicitly() {
+		rows, err := db.DataSource.Query(query, params...)
+		if err != nil {
+			return err
+		}
+		return consume(rows)
+	}
+
+	return db.runWithStatementTimeout(query, timeout, func(ctx context.Context, conn *sql.Conn) error {
+		rows, err := conn.QueryContext(ctx, query, params...)
+		if err != nil {
+			return err
+		}
+		return consume(rows)
+	})
+}
+
+/**
+ * 执行只返回单行的查询并扫描到 dest（经过插件，不支持短路）
+ *
+ * @param query SQL 语句
+ * @param params 参数
+ * @param timeout 语句超时，0 表示不限制
+ * @param dest 扫描目标
+ * @return error 执行错误，没有结果行时为 sql.ErrNoRows
+ */
+func (db *Db) executeScalarOnce(query string, params []interface{}, timeout time.Duration, dest ...interface{}) error {
+	_, err := db.executeWithPlugins(query, params, nil, func(query string, params []interface{}) (interface{}, int, error) {
+		if bound, err := db.runInBoundTx(query, timeout, func(ctx context.Context, tx *sql.Tx) error {
+			return tx.QueryRowContext(ctx, query, params...).Scan(dest...)
+		}); bound {
+			return nil, 0, err
+		}
+		if timeout <= 0 && !db.acquiresConnExplicitly() {
+			err := db.DataSource.QueryRow(query, params...).Scan(dest...)
+			return nil, 0, err
+		}
+		err := db.runWithStatementTimeout(query, timeout, func(ctx context.Context, conn *sql.Conn) error {
+			return conn.QueryRowContext(ctx, query, params...).Scan(dest...)
+		})
+		return nil, 0, err
+	})
+	return err
+}
+
+/**
+ * 执行单次更新
+ *
+ * @param query SQL 语句
+ * @param params 参数
+ * @param timeout 语句超时，0 表示不限制
+ * @return int64 影响行数
+ * @return error 执行错误
+ */
+func (db *Db) executeUpdateOnce(query string, params []interface{}, timeout time.Duration) (int64, error) {
+	var affected int64
+	_, err := db.executeWithPlugins(query, params, nil, func(query string, params []interface{}) (interface{}, int, error) {
+		var err error
+		affected, err = db.updateOnce(query, params, timeout)
+		return nil, int(affected), err
+	})
+	return affected, err
+}
+
+/**
+ * 执行单次更新（不经过插件）
+ */
+func (db *Db) updateOnce(query string, params []interface{}, timeout time.Duration) (int64, error) {
+	var affected int64
+	if bound, err := db.runInBoundTx(query, timeout, func(ctx context.Context, tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query, params...)
+		if err != nil {
+			return err
+		}
+		affected, _ = result.RowsAffected()
+		return nil
+	}); bound {
+		return affected, err
+	}
+
+	if timeout <= 0 && !db.acquiresConnExplicitly() {
+		result, err := db.DataSource.Exec(query, params...)
+		if err != nil {
+			return 0, err
+		}
+		return result.RowsAffected()
+	}
+
+	err := db.runWithStatementTimeout(query, timeout, func(ctx context.Context, conn *sql.Conn) error {
+		result, err := conn.ExecContext(ctx, query, params...)
+		if err != nil {
+			return err
+		}
+		affected, _ = result.RowsAffected()
+		return nil
+	})
+	return affected, err
+}
+
+/**
+ * 执行库内部生成的写语句（经过插件，返回 sql.Result 以获取自增主键）
+ *
+ * @param query SQL 语句
+ * @param params 参数
+ * @return sql.Result 执行结果
+ * @return error 执行错误
+ */
+func (db *Db) execGenerated(query string, params []interface{}) (sql.Result, error) {
+	var result sql.Result
+	_, err := db.executeWithPlugins(query, params, nil, func(query string, params []interface{}) (interface{}, int, error) {
+		var err error
+		if bound, txErr := db.runInBoundTx(query, 0, func(ctx context.Context, tx *sql.Tx) error {
+			result, err = tx.ExecContext(ctx, query, params...)
+			return err
+		}); bound {
+			err = txErr
+		} else if db.acquiresConnExplicitly() {
+			err = db.runWithStatementTimeout(query, 0, func(ctx context.Context, conn *sql.Conn) error {
+				result, err = conn.ExecContext(ctx, query, params...)
+				return err
+			})
+		} else {
+			result, err = db.DataSource.Exec(query, params...)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		affected, _ := result.RowsAffected()
+		return result, int(affected), nil
+	})
+	return result, err
+}
+
+/**
+ * 执行库内部生成的 INSERT / UPSERT，返回的 LastInsertId 为自增列的值
+ *
+ * SQL Server 驱动不支持 LastInsertId：有自增列时追加 OUTPUT INSERTED.col 并以查询方式执行，
+ * 没有返回行（如 MERGE 匹配后不更新）时影响行数为 0
+ *
+ * @param query SQL 语句
+ * @param params 参数
+ * @param autoIncrementColumn 由数据库生成的自增列，为空时按普通写语句执行
+ */
+func (db *Db) execInsert(query string, params []interface{}, autoIncrementColumn string) (sql.Result, error) {
+	dialect, ok := db.GetSqlDialect().(*SQLServerDialect)
+	if !ok || autoIncrementColumn == "" {
+		return db.execGenerated(query, params)
+	}
+
+	query = dialect.applyOutputInserted(query, autoIncrementColumn)
+	var id int64
+	err := db.executeScalarOnce(query, params, 0, &id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return generatedResult{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return generatedResult{lastInsertId: id, rowsAffected: 1}, nil
+}
+
+/**
+ * 在插件钩子（PreExecuteSql / PostExecuteSql）包裹下执行单条 SQL
+ *
+ * 插件为全局、所属 DbGroup 与该 Db 的插件合并后按优先级排序的结果（见 PluginChain）
+ *
+ * 插件可在 PreExecuteSql 中通过 context.RewriteSql 改写 SQL 与参数，实际执行使用改写后的语句；
+ * 查询语句可由插件在 PreExecuteSql 中调用 context.ShortCircuit 直接给出结果，此时跳过实际执行
+ *
+ * 设置了诊断采集器时，死锁 / 锁等待超时错误会被替换为附带锁快照的 *LockDiagnosticsException
+ *
+ * 设置了熔断器时，实际执行受熔断器保护（插件短路的结果不计入统计），熔断打开时返回 *CircuitOpenException
+ *
+ * 设置了语句限流器时，实际执行前先获取限流许可（排在熔断器之前），超出限制时返回 *QueryThrottledException
+ *
+ * 设置了优先级闸门时，通过限流后按 Db 的优先级排队获取连接（见 WithPriority）
+ *
+ * 绑定了事务的 Db（见 ContextWithTx）在该事务中执行，不经过优先级闸门
+ *
+ * Db 排空（Drain）后拒绝新语句，执行中的语句计入 GetInFlightStatements
+ *
+ * 带查询标签的 Db 视图（见 WithQueryTag）在实际执行的语句末尾追加标签注释，并按标签计入性能监控器
+ *
+ * 启用自动埋点（见 NewInstrumentedDb）时，实际执行的语句计入性能监控器
+ *
+ * @param query SQL 语句
+ * @param params 参数
+ * @param returnType 查询返回类型，更新语句为 nil
+ * @param execute 实际执行函数，接收（可能被改写的）SQL 与参数，返回结果、影响行数和错误
+ * @return interface{} 执行结果
+ * @return error 执行错误
+ */
+func (db *Db) executeWithPlugins(query string, params []interface{}, returnType interface{}, execute func(query string, params []interface{}) (interface{}, int, error)) (interface{}, error) {
+	finish, err := db.beginStatement()
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+
+	params, err = convertParamsToDb(params)
+	if err != nil {
+		return nil, NewValidationExceptionWithCause(err, "SQL 参数类型转换失败")
+	}
+
+	if tag, instrumented := db.queryTag, db.instrumentationMonitor(); tag != "" || instrumented != nil {
+		measuredExecute := execute
+		execute = func(query string, params []interface{}) (interface{}, int, error) {
+			sent := query
+			if tag != "" && !db.root().queryTagCommentDisabled {
+				sent = appendQueryTagComment(query, tag)
+			}
+			start := time.Now()
+			result, affected, err := measuredExecute(sent, params)
+			duration := time.Since(start)
+			if instrumented != nil {
+				instrumented.RecordQuery(query, duration, err == nil, err)
+			}
+			if monitor := db.root().performanceMonitor; monitor != nil && tag != "" {
+				monitor.recordQueryTag(tag, duration, err == nil)
+			}
+			return result, affected, err
+		}
+	}
+
+	if collector := db.root().diagnosticsCollector; collector != nil {
+		rawExecute := execute
+		execute = func(query string, params []interface{}) (interface{}, int, error) {
+			result, affected, err := rawExecute(query, params)
+			if err != nil {
+				err = collector.captureForError(query, err)
+			}
+			return result, affected, err
+		}
+	}
+
+	if breaker := db.root().circuitBreaker; breaker != nil {
+		guardedExecute := execute
+		execute = func(query string, params []interface{}) (interface{}, int, error) {
+			probe, err := breaker.acquire()
+			if err != nil {
+				return nil, 0, err
+			}
+			start := time.Now()
+			result, affected, err := guardedExecute(query, params)
+			breaker.record(probe, time.Since(start), err)
+			return result, affected, err
+		}
+	}
+
+	// 绑定事务的语句已持有事务的连接，不再排队获取连接
+	if gate := db.root().priorityGate; gate != nil && db.tx == nil {
+		gatedExecute := execute
+		execute = func(query string, params []interface{}) (interface{}, int, error) {
+			release, err := gate.Acquire(db.priority)
+			if err != nil {
+				return nil, 0, err
+			}
+			defer release()
+			return gatedExecute(query, params)
+		}
+	}
+
+	if throttle := db.root().queryThrottle; throttle != nil {
+		throttledExecute := execute
+		execute = func(query string, params []interface{}) (interface{}, int, error) {
+			release, err := throttle.acquire(query)
+			if err != nil {
+				return nil, 0, err
+			}
+			defer release()
+			return throttledExecute(query, params)
+		}
+	}
+
+	plugins := db.GetEffectivePlugins()
+	if len(plugins) == 0 {
+		result, _, err := execute(query, params)
+		return result, err
+	}
+
+	sqlContext := NewExecuteSqlContext(query, params)
+	sqlContext.DataSource = db.DataSource
+	sqlContext.ReturnType = returnType
+	if db.queryTag != "" {
+		sqlContext.SetAttribute("query_tag", db.queryTag)
+	}
+	for _, plugin := range plugins {
+		plugin.PreExecuteSql(sqlContext)
+	}
+
+	if sqlContext.IsShortCircuited() && returnType != nil {
+		for _, plugin := range plugins {
+			plugin.PostExecuteSql(sqlContext)
+		}
+		return sqlContext.Result, sqlContext.Error
+	}
+
+	sqlContext.shortCircuited = false
+	if sqlContext.IsRewritten() {
+		LogFields(DEBUG, "SQL 已被插件改写", "originalSql", query, "sql", sqlContext.Sql)
+	}
+	sqlContext.MarkStart()
+	result, affected, err := execute(sqlContext.Sql, sqlContext.Params)
+	if err != nil {
+		sqlContext.SetError(err)
+	} else {
+		sqlContext.SetResult(result, affected)
+	}
+	for _, plugin := range plugins {
+		plugin.PostExecuteSql(sqlContext)
+	}
+	return result, err
+}
@@ -0,0 +1,302 @@
+/**
+ * Package db233test - 集成测试辅助
+ *
+ * LoadFixtures 从 YAML 夹具文件加载测试数据：每个文件对应一张表（文件名即表名），
+ * 内容为行列表，加载前按外键依赖顺序清空夹具涉及的表，再按父表优先的顺序插入：
+ *
+ *   //go:embed testdata/fixtures
+ *   var fixtures embed.FS
+ *
+ *   func TestOrder(t *testing.T) {
+ *       db := CreateTestDb(t)
+ *       sub, _ := fs.Sub(fixtures, "testdata/fixtures")
+ *       db233test.MustLoadFixtures(t, db, sub)
+ *       ...
+ *   }
+ *
+ * testdata/fixtures/users.yml：
+ *
+ *   - id: 1
+ *     username: alice
+ *     profile: {level: 3}   # map / 列表按 JSON 写入
+ *   - id: 2
+ *     username: bob
+ *
+ * 空文件只清空表。MySQL 下在同一连接上临时关闭 FOREIGN_KEY_CHECKS 后执行 TRUNCATE 与插入；
+ * PostgreSQL 下一条 TRUNCATE ... RESTART IDENTITY 清空所有夹具表（其他表引用这些表时会报错，
+ * 需把引用表也加入夹具）；其他数据库使用 DELETE
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+package db233test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+	"gopkg.in/yaml.v3"
+)
+
+/**
+ * Fixture - 单张表的夹具数据
+ */
+type Fixture struct {
+	Table string
+	Rows  []map[string]interface{}
+}
+
+/**
+ * 加载夹具目录（fsys 根目录下的 *.yml / *.yaml）
+ *
+ * @param db 数据库
+ * @param fsys 夹具文件系统（os.DirFS、embed.FS 等）
+ * @return []string 按插入顺序排列的表名
+ */
+func LoadFixtures(db *db233.Db, fsys fs.FS) ([]string, error) {
+	fixtures, err := ParseFixtures(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return ApplyFixtures(db, fixtures)
+}
+
+/**
+ * 加载夹具，失败时终止测试
+ */
+func MustLoadFixtures(t testing.TB, db *db233.Db, fsys fs.FS) []string {
+	t.Helper()
+	tables, err := LoadFixtures(db, fsys)
+	if err != nil {
+		t.Fatalf("加载测试夹具失败: %v", err)
+	}
+	return tables
+}
+
+/**
+ * 解析夹具文件（按文件名排序）
+ */
+func ParseFixtures(fsys fs.FS) ([]Fixture, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, db233.NewConfigurationExceptionWithCause(err, "读取夹具目录失败")
+	}
+
+	var fixtures []Fixture
+	for _, entry := range entries {
+		ext := path.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, db233.NewConfigurationExceptionWithCause(err, "读取夹具文件失败: "+entry.Name())
+		}
+		var rows []map[string]interface{}
+		if err := yaml.Unmarshal(data, &rows); err != nil {
+			return nil, db233.NewConfigurationExceptionWithCause(err, "解析夹具文件失败: "+entry.Name())
+		}
+		fixtures = append(fixtures, Fixture{Table: strings.TrimSuffix(entry.Name(), ext), Rows: rows})
+	}
+	return fixtures, nil
+}
+
+/**
+ * 清空夹具涉及的表并插入数据
+ *
+ * @return []string 按插入顺序排列的表名
+ */
+func ApplyFixtures(db *db233.Db, fixtures []Fixture) ([]string, error) {
+	if db == nil || db.DataSource == nil {
+		return nil, db233.NewValidationException("数据库不能为 nil")
+	}
+	if len(fixtures) == 0 {
+		return nil, nil
+	}
+
+	byTable := make(map[string]Fixture, len(fixtures))
+	tables := make([]string, 0, len(fixtures))
+	for _, fixture := range fixtures {
+		if _, exists := byTable[fixture.Table]; exists {
+			return nil, db233.NewValidationException("夹具表重复: " + fixture.Table)
+		}
+		byTable[fixture.Table] = fixture
+		tables = append(tables, fixture.Table)
+	}
+
+	ctx := context.Background()
+	conn, err := db.DataSource.Conn(ctx)
+	if err != nil {
+		return nil, db233.NewQueryExceptionWithCause(err, "获取夹具连接失败")
+	}
+	defer conn.Close()
+
+	ordered := sortByDependencies(tables, loadForeignKeys(ctx, conn, db.DatabaseType))
+
+	if db.DatabaseType == db233.EnumDatabaseTypeMySQL || db.DatabaseType == "" {
+		// 被引用的表无法 TRUNCATE，夹具之间也可能循环引用：加载期间关闭外键检查，连接归还前恢复
+		if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+			return nil, db233.NewQueryExceptionWithCause(err, "关闭外键检查失败")
+		}
+		defer conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1")
+	}
+
+	if err := truncateTables(ctx, conn, db.DatabaseType, ordered); err != nil {
+		return nil, err
+	}
+	for _, table := range ordered {
+		for i, row := range byTable[table].Rows {
+			if err := insertRow(ctx, conn, table, row); err != nil {
+				return nil, db233.NewQueryExceptionWithCause(err, fmt.Sprintf("插入夹具失败: 表=%s, 第 %d 行", table, i+1))
+			}
+		}
+	}
+
+	db233.LogDebug("测试夹具已加载: 表=%v", ordered)
+	return ordered, nil
+}
+
+/**
+ * 清空表（子表优先）
+ */
+func truncateTables(ctx context.Context, conn *sql.Conn, databaseType db233.EnumDatabaseType, ordered []string) error {
+	switch databaseType {
+	case db233.EnumDatabaseTypeMySQL, "":
+		for i := len(ordered) - 1; i >= 0; i-- {
+			if _, err := conn.ExecContext(ctx, "TRUNCATE TABLE "+ordered[i]); err != nil {
+				return db233.NewQueryExceptionWithCause(err, "清空表失败: "+ordered[i])
+			}
+		}
+		return nil
+	case db233.EnumDatabaseTypePostgreSQL:
+		if _, err := conn.ExecContext(ctx, "TRUNCATE TABLE "+strings.Join(ordered, ", ")+" RESTART IDENTITY"); err != nil {
+			return db233.NewQueryExceptionWithCause(err, "清空表失败")
+		}
+		return nil
+	}
+	for i := len(ordered) - 1; i >= 0; i-- {
+		if _, err := conn.ExecContext(ctx, "DELETE FROM "+ordered[i]); err != nil {
+			return db233.NewQueryExceptionWithCause(err, "清空表失败: "+ordered[i])
+		}
+	}
+	return nil
+}
+
+/**
+ * 插入一行（列按名称排序，map / 列表按 JSON 写入）
+ */
+func insertRow(ctx context.Context, conn *sql.Conn, table string, row map[string]interface{}) error {
+	if len(row) == 0 {
+		return nil
+	}
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		value := row[column]
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			value = string(encoded)
+		}
+		values[i] = value
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	_, err := conn.ExecContext(ctx, "INSERT INTO "+table+" ("+strings.Join(columns, ", ")+") VALUES ("+placeholders+")", values...)
+	return err
+}
+
+/**
+ * 查询外键依赖（子表 -> 父表），查询失败时返回 nil（退化为按文件名顺序）
+ */
+func loadForeignKeys(ctx context.Context, conn *sql.Conn, databaseType db233.EnumDatabaseType) map[string][]string {
+	var query string
+	switch databaseType {
+	case db233.EnumDatabaseTypeMySQL, "":
+		query = "SELECT TABLE_NAME, REFERENCED_TABLE_NAME FROM information_schema.KEY_COLUMN_USAGE " +
+			"WHERE TABLE_SCHEMA = DATABASE() AND REFERENCED_TABLE_NAME IS NOT NULL"
+	case db233.EnumDatabaseTypePostgreSQL:
+		query = "SELECT tc.table_name, ccu.table_name FROM information_schema.table_constraints tc " +
+			"JOIN information_schema.constraint_column_usage ccu ON tc.constraint_name = ccu.constraint_name AND tc.table_schema = ccu.table_schema " +
+			"WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = current_schema()"
+	default:
+		return nil
+	}
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		db233.LogWarn("查询外键依赖失败，按文件名顺序加载夹具: %v", err)
+		return nil
+	}
+	defer rows.Close()
+
+	dependencies := make(map[string][]string)
+	for rows.Next() {
+		var child, parent string
+		if err := rows.Scan(&child, &parent); err != nil {
+			db233.LogWarn("扫描外键依赖失败，按文件名顺序加载夹具: %v", err)
+			return nil
+		}
+		dependencies[child] = append(dependencies[child], parent)
+	}
+	return dependencies
+}
+
+/**
+ * 按依赖拓扑排序（父表在前），同层按原顺序；忽略自引用，循环依赖按原顺序追加
+ */
+func sortByDependencies(tables []string, dependencies map[string][]string) []string {
+	included := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		included[table] = true
+	}
+
+	placed := make(map[string]bool, len(tables))
+	ordered := make([]string, 0, len(tables))
+	for len(ordered) < len(tables) {
+		progressed := false
+		for _, table := range tables {
+			if placed[table] {
+				continue
+			}
+			ready := true
+			for _, parent := range dependencies[table] {
+				if parent != table && included[parent] && !placed[parent] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				placed[table] = true
+				ordered = append(ordered, table)
+				progressed = true
+			}
+		}
+		if !progressed {
+			for _, table := range tables {
+				if !placed[table] {
+					db233.LogWarn("夹具表存在循环外键依赖: %s", table)
+					placed[table] = true
+					ordered = append(ordered, table)
+				}
+			}
+		}
+	}
+	return ordered
+}
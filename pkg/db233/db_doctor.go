@@ -0,0 +1,564 @@
+package db233
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+ * DbDoctor - 启动自检
+ *
+ * 启动时对数据库做一轮体检，输出可操作的报告：
+ *   connectivity     连通性
+ *   maintenance      维护模式
+ *   permissions      自动建表/迁移所需权限（CREATE / ALTER / INDEX）
+ *   charset          数据库字符集（建议 utf8mb4）
+ *   timezone         数据库时区与应用时区是否一致
+ *   max_connections  连接池上限 vs 服务端 max_connections
+ *   primary_keys     缺少主键索引的表 / 实体主键与表主键不一致
+ *
+ * 用法：
+ *   report := db233.Doctor(db, &User{}, &Order{})
+ *   if report.HasFailures() { os.Exit(1) }
+ *
+ * 命令行：go run github.com/neko233-com/db233-go/cmd/db233 doctor -host ... -database ...
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type DbDoctor struct {
+	db          *Db
+	entityTypes []reflect.Type
+	timeout     time.Duration
+	output      io.Writer
+}
+
+/**
+ * 检查结果状态
+ */
+type DoctorStatus string
+
+const (
+	DoctorStatusOK   DoctorStatus = "OK"
+	DoctorStatusWarn DoctorStatus = "WARN"
+	DoctorStatusFail DoctorStatus = "FAIL"
+	DoctorStatusSkip DoctorStatus = "SKIP"
+)
+
+/**
+ * DoctorCheck - 单项检查结果
+ */
+type DoctorCheck struct {
+	Name       string       `json:"name"`
+	Status     DoctorStatus `json:"status"`
+	Message    string       `json:"message"`
+	Suggestion string       `json:"suggestion,omitempty"`
+}
+
+/**
+ * DoctorReport - 自检报告
+ */
+type DoctorReport struct {
+	DbId         int              `json:"db_id"`
+	DatabaseType EnumDatabaseType `json:"database_type"`
+	StartedAt    time.Time        `json:"started_at"`
+	Duration     time.Duration    `json:"duration"`
+	Checks       []DoctorCheck    `json:"checks"`
+}
+
+/**
+ * 运行自检并把报告打印到标准输出
+ *
+ * @param db 数据库
+ * @param entities 需要核对主键的实体（可选）
+ */
+func Doctor(db *Db, entities ...interface{}) *DoctorReport {
+	return NewDbDoctor(db).AddEntities(entities...).SetOutput(os.Stdout).Run()
+}
+
+/**
+ * 创建自检器（默认不打印，单项检查超时 5 秒）
+ */
+func NewDbDoctor(db *Db) *DbDoctor {
+	return &DbDoctor{
+		db:          db,
+		entityTypes: make([]reflect.Type, 0),
+		timeout:     5 * time.Second,
+	}
+}
+
+/**
+ * 添加需要核对主键的实体
+ */
+func (d *DbDoctor) AddEntities(entities ...interface{}) *DbDoctor {
+	for _, entity := range entities {
+		t := reflect.TypeOf(entity)
+		if t == nil {
+			continue
+		}
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			d.entityTypes = append(d.entityTypes, t)
+		}
+	}
+	return d
+}
+
+/**
+ * 设置单项检查超时
+ */
+func (d *DbDoctor) SetTimeout(timeout time.Duration) *DbDoctor {
+	d.timeout = timeout
+	return d
+}
+
+/**
+ * 设置报告输出（nil 表示不打印）
+ */
+func (d *DbDoctor) SetOutput(output io.Writer) *DbDoctor {
+	d.output = output
+	return d
+}
+
+/**
+ * 执行全部检查
+ */
+func (d *DbDoctor) Run() *DoctorReport {
+	report := &DoctorReport{StartedAt: time.Now()}
+	if d.db != nil {
+		report.DbId = d.db.DbId
+		report.DatabaseType = d.db.DatabaseType
+	}
+
+	connectivity := d.checkConnectivity()
+	report.Checks = append(report.Checks, connectivity, d.checkMaintenance())
+
+	remaining := []struct {
+		name  string
+		check func() DoctorCheck
+	}{
+		{"permissions", d.checkPermissions},
+		{"charset", d.checkCharset},
+		{"timezone", d.checkTimezone},
+		{"max_connections", d.checkMaxConnections},
+		{"primary_keys", d.checkPrimaryKeys},
+	}
+	for _, item := range remaining {
+		if connectivity.Status == DoctorStatusFail {
+			report.Checks = append(report.Checks, DoctorCheck{Name: item.name, Status: DoctorStatusSkip, Message: "数据库不可达，跳过"})
+			continue
+		}
+		report.Checks = append(report.Checks, item.check())
+	}
+
+	report.Duration = time.Since(report.StartedAt)
+	LogInfo("数据库自检完成: DbId=%d, 失败=%d, 警告=%d, 耗时=%v",
+		report.DbId, report.CountByStatus(DoctorStatusFail), report.CountByStatus(DoctorStatusWarn), report.Duration)
+
+	if d.output != nil {
+		report.WriteText(d.output)
+	}
+	return report
+}
+
+/**
+ * 是否为 PostgreSQL
+ */
+func (d *DbDoctor) isPostgreSQL() bool {
+	return d.db.DatabaseType == EnumDatabaseTypePostgreSQL
+}
+
+/**
+ * 检查连通性
+ */
+func (d *DbDoctor) checkConnectivity() DoctorCheck {
+	check := DoctorCheck{Name: "connectivity"}
+	if d.db == nil || d.db.DataSource == nil {
+		check.Status = DoctorStatusFail
+		check.Message = "数据源未初始化"
+		check.Suggestion = "使用 DbConnectionConfig.CreateDb 创建 Db，或确认 DataSource 不为 nil"
+		return check
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+	start := time.Now()
+	if err := d.db.DataSource.PingContext(ctx); err != nil {
+		check.Status = DoctorStatusFail
+		check.Message = fmt.Sprintf("无法连接数据库: %v", err)
+		check.Suggestion = "检查主机/端口/账号密码、防火墙以及数据库服务是否启动"
+		return check
+	}
+	check.Status = DoctorStatusOK
+	check.Message = fmt.Sprintf("连接正常，耗时 %v", time.Since(start).Round(time.Millisecond))
+	return check
+}
+
+/**
+ * 检查维护模式
+ */
+func (d *DbDoctor) checkMaintenance() DoctorCheck {
+	check := DoctorCheck{Name: "maintenance", Status: DoctorStatusOK, Message: "未处于维护模式"}
+	if inMaintenance, reason, since := d.db.GetMaintenanceInfo(); inMaintenance {
+		check.Status = DoctorStatusWarn
+		check.Message = fmt.Sprintf("处于维护模式（%s），开始于 %s", reason, since.Format("2006-01-02 15:04:05"))
+		check.Suggestion = "维护结束后调用 db.ExitMaintenance() 恢复健康检查与告警"
+	}
+	return check
+}
+
+/**
+ * 检查自动建表/迁移所需权限
+ */
+func (d *DbDoctor) checkPermissions() DoctorCheck {
+	check := DoctorCheck{Name: "permissions"}
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	if d.isPostgreSQL() {
+		var canCreate bool
+		err := d.db.DataSource.QueryRowContext(ctx, "SELECT has_schema_privilege(current_schema(), 'CREATE')").Scan(&canCreate)
+		if err != nil {
+			return d.queryFailed(check, err)
+		}
+		if !canCreate {
+			check.Status = DoctorStatusFail
+			check.Message = "当前用户在当前 schema 上没有 CREATE 权限"
+			check.Suggestion = "执行 GRANT CREATE ON SCHEMA <schema> TO <user>，或关闭自动建表"
+			return check
+		}
+		check.Status = DoctorStatusOK
+		check.Message = "具备 CREATE 权限"
+		return check
+	}
+
+	var database string
+	if err := d.db.DataSource.QueryRowContext(ctx, "SELECT IFNULL(DATABASE(), '')").Scan(&database); err != nil {
+		return d.queryFailed(check, err)
+	}
+	grants, err := d.queryStrings(ctx, "SHOW GRANTS FOR CURRENT_USER()")
+	if err != nil {
+		return d.queryFailed(check, err)
+	}
+
+	granted := make(map[string]bool)
+	for _, grant := range grants {
+		for _, privilege := range parseMySQLGrant(grant, database) {
+			granted[privilege] = true
+		}
+	}
+
+	missing := make([]string, 0)
+	for _, privilege := range []string{"CREATE", "ALTER", "INDEX"} {
+		if !granted["ALL PRIVILEGES"] && !granted[privilege] {
+			missing = append(missing, privilege)
+		}
+	}
+	if len(missing) > 0 {
+		check.Status = DoctorStatusWarn
+		check.Message = fmt.Sprintf("缺少自动建表/迁移权限: %s", strings.Join(missing, ", "))
+		check.Suggestion = fmt.Sprintf("执行 GRANT %s ON `%s`.* TO CURRENT_USER()，或关闭 AutoCreateTable/AutoMigrateTable", strings.Join(missing, ", "), database)
+		return check
+	}
+	check.Status = DoctorStatusOK
+	check.Message = "具备 CREATE / ALTER / INDEX 权限"
+	return check
+}
+
+/**
+ * 解析 MySQL GRANT 语句，返回对当前库生效的权限
+ *
+ * 例：GRANT SELECT, CREATE ON `game`.* TO `u`@`%` -> [SELECT CREATE]
+ */
+func parseMySQLGrant(grant string, database string) []string {
+	upper := strings.ToUpper(grant)
+	if !strings.HasPrefix(upper, "GRANT ") {
+		return nil
+	}
+	onIndex := strings.Index(upper, " ON ")
+	toIndex := strings.Index(upper, " TO ")
+	if onIndex < 0 || toIndex < onIndex {
+		return nil
+	}
+
+	target := strings.ReplaceAll(strings.TrimSpace(grant[onIndex+4:toIndex]), "`", "")
+	if target != "*.*" && !strings.EqualFold(target, database+".*") {
+		return nil
+	}
+
+	privileges := make([]string, 0)
+	for _, privilege := range strings.Split(upper[len("GRANT "):onIndex], ",") {
+		privileges = append(privileges, strings.TrimSpace(privilege))
+	}
+	return privileges
+}
+
+/**
+ * 检查字符集
+ */
+func (d *DbDoctor) checkCharset() DoctorCheck {
+	check := DoctorCheck{Name: "charset"}
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	if d.isPostgreSQL() {
+		var serverEncoding, clientEncoding string
+		err := d.db.DataSource.QueryRowContext(ctx, "SELECT current_setting('server_encoding'), current_setting('client_encoding')").Scan(&serverEncoding, &clientEncoding)
+		if err != nil {
+			return d.queryFailed(check, err)
+		}
+		if !strings.EqualFold(serverEncoding, "UTF8") || !strings.EqualFold(clientEncoding, "UTF8") {
+			check.Status = DoctorStatusWarn
+			check.Message = fmt.Sprintf("编码不是 UTF8: server=%s, client=%s", serverEncoding, clientEncoding)
+			check.Suggestion = "使用 ENCODING 'UTF8' 建库，并在连接参数中设置 client_encoding=UTF8"
+			return check
+		}
+		check.Status = DoctorStatusOK
+		check.Message = "server/client 编码均为 UTF8"
+		return check
+	}
+
+	var databaseCharset, connectionCharset string
+	err := d.db.DataSource.QueryRowContext(ctx, "SELECT @@character_set_database, @@character_set_connection").Scan(&databaseCharset, &connectionCharset)
+	if err != nil {
+		return d.queryFailed(check, err)
+	}
+	if databaseCharset != "utf8mb4" || connectionCharset != "utf8mb4" {
+		check.Status = DoctorStatusWarn
+		check.Message = fmt.Sprintf("字符集不是 utf8mb4: database=%s, connection=%s（emoji 等 4 字节字符会写入失败）", databaseCharset, connectionCharset)
+		check.Suggestion = "执行 ALTER DATABASE <db> CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci，并在 DSN 中设置 charset=utf8mb4"
+		return check
+	}
+	check.Status = DoctorStatusOK
+	check.Message = "database/connection 字符集均为 utf8mb4"
+	return check
+}
+
+/**
+ * 检查数据库时区与应用时区是否一致（按 UTC 偏移比较）
+ */
+func (d *DbDoctor) checkTimezone() DoctorCheck {
+	check := DoctorCheck{Name: "timezone"}
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	query := "SELECT @@time_zone, TIMESTAMPDIFF(SECOND, UTC_TIMESTAMP(), NOW())"
+	if d.isPostgreSQL() {
+		query = "SELECT current_setting('TimeZone'), CAST(EXTRACT(TIMEZONE FROM now()) AS INTEGER)"
+	}
+	var dbZone string
+	var dbOffset int
+	if err := d.db.DataSource.QueryRowContext(ctx, query).Scan(&dbZone, &dbOffset); err != nil {
+		return d.queryFailed(check, err)
+	}
+
+	appZone, appOffset := time.Now().Zone()
+	// NOW() 与 UTC_TIMESTAMP() 分别取值，允许 1 分钟误差
+	if diff := dbOffset - appOffset; diff > 60 || diff < -60 {
+		check.Status = DoctorStatusWarn
+		check.Message = fmt.Sprintf("时区不一致: 数据库=%s(UTC%+.1fh), 应用=%s(UTC%+.1fh)", dbZone, float64(dbOffset)/3600, appZone, float64(appOffset)/3600)
+		check.Suggestion = "统一数据库与应用时区，或在 DSN 中设置 loc 与 time_zone 参数（MySQL 建议 parseTime=true&loc=Local）"
+		return check
+	}
+	check.Status = DoctorStatusOK
+	check.Message = fmt.Sprintf("时区一致: UTC%+.1fh", float64(appOffset)/3600)
+	return check
+}
+
+/**
+ * 检查连接池上限与服务端 max_connections
+ */
+func (d *DbDoctor) checkMaxConnections() DoctorCheck {
+	check := DoctorCheck{Name: "max_connections"}
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	query := "SELECT @@max_connections"
+	if d.isPostgreSQL() {
+		query = "SELECT current_setting('max_connections')"
+	}
+	var raw string
+	if err := d.db.DataSource.QueryRowContext(ctx, query).Scan(&raw); err != nil {
+		return d.queryFailed(check, err)
+	}
+	maxConnections, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		return d.queryFailed(check, err)
+	}
+
+	poolSize := d.db.DataSource.Stats().MaxOpenConnections
+	switch {
+	case poolSize <= 0:
+		check.Status = DoctorStatusWarn
+		check.Message = fmt.Sprintf("连接池未设置上限，服务端 max_connections=%d", maxConnections)
+		check.Suggestion = fmt.Sprintf("设置 MaxOpenConns（建议不超过 %d，为其他节点与运维连接留余量）", maxConnections*8/10)
+	case poolSize > maxConnections:
+		check.Status = DoctorStatusFail
+		check.Message = fmt.Sprintf("连接池上限 %d 超过服务端 max_connections=%d", poolSize, maxConnections)
+		check.Suggestion = "调小 MaxOpenConns，或调大服务端 max_connections"
+	case poolSize > maxConnections*8/10:
+		check.Status = DoctorStatusWarn
+		check.Message = fmt.Sprintf("连接池上限 %d 已超过服务端 max_connections=%d 的 80%%", poolSize, maxConnections)
+		check.Suggestion = "多节点部署时总连接数会超限，按节点数分摊 MaxOpenConns"
+	default:
+		check.Status = DoctorStatusOK
+		check.Message = fmt.Sprintf("连接池上限 %d / 服务端 max_connections=%d", poolSize, maxConnections)
+	}
+	return check
+}
+
+/**
+ * 检查主键索引：库中缺少主键的表，以及实体声明的主键与表主键是否一致
+ */
+func (d *DbDoctor) checkPrimaryKeys() DoctorCheck {
+	check := DoctorCheck{Name: "primary_keys"}
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	query := `SELECT t.TABLE_NAME FROM information_schema.TABLES t
+		LEFT JOIN information_schema.TABLE_CONSTRAINTS c
+			ON c.TABLE_SCHEMA = t.TABLE_SCHEMA AND c.TABLE_NAME = t.TABLE_NAME AND c.CONSTRAINT_TYPE = 'PRIMARY KEY'
+		WHERE t.TABLE_SCHEMA = DATABASE() AND t.TABLE_TYPE = 'BASE TABLE' AND c.CONSTRAINT_NAME IS NULL`
+	if d.isPostgreSQL() {
+		query = `SELECT t.table_name FROM information_schema.tables t
+			LEFT JOIN information_schema.table_constraints c
+				ON c.table_schema = t.table_schema AND c.table_name = t.table_name AND c.constraint_type = 'PRIMARY KEY'
+			WHERE t.table_schema = current_schema() AND t.table_type = 'BASE TABLE' AND c.constraint_name IS NULL`
+	}
+	tablesWithoutPk, err := d.queryStrings(ctx, query)
+	if err != nil {
+		return d.queryFailed(check, err)
+	}
+
+	problems := make([]string, 0)
+	if len(tablesWithoutPk) > 0 {
+		problems = append(problems, fmt.Sprintf("缺少主键的表: %s", strings.Join(tablesWithoutPk, ", ")))
+	}
+
+	cm := GetCrudManagerInstance()
+	for _, entityType := range d.entityTypes {
+		tableName := cm.GetTableName(entityType)
+		expected := cm.GetPrimaryKeyColumnNames(reflect.New(entityType).Interface())
+
+		pkQuery := "SELECT COLUMN_NAME FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND INDEX_NAME = 'PRIMARY' ORDER BY SEQ_IN_INDEX"
+		if d.isPostgreSQL() {
+			pkQuery = `SELECT k.column_name FROM information_schema.table_constraints c
+				JOIN information_schema.key_column_usage k
+					ON k.constraint_name = c.constraint_name AND k.table_schema = c.table_schema AND k.table_name = c.table_name
+				WHERE c.table_schema = current_schema() AND c.table_name = $1 AND c.constraint_type = 'PRIMARY KEY'
+				ORDER BY k.ordinal_position`
+		}
+		actual, err := d.queryStrings(ctx, pkQuery, tableName)
+		if err != nil {
+			return d.queryFailed(check, err)
+		}
+		if len(actual) == 0 {
+			if !containsColumn(tablesWithoutPk, tableName) {
+				problems = append(problems, fmt.Sprintf("实体 %s 的表 %s 不存在", entityType.Name(), tableName))
+			}
+			continue
+		}
+		if strings.Join(actual, ",") != strings.Join(expected, ",") {
+			problems = append(problems, fmt.Sprintf("实体 %s 声明主键 (%s)，表 %s 主键为 (%s)",
+				entityType.Name(), strings.Join(expected, ","), tableName, strings.Join(actual, ",")))
+		}
+	}
+
+	if len(problems) > 0 {
+		check.Status = DoctorStatusWarn
+		check.Message = strings.Join(problems, "; ")
+		check.Suggestion = "为表添加 PRIMARY KEY（按主键的更新/删除会退化为全表扫描），或执行 AutoCreateTable 建表"
+		return check
+	}
+	check.Status = DoctorStatusOK
+	check.Message = fmt.Sprintf("所有表均有主键，已核对 %d 个实体", len(d.entityTypes))
+	return check
+}
+
+/**
+ * 查询单列字符串结果
+ */
+func (d *DbDoctor) queryStrings(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := d.db.DataSource.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make([]string, 0)
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
+
+/**
+ * 查询失败时的检查结果
+ */
+func (d *DbDoctor) queryFailed(check DoctorCheck, err error) DoctorCheck {
+	LogWarn("数据库自检查询失败: 检查项=%s, 错误=%v", check.Name, err)
+	check.Status = DoctorStatusWarn
+	check.Message = fmt.Sprintf("无法完成检查: %v", err)
+	check.Suggestion = "确认当前用户可读取 information_schema 及系统变量"
+	return check
+}
+
+/**
+ * 统计指定状态的检查项数
+ */
+func (r *DoctorReport) CountByStatus(status DoctorStatus) int {
+	count := 0
+	for _, check := range r.Checks {
+		if check.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+/**
+ * 是否存在失败项
+ */
+func (r *DoctorReport) HasFailures() bool {
+	return r.CountByStatus(DoctorStatusFail) > 0
+}
+
+/**
+ * 获取指定检查项
+ */
+func (r *DoctorReport) GetCheck(name string) (DoctorCheck, bool) {
+	for _, check := range r.Checks {
+		if check.Name == name {
+			return check, true
+		}
+	}
+	return DoctorCheck{}, false
+}
+
+/**
+ * 以文本形式输出报告
+ */
+func (r *DoctorReport) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "=== db233 自检报告 (DbId=%d, 类型=%s) ===\n", r.DbId, r.DatabaseType)
+	for _, check := range r.Checks {
+		fmt.Fprintf(w, "[%-4s] %-16s %s\n", check.Status, check.Name, check.Message)
+		if check.Suggestion != "" && (check.Status == DoctorStatusWarn || check.Status == DoctorStatusFail) {
+			fmt.Fprintf(w, "       %-16s -> %s\n", "", check.Suggestion)
+		}
+	}
+	fmt.Fprintf(w, "结果: %d 通过, %d 警告, %d 失败, %d 跳过 (耗时 %v)\n",
+		r.CountByStatus(DoctorStatusOK), r.CountByStatus(DoctorStatusWarn),
+		r.CountByStatus(DoctorStatusFail), r.CountByStatus(DoctorStatusSkip), r.Duration.Round(time.Millisecond))
+}
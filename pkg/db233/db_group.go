@@ -24,6 +24,9 @@ type DbGroup struct {
 	DbMap                    map[int]*Db
 	isInit                   bool
 	mu                       sync.Mutex
+
+	// 作用于组内所有 Db 的插件
+	plugins PluginChain
 }
 
 /**
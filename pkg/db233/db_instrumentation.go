@@ -0,0 +1,56 @@
+package db233
+
+import "time"
+
+/**
+ * Db 自动埋点
+ *
+ * 启用后 PerformanceMonitor 自动记录该 Db（含其视图与事务）的每条语句与事务，不再需要业务代码手动调用 RecordQuery：
+ *
+ *   db = db233.NewInstrumentedDb(db, db233.NewPerformanceMonitor("orders", db))
+ *
+ * 记录内容：
+ *   - 每条语句（ExecuteQuery / ExecuteUpdate / 存储库 / TransactionManager.Query、Exec）的成功与否、耗时与错误
+ *   - 事务开始、提交与回滚（RecordTransactionStart / RecordTransactionEnd）
+ *   - 从连接池获取连接的等待时间（RecordConnectionAcquired / RecordConnectionReleased），
+ *     启用后未绑定事务的语句显式获取连接执行，以便测量等待时间
+ *
+ * 插件短路的查询没有实际执行，不计入统计
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+func NewInstrumentedDb(db *Db, monitor *PerformanceMonitor) *Db {
+	root := db.root()
+	root.SetPerformanceMonitor(monitor)
+	root.instrumented = monitor != nil
+	LogInfo("Db 自动埋点已启用: dbId=%d", root.DbId)
+	return db
+}
+
+/**
+ * 是否启用了自动埋点
+ */
+func (db *Db) IsInstrumented() bool {
+	return db.instrumentationMonitor() != nil
+}
+
+/**
+ * 自动埋点使用的监控器，未启用时为 nil
+ */
+func (db *Db) instrumentationMonitor() *PerformanceMonitor {
+	root := db.root()
+	if !root.instrumented {
+		return nil
+	}
+	return root.performanceMonitor
+}
+
+/**
+ * 记录一条语句（未启用自动埋点时忽略）
+ */
+func (db *Db) recordInstrumentedQuery(query string, start time.Time, err error) {
+	if monitor := db.instrumentationMonitor(); monitor != nil {
+		monitor.RecordQuery(query, time.Since(start), err == nil, err)
+	}
+}
@@ -0,0 +1,83 @@
+package db233
+
+import (
+	"time"
+)
+
+/**
+ * Db 维护模式
+ *
+ * 计划停机期间把 Db 标记为维护中：
+ * - HealthChecker 报告 "maintenance"（既不算健康，也不触发告警）
+ * - 绑定了该 Db 的告警规则（AlertRule.MaintenanceDb）暂停评估
+ * - SLO 评估器跳过维护期间的请求计数，避免污染可用性与燃烧率
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type dbMaintenanceState struct {
+	reason string
+	since  time.Time
+}
+
+/**
+ * 进入维护模式
+ *
+ * @param reason 维护原因（用于健康检查消息）
+ */
+func (db *Db) EnterMaintenance(reason string) {
+	db = db.root()
+	db.maintenanceMu.Lock()
+	defer db.maintenanceMu.Unlock()
+
+	db.maintenance = &dbMaintenanceState{reason: reason, since: time.Now()}
+	LogInfo("数据库进入维护模式: DbId=%d, 原因=%s", db.DbId, reason)
+}
+
+/**
+ * 退出维护模式
+ */
+func (db *Db) ExitMaintenance() {
+	db = db.root()
+	db.maintenanceMu.Lock()
+	defer db.maintenanceMu.Unlock()
+
+	if db.maintenance != nil {
+		LogInfo("数据库退出维护模式: DbId=%d, 维护时长=%v", db.DbId, time.Since(db.maintenance.since))
+		db.maintenance = nil
+	}
+}
+
+/**
+ * 是否处于维护模式（nil Db 视为不在维护中）
+ */
+func (db *Db) IsInMaintenance() bool {
+	if db == nil {
+		return false
+	}
+	db = db.root()
+	db.maintenanceMu.RLock()
+	defer db.maintenanceMu.RUnlock()
+	return db.maintenance != nil
+}
+
+/**
+ * 获取维护信息
+ *
+ * @return bool 是否处于维护模式
+ * @return string 维护原因
+ * @return time.Time 进入维护的时间
+ */
+func (db *Db) GetMaintenanceInfo() (bool, string, time.Time) {
+	if db == nil {
+		return false, "", time.Time{}
+	}
+	db = db.root()
+	db.maintenanceMu.RLock()
+	defer db.maintenanceMu.RUnlock()
+
+	if db.maintenance == nil {
+		return false, "", time.Time{}
+	}
+	return true, db.maintenance.reason, db.maintenance.since
+}
@@ -23,6 +23,10 @@ import (
 type DbManager struct {
 	groupNameToDbGroupMap map[string]*DbGroup
 	mu                    sync.RWMutex
+
+	// 启动报告（见 startup_report.go）
+	startupBannerEnabled        bool
+	groupNameToMigrationManager map[string]*MigrationManager
 }
 
 var instance *DbManager
@@ -45,11 +49,23 @@ func GetInstance() *DbManager {
 /**
  * 提供一个回调，让调用方以自定义方式初始化 DbManager
  *
+ * 开启 SetStartupBannerEnabled 时，初始化成功后输出启动报告
+ *
  * @param fn 一个接收 DbManager 的回调函数，调用方可以在其中调用 AddDbGroup 等方法完成自定义初始化
  * @return error 初始化错误
  */
 func (dm *DbManager) InitByYourDiy(fn func(*DbManager) error) error {
-	return fn(dm)
+	if err := fn(dm); err != nil {
+		return err
+	}
+
+	dm.mu.RLock()
+	bannerEnabled := dm.startupBannerEnabled
+	dm.mu.RUnlock()
+	if bannerEnabled {
+		dm.LogStartupBanner()
+	}
+	return nil
 }
 
 /**
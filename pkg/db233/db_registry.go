@@ -0,0 +1,224 @@
+package db233
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+/**
+ * DbRegistry - 按名称注册与查找 Db
+ *
+ * 应用启动时注册各数据源，存储库与迁移管理器按名称构造，不必把 *Db 逐层传入：
+ *
+ *   db233.RegisterDb("users", usersDb)
+ *   db233.RegisterDb("logs", logsDb)
+ *
+ *   repo, err := db233.NewBaseCrudRepositoryByName("users")
+ *   migrations, err := db233.NewMigrationManagerByName("logs", "./migrations/logs")
+ *
+ * 也可以按配置文件 databases 下声明的连接批量打开并注册（同时绑定连接池热加载）：
+ *
+ *   fileConfig, _ := db233.GetConfigManager().LoadFile("db233.yaml")
+ *   err := db233.GetDbRegistryInstance().OpenFromConfig(fileConfig)
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type DbRegistry struct {
+	nameToDb map[string]*Db
+	mu       sync.RWMutex
+}
+
+var (
+	dbRegistryInstance *DbRegistry
+	dbRegistryOnce     sync.Once
+)
+
+/**
+ * 获取全局 Db 注册表
+ */
+func GetDbRegistryInstance() *DbRegistry {
+	dbRegistryOnce.Do(func() {
+		dbRegistryInstance = NewDbRegistry()
+	})
+	return dbRegistryInstance
+}
+
+/**
+ * 创建 Db 注册表
+ */
+func NewDbRegistry() *DbRegistry {
+	return &DbRegistry{nameToDb: make(map[string]*Db)}
+}
+
+/**
+ * 注册 Db（名称重复时返回错误，替换请先 Unregister）
+ */
+func (r *DbRegistry) Register(name string, db *Db) error {
+	if name == "" {
+		return NewValidationException("Db 名称不能为空")
+	}
+	if db == nil {
+		return NewValidationException(fmt.Sprintf("Db 不能为 nil: %s", name))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.nameToDb[name]; exists {
+		return NewValidationException(fmt.Sprintf("Db 名称已注册: %s", name))
+	}
+	r.nameToDb[name] = db
+	LogInfo("Db 已注册: %s, DbId=%d", name, db.DbId)
+	return nil
+}
+
+/**
+ * 取消注册（不关闭连接池），返回被移除的 Db，不存在时为 nil
+ */
+func (r *DbRegistry) Unregister(name string) *Db {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	db, exists := r.nameToDb[name]
+	if !exists {
+		return nil
+	}
+	delete(r.nameToDb, name)
+	return db
+}
+
+/**
+ * 按名称获取 Db，未注册时返回 DB_NOT_REGISTERED 错误
+ */
+func (r *DbRegistry) Get(name string) (*Db, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if db, exists := r.nameToDb[name]; exists {
+		return db, nil
+	}
+	return nil, NewDb233ExceptionWithCode("DB_NOT_REGISTERED", fmt.Sprintf("Db 未注册: %s", name))
+}
+
+/**
+ * 按名称获取 Db，未注册时 panic（用于启动阶段）
+ */
+func (r *DbRegistry) MustGet(name string) *Db {
+	db, err := r.Get(name)
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+/**
+ * 已注册的名称（排序后）
+ */
+func (r *DbRegistry) GetNames() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.nameToDb))
+	for name := range r.nameToDb {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/**
+ * 获取名称 -> Db 映射的副本
+ */
+func (r *DbRegistry) GetAll() map[string]*Db {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make(map[string]*Db, len(r.nameToDb))
+	for name, db := range r.nameToDb {
+		result[name] = db
+	}
+	return result
+}
+
+/**
+ * 按配置文件 databases 下的连接打开并注册 Db（按名称排序依次分配 DbId），
+ * 并绑定到 ConfigManager 以便连接池参数热加载；任一连接失败时关闭本次已打开的连接并返回错误
+ */
+func (r *DbRegistry) OpenFromConfig(fileConfig *Db233FileConfig) error {
+	if fileConfig == nil || len(fileConfig.Databases) == 0 {
+		return NewConfigurationException("配置中没有声明 databases")
+	}
+	names := make([]string, 0, len(fileConfig.Databases))
+	for name := range fileConfig.Databases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	opened := make([]string, 0, len(names))
+	rollback := func() {
+		for _, name := range opened {
+			if db := r.Unregister(name); db != nil {
+				_ = db.Close()
+			}
+		}
+	}
+	for i, name := range names {
+		config := fileConfig.Databases[name]
+		if config == nil {
+			rollback()
+			return NewConfigurationException(fmt.Sprintf("数据库配置为空: %s", name))
+		}
+		db, err := config.CreateDb(i+1, nil)
+		if err != nil {
+			rollback()
+			return NewConfigurationException(fmt.Sprintf("打开数据库失败: %s, 错误=%v", name, err))
+		}
+		if err := r.Register(name, db); err != nil {
+			_ = db.Close()
+			rollback()
+			return err
+		}
+		opened = append(opened, name)
+		GetConfigManager().BindDb(name, db)
+	}
+	return nil
+}
+
+/**
+ * 注册 Db 到全局注册表
+ */
+func RegisterDb(name string, db *Db) error {
+	return GetDbRegistryInstance().Register(name, db)
+}
+
+/**
+ * 从全局注册表按名称获取 Db
+ */
+func GetDb(name string) (*Db, error) {
+	return GetDbRegistryInstance().Get(name)
+}
+
+/**
+ * 从全局注册表按名称获取 Db，未注册时 panic
+ */
+func MustGetDb(name string) *Db {
+	return GetDbRegistryInstance().MustGet(name)
+}
+
+/**
+ * 按注册名称创建存储库
+ */
+func NewBaseCrudRepositoryByName(name string) (*BaseCrudRepository, error) {
+	db, err := GetDb(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewBaseCrudRepository(db), nil
+}
+
+/**
+ * 按注册名称创建迁移管理器
+ */
+func NewMigrationManagerByName(name string, migrationsDir string) (*MigrationManager, error) {
+	db, err := GetDb(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewMigrationManager(db, migrationsDir), nil
+}
@@ -0,0 +1,352 @@
+package db233
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/**
+ * Decimal - 精确定点数（金额等）
+ *
+ * 以 big.Int 保存未缩放值与小数位数，不经过 float64，读写 DECIMAL 列时不会产生舍入误差；
+ * 实现了 driver.Valuer / sql.Scanner / json.Marshaler，可直接作为实体字段：
+ *
+ *   Balance db233.Decimal `db:"balance" decimal:"19,4"`   // 建表为 DECIMAL(19,4)，写入前按 4 位小数四舍五入
+ *
+ *   price := db233.MustDecimal("19.99")
+ *   total := price.Mul(db233.NewDecimalFromInt(3)).Round(2)   // 59.97
+ *
+ * decimal 标签同样可用于 float64 字段（只影响列类型）与通过 RegisterTypeConverter 注册的第三方类型
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type Decimal struct {
+	// 未缩放值，nil 表示 0
+	unscaled *big.Int
+	scale    int32
+}
+
+/**
+ * 由未缩放值与小数位数创建，如 NewDecimal(1999, 2) = 19.99
+ */
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	return Decimal{unscaled: big.NewInt(unscaled), scale: scale}
+}
+
+/**
+ * 由整数创建
+ */
+func NewDecimalFromInt(value int64) Decimal {
+	return NewDecimal(value, 0)
+}
+
+/**
+ * 解析十进制文本（如 -12.340、+5、.5）
+ */
+func NewDecimalFromString(text string) (Decimal, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return Decimal{}, fmt.Errorf("无法解析空文本为 Decimal")
+	}
+	sign := ""
+	if text[0] == '-' || text[0] == '+' {
+		if text[0] == '-' {
+			sign = "-"
+		}
+		text = text[1:]
+	}
+	integerPart, fractionPart := text, ""
+	if dot := strings.IndexByte(text, '.'); dot >= 0 {
+		integerPart, fractionPart = text[:dot], text[dot+1:]
+	}
+	digits := integerPart + fractionPart
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("无法解析 Decimal: %s", text)
+	}
+	unscaled, ok := new(big.Int).SetString(sign+digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("无法解析 Decimal: %s", text)
+	}
+	return Decimal{unscaled: unscaled, scale: int32(len(fractionPart))}, nil
+}
+
+/**
+ * 解析十进制文本，失败时 panic（用于常量）
+ */
+func MustDecimal(text string) Decimal {
+	d, err := NewDecimalFromString(text)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d Decimal) value() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+/**
+ * 小数位数
+ */
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+/**
+ * 符号：-1、0、1
+ */
+func (d Decimal) Sign() int {
+	return d.value().Sign()
+}
+
+/**
+ * 是否为 0
+ */
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+/**
+ * 对齐到 scale（只放大，不丢失精度）
+ */
+func (d Decimal) rescaleUp(scale int32) *big.Int {
+	if scale <= d.scale {
+		return new(big.Int).Set(d.value())
+	}
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale-d.scale)), nil)
+	return new(big.Int).Mul(d.value(), factor)
+}
+
+func maxScale(a, b Decimal) int32 {
+	if a.scale > b.scale {
+		return a.scale
+	}
+	return b.scale
+}
+
+/**
+ * 加法
+ */
+func (d Decimal) Add(other Decimal) Decimal {
+	scale := maxScale(d, other)
+	return Decimal{unscaled: new(big.Int).Add(d.rescaleUp(scale), other.rescaleUp(scale)), scale: scale}
+}
+
+/**
+ * 减法
+ */
+func (d Decimal) Sub(other Decimal) Decimal {
+	scale := maxScale(d, other)
+	return Decimal{unscaled: new(big.Int).Sub(d.rescaleUp(scale), other.rescaleUp(scale)), scale: scale}
+}
+
+/**
+ * 乘法（小数位数相加，需要时用 Round 收敛）
+ */
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.value(), other.value()), scale: d.scale + other.scale}
+}
+
+/**
+ * 取反
+ */
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.value()), scale: d.scale}
+}
+
+/**
+ * 比较大小：-1、0、1
+ */
+func (d Decimal) Cmp(other Decimal) int {
+	scale := maxScale(d, other)
+	return d.rescaleUp(scale).Cmp(other.rescaleUp(scale))
+}
+
+/**
+ * 数值是否相等（忽略小数位数，1.50 等于 1.5）
+ */
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+/**
+ * 四舍五入（远离零）到 scale 位小数
+ */
+func (d Decimal) Round(scale int32) Decimal {
+	if scale >= d.scale {
+		return Decimal{unscaled: d.rescaleUp(scale), scale: scale}
+	}
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.scale-scale)), nil)
+	quotient, remainder := new(big.Int).QuoRem(d.value(), factor, new(big.Int))
+	// |remainder| * 2 >= factor 时进位
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(factor) >= 0 {
+		if d.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	return Decimal{unscaled: quotient, scale: scale}
+}
+
+/**
+ * 转换为 float64（可能损失精度，仅用于展示 / 统计）
+ */
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+/**
+ * 十进制文本（保留全部小数位，如 12.340）
+ */
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.value()).String()
+	sign := ""
+	if d.Sign() < 0 {
+		sign = "-"
+	}
+	if d.scale <= 0 {
+		if d.scale < 0 {
+			digits += strings.Repeat("0", int(-d.scale))
+		}
+		return sign + digits
+	}
+	if len(digits) <= int(d.scale) {
+		digits = strings.Repeat("0", int(d.scale)-len(digits)+1) + digits
+	}
+	point := len(digits) - int(d.scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+/**
+ * 写入数据库（文本形式，由数据库按 DECIMAL 精确解析）
+ */
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+/**
+ * 从数据库读取（DECIMAL 列通常以文本返回；整数直接转换，浮点按最短表示转换）
+ */
+func (d *Decimal) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case nil:
+		*d = Decimal{}
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case int64:
+		*d = NewDecimalFromInt(v)
+		return nil
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("不支持从 %T 读取 Decimal", src)
+	}
+	parsed, err := NewDecimalFromString(text)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+/**
+ * JSON 序列化为字符串，避免 JSON 数字被解析为 float64
+ */
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+/**
+ * JSON 反序列化（接受字符串或数字）
+ */
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "null" {
+		*d = Decimal{}
+		return nil
+	}
+	parsed, err := NewDecimalFromString(text)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+/**
+ * 解析 decimal 标签（decimal:"19,4"），返回精度与小数位数
+ */
+func decimalColumnSpec(field reflect.StructField) (int, int, bool) {
+	tag := strings.TrimSpace(field.Tag.Get("decimal"))
+	if tag == "" {
+		return 0, 0, false
+	}
+	parts := strings.Split(tag, ",")
+	precision, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || precision <= 0 || precision > 65 {
+		LogWarn("忽略无效的 decimal 标签: 字段=%s, 标签=%s", field.Name, tag)
+		return 0, 0, false
+	}
+	scale := 0
+	if len(parts) > 1 {
+		scale, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || scale < 0 || scale > precision {
+			LogWarn("忽略无效的 decimal 标签: 字段=%s, 标签=%s", field.Name, tag)
+			return 0, 0, false
+		}
+	}
+	return precision, scale, true
+}
+
+var decimalType = reflect.TypeOf(Decimal{})
+
+/**
+ * decimal 标签或 Decimal 字段的列类型（Decimal 未声明标签时为 DECIMAL(19,4)），其他字段返回空字符串
+ */
+func decimalColumnSQLType(field reflect.StructField) string {
+	if precision, scale, ok := decimalColumnSpec(field); ok {
+		return fmt.Sprintf("DECIMAL(%d,%d)", precision, scale)
+	}
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType == decimalType {
+		return "DECIMAL(19,4)"
+	}
+	return ""
+}
+
+/**
+ * 写入前把 Decimal 按 decimal 标签的小数位数四舍五入（其他值原样返回）
+ */
+func roundDecimalForColumn(field reflect.StructField, value interface{}) interface{} {
+	_, scale, ok := decimalColumnSpec(field)
+	if !ok {
+		return value
+	}
+	switch d := value.(type) {
+	case Decimal:
+		return d.Round(int32(scale))
+	case *Decimal:
+		if d != nil {
+			rounded := d.Round(int32(scale))
+			return &rounded
+		}
+	}
+	return value
+}
@@ -0,0 +1,434 @@
+package db233
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * DiagnosticsCollector - 死锁与锁等待诊断采集
+ *
+ * 按需（Capture）或在语句遇到死锁 / 锁等待超时错误时自动采集锁快照：
+ * - MySQL：SHOW ENGINE INNODB STATUS 中的 LATEST DETECTED DEADLOCK 段落 + sys.innodb_lock_waits
+ * - PostgreSQL：pg_locks / pg_stat_activity（pg_blocking_pids）
+ * 并关联被阻塞与阻塞方语句。自动采集时快照附加到返回的错误上（*LockDiagnosticsException），
+ * 同时保留在采集器中，供监控报告的 details 段落展示：
+ *
+ *   collector := db233.NewDiagnosticsCollector(db)
+ *   db.SetDiagnosticsCollector(collector)
+ *   reportGenerator.AddDiagnosticsCollector("main", collector)
+ *
+ *   if snapshot := db233.GetLockDiagnostics(err); snapshot != nil {
+ *       fmt.Println(snapshot.String())
+ *   }
+ *
+ * 采集直接使用数据源执行，不经过插件；自动采集在 minCaptureInterval 内复用上一次快照，避免死锁风暴时反复采集
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type DiagnosticsCollector struct {
+	db *Db
+
+	snapshots          []*LockDiagnosticsSnapshot
+	maxSnapshots       int
+	minCaptureInterval time.Duration
+	captureTimeout     time.Duration
+	mu                 sync.RWMutex
+}
+
+/**
+ * LockDiagnosticsSnapshot - 锁诊断快照
+ */
+type LockDiagnosticsSnapshot struct {
+	CapturedAt   time.Time        `json:"captured_at"`
+	DatabaseType EnumDatabaseType `json:"database_type"`
+
+	// 触发采集的语句与错误（按需采集时为空）
+	TriggerSql   string `json:"trigger_sql,omitempty"`
+	TriggerError string `json:"trigger_error,omitempty"`
+
+	// 当前的锁等待关系
+	LockWaits []LockWait `json:"lock_waits"`
+
+	// 最近一次死锁（MySQL 取自 LATEST DETECTED DEADLOCK 段落）
+	LatestDeadlock string `json:"latest_deadlock,omitempty"`
+
+	// 采集过程中的错误（如权限不足），快照仍可能部分可用
+	Errors []string `json:"errors,omitempty"`
+}
+
+/**
+ * LockWait - 一条锁等待关系（被阻塞方 -> 阻塞方）
+ */
+type LockWait struct {
+	WaitingPid    int64   `json:"waiting_pid"`
+	WaitingQuery  string  `json:"waiting_query"`
+	WaitSeconds   float64 `json:"wait_seconds"`
+	BlockingPid   int64   `json:"blocking_pid"`
+	BlockingQuery string  `json:"blocking_query"`
+	LockedTable   string  `json:"locked_table"`
+	LockMode      string  `json:"lock_mode"`
+}
+
+/**
+ * LockDiagnosticsException - 附带锁诊断快照的死锁 / 锁等待超时异常
+ */
+type LockDiagnosticsException struct {
+	*Db233Exception
+	Sql      string
+	Deadlock bool
+	Snapshot *LockDiagnosticsSnapshot
+}
+
+/**
+ * 创建锁诊断异常
+ */
+func NewLockDiagnosticsException(cause error, sql string, deadlock bool, snapshot *LockDiagnosticsSnapshot) *LockDiagnosticsException {
+	code, message := "LOCK_WAIT_TIMEOUT", "锁等待超时"
+	if deadlock {
+		code, message = "DEADLOCK", "检测到死锁"
+	}
+	exception := NewDb233ExceptionWithCause(cause, fmt.Sprintf("%s，已采集锁诊断快照（%d 条锁等待） (SQL: %s)", message, len(snapshot.LockWaits), sql))
+	exception.Code = code
+	return &LockDiagnosticsException{
+		Db233Exception: exception,
+		Sql:            sql,
+		Deadlock:       deadlock,
+		Snapshot:       snapshot,
+	}
+}
+
+/**
+ * 创建诊断采集器
+ *
+ * 默认保留最近 20 个快照，自动采集最小间隔 5 秒，单次采集超时 5 秒
+ */
+func NewDiagnosticsCollector(db *Db) *DiagnosticsCollector {
+	return &DiagnosticsCollector{
+		db:                 db,
+		maxSnapshots:       20,
+		minCaptureInterval: 5 * time.Second,
+		captureTimeout:     5 * time.Second,
+	}
+}
+
+/**
+ * 设置保留的快照数量
+ */
+func (dc *DiagnosticsCollector) SetMaxSnapshots(max int) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if max > 0 {
+		dc.maxSnapshots = max
+		dc.trimLocked()
+	}
+}
+
+/**
+ * 设置自动采集的最小间隔（0 表示每次错误都采集）
+ */
+func (dc *DiagnosticsCollector) SetMinCaptureInterval(interval time.Duration) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if interval >= 0 {
+		dc.minCaptureInterval = interval
+	}
+}
+
+/**
+ * 设置单次采集超时
+ */
+func (dc *DiagnosticsCollector) SetCaptureTimeout(timeout time.Duration) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if timeout > 0 {
+		dc.captureTimeout = timeout
+	}
+}
+
+/**
+ * 按需采集锁快照
+ */
+func (dc *DiagnosticsCollector) Capture() *LockDiagnosticsSnapshot {
+	return dc.capture("", nil)
+}
+
+/**
+ * 采集快照并保存
+ */
+func (dc *DiagnosticsCollector) capture(triggerSql string, triggerErr error) *LockDiagnosticsSnapshot {
+	dc.mu.RLock()
+	timeout := dc.captureTimeout
+	dc.mu.RUnlock()
+
+	snapshot := &LockDiagnosticsSnapshot{
+		CapturedAt:   time.Now(),
+		DatabaseType: dc.db.DatabaseType,
+		TriggerSql:   triggerSql,
+		LockWaits:    make([]LockWait, 0),
+	}
+	if triggerErr != nil {
+		snapshot.TriggerError = triggerErr.Error()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if dc.db.DatabaseType == EnumDatabaseTypePostgreSQL {
+		dc.capturePostgreSQL(ctx, snapshot)
+	} else {
+		dc.captureMySQL(ctx, snapshot)
+	}
+
+	dc.mu.Lock()
+	dc.snapshots = append(dc.snapshots, snapshot)
+	dc.trimLocked()
+	dc.mu.Unlock()
+
+	LogFields(WARN, "已采集锁诊断快照", "lockWaits", len(snapshot.LockWaits), "errors", len(snapshot.Errors), "sql", triggerSql)
+	return snapshot
+}
+
+/**
+ * 语句遇到锁错误时采集（间隔内复用最近的快照），返回附带快照的异常
+ */
+func (dc *DiagnosticsCollector) captureForError(sql string, err error) error {
+	deadlock, isLockError := classifyLockError(err)
+	if !isLockError {
+		return err
+	}
+
+	dc.mu.RLock()
+	var snapshot *LockDiagnosticsSnapshot
+	if n := len(dc.snapshots); n > 0 && time.Since(dc.snapshots[n-1].CapturedAt) < dc.minCaptureInterval {
+		snapshot = dc.snapshots[n-1]
+	}
+	dc.mu.RUnlock()
+
+	if snapshot == nil {
+		snapshot = dc.capture(sql, err)
+	}
+	return NewLockDiagnosticsException(err, sql, deadlock, snapshot)
+}
+
+/**
+ * 采集 MySQL 锁信息
+ */
+func (dc *DiagnosticsCollector) captureMySQL(ctx context.Context, snapshot *LockDiagnosticsSnapshot) {
+	var engineType, name, status string
+	if err := dc.db.DataSource.QueryRowContext(ctx, "SHOW ENGINE INNODB STATUS").Scan(&engineType, &name, &status); err != nil {
+		snapshot.Errors = append(snapshot.Errors, "SHOW ENGINE INNODB STATUS: "+err.Error())
+	} else {
+		snapshot.LatestDeadlock = extractInnodbStatusSection(status, "LATEST DETECTED DEADLOCK")
+	}
+
+	query := `SELECT waiting_pid, waiting_query, wait_age_secs, blocking_pid, blocking_query, locked_table, waiting_lock_mode
+FROM sys.innodb_lock_waits`
+	if err := dc.scanLockWaits(ctx, query, snapshot); err != nil {
+		snapshot.Errors = append(snapshot.Errors, "sys.innodb_lock_waits: "+err.Error())
+	}
+}
+
+/**
+ * 采集 PostgreSQL 锁信息
+ */
+func (dc *DiagnosticsCollector) capturePostgreSQL(ctx context.Context, snapshot *LockDiagnosticsSnapshot) {
+	query := `SELECT waiting.pid, waiting.query, EXTRACT(EPOCH FROM now() - waiting.query_start),
+	blocking.pid, blocking.query, COALESCE(l.relation::regclass::text, ''), COALESCE(l.mode, '')
+FROM pg_stat_activity waiting
+JOIN LATERAL unnest(pg_blocking_pids(waiting.pid)) AS blocker(pid) ON true
+JOIN pg_stat_activity blocking ON blocking.pid = blocker.pid
+LEFT JOIN pg_locks l ON l.pid = waiting.pid AND NOT l.granted`
+	if err := dc.scanLockWaits(ctx, query, snapshot); err != nil {
+		snapshot.Errors = append(snapshot.Errors, "pg_locks: "+err.Error())
+	}
+}
+
+/**
+ * 读取锁等待关系（列顺序与 LockWait 字段一致）
+ */
+func (dc *DiagnosticsCollector) scanLockWaits(ctx context.Context, query string, snapshot *LockDiagnosticsSnapshot) error {
+	rows, err := dc.db.DataSource.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var waitingQuery, blockingQuery, lockedTable, lockMode sql.NullString
+		var waitSeconds sql.NullFloat64
+		var wait LockWait
+		if err := rows.Scan(&wait.WaitingPid, &waitingQuery, &waitSeconds, &wait.BlockingPid, &blockingQuery, &lockedTable, &lockMode); err != nil {
+			return err
+		}
+		wait.WaitingQuery = waitingQuery.String
+		wait.WaitSeconds = waitSeconds.Float64
+		wait.BlockingQuery = blockingQuery.String
+		wait.LockedTable = lockedTable.String
+		wait.LockMode = lockMode.String
+		snapshot.LockWaits = append(snapshot.LockWaits, wait)
+	}
+	return rows.Err()
+}
+
+/**
+ * 获取所有快照（按采集时间先后）
+ */
+func (dc *DiagnosticsCollector) GetSnapshots() []*LockDiagnosticsSnapshot {
+	dc.mu.RLock()
+	defer dc.mu.RUnlock()
+	return append([]*LockDiagnosticsSnapshot(nil), dc.snapshots...)
+}
+
+/**
+ * 获取最近一次快照，没有时返回 nil
+ */
+func (dc *DiagnosticsCollector) GetLatestSnapshot() *LockDiagnosticsSnapshot {
+	dc.mu.RLock()
+	defer dc.mu.RUnlock()
+	if len(dc.snapshots) == 0 {
+		return nil
+	}
+	return dc.snapshots[len(dc.snapshots)-1]
+}
+
+/**
+ * 清空快照
+ */
+func (dc *DiagnosticsCollector) Clear() {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.snapshots = nil
+}
+
+/**
+ * 丢弃超出数量的旧快照（需持有写锁）
+ */
+func (dc *DiagnosticsCollector) trimLocked() {
+	if overflow := len(dc.snapshots) - dc.maxSnapshots; overflow > 0 {
+		dc.snapshots = append([]*LockDiagnosticsSnapshot(nil), dc.snapshots[overflow:]...)
+	}
+}
+
+/**
+ * 快照的文本形式
+ */
+func (s *LockDiagnosticsSnapshot) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("锁诊断快照 %s (%s)\n", s.CapturedAt.Format("2006-01-02 15:04:05"), s.DatabaseType))
+	if s.TriggerSql != "" {
+		sb.WriteString(fmt.Sprintf("  触发语句: %s\n", s.TriggerSql))
+		sb.WriteString(fmt.Sprintf("  触发错误: %s\n", s.TriggerError))
+	}
+	sb.WriteString(fmt.Sprintf("  锁等待: %d\n", len(s.LockWaits)))
+	for _, wait := range s.LockWaits {
+		sb.WriteString(fmt.Sprintf("    [%d] %s 等待 %.1fs，被 [%d] %s 阻塞（%s %s）\n",
+			wait.WaitingPid, wait.WaitingQuery, wait.WaitSeconds, wait.BlockingPid, wait.BlockingQuery, wait.LockedTable, wait.LockMode))
+	}
+	if s.LatestDeadlock != "" {
+		sb.WriteString("  最近死锁:\n")
+		for _, line := range strings.Split(s.LatestDeadlock, "\n") {
+			sb.WriteString("    " + line + "\n")
+		}
+	}
+	for _, message := range s.Errors {
+		sb.WriteString(fmt.Sprintf("  采集错误: %s\n", message))
+	}
+	return sb.String()
+}
+
+/**
+ * 设置诊断采集器，语句遇到死锁 / 锁等待超时时自动采集（nil 关闭）
+ */
+func (db *Db) SetDiagnosticsCollector(collector *DiagnosticsCollector) {
+	db.root().diagnosticsCollector = collector
+}
+
+/**
+ * 获取诊断采集器，未设置时为 nil
+ */
+func (db *Db) GetDiagnosticsCollector() *DiagnosticsCollector {
+	return db.root().diagnosticsCollector
+}
+
+/**
+ * 从错误链中取出锁诊断快照，没有时返回 nil
+ */
+func GetLockDiagnostics(err error) *LockDiagnosticsSnapshot {
+	var exception *LockDiagnosticsException
+	if errors.As(err, &exception) {
+		return exception.Snapshot
+	}
+	return nil
+}
+
+/**
+ * 是否为死锁或锁等待超时错误
+ */
+func IsLockError(err error) bool {
+	_, isLockError := classifyLockError(err)
+	return isLockError
+}
+
+/**
+ * 判断锁错误类型
+ *
+ * MySQL：1213 死锁、1205 锁等待超时；PostgreSQL：40P01 死锁、55P03 无法获取锁
+ *
+ * @return deadlock 是否为死锁
+ * @return isLockError 是否为锁错误
+ */
+func classifyLockError(err error) (deadlock bool, isLockError bool) {
+	if err == nil {
+		return false, false
+	}
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "Error 1213"), strings.Contains(message, "Deadlock found"),
+		strings.Contains(message, "40P01"), strings.Contains(message, "deadlock detected"):
+		return true, true
+	case strings.Contains(message, "Error 1205"), strings.Contains(message, "Lock wait timeout exceeded"),
+		strings.Contains(message, "55P03"), strings.Contains(message, "could not obtain lock"):
+		return false, true
+	}
+	return false, false
+}
+
+/**
+ * 截取 InnoDB 状态中的指定段落（段落标题上下各有一行分隔线）
+ */
+func extractInnodbStatusSection(status string, title string) string {
+	lines := strings.Split(status, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != title {
+			continue
+		}
+		start := i + 1
+		if start < len(lines) && isInnodbStatusSeparator(lines[start]) {
+			start++
+		}
+		end := len(lines)
+		for j := start; j+1 < len(lines); j++ {
+			// 下一个段落：分隔线 + 标题 + 分隔线
+			if isInnodbStatusSeparator(lines[j]) && j+2 < len(lines) && isInnodbStatusSeparator(lines[j+2]) {
+				end = j
+				break
+			}
+		}
+		return strings.TrimSpace(strings.Join(lines[start:end], "\n"))
+	}
+	return ""
+}
+
+/**
+ * 是否为 InnoDB 状态的分隔线（---- 或 ====）
+ */
+func isInnodbStatusSeparator(line string) bool {
+	line = strings.TrimSpace(line)
+	return len(line) >= 3 && (strings.Trim(line, "-") == "" || strings.Trim(line, "=") == "")
+}
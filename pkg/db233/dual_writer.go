@@ -0,0 +1,378 @@
+package db233
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+/**
+ * DualWriter - 双写迁移助手
+ *
+ * 写入主库成功后，把选定实体的写操作异步镜像到第二个 Db（新集群/新 schema），
+ * 用于零停机存储迁移：
+ * - 镜像写入经由有界队列异步执行，不阻塞主链路
+ * - 镜像失败或队列溢出的写入进入隔离区，可在修复后重放
+ * - 记录镜像延迟（入队到写入完成的时间）
+ *
+ * 注意：镜像使用入队时的实体指针，入队后继续修改实体会影响镜像内容
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type DualWriter struct {
+	primary   *BaseCrudRepository
+	secondary *BaseCrudRepository
+
+	// 需要镜像的表名
+	tables map[string]bool
+
+	queue      chan *DualWriteTask
+	quarantine []*QuarantinedWrite
+
+	// 统计信息
+	enqueuedWrites int64
+	mirroredWrites int64
+	failedWrites   int64
+	lastLag        time.Duration
+	maxLag         time.Duration
+
+	running  bool
+	stopChan chan bool
+	doneChan chan bool
+	mu       sync.RWMutex
+}
+
+/**
+ * EnumDualWriteOperation - 双写操作类型
+ */
+type EnumDualWriteOperation string
+
+const (
+	EnumDualWriteOperationSave   EnumDualWriteOperation = "save"
+	EnumDualWriteOperationUpdate EnumDualWriteOperation = "update"
+	EnumDualWriteOperationDelete EnumDualWriteOperation = "delete"
+)
+
+/**
+ * DualWriteTask - 待镜像的写操作
+ */
+type DualWriteTask struct {
+	Operation  EnumDualWriteOperation
+	Entity     IDbEntity
+	Id         interface{}
+	EnqueuedAt time.Time
+}
+
+/**
+ * QuarantinedWrite - 被隔离的镜像写入
+ */
+type QuarantinedWrite struct {
+	Task          *DualWriteTask
+	Error         error
+	QuarantinedAt time.Time
+}
+
+/**
+ * 创建双写迁移助手
+ *
+ * @param primary 主库（当前生效的存储）
+ * @param secondary 镜像库（迁移目标）
+ * @param queueSize 镜像队列容量，<= 0 时默认 1024
+ */
+func NewDualWriter(primary *Db, secondary *Db, queueSize int) *DualWriter {
+	if queueSize <= 0 {
+		queueSize = 1024
+	}
+	return &DualWriter{
+		primary:    NewBaseCrudRepository(primary),
+		secondary:  NewBaseCrudRepository(secondary),
+		tables:     make(map[string]bool),
+		queue:      make(chan *DualWriteTask, queueSize),
+		quarantine: make([]*QuarantinedWrite, 0),
+	}
+}
+
+/**
+ * 注册需要镜像的实体
+ */
+func (dw *DualWriter) RegisterEntity(entityType IDbEntity) {
+	tableName := dw.primary.getTableName(entityType)
+
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+	dw.tables[tableName] = true
+	LogInfo("双写已注册实体: 表=%s", tableName)
+}
+
+/**
+ * 判断实体是否需要镜像
+ */
+func (dw *DualWriter) IsMirrored(entityType IDbEntity) bool {
+	tableName := dw.primary.getTableName(entityType)
+
+	dw.mu.RLock()
+	defer dw.mu.RUnlock()
+	return dw.tables[tableName]
+}
+
+/**
+ * 启动镜像工作协程
+ */
+func (dw *DualWriter) Start() {
+	dw.mu.Lock()
+	if dw.running {
+		dw.mu.Unlock()
+		return
+	}
+	dw.running = true
+	dw.stopChan = make(chan bool)
+	dw.doneChan = make(chan bool)
+	dw.mu.Unlock()
+
+	LogInfo("双写镜像已启动: 队列容量=%d", cap(dw.queue))
+
+	go func() {
+		defer close(dw.doneChan)
+		for {
+			select {
+			case task := <-dw.queue:
+				dw.apply(task)
+			case <-dw.stopChan:
+				dw.drain()
+				LogInfo("双写镜像已停止")
+				return
+			}
+		}
+	}()
+}
+
+/**
+ * 停止镜像工作协程（会先处理完队列中剩余的写入）
+ */
+func (dw *DualWriter) Stop() {
+	dw.mu.Lock()
+	if !dw.running {
+		dw.mu.Unlock()
+		return
+	}
+	dw.running = false
+	dw.mu.Unlock()
+
+	close(dw.stopChan)
+	<-dw.doneChan
+}
+
+/**
+ * 保存实体，并镜像到第二个 Db
+ */
+func (dw *DualWriter) Save(entity IDbEntity) error {
+	if err := dw.primary.Save(entity); err != nil {
+		return err
+	}
+	if dw.IsMirrored(entity) {
+		dw.enqueue(&DualWriteTask{Operation: EnumDualWriteOperationSave, Entity: entity})
+	}
+	return nil
+}
+
+/**
+ * 更新实体，并镜像到第二个 Db
+ */
+func (dw *DualWriter) Update(entity IDbEntity) error {
+	if err := dw.primary.Update(entity); err != nil {
+		return err
+	}
+	if dw.IsMirrored(entity) {
+		dw.enqueue(&DualWriteTask{Operation: EnumDualWriteOperationUpdate, Entity: entity})
+	}
+	return nil
+}
+
+/**
+ * 根据主键删除，并镜像到第二个 Db
+ */
+func (dw *DualWriter) DeleteById(id interface{}, entityType IDbEntity) error {
+	if err := dw.primary.DeleteById(id, entityType); err != nil {
+		return err
+	}
+	if dw.IsMirrored(entityType) {
+		dw.enqueue(&DualWriteTask{Operation: EnumDualWriteOperationDelete, Entity: entityType, Id: id})
+	}
+	return nil
+}
+
+/**
+ * 入队镜像写入，队列已满时直接隔离，不阻塞主链路
+ */
+func (dw *DualWriter) enqueue(task *DualWriteTask) {
+	task.EnqueuedAt = time.Now()
+
+	dw.mu.Lock()
+	dw.enqueuedWrites++
+	dw.mu.Unlock()
+
+	select {
+	case dw.queue <- task:
+	default:
+		dw.quarantineTask(task, NewDb233ExceptionWithCode("DUAL_WRITE_QUEUE_FULL", "双写镜像队列已满"))
+	}
+}
+
+/**
+ * 处理队列中剩余的写入
+ */
+func (dw *DualWriter) drain() {
+	for {
+		select {
+		case task := <-dw.queue:
+			dw.apply(task)
+		default:
+			return
+		}
+	}
+}
+
+/**
+ * 在镜像库执行写入
+ */
+func (dw *DualWriter) apply(task *DualWriteTask) {
+	if err := dw.execute(task); err != nil {
+		dw.quarantineTask(task, err)
+		return
+	}
+
+	lag := time.Since(task.EnqueuedAt)
+	dw.mu.Lock()
+	dw.mirroredWrites++
+	dw.lastLag = lag
+	if lag > dw.maxLag {
+		dw.maxLag = lag
+	}
+	dw.mu.Unlock()
+}
+
+/**
+ * 执行镜像写操作
+ */
+func (dw *DualWriter) execute(task *DualWriteTask) error {
+	switch task.Operation {
+	case EnumDualWriteOperationSave:
+		return dw.secondary.Save(task.Entity)
+	case EnumDualWriteOperationUpdate:
+		return dw.secondary.Update(task.Entity)
+	case EnumDualWriteOperationDelete:
+		return dw.secondary.DeleteById(task.Id, task.Entity)
+	default:
+		return NewValidationException(fmt.Sprintf("未知的双写操作: %s", task.Operation))
+	}
+}
+
+/**
+ * 隔离失败的镜像写入
+ */
+func (dw *DualWriter) quarantineTask(task *DualWriteTask, err error) {
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+	dw.failedWrites++
+	dw.quarantine = append(dw.quarantine, &QuarantinedWrite{
+		Task:          task,
+		Error:         err,
+		QuarantinedAt: time.Now(),
+	})
+	LogWarn("双写镜像失败，已隔离: 操作=%s, 实体=%T, 错误=%v", task.Operation, task.Entity, err)
+}
+
+/**
+ * 获取隔离区中的写入
+ */
+func (dw *DualWriter) GetQuarantine() []*QuarantinedWrite {
+	dw.mu.RLock()
+	defer dw.mu.RUnlock()
+	result := make([]*QuarantinedWrite, len(dw.quarantine))
+	copy(result, dw.quarantine)
+	return result
+}
+
+/**
+ * 清空隔离区
+ */
+func (dw *DualWriter) ClearQuarantine() {
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+	dw.quarantine = make([]*QuarantinedWrite, 0)
+}
+
+/**
+ * 同步重放隔离区中的写入，仍然失败的写入重新进入隔离区
+ *
+ * @return int 重放成功的数量
+ */
+func (dw *DualWriter) RetryQuarantine() int {
+	dw.mu.Lock()
+	pending := dw.quarantine
+	dw.quarantine = make([]*QuarantinedWrite, 0)
+	dw.mu.Unlock()
+
+	succeeded := 0
+	for _, item := range pending {
+		if err := dw.execute(item.Task); err != nil {
+			dw.mu.Lock()
+			dw.quarantine = append(dw.quarantine, &QuarantinedWrite{
+				Task:          item.Task,
+				Error:         err,
+				QuarantinedAt: time.Now(),
+			})
+			dw.mu.Unlock()
+			continue
+		}
+		succeeded++
+		dw.mu.Lock()
+		dw.mirroredWrites++
+		dw.mu.Unlock()
+	}
+
+	LogInfo("双写隔离区重放完成: 成功=%d, 剩余=%d", succeeded, len(pending)-succeeded)
+	return succeeded
+}
+
+/**
+ * 获取最近一次镜像写入的延迟
+ */
+func (dw *DualWriter) GetLag() time.Duration {
+	dw.mu.RLock()
+	defer dw.mu.RUnlock()
+	return dw.lastLag
+}
+
+/**
+ * 获取待镜像的队列长度
+ */
+func (dw *DualWriter) GetQueueDepth() int {
+	return len(dw.queue)
+}
+
+/**
+ * 获取统计信息
+ */
+func (dw *DualWriter) GetStats() map[string]interface{} {
+	dw.mu.RLock()
+	defer dw.mu.RUnlock()
+
+	tables := make([]string, 0, len(dw.tables))
+	for table := range dw.tables {
+		tables = append(tables, table)
+	}
+
+	return map[string]interface{}{
+		"running":         dw.running,
+		"tables":          tables,
+		"enqueued_writes": dw.enqueuedWrites,
+		"mirrored_writes": dw.mirroredWrites,
+		"failed_writes":   dw.failedWrites,
+		"quarantine_size": len(dw.quarantine),
+		"queue_depth":     len(dw.queue),
+		"last_lag_ms":     dw.lastLag.Milliseconds(),
+		"max_lag_ms":      dw.maxLag.Milliseconds(),
+	}
+}
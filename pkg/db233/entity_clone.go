@@ -0,0 +1,252 @@
+package db233
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+/**
+ * 实体复制与合并工具
+ *
+ * 基于实体元数据（EntityMetadataCache）按映射列处理字段，取代手写的逐字段复制：
+ *
+ *   snapshot, _ := db233.CloneEntity(player)          // 修改前快照
+ *   columns, _ := db233.MergeNonZero(player, patch)   // 局部更新：只合并 patch 中的非零字段
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+
+/**
+ * 映射列对应的字段
+ */
+type entityColumnField struct {
+	column string
+	index  []int
+}
+
+/**
+ * 深拷贝实体
+ *
+ * 映射列字段（含嵌入结构体中的字段）做深拷贝，切片 / map / 指针不与原实体共享；
+ * 未映射的字段（db:"-"、关联字段等）按值浅拷贝
+ *
+ * @param src 源实体（结构体指针）
+ * @return IDbEntity 新实体（与 src 类型相同）
+ */
+func CloneEntity(src IDbEntity) (IDbEntity, error) {
+	srcValue, fields, err := entityColumnFieldsOf(src)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := reflect.New(srcValue.Type())
+	clone.Elem().Set(srcValue)
+	detachEmbeddedPointers(clone.Elem())
+
+	for _, field := range fields {
+		fieldValue, err := clone.Elem().FieldByIndexErr(field.index)
+		if err != nil {
+			continue
+		}
+		fieldValue.Set(deepCopyValue(fieldValue))
+	}
+
+	entity, ok := clone.Interface().(IDbEntity)
+	if !ok {
+		return nil, NewValidationException(fmt.Sprintf("实体 %T 的指针类型未实现 IDbEntity 接口", src))
+	}
+	return entity, nil
+}
+
+/**
+ * 将 src 中非零值的映射列合并到 dst
+ *
+ * 主键列不参与合并；零值判断与 Save 一致（0、空字符串、空切片等视为未设置）
+ *
+ * @param dst 目标实体（结构体指针）
+ * @param src 来源实体，类型必须与 dst 相同
+ * @return []string 被合并的列名（可直接用于局部更新）
+ */
+func MergeNonZero(dst IDbEntity, src IDbEntity) ([]string, error) {
+	dstValue, fields, err := entityColumnFieldsOf(dst)
+	if err != nil {
+		return nil, err
+	}
+	srcValue, _, err := entityColumnFieldsOf(src)
+	if err != nil {
+		return nil, err
+	}
+	if dstValue.Type() != srcValue.Type() {
+		return nil, NewValidationException(fmt.Sprintf("合并的实体类型不一致: %T <- %T", dst, src))
+	}
+	if reflect.ValueOf(dst).Kind() != reflect.Ptr {
+		return nil, NewValidationException(fmt.Sprintf("合并目标必须是结构体指针，得到 %T", dst))
+	}
+
+	pkColumns := GetCrudManagerInstance().GetPrimaryKeyColumnNames(dst)
+	repo := NewBaseCrudRepository(nil)
+	merged := make([]string, 0)
+	for _, field := range fields {
+		if containsColumn(pkColumns, field.column) {
+			continue
+		}
+		srcField, err := srcValue.FieldByIndexErr(field.index)
+		if err != nil || repo.isZeroValue(srcField.Interface()) {
+			continue
+		}
+		dstField, err := dstValue.FieldByIndexErr(field.index)
+		if err != nil {
+			// 目标的嵌入指针为 nil，先补齐
+			dstField = fieldByIndexAlloc(dstValue, field.index)
+		}
+		dstField.Set(deepCopyValue(srcField))
+		merged = append(merged, field.column)
+	}
+
+	LogDebug("合并实体字段: 实体=%T, 合并列=%v", dst, merged)
+	return merged, nil
+}
+
+/**
+ * 获取实体的结构体值及其映射列字段（按列顺序）
+ */
+func entityColumnFieldsOf(entity IDbEntity) (reflect.Value, []entityColumnField, error) {
+	if entity == nil {
+		return reflect.Value{}, nil, NewValidationException("实体不能为 nil")
+	}
+	value := reflect.ValueOf(entity)
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return reflect.Value{}, nil, NewValidationException("实体不能为 nil")
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return reflect.Value{}, nil, NewValidationException(fmt.Sprintf("实体必须是结构体，得到 %T", entity))
+	}
+
+	metadata, err := GetEntityMetadataCacheInstance().GetOrBuild(entity)
+	if err != nil {
+		return reflect.Value{}, nil, NewValidationException(err.Error())
+	}
+
+	columnToFieldName := make(map[string]string, len(metadata.FieldNameToColumn))
+	for fieldName, column := range metadata.FieldNameToColumn {
+		columnToFieldName[column] = fieldName
+	}
+
+	fields := make([]entityColumnField, 0, len(metadata.AllColumns))
+	for _, column := range metadata.AllColumns {
+		structField, ok := value.Type().FieldByName(columnToFieldName[column])
+		if !ok {
+			continue
+		}
+		fields = append(fields, entityColumnField{column: column, index: structField.Index})
+	}
+	return value, fields, nil
+}
+
+/**
+ * 为克隆体复制嵌入的结构体指针，避免修改克隆体时影响原实体
+ */
+func detachEmbeddedPointers(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.Anonymous || !field.IsExported() {
+			continue
+		}
+		fieldValue := v.Field(i)
+		switch {
+		case field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct:
+			if fieldValue.IsNil() {
+				continue
+			}
+			copied := reflect.New(field.Type.Elem())
+			copied.Elem().Set(fieldValue.Elem())
+			fieldValue.Set(copied)
+			detachEmbeddedPointers(copied.Elem())
+		case field.Type.Kind() == reflect.Struct:
+			detachEmbeddedPointers(fieldValue)
+		}
+	}
+}
+
+/**
+ * 按索引路径获取字段，途经的 nil 嵌入指针自动分配
+ */
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+/**
+ * 深拷贝值（指针、切片、map、数组、结构体递归复制，time.Time 按值复制）
+ */
+func deepCopyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type().Elem())
+		copied.Elem().Set(deepCopyValue(v.Elem()))
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopyValue(v.Index(i)))
+		}
+		return copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), deepCopyValue(iter.Value()))
+		}
+		return copied
+	case reflect.Array:
+		copied := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopyValue(v.Index(i)))
+		}
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(deepCopyValue(v.Elem()))
+		return copied
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				copied.Field(i).Set(deepCopyValue(v.Field(i)))
+			}
+		}
+		return copied
+	default:
+		return v
+	}
+}
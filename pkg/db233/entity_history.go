@@ -0,0 +1,320 @@
+package db233
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+/**
+ * EntityHistoryManager - 实体版本历史管理器
+ *
+ * 为开启历史追踪的实体保存每次写入后的完整快照，
+ * 并提供按时间范围查询历史和字段级版本对比（客服/运营工具使用）
+ *
+ * 历史表命名：<实体表名>_history
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type EntityHistoryManager struct {
+	db         *Db
+	repository *BaseCrudRepository
+	suffix     string
+}
+
+/**
+ * IHistoryTrackedEntity - 历史追踪实体接口（可选）
+ *
+ * 实体实现此接口并返回 true 时，SaveWithHistory / UpdateWithHistory 会记录快照
+ */
+type IHistoryTrackedEntity interface {
+	IDbEntity
+
+	/**
+	 * 是否开启历史追踪
+	 */
+	EnableHistoryTracking() bool
+}
+
+/**
+ * EntityVersion - 实体的某个历史版本
+ */
+type EntityVersion struct {
+	EntityId  string
+	Version   int
+	ChangedAt time.Time
+	Snapshot  map[string]interface{}
+}
+
+/**
+ * HistoryRange - 历史查询时间范围，零值表示不限制
+ */
+type HistoryRange struct {
+	From time.Time
+	To   time.Time
+}
+
+/**
+ * FieldChange - 字段级变更
+ */
+type FieldChange struct {
+	Column   string
+	OldValue interface{}
+	NewValue interface{}
+}
+
+/**
+ * 创建实体版本历史管理器
+ */
+func NewEntityHistoryManager(db *Db) *EntityHistoryManager {
+	return &EntityHistoryManager{
+		db:         db,
+		repository: NewBaseCrudRepository(db),
+		suffix:     "_history",
+	}
+}
+
+/**
+ * 获取历史表名
+ */
+func (hm *EntityHistoryManager) GetHistoryTableName(entityType IDbEntity) string {
+	return hm.repository.getTableName(entityType) + hm.suffix
+}
+
+/**
+ * 创建历史表（如果不存在）
+ */
+func (hm *EntityHistoryManager) EnsureHistoryTable(entityType IDbEntity) error {
+	if entityType == nil {
+		return NewValidationException("实体类型不能为 nil")
+	}
+
+	historyTable := hm.GetHistoryTableName(entityType)
+	createTableSQL := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			entity_id VARCHAR(255) NOT NULL,
+			version INT NOT NULL,
+			snapshot TEXT NOT NULL,
+			changed_at TIMESTAMP(3) NOT NULL,
+			UNIQUE KEY uk_entity_version (entity_id, version)
+		)
+	`, historyTable)
+
+	if _, err := hm.db.DataSource.Exec(createTableSQL); err != nil {
+		return NewQueryExceptionWithCause(err, "创建历史表失败: "+historyTable)
+	}
+
+	LogInfo("历史表已初始化: %s", historyTable)
+	return nil
+}
+
+/**
+ * 保存实体并记录历史快照
+ */
+func (hm *EntityHistoryManager) SaveWithHistory(entity IDbEntity) error {
+	if err := hm.repository.Save(entity); err != nil {
+		return err
+	}
+	if !hm.isTracked(entity) {
+		return nil
+	}
+	_, err := hm.RecordVersion(entity)
+	return err
+}
+
+/**
+ * 更新实体并记录历史快照
+ */
+func (hm *EntityHistoryManager) UpdateWithHistory(entity IDbEntity) error {
+	if err := hm.repository.Update(entity); err != nil {
+		return err
+	}
+	if !hm.isTracked(entity) {
+		return nil
+	}
+	_, err := hm.RecordVersion(entity)
+	return err
+}
+
+/**
+ * 记录实体当前状态为一个新版本
+ *
+ * @return int 新版本号（从 1 开始）
+ */
+func (hm *EntityHistoryManager) RecordVersion(entity IDbEntity) (int, error) {
+	if entity == nil {
+		return 0, NewValidationException("实体不能为 nil")
+	}
+
+	entityId := GetCrudManagerInstance().GetPrimaryKeyId(entity)
+	if entityId == nil || hm.repository.isZeroValue(entityId) {
+		return 0, NewValidationException(fmt.Sprintf("实体 %T 主键为空，无法记录历史", entity))
+	}
+
+	snapshot, err := json.Marshal(hm.repository.getFields(entity))
+	if err != nil {
+		return 0, NewDb233ExceptionWithCause(err, "序列化实体快照失败")
+	}
+
+	historyTable := hm.GetHistoryTableName(entity)
+	idStr := fmt.Sprintf("%v", entityId)
+	version := 0
+
+	err = WithTransaction(hm.db, func(tm *TransactionManager) error {
+		var maxVersion int
+		row := tm.tx.QueryRow(fmt.Sprintf("SELECT COALESCE(MAX(version), 0) FROM %s WHERE entity_id = ? FOR UPDATE", historyTable), idStr)
+		if err := row.Scan(&maxVersion); err != nil {
+			return err
+		}
+		version = maxVersion + 1
+
+		_, err := tm.Exec(fmt.Sprintf("INSERT INTO %s (entity_id, version, snapshot, changed_at) VALUES (?, ?, ?, ?)", historyTable),
+			idStr, version, string(snapshot), time.Now())
+		return err
+	})
+	if err != nil {
+		LogError("记录实体历史失败: 表=%s, ID=%s, 错误=%v", historyTable, idStr, err)
+		return 0, NewQueryExceptionWithCause(err, "记录实体历史失败: "+historyTable)
+	}
+
+	LogDebug("实体历史已记录: 表=%s, ID=%s, 版本=%d", historyTable, idStr, version)
+	return version, nil
+}
+
+/**
+ * 查询实体在时间范围内的历史版本（按版本号升序）
+ */
+func (hm *EntityHistoryManager) GetHistory(id interface{}, timeRange HistoryRange, entityType IDbEntity) ([]*EntityVersion, error) {
+	if entityType == nil {
+		return nil, NewValidationException("实体类型不能为 nil")
+	}
+	if id == nil {
+		return nil, NewValidationException("查询ID不能为 nil")
+	}
+
+	conditions := []string{"entity_id = ?"}
+	params := []interface{}{fmt.Sprintf("%v", id)}
+	if !timeRange.From.IsZero() {
+		conditions = append(conditions, "changed_at >= ?")
+		params = append(params, timeRange.From)
+	}
+	if !timeRange.To.IsZero() {
+		conditions = append(conditions, "changed_at <= ?")
+		params = append(params, timeRange.To)
+	}
+
+	query := fmt.Sprintf("SELECT entity_id, version, snapshot, changed_at FROM %s WHERE %s ORDER BY version ASC",
+		hm.GetHistoryTableName(entityType), strings.Join(conditions, " AND "))
+	return hm.queryVersions(query, params)
+}
+
+/**
+ * 获取指定版本
+ */
+func (hm *EntityHistoryManager) GetVersion(id interface{}, version int, entityType IDbEntity) (*EntityVersion, error) {
+	if entityType == nil {
+		return nil, NewValidationException("实体类型不能为 nil")
+	}
+
+	query := fmt.Sprintf("SELECT entity_id, version, snapshot, changed_at FROM %s WHERE entity_id = ? AND version = ?",
+		hm.GetHistoryTableName(entityType))
+	versions, err := hm.queryVersions(query, []interface{}{fmt.Sprintf("%v", id), version})
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, NewDb233ExceptionWithCode("HISTORY_NOT_FOUND", fmt.Sprintf("未找到历史版本: ID=%v, 版本=%d", id, version))
+	}
+	return versions[0], nil
+}
+
+/**
+ * 对比两个版本，返回字段级变更（按列名排序）
+ */
+func (hm *EntityHistoryManager) DiffVersions(id interface{}, v1, v2 int, entityType IDbEntity) ([]FieldChange, error) {
+	oldVersion, err := hm.GetVersion(id, v1, entityType)
+	if err != nil {
+		return nil, err
+	}
+	newVersion, err := hm.GetVersion(id, v2, entityType)
+	if err != nil {
+		return nil, err
+	}
+	return DiffSnapshots(oldVersion.Snapshot, newVersion.Snapshot), nil
+}
+
+/**
+ * 执行历史查询
+ */
+func (hm *EntityHistoryManager) queryVersions(query string, params []interface{}) ([]*EntityVersion, error) {
+	rows, err := hm.db.DataSource.Query(query, params...)
+	if err != nil {
+		return nil, NewQueryExceptionWithCause(err, "查询实体历史失败")
+	}
+	defer rows.Close()
+
+	versions := make([]*EntityVersion, 0)
+	for rows.Next() {
+		var snapshot string
+		version := &EntityVersion{}
+		if err := rows.Scan(&version.EntityId, &version.Version, &snapshot, &version.ChangedAt); err != nil {
+			return nil, NewQueryExceptionWithCause(err, "扫描实体历史失败")
+		}
+		if err := json.Unmarshal([]byte(snapshot), &version.Snapshot); err != nil {
+			return nil, NewDb233ExceptionWithCause(err, "解析实体快照失败")
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+/**
+ * 判断实体是否开启历史追踪（未实现 IHistoryTrackedEntity 的实体默认开启）
+ */
+func (hm *EntityHistoryManager) isTracked(entity IDbEntity) bool {
+	if tracked, ok := entity.(IHistoryTrackedEntity); ok {
+		return tracked.EnableHistoryTracking()
+	}
+	return true
+}
+
+/**
+ * 对比两个快照，返回字段级变更（按列名排序）
+ *
+ * 仅存在于一侧的字段也视为变更，缺失一侧的值为 nil
+ */
+func DiffSnapshots(oldSnapshot, newSnapshot map[string]interface{}) []FieldChange {
+	columns := make(map[string]bool)
+	for col := range oldSnapshot {
+		columns[col] = true
+	}
+	for col := range newSnapshot {
+		columns[col] = true
+	}
+
+	sortedColumns := make([]string, 0, len(columns))
+	for col := range columns {
+		sortedColumns = append(sortedColumns, col)
+	}
+	sort.Strings(sortedColumns)
+
+	changes := make([]FieldChange, 0)
+	for _, col := range sortedColumns {
+		oldValue, oldExists := oldSnapshot[col]
+		newValue, newExists := newSnapshot[col]
+		if oldExists && newExists && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		changes = append(changes, FieldChange{
+			Column:   col,
+			OldValue: oldValue,
+			NewValue: newValue,
+		})
+	}
+	return changes
+}
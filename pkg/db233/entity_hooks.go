@@ -0,0 +1,129 @@
+package db233
+
+import (
+	"context"
+)
+
+/**
+ * 实体生命周期钩子（可选接口）
+ *
+ * 在 SerializeBeforeSaveDb / DeserializeAfterLoadDb 之外，实体可按需实现以下接口，
+ * BaseCrudRepository 会在对应时机调用；Before* 钩子返回错误时操作被否决，错误原样返回
+ *
+ * 调用顺序：
+ *   Save / Update：BeforeSave -> SerializeBeforeSaveDb -> 校验 -> 写库 -> AfterSave
+ *   DeleteById：   加载实体 -> BeforeDelete -> 删除（记录不存在时不调用钩子）
+ *   FindById / FindAll / FindByCondition：DeserializeAfterLoadDb -> AfterFind
+ *
+ * 钩子收到的 context 由 BaseCrudRepository.WithContext 指定；不修改实体的横切逻辑见 RepositoryInterceptor
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+
+/**
+ * BeforeSaveHook - 保存 / 更新前钩子
+ */
+type BeforeSaveHook interface {
+	BeforeSave(ctx context.Context) error
+}
+
+/**
+ * AfterSaveHook - 保存 / 更新成功后钩子
+ */
+type AfterSaveHook interface {
+	AfterSave(ctx context.Context)
+}
+
+/**
+ * BeforeDeleteHook - 删除前钩子
+ */
+type BeforeDeleteHook interface {
+	BeforeDelete(ctx context.Context) error
+}
+
+/**
+ * AfterFindHook - 查询加载后钩子
+ */
+type AfterFindHook interface {
+	AfterFind(ctx context.Context)
+}
+
+/**
+ * 返回绑定了 context 的存储库副本（原存储库不受影响）
+ *
+ * ctx 中有同一 Db 的事务（见 ContextWithTx）时，副本的语句在该事务中执行
+ */
+func (r *BaseCrudRepository) WithContext(ctx context.Context) *BaseCrudRepository {
+	clone := *r
+	clone.ctx = ctx
+	clone.db = r.db.WithContext(ctx)
+	return &clone
+}
+
+/**
+ * 获取传给生命周期钩子的 context
+ */
+func (r *BaseCrudRepository) GetContext() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+/**
+ * 调用保存前钩子
+ */
+func (r *BaseCrudRepository) callBeforeSave(entity IDbEntity) error {
+	if hook, ok := entity.(BeforeSaveHook); ok {
+		if err := hook.BeforeSave(r.GetContext()); err != nil {
+			LogDebug("BeforeSave 钩子否决写入: 实体=%T, 错误=%v", entity, err)
+			return err
+		}
+	}
+	return r.interceptBeforeSave(entity)
+}
+
+/**
+ * 调用保存后钩子
+ */
+func (r *BaseCrudRepository) callAfterSave(entity IDbEntity) {
+	if hook, ok := entity.(AfterSaveHook); ok {
+		hook.AfterSave(r.GetContext())
+	}
+	r.interceptAfterSave(entity)
+}
+
+/**
+ * 调用删除前钩子与拦截器（实体类型实现了钩子或有删除拦截器时才加载记录）
+ */
+func (r *BaseCrudRepository) callBeforeDelete(id interface{}, entityType IDbEntity) error {
+	if _, ok := entityType.(BeforeDeleteHook); !ok && !r.hasDeleteInterceptor() {
+		return nil
+	}
+
+	current, err := r.FindById(id, entityType)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return nil
+	}
+	if hook, ok := current.(BeforeDeleteHook); ok {
+		if err := hook.BeforeDelete(r.GetContext()); err != nil {
+			LogDebug("BeforeDelete 钩子否决删除: 实体=%T, ID=%v, 错误=%v", entityType, id, err)
+			return err
+		}
+	}
+	return r.interceptOnDelete(id, current)
+}
+
+/**
+ * 调用查询后钩子
+ */
+func (r *BaseCrudRepository) callAfterFind(entity IDbEntity) {
+	if hook, ok := entity.(AfterFindHook); ok {
+		hook.AfterFind(r.GetContext())
+	}
+	r.interceptAfterFind(entity)
+}
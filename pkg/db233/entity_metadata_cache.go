@@ -21,9 +21,12 @@ type EntityMetadata struct {
 	// 表名
 	TableName string
 
-	// 主键列名
+	// 主键列名（复合主键时为第一个主键列）
 	PrimaryKeyColumn string
 
+	// 全部主键列名（按字段顺序，复合主键时有多个）
+	PrimaryKeyColumns []string
+
 	// 主键字段名（struct field name）
 	PrimaryKeyFieldName string
 
@@ -123,6 +126,7 @@ func (c *EntityMetadataCache) buildMetadata(entity interface{}, entityType refle
 		ColumnToFieldIndex: make(map[string]int),
 		FieldNameToColumn:  make(map[string]string),
 		AllColumns:         make([]string, 0),
+		PrimaryKeyColumns:  make([]string, 0),
 	}
 
 	// 获取表名
@@ -148,6 +152,7 @@ func (c *EntityMetadataCache) buildMetadata(entity interface{}, entityType refle
 	// 如果没有找到主键，使用默认值 "id"
 	if metadata.PrimaryKeyColumn == "" {
 		metadata.PrimaryKeyColumn = "id"
+		metadata.PrimaryKeyColumns = []string{"id"}
 		LogWarn("实体 %s 未找到主键字段，使用默认主键列名: id", entityType.Name())
 	}
 
@@ -199,8 +204,11 @@ func (c *EntityMetadataCache) scanFields(t reflect.Type, metadata *EntityMetadat
 
 		// 检查是否为主键
 		if cm.IsPrimaryKey(field) {
-			metadata.PrimaryKeyColumn = columnName
-			metadata.PrimaryKeyFieldName = field.Name
+			if metadata.PrimaryKeyColumn == "" {
+				metadata.PrimaryKeyColumn = columnName
+				metadata.PrimaryKeyFieldName = field.Name
+			}
+			metadata.PrimaryKeyColumns = append(metadata.PrimaryKeyColumns, columnName)
 
 			// 检查是否自增（支持两种方式）
 			if cm.IsAutoIncrement(field) {
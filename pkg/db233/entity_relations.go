@@ -0,0 +1,388 @@
+package db233
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+/**
+ * 实体关联加载（has-one / has-many / belongs-to）
+ *
+ * 关联字段通过 rel 标签声明，不需要（也不应该）声明 db 标签：
+ *
+ *   type Player struct {
+ *       ID        int          `db:"id,primary_key"`
+ *       GuildId   int          `db:"guild_id"`
+ *       Inventory []*Item      `rel:"hasMany,foreignKey=player_id"`
+ *       Strength  *Strength    `rel:"hasOne,foreignKey=player_id"`
+ *       Guild     *Guild       `rel:"belongsTo,foreignKey=guild_id"`
+ *   }
+ *
+ * 标签选项：
+ *   - foreignKey：hasOne / hasMany 为目标表中指向本实体的列；belongsTo 为本实体中指向目标的列
+ *   - references：被引用的列，默认为被引用实体的主键
+ *
+ * 每个关联只发出一条 IN 查询（WHERE fk IN (...)），批量加载时同样如此
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+
+/**
+ * RelationKind - 关联类型
+ */
+type RelationKind string
+
+const (
+	RelationHasOne    RelationKind = "hasOne"
+	RelationHasMany   RelationKind = "hasMany"
+	RelationBelongsTo RelationKind = "belongsTo"
+)
+
+/**
+ * RelationMetadata - 关联字段元数据
+ */
+type RelationMetadata struct {
+	// 结构体字段名（LoadRelations 使用的名称）
+	FieldName string
+
+	// 关联类型
+	Kind RelationKind
+
+	// 外键列
+	ForeignKey string
+
+	// 被引用列
+	References string
+
+	// 目标实体类型（非指针）
+	TargetType reflect.Type
+
+	// 字段索引
+	fieldIndex []int
+
+	// 字段元素是否为指针（[]*T / *T）
+	elemIsPtr bool
+}
+
+var (
+	relationMetadataCache   = make(map[reflect.Type]map[string]*RelationMetadata)
+	relationMetadataCacheMu sync.RWMutex
+)
+
+/**
+ * 返回绑定了预加载关联的存储库副本（原存储库不受影响）
+ *
+ * 之后的 FindById / FindAll / FindByCondition 会自动批量加载这些关联，
+ * 此时返回的实体均为指针
+ *
+ * @param relations 关联字段名
+ * @return *BaseCrudRepository 新的存储库
+ */
+func (r *BaseCrudRepository) WithRelations(relations ...string) *BaseCrudRepository {
+	clone := *r
+	clone.relations = relations
+	return &clone
+}
+
+/**
+ * 加载单个实体的关联
+ *
+ * @param entity 实体指针
+ * @param relations 关联字段名
+ */
+func (r *BaseCrudRepository) LoadRelations(entity IDbEntity, relations ...string) error {
+	if entity == nil {
+		return NewValidationException("实体不能为 nil")
+	}
+	return r.LoadRelationsBatch([]IDbEntity{entity}, relations...)
+}
+
+/**
+ * 批量加载实体的关联（每个关联一条 IN 查询）
+ *
+ * @param entities 同一类型的实体指针列表
+ * @param relations 关联字段名
+ */
+func (r *BaseCrudRepository) LoadRelationsBatch(entities []IDbEntity, relations ...string) error {
+	if len(entities) == 0 || len(relations) == 0 {
+		return nil
+	}
+
+	ownerType := reflect.TypeOf(entities[0])
+	if ownerType.Kind() != reflect.Ptr {
+		return NewValidationException(fmt.Sprintf("加载关联需要实体指针，实际类型: %T", entities[0]))
+	}
+	for i, entity := range entities {
+		if reflect.TypeOf(entity) != ownerType {
+			return NewValidationException(fmt.Sprintf("批量加载关联要求实体类型一致: 索引=%d, 期望=%s, 实际=%T", i, ownerType, entity))
+		}
+	}
+
+	metas, err := GetRelationMetadata(ownerType.Elem())
+	if err != nil {
+		return err
+	}
+
+	for _, name := range relations {
+		meta, ok := metas[name]
+		if !ok {
+			return NewValidationException(fmt.Sprintf("实体 %s 没有名为 %s 的关联（需声明 rel 标签）", ownerType.Elem().Name(), name))
+		}
+		if err := r.loadRelation(entities, meta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ * 获取实体类型的关联元数据（按字段名索引，结果缓存）
+ */
+func GetRelationMetadata(t reflect.Type) (map[string]*RelationMetadata, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	relationMetadataCacheMu.RLock()
+	metas, exists := relationMetadataCache[t]
+	relationMetadataCacheMu.RUnlock()
+	if exists {
+		return metas, nil
+	}
+
+	metas, err := parseRelationMetadata(t)
+	if err != nil {
+		return nil, err
+	}
+
+	relationMetadataCacheMu.Lock()
+	relationMetadataCache[t] = metas
+	relationMetadataCacheMu.Unlock()
+	return metas, nil
+}
+
+/**
+ * 解析 rel 标签
+ */
+func parseRelationMetadata(t reflect.Type) (map[string]*RelationMetadata, error) {
+	metas := make(map[string]*RelationMetadata)
+	entityInterface := reflect.TypeOf((*IDbEntity)(nil)).Elem()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("rel")
+		if tag == "" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		meta := &RelationMetadata{
+			FieldName:  field.Name,
+			Kind:       RelationKind(strings.TrimSpace(parts[0])),
+			fieldIndex: field.Index,
+		}
+		for _, part := range parts[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch strings.TrimSpace(key) {
+			case "foreignKey":
+				meta.ForeignKey = strings.TrimSpace(value)
+			case "references":
+				meta.References = strings.TrimSpace(value)
+			default:
+				return nil, NewConfigurationException(fmt.Sprintf("关联字段 %s.%s 的 rel 标签选项未知: %s", t.Name(), field.Name, part))
+			}
+		}
+
+		targetType := field.Type
+		switch meta.Kind {
+		case RelationHasMany:
+			if targetType.Kind() != reflect.Slice {
+				return nil, NewConfigurationException(fmt.Sprintf("hasMany 关联字段 %s.%s 必须为切片", t.Name(), field.Name))
+			}
+			targetType = targetType.Elem()
+		case RelationHasOne, RelationBelongsTo:
+		default:
+			return nil, NewConfigurationException(fmt.Sprintf("关联字段 %s.%s 的关联类型未知: %s", t.Name(), field.Name, meta.Kind))
+		}
+		if targetType.Kind() == reflect.Ptr {
+			meta.elemIsPtr = true
+			targetType = targetType.Elem()
+		}
+		if targetType.Kind() != reflect.Struct || !reflect.PointerTo(targetType).Implements(entityInterface) {
+			return nil, NewConfigurationException(fmt.Sprintf("关联字段 %s.%s 的目标类型 %s 未实现 IDbEntity 接口", t.Name(), field.Name, targetType))
+		}
+		meta.TargetType = targetType
+
+		if meta.ForeignKey == "" {
+			if meta.Kind == RelationBelongsTo {
+				meta.ForeignKey = StringUtilsInstance.CamelToSnake(field.Name) + "_id"
+			} else {
+				meta.ForeignKey = StringUtilsInstance.CamelToSnake(t.Name()) + "_id"
+			}
+		}
+		if meta.References == "" {
+			referenced := reflect.New(t).Interface()
+			if meta.Kind == RelationBelongsTo {
+				referenced = reflect.New(targetType).Interface()
+			}
+			meta.References = GetCrudManagerInstance().GetPrimaryKeyColumnName(referenced)
+			if meta.References == "" {
+				meta.References = "id"
+			}
+		}
+
+		metas[field.Name] = meta
+	}
+	return metas, nil
+}
+
+/**
+ * 加载一个关联并回填到所有实体
+ */
+func (r *BaseCrudRepository) loadRelation(entities []IDbEntity, meta *RelationMetadata) error {
+	// 本实体上用于匹配的列：hasX 为被引用列，belongsTo 为外键列
+	ownerColumn, targetColumn := meta.References, meta.ForeignKey
+	if meta.Kind == RelationBelongsTo {
+		ownerColumn, targetColumn = meta.ForeignKey, meta.References
+	}
+
+	ownerKeys := make([]string, len(entities))
+	keys := make([]interface{}, 0, len(entities))
+	seen := make(map[string]bool, len(entities))
+	for i, entity := range entities {
+		value, exists := r.getFields(entity)[ownerColumn]
+		if !exists {
+			return NewValidationException(fmt.Sprintf("实体 %T 缺少关联 %s 所需的列 %s", entity, meta.FieldName, ownerColumn))
+		}
+		if r.isZeroValue(value) {
+			continue
+		}
+		key := fmt.Sprint(value)
+		ownerKeys[i] = key
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, value)
+		}
+	}
+
+	grouped := make(map[string][]reflect.Value)
+	if len(keys) > 0 {
+		targets, err := r.queryRelationTargets(meta.TargetType, targetColumn, keys)
+		if err != nil {
+			return err
+		}
+		for _, target := range targets {
+			key := fmt.Sprint(r.getFields(target.Interface())[targetColumn])
+			grouped[key] = append(grouped[key], target)
+		}
+	}
+
+	for i, entity := range entities {
+		field := reflect.ValueOf(entity).Elem().FieldByIndex(meta.fieldIndex)
+		var matched []reflect.Value
+		if ownerKeys[i] != "" {
+			matched = grouped[ownerKeys[i]]
+		}
+
+		if meta.Kind == RelationHasMany {
+			slice := reflect.MakeSlice(field.Type(), 0, len(matched))
+			for _, target := range matched {
+				slice = reflect.Append(slice, relationElem(target, meta.elemIsPtr))
+			}
+			field.Set(slice)
+			continue
+		}
+
+		if len(matched) == 0 {
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+		if len(matched) > 1 {
+			LogWarn("%s 关联匹配到多条记录，取第一条: 实体=%T, 关联=%s, 键=%s", meta.Kind, entity, meta.FieldName, ownerKeys[i])
+		}
+		field.Set(relationElem(matched[0], meta.elemIsPtr))
+	}
+
+	LogDebug("关联加载完成: 关联=%s, 类型=%s, 实体数=%d, 目标记录数=%d", meta.FieldName, meta.Kind, len(entities), len(grouped))
+	return nil
+}
+
+/**
+ * 以 IN 查询加载目标实体（返回指针）
+ */
+func (r *BaseCrudRepository) queryRelationTargets(targetType reflect.Type, column string, keys []interface{}) ([]reflect.Value, error) {
+	prototype := reflect.New(targetType).Interface().(IDbEntity)
+	tableName := r.getTableName(prototype)
+	if tableName == "" {
+		return nil, NewValidationException(fmt.Sprintf("无法获取关联实体 %s 的表名", targetType.Name()))
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(keys)), ", ")
+	where, params, err := r.applyScope(prototype, column+" IN ("+placeholders+")", keys)
+	if err != nil {
+		return nil, err
+	}
+	sql := "SELECT * FROM " + tableName + " WHERE " + where
+	LogDebug("执行关联查询: 表=%s, 列=%s, 键数=%d, SQL=%s", tableName, column, len(keys), sql)
+
+	results, err := r.db.executeQueryBatchE(sql, [][]interface{}{params}, prototype)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]reflect.Value, 0, len(results))
+	for _, result := range results {
+		ptr := toEntityPointer(result)
+		dbEntity, ok := ptr.Interface().(IDbEntity)
+		if !ok {
+			LogWarn("关联查询结果类型错误: 表=%s, 结果类型=%T, 未实现 IDbEntity 接口", tableName, result)
+			continue
+		}
+		dbEntity.DeserializeAfterLoadDb()
+		r.callAfterFind(dbEntity)
+		targets = append(targets, ptr)
+	}
+	return targets, nil
+}
+
+/**
+ * 预加载 WithRelations 指定的关联（结果统一转换为指针）
+ */
+func (r *BaseCrudRepository) preloadRelations(entities []IDbEntity) ([]IDbEntity, error) {
+	if len(r.relations) == 0 || len(entities) == 0 {
+		return entities, nil
+	}
+	pointers := make([]IDbEntity, len(entities))
+	for i, entity := range entities {
+		pointers[i] = toEntityPointer(entity).Interface().(IDbEntity)
+	}
+	if err := r.LoadRelationsBatch(pointers, r.relations...); err != nil {
+		return nil, err
+	}
+	return pointers, nil
+}
+
+/**
+ * 把查询结果转换为指针（ORM 映射返回的是结构体值）
+ */
+func toEntityPointer(result interface{}) reflect.Value {
+	v := reflect.ValueOf(result)
+	if v.Kind() == reflect.Ptr {
+		return v
+	}
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	return ptr
+}
+
+/**
+ * 按字段元素类型取指针或值
+ */
+func relationElem(target reflect.Value, elemIsPtr bool) reflect.Value {
+	if elemIsPtr {
+		return target
+	}
+	return target.Elem()
+}
@@ -0,0 +1,110 @@
+package db233
+
+import (
+	"fmt"
+	"reflect"
+)
+
+/**
+ * EntitySeeder - 实体种子数据
+ *
+ * 实体实现该接口后，自动建表 / 迁移（AutoCreateTable、AutoMigrateTable、AutoMigrateTableSimple、
+ * AutoMigrateAllTablesConcurrently）完成时按主键 upsert 这些记录，新环境中的道具表等配置表无需手工导数：
+ *
+ *   func (e *ItemCatalog) SeedData() []db233.IDbEntity {
+ *       return []db233.IDbEntity{
+ *           &ItemCatalog{ItemId: 1001, Name: "小血瓶"},
+ *           &ItemCatalog{ItemId: 1002, Name: "大血瓶"},
+ *       }
+ *   }
+ *
+ * 种子记录必须带主键值（重复执行时按主键覆盖，不会产生重复行）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type EntitySeeder interface {
+	SeedData() []IDbEntity
+}
+
+/**
+ * 写入实体的种子数据（幂等）
+ *
+ * @param db 数据库
+ * @param entityType 实体类型（未实现 EntitySeeder 时直接返回）
+ * @return int 写入的记录数
+ * @return error 写入错误
+ */
+func SeedEntityData(db *Db, entityType interface{}) (int, error) {
+	seeder, ok := seederOf(entityType)
+	if !ok {
+		return 0, nil
+	}
+
+	seeds := seeder.SeedData()
+	if len(seeds) == 0 {
+		return 0, nil
+	}
+
+	cm := GetCrudManagerInstance()
+	repo := NewBaseCrudRepository(nil)
+	for i, seed := range seeds {
+		if seed == nil {
+			return 0, NewValidationException(fmt.Sprintf("实体 %T 的第 %d 条种子数据为 nil", entityType, i))
+		}
+		fields := repo.getFields(seed)
+		for _, pkColumn := range cm.GetPrimaryKeyColumnNames(seed) {
+			if repo.isZeroValue(fields[pkColumn]) {
+				return 0, NewValidationException(fmt.Sprintf("实体 %T 的第 %d 条种子数据缺少主键 %s，种子数据必须指定主键以保证幂等", entityType, i, pkColumn))
+			}
+		}
+	}
+
+	if err := NewBaseCrudRepository(db).SaveBatch(seeds); err != nil {
+		return 0, err
+	}
+	LogInfo("种子数据已写入: 实体=%T, 记录数=%d", entityType, len(seeds))
+	return len(seeds), nil
+}
+
+/**
+ * 建表 / 迁移完成后写入种子数据（表不存在时跳过，如建表权限被关闭）
+ */
+func (cm *CrudManager) seedAfterSchema(db *Db, entityType interface{}) error {
+	if _, ok := seederOf(entityType); !ok {
+		return nil
+	}
+
+	tableName := cm.GetTableName(reflect.Indirect(reflect.ValueOf(entityType)).Type())
+	exists, err := GetStrategyFactoryInstance().GetStrategyForDb(db).TableExists(db, tableName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		LogWarn("表不存在，跳过种子数据: 表=%s", tableName)
+		return nil
+	}
+
+	_, err = SeedEntityData(db, entityType)
+	return err
+}
+
+/**
+ * 获取实体的种子数据接口（兼容值类型）
+ */
+func seederOf(entityType interface{}) (EntitySeeder, bool) {
+	if entityType == nil {
+		return nil, false
+	}
+	if seeder, ok := entityType.(EntitySeeder); ok {
+		return seeder, true
+	}
+	v := reflect.ValueOf(entityType)
+	if v.Kind() != reflect.Ptr {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		seeder, ok := ptr.Interface().(EntitySeeder)
+		return seeder, ok
+	}
+	return nil, false
+}
@@ -0,0 +1,230 @@
+package db233
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/**
+ * EnumRegistry - PostgreSQL 枚举类型注册表
+ *
+ * Go 无法反射出某个类型的全部常量，枚举值需显式声明一次；Sync 据此生成 CREATE TYPE ... AS ENUM，
+ * 之后新增的常量通过 ALTER TYPE ... ADD VALUE IF NOT EXISTS 追加（PostgreSQL 不支持删除枚举值）：
+ *
+ *   type PlayerRole string
+ *   const (
+ *       PlayerRoleAdmin  PlayerRole = "admin"
+ *       PlayerRoleMember PlayerRole = "member"
+ *   )
+ *
+ *   db233.GetEnumRegistryInstance().Register("player_role", PlayerRoleAdmin, PlayerRoleMember)
+ *   err := db233.GetEnumRegistryInstance().Sync(pgDb)
+ *
+ *   Role PlayerRole `db:"role" db_type:"player_role"`
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type EnumRegistry struct {
+	enums   map[string]*EnumTypeDefinition
+	goTypes map[reflect.Type]string
+	mu      sync.RWMutex
+}
+
+/**
+ * EnumTypeDefinition - 枚举类型定义（值按声明顺序排列）
+ */
+type EnumTypeDefinition struct {
+	Name   string
+	GoType reflect.Type
+	Values []string
+}
+
+var (
+	enumRegistryInstance *EnumRegistry
+	enumRegistryOnce     sync.Once
+
+	enumTypeNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+)
+
+/**
+ * 获取枚举注册表单例
+ */
+func GetEnumRegistryInstance() *EnumRegistry {
+	enumRegistryOnce.Do(func() {
+		enumRegistryInstance = NewEnumRegistry()
+	})
+	return enumRegistryInstance
+}
+
+/**
+ * 创建枚举注册表
+ */
+func NewEnumRegistry() *EnumRegistry {
+	return &EnumRegistry{
+		enums:   make(map[string]*EnumTypeDefinition),
+		goTypes: make(map[reflect.Type]string),
+	}
+}
+
+/**
+ * 注册枚举类型
+ *
+ * @param typeName 数据库类型名（小写字母、数字、下划线）
+ * @param values 同一 Go 字符串类型的常量，重复注册同名类型时追加新值
+ */
+func (r *EnumRegistry) Register(typeName string, values ...interface{}) error {
+	if !enumTypeNamePattern.MatchString(typeName) {
+		return NewValidationException(fmt.Sprintf("非法的枚举类型名: %s", typeName))
+	}
+	if len(values) == 0 {
+		return NewValidationException(fmt.Sprintf("枚举类型 %s 至少需要一个值", typeName))
+	}
+
+	var goType reflect.Type
+	names := make([]string, 0, len(values))
+	for _, value := range values {
+		v := reflect.ValueOf(value)
+		if value == nil || v.Kind() != reflect.String {
+			return NewValidationException(fmt.Sprintf("枚举 %s 的值必须为字符串常量: %T", typeName, value))
+		}
+		if goType != nil && v.Type() != goType {
+			return NewValidationException(fmt.Sprintf("枚举 %s 的值类型不一致: %s / %s", typeName, goType, v.Type()))
+		}
+		if v.String() == "" {
+			return NewValidationException(fmt.Sprintf("枚举 %s 的值不能为空字符串", typeName))
+		}
+		goType = v.Type()
+		names = append(names, v.String())
+	}
+
+	// 只有自定义字符串类型才建立 Go 类型 -> 枚举的映射，普通 string 只登记值
+	namedType := goType.PkgPath() != ""
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existingName, exists := r.goTypes[goType]; exists && existingName != typeName {
+		return NewValidationException(fmt.Sprintf("Go 类型 %s 已注册为枚举 %s", goType, existingName))
+	}
+	definition, exists := r.enums[typeName]
+	if !exists {
+		definition = &EnumTypeDefinition{Name: typeName, GoType: goType}
+		r.enums[typeName] = definition
+	} else if definition.GoType != goType {
+		return NewValidationException(fmt.Sprintf("枚举 %s 已使用 Go 类型 %s 注册", typeName, definition.GoType))
+	}
+	for _, name := range names {
+		if !containsColumn(definition.Values, name) {
+			definition.Values = append(definition.Values, name)
+		}
+	}
+	if namedType {
+		r.goTypes[goType] = typeName
+	}
+	return nil
+}
+
+/**
+ * 获取枚举类型定义
+ */
+func (r *EnumRegistry) Get(typeName string) (*EnumTypeDefinition, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	definition, exists := r.enums[typeName]
+	return definition, exists
+}
+
+/**
+ * 获取 Go 类型对应的数据库枚举类型名
+ */
+func (r *EnumRegistry) GetTypeName(goType reflect.Type) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	typeName, exists := r.goTypes[goType]
+	return typeName, exists
+}
+
+/**
+ * 判断值是否属于其 Go 类型注册的枚举（未注册的类型返回 true）
+ */
+func (r *EnumRegistry) IsValidValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	typeName, exists := r.GetTypeName(reflect.TypeOf(value))
+	if !exists {
+		return true
+	}
+	definition, _ := r.Get(typeName)
+	return containsColumn(definition.Values, reflect.ValueOf(value).String())
+}
+
+/**
+ * 生成创建枚举类型的 SQL（类型已存在时忽略）
+ */
+func (r *EnumRegistry) GenerateCreateTypeSQL(typeName string) (string, error) {
+	definition, exists := r.Get(typeName)
+	if !exists {
+		return "", NewValidationException(fmt.Sprintf("枚举类型未注册: %s", typeName))
+	}
+	quoted := make([]string, len(definition.Values))
+	for i, value := range definition.Values {
+		quoted[i] = quoteEnumValue(value)
+	}
+	return fmt.Sprintf("DO $$ BEGIN CREATE TYPE %s AS ENUM (%s); EXCEPTION WHEN duplicate_object THEN NULL; END $$",
+		typeName, strings.Join(quoted, ", ")), nil
+}
+
+/**
+ * 生成同步全部枚举的 SQL：每个类型一条 CREATE TYPE，再为每个值追加 ADD VALUE IF NOT EXISTS（按类型名排序）
+ */
+func (r *EnumRegistry) GenerateSyncSQL() []string {
+	r.mu.RLock()
+	typeNames := make([]string, 0, len(r.enums))
+	for typeName := range r.enums {
+		typeNames = append(typeNames, typeName)
+	}
+	r.mu.RUnlock()
+	sort.Strings(typeNames)
+
+	var statements []string
+	for _, typeName := range typeNames {
+		createSQL, err := r.GenerateCreateTypeSQL(typeName)
+		if err != nil {
+			continue
+		}
+		statements = append(statements, createSQL)
+		definition, _ := r.Get(typeName)
+		for _, value := range definition.Values {
+			statements = append(statements, fmt.Sprintf("ALTER TYPE %s ADD VALUE IF NOT EXISTS %s", typeName, quoteEnumValue(value)))
+		}
+	}
+	return statements
+}
+
+/**
+ * 在 PostgreSQL 上创建 / 补全全部已注册的枚举类型
+ *
+ * ALTER TYPE ... ADD VALUE 在 PostgreSQL 12 之前不能在事务中执行，这里逐条直接执行
+ */
+func (r *EnumRegistry) Sync(db *Db) error {
+	if db.DatabaseType != EnumDatabaseTypePostgreSQL {
+		return NewConfigurationException(fmt.Sprintf("数据库类型 %s 不支持枚举类型", db.DatabaseType))
+	}
+	statements := r.GenerateSyncSQL()
+	for _, statement := range statements {
+		if _, err := db.DataSource.Exec(statement); err != nil {
+			return NewQueryExceptionWithCause(err, fmt.Sprintf("同步枚举类型失败: %s", statement))
+		}
+	}
+	LogInfo("枚举类型同步完成: 语句数=%d", len(statements))
+	return nil
+}
+
+func quoteEnumValue(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -3,6 +3,7 @@ package db233
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
 /**
@@ -113,6 +114,13 @@ func (e *Db233Exception) GetCause() error {
 	return e.Cause
 }
 
+/**
+ * 返回原因错误，支持 errors.Is / errors.As
+ */
+func (e *Db233Exception) Unwrap() error {
+	return e.Cause
+}
+
 /**
  * ConnectionException - 数据库连接异常
  */
@@ -247,3 +255,233 @@ func NewValidationExceptionWithCause(cause error, message string) *ValidationExc
 		Db233Exception: exc,
 	}
 }
+
+/**
+ * StatementTimeoutException - 语句超时异常
+ */
+type StatementTimeoutException struct {
+	*Db233Exception
+	Sql     string
+	Timeout time.Duration
+	Killed  bool
+}
+
+/**
+ * 创建语句超时异常
+ */
+func NewStatementTimeoutException(sql string, timeout time.Duration, killed bool) *StatementTimeoutException {
+	return &StatementTimeoutException{
+		Db233Exception: NewDb233ExceptionWithCode("STATEMENT_TIMEOUT", fmt.Sprintf("语句执行超过 %v 已被取消 (SQL: %s)", timeout, sql)),
+		Sql:            sql,
+		Timeout:        timeout,
+		Killed:         killed,
+	}
+}
+
+/**
+ * QueryNotAllowedException - SQL 不在白名单中（严格模式）
+ */
+type QueryNotAllowedException struct {
+	*Db233Exception
+	Sql string
+}
+
+/**
+ * 创建 SQL 白名单拒绝异常
+ */
+func NewQueryNotAllowedException(sql string) *QueryNotAllowedException {
+	return &QueryNotAllowedException{
+		Db233Exception: NewDb233ExceptionWithCode("QUERY_NOT_ALLOWED", fmt.Sprintf("SQL 未在白名单中注册，已拒绝执行 (SQL: %s)", sql)),
+		Sql:            sql,
+	}
+}
+
+/**
+ * MigrationLockTimeoutException - 等待迁移锁超时（其他实例正在迁移）
+ */
+type MigrationLockTimeoutException struct {
+	*Db233Exception
+	LockName string
+	Timeout  time.Duration
+}
+
+/**
+ * 创建迁移锁超时异常
+ */
+func NewMigrationLockTimeoutException(lockName string, timeout time.Duration) *MigrationLockTimeoutException {
+	return &MigrationLockTimeoutException{
+		Db233Exception: NewDb233ExceptionWithCode("MIGRATION_LOCK_TIMEOUT", fmt.Sprintf("等待迁移锁 %s 超过 %v，其他实例可能正在迁移", lockName, timeout)),
+		LockName:       lockName,
+		Timeout:        timeout,
+	}
+}
+
+/**
+ * SagaAbortedException - Saga 某一步失败而中止
+ *
+ * Compensated 为 true 表示已完成的步骤全部补偿成功；为 false 表示补偿失败，状态记为 FAILED，需要 Recover 重试或人工处理
+ */
+type SagaAbortedException struct {
+	*Db233Exception
+	SagaId      string
+	SagaName    string
+	FailedStep  string
+	Compensated bool
+}
+
+/**
+ * 创建 Saga 中止异常
+ */
+func NewSagaAbortedException(sagaId string, sagaName string, failedStep string, compensated bool, cause error) *SagaAbortedException {
+	code, message := "SAGA_ABORTED", fmt.Sprintf("Saga %s(%s) 在步骤 %s 失败，已补偿完成的步骤", sagaName, sagaId, failedStep)
+	if !compensated {
+		code, message = "SAGA_COMPENSATION_FAILED", fmt.Sprintf("Saga %s(%s) 在步骤 %s 失败，补偿未完成", sagaName, sagaId, failedStep)
+	}
+	exception := NewDb233ExceptionWithCode(code, message)
+	exception.Cause = cause
+	return &SagaAbortedException{
+		Db233Exception: exception,
+		SagaId:         sagaId,
+		SagaName:       sagaName,
+		FailedStep:     failedStep,
+		Compensated:    compensated,
+	}
+}
+
+/**
+ * FieldError - 单个字段的校验失败信息
+ */
+type FieldError struct {
+	Field   string
+	Column  string
+	Rule    string
+	Message string
+}
+
+/**
+ * ValidationError - 结构化校验异常，包含所有未通过校验的字段
+ */
+type ValidationError struct {
+	*ValidationException
+	Errors []FieldError
+}
+
+/**
+ * 创建结构化校验异常
+ */
+func NewValidationError(entityName string, errors []FieldError) *ValidationError {
+	messages := make([]string, 0, len(errors))
+	for _, fieldError := range errors {
+		messages = append(messages, fmt.Sprintf("%s: %s", fieldError.Field, fieldError.Message))
+	}
+	return &ValidationError{
+		ValidationException: NewValidationException(fmt.Sprintf("实体 %s 校验失败: %s", entityName, strings.Join(messages, "; "))),
+		Errors:              errors,
+	}
+}
+
+/**
+ * 获取指定字段的校验失败信息
+ */
+func (e *ValidationError) GetFieldErrors(field string) []FieldError {
+	result := make([]FieldError, 0)
+	for _, fieldError := range e.Errors {
+		if fieldError.Field == field || fieldError.Column == field {
+			result = append(result, fieldError)
+		}
+	}
+	return result
+}
+
+/**
+ * SecurityPolicyDeniedException - 行级安全策略拒绝访问
+ */
+type SecurityPolicyDeniedException struct {
+	*Db233Exception
+	TableName string
+}
+
+/**
+ * 创建行级安全策略拒绝异常
+ */
+func NewSecurityPolicyDeniedException(tableName string, cause error) *SecurityPolicyDeniedException {
+	exception := NewDb233ExceptionWithCode("SECURITY_POLICY_DENIED", fmt.Sprintf("行级安全策略拒绝访问表 %s", tableName))
+	exception.Cause = cause
+	return &SecurityPolicyDeniedException{
+		Db233Exception: exception,
+		TableName:      tableName,
+	}
+}
+
+/**
+ * CircuitOpenException - 熔断器打开，请求被快速失败
+ */
+type CircuitOpenException struct {
+	*Db233Exception
+	BreakerName string
+	RetryAfter  time.Duration
+}
+
+/**
+ * 创建熔断异常
+ *
+ * @param breakerName 熔断器名称
+ * @param retryAfter 距离进入半开的剩余时间，半开探测已满时为 0
+ */
+func NewCircuitOpenException(breakerName string, retryAfter time.Duration) *CircuitOpenException {
+	return &CircuitOpenException{
+		Db233Exception: NewDb233ExceptionWithCode("CIRCUIT_OPEN", fmt.Sprintf("数据源 %s 熔断中，请求已被拒绝（%v 后重试）", breakerName, retryAfter)),
+		BreakerName:    breakerName,
+		RetryAfter:     retryAfter,
+	}
+}
+
+/**
+ * QueryThrottledException - 语句被限流拒绝
+ */
+type QueryThrottledException struct {
+	*Db233Exception
+	ThrottleName string
+	Class        string
+	Sql          string
+}
+
+/**
+ * 创建限流异常
+ *
+ * @param throttleName 限流器名称
+ * @param class 触发限制的类别（db、类别名或 SQL 指纹）
+ * @param reason 拒绝原因
+ * @param sql 被拒绝的 SQL
+ */
+func NewQueryThrottledException(throttleName string, class string, reason string, sql string) *QueryThrottledException {
+	return &QueryThrottledException{
+		Db233Exception: NewDb233ExceptionWithCode("QUERY_THROTTLED", fmt.Sprintf("语句被限流器 %s 拒绝 [%s]: %s (SQL: %s)", throttleName, class, reason, sql)),
+		ThrottleName:   throttleName,
+		Class:          class,
+		Sql:            sql,
+	}
+}
+
+/**
+ * ReadOnlyRepositoryException - 只读存储库拒绝写操作
+ */
+type ReadOnlyRepositoryException struct {
+	*Db233Exception
+	Operation string
+	TableName string
+}
+
+/**
+ * 创建只读存储库异常
+ *
+ * @param operation 被拒绝的操作（Save、DeleteById 等）
+ * @param tableName 表名，批量操作时可能为空
+ */
+func NewReadOnlyRepositoryException(operation string, tableName string) *ReadOnlyRepositoryException {
+	return &ReadOnlyRepositoryException{
+		Db233Exception: NewDb233ExceptionWithCode("READ_ONLY_REPOSITORY", fmt.Sprintf("只读存储库不允许 %s 操作 (表: %s)", operation, tableName)),
+		Operation:      operation,
+		TableName:      tableName,
+	}
+}
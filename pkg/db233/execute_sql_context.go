@@ -1,6 +1,7 @@
 package db233
 
 import (
+	"reflect"
 	"time"
 )
 
@@ -20,6 +21,12 @@ type ExecuteSqlContext struct {
 	// SQL 参数
 	Params []interface{}
 
+	// 改写前的 SQL 语句
+	OriginalSql string
+
+	// 改写前的 SQL 参数
+	OriginalParams []interface{}
+
 	// 执行开始时间
 	StartTime time.Time
 
@@ -41,6 +48,12 @@ type ExecuteSqlContext struct {
 	// 数据库连接信息
 	DataSource interface{}
 
+	// 查询返回类型（更新语句为 nil）
+	ReturnType interface{}
+
+	// 是否已由插件直接给出结果（跳过实际执行，仅对查询生效）
+	shortCircuited bool
+
 	// 其他上下文信息
 	Attributes map[string]interface{}
 }
@@ -50,10 +63,12 @@ type ExecuteSqlContext struct {
  */
 func NewExecuteSqlContext(sql string, params []interface{}) *ExecuteSqlContext {
 	return &ExecuteSqlContext{
-		Sql:        sql,
-		Params:     params,
-		StartTime:  time.Now(),
-		Attributes: make(map[string]interface{}),
+		Sql:            sql,
+		Params:         params,
+		OriginalSql:    sql,
+		OriginalParams: params,
+		StartTime:      time.Now(),
+		Attributes:     make(map[string]interface{}),
 	}
 }
 
@@ -102,3 +117,48 @@ func (ctx *ExecuteSqlContext) GetAttribute(key string) interface{} {
 func (ctx *ExecuteSqlContext) SetAttribute(key string, value interface{}) {
 	ctx.Attributes[key] = value
 }
+
+/**
+ * 由插件直接给出查询结果，跳过实际执行（如查询缓存命中）
+ */
+func (ctx *ExecuteSqlContext) ShortCircuit(result interface{}) {
+	ctx.shortCircuited = true
+	ctx.SetResult(result, 0)
+}
+
+/**
+ * 结果是否由插件直接给出
+ */
+func (ctx *ExecuteSqlContext) IsShortCircuited() bool {
+	return ctx.shortCircuited
+}
+
+/**
+ * 改写待执行的 SQL 与参数（仅在 PreExecuteSql 中生效）
+ *
+ * 用于追加表前缀、分片提示、带 trace id 的注释等，后续插件看到的是改写后的语句：
+ *
+ *   ctx.RewriteSql(ctx.Sql+" -- trace_id=abc", ctx.Params)
+ *
+ * @param sql 新的 SQL 语句
+ * @param params 新的参数，占位符数量需与 sql 一致
+ */
+func (ctx *ExecuteSqlContext) RewriteSql(sql string, params []interface{}) {
+	ctx.Sql = sql
+	ctx.Params = params
+}
+
+/**
+ * SQL 或参数是否已被改写
+ */
+func (ctx *ExecuteSqlContext) IsRewritten() bool {
+	if ctx.Sql != ctx.OriginalSql || len(ctx.Params) != len(ctx.OriginalParams) {
+		return true
+	}
+	for i := range ctx.Params {
+		if !reflect.DeepEqual(ctx.Params[i], ctx.OriginalParams[i]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,505 @@
+package db233
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+/**
+ * FieldEncryptionManager - 字段级加密管理器
+ *
+ * 带 encrypted 选项的字段（db:"ssn,encrypted"）在 INSERT / UPDATE 前透明加密，
+ * 查询结果映射时自动解密。算法为 AES-GCM，密钥由可插拔的 EncryptionKeyProvider 提供
+ *
+ * 标签选项：
+ *   encrypted                随机 nonce，同一明文每次密文不同
+ *   encrypted,deterministic  nonce 由明文 HMAC 派生（HMAC 密钥经 HKDF 从加密密钥独立派生），
+ *                            同一密钥下密文固定，可用于等值查询
+ *
+ * 密文格式：enc:<密钥ID>:<base64(nonce + 密文)>，解密时按密钥 ID 选择密钥，
+ * 因此轮换密钥后旧数据仍可读取，新写入使用当前密钥。不带前缀的值视为明文原样返回。
+ * 写入时一律加密，即使明文本身以 enc: 开头，避免用户数据伪装成密文绕过加密
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type FieldEncryptionManager struct {
+	keyProvider EncryptionKeyProvider
+	columns     map[reflect.Type]map[string]EncryptedColumn
+	mu          sync.RWMutex
+}
+
+/**
+ * EncryptedColumn - 加密列配置
+ */
+type EncryptedColumn struct {
+	Column        string
+	Deterministic bool
+}
+
+/**
+ * EncryptionKeyProvider - 加密密钥提供者接口
+ */
+type EncryptionKeyProvider interface {
+	/**
+	 * 当前用于加密的密钥 ID
+	 */
+	GetCurrentKeyId() string
+
+	/**
+	 * 根据密钥 ID 获取密钥（16 / 24 / 32 字节）
+	 */
+	GetKey(keyId string) ([]byte, error)
+
+	/**
+	 * 所有可用的密钥 ID（用于确定性加密的跨密钥查询）
+	 */
+	GetKeyIds() []string
+}
+
+/**
+ * 密文前缀
+ */
+const encryptedValuePrefix = "enc:"
+
+/**
+ * 确定性 nonce 的 HKDF info（与加密密钥做域分离）
+ */
+const deterministicNonceKeyInfo = "db233 field encryption deterministic nonce"
+
+var (
+	fieldEncryptionManagerInstance *FieldEncryptionManager
+	fieldEncryptionManagerOnce     sync.Once
+)
+
+/**
+ * 获取字段加密管理器单例
+ */
+func GetFieldEncryptionManagerInstance() *FieldEncryptionManager {
+	fieldEncryptionManagerOnce.Do(func() {
+		fieldEncryptionManagerInstance = &FieldEncryptionManager{
+			columns: make(map[reflect.Type]map[string]EncryptedColumn),
+		}
+	})
+	return fieldEncryptionManagerInstance
+}
+
+/**
+ * 设置密钥提供者
+ */
+func (fem *FieldEncryptionManager) SetKeyProvider(provider EncryptionKeyProvider) {
+	fem.mu.Lock()
+	defer fem.mu.Unlock()
+	fem.keyProvider = provider
+}
+
+/**
+ * 获取密钥提供者
+ */
+func (fem *FieldEncryptionManager) GetKeyProvider() EncryptionKeyProvider {
+	fem.mu.RLock()
+	defer fem.mu.RUnlock()
+	return fem.keyProvider
+}
+
+/**
+ * 获取实体的加密列（列名 -> 配置，结果按类型缓存）
+ */
+func (fem *FieldEncryptionManager) GetEncryptedColumns(entityType interface{}) map[string]EncryptedColumn {
+	t := reflect.TypeOf(entityType)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return fem.getEncryptedColumnsByType(t)
+}
+
+/**
+ * 按结构体类型获取加密列
+ */
+func (fem *FieldEncryptionManager) getEncryptedColumnsByType(t reflect.Type) map[string]EncryptedColumn {
+	fem.mu.RLock()
+	columns, exists := fem.columns[t]
+	fem.mu.RUnlock()
+	if exists {
+		return columns
+	}
+
+	columns = make(map[string]EncryptedColumn)
+	if t.Kind() == reflect.Struct {
+		collectEncryptedColumnsRecursive(t, columns)
+	}
+
+	fem.mu.Lock()
+	fem.columns[t] = columns
+	fem.mu.Unlock()
+	return columns
+}
+
+/**
+ * 递归收集带 encrypted 选项的列（支持嵌入结构体）
+ */
+func collectEncryptedColumnsRecursive(t reflect.Type, columns map[string]EncryptedColumn) {
+	cm := GetCrudManagerInstance()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				collectEncryptedColumnsRecursive(embeddedType, columns)
+				continue
+			}
+		}
+
+		if !IsEncryptedField(field) {
+			continue
+		}
+		if colName := cm.GetColumnName(field); colName != "" {
+			columns[colName] = EncryptedColumn{
+				Column:        colName,
+				Deterministic: hasDbTagOption(field, "deterministic"),
+			}
+		}
+	}
+}
+
+/**
+ * 判断字段是否带 encrypted 选项
+ */
+func IsEncryptedField(field reflect.StructField) bool {
+	return hasDbTagOption(field, "encrypted")
+}
+
+/**
+ * 判断 db 标签是否包含指定选项（不含列名部分）
+ */
+func hasDbTagOption(field reflect.StructField, option string) bool {
+	tagParts := strings.Split(field.Tag.Get("db"), ",")
+	for _, part := range tagParts[1:] {
+		if strings.TrimSpace(part) == option {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * 加密明文
+ */
+func (fem *FieldEncryptionManager) Encrypt(plaintext string, deterministic bool) (string, error) {
+	provider := fem.GetKeyProvider()
+	if provider == nil {
+		return "", NewConfigurationException("未配置加密密钥提供者，无法加密字段")
+	}
+	return fem.encryptWithKey(provider, provider.GetCurrentKeyId(), plaintext, deterministic)
+}
+
+/**
+ * 使用指定密钥加密
+ */
+func (fem *FieldEncryptionManager) encryptWithKey(provider EncryptionKeyProvider, keyId string, plaintext string, deterministic bool) (string, error) {
+	if strings.Contains(keyId, ":") {
+		return "", NewConfigurationException("密钥 ID 不能包含冒号: " + keyId)
+	}
+	key, err := provider.GetKey(keyId)
+	if err != nil {
+		return "", NewConfigurationExceptionWithCause(err, "获取加密密钥失败: "+keyId)
+	}
+	gcm, err := newFieldGcm(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if deterministic {
+		// 由明文派生 nonce：同一密钥 + 同一明文得到相同密文
+		mac := hmac.New(sha256.New, hkdfSha256(key, deterministicNonceKeyInfo))
+		mac.Write([]byte(plaintext))
+		copy(nonce, mac.Sum(nil))
+	} else if _, err := rand.Read(nonce); err != nil {
+		return "", NewDb233ExceptionWithCause(err, "生成随机 nonce 失败")
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedValuePrefix + keyId + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+/**
+ * 解密密文（不带密文前缀的值原样返回）
+ */
+func (fem *FieldEncryptionManager) Decrypt(value string) (string, error) {
+	if !IsEncryptedValue(value) {
+		return value, nil
+	}
+	provider := fem.GetKeyProvider()
+	if provider == nil {
+		return "", NewConfigurationException("未配置加密密钥提供者，无法解密字段")
+	}
+
+	keyId, payload, err := splitEncryptedValue(value)
+	if err != nil {
+		return "", err
+	}
+	key, err := provider.GetKey(keyId)
+	if err != nil {
+		return "", NewConfigurationExceptionWithCause(err, "获取解密密钥失败: "+keyId)
+	}
+	gcm, err := newFieldGcm(key)
+	if err != nil {
+		return "", err
+	}
+	if len(payload) < gcm.NonceSize() {
+		return "", NewValidationException("密文长度不足")
+	}
+
+	plaintext, err := gcm.Open(nil, payload[:gcm.NonceSize()], payload[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", NewDb233ExceptionWithCause(err, "解密字段失败（密钥 "+keyId+"）")
+	}
+	return string(plaintext), nil
+}
+
+/**
+ * 生成等值查询参数（确定性加密，每个可用密钥各一个密文）
+ *
+ * 密钥轮换后旧数据仍使用旧密钥的密文，查询时应使用 IN 匹配全部结果
+ */
+func (fem *FieldEncryptionManager) EncryptForLookup(plaintext string) ([]interface{}, error) {
+	provider := fem.GetKeyProvider()
+	if provider == nil {
+		return nil, NewConfigurationException("未配置加密密钥提供者，无法生成查询密文")
+	}
+
+	keyIds := provider.GetKeyIds()
+	if len(keyIds) == 0 {
+		keyIds = []string{provider.GetCurrentKeyId()}
+	}
+	result := make([]interface{}, 0, len(keyIds))
+	for _, keyId := range keyIds {
+		ciphertext, err := fem.encryptWithKey(provider, keyId, plaintext, true)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ciphertext)
+	}
+	return result, nil
+}
+
+/**
+ * 判断密文是否需要轮换（不是由当前密钥加密）
+ */
+func (fem *FieldEncryptionManager) NeedsRotation(value string) bool {
+	provider := fem.GetKeyProvider()
+	if provider == nil || !IsEncryptedValue(value) {
+		return false
+	}
+	keyId, _, err := splitEncryptedValue(value)
+	return err == nil && keyId != provider.GetCurrentKeyId()
+}
+
+/**
+ * 加密实体字段（写入前调用，直接修改 fields）
+ */
+func (fem *FieldEncryptionManager) encryptFields(entity interface{}, fields map[string]interface{}) error {
+	columns := fem.GetEncryptedColumns(entity)
+	for column, config := range columns {
+		value, exists := fields[column]
+		if !exists || value == nil {
+			continue
+		}
+		ciphertext, err := fem.Encrypt(encryptionPlaintext(value), config.Deterministic)
+		if err != nil {
+			return err
+		}
+		fields[column] = ciphertext
+	}
+	return nil
+}
+
+/**
+ * 解密查询结果中的单列值（映射前调用）
+ */
+func (fem *FieldEncryptionManager) decryptColumnValue(value interface{}) (interface{}, error) {
+	var raw string
+	switch v := value.(type) {
+	case []byte:
+		raw = string(v)
+	case string:
+		raw = v
+	default:
+		return value, nil
+	}
+	if !IsEncryptedValue(raw) {
+		return value, nil
+	}
+	plaintext, err := fem.Decrypt(raw)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(plaintext), nil
+}
+
+/**
+ * 判断值是否为密文
+ */
+func IsEncryptedValue(value string) bool {
+	return strings.HasPrefix(value, encryptedValuePrefix)
+}
+
+/**
+ * 拆分密文：密钥 ID + 载荷
+ */
+func splitEncryptedValue(value string) (string, []byte, error) {
+	parts := strings.SplitN(strings.TrimPrefix(value, encryptedValuePrefix), ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", nil, NewValidationException("密文格式错误")
+	}
+	payload, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, NewValidationExceptionWithCause(err, "密文 base64 解码失败")
+	}
+	return parts[0], payload, nil
+}
+
+/**
+ * 将字段值转换为待加密的明文
+ */
+func encryptionPlaintext(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+/**
+ * HKDF-SHA256（RFC 5869，空 salt），派生一个 32 字节子密钥
+ */
+func hkdfSha256(secret []byte, info string) []byte {
+	extract := hmac.New(sha256.New, make([]byte, sha256.Size))
+	extract.Write(secret)
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write([]byte(info))
+	expand.Write([]byte{0x01})
+	return expand.Sum(nil)
+}
+
+/**
+ * 创建 AES-GCM
+ */
+func newFieldGcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, NewConfigurationExceptionWithCause(err, "加密密钥无效（需要 16 / 24 / 32 字节）")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, NewDb233ExceptionWithCause(err, "创建 AES-GCM 失败")
+	}
+	return gcm, nil
+}
+
+/**
+ * StaticKeyProvider - 内存密钥提供者
+ *
+ * 支持多把密钥并切换当前密钥，实现密钥轮换
+ */
+type StaticKeyProvider struct {
+	keys      map[string][]byte
+	keyOrder  []string
+	currentId string
+	mu        sync.RWMutex
+}
+
+/**
+ * 创建内存密钥提供者（初始密钥即当前密钥）
+ */
+func NewStaticKeyProvider(keyId string, key []byte) (*StaticKeyProvider, error) {
+	provider := &StaticKeyProvider{keys: make(map[string][]byte)}
+	if err := provider.AddKey(keyId, key); err != nil {
+		return nil, err
+	}
+	provider.currentId = keyId
+	return provider, nil
+}
+
+/**
+ * 添加密钥（不改变当前密钥）
+ */
+func (p *StaticKeyProvider) AddKey(keyId string, key []byte) error {
+	if keyId == "" || strings.Contains(keyId, ":") {
+		return NewValidationException("密钥 ID 不能为空且不能包含冒号")
+	}
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return NewValidationException(fmt.Sprintf("密钥长度必须为 16 / 24 / 32 字节，实际为 %d", len(key)))
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, exists := p.keys[keyId]; !exists {
+		p.keyOrder = append(p.keyOrder, keyId)
+	}
+	p.keys[keyId] = append([]byte(nil), key...)
+	return nil
+}
+
+/**
+ * 轮换当前密钥（新密钥需已添加）
+ */
+func (p *StaticKeyProvider) Rotate(keyId string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, exists := p.keys[keyId]; !exists {
+		return NewValidationException("密钥不存在: " + keyId)
+	}
+	p.currentId = keyId
+	LogInfo("加密密钥已轮换: %s", keyId)
+	return nil
+}
+
+/**
+ * 当前用于加密的密钥 ID
+ */
+func (p *StaticKeyProvider) GetCurrentKeyId() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.currentId
+}
+
+/**
+ * 根据密钥 ID 获取密钥
+ */
+func (p *StaticKeyProvider) GetKey(keyId string) ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	key, exists := p.keys[keyId]
+	if !exists {
+		return nil, fmt.Errorf("密钥不存在: %s", keyId)
+	}
+	return key, nil
+}
+
+/**
+ * 所有可用的密钥 ID（按添加顺序）
+ */
+func (p *StaticKeyProvider) GetKeyIds() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]string(nil), p.keyOrder...)
+}
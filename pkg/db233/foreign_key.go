@@ -0,0 +1,306 @@
+package db233
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/**
+ * ForeignKeyDefinition - 外键约束定义
+ *
+ * 由字段的 fk 标签声明（单列外键）：
+ *
+ *   UserId int64 `db:"user_id" fk:"users(id),onDelete=CASCADE"`
+ *   TeamId int64 `db:"team_id" fk:"teams(id),onDelete=SET NULL,onUpdate=CASCADE,name=fk_member_team"`
+ *
+ * 未指定 name 时约束名为 fk_<表名>_<列名>；未指定的 ON DELETE / ON UPDATE 为 NO ACTION
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type ForeignKeyDefinition struct {
+	Name      string
+	Column    string
+	RefTable  string
+	RefColumn string
+	OnDelete  string
+	OnUpdate  string
+}
+
+/**
+ * IForeignKeyStrategy - 外键约束生成与查询（建表策略的可选扩展）
+ *
+ * 实现该接口的建表策略在自动建表时生成 FOREIGN KEY 约束，并参与迁移时的外键差异比对
+ */
+type IForeignKeyStrategy interface {
+	/**
+	 * 生成建表语句中的约束子句
+	 */
+	GenerateForeignKeyClause(fk ForeignKeyDefinition) string
+
+	/**
+	 * 生成添加外键的 SQL
+	 */
+	GenerateAddForeignKeySQL(tableName string, fk ForeignKeyDefinition) string
+
+	/**
+	 * 生成删除外键的 SQL
+	 */
+	GenerateDropForeignKeySQL(tableName string, constraintName string) string
+
+	/**
+	 * 查询表上已有的外键（约束名 -> 定义）
+	 */
+	GetForeignKeys(db *Db, tableName string) (map[string]ForeignKeyDefinition, error)
+}
+
+var foreignKeyReferencePattern = regexp.MustCompile(`^\s*([A-Za-z0-9_.$]+)\s*\(\s*([A-Za-z0-9_$]+)\s*\)\s*$`)
+
+/**
+ * 解析 fk 标签
+ *
+ * @param tableName 所在表名（用于默认约束名）
+ * @param column 所在列名
+ * @param tag 标签值，如 users(id),onDelete=CASCADE
+ */
+func ParseForeignKeyTag(tableName string, column string, tag string) (ForeignKeyDefinition, error) {
+	parts := strings.Split(tag, ",")
+	match := foreignKeyReferencePattern.FindStringSubmatch(parts[0])
+	if match == nil {
+		return ForeignKeyDefinition{}, NewValidationException(fmt.Sprintf("外键标签格式错误，应为 表(列): 列=%s, fk=%q", column, tag))
+	}
+
+	fk := ForeignKeyDefinition{
+		Name:      "fk_" + tableName + "_" + column,
+		Column:    column,
+		RefTable:  match[1],
+		RefColumn: match[2],
+		OnDelete:  "NO ACTION",
+		OnUpdate:  "NO ACTION",
+	}
+	for _, part := range parts[1:] {
+		key, value, found := strings.Cut(part, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !found || value == "" {
+			return fk, NewValidationException(fmt.Sprintf("外键选项格式错误: 列=%s, 选项=%q", column, part))
+		}
+		switch strings.ToLower(key) {
+		case "name":
+			fk.Name = value
+		case "ondelete", "onupdate":
+			action, err := normalizeForeignKeyAction(value)
+			if err != nil {
+				return fk, err
+			}
+			if strings.ToLower(key) == "ondelete" {
+				fk.OnDelete = action
+			} else {
+				fk.OnUpdate = action
+			}
+		default:
+			return fk, NewValidationException(fmt.Sprintf("未知的外键选项: 列=%s, 选项=%s", column, key))
+		}
+	}
+	return fk, nil
+}
+
+/**
+ * 规范化引用动作（CASCADE、SET NULL、SET DEFAULT、RESTRICT、NO ACTION，允许下划线与小写）
+ */
+func normalizeForeignKeyAction(action string) (string, error) {
+	normalized := strings.ToUpper(strings.Join(strings.Fields(strings.ReplaceAll(action, "_", " ")), " "))
+	switch normalized {
+	case "CASCADE", "SET NULL", "SET DEFAULT", "RESTRICT", "NO ACTION":
+		return normalized, nil
+	case "":
+		return "NO ACTION", nil
+	}
+	return "", NewValidationException("不支持的外键引用动作: " + action)
+}
+
+/**
+ * 收集实体声明的外键（支持嵌入结构体，按约束名排序），标签格式错误时记录警告并跳过
+ */
+func (cm *CrudManager) GetForeignKeys(entityType reflect.Type, tableName string) []ForeignKeyDefinition {
+	if entityType.Kind() == reflect.Ptr {
+		entityType = entityType.Elem()
+	}
+	var foreignKeys []ForeignKeyDefinition
+	cm.collectForeignKeys(entityType, tableName, &foreignKeys)
+	sort.Slice(foreignKeys, func(i, j int) bool { return foreignKeys[i].Name < foreignKeys[j].Name })
+	return foreignKeys
+}
+
+func (cm *CrudManager) collectForeignKeys(entityType reflect.Type, tableName string, foreignKeys *[]ForeignKeyDefinition) {
+	if entityType.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < entityType.NumField(); i++ {
+		field := entityType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				cm.collectForeignKeys(embeddedType, tableName, foreignKeys)
+				continue
+			}
+		}
+
+		tag := field.Tag.Get("fk")
+		colName := cm.GetColumnName(field)
+		if tag == "" || colName == "" {
+			continue
+		}
+		fk, err := ParseForeignKeyTag(tableName, colName, tag)
+		if err != nil {
+			LogWarn("忽略外键声明: 表=%s, 字段=%s, 错误=%v", tableName, field.Name, err)
+			continue
+		}
+		*foreignKeys = append(*foreignKeys, fk)
+	}
+}
+
+/**
+ * 比较外键定义（RESTRICT 与 NO ACTION 视为相同，表名与列名不区分大小写）
+ */
+func (fk ForeignKeyDefinition) Equals(other ForeignKeyDefinition) bool {
+	return strings.EqualFold(fk.Column, other.Column) &&
+		strings.EqualFold(fk.RefTable, other.RefTable) &&
+		strings.EqualFold(fk.RefColumn, other.RefColumn) &&
+		equivalentForeignKeyAction(fk.OnDelete) == equivalentForeignKeyAction(other.OnDelete) &&
+		equivalentForeignKeyAction(fk.OnUpdate) == equivalentForeignKeyAction(other.OnUpdate)
+}
+
+func equivalentForeignKeyAction(action string) string {
+	normalized, err := normalizeForeignKeyAction(action)
+	if err != nil || normalized == "RESTRICT" {
+		return "NO ACTION"
+	}
+	return normalized
+}
+
+/**
+ * 比对期望与现有外键
+ *
+ * @param existing 数据库中已有的外键（约束名 -> 定义）
+ * @param desired 实体声明的外键
+ * @return missing 缺失的外键（需添加）
+ * @return changed 定义不同的外键（需删除后重建）
+ * @return undeclared 实体未声明的外键（按约束名排序）
+ */
+func DiffForeignKeys(existing map[string]ForeignKeyDefinition, desired []ForeignKeyDefinition) (missing []ForeignKeyDefinition, changed []ForeignKeyDefinition, undeclared []string) {
+	declared := make(map[string]bool, len(desired))
+	for _, fk := range desired {
+		declared[strings.ToLower(fk.Name)] = true
+		current, exists := lookupForeignKey(existing, fk.Name)
+		switch {
+		case !exists:
+			missing = append(missing, fk)
+		case !fk.Equals(current):
+			changed = append(changed, fk)
+		}
+	}
+	for name := range existing {
+		if !declared[strings.ToLower(name)] {
+			undeclared = append(undeclared, name)
+		}
+	}
+	sort.Strings(undeclared)
+	return missing, changed, undeclared
+}
+
+func lookupForeignKey(existing map[string]ForeignKeyDefinition, name string) (ForeignKeyDefinition, bool) {
+	if fk, exists := existing[name]; exists {
+		return fk, true
+	}
+	for existingName, fk := range existing {
+		if strings.EqualFold(existingName, name) {
+			return fk, true
+		}
+	}
+	return ForeignKeyDefinition{}, false
+}
+
+/**
+ * 迁移外键约束：添加缺失的外键（需 CreateColumn 权限）；删除或重建变更 / 未声明的外键（需 DropForeignKey 权限）
+ *
+ * 建表策略未实现 IForeignKeyStrategy 时跳过
+ *
+ * @return int 执行的变更数
+ */
+func migrateForeignKeys(db *Db, strategy ITableCreationStrategy, tableName string, entityType reflect.Type, permissions *AutoDbPermission) (int, error) {
+	fkStrategy, ok := strategy.(IForeignKeyStrategy)
+	if !ok {
+		return 0, nil
+	}
+	desired := GetCrudManagerInstance().GetForeignKeys(entityType, tableName)
+	existing, err := fkStrategy.GetForeignKeys(db, tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	missing, changed, undeclared := DiffForeignKeys(existing, desired)
+	allowDrop := permissions.IsAllowed(EnumAutoDbOperateTypeDropForeignKey)
+	applied := 0
+	exec := func(sql string) {
+		if _, err := db.DataSource.Exec(sql); err != nil {
+			LogError("外键迁移失败: 表=%s, SQL=%s, 错误=%v", tableName, sql, err)
+			return
+		}
+		LogInfo("外键迁移成功: 表=%s, SQL=%s", tableName, sql)
+		applied++
+	}
+
+	for _, name := range undeclared {
+		if !allowDrop {
+			LogWarn("外键未在实体中声明，删除操作被禁用: 表=%s, 约束=%s", tableName, name)
+			continue
+		}
+		exec(fkStrategy.GenerateDropForeignKeySQL(tableName, name))
+	}
+	for _, fk := range changed {
+		if !allowDrop {
+			LogWarn("外键定义已变更，重建操作被禁用: 表=%s, 约束=%s", tableName, fk.Name)
+			continue
+		}
+		exec(fkStrategy.GenerateDropForeignKeySQL(tableName, fk.Name))
+		exec(fkStrategy.GenerateAddForeignKeySQL(tableName, fk))
+	}
+	if permissions.IsAllowed(EnumAutoDbOperateTypeCreateColumn) {
+		for _, fk := range missing {
+			exec(fkStrategy.GenerateAddForeignKeySQL(tableName, fk))
+		}
+	}
+	return applied, nil
+}
+
+/**
+ * 执行外键查询并扫描（列顺序：约束名、列、引用表、引用列、ON DELETE、ON UPDATE）
+ */
+func scanForeignKeys(db *Db, query string, tableName string) (map[string]ForeignKeyDefinition, error) {
+	rows, err := db.DataSource.Query(query, tableName)
+	if err != nil {
+		return nil, NewQueryExceptionWithCause(err, "查询外键失败: "+tableName)
+	}
+	defer rows.Close()
+
+	foreignKeys := make(map[string]ForeignKeyDefinition)
+	for rows.Next() {
+		var fk ForeignKeyDefinition
+		if err := rows.Scan(&fk.Name, &fk.Column, &fk.RefTable, &fk.RefColumn, &fk.OnDelete, &fk.OnUpdate); err != nil {
+			return nil, NewQueryExceptionWithCause(err, "扫描外键失败: "+tableName)
+		}
+		fk.OnDelete = equivalentForeignKeyAction(fk.OnDelete)
+		fk.OnUpdate = equivalentForeignKeyAction(fk.OnUpdate)
+		foreignKeys[fk.Name] = fk
+	}
+	return foreignKeys, rows.Err()
+}
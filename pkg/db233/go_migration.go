@@ -0,0 +1,130 @@
+package db233
+
+import (
+	"fmt"
+)
+
+/**
+ * Go 代码迁移与迁移钩子
+ *
+ * 需要应用逻辑的数据回填（如 JSON 结构转换）可注册为 Go 函数迁移，与 SQL 文件按版本号交错执行，
+ * 同样记入 schema_migrations 并在同一事务中执行：
+ *
+ *   mm.RegisterGoMigration(1760601600, "split_player_profile",
+ *       func(tm *db233.TransactionManager) error { ... 读取旧 JSON，写入新列 ... },
+ *       func(tm *db233.TransactionManager) error { ... 还原 ... })
+ *
+ * 前置钩子在每个迁移执行前调用，返回错误时迁移不执行；后置钩子在事务结束后调用（成功或失败）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+
+/**
+ * GoMigrationFunc - Go 代码迁移函数（在迁移事务中执行，返回错误时回滚）
+ */
+type GoMigrationFunc func(tm *TransactionManager) error
+
+/**
+ * BeforeMigrationHook - 迁移前置钩子
+ *
+ * @param isUp true 为上迁，false 为回滚
+ */
+type BeforeMigrationHook func(migration Migration, isUp bool) error
+
+/**
+ * AfterMigrationHook - 迁移后置钩子
+ *
+ * @param err 迁移结果，成功时为 nil
+ */
+type AfterMigrationHook func(migration Migration, isUp bool, err error)
+
+/**
+ * 注册 Go 代码迁移
+ *
+ * @param version 版本号（与 SQL 文件共用同一版本序列，不可重复）
+ * @param upFn 上迁函数（必填）
+ * @param downFn 回滚函数（可为 nil，此时该迁移不可回滚）
+ */
+func (mm *MigrationManager) RegisterGoMigration(version int64, name string, upFn GoMigrationFunc, downFn GoMigrationFunc) error {
+	if upFn == nil {
+		return NewValidationException(fmt.Sprintf("Go 迁移 %d_%s 缺少上迁函数", version, name))
+	}
+	if _, exists := mm.goMigrations[version]; exists {
+		return NewValidationException(fmt.Sprintf("Go 迁移版本重复: %d", version))
+	}
+	mm.goMigrations[version] = Migration{Version: version, Name: name, UpFunc: upFn, DownFunc: downFn}
+	LogDebug("注册 Go 迁移: %d_%s", version, name)
+	return nil
+}
+
+/**
+ * 添加迁移前置钩子
+ */
+func (mm *MigrationManager) AddBeforeMigrationHook(hook BeforeMigrationHook) *MigrationManager {
+	if hook != nil {
+		mm.beforeHooks = append(mm.beforeHooks, hook)
+	}
+	return mm
+}
+
+/**
+ * 添加迁移后置钩子
+ */
+func (mm *MigrationManager) AddAfterMigrationHook(hook AfterMigrationHook) *MigrationManager {
+	if hook != nil {
+		mm.afterHooks = append(mm.afterHooks, hook)
+	}
+	return mm
+}
+
+func (mm *MigrationManager) runBeforeHooks(migration Migration, isUp bool) error {
+	for _, hook := range mm.beforeHooks {
+		if err := hook(migration, isUp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mm *MigrationManager) runAfterHooks(migration Migration, isUp bool, err error) {
+	for _, hook := range mm.afterHooks {
+		hook(migration, isUp, err)
+	}
+}
+
+/**
+ * 合并 Go 迁移到 SQL 文件迁移列表（版本号冲突时报错）
+ */
+func (mm *MigrationManager) mergeGoMigrations(migrations []Migration) ([]Migration, error) {
+	for _, migration := range migrations {
+		if goMigration, exists := mm.goMigrations[migration.Version]; exists {
+			return nil, NewConfigurationException(fmt.Sprintf("迁移版本冲突: SQL 文件 %d_%s 与 Go 迁移 %s", migration.Version, migration.Name, goMigration.Name))
+		}
+	}
+	for _, goMigration := range mm.goMigrations {
+		migrations = append(migrations, goMigration)
+	}
+	return migrations, nil
+}
+
+/**
+ * 按版本号为迁移记录补全迁移内容（找不到对应文件 / Go 迁移的保持原样）
+ */
+func (mm *MigrationManager) fillMigrationContent(applied []Migration) ([]Migration, error) {
+	all, err := mm.getAllMigrations()
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]Migration, len(all))
+	for _, migration := range all {
+		byVersion[migration.Version] = migration
+	}
+	for i, record := range applied {
+		if migration, exists := byVersion[record.Version]; exists {
+			migration.AppliedAt = record.AppliedAt
+			applied[i] = migration
+		}
+	}
+	return applied, nil
+}
@@ -0,0 +1,280 @@
+package db233
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+/**
+ * GoroutineSupervisor - 后台协程监管
+ *
+ * 监控、调度等后台协程 panic 后不再静默退出：恢复 panic、记录堆栈与崩溃次数，
+ * 按指数退避重新启动，直到协程正常返回或 stop 通道关闭：
+ *
+ *   done := db233.GetGoroutineSupervisorInstance().Go("metrics_collector:orders", stopChan, func() {
+ *       for { select { case <-ticker.C: collect() case <-stopChan: return } }
+ *   })
+ *
+ * 监管器实现 MetricsDataSource，加入 MetricsCollector 后按组件导出
+ * <component>.crashes / <component>.restarts / <component>.running
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type GoroutineSupervisor struct {
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	components map[string]*supervisedComponent
+	crashHooks []func(component string, recovered interface{}, stack []byte)
+
+	mu sync.RWMutex
+}
+
+/**
+ * 单个组件的监管统计
+ */
+type supervisedComponent struct {
+	crashes     int64
+	restarts    int64
+	running     int64
+	lastPanic   string
+	lastCrashAt time.Time
+}
+
+/**
+ * SupervisedGoroutineStats - 组件的监管统计
+ */
+type SupervisedGoroutineStats struct {
+	Component   string
+	Crashes     int64
+	Restarts    int64
+	Running     int64
+	LastPanic   string
+	LastCrashAt time.Time
+}
+
+var (
+	goroutineSupervisorInstance *GoroutineSupervisor
+	goroutineSupervisorOnce     sync.Once
+)
+
+/**
+ * 获取全局监管器
+ */
+func GetGoroutineSupervisorInstance() *GoroutineSupervisor {
+	goroutineSupervisorOnce.Do(func() {
+		goroutineSupervisorInstance = NewGoroutineSupervisor()
+	})
+	return goroutineSupervisorInstance
+}
+
+/**
+ * 创建监管器（默认退避 100ms 起，每次翻倍，最长 30s）
+ */
+func NewGoroutineSupervisor() *GoroutineSupervisor {
+	return &GoroutineSupervisor{
+		initialBackoff: 100 * time.Millisecond,
+		maxBackoff:     30 * time.Second,
+		components:     make(map[string]*supervisedComponent),
+	}
+}
+
+/**
+ * 设置重启退避（协程连续运行超过 max 后退避重置为 initial）
+ */
+func (s *GoroutineSupervisor) SetBackoff(initial, max time.Duration) *GoroutineSupervisor {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if initial > 0 {
+		s.initialBackoff = initial
+	}
+	if max >= s.initialBackoff {
+		s.maxBackoff = max
+	}
+	return s
+}
+
+/**
+ * 注册崩溃回调（在重启前调用，可用于告警）
+ */
+func (s *GoroutineSupervisor) OnCrash(hook func(component string, recovered interface{}, stack []byte)) *GoroutineSupervisor {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.crashHooks = append(s.crashHooks, hook)
+	return s
+}
+
+/**
+ * 在监管下启动协程
+ *
+ * run 正常返回视为停止；panic 后按退避重启，退避期间 stop 关闭则不再重启。
+ * 返回的通道在监管结束时关闭
+ *
+ * @param component 组件名称（用于日志与指标）
+ * @param stop 停止信号，可为 nil
+ * @param run 协程主体
+ */
+func (s *GoroutineSupervisor) Go(component string, stop <-chan bool, run func()) <-chan struct{} {
+	done := make(chan struct{})
+	stats := s.component(component)
+	go func() {
+		defer close(done)
+		s.mu.RLock()
+		backoff := s.initialBackoff
+		s.mu.RUnlock()
+
+		for {
+			start := time.Now()
+			if !s.runOnce(component, stats, run) {
+				return
+			}
+
+			s.mu.RLock()
+			initialBackoff, maxBackoff := s.initialBackoff, s.maxBackoff
+			s.mu.RUnlock()
+			if time.Since(start) >= maxBackoff {
+				backoff = initialBackoff
+			}
+
+			LogWarn("后台协程将在 %v 后重启: %s", backoff, component)
+			timer := time.NewTimer(backoff)
+			select {
+			case <-timer.C:
+			case <-stop:
+				timer.Stop()
+				LogInfo("后台协程已停止，不再重启: %s", component)
+				return
+			}
+
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+			s.mu.Lock()
+			stats.restarts++
+			s.mu.Unlock()
+		}
+	}()
+	return done
+}
+
+/**
+ * 运行一次协程主体，发生 panic 时返回 true
+ */
+func (s *GoroutineSupervisor) runOnce(component string, stats *supervisedComponent, run func()) (crashed bool) {
+	s.mu.Lock()
+	stats.running++
+	s.mu.Unlock()
+
+	defer func() {
+		recovered := recover()
+		s.mu.Lock()
+		stats.running--
+		var hooks []func(string, interface{}, []byte)
+		if recovered != nil {
+			stats.crashes++
+			stats.lastPanic = fmt.Sprint(recovered)
+			stats.lastCrashAt = time.Now()
+			hooks = append(hooks, s.crashHooks...)
+		}
+		s.mu.Unlock()
+		if recovered == nil {
+			return
+		}
+
+		stack := debug.Stack()
+		LogError("后台协程 panic: %s, 错误=%v\n%s", component, recovered, stack)
+		for _, hook := range hooks {
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						LogError("崩溃回调 panic: %s, 错误=%v", component, r)
+					}
+				}()
+				hook(component, recovered, stack)
+			}()
+		}
+		crashed = true
+	}()
+
+	run()
+	return false
+}
+
+func (s *GoroutineSupervisor) component(name string) *supervisedComponent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats, ok := s.components[name]
+	if !ok {
+		stats = &supervisedComponent{}
+		s.components[name] = stats
+	}
+	return stats
+}
+
+/**
+ * 获取组件的崩溃次数
+ */
+func (s *GoroutineSupervisor) GetCrashCount(component string) int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if stats, ok := s.components[component]; ok {
+		return stats.crashes
+	}
+	return 0
+}
+
+/**
+ * 获取所有组件的监管统计（按组件名排序）
+ */
+func (s *GoroutineSupervisor) GetStats() []SupervisedGoroutineStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]SupervisedGoroutineStats, 0, len(s.components))
+	for name, stats := range s.components {
+		result = append(result, SupervisedGoroutineStats{
+			Component:   name,
+			Crashes:     stats.crashes,
+			Restarts:    stats.restarts,
+			Running:     stats.running,
+			LastPanic:   stats.lastPanic,
+			LastCrashAt: stats.lastCrashAt,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Component < result[j].Component })
+	return result
+}
+
+/**
+ * 数据源名称
+ */
+func (s *GoroutineSupervisor) GetName() string {
+	return "goroutine_supervisor"
+}
+
+/**
+ * 按组件导出崩溃次数、重启次数与运行中的协程数
+ */
+func (s *GoroutineSupervisor) GetMetrics() map[string]interface{} {
+	metrics := make(map[string]interface{})
+	var totalCrashes int64
+	for _, stats := range s.GetStats() {
+		metrics[stats.Component+".crashes"] = stats.Crashes
+		metrics[stats.Component+".restarts"] = stats.Restarts
+		metrics[stats.Component+".running"] = stats.Running
+		totalCrashes += stats.Crashes
+	}
+	metrics["total_crashes"] = totalCrashes
+	return metrics
+}
+
+/**
+ * 在全局监管器下启动后台协程
+ */
+func goSupervised(component string, stop <-chan bool, run func()) <-chan struct{} {
+	return GetGoroutineSupervisorInstance().Go(component, stop, run)
+}
@@ -2,6 +2,8 @@ package db233
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"time"
 )
 
@@ -17,6 +19,9 @@ type HealthChecker struct {
 	db         *Db
 	timeout    time.Duration
 	checkQuery string
+
+	// 复制延迟探测（见 replication_lag.go），nil 时按数据库类型选择
+	replicationLagProbe ReplicationLagProbe
 }
 
 /**
@@ -28,6 +33,41 @@ type HealthCheckResult struct {
 	Timestamp    time.Time
 	ResponseTime time.Duration
 	Error        error
+
+	// 维护模式：既不算健康，也不应触发告警
+	Maintenance bool
+
+	// 复制延迟（仅 CheckReplicationLag 填充）
+	ReplicationLag time.Duration
+}
+
+/**
+ * 健康状态字符串
+ */
+const (
+	HealthStatusHealthy     = "healthy"
+	HealthStatusUnhealthy   = "unhealthy"
+	HealthStatusMaintenance = "maintenance"
+)
+
+/**
+ * 获取健康状态字符串（healthy / unhealthy / maintenance）
+ */
+func (r *HealthCheckResult) GetStatus() string {
+	if r.Maintenance {
+		return HealthStatusMaintenance
+	}
+	if r.Healthy {
+		return HealthStatusHealthy
+	}
+	return HealthStatusUnhealthy
+}
+
+/**
+ * 是否需要告警（维护中的不健康不告警）
+ */
+func (r *HealthCheckResult) IsAlertWorthy() bool {
+	return !r.Healthy && !r.Maintenance
 }
 
 /**
@@ -61,6 +101,10 @@ func (hc *HealthChecker) SetCheckQuery(query string) {
 func (hc *HealthChecker) Check() *HealthCheckResult {
 	start := time.Now()
 
+	if result := hc.maintenanceResult(start); result != nil {
+		return result
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), hc.timeout)
 	defer cancel()
 
@@ -69,7 +113,11 @@ func (hc *HealthChecker) Check() *HealthCheckResult {
 	}
 
 	// 执行健康检查查询
-	_, err := hc.db.DataSource.QueryContext(ctx, hc.checkQuery)
+	rows, err := hc.db.DataSource.QueryContext(ctx, hc.checkQuery)
+	if err == nil {
+		// 及时归还连接，避免周期性探测耗尽连接池
+		err = rows.Close()
+	}
 	result.ResponseTime = time.Since(start)
 
 	if err != nil {
@@ -86,6 +134,21 @@ func (hc *HealthChecker) Check() *HealthCheckResult {
 	return result
 }
 
+/**
+ * 维护模式下返回维护结果，否则返回 nil
+ */
+func (hc *HealthChecker) maintenanceResult(timestamp time.Time) *HealthCheckResult {
+	inMaintenance, reason, since := hc.db.GetMaintenanceInfo()
+	if !inMaintenance {
+		return nil
+	}
+	return &HealthCheckResult{
+		Maintenance: true,
+		Timestamp:   timestamp,
+		Message:     fmt.Sprintf("数据库维护中（自 %s）: %s", since.Format("2006-01-02 15:04:05"), reason),
+	}
+}
+
 /**
  * 执行异步健康检查
  */
@@ -135,6 +198,10 @@ func CheckMultipleHealth(checkers map[string]*HealthChecker) map[string]*HealthC
  * 数据库连接池健康检查
  */
 func (hc *HealthChecker) CheckConnectionPool() *HealthCheckResult {
+	if result := hc.maintenanceResult(time.Now()); result != nil {
+		return result
+	}
+
 	result := &HealthCheckResult{
 		Timestamp: time.Now(),
 	}
@@ -185,18 +252,22 @@ func (hc *HealthChecker) ComprehensiveCheck() map[string]*HealthCheckResult {
 
 	// 计算整体健康状态
 	overallHealthy := true
+	maintenance := false
 	for _, result := range results {
+		if result.Maintenance {
+			maintenance = true
+		}
 		if !result.Healthy {
 			overallHealthy = false
-			break
 		}
 	}
 
 	// 添加整体状态
 	results["overall"] = &HealthCheckResult{
-		Healthy:   overallHealthy,
-		Timestamp: time.Now(),
-		Message:   "综合健康检查完成",
+		Healthy:     overallHealthy,
+		Maintenance: maintenance,
+		Timestamp:   time.Now(),
+		Message:     "综合健康检查完成",
 	}
 
 	return results
@@ -209,6 +280,7 @@ type HealthCheckScheduler struct {
 	checkers   map[string]*HealthChecker
 	interval   time.Duration
 	stopChan   chan bool
+	stopOnce   sync.Once
 	lastResult map[string]*HealthCheckResult
 }
 
@@ -236,7 +308,7 @@ func (hcs *HealthCheckScheduler) AddChecker(name string, checker *HealthChecker)
 func (hcs *HealthCheckScheduler) Start() {
 	LogInfo("健康检查调度器启动，检查间隔: %v", hcs.interval)
 
-	go func() {
+	goSupervised("health_check_scheduler", hcs.stopChan, func() {
 		ticker := time.NewTicker(hcs.interval)
 		defer ticker.Stop()
 
@@ -247,7 +319,9 @@ func (hcs *HealthCheckScheduler) Start() {
 
 				// 记录不健康的状态
 				for name, result := range hcs.lastResult {
-					if !result.Healthy {
+					if result.Maintenance {
+						LogInfo("定期健康检查跳过（维护中） [%s]: %s", name, result.Message)
+					} else if !result.Healthy {
 						LogError("定期健康检查失败 [%s]: %s", name, result.Message)
 					} else {
 						LogDebug("定期健康检查通过 [%s]: %s", name, result.Message)
@@ -259,14 +333,16 @@ func (hcs *HealthCheckScheduler) Start() {
 				return
 			}
 		}
-	}()
+	})
 }
 
 /**
  * 停止定期检查
  */
 func (hcs *HealthCheckScheduler) Stop() {
-	hcs.stopChan <- true
+	hcs.stopOnce.Do(func() {
+		close(hcs.stopChan)
+	})
 }
 
 /**
@@ -285,6 +361,13 @@ func (hc *HealthChecker) GetMetrics() map[string]interface{} {
 		metrics["health_status"] = 0.0
 	}
 
+	// 维护模式
+	if result.Maintenance {
+		metrics["maintenance_mode"] = 1.0
+	} else {
+		metrics["maintenance_mode"] = 0.0
+	}
+
 	// 响应时间（毫秒）
 	metrics["health_check_response_time_ms"] = float64(result.ResponseTime.Nanoseconds()) / 1000000.0
 
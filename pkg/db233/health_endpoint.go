@@ -0,0 +1,337 @@
+package db233
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+/**
+ * HealthEndpoint - Kubernetes 风格的健康检查 HTTP 端点
+ *
+ * 区分存活与就绪：
+ *   GET /livez   存活：进程能响应即为 200（不访问数据库，避免数据库故障导致 Pod 被反复重启）
+ *   GET /readyz  就绪：所有数据库可达、连接池未饱和、复制延迟低于阈值时为 200，否则 503
+ *
+ * 响应体为 JSON 详情，可挂载到已有 HTTP 服务：
+ *
+ *   endpoint := db233.NewHealthEndpoint(db233.HealthEndpointOptions{MaxReplicationLag: 5 * time.Second})
+ *   endpoint.AddChecker("main", db233.NewHealthChecker(mainDb))
+ *   endpoint.SetReplicationLagProbe("main", db233.MySQLReplicationLagProbe(replicaDb))
+ *   http.Handle("/", endpoint.Handler())
+ *
+ * 维护模式中的数据库视为未就绪（从负载均衡摘除），但不影响存活
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type HealthEndpoint struct {
+	options HealthEndpointOptions
+
+	checkers  map[string]*HealthChecker
+	lagProbes map[string]ReplicationLagProbe
+	startTime time.Time
+	mu        sync.RWMutex
+}
+
+/**
+ * HealthEndpointOptions - 健康检查端点选项
+ */
+type HealthEndpointOptions struct {
+	// 连接池使用率（使用中 / 最大连接数）达到该值视为饱和，默认 1.0
+	MaxPoolUtilization float64
+
+	// 复制延迟阈值，0 表示不检查复制延迟
+	MaxReplicationLag time.Duration
+}
+
+/**
+ * HealthProbeReport - 健康探测报告
+ */
+type HealthProbeReport struct {
+	Probe     string                       `json:"probe"`
+	Status    string                       `json:"status"`
+	Timestamp time.Time                    `json:"timestamp"`
+	Checks    map[string]*HealthProbeCheck `json:"checks,omitempty"`
+	Details   map[string]interface{}       `json:"details,omitempty"`
+}
+
+/**
+ * HealthProbeCheck - 单项检查结果
+ */
+type HealthProbeCheck struct {
+	Status         string                 `json:"status"`
+	Message        string                 `json:"message"`
+	ResponseTimeMs float64                `json:"responseTimeMs"`
+	Details        map[string]interface{} `json:"details,omitempty"`
+}
+
+/**
+ * 探测状态
+ */
+const (
+	HealthProbeUp   = "UP"
+	HealthProbeDown = "DOWN"
+)
+
+/**
+ * 创建健康检查端点
+ */
+func NewHealthEndpoint(options HealthEndpointOptions) *HealthEndpoint {
+	if options.MaxPoolUtilization <= 0 {
+		options.MaxPoolUtilization = 1.0
+	}
+	return &HealthEndpoint{
+		options:   options,
+		checkers:  make(map[string]*HealthChecker),
+		lagProbes: make(map[string]ReplicationLagProbe),
+		startTime: time.Now(),
+	}
+}
+
+/**
+ * 添加数据库健康检查器（参与就绪检查）
+ */
+func (he *HealthEndpoint) AddChecker(name string, checker *HealthChecker) {
+	he.mu.Lock()
+	defer he.mu.Unlock()
+	he.checkers[name] = checker
+}
+
+/**
+ * 移除数据库健康检查器
+ */
+func (he *HealthEndpoint) RemoveChecker(name string) {
+	he.mu.Lock()
+	defer he.mu.Unlock()
+	delete(he.checkers, name)
+	delete(he.lagProbes, name)
+}
+
+/**
+ * 设置数据库的复制延迟探测（需同时设置 MaxReplicationLag；未设置时使用检查器自身的探测）
+ */
+func (he *HealthEndpoint) SetReplicationLagProbe(name string, probe ReplicationLagProbe) {
+	he.mu.Lock()
+	defer he.mu.Unlock()
+	he.lagProbes[name] = probe
+}
+
+/**
+ * 存活检查（不访问数据库）
+ */
+func (he *HealthEndpoint) Liveness() *HealthProbeReport {
+	return &HealthProbeReport{
+		Probe:     "liveness",
+		Status:    HealthProbeUp,
+		Timestamp: time.Now(),
+		Details: map[string]interface{}{
+			"uptimeSeconds": int64(time.Since(he.startTime).Seconds()),
+			"goroutines":    runtime.NumGoroutine(),
+		},
+	}
+}
+
+/**
+ * 就绪检查（并发检查所有数据库的连接、连接池、复制延迟）
+ */
+func (he *HealthEndpoint) Readiness() *HealthProbeReport {
+	he.mu.RLock()
+	checkers := make(map[string]*HealthChecker, len(he.checkers))
+	for name, checker := range he.checkers {
+		checkers[name] = checker
+	}
+	lagProbes := make(map[string]ReplicationLagProbe, len(he.lagProbes))
+	for name, probe := range he.lagProbes {
+		lagProbes[name] = probe
+	}
+	he.mu.RUnlock()
+
+	report := &HealthProbeReport{
+		Probe:     "readiness",
+		Status:    HealthProbeUp,
+		Timestamp: time.Now(),
+		Checks:    make(map[string]*HealthProbeCheck),
+	}
+
+	var wg sync.WaitGroup
+	var reportMu sync.Mutex
+	for name, checker := range checkers {
+		wg.Add(1)
+		go func(name string, checker *HealthChecker) {
+			defer wg.Done()
+			checks := he.checkDatabase(checker, lagProbes[name])
+			reportMu.Lock()
+			defer reportMu.Unlock()
+			for checkName, check := range checks {
+				report.Checks[name+"."+checkName] = check
+			}
+		}(name, checker)
+	}
+	wg.Wait()
+
+	for _, check := range report.Checks {
+		if check.Status != HealthStatusHealthy {
+			report.Status = HealthProbeDown
+		}
+	}
+	return report
+}
+
+/**
+ * 检查单个数据库
+ */
+func (he *HealthEndpoint) checkDatabase(checker *HealthChecker, lagProbe ReplicationLagProbe) map[string]*HealthProbeCheck {
+	checks := make(map[string]*HealthProbeCheck)
+
+	connection := checker.Check()
+	checks["connection"] = healthProbeCheckOf(connection)
+	if connection.Maintenance || !connection.Healthy {
+		return checks
+	}
+
+	checks["pool"] = he.checkPool(checker.db)
+
+	if lagProbe == nil {
+		lagProbe = checker.replicationLagProbe
+	}
+	if lagProbe != nil && he.options.MaxReplicationLag > 0 {
+		checks["replication"] = he.checkReplication(checker, lagProbe)
+	}
+	return checks
+}
+
+/**
+ * 连接池饱和检查
+ */
+func (he *HealthEndpoint) checkPool(db *Db) *HealthProbeCheck {
+	stats := db.DataSource.Stats()
+	check := &HealthProbeCheck{
+		Status:  HealthStatusHealthy,
+		Message: "连接池状态正常",
+		Details: map[string]interface{}{
+			"maxOpenConnections": stats.MaxOpenConnections,
+			"openConnections":    stats.OpenConnections,
+			"inUse":              stats.InUse,
+			"idle":               stats.Idle,
+			"waitCount":          stats.WaitCount,
+		},
+	}
+	if stats.MaxOpenConnections <= 0 {
+		return check
+	}
+
+	utilization := float64(stats.InUse) / float64(stats.MaxOpenConnections)
+	check.Details["utilization"] = utilization
+	if utilization >= he.options.MaxPoolUtilization {
+		check.Status = HealthStatusUnhealthy
+		check.Message = fmt.Sprintf("连接池饱和: 使用中 %d / 最大 %d", stats.InUse, stats.MaxOpenConnections)
+	}
+	return check
+}
+
+/**
+ * 复制延迟检查
+ */
+func (he *HealthEndpoint) checkReplication(checker *HealthChecker, lagProbe ReplicationLagProbe) *HealthProbeCheck {
+	ctx, cancel := context.WithTimeout(context.Background(), checker.timeout)
+	defer cancel()
+
+	start := time.Now()
+	lag, err := lagProbe(ctx)
+	check := &HealthProbeCheck{
+		Status:         HealthStatusHealthy,
+		ResponseTimeMs: float64(time.Since(start).Microseconds()) / 1000,
+		Details: map[string]interface{}{
+			"thresholdSeconds": he.options.MaxReplicationLag.Seconds(),
+		},
+	}
+	switch {
+	case err != nil:
+		check.Status = HealthStatusUnhealthy
+		check.Message = "复制延迟探测失败: " + err.Error()
+	case lag > he.options.MaxReplicationLag:
+		check.Status = HealthStatusUnhealthy
+		check.Message = fmt.Sprintf("复制延迟 %v 超过阈值 %v", lag, he.options.MaxReplicationLag)
+		check.Details["lagSeconds"] = lag.Seconds()
+	default:
+		check.Message = "复制延迟正常"
+		check.Details["lagSeconds"] = lag.Seconds()
+	}
+	return check
+}
+
+/**
+ * 转换健康检查结果
+ */
+func healthProbeCheckOf(result *HealthCheckResult) *HealthProbeCheck {
+	return &HealthProbeCheck{
+		Status:         result.GetStatus(),
+		Message:        result.Message,
+		ResponseTimeMs: float64(result.ResponseTime.Microseconds()) / 1000,
+	}
+}
+
+/**
+ * 存活检查 Handler
+ */
+func (he *HealthEndpoint) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealthProbeReport(w, he.Liveness())
+	})
+}
+
+/**
+ * 就绪检查 Handler
+ */
+func (he *HealthEndpoint) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealthProbeReport(w, he.Readiness())
+	})
+}
+
+/**
+ * 构造路由：/livez、/readyz（以及兼容的 /healthz，等同 /readyz）
+ */
+func (he *HealthEndpoint) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/livez", he.LivenessHandler())
+	mux.Handle("/readyz", he.ReadinessHandler())
+	mux.Handle("/healthz", he.ReadinessHandler())
+	return mux
+}
+
+/**
+ * 写出探测报告（UP 为 200，DOWN 为 503）
+ */
+func writeHealthProbeReport(w http.ResponseWriter, report *HealthProbeReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status == HealthProbeUp {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		LogError("健康检查响应编码失败: %v", err)
+	}
+}
+
+/**
+ * 未通过的检查项名称（按名称排序）
+ */
+func (r *HealthProbeReport) FailedChecks() []string {
+	failed := make([]string, 0)
+	for name, check := range r.Checks {
+		if check.Status != HealthStatusHealthy {
+			failed = append(failed, name)
+		}
+	}
+	sort.Strings(failed)
+	return failed
+}
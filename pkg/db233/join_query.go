@@ -0,0 +1,507 @@
+package db233
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * JoinQuery - 多表 JOIN 查询构建器
+ *
+ * 按表别名拼接 JOIN，并把结果映射到组合 DTO，常见的关联查询不再需要手写 rows.Scan：
+ *
+ *   type OrderView struct {
+ *       Order                    `db:"o"`       // 嵌入实体：选出 o 的全部列
+ *       Buyer    *User           `db:"u"`       // 实体字段：LEFT JOIN 没有匹配行时为 nil
+ *       UserName string          `db:"u.name"`  // 单列
+ *       Items    int64           `db:"items"`   // SelectExpr 定义的表达式
+ *   }
+ *
+ *   var views []OrderView
+ *   err := db233.NewJoinQuery(db).
+ *       From(&Order{}, "o").
+ *       LeftJoin(&User{}, "u", "u.id = o.user_id").
+ *       SelectExpr("(SELECT COUNT(*) FROM order_item i WHERE i.order_id = o.id)", "items").
+ *       Where("o.status = ?", "paid").
+ *       OrderBy("o.id DESC").
+ *       Limit(20).
+ *       Find(&views)
+ *
+ * DTO 字段的 db 标签：
+ *   - "alias.column"：对应别名表的一列
+ *   - "alias"（字段为结构体或结构体指针）：对应别名表的全部列（EntityMetadata.AllColumns），按列名映射到该实体
+ *   - "name"（其余字段）：SelectExpr 定义的表达式或结果集中的同名列
+ *
+ * 每列以 alias__column 为标签选出，不同表的同名列（如 id）不会冲突
+ *
+ * 主表与每个 JOIN 表都带上各自的作用域谓词与行级安全策略谓词：有谓词的表改写为过滤后的派生表
+ * (SELECT * FROM t WHERE 谓词) alias，谓词中未加别名的列不会与其他表冲突，LEFT JOIN 也只能匹配到可见行。
+ * 通过 repo.NewJoinQuery() 创建时继承存储库的作用域与 context（行级安全策略按该 context 生成谓词）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type JoinQuery struct {
+	db         *Db
+	repo       *BaseCrudRepository
+	from       string
+	fromParams []interface{}
+	joins      []string
+	joinParams []interface{}
+	exprs      []joinSelectExpr
+	conditions []string
+	params     []interface{}
+	orderBy    []string
+	groupBy    []string
+	limit      int
+	offset     int
+	err        error
+}
+
+/**
+ * SelectExpr 定义的表达式
+ */
+type joinSelectExpr struct {
+	expr  string
+	label string
+}
+
+/**
+ * DTO 的查询计划：选择列表与标签到字段的映射
+ */
+type joinPlan struct {
+	selects []string
+	fields  map[string][]int
+}
+
+var joinPlanCache sync.Map
+
+/**
+ * 创建 JOIN 查询构建器
+ */
+func NewJoinQuery(db *Db) *JoinQuery {
+	return NewBaseCrudRepository(db).NewJoinQuery()
+}
+
+/**
+ * 基于存储库创建 JOIN 查询构建器（继承作用域与 context）
+ */
+func (r *BaseCrudRepository) NewJoinQuery() *JoinQuery {
+	return &JoinQuery{db: r.db, repo: r}
+}
+
+/**
+ * 设置主表
+ *
+ * @param table 实体（按 EntityMetadata 解析表名）或表名
+ * @param alias 表别名
+ */
+func (q *JoinQuery) From(table interface{}, alias string) *JoinQuery {
+	q.from, q.fromParams = q.tableRef(table, alias)
+	return q
+}
+
+/**
+ * INNER JOIN
+ */
+func (q *JoinQuery) Join(table interface{}, alias string, on string) *JoinQuery {
+	return q.join("INNER JOIN", table, alias, on)
+}
+
+/**
+ * LEFT JOIN
+ */
+func (q *JoinQuery) LeftJoin(table interface{}, alias string, on string) *JoinQuery {
+	return q.join("LEFT JOIN", table, alias, on)
+}
+
+/**
+ * RIGHT JOIN
+ */
+func (q *JoinQuery) RightJoin(table interface{}, alias string, on string) *JoinQuery {
+	return q.join("RIGHT JOIN", table, alias, on)
+}
+
+func (q *JoinQuery) join(kind string, table interface{}, alias string, on string) *JoinQuery {
+	if strings.TrimSpace(on) == "" {
+		q.setErr(NewValidationException(fmt.Sprintf("%s %s 缺少 ON 条件", kind, alias)))
+		return q
+	}
+	ref, params := q.tableRef(table, alias)
+	q.joins = append(q.joins, fmt.Sprintf("%s %s ON %s", kind, ref, on))
+	q.joinParams = append(q.joinParams, params...)
+	return q
+}
+
+/**
+ * 追加选择表达式，结果以 label 为列名，对应 DTO 中 db:"label" 的字段
+ */
+func (q *JoinQuery) SelectExpr(expr string, label string) *JoinQuery {
+	if !isJoinIdentifier(label) {
+		q.setErr(NewValidationException(fmt.Sprintf("选择表达式的标签无效: %s", label)))
+		return q
+	}
+	q.exprs = append(q.exprs, joinSelectExpr{expr: expr, label: label})
+	return q
+}
+
+/**
+ * 追加条件（与其他条件以 AND 连接）
+ */
+func (q *JoinQuery) Where(condition string, params ...interface{}) *JoinQuery {
+	if strings.TrimSpace(condition) == "" {
+		return q
+	}
+	converted, err := convertParamsToDb(params)
+	if err != nil {
+		q.setErr(NewValidationExceptionWithCause(err, "查询参数类型转换失败"))
+		return q
+	}
+	q.conditions = append(q.conditions, condition)
+	q.params = append(q.params, converted...)
+	return q
+}
+
+/**
+ * 分组
+ */
+func (q *JoinQuery) GroupBy(columns ...string) *JoinQuery {
+	q.groupBy = append(q.groupBy, columns...)
+	return q
+}
+
+/**
+ * 排序，如 OrderBy("o.id DESC")
+ */
+func (q *JoinQuery) OrderBy(orderBy ...string) *JoinQuery {
+	q.orderBy = append(q.orderBy, orderBy...)
+	return q
+}
+
+/**
+ * 限制返回行数
+ */
+func (q *JoinQuery) Limit(limit int) *JoinQuery {
+	q.limit = limit
+	return q
+}
+
+/**
+ * 跳过行数
+ */
+func (q *JoinQuery) Offset(offset int) *JoinQuery {
+	q.offset = offset
+	return q
+}
+
+/**
+ * 按 DTO 生成 SQL 与参数
+ *
+ * @param dto DTO 实例或指针，仅用于获取类型
+ */
+func (q *JoinQuery) Build(dto interface{}) (string, []interface{}, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
+	if q.from == "" {
+		return "", nil, NewValidationException("JOIN 查询缺少主表（From）")
+	}
+	plan, err := buildJoinPlan(joinDtoType(dto))
+	if err != nil {
+		return "", nil, err
+	}
+
+	selects := make([]string, 0, len(plan.selects)+len(q.exprs))
+	labels := make(map[string]bool, len(q.exprs))
+	for _, expr := range q.exprs {
+		selects = append(selects, expr.expr+" AS "+expr.label)
+		labels[expr.label] = true
+	}
+	for _, item := range plan.selects {
+		if !labels[joinSelectLabel(item)] {
+			selects = append(selects, item)
+		}
+	}
+
+	var builder strings.Builder
+	builder.WriteString("SELECT ")
+	builder.WriteString(strings.Join(selects, ", "))
+	builder.WriteString(" FROM ")
+	builder.WriteString(q.from)
+	for _, join := range q.joins {
+		builder.WriteString(" ")
+		builder.WriteString(join)
+	}
+	if len(q.conditions) > 0 {
+		builder.WriteString(" WHERE ")
+		builder.WriteString(strings.Join(q.conditions, " AND "))
+	}
+	if len(q.groupBy) > 0 {
+		builder.WriteString(" GROUP BY ")
+		builder.WriteString(strings.Join(q.groupBy, ", "))
+	}
+	if len(q.orderBy) > 0 {
+		builder.WriteString(" ORDER BY ")
+		builder.WriteString(strings.Join(q.orderBy, ", "))
+	}
+	query := builder.String()
+	if q.limit > 0 || q.offset > 0 {
+		query = q.db.GetSqlDialect().ApplyLimitOffset(query, q.limit, q.offset)
+	}
+	params := make([]interface{}, 0, len(q.fromParams)+len(q.joinParams)+len(q.params))
+	params = append(params, q.fromParams...)
+	params = append(params, q.joinParams...)
+	params = append(params, q.params...)
+	return query, params, nil
+}
+
+/**
+ * 执行查询并映射到 DTO 切片
+ *
+ * @param dest *[]DTO 或 *[]*DTO
+ */
+func (q *JoinQuery) Find(dest interface{}) error {
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.Elem().Kind() != reflect.Slice {
+		return NewValidationException("JOIN 查询结果必须是切片指针")
+	}
+	elemType := destValue.Elem().Type().Elem()
+	dtoType := elemType
+	if dtoType.Kind() == reflect.Ptr {
+		dtoType = dtoType.Elem()
+	}
+
+	query, params, err := q.Build(reflect.New(dtoType).Interface())
+	if err != nil {
+		return err
+	}
+	plan, _ := buildJoinPlan(dtoType)
+	rows, err := q.db.QueryWithMapper(query, params, &joinRowMapper{dtoType: dtoType, plan: plan, policy: q.db.GetTimePolicy()})
+	if err != nil {
+		return err
+	}
+
+	result := reflect.MakeSlice(destValue.Elem().Type(), 0, len(rows))
+	for _, row := range rows {
+		value := reflect.ValueOf(row)
+		if elemType.Kind() != reflect.Ptr {
+			value = value.Elem()
+		}
+		result = reflect.Append(result, value)
+	}
+	destValue.Elem().Set(result)
+	return nil
+}
+
+/**
+ * 执行查询并只返回第一行（Limit 设为 1）
+ *
+ * @param dest *DTO
+ * @return bool 是否有结果
+ */
+func (q *JoinQuery) FindOne(dest interface{}) (bool, error) {
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.Elem().Kind() != reflect.Struct {
+		return false, NewValidationException("JOIN 查询结果必须是结构体指针")
+	}
+	rows := reflect.New(reflect.SliceOf(destValue.Elem().Type()))
+	if err := q.Limit(1).Find(rows.Interface()); err != nil {
+		return false, err
+	}
+	if rows.Elem().Len() == 0 {
+		return false, nil
+	}
+	destValue.Elem().Set(rows.Elem().Index(0))
+	return true, nil
+}
+
+/**
+ * 生成表引用，有作用域或行级安全策略谓词时改写为过滤后的派生表
+ */
+func (q *JoinQuery) tableRef(table interface{}, alias string) (string, []interface{}) {
+	if !isJoinIdentifier(alias) {
+		q.setErr(NewValidationException(fmt.Sprintf("表别名无效: %s", alias)))
+		return "", nil
+	}
+
+	var tableName, logicalName string
+	switch t := table.(type) {
+	case string:
+		logicalName = t
+		tableName = q.repo.mapTableName(t)
+	case IDbEntity:
+		logicalName = logicalTableName(t)
+		tableName = q.repo.getTableName(t)
+	default:
+		metadata, err := GetEntityMetadataCacheInstance().GetOrBuild(table)
+		if err != nil {
+			q.setErr(err)
+			return "", nil
+		}
+		logicalName = metadata.TableName
+		tableName = q.repo.mapTableName(metadata.TableName)
+	}
+
+	predicates, err := q.repo.tableScopePredicates(logicalName)
+	if err != nil {
+		q.setErr(err)
+		return "", nil
+	}
+	where, params := mergeScopePredicates("", nil, predicates)
+	if where == "" {
+		return tableName + " " + alias, nil
+	}
+	return "(SELECT * FROM " + tableName + " WHERE " + where + ") " + alias, params
+}
+
+func (q *JoinQuery) setErr(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+func joinDtoType(dto interface{}) reflect.Type {
+	dtoType := reflect.TypeOf(dto)
+	for dtoType != nil && dtoType.Kind() == reflect.Ptr {
+		dtoType = dtoType.Elem()
+	}
+	return dtoType
+}
+
+/**
+ * 解析 DTO 的查询计划（按类型缓存）
+ */
+func buildJoinPlan(dtoType reflect.Type) (*joinPlan, error) {
+	if dtoType == nil || dtoType.Kind() != reflect.Struct {
+		return nil, NewValidationException(fmt.Sprintf("JOIN 查询的 DTO 必须是结构体: %v", dtoType))
+	}
+	if cached, exists := joinPlanCache.Load(dtoType); exists {
+		return cached.(*joinPlan), nil
+	}
+
+	plan := &joinPlan{fields: make(map[string][]int)}
+	add := func(selectItem string, label string, index []int) error {
+		if _, exists := plan.fields[label]; exists {
+			return NewValidationException(fmt.Sprintf("DTO %s 的列重复: %s", dtoType.Name(), label))
+		}
+		plan.selects = append(plan.selects, selectItem)
+		plan.fields[label] = index
+		return nil
+	}
+
+	for i := 0; i < dtoType.NumField(); i++ {
+		field := dtoType.Field(i)
+		tag := strings.TrimSpace(strings.Split(field.Tag.Get("db"), ",")[0])
+		if tag == "" || tag == "-" || (field.PkgPath != "" && !field.Anonymous) {
+			continue
+		}
+
+		if alias, column, qualified := strings.Cut(tag, "."); qualified {
+			if !isJoinIdentifier(alias) || !isJoinIdentifier(column) {
+				return nil, NewValidationException(fmt.Sprintf("DTO %s 字段 %s 的列无效: %s", dtoType.Name(), field.Name, tag))
+			}
+			label := alias + "__" + column
+			if err := add(tag+" AS "+label, label, field.Index); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		entityType := field.Type
+		if entityType.Kind() == reflect.Ptr {
+			entityType = entityType.Elem()
+		}
+		if entityType.Kind() == reflect.Struct && entityType != reflect.TypeOf(time.Time{}) && !isSqlScannerType(field.Type) {
+			if !isJoinIdentifier(tag) {
+				return nil, NewValidationException(fmt.Sprintf("DTO %s 字段 %s 的表别名无效: %s", dtoType.Name(), field.Name, tag))
+			}
+			metadata, err := GetEntityMetadataCacheInstance().GetOrBuild(reflect.New(entityType).Interface())
+			if err != nil {
+				return nil, err
+			}
+			for _, column := range metadata.AllColumns {
+				index := structFieldIndexByColumn(entityType, column)
+				if index == nil {
+					continue
+				}
+				label := tag + "__" + column
+				if err := add(tag+"."+column+" AS "+label, label, append(append([]int(nil), field.Index...), index...)); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		if err := add(tag, tag, field.Index); err != nil {
+			return nil, err
+		}
+	}
+	if len(plan.selects) == 0 {
+		return nil, NewValidationException(fmt.Sprintf("DTO %s 没有带 db 标签的字段", dtoType.Name()))
+	}
+
+	actual, _ := joinPlanCache.LoadOrStore(dtoType, plan)
+	return actual.(*joinPlan), nil
+}
+
+/**
+ * 选择项的结果列名
+ */
+func joinSelectLabel(item string) string {
+	if index := strings.LastIndex(item, " AS "); index >= 0 {
+		return item[index+len(" AS "):]
+	}
+	return item
+}
+
+func isJoinIdentifier(name string) bool {
+	if name == "" || !isTemplateParamStart(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isTemplateParamPart(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+ * 按查询计划把每行映射到 DTO 指针（LEFT JOIN 未匹配时实体指针字段保持 nil）
+ */
+type joinRowMapper struct {
+	dtoType reflect.Type
+	plan    *joinPlan
+	policy  *TimePolicy
+}
+
+func (m *joinRowMapper) MapRow(columns []string, rows *sql.Rows, rowNum int) (interface{}, error) {
+	values, err := scanRowValues(columns, rows, false)
+	if err != nil {
+		return nil, err
+	}
+	dto := reflect.New(m.dtoType)
+	for i, column := range columns {
+		index, exists := m.plan.fields[column]
+		if !exists || values[i] == nil {
+			continue
+		}
+		field := fieldByIndexAlloc(dto.Elem(), index)
+		source := reflect.ValueOf(&values[i]).Elem()
+		var converted reflect.Value
+		if isTimeFieldType(field.Type()) {
+			converted, err = m.policy.fromStorage(source, field.Type())
+		} else {
+			converted, err = OrmHandlerInstance.convertValue(source, field.Type())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("列 %s 转换为 %s 失败: %w", column, field.Type(), err)
+		}
+		field.Set(converted)
+	}
+	return dto.Interface(), nil
+}
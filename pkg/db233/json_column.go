@@ -0,0 +1,302 @@
+package db233
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+ * JSON 列与 JSON 路径查询
+ *
+ * map / slice / 结构体字段保存时序列化为 JSON、加载时反序列化；标注 db_type:"JSON" 时建表为原生 JSON 列
+ * （MySQL JSON，SQL Server 没有原生类型，使用 NVARCHAR(MAX)）。
+ *
+ * 路径语法为 $.key / [n] 的组合，如 $.items[0].id；各数据库生成的条件：
+ *   MySQL        JSON_EXTRACT(col, '$.items[0].id') = CAST(? AS JSON)    JSON_CONTAINS(col, ?)
+ *   PostgreSQL   (col #>> '{items,0,id}') = ?（按文本比较）              col::jsonb @> ?::jsonb
+ *   SQL Server   JSON_VALUE(col, '$.items[0].id') = ?                    OPENJSON 展开后逐个匹配（仅支持标量、标量数组与扁平对象）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type JSONDialect interface {
+	/**
+	 * 生成 JSON 路径等值条件
+	 */
+	JSONPathEquals(column string, path string, value interface{}) (string, []interface{}, error)
+
+	/**
+	 * 生成 JSON 包含条件（列中的文档包含 value）
+	 */
+	JSONContains(column string, value interface{}) (string, []interface{}, error)
+}
+
+var (
+	jsonColumnNamePattern  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+	jsonPathSegmentPattern = regexp.MustCompile(`^(\.[A-Za-z_][A-Za-z0-9_]*|\[[0-9]+\])`)
+)
+
+/**
+ * JSON 路径中的一段（对象键或数组下标）
+ */
+type jsonPathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+/**
+ * 解析 JSON 路径（只接受 $、.key 与 [n]，可安全拼入 SQL）
+ */
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, NewValidationException(fmt.Sprintf("JSON 路径必须以 $ 开头: %s", path))
+	}
+	var segments []jsonPathSegment
+	rest := path[1:]
+	for rest != "" {
+		match := jsonPathSegmentPattern.FindString(rest)
+		if match == "" {
+			return nil, NewValidationException(fmt.Sprintf("不支持的 JSON 路径: %s", path))
+		}
+		if strings.HasPrefix(match, "[") {
+			index, _ := strconv.Atoi(match[1 : len(match)-1])
+			segments = append(segments, jsonPathSegment{index: index, isIndex: true})
+		} else {
+			segments = append(segments, jsonPathSegment{key: match[1:]})
+		}
+		rest = rest[len(match):]
+	}
+	if len(segments) == 0 {
+		return nil, NewValidationException("JSON 路径不能只有 $")
+	}
+	return segments, nil
+}
+
+/**
+ * 路径的标准形式（$.items[0].id）
+ */
+func formatJSONPath(segments []jsonPathSegment) string {
+	var sb strings.Builder
+	sb.WriteString("$")
+	for _, segment := range segments {
+		if segment.isIndex {
+			sb.WriteString("[" + strconv.Itoa(segment.index) + "]")
+		} else {
+			sb.WriteString("." + segment.key)
+		}
+	}
+	return sb.String()
+}
+
+func validateJSONColumn(column string) error {
+	if !jsonColumnNamePattern.MatchString(column) {
+		return NewValidationException(fmt.Sprintf("非法的 JSON 列名: %s", column))
+	}
+	return nil
+}
+
+/**
+ * 解析列名与路径
+ */
+func prepareJSONPath(column string, path string) ([]jsonPathSegment, error) {
+	if err := validateJSONColumn(column); err != nil {
+		return nil, err
+	}
+	return parseJSONPath(path)
+}
+
+func marshalJSONParam(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", NewValidationExceptionWithCause(err, "JSON 条件值序列化失败")
+	}
+	return string(data), nil
+}
+
+/**
+ * 把标量转换为 JSON 文本形式（字符串原样，布尔为 true / false）
+ */
+func jsonScalarText(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", NewValidationException("JSON 路径条件的值不能为 nil")
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	kind := reflect.TypeOf(value).Kind()
+	if kind == reflect.Map || kind == reflect.Slice || kind == reflect.Array || kind == reflect.Struct {
+		return "", NewValidationException(fmt.Sprintf("JSON 路径条件只支持标量值: %T", value))
+	}
+	return fmt.Sprint(value), nil
+}
+
+/**
+ * MySQL：比较 JSON 值（数值、字符串、布尔按 JSON 语义比较）
+ */
+func (d *MySQLDialect) JSONPathEquals(column string, path string, value interface{}) (string, []interface{}, error) {
+	segments, err := prepareJSONPath(column, path)
+	if err != nil {
+		return "", nil, err
+	}
+	param, err := marshalJSONParam(value)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("JSON_EXTRACT(%s, '%s') = CAST(? AS JSON)", column, formatJSONPath(segments)), []interface{}{param}, nil
+}
+
+/**
+ * MySQL：JSON_CONTAINS
+ */
+func (d *MySQLDialect) JSONContains(column string, value interface{}) (string, []interface{}, error) {
+	if err := validateJSONColumn(column); err != nil {
+		return "", nil, err
+	}
+	param, err := marshalJSONParam(value)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("JSON_CONTAINS(%s, ?)", column), []interface{}{param}, nil
+}
+
+/**
+ * PostgreSQL：#>> 取出文本后比较
+ */
+func (d *PostgreSQLDialect) JSONPathEquals(column string, path string, value interface{}) (string, []interface{}, error) {
+	segments, err := prepareJSONPath(column, path)
+	if err != nil {
+		return "", nil, err
+	}
+	text, err := jsonScalarText(value)
+	if err != nil {
+		return "", nil, err
+	}
+	keys := make([]string, len(segments))
+	for i, segment := range segments {
+		if segment.isIndex {
+			keys[i] = strconv.Itoa(segment.index)
+		} else {
+			keys[i] = segment.key
+		}
+	}
+	return fmt.Sprintf("(%s #>> '{%s}') = ?", column, strings.Join(keys, ",")), []interface{}{text}, nil
+}
+
+/**
+ * PostgreSQL：jsonb 包含运算符 @>
+ */
+func (d *PostgreSQLDialect) JSONContains(column string, value interface{}) (string, []interface{}, error) {
+	if err := validateJSONColumn(column); err != nil {
+		return "", nil, err
+	}
+	param, err := marshalJSONParam(value)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("%s::jsonb @> ?::jsonb", column), []interface{}{param}, nil
+}
+
+/**
+ * SQL Server：JSON_VALUE
+ */
+func (d *SQLServerDialect) JSONPathEquals(column string, path string, value interface{}) (string, []interface{}, error) {
+	segments, err := prepareJSONPath(column, path)
+	if err != nil {
+		return "", nil, err
+	}
+	text, err := jsonScalarText(value)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("JSON_VALUE(%s, '%s') = ?", column, formatJSONPath(segments)), []interface{}{text}, nil
+}
+
+/**
+ * SQL Server：没有 JSON_CONTAINS，数组元素用 OPENJSON 展开匹配，扁平对象按键比较
+ */
+func (d *SQLServerDialect) JSONContains(column string, value interface{}) (string, []interface{}, error) {
+	if err := validateJSONColumn(column); err != nil {
+		return "", nil, err
+	}
+
+	v := reflect.ValueOf(value)
+	var conditions []string
+	var params []interface{}
+	switch {
+	case value == nil:
+		return "", nil, NewValidationException("JSON 包含条件的值不能为 nil")
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			text, err := jsonScalarText(v.Index(i).Interface())
+			if err != nil {
+				return "", nil, err
+			}
+			conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM OPENJSON(%s) WHERE [value] = ?)", column))
+			params = append(params, text)
+		}
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			segments, err := parseJSONPath("$." + key.String())
+			if err != nil {
+				return "", nil, err
+			}
+			text, err := jsonScalarText(v.MapIndex(key).Interface())
+			if err != nil {
+				return "", nil, err
+			}
+			conditions = append(conditions, fmt.Sprintf("JSON_VALUE(%s, '%s') = ?", column, formatJSONPath(segments)))
+			params = append(params, text)
+		}
+	default:
+		text, err := jsonScalarText(value)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM OPENJSON(%s) WHERE [value] = ?)", column))
+		params = append(params, text)
+	}
+	if len(conditions) == 0 {
+		return "1 = 1", nil, nil
+	}
+	return "(" + strings.Join(conditions, " AND ") + ")", params, nil
+}
+
+/**
+ * 是否需要按 JSON 反序列化的目标类型（map、非 []byte 的切片、数组、time.Time 以外的结构体）
+ */
+func isJSONDecodableType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Map, reflect.Array:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	case reflect.Struct:
+		return t != reflect.TypeOf(time.Time{})
+	}
+	return false
+}
+
+/**
+ * 把 JSON 文本反序列化为目标类型（空文本返回零值）
+ */
+func decodeJSONValue(data []byte, targetType reflect.Type) (reflect.Value, error) {
+	target := reflect.New(targetType)
+	if len(data) == 0 {
+		return target.Elem(), nil
+	}
+	if err := json.Unmarshal(data, target.Interface()); err != nil {
+		return reflect.Value{}, fmt.Errorf("JSON 反序列化失败: %w", err)
+	}
+	return target.Elem(), nil
+}
@@ -0,0 +1,304 @@
+package db233
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * KeyGenerator - 主键生成器
+ *
+ * Save 前为带 gen 选项且为零值的字段自动生成主键：
+ *
+ *   ID string `db:"id,primary_key,gen=uuidv7"`
+ *
+ * 整数字段要求生成器同时实现 NumericKeyGenerator（如 snowflake）
+ *
+ * 内置生成器：
+ *   uuid / uuidv4   随机 UUID（36 位带连字符）
+ *   uuidv7          按毫秒时间排序的 UUID（RFC 9562），适合作为 InnoDB 聚簇索引
+ *   ulid            26 位 Crockford Base32，按毫秒时间排序
+ *
+ *   snowflake       64 位整数雪花 ID，需先调用 RegisterSnowflake(workerId) 注册
+ *
+ * 自定义生成器通过 GetKeyGeneratorRegistryInstance().Register 注册
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type KeyGenerator interface {
+	Generate() (string, error)
+}
+
+/**
+ * NumericKeyGenerator - 可生成整数主键的生成器
+ */
+type NumericKeyGenerator interface {
+	KeyGenerator
+	GenerateInt64() (int64, error)
+}
+
+/**
+ * KeyGeneratorFunc - 函数式主键生成器
+ */
+type KeyGeneratorFunc func() (string, error)
+
+/**
+ * 生成主键
+ */
+func (f KeyGeneratorFunc) Generate() (string, error) {
+	return f()
+}
+
+/**
+ * KeyGeneratorRegistry - 主键生成器注册表
+ */
+type KeyGeneratorRegistry struct {
+	generators map[string]KeyGenerator
+	typeFields map[reflect.Type][]keyGenField
+	mu         sync.RWMutex
+}
+
+/**
+ * 带 gen 选项的字段
+ */
+type keyGenField struct {
+	index     []int
+	name      string
+	generator string
+}
+
+var (
+	keyGeneratorRegistryInstance *KeyGeneratorRegistry
+	keyGeneratorRegistryOnce     sync.Once
+)
+
+/**
+ * 获取主键生成器注册表单例
+ */
+func GetKeyGeneratorRegistryInstance() *KeyGeneratorRegistry {
+	keyGeneratorRegistryOnce.Do(func() {
+		keyGeneratorRegistryInstance = &KeyGeneratorRegistry{
+			generators: make(map[string]KeyGenerator),
+			typeFields: make(map[reflect.Type][]keyGenField),
+		}
+		keyGeneratorRegistryInstance.generators["uuid"] = KeyGeneratorFunc(NewUUIDv4)
+		keyGeneratorRegistryInstance.generators["uuidv4"] = KeyGeneratorFunc(NewUUIDv4)
+		keyGeneratorRegistryInstance.generators["uuidv7"] = KeyGeneratorFunc(NewUUIDv7)
+		keyGeneratorRegistryInstance.generators["ulid"] = KeyGeneratorFunc(NewULID)
+	})
+	return keyGeneratorRegistryInstance
+}
+
+/**
+ * 注册生成器（同名覆盖内置生成器）
+ */
+func (r *KeyGeneratorRegistry) Register(name string, generator KeyGenerator) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.generators[name] = generator
+}
+
+/**
+ * 获取生成器
+ */
+func (r *KeyGeneratorRegistry) Get(name string) (KeyGenerator, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	generator, exists := r.generators[name]
+	return generator, exists
+}
+
+/**
+ * 为实体中带 gen 选项且为零值的字段生成主键
+ */
+func (r *KeyGeneratorRegistry) PopulateKeys(entity interface{}) error {
+	value := reflect.ValueOf(entity)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return nil
+	}
+	value = value.Elem()
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for _, field := range r.getKeyGenFields(value.Type()) {
+		fieldValue, err := value.FieldByIndexErr(field.index)
+		if err != nil || !fieldValue.IsZero() {
+			continue
+		}
+		generator, exists := r.Get(field.generator)
+		if !exists {
+			return NewConfigurationException(fmt.Sprintf("字段 %s.%s 的主键生成器未注册: %s", value.Type().Name(), field.name, field.generator))
+		}
+
+		switch fieldValue.Kind() {
+		case reflect.String:
+			key, err := generator.Generate()
+			if err != nil {
+				return NewDb233ExceptionWithCause(err, fmt.Sprintf("生成主键失败: 字段=%s.%s, 生成器=%s", value.Type().Name(), field.name, field.generator))
+			}
+			fieldValue.SetString(key)
+		case reflect.Int64, reflect.Uint64:
+			numericGenerator, ok := generator.(NumericKeyGenerator)
+			if !ok {
+				return NewConfigurationException(fmt.Sprintf("字段 %s.%s 为整数类型，但生成器 %s 不支持生成整数主键", value.Type().Name(), field.name, field.generator))
+			}
+			key, err := numericGenerator.GenerateInt64()
+			if err != nil {
+				return NewDb233ExceptionWithCause(err, fmt.Sprintf("生成主键失败: 字段=%s.%s, 生成器=%s", value.Type().Name(), field.name, field.generator))
+			}
+			if fieldValue.Kind() == reflect.Int64 {
+				fieldValue.SetInt(key)
+			} else {
+				fieldValue.SetUint(uint64(key))
+			}
+		default:
+			return NewConfigurationException(fmt.Sprintf("字段 %s.%s 声明了 gen=%s，但只支持 string/int64/uint64 类型", value.Type().Name(), field.name, field.generator))
+		}
+		LogDebug("已生成主键: 实体=%s, 字段=%s, 生成器=%s, 值=%v", value.Type().Name(), field.name, field.generator, fieldValue.Interface())
+	}
+	return nil
+}
+
+/**
+ * 获取类型中带 gen 选项的字段（按类型缓存）
+ */
+func (r *KeyGeneratorRegistry) getKeyGenFields(t reflect.Type) []keyGenField {
+	r.mu.RLock()
+	fields, exists := r.typeFields[t]
+	r.mu.RUnlock()
+	if exists {
+		return fields
+	}
+
+	fields = make([]keyGenField, 0)
+	collectKeyGenFieldsRecursive(t, nil, &fields)
+
+	r.mu.Lock()
+	r.typeFields[t] = fields
+	r.mu.Unlock()
+	return fields
+}
+
+/**
+ * 递归收集带 gen 选项的字段（支持嵌入结构体）
+ */
+func collectKeyGenFieldsRecursive(t reflect.Type, parentIndex []int, fields *[]keyGenField) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		index := append(append([]int(nil), parentIndex...), i)
+
+		if field.Anonymous {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				collectKeyGenFieldsRecursive(embeddedType, index, fields)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		tagParts := strings.Split(field.Tag.Get("db"), ",")
+		for _, part := range tagParts[1:] {
+			name, generator, found := strings.Cut(strings.TrimSpace(part), "=")
+			if found && strings.TrimSpace(name) == "gen" {
+				*fields = append(*fields, keyGenField{index: index, name: field.Name, generator: strings.TrimSpace(generator)})
+				break
+			}
+		}
+	}
+}
+
+/**
+ * 生成 UUIDv4（随机）
+ */
+func NewUUIDv4() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return formatUUID(b), nil
+}
+
+/**
+ * 生成 UUIDv7（48 位毫秒时间戳 + 随机数）
+ */
+func NewUUIDv7() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", err
+	}
+	putUnixMillis48(b[:6], time.Now())
+	b[6] = (b[6] & 0x0f) | 0x70
+	b[8] = (b[8] & 0x3f) | 0x80
+	return formatUUID(b), nil
+}
+
+/**
+ * 生成 ULID（48 位毫秒时间戳 + 80 位随机数，Crockford Base32）
+ */
+func NewULID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", err
+	}
+	putUnixMillis48(b[:6], time.Now())
+	return encodeCrockford128(b), nil
+}
+
+/**
+ * 写入 48 位大端毫秒时间戳
+ */
+func putUnixMillis48(dst []byte, now time.Time) {
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(now.UnixMilli()))
+	copy(dst, ts[2:])
+}
+
+/**
+ * 格式化为 8-4-4-4-12
+ */
+func formatUUID(b [16]byte) string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf[:])
+}
+
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+/**
+ * 128 位编码为 26 位 Crockford Base32（26 x 5 = 130 位，首字符取值 0-7）
+ */
+func encodeCrockford128(b [16]byte) string {
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+
+	var buf [26]byte
+	for i := len(buf) - 1; i >= 0; i-- {
+		buf[i] = crockfordAlphabet[lo&0x1f]
+		lo = (lo >> 5) | (hi << 59)
+		hi >>= 5
+	}
+	return string(buf[:])
+}
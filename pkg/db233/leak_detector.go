@@ -0,0 +1,435 @@
+package db233
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * LeakDetector - 连接与事务泄漏检测器
+ *
+ * 跟踪 Db 上开启的事务（TransactionManager.Begin 到 Commit / Rollback）与检出的连接
+ * （ExecuteWithConnection 回调及语句显式获取的连接），定期检查持有时间超过阈值的对象，
+ * 输出警告并通过 AlertManager 告警，帮助定位忘记 Commit 的代码：
+ *
+ *   detector := db233.NewLeakDetector("main").SetDebug(true).SetAlertManager(alertManager)
+ *   db.SetLeakDetector(detector)
+ *   detector.Start()
+ *
+ * 调试模式下在检出时记录调用栈并附在警告中（有一定开销，生产环境按需开启）。
+ * 每个对象只报告一次，泄漏的对象最终释放时记录实际持有时间，全部释放后告警自动解决
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type LeakDetector struct {
+	name string
+
+	// 阈值
+	transactionThreshold time.Duration
+	connectionThreshold  time.Duration
+	checkInterval        time.Duration
+
+	// 调试模式：检出时记录调用栈
+	debug bool
+
+	alertManager *AlertManager
+
+	// 跟踪中的对象
+	tracked map[int64]*leakTracked
+	nextId  int64
+
+	// 累计报告的泄漏数
+	leakedTransactions int64
+	leakedConnections  int64
+
+	// 上次送入告警的泄漏数（按类型），变化时才送入，避免重复通知
+	reportedCounts map[string]int
+
+	mu       sync.Mutex
+	stopChan chan bool
+	stopOnce sync.Once
+}
+
+/**
+ * 泄漏类型
+ */
+const (
+	LeakKindTransaction = "transaction"
+	LeakKindConnection  = "connection"
+)
+
+/**
+ * LeakReport - 泄漏报告
+ */
+type LeakReport struct {
+	Id        int64
+	Kind      string
+	DbId      int
+	StartTime time.Time
+	Duration  time.Duration
+
+	// 检出时的调用栈（仅调试模式）
+	Stack string
+}
+
+/**
+ * 跟踪中的对象
+ */
+type leakTracked struct {
+	id        int64
+	kind      string
+	dbId      int
+	startTime time.Time
+	stack     string
+	leaked    bool
+}
+
+/**
+ * 创建泄漏检测器，默认事务 30 秒、连接 10 秒视为泄漏
+ */
+func NewLeakDetector(name string) *LeakDetector {
+	return &LeakDetector{
+		name:                 name,
+		transactionThreshold: 30 * time.Second,
+		connectionThreshold:  10 * time.Second,
+		checkInterval:        5 * time.Second,
+		tracked:              make(map[int64]*leakTracked),
+		reportedCounts:       make(map[string]int),
+		stopChan:             make(chan bool),
+	}
+}
+
+/**
+ * 设置事务泄漏阈值
+ */
+func (ld *LeakDetector) SetTransactionThreshold(threshold time.Duration) *LeakDetector {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+	ld.transactionThreshold = threshold
+	return ld
+}
+
+/**
+ * 设置连接泄漏阈值
+ */
+func (ld *LeakDetector) SetConnectionThreshold(threshold time.Duration) *LeakDetector {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+	ld.connectionThreshold = threshold
+	return ld
+}
+
+/**
+ * 设置检查间隔
+ */
+func (ld *LeakDetector) SetCheckInterval(interval time.Duration) *LeakDetector {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+	if interval > 0 {
+		ld.checkInterval = interval
+	}
+	return ld
+}
+
+/**
+ * 设置调试模式（检出时记录调用栈）
+ */
+func (ld *LeakDetector) SetDebug(enabled bool) *LeakDetector {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+	ld.debug = enabled
+	return ld
+}
+
+/**
+ * 设置告警管理器，并添加事务与连接泄漏两条告警规则（指标 leak.transaction / leak.connection 为当前泄漏数）
+ */
+func (ld *LeakDetector) SetAlertManager(alertManager *AlertManager) *LeakDetector {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+	ld.alertManager = alertManager
+	if alertManager == nil {
+		return ld
+	}
+	alertManager.AddAlertRule(AlertRule{
+		ID:          "leak_transaction",
+		Name:        "事务泄漏",
+		Description: fmt.Sprintf("存在持续超过 %v 未提交或回滚的事务: %s", ld.transactionThreshold, ld.name),
+		Metric:      leakMetric(LeakKindTransaction),
+		Condition:   GreaterThan,
+		Threshold:   0,
+		Severity:    Critical,
+		Enabled:     true,
+		Target:      ld.name,
+	})
+	alertManager.AddAlertRule(AlertRule{
+		ID:          "leak_connection",
+		Name:        "连接泄漏",
+		Description: fmt.Sprintf("存在检出超过 %v 未归还的连接: %s", ld.connectionThreshold, ld.name),
+		Metric:      leakMetric(LeakKindConnection),
+		Condition:   GreaterThan,
+		Threshold:   0,
+		Severity:    Warning,
+		Enabled:     true,
+		Target:      ld.name,
+	})
+	return ld
+}
+
+/**
+ * 开始定期检查
+ */
+func (ld *LeakDetector) Start() {
+	LogInfo("泄漏检测启动: %s, 间隔: %v", ld.name, ld.checkInterval)
+
+	goSupervised("leak_detector:"+ld.name, ld.stopChan, func() {
+		ticker := time.NewTicker(ld.checkInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ld.Check(time.Now())
+			case <-ld.stopChan:
+				LogInfo("泄漏检测停止: %s", ld.name)
+				return
+			}
+		}
+	})
+}
+
+/**
+ * 停止定期检查
+ */
+func (ld *LeakDetector) Stop() {
+	ld.stopOnce.Do(func() {
+		close(ld.stopChan)
+	})
+}
+
+/**
+ * 检查一次，返回本次新发现的泄漏
+ */
+func (ld *LeakDetector) Check(now time.Time) []LeakReport {
+	ld.mu.Lock()
+	found := make([]LeakReport, 0)
+	for _, item := range ld.tracked {
+		if item.leaked || now.Sub(item.startTime) < ld.thresholdOf(item.kind) {
+			continue
+		}
+		item.leaked = true
+		if item.kind == LeakKindTransaction {
+			ld.leakedTransactions++
+		} else {
+			ld.leakedConnections++
+		}
+		found = append(found, item.report(now))
+	}
+	alertManager, counts := ld.pendingAlertCounts()
+	ld.mu.Unlock()
+
+	sort.Slice(found, func(i, j int) bool { return found[i].Id < found[j].Id })
+	for _, leak := range found {
+		if leak.Stack != "" {
+			LogWarn("疑似%s泄漏: %s, dbId=%d, 已持有 %v, 检出位置:\n%s", leakKindName(leak.Kind), ld.name, leak.DbId, leak.Duration, leak.Stack)
+		} else {
+			LogWarn("疑似%s泄漏: %s, dbId=%d, 已持有 %v（开启调试模式可记录检出位置）", leakKindName(leak.Kind), ld.name, leak.DbId, leak.Duration)
+		}
+	}
+	ld.reportAlerts(alertManager, counts)
+	return found
+}
+
+/**
+ * 获取当前疑似泄漏（已超过阈值仍未释放）的对象
+ */
+func (ld *LeakDetector) GetActiveLeaks() []LeakReport {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+
+	now := time.Now()
+	result := make([]LeakReport, 0)
+	for _, item := range ld.tracked {
+		if item.leaked {
+			result = append(result, item.report(now))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
+	return result
+}
+
+/**
+ * 获取状态
+ */
+func (ld *LeakDetector) GetStatus() map[string]interface{} {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+
+	open := map[string]int{LeakKindTransaction: 0, LeakKindConnection: 0}
+	leaked := map[string]int{LeakKindTransaction: 0, LeakKindConnection: 0}
+	for _, item := range ld.tracked {
+		open[item.kind]++
+		if item.leaked {
+			leaked[item.kind]++
+		}
+	}
+	return map[string]interface{}{
+		"name":                      ld.name,
+		"debug":                     ld.debug,
+		"transaction_threshold":     ld.transactionThreshold.String(),
+		"connection_threshold":      ld.connectionThreshold.String(),
+		"open_transactions":         open[LeakKindTransaction],
+		"open_connections":          open[LeakKindConnection],
+		"leaking_transactions":      leaked[LeakKindTransaction],
+		"leaking_connections":       leaked[LeakKindConnection],
+		"total_leaked_transactions": ld.leakedTransactions,
+		"total_leaked_connections":  ld.leakedConnections,
+	}
+}
+
+/**
+ * 开始跟踪，返回句柄（检测器为 nil 时返回 0）
+ */
+func (ld *LeakDetector) track(kind string, dbId int) int64 {
+	if ld == nil {
+		return 0
+	}
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+
+	ld.nextId++
+	item := &leakTracked{id: ld.nextId, kind: kind, dbId: dbId, startTime: time.Now()}
+	if ld.debug {
+		item.stack = trimLeakStack(string(debug.Stack()))
+	}
+	ld.tracked[item.id] = item
+	return item.id
+}
+
+/**
+ * 结束跟踪，已报告泄漏的对象记录实际持有时间
+ */
+func (ld *LeakDetector) untrack(handle int64) {
+	if ld == nil || handle == 0 {
+		return
+	}
+	ld.mu.Lock()
+	item, exists := ld.tracked[handle]
+	delete(ld.tracked, handle)
+	var alertManager *AlertManager
+	var counts map[string]int
+	if exists && item.leaked {
+		alertManager, counts = ld.pendingAlertCounts()
+	}
+	ld.mu.Unlock()
+
+	if exists && item.leaked {
+		LogInfo("疑似泄漏的%s已释放: %s, dbId=%d, 共持有 %v", leakKindName(item.kind), ld.name, item.dbId, time.Since(item.startTime))
+		ld.reportAlerts(alertManager, counts)
+	}
+}
+
+/**
+ * 泄漏数有变化的类型（调用方持有 ld.mu）
+ */
+func (ld *LeakDetector) pendingAlertCounts() (*AlertManager, map[string]int) {
+	if ld.alertManager == nil {
+		return nil, nil
+	}
+	current := map[string]int{LeakKindTransaction: 0, LeakKindConnection: 0}
+	for _, item := range ld.tracked {
+		if item.leaked {
+			current[item.kind]++
+		}
+	}
+	changed := make(map[string]int)
+	for kind, count := range current {
+		if ld.reportedCounts[kind] != count {
+			ld.reportedCounts[kind] = count
+			changed[kind] = count
+		}
+	}
+	return ld.alertManager, changed
+}
+
+/**
+ * 把变化的泄漏数送入告警管理器（泄漏数归零时告警自动解决）
+ */
+func (ld *LeakDetector) reportAlerts(alertManager *AlertManager, counts map[string]int) {
+	if alertManager == nil {
+		return
+	}
+	for kind, count := range counts {
+		alertManager.CheckMetric(leakMetric(kind), count)
+	}
+}
+
+func (ld *LeakDetector) thresholdOf(kind string) time.Duration {
+	if kind == LeakKindTransaction {
+		return ld.transactionThreshold
+	}
+	return ld.connectionThreshold
+}
+
+func (item *leakTracked) report(now time.Time) LeakReport {
+	return LeakReport{
+		Id:        item.id,
+		Kind:      item.kind,
+		DbId:      item.dbId,
+		StartTime: item.startTime,
+		Duration:  now.Sub(item.startTime),
+		Stack:     item.stack,
+	}
+}
+
+/**
+ * 为 Db 设置泄漏检测器（作用于 Db 及其视图）
+ */
+func (db *Db) SetLeakDetector(detector *LeakDetector) *Db {
+	db.root().leakDetector = detector
+	return db
+}
+
+/**
+ * 获取 Db 的泄漏检测器，未设置时为 nil
+ */
+func (db *Db) GetLeakDetector() *LeakDetector {
+	return db.root().leakDetector
+}
+
+func leakMetric(kind string) string {
+	return "leak." + kind
+}
+
+func leakKindName(kind string) string {
+	if kind == LeakKindTransaction {
+		return "事务"
+	}
+	return "连接"
+}
+
+/**
+ * 去掉调用栈中 debug.Stack 与检测器自身的帧，从业务调用开始
+ */
+func trimLeakStack(stack string) string {
+	lines := strings.Split(strings.TrimSpace(stack), "\n")
+	if len(lines) == 0 {
+		return stack
+	}
+	result := []string{lines[0]}
+	skipping := true
+	for i := 1; i+1 < len(lines); i += 2 {
+		function := lines[i]
+		if skipping && (strings.HasPrefix(function, "runtime/debug.") || strings.Contains(function, "db233.(*LeakDetector)")) {
+			continue
+		}
+		skipping = false
+		result = append(result, function, lines[i+1])
+	}
+	return strings.Join(result, "\n")
+}
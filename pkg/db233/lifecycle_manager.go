@@ -0,0 +1,315 @@
+package db233
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/**
+ * LifecycleManager - 后台组件的统一停机编排
+ *
+ * 注册监控、调度、告警等后台组件与 Db / DbGroup，Shutdown(ctx) 按以下顺序停机：
+ *   1. 按注册的逆序停止后台组件（每个组件单独计时，超出 ctx 截止时间记为未按时停止）
+ *   2. 所有 Db 进入排空状态：拒绝新语句，等待执行中的语句完成
+ *   3. 关闭连接池
+ *
+ *   lifecycle := db233.NewLifecycleManager("app")
+ *   lifecycle.Register("metrics", collector)
+ *   lifecycle.Register("dashboard", dashboardServer)
+ *   lifecycle.Register("orders", ordersDbGroup)
+ *
+ *   ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+ *   defer cancel()
+ *   report, err := lifecycle.Shutdown(ctx)   // err 非 nil 时 report 列出未按时停止 / 失败的组件
+ *
+ * 支持的组件：Stop()、Stop() error、Shutdown(ctx) error、func(ctx) error、*ReadWriteRouter、*Db、*DbGroup、*DbRegistry
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type LifecycleManager struct {
+	name       string
+	components []*lifecycleComponent
+	dbs        []*lifecycleDb
+	shutdown   bool
+	mu         sync.Mutex
+}
+
+/**
+ * 已注册的后台组件
+ */
+type lifecycleComponent struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+/**
+ * 已注册的 Db
+ */
+type lifecycleDb struct {
+	name string
+	db   *Db
+}
+
+/**
+ * ShutdownReport - 停机结果
+ */
+type ShutdownReport struct {
+	// 按时停止的组件与 Db
+	Stopped []string
+
+	// 停止时返回错误的组件与 Db
+	Failed map[string]error
+
+	// 超出截止时间仍未停止的组件与 Db
+	TimedOut []string
+
+	Duration time.Duration
+}
+
+/**
+ * 是否全部按时停止
+ */
+func (r *ShutdownReport) IsClean() bool {
+	return len(r.Failed) == 0 && len(r.TimedOut) == 0
+}
+
+/**
+ * 创建停机编排器
+ */
+func NewLifecycleManager(name string) *LifecycleManager {
+	return &LifecycleManager{name: name}
+}
+
+/**
+ * 注册组件（同名组件会各自停止，名称仅用于报告）
+ *
+ * @param name 组件名称
+ * @param component 组件，见类型注释中支持的类型
+ */
+func (lm *LifecycleManager) Register(name string, component interface{}) error {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	switch c := component.(type) {
+	case *Db:
+		lm.dbs = append(lm.dbs, &lifecycleDb{name: name, db: c})
+		return nil
+	case *DbGroup:
+		dbIds := make([]int, 0, len(c.DbMap))
+		for dbId := range c.DbMap {
+			dbIds = append(dbIds, dbId)
+		}
+		sort.Ints(dbIds)
+		for _, dbId := range dbIds {
+			lm.dbs = append(lm.dbs, &lifecycleDb{name: fmt.Sprintf("%s/%d", name, dbId), db: c.DbMap[dbId]})
+		}
+		return nil
+	case *DbRegistry:
+		dbs := c.GetAll()
+		dbNames := make([]string, 0, len(dbs))
+		for dbName := range dbs {
+			dbNames = append(dbNames, dbName)
+		}
+		sort.Strings(dbNames)
+		for _, dbName := range dbNames {
+			lm.dbs = append(lm.dbs, &lifecycleDb{name: fmt.Sprintf("%s/%s", name, dbName), db: dbs[dbName]})
+		}
+		return nil
+	}
+
+	stop, err := lifecycleStopFunc(component)
+	if err != nil {
+		return NewValidationException(fmt.Sprintf("无法注册组件 %s: %v", name, err))
+	}
+	lm.components = append(lm.components, &lifecycleComponent{name: name, stop: stop})
+	return nil
+}
+
+/**
+ * 把组件的停止方法统一为 func(ctx) error
+ */
+func lifecycleStopFunc(component interface{}) (func(ctx context.Context) error, error) {
+	switch c := component.(type) {
+	case func(ctx context.Context) error:
+		return c, nil
+	case interface{ Shutdown(context.Context) error }:
+		return c.Shutdown, nil
+	case *ReadWriteRouter:
+		return func(ctx context.Context) error {
+			c.StopLagMonitor()
+			return nil
+		}, nil
+	case interface{ Stop() error }:
+		return func(ctx context.Context) error { return c.Stop() }, nil
+	case interface{ Stop() }:
+		return func(ctx context.Context) error {
+			c.Stop()
+			return nil
+		}, nil
+	}
+	return nil, fmt.Errorf("不支持的组件类型 %T", component)
+}
+
+/**
+ * 停机（只执行一次，重复调用返回错误）
+ */
+func (lm *LifecycleManager) Shutdown(ctx context.Context) (*ShutdownReport, error) {
+	lm.mu.Lock()
+	if lm.shutdown {
+		lm.mu.Unlock()
+		return nil, NewValidationException(fmt.Sprintf("停机编排器 %s 已停机", lm.name))
+	}
+	lm.shutdown = true
+	components := append([]*lifecycleComponent(nil), lm.components...)
+	dbs := append([]*lifecycleDb(nil), lm.dbs...)
+	lm.mu.Unlock()
+
+	start := time.Now()
+	report := &ShutdownReport{Failed: make(map[string]error)}
+	LogInfo("开始停机: %s, 组件=%d, Db=%d", lm.name, len(components), len(dbs))
+
+	// 1. 逆序停止后台组件
+	for i := len(components) - 1; i >= 0; i-- {
+		component := components[i]
+		runWithDeadline(ctx, report, component.name, component.stop)
+	}
+
+	// 2. 并发排空所有 Db，3. 排空后关闭连接池
+	var wg sync.WaitGroup
+	var reportMu sync.Mutex
+	for _, entry := range dbs {
+		wg.Add(1)
+		go func(entry *lifecycleDb) {
+			defer wg.Done()
+			dbReport := &ShutdownReport{Failed: make(map[string]error)}
+			runWithDeadline(ctx, dbReport, entry.name, func(ctx context.Context) error {
+				if err := entry.db.Drain(ctx); err != nil {
+					return err
+				}
+				if entry.db.DataSource == nil {
+					return nil
+				}
+				return entry.db.Close()
+			})
+			reportMu.Lock()
+			report.merge(dbReport)
+			reportMu.Unlock()
+		}(entry)
+	}
+	wg.Wait()
+
+	report.Duration = time.Since(start)
+	if report.IsClean() {
+		LogInfo("停机完成: %s, 耗时=%v", lm.name, report.Duration)
+		return report, nil
+	}
+	failed := make([]string, 0, len(report.Failed))
+	for name := range report.Failed {
+		failed = append(failed, name)
+	}
+	sort.Strings(failed)
+	LogWarn("停机未完全完成: %s, 失败=%v, 超时=%v", lm.name, failed, report.TimedOut)
+	return report, NewDb233ExceptionWithCode("SHUTDOWN_INCOMPLETE", fmt.Sprintf("停机未完全完成: 失败=[%s], 超时=[%s]", strings.Join(failed, ", "), strings.Join(report.TimedOut, ", ")))
+}
+
+/**
+ * 在截止时间内执行停止函数，超时则记录并继续（停止函数仍在后台运行）；
+ * 截止时间已过时仍尽力调用停止函数，但直接记为未按时停止
+ */
+func runWithDeadline(ctx context.Context, report *ShutdownReport, name string, stop func(ctx context.Context) error) {
+	if ctx.Err() != nil {
+		go func() {
+			defer func() { recover() }()
+			stop(ctx)
+		}()
+		report.TimedOut = append(report.TimedOut, name)
+		LogWarn("已超过停机截止时间，组件在后台尽力停止: %s", name)
+		return
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("停止时发生 panic: %v", r)
+			}
+		}()
+		done <- stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			report.Failed[name] = err
+			LogWarn("组件停止失败: %s, 错误=%v", name, err)
+			return
+		}
+		report.Stopped = append(report.Stopped, name)
+	case <-ctx.Done():
+		report.TimedOut = append(report.TimedOut, name)
+		LogWarn("组件未在截止时间内停止: %s", name)
+	}
+}
+
+func (r *ShutdownReport) merge(other *ShutdownReport) {
+	r.Stopped = append(r.Stopped, other.Stopped...)
+	r.TimedOut = append(r.TimedOut, other.TimedOut...)
+	for name, err := range other.Failed {
+		r.Failed[name] = err
+	}
+}
+
+/**
+ * 开始执行一条语句，排空中返回错误
+ */
+func (db *Db) beginStatement() (func(), error) {
+	root := db.root()
+	// 先计数再检查，保证 Drain 看到的计数包含所有已放行的语句
+	atomic.AddInt64(&root.inFlight, 1)
+	if atomic.LoadInt32(&root.draining) == 1 {
+		atomic.AddInt64(&root.inFlight, -1)
+		return nil, NewConnectionException(fmt.Sprintf("数据库正在停机，拒绝新语句: DbId=%d", root.DbId))
+	}
+	return func() { atomic.AddInt64(&root.inFlight, -1) }, nil
+}
+
+/**
+ * 排空：拒绝新语句，等待执行中的语句完成（ctx 结束时返回 ctx 的错误）
+ *
+ * 只统计经过 Db 执行的语句，TransactionManager 与直接使用 DataSource 的语句不在统计内
+ */
+func (db *Db) Drain(ctx context.Context) error {
+	root := db.root()
+	atomic.StoreInt32(&root.draining, 1)
+
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+	for atomic.LoadInt64(&root.inFlight) > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("仍有 %d 条语句在执行: %w", atomic.LoadInt64(&root.inFlight), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+/**
+ * 是否处于排空状态
+ */
+func (db *Db) IsDraining() bool {
+	return atomic.LoadInt32(&db.root().draining) == 1
+}
+
+/**
+ * 执行中的语句数
+ */
+func (db *Db) GetInFlightStatements() int64 {
+	return atomic.LoadInt64(&db.root().inFlight)
+}
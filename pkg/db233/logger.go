@@ -9,7 +9,8 @@ import (
 /**
  * Logger - 日志记录器
  *
- * 提供统一的日志记录功能，支持不同级别的日志输出
+ * 提供统一的日志记录功能，支持不同级别的日志输出；
+ * 同时是 StructuredLogger 的默认实现，可用 SetStructuredLogger 替换为 slog 等
  *
  * @author SolarisNeko
  * @since 2025-12-29
@@ -113,53 +114,51 @@ func (l *Logger) Fatal(format string, args ...interface{}) {
  * 内部日志记录方法
  */
 func (l *Logger) log(level LogLevel, format string, args ...interface{}) {
-	if level < l.level {
+	if !l.Enabled(level) {
 		return
 	}
-
-	levelName := logLevelNames[level]
-	message := fmt.Sprintf(format, args...)
-	l.logger.Printf("[%s] %s", levelName, message)
+	l.Log(level, fmt.Sprintf(format, args...))
 }
 
 /**
- * 便捷方法：记录 TRACE 级别日志到默认记录器
+ * 便捷方法：记录 TRACE 级别日志（经由 StructuredLogger 输出，默认为该记录器）
  */
 func LogTrace(format string, args ...interface{}) {
-	defaultLogger.Trace(format, args...)
+	logFormatted(TRACE, format, args...)
 }
 
 /**
- * 便捷方法：记录 DEBUG 级别日志到默认记录器
+ * 便捷方法：记录 DEBUG 级别日志
  */
 func LogDebug(format string, args ...interface{}) {
-	defaultLogger.Debug(format, args...)
+	logFormatted(DEBUG, format, args...)
 }
 
 /**
- * 便捷方法：记录 INFO 级别日志到默认记录器
+ * 便捷方法：记录 INFO 级别日志
  */
 func LogInfo(format string, args ...interface{}) {
-	defaultLogger.Info(format, args...)
+	logFormatted(INFO, format, args...)
 }
 
 /**
- * 便捷方法：记录 WARN 级别日志到默认记录器
+ * 便捷方法：记录 WARN 级别日志
  */
 func LogWarn(format string, args ...interface{}) {
-	defaultLogger.Warn(format, args...)
+	logFormatted(WARN, format, args...)
 }
 
 /**
- * 便捷方法：记录 ERROR 级别日志到默认记录器
+ * 便捷方法：记录 ERROR 级别日志
  */
 func LogError(format string, args ...interface{}) {
-	defaultLogger.Error(format, args...)
+	logFormatted(ERROR, format, args...)
 }
 
 /**
- * 便捷方法：记录 FATAL 级别日志到默认记录器
+ * 便捷方法：记录 FATAL 级别日志
  */
 func LogFatal(format string, args ...interface{}) {
-	defaultLogger.Fatal(format, args...)
+	logFormatted(FATAL, format, args...)
+	os.Exit(1)
 }
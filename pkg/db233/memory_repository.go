@@ -0,0 +1,666 @@
+package db233
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * MemoryRepository - 内存版 CrudRepository
+ *
+ * 按实体元数据中的主键把实体保存在内存 map 中，业务服务依赖 CrudRepository 接口时，
+ * 单元测试可直接注入，无需启动 MySQL：
+ *
+ *   repo := db233.NewMemoryRepository()
+ *   service := NewPlayerService(repo)
+ *
+ * 与 BaseCrudRepository 一致：Save 按主键 upsert，自增主键为零值时自动分配，
+ * 调用 BeforeSave / AfterSave / AfterFind 钩子与 validate 校验。存取时复制实体，
+ * 调用方修改返回值不会影响已保存的数据。
+ *
+ * FindByCondition 支持由 AND 连接的简单条件：col = ?、!= / <>、> / >= / < / <=、LIKE ?、
+ * IN (?, ...)、IS [NOT] NULL；更复杂的条件通过 SetConditionMatcher 自定义
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	tables map[string]*memoryTable
+
+	// 复用字段扫描、主键处理等工具方法（不访问数据库）
+	helper *BaseCrudRepository
+
+	conditionMatcher MemoryConditionMatcher
+}
+
+/**
+ * MemoryConditionMatcher - 自定义条件匹配，handled 为 false 时使用内置解析
+ */
+type MemoryConditionMatcher func(condition string, params []interface{}, fields map[string]interface{}) (matched bool, handled bool, err error)
+
+/**
+ * 内存表
+ */
+type memoryTable struct {
+	rows   map[string]IDbEntity
+	order  []string
+	nextId int64
+}
+
+var _ CrudRepository = (*MemoryRepository)(nil)
+
+/**
+ * 创建内存存储库
+ */
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		tables: make(map[string]*memoryTable),
+		helper: NewBaseCrudRepository(nil),
+	}
+}
+
+/**
+ * 设置自定义条件匹配
+ */
+func (m *MemoryRepository) SetConditionMatcher(matcher MemoryConditionMatcher) {
+	m.conditionMatcher = matcher
+}
+
+/**
+ * 内存存储库没有数据源，返回 nil
+ */
+func (m *MemoryRepository) GetBindingDataSource() *sql.DB {
+	return nil
+}
+
+/**
+ * 内存存储库没有数据库实例，返回 nil
+ */
+func (m *MemoryRepository) GetDb() *Db {
+	return nil
+}
+
+/**
+ * 保存实体（按主键 upsert）
+ */
+func (m *MemoryRepository) Save(entity IDbEntity) error {
+	if entity == nil {
+		return NewValidationException("实体不能为 nil")
+	}
+	if err := m.helper.callBeforeSave(entity); err != nil {
+		return err
+	}
+	entity.SerializeBeforeSaveDb()
+	if err := GetKeyGeneratorRegistryInstance().PopulateKeys(entity); err != nil {
+		return err
+	}
+	if err := GetValidatorInstance().Validate(entity); err != nil {
+		return err
+	}
+
+	tableName := m.helper.getTableName(entity)
+	if tableName == "" {
+		return NewValidationException("无法获取表名，请确保实体实现了 TableName() 方法并返回非空字符串")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	table := m.table(tableName, true)
+
+	pkColumns := GetCrudManagerInstance().GetPrimaryKeyColumnNames(entity)
+	fields := m.helper.getFields(entity)
+	for _, pkColumn := range pkColumns {
+		if !m.helper.isZeroValue(fields[pkColumn]) {
+			continue
+		}
+		if !m.helper.isAutoIncrementPrimaryKey(entity, pkColumn) {
+			return NewValidationException(fmt.Sprintf("主键字段 %s 不能为零值（0 或空字符串），请设置有效的主键值", pkColumn))
+		}
+		table.nextId++
+		m.helper.setPrimaryKeyValue(entity, table.nextId)
+		fields = m.helper.getFields(entity)
+	}
+
+	key, err := m.keyOf(pkColumns, fields)
+	if err != nil {
+		return err
+	}
+	// 显式指定的自增主键也推进计数，避免之后分配到重复值
+	if len(pkColumns) == 1 {
+		if id, ok := toInt64(fields[pkColumns[0]]); ok && id > table.nextId {
+			table.nextId = id
+		}
+	}
+
+	stored, err := CloneEntity(entity)
+	if err != nil {
+		return err
+	}
+	if _, exists := table.rows[key]; !exists {
+		table.order = append(table.order, key)
+	}
+	table.rows[key] = stored
+
+	m.helper.callAfterSave(entity)
+	return nil
+}
+
+/**
+ * 批量保存
+ */
+func (m *MemoryRepository) SaveBatch(entities []IDbEntity) error {
+	if len(entities) == 0 {
+		return NewValidationException("实体列表不能为空")
+	}
+	for i, entity := range entities {
+		if err := m.Save(entity); err != nil {
+			return NewQueryExceptionWithCause(err, fmt.Sprintf("批量保存失败，第 %d 条记录保存失败", i+1))
+		}
+	}
+	return nil
+}
+
+/**
+ * 按主键删除（记录不存在时不报错）
+ */
+func (m *MemoryRepository) DeleteById(id interface{}, entityType IDbEntity) error {
+	if entityType == nil {
+		return NewValidationException("实体类型不能为 nil")
+	}
+	if id == nil {
+		return NewValidationException("删除ID不能为 nil")
+	}
+	key, err := m.keyOfId(id, entityType)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := entityType.(BeforeDeleteHook); ok {
+		current, err := m.FindById(id, entityType)
+		if err != nil {
+			return err
+		}
+		if hook, ok := current.(BeforeDeleteHook); ok {
+			if err := hook.BeforeDelete(m.helper.GetContext()); err != nil {
+				return err
+			}
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	table := m.table(m.helper.getTableName(entityType), false)
+	if _, exists := table.rows[key]; !exists {
+		return nil
+	}
+	delete(table.rows, key)
+	for i, existing := range table.order {
+		if existing == key {
+			table.order = append(table.order[:i], table.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+/**
+ * 按主键查找，不存在时返回 nil, nil
+ */
+func (m *MemoryRepository) FindById(id interface{}, entityType IDbEntity) (IDbEntity, error) {
+	if entityType == nil {
+		return nil, NewValidationException("实体类型不能为 nil")
+	}
+	if id == nil {
+		return nil, NewValidationException("查询ID不能为 nil")
+	}
+	key, err := m.keyOfId(id, entityType)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	stored, exists := m.table(m.helper.getTableName(entityType), false).rows[key]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, nil
+	}
+	return m.load(stored)
+}
+
+/**
+ * 查找全部（按首次保存顺序）
+ */
+func (m *MemoryRepository) FindAll(entityType IDbEntity) ([]IDbEntity, error) {
+	return m.FindByCondition("", nil, entityType)
+}
+
+/**
+ * 按条件查找（按首次保存顺序）
+ */
+func (m *MemoryRepository) FindByCondition(condition string, params []interface{}, entityType IDbEntity) ([]IDbEntity, error) {
+	if entityType == nil {
+		return nil, NewValidationException("实体类型不能为 nil")
+	}
+
+	m.mu.RLock()
+	table := m.table(m.helper.getTableName(entityType), false)
+	candidates := make([]IDbEntity, 0, len(table.order))
+	for _, key := range table.order {
+		candidates = append(candidates, table.rows[key])
+	}
+	m.mu.RUnlock()
+
+	results := make([]IDbEntity, 0, len(candidates))
+	for _, stored := range candidates {
+		matched, err := m.matches(condition, params, m.helper.getFields(stored))
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+		entity, err := m.load(stored)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, entity)
+	}
+	return results, nil
+}
+
+/**
+ * 按主键更新（记录不存在时不报错，与 BaseCrudRepository 一致）
+ */
+func (m *MemoryRepository) Update(entity IDbEntity) error {
+	if entity == nil {
+		return NewValidationException("实体不能为 nil")
+	}
+	pkColumns := GetCrudManagerInstance().GetPrimaryKeyColumnNames(entity)
+	key, err := m.keyOf(pkColumns, m.helper.getFields(entity))
+	if err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	_, exists := m.table(m.helper.getTableName(entity), false).rows[key]
+	m.mu.RUnlock()
+	if !exists {
+		LogWarn("更新无影响: 实体=%T, 主键=%s, 可能记录不存在", entity, key)
+		return nil
+	}
+	return m.Save(entity)
+}
+
+/**
+ * 批量更新
+ */
+func (m *MemoryRepository) UpdateBatch(entities []IDbEntity) error {
+	if len(entities) == 0 {
+		return NewValidationException("实体列表不能为空")
+	}
+	for i, entity := range entities {
+		if entity == nil {
+			continue
+		}
+		if err := m.Update(entity); err != nil {
+			return NewQueryExceptionWithCause(err, fmt.Sprintf("批量更新失败，第 %d 条记录更新失败", i+1))
+		}
+	}
+	return nil
+}
+
+/**
+ * 统计记录数
+ */
+func (m *MemoryRepository) Count(entityType IDbEntity) (int64, error) {
+	if entityType == nil {
+		return 0, NewValidationException("实体类型不能为 nil")
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return int64(len(m.table(m.helper.getTableName(entityType), false).rows)), nil
+}
+
+/**
+ * 清空所有数据
+ */
+func (m *MemoryRepository) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tables = make(map[string]*memoryTable)
+}
+
+/**
+ * 获取内存表，调用方需持有锁；create 为 false 且表不存在时返回空表（不写入，可在读锁下调用）
+ */
+func (m *MemoryRepository) table(tableName string, create bool) *memoryTable {
+	if table, exists := m.tables[tableName]; exists {
+		return table
+	}
+	table := &memoryTable{rows: make(map[string]IDbEntity)}
+	if create {
+		m.tables[tableName] = table
+	}
+	return table
+}
+
+/**
+ * 复制已保存的实体并调用加载钩子
+ */
+func (m *MemoryRepository) load(stored IDbEntity) (IDbEntity, error) {
+	entity, err := CloneEntity(stored)
+	if err != nil {
+		return nil, err
+	}
+	entity.DeserializeAfterLoadDb()
+	m.helper.callAfterFind(entity)
+	return entity, nil
+}
+
+/**
+ * 由主键列取值生成 map 键（值按 fmt 格式化，int 与 int64 的同一主键得到相同的键）
+ */
+func (m *MemoryRepository) keyOf(pkColumns []string, values map[string]interface{}) (string, error) {
+	if len(pkColumns) == 0 {
+		return "", NewValidationException("实体没有主键，无法保存到内存存储库")
+	}
+	parts := make([]string, len(pkColumns))
+	for i, pkColumn := range pkColumns {
+		value, exists := values[pkColumn]
+		if !exists {
+			return "", NewValidationException(fmt.Sprintf("主键缺少列 %s", pkColumn))
+		}
+		parts[i] = fmt.Sprintf("%v", reflect.Indirect(reflect.ValueOf(value)).Interface())
+	}
+	return strings.Join(parts, "\x00"), nil
+}
+
+/**
+ * 由 FindById / DeleteById 的 id 参数生成 map 键（支持复合主键的 map / 结构体）
+ */
+func (m *MemoryRepository) keyOfId(id interface{}, entityType IDbEntity) (string, error) {
+	pkColumns := GetCrudManagerInstance().GetPrimaryKeyColumnNames(entityType)
+	values := m.helper.extractKeyValues(id)
+	if values == nil {
+		if len(pkColumns) > 1 {
+			return "", NewValidationException(fmt.Sprintf("实体 %T 为复合主键 %v，主键需传入 map[string]interface{} 或结构体", entityType, pkColumns))
+		}
+		values = map[string]interface{}{}
+		if len(pkColumns) == 1 {
+			values[pkColumns[0]] = id
+		}
+	}
+	return m.keyOf(pkColumns, values)
+}
+
+/**
+ * 判断记录是否满足条件
+ */
+func (m *MemoryRepository) matches(condition string, params []interface{}, fields map[string]interface{}) (bool, error) {
+	condition = strings.TrimSpace(condition)
+	if condition == "" {
+		return true, nil
+	}
+	if m.conditionMatcher != nil {
+		matched, handled, err := m.conditionMatcher(condition, params, fields)
+		if handled || err != nil {
+			return matched, err
+		}
+	}
+
+	predicates := memoryAndSplitter.Split(trimOuterParentheses(condition), -1)
+	paramIndex := 0
+	for _, predicate := range predicates {
+		var remaining []interface{}
+		if paramIndex < len(params) {
+			remaining = params[paramIndex:]
+		}
+		matched, used, err := matchMemoryPredicate(strings.TrimSpace(predicate), remaining, fields)
+		if err != nil {
+			return false, err
+		}
+		paramIndex += used
+		if !matched {
+			return false, nil
+		}
+	}
+	if paramIndex != len(params) {
+		return false, NewValidationException(fmt.Sprintf("条件占位符数量与参数数量不一致: %s", condition))
+	}
+	return true, nil
+}
+
+var (
+	memoryAndSplitter    = regexp.MustCompile(`(?i)\s+AND\s+`)
+	memoryComparePattern = regexp.MustCompile(`(?i)^(\w+)\s*(=|!=|<>|>=|<=|>|<|LIKE|NOT LIKE)\s*\?$`)
+	memoryInPattern      = regexp.MustCompile(`(?i)^(\w+)\s+(NOT\s+)?IN\s*\(([?,\s]+)\)$`)
+	memoryNullPattern    = regexp.MustCompile(`(?i)^(\w+)\s+IS\s+(NOT\s+)?NULL$`)
+	memoryTruePattern    = regexp.MustCompile(`^1\s*=\s*1$`)
+)
+
+/**
+ * 匹配单个谓词，返回是否满足与消耗的参数数
+ */
+func matchMemoryPredicate(predicate string, params []interface{}, fields map[string]interface{}) (bool, int, error) {
+	predicate = trimOuterParentheses(predicate)
+	if memoryTruePattern.MatchString(predicate) {
+		return true, 0, nil
+	}
+
+	if match := memoryNullPattern.FindStringSubmatch(predicate); match != nil {
+		value, err := memoryFieldValue(fields, match[1])
+		if err != nil {
+			return false, 0, err
+		}
+		isNull := isNilValue(value)
+		return isNull == (match[2] == ""), 0, nil
+	}
+
+	if match := memoryInPattern.FindStringSubmatch(predicate); match != nil {
+		count := strings.Count(match[3], "?")
+		if count > len(params) {
+			return false, 0, NewValidationException("条件参数不足: " + predicate)
+		}
+		value, err := memoryFieldValue(fields, match[1])
+		if err != nil {
+			return false, 0, err
+		}
+		found := false
+		for _, param := range params[:count] {
+			if compareMemoryValues(value, param) == 0 {
+				found = true
+				break
+			}
+		}
+		return found == (match[2] == ""), count, nil
+	}
+
+	if match := memoryComparePattern.FindStringSubmatch(predicate); match != nil {
+		if len(params) == 0 {
+			return false, 0, NewValidationException("条件参数不足: " + predicate)
+		}
+		value, err := memoryFieldValue(fields, match[1])
+		if err != nil {
+			return false, 0, err
+		}
+		param := params[0]
+		operator := strings.ToUpper(strings.Join(strings.Fields(match[2]), " "))
+		if operator == "LIKE" || operator == "NOT LIKE" {
+			matched := likeToRegexp(fmt.Sprint(param)).MatchString(fmt.Sprint(value))
+			return matched == (operator == "LIKE"), 1, nil
+		}
+		if isNilValue(value) || isNilValue(param) {
+			return false, 1, nil
+		}
+		cmp := compareMemoryValues(value, param)
+		switch operator {
+		case "=":
+			return cmp == 0, 1, nil
+		case "!=", "<>":
+			return cmp != 0, 1, nil
+		case ">":
+			return cmp > 0, 1, nil
+		case ">=":
+			return cmp >= 0, 1, nil
+		case "<":
+			return cmp < 0, 1, nil
+		case "<=":
+			return cmp <= 0, 1, nil
+		}
+	}
+
+	return false, 0, NewValidationException(fmt.Sprintf("内存存储库不支持的条件: %s（可通过 SetConditionMatcher 自定义）", predicate))
+}
+
+/**
+ * 取字段值（列不存在时报错，避免拼写错误被当成不匹配）
+ */
+func memoryFieldValue(fields map[string]interface{}, column string) (interface{}, error) {
+	for name, value := range fields {
+		if strings.EqualFold(name, column) {
+			return value, nil
+		}
+	}
+	return nil, NewValidationException("内存存储库条件中的列不存在: " + column)
+}
+
+/**
+ * 比较两个值：数值按 float64，时间按先后，其余按字符串
+ */
+func compareMemoryValues(a interface{}, b interface{}) int {
+	a, b = derefValue(a), derefValue(b)
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Compare(tb)
+		}
+	}
+	if fa, ok := toFloat64(a); ok {
+		if fb, ok := toFloat64(b); ok {
+			switch {
+			case fa < fb:
+				return -1
+			case fa > fb:
+				return 1
+			}
+			return 0
+		}
+	}
+	if ba, ok := a.(bool); ok {
+		if bb, ok := b.(bool); ok {
+			if ba == bb {
+				return 0
+			}
+			if !ba {
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+/**
+ * 解引用指针，nil 指针返回 nil
+ */
+func derefValue(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	for v.IsValid() && v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+/**
+ * 是否为 nil（含 nil 指针）
+ */
+func isNilValue(value interface{}) bool {
+	return derefValue(value) == nil
+}
+
+/**
+ * 数值转换为 float64
+ */
+func toFloat64(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+/**
+ * 整数转换为 int64
+ */
+func toInt64(value interface{}) (int64, bool) {
+	v := reflect.ValueOf(derefValue(value))
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), true
+	}
+	return 0, false
+}
+
+/**
+ * LIKE 模式转换为正则（% 任意字符串，_ 单个字符）
+ */
+func likeToRegexp(pattern string) *regexp.Regexp {
+	var builder strings.Builder
+	builder.WriteString("(?is)^")
+	for _, c := range pattern {
+		switch c {
+		case '%':
+			builder.WriteString(".*")
+		case '_':
+			builder.WriteString(".")
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	builder.WriteString("$")
+	return regexp.MustCompile(builder.String())
+}
+
+/**
+ * 去掉包裹整个表达式的括号
+ */
+func trimOuterParentheses(expression string) string {
+	for strings.HasPrefix(expression, "(") && strings.HasSuffix(expression, ")") {
+		depth := 0
+		wrapsAll := true
+		for i, c := range expression {
+			if c == '(' {
+				depth++
+			} else if c == ')' {
+				depth--
+				if depth == 0 && i != len(expression)-1 {
+					wrapsAll = false
+					break
+				}
+			}
+		}
+		if !wrapsAll {
+			break
+		}
+		expression = strings.TrimSpace(expression[1 : len(expression)-1])
+	}
+	return expression
+}
@@ -28,6 +28,9 @@ type MetricsAggregator struct {
 	// 聚合配置
 	aggregationRules map[string]AggregationRule
 
+	// SQL 指纹异常检测，未启用时为 nil
+	anomalyDetector *queryAnomalyDetector
+
 	// 锁
 	mu sync.RWMutex
 
@@ -386,7 +389,7 @@ func (ma *MetricsAggregator) GetStatus() map[string]interface{} {
 	ma.mu.RLock()
 	defer ma.mu.RUnlock()
 
-	return map[string]interface{}{
+	status := map[string]interface{}{
 		"name":              ma.name,
 		"enabled":           ma.enabled,
 		"data_sources":      len(ma.dataSources),
@@ -395,6 +398,11 @@ func (ma *MetricsAggregator) GetStatus() map[string]interface{} {
 		"cache_duration":    ma.cacheDuration.String(),
 		"last_aggregation":  ma.lastAggregation,
 	}
+	if ma.anomalyDetector != nil {
+		status["anomaly_fingerprints"] = len(ma.anomalyDetector.fingerprints)
+		status["query_anomalies"] = len(ma.anomalyDetector.anomalies)
+	}
+	return status
 }
 
 /**
@@ -432,6 +440,11 @@ func (ma *MetricsAggregator) Reset() {
 
 	ma.aggregatedMetrics = make(map[string]AggregatedMetric)
 	ma.lastAggregation = time.Now().Add(-time.Hour)
+	if ma.anomalyDetector != nil {
+		ma.anomalyDetector.fingerprints = make(map[string]*queryFingerprintState)
+		ma.anomalyDetector.anomalies = nil
+		ma.anomalyDetector.windowStart = time.Now()
+	}
 
 	LogInfo("指标聚合器已重置: %s", ma.name)
 }
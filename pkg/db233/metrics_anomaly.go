@@ -0,0 +1,431 @@
+package db233
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"time"
+)
+
+/**
+ * 按 SQL 指纹的异常检测
+ *
+ * 聚合器按时间窗口统计每个 SQL 指纹（见 SqlFingerprint）的平均延迟与 QPS，用指数加权均值 / 方差学习基线，
+ * 当前窗口偏离基线超过 Sigma 个标准差且相对偏离超过 MinDeviationRatio 时向 AlertManager 报告，
+ * 捕获静态阈值发现不了的回归（如某条原本 2ms 的查询变成 20ms）：
+ *
+ *   aggregator.EnableQueryAnomalyDetection(alertManager, db233.DefaultQueryAnomalyConfig())
+ *   db.AddPlugin(db233.NewQueryAnomalyPlugin(aggregator), 0) // 或手动调用 aggregator.ObserveQuery
+ *
+ * 每个指纹按需生成两条告警规则 sql_anomaly_latency_<hash> / sql_anomaly_qps_<hash>，
+ * 送入的指标值为偏离的标准差倍数，恢复正常后自动解决。异常窗口不计入基线，回归持续期间会一直告警
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type QueryAnomalyConfig struct {
+	// 统计窗口
+	Window time.Duration
+
+	// 偏离基线的标准差倍数
+	Sigma float64
+
+	// 相对基线均值的最小偏离比例（0.5 表示 50%），避免基线很平稳时微小波动也告警
+	MinDeviationRatio float64
+
+	// 基线至少学习的窗口数，之前不检测
+	MinBaselineWindows int
+
+	// 窗口内至少的执行次数，少于时不检测延迟
+	MinQueriesPerWindow int
+
+	// 指数加权系数（0, 1]，越大越偏向最近的窗口
+	Alpha float64
+
+	// 跟踪的指纹数上限，超出后新指纹不再跟踪
+	MaxFingerprints int
+
+	// 告警严重程度
+	Severity AlertSeverity
+}
+
+/**
+ * QueryBaseline - 指纹基线
+ */
+type QueryBaseline struct {
+	Fingerprint   string
+	Windows       int
+	LatencyMeanMs float64
+	LatencyStdMs  float64
+	QpsMean       float64
+	QpsStd        float64
+}
+
+/**
+ * QueryAnomaly - 检测到的异常
+ */
+type QueryAnomaly struct {
+	Fingerprint string
+
+	// latency 或 qps
+	Kind string
+
+	Current  float64
+	Baseline float64
+	Sigma    float64
+}
+
+/**
+ * 默认配置：1 分钟窗口，3 sigma 且偏离 50%，学习 10 个窗口后开始检测
+ */
+func DefaultQueryAnomalyConfig() QueryAnomalyConfig {
+	return QueryAnomalyConfig{
+		Window:              time.Minute,
+		Sigma:               3,
+		MinDeviationRatio:   0.5,
+		MinBaselineWindows:  10,
+		MinQueriesPerWindow: 5,
+		Alpha:               0.1,
+		MaxFingerprints:     200,
+		Severity:            Warning,
+	}
+}
+
+/**
+ * 指数加权均值与方差
+ */
+type ewmaStats struct {
+	mean     float64
+	variance float64
+	count    int
+}
+
+func (s *ewmaStats) add(value float64, alpha float64) {
+	if s.count == 0 {
+		s.mean = value
+		s.variance = 0
+	} else {
+		diff := value - s.mean
+		increment := alpha * diff
+		s.mean += increment
+		s.variance = (1 - alpha) * (s.variance + diff*increment)
+	}
+	s.count++
+}
+
+func (s *ewmaStats) std() float64 {
+	return math.Sqrt(s.variance)
+}
+
+/**
+ * 偏离的标准差倍数（标准差为 0 时任何偏离都视为无穷大，由相对偏离比例把关）
+ */
+func (s *ewmaStats) deviation(value float64) (sigma float64, ratio float64) {
+	diff := math.Abs(value - s.mean)
+	if s.mean != 0 {
+		ratio = diff / math.Abs(s.mean)
+	} else if diff > 0 {
+		ratio = math.Inf(1)
+	}
+	if std := s.std(); std > 0 {
+		return diff / std, ratio
+	}
+	if diff > 0 {
+		return math.Inf(1), ratio
+	}
+	return 0, ratio
+}
+
+/**
+ * 指纹状态
+ */
+type queryFingerprintState struct {
+	fingerprint string
+	key         string
+
+	// 当前窗口
+	count        int64
+	totalLatency time.Duration
+
+	latency    ewmaStats
+	qps        ewmaStats
+	rulesAdded bool
+}
+
+/**
+ * 异常检测器（MetricsAggregator 内部状态）
+ */
+type queryAnomalyDetector struct {
+	config       QueryAnomalyConfig
+	alertManager *AlertManager
+	fingerprints map[string]*queryFingerprintState
+	windowStart  time.Time
+	anomalies    []QueryAnomaly
+}
+
+/**
+ * 启用按 SQL 指纹的异常检测（零值字段使用默认配置）
+ */
+func (ma *MetricsAggregator) EnableQueryAnomalyDetection(alertManager *AlertManager, config QueryAnomalyConfig) *MetricsAggregator {
+	defaults := DefaultQueryAnomalyConfig()
+	if config.Window <= 0 {
+		config.Window = defaults.Window
+	}
+	if config.Sigma <= 0 {
+		config.Sigma = defaults.Sigma
+	}
+	if config.MinDeviationRatio <= 0 {
+		config.MinDeviationRatio = defaults.MinDeviationRatio
+	}
+	if config.MinBaselineWindows <= 0 {
+		config.MinBaselineWindows = defaults.MinBaselineWindows
+	}
+	if config.MinQueriesPerWindow <= 0 {
+		config.MinQueriesPerWindow = defaults.MinQueriesPerWindow
+	}
+	if config.Alpha <= 0 || config.Alpha > 1 {
+		config.Alpha = defaults.Alpha
+	}
+	if config.MaxFingerprints <= 0 {
+		config.MaxFingerprints = defaults.MaxFingerprints
+	}
+
+	ma.mu.Lock()
+	defer ma.mu.Unlock()
+	ma.anomalyDetector = &queryAnomalyDetector{
+		config:       config,
+		alertManager: alertManager,
+		fingerprints: make(map[string]*queryFingerprintState),
+		windowStart:  time.Now(),
+	}
+	LogInfo("SQL 指纹异常检测已启用: %s, 窗口=%v, sigma=%.1f", ma.name, config.Window, config.Sigma)
+	return ma
+}
+
+/**
+ * 记录一次语句执行（窗口结束时自动评估）
+ */
+func (ma *MetricsAggregator) ObserveQuery(sql string, duration time.Duration) {
+	fingerprint := SqlFingerprint(sql)
+	now := time.Now()
+
+	ma.mu.Lock()
+	detector := ma.anomalyDetector
+	if detector == nil {
+		ma.mu.Unlock()
+		return
+	}
+	state, exists := detector.fingerprints[fingerprint]
+	if !exists && len(detector.fingerprints) < detector.config.MaxFingerprints {
+		state = &queryFingerprintState{fingerprint: fingerprint, key: queryFingerprintKey(fingerprint)}
+		detector.fingerprints[fingerprint] = state
+	}
+	if state != nil {
+		state.count++
+		state.totalLatency += duration
+	}
+	windowEnded := now.Sub(detector.windowStart) >= detector.config.Window
+	ma.mu.Unlock()
+
+	if windowEnded {
+		ma.EvaluateQueryAnomalies(now)
+	}
+}
+
+/**
+ * 结束当前窗口：与基线比较、更新基线并向 AlertManager 报告，返回本窗口检测到的异常
+ */
+func (ma *MetricsAggregator) EvaluateQueryAnomalies(now time.Time) []QueryAnomaly {
+	type check struct {
+		metric string
+		value  float64
+	}
+	checks := make([]check, 0)
+	anomalies := make([]QueryAnomaly, 0)
+
+	ma.mu.Lock()
+	detector := ma.anomalyDetector
+	if detector == nil {
+		ma.mu.Unlock()
+		return anomalies
+	}
+	config := detector.config
+	elapsed := now.Sub(detector.windowStart).Seconds()
+	if elapsed <= 0 {
+		elapsed = config.Window.Seconds()
+	}
+	detector.windowStart = now
+
+	for _, state := range detector.fingerprints {
+		qps := float64(state.count) / elapsed
+		hasLatency := state.count >= int64(config.MinQueriesPerWindow)
+		latencyMs := 0.0
+		if state.count > 0 {
+			latencyMs = float64(state.totalLatency) / float64(state.count) / float64(time.Millisecond)
+		}
+		state.count = 0
+		state.totalLatency = 0
+
+		// 基线学习完成前只学习
+		if state.qps.count < config.MinBaselineWindows {
+			state.qps.add(qps, config.Alpha)
+			if hasLatency {
+				state.latency.add(latencyMs, config.Alpha)
+			}
+			continue
+		}
+		if !state.rulesAdded && detector.alertManager != nil {
+			ma.addQueryAnomalyRules(detector, state)
+		}
+
+		if hasLatency && state.latency.count >= config.MinBaselineWindows {
+			sigma, ratio := state.latency.deviation(latencyMs)
+			if sigma >= config.Sigma && ratio >= config.MinDeviationRatio {
+				anomalies = append(anomalies, QueryAnomaly{Fingerprint: state.fingerprint, Kind: "latency", Current: latencyMs, Baseline: state.latency.mean, Sigma: sigma})
+				checks = append(checks, check{queryAnomalyMetric(state.key, "latency"), finiteSigma(sigma)})
+			} else {
+				state.latency.add(latencyMs, config.Alpha)
+				checks = append(checks, check{queryAnomalyMetric(state.key, "latency"), 0})
+			}
+		} else if hasLatency {
+			state.latency.add(latencyMs, config.Alpha)
+		}
+
+		sigma, ratio := state.qps.deviation(qps)
+		if sigma >= config.Sigma && ratio >= config.MinDeviationRatio {
+			anomalies = append(anomalies, QueryAnomaly{Fingerprint: state.fingerprint, Kind: "qps", Current: qps, Baseline: state.qps.mean, Sigma: sigma})
+			checks = append(checks, check{queryAnomalyMetric(state.key, "qps"), finiteSigma(sigma)})
+		} else {
+			state.qps.add(qps, config.Alpha)
+			checks = append(checks, check{queryAnomalyMetric(state.key, "qps"), 0})
+		}
+	}
+	detector.anomalies = anomalies
+	alertManager := detector.alertManager
+	ma.mu.Unlock()
+
+	// 在聚合器锁外送入告警，通知器可能回调其他组件
+	if alertManager != nil {
+		for _, c := range checks {
+			alertManager.CheckMetric(c.metric, c.value)
+		}
+	}
+	for _, anomaly := range anomalies {
+		LogWarn("SQL 指纹异常: %s %s 当前=%.2f 基线=%.2f (%.1f sigma)", anomaly.Kind, anomaly.Fingerprint, anomaly.Current, anomaly.Baseline, anomaly.Sigma)
+	}
+	return anomalies
+}
+
+/**
+ * 为指纹添加告警规则（调用方持有 ma.mu）
+ */
+func (ma *MetricsAggregator) addQueryAnomalyRules(detector *queryAnomalyDetector, state *queryFingerprintState) {
+	target := truncateAlertText(state.fingerprint, 120)
+	for _, kind := range []string{"latency", "qps"} {
+		name := "延迟"
+		if kind == "qps" {
+			name = "QPS"
+		}
+		detector.alertManager.AddAlertRule(AlertRule{
+			ID:          fmt.Sprintf("sql_anomaly_%s_%s", kind, state.key),
+			Name:        fmt.Sprintf("SQL %s偏离基线", name),
+			Description: fmt.Sprintf("偏离基线超过 %.1f 个标准差: %s", detector.config.Sigma, target),
+			Metric:      queryAnomalyMetric(state.key, kind),
+			Condition:   GreaterThanOrEqual,
+			Threshold:   detector.config.Sigma,
+			Severity:    detector.config.Severity,
+			Enabled:     true,
+			Target:      target,
+		})
+	}
+	state.rulesAdded = true
+}
+
+/**
+ * 获取各指纹的基线（按指纹排序）
+ */
+func (ma *MetricsAggregator) GetQueryBaselines() []QueryBaseline {
+	ma.mu.RLock()
+	defer ma.mu.RUnlock()
+
+	result := make([]QueryBaseline, 0)
+	if ma.anomalyDetector == nil {
+		return result
+	}
+	for _, state := range ma.anomalyDetector.fingerprints {
+		result = append(result, QueryBaseline{
+			Fingerprint:   state.fingerprint,
+			Windows:       state.qps.count,
+			LatencyMeanMs: state.latency.mean,
+			LatencyStdMs:  state.latency.std(),
+			QpsMean:       state.qps.mean,
+			QpsStd:        state.qps.std(),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Fingerprint < result[j].Fingerprint })
+	return result
+}
+
+/**
+ * 获取最近一个窗口检测到的异常
+ */
+func (ma *MetricsAggregator) GetQueryAnomalies() []QueryAnomaly {
+	ma.mu.RLock()
+	defer ma.mu.RUnlock()
+	if ma.anomalyDetector == nil {
+		return []QueryAnomaly{}
+	}
+	return append([]QueryAnomaly(nil), ma.anomalyDetector.anomalies...)
+}
+
+/**
+ * 指纹的短键（FNV-1a 32 位十六进制）
+ */
+func queryFingerprintKey(fingerprint string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(fingerprint))
+	return fmt.Sprintf("%08x", hash.Sum32())
+}
+
+/**
+ * 异常指标名：sql_anomaly.<hash>.<latency|qps>_sigma
+ */
+func queryAnomalyMetric(key string, kind string) string {
+	return fmt.Sprintf("sql_anomaly.%s.%s_sigma", key, kind)
+}
+
+/**
+ * 标准差为 0 时的无穷大替换为较大的有限值，便于告警展示与比较
+ */
+func finiteSigma(sigma float64) float64 {
+	if math.IsInf(sigma, 0) {
+		return math.MaxInt32
+	}
+	return sigma
+}
+
+/**
+ * QueryAnomalyPlugin - 把每条执行的语句送入聚合器的异常检测
+ */
+type QueryAnomalyPlugin struct {
+	*AbstractDb233Plugin
+	aggregator *MetricsAggregator
+}
+
+/**
+ * 创建异常检测插件
+ */
+func NewQueryAnomalyPlugin(aggregator *MetricsAggregator) *QueryAnomalyPlugin {
+	return &QueryAnomalyPlugin{
+		AbstractDb233Plugin: NewAbstractDb233Plugin("query-anomaly-plugin"),
+		aggregator:          aggregator,
+	}
+}
+
+/**
+ * SQL 执行后记录耗时（按改写前的语句计算指纹）
+ */
+func (p *QueryAnomalyPlugin) PostExecuteSql(context *ExecuteSqlContext) {
+	p.aggregator.ObserveQuery(context.OriginalSql, context.Duration)
+}
@@ -3,8 +3,10 @@ package db233
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -24,6 +26,12 @@ type MetricsCollector struct {
 	metricsData map[string][]MetricPoint
 	maxPoints   int
 
+	// 分层保留与汇总数据（见 metrics_retention.go）
+	retentionTiers []MetricsRetentionTier
+	rollups        map[time.Duration]map[string][]MetricRollup
+	rolledUntil    map[time.Duration]time.Time
+	lastDownsample time.Time
+
 	// 收集间隔
 	collectionInterval time.Duration
 
@@ -36,6 +44,7 @@ type MetricsCollector struct {
 	// 控制
 	enabled    bool
 	stopChan   chan bool
+	stopOnce   sync.Once
 	lastUpdate time.Time
 }
 
@@ -127,7 +136,7 @@ func (mc *MetricsCollector) Disable() {
 func (mc *MetricsCollector) Start() {
 	LogInfo("监控数据收集器启动: %s, 间隔: %v", mc.name, mc.collectionInterval)
 
-	go func() {
+	goSupervised("metrics_collector:"+mc.name, mc.stopChan, func() {
 		ticker := time.NewTicker(mc.collectionInterval)
 		defer ticker.Stop()
 
@@ -140,19 +149,16 @@ func (mc *MetricsCollector) Start() {
 				return
 			}
 		}
-	}()
+	})
 }
 
 /**
  * 停止数据收集
  */
 func (mc *MetricsCollector) Stop() {
-	select {
-	case mc.stopChan <- true:
-		// 成功发送停止信号
-	default:
-		// channel已满或没有接收者，忽略
-	}
+	mc.stopOnce.Do(func() {
+		close(mc.stopChan)
+	})
 }
 
 /**
@@ -200,29 +206,31 @@ func (mc *MetricsCollector) collectMetrics() {
 			}
 		}
 	}
+
+	if mc.downsampleDue(now) {
+		mc.downsampleLocked(now)
+	}
 }
 
 /**
- * 获取指定指标的历史数据
+ * 获取指定指标的历史数据（启用分层保留时用汇总数据补齐更早的时间段）
  */
 func (mc *MetricsCollector) GetMetricHistory(metricName string, duration time.Duration) []MetricPoint {
 	mc.mu.RLock()
 	defer mc.mu.RUnlock()
 
-	points, exists := mc.metricsData[metricName]
-	if !exists {
-		return []MetricPoint{}
-	}
-
 	cutoff := time.Now().Add(-duration)
 	result := make([]MetricPoint, 0)
 
-	for _, point := range points {
+	for _, point := range mc.metricsData[metricName] {
 		if point.Timestamp.After(cutoff) {
 			result = append(result, point)
 		}
 	}
 
+	if len(mc.retentionTiers) > 1 {
+		result = mc.prependRollups(metricName, cutoff, result)
+	}
 	return result
 }
 
@@ -233,10 +241,21 @@ func (mc *MetricsCollector) GetMetricNames() []string {
 	mc.mu.RLock()
 	defer mc.mu.RUnlock()
 
+	seen := make(map[string]bool, len(mc.metricsData))
 	names := make([]string, 0, len(mc.metricsData))
 	for name := range mc.metricsData {
+		seen[name] = true
 		names = append(names, name)
 	}
+	// 原始数据已过期、只剩汇总数据的指标
+	for _, byName := range mc.rollups {
+		for name := range byName {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
 	sort.Strings(names)
 	return names
 }
@@ -308,6 +327,13 @@ func (mc *MetricsCollector) GetMetricStats(metricName string, duration time.Dura
  * 计算数值统计
  */
 func (mc *MetricsCollector) calculateStats(values []float64) (min, max, avg, p95, p99 float64) {
+	return calculateMetricStats(values)
+}
+
+/**
+ * 计算数值的最小值、最大值、平均值与 P95 / P99
+ */
+func calculateMetricStats(values []float64) (min, max, avg, p95, p99 float64) {
 	if len(values) == 0 {
 		return 0, 0, 0, 0, 0
 	}
@@ -379,25 +405,55 @@ func (mc *MetricsCollector) ExportToFile(filename string) error {
 }
 
 /**
- * 从文件导入数据
+ * 从文件导入数据（扩展名匹配已注册压缩器时自动解压）
+ *
+ * 导入的数据点与已有数据按时间戳合并去重，便于汇总多个节点的快照
  */
 func (mc *MetricsCollector) ImportFromFile(filename string) error {
-	mc.mu.Lock()
-	defer mc.mu.Unlock()
-
 	file, err := os.Open(filename)
 	if err != nil {
 		return fmt.Errorf("打开导入文件失败: %w", err)
 	}
 	defer file.Close()
 
+	var reader io.Reader = file
+	if compressor, ok := getMetricsCompressorByFilename(filename); ok {
+		decompressed, err := compressor.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("解压导入文件失败: %w", err)
+		}
+		defer decompressed.Close()
+		reader = decompressed
+	}
+
+	metricsData, err := parseMetricsSnapshot(reader)
+	if err != nil {
+		return err
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	for name, points := range metricsData {
+		mc.metricsData[name] = mc.mergeMetricPoints(mc.metricsData[name], points)
+	}
+
+	LogInfo("监控数据已从文件导入: %s", filename)
+	return nil
+}
+
+/**
+ * 解析导出格式的监控快照（指标名 -> 数据点）
+ */
+func parseMetricsSnapshot(reader io.Reader) (map[string][]MetricPoint, error) {
 	var data map[string]interface{}
-	decoder := json.NewDecoder(file)
+	decoder := json.NewDecoder(reader)
 
 	if err := decoder.Decode(&data); err != nil {
-		return fmt.Errorf("解析导入数据失败: %w", err)
+		return nil, fmt.Errorf("解析导入数据失败: %w", err)
 	}
 
+	result := make(map[string][]MetricPoint)
+
 	// 解析指标数据
 	if metricsData, ok := data["metrics"].(map[string]interface{}); ok {
 		for name, pointsData := range metricsData {
@@ -429,13 +485,62 @@ func (mc *MetricsCollector) ImportFromFile(filename string) error {
 						points = append(points, point)
 					}
 				}
-				mc.metricsData[name] = points
+				result[name] = points
 			}
 		}
 	}
 
-	LogInfo("监控数据已从文件导入: %s", filename)
-	return nil
+	return result, nil
+}
+
+/**
+ * 按时间戳合并数据点
+ *
+ * 时间戳和标签都相同的数据点视为重复，以新导入的为准；
+ * 合并后按时间升序排列，并按 maxPoints 保留最新的数据点
+ */
+func (mc *MetricsCollector) mergeMetricPoints(existing []MetricPoint, incoming []MetricPoint) []MetricPoint {
+	merged := make([]MetricPoint, 0, len(existing)+len(incoming))
+	index := make(map[string]int, len(existing)+len(incoming))
+
+	for _, points := range [][]MetricPoint{existing, incoming} {
+		for _, point := range points {
+			key := metricPointKey(point)
+			if i, exists := index[key]; exists {
+				merged[i] = point
+				continue
+			}
+			index[key] = len(merged)
+			merged = append(merged, point)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Timestamp.Before(merged[j].Timestamp)
+	})
+
+	if mc.maxPoints > 0 && len(merged) > mc.maxPoints {
+		merged = merged[len(merged)-mc.maxPoints:]
+	}
+	return merged
+}
+
+/**
+ * 数据点去重键：时间戳 + 排序后的标签
+ */
+func metricPointKey(point MetricPoint) string {
+	tagKeys := make([]string, 0, len(point.Tags))
+	for k := range point.Tags {
+		tagKeys = append(tagKeys, k)
+	}
+	sort.Strings(tagKeys)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d", point.Timestamp.UnixNano()))
+	for _, k := range tagKeys {
+		sb.WriteString("|" + k + "=" + point.Tags[k])
+	}
+	return sb.String()
 }
 
 /**
@@ -476,6 +581,12 @@ func (mc *MetricsCollector) GetStatus() map[string]interface{} {
 	for _, points := range mc.metricsData {
 		totalPoints += len(points)
 	}
+	rollupPoints := 0
+	for _, byName := range mc.rollups {
+		for _, rollups := range byName {
+			rollupPoints += len(rollups)
+		}
+	}
 
 	return map[string]interface{}{
 		"name":                mc.name,
@@ -484,6 +595,8 @@ func (mc *MetricsCollector) GetStatus() map[string]interface{} {
 		"metrics_count":       len(mc.metricsData),
 		"total_data_points":   totalPoints,
 		"max_points":          mc.maxPoints,
+		"retention_tiers":     len(mc.retentionTiers),
+		"rollup_points":       rollupPoints,
 		"collection_interval": mc.collectionInterval.String(),
 		"last_update":         mc.lastUpdate,
 	}
@@ -498,6 +611,10 @@ func (mc *MetricsCollector) Reset() {
 
 	mc.metricsData = make(map[string][]MetricPoint)
 	mc.lastUpdate = time.Now()
+	for resolution := range mc.rollups {
+		mc.rollups[resolution] = make(map[string][]MetricRollup)
+	}
+	mc.rolledUntil = make(map[time.Duration]time.Time)
 
 	LogInfo("监控数据收集器已重置: %s", mc.name)
 }
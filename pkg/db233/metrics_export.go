@@ -0,0 +1,277 @@
+package db233
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * 监控数据导出增强 - Go 版
+ *
+ * - 可插拔压缩：内置 gzip，zstd 等算法通过 RegisterMetricsCompressor 注册
+ * - 流式编码：逐个数据点写出，不在内存中拼装整份 JSON
+ * - 导出过滤：按指标名通配符和时间范围筛选
+ *
+ * 输出格式与 ExportToFile 一致，可直接用 ImportFromFile 导入（按扩展名自动解压）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+
+/**
+ * MetricsCompressor - 导出压缩器接口
+ */
+type MetricsCompressor interface {
+	/**
+	 * 压缩算法名称，如 gzip / zstd
+	 */
+	GetName() string
+
+	/**
+	 * 文件扩展名，如 .gz / .zst
+	 */
+	GetExtension() string
+
+	/**
+	 * 包装压缩写入器
+	 */
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+
+	/**
+	 * 包装解压读取器
+	 */
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+/**
+ * MetricsExportOptions - 监控数据导出选项
+ */
+type MetricsExportOptions struct {
+	// 压缩算法名称，为空时不压缩
+	Compression string
+
+	// 指标名通配符（path.Match 语法，如 "pool.*"），为空时导出全部
+	MetricPatterns []string
+
+	// 时间范围，零值表示不限制
+	From time.Time
+	To   time.Time
+
+	// 写出缓冲区大小（字节），默认 64KB
+	ChunkSize int
+}
+
+var metricsCompressors = map[string]MetricsCompressor{
+	"gzip": &GzipMetricsCompressor{Level: gzip.DefaultCompression},
+}
+var metricsCompressorsMu sync.RWMutex
+
+/**
+ * 注册导出压缩器（同名覆盖）
+ */
+func RegisterMetricsCompressor(compressor MetricsCompressor) {
+	metricsCompressorsMu.Lock()
+	defer metricsCompressorsMu.Unlock()
+	metricsCompressors[compressor.GetName()] = compressor
+}
+
+/**
+ * 获取导出压缩器
+ */
+func GetMetricsCompressor(name string) (MetricsCompressor, bool) {
+	metricsCompressorsMu.RLock()
+	defer metricsCompressorsMu.RUnlock()
+	compressor, exists := metricsCompressors[name]
+	return compressor, exists
+}
+
+/**
+ * 按文件扩展名查找压缩器
+ */
+func getMetricsCompressorByFilename(filename string) (MetricsCompressor, bool) {
+	metricsCompressorsMu.RLock()
+	defer metricsCompressorsMu.RUnlock()
+	for _, compressor := range metricsCompressors {
+		if strings.HasSuffix(filename, compressor.GetExtension()) {
+			return compressor, true
+		}
+	}
+	return nil, false
+}
+
+/**
+ * 按选项导出监控数据到文件
+ */
+func (mc *MetricsCollector) ExportToFileWithOptions(filename string, options MetricsExportOptions) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("创建导出文件失败: %w", err)
+	}
+	defer file.Close()
+
+	if err := mc.ExportWithOptions(file, options); err != nil {
+		return err
+	}
+
+	LogInfo("监控数据已导出到文件: %s, 压缩=%s", filename, options.Compression)
+	return nil
+}
+
+/**
+ * 按选项流式导出监控数据
+ */
+func (mc *MetricsCollector) ExportWithOptions(w io.Writer, options MetricsExportOptions) error {
+	var compressed io.WriteCloser
+	if options.Compression != "" {
+		compressor, exists := GetMetricsCompressor(options.Compression)
+		if !exists {
+			return fmt.Errorf("未注册的压缩算法: %s", options.Compression)
+		}
+		var err error
+		if compressed, err = compressor.NewWriter(w); err != nil {
+			return fmt.Errorf("创建压缩写入器失败: %w", err)
+		}
+		w = compressed
+	}
+
+	chunkSize := options.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = 64 * 1024
+	}
+	buffered := bufio.NewWriterSize(w, chunkSize)
+
+	if err := mc.streamMetrics(buffered, options); err != nil {
+		return fmt.Errorf("导出数据失败: %w", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("导出数据失败: %w", err)
+	}
+	if compressed != nil {
+		if err := compressed.Close(); err != nil {
+			return fmt.Errorf("关闭压缩写入器失败: %w", err)
+		}
+	}
+	return nil
+}
+
+/**
+ * 逐个数据点写出 JSON
+ */
+func (mc *MetricsCollector) streamMetrics(w *bufio.Writer, options MetricsExportOptions) error {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	header, err := json.Marshal(map[string]interface{}{
+		"collector":    mc.name,
+		"export_time":  time.Now(),
+		"last_update":  mc.lastUpdate,
+		"data_sources": len(mc.dataSources),
+	})
+	if err != nil {
+		return err
+	}
+	// 去掉结尾的 "}"，接着写 metrics 字段
+	w.Write(header[:len(header)-1])
+	w.WriteString(`,"metrics":{`)
+
+	names := make([]string, 0, len(mc.metricsData))
+	for name := range mc.metricsData {
+		if matchMetricPatterns(name, options.MetricPatterns) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		w.Write(key)
+		w.WriteString(":[")
+
+		first := true
+		for _, point := range mc.metricsData[name] {
+			if !options.From.IsZero() && point.Timestamp.Before(options.From) {
+				continue
+			}
+			if !options.To.IsZero() && point.Timestamp.After(options.To) {
+				continue
+			}
+			encoded, err := json.Marshal(point)
+			if err != nil {
+				return err
+			}
+			if !first {
+				w.WriteByte(',')
+			}
+			first = false
+			if _, err := w.Write(encoded); err != nil {
+				return err
+			}
+		}
+		w.WriteByte(']')
+	}
+
+	_, err = w.WriteString("}}\n")
+	return err
+}
+
+/**
+ * 判断指标名是否匹配任一通配符，通配符为空时全部匹配
+ */
+func matchMetricPatterns(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * GzipMetricsCompressor - gzip 压缩器
+ */
+type GzipMetricsCompressor struct {
+	Level int
+}
+
+/**
+ * 压缩算法名称
+ */
+func (c *GzipMetricsCompressor) GetName() string {
+	return "gzip"
+}
+
+/**
+ * 文件扩展名
+ */
+func (c *GzipMetricsCompressor) GetExtension() string {
+	return ".gz"
+}
+
+/**
+ * 包装压缩写入器
+ */
+func (c *GzipMetricsCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, c.Level)
+}
+
+/**
+ * 包装解压读取器
+ */
+func (c *GzipMetricsCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
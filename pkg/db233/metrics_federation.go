@@ -0,0 +1,421 @@
+package db233
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * MetricsFederation - 集群监控数据联邦
+ *
+ * 定期抓取其他 db233 实例的快照 HTTP 端点（MetricsCollector.SnapshotHandler），
+ * 按节点标签合并到本地 MetricsCollector，形成覆盖整个集群的统一视图
+ *
+ * 合并规则：远端指标 <metric> 存为 <node>.<metric>，数据点附加 node 标签；
+ * 每个节点只增量抓取上次最新时间戳之后的数据，重叠部分按时间戳去重
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type MetricsFederation struct {
+	collector      *MetricsCollector
+	nodes          map[string]*FederatedNode
+	client         *http.Client
+	scrapeInterval time.Duration
+	metricPatterns []string
+
+	mu       sync.RWMutex
+	running  bool
+	stopChan chan bool
+}
+
+/**
+ * FederatedNode - 联邦节点状态
+ */
+type FederatedNode struct {
+	Name         string
+	Url          string
+	LastScrape   time.Time
+	LastSuccess  time.Time
+	LastError    string
+	ScrapeCount  int64
+	FailureCount int64
+	PointsMerged int64
+
+	// 已合并的最新数据点时间，用于增量抓取
+	lastPointTime time.Time
+}
+
+/**
+ * 联邦数据点的节点标签名
+ */
+const FederationNodeTag = "node"
+
+/**
+ * 创建监控数据联邦
+ */
+func NewMetricsFederation(collector *MetricsCollector) *MetricsFederation {
+	return &MetricsFederation{
+		collector:      collector,
+		nodes:          make(map[string]*FederatedNode),
+		client:         &http.Client{Timeout: 10 * time.Second},
+		scrapeInterval: 30 * time.Second,
+	}
+}
+
+/**
+ * 添加节点（url 为对方 SnapshotHandler 的地址）
+ */
+func (mf *MetricsFederation) AddNode(name string, nodeUrl string) error {
+	if name == "" {
+		return fmt.Errorf("节点名称不能为空")
+	}
+	parsed, err := url.Parse(nodeUrl)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return fmt.Errorf("节点地址无效: %s", nodeUrl)
+	}
+
+	mf.mu.Lock()
+	defer mf.mu.Unlock()
+	if _, exists := mf.nodes[name]; exists {
+		return fmt.Errorf("节点已存在: %s", name)
+	}
+	mf.nodes[name] = &FederatedNode{Name: name, Url: nodeUrl}
+	LogInfo("联邦节点已添加: %s -> %s", name, nodeUrl)
+	return nil
+}
+
+/**
+ * 移除节点（已合并的数据保留）
+ */
+func (mf *MetricsFederation) RemoveNode(name string) {
+	mf.mu.Lock()
+	defer mf.mu.Unlock()
+	delete(mf.nodes, name)
+}
+
+/**
+ * 获取所有节点状态（按名称排序）
+ */
+func (mf *MetricsFederation) GetNodes() []FederatedNode {
+	mf.mu.RLock()
+	defer mf.mu.RUnlock()
+
+	nodes := make([]FederatedNode, 0, len(mf.nodes))
+	for _, node := range mf.nodes {
+		nodes = append(nodes, *node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+	return nodes
+}
+
+/**
+ * 设置抓取间隔
+ */
+func (mf *MetricsFederation) SetScrapeInterval(interval time.Duration) {
+	mf.mu.Lock()
+	defer mf.mu.Unlock()
+	mf.scrapeInterval = interval
+}
+
+/**
+ * 设置只抓取的指标名通配符（path.Match 语法）
+ */
+func (mf *MetricsFederation) SetMetricPatterns(patterns ...string) {
+	mf.mu.Lock()
+	defer mf.mu.Unlock()
+	mf.metricPatterns = patterns
+}
+
+/**
+ * 设置 HTTP 客户端（自定义超时、TLS 等）
+ */
+func (mf *MetricsFederation) SetHttpClient(client *http.Client) {
+	mf.mu.Lock()
+	defer mf.mu.Unlock()
+	mf.client = client
+}
+
+/**
+ * 启动定期抓取
+ */
+func (mf *MetricsFederation) Start() {
+	mf.mu.Lock()
+	if mf.running {
+		mf.mu.Unlock()
+		return
+	}
+	mf.running = true
+	mf.stopChan = make(chan bool)
+	interval := mf.scrapeInterval
+	mf.mu.Unlock()
+
+	LogInfo("监控数据联邦启动: %s, 间隔: %v", mf.collector.name, interval)
+
+	goSupervised("metrics_federation:"+mf.collector.name, mf.stopChan, func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		mf.ScrapeAll()
+		for {
+			select {
+			case <-ticker.C:
+				mf.ScrapeAll()
+			case <-mf.stopChan:
+				LogInfo("监控数据联邦停止: %s", mf.collector.name)
+				return
+			}
+		}
+	})
+}
+
+/**
+ * 停止定期抓取
+ */
+func (mf *MetricsFederation) Stop() {
+	mf.mu.Lock()
+	defer mf.mu.Unlock()
+	if !mf.running {
+		return
+	}
+	mf.running = false
+	close(mf.stopChan)
+}
+
+/**
+ * 并发抓取所有节点，返回失败节点的错误
+ */
+func (mf *MetricsFederation) ScrapeAll() map[string]error {
+	mf.mu.RLock()
+	names := make([]string, 0, len(mf.nodes))
+	for name := range mf.nodes {
+		names = append(names, name)
+	}
+	mf.mu.RUnlock()
+
+	errs := make(map[string]error)
+	var errsMu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			if err := mf.ScrapeNode(name); err != nil {
+				errsMu.Lock()
+				errs[name] = err
+				errsMu.Unlock()
+			}
+		}(name)
+	}
+	wg.Wait()
+	return errs
+}
+
+/**
+ * 抓取单个节点并合并到本地收集器
+ */
+func (mf *MetricsFederation) ScrapeNode(name string) error {
+	mf.mu.RLock()
+	node, exists := mf.nodes[name]
+	if !exists {
+		mf.mu.RUnlock()
+		return fmt.Errorf("节点不存在: %s", name)
+	}
+	requestUrl := mf.buildScrapeUrl(node)
+	client := mf.client
+	mf.mu.RUnlock()
+
+	snapshot, err := mf.fetchSnapshot(client, requestUrl)
+
+	mf.mu.Lock()
+	defer mf.mu.Unlock()
+	node.LastScrape = time.Now()
+	node.ScrapeCount++
+	if err != nil {
+		node.FailureCount++
+		node.LastError = err.Error()
+		LogWarn("联邦节点抓取失败: %s, 错误: %v", name, err)
+		return err
+	}
+	node.LastSuccess = node.LastScrape
+	node.LastError = ""
+
+	merged, latest := mf.collector.mergeFederatedSnapshot(name, snapshot)
+	node.PointsMerged += int64(merged)
+	if latest.After(node.lastPointTime) {
+		node.lastPointTime = latest
+	}
+	LogDebug("联邦节点抓取完成: %s, 数据点=%d", name, merged)
+	return nil
+}
+
+/**
+ * 构造抓取地址（附加增量时间与指标过滤参数）
+ */
+func (mf *MetricsFederation) buildScrapeUrl(node *FederatedNode) string {
+	parsed, err := url.Parse(node.Url)
+	if err != nil {
+		return node.Url
+	}
+	query := parsed.Query()
+	if !node.lastPointTime.IsZero() {
+		query.Set("from", node.lastPointTime.Format(time.RFC3339Nano))
+	}
+	if len(mf.metricPatterns) > 0 {
+		query.Set("metrics", strings.Join(mf.metricPatterns, ","))
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+/**
+ * 请求并解析远端快照
+ */
+func (mf *MetricsFederation) fetchSnapshot(client *http.Client, requestUrl string) (map[string][]MetricPoint, error) {
+	resp, err := client.Get(requestUrl)
+	if err != nil {
+		return nil, fmt.Errorf("请求快照失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("快照端点返回状态码 %d", resp.StatusCode)
+	}
+	return parseMetricsSnapshot(resp.Body)
+}
+
+/**
+ * 获取某个指标在各节点的最新数据点（节点名 -> 数据点）
+ */
+func (mf *MetricsFederation) GetLatestByNode(metricName string) map[string]MetricPoint {
+	mf.mu.RLock()
+	names := make([]string, 0, len(mf.nodes))
+	for name := range mf.nodes {
+		names = append(names, name)
+	}
+	mf.mu.RUnlock()
+
+	mf.collector.mu.RLock()
+	defer mf.collector.mu.RUnlock()
+
+	result := make(map[string]MetricPoint)
+	for _, name := range names {
+		points := mf.collector.metricsData[federatedMetricName(name, metricName)]
+		if len(points) > 0 {
+			result[name] = points[len(points)-1]
+		}
+	}
+	return result
+}
+
+/**
+ * 获取联邦状态
+ */
+func (mf *MetricsFederation) GetStatus() map[string]interface{} {
+	mf.mu.RLock()
+	defer mf.mu.RUnlock()
+
+	healthy := 0
+	nodes := make(map[string]interface{}, len(mf.nodes))
+	for name, node := range mf.nodes {
+		if node.LastError == "" && !node.LastSuccess.IsZero() {
+			healthy++
+		}
+		nodes[name] = map[string]interface{}{
+			"url":           node.Url,
+			"last_scrape":   node.LastScrape,
+			"last_success":  node.LastSuccess,
+			"last_error":    node.LastError,
+			"scrape_count":  node.ScrapeCount,
+			"failure_count": node.FailureCount,
+			"points_merged": node.PointsMerged,
+		}
+	}
+
+	return map[string]interface{}{
+		"collector":       mf.collector.name,
+		"running":         mf.running,
+		"scrape_interval": mf.scrapeInterval.String(),
+		"node_count":      len(mf.nodes),
+		"healthy_nodes":   healthy,
+		"nodes":           nodes,
+	}
+}
+
+/**
+ * 联邦指标名：<node>.<metric>
+ */
+func federatedMetricName(node string, metricName string) string {
+	return node + "." + metricName
+}
+
+/**
+ * 合并远端快照，返回合并的数据点数与最新数据点时间
+ */
+func (mc *MetricsCollector) mergeFederatedSnapshot(node string, snapshot map[string][]MetricPoint) (int, time.Time) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	merged := 0
+	var latest time.Time
+	for metricName, points := range snapshot {
+		name := federatedMetricName(node, metricName)
+		for i := range points {
+			points[i].Name = name
+			points[i].Tags[FederationNodeTag] = node
+			if points[i].Timestamp.After(latest) {
+				latest = points[i].Timestamp
+			}
+		}
+		mc.metricsData[name] = mc.mergeMetricPoints(mc.metricsData[name], points)
+		merged += len(points)
+	}
+	mc.lastUpdate = time.Now()
+	return merged, latest
+}
+
+/**
+ * 快照 HTTP 端点，供其他实例的 MetricsFederation 抓取
+ *
+ * 查询参数：
+ *   metrics  逗号分隔的指标名通配符
+ *   from     RFC3339 时间，只返回该时间之后的数据点
+ * 请求头 Accept-Encoding 包含 gzip 时压缩响应
+ */
+func (mc *MetricsCollector) SnapshotHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		options := MetricsExportOptions{}
+		if metrics := r.URL.Query().Get("metrics"); metrics != "" {
+			options.MetricPatterns = strings.Split(metrics, ",")
+		}
+		if from := r.URL.Query().Get("from"); from != "" {
+			parsed, err := time.Parse(time.RFC3339Nano, from)
+			if err != nil {
+				http.Error(w, "from 参数格式错误: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			options.From = parsed
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			options.Compression = "gzip"
+		}
+		if err := mc.ExportWithOptions(w, options); err != nil {
+			LogError("快照端点导出失败: %s, 错误: %v", mc.name, err)
+		}
+	})
+}
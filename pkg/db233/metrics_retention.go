@@ -0,0 +1,279 @@
+package db233
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+/**
+ * 监控数据分层保留与降采样
+ *
+ * 原始数据只保留较短时间，较早的数据按分钟、小时汇总，长时间范围的趋势报告不再为空，也不占用大量内存：
+ *
+ *   collector.SetRetentionTiers(db233.DefaultMetricsRetentionTiers()...) // 原始 1h、1 分钟汇总 24h、1 小时汇总 30d
+ *
+ * 收集循环按最小汇总粒度自动降采样，也可手动调用 Downsample。
+ * GetMetricHistory 在原始数据覆盖不到的时间段使用汇总数据补齐（汇总点的值为平均值，Tags["resolution"] 为粒度）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type MetricsRetentionTier struct {
+	// 汇总粒度，0 表示原始数据（第一层必须为原始数据）
+	Resolution time.Duration
+
+	// 保留时长
+	Retention time.Duration
+}
+
+/**
+ * MetricRollup - 汇总数据点
+ */
+type MetricRollup struct {
+	Start      time.Time
+	Resolution time.Duration
+	Count      int
+	Sum        float64
+	Min        float64
+	Max        float64
+}
+
+/**
+ * 平均值
+ */
+func (r MetricRollup) Avg() float64 {
+	if r.Count == 0 {
+		return 0
+	}
+	return r.Sum / float64(r.Count)
+}
+
+/**
+ * 默认分层：原始 1 小时、1 分钟汇总 24 小时、1 小时汇总 30 天
+ */
+func DefaultMetricsRetentionTiers() []MetricsRetentionTier {
+	return []MetricsRetentionTier{
+		{Resolution: 0, Retention: time.Hour},
+		{Resolution: time.Minute, Retention: 24 * time.Hour},
+		{Resolution: time.Hour, Retention: 30 * 24 * time.Hour},
+	}
+}
+
+/**
+ * 设置分层保留（不传参数时关闭分层，恢复只按 maxPoints 保留原始数据）
+ */
+func (mc *MetricsCollector) SetRetentionTiers(tiers ...MetricsRetentionTier) error {
+	for i, tier := range tiers {
+		if tier.Retention <= 0 {
+			return NewValidationException(fmt.Sprintf("第 %d 层保留时长必须大于 0", i))
+		}
+		if i == 0 {
+			if tier.Resolution != 0 {
+				return NewValidationException("第一层必须为原始数据（Resolution 为 0）")
+			}
+			continue
+		}
+		previous := tiers[i-1]
+		if tier.Resolution <= previous.Resolution || (previous.Resolution > 0 && tier.Resolution%previous.Resolution != 0) {
+			return NewValidationException(fmt.Sprintf("第 %d 层粒度 %v 必须是上一层粒度 %v 的整数倍", i, tier.Resolution, previous.Resolution))
+		}
+		// 上一层数据过期前必须已汇总到本层
+		if previous.Retention < tier.Resolution {
+			return NewValidationException(fmt.Sprintf("第 %d 层保留时长 %v 小于下一层粒度 %v", i-1, previous.Retention, tier.Resolution))
+		}
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.retentionTiers = append([]MetricsRetentionTier(nil), tiers...)
+	mc.rollups = make(map[time.Duration]map[string][]MetricRollup)
+	mc.rolledUntil = make(map[time.Duration]time.Time)
+	for i := 1; i < len(tiers); i++ {
+		mc.rollups[tiers[i].Resolution] = make(map[string][]MetricRollup)
+	}
+	return nil
+}
+
+/**
+ * 降采样：把上一层已结束的时间桶汇总到下一层，并清理各层过期数据
+ */
+func (mc *MetricsCollector) Downsample(now time.Time) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.downsampleLocked(now)
+}
+
+func (mc *MetricsCollector) downsampleLocked(now time.Time) {
+	if len(mc.retentionTiers) == 0 {
+		return
+	}
+	mc.lastDownsample = now
+
+	for i := 1; i < len(mc.retentionTiers); i++ {
+		resolution := mc.retentionTiers[i].Resolution
+		from := mc.rolledUntil[resolution]
+		until := now.Truncate(resolution)
+		if !until.After(from) {
+			continue
+		}
+
+		buckets := make(map[string]map[time.Time]*MetricRollup)
+		add := func(name string, timestamp time.Time, rollup MetricRollup) {
+			if timestamp.Before(from) || !timestamp.Before(until) {
+				return
+			}
+			if buckets[name] == nil {
+				buckets[name] = make(map[time.Time]*MetricRollup)
+			}
+			start := timestamp.Truncate(resolution)
+			if bucket, exists := buckets[name][start]; exists {
+				bucket.Count += rollup.Count
+				bucket.Sum += rollup.Sum
+				bucket.Min = minFloat(bucket.Min, rollup.Min)
+				bucket.Max = maxFloat(bucket.Max, rollup.Max)
+				return
+			}
+			rollup.Start = start
+			rollup.Resolution = resolution
+			buckets[name][start] = &rollup
+		}
+
+		if i == 1 {
+			for name, points := range mc.metricsData {
+				for _, point := range points {
+					if value, ok := toFloat64(point.Value); ok {
+						add(name, point.Timestamp, MetricRollup{Count: 1, Sum: value, Min: value, Max: value})
+					}
+				}
+			}
+		} else {
+			for name, rollups := range mc.rollups[mc.retentionTiers[i-1].Resolution] {
+				for _, rollup := range rollups {
+					add(name, rollup.Start, rollup)
+				}
+			}
+		}
+
+		for name, byStart := range buckets {
+			rollups := mc.rollups[resolution][name]
+			for _, bucket := range byStart {
+				rollups = append(rollups, *bucket)
+			}
+			sort.Slice(rollups, func(a, b int) bool { return rollups[a].Start.Before(rollups[b].Start) })
+			mc.rollups[resolution][name] = rollups
+		}
+		mc.rolledUntil[resolution] = until
+	}
+
+	// 清理过期数据
+	rawCutoff := now.Add(-mc.retentionTiers[0].Retention)
+	for name, points := range mc.metricsData {
+		index := sort.Search(len(points), func(i int) bool { return points[i].Timestamp.After(rawCutoff) })
+		if index == len(points) {
+			delete(mc.metricsData, name)
+		} else if index > 0 {
+			mc.metricsData[name] = append([]MetricPoint(nil), points[index:]...)
+		}
+	}
+	for _, tier := range mc.retentionTiers[1:] {
+		cutoff := now.Add(-tier.Retention)
+		for name, rollups := range mc.rollups[tier.Resolution] {
+			index := sort.Search(len(rollups), func(i int) bool { return rollups[i].Start.Add(tier.Resolution).After(cutoff) })
+			if index == len(rollups) {
+				delete(mc.rollups[tier.Resolution], name)
+			} else if index > 0 {
+				mc.rollups[tier.Resolution][name] = append([]MetricRollup(nil), rollups[index:]...)
+			}
+		}
+	}
+}
+
+/**
+ * 是否到了自动降采样的时间（按最小汇总粒度；只有原始层时每次收集都清理，调用方持有 mc.mu）
+ */
+func (mc *MetricsCollector) downsampleDue(now time.Time) bool {
+	switch len(mc.retentionTiers) {
+	case 0:
+		return false
+	case 1:
+		return true
+	default:
+		return now.Sub(mc.lastDownsample) >= mc.retentionTiers[1].Resolution
+	}
+}
+
+/**
+ * 获取指定粒度的汇总数据
+ */
+func (mc *MetricsCollector) GetMetricRollups(metricName string, resolution time.Duration, duration time.Duration) []MetricRollup {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	cutoff := time.Now().Add(-duration)
+	result := make([]MetricRollup, 0)
+	for _, rollup := range mc.rollups[resolution][metricName] {
+		if rollup.Start.After(cutoff) {
+			result = append(result, rollup)
+		}
+	}
+	return result
+}
+
+/**
+ * 用汇总数据补齐原始数据之前的时间段，从细到粗依次向前补齐（调用方持有 mc.mu）
+ */
+func (mc *MetricsCollector) prependRollups(metricName string, cutoff time.Time, points []MetricPoint) []MetricPoint {
+	earliest := time.Now()
+	if len(points) > 0 {
+		earliest = points[0].Timestamp
+	}
+
+	for _, tier := range mc.retentionTiers[1:] {
+		prefix := make([]MetricPoint, 0)
+		for _, rollup := range mc.rollups[tier.Resolution][metricName] {
+			end := rollup.Start.Add(tier.Resolution)
+			if !end.After(cutoff) {
+				continue
+			}
+			if end.After(earliest) {
+				break
+			}
+			prefix = append(prefix, rollup.toMetricPoint(metricName))
+		}
+		if len(prefix) > 0 {
+			points = append(prefix, points...)
+			earliest = prefix[0].Timestamp
+		}
+	}
+	return points
+}
+
+/**
+ * 转换为数据点（值为平均值）
+ */
+func (r MetricRollup) toMetricPoint(metricName string) MetricPoint {
+	return MetricPoint{
+		Timestamp: r.Start,
+		Name:      metricName,
+		Value:     r.Avg(),
+		Tags: map[string]string{
+			"resolution": r.Resolution.String(),
+			"count":      fmt.Sprintf("%d", r.Count),
+		},
+	}
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
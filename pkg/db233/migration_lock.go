@@ -0,0 +1,213 @@
+package db233
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"time"
+)
+
+/**
+ * 迁移锁模式
+ *
+ * 多个应用实例同时启动并执行 MigrationManager.Up 时，通过数据库的会话级咨询锁保证只有一个实例迁移：
+ *   - MySQL / TiDB：GET_LOCK / RELEASE_LOCK
+ *   - SQL Server：sp_getapplock / sp_releaseapplock（Session 所有者）
+ *   - PostgreSQL：pg_try_advisory_lock / pg_advisory_unlock
+ * 其他数据库不支持咨询锁，记录警告后不加锁执行
+ *
+ * 等待锁的实例拿到锁后重新读取待应用迁移，已被其他实例应用的不会重复执行
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type EnumMigrationLockMode string
+
+const (
+	// EnumMigrationLockModeWait 等待锁直至超时，超时返回 MigrationLockTimeoutException（默认）
+	EnumMigrationLockModeWait EnumMigrationLockMode = "wait"
+	// EnumMigrationLockModeSkip 锁被其他实例持有时直接跳过本次迁移（返回 nil）
+	EnumMigrationLockModeSkip EnumMigrationLockMode = "skip"
+	// EnumMigrationLockModeNone 不加锁
+	EnumMigrationLockModeNone EnumMigrationLockMode = "none"
+)
+
+const defaultMigrationLockTimeout = 60 * time.Second
+
+// PostgreSQL 没有带超时的咨询锁，按该间隔轮询 pg_try_advisory_lock
+const postgresMigrationLockPollInterval = 500 * time.Millisecond
+
+/**
+ * migrationLock - 已持有的迁移锁（绑定在单个连接上）
+ */
+type migrationLock struct {
+	conn       *sql.Conn
+	releaseSQL string
+	name       string
+}
+
+/**
+ * 设置迁移锁模式
+ */
+func (mm *MigrationManager) SetLockMode(mode EnumMigrationLockMode) *MigrationManager {
+	mm.lockMode = mode
+	return mm
+}
+
+/**
+ * 设置等待迁移锁的超时时间（<= 0 表示一直等待，仅 Wait 模式生效）
+ */
+func (mm *MigrationManager) SetLockTimeout(timeout time.Duration) *MigrationManager {
+	mm.lockTimeout = timeout
+	return mm
+}
+
+/**
+ * 设置迁移锁名称（同一数据库中多套迁移目录互不阻塞时使用不同名称）
+ */
+func (mm *MigrationManager) SetLockName(name string) *MigrationManager {
+	if name != "" {
+		mm.lockName = name
+	}
+	return mm
+}
+
+/**
+ * 持有迁移锁执行操作
+ */
+func (mm *MigrationManager) withMigrationLock(operation string, fn func() error) error {
+	if mm.lockMode == EnumMigrationLockModeNone {
+		return fn()
+	}
+
+	lock, acquired, err := mm.acquireMigrationLock()
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		if mm.lockMode == EnumMigrationLockModeSkip {
+			LogInfo("迁移锁被其他实例持有，跳过%s: 锁=%s", operation, mm.lockName)
+			return nil
+		}
+		return NewMigrationLockTimeoutException(mm.lockName, mm.lockTimeout)
+	}
+	if lock == nil {
+		return fn()
+	}
+	defer lock.release()
+
+	LogInfo("已获取迁移锁: 锁=%s, 操作=%s", mm.lockName, operation)
+	return fn()
+}
+
+/**
+ * 获取迁移锁
+ *
+ * @return *migrationLock 已持有的锁，数据库不支持咨询锁时为 nil
+ * @return bool 是否获取成功（不支持时视为成功）
+ */
+func (mm *MigrationManager) acquireMigrationLock() (*migrationLock, bool, error) {
+	var acquireSQL, releaseSQL string
+	switch mm.db.DatabaseType {
+	case EnumDatabaseTypeMySQL:
+		acquireSQL, releaseSQL = "SELECT GET_LOCK(?, ?)", "SELECT RELEASE_LOCK(?)"
+	case EnumDatabaseTypeSQLServer:
+		acquireSQL = "DECLARE @result INT; EXEC @result = sp_getapplock @Resource = ?, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = ?; SELECT @result"
+		releaseSQL = "EXEC sp_releaseapplock @Resource = ?, @LockOwner = 'Session'"
+	case EnumDatabaseTypePostgreSQL:
+		acquireSQL, releaseSQL = "SELECT pg_try_advisory_lock(hashtext($1))", "SELECT pg_advisory_unlock(hashtext($1))"
+	default:
+		LogWarn("%s 不支持迁移锁，多实例同时迁移可能冲突", mm.db.DatabaseType)
+		return nil, true, nil
+	}
+
+	ctx := context.Background()
+	conn, err := mm.db.DataSource.Conn(ctx)
+	if err != nil {
+		return nil, false, NewConnectionExceptionWithCause(err, "获取迁移锁连接失败")
+	}
+
+	acquired, err := mm.tryAcquire(ctx, conn, acquireSQL)
+	if err != nil || !acquired {
+		conn.Close()
+		if err != nil {
+			return nil, false, NewQueryExceptionWithCause(err, "获取迁移锁失败: "+mm.lockName)
+		}
+		return nil, false, nil
+	}
+	return &migrationLock{conn: conn, releaseSQL: releaseSQL, name: mm.lockName}, true, nil
+}
+
+/**
+ * 按数据库语义尝试加锁（Skip 模式不等待）
+ */
+func (mm *MigrationManager) tryAcquire(ctx context.Context, conn *sql.Conn, acquireSQL string) (bool, error) {
+	wait := mm.lockMode == EnumMigrationLockModeWait
+	switch mm.db.DatabaseType {
+	case EnumDatabaseTypeMySQL:
+		// GET_LOCK 超时单位为秒，负数表示一直等待；返回 1 成功，0 超时
+		seconds := 0
+		if wait {
+			seconds = -1
+			if mm.lockTimeout > 0 {
+				seconds = int(math.Ceil(mm.lockTimeout.Seconds()))
+			}
+		}
+		var result sql.NullInt64
+		if err := conn.QueryRowContext(ctx, acquireSQL, mm.lockName, seconds).Scan(&result); err != nil {
+			return false, err
+		}
+		return result.Valid && result.Int64 == 1, nil
+
+	case EnumDatabaseTypeSQLServer:
+		// @LockTimeout 单位为毫秒，-1 表示一直等待；返回值 >= 0 成功，-1 超时
+		millis := int64(0)
+		if wait {
+			millis = -1
+			if mm.lockTimeout > 0 {
+				millis = mm.lockTimeout.Milliseconds()
+			}
+		}
+		var result int64
+		if err := conn.QueryRowContext(ctx, acquireSQL, mm.lockName, millis).Scan(&result); err != nil {
+			return false, err
+		}
+		if result < -1 {
+			return false, NewDb233Exception(fmt.Sprintf("sp_getapplock 返回错误码: %d", result))
+		}
+		return result >= 0, nil
+	}
+
+	// PostgreSQL：轮询 pg_try_advisory_lock
+	deadline := time.Now().Add(mm.lockTimeout)
+	for {
+		var locked bool
+		if err := conn.QueryRowContext(ctx, acquireSQL, mm.lockName).Scan(&locked); err != nil {
+			return false, err
+		}
+		if locked {
+			return true, nil
+		}
+		if !wait || (mm.lockTimeout > 0 && time.Now().After(deadline)) {
+			return false, nil
+		}
+		time.Sleep(postgresMigrationLockPollInterval)
+	}
+}
+
+/**
+ * 释放迁移锁并归还连接
+ *
+ * 释放失败时丢弃该连接（会话结束后数据库自动释放锁），避免带锁的连接回到连接池
+ */
+func (lock *migrationLock) release() {
+	var ignored interface{}
+	if err := lock.conn.QueryRowContext(context.Background(), lock.releaseSQL, lock.name).Scan(&ignored); err != nil && err != sql.ErrNoRows {
+		LogWarn("释放迁移锁失败，丢弃连接: 锁=%s, 错误=%v", lock.name, err)
+		lock.conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	}
+	lock.conn.Close()
+	LogInfo("已释放迁移锁: 锁=%s", lock.name)
+}
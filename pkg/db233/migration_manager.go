@@ -3,6 +3,7 @@ package db233
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -22,16 +23,30 @@ type MigrationManager struct {
 	db            *Db
 	tableName     string
 	migrationsDir string
+
+	// Go 代码迁移与迁移钩子（见 go_migration.go）
+	goMigrations map[int64]Migration
+	beforeHooks  []BeforeMigrationHook
+	afterHooks   []AfterMigrationHook
+
+	// 多实例迁移锁（见 migration_lock.go）
+	lockMode    EnumMigrationLockMode
+	lockTimeout time.Duration
+	lockName    string
 }
 
 /**
  * Migration - 迁移记录
+ *
+ * SQL 文件迁移使用 UpSQL / DownSQL，Go 代码迁移使用 UpFunc / DownFunc
  */
 type Migration struct {
 	Version   int64
 	Name      string
 	UpSQL     string
 	DownSQL   string
+	UpFunc    GoMigrationFunc
+	DownFunc  GoMigrationFunc
 	AppliedAt *time.Time
 }
 
@@ -43,6 +58,10 @@ func NewMigrationManager(db *Db, migrationsDir string) *MigrationManager {
 		db:            db,
 		tableName:     "schema_migrations",
 		migrationsDir: migrationsDir,
+		goMigrations:  make(map[int64]Migration),
+		lockMode:      EnumMigrationLockModeWait,
+		lockTimeout:   defaultMigrationLockTimeout,
+		lockName:      "db233_schema_migrations",
 	}
 }
 
@@ -71,36 +90,51 @@ func (mm *MigrationManager) Init() error {
  * 创建新的迁移文件
  */
 func (mm *MigrationManager) CreateMigration(name string) error {
+	_, err := mm.WriteMigration(name, "-- Add your up migration SQL here\n", "-- Add your down migration SQL here\n")
+	return err
+}
+
+/**
+ * 写入一对带时间戳版本号的迁移文件（{version}_{name}.up.sql / .down.sql）
+ *
+ * @param upSQL 上迁 SQL
+ * @param downSQL 下迁 SQL
+ * @return Migration 写入的迁移（未应用）
+ */
+func (mm *MigrationManager) WriteMigration(name string, upSQL string, downSQL string) (Migration, error) {
 	version := time.Now().Unix()
 	upFile := filepath.Join(mm.migrationsDir, fmt.Sprintf("%d_%s.up.sql", version, name))
 	downFile := filepath.Join(mm.migrationsDir, fmt.Sprintf("%d_%s.down.sql", version, name))
+	header := fmt.Sprintf("-- Migration: %s\n-- Version: %d\n-- Created: %s\n\n", name, version, time.Now().Format(time.RFC3339))
 
 	// 创建上迁文件
-	upContent := fmt.Sprintf("-- Migration: %s\n-- Version: %d\n-- Created: %s\n\n-- Add your up migration SQL here\n\n",
-		name, version, time.Now().Format(time.RFC3339))
-
+	upContent := header + upSQL + "\n"
 	err := ioutil.WriteFile(upFile, []byte(upContent), 0644)
 	if err != nil {
-		return NewConfigurationExceptionWithCause(err, "创建上迁文件失败")
+		return Migration{}, NewConfigurationExceptionWithCause(err, "创建上迁文件失败")
 	}
 
 	// 创建下迁文件
-	downContent := fmt.Sprintf("-- Migration: %s\n-- Version: %d\n-- Created: %s\n\n-- Add your down migration SQL here\n\n",
-		name, version, time.Now().Format(time.RFC3339))
-
+	downContent := header + downSQL + "\n"
 	err = ioutil.WriteFile(downFile, []byte(downContent), 0644)
 	if err != nil {
-		return NewConfigurationExceptionWithCause(err, "创建下迁文件失败")
+		return Migration{}, NewConfigurationExceptionWithCause(err, "创建下迁文件失败")
 	}
 
 	LogInfo("迁移文件已创建: %s", name)
-	return nil
+	return Migration{Version: version, Name: name, UpSQL: upContent, DownSQL: downContent}, nil
 }
 
 /**
- * 执行上迁
+ * 执行上迁（持有迁移锁，其他实例等待或跳过）
  */
 func (mm *MigrationManager) Up(steps int) error {
+	return mm.withMigrationLock("上迁", func() error {
+		return mm.up(steps)
+	})
+}
+
+func (mm *MigrationManager) up(steps int) error {
 	// 获取待应用的迁移
 	pendingMigrations, err := mm.getPendingMigrations()
 	if err != nil {
@@ -130,9 +164,15 @@ func (mm *MigrationManager) Up(steps int) error {
 }
 
 /**
- * 执行下迁
+ * 执行下迁（持有迁移锁）
  */
 func (mm *MigrationManager) Down(steps int) error {
+	return mm.withMigrationLock("下迁", func() error {
+		return mm.down(steps)
+	})
+}
+
+func (mm *MigrationManager) down(steps int) error {
 	// 获取已应用的迁移
 	appliedMigrations, err := mm.getAppliedMigrations()
 	if err != nil {
@@ -145,8 +185,8 @@ func (mm *MigrationManager) Down(steps int) error {
 	}
 
 	// 反转顺序（最新的先回滚）
-	for i := len(appliedMigrations) - 1; i >= 0; i-- {
-		appliedMigrations[i] = appliedMigrations[len(appliedMigrations)-1-i]
+	for i, j := 0, len(appliedMigrations)-1; i < j; i, j = i+1, j-1 {
+		appliedMigrations[i], appliedMigrations[j] = appliedMigrations[j], appliedMigrations[i]
 	}
 
 	// 限制步骤数
@@ -167,9 +207,15 @@ func (mm *MigrationManager) Down(steps int) error {
 }
 
 /**
- * 迁移到指定版本
+ * 迁移到指定版本（持有迁移锁）
  */
 func (mm *MigrationManager) MigrateToVersion(targetVersion int64) error {
+	return mm.withMigrationLock("迁移到指定版本", func() error {
+		return mm.migrateToVersion(targetVersion)
+	})
+}
+
+func (mm *MigrationManager) migrateToVersion(targetVersion int64) error {
 	currentVersion, err := mm.getCurrentVersion()
 	if err != nil {
 		return err
@@ -231,24 +277,36 @@ func (mm *MigrationManager) GetStatus() ([]Migration, error) {
  */
 func (mm *MigrationManager) applyMigration(migration Migration, isUp bool) error {
 	var sql string
+	var fn GoMigrationFunc
 	var operation string
 
 	if isUp {
-		sql = migration.UpSQL
+		sql, fn = migration.UpSQL, migration.UpFunc
 		operation = "应用"
 	} else {
-		sql = migration.DownSQL
+		sql, fn = migration.DownSQL, migration.DownFunc
 		operation = "回滚"
 	}
 
-	if sql == "" {
+	if sql == "" && fn == nil {
 		return fmt.Errorf("迁移 %d_%s 的 %s SQL 为空", migration.Version, migration.Name, strings.ToLower(operation))
 	}
 
+	// 前置钩子返回错误时不执行迁移
+	if err := mm.runBeforeHooks(migration, isUp); err != nil {
+		LogError("迁移前置钩子否决%s %d_%s: %v", operation, migration.Version, migration.Name, err)
+		return err
+	}
+
 	// 在事务中执行迁移
 	err := WithTransaction(mm.db, func(tm *TransactionManager) error {
-		// 执行迁移SQL
-		_, err := tm.Exec(sql)
+		// 执行 Go 迁移函数或迁移SQL
+		var err error
+		if fn != nil {
+			err = fn(tm)
+		} else {
+			_, err = tm.Exec(sql)
+		}
 		if err != nil {
 			return err
 		}
@@ -263,6 +321,7 @@ func (mm *MigrationManager) applyMigration(migration Migration, isUp bool) error
 
 		return err
 	})
+	mm.runAfterHooks(migration, isUp, err)
 
 	if err != nil {
 		LogError("%s迁移失败 %d_%s: %v", operation, migration.Version, migration.Name, err)
@@ -325,7 +384,8 @@ func (mm *MigrationManager) getAppliedMigrations() ([]Migration, error) {
 		migrations = append(migrations, migration)
 	}
 
-	return migrations, nil
+	// 迁移记录只有版本号，按版本补全下迁 SQL / Go 函数
+	return mm.fillMigrationContent(migrations)
 }
 
 /**
@@ -333,7 +393,7 @@ func (mm *MigrationManager) getAppliedMigrations() ([]Migration, error) {
  */
 func (mm *MigrationManager) getAllMigrations() ([]Migration, error) {
 	files, err := ioutil.ReadDir(mm.migrationsDir)
-	if err != nil {
+	if err != nil && !(os.IsNotExist(err) && len(mm.goMigrations) > 0) {
 		return nil, NewConfigurationExceptionWithCause(err, "读取迁移目录失败")
 	}
 
@@ -349,6 +409,12 @@ func (mm *MigrationManager) getAllMigrations() ([]Migration, error) {
 		}
 	}
 
+	// 合并 Go 代码迁移（与 SQL 文件按版本号交错执行）
+	migrations, err = mm.mergeGoMigrations(migrations)
+	if err != nil {
+		return nil, err
+	}
+
 	// 按版本排序
 	sort.Slice(migrations, func(i, j int) bool {
 		return migrations[i].Version < migrations[j].Version
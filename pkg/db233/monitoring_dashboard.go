@@ -24,6 +24,7 @@ type MonitoringDashboard struct {
 	alertManagers       map[string]*AlertManager
 	metricsCollectors   map[string]*MetricsCollector
 	metricsAggregators  map[string]*MetricsAggregator
+	circuitBreakers     map[string]*CircuitBreaker
 
 	// 报告生成器
 	reportGenerator *MonitoringReportGenerator
@@ -42,6 +43,7 @@ type MonitoringDashboard struct {
 	// 控制
 	enabled  bool
 	stopChan chan bool
+	stopOnce sync.Once
 }
 
 /**
@@ -54,6 +56,9 @@ type DashboardSnapshot struct {
 	Alerts       []AlertSummary
 	HealthStatus map[string]HealthSummary
 	Performance  map[string]PerformanceSummary
+
+	// 熔断器状态
+	CircuitBreakers map[string]CircuitBreakerSnapshot
 }
 
 /**
@@ -115,6 +120,7 @@ func NewMonitoringDashboard(name string) *MonitoringDashboard {
 		alertManagers:       make(map[string]*AlertManager),
 		metricsCollectors:   make(map[string]*MetricsCollector),
 		metricsAggregators:  make(map[string]*MetricsAggregator),
+		circuitBreakers:     make(map[string]*CircuitBreaker),
 		refreshInterval:     30 * time.Second,
 		autoRefresh:         true,
 		enabled:             true,
@@ -204,6 +210,18 @@ func (md *MonitoringDashboard) AddMetricsAggregator(name string, aggregator *Met
 	LogInfo("指标聚合器已添加到仪表板: %s -> %s", md.name, name)
 }
 
+/**
+ * 添加熔断器
+ */
+func (md *MonitoringDashboard) AddCircuitBreaker(name string, breaker *CircuitBreaker) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+
+	md.circuitBreakers[name] = breaker
+
+	LogInfo("熔断器已添加到仪表板: %s -> %s", md.name, name)
+}
+
 /**
  * 设置自动刷新间隔
  */
@@ -258,7 +276,7 @@ func (md *MonitoringDashboard) Start() {
 	LogInfo("监控仪表板启动: %s", md.name)
 
 	if md.autoRefresh {
-		go func() {
+		goSupervised("monitoring_dashboard:"+md.name, md.stopChan, func() {
 			ticker := time.NewTicker(md.refreshInterval)
 			defer ticker.Stop()
 
@@ -271,7 +289,7 @@ func (md *MonitoringDashboard) Start() {
 					return
 				}
 			}
-		}()
+		})
 	}
 }
 
@@ -279,12 +297,9 @@ func (md *MonitoringDashboard) Start() {
  * 停止仪表板
  */
 func (md *MonitoringDashboard) Stop() {
-	select {
-	case md.stopChan <- true:
-		// 成功发送停止信号
-	default:
-		// channel已满或没有接收者，忽略
-	}
+	md.stopOnce.Do(func() {
+		close(md.stopChan)
+	})
 }
 
 /**
@@ -305,6 +320,8 @@ func (md *MonitoringDashboard) refreshSnapshot() {
 		Alerts:       md.generateAlertSummaries(),
 		HealthStatus: make(map[string]HealthSummary),
 		Performance:  make(map[string]PerformanceSummary),
+
+		CircuitBreakers: make(map[string]CircuitBreakerSnapshot),
 	}
 
 	// 收集各组件状态
@@ -316,6 +333,10 @@ func (md *MonitoringDashboard) refreshSnapshot() {
 		snapshot.Performance[name] = md.generatePerformanceSummary(monitor)
 	}
 
+	for name, breaker := range md.circuitBreakers {
+		snapshot.CircuitBreakers[name] = breaker.GetSnapshot()
+	}
+
 	// 收集组件状态信息
 	components := make(map[string]interface{})
 
@@ -339,6 +360,10 @@ func (md *MonitoringDashboard) refreshSnapshot() {
 		components[fmt.Sprintf("aggregator_%s", name)] = aggregator.GetStatus()
 	}
 
+	for name, breaker := range md.circuitBreakers {
+		components[fmt.Sprintf("circuit_breaker_%s", name)] = breaker.GetSnapshot()
+	}
+
 	snapshot.Components = components
 	md.lastSnapshot = snapshot
 	md.lastUpdate = time.Now()
@@ -460,11 +485,10 @@ func (md *MonitoringDashboard) generateHealthSummary(name string, checker *Healt
 		ResponseTime: result.ResponseTime,
 	}
 
+	summary.Status = result.GetStatus()
 	if result.Healthy {
-		summary.Status = "healthy"
 		summary.Score = 1.0
 	} else {
-		summary.Status = "unhealthy"
 		summary.Score = 0.0
 	}
 
@@ -540,6 +564,7 @@ func (md *MonitoringDashboard) GetStatus() map[string]interface{} {
 		"alert_managers":       len(md.alertManagers),
 		"metrics_collectors":   len(md.metricsCollectors),
 		"metrics_aggregators":  len(md.metricsAggregators),
+		"circuit_breakers":     len(md.circuitBreakers),
 		"last_update":          md.lastUpdate,
 		"has_snapshot":         md.lastSnapshot != nil,
 	}
@@ -584,6 +609,10 @@ func (md *MonitoringDashboard) GetComponentStatus(componentType, name string) in
 		if aggregator, exists := md.metricsAggregators[name]; exists {
 			return aggregator.GetStatus()
 		}
+	case "circuit_breaker":
+		if breaker, exists := md.circuitBreakers[name]; exists {
+			return breaker.GetSnapshot()
+		}
 	}
 
 	return nil
@@ -613,6 +642,8 @@ func (md *MonitoringDashboard) alertStatusToString(status AlertStatus) string {
 		return "active"
 	case Resolved:
 		return "resolved"
+	case Acknowledged:
+		return "acknowledged"
 	default:
 		return "unknown"
 	}
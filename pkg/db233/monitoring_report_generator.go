@@ -26,6 +26,7 @@ type MonitoringReportGenerator struct {
 	healthCheckers      map[string]*HealthChecker
 	metricsCollectors   map[string]*MetricsCollector
 	alertManagers       map[string]*AlertManager
+	diagnostics         map[string]*DiagnosticsCollector
 
 	// 报告配置
 	reportTitle   string
@@ -52,10 +53,12 @@ type ReportData struct {
 type ReportSummary struct {
 	TotalDatabases   int     `json:"total_databases"`
 	HealthyDatabases int     `json:"healthy_databases"`
+	MaintenanceDbs   int     `json:"maintenance_databases"`
 	TotalQueries     int64   `json:"total_queries"`
 	AvgResponseTime  string  `json:"avg_response_time"`
 	ErrorRate        float64 `json:"error_rate"`
 	ActiveAlerts     int     `json:"active_alerts"`
+	SilencedAlerts   int     `json:"silenced_alerts"`
 	HealthScore      float64 `json:"health_score"`
 }
 
@@ -66,6 +69,9 @@ type ReportDetails struct {
 	Databases []DatabaseReport `json:"databases"`
 	Alerts    []AlertReport    `json:"alerts"`
 	Trends    []TrendReport    `json:"trends"`
+
+	// 报告周期内采集的锁诊断快照
+	LockDiagnostics []LockDiagnosticsReport `json:"lock_diagnostics,omitempty"`
 }
 
 /**
@@ -128,6 +134,21 @@ type AlertReport struct {
 	Threshold string    `json:"threshold"`
 	Timestamp time.Time `json:"timestamp"`
 	Duration  string    `json:"duration,omitempty"`
+
+	AcknowledgedBy   string     `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt   *time.Time `json:"acknowledged_at,omitempty"`
+	AckNote          string     `json:"ack_note,omitempty"`
+	ResolvedManually bool       `json:"resolved_manually,omitempty"`
+	Silenced         bool       `json:"silenced,omitempty"`
+	SilencedBy       string     `json:"silenced_by,omitempty"`
+}
+
+/**
+ * LockDiagnosticsReport - 锁诊断报告
+ */
+type LockDiagnosticsReport struct {
+	Database  string                     `json:"database"`
+	Snapshots []*LockDiagnosticsSnapshot `json:"snapshots"`
 }
 
 /**
@@ -160,6 +181,7 @@ func NewMonitoringReportGenerator(name string) *MonitoringReportGenerator {
 		healthCheckers:      make(map[string]*HealthChecker),
 		metricsCollectors:   make(map[string]*MetricsCollector),
 		alertManagers:       make(map[string]*AlertManager),
+		diagnostics:         make(map[string]*DiagnosticsCollector),
 		reportTitle:         "数据库监控报告",
 		reportPeriod:        time.Hour,
 		includeCharts:       true,
@@ -202,6 +224,13 @@ func (rg *MonitoringReportGenerator) AddAlertManager(name string, manager *Alert
 	rg.alertManagers[name] = manager
 }
 
+/**
+ * 添加锁诊断采集器
+ */
+func (rg *MonitoringReportGenerator) AddDiagnosticsCollector(name string, collector *DiagnosticsCollector) {
+	rg.diagnostics[name] = collector
+}
+
 /**
  * 设置报告标题
  */
@@ -268,6 +297,8 @@ func (rg *MonitoringReportGenerator) generateSummary() ReportSummary {
 		result := checker.Check()
 		if result.Healthy {
 			healthyCount++
+		} else if result.Maintenance {
+			summary.MaintenanceDbs++
 		}
 	}
 
@@ -305,13 +336,19 @@ func (rg *MonitoringReportGenerator) generateSummary() ReportSummary {
 	// 计算活跃告警数量
 	activeAlerts := 0
 	for _, manager := range rg.alertManagers {
-		activeAlerts += len(manager.GetActiveAlerts())
+		for _, alert := range manager.GetActiveAlerts() {
+			activeAlerts++
+			if alert.Silenced {
+				summary.SilencedAlerts++
+			}
+		}
 	}
 	summary.ActiveAlerts = activeAlerts
 
 	// 计算健康评分
-	if summary.TotalDatabases > 0 {
-		healthScore := float64(summary.HealthyDatabases) / float64(summary.TotalDatabases)
+	// 维护中的数据库不计入健康评分
+	if scoredDatabases := summary.TotalDatabases - summary.MaintenanceDbs; scoredDatabases > 0 {
+		healthScore := float64(summary.HealthyDatabases) / float64(scoredDatabases)
 		if summary.ErrorRate < 0.1 { // 错误率低于10%加分
 			healthScore += 0.2
 		}
@@ -332,11 +369,34 @@ func (rg *MonitoringReportGenerator) generateDetails() ReportDetails {
 		Databases: rg.generateDatabaseReports(),
 		Alerts:    rg.generateAlertReports(),
 		Trends:    rg.generateTrendReports(),
+
+		LockDiagnostics: rg.generateLockDiagnosticsReports(),
 	}
 
 	return details
 }
 
+/**
+ * 生成锁诊断报告（仅包含报告周期内的快照，按数据库名称排序）
+ */
+func (rg *MonitoringReportGenerator) generateLockDiagnosticsReports() []LockDiagnosticsReport {
+	reports := make([]LockDiagnosticsReport, 0)
+	since := time.Now().Add(-rg.reportPeriod)
+	for name, collector := range rg.diagnostics {
+		snapshots := make([]*LockDiagnosticsSnapshot, 0)
+		for _, snapshot := range collector.GetSnapshots() {
+			if snapshot.CapturedAt.After(since) {
+				snapshots = append(snapshots, snapshot)
+			}
+		}
+		if len(snapshots) > 0 {
+			reports = append(reports, LockDiagnosticsReport{Database: name, Snapshots: snapshots})
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Database < reports[j].Database })
+	return reports
+}
+
 /**
  * 生成数据库报告
  */
@@ -370,7 +430,7 @@ func (rg *MonitoringReportGenerator) generateDatabaseReports() []DatabaseReport
 			for checkType, result := range healthResults {
 				healthReport := HealthReport{
 					CheckType:    checkType,
-					Status:       rg.boolToStatus(result.Healthy),
+					Status:       result.GetStatus(),
 					ResponseTime: result.ResponseTime.String(),
 					Message:      result.Message,
 					Timestamp:    result.Timestamp,
@@ -479,6 +539,7 @@ func (rg *MonitoringReportGenerator) generateAlertReports() []AlertReport {
 			if alert.Duration != nil {
 				report.Duration = alert.Duration.String()
 			}
+			rg.fillAlertHandling(&report, alert)
 
 			reports = append(reports, report)
 		}
@@ -501,6 +562,7 @@ func (rg *MonitoringReportGenerator) generateAlertReports() []AlertReport {
 				if alert.Duration != nil {
 					report.Duration = alert.Duration.String()
 				}
+				rg.fillAlertHandling(&report, alert)
 
 				reports = append(reports, report)
 			}
@@ -663,11 +725,14 @@ func (rg *MonitoringReportGenerator) generateHealthChart() map[string]interface{
 
 	healthy := 0
 	unhealthy := 0
+	maintenance := 0
 
 	for _, checker := range rg.healthCheckers {
 		result := checker.Check()
 		if result.Healthy {
 			healthy++
+		} else if result.Maintenance {
+			maintenance++
 		} else {
 			unhealthy++
 		}
@@ -676,6 +741,7 @@ func (rg *MonitoringReportGenerator) generateHealthChart() map[string]interface{
 	chart["data"] = []map[string]interface{}{
 		{"name": "健康", "value": healthy, "color": "#00ff00"},
 		{"name": "不健康", "value": unhealthy, "color": "#ff0000"},
+		{"name": "维护中", "value": maintenance, "color": "#999999"},
 	}
 
 	return chart
@@ -724,13 +790,6 @@ func (rg *MonitoringReportGenerator) calculateHealthScore(report *DatabaseReport
 /**
  * 工具方法
  */
-func (rg *MonitoringReportGenerator) boolToStatus(healthy bool) string {
-	if healthy {
-		return "healthy"
-	}
-	return "unhealthy"
-}
-
 func (rg *MonitoringReportGenerator) healthScoreToStatus(score float64) string {
 	if score >= 0.8 {
 		return "excellent"
@@ -757,12 +816,26 @@ func (rg *MonitoringReportGenerator) alertSeverityToString(severity AlertSeverit
 	}
 }
 
+/**
+ * 填充告警的人工处理信息（确认 / 手动解决）
+ */
+func (rg *MonitoringReportGenerator) fillAlertHandling(report *AlertReport, alert *Alert) {
+	report.AcknowledgedBy = alert.AcknowledgedBy
+	report.AcknowledgedAt = alert.AcknowledgedAt
+	report.AckNote = alert.AckNote
+	report.ResolvedManually = alert.ResolvedManually
+	report.Silenced = alert.Silenced
+	report.SilencedBy = alert.SilencedBy
+}
+
 func (rg *MonitoringReportGenerator) alertStatusToString(status AlertStatus) string {
 	switch status {
 	case Active:
 		return "active"
 	case Resolved:
 		return "resolved"
+	case Acknowledged:
+		return "acknowledged"
 	default:
 		return "unknown"
 	}
@@ -784,6 +857,29 @@ func (rg *MonitoringReportGenerator) ExportReport(filename string, format string
 	}
 }
 
+/**
+ * 渲染报告内容（不落盘），供定时投递使用
+ *
+ * @param format 格式：json / text
+ * @return []byte 报告内容
+ */
+func (rg *MonitoringReportGenerator) RenderReport(format string) ([]byte, error) {
+	report := rg.GenerateReportData()
+
+	switch strings.ToLower(format) {
+	case "json":
+		content, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("渲染JSON报告失败: %w", err)
+		}
+		return content, nil
+	case "text":
+		return []byte(rg.generateTextReport(report)), nil
+	default:
+		return nil, fmt.Errorf("不支持的格式: %s", format)
+	}
+}
+
 /**
  * 导出JSON报告
  */
@@ -840,6 +936,9 @@ func (rg *MonitoringReportGenerator) generateTextReport(report *ReportData) stri
 	sb.WriteString("=== 摘要 ===\n")
 	sb.WriteString(fmt.Sprintf("数据库总数: %d\n", report.Summary.TotalDatabases))
 	sb.WriteString(fmt.Sprintf("健康数据库: %d\n", report.Summary.HealthyDatabases))
+	if report.Summary.MaintenanceDbs > 0 {
+		sb.WriteString(fmt.Sprintf("维护中数据库: %d\n", report.Summary.MaintenanceDbs))
+	}
 	sb.WriteString(fmt.Sprintf("总查询数: %d\n", report.Summary.TotalQueries))
 	sb.WriteString(fmt.Sprintf("平均响应时间: %s\n", report.Summary.AvgResponseTime))
 	sb.WriteString(fmt.Sprintf("错误率: %.2f%%\n", report.Summary.ErrorRate*100))
@@ -870,6 +969,27 @@ func (rg *MonitoringReportGenerator) generateTextReport(report *ReportData) stri
 			if alert.Duration != "" {
 				sb.WriteString(fmt.Sprintf("  持续时间: %s\n", alert.Duration))
 			}
+			if alert.AcknowledgedBy != "" {
+				sb.WriteString(fmt.Sprintf("  确认人: %s, 备注: %s\n", alert.AcknowledgedBy, alert.AckNote))
+			}
+			if alert.ResolvedManually {
+				sb.WriteString("  手动解决\n")
+			}
+			if alert.Silenced {
+				sb.WriteString(fmt.Sprintf("  已静默: %s\n", alert.SilencedBy))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	// 锁诊断
+	if len(report.Details.LockDiagnostics) > 0 {
+		sb.WriteString("=== 锁诊断 ===\n")
+		for _, diagnostics := range report.Details.LockDiagnostics {
+			sb.WriteString(fmt.Sprintf("数据库: %s\n", diagnostics.Database))
+			for _, snapshot := range diagnostics.Snapshots {
+				sb.WriteString(snapshot.String())
+			}
 		}
 		sb.WriteString("\n")
 	}
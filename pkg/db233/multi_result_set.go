@@ -0,0 +1,105 @@
+package db233
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+/**
+ * 多结果集查询
+ *
+ * ExecuteQuery 只映射第一个结果集，存储过程（CALL proc()）或多语句批量返回的其余结果集会被忽略。
+ * ExecuteMultiQuery 按顺序读取全部结果集，第 i 个结果集使用 returnTypes[i] 经 OrmHandler 映射：
+ *
+ *   sets, err := db.ExecuteMultiQuery("CALL order_summary(?)", []interface{}{userId}, &Order{}, &OrderItem{})
+ *   orders, items := sets[0], sets[1]
+ *
+ * returnTypes 中为 nil 或超出 returnTypes 数量的结果集会被读完并丢弃（对应位置为 nil），
+ * 连接不会残留未读的结果集。MySQL 执行多语句批量需要在 DSN 中开启 multiStatements=true
+ *
+ * 插件的 PostExecuteSql 收到的 Result 为 [][]interface{}，影响行数为已映射结果集的行数之和；
+ * 多结果集查询不支持插件短路（如查询缓存）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+
+/**
+ * 执行返回多个结果集的查询
+ *
+ * @param query SQL 语句
+ * @param params 参数
+ * @param returnTypes 各结果集的返回类型，按结果集顺序对应
+ * @return [][]interface{} 每个结果集的映射结果，长度为实际返回的结果集数量
+ * @return error 执行错误
+ */
+func (db *Db) ExecuteMultiQuery(query string, params []interface{}, returnTypes ...interface{}) ([][]interface{}, error) {
+	if err := db.checkQueryAllowed(query); err != nil {
+		return nil, err
+	}
+	timeout := db.statementTimeout()
+	result, err := db.executeWithPlugins(query, params, nil, func(query string, params []interface{}) (interface{}, int, error) {
+		sets, err := db.queryMultiOnce(query, params, returnTypes, timeout)
+		rowCount := 0
+		for _, set := range sets {
+			rowCount += len(set)
+		}
+		return sets, rowCount, err
+	})
+	sets, _ := result.([][]interface{})
+	if err != nil {
+		if _, ok := err.(*StatementTimeoutException); ok {
+			return sets, err
+		}
+		return sets, NewQueryExceptionWithCause(err, "多结果集查询执行失败: "+query)
+	}
+	return sets, nil
+}
+
+/**
+ * 执行单次多结果集查询（不经过插件）
+ */
+func (db *Db) queryMultiOnce(query string, params []interface{}, returnTypes []interface{}, timeout time.Duration) ([][]interface{}, error) {
+	var sets [][]interface{}
+	err := db.queryRows(query, params, timeout, func(rows *sql.Rows) error {
+		var err error
+		sets, err = db.ormResultSets(rows, returnTypes)
+		return err
+	})
+	return sets, err
+}
+
+/**
+ * 按顺序映射全部结果集并关闭 rows
+ */
+func (db *Db) ormResultSets(rows *sql.Rows, returnTypes []interface{}) ([][]interface{}, error) {
+	defer rows.Close()
+
+	sets := make([][]interface{}, 0, len(returnTypes))
+	for index := 0; ; index++ {
+		if index < len(returnTypes) && returnTypes[index] != nil {
+			set := OrmHandlerInstance.ormResultSet(rows, returnTypes[index], db.GetTimePolicy())
+			if set == nil {
+				set = make([]interface{}, 0)
+			}
+			sets = append(sets, set)
+		} else {
+			skipped := 0
+			for rows.Next() {
+				skipped++
+			}
+			if skipped > 0 {
+				LogDebug("未指定返回类型的结果集已丢弃: 第 %d 个, %d 行", index+1, skipped)
+			}
+			sets = append(sets, nil)
+		}
+		if err := rows.Err(); err != nil {
+			return sets, fmt.Errorf("读取第 %d 个结果集失败: %w", index+1, err)
+		}
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+	return sets, rows.Err()
+}
@@ -0,0 +1,181 @@
+package db233
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/**
+ * MySQL 协议兼容数据库的兼容模式
+ *
+ * TiDB、Vitess 使用 MySQL 协议与方言，但 DDL 能力与可重试错误不同，通过 Db.CompatibilityMode 开启：
+ *   - ALTER 不增删 AUTO_INCREMENT（TiDB 不支持通过 ALTER 修改自增属性，Vitess 在线 DDL 同样避免）
+ *   - 带 auto_random 标签的主键在 TiDB 下生成 AUTO_RANDOM（聚簇主键），其他模式退化为 AUTO_INCREMENT
+ *   - 实体实现 ITableShardingComment 时，建表语句保留其分片注释（如 TiDB 的 SHARD_ROW_ID_BITS 特殊注释）
+ *   - IsRetryableError 额外识别 TiDB 写冲突 / Region 不可用、Vitess 主库切换等瞬时错误
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type EnumMySQLCompatibilityMode string
+
+const (
+	// EnumMySQLCompatibilityModeNone 原生 MySQL
+	EnumMySQLCompatibilityModeNone EnumMySQLCompatibilityMode = ""
+	// EnumMySQLCompatibilityModeTiDB TiDB
+	EnumMySQLCompatibilityModeTiDB EnumMySQLCompatibilityMode = "tidb"
+	// EnumMySQLCompatibilityModeVitess Vitess
+	EnumMySQLCompatibilityModeVitess EnumMySQLCompatibilityMode = "vitess"
+)
+
+/**
+ * 判断是否为有效的兼容模式
+ */
+func (m EnumMySQLCompatibilityMode) IsValid() bool {
+	return m == EnumMySQLCompatibilityModeNone || m == EnumMySQLCompatibilityModeTiDB || m == EnumMySQLCompatibilityModeVitess
+}
+
+/**
+ * ITableShardingComment - 建表分片注释（可选接口）
+ *
+ * 返回的注释原样追加到 CREATE TABLE 的表选项之后，原生 MySQL 会忽略这类注释
+ */
+type ITableShardingComment interface {
+	// 如 TiDB 的 "/*T! SHARD_ROW_ID_BITS=4 PRE_SPLIT_REGIONS=2 */"
+	ShardingComment() string
+}
+
+/**
+ * 创建指定兼容模式的 MySQL 策略实例
+ */
+func NewMySQLStrategyWithCompatibility(cm *CrudManager, mode EnumMySQLCompatibilityMode) *MySQLStrategy {
+	return &MySQLStrategy{cm: cm, compatibilityMode: mode}
+}
+
+/**
+ * 获取兼容模式
+ */
+func (s *MySQLStrategy) GetCompatibilityMode() EnumMySQLCompatibilityMode {
+	return s.compatibilityMode
+}
+
+/**
+ * 获取自增列的修饰：TiDB 下 auto_random 字段为 AUTO_RANDOM[(n)]，其余为 AUTO_INCREMENT
+ *
+ * @return string 列修饰，非自增字段为空
+ * @return bool 是否为 AUTO_RANDOM
+ */
+func (s *MySQLStrategy) autoGenerateModifier(field reflect.StructField) (string, bool) {
+	if s.compatibilityMode == EnumMySQLCompatibilityModeTiDB && s.cm.IsAutoRandom(field) {
+		if bits := field.Tag.Get("auto_random"); bits != "" && bits != "true" {
+			if _, err := strconv.Atoi(bits); err == nil {
+				return " AUTO_RANDOM(" + bits + ")", true
+			}
+		}
+		return " AUTO_RANDOM", true
+	}
+	if s.cm.IsAutoIncrement(field) {
+		return " AUTO_INCREMENT", false
+	}
+	return "", false
+}
+
+/**
+ * ALTER 语句中是否允许自增修饰（兼容模式下不允许）
+ */
+func (s *MySQLStrategy) allowAutoIncrementInAlter(tableName string, colName string, field reflect.StructField) bool {
+	if s.compatibilityMode == EnumMySQLCompatibilityModeNone {
+		return true
+	}
+	if s.cm.IsAutoIncrement(field) {
+		LogWarn("%s 不支持通过 ALTER 修改自增属性，已忽略: 表=%s, 列=%s", s.compatibilityMode, tableName, colName)
+	}
+	return false
+}
+
+/**
+ * 获取实体声明的分片注释
+ */
+func shardingCommentOf(entityType reflect.Type) string {
+	if entityType.Kind() != reflect.Struct {
+		return ""
+	}
+	if provider, ok := reflect.New(entityType).Interface().(ITableShardingComment); ok {
+		return strings.TrimSpace(provider.ShardingComment())
+	}
+	return ""
+}
+
+/**
+ * 判断 auto_random 字段（db 标签 auto_random 选项或 auto_random:"true" / auto_random:"5"）
+ */
+func (cm *CrudManager) IsAutoRandom(field reflect.StructField) bool {
+	if strings.Contains(field.Tag.Get("db"), "auto_random") {
+		return true
+	}
+	bits := field.Tag.Get("auto_random")
+	return bits != "" && bits != "false"
+}
+
+/**
+ * 按 Db 的兼容模式获取建表策略（MySQL 兼容模式下返回对应模式的 MySQL 策略）
+ */
+func (f *TableCreationStrategyFactory) GetStrategyForDb(db *Db) ITableCreationStrategy {
+	strategy := f.GetStrategy(db.DatabaseType)
+	if db.CompatibilityMode == EnumMySQLCompatibilityModeNone {
+		return strategy
+	}
+	if mysqlStrategy, ok := strategy.(*MySQLStrategy); ok && mysqlStrategy.compatibilityMode != db.CompatibilityMode {
+		return NewMySQLStrategyWithCompatibility(mysqlStrategy.cm, db.CompatibilityMode)
+	}
+	return strategy
+}
+
+/**
+ * 是否为可重试的瞬时错误（死锁、锁等待超时、连接中断）
+ */
+func IsRetryableError(err error) bool {
+	return IsRetryableErrorForMode(err, EnumMySQLCompatibilityModeNone)
+}
+
+/**
+ * 按 Db 的兼容模式判断是否为可重试的瞬时错误
+ */
+func (db *Db) IsRetryableError(err error) bool {
+	return IsRetryableErrorForMode(err, db.CompatibilityMode)
+}
+
+/**
+ * 按兼容模式判断是否为可重试的瞬时错误
+ *
+ * TiDB：8002 / 8005 / 9007 写冲突，8022 事务重试，8027 / 8028 Schema 变更，9001 ~ 9005 PD / TiKV 超时与 Region 不可用
+ * Vitess：vttablet 返回的 Unavailable / Aborted / ResourceExhausted，以及主库切换期间的 not serving
+ */
+func IsRetryableErrorForMode(err error, mode EnumMySQLCompatibilityMode) bool {
+	if err == nil {
+		return false
+	}
+	if IsLockError(err) || isConnectionError(err) {
+		return true
+	}
+
+	message := err.Error()
+	switch mode {
+	case EnumMySQLCompatibilityModeTiDB:
+		for _, code := range []int{8002, 8005, 8022, 8027, 8028, 9001, 9002, 9003, 9004, 9005, 9007} {
+			if strings.Contains(message, fmt.Sprintf("Error %d", code)) {
+				return true
+			}
+		}
+		return strings.Contains(message, "Information schema is changed") ||
+			strings.Contains(message, "Write conflict")
+	case EnumMySQLCompatibilityModeVitess:
+		return strings.Contains(message, "code = Unavailable") ||
+			strings.Contains(message, "code = Aborted") ||
+			strings.Contains(message, "code = ResourceExhausted") ||
+			strings.Contains(message, "not serving")
+	}
+	return false
+}
@@ -17,6 +17,9 @@ import (
  */
 type MySQLStrategy struct {
 	cm *CrudManager
+
+	// TiDB / Vitess 兼容模式，见 EnumMySQLCompatibilityMode
+	compatibilityMode EnumMySQLCompatibilityMode
 }
 
 /**
@@ -45,10 +48,19 @@ func (s *MySQLStrategy) GenerateCreateTableSQL(tableName string, entityType refl
 	var primaryKeys []string
 
 	// 递归收集所有字段（包括嵌入结构体）
-	s.collectFieldsForCreateTable(entityType, tableName, uidColumn, &columns, &primaryKeys)
+	autoRandom := s.collectFieldsForCreateTable(entityType, tableName, uidColumn, &columns, &primaryKeys)
 
 	if len(primaryKeys) > 0 {
-		columns = append(columns, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primaryKeys, ", ")))
+		primaryKey := fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primaryKeys, ", "))
+		if autoRandom {
+			// AUTO_RANDOM 要求聚簇主键
+			primaryKey += " /*T![clustered_index] CLUSTERED */"
+		}
+		columns = append(columns, primaryKey)
+	}
+
+	for _, fk := range s.cm.GetForeignKeys(entityType, tableName) {
+		columns = append(columns, s.GenerateForeignKeyClause(fk))
 	}
 
 	if len(columns) == 0 {
@@ -56,6 +68,9 @@ func (s *MySQLStrategy) GenerateCreateTableSQL(tableName string, entityType refl
 	}
 
 	createSQL := fmt.Sprintf("CREATE TABLE `%s` (\n\t%s\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci", tableName, strings.Join(columns, ",\n\t"))
+	if comment := shardingCommentOf(entityType); comment != "" {
+		createSQL += " " + comment
+	}
 
 	LogDebug("生成 MySQL 建表SQL: 表=%s, SQL=%s", tableName, createSQL)
 	return createSQL, nil
@@ -63,8 +78,11 @@ func (s *MySQLStrategy) GenerateCreateTableSQL(tableName string, entityType refl
 
 /**
  * 递归收集字段用于建表（支持嵌入结构体）
+ *
+ * @return bool 是否包含 AUTO_RANDOM 列
  */
-func (s *MySQLStrategy) collectFieldsForCreateTable(entityType reflect.Type, tableName string, uidColumn string, columns *[]string, primaryKeys *[]string) {
+func (s *MySQLStrategy) collectFieldsForCreateTable(entityType reflect.Type, tableName string, uidColumn string, columns *[]string, primaryKeys *[]string) bool {
+	autoRandom := false
 	for i := 0; i < entityType.NumField(); i++ {
 		field := entityType.Field(i)
 		if !field.IsExported() {
@@ -82,7 +100,9 @@ func (s *MySQLStrategy) collectFieldsForCreateTable(entityType reflect.Type, tab
 			// 如果是结构体，递归收集
 			if embeddedType.Kind() == reflect.Struct {
 				LogDebug("递归收集嵌入结构体字段: 表=%s, 嵌入字段=%s", tableName, field.Name)
-				s.collectFieldsForCreateTable(embeddedType, tableName, uidColumn, columns, primaryKeys)
+				if s.collectFieldsForCreateTable(embeddedType, tableName, uidColumn, columns, primaryKeys) {
+					autoRandom = true
+				}
 				continue
 			}
 		}
@@ -101,10 +121,11 @@ func (s *MySQLStrategy) collectFieldsForCreateTable(entityType reflect.Type, tab
 		// 获取 db 标签（用于其他检查）
 		dbTag := field.Tag.Get("db")
 
-		// 检查是否自增（支持两种方式）
-		isAutoIncrement := s.cm.IsAutoIncrement(field)
-		if isAutoIncrement {
-			colDef += " AUTO_INCREMENT"
+		// 检查是否自增（支持两种方式，TiDB 下 auto_random 字段为 AUTO_RANDOM）
+		modifier, isAutoRandom := s.autoGenerateModifier(field)
+		colDef += modifier
+		if isAutoRandom {
+			autoRandom = true
 		}
 
 		// 判断是否为主键
@@ -122,12 +143,16 @@ func (s *MySQLStrategy) collectFieldsForCreateTable(entityType reflect.Type, tab
 			colDef += " NULL"
 		}
 
+		// 默认值与注释（default / comment 标签）
+		colDef += mysqlColumnOptions(field, colType)
+
 		*columns = append(*columns, colDef)
 
 		if isPrimaryKey {
 			*primaryKeys = append(*primaryKeys, fmt.Sprintf("`%s`", colName))
 		}
 	}
+	return autoRandom
 }
 
 /**
@@ -146,6 +171,11 @@ func (s *MySQLStrategy) GetSQLType(field reflect.StructField) string {
 		return typeTag
 	}
 
+	// 加密字段存储密文，长度不固定，使用 TEXT 类型
+	if IsEncryptedField(field) {
+		return "TEXT"
+	}
+
 	// 处理指针类型
 	kind := fieldType.Kind()
 	if kind == reflect.Ptr {
@@ -153,6 +183,24 @@ func (s *MySQLStrategy) GetSQLType(field reflect.StructField) string {
 		kind = fieldType.Kind()
 	}
 
+	// decimal 标签与 Decimal 字段使用 DECIMAL(p,s)
+	if sqlType := decimalColumnSQLType(field); sqlType != "" {
+		return sqlType
+	}
+
+	// 注册了类型转换器且声明了列类型的类型
+	if converter, exists := GetTypeConverterRegistryInstance().Get(fieldType); exists {
+		if sqlType := converter.GetSQLType(s.GetDatabaseType()); sqlType != "" {
+			return sqlType
+		}
+	}
+
+	// sql.NullString 等按其值类型建列
+	if valueType, ok := sqlNullValueType(fieldType); ok {
+		fieldType = valueType
+		kind = fieldType.Kind()
+	}
+
 	// 检查是否为复杂类型（map, slice, array），需要序列化为 JSON，使用 TEXT 类型
 	if s.isComplexTypeForSQL(kind, fieldType) {
 		LogDebug("检测到复杂类型字段，使用 TEXT 类型: 字段=%s, 类型=%s", field.Name, fieldType.String())
@@ -196,7 +244,7 @@ func (s *MySQLStrategy) GetSQLType(field reflect.StructField) string {
 		return "TINYINT(1)"
 	case reflect.Struct:
 		if fieldType == reflect.TypeOf(time.Time{}) {
-			return "TIMESTAMP"
+			return "TIMESTAMP" + timeColumnPrecisionSuffix(field)
 		}
 		// 其他结构体类型，使用 TEXT（需要序列化）
 		LogDebug("检测到结构体类型字段，使用 TEXT 类型: 字段=%s, 类型=%s", field.Name, fieldType.String())
@@ -297,9 +345,9 @@ func (s *MySQLStrategy) GenerateAddColumnSQL(tableName string, field reflect.Str
 
 	colDef := fmt.Sprintf("ADD COLUMN `%s` %s", colName, colType)
 
-	// 检查是否自增（支持两种方式）
+	// 检查是否自增（支持两种方式，兼容模式下不在 ALTER 中修改自增属性）
 	isAutoIncrement := s.cm.IsAutoIncrement(field)
-	if isAutoIncrement {
+	if isAutoIncrement && s.allowAutoIncrementInAlter(tableName, colName, field) {
 		colDef += " AUTO_INCREMENT"
 	}
 
@@ -313,6 +361,9 @@ func (s *MySQLStrategy) GenerateAddColumnSQL(tableName string, field reflect.Str
 		colDef += " NULL"
 	}
 
+	// 默认值与注释（NOT NULL 列带默认值时已有行会填充该值）
+	colDef += mysqlColumnOptions(field, colType)
+
 	return fmt.Sprintf("ALTER TABLE `%s` %s", tableName, colDef), nil
 }
 
@@ -375,8 +426,8 @@ func (s *MySQLStrategy) GenerateModifyColumnSQL(tableName string, field reflect.
 
 	colDef := fmt.Sprintf("MODIFY COLUMN `%s` %s", colName, colType)
 
-	// 检查是否自增
-	if strings.Contains(dbTag, "auto_increment") {
+	// 检查是否自增（兼容模式下不在 ALTER 中修改自增属性）
+	if strings.Contains(dbTag, "auto_increment") && s.allowAutoIncrementInAlter(tableName, colName, field) {
 		colDef += " AUTO_INCREMENT"
 	}
 
@@ -390,5 +441,44 @@ func (s *MySQLStrategy) GenerateModifyColumnSQL(tableName string, field reflect.
 		colDef += " NULL"
 	}
 
+	// 默认值与注释（MODIFY 未写出的默认值与注释会被清除）
+	colDef += mysqlColumnOptions(field, colType)
+
 	return fmt.Sprintf("ALTER TABLE `%s` %s", tableName, colDef), nil
 }
+
+/**
+ * 生成建表语句中的外键约束子句
+ */
+func (s *MySQLStrategy) GenerateForeignKeyClause(fk ForeignKeyDefinition) string {
+	return fmt.Sprintf("CONSTRAINT `%s` FOREIGN KEY (`%s`) REFERENCES `%s` (`%s`) ON DELETE %s ON UPDATE %s",
+		fk.Name, fk.Column, fk.RefTable, fk.RefColumn, fk.OnDelete, fk.OnUpdate)
+}
+
+/**
+ * 生成添加外键的 SQL
+ */
+func (s *MySQLStrategy) GenerateAddForeignKeySQL(tableName string, fk ForeignKeyDefinition) string {
+	return fmt.Sprintf("ALTER TABLE `%s` ADD %s", tableName, s.GenerateForeignKeyClause(fk))
+}
+
+/**
+ * 生成删除外键的 SQL
+ */
+func (s *MySQLStrategy) GenerateDropForeignKeySQL(tableName string, constraintName string) string {
+	return fmt.Sprintf("ALTER TABLE `%s` DROP FOREIGN KEY `%s`", tableName, constraintName)
+}
+
+/**
+ * 查询表上已有的外键
+ */
+func (s *MySQLStrategy) GetForeignKeys(db *Db, tableName string) (map[string]ForeignKeyDefinition, error) {
+	query := `
+		SELECT k.CONSTRAINT_NAME, k.COLUMN_NAME, k.REFERENCED_TABLE_NAME, k.REFERENCED_COLUMN_NAME, r.DELETE_RULE, r.UPDATE_RULE
+		FROM information_schema.KEY_COLUMN_USAGE k
+		JOIN information_schema.REFERENTIAL_CONSTRAINTS r
+			ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME
+		WHERE k.TABLE_SCHEMA = DATABASE() AND k.TABLE_NAME = ? AND k.REFERENCED_TABLE_NAME IS NOT NULL
+	`
+	return scanForeignKeys(db, query, tableName)
+}
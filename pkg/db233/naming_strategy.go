@@ -0,0 +1,181 @@
+package db233
+
+import (
+	"reflect"
+	"strings"
+)
+
+/**
+ * EnumNamingStyle - 表名 / 列名的命名风格
+ */
+type EnumNamingStyle int
+
+const (
+	// 默认：表名转为 snake_case，列名要求显式 db 标签
+	NamingStyleDefault EnumNamingStyle = iota
+	// 类型名 / 字段名转为 snake_case（PlayerItem -> player_item）
+	NamingStyleSnakeCase
+	// 原样使用类型名 / 字段名（PlayerItem -> PlayerItem）
+	NamingStylePassthrough
+)
+
+/**
+ * NamingStrategy - 命名策略
+ *
+ * 实体的 TableName() 返回空字符串时按策略从类型名生成表名；ColumnStyle 非默认时，
+ * 没有 db 标签的导出字段按策略生成列名（db:"-" 仍表示跳过）：
+ *
+ *   // 遗留库：表名与类型名一致（TableNamesLikeThis），列名与字段名一致
+ *   db233.GetCrudManagerInstance().SetNamingStrategy(&db233.NamingStrategy{
+ *       TableStyle:  db233.NamingStylePassthrough,
+ *       ColumnStyle: db233.NamingStylePassthrough,
+ *   })
+ *
+ *   // 单个实体使用单独的策略：PlayerItem -> game_player_items
+ *   db233.GetCrudManagerInstance().SetEntityNamingStrategy(&PlayerItem{},
+ *       &db233.NamingStrategy{TablePrefix: "game_", PluralTables: true})
+ *
+ * 策略应在初始化实体元数据（AutoInitEntity / 建表）之前设置；TableName() 返回非空时以其为准。
+ * 列名策略为全局设置，不支持按实体区分
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type NamingStrategy struct {
+	// 表名风格，默认 snake_case
+	TableStyle EnumNamingStyle
+
+	// 列名风格，默认要求显式 db 标签
+	ColumnStyle EnumNamingStyle
+
+	// 表名使用复数（player_item -> player_items，category -> categories）
+	PluralTables bool
+
+	// 表名前缀，如 game_
+	TablePrefix string
+}
+
+/**
+ * 按策略生成表名
+ */
+func (ns *NamingStrategy) TableName(typeName string) string {
+	if typeName == "" {
+		return ""
+	}
+	name := typeName
+	if ns.TableStyle != NamingStylePassthrough {
+		name = StringUtilsInstance.CamelToSnake(typeName)
+	}
+	if ns.PluralTables {
+		name = pluralizeName(name)
+	}
+	return ns.TablePrefix + name
+}
+
+/**
+ * 按策略生成列名，默认风格返回空字符串（要求显式 db 标签）
+ */
+func (ns *NamingStrategy) ColumnName(fieldName string) string {
+	switch ns.ColumnStyle {
+	case NamingStyleSnakeCase:
+		return StringUtilsInstance.CamelToSnake(fieldName)
+	case NamingStylePassthrough:
+		return fieldName
+	default:
+		return ""
+	}
+}
+
+/**
+ * 英文复数（只处理末尾单词的常见规则）
+ */
+func pluralizeName(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return name + "es"
+	case len(lower) > 1 && strings.HasSuffix(lower, "y") && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		if strings.HasSuffix(name, "Y") {
+			return name[:len(name)-1] + "IES"
+		}
+		return name[:len(name)-1] + "ies"
+	default:
+		return name + "s"
+	}
+}
+
+/**
+ * 设置全局命名策略（nil 恢复默认）
+ */
+func (cm *CrudManager) SetNamingStrategy(strategy *NamingStrategy) *CrudManager {
+	cm.namingMu.Lock()
+	defer cm.namingMu.Unlock()
+	cm.namingStrategy = strategy
+	return cm
+}
+
+/**
+ * 获取全局命名策略
+ */
+func (cm *CrudManager) GetNamingStrategy() *NamingStrategy {
+	cm.namingMu.RLock()
+	defer cm.namingMu.RUnlock()
+	if cm.namingStrategy == nil {
+		return &NamingStrategy{}
+	}
+	return cm.namingStrategy
+}
+
+/**
+ * 为单个实体设置表名策略（nil 表示使用全局策略）
+ */
+func (cm *CrudManager) SetEntityNamingStrategy(entity interface{}, strategy *NamingStrategy) *CrudManager {
+	t := reflect.TypeOf(entity)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	cm.namingMu.Lock()
+	defer cm.namingMu.Unlock()
+	if strategy == nil {
+		delete(cm.entityNamingStrategies, t)
+		return cm
+	}
+	if cm.entityNamingStrategies == nil {
+		cm.entityNamingStrategies = make(map[reflect.Type]*NamingStrategy)
+	}
+	cm.entityNamingStrategies[t] = strategy
+	return cm
+}
+
+/**
+ * 按命名策略生成类型的表名（实体策略优先于全局策略）
+ */
+func (cm *CrudManager) strategyTableName(t reflect.Type) string {
+	cm.namingMu.RLock()
+	strategy, ok := cm.entityNamingStrategies[t]
+	if !ok {
+		strategy = cm.namingStrategy
+	}
+	cm.namingMu.RUnlock()
+	if strategy == nil {
+		strategy = &NamingStrategy{}
+	}
+	return strategy.TableName(t.Name())
+}
+
+/**
+ * 按全局命名策略生成没有 db 标签的字段的列名（未导出字段、嵌入字段与默认风格返回空字符串）
+ */
+func (cm *CrudManager) strategyColumnName(field reflect.StructField) string {
+	if field.PkgPath != "" || field.Anonymous {
+		return ""
+	}
+	cm.namingMu.RLock()
+	strategy := cm.namingStrategy
+	cm.namingMu.RUnlock()
+	if strategy == nil {
+		return ""
+	}
+	return strategy.ColumnName(field.Name)
+}
@@ -0,0 +1,125 @@
+package db233
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+/**
+ * NULL 值映射
+ *
+ * 读取：
+ *   *T                      NULL 为 nil，非 NULL 时按 T 转换后取地址
+ *   sql.NullString 等       实现 sql.Scanner 的类型直接调用 Scan（NULL 时 Valid = false）
+ *   T                       NULL 为零值
+ * 写入：
+ *   nil 指针与 Valid = false 的 sql.Null* 写为 NULL；
+ *   带 omitempty 选项的字段为零值时不出现在 INSERT 列中，由数据库默认值（或 NULL）填充：
+ *
+ *   Nickname *string        `db:"nickname"`
+ *   Referrer sql.NullInt64  `db:"referrer_id"`
+ *   Level    int            `db:"level,omitempty"`
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+var (
+	sqlScannerType   = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	driverValuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+	// sql.Null* 对应的值类型（用于建表时推断列类型）
+	sqlNullValueTypes = map[reflect.Type]reflect.Type{
+		reflect.TypeOf(sql.NullString{}):  reflect.TypeOf(""),
+		reflect.TypeOf(sql.NullInt64{}):   reflect.TypeOf(int64(0)),
+		reflect.TypeOf(sql.NullInt32{}):   reflect.TypeOf(int32(0)),
+		reflect.TypeOf(sql.NullInt16{}):   reflect.TypeOf(int16(0)),
+		reflect.TypeOf(sql.NullByte{}):    reflect.TypeOf(uint8(0)),
+		reflect.TypeOf(sql.NullFloat64{}): reflect.TypeOf(float64(0)),
+		reflect.TypeOf(sql.NullBool{}):    reflect.TypeOf(false),
+		reflect.TypeOf(sql.NullTime{}):    timeType,
+	}
+
+	omitEmptyColumnsCache sync.Map
+)
+
+/**
+ * 目标类型是否通过 sql.Scanner 读取（指针接收者的 Scan）
+ */
+func isSqlScannerType(t reflect.Type) bool {
+	return t.Kind() != reflect.Ptr && reflect.PtrTo(t).Implements(sqlScannerType)
+}
+
+/**
+ * 类型（或其指向的类型）是否实现 driver.Valuer，由驱动自行转换，不按 JSON 序列化
+ */
+func isDriverValuerType(t reflect.Type) bool {
+	if t.Implements(driverValuerType) {
+		return true
+	}
+	return t.Kind() == reflect.Ptr && t.Elem().Implements(driverValuerType)
+}
+
+/**
+ * sql.Null* 对应的值类型
+ */
+func sqlNullValueType(t reflect.Type) (reflect.Type, bool) {
+	valueType, ok := sqlNullValueTypes[t]
+	return valueType, ok
+}
+
+/**
+ * 调用 sql.Scanner 读取驱动值（NULL 也交给 Scan 处理）
+ */
+func scanIntoScanner(source reflect.Value, targetType reflect.Type) (reflect.Value, error) {
+	var value interface{}
+	if source.IsValid() && !(source.Kind() == reflect.Interface && source.IsNil()) {
+		value = source.Interface()
+	}
+	target := reflect.New(targetType)
+	if err := target.Interface().(sql.Scanner).Scan(value); err != nil {
+		return reflect.Value{}, fmt.Errorf("扫描到 %s 失败: %w", targetType, err)
+	}
+	return target.Elem(), nil
+}
+
+/**
+ * 带 omitempty 选项的列（支持嵌入结构体，按类型缓存）
+ */
+func omitEmptyColumns(t reflect.Type) map[string]bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if cached, ok := omitEmptyColumnsCache.Load(t); ok {
+		return cached.(map[string]bool)
+	}
+	columns := make(map[string]bool)
+	if t.Kind() == reflect.Struct {
+		collectOmitEmptyColumnsRecursive(t, columns)
+	}
+	omitEmptyColumnsCache.Store(t, columns)
+	return columns
+}
+
+func collectOmitEmptyColumnsRecursive(t reflect.Type, columns map[string]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				collectOmitEmptyColumnsRecursive(embeddedType, columns)
+				continue
+			}
+		}
+		if hasDbTagOption(field, "omitempty") {
+			if parts := splitDbTag(field.Tag.Get("db")); len(parts) > 0 {
+				columns[parts[0]] = true
+			}
+		}
+	}
+}
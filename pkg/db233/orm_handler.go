@@ -29,8 +29,21 @@ type OrmHandler struct{}
  * @return []interface{} 映射后的对象列表
  */
 func (o *OrmHandler) OrmBatch(rows *sql.Rows, returnType interface{}) []interface{} {
+	return o.ormBatch(rows, returnType, GetDefaultTimePolicy())
+}
+
+/**
+ * 批量 ORM 映射（时间字段按 policy 转换时区与零值）
+ */
+func (o *OrmHandler) ormBatch(rows *sql.Rows, returnType interface{}, policy *TimePolicy) []interface{} {
 	defer rows.Close()
+	return o.ormResultSet(rows, returnType, policy)
+}
 
+/**
+ * 映射当前结果集（不关闭 rows，供多结果集逐个映射）
+ */
+func (o *OrmHandler) ormResultSet(rows *sql.Rows, returnType interface{}, policy *TimePolicy) []interface{} {
 	var results []interface{}
 
 	// 获取结构体类型
@@ -46,6 +59,10 @@ func (o *OrmHandler) OrmBatch(rows *sql.Rows, returnType interface{}) []interfac
 		return results
 	}
 
+	// 加密列（映射前解密）
+	fem := GetFieldEncryptionManagerInstance()
+	encryptedColumns := fem.getEncryptedColumnsByType(structType)
+
 	for rows.Next() {
 		// 创建新实例
 		newInstance := reflect.New(structType).Elem()
@@ -70,9 +87,23 @@ func (o *OrmHandler) OrmBatch(rows *sql.Rows, returnType interface{}) []interfac
 
 			if field.IsValid() && field.CanSet() {
 				val := reflect.ValueOf(scanTargets[i]).Elem()
+				if _, encrypted := encryptedColumns[col]; encrypted && val.IsValid() && !val.IsNil() {
+					plain, err := fem.decryptColumnValue(val.Interface())
+					if err != nil {
+						LogWarn("字段解密失败: 列=%s, 错误=%v", col, err)
+						continue
+					}
+					val = reflect.ValueOf(&plain).Elem()
+				}
 				if val.IsValid() {
-					// 处理类型转换（使用新的转换方法）
-					convertedVal, err := o.convertValue(val, field.Type())
+					// 处理类型转换（使用新的转换方法，时间字段按时间策略转换）
+					var convertedVal reflect.Value
+					var err error
+					if isTimeFieldType(field.Type()) {
+						convertedVal, err = policy.fromStorage(val, field.Type())
+					} else {
+						convertedVal, err = o.convertValue(val, field.Type())
+					}
 					if err != nil {
 						LogDebug("字段类型转换警告: 列=%s, 源类型=%s, 目标类型=%s, 错误=%v", col, val.Type(), field.Type(), err)
 						continue
@@ -152,6 +183,9 @@ func (o *OrmHandler) findFieldByColumnName(structValue reflect.Value, structType
 					return fieldValue
 				}
 			}
+		} else if GetCrudManagerInstance().strategyColumnName(structField) == columnName && fieldValue.CanSet() {
+			// 没有 db 标签时按命名策略生成的列名匹配
+			return fieldValue
 		}
 	}
 
@@ -180,7 +214,17 @@ func (o *OrmHandler) OrmSingle(rows *sql.Rows, returnType interface{}) interface
  * 处理 MySQL 返回的 []uint8 (byte array) 到各种 Go 类型的转换
  */
 func (o *OrmHandler) convertValue(sourceVal reflect.Value, targetType reflect.Type) (reflect.Value, error) {
-	// 如果源值是 nil，返回零值
+	// 注册了类型转换器的类型（含 *T）由转换器处理
+	if converter, exists := GetTypeConverterRegistryInstance().Get(targetType); exists {
+		return converter.fromDb(sourceVal, targetType)
+	}
+
+	// sql.NullString 等实现 sql.Scanner 的类型由 Scan 处理（包括 NULL）
+	if isSqlScannerType(targetType) {
+		return scanIntoScanner(sourceVal, targetType)
+	}
+
+	// 如果源值是 nil，返回零值（指针为 nil）
 	if !sourceVal.IsValid() || (sourceVal.Kind() == reflect.Interface && sourceVal.IsNil()) {
 		return reflect.Zero(targetType), nil
 	}
@@ -195,17 +239,29 @@ func (o *OrmHandler) convertValue(sourceVal reflect.Value, targetType reflect.Ty
 		return sourceVal, nil
 	}
 
+	// JSON 列：文本反序列化为 map / 切片 / 结构体；PostgreSQL 数组列以 { 开头，按数组字面量解析
+	if isJSONDecodableType(targetType) {
+		var text []byte
+		switch v := sourceVal.Interface().(type) {
+		case []byte:
+			text = v
+		case string:
+			text = []byte(v)
+		}
+		if text != nil {
+			if targetType.Kind() == reflect.Slice && strings.HasPrefix(strings.TrimSpace(string(text)), "{") {
+				return decodePostgreSQLArray(string(text), targetType)
+			}
+			return decodeJSONValue(text, targetType)
+		}
+	}
+
 	// 如果可以直接转换，使用 Convert
 	if sourceVal.Type().ConvertibleTo(targetType) {
 		return sourceVal.Convert(targetType), nil
 	}
 
-	// 特殊处理：[]uint8 (MySQL byte array) 转换
-	if sourceVal.Kind() == reflect.Slice && sourceVal.Type().Elem().Kind() == reflect.Uint8 {
-		return o.convertFromBytes(sourceVal.Interface().([]byte), targetType)
-	}
-
-	// 处理指针类型
+	// 处理指针类型（*T：非 NULL 时按 T 转换后取地址）
 	if targetType.Kind() == reflect.Ptr {
 		// 创建指针指向的类型的值
 		elemType := targetType.Elem()
@@ -218,6 +274,11 @@ func (o *OrmHandler) convertValue(sourceVal reflect.Value, targetType reflect.Ty
 		return ptrVal, nil
 	}
 
+	// 特殊处理：[]uint8 (MySQL byte array) 转换
+	if sourceVal.Kind() == reflect.Slice && sourceVal.Type().Elem().Kind() == reflect.Uint8 {
+		return o.convertFromBytes(sourceVal.Interface().([]byte), targetType)
+	}
+
 	return reflect.Value{}, fmt.Errorf("无法转换类型: %s -> %s", sourceVal.Type(), targetType)
 }
 
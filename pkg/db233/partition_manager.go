@@ -0,0 +1,597 @@
+package db233
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * PartitionManager - 表分区管理（MySQL / PostgreSQL 范围分区）
+ *
+ * 按实体声明分区方案（如按 created_at 每月一个分区），定期预建即将使用的分区，
+ * 并删除（可先归档）超出保留期的分区：
+ *
+ *   pm := db233.NewPartitionManager(db)
+ *   pm.Register(&Order{}, db233.NewMonthlyPartitionScheme("created_at").
+ *       SetPremake(3).
+ *       SetRetention(12).
+ *       SetArchiveTable("order_archive"))
+ *   pm.Start() // 启动时及每小时维护一次，也可手动调用 Maintain
+ *
+ * 分区命名：MySQL 为 p202610，PostgreSQL 为子表 order_p202610（按日分区为 p20261016），
+ * 名称不符合规则的分区不受管理。
+ * MySQL 表尚未分区时，首次维护把表转换为 RANGE COLUMNS 分区，当前周期之前的数据进入 p_history
+ * （分区列必须包含在主键中）；PostgreSQL 父表需在建表时声明 PARTITION BY RANGE。
+ *
+ * 创建分区受 EnumAutoDbOperateTypeCreatePartition 控制，删除 / 归档受 EnumAutoDbOperateTypeDropPartition 控制，
+ * 默认为 NewSafeAutoDbPermission（不删除过期分区，只在结果中报告）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type PartitionManager struct {
+	db          *Db
+	schemes     map[string]*PartitionScheme
+	permissions *AutoDbPermission
+
+	// 定时维护间隔
+	tickInterval time.Duration
+
+	running  bool
+	stopChan chan bool
+	mu       sync.RWMutex
+}
+
+/**
+ * PartitionInterval - 分区周期
+ */
+type PartitionInterval string
+
+const (
+	// PartitionIntervalDay 按日分区
+	PartitionIntervalDay PartitionInterval = "day"
+	// PartitionIntervalMonth 按月分区
+	PartitionIntervalMonth PartitionInterval = "month"
+)
+
+/**
+ * PartitionScheme - 表的分区方案
+ */
+type PartitionScheme struct {
+	// 表名（Register 时按实体填充）
+	Table string
+
+	// 分区列（日期 / 时间类型）
+	Column string
+
+	// 分区周期
+	Interval PartitionInterval
+
+	// 预建的未来分区数（不含当前周期），默认 3
+	Premake int
+
+	// 保留的历史分区数（不含当前周期），0 表示永久保留
+	Retention int
+
+	// 归档表：删除过期分区前把其中的数据复制到该表（结构需与原表一致），为空时直接删除
+	ArchiveTable string
+}
+
+/**
+ * PartitionInfo - 已存在的受管理分区
+ */
+type PartitionInfo struct {
+	Name string
+
+	// 分区范围 [Start, End)
+	Start time.Time
+	End   time.Time
+}
+
+/**
+ * PartitionMaintenanceResult - 单表维护结果
+ */
+type PartitionMaintenanceResult struct {
+	Table    string
+	Created  []string
+	Archived []string
+	Dropped  []string
+
+	// 因权限未执行的操作（如 "DROP p202501"）
+	Skipped []string
+}
+
+/**
+ * 创建分区方案
+ *
+ * @param column 分区列
+ * @param interval 分区周期
+ */
+func NewPartitionScheme(column string, interval PartitionInterval) *PartitionScheme {
+	return &PartitionScheme{Column: column, Interval: interval, Premake: 3}
+}
+
+/**
+ * 创建按月分区方案
+ */
+func NewMonthlyPartitionScheme(column string) *PartitionScheme {
+	return NewPartitionScheme(column, PartitionIntervalMonth)
+}
+
+/**
+ * 设置预建的未来分区数
+ */
+func (s *PartitionScheme) SetPremake(premake int) *PartitionScheme {
+	s.Premake = premake
+	return s
+}
+
+/**
+ * 设置保留的历史分区数（0 表示永久保留）
+ */
+func (s *PartitionScheme) SetRetention(retention int) *PartitionScheme {
+	s.Retention = retention
+	return s
+}
+
+/**
+ * 设置归档表
+ */
+func (s *PartitionScheme) SetArchiveTable(archiveTable string) *PartitionScheme {
+	s.ArchiveTable = archiveTable
+	return s
+}
+
+/**
+ * 时间所在周期的起点
+ */
+func (s *PartitionScheme) periodStart(t time.Time) time.Time {
+	if s.Interval == PartitionIntervalDay {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+/**
+ * 向后（n 为负时向前）移动 n 个周期
+ */
+func (s *PartitionScheme) addPeriods(start time.Time, n int) time.Time {
+	if s.Interval == PartitionIntervalDay {
+		return start.AddDate(0, 0, n)
+	}
+	return start.AddDate(0, n, 0)
+}
+
+func (s *PartitionScheme) nameLayout() string {
+	if s.Interval == PartitionIntervalDay {
+		return "20060102"
+	}
+	return "200601"
+}
+
+/**
+ * 分区名前缀：MySQL 为分区名，PostgreSQL 为子表名
+ */
+func (s *PartitionScheme) namePrefix(dbType EnumDatabaseType) string {
+	if dbType == EnumDatabaseTypePostgreSQL {
+		return s.Table + "_p"
+	}
+	return "p"
+}
+
+func (s *PartitionScheme) partitionName(dbType EnumDatabaseType, start time.Time) string {
+	return s.namePrefix(dbType) + start.Format(s.nameLayout())
+}
+
+/**
+ * 按名称解析受管理的分区，不符合命名规则时返回 false
+ */
+func (s *PartitionScheme) parsePartition(dbType EnumDatabaseType, name string, location *time.Location) (PartitionInfo, bool) {
+	prefix := s.namePrefix(dbType)
+	if !strings.HasPrefix(name, prefix) || len(name) != len(prefix)+len(s.nameLayout()) {
+		return PartitionInfo{}, false
+	}
+	start, err := time.ParseInLocation(s.nameLayout(), name[len(prefix):], location)
+	if err != nil {
+		return PartitionInfo{}, false
+	}
+	return PartitionInfo{Name: name, Start: start, End: s.addPeriods(start, 1)}, true
+}
+
+/**
+ * 创建分区管理器
+ */
+func NewPartitionManager(db *Db) *PartitionManager {
+	return &PartitionManager{
+		db:           db,
+		schemes:      make(map[string]*PartitionScheme),
+		permissions:  NewSafeAutoDbPermission(),
+		tickInterval: time.Hour,
+	}
+}
+
+/**
+ * 设置允许的分区操作（删除过期分区需显式开启 EnumAutoDbOperateTypeDropPartition）
+ */
+func (pm *PartitionManager) SetPermissions(permissions *AutoDbPermission) *PartitionManager {
+	if permissions != nil {
+		pm.mu.Lock()
+		pm.permissions = permissions
+		pm.mu.Unlock()
+	}
+	return pm
+}
+
+/**
+ * 设置定时维护间隔（默认 1 小时）
+ */
+func (pm *PartitionManager) SetTickInterval(interval time.Duration) *PartitionManager {
+	if interval > 0 {
+		pm.mu.Lock()
+		pm.tickInterval = interval
+		pm.mu.Unlock()
+	}
+	return pm
+}
+
+/**
+ * 声明表的分区方案
+ *
+ * @param entity 实体（实例或指针）或表名
+ * @param scheme 分区方案
+ */
+func (pm *PartitionManager) Register(entity interface{}, scheme *PartitionScheme) error {
+	if scheme == nil {
+		return NewValidationException("分区方案不能为空")
+	}
+	dbType := pm.db.DatabaseType
+	if dbType != EnumDatabaseTypeMySQL && dbType != EnumDatabaseTypePostgreSQL {
+		return NewValidationException(fmt.Sprintf("分区管理不支持数据库类型: %s", dbType))
+	}
+	if scheme.Interval != PartitionIntervalDay && scheme.Interval != PartitionIntervalMonth {
+		return NewValidationException(fmt.Sprintf("分区周期无效: %s", scheme.Interval))
+	}
+	if scheme.Premake < 0 || scheme.Retention < 0 {
+		return NewValidationException("预建分区数与保留分区数不能为负")
+	}
+
+	registered := *scheme
+	if table, ok := entity.(string); ok {
+		registered.Table = table
+	} else {
+		metadata, err := GetEntityMetadataCacheInstance().GetOrBuild(entity)
+		if err != nil {
+			return err
+		}
+		if !containsColumn(metadata.AllColumns, scheme.Column) {
+			return NewValidationException(fmt.Sprintf("分区列不存在: %s.%s", metadata.TableName, scheme.Column))
+		}
+		registered.Table = metadata.TableName
+	}
+	for _, identifier := range []string{registered.Table, registered.Column} {
+		if !isJoinIdentifier(identifier) {
+			return NewValidationException(fmt.Sprintf("标识符无效: %s", identifier))
+		}
+	}
+	if registered.ArchiveTable != "" && !isJoinIdentifier(registered.ArchiveTable) {
+		return NewValidationException(fmt.Sprintf("归档表名无效: %s", registered.ArchiveTable))
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.schemes[registered.Table] = &registered
+	return nil
+}
+
+/**
+ * 获取已声明的分区方案（按表名排序）
+ */
+func (pm *PartitionManager) GetSchemes() []PartitionScheme {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	result := make([]PartitionScheme, 0, len(pm.schemes))
+	for _, scheme := range pm.schemes {
+		result = append(result, *scheme)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Table < result[j].Table })
+	return result
+}
+
+/**
+ * 获取表的受管理分区（按范围排序）
+ */
+func (pm *PartitionManager) GetPartitions(table string) ([]PartitionInfo, error) {
+	scheme, err := pm.scheme(table)
+	if err != nil {
+		return nil, err
+	}
+	names, err := pm.listPartitionNames(table)
+	if err != nil {
+		return nil, err
+	}
+	return pm.managedPartitions(scheme, names, time.Local), nil
+}
+
+/**
+ * 维护全部已声明的表（单表失败不影响其余表，返回第一个错误）
+ */
+func (pm *PartitionManager) Maintain(now time.Time) ([]*PartitionMaintenanceResult, error) {
+	schemes := pm.GetSchemes()
+	results := make([]*PartitionMaintenanceResult, 0, len(schemes))
+	var firstErr error
+	for _, scheme := range schemes {
+		result, err := pm.MaintainTable(scheme.Table, now)
+		if err != nil {
+			LogError("分区维护失败: %s, %v", scheme.Table, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		if result != nil {
+			results = append(results, result)
+		}
+	}
+	return results, firstErr
+}
+
+/**
+ * 维护单个表：预建当前及未来的分区，删除（可先归档）过期分区
+ *
+ * @param now 当前时间，周期边界按其时区计算
+ * @return *PartitionMaintenanceResult 已执行的操作（出错时为出错前的部分）
+ */
+func (pm *PartitionManager) MaintainTable(table string, now time.Time) (*PartitionMaintenanceResult, error) {
+	scheme, err := pm.scheme(table)
+	if err != nil {
+		return nil, err
+	}
+	pm.mu.RLock()
+	permissions := pm.permissions
+	pm.mu.RUnlock()
+
+	names, err := pm.listPartitionNames(table)
+	if err != nil {
+		return nil, err
+	}
+	result := &PartitionMaintenanceResult{Table: table}
+	current := scheme.periodStart(now)
+	if err := pm.createPartitions(scheme, names, current, permissions, result); err != nil {
+		return result, err
+	}
+	if scheme.Retention > 0 {
+		cutoff := scheme.addPeriods(current, -scheme.Retention)
+		if err := pm.dropExpired(scheme, pm.managedPartitions(scheme, names, now.Location()), cutoff, permissions, result); err != nil {
+			return result, err
+		}
+	}
+	if len(result.Created) > 0 || len(result.Dropped) > 0 {
+		LogInfo("分区维护完成: %s, 创建=%v, 删除=%v", table, result.Created, result.Dropped)
+	}
+	return result, nil
+}
+
+/**
+ * 启动定时维护（启动时立即执行一次）
+ */
+func (pm *PartitionManager) Start() {
+	pm.mu.Lock()
+	if pm.running {
+		pm.mu.Unlock()
+		return
+	}
+	pm.running = true
+	pm.stopChan = make(chan bool)
+	tickInterval := pm.tickInterval
+	pm.mu.Unlock()
+
+	LogInfo("分区管理器启动，维护间隔: %v", tickInterval)
+
+	goSupervised("partition_manager", pm.stopChan, func() {
+		pm.Maintain(time.Now())
+
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				pm.Maintain(now)
+			case <-pm.stopChan:
+				LogInfo("分区管理器停止")
+				return
+			}
+		}
+	})
+}
+
+/**
+ * 停止定时维护
+ */
+func (pm *PartitionManager) Stop() {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if !pm.running {
+		return
+	}
+	pm.running = false
+	close(pm.stopChan)
+}
+
+func (pm *PartitionManager) scheme(table string) (*PartitionScheme, error) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	scheme, exists := pm.schemes[table]
+	if !exists {
+		return nil, NewValidationException(fmt.Sprintf("表未声明分区方案: %s", table))
+	}
+	return scheme, nil
+}
+
+/**
+ * 查询表的全部分区名（包括不受管理的分区）
+ */
+func (pm *PartitionManager) listPartitionNames(table string) ([]string, error) {
+	query := "SELECT PARTITION_NAME FROM information_schema.PARTITIONS " +
+		"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND PARTITION_NAME IS NOT NULL"
+	if pm.db.DatabaseType == EnumDatabaseTypePostgreSQL {
+		query = "SELECT c.relname FROM pg_inherits i " +
+			"JOIN pg_class c ON c.oid = i.inhrelid JOIN pg_class p ON p.oid = i.inhparent WHERE p.relname = $1"
+	}
+	rows, err := pm.db.DataSource.Query(query, table)
+	if err != nil {
+		return nil, NewQueryExceptionWithCause(err, "查询分区失败: "+table)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, NewQueryExceptionWithCause(err, "扫描分区失败: "+table)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func (pm *PartitionManager) managedPartitions(scheme *PartitionScheme, names []string, location *time.Location) []PartitionInfo {
+	partitions := make([]PartitionInfo, 0, len(names))
+	for _, name := range names {
+		if partition, ok := scheme.parsePartition(pm.db.DatabaseType, name, location); ok {
+			partitions = append(partitions, partition)
+		}
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].Start.Before(partitions[j].Start) })
+	return partitions
+}
+
+/**
+ * 创建当前周期及之后 Premake 个周期中缺少的分区
+ *
+ * MySQL 只能在最后一个分区之后追加，已有分区覆盖的周期不再创建
+ */
+func (pm *PartitionManager) createPartitions(scheme *PartitionScheme, names []string, current time.Time, permissions *AutoDbPermission, result *PartitionMaintenanceResult) error {
+	dbType := pm.db.DatabaseType
+	existing := make(map[string]bool, len(names))
+	for _, name := range names {
+		existing[name] = true
+	}
+	var coveredUntil time.Time
+	if partitions := pm.managedPartitions(scheme, names, current.Location()); len(partitions) > 0 {
+		coveredUntil = partitions[len(partitions)-1].End
+	}
+
+	var missing []time.Time
+	for i := 0; i <= scheme.Premake; i++ {
+		start := scheme.addPeriods(current, i)
+		if existing[scheme.partitionName(dbType, start)] {
+			continue
+		}
+		if dbType == EnumDatabaseTypeMySQL && start.Before(coveredUntil) {
+			continue
+		}
+		missing = append(missing, start)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if !permissions.IsAllowed(EnumAutoDbOperateTypeCreatePartition) {
+		for _, start := range missing {
+			result.Skipped = append(result.Skipped, "CREATE "+scheme.partitionName(dbType, start))
+		}
+		LogWarn("没有创建分区的权限，跳过: %s, %v", scheme.Table, result.Skipped)
+		return nil
+	}
+
+	// MySQL 表尚未分区：一次性转换，当前周期之前的数据进入 p_history
+	if dbType == EnumDatabaseTypeMySQL && len(names) == 0 {
+		definitions := []string{fmt.Sprintf("PARTITION p_history VALUES LESS THAN ('%s')", partitionBoundary(current))}
+		for _, start := range missing {
+			definitions = append(definitions, pm.mysqlPartitionDefinition(scheme, start))
+		}
+		sql := fmt.Sprintf("ALTER TABLE %s PARTITION BY RANGE COLUMNS(%s) (%s)", scheme.Table, scheme.Column, strings.Join(definitions, ", "))
+		if err := pm.execDdl(sql); err != nil {
+			return err
+		}
+		result.Created = append(result.Created, "p_history")
+		for _, start := range missing {
+			result.Created = append(result.Created, scheme.partitionName(dbType, start))
+		}
+		return nil
+	}
+
+	for _, start := range missing {
+		name := scheme.partitionName(dbType, start)
+		sql := fmt.Sprintf("ALTER TABLE %s ADD PARTITION (%s)", scheme.Table, pm.mysqlPartitionDefinition(scheme, start))
+		if dbType == EnumDatabaseTypePostgreSQL {
+			sql = fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')",
+				name, scheme.Table, partitionBoundary(start), partitionBoundary(scheme.addPeriods(start, 1)))
+		}
+		if err := pm.execDdl(sql); err != nil {
+			return err
+		}
+		result.Created = append(result.Created, name)
+	}
+	return nil
+}
+
+func (pm *PartitionManager) mysqlPartitionDefinition(scheme *PartitionScheme, start time.Time) string {
+	return fmt.Sprintf("PARTITION %s VALUES LESS THAN ('%s')",
+		scheme.partitionName(EnumDatabaseTypeMySQL, start), partitionBoundary(scheme.addPeriods(start, 1)))
+}
+
+/**
+ * 删除范围完全早于 cutoff 的分区，设置了归档表时先复制数据
+ */
+func (pm *PartitionManager) dropExpired(scheme *PartitionScheme, partitions []PartitionInfo, cutoff time.Time, permissions *AutoDbPermission, result *PartitionMaintenanceResult) error {
+	allowed := permissions.IsAllowed(EnumAutoDbOperateTypeDropPartition)
+	for _, partition := range partitions {
+		if partition.End.After(cutoff) {
+			continue
+		}
+		if !allowed {
+			result.Skipped = append(result.Skipped, "DROP "+partition.Name)
+			LogWarn("没有删除分区的权限，过期分区保留: %s.%s", scheme.Table, partition.Name)
+			continue
+		}
+
+		if scheme.ArchiveTable != "" {
+			from, to := "?", "?"
+			if pm.db.DatabaseType == EnumDatabaseTypePostgreSQL {
+				from, to = "$1", "$2"
+			}
+			sql := fmt.Sprintf("INSERT INTO %s SELECT * FROM %s WHERE %s >= %s AND %s < %s",
+				scheme.ArchiveTable, scheme.Table, scheme.Column, from, scheme.Column, to)
+			if _, err := pm.db.DataSource.Exec(sql, partitionBoundary(partition.Start), partitionBoundary(partition.End)); err != nil {
+				return NewDb233ExceptionWithCause(err, fmt.Sprintf("归档分区失败: %s.%s", scheme.Table, partition.Name))
+			}
+			result.Archived = append(result.Archived, partition.Name)
+		}
+
+		sql := fmt.Sprintf("ALTER TABLE %s DROP PARTITION %s", scheme.Table, partition.Name)
+		if pm.db.DatabaseType == EnumDatabaseTypePostgreSQL {
+			sql = "DROP TABLE IF EXISTS " + partition.Name
+		}
+		if err := pm.execDdl(sql); err != nil {
+			return err
+		}
+		result.Dropped = append(result.Dropped, partition.Name)
+	}
+	return nil
+}
+
+func (pm *PartitionManager) execDdl(sql string) error {
+	if _, err := pm.db.DataSource.Exec(sql); err != nil {
+		return NewDb233ExceptionWithCause(err, "分区操作失败: "+sql)
+	}
+	LogDebug("分区操作已执行: %s", sql)
+	return nil
+}
+
+func partitionBoundary(t time.Time) string {
+	return t.Format("2006-01-02")
+}
@@ -25,6 +25,8 @@ type PerformanceMonitor struct {
 	failedQueries     int64
 	slowQueries       int64
 	verySlowQueries   int64
+	timedOutQueries   int64
+	killedQueries     int64
 
 	// 时间统计
 	totalQueryTime    time.Duration
@@ -50,6 +52,9 @@ type PerformanceMonitor struct {
 	errorCount map[string]int64
 	lastErrors []ErrorRecord
 
+	// 按查询标签的统计（见 query_tag.go）
+	queryTags map[string]*queryTagAccumulator
+
 	// 阈值设置
 	slowQueryThreshold     time.Duration
 	verySlowQueryThreshold time.Duration
@@ -212,13 +217,44 @@ func (pm *PerformanceMonitor) RecordQuery(query string, duration time.Duration,
 	if duration >= pm.verySlowQueryThreshold {
 		pm.verySlowQueries++
 		pm.verySlowQueryTime += duration
-		LogWarn("非常慢查询 [%s]: %v, 查询: %s", pm.dbGroupName, duration, query)
+		LogFields(WARN, "非常慢查询", "group", pm.dbGroupName, "duration", duration, "sql", query)
 	}
 
 	// 时间窗口统计
 	pm.updateTimeWindowStats(duration)
 }
 
+/**
+ * 记录语句超时事件
+ *
+ * @param query 超时的 SQL
+ * @param timeout 超时阈值
+ * @param killed 是否已在服务端终止（KILL QUERY）
+ */
+func (pm *PerformanceMonitor) RecordStatementTimeout(query string, timeout time.Duration, killed bool) {
+	if !pm.enabled {
+		return
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.timedOutQueries++
+	if killed {
+		pm.killedQueries++
+	}
+
+	pm.lastErrors = append(pm.lastErrors, ErrorRecord{
+		Timestamp: time.Now(),
+		Error:     NewStatementTimeoutException(query, timeout, killed),
+		Query:     query,
+		Duration:  timeout,
+	})
+	if len(pm.lastErrors) > pm.maxErrorsToKeep {
+		pm.lastErrors = pm.lastErrors[1:]
+	}
+}
+
 /**
  * 记录连接获取
  */
@@ -350,6 +386,8 @@ func (pm *PerformanceMonitor) GetDetailedReport() map[string]interface{} {
 	report["failed_queries"] = pm.failedQueries
 	report["slow_queries"] = pm.slowQueries
 	report["very_slow_queries"] = pm.verySlowQueries
+	report["timed_out_queries"] = pm.timedOutQueries
+	report["killed_queries"] = pm.killedQueries
 
 	// 成功率和错误率
 	if pm.totalQueries > 0 {
@@ -429,6 +467,21 @@ func (pm *PerformanceMonitor) GetDetailedReport() map[string]interface{} {
 		"p99_response_time": pm.windowStats.P99ResponseTime.String(),
 	}
 
+	// 按查询标签的统计
+	if len(pm.queryTags) > 0 {
+		tags := make(map[string]interface{}, len(pm.queryTags))
+		for tag, acc := range pm.queryTags {
+			tags[tag] = map[string]interface{}{
+				"query_count":    acc.queryCount,
+				"error_count":    acc.errorCount,
+				"slow_count":     acc.slowCount,
+				"avg_query_time": (acc.totalTime / time.Duration(acc.queryCount)).String(),
+				"max_query_time": acc.maxTime.String(),
+			}
+		}
+		report["query_tags"] = tags
+	}
+
 	// 阈值设置
 	report["thresholds"] = map[string]interface{}{
 		"slow_query_threshold":      pm.slowQueryThreshold.String(),
@@ -471,6 +524,8 @@ func (pm *PerformanceMonitor) Reset() {
 	pm.failedQueries = 0
 	pm.slowQueries = 0
 	pm.verySlowQueries = 0
+	pm.timedOutQueries = 0
+	pm.killedQueries = 0
 
 	pm.totalQueryTime = 0
 	pm.minQueryTime = time.Hour
@@ -491,6 +546,7 @@ func (pm *PerformanceMonitor) Reset() {
 
 	pm.errorCount = make(map[string]int64)
 	pm.lastErrors = make([]ErrorRecord, 0)
+	pm.queryTags = nil
 
 	pm.windowStart = time.Now()
 	pm.windowStats = &TimeWindowStats{
@@ -540,6 +596,12 @@ func (pm *PerformanceMonitor) GetMetrics() map[string]interface{} {
 	if val, ok := report["very_slow_queries"].(int64); ok {
 		metrics["very_slow_queries"] = val
 	}
+	if val, ok := report["timed_out_queries"].(int64); ok {
+		metrics["timed_out_queries"] = val
+	}
+	if val, ok := report["killed_queries"].(int64); ok {
+		metrics["killed_queries"] = val
+	}
 
 	// 连接指标
 	if val, ok := report["connection_acquired"].(int64); ok {
@@ -562,6 +624,15 @@ func (pm *PerformanceMonitor) GetMetrics() map[string]interface{} {
 		metrics["error_count"] = val
 	}
 
+	// 按查询标签的指标，如 tag.leaderboard.refresh.p99_ms
+	for _, stats := range pm.GetQueryTagStats() {
+		prefix := "tag." + stats.Tag + "."
+		metrics[prefix+"query_count"] = stats.QueryCount
+		metrics[prefix+"error_count"] = stats.ErrorCount
+		metrics[prefix+"avg_ms"] = float64(stats.AvgTime.Nanoseconds()) / 1000000.0
+		metrics[prefix+"p99_ms"] = float64(stats.P99Time.Nanoseconds()) / 1000000.0
+	}
+
 	return metrics
 }
 
@@ -0,0 +1,198 @@
+package db233
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/**
+ * PostgreSQL 原生数组列
+ *
+ * 在 PostgreSQL 上，db_type 以 [] 结尾的切片字段（如 TEXT[]、INTEGER[]）按数组字面量 {a,b} 读写，
+ * 不再序列化为 JSON 文本；其他数据库仍按 JSON 处理：
+ *
+ *   Tags   []string `db:"tags" db_type:"TEXT[]"`
+ *   Scores []int    `db:"scores" db_type:"INTEGER[]"`
+ *
+ * 只支持一维数组，元素为字符串 / 整数 / 浮点 / 布尔（含以它们为底层类型的自定义类型）；
+ * 查询条件见 ConditionBuilder.WhereArrayAny / WhereArrayContains / WhereArrayOverlaps
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type ArrayDialect interface {
+	/**
+	 * 生成 ? = ANY(column) 条件（数组中存在等于 value 的元素）
+	 */
+	ArrayAny(column string, value interface{}) (string, []interface{}, error)
+
+	/**
+	 * 生成 column @> ? 条件（数组包含 values 的全部元素）
+	 */
+	ArrayContains(column string, values interface{}) (string, []interface{}, error)
+
+	/**
+	 * 生成 column && ? 条件（数组与 values 至少有一个相同元素）
+	 */
+	ArrayOverlaps(column string, values interface{}) (string, []interface{}, error)
+}
+
+/**
+ * 字段是否声明为 PostgreSQL 数组列（db_type 以 [] 结尾）
+ */
+func isPostgreSQLArrayColumn(field reflect.StructField) bool {
+	return strings.HasSuffix(strings.TrimSpace(field.Tag.Get("db_type")), "[]")
+}
+
+/**
+ * PostgreSQL：? = ANY(column)
+ */
+func (d *PostgreSQLDialect) ArrayAny(column string, value interface{}) (string, []interface{}, error) {
+	if err := validateJSONColumn(column); err != nil {
+		return "", nil, err
+	}
+	if value == nil {
+		return "", nil, NewValidationException("数组条件的值不能为 nil")
+	}
+	return fmt.Sprintf("? = ANY(%s)", column), []interface{}{value}, nil
+}
+
+/**
+ * PostgreSQL：column @> ?
+ */
+func (d *PostgreSQLDialect) ArrayContains(column string, values interface{}) (string, []interface{}, error) {
+	return arrayOperatorCondition(column, "@>", values)
+}
+
+/**
+ * PostgreSQL：column && ?
+ */
+func (d *PostgreSQLDialect) ArrayOverlaps(column string, values interface{}) (string, []interface{}, error) {
+	return arrayOperatorCondition(column, "&&", values)
+}
+
+func arrayOperatorCondition(column string, operator string, values interface{}) (string, []interface{}, error) {
+	if err := validateJSONColumn(column); err != nil {
+		return "", nil, err
+	}
+	v := reflect.ValueOf(values)
+	if values == nil || (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) {
+		return "", nil, NewValidationException(fmt.Sprintf("数组条件的值必须为切片: %T", values))
+	}
+	literal, err := encodePostgreSQLArray(v)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("%s %s ?", column, operator), []interface{}{literal}, nil
+}
+
+/**
+ * 把切片编码为 PostgreSQL 数组字面量（nil 切片编码为空数组 {}）
+ */
+func encodePostgreSQLArray(value reflect.Value) (string, error) {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return "{}", nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return "", NewValidationException(fmt.Sprintf("PostgreSQL 数组列只支持切片: %s", value.Type()))
+	}
+
+	elements := make([]string, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		element := value.Index(i)
+		switch element.Kind() {
+		case reflect.String:
+			elements[i] = quotePostgreSQLArrayElement(element.String())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			elements[i] = strconv.FormatInt(element.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			elements[i] = strconv.FormatUint(element.Uint(), 10)
+		case reflect.Float32, reflect.Float64:
+			elements[i] = strconv.FormatFloat(element.Float(), 'g', -1, 64)
+		case reflect.Bool:
+			elements[i] = strconv.FormatBool(element.Bool())
+		default:
+			return "", NewValidationException(fmt.Sprintf("PostgreSQL 数组不支持的元素类型: %s", element.Type()))
+		}
+	}
+	return "{" + strings.Join(elements, ",") + "}", nil
+}
+
+/**
+ * 字符串元素一律加双引号，转义 \ 与 "
+ */
+func quotePostgreSQLArrayElement(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+/**
+ * 解析一维 PostgreSQL 数组字面量（{a,"b c",NULL}）为目标切片，NULL 元素为零值
+ */
+func decodePostgreSQLArray(text string, targetType reflect.Type) (reflect.Value, error) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "{") || !strings.HasSuffix(text, "}") {
+		return reflect.Value{}, fmt.Errorf("不是 PostgreSQL 数组字面量: %s", text)
+	}
+	body := text[1 : len(text)-1]
+	result := reflect.MakeSlice(targetType, 0, 0)
+	if body == "" {
+		return result, nil
+	}
+
+	elemType := targetType.Elem()
+	for i := 0; i <= len(body); {
+		var element strings.Builder
+		quoted := false
+		if i < len(body) && body[i] == '"' {
+			quoted = true
+			i++
+			for ; i < len(body) && body[i] != '"'; i++ {
+				if body[i] == '\\' && i+1 < len(body) {
+					i++
+				}
+				element.WriteByte(body[i])
+			}
+			if i >= len(body) {
+				return reflect.Value{}, fmt.Errorf("PostgreSQL 数组字面量引号未闭合: %s", text)
+			}
+			i++
+		} else {
+			for ; i < len(body) && body[i] != ','; i++ {
+				if body[i] == '{' {
+					return reflect.Value{}, fmt.Errorf("不支持多维 PostgreSQL 数组: %s", text)
+				}
+				element.WriteByte(body[i])
+			}
+		}
+		if i < len(body) && body[i] != ',' {
+			return reflect.Value{}, fmt.Errorf("PostgreSQL 数组字面量格式错误: %s", text)
+		}
+		i++
+
+		raw := element.String()
+		if !quoted {
+			raw = strings.TrimSpace(raw)
+		}
+		if !quoted && strings.EqualFold(raw, "NULL") {
+			result = reflect.Append(result, reflect.Zero(elemType))
+			continue
+		}
+		if elemType.Kind() == reflect.String {
+			result = reflect.Append(result, reflect.ValueOf(raw).Convert(elemType))
+			continue
+		}
+		converted, err := OrmHandlerInstance.convertFromBytes([]byte(raw), elemType)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		result = reflect.Append(result, converted.Convert(elemType))
+	}
+	return result, nil
+}
@@ -0,0 +1,235 @@
+package db233
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+/**
+ * PluginChain - 带优先级的插件链
+ *
+ * 全局（Db233PluginManager）、DbGroup、Db 各持有一条插件链。
+ * 执行 SQL 时三条链合并：
+ *   - 按 priority 升序执行（数值越小越先执行），同优先级按 全局 -> DbGroup -> Db、再按注册顺序
+ *   - 同名插件以范围更小的为准（Db 覆盖 DbGroup，DbGroup 覆盖全局）
+ *
+ * 零值可直接使用
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type PluginChain struct {
+	entries []*pluginEntry
+	mu      sync.RWMutex
+}
+
+/**
+ * 插件作用范围
+ */
+type pluginScope int
+
+const (
+	pluginScopeGlobal pluginScope = iota
+	pluginScopeDbGroup
+	pluginScopeDb
+)
+
+/**
+ * 插件链中的条目
+ */
+type pluginEntry struct {
+	plugin   Db233Plugin
+	priority int
+	scope    pluginScope
+	seq      int64
+}
+
+// 注册序号（跨所有插件链递增，用于同优先级时保持注册顺序）
+var pluginRegisterSeq int64
+
+/**
+ * 添加插件（同名插件会被替换）
+ *
+ * @param plugin 插件
+ * @param priority 优先级，数值越小越先执行
+ */
+func (c *PluginChain) Add(plugin Db233Plugin, priority int) {
+	plugin.InitPlugin()
+	entry := &pluginEntry{
+		plugin:   plugin,
+		priority: priority,
+		seq:      atomic.AddInt64(&pluginRegisterSeq, 1),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(plugin.GetPluginName())
+	c.entries = append(c.entries, entry)
+}
+
+/**
+ * 根据插件名称移除插件
+ */
+func (c *PluginChain) Remove(pluginName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(pluginName)
+}
+
+/**
+ * 移除同名插件（调用方需持有锁）
+ */
+func (c *PluginChain) removeLocked(pluginName string) {
+	for i, entry := range c.entries {
+		if entry.plugin.GetPluginName() == pluginName {
+			c.entries = append(c.entries[:i], c.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+/**
+ * 移除所有插件
+ */
+func (c *PluginChain) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = nil
+}
+
+/**
+ * 根据插件名称获取插件
+ */
+func (c *PluginChain) Get(pluginName string) Db233Plugin {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, entry := range c.entries {
+		if entry.plugin.GetPluginName() == pluginName {
+			return entry.plugin
+		}
+	}
+	return nil
+}
+
+/**
+ * 获取所有插件（按执行顺序）
+ */
+func (c *PluginChain) GetAll() []Db233Plugin {
+	return pluginsOf(mergePluginEntries(c.snapshot(pluginScopeGlobal)))
+}
+
+/**
+ * 获取插件数量
+ */
+func (c *PluginChain) Size() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+/**
+ * 复制条目并标记作用范围
+ */
+func (c *PluginChain) snapshot(scope pluginScope) []pluginEntry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entries := make([]pluginEntry, len(c.entries))
+	for i, entry := range c.entries {
+		entries[i] = *entry
+		entries[i].scope = scope
+	}
+	return entries
+}
+
+/**
+ * 合并多条插件链：同名取范围最小者，再按 priority / scope / 注册顺序排序
+ */
+func mergePluginEntries(groups ...[]pluginEntry) []pluginEntry {
+	byName := make(map[string]pluginEntry)
+	for _, group := range groups {
+		for _, entry := range group {
+			name := entry.plugin.GetPluginName()
+			if existing, exists := byName[name]; !exists || entry.scope >= existing.scope {
+				byName[name] = entry
+			}
+		}
+	}
+
+	merged := make([]pluginEntry, 0, len(byName))
+	for _, entry := range byName {
+		merged = append(merged, entry)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].priority != merged[j].priority {
+			return merged[i].priority < merged[j].priority
+		}
+		if merged[i].scope != merged[j].scope {
+			return merged[i].scope < merged[j].scope
+		}
+		return merged[i].seq < merged[j].seq
+	})
+	return merged
+}
+
+/**
+ * 提取插件列表
+ */
+func pluginsOf(entries []pluginEntry) []Db233Plugin {
+	plugins := make([]Db233Plugin, len(entries))
+	for i, entry := range entries {
+		plugins[i] = entry.plugin
+	}
+	return plugins
+}
+
+/**
+ * 为 Db 添加插件（仅作用于该 Db）
+ *
+ * @param plugin 插件
+ * @param priority 优先级，数值越小越先执行
+ */
+func (db *Db) AddPlugin(plugin Db233Plugin, priority int) {
+	db.root().plugins.Add(plugin, priority)
+}
+
+/**
+ * 移除 Db 上的插件
+ */
+func (db *Db) RemovePlugin(pluginName string) {
+	db.root().plugins.Remove(pluginName)
+}
+
+/**
+ * 获取作用于该 Db 的全部插件（全局 + DbGroup + Db，按执行顺序）
+ */
+func (db *Db) GetEffectivePlugins() []Db233Plugin {
+	db = db.root()
+	if db.isolatedPlugins {
+		return pluginsOf(mergePluginEntries(db.plugins.snapshot(pluginScopeDb)))
+	}
+	groups := [][]pluginEntry{GetPluginManagerInstance().globalPlugins.snapshot(pluginScopeGlobal)}
+	if db.DbGroup != nil {
+		groups = append(groups, db.DbGroup.plugins.snapshot(pluginScopeDbGroup))
+	}
+	groups = append(groups, db.plugins.snapshot(pluginScopeDb))
+	return pluginsOf(mergePluginEntries(groups...))
+}
+
+/**
+ * 为 DbGroup 添加插件（作用于组内所有 Db）
+ *
+ * @param plugin 插件
+ * @param priority 优先级，数值越小越先执行
+ */
+func (dg *DbGroup) AddPlugin(plugin Db233Plugin, priority int) {
+	dg.plugins.Add(plugin, priority)
+}
+
+/**
+ * 移除 DbGroup 上的插件
+ */
+func (dg *DbGroup) RemovePlugin(pluginName string) {
+	dg.plugins.Remove(pluginName)
+}
@@ -8,15 +8,14 @@ import (
  * Db233PluginManager - Db233 插件管理器
  *
  * 对应 Kotlin 版本的 Db233PluginManager
- * 管理全局插件的注册、移除和查询
+ * 管理全局插件的注册、移除和查询；Db / DbGroup 级插件见 PluginChain
  *
  * @author neko233-com
  * @since 2025-12-28
  */
 type Db233PluginManager struct {
-	// 全局插件存储
-	globalPlugins map[string]Db233Plugin
-	mu            sync.RWMutex
+	// 全局插件链
+	globalPlugins PluginChain
 }
 
 /**
@@ -30,9 +29,7 @@ var pluginManagerOnce sync.Once
  */
 func GetPluginManagerInstance() *Db233PluginManager {
 	pluginManagerOnce.Do(func() {
-		pluginManagerInstance = &Db233PluginManager{
-			globalPlugins: make(map[string]Db233Plugin),
-		}
+		pluginManagerInstance = &Db233PluginManager{}
 	})
 	return pluginManagerInstance
 }
@@ -41,88 +38,63 @@ func GetPluginManagerInstance() *Db233PluginManager {
  * 添加全局插件
  */
 func (pm *Db233PluginManager) AddGlobalPlugin(plugin Db233Plugin) {
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
-
-	// 初始化插件
-	plugin.InitPlugin()
+	pm.globalPlugins.Add(plugin, 0)
+}
 
-	pm.globalPlugins[plugin.GetPluginName()] = plugin
+/**
+ * 添加带优先级的全局插件（数值越小越先执行）
+ */
+func (pm *Db233PluginManager) AddGlobalPluginWithPriority(plugin Db233Plugin, priority int) {
+	pm.globalPlugins.Add(plugin, priority)
 }
 
 /**
  * 移除全局插件
  */
 func (pm *Db233PluginManager) RemoveGlobalPlugin(plugin Db233Plugin) {
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
-
-	delete(pm.globalPlugins, plugin.GetPluginName())
+	pm.globalPlugins.Remove(plugin.GetPluginName())
 }
 
 /**
  * 根据插件名称移除插件
  */
 func (pm *Db233PluginManager) RemoveGlobalPluginByName(pluginName string) {
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
-
-	delete(pm.globalPlugins, pluginName)
+	pm.globalPlugins.Remove(pluginName)
 }
 
 /**
  * 获取所有已注册的插件
  */
 func (pm *Db233PluginManager) GetAll() []Db233Plugin {
-	pm.mu.RLock()
-	defer pm.mu.RUnlock()
-
-	plugins := make([]Db233Plugin, 0, len(pm.globalPlugins))
-	for _, plugin := range pm.globalPlugins {
-		plugins = append(plugins, plugin)
-	}
-	return plugins
+	return pm.globalPlugins.GetAll()
 }
 
 /**
  * 根据插件名称获取插件
  */
 func (pm *Db233PluginManager) GetPlugin(pluginName string) Db233Plugin {
-	pm.mu.RLock()
-	defer pm.mu.RUnlock()
-
-	return pm.globalPlugins[pluginName]
+	return pm.globalPlugins.Get(pluginName)
 }
 
 /**
  * 检查插件是否已注册
  */
 func (pm *Db233PluginManager) HasPlugin(pluginName string) bool {
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
-
-	_, exists := pm.globalPlugins[pluginName]
-	return exists
+	return pm.globalPlugins.Get(pluginName) != nil
 }
 
 /**
  * 移除所有插件
  */
 func (pm *Db233PluginManager) RemoveAll() {
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
-
-	pm.globalPlugins = make(map[string]Db233Plugin)
+	pm.globalPlugins.Clear()
 }
 
 /**
  * 获取插件数量
  */
 func (pm *Db233PluginManager) Size() int {
-	pm.mu.RLock()
-	defer pm.mu.RUnlock()
-
-	return len(pm.globalPlugins)
+	return pm.globalPlugins.Size()
 }
 
 /**
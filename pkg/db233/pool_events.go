@@ -0,0 +1,303 @@
+package db233
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+)
+
+/**
+ * 连接池事件
+ *
+ * PoolEventSampler 定期采样 sql.DBStats 并与上次采样比较，把连接池生命周期变化转换为事件分发给监听器：
+ *
+ *   sampler := db233.NewPoolEventSampler("main", db, 5*time.Second)
+ *   sampler.AddListener(db233.PoolEventListenerFunc(func(event db233.PoolEvent) { ... }))
+ *   sampler.Start()
+ *
+ * 采样器创建后挂在 Db 上，未绑定事务的语句改为显式获取连接（同自动埋点），获取连接时若连接池已满，
+ * 立即发出带实际等待时间的 WaitStarted / WaitEnded 事件（Source 为 "wrapper"），
+ * 采样时只补发包装层未观察到的等待（如直接使用 DataSource 的代码），不会重复计数。
+ * 包装层事件在调用方的 goroutine 中同步分发，监听器应尽快返回
+ *
+ * ConnectionPoolMonitor 实现了 PoolEventListener，见 ConnectionPoolMonitor.StartPoolSampling
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type EnumPoolEventType string
+
+const (
+	// 每次采样都会发出，携带最新的 sql.DBStats
+	PoolEventStatsSampled EnumPoolEventType = "stats_sampled"
+
+	// 新建连接（Count 为数量）
+	PoolEventConnectionCreated EnumPoolEventType = "connection_created"
+
+	// 关闭连接（空闲过多、空闲超时、超过最大生命周期或连接失效）
+	PoolEventConnectionClosed EnumPoolEventType = "connection_closed"
+
+	// 开始等待空闲连接
+	PoolEventWaitStarted EnumPoolEventType = "wait_started"
+
+	// 等待结束（WaitDuration 为等待时间）
+	PoolEventWaitEnded EnumPoolEventType = "wait_ended"
+
+	// 打开的连接数达到 MaxOpenConnections（进入已满状态时发出一次）
+	PoolEventMaxReached EnumPoolEventType = "max_reached"
+)
+
+/**
+ * PoolEvent - 连接池事件
+ */
+type PoolEvent struct {
+	Type      EnumPoolEventType
+	DbGroup   string
+	Timestamp time.Time
+
+	// sample（采样差值）或 wrapper（获取连接时观察到）
+	Source string
+
+	// 事件涉及的连接数或等待次数
+	Count int64
+
+	// 等待时间（WaitEnded）
+	WaitDuration time.Duration
+
+	// 发出事件时的连接池统计
+	Stats sql.DBStats
+}
+
+/**
+ * PoolEventListener - 连接池事件监听器
+ */
+type PoolEventListener interface {
+	OnPoolEvent(event PoolEvent)
+}
+
+/**
+ * 函数形式的监听器
+ */
+type PoolEventListenerFunc func(event PoolEvent)
+
+func (f PoolEventListenerFunc) OnPoolEvent(event PoolEvent) {
+	f(event)
+}
+
+/**
+ * PoolEventSampler - 连接池事件采样器
+ */
+type PoolEventSampler struct {
+	dbGroupName string
+	db          *Db
+	interval    time.Duration
+	listeners   []PoolEventListener
+
+	// 上次采样
+	last    sql.DBStats
+	sampled bool
+	atMax   bool
+
+	// 包装层已报告、尚未被采样抵消的等待
+	observedWaits        int64
+	observedWaitDuration time.Duration
+
+	mu       sync.Mutex
+	stopChan chan bool
+	stopOnce sync.Once
+}
+
+/**
+ * 创建连接池事件采样器并挂到 Db 上
+ */
+func NewPoolEventSampler(dbGroupName string, db *Db, interval time.Duration) *PoolEventSampler {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	sampler := &PoolEventSampler{
+		dbGroupName: dbGroupName,
+		db:          db.root(),
+		interval:    interval,
+		listeners:   make([]PoolEventListener, 0),
+		stopChan:    make(chan bool),
+	}
+	sampler.db.poolEventSampler = sampler
+	return sampler
+}
+
+/**
+ * 添加监听器
+ */
+func (s *PoolEventSampler) AddListener(listener PoolEventListener) *PoolEventSampler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, listener)
+	return s
+}
+
+/**
+ * 开始定期采样
+ */
+func (s *PoolEventSampler) Start() {
+	LogInfo("连接池事件采样启动: %s, 间隔: %v", s.dbGroupName, s.interval)
+
+	goSupervised("pool_event_sampler:"+s.dbGroupName, s.stopChan, func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		s.Sample()
+		for {
+			select {
+			case <-ticker.C:
+				s.Sample()
+			case <-s.stopChan:
+				LogInfo("连接池事件采样停止: %s", s.dbGroupName)
+				return
+			}
+		}
+	})
+}
+
+/**
+ * 停止定期采样（包装层事件仍会分发）
+ */
+func (s *PoolEventSampler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+	})
+}
+
+/**
+ * 立即采样一次并分发事件（首次采样只建立基线）
+ */
+func (s *PoolEventSampler) Sample() {
+	stats := s.db.DataSource.Stats()
+	now := time.Now()
+	events := make([]PoolEvent, 0)
+	newEvent := func(eventType EnumPoolEventType, count int64) PoolEvent {
+		return PoolEvent{Type: eventType, DbGroup: s.dbGroupName, Timestamp: now, Source: "sample", Count: count, Stats: stats}
+	}
+
+	s.mu.Lock()
+	events = append(events, newEvent(PoolEventStatsSampled, 0))
+	if s.sampled {
+		prev := s.last
+		closed := closedConnections(stats) - closedConnections(prev)
+		created := int64(stats.OpenConnections-prev.OpenConnections) + closed
+		if created < 0 {
+			// 因连接失效关闭的连接没有单独计数
+			closed -= created
+			created = 0
+		}
+		if created > 0 {
+			events = append(events, newEvent(PoolEventConnectionCreated, created))
+		}
+		if closed > 0 {
+			events = append(events, newEvent(PoolEventConnectionClosed, closed))
+		}
+
+		waits := stats.WaitCount - prev.WaitCount - s.observedWaits
+		waitDuration := stats.WaitDuration - prev.WaitDuration - s.observedWaitDuration
+		s.observedWaits = 0
+		s.observedWaitDuration = 0
+		if waits > 0 {
+			if waitDuration < 0 {
+				waitDuration = 0
+			}
+			events = append(events, newEvent(PoolEventWaitStarted, waits))
+			ended := newEvent(PoolEventWaitEnded, waits)
+			ended.WaitDuration = waitDuration
+			events = append(events, ended)
+		}
+	}
+	atMax := stats.MaxOpenConnections > 0 && stats.OpenConnections >= stats.MaxOpenConnections
+	if atMax && !s.atMax {
+		events = append(events, newEvent(PoolEventMaxReached, int64(stats.OpenConnections)))
+	}
+	s.atMax = atMax
+	s.last = stats
+	s.sampled = true
+	listeners := append([]PoolEventListener(nil), s.listeners...)
+	s.mu.Unlock()
+
+	dispatchPoolEvents(listeners, events)
+}
+
+/**
+ * 包装层获取连接前调用：连接池已满时发出 WaitStarted，返回是否会等待
+ */
+func (s *PoolEventSampler) beginWait() bool {
+	if s == nil {
+		return false
+	}
+	stats := s.db.DataSource.Stats()
+	if stats.MaxOpenConnections <= 0 || stats.InUse < stats.MaxOpenConnections {
+		return false
+	}
+	s.mu.Lock()
+	listeners := append([]PoolEventListener(nil), s.listeners...)
+	s.mu.Unlock()
+
+	dispatchPoolEvents(listeners, []PoolEvent{{
+		Type: PoolEventWaitStarted, DbGroup: s.dbGroupName, Timestamp: time.Now(), Source: "wrapper", Count: 1, Stats: stats,
+	}})
+	return true
+}
+
+/**
+ * 包装层获取连接后调用：发出 WaitEnded 并记入已观察的等待，供下次采样抵消
+ */
+func (s *PoolEventSampler) endWait(waited bool, waitDuration time.Duration) {
+	if s == nil || !waited {
+		return
+	}
+	stats := s.db.DataSource.Stats()
+	s.mu.Lock()
+	s.observedWaits++
+	s.observedWaitDuration += waitDuration
+	listeners := append([]PoolEventListener(nil), s.listeners...)
+	s.mu.Unlock()
+
+	dispatchPoolEvents(listeners, []PoolEvent{{
+		Type: PoolEventWaitEnded, DbGroup: s.dbGroupName, Timestamp: time.Now(), Source: "wrapper", Count: 1, WaitDuration: waitDuration, Stats: stats,
+	}})
+}
+
+/**
+ * 获取挂在 Db 上的连接池事件采样器，未创建时为 nil
+ */
+func (db *Db) GetPoolEventSampler() *PoolEventSampler {
+	return db.root().poolEventSampler
+}
+
+/**
+ * 未绑定事务的语句是否显式获取连接（自动埋点或连接池事件需要测量获取连接的等待）
+ */
+func (db *Db) acquiresConnExplicitly() bool {
+	return db.IsInstrumented() || db.GetPoolEventSampler() != nil
+}
+
+/**
+ * 连接池主动关闭的连接总数
+ */
+func closedConnections(stats sql.DBStats) int64 {
+	return stats.MaxIdleClosed + stats.MaxIdleTimeClosed + stats.MaxLifetimeClosed
+}
+
+/**
+ * 分发事件，单个监听器 panic 不影响其他监听器
+ */
+func dispatchPoolEvents(listeners []PoolEventListener, events []PoolEvent) {
+	for _, event := range events {
+		for _, listener := range listeners {
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						LogError("连接池事件监听器异常: %s, %v", event.Type, r)
+					}
+				}()
+				listener.OnPoolEvent(event)
+			}()
+		}
+	}
+}
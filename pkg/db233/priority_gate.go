@@ -0,0 +1,317 @@
+package db233
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+/**
+ * EnumQueryPriority - 获取连接的优先级
+ */
+type EnumQueryPriority int
+
+const (
+	// 在线请求（默认），连接池饱和时优先获得连接
+	QueryPriorityInteractive EnumQueryPriority = iota
+	// 批处理 / 后台任务，只有没有在线请求等待时才获得连接
+	QueryPriorityBatch
+
+	queryPriorityCount
+)
+
+/**
+ * 获取优先级字符串
+ */
+func (p EnumQueryPriority) String() string {
+	switch p {
+	case QueryPriorityInteractive:
+		return "interactive"
+	case QueryPriorityBatch:
+		return "batch"
+	default:
+		return fmt.Sprintf("priority_%d", int(p))
+	}
+}
+
+/**
+ * PriorityConnectionGate - 按优先级分配连接
+ *
+ * database/sql 连接池按到达顺序分配连接，批处理任务会与在线请求平等竞争；
+ * 在 Db 前加一道容量与连接池相同的闸门，饱和时按优先级（同优先级按先后）放行：
+ *
+ *   dataSource.SetMaxOpenConns(50)
+ *   db.SetPriorityGate(db233.NewPriorityConnectionGate(50).SetAcquireTimeout(3 * time.Second))
+ *
+ *   batchDb := db.WithPriority(db233.QueryPriorityBatch)
+ *   db233.NewBaseCrudRepository(batchDb).BatchSave(rows)   // 有在线请求排队时让行
+ *
+ * 经过 Db 执行的语句与 ExecuteWithConnection 受闸门约束；各优先级的等待时间见
+ * GetWaitStats 或 ConnectionPoolMonitor.GetReport()["priority_wait"]
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type PriorityConnectionGate struct {
+	capacity       int
+	acquireTimeout time.Duration
+
+	inUse   int
+	waiters [queryPriorityCount][]*priorityWaiter
+	stats   [queryPriorityCount]priorityWaitStats
+
+	mu sync.Mutex
+}
+
+/**
+ * 等待中的请求
+ */
+type priorityWaiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+/**
+ * 单个优先级的等待统计
+ */
+type priorityWaitStats struct {
+	acquired  int64
+	timeouts  int64
+	totalWait time.Duration
+	maxWait   time.Duration
+}
+
+/**
+ * 创建优先级闸门
+ *
+ * @param capacity 同时持有连接的上限（应与 SetMaxOpenConns 一致）
+ */
+func NewPriorityConnectionGate(capacity int) *PriorityConnectionGate {
+	if capacity <= 0 {
+		LogWarn("优先级闸门容量无效: %d，使用 1", capacity)
+		capacity = 1
+	}
+	return &PriorityConnectionGate{capacity: capacity}
+}
+
+/**
+ * 设置获取连接的最长等待时间，0 表示一直等待
+ */
+func (g *PriorityConnectionGate) SetAcquireTimeout(timeout time.Duration) *PriorityConnectionGate {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.acquireTimeout = timeout
+	return g
+}
+
+/**
+ * 按优先级获取许可，返回释放函数；等待超时返回 *ConnectionException
+ */
+func (g *PriorityConnectionGate) Acquire(priority EnumQueryPriority) (func(), error) {
+	if priority < 0 || priority >= queryPriorityCount {
+		priority = QueryPriorityBatch
+	}
+	start := time.Now()
+
+	g.mu.Lock()
+	if g.inUse < g.capacity && g.waitingCount() == 0 {
+		g.inUse++
+		g.recordWait(priority, 0)
+		g.mu.Unlock()
+		return g.release, nil
+	}
+	waiter := &priorityWaiter{ready: make(chan struct{})}
+	g.waiters[priority] = append(g.waiters[priority], waiter)
+	timeout := g.acquireTimeout
+	g.mu.Unlock()
+
+	var timeoutChan <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutChan = timer.C
+	}
+	select {
+	case <-waiter.ready:
+	case <-timeoutChan:
+		g.mu.Lock()
+		// 超时与放行同时发生时以放行为准
+		if !waiter.granted {
+			g.removeWaiter(priority, waiter)
+			g.stats[priority].timeouts++
+			g.mu.Unlock()
+			return nil, NewConnectionException(fmt.Sprintf("等待连接超过 %v (优先级 %s, 上限 %d)", timeout, priority, g.capacity))
+		}
+		g.mu.Unlock()
+	}
+
+	g.mu.Lock()
+	g.recordWait(priority, time.Since(start))
+	g.mu.Unlock()
+	return g.release, nil
+}
+
+/**
+ * 释放许可：优先把许可直接交给最高优先级的等待者
+ */
+func (g *PriorityConnectionGate) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for priority := range g.waiters {
+		if queue := g.waiters[priority]; len(queue) > 0 {
+			waiter := queue[0]
+			g.waiters[priority] = queue[1:]
+			waiter.granted = true
+			close(waiter.ready)
+			return
+		}
+	}
+	g.inUse--
+}
+
+func (g *PriorityConnectionGate) waitingCount() int {
+	count := 0
+	for _, queue := range g.waiters {
+		count += len(queue)
+	}
+	return count
+}
+
+func (g *PriorityConnectionGate) removeWaiter(priority EnumQueryPriority, waiter *priorityWaiter) {
+	queue := g.waiters[priority]
+	for i, candidate := range queue {
+		if candidate == waiter {
+			g.waiters[priority] = append(queue[:i:i], queue[i+1:]...)
+			return
+		}
+	}
+}
+
+func (g *PriorityConnectionGate) recordWait(priority EnumQueryPriority, wait time.Duration) {
+	stats := &g.stats[priority]
+	stats.acquired++
+	stats.totalWait += wait
+	if wait > stats.maxWait {
+		stats.maxWait = wait
+	}
+}
+
+/**
+ * 各优先级的等待统计（acquired / waiting / timeouts / avg_wait / max_wait）
+ */
+func (g *PriorityConnectionGate) GetWaitStats() map[string]map[string]interface{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	result := make(map[string]map[string]interface{}, queryPriorityCount)
+	for priority := EnumQueryPriority(0); priority < queryPriorityCount; priority++ {
+		stats := g.stats[priority]
+		avgWait := time.Duration(0)
+		if stats.acquired > 0 {
+			avgWait = stats.totalWait / time.Duration(stats.acquired)
+		}
+		result[priority.String()] = map[string]interface{}{
+			"acquired": stats.acquired,
+			"waiting":  int64(len(g.waiters[priority])),
+			"timeouts": stats.timeouts,
+			"avg_wait": avgWait.String(),
+			"max_wait": stats.maxWait.String(),
+		}
+	}
+	return result
+}
+
+/**
+ * 当前持有许可的数量
+ */
+func (g *PriorityConnectionGate) InUse() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.inUse
+}
+
+/**
+ * 设置优先级闸门（nil 表示不按优先级排队）
+ */
+func (db *Db) SetPriorityGate(gate *PriorityConnectionGate) *Db {
+	db.root().priorityGate = gate
+	return db
+}
+
+/**
+ * 获取优先级闸门，未设置时为 nil
+ */
+func (db *Db) GetPriorityGate() *PriorityConnectionGate {
+	return db.root().priorityGate
+}
+
+/**
+ * 获取当前 Db 的优先级
+ */
+func (db *Db) GetPriority() EnumQueryPriority {
+	return db.priority
+}
+
+/**
+ * 返回以指定优先级获取连接的 Db 视图
+ *
+ * 视图与原 Db 共享数据源、插件、维护模式与优先级闸门，其余配置（语句超时、白名单、熔断器等）
+ * 为创建时的快照，修改配置请在原 Db 上进行
+ */
+func (db *Db) WithPriority(priority EnumQueryPriority) *Db {
+	if root := db.root(); db.tx == nil && priority == root.priority {
+		return root
+	}
+	if priority == db.priority {
+		return db
+	}
+	view := db.newView()
+	view.priority = priority
+	return view
+}
+
+/**
+ * 创建共享原 Db 状态的视图（保留当前的优先级、绑定的事务与查询标签）
+ */
+func (db *Db) newView() *Db {
+	root := db.root()
+	return &Db{
+		DataSource:                 root.DataSource,
+		DbId:                       root.DbId,
+		DbGroup:                    root.DbGroup,
+		DatabaseType:               root.DatabaseType,
+		CompatibilityMode:          root.CompatibilityMode,
+		StatementTimeout:           db.StatementTimeout,
+		statementTimeoutOverridden: db.statementTimeoutOverridden,
+		isolatedPlugins:            root.isolatedPlugins,
+		priority:                   db.priority,
+		priorityRoot:               root,
+		tx:                         db.tx,
+		queryTag:                   db.queryTag,
+	}
+}
+
+/**
+ * 视图（优先级 / 事务）对应的原 Db（非视图返回自身）
+ */
+func (db *Db) root() *Db {
+	if db.priorityRoot != nil {
+		return db.priorityRoot
+	}
+	return db
+}
+
+/**
+ * 在优先级闸门下执行（未设置闸门时直接执行）
+ */
+func (db *Db) withPriorityGate(fn func() error) error {
+	gate := db.root().priorityGate
+	if gate == nil {
+		return fn()
+	}
+	release, err := gate.Acquire(db.priority)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn()
+}
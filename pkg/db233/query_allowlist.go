@@ -0,0 +1,192 @@
+package db233
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+/**
+ * QueryAllowlist - SQL 白名单（锁定模式）
+ *
+ * 开启后，只有库内部生成的 SQL（Repository 的增删改查、关联加载等）
+ * 以及预先注册的模板可以执行，未知的原生 SQL 被拒绝或仅记录日志：
+ *
+ *   allowlist := db233.NewQueryAllowlist(db233.QueryAllowlistModeStrict)
+ *   allowlist.RegisterTemplates(
+ *       "SELECT * FROM player WHERE level > ?", // Db.ExecuteQuery 完整语句
+ *       "name = ? AND server_id = ?",           // FindByCondition 条件
+ *   )
+ *   db.SetQueryAllowlist(allowlist)
+ *
+ * 受检入口：Db.ExecuteQuery / ExecuteOriginalUpdate / ExecuteUpdateByStatement /
+ * ExecuteQueryWithTimeout / ExecuteUpdateWithTimeout、Repository.FindByCondition 的条件、
+ * TransactionManager.Query / Exec。直接使用 DataSource 或 ExecuteWithConnection 不受约束。
+ *
+ * 模板按规范化后的文本匹配（合并空白、去掉末尾分号），参数必须使用占位符。
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type QueryAllowlist struct {
+	mode      QueryAllowlistMode
+	templates map[string]bool
+
+	allowedCount  int64
+	rejectedCount int64
+
+	mu sync.RWMutex
+}
+
+/**
+ * 白名单模式
+ */
+type QueryAllowlistMode int
+
+const (
+	// 关闭：不做检查
+	QueryAllowlistModeOff QueryAllowlistMode = iota
+	// 记录：未注册的 SQL 记录警告后继续执行（上线前收集模板）
+	QueryAllowlistModeLog
+	// 严格：未注册的 SQL 拒绝执行
+	QueryAllowlistModeStrict
+)
+
+/**
+ * 获取模式字符串
+ */
+func (m QueryAllowlistMode) String() string {
+	switch m {
+	case QueryAllowlistModeLog:
+		return "log"
+	case QueryAllowlistModeStrict:
+		return "strict"
+	default:
+		return "off"
+	}
+}
+
+/**
+ * 创建 SQL 白名单
+ */
+func NewQueryAllowlist(mode QueryAllowlistMode) *QueryAllowlist {
+	return &QueryAllowlist{
+		mode:      mode,
+		templates: make(map[string]bool),
+	}
+}
+
+/**
+ * 设置模式
+ */
+func (a *QueryAllowlist) SetMode(mode QueryAllowlistMode) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.mode = mode
+}
+
+/**
+ * 获取模式
+ */
+func (a *QueryAllowlist) GetMode() QueryAllowlistMode {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.mode
+}
+
+/**
+ * 注册 SQL 模板
+ */
+func (a *QueryAllowlist) RegisterTemplate(sql string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.templates[normalizeSqlText(sql)] = true
+}
+
+/**
+ * 批量注册 SQL 模板
+ */
+func (a *QueryAllowlist) RegisterTemplates(sqls ...string) {
+	for _, sql := range sqls {
+		a.RegisterTemplate(sql)
+	}
+}
+
+/**
+ * 是否已注册
+ */
+func (a *QueryAllowlist) IsRegistered(sql string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.templates[normalizeSqlText(sql)]
+}
+
+/**
+ * 检查 SQL 是否允许执行
+ *
+ * @return error 严格模式下未注册时返回 *QueryNotAllowedException
+ */
+func (a *QueryAllowlist) Check(sql string) error {
+	mode := a.GetMode()
+	if mode == QueryAllowlistModeOff || a.IsRegistered(sql) {
+		atomic.AddInt64(&a.allowedCount, 1)
+		return nil
+	}
+
+	atomic.AddInt64(&a.rejectedCount, 1)
+	if mode == QueryAllowlistModeLog {
+		LogWarn("SQL 未在白名单中注册（记录模式，继续执行）: %s", sql)
+		return nil
+	}
+	LogError("SQL 未在白名单中注册，已拒绝执行: %s", sql)
+	return NewQueryNotAllowedException(sql)
+}
+
+/**
+ * 获取统计信息
+ */
+func (a *QueryAllowlist) GetStats() map[string]interface{} {
+	a.mu.RLock()
+	templateCount := len(a.templates)
+	mode := a.mode
+	a.mu.RUnlock()
+
+	return map[string]interface{}{
+		"mode":           mode.String(),
+		"template_count": templateCount,
+		"allowed_count":  atomic.LoadInt64(&a.allowedCount),
+		"rejected_count": atomic.LoadInt64(&a.rejectedCount),
+	}
+}
+
+/**
+ * 规范化 SQL：合并空白、去掉末尾分号
+ */
+func normalizeSqlText(sql string) string {
+	return strings.TrimRight(strings.Join(strings.Fields(sql), " "), "; ")
+}
+
+/**
+ * 设置 SQL 白名单（nil 表示关闭）
+ */
+func (db *Db) SetQueryAllowlist(allowlist *QueryAllowlist) {
+	db.root().queryAllowlist = allowlist
+}
+
+/**
+ * 获取 SQL 白名单
+ */
+func (db *Db) GetQueryAllowlist() *QueryAllowlist {
+	return db.root().queryAllowlist
+}
+
+/**
+ * 检查原生 SQL 是否允许执行
+ */
+func (db *Db) checkQueryAllowed(sql string) error {
+	allowlist := db.root().queryAllowlist
+	if allowlist == nil {
+		return nil
+	}
+	return allowlist.Check(sql)
+}
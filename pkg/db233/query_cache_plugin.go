@@ -0,0 +1,348 @@
+package db233
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * QueryCachePlugin - 查询结果缓存插件
+ *
+ * SELECT 结果按「数据源 + SQL 指纹 + 参数 + 返回类型」缓存，TTL 到期失效；
+ * 经过 Db / Repository 执行的 INSERT / UPDATE / DELETE / REPLACE / TRUNCATE
+ * 会使涉及到的表的所有缓存条目失效。
+ *
+ *   cachePlugin := db233.NewQueryCachePlugin(30*time.Second, 10000)
+ *   db233.GetPluginManagerInstance().AddGlobalPlugin(cachePlugin)
+ *   collector.AddDataSource(cachePlugin) // 命中率等指标接入 MetricsCollector
+ *
+ * 注意：TransactionManager 与直接使用 DataSource 的写入不经过插件，
+ * 需要时手动调用 InvalidateTables
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type QueryCachePlugin struct {
+	*AbstractDb233Plugin
+
+	ttl        time.Duration
+	maxEntries int
+
+	entries   map[string]*queryCacheEntry
+	tableKeys map[string]map[string]bool
+
+	// 表版本号：写入时递增，防止并发查询把失效前读到的旧结果写回缓存
+	tableVersions map[string]int64
+
+	hits          int64
+	misses        int64
+	invalidations int64
+	evictions     int64
+
+	mu sync.Mutex
+}
+
+/**
+ * 缓存条目
+ */
+type queryCacheEntry struct {
+	result    []interface{}
+	tables    []string
+	expiresAt time.Time
+}
+
+/**
+ * 查询执行前记录的缓存信息
+ */
+type queryCachePending struct {
+	key      string
+	tables   []string
+	versions []int64
+}
+
+const queryCachePendingAttribute = "query_cache_pending"
+
+var (
+	queryCacheTablePattern  = regexp.MustCompile("(?i)\\b(?:FROM|JOIN|INTO|UPDATE|TABLE)\\s+([`\"\\w.]+)")
+	queryCacheWritePrefixes = []string{"INSERT", "UPDATE", "DELETE", "REPLACE", "TRUNCATE"}
+)
+
+/**
+ * 创建查询缓存插件
+ *
+ * @param ttl 缓存有效期
+ * @param maxEntries 最大条目数，<= 0 表示不限制
+ */
+func NewQueryCachePlugin(ttl time.Duration, maxEntries int) *QueryCachePlugin {
+	return &QueryCachePlugin{
+		AbstractDb233Plugin: NewAbstractDb233Plugin("query-cache-plugin"),
+		ttl:                 ttl,
+		maxEntries:          maxEntries,
+		entries:             make(map[string]*queryCacheEntry),
+		tableKeys:           make(map[string]map[string]bool),
+		tableVersions:       make(map[string]int64),
+	}
+}
+
+/**
+ * 初始化插件
+ */
+func (p *QueryCachePlugin) InitPlugin() {
+	LogInfo("查询缓存插件已初始化: TTL=%v, 最大条目数=%d", p.ttl, p.maxEntries)
+}
+
+/**
+ * SQL 执行前：命中缓存时直接返回结果
+ */
+func (p *QueryCachePlugin) PreExecuteSql(context *ExecuteSqlContext) {
+	if context.ReturnType == nil || !isCacheableQuery(context.Sql) {
+		return
+	}
+
+	key := p.buildKey(context)
+	tables := extractSqlTables(context.Sql)
+	now := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if entry, exists := p.entries[key]; exists {
+		if now.Before(entry.expiresAt) {
+			p.hits++
+			context.ShortCircuit(append([]interface{}(nil), entry.result...))
+			return
+		}
+		p.removeEntryLocked(key)
+	}
+	p.misses++
+
+	versions := make([]int64, len(tables))
+	for i, table := range tables {
+		versions[i] = p.tableVersions[table]
+	}
+	context.SetAttribute(queryCachePendingAttribute, &queryCachePending{key: key, tables: tables, versions: versions})
+}
+
+/**
+ * SQL 执行后：缓存查询结果，或使写语句涉及的表失效
+ */
+func (p *QueryCachePlugin) PostExecuteSql(context *ExecuteSqlContext) {
+	if context.IsShortCircuited() || context.Error != nil {
+		return
+	}
+
+	if pending, ok := context.GetAttribute(queryCachePendingAttribute).(*queryCachePending); ok {
+		if result, ok := context.Result.([]interface{}); ok {
+			p.store(pending, result)
+		}
+		return
+	}
+
+	if isWriteStatement(context.Sql) {
+		p.InvalidateTables(extractSqlTables(context.Sql)...)
+	}
+}
+
+/**
+ * 写入缓存（执行期间表被写入过则放弃）
+ */
+func (p *QueryCachePlugin) store(pending *queryCachePending, result []interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, table := range pending.tables {
+		if p.tableVersions[table] != pending.versions[i] {
+			return
+		}
+	}
+
+	if _, exists := p.entries[pending.key]; !exists && p.maxEntries > 0 && len(p.entries) >= p.maxEntries {
+		p.evictLocked()
+	}
+	p.entries[pending.key] = &queryCacheEntry{
+		result:    append([]interface{}(nil), result...),
+		tables:    pending.tables,
+		expiresAt: time.Now().Add(p.ttl),
+	}
+	for _, table := range pending.tables {
+		if p.tableKeys[table] == nil {
+			p.tableKeys[table] = make(map[string]bool)
+		}
+		p.tableKeys[table][pending.key] = true
+	}
+}
+
+/**
+ * 淘汰条目：优先清理已过期条目，否则淘汰最早过期的一条
+ */
+func (p *QueryCachePlugin) evictLocked() {
+	now := time.Now()
+	oldestKey := ""
+	var oldestExpiresAt time.Time
+	for key, entry := range p.entries {
+		if !now.Before(entry.expiresAt) {
+			p.removeEntryLocked(key)
+			p.evictions++
+			continue
+		}
+		if oldestKey == "" || entry.expiresAt.Before(oldestExpiresAt) {
+			oldestKey = key
+			oldestExpiresAt = entry.expiresAt
+		}
+	}
+	if len(p.entries) >= p.maxEntries && oldestKey != "" {
+		p.removeEntryLocked(oldestKey)
+		p.evictions++
+	}
+}
+
+/**
+ * 删除条目及其表索引
+ */
+func (p *QueryCachePlugin) removeEntryLocked(key string) {
+	entry, exists := p.entries[key]
+	if !exists {
+		return
+	}
+	delete(p.entries, key)
+	for _, table := range entry.tables {
+		delete(p.tableKeys[table], key)
+		if len(p.tableKeys[table]) == 0 {
+			delete(p.tableKeys, table)
+		}
+	}
+}
+
+/**
+ * 使指定表的缓存失效
+ */
+func (p *QueryCachePlugin) InvalidateTables(tables ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, table := range tables {
+		table = normalizeSqlTableName(table)
+		p.tableVersions[table]++
+		for key := range p.tableKeys[table] {
+			p.removeEntryLocked(key)
+			p.invalidations++
+		}
+	}
+}
+
+/**
+ * 清空缓存
+ */
+func (p *QueryCachePlugin) Clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for table := range p.tableKeys {
+		p.tableVersions[table]++
+	}
+	p.entries = make(map[string]*queryCacheEntry)
+	p.tableKeys = make(map[string]map[string]bool)
+}
+
+/**
+ * 获取命中率（无请求时为 0）
+ */
+func (p *QueryCachePlugin) GetHitRate() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.hitRateLocked()
+}
+
+/**
+ * 计算命中率（调用方需持有锁）
+ */
+func (p *QueryCachePlugin) hitRateLocked() float64 {
+	total := p.hits + p.misses
+	if total == 0 {
+		return 0
+	}
+	return float64(p.hits) / float64(total)
+}
+
+/**
+ * 获取指标（实现 MetricsDataSource）
+ */
+func (p *QueryCachePlugin) GetMetrics() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return map[string]interface{}{
+		"hits":          p.hits,
+		"misses":        p.misses,
+		"hit_rate":      p.hitRateLocked(),
+		"entries":       len(p.entries),
+		"invalidations": p.invalidations,
+		"evictions":     p.evictions,
+	}
+}
+
+/**
+ * 获取数据源名称（实现 MetricsDataSource）
+ */
+func (p *QueryCachePlugin) GetName() string {
+	return p.GetPluginName()
+}
+
+/**
+ * 构建缓存键：数据源 + SQL 指纹 + 参数 + 返回类型
+ */
+func (p *QueryCachePlugin) buildKey(context *ExecuteSqlContext) string {
+	return fmt.Sprintf("%p|%T|%s|%#v", context.DataSource, context.ReturnType, normalizeSqlText(context.Sql), context.Params)
+}
+
+/**
+ * 是否为可缓存的查询（排除加锁读）
+ */
+func isCacheableQuery(query string) bool {
+	upper := strings.ToUpper(normalizeSqlText(query))
+	if !strings.HasPrefix(upper, "SELECT") && !strings.HasPrefix(upper, "WITH") {
+		return false
+	}
+	return !strings.Contains(upper, " FOR UPDATE") && !strings.Contains(upper, " LOCK IN SHARE MODE") && !strings.Contains(upper, " FOR SHARE")
+}
+
+/**
+ * 是否为写语句
+ */
+func isWriteStatement(query string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(query))
+	for _, prefix := range queryCacheWritePrefixes {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * 提取 SQL 涉及的表名（去重、小写、去掉库名与引号）
+ */
+func extractSqlTables(query string) []string {
+	tables := make([]string, 0)
+	for _, match := range queryCacheTablePattern.FindAllStringSubmatch(query, -1) {
+		table := normalizeSqlTableName(match[1])
+		if table != "" && !containsColumn(tables, table) {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+/**
+ * 规范化表名
+ */
+func normalizeSqlTableName(table string) string {
+	table = strings.Trim(table, "`\"")
+	if index := strings.LastIndex(table, "."); index >= 0 {
+		table = table[index+1:]
+	}
+	return strings.ToLower(strings.Trim(table, "`\""))
+}
@@ -0,0 +1,238 @@
+package db233
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+/**
+ * 查询标签（调用方归因）
+ *
+ * 调用方在 context 中标记业务操作，经 WithContext 执行的语句自动带上标签：
+ *
+ *   ctx = db233.WithQueryTag(ctx, "leaderboard.refresh")
+ *   repo.WithContext(ctx).FindAll()
+ *
+ * 带标签的语句：
+ *   - 末尾追加 db233_tag='leaderboard.refresh' 注释，DBA 可从服务端慢日志反查代码路径
+ *     （SetQueryTagComment(false) 关闭，注释只加在实际发往数据库的语句上，插件与限流器看到的仍是原语句）
+ *   - Db 设置了性能监控器时，按标签统计延迟与错误（见 PerformanceMonitor.GetQueryTagStats）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type queryTagContextKey struct{}
+
+/**
+ * 标签数上限，超出后新标签计入 "other"，避免动态标签导致统计无限增长
+ */
+const maxQueryTags = 500
+
+/**
+ * 每个标签保留用于计算百分位的耗时样本数
+ */
+const maxQueryTagSamples = 1000
+
+/**
+ * 把查询标签写入 context
+ */
+func WithQueryTag(ctx context.Context, tag string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, queryTagContextKey{}, tag)
+}
+
+/**
+ * 从 context 读取查询标签
+ */
+func QueryTagFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	tag, ok := ctx.Value(queryTagContextKey{}).(string)
+	return tag, ok && tag != ""
+}
+
+/**
+ * 返回带查询标签的 Db 视图
+ */
+func (db *Db) WithQueryTag(tag string) *Db {
+	if tag == db.queryTag {
+		return db
+	}
+	view := db.newView()
+	view.queryTag = tag
+	return view
+}
+
+/**
+ * 获取当前 Db 视图的查询标签
+ */
+func (db *Db) GetQueryTag() string {
+	return db.queryTag
+}
+
+/**
+ * 设置是否把查询标签作为 SQL 注释发往数据库（默认开启）
+ */
+func (db *Db) SetQueryTagComment(enabled bool) *Db {
+	db.root().queryTagCommentDisabled = !enabled
+	return db
+}
+
+/**
+ * 在语句末尾（分号之前）追加标签注释
+ */
+func appendQueryTagComment(query string, tag string) string {
+	trimmed := strings.TrimRight(query, " \t\r\n")
+	suffix := ""
+	if strings.HasSuffix(trimmed, ";") {
+		trimmed = strings.TrimRight(strings.TrimSuffix(trimmed, ";"), " \t\r\n")
+		suffix = ";"
+	}
+	return fmt.Sprintf("%s /* db233_tag='%s' */%s", trimmed, sanitizeQueryTag(tag), suffix)
+}
+
+/**
+ * 只保留字母、数字与 . _ - : /，其余字符替换为 _，防止标签闭合注释
+ */
+func sanitizeQueryTag(tag string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case strings.ContainsRune("._-:/", r):
+			return r
+		default:
+			return '_'
+		}
+	}, tag)
+}
+
+/**
+ * QueryTagStats - 按标签的查询统计
+ */
+type QueryTagStats struct {
+	Tag        string
+	QueryCount int64
+	ErrorCount int64
+	SlowCount  int64
+	TotalTime  time.Duration
+	MinTime    time.Duration
+	MaxTime    time.Duration
+	AvgTime    time.Duration
+	P95Time    time.Duration
+	P99Time    time.Duration
+	ErrorRate  float64
+}
+
+/**
+ * 标签统计累计值
+ */
+type queryTagAccumulator struct {
+	queryCount int64
+	errorCount int64
+	slowCount  int64
+	totalTime  time.Duration
+	minTime    time.Duration
+	maxTime    time.Duration
+	samples    []time.Duration
+}
+
+/**
+ * 记录带标签的查询（同时计入总体统计）
+ */
+func (pm *PerformanceMonitor) RecordTaggedQuery(tag string, query string, duration time.Duration, success bool, err error) {
+	pm.RecordQuery(query, duration, success, err)
+	pm.recordQueryTag(tag, duration, success)
+}
+
+/**
+ * 只计入按标签的统计
+ */
+func (pm *PerformanceMonitor) recordQueryTag(tag string, duration time.Duration, success bool) {
+	if !pm.enabled || tag == "" {
+		return
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if pm.queryTags == nil {
+		pm.queryTags = make(map[string]*queryTagAccumulator)
+	}
+	acc, exists := pm.queryTags[tag]
+	if !exists {
+		if len(pm.queryTags) >= maxQueryTags {
+			tag = "other"
+			acc = pm.queryTags[tag]
+		}
+		if acc == nil {
+			acc = &queryTagAccumulator{minTime: duration}
+			pm.queryTags[tag] = acc
+		}
+	}
+
+	acc.queryCount++
+	if !success {
+		acc.errorCount++
+	}
+	if duration >= pm.slowQueryThreshold {
+		acc.slowCount++
+	}
+	acc.totalTime += duration
+	if duration < acc.minTime {
+		acc.minTime = duration
+	}
+	if duration > acc.maxTime {
+		acc.maxTime = duration
+	}
+	acc.samples = append(acc.samples, duration)
+	if len(acc.samples) > maxQueryTagSamples {
+		acc.samples = acc.samples[len(acc.samples)-maxQueryTagSamples:]
+	}
+}
+
+/**
+ * 获取按标签的统计（按总耗时降序）
+ */
+func (pm *PerformanceMonitor) GetQueryTagStats() []QueryTagStats {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	result := make([]QueryTagStats, 0, len(pm.queryTags))
+	for tag, acc := range pm.queryTags {
+		stats := QueryTagStats{
+			Tag:        tag,
+			QueryCount: acc.queryCount,
+			ErrorCount: acc.errorCount,
+			SlowCount:  acc.slowCount,
+			TotalTime:  acc.totalTime,
+			MinTime:    acc.minTime,
+			MaxTime:    acc.maxTime,
+		}
+		if acc.queryCount > 0 {
+			stats.AvgTime = acc.totalTime / time.Duration(acc.queryCount)
+			stats.ErrorRate = float64(acc.errorCount) / float64(acc.queryCount)
+		}
+		samples := make([]float64, len(acc.samples))
+		for i, sample := range acc.samples {
+			samples[i] = float64(sample)
+		}
+		_, _, _, p95, p99 := calculateMetricStats(samples)
+		stats.P95Time = time.Duration(p95)
+		stats.P99Time = time.Duration(p99)
+		result = append(result, stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalTime != result[j].TotalTime {
+			return result[i].TotalTime > result[j].TotalTime
+		}
+		return result[i].Tag < result[j].Tag
+	})
+	return result
+}
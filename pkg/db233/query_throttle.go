@@ -0,0 +1,367 @@
+package db233
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/**
+ * QueryThrottle - 语句限流（并发数 / QPS）
+ *
+ * 按 Db 整体、以及按查询类别（自定义匹配或 SQL 指纹）限制并发语句数与 QPS，
+ * 避免后台任务挤占与在线请求共享的连接池：
+ *
+ *   throttle := db233.NewQueryThrottle("orders", db233.ThrottleLimit{MaxConcurrent: 40})
+ *   throttle.AddClass("report", func(sql string) bool { return strings.Contains(sql, "report_") },
+ *       db233.ThrottleLimit{MaxConcurrent: 4, MaxQPS: 20, Policy: db233.ThrottlePolicyQueue, QueueTimeout: 5 * time.Second})
+ *   throttle.SetFingerprintLimit("DELETE FROM session WHERE expire_at < ?",
+ *       db233.ThrottleLimit{MaxConcurrent: 1, Policy: db233.ThrottlePolicyShed})
+ *   db.SetQueryThrottle(throttle)
+ *
+ * 语句先经过所属类别的限制（第一个匹配的类别，其次为指纹限制），再经过 Db 整体的限制；
+ * 无法在策略允许的时间内获得许可时返回 *QueryThrottledException
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type QueryThrottle struct {
+	name string
+
+	dbLimiter    *throttleLimiter
+	classes      []*throttleClass
+	fingerprints map[string]*throttleLimiter
+
+	mu sync.RWMutex
+}
+
+/**
+ * ThrottlePolicy - 超出限制时的处理策略
+ */
+type ThrottlePolicy int
+
+const (
+	// 排队：等待许可，超过 QueueTimeout 后拒绝
+	ThrottlePolicyQueue ThrottlePolicy = iota
+	// 丢弃：没有许可时立即拒绝
+	ThrottlePolicyShed
+)
+
+/**
+ * 获取策略字符串
+ */
+func (p ThrottlePolicy) String() string {
+	if p == ThrottlePolicyShed {
+		return "shed"
+	}
+	return "queue"
+}
+
+/**
+ * ThrottleLimit - 限制配置
+ */
+type ThrottleLimit struct {
+	// 最大并发语句数，0 表示不限制
+	MaxConcurrent int
+
+	// 每秒最多放行的语句数，0 表示不限制（允许 1 秒的突发）
+	MaxQPS float64
+
+	// 超出限制时的策略
+	Policy ThrottlePolicy
+
+	// 排队策略下的最长等待时间，默认 1 秒
+	QueueTimeout time.Duration
+
+	// 排队策略下的最大排队数，超出后直接拒绝，0 表示不限制
+	MaxQueue int
+}
+
+/**
+ * 查询类别
+ */
+type throttleClass struct {
+	name    string
+	match   func(sql string) bool
+	limiter *throttleLimiter
+}
+
+/**
+ * 单个限制的运行状态
+ */
+type throttleLimiter struct {
+	name  string
+	limit ThrottleLimit
+
+	// 并发许可
+	slots chan struct{}
+
+	// QPS 令牌桶
+	tokens     float64
+	lastRefill time.Time
+	bucketMu   sync.Mutex
+
+	waiting  int64
+	admitted int64
+	rejected int64
+}
+
+/**
+ * 创建语句限流器
+ *
+ * @param name 名称（用于日志与异常）
+ * @param dbLimit Db 整体的限制
+ */
+func NewQueryThrottle(name string, dbLimit ThrottleLimit) *QueryThrottle {
+	return &QueryThrottle{
+		name:         name,
+		dbLimiter:    newThrottleLimiter("db", dbLimit),
+		fingerprints: make(map[string]*throttleLimiter),
+	}
+}
+
+func newThrottleLimiter(name string, limit ThrottleLimit) *throttleLimiter {
+	if limit.QueueTimeout <= 0 {
+		limit.QueueTimeout = time.Second
+	}
+	limiter := &throttleLimiter{name: name, limit: limit, tokens: limit.MaxQPS, lastRefill: time.Now()}
+	if limit.MaxConcurrent > 0 {
+		limiter.slots = make(chan struct{}, limit.MaxConcurrent)
+	}
+	return limiter
+}
+
+/**
+ * 添加查询类别（按添加顺序匹配，同名类别覆盖）
+ *
+ * @param name 类别名称
+ * @param match 判断 SQL 是否属于该类别
+ * @param limit 该类别的限制
+ */
+func (qt *QueryThrottle) AddClass(name string, match func(sql string) bool, limit ThrottleLimit) *QueryThrottle {
+	qt.mu.Lock()
+	defer qt.mu.Unlock()
+	class := &throttleClass{name: name, match: match, limiter: newThrottleLimiter(name, limit)}
+	for i, existing := range qt.classes {
+		if existing.name == name {
+			qt.classes[i] = class
+			return qt
+		}
+	}
+	qt.classes = append(qt.classes, class)
+	return qt
+}
+
+/**
+ * 按 SQL 指纹设置限制（字面量不同的同一语句共享限制）
+ */
+func (qt *QueryThrottle) SetFingerprintLimit(sql string, limit ThrottleLimit) *QueryThrottle {
+	fingerprint := SqlFingerprint(sql)
+	qt.mu.Lock()
+	defer qt.mu.Unlock()
+	qt.fingerprints[fingerprint] = newThrottleLimiter(fingerprint, limit)
+	return qt
+}
+
+var (
+	sqlFingerprintStringPattern = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'`)
+	sqlFingerprintNumberPattern = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	sqlFingerprintInListPattern = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+)
+
+/**
+ * SQL 指纹：字符串与数字字面量替换为 ?，IN 列表合并为 (?)，合并空白并转为小写
+ */
+func SqlFingerprint(sql string) string {
+	fingerprint := sqlFingerprintStringPattern.ReplaceAllString(sql, "?")
+	fingerprint = sqlFingerprintNumberPattern.ReplaceAllString(fingerprint, "?")
+	fingerprint = sqlFingerprintInListPattern.ReplaceAllString(fingerprint, "(?)")
+	return strings.ToLower(normalizeSqlText(fingerprint))
+}
+
+/**
+ * 查找语句所属类别的限制
+ */
+func (qt *QueryThrottle) classLimiter(sql string) *throttleLimiter {
+	qt.mu.RLock()
+	defer qt.mu.RUnlock()
+	for _, class := range qt.classes {
+		if class.match != nil && class.match(sql) {
+			return class.limiter
+		}
+	}
+	if len(qt.fingerprints) == 0 {
+		return nil
+	}
+	return qt.fingerprints[SqlFingerprint(sql)]
+}
+
+/**
+ * 获取执行许可，返回释放函数
+ */
+func (qt *QueryThrottle) acquire(sql string) (func(), error) {
+	classLimiter := qt.classLimiter(sql)
+	releaseClass := func() {}
+	if classLimiter != nil {
+		release, err := classLimiter.acquire()
+		if err != nil {
+			return nil, NewQueryThrottledException(qt.name, classLimiter.name, err.Error(), sql)
+		}
+		releaseClass = release
+	}
+	releaseDb, err := qt.dbLimiter.acquire()
+	if err != nil {
+		releaseClass()
+		return nil, NewQueryThrottledException(qt.name, qt.dbLimiter.name, err.Error(), sql)
+	}
+	return func() {
+		releaseDb()
+		releaseClass()
+	}, nil
+}
+
+/**
+ * 在限流保护下执行 fn（不经过 Db 的语句也可以共享同一限制）
+ */
+func (qt *QueryThrottle) Execute(sql string, fn func() error) error {
+	release, err := qt.acquire(sql)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn()
+}
+
+/**
+ * 获取许可：先取 QPS 令牌，再取并发许可
+ */
+func (l *throttleLimiter) acquire() (func(), error) {
+	deadline := time.Now().Add(l.limit.QueueTimeout)
+	if l.limit.Policy == ThrottlePolicyQueue && l.limit.MaxQueue > 0 {
+		if atomic.AddInt64(&l.waiting, 1) > int64(l.limit.MaxQueue) {
+			atomic.AddInt64(&l.waiting, -1)
+			atomic.AddInt64(&l.rejected, 1)
+			return nil, fmt.Errorf("排队数已满 (%d)", l.limit.MaxQueue)
+		}
+		defer atomic.AddInt64(&l.waiting, -1)
+	}
+
+	if err := l.takeToken(deadline); err != nil {
+		atomic.AddInt64(&l.rejected, 1)
+		return nil, err
+	}
+
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		default:
+			if l.limit.Policy == ThrottlePolicyShed {
+				atomic.AddInt64(&l.rejected, 1)
+				return nil, fmt.Errorf("并发数已达上限 (%d)", l.limit.MaxConcurrent)
+			}
+			timer := time.NewTimer(time.Until(deadline))
+			select {
+			case l.slots <- struct{}{}:
+				timer.Stop()
+			case <-timer.C:
+				atomic.AddInt64(&l.rejected, 1)
+				return nil, fmt.Errorf("等待并发许可超过 %v (上限 %d)", l.limit.QueueTimeout, l.limit.MaxConcurrent)
+			}
+		}
+	}
+
+	atomic.AddInt64(&l.admitted, 1)
+	return func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}, nil
+}
+
+/**
+ * 从令牌桶取一个令牌，排队策略下等待到 deadline
+ */
+func (l *throttleLimiter) takeToken(deadline time.Time) error {
+	if l.limit.MaxQPS <= 0 {
+		return nil
+	}
+	l.bucketMu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.lastRefill).Seconds() * l.limit.MaxQPS
+	if l.tokens > l.limit.MaxQPS {
+		l.tokens = l.limit.MaxQPS
+	}
+	l.lastRefill = now
+	if l.tokens >= 1 {
+		l.tokens--
+		l.bucketMu.Unlock()
+		return nil
+	}
+
+	// 预占下一个令牌：等待时间为补足到 1 个令牌所需的时间
+	wait := time.Duration((1 - l.tokens) / l.limit.MaxQPS * float64(time.Second))
+	if l.limit.Policy == ThrottlePolicyShed || now.Add(wait).After(deadline) {
+		l.bucketMu.Unlock()
+		return fmt.Errorf("QPS 已达上限 (%.1f/s)", l.limit.MaxQPS)
+	}
+	l.tokens--
+	l.bucketMu.Unlock()
+	time.Sleep(wait)
+	return nil
+}
+
+/**
+ * 限制的运行状态
+ */
+func (l *throttleLimiter) status() map[string]interface{} {
+	return map[string]interface{}{
+		"max_concurrent": l.limit.MaxConcurrent,
+		"max_qps":        l.limit.MaxQPS,
+		"policy":         l.limit.Policy.String(),
+		"in_flight":      len(l.slots),
+		"waiting":        atomic.LoadInt64(&l.waiting),
+		"admitted":       atomic.LoadInt64(&l.admitted),
+		"rejected":       atomic.LoadInt64(&l.rejected),
+	}
+}
+
+/**
+ * 获取限流状态（Db 整体、各类别与各指纹）
+ */
+func (qt *QueryThrottle) GetStatus() map[string]interface{} {
+	qt.mu.RLock()
+	defer qt.mu.RUnlock()
+
+	classes := make(map[string]interface{}, len(qt.classes))
+	for _, class := range qt.classes {
+		classes[class.name] = class.limiter.status()
+	}
+	fingerprints := make(map[string]interface{}, len(qt.fingerprints))
+	for fingerprint, limiter := range qt.fingerprints {
+		fingerprints[fingerprint] = limiter.status()
+	}
+	return map[string]interface{}{
+		"name":         qt.name,
+		"db":           qt.dbLimiter.status(),
+		"classes":      classes,
+		"fingerprints": fingerprints,
+	}
+}
+
+/**
+ * 设置语句限流器（nil 表示不限流）
+ */
+func (db *Db) SetQueryThrottle(throttle *QueryThrottle) *Db {
+	db.root().queryThrottle = throttle
+	return db
+}
+
+/**
+ * 获取语句限流器，未设置时为 nil
+ */
+func (db *Db) GetQueryThrottle() *QueryThrottle {
+	return db.root().queryThrottle
+}
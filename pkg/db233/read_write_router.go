@@ -0,0 +1,345 @@
+package db233
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/**
+ * 复制延迟指标名（所有从库中的最大延迟，单位秒）
+ */
+const ReplicationLagMetric = "replication_lag_seconds"
+
+/**
+ * 单个从库的复制延迟指标名，如 replication_lag_seconds.replica1
+ */
+func ReplicationLagMetricName(replicaName string) string {
+	return ReplicationLagMetric + "." + replicaName
+}
+
+/**
+ * ReadWriteRouter - 读写分离路由
+ *
+ * 写请求走主库，读请求在"轮换中"的从库间轮询；开启延迟监控后，
+ * 复制延迟超过阈值（或探测失败、维护中）的从库自动移出轮换，延迟恢复后重新加入，
+ * 所有从库都不可用时读请求回退到主库。延迟同时上报给 AlertManager：
+ *
+ *   router := db233.NewReadWriteRouter(primaryDb)
+ *   router.AddReplica("replica1", replicaDb1)
+ *   router.AddReplica("replica2", replicaDb2)
+ *   router.StartLagMonitor(db233.ReplicationLagMonitorOptions{
+ *       MaxLag:       5 * time.Second,
+ *       Interval:     10 * time.Second,
+ *       AlertManager: alertManager, // 规则 Metric 为 replication_lag_seconds 或 replication_lag_seconds.replica1
+ *   })
+ *
+ *   repo := db233.NewBaseCrudRepository(router.ForRead())
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type ReadWriteRouter struct {
+	primary  *Db
+	replicas []*replicaState
+	next     uint64
+	mu       sync.RWMutex
+
+	stopChan    chan bool
+	monitorDone <-chan struct{}
+
+	// 读己之写选项，见 SetReadYourWrites / NewSession
+	readYourWrites ReadYourWritesOptions
+}
+
+/**
+ * ReplicationLagMonitorOptions - 复制延迟监控选项
+ */
+type ReplicationLagMonitorOptions struct {
+	// 延迟超过该值时移出轮换
+	MaxLag time.Duration
+
+	// 延迟回落到该值以下时重新加入轮换，默认 MaxLag / 2（避免在阈值附近反复切换）
+	RecoverLag time.Duration
+
+	// 检查间隔，默认 10 秒
+	Interval time.Duration
+
+	// 告警管理器（可选），上报 ReplicationLagMetric 与 ReplicationLagMetricName(name)
+	AlertManager *AlertManager
+}
+
+/**
+ * ReplicaStatus - 从库状态
+ */
+type ReplicaStatus struct {
+	Name        string        `json:"name"`
+	InRotation  bool          `json:"inRotation"`
+	Lag         time.Duration `json:"lag"`
+	LastChecked time.Time     `json:"lastChecked"`
+	Message     string        `json:"message"`
+}
+
+/**
+ * 从库运行状态
+ */
+type replicaState struct {
+	name    string
+	db      *Db
+	checker *HealthChecker
+
+	inRotation  bool
+	lag         time.Duration
+	lastChecked time.Time
+	message     string
+}
+
+/**
+ * 创建读写分离路由
+ *
+ * @param primary 主库
+ */
+func NewReadWriteRouter(primary *Db) *ReadWriteRouter {
+	return &ReadWriteRouter{primary: primary}
+}
+
+/**
+ * 添加从库（初始在轮换中）
+ *
+ * @param name 从库名称（用于指标与状态）
+ * @param db 从库
+ * @return *HealthChecker 该从库的健康检查器，可调整超时或复制延迟探测
+ */
+func (rw *ReadWriteRouter) AddReplica(name string, db *Db) *HealthChecker {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	checker := NewHealthChecker(db)
+	for _, replica := range rw.replicas {
+		if replica.name == name {
+			replica.db = db
+			replica.checker = checker
+			return checker
+		}
+	}
+	rw.replicas = append(rw.replicas, &replicaState{
+		name:       name,
+		db:         db,
+		checker:    checker,
+		inRotation: true,
+		message:    "尚未检查",
+	})
+	return checker
+}
+
+/**
+ * 移除从库
+ */
+func (rw *ReadWriteRouter) RemoveReplica(name string) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	for i, replica := range rw.replicas {
+		if replica.name == name {
+			rw.replicas = append(rw.replicas[:i], rw.replicas[i+1:]...)
+			return
+		}
+	}
+}
+
+/**
+ * 写库（主库）
+ */
+func (rw *ReadWriteRouter) ForWrite() *Db {
+	return rw.primary
+}
+
+/**
+ * 读库：轮换中的从库轮询，没有可用从库时回退到主库
+ */
+func (rw *ReadWriteRouter) ForRead() *Db {
+	rw.mu.RLock()
+	defer rw.mu.RUnlock()
+
+	available := make([]*Db, 0, len(rw.replicas))
+	for _, replica := range rw.replicas {
+		if replica.inRotation {
+			available = append(available, replica.db)
+		}
+	}
+	if len(available) == 0 {
+		return rw.primary
+	}
+	index := atomic.AddUint64(&rw.next, 1) - 1
+	return available[index%uint64(len(available))]
+}
+
+/**
+ * 手动设置从库是否在轮换中（下一次延迟检查会按结果重新评估）
+ */
+func (rw *ReadWriteRouter) SetReplicaInRotation(name string, inRotation bool) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	for _, replica := range rw.replicas {
+		if replica.name == name {
+			replica.inRotation = inRotation
+			replica.message = "手动设置"
+			return nil
+		}
+	}
+	return NewConfigurationException(fmt.Sprintf("从库不存在: %s", name))
+}
+
+/**
+ * 获取所有从库状态（按名称排序）
+ */
+func (rw *ReadWriteRouter) GetReplicaStatus() []ReplicaStatus {
+	rw.mu.RLock()
+	defer rw.mu.RUnlock()
+
+	statuses := make([]ReplicaStatus, 0, len(rw.replicas))
+	for _, replica := range rw.replicas {
+		statuses = append(statuses, ReplicaStatus{
+			Name:        replica.name,
+			InRotation:  replica.inRotation,
+			Lag:         replica.lag,
+			LastChecked: replica.lastChecked,
+			Message:     replica.message,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+/**
+ * 立即检查所有从库的复制延迟并更新轮换状态
+ *
+ * @param options 监控选项（MaxLag 必填）
+ */
+func (rw *ReadWriteRouter) CheckReplicas(options ReplicationLagMonitorOptions) []ReplicaStatus {
+	options = normalizeLagMonitorOptions(options)
+
+	rw.mu.RLock()
+	replicas := append([]*replicaState(nil), rw.replicas...)
+	rw.mu.RUnlock()
+
+	// 探测在锁外并发执行，避免慢从库阻塞读路由
+	results := make([]*HealthCheckResult, len(replicas))
+	var wg sync.WaitGroup
+	for i, replica := range replicas {
+		wg.Add(1)
+		go func(i int, checker *HealthChecker) {
+			defer wg.Done()
+			results[i] = checker.CheckReplicationLag(options.MaxLag)
+		}(i, replica.checker)
+	}
+	wg.Wait()
+
+	var maxLag time.Duration
+	measured := false
+	rw.mu.Lock()
+	for i, replica := range replicas {
+		result := results[i]
+		wasInRotation := replica.inRotation
+		replica.lastChecked = result.Timestamp
+		replica.message = result.Message
+
+		measuredLag := !result.Maintenance && result.Error == nil
+		switch {
+		case !measuredLag:
+			// 维护中或探测失败
+			replica.inRotation = false
+		case result.ReplicationLag > options.MaxLag:
+			replica.inRotation = false
+		case result.ReplicationLag <= options.RecoverLag:
+			replica.inRotation = true
+		}
+		if measuredLag {
+			replica.lag = result.ReplicationLag
+			measured = true
+			if result.ReplicationLag > maxLag {
+				maxLag = result.ReplicationLag
+			}
+		}
+
+		if wasInRotation && !replica.inRotation {
+			LogFields(WARN, "从库移出读轮换", "replica", replica.name, "reason", result.Message)
+		} else if !wasInRotation && replica.inRotation {
+			LogFields(INFO, "从库重新加入读轮换", "replica", replica.name, "lag", result.ReplicationLag)
+		}
+	}
+	rw.mu.Unlock()
+
+	if options.AlertManager != nil {
+		for i, replica := range replicas {
+			result := results[i]
+			if result.Maintenance || result.Error != nil {
+				continue
+			}
+			options.AlertManager.CheckMetric(ReplicationLagMetricName(replica.name), result.ReplicationLag.Seconds())
+		}
+		if measured {
+			options.AlertManager.CheckMetric(ReplicationLagMetric, maxLag.Seconds())
+		}
+	}
+	return rw.GetReplicaStatus()
+}
+
+/**
+ * 启动后台复制延迟监控（重复调用时先停止上一次）
+ */
+func (rw *ReadWriteRouter) StartLagMonitor(options ReplicationLagMonitorOptions) {
+	rw.StopLagMonitor()
+	options = normalizeLagMonitorOptions(options)
+
+	rw.mu.Lock()
+	stopChan := make(chan bool)
+	rw.stopChan = stopChan
+	rw.monitorDone = goSupervised("replication_lag_monitor", stopChan, func() {
+		ticker := time.NewTicker(options.Interval)
+		defer ticker.Stop()
+
+		rw.CheckReplicas(options)
+		for {
+			select {
+			case <-ticker.C:
+				rw.CheckReplicas(options)
+			case <-stopChan:
+				return
+			}
+		}
+	})
+	rw.mu.Unlock()
+	LogInfo("复制延迟监控启动: 阈值=%v, 恢复阈值=%v, 间隔=%v", options.MaxLag, options.RecoverLag, options.Interval)
+}
+
+/**
+ * 停止后台复制延迟监控
+ */
+func (rw *ReadWriteRouter) StopLagMonitor() {
+	rw.mu.Lock()
+	stopChan, monitorDone := rw.stopChan, rw.monitorDone
+	rw.stopChan, rw.monitorDone = nil, nil
+	rw.mu.Unlock()
+
+	if stopChan == nil {
+		return
+	}
+	close(stopChan)
+	<-monitorDone
+	LogInfo("复制延迟监控停止")
+}
+
+/**
+ * 填充监控选项默认值
+ */
+func normalizeLagMonitorOptions(options ReplicationLagMonitorOptions) ReplicationLagMonitorOptions {
+	if options.RecoverLag <= 0 || options.RecoverLag > options.MaxLag {
+		options.RecoverLag = options.MaxLag / 2
+	}
+	if options.Interval <= 0 {
+		options.Interval = 10 * time.Second
+	}
+	return options
+}
@@ -0,0 +1,239 @@
+package db233
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/**
+ * 读己之写（read-your-writes）一致性
+ *
+ * 同一会话写入后的 Window 内，读请求固定走主库，避免刚 Save 完就从尚未同步的从库读到旧数据；
+ * 开启 WaitForGTID（MySQL）后，改为在从库上执行 WAIT_FOR_EXECUTED_GTID_SET 等待写入同步，
+ * 等到则读从库，超时回退到主库：
+ *
+ *   router.SetReadYourWrites(db233.ReadYourWritesOptions{Window: 5 * time.Second})
+ *
+ *   session := router.NewSession()
+ *   db233.NewBaseCrudRepository(session.ForWrite()).Save(order)   // ForWrite 视为写入
+ *   db233.NewBaseCrudRepository(session.ForRead()).FindById(...)  // 5 秒内读主库
+ *
+ * 跨请求（如 HTTP）时把 session.Token().String() 放入 Cookie / Header，下个请求解析后恢复：
+ *
+ *   token, _ := db233.ParseConsistencyToken(cookie.Value)
+ *   session := router.ResumeSession(token)
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type ReadYourWritesOptions struct {
+	// 写入后读主库的时间窗口，默认 5 秒
+	Window time.Duration
+
+	// 在从库上等待写入时的 GTID 同步（仅 MySQL，需开启 gtid_mode）
+	WaitForGTID bool
+
+	// 单个从库的 GTID 等待超时，默认 1 秒
+	GTIDWaitTimeout time.Duration
+}
+
+/**
+ * ConsistencyToken - 一致性令牌（写入时间与主库已执行的 GTID 集合）
+ */
+type ConsistencyToken struct {
+	WrittenAt time.Time
+	GTIDSet   string
+}
+
+/**
+ * 令牌是否为空（没有写入）
+ */
+func (t ConsistencyToken) IsZero() bool {
+	return t.WrittenAt.IsZero()
+}
+
+/**
+ * 序列化为字符串（毫秒时间戳;GTID 集合）
+ */
+func (t ConsistencyToken) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.WrittenAt.UnixMilli(), 10) + ";" + t.GTIDSet
+}
+
+/**
+ * 解析 ConsistencyToken.String() 的结果（空字符串返回空令牌）
+ */
+func ParseConsistencyToken(text string) (ConsistencyToken, error) {
+	if text == "" {
+		return ConsistencyToken{}, nil
+	}
+	millisText, gtidSet, _ := strings.Cut(text, ";")
+	millis, err := strconv.ParseInt(millisText, 10, 64)
+	if err != nil {
+		return ConsistencyToken{}, NewValidationExceptionWithCause(err, fmt.Sprintf("无效的一致性令牌: %s", text))
+	}
+	return ConsistencyToken{WrittenAt: time.UnixMilli(millis), GTIDSet: gtidSet}, nil
+}
+
+/**
+ * 设置读己之写选项
+ */
+func (rw *ReadWriteRouter) SetReadYourWrites(options ReadYourWritesOptions) *ReadWriteRouter {
+	if options.Window <= 0 {
+		options.Window = 5 * time.Second
+	}
+	if options.GTIDWaitTimeout <= 0 {
+		options.GTIDWaitTimeout = time.Second
+	}
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	rw.readYourWrites = options
+	return rw
+}
+
+func (rw *ReadWriteRouter) getReadYourWrites() ReadYourWritesOptions {
+	rw.mu.RLock()
+	options := rw.readYourWrites
+	rw.mu.RUnlock()
+	if options.Window <= 0 {
+		options.Window = 5 * time.Second
+	}
+	if options.GTIDWaitTimeout <= 0 {
+		options.GTIDWaitTimeout = time.Second
+	}
+	return options
+}
+
+/**
+ * 生成当前写入的令牌（开启 WaitForGTID 时读取主库的 @@GLOBAL.gtid_executed，应在写入完成后调用）
+ */
+func (rw *ReadWriteRouter) CaptureWriteToken() ConsistencyToken {
+	token := ConsistencyToken{WrittenAt: time.Now()}
+	options := rw.getReadYourWrites()
+	if !options.WaitForGTID || rw.primary.DatabaseType != EnumDatabaseTypeMySQL {
+		return token
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), options.GTIDWaitTimeout)
+	defer cancel()
+	if err := rw.primary.DataSource.QueryRowContext(ctx, "SELECT @@GLOBAL.gtid_executed").Scan(&token.GTIDSet); err != nil {
+		LogWarn("读取主库 GTID 失败，写入窗口内将读主库: %v", err)
+		token.GTIDSet = ""
+	}
+	return token
+}
+
+/**
+ * 按令牌选择读库：令牌为空或超出窗口时正常读从库；窗口内优先等待 GTID 同步的从库，否则读主库
+ */
+func (rw *ReadWriteRouter) ForReadAfter(token ConsistencyToken) *Db {
+	options := rw.getReadYourWrites()
+	if token.IsZero() || time.Since(token.WrittenAt) >= options.Window {
+		return rw.ForRead()
+	}
+	if options.WaitForGTID && token.GTIDSet != "" {
+		if replica := rw.waitForGTIDReplica(token.GTIDSet, options.GTIDWaitTimeout); replica != nil {
+			return replica
+		}
+	}
+	return rw.primary
+}
+
+/**
+ * 依次在轮换中的从库上等待 GTID 同步，返回第一个等到的从库
+ */
+func (rw *ReadWriteRouter) waitForGTIDReplica(gtidSet string, timeout time.Duration) *Db {
+	rw.mu.RLock()
+	available := make([]*replicaState, 0, len(rw.replicas))
+	for _, replica := range rw.replicas {
+		if replica.inRotation {
+			available = append(available, replica)
+		}
+	}
+	rw.mu.RUnlock()
+	if len(available) == 0 {
+		return nil
+	}
+
+	start := atomic.AddUint64(&rw.next, 1) - 1
+	for i := range available {
+		replica := available[(start+uint64(i))%uint64(len(available))]
+		ctx, cancel := context.WithTimeout(context.Background(), timeout+time.Second)
+		var result int
+		err := replica.db.DataSource.QueryRowContext(ctx, "SELECT WAIT_FOR_EXECUTED_GTID_SET(?, ?)", gtidSet, timeout.Seconds()).Scan(&result)
+		cancel()
+		if err == nil && result == 0 {
+			return replica.db
+		}
+		LogDebug("从库未在超时内同步 GTID: replica=%s, result=%d, 错误=%v", replica.name, result, err)
+	}
+	return nil
+}
+
+/**
+ * ReadWriteSession - 读己之写会话（同一用户 / 请求链路共享一个会话）
+ */
+type ReadWriteSession struct {
+	router *ReadWriteRouter
+
+	mu           sync.Mutex
+	token        ConsistencyToken
+	pendingWrite bool
+}
+
+/**
+ * 创建会话
+ */
+func (rw *ReadWriteRouter) NewSession() *ReadWriteSession {
+	return &ReadWriteSession{router: rw}
+}
+
+/**
+ * 用上个请求的令牌恢复会话
+ */
+func (rw *ReadWriteRouter) ResumeSession(token ConsistencyToken) *ReadWriteSession {
+	return &ReadWriteSession{router: rw, token: token}
+}
+
+/**
+ * 写库（视为一次写入）
+ */
+func (s *ReadWriteSession) ForWrite() *Db {
+	s.MarkWrite()
+	return s.router.ForWrite()
+}
+
+/**
+ * 记录一次写入（不通过 ForWrite 写入时手动调用）
+ */
+func (s *ReadWriteSession) MarkWrite() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pendingWrite = true
+}
+
+/**
+ * 读库（写入后的窗口内走主库或已同步的从库）
+ */
+func (s *ReadWriteSession) ForRead() *Db {
+	return s.router.ForReadAfter(s.Token())
+}
+
+/**
+ * 当前令牌（有未记录的写入时先生成令牌，GTID 在写入完成后读取）
+ */
+func (s *ReadWriteSession) Token() ConsistencyToken {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pendingWrite {
+		s.token = s.router.CaptureWriteToken()
+		s.pendingWrite = false
+	}
+	return s.token
+}
@@ -0,0 +1,171 @@
+package db233
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+ * ReplicationLagProbe - 复制延迟探测
+ *
+ * @return time.Duration 当前复制延迟
+ * @return error 探测失败（视为未就绪 / 移出读轮换）
+ */
+type ReplicationLagProbe func(ctx context.Context) (time.Duration, error)
+
+/**
+ * MySQL 从库复制延迟探测（SHOW REPLICA STATUS，旧版本回退到 SHOW SLAVE STATUS）
+ *
+ * 非从库（无复制状态）视为延迟 0；复制线程停止时 Seconds_Behind_Source 为 NULL，视为探测失败
+ */
+func MySQLReplicationLagProbe(db *Db) ReplicationLagProbe {
+	return func(ctx context.Context) (time.Duration, error) {
+		status, err := queryReplicationStatus(ctx, db.DataSource, "SHOW REPLICA STATUS")
+		if err != nil {
+			status, err = queryReplicationStatus(ctx, db.DataSource, "SHOW SLAVE STATUS")
+		}
+		if err != nil {
+			return 0, err
+		}
+		if status == nil {
+			return 0, nil
+		}
+
+		for _, column := range []string{"seconds_behind_source", "seconds_behind_master"} {
+			value, exists := status[column]
+			if !exists {
+				continue
+			}
+			if !value.Valid {
+				return 0, fmt.Errorf("复制未运行（%s 为 NULL）", column)
+			}
+			seconds, err := strconv.ParseInt(value.String, 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("无法解析复制延迟 %q: %w", value.String, err)
+			}
+			return time.Duration(seconds) * time.Second, nil
+		}
+		return 0, fmt.Errorf("复制状态中没有延迟列")
+	}
+}
+
+/**
+ * 读取复制状态的第一行（列名小写），没有行时返回 nil
+ */
+func queryReplicationStatus(ctx context.Context, dataSource *sql.DB, query string) (map[string]sql.NullString, error) {
+	rows, err := dataSource.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	status := make(map[string]sql.NullString, len(columns))
+	for i, column := range columns {
+		status[strings.ToLower(column)] = values[i]
+	}
+	return status, nil
+}
+
+/**
+ * PostgreSQL 备库复制延迟探测
+ *
+ * 非备库（pg_is_in_recovery() 为 false）视为延迟 0；已回放到最新接收位置
+ * （pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn()）时视为延迟 0，
+ * 否则取 now() - pg_last_xact_replay_timestamp()
+ */
+func PostgreSQLReplicationLagProbe(db *Db) ReplicationLagProbe {
+	return func(ctx context.Context) (time.Duration, error) {
+		query := `SELECT CASE
+			WHEN NOT pg_is_in_recovery() THEN 0
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), -1)
+		END`
+		var seconds float64
+		if err := db.DataSource.QueryRowContext(ctx, query).Scan(&seconds); err != nil {
+			return 0, err
+		}
+		if seconds < 0 {
+			return 0, fmt.Errorf("备库尚未回放任何事务，无法计算复制延迟")
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+}
+
+/**
+ * 按数据库类型选择复制延迟探测
+ */
+func DefaultReplicationLagProbe(db *Db) ReplicationLagProbe {
+	if db.DatabaseType == EnumDatabaseTypePostgreSQL {
+		return PostgreSQLReplicationLagProbe(db)
+	}
+	return MySQLReplicationLagProbe(db)
+}
+
+/**
+ * 设置复制延迟探测（默认按数据库类型选择，见 DefaultReplicationLagProbe）
+ */
+func (hc *HealthChecker) SetReplicationLagProbe(probe ReplicationLagProbe) {
+	hc.replicationLagProbe = probe
+}
+
+/**
+ * 复制延迟检查
+ *
+ * 延迟超过 maxLag、复制未运行或探测失败时不健康；探测失败时 Error 非 nil，
+ * 否则 ReplicationLag 为测得的延迟
+ *
+ * @param maxLag 延迟阈值
+ */
+func (hc *HealthChecker) CheckReplicationLag(maxLag time.Duration) *HealthCheckResult {
+	start := time.Now()
+	if result := hc.maintenanceResult(start); result != nil {
+		return result
+	}
+
+	probe := hc.replicationLagProbe
+	if probe == nil {
+		probe = DefaultReplicationLagProbe(hc.db)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hc.timeout)
+	defer cancel()
+
+	lag, err := probe(ctx)
+	result := &HealthCheckResult{
+		Timestamp:      start,
+		ResponseTime:   time.Since(start),
+		ReplicationLag: lag,
+	}
+	switch {
+	case err != nil:
+		result.Error = err
+		result.Message = "复制延迟探测失败: " + err.Error()
+	case lag > maxLag:
+		result.Message = fmt.Sprintf("复制延迟 %v 超过阈值 %v", lag, maxLag)
+	default:
+		result.Healthy = true
+		result.Message = fmt.Sprintf("复制延迟 %v", lag)
+	}
+	return result
+}
@@ -0,0 +1,297 @@
+package db233
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * ReportScheduler - 监控报告定时投递调度器
+ *
+ * 按类 cron 表达式周期性生成 MonitoringReportGenerator 报告，
+ * 并通过一个或多个 ReportSink 投递（本地目录、S3 兼容存储、HTTP POST、邮件）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type ReportScheduler struct {
+	generator *MonitoringReportGenerator
+	schedules map[string]*ScheduledReport
+
+	// 调度检查间隔
+	tickInterval time.Duration
+
+	running  bool
+	stopChan chan bool
+	mu       sync.RWMutex
+}
+
+/**
+ * ScheduledReport - 定时报告任务
+ */
+type ScheduledReport struct {
+	Name     string
+	Schedule *CronSchedule
+	Format   string
+	Sinks    []ReportSink
+
+	NextRun   time.Time
+	LastRun   time.Time
+	LastError error
+	RunCount  int64
+}
+
+/**
+ * RenderedReport - 待投递的报告
+ */
+type RenderedReport struct {
+	// 任务名
+	Name string
+
+	// 格式：json / text
+	Format string
+
+	// 建议文件名，如 daily_20260101_000000.json
+	FileName string
+
+	// 报告内容
+	Content []byte
+
+	GeneratedAt time.Time
+}
+
+/**
+ * ReportSink - 报告投递目标接口
+ */
+type ReportSink interface {
+	/**
+	 * 获取投递目标名称
+	 */
+	GetName() string
+
+	/**
+	 * 投递报告
+	 */
+	Deliver(report *RenderedReport) error
+}
+
+/**
+ * ReportRetentionPolicy - 报告保留策略，零值表示不清理
+ */
+type ReportRetentionPolicy struct {
+	// 最多保留的报告数
+	MaxReports int
+
+	// 最长保留时间
+	MaxAge time.Duration
+}
+
+/**
+ * 创建报告调度器
+ */
+func NewReportScheduler(generator *MonitoringReportGenerator) *ReportScheduler {
+	return &ReportScheduler{
+		generator:    generator,
+		schedules:    make(map[string]*ScheduledReport),
+		tickInterval: time.Second,
+	}
+}
+
+/**
+ * 添加定时报告任务（同名覆盖）
+ *
+ * @param name 任务名（也用作报告文件名前缀）
+ * @param expression cron 表达式
+ * @param format 报告格式：json / text
+ * @param sinks 投递目标
+ */
+func (rs *ReportScheduler) AddSchedule(name string, expression string, format string, sinks ...ReportSink) error {
+	if name == "" {
+		return NewValidationException("报告任务名不能为空")
+	}
+	if len(sinks) == 0 {
+		return NewValidationException(fmt.Sprintf("报告任务 %s 至少需要一个投递目标", name))
+	}
+	format = strings.ToLower(format)
+	if format != "json" && format != "text" {
+		return NewValidationException(fmt.Sprintf("不支持的报告格式: %s", format))
+	}
+
+	schedule, err := ParseCronExpression(expression)
+	if err != nil {
+		return err
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.schedules[name] = &ScheduledReport{
+		Name:     name,
+		Schedule: schedule,
+		Format:   format,
+		Sinks:    sinks,
+		NextRun:  schedule.Next(time.Now()),
+	}
+	LogInfo("报告任务已添加: %s, 表达式=%s, 下次运行=%s", name, expression, rs.schedules[name].NextRun.Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+/**
+ * 移除定时报告任务
+ */
+func (rs *ReportScheduler) RemoveSchedule(name string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	delete(rs.schedules, name)
+}
+
+/**
+ * 获取所有任务（按名称排序）
+ */
+func (rs *ReportScheduler) GetSchedules() []*ScheduledReport {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	result := make([]*ScheduledReport, 0, len(rs.schedules))
+	for _, schedule := range rs.schedules {
+		result = append(result, schedule)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+/**
+ * 启动调度
+ */
+func (rs *ReportScheduler) Start() {
+	rs.mu.Lock()
+	if rs.running {
+		rs.mu.Unlock()
+		return
+	}
+	rs.running = true
+	rs.stopChan = make(chan bool)
+	rs.mu.Unlock()
+
+	LogInfo("报告调度器启动")
+
+	goSupervised("report_scheduler", rs.stopChan, func() {
+		ticker := time.NewTicker(rs.tickInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				rs.runDue(now)
+			case <-rs.stopChan:
+				LogInfo("报告调度器停止")
+				return
+			}
+		}
+	})
+}
+
+/**
+ * 停止调度
+ */
+func (rs *ReportScheduler) Stop() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if !rs.running {
+		return
+	}
+	rs.running = false
+	close(rs.stopChan)
+}
+
+/**
+ * 执行到期任务
+ */
+func (rs *ReportScheduler) runDue(now time.Time) {
+	rs.mu.Lock()
+	due := make([]*ScheduledReport, 0)
+	for _, schedule := range rs.schedules {
+		if !schedule.NextRun.IsZero() && !now.Before(schedule.NextRun) {
+			schedule.NextRun = schedule.Schedule.Next(now)
+			due = append(due, schedule)
+		}
+	}
+	rs.mu.Unlock()
+
+	for _, schedule := range due {
+		rs.execute(schedule, now)
+	}
+}
+
+/**
+ * 立即运行指定任务
+ */
+func (rs *ReportScheduler) RunNow(name string) error {
+	rs.mu.RLock()
+	schedule, exists := rs.schedules[name]
+	rs.mu.RUnlock()
+	if !exists {
+		return NewValidationException(fmt.Sprintf("报告任务不存在: %s", name))
+	}
+	return rs.execute(schedule, time.Now())
+}
+
+/**
+ * 生成报告并投递到所有目标
+ */
+func (rs *ReportScheduler) execute(schedule *ScheduledReport, now time.Time) error {
+	content, err := rs.generator.RenderReport(schedule.Format)
+	if err == nil {
+		report := &RenderedReport{
+			Name:        schedule.Name,
+			Format:      schedule.Format,
+			FileName:    fmt.Sprintf("%s_%s.%s", schedule.Name, now.Format("20060102_150405"), reportFileExtension(schedule.Format)),
+			Content:     content,
+			GeneratedAt: now,
+		}
+
+		failures := make([]string, 0)
+		for _, sink := range schedule.Sinks {
+			if deliverErr := sink.Deliver(report); deliverErr != nil {
+				LogError("报告投递失败: 任务=%s, 目标=%s, 错误=%v", schedule.Name, sink.GetName(), deliverErr)
+				failures = append(failures, fmt.Sprintf("%s: %v", sink.GetName(), deliverErr))
+			}
+		}
+		if len(failures) > 0 {
+			err = fmt.Errorf("报告投递失败: %s", strings.Join(failures, "; "))
+		}
+	}
+
+	rs.mu.Lock()
+	schedule.LastRun = now
+	schedule.LastError = err
+	schedule.RunCount++
+	rs.mu.Unlock()
+
+	if err == nil {
+		LogInfo("报告已投递: 任务=%s, 目标数=%d", schedule.Name, len(schedule.Sinks))
+	}
+	return err
+}
+
+/**
+ * 报告格式对应的文件扩展名
+ */
+func reportFileExtension(format string) string {
+	if format == "text" {
+		return "txt"
+	}
+	return format
+}
+
+/**
+ * 报告内容类型
+ */
+func reportContentType(format string) string {
+	if format == "json" {
+		return "application/json"
+	}
+	return "text/plain; charset=utf-8"
+}
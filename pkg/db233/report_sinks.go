@@ -0,0 +1,497 @@
+package db233
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+/**
+ * 报告投递目标 - Go 版
+ *
+ * 内置：本地目录（带轮转保留）、S3 兼容存储（SigV4 签名，带保留）、HTTP POST、邮件
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+
+/**
+ * DirectoryReportSink - 本地目录投递
+ */
+type DirectoryReportSink struct {
+	Dir       string
+	Retention ReportRetentionPolicy
+}
+
+/**
+ * 创建本地目录投递目标
+ */
+func NewDirectoryReportSink(dir string, retention ReportRetentionPolicy) *DirectoryReportSink {
+	return &DirectoryReportSink{Dir: dir, Retention: retention}
+}
+
+/**
+ * 获取投递目标名称
+ */
+func (s *DirectoryReportSink) GetName() string {
+	return "directory:" + s.Dir
+}
+
+/**
+ * 写入报告文件并按保留策略清理旧报告
+ */
+func (s *DirectoryReportSink) Deliver(report *RenderedReport) error {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return fmt.Errorf("创建报告目录失败: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.Dir, report.FileName), report.Content, 0644); err != nil {
+		return fmt.Errorf("写入报告文件失败: %w", err)
+	}
+	return s.applyRetention(report.Name)
+}
+
+/**
+ * 清理同一任务的旧报告
+ */
+func (s *DirectoryReportSink) applyRetention(name string) error {
+	if s.Retention.MaxReports <= 0 && s.Retention.MaxAge <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return fmt.Errorf("读取报告目录失败: %w", err)
+	}
+
+	files := make([]reportObject, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), name+"_") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, reportObject{Key: entry.Name(), ModTime: info.ModTime()})
+	}
+
+	for _, file := range selectExpiredReports(files, s.Retention, time.Now()) {
+		if err := os.Remove(filepath.Join(s.Dir, file.Key)); err != nil {
+			LogWarn("删除过期报告失败: %s, 错误=%v", file.Key, err)
+		}
+	}
+	return nil
+}
+
+/**
+ * HttpPostReportSink - HTTP POST 投递
+ */
+type HttpPostReportSink struct {
+	Url     string
+	Headers map[string]string
+	Client  *http.Client
+}
+
+/**
+ * 创建 HTTP POST 投递目标
+ */
+func NewHttpPostReportSink(url string, headers map[string]string) *HttpPostReportSink {
+	return &HttpPostReportSink{
+		Url:     url,
+		Headers: headers,
+		Client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+/**
+ * 获取投递目标名称
+ */
+func (s *HttpPostReportSink) GetName() string {
+	return "http:" + s.Url
+}
+
+/**
+ * POST 报告内容，非 2xx 响应视为失败
+ */
+func (s *HttpPostReportSink) Deliver(report *RenderedReport) error {
+	req, err := http.NewRequest(http.MethodPost, s.Url, bytes.NewReader(report.Content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", reportContentType(report.Format))
+	req.Header.Set("X-Report-Name", report.Name)
+	req.Header.Set("X-Report-File", report.FileName)
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP 投递返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+/**
+ * EmailReportSink - 邮件投递（报告作为附件）
+ */
+type EmailReportSink struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+/**
+ * 创建邮件投递目标
+ */
+func NewEmailReportSink(host string, port int, username, password, from string, to ...string) *EmailReportSink {
+	return &EmailReportSink{
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: password,
+		From:     from,
+		To:       to,
+	}
+}
+
+/**
+ * 获取投递目标名称
+ */
+func (s *EmailReportSink) GetName() string {
+	return "email:" + strings.Join(s.To, ",")
+}
+
+/**
+ * 发送报告邮件
+ */
+func (s *EmailReportSink) Deliver(report *RenderedReport) error {
+	if len(s.To) == 0 {
+		return NewValidationException("邮件收件人不能为空")
+	}
+
+	message, err := s.buildMessage(report)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	return smtp.SendMail(fmt.Sprintf("%s:%d", s.Host, s.Port), auth, s.From, s.To, message)
+}
+
+/**
+ * 构建 MIME 邮件
+ */
+func (s *EmailReportSink) buildMessage(report *RenderedReport) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	textPart, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(textPart, "监控报告 %s 已生成，生成时间 %s，详见附件。\r\n", report.Name, report.GeneratedAt.Format("2006-01-02 15:04:05"))
+
+	attachment, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {reportContentType(report.Format)},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", report.FileName)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(report.Content)
+	for len(encoded) > 76 {
+		attachment.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	attachment.Write([]byte(encoded + "\r\n"))
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", s.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&message, "Subject: =?UTF-8?B?%s?=\r\n", base64.StdEncoding.EncodeToString([]byte("监控报告: "+report.Name)))
+	fmt.Fprintf(&message, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", writer.Boundary())
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
+
+/**
+ * S3ReportSink - S3 兼容对象存储投递（路径风格，AWS SigV4 签名）
+ */
+type S3ReportSink struct {
+	// 服务地址，如 https://s3.amazonaws.com 或 http://127.0.0.1:9000（MinIO）
+	Endpoint  string
+	Region    string
+	Bucket    string
+	Prefix    string
+	AccessKey string
+	SecretKey string
+	Retention ReportRetentionPolicy
+	Client    *http.Client
+}
+
+/**
+ * 创建 S3 兼容存储投递目标
+ */
+func NewS3ReportSink(endpoint, region, bucket, prefix, accessKey, secretKey string, retention ReportRetentionPolicy) *S3ReportSink {
+	return &S3ReportSink{
+		Endpoint:  strings.TrimRight(endpoint, "/"),
+		Region:    region,
+		Bucket:    bucket,
+		Prefix:    prefix,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		Retention: retention,
+		Client:    &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+/**
+ * 获取投递目标名称
+ */
+func (s *S3ReportSink) GetName() string {
+	return "s3:" + s.Bucket + "/" + s.Prefix
+}
+
+/**
+ * 上传报告并按保留策略清理旧报告
+ */
+func (s *S3ReportSink) Deliver(report *RenderedReport) error {
+	key := s.Prefix + report.FileName
+	headers := map[string]string{"Content-Type": reportContentType(report.Format)}
+	if _, err := s.do(http.MethodPut, key, nil, report.Content, headers); err != nil {
+		return fmt.Errorf("上传报告失败: %w", err)
+	}
+	return s.applyRetention(report.Name)
+}
+
+/**
+ * 清理同一任务的旧报告
+ */
+func (s *S3ReportSink) applyRetention(name string) error {
+	if s.Retention.MaxReports <= 0 && s.Retention.MaxAge <= 0 {
+		return nil
+	}
+
+	objects, err := s.listObjects(s.Prefix + name + "_")
+	if err != nil {
+		return fmt.Errorf("列举报告失败: %w", err)
+	}
+	for _, object := range selectExpiredReports(objects, s.Retention, time.Now()) {
+		if _, err := s.do(http.MethodDelete, object.Key, nil, nil, nil); err != nil {
+			LogWarn("删除过期报告失败: %s, 错误=%v", object.Key, err)
+		}
+	}
+	return nil
+}
+
+/**
+ * ListObjectsV2 响应（只解析需要的字段）
+ */
+type s3ListResult struct {
+	Contents []struct {
+		Key          string
+		LastModified time.Time
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+/**
+ * 列举指定前缀的对象
+ */
+func (s *S3ReportSink) listObjects(prefix string) ([]reportObject, error) {
+	objects := make([]reportObject, 0)
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		body, err := s.do(http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result s3ListResult
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, err
+		}
+		for _, content := range result.Contents {
+			objects = append(objects, reportObject{Key: content.Key, ModTime: content.LastModified})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+/**
+ * 发送签名请求
+ */
+func (s *S3ReportSink) do(method string, key string, query url.Values, payload []byte, headers map[string]string) ([]byte, error) {
+	target := s.Endpoint + "/" + s.Bucket + "/" + key
+	if query != nil {
+		target += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	s.sign(req, payload, time.Now().UTC())
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("S3 返回状态码 %d: %s", resp.StatusCode, string(respBody))
+	}
+	return respBody, nil
+}
+
+/**
+ * AWS Signature Version 4 签名
+ */
+func (s *S3ReportSink) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// 规范化请求头（小写、排序）
+	headerNames := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		headerNames = append(headerNames, strings.ToLower(name))
+	}
+	sort.Strings(headerNames)
+	var canonicalHeaders strings.Builder
+	for _, name := range headerNames {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n")
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+
+	// url.Values.Encode 已按键排序，空格需编码为 %20
+	canonicalQuery := strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20")
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	signingKey := hmacSha256([]byte("AWS4"+s.SecretKey), date)
+	signingKey = hmacSha256(signingKey, s.Region)
+	signingKey = hmacSha256(signingKey, "s3")
+	signingKey = hmacSha256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, signedHeaders, signature))
+}
+
+/**
+ * SHA-256 十六进制摘要
+ */
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+/**
+ * HMAC-SHA256
+ */
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+/**
+ * reportObject - 已投递的报告（文件或对象）
+ */
+type reportObject struct {
+	Key     string
+	ModTime time.Time
+}
+
+/**
+ * 按保留策略选出需要删除的报告
+ */
+func selectExpiredReports(objects []reportObject, retention ReportRetentionPolicy, now time.Time) []reportObject {
+	// 新的在前
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].ModTime.Equal(objects[j].ModTime) {
+			return objects[i].Key > objects[j].Key
+		}
+		return objects[i].ModTime.After(objects[j].ModTime)
+	})
+
+	expired := make([]reportObject, 0)
+	for i, object := range objects {
+		if retention.MaxReports > 0 && i >= retention.MaxReports {
+			expired = append(expired, object)
+			continue
+		}
+		if retention.MaxAge > 0 && now.Sub(object.ModTime) > retention.MaxAge {
+			expired = append(expired, object)
+		}
+	}
+	return expired
+}
@@ -0,0 +1,270 @@
+package db233
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+/**
+ * 存储库拦截器（ORM 层的横切逻辑）
+ *
+ * 插件只能看到 SQL；拦截器在存储库操作层面收到实体与元数据（表名、主键列、操作类型），
+ * 适合审计、缓存失效、按实体统计等。拦截器按需实现以下接口，与实体生命周期钩子同一时机调用：
+ *
+ *   BeforeSaveEntity：Save / Update / Upsert 前（在实体 BeforeSave 钩子之后），返回错误时否决写入
+ *   AfterSaveEntity： 写库成功后
+ *   AfterFindEntity： 查询加载后（在实体 AfterFind 钩子之后）
+ *   OnDelete：        DeleteById 删除前，收到删除前的实体（记录不存在时不调用），返回错误时否决删除
+ *
+ *   db233.GetRepositoryInterceptorManagerInstance().AddInterceptor(auditInterceptor)   // 全局
+ *   repo := db233.NewBaseCrudRepository(db).WithInterceptors(cacheInvalidator)       // 单个存储库
+ *
+ * 全局拦截器先于存储库拦截器调用，同级按添加顺序调用
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type RepositoryInterceptor interface {
+	GetInterceptorName() string
+}
+
+/**
+ * BeforeSaveEntityInterceptor - 保存 / 更新前拦截
+ */
+type BeforeSaveEntityInterceptor interface {
+	BeforeSaveEntity(ctx context.Context, info *EntityOperationInfo, entity IDbEntity) error
+}
+
+/**
+ * AfterSaveEntityInterceptor - 保存 / 更新成功后拦截
+ */
+type AfterSaveEntityInterceptor interface {
+	AfterSaveEntity(ctx context.Context, info *EntityOperationInfo, entity IDbEntity)
+}
+
+/**
+ * AfterFindEntityInterceptor - 查询加载后拦截
+ */
+type AfterFindEntityInterceptor interface {
+	AfterFindEntity(ctx context.Context, info *EntityOperationInfo, entity IDbEntity)
+}
+
+/**
+ * OnDeleteInterceptor - 删除前拦截
+ */
+type OnDeleteInterceptor interface {
+	OnDelete(ctx context.Context, info *EntityOperationInfo, entity IDbEntity) error
+}
+
+/**
+ * EnumEntityOperation - 存储库操作类型
+ */
+type EnumEntityOperation string
+
+const (
+	// Save / Update / Upsert / SaveBatch
+	EntityOperationSave EnumEntityOperation = "save"
+	// FindById / FindAll / FindByCondition 等查询
+	EntityOperationFind EnumEntityOperation = "find"
+	// DeleteById
+	EntityOperationDelete EnumEntityOperation = "delete"
+)
+
+/**
+ * EntityOperationInfo - 拦截器收到的操作元数据
+ */
+type EntityOperationInfo struct {
+	Operation EnumEntityOperation
+
+	// 逻辑表名（未经过作用域改写）
+	TableName string
+
+	EntityType        reflect.Type
+	PrimaryKeyColumns []string
+
+	// 删除时的主键参数，其余操作为 nil
+	Id interface{}
+
+	// 执行操作的 Db（MemoryRepository 为 nil）
+	Db *Db
+}
+
+/**
+ * RepositoryInterceptorManager - 全局存储库拦截器
+ */
+type RepositoryInterceptorManager struct {
+	interceptors []RepositoryInterceptor
+	mu           sync.RWMutex
+}
+
+var (
+	repositoryInterceptorManagerInstance *RepositoryInterceptorManager
+	repositoryInterceptorManagerOnce     sync.Once
+)
+
+/**
+ * 获取单例实例
+ */
+func GetRepositoryInterceptorManagerInstance() *RepositoryInterceptorManager {
+	repositoryInterceptorManagerOnce.Do(func() {
+		repositoryInterceptorManagerInstance = &RepositoryInterceptorManager{}
+	})
+	return repositoryInterceptorManagerInstance
+}
+
+/**
+ * 添加全局拦截器（同名拦截器替换原位置）
+ */
+func (m *RepositoryInterceptorManager) AddInterceptor(interceptor RepositoryInterceptor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, existing := range m.interceptors {
+		if existing.GetInterceptorName() == interceptor.GetInterceptorName() {
+			m.interceptors[i] = interceptor
+			return
+		}
+	}
+	m.interceptors = append(m.interceptors, interceptor)
+}
+
+/**
+ * 按名称移除全局拦截器
+ */
+func (m *RepositoryInterceptorManager) RemoveInterceptor(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, existing := range m.interceptors {
+		if existing.GetInterceptorName() == name {
+			m.interceptors = append(m.interceptors[:i:i], m.interceptors[i+1:]...)
+			return
+		}
+	}
+}
+
+/**
+ * 获取全部全局拦截器
+ */
+func (m *RepositoryInterceptorManager) GetAll() []RepositoryInterceptor {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]RepositoryInterceptor(nil), m.interceptors...)
+}
+
+/**
+ * 返回附加了拦截器的存储库副本（原存储库不受影响）
+ */
+func (r *BaseCrudRepository) WithInterceptors(interceptors ...RepositoryInterceptor) *BaseCrudRepository {
+	clone := *r
+	clone.interceptors = append(append([]RepositoryInterceptor(nil), r.interceptors...), interceptors...)
+	return &clone
+}
+
+/**
+ * 生效的拦截器：全局在前，存储库在后
+ */
+func (r *BaseCrudRepository) effectiveInterceptors() []RepositoryInterceptor {
+	global := GetRepositoryInterceptorManagerInstance().GetAll()
+	if len(r.interceptors) == 0 {
+		return global
+	}
+	return append(global, r.interceptors...)
+}
+
+/**
+ * 构建操作元数据
+ */
+func (r *BaseCrudRepository) entityOperationInfo(operation EnumEntityOperation, entity IDbEntity, id interface{}) *EntityOperationInfo {
+	entityType := reflect.TypeOf(entity)
+	if entityType.Kind() == reflect.Ptr {
+		entityType = entityType.Elem()
+	}
+	return &EntityOperationInfo{
+		Operation:         operation,
+		TableName:         logicalTableName(entity),
+		EntityType:        entityType,
+		PrimaryKeyColumns: GetCrudManagerInstance().GetPrimaryKeyColumnNames(entity),
+		Id:                id,
+		Db:                r.db,
+	}
+}
+
+/**
+ * 调用保存前拦截器
+ */
+func (r *BaseCrudRepository) interceptBeforeSave(entity IDbEntity) error {
+	var info *EntityOperationInfo
+	for _, interceptor := range r.effectiveInterceptors() {
+		if before, ok := interceptor.(BeforeSaveEntityInterceptor); ok {
+			if info == nil {
+				info = r.entityOperationInfo(EntityOperationSave, entity, nil)
+			}
+			if err := before.BeforeSaveEntity(r.GetContext(), info, entity); err != nil {
+				LogDebug("拦截器否决写入: 拦截器=%s, 实体=%T, 错误=%v", interceptor.GetInterceptorName(), entity, err)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/**
+ * 调用保存后拦截器
+ */
+func (r *BaseCrudRepository) interceptAfterSave(entity IDbEntity) {
+	var info *EntityOperationInfo
+	for _, interceptor := range r.effectiveInterceptors() {
+		if after, ok := interceptor.(AfterSaveEntityInterceptor); ok {
+			if info == nil {
+				info = r.entityOperationInfo(EntityOperationSave, entity, nil)
+			}
+			after.AfterSaveEntity(r.GetContext(), info, entity)
+		}
+	}
+}
+
+/**
+ * 调用查询后拦截器
+ */
+func (r *BaseCrudRepository) interceptAfterFind(entity IDbEntity) {
+	var info *EntityOperationInfo
+	for _, interceptor := range r.effectiveInterceptors() {
+		if after, ok := interceptor.(AfterFindEntityInterceptor); ok {
+			if info == nil {
+				info = r.entityOperationInfo(EntityOperationFind, entity, nil)
+			}
+			after.AfterFindEntity(r.GetContext(), info, entity)
+		}
+	}
+}
+
+/**
+ * 是否有删除前拦截器（有时删除前需要加载实体）
+ */
+func (r *BaseCrudRepository) hasDeleteInterceptor() bool {
+	for _, interceptor := range r.effectiveInterceptors() {
+		if _, ok := interceptor.(OnDeleteInterceptor); ok {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * 调用删除前拦截器
+ */
+func (r *BaseCrudRepository) interceptOnDelete(id interface{}, entity IDbEntity) error {
+	var info *EntityOperationInfo
+	for _, interceptor := range r.effectiveInterceptors() {
+		if onDelete, ok := interceptor.(OnDeleteInterceptor); ok {
+			if info == nil {
+				info = r.entityOperationInfo(EntityOperationDelete, entity, id)
+			}
+			if err := onDelete.OnDelete(r.GetContext(), info, entity); err != nil {
+				LogDebug("拦截器否决删除: 拦截器=%s, 实体=%T, ID=%v, 错误=%v", interceptor.GetInterceptorName(), entity, id, err)
+				return err
+			}
+		}
+	}
+	return nil
+}
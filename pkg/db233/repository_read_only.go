@@ -0,0 +1,91 @@
+package db233
+
+import "time"
+
+/**
+ * 只读存储库（报表 / 分析负载）
+ *
+ * ReadOnly 返回存储库的只读副本：Save / SaveBatch / Update / UpdateBatch / UpdateColumns / UpdateNonZero /
+ * Upsert / DeleteById / UpdateWhere / DeleteWhere 直接返回 *ReadOnlyRepositoryException，不会触发钩子与拦截器；
+ * 读取可以指向从库，并使用更长的语句超时与更大的默认分页大小：
+ *
+ *   reportRepo := repo.ReadOnly(db233.ReadOnlyRepositoryOptions{
+ *       Db:               router.ForRead(),
+ *       StatementTimeout: 5 * time.Minute,
+ *       FetchSize:        5000,
+ *   })
+ *   reportRepo.FindPage("", nil, 1, 0, &Order{}) // pageSize <= 0 时使用 FetchSize
+ *
+ * 副本保留原存储库的作用域、context、关联预加载与拦截器，原存储库不受影响
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type ReadOnlyRepositoryOptions struct {
+	// 执行读取的 Db（如从库或 ReadWriteRouter.ForRead()），nil 时沿用原存储库的 Db
+	Db *Db
+
+	// 读取的语句超时，<= 0 时沿用 Db 的默认超时
+	StatementTimeout time.Duration
+
+	// 默认分页大小：FindPage / FindPageAfter 的 pageSize <= 0 时使用
+	FetchSize int
+}
+
+/**
+ * 返回只读副本（原存储库不受影响）
+ */
+func (r *BaseCrudRepository) ReadOnly(opts ...ReadOnlyRepositoryOptions) *BaseCrudRepository {
+	clone := *r
+	clone.readOnly = true
+	if len(opts) == 0 {
+		return &clone
+	}
+
+	opt := opts[0]
+	if opt.Db != nil {
+		clone.db = opt.Db
+		if r.ctx != nil {
+			clone.db = opt.Db.WithContext(r.ctx)
+		}
+	}
+	if opt.StatementTimeout > 0 {
+		clone.db = clone.db.WithStatementTimeout(opt.StatementTimeout)
+	}
+	if opt.FetchSize > 0 {
+		clone.fetchSize = opt.FetchSize
+	}
+	return &clone
+}
+
+/**
+ * 是否为只读存储库
+ */
+func (r *BaseCrudRepository) IsReadOnly() bool {
+	return r.readOnly
+}
+
+/**
+ * 只读存储库拒绝写操作
+ */
+func (r *BaseCrudRepository) checkWritable(operation string, entity IDbEntity) error {
+	if !r.readOnly {
+		return nil
+	}
+	tableName := ""
+	if entity != nil {
+		tableName = logicalTableName(entity)
+	}
+	LogWarn("只读存储库拒绝写操作: %s, 表=%s", operation, tableName)
+	return NewReadOnlyRepositoryException(operation, tableName)
+}
+
+/**
+ * 分页大小：未指定（<= 0）时使用 FetchSize
+ */
+func (r *BaseCrudRepository) resolvePageSize(pageSize int) int {
+	if pageSize <= 0 && r.fetchSize > 0 {
+		return r.fetchSize
+	}
+	return pageSize
+}
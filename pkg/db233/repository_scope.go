@@ -0,0 +1,269 @@
+package db233
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+/**
+ * RepositoryScope - 存储库作用域
+ *
+ * 限定存储库可见的数据范围，用于多租户等场景：
+ * - TableNameMapper：改写表名（如加上 schema 前缀）
+ * - Predicates：附加到所有查询/更新/删除 WHERE 子句的谓词（AND 连接）
+ * - ForcedColumns：保存/更新时强制写入的列值（如 tenant_id）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type RepositoryScope struct {
+	TableNameMapper func(tableName string) string
+	Predicates      []ScopePredicate
+	ForcedColumns   map[string]interface{}
+}
+
+/**
+ * ScopePredicate - 作用域谓词
+ */
+type ScopePredicate struct {
+	// 条件 SQL，如 "tenant_id = ?"
+	Sql string
+
+	// 条件参数
+	Params []interface{}
+}
+
+/**
+ * 返回绑定了作用域的存储库副本（原存储库不受影响）
+ *
+ * @param scope 作用域，nil 表示取消限制
+ * @return *BaseCrudRepository 新的存储库
+ */
+func (r *BaseCrudRepository) WithScope(scope *RepositoryScope) *BaseCrudRepository {
+	clone := *r
+	clone.scope = scope
+	return &clone
+}
+
+/**
+ * 获取当前作用域
+ */
+func (r *BaseCrudRepository) GetScope() *RepositoryScope {
+	return r.scope
+}
+
+/**
+ * 把作用域谓词与行级安全策略谓词追加到 WHERE 条件
+ *
+ * @param entityType 实体类型（用于查找行级安全策略）
+ * @param where 原条件（可为空）
+ * @param params 原参数
+ * @return string 合并后的条件（可能为空）
+ * @return []interface{} 合并后的参数
+ * @return error 行级安全策略拒绝访问
+ */
+func (r *BaseCrudRepository) applyScope(entityType IDbEntity, where string, params []interface{}) (string, []interface{}, error) {
+	predicates, err := r.scopePredicates(entityType)
+	if err != nil {
+		return "", nil, err
+	}
+	where, params = mergeScopePredicates(where, params, predicates)
+	return where, params, nil
+}
+
+/**
+ * 收集作用域谓词与行级安全策略谓词
+ *
+ * @return error 行级安全策略拒绝访问
+ */
+func (r *BaseCrudRepository) scopePredicates(entityType IDbEntity) ([]ScopePredicate, error) {
+	return r.tableScopePredicates(logicalTableName(entityType))
+}
+
+/**
+ * 按逻辑表名收集作用域谓词与行级安全策略谓词（JoinQuery 按表名引用时使用）
+ */
+func (r *BaseCrudRepository) tableScopePredicates(tableName string) ([]ScopePredicate, error) {
+	predicates := make([]ScopePredicate, 0)
+	if r.scope != nil {
+		predicates = append(predicates, r.scope.Predicates...)
+	}
+	policyPredicate, err := GetSecurityPolicyRegistryInstance().predicateFor(r.GetContext(), tableName)
+	if err != nil {
+		return nil, err
+	}
+	if policyPredicate != nil {
+		predicates = append(predicates, *policyPredicate)
+	}
+	return predicates, nil
+}
+
+/**
+ * 把谓词以 AND 追加到 WHERE 条件，没有谓词时原样返回
+ */
+func mergeScopePredicates(where string, params []interface{}, predicates []ScopePredicate) (string, []interface{}) {
+	if len(predicates) == 0 {
+		return where, params
+	}
+
+	conditions := make([]string, 0, len(predicates)+1)
+	if where != "" {
+		conditions = append(conditions, where)
+	}
+	merged := make([]interface{}, 0, len(params))
+	merged = append(merged, params...)
+	for _, predicate := range predicates {
+		conditions = append(conditions, predicate.Sql)
+		merged = append(merged, predicate.Params...)
+	}
+	return strings.Join(conditions, " AND "), merged
+}
+
+/**
+ * 用作用域的强制列值覆盖实体字段
+ */
+func (r *BaseCrudRepository) applyForcedColumns(fields map[string]interface{}) {
+	if r.scope == nil {
+		return
+	}
+	for column, value := range r.scope.ForcedColumns {
+		fields[column] = value
+	}
+}
+
+/**
+ * 是否为作用域强制写入的列（UPSERT 冲突时不更新，避免把其他租户的行改到本租户）
+ */
+func (r *BaseCrudRepository) isForcedColumn(column string) bool {
+	if r.scope == nil {
+		return false
+	}
+	_, forced := r.scope.ForcedColumns[column]
+	return forced
+}
+
+/**
+ * 作用域内的 UPSERT
+ *
+ * 带作用域谓词或行级安全策略谓词时不能使用 INSERT ... ON DUPLICATE KEY UPDATE：冲突行可能属于其他租户
+ * 或不在策略允许的范围内，更新会越过作用域。改为在同一事务中（加入绑定的事务，没有时开启新事务）：
+ *   1. UPDATE ... SET 更新列 WHERE 冲突列 = ? AND 作用域谓词（命中的行被锁定到事务结束）
+ *   2. 未命中时按同样条件确认可见行是否存在（值未变化时 MySQL 的影响行数为 0）
+ *   3. 可见行不存在才执行普通 INSERT，冲突行在作用域外或被并发写入时由唯一约束报错，不会被覆盖
+ * 没有冲突列，或冲突列没有插入值（自增主键为零值）时直接执行普通 INSERT
+ *
+ * @param db 执行语句的 Db（事务内为绑定事务的视图）
+ * @param plan 插入计划（scopePredicates 为空时不处理）
+ * @param keyColumns 冲突列
+ * @param updateColumns 冲突时更新的列（已排除强制列）
+ * @return sql.Result 执行结果，命中已有行时 LastInsertId 为该行的自增主键
+ * @return string 最后执行的语句（用于错误日志）
+ * @return bool 是否按作用域处理，false 时调用方按原方式执行
+ */
+func (r *BaseCrudRepository) scopedUpsert(db *Db, plan *insertPlan, keyColumns []string, updateColumns []string) (sql.Result, string, bool, error) {
+	if len(plan.scopePredicates) == 0 {
+		return nil, "", false, nil
+	}
+
+	keyConditions := make([]string, 0, len(keyColumns))
+	keyValues := make([]interface{}, 0, len(keyColumns))
+	for _, column := range keyColumns {
+		index := indexOfColumn(plan.columns, column)
+		if index < 0 {
+			keyConditions = nil
+			break
+		}
+		keyConditions = append(keyConditions, column+" = ?")
+		keyValues = append(keyValues, plan.values[index])
+	}
+	if len(keyConditions) == 0 {
+		insertSql := plan.insertSql()
+		result, err := db.execInsert(insertSql, plan.values, plan.skippedAutoIncrementColumn)
+		return result, insertSql, true, err
+	}
+	where, whereParams := mergeScopePredicates(strings.Join(keyConditions, " AND "), keyValues, plan.scopePredicates)
+
+	var result sql.Result
+	executed := ""
+	err := WithTransaction(db, func(tm *TransactionManager) error {
+		var err error
+		result, err = scopedUpsertInTx(tm.GetDb(), plan, where, whereParams, updateColumns, &executed)
+		return err
+	})
+	return result, executed, true, err
+}
+
+/**
+ * 在事务中执行作用域 UPSERT 的三个步骤，executed 记录最后执行的语句
+ */
+func scopedUpsertInTx(db *Db, plan *insertPlan, where string, whereParams []interface{}, updateColumns []string, executed *string) (sql.Result, error) {
+	var affected int64
+	if len(updateColumns) > 0 {
+		setParts := make([]string, 0, len(updateColumns))
+		params := make([]interface{}, 0, len(updateColumns)+len(whereParams))
+		for _, column := range updateColumns {
+			setParts = append(setParts, column+" = ?")
+			params = append(params, plan.values[indexOfColumn(plan.columns, column)])
+		}
+		params = append(params, whereParams...)
+		*executed = "UPDATE " + plan.tableName + " SET " + strings.Join(setParts, ", ") + " WHERE " + where
+		result, err := db.execGenerated(*executed, params)
+		if err != nil {
+			return nil, err
+		}
+		affected, _ = result.RowsAffected()
+		if affected > 0 && plan.skippedAutoIncrementColumn == "" {
+			LogDebug("作用域 UPSERT 更新已有行: 表=%s, 影响行数=%d", plan.tableName, affected)
+			return result, nil
+		}
+	}
+
+	// 查询作用域内的已有行（有自增列时取其主键用于回填）
+	column := "1"
+	if plan.skippedAutoIncrementColumn != "" {
+		column = plan.skippedAutoIncrementColumn
+	}
+	*executed = db.GetSqlDialect().ApplyLimitOffset("SELECT "+column+" FROM "+plan.tableName+" WHERE "+where, 1, 0)
+	var id int64
+	err := db.executeScalarOnce(*executed, whereParams, db.statementTimeout(), &id)
+	if errors.Is(err, sql.ErrNoRows) && affected == 0 {
+		LogDebug("作用域 UPSERT 未找到可见行，执行 INSERT: 表=%s", plan.tableName)
+		*executed = plan.insertSql()
+		return db.execInsert(*executed, plan.values, plan.skippedAutoIncrementColumn)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		// 更新后的行已不满足作用域谓词
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if column == "1" {
+		id = 0
+	}
+	return generatedResult{lastInsertId: id, rowsAffected: affected}, nil
+}
+
+/**
+ * 库内部生成的执行结果（不经过驱动返回的 sql.Result）
+ */
+type generatedResult struct {
+	lastInsertId int64
+	rowsAffected int64
+}
+
+func (r generatedResult) LastInsertId() (int64, error) { return r.lastInsertId, nil }
+func (r generatedResult) RowsAffected() (int64, error) { return r.rowsAffected, nil }
+
+/**
+ * 列在列表中的位置，不存在时返回 -1
+ */
+func indexOfColumn(columns []string, column string) int {
+	for i, c := range columns {
+		if c == column {
+			return i
+		}
+	}
+	return -1
+}
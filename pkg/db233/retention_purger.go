@@ -0,0 +1,463 @@
+package db233
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+/**
+ * RetentionPurger - 按实体声明的数据保留策略（TTL）清理器
+ *
+ * 日志、遥测类表按时间列分批删除超过保留时长的数据，避免无限增长：
+ *
+ *   purger := db233.NewRetentionPurger(db)
+ *   purger.Register(&AccessLog{}, db233.NewRetentionPolicy("created_at", 30*24*time.Hour).
+ *       SetBatchSize(5000).
+ *       SetSchedule("0 3 * * *"))
+ *   purger.Start()
+ *
+ * 每批删除一次（MySQL DELETE ... LIMIT，PostgreSQL 按 ctid 子查询，SQL Server DELETE TOP），
+ * 单批影响行数不足批大小时结束，避免长事务与长时间锁表。
+ * 试运行模式（SetDryRun）只统计将被删除的行数，不执行删除。
+ * 每次运行的结果记录在策略上（LastResult），并通过 GetMetrics 导出（可注册到 MetricsCollector）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type RetentionPurger struct {
+	db       *Db
+	policies map[string]*RetentionPolicy
+	dryRun   bool
+
+	// 调度检查间隔
+	tickInterval time.Duration
+
+	running  bool
+	stopChan chan bool
+	mu       sync.RWMutex
+}
+
+/**
+ * RetentionPolicy - 数据保留策略
+ */
+type RetentionPolicy struct {
+	// 策略名，默认为表名
+	Name string
+
+	// 表名（Register 时按实体填充）
+	Table string
+
+	// 时间列，早于 当前时间 - MaxAge 的行被删除
+	Column string
+
+	// 最大保留时长
+	MaxAge time.Duration
+
+	// 每批删除的行数，默认 1000
+	BatchSize int
+
+	// 单次运行最多执行的批数，0 表示删完为止
+	MaxBatches int
+
+	// 调度表达式（见 CronSchedule），默认 @hourly
+	Schedule string
+
+	schedule *CronSchedule
+
+	NextRun      time.Time
+	LastRun      time.Time
+	LastResult   *RetentionRunResult
+	RunCount     int64
+	ErrorCount   int64
+	TotalDeleted int64
+}
+
+/**
+ * RetentionRunResult - 单次清理结果
+ */
+type RetentionRunResult struct {
+	Policy string
+	Table  string
+
+	// 删除早于该时间的行
+	Cutoff time.Time
+
+	// 删除的行数（试运行时为将被删除的行数）
+	Deleted int64
+
+	// 执行的批数（试运行时为预计批数）
+	Batches int
+
+	DryRun    bool
+	StartedAt time.Time
+	Duration  time.Duration
+	Error     error
+}
+
+/**
+ * 创建数据保留策略
+ *
+ * @param column 时间列
+ * @param maxAge 最大保留时长
+ */
+func NewRetentionPolicy(column string, maxAge time.Duration) *RetentionPolicy {
+	return &RetentionPolicy{Column: column, MaxAge: maxAge, BatchSize: 1000, Schedule: "@hourly"}
+}
+
+/**
+ * 设置策略名（同一张表有多条策略时使用）
+ */
+func (p *RetentionPolicy) SetName(name string) *RetentionPolicy {
+	p.Name = name
+	return p
+}
+
+/**
+ * 设置每批删除的行数
+ */
+func (p *RetentionPolicy) SetBatchSize(batchSize int) *RetentionPolicy {
+	p.BatchSize = batchSize
+	return p
+}
+
+/**
+ * 设置单次运行最多执行的批数
+ */
+func (p *RetentionPolicy) SetMaxBatches(maxBatches int) *RetentionPolicy {
+	p.MaxBatches = maxBatches
+	return p
+}
+
+/**
+ * 设置调度表达式
+ */
+func (p *RetentionPolicy) SetSchedule(expression string) *RetentionPolicy {
+	p.Schedule = expression
+	return p
+}
+
+/**
+ * 创建数据保留清理器
+ */
+func NewRetentionPurger(db *Db) *RetentionPurger {
+	return &RetentionPurger{
+		db:           db,
+		policies:     make(map[string]*RetentionPolicy),
+		tickInterval: time.Minute,
+	}
+}
+
+/**
+ * 设置试运行模式（只统计行数，不删除）
+ */
+func (rp *RetentionPurger) SetDryRun(dryRun bool) *RetentionPurger {
+	rp.mu.Lock()
+	rp.dryRun = dryRun
+	rp.mu.Unlock()
+	return rp
+}
+
+/**
+ * 设置调度检查间隔（默认 1 分钟）
+ */
+func (rp *RetentionPurger) SetTickInterval(interval time.Duration) *RetentionPurger {
+	if interval > 0 {
+		rp.mu.Lock()
+		rp.tickInterval = interval
+		rp.mu.Unlock()
+	}
+	return rp
+}
+
+/**
+ * 注册数据保留策略（同名策略被替换）
+ *
+ * @param entity 实体（实例或指针）或表名
+ * @param policy 保留策略
+ */
+func (rp *RetentionPurger) Register(entity interface{}, policy *RetentionPolicy) error {
+	if policy == nil {
+		return NewValidationException("保留策略不能为空")
+	}
+	if policy.MaxAge <= 0 {
+		return NewValidationException("最大保留时长必须大于 0")
+	}
+	if policy.BatchSize <= 0 || policy.MaxBatches < 0 {
+		return NewValidationException("批大小必须大于 0，最大批数不能为负")
+	}
+
+	registered := *policy
+	if table, ok := entity.(string); ok {
+		registered.Table = table
+	} else {
+		metadata, err := GetEntityMetadataCacheInstance().GetOrBuild(entity)
+		if err != nil {
+			return err
+		}
+		if !containsColumn(metadata.AllColumns, policy.Column) {
+			return NewValidationException(fmt.Sprintf("时间列不存在: %s.%s", metadata.TableName, policy.Column))
+		}
+		registered.Table = metadata.TableName
+	}
+	for _, identifier := range []string{registered.Table, registered.Column} {
+		if !isJoinIdentifier(identifier) {
+			return NewValidationException(fmt.Sprintf("标识符无效: %s", identifier))
+		}
+	}
+	if registered.Name == "" {
+		registered.Name = registered.Table
+	}
+
+	schedule, err := ParseCronExpression(registered.Schedule)
+	if err != nil {
+		return err
+	}
+	registered.schedule = schedule
+	registered.NextRun = schedule.Next(time.Now())
+	registered.LastResult = nil
+	registered.RunCount, registered.ErrorCount, registered.TotalDeleted = 0, 0, 0
+
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	rp.policies[registered.Name] = &registered
+	LogInfo("数据保留策略已注册: %s, 表=%s, 保留=%v, 下次运行=%s",
+		registered.Name, registered.Table, registered.MaxAge, registered.NextRun.Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+/**
+ * 移除数据保留策略
+ */
+func (rp *RetentionPurger) RemovePolicy(name string) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	delete(rp.policies, name)
+}
+
+/**
+ * 获取全部策略及运行状态（按名称排序的副本）
+ */
+func (rp *RetentionPurger) GetPolicies() []RetentionPolicy {
+	rp.mu.RLock()
+	defer rp.mu.RUnlock()
+
+	result := make([]RetentionPolicy, 0, len(rp.policies))
+	for _, policy := range rp.policies {
+		result = append(result, *policy)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+/**
+ * 启动调度
+ */
+func (rp *RetentionPurger) Start() {
+	rp.mu.Lock()
+	if rp.running {
+		rp.mu.Unlock()
+		return
+	}
+	rp.running = true
+	rp.stopChan = make(chan bool)
+	tickInterval := rp.tickInterval
+	rp.mu.Unlock()
+
+	LogInfo("数据保留清理器启动")
+
+	goSupervised("retention_purger", rp.stopChan, func() {
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				rp.runDue(now)
+			case <-rp.stopChan:
+				LogInfo("数据保留清理器停止")
+				return
+			}
+		}
+	})
+}
+
+/**
+ * 停止调度
+ */
+func (rp *RetentionPurger) Stop() {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	if !rp.running {
+		return
+	}
+	rp.running = false
+	close(rp.stopChan)
+}
+
+/**
+ * 执行到期的策略
+ */
+func (rp *RetentionPurger) runDue(now time.Time) {
+	rp.mu.Lock()
+	due := make([]*RetentionPolicy, 0)
+	for _, policy := range rp.policies {
+		if !policy.NextRun.IsZero() && !now.Before(policy.NextRun) {
+			policy.NextRun = policy.schedule.Next(now)
+			due = append(due, policy)
+		}
+	}
+	rp.mu.Unlock()
+
+	for _, policy := range due {
+		rp.execute(policy, now)
+	}
+}
+
+/**
+ * 立即执行指定策略
+ *
+ * @return *RetentionRunResult 运行结果（出错时包含出错前已删除的行数）
+ */
+func (rp *RetentionPurger) RunNow(name string) (*RetentionRunResult, error) {
+	rp.mu.RLock()
+	policy, exists := rp.policies[name]
+	rp.mu.RUnlock()
+	if !exists {
+		return nil, NewValidationException(fmt.Sprintf("保留策略不存在: %s", name))
+	}
+	result := rp.execute(policy, time.Now())
+	return result, result.Error
+}
+
+/**
+ * 执行策略并记录结果
+ */
+func (rp *RetentionPurger) execute(policy *RetentionPolicy, now time.Time) *RetentionRunResult {
+	rp.mu.RLock()
+	dryRun := rp.dryRun
+	rp.mu.RUnlock()
+
+	result := &RetentionRunResult{
+		Policy:    policy.Name,
+		Table:     policy.Table,
+		Cutoff:    now.Add(-policy.MaxAge),
+		DryRun:    dryRun,
+		StartedAt: time.Now(),
+	}
+	if dryRun {
+		result.Error = rp.countExpired(policy, result)
+	} else {
+		result.Error = rp.purge(policy, result)
+	}
+	result.Duration = time.Since(result.StartedAt)
+
+	rp.mu.Lock()
+	policy.LastRun = now
+	policy.LastResult = result
+	policy.RunCount++
+	if result.Error != nil {
+		policy.ErrorCount++
+	}
+	if !dryRun {
+		policy.TotalDeleted += result.Deleted
+	}
+	rp.mu.Unlock()
+
+	if result.Error != nil {
+		LogError("数据保留清理失败: 策略=%s, 已删除=%d, 错误=%v", policy.Name, result.Deleted, result.Error)
+	} else if dryRun {
+		LogInfo("数据保留试运行: 策略=%s, 将删除=%d, 截止=%s", policy.Name, result.Deleted, result.Cutoff.Format("2006-01-02 15:04:05"))
+	} else if result.Deleted > 0 {
+		LogInfo("数据保留清理完成: 策略=%s, 删除=%d, 批数=%d, 耗时=%v", policy.Name, result.Deleted, result.Batches, result.Duration)
+	}
+	return result
+}
+
+/**
+ * 分批删除过期数据，单批不足批大小或达到最大批数时结束
+ */
+func (rp *RetentionPurger) purge(policy *RetentionPolicy, result *RetentionRunResult) error {
+	query := rp.batchDeleteSql(policy)
+	for policy.MaxBatches == 0 || result.Batches < policy.MaxBatches {
+		res, err := rp.db.DataSource.Exec(query, result.Cutoff)
+		if err != nil {
+			return NewQueryExceptionWithCause(err, "删除过期数据失败: "+policy.Table)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return NewQueryExceptionWithCause(err, "获取删除行数失败: "+policy.Table)
+		}
+		result.Deleted += affected
+		result.Batches++
+		if affected < int64(policy.BatchSize) {
+			break
+		}
+	}
+	return nil
+}
+
+/**
+ * 试运行：统计将被删除的行数与预计批数
+ */
+func (rp *RetentionPurger) countExpired(policy *RetentionPolicy, result *RetentionRunResult) error {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s < %s", policy.Table, policy.Column, rp.placeholder())
+	var count int64
+	if err := rp.db.DataSource.QueryRow(query, result.Cutoff).Scan(&count); err != nil {
+		return NewQueryExceptionWithCause(err, "统计过期数据失败: "+policy.Table)
+	}
+	batches := int((count + int64(policy.BatchSize) - 1) / int64(policy.BatchSize))
+	if policy.MaxBatches > 0 && batches > policy.MaxBatches {
+		batches = policy.MaxBatches
+		count = int64(policy.MaxBatches * policy.BatchSize)
+	}
+	result.Deleted, result.Batches = count, batches
+	return nil
+}
+
+func (rp *RetentionPurger) batchDeleteSql(policy *RetentionPolicy) string {
+	switch rp.db.DatabaseType {
+	case EnumDatabaseTypePostgreSQL:
+		return fmt.Sprintf("DELETE FROM %s WHERE ctid IN (SELECT ctid FROM %s WHERE %s < $1 LIMIT %d)",
+			policy.Table, policy.Table, policy.Column, policy.BatchSize)
+	case EnumDatabaseTypeSQLServer:
+		return fmt.Sprintf("DELETE TOP (%d) FROM %s WHERE %s < ?", policy.BatchSize, policy.Table, policy.Column)
+	default:
+		return fmt.Sprintf("DELETE FROM %s WHERE %s < ? LIMIT %d", policy.Table, policy.Column, policy.BatchSize)
+	}
+}
+
+func (rp *RetentionPurger) placeholder() string {
+	if rp.db.DatabaseType == EnumDatabaseTypePostgreSQL {
+		return "$1"
+	}
+	return "?"
+}
+
+/**
+ * 数据源名称
+ */
+func (rp *RetentionPurger) GetName() string {
+	return "retention_purger"
+}
+
+/**
+ * 按策略导出累计删除行数、运行 / 失败次数及最近一次运行的删除行数与耗时
+ */
+func (rp *RetentionPurger) GetMetrics() map[string]interface{} {
+	metrics := make(map[string]interface{})
+	var totalDeleted int64
+	for _, policy := range rp.GetPolicies() {
+		metrics[policy.Name+".deleted_total"] = policy.TotalDeleted
+		metrics[policy.Name+".runs"] = policy.RunCount
+		metrics[policy.Name+".errors"] = policy.ErrorCount
+		if policy.LastResult != nil {
+			metrics[policy.Name+".last_deleted"] = policy.LastResult.Deleted
+			metrics[policy.Name+".last_duration_ms"] = policy.LastResult.Duration.Milliseconds()
+		}
+		totalDeleted += policy.TotalDeleted
+	}
+	metrics["total_deleted"] = totalDeleted
+	return metrics
+}
@@ -0,0 +1,293 @@
+package db233
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+/**
+ * RowMapper - 自定义行映射
+ *
+ * 反射 ORM 按列名逐行查找字段，热点查询或不规则的结果形状（聚合、跨实体的 JOIN）可以绕过它，
+ * 通过 Db.QueryWithMapper 自行读取每一行：
+ *
+ *   rows, err := db.QueryWithMapper("SELECT o.id, u.name, SUM(o.amount) FROM ...", nil,
+ *       db233.RowMapperFunc(func(columns []string, rows *sql.Rows, rowNum int) (interface{}, error) {
+ *           var summary OrderSummary
+ *           err := rows.Scan(&summary.OrderId, &summary.UserName, &summary.Total)
+ *           return &summary, err
+ *       }))
+ *
+ * 内置映射器：
+ *   - StructMapper：按列名映射到结构体指针，列到字段的对应关系只在列集合变化时计算一次
+ *   - MapMapper：每行映射为 map[string]interface{}（[]byte 转为 string）
+ *   - SliceMapper：每行映射为按列顺序排列的 []interface{}
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type RowMapper interface {
+	/**
+	 * 映射当前行
+	 *
+	 * @param columns 结果集的列名
+	 * @param rows 已定位到当前行的结果集，只应调用 Scan
+	 * @param rowNum 行号（从 0 开始）
+	 * @return interface{} 映射结果
+	 * @return error 映射失败时终止查询
+	 */
+	MapRow(columns []string, rows *sql.Rows, rowNum int) (interface{}, error)
+}
+
+/**
+ * 函数形式的行映射器
+ */
+type RowMapperFunc func(columns []string, rows *sql.Rows, rowNum int) (interface{}, error)
+
+func (f RowMapperFunc) MapRow(columns []string, rows *sql.Rows, rowNum int) (interface{}, error) {
+	return f(columns, rows, rowNum)
+}
+
+/**
+ * 使用自定义行映射器执行查询（经过 SQL 白名单、插件与语句超时，不支持插件短路）
+ *
+ * @param query SQL 语句
+ * @param params 参数
+ * @param mapper 行映射器
+ * @return []interface{} 每行的映射结果
+ * @return error 执行或映射错误
+ */
+func (db *Db) QueryWithMapper(query string, params []interface{}, mapper RowMapper) ([]interface{}, error) {
+	if mapper == nil {
+		return nil, NewValidationException("行映射器不能为空")
+	}
+	if err := db.checkQueryAllowed(query); err != nil {
+		return nil, err
+	}
+	timeout := db.statementTimeout()
+	result, err := db.executeWithPlugins(query, params, nil, func(query string, params []interface{}) (interface{}, int, error) {
+		var results []interface{}
+		err := db.queryRows(query, params, timeout, func(rows *sql.Rows) error {
+			var err error
+			results, err = mapRows(rows, mapper)
+			return err
+		})
+		return results, len(results), err
+	})
+	results, _ := result.([]interface{})
+	if err != nil {
+		if _, ok := err.(*StatementTimeoutException); ok {
+			return nil, err
+		}
+		return nil, NewQueryExceptionWithCause(err, "查询执行失败: "+query)
+	}
+	return results, nil
+}
+
+/**
+ * 逐行调用映射器并关闭 rows
+ */
+func mapRows(rows *sql.Rows, mapper RowMapper) ([]interface{}, error) {
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]interface{}, 0)
+	for rowNum := 0; rows.Next(); rowNum++ {
+		row, err := mapper.MapRow(columns, rows, rowNum)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行映射失败: %w", rowNum, err)
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+/**
+ * 扫描当前行的全部列
+ *
+ * @param bytesToString 是否把 []byte 转为 string（结构体映射保留原始值，交给类型转换处理）
+ */
+func scanRowValues(columns []string, rows *sql.Rows, bytesToString bool) ([]interface{}, error) {
+	values := make([]interface{}, len(columns))
+	targets := make([]interface{}, len(columns))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	if err := rows.Scan(targets...); err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		if bytes, ok := value.([]byte); ok && bytesToString {
+			values[i] = string(bytes)
+		}
+	}
+	return values, nil
+}
+
+/**
+ * MapMapper - 每行映射为 map[string]interface{}
+ */
+type MapMapper struct{}
+
+/**
+ * 创建 Map 行映射器
+ */
+func NewMapMapper() *MapMapper {
+	return &MapMapper{}
+}
+
+func (m *MapMapper) MapRow(columns []string, rows *sql.Rows, rowNum int) (interface{}, error) {
+	values, err := scanRowValues(columns, rows, true)
+	if err != nil {
+		return nil, err
+	}
+	row := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		row[column] = values[i]
+	}
+	return row, nil
+}
+
+/**
+ * SliceMapper - 每行映射为按列顺序排列的 []interface{}
+ */
+type SliceMapper struct{}
+
+/**
+ * 创建切片行映射器
+ */
+func NewSliceMapper() *SliceMapper {
+	return &SliceMapper{}
+}
+
+func (m *SliceMapper) MapRow(columns []string, rows *sql.Rows, rowNum int) (interface{}, error) {
+	return scanRowValues(columns, rows, true)
+}
+
+/**
+ * StructMapper - 按列名映射到结构体指针
+ *
+ * 列名匹配规则与反射 ORM 一致（字段名、db 标签、命名策略，支持嵌入结构体），
+ * 没有对应字段的列被忽略。时间字段按 TimePolicy 转换，默认使用全局时间策略
+ */
+type StructMapper struct {
+	structType reflect.Type
+	policy     *TimePolicy
+
+	// 最近一次的列集合与字段路径（nil 表示该列没有对应字段）
+	columnKey    string
+	fieldIndexes [][]int
+	mu           sync.Mutex
+}
+
+/**
+ * 创建结构体行映射器
+ *
+ * @param entity 结构体实例或指针，仅用于获取类型
+ */
+func NewStructMapper(entity interface{}) *StructMapper {
+	structType := reflect.TypeOf(entity)
+	for structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	return &StructMapper{structType: structType}
+}
+
+/**
+ * 设置时间策略（通常传入 db.GetTimePolicy()）
+ */
+func (m *StructMapper) SetTimePolicy(policy *TimePolicy) *StructMapper {
+	m.policy = policy
+	return m
+}
+
+func (m *StructMapper) MapRow(columns []string, rows *sql.Rows, rowNum int) (interface{}, error) {
+	fieldIndexes := m.resolveFields(columns)
+	values, err := scanRowValues(columns, rows, false)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := m.policy
+	if policy == nil {
+		policy = GetDefaultTimePolicy()
+	}
+	instance := reflect.New(m.structType)
+	for i, index := range fieldIndexes {
+		if index == nil || values[i] == nil {
+			continue
+		}
+		field := fieldByIndexAlloc(instance.Elem(), index)
+		source := reflect.ValueOf(&values[i]).Elem()
+		var converted reflect.Value
+		if isTimeFieldType(field.Type()) {
+			converted, err = policy.fromStorage(source, field.Type())
+		} else {
+			converted, err = OrmHandlerInstance.convertValue(source, field.Type())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("列 %s 转换为 %s 失败: %w", columns[i], field.Type(), err)
+		}
+		field.Set(converted)
+	}
+	return instance.Interface(), nil
+}
+
+/**
+ * 计算列到字段的路径，列集合不变时复用
+ */
+func (m *StructMapper) resolveFields(columns []string) [][]int {
+	key := strings.Join(columns, "\x00")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fieldIndexes != nil && m.columnKey == key {
+		return m.fieldIndexes
+	}
+	fieldIndexes := make([][]int, len(columns))
+	for i, column := range columns {
+		fieldIndexes[i] = structFieldIndexByColumn(m.structType, column)
+	}
+	m.columnKey, m.fieldIndexes = key, fieldIndexes
+	return fieldIndexes
+}
+
+/**
+ * 按列名查找字段路径（与 OrmHandler.findFieldByColumnName 的匹配顺序一致）
+ */
+func structFieldIndexByColumn(structType reflect.Type, column string) []int {
+	if field, found := structType.FieldByName(column); found && field.PkgPath == "" {
+		return field.Index
+	}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.Anonymous {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				if index := structFieldIndexByColumn(embeddedType, column); index != nil {
+					return append([]int{i}, index...)
+				}
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if tag := field.Tag.Get("db"); tag != "" {
+			if name := strings.TrimSpace(strings.Split(tag, ",")[0]); name != "-" && name == column {
+				return field.Index
+			}
+		} else if GetCrudManagerInstance().strategyColumnName(field) == column {
+			return field.Index
+		}
+	}
+	return nil
+}
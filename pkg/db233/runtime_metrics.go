@@ -0,0 +1,102 @@
+package db233
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+/**
+ * RuntimeMetricsDataSource - Go 运行时指标数据源
+ *
+ * 采集 goroutine 数、堆内存与 GC 停顿等运行时指标，与数据库指标一同
+ * 写入 MetricsCollector，便于把数据库延迟与应用压力放在同一时间轴上对照
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type RuntimeMetricsDataSource struct {
+	lastNumGC uint32
+	mu        sync.Mutex
+}
+
+/**
+ * 创建运行时指标数据源
+ */
+func NewRuntimeMetricsDataSource() *RuntimeMetricsDataSource {
+	return &RuntimeMetricsDataSource{}
+}
+
+/**
+ * 数据源名称
+ */
+func (s *RuntimeMetricsDataSource) GetName() string {
+	return "runtime"
+}
+
+/**
+ * 采集运行时指标
+ */
+func (s *RuntimeMetricsDataSource) GetMetrics() map[string]interface{} {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	s.mu.Lock()
+	recentMaxPause := s.recentMaxPause(&stats)
+	s.lastNumGC = stats.NumGC
+	s.mu.Unlock()
+
+	var lastPause time.Duration
+	if stats.NumGC > 0 {
+		lastPause = time.Duration(stats.PauseNs[(stats.NumGC+255)%256])
+	}
+
+	return map[string]interface{}{
+		"goroutines":             runtime.NumGoroutine(),
+		"cpu_count":              runtime.NumCPU(),
+		"heap_alloc_bytes":       stats.HeapAlloc,
+		"heap_inuse_bytes":       stats.HeapInuse,
+		"heap_objects":           stats.HeapObjects,
+		"sys_bytes":              stats.Sys,
+		"gc_count":               stats.NumGC,
+		"gc_cpu_fraction":        stats.GCCPUFraction,
+		"gc_pause_last_ms":       float64(lastPause) / float64(time.Millisecond),
+		"gc_pause_total_ms":      float64(stats.PauseTotalNs) / float64(time.Millisecond),
+		"gc_pause_recent_max_ms": float64(recentMaxPause) / float64(time.Millisecond),
+	}
+}
+
+/**
+ * 上次采集以来的最大 GC 停顿（GC 环形缓冲最多保留 256 次）
+ */
+func (s *RuntimeMetricsDataSource) recentMaxPause(stats *runtime.MemStats) time.Duration {
+	count := stats.NumGC - s.lastNumGC
+	if count > 256 {
+		count = 256
+	}
+
+	var maxPause uint64
+	for i := uint32(0); i < count; i++ {
+		pause := stats.PauseNs[(stats.NumGC-i+255)%256]
+		if pause > maxPause {
+			maxPause = pause
+		}
+	}
+	return time.Duration(maxPause)
+}
+
+/**
+ * 启用运行时指标采集（重复调用只注册一次）
+ */
+func (mc *MetricsCollector) EnableRuntimeMetrics() {
+	mc.mu.RLock()
+	for _, source := range mc.dataSources {
+		if _, ok := source.(*RuntimeMetricsDataSource); ok {
+			mc.mu.RUnlock()
+			return
+		}
+	}
+	mc.mu.RUnlock()
+
+	mc.AddDataSource(NewRuntimeMetricsDataSource())
+}
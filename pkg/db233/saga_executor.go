@@ -0,0 +1,488 @@
+package db233
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * Saga 编排与补偿
+ *
+ * 跨库业务流程（如扣库存 → 扣余额 → 发道具，分属不同的 Db）不适合两阶段提交时，
+ * 把流程拆成有序步骤，每步在各自 Db 上的本地事务中执行并提供补偿函数；
+ * 某一步失败时按逆序补偿已完成的步骤。Saga 状态持久化到状态库，进程崩溃后由 Recover 继续补偿
+ *
+ *   saga := db233.NewSagaDefinition("purchase").
+ *       AddStep("reserve_stock", shopDb, reserveStock, releaseStock).
+ *       AddStep("charge_gold", playerDb, chargeGold, refundGold).
+ *       AddStep("grant_item", itemDb, grantItem, nil)
+ *   executor := db233.NewSagaExecutor(stateDb)
+ *   executor.Init()
+ *   executor.Register(saga)
+ *   executor.Recover()                                            // 启动时补偿上次中断的 Saga
+ *   ctx, err := executor.Execute("purchase", map[string]string{"orderId": "1001"})
+ *
+ * 注意：崩溃可能发生在步骤提交之后、状态落盘之前，恢复时会补偿这个“执行中”的步骤，
+ * 因此补偿函数必须幂等，且能处理对应操作并未发生的情况。
+ * Recover 会补偿状态表中所有未结束的 Saga，应在开始 Execute 之前调用；多实例共用状态表时只由一个实例恢复
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+
+/**
+ * SagaStepFunc - Saga 步骤 / 补偿函数（在步骤 Db 的事务中执行，返回错误时回滚）
+ */
+type SagaStepFunc func(tm *TransactionManager, saga *SagaContext) error
+
+/**
+ * SagaContext - Saga 执行上下文
+ *
+ * Data 随状态持久化，步骤可写入后续步骤或补偿需要的值（如生成的订单号）
+ */
+type SagaContext struct {
+	SagaId   string
+	SagaName string
+	Data     map[string]string
+}
+
+/**
+ * 读取上下文数据
+ */
+func (c *SagaContext) Get(key string) string {
+	return c.Data[key]
+}
+
+/**
+ * 写入上下文数据
+ */
+func (c *SagaContext) Set(key string, value string) {
+	if c.Data == nil {
+		c.Data = make(map[string]string)
+	}
+	c.Data[key] = value
+}
+
+/**
+ * SagaStep - Saga 步骤
+ */
+type SagaStep struct {
+	Name string
+	Db   *Db
+
+	// 正向操作
+	Action SagaStepFunc
+
+	// 补偿操作（可为 nil，表示无需补偿）
+	Compensate SagaStepFunc
+}
+
+/**
+ * SagaDefinition - Saga 定义（有序步骤）
+ */
+type SagaDefinition struct {
+	name  string
+	steps []SagaStep
+}
+
+/**
+ * 创建 Saga 定义
+ */
+func NewSagaDefinition(name string) *SagaDefinition {
+	return &SagaDefinition{name: name}
+}
+
+/**
+ * 追加步骤
+ */
+func (d *SagaDefinition) AddStep(name string, db *Db, action SagaStepFunc, compensate SagaStepFunc) *SagaDefinition {
+	d.steps = append(d.steps, SagaStep{Name: name, Db: db, Action: action, Compensate: compensate})
+	return d
+}
+
+/**
+ * 获取名称
+ */
+func (d *SagaDefinition) GetName() string {
+	return d.name
+}
+
+/**
+ * 获取步骤
+ */
+func (d *SagaDefinition) GetSteps() []SagaStep {
+	return d.steps
+}
+
+/**
+ * EnumSagaStatus - Saga 状态
+ */
+type EnumSagaStatus string
+
+const (
+	// 正向执行中（current_step 为正在执行的步骤）
+	EnumSagaStatusRunning EnumSagaStatus = "RUNNING"
+
+	// 补偿中（current_step 为下一个待补偿的步骤）
+	EnumSagaStatusCompensating EnumSagaStatus = "COMPENSATING"
+
+	// 全部步骤执行成功
+	EnumSagaStatusCompleted EnumSagaStatus = "COMPLETED"
+
+	// 已补偿完成
+	EnumSagaStatusCompensated EnumSagaStatus = "COMPENSATED"
+
+	// 补偿失败，等待 Recover 重试或人工处理
+	EnumSagaStatusFailed EnumSagaStatus = "FAILED"
+)
+
+/**
+ * SagaState - 持久化的 Saga 状态
+ */
+type SagaState struct {
+	SagaId       string
+	SagaName     string
+	Status       EnumSagaStatus
+	CurrentStep  int
+	Data         map[string]string
+	ErrorMessage string
+}
+
+/**
+ * SagaExecutor - Saga 执行器
+ */
+type SagaExecutor struct {
+	db        *Db
+	tableName string
+
+	compensationRetries  int
+	compensationInterval time.Duration
+
+	mu          sync.RWMutex
+	definitions map[string]*SagaDefinition
+}
+
+/**
+ * 创建 Saga 执行器
+ *
+ * @param stateDb 保存 Saga 状态的数据库
+ */
+func NewSagaExecutor(stateDb *Db) *SagaExecutor {
+	return &SagaExecutor{
+		db:                   stateDb,
+		tableName:            "db233_saga_state",
+		compensationRetries:  3,
+		compensationInterval: 100 * time.Millisecond,
+		definitions:          make(map[string]*SagaDefinition),
+	}
+}
+
+/**
+ * 设置状态表名（默认 db233_saga_state）
+ */
+func (se *SagaExecutor) SetTableName(tableName string) *SagaExecutor {
+	se.tableName = tableName
+	return se
+}
+
+/**
+ * 设置补偿失败时的重试次数与间隔（默认 3 次、100ms）
+ */
+func (se *SagaExecutor) SetCompensationRetry(retries int, interval time.Duration) *SagaExecutor {
+	if retries >= 0 {
+		se.compensationRetries = retries
+	}
+	se.compensationInterval = interval
+	return se
+}
+
+/**
+ * 初始化状态表
+ */
+func (se *SagaExecutor) Init() error {
+	createTableSQL := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			saga_id VARCHAR(64) PRIMARY KEY,
+			saga_name VARCHAR(255) NOT NULL,
+			status VARCHAR(32) NOT NULL,
+			current_step INT NOT NULL,
+			data TEXT,
+			error_message TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NULL
+		)
+	`, se.tableName)
+
+	if _, err := se.db.DataSource.Exec(createTableSQL); err != nil {
+		return NewQueryExceptionWithCause(err, "创建 Saga 状态表失败")
+	}
+	LogInfo("Saga 状态表已初始化: %s", se.tableName)
+	return nil
+}
+
+/**
+ * 注册 Saga 定义
+ */
+func (se *SagaExecutor) Register(definition *SagaDefinition) error {
+	if definition == nil || definition.name == "" {
+		return NewValidationException("Saga 定义及其名称不能为空")
+	}
+	if len(definition.steps) == 0 {
+		return NewValidationException(fmt.Sprintf("Saga %s 没有步骤", definition.name))
+	}
+	for _, step := range definition.steps {
+		if step.Db == nil || step.Action == nil {
+			return NewValidationException(fmt.Sprintf("Saga %s 的步骤 %s 缺少 Db 或正向操作", definition.name, step.Name))
+		}
+	}
+
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	if _, exists := se.definitions[definition.name]; exists {
+		return NewValidationException(fmt.Sprintf("Saga 重复注册: %s", definition.name))
+	}
+	se.definitions[definition.name] = definition
+	return nil
+}
+
+/**
+ * 执行 Saga
+ *
+ * 某一步失败时逆序补偿已完成的步骤，返回 SagaAbortedException
+ *
+ * @param data 初始上下文数据（可为 nil）
+ * @return *SagaContext 执行后的上下文（包含 SagaId 与步骤写入的数据）
+ */
+func (se *SagaExecutor) Execute(sagaName string, data map[string]string) (*SagaContext, error) {
+	definition, err := se.getDefinition(sagaName)
+	if err != nil {
+		return nil, err
+	}
+	sagaId, err := NewUUIDv7()
+	if err != nil {
+		return nil, NewDb233ExceptionWithCause(err, "生成 SagaId 失败")
+	}
+
+	saga := &SagaContext{SagaId: sagaId, SagaName: sagaName, Data: make(map[string]string, len(data))}
+	for key, value := range data {
+		saga.Data[key] = value
+	}
+	if err := se.insertState(saga); err != nil {
+		return nil, err
+	}
+	LogInfo("Saga 开始: %s(%s)", sagaName, sagaId)
+
+	for i, step := range definition.steps {
+		if i > 0 {
+			if err := se.saveState(saga, EnumSagaStatusRunning, i, ""); err != nil {
+				return saga, se.abort(definition, saga, i-1, step.Name, err)
+			}
+		}
+		err := WithTransaction(step.Db, func(tm *TransactionManager) error {
+			return step.Action(tm, saga)
+		})
+		if err != nil {
+			LogError("Saga 步骤失败: %s(%s), 步骤=%s, 错误=%v", sagaName, sagaId, step.Name, err)
+			return saga, se.abort(definition, saga, i-1, step.Name, err)
+		}
+	}
+
+	if err := se.saveState(saga, EnumSagaStatusCompleted, len(definition.steps), ""); err != nil {
+		// 状态仍为 RUNNING，Recover 会补偿整个 Saga，因此按失败返回
+		LogError("Saga 已完成但状态写入失败: %s(%s), 错误=%v", sagaName, sagaId, err)
+		return saga, err
+	}
+	LogInfo("Saga 完成: %s(%s)", sagaName, sagaId)
+	return saga, nil
+}
+
+/**
+ * 补偿上次进程中断或补偿失败的 Saga（通常在启动时、注册完定义后调用）
+ *
+ * @return int 本次补偿完成的数量
+ */
+func (se *SagaExecutor) Recover() (int, error) {
+	states, err := se.loadStates(EnumSagaStatusRunning, EnumSagaStatusCompensating, EnumSagaStatusFailed)
+	if err != nil {
+		return 0, err
+	}
+
+	recovered := 0
+	var firstErr error
+	for _, state := range states {
+		definition, err := se.getDefinition(state.SagaName)
+		if err != nil {
+			LogWarn("Saga 定义未注册，跳过恢复: %s(%s)", state.SagaName, state.SagaId)
+			continue
+		}
+
+		// RUNNING 时 current_step 可能已提交但未记录，一并补偿
+		fromStep := state.CurrentStep
+		if fromStep >= len(definition.steps) {
+			fromStep = len(definition.steps) - 1
+		}
+		saga := &SagaContext{SagaId: state.SagaId, SagaName: state.SagaName, Data: state.Data}
+		if saga.Data == nil {
+			saga.Data = make(map[string]string)
+		}
+		LogInfo("恢复 Saga: %s(%s), 状态=%s, 从步骤 %d 开始补偿", state.SagaName, state.SagaId, state.Status, fromStep)
+		if err := se.compensate(definition, saga, fromStep, state.ErrorMessage); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		recovered++
+	}
+	return recovered, firstErr
+}
+
+/**
+ * 查询 Saga 状态，不存在时返回 nil
+ */
+func (se *SagaExecutor) GetState(sagaId string) (*SagaState, error) {
+	rows, err := se.db.DataSource.Query(fmt.Sprintf(
+		"SELECT saga_id, saga_name, status, current_step, data, error_message FROM %s WHERE saga_id = ?", se.tableName), sagaId)
+	if err != nil {
+		return nil, NewQueryExceptionWithCause(err, "查询 Saga 状态失败")
+	}
+	states, err := scanSagaStates(rows)
+	if err != nil || len(states) == 0 {
+		return nil, err
+	}
+	return &states[0], nil
+}
+
+func (se *SagaExecutor) getDefinition(sagaName string) (*SagaDefinition, error) {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+	definition, exists := se.definitions[sagaName]
+	if !exists {
+		return nil, NewConfigurationException(fmt.Sprintf("Saga 未注册: %s", sagaName))
+	}
+	return definition, nil
+}
+
+/**
+ * 步骤失败后中止：从 fromStep 开始逆序补偿
+ */
+func (se *SagaExecutor) abort(definition *SagaDefinition, saga *SagaContext, fromStep int, failedStep string, cause error) error {
+	if err := se.compensate(definition, saga, fromStep, cause.Error()); err != nil {
+		return NewSagaAbortedException(saga.SagaId, saga.SagaName, failedStep, false, cause)
+	}
+	return NewSagaAbortedException(saga.SagaId, saga.SagaName, failedStep, true, cause)
+}
+
+/**
+ * 从 fromStep 逆序补偿到第一步，每补偿一步记录进度；补偿失败时记为 FAILED
+ */
+func (se *SagaExecutor) compensate(definition *SagaDefinition, saga *SagaContext, fromStep int, reason string) error {
+	for i := fromStep; i >= 0; i-- {
+		step := definition.steps[i]
+		se.saveStateQuietly(saga, EnumSagaStatusCompensating, i, reason)
+		if step.Compensate == nil {
+			continue
+		}
+
+		var err error
+		for attempt := 0; attempt <= se.compensationRetries; attempt++ {
+			if attempt > 0 && se.compensationInterval > 0 {
+				time.Sleep(se.compensationInterval)
+			}
+			err = WithTransaction(step.Db, func(tm *TransactionManager) error {
+				return step.Compensate(tm, saga)
+			})
+			if err == nil {
+				break
+			}
+			LogWarn("Saga 补偿失败: %s(%s), 步骤=%s, 第 %d 次, 错误=%v", saga.SagaName, saga.SagaId, step.Name, attempt+1, err)
+		}
+		if err != nil {
+			se.saveStateQuietly(saga, EnumSagaStatusFailed, i, fmt.Sprintf("补偿步骤 %s 失败: %v", step.Name, err))
+			LogError("Saga 补偿放弃: %s(%s), 步骤=%s, 需要重试 Recover 或人工处理", saga.SagaName, saga.SagaId, step.Name)
+			return err
+		}
+	}
+	se.saveStateQuietly(saga, EnumSagaStatusCompensated, -1, reason)
+	LogInfo("Saga 已补偿: %s(%s)", saga.SagaName, saga.SagaId)
+	return nil
+}
+
+func (se *SagaExecutor) insertState(saga *SagaContext) error {
+	data, err := json.Marshal(saga.Data)
+	if err != nil {
+		return NewValidationExceptionWithCause(err, "序列化 Saga 数据失败")
+	}
+	_, err = se.db.DataSource.Exec(fmt.Sprintf(
+		"INSERT INTO %s (saga_id, saga_name, status, current_step, data, error_message, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)", se.tableName),
+		saga.SagaId, saga.SagaName, string(EnumSagaStatusRunning), 0, string(data), "", time.Now())
+	if err != nil {
+		return NewQueryExceptionWithCause(err, "写入 Saga 状态失败")
+	}
+	return nil
+}
+
+func (se *SagaExecutor) saveState(saga *SagaContext, status EnumSagaStatus, currentStep int, errorMessage string) error {
+	data, err := json.Marshal(saga.Data)
+	if err != nil {
+		return NewValidationExceptionWithCause(err, "序列化 Saga 数据失败")
+	}
+	_, err = se.db.DataSource.Exec(fmt.Sprintf(
+		"UPDATE %s SET status = ?, current_step = ?, data = ?, error_message = ?, updated_at = ? WHERE saga_id = ?", se.tableName),
+		string(status), currentStep, string(data), errorMessage, time.Now(), saga.SagaId)
+	if err != nil {
+		return NewQueryExceptionWithCause(err, "更新 Saga 状态失败")
+	}
+	return nil
+}
+
+/**
+ * 补偿阶段的状态写入失败只记录日志，不中断补偿
+ */
+func (se *SagaExecutor) saveStateQuietly(saga *SagaContext, status EnumSagaStatus, currentStep int, errorMessage string) {
+	if err := se.saveState(saga, status, currentStep, errorMessage); err != nil {
+		LogError("Saga 状态写入失败: %s(%s), 状态=%s, 错误=%v", saga.SagaName, saga.SagaId, status, err)
+	}
+}
+
+func (se *SagaExecutor) loadStates(statuses ...EnumSagaStatus) ([]SagaState, error) {
+	placeholders := make([]string, len(statuses))
+	args := make([]interface{}, len(statuses))
+	for i, status := range statuses {
+		placeholders[i] = "?"
+		args[i] = string(status)
+	}
+	rows, err := se.db.DataSource.Query(fmt.Sprintf(
+		"SELECT saga_id, saga_name, status, current_step, data, error_message FROM %s WHERE status IN (%s) ORDER BY created_at",
+		se.tableName, strings.Join(placeholders, ", ")), args...)
+	if err != nil {
+		return nil, NewQueryExceptionWithCause(err, "查询待恢复的 Saga 失败")
+	}
+	return scanSagaStates(rows)
+}
+
+func scanSagaStates(rows *sql.Rows) ([]SagaState, error) {
+	defer rows.Close()
+	var states []SagaState
+	for rows.Next() {
+		var state SagaState
+		var status string
+		var data, errorMessage sql.NullString
+		if err := rows.Scan(&state.SagaId, &state.SagaName, &status, &state.CurrentStep, &data, &errorMessage); err != nil {
+			return nil, NewQueryExceptionWithCause(err, "扫描 Saga 状态失败")
+		}
+		state.Status = EnumSagaStatus(status)
+		state.ErrorMessage = errorMessage.String
+		if data.String != "" {
+			if err := json.Unmarshal([]byte(data.String), &state.Data); err != nil {
+				return nil, NewQueryExceptionWithCause(err, "解析 Saga 数据失败")
+			}
+		}
+		states = append(states, state)
+	}
+	return states, rows.Err()
+}
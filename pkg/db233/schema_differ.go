@@ -0,0 +1,325 @@
+package db233
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+/**
+ * SchemaDiffer - 模式差异生成器
+ *
+ * 比对实体元数据与线上数据库，把自动迁移会执行的变更写成一对可审查的迁移文件（不直接执行），
+ * 再由 MigrationManager 按版本应用 / 回滚：
+ *
+ *   differ := db233.NewSchemaDiffer(db, "migrations").AddEntities(&Player{}, &Item{})
+ *   migration, err := differ.WriteMigration("add_player_gold")
+ *   // 审查 migrations/{version}_add_player_gold.up.sql / .down.sql 后
+ *   db233.NewMigrationManager(db, "migrations").Up(0)
+ *
+ * 未调用 AddEntities 时使用 CrudManager 中已初始化的全部实体；变更范围与 AutoMigrateTable 一致
+ * （建表、增删列、外键），受 AutoDbPermission 约束，默认为 NewSafeAutoDbPermission
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type SchemaDiffer struct {
+	db               *Db
+	cm               *CrudManager
+	migrationManager *MigrationManager
+	entityTypes      []reflect.Type
+	permissions      *AutoDbPermission
+}
+
+/**
+ * SchemaChange - 单项模式变更
+ */
+type SchemaChange struct {
+	TableName   string
+	Description string
+	UpSQL       string
+	DownSQL     string
+}
+
+/**
+ * SchemaDiff - 模式差异（按执行顺序排列的变更）
+ */
+type SchemaDiff struct {
+	Changes []SchemaChange
+}
+
+/**
+ * 创建模式差异生成器
+ *
+ * @param migrationsDir 迁移文件目录（与 MigrationManager 相同）
+ */
+func NewSchemaDiffer(db *Db, migrationsDir string) *SchemaDiffer {
+	return &SchemaDiffer{
+		db:               db,
+		cm:               GetCrudManagerInstance(),
+		migrationManager: NewMigrationManager(db, migrationsDir),
+		permissions:      NewSafeAutoDbPermission(),
+	}
+}
+
+/**
+ * 添加参与比对的实体
+ */
+func (d *SchemaDiffer) AddEntities(entities ...interface{}) *SchemaDiffer {
+	for _, entity := range entities {
+		t := reflect.TypeOf(entity)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		d.entityTypes = append(d.entityTypes, t)
+	}
+	return d
+}
+
+/**
+ * 设置允许生成的变更类型（删除列、删除外键需显式开启）
+ */
+func (d *SchemaDiffer) SetPermissions(permissions *AutoDbPermission) *SchemaDiffer {
+	if permissions != nil {
+		d.permissions = permissions
+	}
+	return d
+}
+
+/**
+ * 比对实体与数据库，生成模式差异
+ */
+func (d *SchemaDiffer) Diff() (*SchemaDiff, error) {
+	entityTypes := d.entityTypes
+	if len(entityTypes) == 0 {
+		entityTypes = d.cm.GetRegisteredEntityTypes()
+	}
+
+	strategy := GetStrategyFactoryInstance().GetStrategyForDb(d.db)
+	diff := &SchemaDiff{}
+	for _, entityType := range entityTypes {
+		if err := d.diffTable(strategy, entityType, diff); err != nil {
+			return nil, err
+		}
+	}
+	return diff, nil
+}
+
+/**
+ * 比对并写入迁移文件
+ *
+ * @return *Migration 写入的迁移，没有差异时为 nil（不生成文件）
+ */
+func (d *SchemaDiffer) WriteMigration(name string) (*Migration, error) {
+	diff, err := d.Diff()
+	if err != nil {
+		return nil, err
+	}
+	if diff.IsEmpty() {
+		LogInfo("模式无差异，未生成迁移文件: %s", name)
+		return nil, nil
+	}
+
+	migration, err := d.migrationManager.WriteMigration(name, diff.UpSQL(), diff.DownSQL())
+	if err != nil {
+		return nil, err
+	}
+	LogInfo("模式差异已写入迁移文件: %d_%s, 变更数=%d", migration.Version, name, len(diff.Changes))
+	return &migration, nil
+}
+
+/**
+ * 比对单个实体对应的表
+ */
+func (d *SchemaDiffer) diffTable(strategy ITableCreationStrategy, entityType reflect.Type, diff *SchemaDiff) error {
+	tableName := d.cm.GetTableName(entityType)
+	if tableName == "" {
+		return NewDb233Exception("无法获取表名: " + entityType.String())
+	}
+
+	exists, err := strategy.TableExists(d.db, tableName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if !d.permissions.IsAllowed(EnumAutoDbOperateTypeCreateColumn) {
+			return nil
+		}
+		createSQL, err := strategy.GenerateCreateTableSQL(tableName, entityType, "")
+		if err != nil {
+			return err
+		}
+		diff.add(tableName, "创建表", createSQL, "DROP TABLE "+quoteSchemaIdentifier(strategy, tableName))
+		return nil
+	}
+
+	existingColumns, err := strategy.GetTableColumns(d.db, tableName)
+	if err != nil {
+		return fmt.Errorf("获取表列信息失败: %w", err)
+	}
+	entityColumns := d.cm.getEntityColumns(entityType)
+
+	if d.permissions.IsAllowed(EnumAutoDbOperateTypeCreateColumn) {
+		for _, colName := range sortedKeys(entityColumns) {
+			if _, exists := existingColumns[colName]; exists {
+				continue
+			}
+			addSQL, err := strategy.GenerateAddColumnSQL(tableName, entityColumns[colName], colName)
+			if err != nil {
+				return err
+			}
+			dropSQL, err := strategy.GenerateDropColumnSQL(tableName, colName)
+			if err != nil {
+				return err
+			}
+			diff.add(tableName, "添加列 "+colName, addSQL, dropSQL)
+		}
+	}
+
+	if d.permissions.IsAllowed(EnumAutoDbOperateTypeDeleteColumn) {
+		for _, colName := range sortedKeys(existingColumns) {
+			if _, exists := entityColumns[colName]; exists {
+				continue
+			}
+			dropSQL, err := strategy.GenerateDropColumnSQL(tableName, colName)
+			if err != nil {
+				return err
+			}
+			diff.add(tableName, "删除列 "+colName, dropSQL, restoreColumnSQL(strategy, tableName, existingColumns[colName]))
+		}
+	}
+
+	return d.diffForeignKeys(strategy, tableName, entityType, diff)
+}
+
+/**
+ * 比对外键（建表策略未实现 IForeignKeyStrategy 时跳过）
+ */
+func (d *SchemaDiffer) diffForeignKeys(strategy ITableCreationStrategy, tableName string, entityType reflect.Type, diff *SchemaDiff) error {
+	fkStrategy, ok := strategy.(IForeignKeyStrategy)
+	if !ok {
+		return nil
+	}
+	existing, err := fkStrategy.GetForeignKeys(d.db, tableName)
+	if err != nil {
+		return err
+	}
+	missing, changed, undeclared := DiffForeignKeys(existing, d.cm.GetForeignKeys(entityType, tableName))
+
+	if d.permissions.IsAllowed(EnumAutoDbOperateTypeDropForeignKey) {
+		for _, name := range undeclared {
+			current, _ := lookupForeignKey(existing, name)
+			diff.add(tableName, "删除外键 "+name,
+				fkStrategy.GenerateDropForeignKeySQL(tableName, name),
+				fkStrategy.GenerateAddForeignKeySQL(tableName, current))
+		}
+		for _, fk := range changed {
+			current, _ := lookupForeignKey(existing, fk.Name)
+			diff.add(tableName, "重建外键 "+fk.Name,
+				fkStrategy.GenerateDropForeignKeySQL(tableName, current.Name)+";\n"+fkStrategy.GenerateAddForeignKeySQL(tableName, fk),
+				fkStrategy.GenerateDropForeignKeySQL(tableName, fk.Name)+";\n"+fkStrategy.GenerateAddForeignKeySQL(tableName, current))
+		}
+	}
+	if d.permissions.IsAllowed(EnumAutoDbOperateTypeCreateColumn) {
+		for _, fk := range missing {
+			diff.add(tableName, "添加外键 "+fk.Name,
+				fkStrategy.GenerateAddForeignKeySQL(tableName, fk),
+				fkStrategy.GenerateDropForeignKeySQL(tableName, fk.Name))
+		}
+	}
+	return nil
+}
+
+func (diff *SchemaDiff) add(tableName string, description string, upSQL string, downSQL string) {
+	diff.Changes = append(diff.Changes, SchemaChange{TableName: tableName, Description: description, UpSQL: upSQL, DownSQL: downSQL})
+}
+
+/**
+ * 是否没有任何变更
+ */
+func (diff *SchemaDiff) IsEmpty() bool {
+	return len(diff.Changes) == 0
+}
+
+/**
+ * 上迁 SQL（按变更顺序，每项带说明注释）
+ */
+func (diff *SchemaDiff) UpSQL() string {
+	parts := make([]string, 0, len(diff.Changes))
+	for _, change := range diff.Changes {
+		parts = append(parts, formatSchemaChange(change, change.UpSQL))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+/**
+ * 下迁 SQL（按变更逆序撤销）
+ */
+func (diff *SchemaDiff) DownSQL() string {
+	parts := make([]string, 0, len(diff.Changes))
+	for i := len(diff.Changes) - 1; i >= 0; i-- {
+		change := diff.Changes[i]
+		parts = append(parts, formatSchemaChange(change, change.DownSQL))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func formatSchemaChange(change SchemaChange, sql string) string {
+	return fmt.Sprintf("-- %s: %s\n%s;", change.TableName, change.Description, strings.TrimRight(strings.TrimSpace(sql), ";"))
+}
+
+/**
+ * 按建表策略的方言引用标识符
+ */
+func quoteSchemaIdentifier(strategy ITableCreationStrategy, name string) string {
+	switch strategy.GetDatabaseType() {
+	case EnumDatabaseTypeMySQL:
+		return "`" + name + "`"
+	case EnumDatabaseTypeSQLServer:
+		return "[" + name + "]"
+	}
+	return `"` + name + `"`
+}
+
+/**
+ * 生成恢复被删除列的 SQL（按现有列类型与可空性还原结构，列数据无法恢复）
+ */
+func restoreColumnSQL(strategy ITableCreationStrategy, tableName string, column ColumnInfo) string {
+	nullable := " NULL"
+	if !column.IsNullable {
+		nullable = " NOT NULL"
+	}
+	addKeyword := "ADD COLUMN "
+	if strategy.GetDatabaseType() == EnumDatabaseTypeSQLServer {
+		addKeyword = "ADD "
+	}
+	return fmt.Sprintf("-- 列数据无法恢复，仅还原结构\nALTER TABLE %s %s%s %s%s",
+		quoteSchemaIdentifier(strategy, tableName), addKeyword, quoteSchemaIdentifier(strategy, column.Name), column.Type, nullable)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+/**
+ * 获取已初始化元数据的实体类型（按表名排序）
+ */
+func (cm *CrudManager) GetRegisteredEntityTypes() []reflect.Type {
+	cm.mu.RLock()
+	types := make([]reflect.Type, 0, len(cm.metadataClassSet))
+	for t := range cm.metadataClassSet {
+		types = append(types, t)
+	}
+	cm.mu.RUnlock()
+
+	sort.Slice(types, func(i, j int) bool {
+		return cm.GetTableName(types[i]) < cm.GetTableName(types[j])
+	})
+	return types
+}
@@ -0,0 +1,151 @@
+package db233
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+/**
+ * SecurityPolicy - 行级安全策略
+ *
+ * 按表注册谓词生成器，Repository 的 SELECT / UPDATE / DELETE（含关联加载、Count、分页）
+ * 自动把生成的谓词 AND 到 WHERE 中，租户、归属等过滤不再依赖各调用点手写：
+ *
+ *   db233.GetSecurityPolicyRegistryInstance().Register("orders", func(ctx context.Context) (*db233.ScopePredicate, error) {
+ *       tenantId, ok := ctx.Value(tenantKey{}).(int64)
+ *       if !ok {
+ *           return nil, errors.New("缺少租户信息")
+ *       }
+ *       return &db233.ScopePredicate{Sql: "tenant_id = ?", Params: []interface{}{tenantId}}, nil
+ *   })
+ *
+ *   repo.WithContext(ctx).FindAll(&Order{})  // SELECT * FROM orders WHERE (tenant_id = ?)
+ *
+ * Save / Upsert 带谓词时不使用 ON DUPLICATE KEY UPDATE，而是先按主键（冲突列）与谓词 UPDATE，
+ * 未命中可见行才 INSERT（三步在同一事务中执行），策略范围外的行不会被覆盖。
+ *
+ * 生成器返回 nil 表示本次不限制；返回错误时操作被拒绝（*SecurityPolicyDeniedException）。
+ * 表名为实体 TableName() 的逻辑表名（RepositoryScope.TableNameMapper 改写之前）。
+ * 运维任务可用 BypassSecurityPolicy(ctx) 跳过策略。
+ *
+ * @param ctx 存储库绑定的 context（见 BaseCrudRepository.WithContext）
+ * @return *ScopePredicate 谓词，nil 表示不限制
+ * @return error 拒绝原因
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type SecurityPolicy func(ctx context.Context) (*ScopePredicate, error)
+
+/**
+ * SecurityPolicyRegistry - 行级安全策略注册表
+ */
+type SecurityPolicyRegistry struct {
+	policies map[string]SecurityPolicy
+	mu       sync.RWMutex
+}
+
+var (
+	securityPolicyRegistryInstance *SecurityPolicyRegistry
+	securityPolicyRegistryOnce     sync.Once
+)
+
+/**
+ * 获取行级安全策略注册表单例
+ */
+func GetSecurityPolicyRegistryInstance() *SecurityPolicyRegistry {
+	securityPolicyRegistryOnce.Do(func() {
+		securityPolicyRegistryInstance = &SecurityPolicyRegistry{
+			policies: make(map[string]SecurityPolicy),
+		}
+	})
+	return securityPolicyRegistryInstance
+}
+
+/**
+ * 注册表的安全策略（同表覆盖）
+ *
+ * @param tableName 逻辑表名
+ * @param policy 谓词生成器
+ */
+func (reg *SecurityPolicyRegistry) Register(tableName string, policy SecurityPolicy) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.policies[strings.ToLower(tableName)] = policy
+	LogInfo("行级安全策略已注册: 表=%s", tableName)
+}
+
+/**
+ * 移除表的安全策略
+ */
+func (reg *SecurityPolicyRegistry) Unregister(tableName string) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	delete(reg.policies, strings.ToLower(tableName))
+}
+
+/**
+ * 获取表的安全策略
+ */
+func (reg *SecurityPolicyRegistry) Get(tableName string) (SecurityPolicy, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	policy, exists := reg.policies[strings.ToLower(tableName)]
+	return policy, exists
+}
+
+/**
+ * 移除所有安全策略
+ */
+func (reg *SecurityPolicyRegistry) Clear() {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.policies = make(map[string]SecurityPolicy)
+}
+
+/**
+ * 生成表的安全谓词
+ *
+ * @return *ScopePredicate 谓词，nil 表示不限制
+ * @return error 策略拒绝时返回 *SecurityPolicyDeniedException
+ */
+func (reg *SecurityPolicyRegistry) predicateFor(ctx context.Context, tableName string) (*ScopePredicate, error) {
+	policy, exists := reg.Get(tableName)
+	if !exists || isSecurityPolicyBypassed(ctx) {
+		return nil, nil
+	}
+
+	predicate, err := policy(ctx)
+	if err != nil {
+		LogWarn("行级安全策略拒绝访问: 表=%s, 原因=%v", tableName, err)
+		return nil, NewSecurityPolicyDeniedException(tableName, err)
+	}
+	if predicate == nil || predicate.Sql == "" {
+		return nil, nil
+	}
+	return &ScopePredicate{Sql: "(" + predicate.Sql + ")", Params: predicate.Params}, nil
+}
+
+/**
+ * 跳过行级安全策略的 context 标记
+ */
+type securityPolicyBypassKey struct{}
+
+/**
+ * 返回跳过行级安全策略的 context（仅用于运维、迁移等受信任任务）
+ */
+func BypassSecurityPolicy(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, securityPolicyBypassKey{}, true)
+}
+
+/**
+ * context 是否标记为跳过行级安全策略
+ */
+func isSecurityPolicyBypassed(ctx context.Context) bool {
+	bypassed, _ := ctx.Value(securityPolicyBypassKey{}).(bool)
+	return bypassed
+}
@@ -0,0 +1,474 @@
+package db233
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/**
+ * Seeder - 数据填充器
+ *
+ * MigrationManager 管理表结构，Seeder 管理数据：初始账号、字典表、测试数据等。
+ * 每个 Seeder 在独立事务中执行，成功后记入 schema_seeds 表，之后不再重复执行
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type Seeder interface {
+	/**
+	 * 唯一名称（记录到 schema_seeds 表）
+	 */
+	GetName() string
+
+	/**
+	 * 在事务中写入数据
+	 */
+	Seed(tx *TransactionManager) error
+}
+
+/**
+ * FuncSeeder - 回调数据填充器
+ */
+type FuncSeeder struct {
+	name string
+	fn   func(tx *TransactionManager) error
+}
+
+/**
+ * 创建回调数据填充器
+ */
+func NewFuncSeeder(name string, fn func(tx *TransactionManager) error) *FuncSeeder {
+	return &FuncSeeder{name: name, fn: fn}
+}
+
+/**
+ * 获取名称
+ */
+func (s *FuncSeeder) GetName() string {
+	return s.name
+}
+
+/**
+ * 调用回调写入数据
+ */
+func (s *FuncSeeder) Seed(tx *TransactionManager) error {
+	return s.fn(tx)
+}
+
+/**
+ * SqlSeeder - SQL 脚本数据填充器（按分号拆分为多条语句依次执行）
+ */
+type SqlSeeder struct {
+	name string
+	sql  string
+}
+
+/**
+ * 创建 SQL 脚本数据填充器
+ */
+func NewSqlSeeder(name string, sql string) *SqlSeeder {
+	return &SqlSeeder{name: name, sql: sql}
+}
+
+/**
+ * 获取名称
+ */
+func (s *SqlSeeder) GetName() string {
+	return s.name
+}
+
+/**
+ * 依次执行脚本中的语句
+ */
+func (s *SqlSeeder) Seed(tx *TransactionManager) error {
+	for _, statement := range splitSqlStatements(s.sql) {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ * SeedStatus - 数据填充器状态
+ */
+type SeedStatus struct {
+	Name string
+
+	// 适用环境，为空表示所有环境
+	Environments []string
+
+	// 是否适用于当前环境
+	Enabled bool
+
+	// 是否已执行
+	Applied bool
+}
+
+/**
+ * SeederRunner - 按注册顺序执行数据填充器
+ *
+ *   runner := db233.NewSeederRunner(db, "dev")
+ *   runner.Register(db233.NewSqlSeeder("001_roles", rolesSql))              // 所有环境
+ *   runner.RegisterFunc("002_test_users", seedTestUsers, "dev", "test")     // 仅 dev / test
+ *   runner.LoadSqlDir("seeds")                                              // seeds/*.sql 与 seeds/dev/*.sql
+ *   applied, err := runner.Run()
+ */
+type SeederRunner struct {
+	db          *Db
+	environment string
+	tableName   string
+
+	mu      sync.Mutex
+	entries []*seederEntry
+}
+
+/**
+ * 注册的数据填充器
+ */
+type seederEntry struct {
+	seeder       Seeder
+	environments []string
+}
+
+/**
+ * 创建数据填充执行器
+ *
+ * @param db 数据库
+ * @param environment 当前环境（如 dev / test / staging / prod）
+ */
+func NewSeederRunner(db *Db, environment string) *SeederRunner {
+	return &SeederRunner{
+		db:          db,
+		environment: environment,
+		tableName:   "schema_seeds",
+	}
+}
+
+/**
+ * 设置执行记录表名（默认 schema_seeds）
+ */
+func (sr *SeederRunner) SetTableName(tableName string) {
+	sr.tableName = tableName
+}
+
+/**
+ * 获取当前环境
+ */
+func (sr *SeederRunner) GetEnvironment() string {
+	return sr.environment
+}
+
+/**
+ * 注册数据填充器
+ *
+ * @param seeder 数据填充器
+ * @param environments 适用环境，不传表示所有环境
+ */
+func (sr *SeederRunner) Register(seeder Seeder, environments ...string) error {
+	if seeder == nil || seeder.GetName() == "" {
+		return NewValidationException("数据填充器及其名称不能为空")
+	}
+
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	for _, entry := range sr.entries {
+		if entry.seeder.GetName() == seeder.GetName() {
+			return NewValidationException(fmt.Sprintf("数据填充器重复注册: %s", seeder.GetName()))
+		}
+	}
+	sr.entries = append(sr.entries, &seederEntry{seeder: seeder, environments: environments})
+	return nil
+}
+
+/**
+ * 注册回调数据填充器
+ */
+func (sr *SeederRunner) RegisterFunc(name string, fn func(tx *TransactionManager) error, environments ...string) error {
+	if fn == nil {
+		return NewValidationException("数据填充回调不能为 nil")
+	}
+	return sr.Register(NewFuncSeeder(name, fn), environments...)
+}
+
+/**
+ * 加载目录中的 SQL 脚本
+ *
+ * 目录下的 *.sql 适用于所有环境，以环境命名的子目录（如 dev/*.sql）只适用于该环境；
+ * 按文件名排序后注册，名称为去掉 .sql 的文件名（子目录中的脚本带环境前缀，如 dev/002_test_users）
+ *
+ * @param dir 脚本目录
+ * @return int 加载的脚本数
+ */
+func (sr *SeederRunner) LoadSqlDir(dir string) (int, error) {
+	type sqlFile struct {
+		name        string
+		path        string
+		environment string
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, NewConfigurationExceptionWithCause(err, "读取数据填充目录失败")
+	}
+
+	var files []sqlFile
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			if strings.HasSuffix(entry.Name(), ".sql") {
+				files = append(files, sqlFile{name: strings.TrimSuffix(entry.Name(), ".sql"), path: filepath.Join(dir, entry.Name())})
+			}
+			continue
+		}
+
+		environment := entry.Name()
+		subEntries, err := os.ReadDir(filepath.Join(dir, environment))
+		if err != nil {
+			return 0, NewConfigurationExceptionWithCause(err, "读取数据填充目录失败")
+		}
+		for _, subEntry := range subEntries {
+			if !subEntry.IsDir() && strings.HasSuffix(subEntry.Name(), ".sql") {
+				files = append(files, sqlFile{
+					name:        environment + "/" + strings.TrimSuffix(subEntry.Name(), ".sql"),
+					path:        filepath.Join(dir, environment, subEntry.Name()),
+					environment: environment,
+				})
+			}
+		}
+	}
+
+	// 按文件名排序，公共脚本与环境脚本可通过编号前缀交错执行
+	sort.SliceStable(files, func(i, j int) bool {
+		baseI, baseJ := filepath.Base(files[i].path), filepath.Base(files[j].path)
+		if baseI != baseJ {
+			return baseI < baseJ
+		}
+		return files[i].name < files[j].name
+	})
+
+	for _, file := range files {
+		content, err := os.ReadFile(file.path)
+		if err != nil {
+			return 0, NewConfigurationExceptionWithCause(err, "读取数据填充脚本失败: "+file.path)
+		}
+		var environments []string
+		if file.environment != "" {
+			environments = []string{file.environment}
+		}
+		if err := sr.Register(NewSqlSeeder(file.name, string(content)), environments...); err != nil {
+			return 0, err
+		}
+	}
+	LogInfo("已加载数据填充脚本: 目录=%s, 数量=%d", dir, len(files))
+	return len(files), nil
+}
+
+/**
+ * 初始化执行记录表
+ */
+func (sr *SeederRunner) Init() error {
+	createTableSQL := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			name VARCHAR(255) PRIMARY KEY,
+			environment VARCHAR(64) NOT NULL,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`, sr.tableName)
+
+	if _, err := sr.db.DataSource.Exec(createTableSQL); err != nil {
+		return NewQueryExceptionWithCause(err, "创建数据填充记录表失败")
+	}
+	LogInfo("数据填充记录表已初始化: %s", sr.tableName)
+	return nil
+}
+
+/**
+ * 按注册顺序执行当前环境下尚未执行的数据填充器，遇到失败立即停止
+ *
+ * @return int 本次执行的数量
+ */
+func (sr *SeederRunner) Run() (int, error) {
+	pending, err := sr.GetPendingSeeders()
+	if err != nil {
+		return 0, err
+	}
+	if len(pending) == 0 {
+		LogInfo("没有待执行的数据填充: 环境=%s", sr.environment)
+		return 0, nil
+	}
+
+	for i, seeder := range pending {
+		if err := sr.apply(seeder); err != nil {
+			return i, fmt.Errorf("执行数据填充失败 %s: %w", seeder.GetName(), err)
+		}
+	}
+	LogInfo("成功执行 %d 个数据填充: 环境=%s", len(pending), sr.environment)
+	return len(pending), nil
+}
+
+/**
+ * 获取当前环境下尚未执行的数据填充器（按注册顺序）
+ */
+func (sr *SeederRunner) GetPendingSeeders() ([]Seeder, error) {
+	applied, err := sr.getAppliedNames()
+	if err != nil {
+		return nil, err
+	}
+
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	var pending []Seeder
+	for _, entry := range sr.entries {
+		if entry.appliesTo(sr.environment) && !applied[entry.seeder.GetName()] {
+			pending = append(pending, entry.seeder)
+		}
+	}
+	return pending, nil
+}
+
+/**
+ * 获取所有已注册数据填充器的状态
+ */
+func (sr *SeederRunner) GetStatus() ([]SeedStatus, error) {
+	applied, err := sr.getAppliedNames()
+	if err != nil {
+		return nil, err
+	}
+
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	statuses := make([]SeedStatus, 0, len(sr.entries))
+	for _, entry := range sr.entries {
+		statuses = append(statuses, SeedStatus{
+			Name:         entry.seeder.GetName(),
+			Environments: entry.environments,
+			Enabled:      entry.appliesTo(sr.environment),
+			Applied:      applied[entry.seeder.GetName()],
+		})
+	}
+	return statuses, nil
+}
+
+/**
+ * 在事务中执行单个数据填充器并记录
+ */
+func (sr *SeederRunner) apply(seeder Seeder) error {
+	err := WithTransaction(sr.db, func(tm *TransactionManager) error {
+		if err := seeder.Seed(tm); err != nil {
+			return err
+		}
+		_, err := tm.Exec(fmt.Sprintf("INSERT INTO %s (name, environment) VALUES (?, ?)", sr.tableName), seeder.GetName(), sr.environment)
+		return err
+	})
+	if err != nil {
+		LogError("执行数据填充失败 %s: %v", seeder.GetName(), err)
+		return err
+	}
+	LogInfo("执行数据填充成功 %s: 环境=%s", seeder.GetName(), sr.environment)
+	return nil
+}
+
+/**
+ * 查询已执行的数据填充器名称
+ */
+func (sr *SeederRunner) getAppliedNames() (map[string]bool, error) {
+	rows, err := sr.db.DataSource.Query(fmt.Sprintf("SELECT name FROM %s", sr.tableName))
+	if err != nil {
+		return nil, NewQueryExceptionWithCause(err, "查询已执行数据填充失败")
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, NewQueryExceptionWithCause(err, "扫描数据填充记录失败")
+		}
+		applied[name] = true
+	}
+	return applied, rows.Err()
+}
+
+/**
+ * 是否适用于指定环境
+ */
+func (e *seederEntry) appliesTo(environment string) bool {
+	if len(e.environments) == 0 {
+		return true
+	}
+	for _, env := range e.environments {
+		if strings.EqualFold(env, environment) {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * 按分号拆分 SQL 脚本（忽略引号内与注释中的分号），去掉空语句
+ */
+func splitSqlStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	var quote rune
+	lineComment, blockComment := false, false
+
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		var next rune
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		switch {
+		case lineComment:
+			if c == '\n' {
+				lineComment = false
+				current.WriteRune(c)
+			}
+			continue
+		case blockComment:
+			if c == '*' && next == '/' {
+				blockComment = false
+				i++
+			}
+			continue
+		case quote != 0:
+			current.WriteRune(c)
+			if c == '\\' && quote != '`' && next != 0 {
+				current.WriteRune(next)
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch {
+		case c == '-' && next == '-':
+			lineComment = true
+		case c == '/' && next == '*':
+			blockComment = true
+			i++
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			current.WriteRune(c)
+		case c == ';':
+			if statement := strings.TrimSpace(current.String()); statement != "" {
+				statements = append(statements, statement)
+			}
+			current.Reset()
+		default:
+			current.WriteRune(c)
+		}
+	}
+	if statement := strings.TrimSpace(current.String()); statement != "" {
+		statements = append(statements, statement)
+	}
+	return statements
+}
@@ -0,0 +1,277 @@
+package db233
+
+import (
+	"math/rand"
+	"reflect"
+	"sync"
+	"time"
+)
+
+/**
+ * ShadowReader - 读影子比对工具
+ *
+ * 读请求照常走主库，同时按采样率把查询重放到影子库（迁移目标），
+ * 对比结果与延迟并记录差异，是 DualWriter 双写的验证配套工具
+ *
+ * 结果按顺序逐行比较，需要稳定顺序的查询应带 ORDER BY
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type ShadowReader struct {
+	primary *Db
+	shadow  *Db
+
+	// 采样率（0~1）
+	sampleRate float64
+
+	// 是否异步重放（默认异步，不阻塞主链路）
+	async bool
+
+	// 保留的差异记录上限
+	maxDivergences int
+	divergences    []*ShadowDivergence
+
+	// 统计信息
+	totalQueries   int64
+	sampledQueries int64
+	matchedQueries int64
+	diverged       int64
+	primaryTime    time.Duration
+	shadowTime     time.Duration
+
+	pending sync.WaitGroup
+	mu      sync.RWMutex
+}
+
+/**
+ * EnumShadowDivergenceReason - 差异原因
+ */
+type EnumShadowDivergenceReason string
+
+const (
+	// EnumShadowDivergenceRowCount 行数不同
+	EnumShadowDivergenceRowCount EnumShadowDivergenceReason = "row_count"
+	// EnumShadowDivergenceContent 行数相同但内容不同
+	EnumShadowDivergenceContent EnumShadowDivergenceReason = "content"
+	// EnumShadowDivergenceError 仅一侧执行出错
+	EnumShadowDivergenceError EnumShadowDivergenceReason = "error"
+)
+
+/**
+ * ShadowDivergence - 一次结果差异
+ */
+type ShadowDivergence struct {
+	Sql            string
+	Params         []interface{}
+	Reason         EnumShadowDivergenceReason
+	PrimaryRows    int
+	ShadowRows     int
+	PrimaryError   error
+	ShadowError    error
+	PrimaryLatency time.Duration
+	ShadowLatency  time.Duration
+	DetectedAt     time.Time
+}
+
+/**
+ * 创建读影子比对工具
+ *
+ * @param primary 主库
+ * @param shadow 影子库
+ * @param sampleRate 采样率（0~1）
+ */
+func NewShadowReader(primary *Db, shadow *Db, sampleRate float64) *ShadowReader {
+	sr := &ShadowReader{
+		primary:        primary,
+		shadow:         shadow,
+		async:          true,
+		maxDivergences: 1000,
+		divergences:    make([]*ShadowDivergence, 0),
+	}
+	sr.SetSampleRate(sampleRate)
+	return sr
+}
+
+/**
+ * 设置采样率（超出 0~1 时截断）
+ */
+func (sr *ShadowReader) SetSampleRate(sampleRate float64) {
+	if sampleRate < 0 {
+		sampleRate = 0
+	}
+	if sampleRate > 1 {
+		sampleRate = 1
+	}
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.sampleRate = sampleRate
+}
+
+/**
+ * 设置是否异步重放
+ */
+func (sr *ShadowReader) SetAsync(async bool) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.async = async
+}
+
+/**
+ * 设置保留的差异记录上限
+ */
+func (sr *ShadowReader) SetMaxDivergences(max int) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.maxDivergences = max
+}
+
+/**
+ * 执行查询：返回主库结果，按采样率在影子库重放并比对
+ *
+ * @param query SQL 语句
+ * @param params 参数
+ * @param returnType 返回类型
+ * @return []interface{} 主库结果
+ * @return error 主库执行错误
+ */
+func (sr *ShadowReader) Query(query string, params []interface{}, returnType interface{}) ([]interface{}, error) {
+	start := time.Now()
+	primaryResults, primaryErr := sr.primary.executeQueryOnce(query, params, returnType, sr.primary.statementTimeout())
+	primaryLatency := time.Since(start)
+
+	sr.mu.Lock()
+	sr.totalQueries++
+	sampled := sr.sampleRate > 0 && rand.Float64() < sr.sampleRate
+	async := sr.async
+	sr.mu.Unlock()
+
+	if sampled {
+		if async {
+			sr.pending.Add(1)
+			go func() {
+				defer sr.pending.Done()
+				sr.compare(query, params, returnType, primaryResults, primaryErr, primaryLatency)
+			}()
+		} else {
+			sr.compare(query, params, returnType, primaryResults, primaryErr, primaryLatency)
+		}
+	}
+
+	if primaryErr != nil {
+		return nil, NewQueryExceptionWithCause(primaryErr, "查询执行失败: "+query)
+	}
+	return primaryResults, nil
+}
+
+/**
+ * 等待所有异步重放完成
+ */
+func (sr *ShadowReader) Wait() {
+	sr.pending.Wait()
+}
+
+/**
+ * 在影子库重放查询并比对
+ */
+func (sr *ShadowReader) compare(query string, params []interface{}, returnType interface{}, primaryResults []interface{}, primaryErr error, primaryLatency time.Duration) {
+	start := time.Now()
+	shadowResults, shadowErr := sr.shadow.executeQueryOnce(query, params, returnType, sr.shadow.statementTimeout())
+	shadowLatency := time.Since(start)
+
+	var reason EnumShadowDivergenceReason
+	switch {
+	case primaryErr != nil || shadowErr != nil:
+		if (primaryErr == nil) != (shadowErr == nil) {
+			reason = EnumShadowDivergenceError
+		}
+	case len(primaryResults) != len(shadowResults):
+		reason = EnumShadowDivergenceRowCount
+	case !reflect.DeepEqual(primaryResults, shadowResults):
+		reason = EnumShadowDivergenceContent
+	}
+
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	sr.sampledQueries++
+	sr.primaryTime += primaryLatency
+	sr.shadowTime += shadowLatency
+
+	if reason == "" {
+		sr.matchedQueries++
+		return
+	}
+
+	sr.diverged++
+	divergence := &ShadowDivergence{
+		Sql:            query,
+		Params:         params,
+		Reason:         reason,
+		PrimaryRows:    len(primaryResults),
+		ShadowRows:     len(shadowResults),
+		PrimaryError:   primaryErr,
+		ShadowError:    shadowErr,
+		PrimaryLatency: primaryLatency,
+		ShadowLatency:  shadowLatency,
+		DetectedAt:     time.Now(),
+	}
+	sr.divergences = append(sr.divergences, divergence)
+	if sr.maxDivergences > 0 && len(sr.divergences) > sr.maxDivergences {
+		sr.divergences = sr.divergences[len(sr.divergences)-sr.maxDivergences:]
+	}
+	LogWarn("影子读结果不一致: 原因=%s, 主库行数=%d, 影子行数=%d, SQL=%s", reason, divergence.PrimaryRows, divergence.ShadowRows, query)
+}
+
+/**
+ * 获取差异记录
+ */
+func (sr *ShadowReader) GetDivergences() []*ShadowDivergence {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+	result := make([]*ShadowDivergence, len(sr.divergences))
+	copy(result, sr.divergences)
+	return result
+}
+
+/**
+ * 清空差异记录和统计
+ */
+func (sr *ShadowReader) Reset() {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.divergences = make([]*ShadowDivergence, 0)
+	sr.totalQueries = 0
+	sr.sampledQueries = 0
+	sr.matchedQueries = 0
+	sr.diverged = 0
+	sr.primaryTime = 0
+	sr.shadowTime = 0
+}
+
+/**
+ * 获取比对报告
+ */
+func (sr *ShadowReader) GetReport() map[string]interface{} {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+
+	var avgPrimary, avgShadow time.Duration
+	var matchRate float64
+	if sr.sampledQueries > 0 {
+		avgPrimary = sr.primaryTime / time.Duration(sr.sampledQueries)
+		avgShadow = sr.shadowTime / time.Duration(sr.sampledQueries)
+		matchRate = float64(sr.matchedQueries) / float64(sr.sampledQueries) * 100
+	}
+
+	return map[string]interface{}{
+		"total_queries":       sr.totalQueries,
+		"sampled_queries":     sr.sampledQueries,
+		"matched_queries":     sr.matchedQueries,
+		"diverged_queries":    sr.diverged,
+		"match_rate":          matchRate,
+		"avg_primary_latency": avgPrimary,
+		"avg_shadow_latency":  avgShadow,
+		"sample_rate":         sr.sampleRate,
+	}
+}
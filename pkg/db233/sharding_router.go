@@ -0,0 +1,408 @@
+package db233
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/**
+ * ShardingRouter - 分片路由器
+ *
+ * 按实体配置的分片键（如 PlayerID % N）把 Save / Update / FindById / DeleteById
+ * 路由到 DbGroup 中对应的 Db；无法确定分片的查询（FindByCondition 等）
+ * 并发分发到所有分片再合并结果（scatter-gather）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type ShardingRouter struct {
+	dbGroup   *DbGroup
+	shardKeys map[string]*ShardKeyConfig
+	metrics   map[int]*ShardMetrics
+	mu        sync.RWMutex
+}
+
+/**
+ * ShardKeyConfig - 实体分片键配置
+ */
+type ShardKeyConfig struct {
+	TableName string
+	Column    string
+
+	// 分片策略，nil 时使用 DbGroup 的策略
+	Strategy ShardingDbStrategy
+}
+
+/**
+ * ShardMetrics - 分片级统计
+ */
+type ShardMetrics struct {
+	DbId           int
+	Reads          int64
+	Writes         int64
+	Errors         int64
+	ScatterQueries int64
+	TotalTime      time.Duration
+}
+
+/**
+ * 创建分片路由器
+ */
+func NewShardingRouter(dbGroup *DbGroup) *ShardingRouter {
+	return &ShardingRouter{
+		dbGroup:   dbGroup,
+		shardKeys: make(map[string]*ShardKeyConfig),
+		metrics:   make(map[int]*ShardMetrics),
+	}
+}
+
+/**
+ * 注册实体分片键
+ *
+ * @param entityType 实体类型
+ * @param column 分片键列名
+ * @param strategy 分片策略，nil 时使用 DbGroup 的策略
+ */
+func (sr *ShardingRouter) RegisterShardKey(entityType IDbEntity, column string, strategy ShardingDbStrategy) {
+	tableName := NewBaseCrudRepository(nil).getTableName(entityType)
+
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.shardKeys[tableName] = &ShardKeyConfig{
+		TableName: tableName,
+		Column:    column,
+		Strategy:  strategy,
+	}
+	LogInfo("分片键已注册: 表=%s, 列=%s", tableName, column)
+}
+
+/**
+ * 获取实体分片键配置
+ */
+func (sr *ShardingRouter) GetShardKeyConfig(entityType IDbEntity) (*ShardKeyConfig, error) {
+	tableName := NewBaseCrudRepository(nil).getTableName(entityType)
+
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+	config, exists := sr.shardKeys[tableName]
+	if !exists {
+		return nil, NewValidationException(fmt.Sprintf("实体 %T 未配置分片键（表=%s）", entityType, tableName))
+	}
+	return config, nil
+}
+
+/**
+ * 根据分片键值路由到 Db
+ *
+ * @return *Db 目标数据库
+ * @return int 目标 dbId
+ */
+func (sr *ShardingRouter) RouteShardKey(shardKey interface{}, entityType IDbEntity) (*Db, int, error) {
+	config, err := sr.GetShardKeyConfig(entityType)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	shardingId, err := toShardingId(shardKey)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	strategy := config.Strategy
+	if strategy == nil {
+		strategy = sr.dbGroup.ShardingDbStrategy
+	}
+	if strategy == nil {
+		return nil, 0, NewConfigurationException(fmt.Sprintf("分片路由失败: 表=%s 未配置分片策略，DbGroup 也没有默认分片策略", config.TableName))
+	}
+	dbId := strategy.CalculateDbId(shardingId)
+
+	db, err := sr.dbGroup.GetDbByDbId(dbId)
+	if err != nil {
+		return nil, dbId, NewConfigurationExceptionWithCause(err, fmt.Sprintf("分片路由失败: 表=%s, 分片键=%v", config.TableName, shardKey))
+	}
+	return db, dbId, nil
+}
+
+/**
+ * 根据实体中的分片键字段路由到 Db
+ */
+func (sr *ShardingRouter) RouteEntity(entity IDbEntity) (*Db, int, error) {
+	config, err := sr.GetShardKeyConfig(entity)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	shardKey, exists := NewBaseCrudRepository(nil).getFields(entity)[config.Column]
+	if !exists {
+		return nil, 0, NewValidationException(fmt.Sprintf("实体 %T 缺少分片键字段: %s", entity, config.Column))
+	}
+	return sr.RouteShardKey(shardKey, entity)
+}
+
+/**
+ * 保存实体到所属分片
+ */
+func (sr *ShardingRouter) Save(entity IDbEntity) error {
+	if entity == nil {
+		return NewValidationException("实体不能为 nil")
+	}
+	db, dbId, err := sr.RouteEntity(entity)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err = NewBaseCrudRepository(db).Save(entity)
+	sr.record(dbId, false, time.Since(start), err)
+	return err
+}
+
+/**
+ * 更新所属分片中的实体
+ */
+func (sr *ShardingRouter) Update(entity IDbEntity) error {
+	if entity == nil {
+		return NewValidationException("实体不能为 nil")
+	}
+	db, dbId, err := sr.RouteEntity(entity)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err = NewBaseCrudRepository(db).Update(entity)
+	sr.record(dbId, false, time.Since(start), err)
+	return err
+}
+
+/**
+ * 根据主键查找
+ *
+ * 分片键就是主键时直接路由，否则分发到所有分片查找
+ */
+func (sr *ShardingRouter) FindById(id interface{}, entityType IDbEntity) (IDbEntity, error) {
+	if sr.isShardedByPrimaryKey(entityType) {
+		return sr.FindByIdWithShardKey(id, id, entityType)
+	}
+
+	results, err := sr.scatter(entityType, func(repo *BaseCrudRepository) ([]IDbEntity, error) {
+		entity, err := repo.FindById(id, entityType)
+		if err != nil || entity == nil {
+			return nil, err
+		}
+		return []IDbEntity{entity}, nil
+	})
+	if err != nil || len(results) == 0 {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+/**
+ * 根据主键和分片键查找（分片键不是主键时使用，避免 scatter）
+ */
+func (sr *ShardingRouter) FindByIdWithShardKey(id interface{}, shardKey interface{}, entityType IDbEntity) (IDbEntity, error) {
+	db, dbId, err := sr.RouteShardKey(shardKey, entityType)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	entity, err := NewBaseCrudRepository(db).FindById(id, entityType)
+	sr.record(dbId, true, time.Since(start), err)
+	return entity, err
+}
+
+/**
+ * 根据主键删除
+ *
+ * 分片键就是主键时直接路由，否则在所有分片执行删除
+ */
+func (sr *ShardingRouter) DeleteById(id interface{}, entityType IDbEntity) error {
+	if sr.isShardedByPrimaryKey(entityType) {
+		db, dbId, err := sr.RouteShardKey(id, entityType)
+		if err != nil {
+			return err
+		}
+		start := time.Now()
+		err = NewBaseCrudRepository(db).DeleteById(id, entityType)
+		sr.record(dbId, false, time.Since(start), err)
+		return err
+	}
+
+	_, err := sr.scatter(entityType, func(repo *BaseCrudRepository) ([]IDbEntity, error) {
+		return nil, repo.DeleteById(id, entityType)
+	})
+	return err
+}
+
+/**
+ * 按条件查询所有分片并合并结果（按 dbId 顺序）
+ */
+func (sr *ShardingRouter) FindByCondition(condition string, params []interface{}, entityType IDbEntity) ([]IDbEntity, error) {
+	return sr.scatter(entityType, func(repo *BaseCrudRepository) ([]IDbEntity, error) {
+		return repo.FindByCondition(condition, params, entityType)
+	})
+}
+
+/**
+ * 并发在所有分片执行操作并合并结果
+ */
+func (sr *ShardingRouter) scatter(entityType IDbEntity, fn func(repo *BaseCrudRepository) ([]IDbEntity, error)) ([]IDbEntity, error) {
+	if entityType == nil {
+		return nil, NewValidationException("实体类型不能为 nil")
+	}
+
+	dbIds := make([]int, 0, len(sr.dbGroup.DbMap))
+	for dbId := range sr.dbGroup.DbMap {
+		dbIds = append(dbIds, dbId)
+	}
+	sort.Ints(dbIds)
+
+	type shardResult struct {
+		entities []IDbEntity
+		err      error
+	}
+	results := make([]shardResult, len(dbIds))
+
+	var wg sync.WaitGroup
+	for i, dbId := range dbIds {
+		wg.Add(1)
+		go func(i int, dbId int) {
+			defer wg.Done()
+			start := time.Now()
+			entities, err := fn(NewBaseCrudRepository(sr.dbGroup.DbMap[dbId]))
+			sr.recordScatter(dbId, time.Since(start), err)
+			results[i] = shardResult{entities: entities, err: err}
+		}(i, dbId)
+	}
+	wg.Wait()
+
+	merged := make([]IDbEntity, 0)
+	for i, result := range results {
+		if result.err != nil {
+			return nil, NewQueryExceptionWithCause(result.err, fmt.Sprintf("分片查询失败: dbId=%d", dbIds[i]))
+		}
+		merged = append(merged, result.entities...)
+	}
+	return merged, nil
+}
+
+/**
+ * 判断分片键是否就是主键
+ */
+func (sr *ShardingRouter) isShardedByPrimaryKey(entityType IDbEntity) bool {
+	config, err := sr.GetShardKeyConfig(entityType)
+	if err != nil {
+		return false
+	}
+	return config.Column == GetCrudManagerInstance().GetPrimaryKeyColumnName(entityType)
+}
+
+/**
+ * 获取分片统计（创建时按需）
+ */
+func (sr *ShardingRouter) getMetrics(dbId int) *ShardMetrics {
+	metrics, exists := sr.metrics[dbId]
+	if !exists {
+		metrics = &ShardMetrics{DbId: dbId}
+		sr.metrics[dbId] = metrics
+	}
+	return metrics
+}
+
+/**
+ * 记录路由操作
+ */
+func (sr *ShardingRouter) record(dbId int, read bool, duration time.Duration, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	metrics := sr.getMetrics(dbId)
+	if read {
+		metrics.Reads++
+	} else {
+		metrics.Writes++
+	}
+	if err != nil {
+		metrics.Errors++
+	}
+	metrics.TotalTime += duration
+}
+
+/**
+ * 记录 scatter 操作
+ */
+func (sr *ShardingRouter) recordScatter(dbId int, duration time.Duration, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	metrics := sr.getMetrics(dbId)
+	metrics.ScatterQueries++
+	if err != nil {
+		metrics.Errors++
+	}
+	metrics.TotalTime += duration
+}
+
+/**
+ * 获取分片级统计
+ *
+ * @return map[int]map[string]interface{} dbId -> 指标
+ */
+func (sr *ShardingRouter) GetShardMetrics() map[int]map[string]interface{} {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+
+	result := make(map[int]map[string]interface{}, len(sr.metrics))
+	for dbId, metrics := range sr.metrics {
+		operations := metrics.Reads + metrics.Writes + metrics.ScatterQueries
+		var avgTime time.Duration
+		if operations > 0 {
+			avgTime = metrics.TotalTime / time.Duration(operations)
+		}
+		result[dbId] = map[string]interface{}{
+			"reads":           metrics.Reads,
+			"writes":          metrics.Writes,
+			"errors":          metrics.Errors,
+			"scatter_queries": metrics.ScatterQueries,
+			"avg_time_ms":     avgTime.Milliseconds(),
+		}
+	}
+	return result
+}
+
+/**
+ * 将分片键值转换为分片 ID
+ *
+ * 整数直接使用；数字字符串按数字解析；其他字符串使用 FNV 哈希
+ */
+func toShardingId(shardKey interface{}) (int64, error) {
+	if shardKey == nil {
+		return 0, NewValidationException("分片键不能为 nil")
+	}
+
+	v := reflect.ValueOf(shardKey)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	case reflect.String:
+		s := v.String()
+		if id, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return id, nil
+		}
+		h := fnv.New64a()
+		h.Write([]byte(s))
+		return int64(h.Sum64() & 0x7FFFFFFFFFFFFFFF), nil
+	default:
+		return 0, NewValidationException(fmt.Sprintf("不支持的分片键类型: %T", shardKey))
+	}
+}
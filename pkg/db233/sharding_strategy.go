@@ -71,3 +71,82 @@ func (s *ShardingDbStrategy100w) CalculateDbId(shardingId int64) int {
  * 单例实例
  */
 var ShardingDbStrategy100wInstance = &ShardingDbStrategy100w{}
+
+/**
+ * ShardingDbStrategyByHash - 取模分片策略
+ *
+ * 计算公式：dbId = |shardingId| % shardCount，例如 PlayerID % N
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type ShardingDbStrategyByHash struct {
+	ShardCount int
+}
+
+/**
+ * 创建取模分片策略
+ *
+ * @param shardCount 分片数量，<= 0 时视为 1
+ */
+func NewShardingDbStrategyByHash(shardCount int) *ShardingDbStrategyByHash {
+	if shardCount <= 0 {
+		shardCount = 1
+	}
+	return &ShardingDbStrategyByHash{ShardCount: shardCount}
+}
+
+/**
+ * 计算分片数据库 ID
+ *
+ * @param shardingId 分片键
+ * @return int 数据库 ID
+ */
+func (s *ShardingDbStrategyByHash) CalculateDbId(shardingId int64) int {
+	if s.ShardCount <= 1 {
+		return 0
+	}
+	// 按无符号取绝对值，math.MinInt64 取反不会溢出
+	magnitude := uint64(shardingId)
+	if shardingId < 0 {
+		magnitude = -magnitude
+	}
+	return int(magnitude % uint64(s.ShardCount))
+}
+
+/**
+ * ShardingDbStrategyByRange - 范围分片策略
+ *
+ * 按上界（不含）升序划分区间，例如 [1000, 5000] 表示：
+ * < 1000 → 0，[1000, 5000) → 1，>= 5000 → 2
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type ShardingDbStrategyByRange struct {
+	UpperBounds []int64
+}
+
+/**
+ * 创建范围分片策略
+ *
+ * @param upperBounds 各分片上界（不含），需升序
+ */
+func NewShardingDbStrategyByRange(upperBounds ...int64) *ShardingDbStrategyByRange {
+	return &ShardingDbStrategyByRange{UpperBounds: upperBounds}
+}
+
+/**
+ * 计算分片数据库 ID
+ *
+ * @param shardingId 分片键
+ * @return int 数据库 ID
+ */
+func (s *ShardingDbStrategyByRange) CalculateDbId(shardingId int64) int {
+	for i, upperBound := range s.UpperBounds {
+		if shardingId < upperBound {
+			return i
+		}
+	}
+	return len(s.UpperBounds)
+}
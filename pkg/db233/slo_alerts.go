@@ -0,0 +1,327 @@
+package db233
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+/**
+ * SLOEvaluator - SLO 告警评估器
+ *
+ * 通过 AlertManager.AddSLORules 为单个被监控的 Db 一次性生成 SLO 告警规则：
+ *   可用性       availability < AvailabilityTarget（1 小时窗口）
+ *   P99 延迟     latency_p99_ms > LatencyP99Target（性能监控器的时间窗口）
+ *   快速燃烧     burn_rate_1h > FastBurnThreshold（默认 14.4，约 2 天耗尽 30 天错误预算）
+ *   慢速燃烧     burn_rate_6h > SlowBurnThreshold（默认 6，约 5 天耗尽 30 天错误预算）
+ *
+ * 评估器定期对 PerformanceMonitor 的累计计数采样，按窗口做差得到错误率，
+ * 再以 slo.<name>.<指标> 的名称送入 AlertManager.CheckMetric
+ *
+ * 被监控的 Db 处于维护模式时，期间的请求计数从采样中扣除，且不送入告警
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type SLOEvaluator struct {
+	name         string
+	monitor      *PerformanceMonitor
+	alertManager *AlertManager
+	config       SLOConfig
+
+	samples     []sloSample
+	lastMetrics map[string]float64
+
+	// 维护期间累计的请求数，从采样中扣除
+	maintenanceTotal  int64
+	maintenanceFailed int64
+	lastRawTotal      int64
+	lastRawFailed     int64
+
+	mu       sync.RWMutex
+	running  bool
+	stopChan chan bool
+}
+
+/**
+ * SLOConfig - SLO 目标配置
+ */
+type SLOConfig struct {
+	// 可用性目标，如 0.999
+	AvailabilityTarget float64
+
+	// P99 延迟目标（查询截止时间）
+	LatencyP99Target time.Duration
+
+	// 错误预算燃烧率阈值
+	FastBurnThreshold float64
+	SlowBurnThreshold float64
+
+	// 采样与评估间隔
+	EvaluationInterval time.Duration
+}
+
+/**
+ * 计数采样点
+ */
+type sloSample struct {
+	timestamp time.Time
+	total     int64
+	failed    int64
+}
+
+/**
+ * SLO 燃烧率窗口
+ */
+const (
+	sloFastBurnWindow = time.Hour
+	sloSlowBurnWindow = 6 * time.Hour
+)
+
+/**
+ * 默认 SLO 配置：99.9% 可用性，P99 500ms
+ */
+func DefaultSLOConfig() SLOConfig {
+	return SLOConfig{
+		AvailabilityTarget: 0.999,
+		LatencyP99Target:   500 * time.Millisecond,
+		FastBurnThreshold:  14.4,
+		SlowBurnThreshold:  6,
+		EvaluationInterval: time.Minute,
+	}
+}
+
+/**
+ * 为被监控的 Db 添加 SLO 告警规则，返回评估器（调用 Start 开始定期评估）
+ */
+func (am *AlertManager) AddSLORules(name string, monitor *PerformanceMonitor, config SLOConfig) (*SLOEvaluator, error) {
+	if monitor == nil {
+		return nil, NewValidationException("性能监控器不能为 nil")
+	}
+	defaults := DefaultSLOConfig()
+	if config.AvailabilityTarget <= 0 || config.AvailabilityTarget >= 1 {
+		return nil, NewValidationException(fmt.Sprintf("可用性目标必须在 (0, 1) 之间: %v", config.AvailabilityTarget))
+	}
+	if config.LatencyP99Target <= 0 {
+		config.LatencyP99Target = defaults.LatencyP99Target
+	}
+	if config.FastBurnThreshold <= 0 {
+		config.FastBurnThreshold = defaults.FastBurnThreshold
+	}
+	if config.SlowBurnThreshold <= 0 {
+		config.SlowBurnThreshold = defaults.SlowBurnThreshold
+	}
+	if config.EvaluationInterval <= 0 {
+		config.EvaluationInterval = defaults.EvaluationInterval
+	}
+
+	cooldown := config.EvaluationInterval
+	rules := []AlertRule{
+		{
+			ID:          fmt.Sprintf("slo_%s_availability", name),
+			Name:        fmt.Sprintf("%s 可用性低于 SLO", name),
+			Description: fmt.Sprintf("1 小时可用性低于 %.3f%%", config.AvailabilityTarget*100),
+			Metric:      sloMetricName(name, "availability"),
+			Condition:   LessThan,
+			Threshold:   config.AvailabilityTarget,
+			Severity:    Error,
+		},
+		{
+			ID:          fmt.Sprintf("slo_%s_latency_p99", name),
+			Name:        fmt.Sprintf("%s P99 延迟超过 SLO", name),
+			Description: fmt.Sprintf("P99 延迟超过 %v", config.LatencyP99Target),
+			Metric:      sloMetricName(name, "latency_p99_ms"),
+			Condition:   GreaterThan,
+			Threshold:   float64(config.LatencyP99Target) / float64(time.Millisecond),
+			Severity:    Warning,
+		},
+		{
+			ID:          fmt.Sprintf("slo_%s_burn_rate_1h", name),
+			Name:        fmt.Sprintf("%s 错误预算快速燃烧", name),
+			Description: fmt.Sprintf("1 小时燃烧率超过 %v", config.FastBurnThreshold),
+			Metric:      sloMetricName(name, "burn_rate_1h"),
+			Condition:   GreaterThan,
+			Threshold:   config.FastBurnThreshold,
+			Severity:    Critical,
+		},
+		{
+			ID:          fmt.Sprintf("slo_%s_burn_rate_6h", name),
+			Name:        fmt.Sprintf("%s 错误预算慢速燃烧", name),
+			Description: fmt.Sprintf("6 小时燃烧率超过 %v", config.SlowBurnThreshold),
+			Metric:      sloMetricName(name, "burn_rate_6h"),
+			Condition:   GreaterThan,
+			Threshold:   config.SlowBurnThreshold,
+			Severity:    Error,
+		},
+	}
+	for _, rule := range rules {
+		rule.Cooldown = cooldown
+		rule.Enabled = true
+		rule.MaintenanceDb = monitor.db
+		am.AddAlertRule(rule)
+	}
+
+	return &SLOEvaluator{
+		name:         name,
+		monitor:      monitor,
+		alertManager: am,
+		config:       config,
+		samples:      make([]sloSample, 0),
+		lastMetrics:  make(map[string]float64),
+	}, nil
+}
+
+/**
+ * SLO 指标名：slo.<name>.<metric>
+ */
+func sloMetricName(name string, metric string) string {
+	return fmt.Sprintf("slo.%s.%s", name, metric)
+}
+
+/**
+ * 启动定期评估
+ */
+func (se *SLOEvaluator) Start() {
+	se.mu.Lock()
+	if se.running {
+		se.mu.Unlock()
+		return
+	}
+	se.running = true
+	se.stopChan = make(chan bool)
+	se.mu.Unlock()
+
+	LogInfo("SLO 评估器启动: %s, 间隔: %v", se.name, se.config.EvaluationInterval)
+
+	goSupervised("slo_evaluator:"+se.name, se.stopChan, func() {
+		ticker := time.NewTicker(se.config.EvaluationInterval)
+		defer ticker.Stop()
+
+		se.Evaluate(time.Now())
+		for {
+			select {
+			case now := <-ticker.C:
+				se.Evaluate(now)
+			case <-se.stopChan:
+				LogInfo("SLO 评估器停止: %s", se.name)
+				return
+			}
+		}
+	})
+}
+
+/**
+ * 停止定期评估
+ */
+func (se *SLOEvaluator) Stop() {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	if !se.running {
+		return
+	}
+	se.running = false
+	close(se.stopChan)
+}
+
+/**
+ * 采样并评估一次，返回本次计算的 SLO 指标
+ */
+func (se *SLOEvaluator) Evaluate(now time.Time) map[string]float64 {
+	se.monitor.mu.RLock()
+	rawTotal, rawFailed := se.monitor.totalQueries, se.monitor.failedQueries
+	p99 := se.monitor.windowStats.P99ResponseTime
+	se.monitor.mu.RUnlock()
+
+	inMaintenance := se.monitor.db.IsInMaintenance()
+
+	se.mu.Lock()
+	// 维护期间的请求计数不计入 SLO
+	if inMaintenance && len(se.samples) > 0 {
+		se.maintenanceTotal += rawTotal - se.lastRawTotal
+		se.maintenanceFailed += rawFailed - se.lastRawFailed
+	}
+	se.lastRawTotal, se.lastRawFailed = rawTotal, rawFailed
+	sample := sloSample{timestamp: now, total: rawTotal - se.maintenanceTotal, failed: rawFailed - se.maintenanceFailed}
+	se.samples = append(se.samples, sample)
+	// 保留覆盖最长窗口所需的采样点
+	cutoff := now.Add(-sloSlowBurnWindow - se.config.EvaluationInterval)
+	for len(se.samples) > 1 && se.samples[0].timestamp.Before(cutoff) {
+		se.samples = se.samples[1:]
+	}
+
+	errorBudget := 1 - se.config.AvailabilityTarget
+	errorRate1h := se.errorRate(now, sloFastBurnWindow)
+	errorRate6h := se.errorRate(now, sloSlowBurnWindow)
+	metrics := map[string]float64{
+		"availability":   1 - errorRate1h,
+		"latency_p99_ms": float64(p99) / float64(time.Millisecond),
+		"burn_rate_1h":   errorRate1h / errorBudget,
+		"burn_rate_6h":   errorRate6h / errorBudget,
+	}
+	se.lastMetrics = metrics
+	se.mu.Unlock()
+
+	if inMaintenance {
+		LogDebug("SLO 评估跳过告警（维护中）: %s", se.name)
+		return metrics
+	}
+	for metric, value := range metrics {
+		se.alertManager.CheckMetric(sloMetricName(se.name, metric), value)
+	}
+	return metrics
+}
+
+/**
+ * 计算窗口内的错误率（采样不足一个窗口时使用最早的采样点）
+ */
+func (se *SLOEvaluator) errorRate(now time.Time, window time.Duration) float64 {
+	latest := se.samples[len(se.samples)-1]
+	base := se.samples[0]
+	windowStart := now.Add(-window)
+	for _, sample := range se.samples {
+		if sample.timestamp.After(windowStart) {
+			break
+		}
+		base = sample
+	}
+
+	total := latest.total - base.total
+	failed := latest.failed - base.failed
+	if total <= 0 || failed <= 0 {
+		return 0
+	}
+	return float64(failed) / float64(total)
+}
+
+/**
+ * 获取最近一次评估的 SLO 指标
+ */
+func (se *SLOEvaluator) GetLastMetrics() map[string]float64 {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	result := make(map[string]float64, len(se.lastMetrics))
+	for k, v := range se.lastMetrics {
+		result[k] = v
+	}
+	return result
+}
+
+/**
+ * 获取评估器状态
+ */
+func (se *SLOEvaluator) GetStatus() map[string]interface{} {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	return map[string]interface{}{
+		"name":                se.name,
+		"running":             se.running,
+		"availability_target": se.config.AvailabilityTarget,
+		"latency_p99_target":  se.config.LatencyP99Target.String(),
+		"evaluation_interval": se.config.EvaluationInterval.String(),
+		"samples":             len(se.samples),
+		"in_maintenance":      se.monitor.db.IsInMaintenance(),
+		"last_metrics":        se.lastMetrics,
+	}
+}
@@ -0,0 +1,184 @@
+package db233
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/**
+ * SnowflakeIDGenerator - 雪花算法分布式 ID 生成器
+ *
+ * 64 位 ID 布局（最高位恒为 0）：
+ *   41 位  毫秒时间戳（相对 SnowflakeEpoch，约 69 年）
+ *   10 位  workerId（0-1023，每个节点唯一）
+ *   12 位  毫秒内序列号（每毫秒 4096 个）
+ *
+ * 时钟回拨时：回拨不超过 maxClockBackwards（默认 5ms）则等待追平，否则返回错误，
+ * 绝不生成重复 ID
+ *
+ * 可独立使用（NextId），也可通过 gen=snowflake 标签用于 Save 自动填充主键：
+ *
+ *   GetKeyGeneratorRegistryInstance().RegisterSnowflake(workerId)
+ *   ID int64 `db:"id,primary_key,gen=snowflake"`
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type SnowflakeIDGenerator struct {
+	workerId          int64
+	maxClockBackwards time.Duration
+
+	lastTimestamp int64
+	sequence      int64
+
+	// 当前时间（毫秒，相对 SnowflakeEpoch），测试时可替换
+	nowFunc func() int64
+
+	mu sync.Mutex
+}
+
+const (
+	snowflakeWorkerIdBits = 10
+	snowflakeSequenceBits = 12
+
+	SnowflakeMaxWorkerId = int64(-1) ^ (int64(-1) << snowflakeWorkerIdBits)
+	snowflakeMaxSequence = int64(-1) ^ (int64(-1) << snowflakeSequenceBits)
+
+	snowflakeWorkerIdShift  = snowflakeSequenceBits
+	snowflakeTimestampShift = snowflakeSequenceBits + snowflakeWorkerIdBits
+)
+
+/**
+ * 雪花 ID 时间起点：2024-01-01 00:00:00 UTC
+ */
+var SnowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+/**
+ * 创建雪花 ID 生成器
+ *
+ * @param workerId 节点 ID（0-1023）
+ */
+func NewSnowflakeIDGenerator(workerId int64) (*SnowflakeIDGenerator, error) {
+	if workerId < 0 || workerId > SnowflakeMaxWorkerId {
+		return nil, NewValidationException(fmt.Sprintf("workerId 必须在 [0, %d] 之间: %d", SnowflakeMaxWorkerId, workerId))
+	}
+	epochMillis := SnowflakeEpoch.UnixMilli()
+	return &SnowflakeIDGenerator{
+		workerId:          workerId,
+		maxClockBackwards: 5 * time.Millisecond,
+		lastTimestamp:     -1,
+		nowFunc: func() int64 {
+			return time.Now().UnixMilli() - epochMillis
+		},
+	}, nil
+}
+
+/**
+ * 设置可容忍的最大时钟回拨（超过时 NextId 返回错误）
+ */
+func (g *SnowflakeIDGenerator) SetMaxClockBackwards(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.maxClockBackwards = d
+}
+
+/**
+ * 获取节点 ID
+ */
+func (g *SnowflakeIDGenerator) GetWorkerId() int64 {
+	return g.workerId
+}
+
+/**
+ * 生成下一个 ID
+ */
+func (g *SnowflakeIDGenerator) NextId() (int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.nowFunc()
+	if now < g.lastTimestamp {
+		backwards := time.Duration(g.lastTimestamp-now) * time.Millisecond
+		if backwards > g.maxClockBackwards {
+			LogError("雪花 ID 时钟回拨过大: workerId=%d, 回拨=%v", g.workerId, backwards)
+			return 0, NewDb233Exception(fmt.Sprintf("时钟回拨 %v 超过允许的 %v，拒绝生成 ID", backwards, g.maxClockBackwards))
+		}
+		LogWarn("雪花 ID 时钟回拨，等待追平: workerId=%d, 回拨=%v", g.workerId, backwards)
+		now = g.waitUntil(g.lastTimestamp)
+	}
+
+	if now == g.lastTimestamp {
+		g.sequence = (g.sequence + 1) & snowflakeMaxSequence
+		if g.sequence == 0 {
+			// 本毫秒序列号用尽，等待下一毫秒
+			now = g.waitUntil(g.lastTimestamp + 1)
+		}
+	} else {
+		g.sequence = 0
+	}
+	if now >= int64(1)<<41 {
+		return 0, NewDb233Exception("雪花 ID 时间戳溢出（超过 SnowflakeEpoch 约 69 年）")
+	}
+
+	g.lastTimestamp = now
+	return now<<snowflakeTimestampShift | g.workerId<<snowflakeWorkerIdShift | g.sequence, nil
+}
+
+/**
+ * 等待直到时间不早于 target（毫秒）
+ */
+func (g *SnowflakeIDGenerator) waitUntil(target int64) int64 {
+	now := g.nowFunc()
+	for now < target {
+		time.Sleep(time.Duration(target-now) * time.Millisecond)
+		now = g.nowFunc()
+	}
+	return now
+}
+
+/**
+ * 生成字符串形式的 ID（实现 KeyGenerator）
+ */
+func (g *SnowflakeIDGenerator) Generate() (string, error) {
+	id, err := g.NextId()
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+/**
+ * 生成数值形式的 ID（实现 NumericKeyGenerator）
+ */
+func (g *SnowflakeIDGenerator) GenerateInt64() (int64, error) {
+	return g.NextId()
+}
+
+/**
+ * 解析雪花 ID
+ *
+ * @return time.Time 生成时间
+ * @return int64 节点 ID
+ * @return int64 序列号
+ */
+func ParseSnowflakeID(id int64) (time.Time, int64, int64) {
+	timestamp := id >> snowflakeTimestampShift
+	workerId := (id >> snowflakeWorkerIdShift) & SnowflakeMaxWorkerId
+	sequence := id & snowflakeMaxSequence
+	return SnowflakeEpoch.Add(time.Duration(timestamp) * time.Millisecond), workerId, sequence
+}
+
+/**
+ * 创建并注册名为 snowflake 的生成器（供 gen=snowflake 使用）
+ */
+func (r *KeyGeneratorRegistry) RegisterSnowflake(workerId int64) (*SnowflakeIDGenerator, error) {
+	generator, err := NewSnowflakeIDGenerator(workerId)
+	if err != nil {
+		return nil, err
+	}
+	r.Register("snowflake", generator)
+	LogInfo("雪花 ID 生成器已注册: workerId=%d", workerId)
+	return generator, nil
+}
@@ -0,0 +1,388 @@
+package db233
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/**
+ * SqlDialect - SQL 方言
+ *
+ * 集中处理各数据库在分页、UPSERT 等语法上的差异，Repository 的分页查询与 Upsert 统一经由方言生成 SQL：
+ *   MySQL / PostgreSQL   LIMIT n OFFSET m
+ *   SQL Server           SELECT TOP n / OFFSET m ROWS FETCH NEXT n ROWS ONLY（新增数据库类型时在此扩展）
+ *   MySQL                ON DUPLICATE KEY UPDATE col = VALUES(col)
+ *   PostgreSQL           ON CONFLICT (key) DO UPDATE SET col = EXCLUDED.col / DO NOTHING
+ *   SQL Server           MERGE ... WHEN MATCHED THEN UPDATE ... WHEN NOT MATCHED THEN INSERT ...
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type SqlDialect interface {
+	/**
+	 * 获取数据库类型
+	 */
+	GetDatabaseType() EnumDatabaseType
+
+	/**
+	 * 为查询追加分页子句
+	 *
+	 * @param query 查询 SQL（应已包含 ORDER BY）
+	 * @param limit 返回行数，<= 0 表示不限制
+	 * @param offset 跳过行数，<= 0 表示不跳过
+	 * @return string 带分页的 SQL
+	 */
+	ApplyLimitOffset(query string, limit int, offset int) string
+
+	/**
+	 * 为 INSERT 语句追加冲突处理子句
+	 *
+	 * @param insertSql INSERT INTO t (...) VALUES (...)
+	 * @param clause 冲突列、更新列与 DoNothing 模式
+	 * @return string 带冲突处理的 SQL
+	 */
+	ApplyUpsert(insertSql string, clause UpsertClause) string
+}
+
+/**
+ * UpsertClause - 冲突处理子句参数
+ */
+type UpsertClause struct {
+	// 冲突目标列（PostgreSQL 的 ON CONFLICT 目标；MySQL 按表上任一主键 / 唯一键冲突，忽略该项）
+	ConflictColumns []string
+
+	// 冲突时更新的列，值取自本次插入的值
+	UpdateColumns []string
+
+	// 冲突时不做任何修改
+	DoNothing bool
+
+	// 由数据库生成的自增主键列（可选），MySQL 下用于在冲突时仍能取回已有行的主键
+	AutoIncrementColumn string
+}
+
+/**
+ * MySQLDialect - MySQL 方言
+ */
+type MySQLDialect struct{}
+
+/**
+ * 获取数据库类型
+ */
+func (d *MySQLDialect) GetDatabaseType() EnumDatabaseType {
+	return EnumDatabaseTypeMySQL
+}
+
+/**
+ * 追加 LIMIT / OFFSET（MySQL 的 OFFSET 必须跟在 LIMIT 之后，只有 offset 时用最大行数占位）
+ */
+func (d *MySQLDialect) ApplyLimitOffset(query string, limit int, offset int) string {
+	if limit <= 0 && offset <= 0 {
+		return query
+	}
+	if limit <= 0 {
+		return query + " LIMIT 18446744073709551615 OFFSET " + strconv.Itoa(offset)
+	}
+	return appendLimitOffset(query, limit, offset)
+}
+
+/**
+ * 追加 ON DUPLICATE KEY UPDATE
+ *
+ * MySQL 没有 DO NOTHING，使用无副作用的自赋值代替（不同于 INSERT IGNORE，不会吞掉其他错误）；
+ * 有自增列时附加 col = LAST_INSERT_ID(col)，冲突时 LastInsertId 返回已有行的主键
+ */
+func (d *MySQLDialect) ApplyUpsert(insertSql string, clause UpsertClause) string {
+	updateParts := make([]string, 0, len(clause.UpdateColumns)+1)
+	if clause.AutoIncrementColumn != "" {
+		updateParts = append(updateParts, clause.AutoIncrementColumn+" = LAST_INSERT_ID("+clause.AutoIncrementColumn+")")
+	}
+	if !clause.DoNothing {
+		for _, col := range clause.UpdateColumns {
+			updateParts = append(updateParts, col+" = VALUES("+col+")")
+		}
+	}
+	if len(updateParts) == 0 {
+		noop := ""
+		if len(clause.ConflictColumns) > 0 {
+			noop = clause.ConflictColumns[0]
+		} else if len(clause.UpdateColumns) > 0 {
+			noop = clause.UpdateColumns[0]
+		}
+		if noop == "" {
+			return insertSql
+		}
+		updateParts = append(updateParts, noop+" = "+noop)
+	}
+	return insertSql + " ON DUPLICATE KEY UPDATE " + strings.Join(updateParts, ", ")
+}
+
+/**
+ * PostgreSQLDialect - PostgreSQL 方言
+ */
+type PostgreSQLDialect struct{}
+
+/**
+ * 获取数据库类型
+ */
+func (d *PostgreSQLDialect) GetDatabaseType() EnumDatabaseType {
+	return EnumDatabaseTypePostgreSQL
+}
+
+/**
+ * 追加 LIMIT / OFFSET（PostgreSQL 允许单独使用 OFFSET）
+ */
+func (d *PostgreSQLDialect) ApplyLimitOffset(query string, limit int, offset int) string {
+	if limit <= 0 && offset <= 0 {
+		return query
+	}
+	if limit <= 0 {
+		return query + " OFFSET " + strconv.Itoa(offset)
+	}
+	return appendLimitOffset(query, limit, offset)
+}
+
+/**
+ * 追加 ON CONFLICT (...) DO UPDATE SET / DO NOTHING（DO UPDATE 必须指定冲突列）
+ */
+func (d *PostgreSQLDialect) ApplyUpsert(insertSql string, clause UpsertClause) string {
+	target := ""
+	if len(clause.ConflictColumns) > 0 {
+		target = " (" + strings.Join(clause.ConflictColumns, ", ") + ")"
+	}
+	if clause.DoNothing || len(clause.UpdateColumns) == 0 {
+		return insertSql + " ON CONFLICT" + target + " DO NOTHING"
+	}
+	setParts := make([]string, len(clause.UpdateColumns))
+	for i, col := range clause.UpdateColumns {
+		setParts[i] = col + " = EXCLUDED." + col
+	}
+	return insertSql + " ON CONFLICT" + target + " DO UPDATE SET " + strings.Join(setParts, ", ")
+}
+
+/**
+ * SQLServerDialect - SQL Server 方言
+ */
+type SQLServerDialect struct{}
+
+var (
+	sqlServerSelectPattern = regexp.MustCompile(`(?is)^(\s*SELECT\s+(?:DISTINCT\s+)?)`)
+	sqlServerInsertPattern = regexp.MustCompile(`(?is)^\s*INSERT\s+INTO\s+(\S+)\s*\(([^)]*)\)\s*VALUES\s*\((.*)\)\s*$`)
+)
+
+/**
+ * 获取数据库类型
+ */
+func (d *SQLServerDialect) GetDatabaseType() EnumDatabaseType {
+	return EnumDatabaseTypeSQLServer
+}
+
+/**
+ * 追加分页
+ *
+ * 只有 limit 时改写为 SELECT TOP n；有 offset 时使用 OFFSET m ROWS FETCH NEXT n ROWS ONLY，
+ * 该语法要求 ORDER BY，查询未排序时补 ORDER BY (SELECT NULL)
+ */
+func (d *SQLServerDialect) ApplyLimitOffset(query string, limit int, offset int) string {
+	if limit <= 0 && offset <= 0 {
+		return query
+	}
+	if offset <= 0 && sqlServerSelectPattern.MatchString(query) {
+		return sqlServerSelectPattern.ReplaceAllString(query, "${1}TOP "+strconv.Itoa(limit)+" ")
+	}
+
+	var builder strings.Builder
+	builder.WriteString(query)
+	if !hasTopLevelOrderBy(query) {
+		builder.WriteString(" ORDER BY (SELECT NULL)")
+	}
+	builder.WriteString(" OFFSET ")
+	builder.WriteString(strconv.Itoa(offset))
+	builder.WriteString(" ROWS")
+	if limit > 0 {
+		builder.WriteString(" FETCH NEXT ")
+		builder.WriteString(strconv.Itoa(limit))
+		builder.WriteString(" ROWS ONLY")
+	}
+	return builder.String()
+}
+
+/**
+ * 将 INSERT 改写为 MERGE（HOLDLOCK 避免并发下的重复插入）
+ *
+ * 冲突列按本次插入的值匹配已有行：匹配时更新 UpdateColumns（DoNothing 时不更新），否则插入；
+ * 由数据库生成的 IDENTITY 列不在插入值中，不参与匹配。无法解析的语句原样返回
+ */
+func (d *SQLServerDialect) ApplyUpsert(insertSql string, clause UpsertClause) string {
+	match := sqlServerInsertPattern.FindStringSubmatch(insertSql)
+	if match == nil {
+		return insertSql
+	}
+	tableName := match[1]
+	columns := strings.Split(match[2], ",")
+	for i := range columns {
+		columns[i] = strings.TrimSpace(columns[i])
+	}
+
+	onParts := make([]string, 0, len(clause.ConflictColumns))
+	for _, col := range clause.ConflictColumns {
+		if containsColumn(columns, col) {
+			onParts = append(onParts, "target."+col+" = source."+col)
+		}
+	}
+	if len(onParts) == 0 {
+		return insertSql
+	}
+
+	sourceColumns := make([]string, len(columns))
+	for i, col := range columns {
+		sourceColumns[i] = "source." + col
+	}
+
+	var builder strings.Builder
+	builder.WriteString("MERGE INTO ")
+	builder.WriteString(tableName)
+	builder.WriteString(" WITH (HOLDLOCK) AS target USING (VALUES (")
+	builder.WriteString(match[3])
+	builder.WriteString(")) AS source (")
+	builder.WriteString(strings.Join(columns, ", "))
+	builder.WriteString(") ON ")
+	builder.WriteString(strings.Join(onParts, " AND "))
+	if !clause.DoNothing && len(clause.UpdateColumns) > 0 {
+		setParts := make([]string, len(clause.UpdateColumns))
+		for i, col := range clause.UpdateColumns {
+			setParts[i] = "target." + col + " = source." + col
+		}
+		builder.WriteString(" WHEN MATCHED THEN UPDATE SET ")
+		builder.WriteString(strings.Join(setParts, ", "))
+	}
+	builder.WriteString(" WHEN NOT MATCHED THEN INSERT (")
+	builder.WriteString(strings.Join(columns, ", "))
+	builder.WriteString(") VALUES (")
+	builder.WriteString(strings.Join(sourceColumns, ", "))
+	builder.WriteString(");")
+	return builder.String()
+}
+
+/**
+ * 为 INSERT / MERGE 追加 OUTPUT INSERTED.col（SQL Server 驱动不支持 LastInsertId，通过结果集取回 IDENTITY 值）
+ *
+ * INSERT 的 OUTPUT 子句位于 VALUES 之前，MERGE 的位于语句末尾的分号之前；无法识别的语句原样返回
+ */
+func (d *SQLServerDialect) applyOutputInserted(query string, column string) string {
+	output := " OUTPUT INSERTED." + column
+	if strings.HasPrefix(query, "MERGE ") && strings.HasSuffix(query, ";") {
+		return strings.TrimSuffix(query, ";") + output + ";"
+	}
+	if index := strings.Index(query, ") VALUES "); strings.HasPrefix(query, "INSERT ") && index >= 0 {
+		return query[:index+1] + output + query[index+1:]
+	}
+	return query
+}
+
+/**
+ * 判断查询最外层是否已有 ORDER BY（忽略括号内的子查询）
+ */
+func hasTopLevelOrderBy(query string) bool {
+	upper := strings.ToUpper(query)
+	index := strings.LastIndex(upper, "ORDER BY")
+	if index < 0 {
+		return false
+	}
+	return strings.Count(upper[index:], ")") <= strings.Count(upper[index:], "(")
+}
+
+/**
+ * 追加标准 LIMIT n [OFFSET m]
+ */
+func appendLimitOffset(query string, limit int, offset int) string {
+	var builder strings.Builder
+	builder.WriteString(query)
+	builder.WriteString(" LIMIT ")
+	builder.WriteString(strconv.Itoa(limit))
+	if offset > 0 {
+		builder.WriteString(" OFFSET ")
+		builder.WriteString(strconv.Itoa(offset))
+	}
+	return builder.String()
+}
+
+var sqlDialects = map[EnumDatabaseType]SqlDialect{
+	EnumDatabaseTypeMySQL:      &MySQLDialect{},
+	EnumDatabaseTypePostgreSQL: &PostgreSQLDialect{},
+	EnumDatabaseTypeSQLServer:  &SQLServerDialect{},
+}
+
+/**
+ * 获取数据库类型对应的方言（未知类型使用 MySQL）
+ */
+func GetSqlDialect(dbType EnumDatabaseType) SqlDialect {
+	if dialect, exists := sqlDialects[dbType]; exists {
+		return dialect
+	}
+	return sqlDialects[EnumDatabaseTypeMySQL]
+}
+
+/**
+ * 获取 Db 的方言
+ */
+func (db *Db) GetSqlDialect() SqlDialect {
+	return GetSqlDialect(db.DatabaseType)
+}
+
+/**
+ * 生成行值比较 (a, b) > (?, ?)，values 为右侧各列的值
+ *
+ * SQL Server 不支持行值构造器，按字典序展开为 (a > ? OR (a = ? AND b > ?))，参数随展开后的占位符重复
+ */
+func rowValueCompare(dialect SqlDialect, columns []string, operator string, values []interface{}) (string, []interface{}) {
+	if dialect.GetDatabaseType() != EnumDatabaseTypeSQLServer {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+		return "(" + strings.Join(columns, ", ") + ") " + operator + " (" + placeholders + ")", values
+	}
+
+	branches := make([]string, 0, len(columns))
+	params := make([]interface{}, 0, len(columns)*(len(columns)+1)/2)
+	for i, column := range columns {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, columns[j]+" = ?")
+			params = append(params, values[j])
+		}
+		parts = append(parts, column+" "+operator+" ?")
+		params = append(params, values[i])
+		branch := strings.Join(parts, " AND ")
+		if i > 0 {
+			branch = "(" + branch + ")"
+		}
+		branches = append(branches, branch)
+	}
+	return "(" + strings.Join(branches, " OR ") + ")", params
+}
+
+/**
+ * 生成多列 IN 条件 (a, b) IN ((?, ?), ...)，values 按行依次排列
+ *
+ * SQL Server 不支持行值构造器，展开为 ((a = ? AND b = ?) OR ...)
+ */
+func rowValueIn(dialect SqlDialect, columns []string, values []interface{}) string {
+	rows := len(values) / len(columns)
+	tuples := make([]string, rows)
+	if dialect.GetDatabaseType() == EnumDatabaseTypeSQLServer {
+		parts := make([]string, len(columns))
+		for i, column := range columns {
+			parts[i] = column + " = ?"
+		}
+		for i := range tuples {
+			tuples[i] = "(" + strings.Join(parts, " AND ") + ")"
+		}
+		return "(" + strings.Join(tuples, " OR ") + ")"
+	}
+
+	tuple := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	for i := range tuples {
+		tuples[i] = tuple
+	}
+	return "(" + strings.Join(columns, ", ") + ") IN (" + strings.Join(tuples, ", ") + ")"
+}
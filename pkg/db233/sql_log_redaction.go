@@ -0,0 +1,237 @@
+package db233
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+/**
+ * 默认的参数脱敏规则（列名通配，不区分大小写）
+ */
+var DefaultRedactionPatterns = []string{"*password*", "*passwd*", "*secret*", "*token*", "*credential*"}
+
+/**
+ * 脱敏后的参数占位文本
+ */
+const RedactedValue = "***"
+
+/**
+ * SqlLogRedactor - SQL 日志参数脱敏
+ *
+ * 根据 SQL 推断每个 ? 绑定的列名（INSERT 列列表、col = ? / col IN (?, ?) 等比较），
+ * 列名匹配任一规则的参数以 *** 代替；规则为 path.Match 风格通配（如 *password*）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type SqlLogRedactor struct {
+	patterns []string
+}
+
+/**
+ * 创建脱敏器
+ *
+ * @param patterns 列名通配规则
+ */
+func NewSqlLogRedactor(patterns ...string) *SqlLogRedactor {
+	redactor := &SqlLogRedactor{}
+	for _, pattern := range patterns {
+		redactor.AddPattern(pattern)
+	}
+	return redactor
+}
+
+/**
+ * 添加列名通配规则
+ */
+func (r *SqlLogRedactor) AddPattern(pattern string) *SqlLogRedactor {
+	r.patterns = append(r.patterns, strings.ToLower(pattern))
+	return r
+}
+
+/**
+ * 清空规则
+ */
+func (r *SqlLogRedactor) ClearPatterns() *SqlLogRedactor {
+	r.patterns = nil
+	return r
+}
+
+/**
+ * 列名是否需要脱敏（忽略表前缀与引号）
+ */
+func (r *SqlLogRedactor) Matches(column string) bool {
+	if column == "" {
+		return false
+	}
+	column = strings.ToLower(unquoteIdentifier(column))
+	if dot := strings.LastIndex(column, "."); dot >= 0 {
+		column = column[dot+1:]
+	}
+	for _, pattern := range r.patterns {
+		if matched, _ := path.Match(pattern, column); matched {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * 返回脱敏后的参数副本
+ */
+func (r *SqlLogRedactor) RedactParams(sql string, params []interface{}) []interface{} {
+	redacted := make([]interface{}, len(params))
+	copy(redacted, params)
+	if len(r.patterns) == 0 {
+		return redacted
+	}
+	for i, column := range placeholderColumns(sql, len(params)) {
+		if r.Matches(column) {
+			redacted[i] = RedactedValue
+		}
+	}
+	return redacted
+}
+
+/**
+ * 将参数内联到 SQL 中（已脱敏），用于日志
+ */
+func (r *SqlLogRedactor) InlineParams(sql string, params []interface{}) string {
+	redacted := r.RedactParams(sql, params)
+
+	var builder strings.Builder
+	index := 0
+	forEachSqlRune(sql, func(i int, ch rune, quoted bool) {
+		if ch == '?' && !quoted && index < len(redacted) {
+			builder.WriteString(formatSqlLiteral(redacted[index]))
+			index++
+			return
+		}
+		builder.WriteRune(ch)
+	})
+	return builder.String()
+}
+
+/**
+ * 按最大长度截断（按字符计，maxLength <= 0 不截断）
+ */
+func truncateForLog(text string, maxLength int) string {
+	if maxLength <= 0 || utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+	runes := []rune(text)
+	return fmt.Sprintf("%s...(已截断，共 %d 字符)", string(runes[:maxLength]), len(runes))
+}
+
+var (
+	insertColumnsPattern  = regexp.MustCompile(`(?is)^\s*(?:INSERT|REPLACE)\s+(?:IGNORE\s+)?INTO\s+\S+\s*\(([^)]*)\)\s*VALUES`)
+	comparedColumnPattern = regexp.MustCompile("(?i)([A-Za-z_][\\w.`\"]*)\\s*(?:=|<>|!=|<=|>=|<|>|\\s+LIKE|\\s+IN\\s*\\()\\s*$")
+	inListContinuePattern = regexp.MustCompile(`^\s*,\s*$`)
+	upsertClausePattern   = regexp.MustCompile(`(?i)\bON\s+(?:DUPLICATE\s+KEY\s+UPDATE|CONFLICT)\b`)
+)
+
+/**
+ * 推断每个占位符对应的列名（无法推断的为空字符串）
+ */
+func placeholderColumns(sql string, count int) []string {
+	columns := make([]string, 0, count)
+
+	var insertColumns []string
+	valuesStart := -1
+	if match := insertColumnsPattern.FindStringSubmatchIndex(sql); match != nil {
+		for _, column := range strings.Split(sql[match[2]:match[3]], ",") {
+			insertColumns = append(insertColumns, strings.TrimSpace(column))
+		}
+		valuesStart = match[1]
+	}
+	updateClauseStart := -1
+	if loc := upsertClausePattern.FindStringIndex(sql); loc != nil {
+		updateClauseStart = loc[0]
+	}
+
+	lastEnd := 0
+	previous := ""
+	insertIndex := 0
+	forEachSqlRune(sql, func(i int, ch rune, quoted bool) {
+		if ch != '?' || quoted || len(columns) >= count {
+			return
+		}
+		column := ""
+		between := sql[lastEnd:i]
+		switch {
+		case insertColumns != nil && i > valuesStart && (updateClauseStart < 0 || i < updateClauseStart):
+			// VALUES (?, ?, ?), (?, ?, ?) 按列顺序循环
+			column = insertColumns[insertIndex%len(insertColumns)]
+			insertIndex++
+		case lastEnd > 0 && previous != "" && inListContinuePattern.MatchString(between):
+			// IN (?, ?, ?) 的后续占位符
+			column = previous
+		default:
+			if match := comparedColumnPattern.FindStringSubmatch(sql[:i]); match != nil {
+				column = match[1]
+			}
+		}
+		columns = append(columns, column)
+		previous = column
+		lastEnd = i + 1
+	})
+	for len(columns) < count {
+		columns = append(columns, "")
+	}
+	return columns
+}
+
+/**
+ * 逐字符遍历 SQL，标记是否位于字符串字面量或引号标识符内
+ */
+func forEachSqlRune(sql string, fn func(i int, ch rune, quoted bool)) {
+	var quote rune
+	for i, ch := range sql {
+		switch {
+		case quote != 0:
+			fn(i, ch, true)
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+			fn(i, ch, true)
+		default:
+			fn(i, ch, false)
+		}
+	}
+}
+
+/**
+ * 去掉标识符两侧的引号
+ */
+func unquoteIdentifier(identifier string) string {
+	return strings.NewReplacer("`", "", `"`, "").Replace(strings.TrimSpace(identifier))
+}
+
+/**
+ * 参数格式化为 SQL 字面量（仅用于日志）
+ */
+func formatSqlLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case []byte:
+		return fmt.Sprintf("<%d bytes>", len(v))
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05.000") + "'"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
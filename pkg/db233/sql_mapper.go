@@ -0,0 +1,831 @@
+package db233
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+/**
+ * SqlMapper - MyBatis 风格的动态 SQL 映射（可选子系统，便于从 Java 迁移）
+ *
+ * 在 XML 或 YAML 中定义语句，动态片段与 MyBatis 一致：
+ *
+ *   <mapper namespace="OrderMapper">
+ *     <sql id="columns">id, user_id, amount, status</sql>
+ *     <select id="findByCondition" resultType="Order">
+ *       SELECT <include refid="columns"/> FROM orders
+ *       <where>
+ *         <if test="userId != null">AND user_id = #{userId}</if>
+ *         <if test="statuses != null and statuses.size() > 0">
+ *           AND status IN <foreach collection="statuses" item="s" open="(" separator="," close=")">#{s}</foreach>
+ *         </if>
+ *       </where>
+ *     </select>
+ *     <update id="updateStatus">
+ *       UPDATE orders <set><if test="status != null">status = #{status},</if></set> WHERE id = #{id}
+ *     </update>
+ *   </mapper>
+ *
+ * YAML 形式（sql 字段使用同样的标签；YAML 中 " #" 开始注释，含 #{} 的 sql 需使用块标量 |）：
+ *
+ *   namespace: OrderMapper
+ *   fragments:
+ *     columns: id, user_id, amount, status
+ *   statements:
+ *     findByCondition:
+ *       type: select
+ *       resultType: Order
+ *       sql: |
+ *         SELECT <include refid="columns"/> FROM orders
+ *         <where><if test="userId != null">AND user_id = #{userId}</if></where>
+ *
+ *   - 支持 <if>、<where>、<set>、<trim>、<foreach>、<choose>/<when>/<otherwise>、<include>
+ *   - #{name} 渲染为 ? 占位符；${name} 字符串拼接存在注入风险，加载时直接拒绝
+ *   - resultType 通过 RegisterResultType 映射到实体类型
+ *   - Bind 把语句绑定到结构体的函数字段，并在启动时按 EntityMetadata 校验参数与结果列映射
+ *
+ *   mapper := db233.NewSqlMapper()
+ *   mapper.RegisterResultType("Order", &Order{})
+ *   mapper.LoadDir("mappers")
+ *   var orders struct {
+ *       FindByCondition func(params map[string]interface{}) ([]*Order, error)
+ *       UpdateStatus    func(params UpdateStatusParams) (int64, error)
+ *   }
+ *   err := mapper.Bind(db, "OrderMapper", &orders)
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type SqlMapper struct {
+	// namespace.id -> 语句
+	statements map[string]*mapperStatement
+
+	// resultType 名称 -> 实体类型
+	resultTypes map[string]reflect.Type
+
+	mu sync.RWMutex
+}
+
+/**
+ * 解析后的映射语句
+ */
+type mapperStatement struct {
+	namespace  string
+	id         string
+	kind       string
+	resultType string
+	source     string
+	nodes      []*mapperNode
+}
+
+/**
+ * 动态 SQL 节点
+ */
+type mapperNode struct {
+	// 空字符串为文本节点
+	tag      string
+	text     string
+	attrs    map[string]string
+	test     mapperExpr
+	children []*mapperNode
+
+	// test 表达式引用的参数路径（用于启动校验）
+	testPaths []string
+}
+
+/**
+ * XML 映射文件结构
+ */
+type xmlMapperFile struct {
+	Namespace string               `xml:"namespace,attr"`
+	Fragments []xmlMapperStatement `xml:"sql"`
+	Selects   []xmlMapperStatement `xml:"select"`
+	Inserts   []xmlMapperStatement `xml:"insert"`
+	Updates   []xmlMapperStatement `xml:"update"`
+	Deletes   []xmlMapperStatement `xml:"delete"`
+}
+
+type xmlMapperStatement struct {
+	Id         string `xml:"id,attr"`
+	ResultType string `xml:"resultType,attr"`
+	Inner      string `xml:",innerxml"`
+}
+
+/**
+ * YAML 映射文件结构
+ */
+type yamlMapperFile struct {
+	Namespace  string                         `yaml:"namespace"`
+	Fragments  map[string]string              `yaml:"fragments"`
+	Statements map[string]yamlMapperStatement `yaml:"statements"`
+}
+
+type yamlMapperStatement struct {
+	Type       string `yaml:"type"`
+	ResultType string `yaml:"resultType"`
+	Sql        string `yaml:"sql"`
+}
+
+/**
+ * 识别的动态标签（false 表示 MyBatis 支持但此处不支持，加载时报错）
+ */
+var mapperDynamicTags = map[string]bool{
+	"if": true, "where": true, "set": true, "trim": true, "foreach": true,
+	"choose": true, "when": true, "otherwise": true, "include": true, "bind": false,
+}
+
+/**
+ * 创建 SQL 映射
+ */
+func NewSqlMapper() *SqlMapper {
+	return &SqlMapper{
+		statements:  make(map[string]*mapperStatement),
+		resultTypes: make(map[string]reflect.Type),
+	}
+}
+
+/**
+ * 注册 resultType 名称对应的实体类型
+ */
+func (m *SqlMapper) RegisterResultType(name string, entity interface{}) *SqlMapper {
+	entityType := reflect.TypeOf(entity)
+	for entityType.Kind() == reflect.Ptr {
+		entityType = entityType.Elem()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resultTypes[name] = entityType
+	return m
+}
+
+/**
+ * 加载目录下所有 .xml / .yaml / .yml 映射文件（按文件名排序，不递归）
+ */
+func (m *SqlMapper) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return NewConfigurationException(fmt.Sprintf("读取 Mapper 目录失败: %s, %v", dir, err))
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".xml", ".yaml", ".yml":
+			if !entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := m.LoadFile(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ * 加载单个映射文件（按扩展名区分 XML 与 YAML）
+ */
+func (m *SqlMapper) LoadFile(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return NewConfigurationException(fmt.Sprintf("读取 Mapper 文件失败: %s, %v", filePath, err))
+	}
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		return m.LoadYaml(filePath, data)
+	default:
+		return m.LoadXml(filePath, data)
+	}
+}
+
+/**
+ * 加载 XML 映射
+ *
+ * @param source 来源（用于错误信息）
+ * @param data 文件内容
+ */
+func (m *SqlMapper) LoadXml(source string, data []byte) error {
+	var file xmlMapperFile
+	if err := xml.Unmarshal(data, &file); err != nil {
+		return NewConfigurationException(fmt.Sprintf("解析 Mapper XML 失败: %s, %v", source, err))
+	}
+
+	fragments := make(map[string]string, len(file.Fragments))
+	for _, fragment := range file.Fragments {
+		fragments[fragment.Id] = fragment.Inner
+	}
+	raws := make([]rawMapperStatement, 0)
+	for kind, list := range map[string][]xmlMapperStatement{
+		"select": file.Selects, "insert": file.Inserts, "update": file.Updates, "delete": file.Deletes,
+	} {
+		for _, statement := range list {
+			raws = append(raws, rawMapperStatement{id: statement.Id, kind: kind, resultType: statement.ResultType, body: statement.Inner})
+		}
+	}
+	return m.register(source, file.Namespace, fragments, raws)
+}
+
+/**
+ * 加载 YAML 映射（type 省略时按 SQL 首个关键字判断）
+ */
+func (m *SqlMapper) LoadYaml(source string, data []byte) error {
+	var file yamlMapperFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return NewConfigurationException(fmt.Sprintf("解析 Mapper YAML 失败: %s, %v", source, err))
+	}
+
+	raws := make([]rawMapperStatement, 0, len(file.Statements))
+	for id, statement := range file.Statements {
+		kind := strings.ToLower(statement.Type)
+		if kind == "" {
+			kind = "update"
+			if isQuerySql(statement.Sql) {
+				kind = "select"
+			}
+		}
+		raws = append(raws, rawMapperStatement{id: id, kind: kind, resultType: statement.ResultType, body: statement.Sql})
+	}
+	return m.register(source, file.Namespace, file.Fragments, raws)
+}
+
+/**
+ * 未解析的语句
+ */
+type rawMapperStatement struct {
+	id         string
+	kind       string
+	resultType string
+	body       string
+}
+
+/**
+ * 解析并注册一个命名空间的语句（任一语句解析失败或重名时整个文件不生效）
+ */
+func (m *SqlMapper) register(source string, namespace string, fragments map[string]string, raws []rawMapperStatement) error {
+	if namespace == "" {
+		return NewConfigurationException(fmt.Sprintf("Mapper 缺少 namespace: %s", source))
+	}
+
+	parsed := make([]*mapperStatement, 0, len(raws))
+	seen := make(map[string]bool, len(raws))
+	for _, raw := range raws {
+		if seen[raw.id] {
+			return NewConfigurationException(fmt.Sprintf("Mapper 语句重名: %s.%s (%s)", namespace, raw.id, source))
+		}
+		seen[raw.id] = true
+		switch raw.kind {
+		case "select", "insert", "update", "delete":
+		default:
+			return NewConfigurationException(fmt.Sprintf("Mapper 语句类型无效: %s.%s, type=%s", namespace, raw.id, raw.kind))
+		}
+		if raw.id == "" {
+			return NewConfigurationException(fmt.Sprintf("Mapper 语句缺少 id: %s (%s)", namespace, source))
+		}
+		nodes, err := parseMapperNodes(raw.body, fragments, nil)
+		if err != nil {
+			return NewConfigurationException(fmt.Sprintf("Mapper 语句解析失败: %s.%s, %v", namespace, raw.id, err))
+		}
+		parsed = append(parsed, &mapperStatement{
+			namespace:  namespace,
+			id:         raw.id,
+			kind:       raw.kind,
+			resultType: raw.resultType,
+			source:     source,
+			nodes:      nodes,
+		})
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, statement := range parsed {
+		if existing, exists := m.statements[statement.fullId()]; exists && existing.source != source {
+			return NewConfigurationException(fmt.Sprintf("Mapper 语句重名: %s（%s 与 %s）", statement.fullId(), existing.source, source))
+		}
+	}
+	for _, statement := range parsed {
+		m.statements[statement.fullId()] = statement
+	}
+	LogDebug("Mapper 已加载: %s, namespace=%s, 语句数=%d", source, namespace, len(parsed))
+	return nil
+}
+
+func (s *mapperStatement) fullId() string {
+	return s.namespace + "." + s.id
+}
+
+/**
+ * 获取所有语句 ID（namespace.id，排序）
+ */
+func (m *SqlMapper) GetStatementIds() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ids := make([]string, 0, len(m.statements))
+	for id := range m.statements {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+/**
+ * 渲染语句
+ *
+ * @param statementId namespace.id
+ * @param params 参数（map 或结构体，nil 表示无参数）
+ * @return *SqlStatement Params 为按顺序收集的参数；resultType 已注册时设置 ReturnType
+ */
+func (m *SqlMapper) GetStatement(statementId string, params interface{}) (*SqlStatement, error) {
+	m.mu.RLock()
+	statement, exists := m.statements[statementId]
+	var resultType reflect.Type
+	if exists {
+		resultType = m.resultTypes[statement.resultType]
+	}
+	m.mu.RUnlock()
+	if !exists {
+		return nil, NewValidationException(fmt.Sprintf("Mapper 语句不存在: %s", statementId))
+	}
+
+	var builder strings.Builder
+	args := make([]interface{}, 0)
+	if err := renderMapperNodes(statement, statement.nodes, &mapperScope{params: params}, &builder, &args); err != nil {
+		return nil, err
+	}
+	result := &SqlStatement{
+		IsQuery:      statement.kind == "select",
+		IsAutoCommit: true,
+		SqlList:      []string{collapseMapperWhitespace(builder.String())},
+		Params:       args,
+	}
+	if resultType != nil {
+		result.ReturnType = reflect.New(resultType).Interface()
+	}
+	return result, nil
+}
+
+/**
+ * 执行查询语句
+ *
+ * @param returnType 结果类型，nil 时使用语句的 resultType
+ */
+func (m *SqlMapper) Select(db *Db, statementId string, params interface{}, returnType interface{}) ([]interface{}, error) {
+	statement, err := m.GetStatement(statementId, params)
+	if err != nil {
+		return nil, err
+	}
+	if returnType == nil {
+		returnType = statement.ReturnType
+	}
+	if returnType == nil {
+		return nil, NewValidationException(fmt.Sprintf("Mapper 语句 %s 未指定 resultType", statementId))
+	}
+	return db.ExecuteQueryWithTimeout(statement.SqlList[0], statement.Params, returnType, 0)
+}
+
+/**
+ * 执行 insert / update / delete 语句
+ */
+func (m *SqlMapper) Exec(db *Db, statementId string, params interface{}) (int64, error) {
+	statement, err := m.GetStatement(statementId, params)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := db.ExecuteUpdateWithTimeout(statement.SqlList[0], statement.Params, 0)
+	return int64(affected), err
+}
+
+/**
+ * 解析语句体中的动态标签（其余文本原样保留，XML 实体与 CDATA 还原为文本）
+ *
+ * @param including 正在展开的 <include>，用于检测循环引用
+ */
+func parseMapperNodes(body string, fragments map[string]string, including []string) ([]*mapperNode, error) {
+	root := &mapperNode{tag: "root"}
+	stack := []*mapperNode{root}
+	var text strings.Builder
+	flushText := func() {
+		if text.Len() > 0 {
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, &mapperNode{text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(body); {
+		if strings.HasPrefix(body[i:], "<![CDATA[") {
+			end := strings.Index(body[i:], "]]>")
+			if end < 0 {
+				return nil, fmt.Errorf("CDATA 未闭合")
+			}
+			text.WriteString(body[i+len("<![CDATA[") : i+end])
+			i += end + len("]]>")
+			continue
+		}
+		if strings.HasPrefix(body[i:], "<!--") {
+			end := strings.Index(body[i:], "-->")
+			if end < 0 {
+				return nil, fmt.Errorf("注释未闭合")
+			}
+			i += end + len("-->")
+			continue
+		}
+		if body[i] == '&' {
+			replaced := false
+			for entity, value := range map[string]string{"&lt;": "<", "&gt;": ">", "&amp;": "&", "&quot;": "\"", "&apos;": "'"} {
+				if strings.HasPrefix(body[i:], entity) {
+					text.WriteString(value)
+					i += len(entity)
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				text.WriteByte('&')
+				i++
+			}
+			continue
+		}
+		if body[i] != '<' {
+			text.WriteByte(body[i])
+			i++
+			continue
+		}
+
+		closing := strings.HasPrefix(body[i:], "</")
+		nameStart := i + 1
+		if closing {
+			nameStart++
+		}
+		nameEnd := nameStart
+		for nameEnd < len(body) && isTemplateParamPart(body[nameEnd]) {
+			nameEnd++
+		}
+		tag := body[nameStart:nameEnd]
+		if _, known := mapperDynamicTags[tag]; !known {
+			// 普通的 < 比较符
+			text.WriteByte('<')
+			i++
+			continue
+		}
+		if !mapperDynamicTags[tag] {
+			return nil, fmt.Errorf("不支持的标签: <%s>", tag)
+		}
+		end := mapperTagEnd(body[nameEnd:])
+		if end < 0 {
+			return nil, fmt.Errorf("标签未闭合: <%s", tag)
+		}
+		rawAttrs := body[nameEnd : nameEnd+end]
+		i = nameEnd + end + 1
+		flushText()
+
+		if closing {
+			top := stack[len(stack)-1]
+			if top.tag != tag {
+				return nil, fmt.Errorf("标签不匹配: </%s>，期望 </%s>", tag, top.tag)
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		selfClosing := strings.HasSuffix(strings.TrimSpace(rawAttrs), "/")
+		attrs, err := parseMapperAttrs(strings.TrimSuffix(strings.TrimSpace(rawAttrs), "/"))
+		if err != nil {
+			return nil, fmt.Errorf("<%s> 属性解析失败: %v", tag, err)
+		}
+		node := &mapperNode{tag: tag, attrs: attrs}
+		if err := prepareMapperNode(node, fragments, including); err != nil {
+			return nil, err
+		}
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, node)
+		if !selfClosing && tag != "include" {
+			stack = append(stack, node)
+		}
+	}
+	flushText()
+	if len(stack) > 1 {
+		return nil, fmt.Errorf("标签未闭合: <%s>", stack[len(stack)-1].tag)
+	}
+	if err := checkMapperText(root.children); err != nil {
+		return nil, err
+	}
+	return root.children, nil
+}
+
+/**
+ * 标签结束的 > 位置（属性值中的 > 不算）
+ */
+func mapperTagEnd(rest string) int {
+	var quote byte
+	for i := 0; i < len(rest); i++ {
+		switch c := rest[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i
+		}
+	}
+	return -1
+}
+
+/**
+ * 编译 test 表达式、展开 <include>、检查必需属性
+ */
+func prepareMapperNode(node *mapperNode, fragments map[string]string, including []string) error {
+	switch node.tag {
+	case "if", "when":
+		source, exists := node.attrs["test"]
+		if !exists {
+			return fmt.Errorf("<%s> 缺少 test 属性", node.tag)
+		}
+		expr, paths, err := parseMapperExpr(source)
+		if err != nil {
+			return fmt.Errorf("<%s test=\"%s\"> 表达式无效: %v", node.tag, source, err)
+		}
+		node.test = expr
+		node.testPaths = paths
+	case "foreach":
+		if node.attrs["collection"] == "" {
+			return fmt.Errorf("<foreach> 缺少 collection 属性")
+		}
+	case "include":
+		refId := node.attrs["refid"]
+		fragment, exists := fragments[refId]
+		if !exists {
+			return fmt.Errorf("<include refid=\"%s\"> 引用的 <sql> 片段不存在", refId)
+		}
+		for _, id := range including {
+			if id == refId {
+				return fmt.Errorf("<include refid=\"%s\"> 循环引用", refId)
+			}
+		}
+		children, err := parseMapperNodes(fragment, fragments, append(including, refId))
+		if err != nil {
+			return err
+		}
+		node.children = children
+	}
+	return nil
+}
+
+/**
+ * 拒绝 ${} 字符串拼接
+ */
+func checkMapperText(nodes []*mapperNode) error {
+	for _, node := range nodes {
+		if node.tag == "" && strings.Contains(node.text, "${") {
+			return fmt.Errorf("不支持 ${} 字符串拼接（存在 SQL 注入风险），请改用 #{}")
+		}
+		if err := checkMapperText(node.children); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ * 解析 name="value" 形式的属性（值中的 XML 实体会被还原）
+ */
+func parseMapperAttrs(raw string) (map[string]string, error) {
+	attrs := make(map[string]string)
+	replacer := strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&", "&quot;", "\"", "&apos;", "'")
+	for raw = strings.TrimSpace(raw); raw != ""; raw = strings.TrimSpace(raw) {
+		eq := strings.IndexByte(raw, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("无效的属性: %s", raw)
+		}
+		name := strings.TrimSpace(raw[:eq])
+		rest := strings.TrimSpace(raw[eq+1:])
+		if rest == "" || (rest[0] != '"' && rest[0] != '\'') {
+			return nil, fmt.Errorf("属性 %s 缺少引号", name)
+		}
+		end := strings.IndexByte(rest[1:], rest[0])
+		if end < 0 {
+			return nil, fmt.Errorf("属性 %s 缺少结束引号", name)
+		}
+		attrs[name] = replacer.Replace(rest[1 : end+1])
+		raw = rest[end+2:]
+	}
+	return attrs, nil
+}
+
+/**
+ * 渲染节点
+ */
+func renderMapperNodes(statement *mapperStatement, nodes []*mapperNode, scope *mapperScope, builder *strings.Builder, args *[]interface{}) error {
+	for _, node := range nodes {
+		switch node.tag {
+		case "":
+			if err := renderMapperText(statement, node.text, scope, builder, args); err != nil {
+				return err
+			}
+		case "if":
+			if mapperTruthy(node.test.eval(scope)) {
+				if err := renderMapperNodes(statement, node.children, scope, builder, args); err != nil {
+					return err
+				}
+			}
+		case "choose":
+			for _, branch := range node.children {
+				if branch.tag == "otherwise" || (branch.tag == "when" && mapperTruthy(branch.test.eval(scope))) {
+					if err := renderMapperNodes(statement, branch.children, scope, builder, args); err != nil {
+						return err
+					}
+					break
+				}
+			}
+		case "where", "set", "trim":
+			var inner strings.Builder
+			if err := renderMapperNodes(statement, node.children, scope, &inner, args); err != nil {
+				return err
+			}
+			builder.WriteString(trimMapperClause(node, inner.String()))
+		case "foreach":
+			if err := renderMapperForeach(statement, node, scope, builder, args); err != nil {
+				return err
+			}
+		case "include":
+			if err := renderMapperNodes(statement, node.children, scope, builder, args); err != nil {
+				return err
+			}
+		default:
+			// <when> / <otherwise> 只在 <choose> 中生效
+		}
+	}
+	return nil
+}
+
+/**
+ * <where> / <set> / <trim>：去掉首尾多余的连接词，内容非空时加前缀
+ */
+func trimMapperClause(node *mapperNode, content string) string {
+	prefix, suffix := node.attrs["prefix"], node.attrs["suffix"]
+	prefixOverrides, suffixOverrides := node.attrs["prefixOverrides"], node.attrs["suffixOverrides"]
+	switch node.tag {
+	case "where":
+		prefix, prefixOverrides = "WHERE", "AND |OR |AND\n|OR\n|AND\t|OR\t"
+	case "set":
+		prefix, suffixOverrides = "SET", ","
+	}
+
+	content = strings.TrimSpace(content)
+	if prefixOverrides != "" {
+		for _, override := range strings.Split(prefixOverrides, "|") {
+			if override != "" && len(content) >= len(override) && strings.EqualFold(content[:len(override)], override) {
+				content = strings.TrimSpace(content[len(override):])
+				break
+			}
+		}
+	}
+	if suffixOverrides != "" {
+		for _, override := range strings.Split(suffixOverrides, "|") {
+			if override != "" && len(content) >= len(override) && strings.EqualFold(content[len(content)-len(override):], override) {
+				content = strings.TrimSpace(content[:len(content)-len(override)])
+				break
+			}
+		}
+	}
+	if content == "" {
+		return ""
+	}
+	return " " + prefix + " " + content + " " + suffix + " "
+}
+
+/**
+ * <foreach>：逐项绑定 item / index 并渲染，空集合不输出任何内容
+ */
+func renderMapperForeach(statement *mapperStatement, node *mapperNode, scope *mapperScope, builder *strings.Builder, args *[]interface{}) error {
+	collectionName := node.attrs["collection"]
+	collection, exists := scope.lookup(collectionName)
+	if !exists {
+		return NewValidationException(fmt.Sprintf("Mapper 语句 %s 缺少参数: %s", statement.fullId(), collectionName))
+	}
+	v := reflect.ValueOf(collection)
+	for v.IsValid() && v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() || (v.Kind() == reflect.Slice && v.IsNil()) {
+		return nil
+	}
+
+	type entry struct{ index, item interface{} }
+	entries := make([]entry, 0)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for k := 0; k < v.Len(); k++ {
+			entries = append(entries, entry{k, v.Index(k).Interface()})
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(a, b int) bool { return fmt.Sprint(keys[a].Interface()) < fmt.Sprint(keys[b].Interface()) })
+		for _, key := range keys {
+			entries = append(entries, entry{key.Interface(), v.MapIndex(key).Interface()})
+		}
+	default:
+		return NewValidationException(fmt.Sprintf("Mapper 语句 %s 的 foreach 参数不是集合: %s", statement.fullId(), collectionName))
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	item, index := node.attrs["item"], node.attrs["index"]
+	builder.WriteString(" " + node.attrs["open"])
+	for k, e := range entries {
+		if k > 0 {
+			builder.WriteString(node.attrs["separator"] + " ")
+		}
+		vars := make(map[string]interface{}, 2)
+		if item != "" {
+			vars[item] = e.item
+		}
+		if index != "" {
+			vars[index] = e.index
+		}
+		if err := renderMapperNodes(statement, node.children, &mapperScope{vars: vars, parent: scope}, builder, args); err != nil {
+			return err
+		}
+	}
+	builder.WriteString(node.attrs["close"] + " ")
+	return nil
+}
+
+/**
+ * 把文本中的 #{name} 替换为占位符（#{name,jdbcType=...} 的选项被忽略）
+ */
+func renderMapperText(statement *mapperStatement, text string, scope *mapperScope, builder *strings.Builder, args *[]interface{}) error {
+	for {
+		start := strings.Index(text, "#{")
+		if start < 0 {
+			builder.WriteString(text)
+			return nil
+		}
+		end := strings.IndexByte(text[start:], '}')
+		if end < 0 {
+			return NewValidationException(fmt.Sprintf("Mapper 语句 %s 的 #{ 未闭合", statement.fullId()))
+		}
+		builder.WriteString(text[:start])
+		path := mapperParamPath(text[start+2 : start+end])
+		value, exists := scope.lookup(path)
+		if !exists {
+			return NewValidationException(fmt.Sprintf("Mapper 语句 %s 缺少参数: %s", statement.fullId(), path))
+		}
+		builder.WriteByte('?')
+		*args = append(*args, value)
+		text = text[start+end+1:]
+	}
+}
+
+/**
+ * #{} 中的参数路径
+ */
+func mapperParamPath(placeholder string) string {
+	return strings.TrimSpace(strings.Split(placeholder, ",")[0])
+}
+
+/**
+ * 合并空白，字符串字面量内部保持原样
+ */
+func collapseMapperWhitespace(sql string) string {
+	var builder strings.Builder
+	pendingSpace := false
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			pendingSpace = builder.Len() > 0
+		case c == '\'' || c == '"' || c == '`':
+			if pendingSpace {
+				builder.WriteByte(' ')
+				pendingSpace = false
+			}
+			end := strings.IndexByte(sql[i+1:], c)
+			if end < 0 {
+				builder.WriteString(sql[i:])
+				return builder.String()
+			}
+			builder.WriteString(sql[i : i+end+2])
+			i += end + 1
+		default:
+			if pendingSpace {
+				builder.WriteByte(' ')
+				pendingSpace = false
+			}
+			builder.WriteByte(c)
+		}
+	}
+	return strings.TrimSuffix(builder.String(), ";")
+}
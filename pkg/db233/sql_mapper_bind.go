@@ -0,0 +1,442 @@
+package db233
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/**
+ * SqlMapper 方法绑定与启动校验
+ *
+ * Bind 按字段名（不区分大小写，或 mapper 标签）把函数字段绑定到同名语句，支持的签名：
+ *
+ *   func(params P) ([]T, error)    select，T 为实体结构体或其指针
+ *   func(params P) (T, error)      select 单行，无结果时返回零值，多行时返回错误
+ *   func(params P) (int64, error)  insert / update / delete，返回影响行数（也可为 int）
+ *   func(params P) error           insert / update / delete
+ *
+ * P 为 map[string]interface{}、结构体或结构体指针，也可以省略参数。绑定时校验：
+ *   - 每个函数字段都有对应语句，语句类型与返回值匹配
+ *   - P 为结构体时，#{}、test 表达式与 foreach 引用的参数都能在 P 中找到
+ *   - select 列表中的每一列（或别名）都能映射到 T 的字段（EntityMetadata）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+var (
+	mapperErrorType = reflect.TypeOf((*error)(nil)).Elem()
+
+	// expr AS alias，或 column alias
+	mapperAliasPattern         = regexp.MustCompile(`(?i)^(.*?)\s+as\s+([A-Za-z_][A-Za-z0-9_]*)$`)
+	mapperImplicitAliasPattern = regexp.MustCompile("^([A-Za-z0-9_.`\"]+)\\s+([A-Za-z_][A-Za-z0-9_]*)$")
+)
+
+/**
+ * 绑定命名空间下的语句到结构体的函数字段
+ *
+ * @param db 执行语句的 Db（可以是事务或优先级视图）
+ * @param namespace Mapper 命名空间
+ * @param target 结构体指针
+ */
+func (m *SqlMapper) Bind(db *Db, namespace string, target interface{}) error {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr || targetValue.Elem().Kind() != reflect.Struct {
+		return NewValidationException("Mapper 绑定目标必须是结构体指针")
+	}
+	targetValue = targetValue.Elem()
+	targetType := targetValue.Type()
+
+	bindings := make(map[int]reflect.Value)
+	for i := 0; i < targetType.NumField(); i++ {
+		field := targetType.Field(i)
+		if field.Type.Kind() != reflect.Func || field.PkgPath != "" {
+			continue
+		}
+		id := field.Tag.Get("mapper")
+		if id == "" {
+			id = field.Name
+		}
+		statement := m.findStatement(namespace, id)
+		if statement == nil {
+			return NewValidationException(fmt.Sprintf("Mapper 语句不存在: %s.%s（字段 %s）", namespace, id, field.Name))
+		}
+		fn, err := m.bindStatement(db, statement, field.Type)
+		if err != nil {
+			return NewValidationException(fmt.Sprintf("Mapper 绑定失败: %s -> %s, %v", field.Name, statement.fullId(), err))
+		}
+		bindings[i] = fn
+	}
+
+	// 全部校验通过后才赋值
+	for i, fn := range bindings {
+		targetValue.Field(i).Set(fn)
+	}
+	LogInfo("Mapper 已绑定: %s, 方法数=%d", namespace, len(bindings))
+	return nil
+}
+
+/**
+ * 校验所有语句：resultType 已注册，select 列表能映射到实体字段
+ */
+func (m *SqlMapper) Validate() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	problems := make([]string, 0)
+	for _, id := range sortedMapperIds(m.statements) {
+		statement := m.statements[id]
+		if statement.resultType == "" {
+			continue
+		}
+		entityType, registered := m.resultTypes[statement.resultType]
+		if !registered {
+			problems = append(problems, fmt.Sprintf("%s: resultType 未注册: %s", id, statement.resultType))
+			continue
+		}
+		if statement.kind != "select" {
+			continue
+		}
+		if err := validateMapperResultColumns(statement, entityType); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", id, err))
+		}
+	}
+	if len(problems) > 0 {
+		return NewValidationException("Mapper 校验失败: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+/**
+ * 按 id 查找语句（不区分大小写）
+ */
+func (m *SqlMapper) findStatement(namespace string, id string) *mapperStatement {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if statement, exists := m.statements[namespace+"."+id]; exists {
+		return statement
+	}
+	for _, statement := range m.statements {
+		if statement.namespace == namespace && strings.EqualFold(statement.id, id) {
+			return statement
+		}
+	}
+	return nil
+}
+
+/**
+ * 校验函数签名并生成绑定函数
+ */
+func (m *SqlMapper) bindStatement(db *Db, statement *mapperStatement, fnType reflect.Type) (reflect.Value, error) {
+	if fnType.NumIn() > 1 || fnType.IsVariadic() {
+		return reflect.Value{}, fmt.Errorf("最多一个参数")
+	}
+	if fnType.NumIn() == 1 {
+		paramType := fnType.In(0)
+		for paramType.Kind() == reflect.Ptr {
+			paramType = paramType.Elem()
+		}
+		switch {
+		case paramType.Kind() == reflect.Map && paramType.Key().Kind() == reflect.String:
+		case paramType.Kind() == reflect.Struct:
+			if err := validateMapperParams(statement, paramType); err != nil {
+				return reflect.Value{}, err
+			}
+		default:
+			return reflect.Value{}, fmt.Errorf("参数必须是 map[string]T 或结构体，实际为 %s", fnType.In(0))
+		}
+	}
+	if fnType.NumOut() == 0 || fnType.Out(fnType.NumOut()-1) != mapperErrorType || fnType.NumOut() > 2 {
+		return reflect.Value{}, fmt.Errorf("最后一个返回值必须是 error")
+	}
+
+	statementId := statement.fullId()
+	paramsOf := func(in []reflect.Value) interface{} {
+		if len(in) == 0 {
+			return nil
+		}
+		return in[0].Interface()
+	}
+
+	if statement.kind != "select" {
+		if fnType.NumOut() == 2 && fnType.Out(0).Kind() != reflect.Int64 && fnType.Out(0).Kind() != reflect.Int {
+			return reflect.Value{}, fmt.Errorf("%s 语句只能返回 (int64, error) 或 error", statement.kind)
+		}
+		return reflect.MakeFunc(fnType, func(in []reflect.Value) []reflect.Value {
+			affected, err := m.Exec(db, statementId, paramsOf(in))
+			if fnType.NumOut() == 1 {
+				return []reflect.Value{mapperErrorValue(err)}
+			}
+			return []reflect.Value{reflect.ValueOf(affected).Convert(fnType.Out(0)), mapperErrorValue(err)}
+		}), nil
+	}
+
+	if fnType.NumOut() != 2 {
+		return reflect.Value{}, fmt.Errorf("select 语句必须返回 ([]T, error) 或 (T, error)")
+	}
+	resultType := fnType.Out(0)
+	many := resultType.Kind() == reflect.Slice
+	elemType := resultType
+	if many {
+		elemType = resultType.Elem()
+	}
+	entityType := elemType
+	if entityType.Kind() == reflect.Ptr {
+		entityType = entityType.Elem()
+	}
+	if entityType.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("select 结果必须是实体结构体，实际为 %s", resultType)
+	}
+	m.mu.RLock()
+	registered, exists := m.resultTypes[statement.resultType]
+	m.mu.RUnlock()
+	if exists && registered != entityType {
+		return reflect.Value{}, fmt.Errorf("返回类型 %s 与 resultType %s 不一致", entityType, statement.resultType)
+	}
+	if err := validateMapperResultColumns(statement, entityType); err != nil {
+		return reflect.Value{}, err
+	}
+
+	returnType := reflect.New(entityType).Interface()
+	return reflect.MakeFunc(fnType, func(in []reflect.Value) []reflect.Value {
+		rows, err := m.Select(db, statementId, paramsOf(in), returnType)
+		if err != nil {
+			return []reflect.Value{reflect.Zero(resultType), mapperErrorValue(err)}
+		}
+		if !many && len(rows) > 1 {
+			err = NewQueryException(fmt.Sprintf("Mapper 语句 %s 期望最多一行，实际返回 %d 行", statementId, len(rows)))
+			return []reflect.Value{reflect.Zero(resultType), mapperErrorValue(err)}
+		}
+		converted := reflect.MakeSlice(reflect.SliceOf(elemType), 0, len(rows))
+		for _, row := range rows {
+			value := reflect.ValueOf(row)
+			if elemType.Kind() == reflect.Ptr {
+				pointer := reflect.New(entityType)
+				pointer.Elem().Set(value)
+				value = pointer
+			}
+			converted = reflect.Append(converted, value)
+		}
+		if many {
+			return []reflect.Value{converted, mapperErrorValue(nil)}
+		}
+		if converted.Len() == 0 {
+			return []reflect.Value{reflect.Zero(resultType), mapperErrorValue(nil)}
+		}
+		return []reflect.Value{converted.Index(0), mapperErrorValue(nil)}
+	}), nil
+}
+
+/**
+ * error 转为反射值（nil 保持为 error 类型的零值）
+ */
+func mapperErrorValue(err error) reflect.Value {
+	if err == nil {
+		return reflect.Zero(mapperErrorType)
+	}
+	return reflect.ValueOf(&err).Elem()
+}
+
+/**
+ * 校验语句引用的参数都能在参数结构体中找到
+ */
+func validateMapperParams(statement *mapperStatement, paramType reflect.Type) error {
+	missing := make([]string, 0)
+	reported := make(map[string]bool)
+	walkMapperParamPaths(statement.nodes, map[string]bool{}, func(path string) {
+		if !reported[path] && !mapperTypeHasPath(paramType, strings.Split(path, ".")) {
+			reported[path] = true
+			missing = append(missing, path)
+		}
+	})
+	if len(missing) > 0 {
+		return fmt.Errorf("参数类型 %s 缺少字段: %s", paramType, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+/**
+ * 遍历语句引用的参数路径（foreach 的 item / index 不计入）
+ */
+func walkMapperParamPaths(nodes []*mapperNode, locals map[string]bool, visit func(path string)) {
+	report := func(path string) {
+		if !locals[strings.Split(path, ".")[0]] {
+			visit(path)
+		}
+	}
+	for _, node := range nodes {
+		switch node.tag {
+		case "":
+			text := node.text
+			for start := strings.Index(text, "#{"); start >= 0; start = strings.Index(text, "#{") {
+				end := strings.IndexByte(text[start:], '}')
+				if end < 0 {
+					break
+				}
+				report(mapperParamPath(text[start+2 : start+end]))
+				text = text[start+end+1:]
+			}
+		case "foreach":
+			report(node.attrs["collection"])
+			inner := make(map[string]bool, len(locals)+2)
+			for name := range locals {
+				inner[name] = true
+			}
+			inner[node.attrs["item"]] = true
+			inner[node.attrs["index"]] = true
+			walkMapperParamPaths(node.children, inner, visit)
+			continue
+		}
+		for _, path := range node.testPaths {
+			report(path)
+		}
+		walkMapperParamPaths(node.children, locals, visit)
+	}
+}
+
+/**
+ * 参数类型中是否存在该路径（遇到 map、interface 等无法静态确定的类型时视为存在）
+ */
+func mapperTypeHasPath(t reflect.Type, segments []string) bool {
+	for _, segment := range segments {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return true
+		}
+		index, found := mapperFieldIndex(t, segment)
+		if !found {
+			return false
+		}
+		t = t.FieldByIndex(index).Type
+	}
+	return true
+}
+
+/**
+ * 校验 select 列表能映射到实体字段（含 * 或列表中有动态片段时跳过）
+ */
+func validateMapperResultColumns(statement *mapperStatement, entityType reflect.Type) error {
+	columns, ok := staticMapperSelectColumns(statement.nodes)
+	if !ok {
+		return nil
+	}
+	metadata, err := GetEntityMetadataCacheInstance().GetOrBuild(reflect.New(entityType).Interface())
+	if err != nil {
+		return err
+	}
+	unmapped := make([]string, 0)
+	for _, column := range columns {
+		if _, exists := metadata.ColumnToFieldIndex[column]; exists {
+			continue
+		}
+		if _, exists := metadata.FieldNameToColumn[column]; exists {
+			continue
+		}
+		unmapped = append(unmapped, column)
+	}
+	if len(unmapped) > 0 {
+		return fmt.Errorf("结果列无法映射到 %s 的字段: %s", entityType.Name(), strings.Join(unmapped, ", "))
+	}
+	return nil
+}
+
+/**
+ * 提取 SELECT 与 FROM 之间的静态列名（去掉表前缀，优先使用别名；无别名的表达式跳过）
+ */
+func staticMapperSelectColumns(nodes []*mapperNode) ([]string, bool) {
+	var builder strings.Builder
+	collectStaticMapperText(nodes, &builder)
+	sql := builder.String()
+	upper := strings.ToUpper(sql)
+	selectIndex := strings.Index(upper, "SELECT")
+	if selectIndex < 0 {
+		return nil, false
+	}
+
+	items := make([]string, 0)
+	depth, start := 0, selectIndex+len("SELECT")
+	for i := start; i < len(sql); i++ {
+		switch sql[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, sql[start:i])
+				start = i + 1
+			}
+		default:
+			if depth == 0 && strings.HasPrefix(upper[i:], "FROM") && i > 0 && isMapperSpace(sql[i-1]) &&
+				(i+4 == len(sql) || isMapperSpace(sql[i+4])) {
+				items = append(items, sql[start:i])
+				return mapperColumnNames(items)
+			}
+		}
+	}
+	return nil, false
+}
+
+/**
+ * 拼接静态文本直到第一个动态节点（<include> 展开），遇到动态节点时返回 true
+ */
+func collectStaticMapperText(nodes []*mapperNode, builder *strings.Builder) bool {
+	for _, node := range nodes {
+		switch node.tag {
+		case "":
+			builder.WriteString(node.text)
+		case "include":
+			if collectStaticMapperText(node.children, builder) {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func mapperColumnNames(items []string) ([]string, bool) {
+	columns := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if strings.HasPrefix(strings.ToUpper(item), "DISTINCT ") {
+			item = strings.TrimSpace(item[len("DISTINCT "):])
+		}
+		if strings.Contains(item, "*") && !strings.Contains(item, "(") {
+			return nil, false
+		}
+		if match := mapperAliasPattern.FindStringSubmatch(item); match != nil {
+			columns = append(columns, match[2])
+			continue
+		}
+		if match := mapperImplicitAliasPattern.FindStringSubmatch(item); match != nil {
+			columns = append(columns, match[2])
+			continue
+		}
+		if strings.ContainsAny(item, "( ") {
+			continue
+		}
+		if dot := strings.LastIndexByte(item, '.'); dot >= 0 {
+			item = item[dot+1:]
+		}
+		columns = append(columns, strings.Trim(item, "`\""))
+	}
+	return columns, true
+}
+
+func isMapperSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func sortedMapperIds(statements map[string]*mapperStatement) []string {
+	ids := make([]string, 0, len(statements))
+	for id := range statements {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
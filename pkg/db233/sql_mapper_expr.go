@@ -0,0 +1,444 @@
+package db233
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/**
+ * Mapper 条件表达式（<if test> / <when test>，OGNL 常用子集）
+ *
+ * 支持：
+ *   - 参数路径：status、user.id、item.name（map 键、结构体字段名或 db 标签列名）
+ *   - 字面量：null、true、false、数字、'字符串' 或 "字符串"
+ *   - 比较：== != > >= < <=（gt gte lt lte eq neq 同义），数字按数值比较，其余按字符串比较
+ *   - 逻辑：and or not（&& || ! 同义）与括号
+ *   - 方法：xxx.size()、xxx.length()、xxx.isEmpty()
+ *
+ * 单独的路径按"存在且非零值"求真，与 SqlTemplateManager 的条件块一致
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type mapperExpr interface {
+	eval(scope *mapperScope) interface{}
+}
+
+/**
+ * 表达式求值时的变量作用域（foreach 的 item / index 覆盖参数）
+ */
+type mapperScope struct {
+	vars   map[string]interface{}
+	params interface{}
+	parent *mapperScope
+}
+
+/**
+ * 按路径取值
+ */
+func (s *mapperScope) lookup(path string) (interface{}, bool) {
+	segments := strings.Split(path, ".")
+	for scope := s; scope != nil; scope = scope.parent {
+		if value, exists := scope.vars[segments[0]]; exists {
+			return resolveMapperPath(value, segments[1:])
+		}
+		if scope.parent == nil {
+			return resolveMapperPath(scope.params, segments)
+		}
+	}
+	return nil, false
+}
+
+/**
+ * 在 map / 结构体中逐级取值
+ */
+func resolveMapperPath(value interface{}, segments []string) (interface{}, bool) {
+	for _, segment := range segments {
+		v := reflect.ValueOf(value)
+		for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+			if v.IsNil() {
+				return nil, false
+			}
+			v = v.Elem()
+		}
+		if !v.IsValid() {
+			return nil, false
+		}
+		switch v.Kind() {
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return nil, false
+			}
+			item := v.MapIndex(reflect.ValueOf(segment).Convert(v.Type().Key()))
+			if !item.IsValid() {
+				return nil, false
+			}
+			value = item.Interface()
+		case reflect.Struct:
+			index, found := mapperFieldIndex(v.Type(), segment)
+			if !found {
+				return nil, false
+			}
+			value = v.FieldByIndex(index).Interface()
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+/**
+ * 按字段名（首字母不区分大小写）或 db 标签列名查找导出字段
+ */
+func mapperFieldIndex(t reflect.Type, name string) ([]int, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		if strings.EqualFold(field.Name, name) {
+			return field.Index, true
+		}
+		if column := strings.Split(field.Tag.Get("db"), ",")[0]; column != "" && column == name {
+			return field.Index, true
+		}
+	}
+	return nil, false
+}
+
+type mapperPathExpr struct {
+	path   string
+	method string
+}
+
+func (e *mapperPathExpr) eval(scope *mapperScope) interface{} {
+	value, exists := scope.lookup(e.path)
+	if e.method == "" {
+		if !exists {
+			return nil
+		}
+		return value
+	}
+	length := 0
+	if exists && value != nil {
+		v := reflect.ValueOf(value)
+		switch v.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map, reflect.String:
+			length = v.Len()
+		}
+	}
+	if e.method == "isEmpty" {
+		return length == 0
+	}
+	return int64(length)
+}
+
+type mapperLiteralExpr struct {
+	value interface{}
+}
+
+func (e *mapperLiteralExpr) eval(scope *mapperScope) interface{} {
+	return e.value
+}
+
+type mapperNotExpr struct {
+	operand mapperExpr
+}
+
+func (e *mapperNotExpr) eval(scope *mapperScope) interface{} {
+	return !mapperTruthy(e.operand.eval(scope))
+}
+
+type mapperLogicExpr struct {
+	and         bool
+	left, right mapperExpr
+}
+
+func (e *mapperLogicExpr) eval(scope *mapperScope) interface{} {
+	left := mapperTruthy(e.left.eval(scope))
+	if e.and {
+		return left && mapperTruthy(e.right.eval(scope))
+	}
+	return left || mapperTruthy(e.right.eval(scope))
+}
+
+type mapperCompareExpr struct {
+	op          string
+	left, right mapperExpr
+}
+
+func (e *mapperCompareExpr) eval(scope *mapperScope) interface{} {
+	left, right := e.left.eval(scope), e.right.eval(scope)
+	if isNilMapperValue(left) || isNilMapperValue(right) {
+		bothNil := isNilMapperValue(left) && isNilMapperValue(right)
+		switch e.op {
+		case "==":
+			return bothNil
+		case "!=":
+			return !bothNil
+		}
+		return false
+	}
+
+	var cmp int
+	leftNumber, leftOk := mapperNumber(left)
+	rightNumber, rightOk := mapperNumber(right)
+	if leftOk && rightOk {
+		switch {
+		case leftNumber < rightNumber:
+			cmp = -1
+		case leftNumber > rightNumber:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(fmt.Sprint(left), fmt.Sprint(right))
+	}
+
+	switch e.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	default:
+		return cmp <= 0
+	}
+}
+
+/**
+ * 表达式求真
+ */
+func mapperTruthy(value interface{}) bool {
+	if b, ok := value.(bool); ok {
+		return b
+	}
+	return !isZeroTemplateValue(value)
+}
+
+func isNilMapperValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+/**
+ * 数值参数转为 float64（字符串不转换）
+ */
+func mapperNumber(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+/**
+ * 表达式解析器
+ */
+type mapperExprParser struct {
+	tokens []string
+	pos    int
+
+	// 表达式引用的参数路径（用于启动校验）
+	paths []string
+}
+
+/**
+ * 解析条件表达式
+ */
+func parseMapperExpr(source string) (mapperExpr, []string, error) {
+	tokens, err := tokenizeMapperExpr(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	parser := &mapperExprParser{tokens: tokens}
+	expr, err := parser.parseOr()
+	if err != nil {
+		return nil, nil, err
+	}
+	if parser.pos < len(tokens) {
+		return nil, nil, fmt.Errorf("表达式多余的内容: %s", tokens[parser.pos])
+	}
+	return expr, parser.paths, nil
+}
+
+func (p *mapperExprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *mapperExprParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *mapperExprParser) parseOr() (mapperExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for token := p.peek(); token == "or" || token == "||"; token = p.peek() {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &mapperLogicExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *mapperExprParser) parseAnd() (mapperExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for token := p.peek(); token == "and" || token == "&&"; token = p.peek() {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &mapperLogicExpr{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *mapperExprParser) parseUnary() (mapperExpr, error) {
+	if token := p.peek(); token == "!" || token == "not" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &mapperNotExpr{operand: operand}, nil
+	}
+	return p.parseCompare()
+}
+
+var mapperCompareOperators = map[string]string{
+	"==": "==", "eq": "==", "!=": "!=", "neq": "!=",
+	">": ">", "gt": ">", ">=": ">=", "gte": ">=",
+	"<": "<", "lt": "<", "<=": "<=", "lte": "<=",
+}
+
+func (p *mapperExprParser) parseCompare() (mapperExpr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if op, ok := mapperCompareOperators[p.peek()]; ok {
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return &mapperCompareExpr{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *mapperExprParser) parsePrimary() (mapperExpr, error) {
+	token := p.next()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("表达式不完整")
+	case token == "(":
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("缺少右括号")
+		}
+		return expr, nil
+	case token == "null":
+		return &mapperLiteralExpr{}, nil
+	case token == "true" || token == "false":
+		return &mapperLiteralExpr{value: token == "true"}, nil
+	case token[0] == '\'' || token[0] == '"':
+		return &mapperLiteralExpr{value: token[1 : len(token)-1]}, nil
+	case token[0] == '-' || (token[0] >= '0' && token[0] <= '9'):
+		number, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的数字: %s", token)
+		}
+		return &mapperLiteralExpr{value: number}, nil
+	case isTemplateParamStart(token[0]):
+		path, method := token, ""
+		for _, candidate := range []string{"size", "length", "isEmpty"} {
+			if strings.HasSuffix(token, "."+candidate+"()") {
+				path, method = strings.TrimSuffix(token, "."+candidate+"()"), candidate
+			}
+		}
+		p.paths = append(p.paths, path)
+		return &mapperPathExpr{path: path, method: method}, nil
+	}
+	return nil, fmt.Errorf("无法识别: %s", token)
+}
+
+/**
+ * 词法分析
+ */
+func tokenizeMapperExpr(source string) ([]string, error) {
+	tokens := make([]string, 0)
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(source[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("字符串缺少结束引号")
+			}
+			tokens = append(tokens, source[i:i+end+2])
+			i += end + 2
+		case strings.ContainsRune("=!<>&|", rune(c)):
+			j := i + 1
+			for j < len(source) && strings.ContainsRune("=&|", rune(source[j])) {
+				j++
+			}
+			tokens = append(tokens, source[i:j])
+			i = j
+		default:
+			j := i
+			for j < len(source) && !strings.ContainsRune(" \t\r\n()=!<>&|'\"", rune(source[j])) {
+				j++
+			}
+			// 方法调用的括号属于路径
+			if strings.HasSuffix(source[i:j], "size") || strings.HasSuffix(source[i:j], "length") || strings.HasSuffix(source[i:j], "isEmpty") {
+				if strings.HasPrefix(source[j:], "()") && strings.Contains(source[i:j], ".") {
+					j += 2
+				}
+			}
+			tokens = append(tokens, source[i:j])
+			i = j
+		}
+	}
+	return tokens, nil
+}
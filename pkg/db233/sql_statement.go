@@ -34,6 +34,9 @@ type SqlStatement struct {
 
 	// ReturnType 返回结果的类型（用于 ORM 映射）
 	ReturnType interface{}
+
+	// Params 占位符参数（作用于 SqlList 中的每条语句），见 SqlTemplateManager.GetStatement
+	Params []interface{}
 }
 
 /**
@@ -0,0 +1,552 @@
+package db233
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * SqlTemplateManager - SQL 模板注册表
+ *
+ * 从 .sql 文件加载具名语句，手工调优的大段 SQL 不再写在 Go 字符串里。文件中每条语句以 "-- name:" 开头：
+ *
+ *   -- name: findOrdersByUser
+ *   -- 普通的整行 -- 注释会被去掉
+ *   SELECT * FROM orders
+ *   WHERE user_id = :userId
+ *   -- #if status
+ *     AND status = :status
+ *   -- #end
+ *   -- #if !includeDeleted
+ *     AND deleted_at IS NULL
+ *   -- #end
+ *   -- #if ids
+ *     AND id IN (:ids)
+ *   -- #end
+ *   ORDER BY created_at DESC
+ *
+ *   - :name 为具名参数，渲染为 ? 并按出现顺序收集参数；切片参数展开为 ?, ?, ?（用于 IN）
+ *   - -- #if name / -- #end 之间的行在参数存在且非零值（nil、空字符串、false、0、空切片视为零值）时保留，
+ *     !name 取反，可以嵌套；指令本身是 SQL 注释，模板文件可以直接在客户端中执行
+ *   - 字符串字面量中的 :xxx 与 PostgreSQL 的 :: 类型转换不会被当作参数
+ *
+ *   templates := db233.NewSqlTemplateManager()
+ *   templates.LoadDir("sql")
+ *   stmt, err := templates.GetStatement("findOrdersByUser", map[string]interface{}{"userId": 1, "status": "paid"})
+ *   stmt.ReturnType = &Order{}
+ *   orders := db.ExecuteQueryByStatement(stmt)
+ *
+ * 开发环境调用 StartHotReload 轮询已加载的文件，内容变化后自动重新解析（解析失败时保留原模板）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type SqlTemplateManager struct {
+	templates map[string]*sqlTemplate
+
+	// 已加载的文件（路径 -> 状态），热加载时轮询
+	files map[string]*sqlTemplateFile
+
+	mu       sync.RWMutex
+	stopChan chan bool
+	stopOnce sync.Once
+}
+
+/**
+ * 已加载的模板文件
+ */
+type sqlTemplateFile struct {
+	// 从 fs.FS 加载时非 nil，热加载时从该文件系统读取
+	fsys  fs.FS
+	hash  [sha256.Size]byte
+	names []string
+}
+
+/**
+ * 解析后的模板
+ */
+type sqlTemplate struct {
+	name    string
+	source  string
+	isQuery bool
+	nodes   []sqlTemplateNode
+}
+
+/**
+ * 模板节点：文本或条件块
+ */
+type sqlTemplateNode struct {
+	text      string
+	condition string
+	negate    bool
+	children  []sqlTemplateNode
+}
+
+var (
+	sqlTemplateNamePattern = regexp.MustCompile(`^--\s*name:\s*([A-Za-z0-9_.\-]+)\s*$`)
+	sqlTemplateIfPattern   = regexp.MustCompile(`^--\s*#if\s+(!?)\s*([A-Za-z_][A-Za-z0-9_]*)\s*$`)
+	sqlTemplateEndPattern  = regexp.MustCompile(`^--\s*#end\s*$`)
+)
+
+/**
+ * 创建 SQL 模板注册表
+ */
+func NewSqlTemplateManager() *SqlTemplateManager {
+	return &SqlTemplateManager{
+		templates: make(map[string]*sqlTemplate),
+		files:     make(map[string]*sqlTemplateFile),
+		stopChan:  make(chan bool),
+	}
+}
+
+/**
+ * 加载目录下的所有 .sql 文件（不递归，按文件名顺序）
+ */
+func (m *SqlTemplateManager) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return NewConfigurationException(fmt.Sprintf("读取 SQL 模板目录失败: %s, 错误=%v", dir, err))
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".sql") {
+			continue
+		}
+		if err := m.LoadFile(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ * 加载单个 .sql 文件（重复加载同一文件时替换该文件中的模板）
+ */
+func (m *SqlTemplateManager) LoadFile(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return NewConfigurationException(fmt.Sprintf("读取 SQL 模板文件失败: %s, 错误=%v", filePath, err))
+	}
+	return m.loadData(filePath, nil, data)
+}
+
+/**
+ * 从 fs.FS（如 go:embed）加载目录下的所有 .sql 文件
+ */
+func (m *SqlTemplateManager) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return NewConfigurationException(fmt.Sprintf("读取 SQL 模板目录失败: %s, 错误=%v", dir, err))
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(path.Ext(entry.Name()), ".sql") {
+			continue
+		}
+		filePath := path.Join(dir, entry.Name())
+		data, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return NewConfigurationException(fmt.Sprintf("读取 SQL 模板文件失败: %s, 错误=%v", filePath, err))
+		}
+		if err := m.loadData(filePath, fsys, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ * 解析文件内容并替换该文件原有的模板（任一模板解析失败或与其他文件重名时整个文件不生效）
+ */
+func (m *SqlTemplateManager) loadData(source string, fsys fs.FS, data []byte) error {
+	parsed, err := parseSqlTemplateFile(source, string(data))
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, template := range parsed {
+		if existing, exists := m.templates[template.name]; exists && existing.source != source {
+			return NewConfigurationException(fmt.Sprintf("SQL 模板重名: %s（%s 与 %s）", template.name, existing.source, source))
+		}
+	}
+	if previous, exists := m.files[source]; exists {
+		for _, name := range previous.names {
+			delete(m.templates, name)
+		}
+	}
+	names := make([]string, 0, len(parsed))
+	for _, template := range parsed {
+		m.templates[template.name] = template
+		names = append(names, template.name)
+	}
+	m.files[source] = &sqlTemplateFile{fsys: fsys, hash: sha256.Sum256(data), names: names}
+	LogDebug("SQL 模板已加载: %s, 语句=%v", source, names)
+	return nil
+}
+
+/**
+ * 渲染具名语句
+ *
+ * @param name 语句名
+ * @param params 具名参数
+ * @return *SqlStatement 单条语句，Params 为按顺序收集的参数，ReturnType 由调用方设置
+ */
+func (m *SqlTemplateManager) GetStatement(name string, params map[string]interface{}) (*SqlStatement, error) {
+	m.mu.RLock()
+	template, exists := m.templates[name]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, NewValidationException(fmt.Sprintf("SQL 模板不存在: %s", name))
+	}
+
+	var builder strings.Builder
+	args := make([]interface{}, 0)
+	if err := renderSqlTemplateNodes(template, template.nodes, params, &builder, &args); err != nil {
+		return nil, err
+	}
+	sql := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(builder.String()), ";"))
+	return &SqlStatement{
+		IsQuery:      template.isQuery,
+		IsAutoCommit: true,
+		SqlList:      []string{sql},
+		Params:       args,
+	}, nil
+}
+
+/**
+ * 是否存在具名语句
+ */
+func (m *SqlTemplateManager) Has(name string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, exists := m.templates[name]
+	return exists
+}
+
+/**
+ * 获取所有语句名（排序）
+ */
+func (m *SqlTemplateManager) GetNames() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.templates))
+	for name := range m.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/**
+ * 开始轮询已加载的文件，内容变化后重新解析（开发环境使用）
+ */
+func (m *SqlTemplateManager) StartHotReload(interval time.Duration) error {
+	if interval <= 0 {
+		return NewValidationException("SQL 模板轮询间隔必须大于 0")
+	}
+	goSupervised("sql_template_reload", m.stopChan, func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.ReloadChanged()
+			case <-m.stopChan:
+				LogInfo("SQL 模板热加载已停止")
+				return
+			}
+		}
+	})
+	LogInfo("SQL 模板热加载已启动: 文件数=%d, 轮询间隔=%v", len(m.files), interval)
+	return nil
+}
+
+/**
+ * 停止热加载
+ */
+func (m *SqlTemplateManager) StopHotReload() {
+	m.stopOnce.Do(func() {
+		close(m.stopChan)
+	})
+}
+
+/**
+ * 重新加载内容有变化的文件，返回重新加载成功的文件
+ */
+func (m *SqlTemplateManager) ReloadChanged() []string {
+	m.mu.RLock()
+	files := make(map[string]*sqlTemplateFile, len(m.files))
+	for source, file := range m.files {
+		files[source] = file
+	}
+	m.mu.RUnlock()
+
+	sources := make([]string, 0, len(files))
+	for source := range files {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	reloaded := make([]string, 0)
+	for _, source := range sources {
+		file := files[source]
+		var data []byte
+		var err error
+		if file.fsys != nil {
+			data, err = fs.ReadFile(file.fsys, source)
+		} else {
+			data, err = os.ReadFile(source)
+		}
+		if err != nil {
+			LogWarn("读取 SQL 模板文件失败，保留当前模板: %s, 错误=%v", source, err)
+			continue
+		}
+		if sha256.Sum256(data) == file.hash {
+			continue
+		}
+		if err := m.loadData(source, file.fsys, data); err != nil {
+			LogError("SQL 模板热加载失败，保留当前模板: %v", err)
+			continue
+		}
+		LogInfo("SQL 模板已重新加载: %s", source)
+		reloaded = append(reloaded, source)
+	}
+	return reloaded
+}
+
+/**
+ * 解析文件：按 "-- name:" 切分语句
+ */
+func parseSqlTemplateFile(source string, content string) ([]*sqlTemplate, error) {
+	templates := make([]*sqlTemplate, 0)
+	seen := make(map[string]bool)
+	var name string
+	var body []string
+
+	flush := func() error {
+		if name == "" {
+			return nil
+		}
+		nodes, err := parseSqlTemplateNodes(body)
+		if err != nil {
+			return NewConfigurationException(fmt.Sprintf("SQL 模板解析失败: %s（%s）: %v", name, source, err))
+		}
+		first := firstSqlTemplateText(nodes)
+		if first == "" {
+			return NewConfigurationException(fmt.Sprintf("SQL 模板为空: %s（%s）", name, source))
+		}
+		templates = append(templates, &sqlTemplate{name: name, source: source, isQuery: isQuerySql(first), nodes: nodes})
+		return nil
+	}
+
+	for lineNo, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if match := sqlTemplateNamePattern.FindStringSubmatch(trimmed); match != nil {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			name, body = match[1], nil
+			if seen[name] {
+				return nil, NewConfigurationException(fmt.Sprintf("SQL 模板重名: %s（%s）", name, source))
+			}
+			seen[name] = true
+			continue
+		}
+		if name == "" {
+			if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				return nil, NewConfigurationException(fmt.Sprintf("SQL 模板文件第 %d 行不属于任何语句，缺少 -- name: 声明（%s）", lineNo+1, source))
+			}
+			continue
+		}
+		body = append(body, line)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+/**
+ * 按 -- #if / -- #end 指令把语句行组织为节点树，去掉空行与普通注释行
+ */
+func parseSqlTemplateNodes(lines []string) ([]sqlTemplateNode, error) {
+	root := &sqlTemplateNode{}
+	stack := []*sqlTemplateNode{root}
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		current := stack[len(stack)-1]
+		if match := sqlTemplateIfPattern.FindStringSubmatch(trimmed); match != nil {
+			current.children = append(current.children, sqlTemplateNode{condition: match[2], negate: match[1] == "!"})
+			stack = append(stack, &current.children[len(current.children)-1])
+			continue
+		}
+		if sqlTemplateEndPattern.MatchString(trimmed) {
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("多余的 -- #end")
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.children = append(current.children, sqlTemplateNode{text: line})
+	}
+	if len(stack) > 1 {
+		return nil, fmt.Errorf("条件块 %s 缺少 -- #end", stack[len(stack)-1].condition)
+	}
+	return root.children, nil
+}
+
+/**
+ * 第一行文本（用于判断语句类型）
+ */
+func firstSqlTemplateText(nodes []sqlTemplateNode) string {
+	for _, node := range nodes {
+		if node.condition == "" {
+			return strings.TrimSpace(node.text)
+		}
+		if text := firstSqlTemplateText(node.children); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+/**
+ * 渲染节点
+ */
+func renderSqlTemplateNodes(template *sqlTemplate, nodes []sqlTemplateNode, params map[string]interface{}, builder *strings.Builder, args *[]interface{}) error {
+	for _, node := range nodes {
+		if node.condition == "" {
+			if builder.Len() > 0 {
+				builder.WriteByte('\n')
+			}
+			if err := renderSqlTemplateText(template, node.text, params, builder, args); err != nil {
+				return err
+			}
+			continue
+		}
+		value, exists := params[node.condition]
+		if (exists && !isZeroTemplateValue(value)) != node.negate {
+			if err := renderSqlTemplateNodes(template, node.children, params, builder, args); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/**
+ * 把文本中的 :name 替换为占位符，跳过字符串字面量、引号标识符与 :: 类型转换
+ */
+func renderSqlTemplateText(template *sqlTemplate, text string, params map[string]interface{}, builder *strings.Builder, args *[]interface{}) error {
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(text[i+1:], c)
+			if end < 0 {
+				builder.WriteString(text[i:])
+				return nil
+			}
+			builder.WriteString(text[i : i+end+2])
+			i += end + 1
+		case c == ':' && i+1 < len(text) && text[i+1] == ':':
+			builder.WriteString("::")
+			i++
+		case c == ':' && i+1 < len(text) && isTemplateParamStart(text[i+1]):
+			j := i + 1
+			for j < len(text) && isTemplateParamPart(text[j]) {
+				j++
+			}
+			paramName := text[i+1 : j]
+			value, exists := params[paramName]
+			if !exists {
+				return NewValidationException(fmt.Sprintf("SQL 模板 %s 缺少参数: %s", template.name, paramName))
+			}
+			writeTemplatePlaceholders(value, builder, args)
+			i = j - 1
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return nil
+}
+
+/**
+ * 写入占位符：切片（[]byte 除外）展开为多个占位符
+ */
+func writeTemplatePlaceholders(value interface{}, builder *strings.Builder, args *[]interface{}) {
+	v := reflect.ValueOf(value)
+	if value != nil && v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		if v.Len() == 0 {
+			// 空列表渲染为 NULL，IN (NULL) 不匹配任何行
+			builder.WriteString("NULL")
+			return
+		}
+		for k := 0; k < v.Len(); k++ {
+			if k > 0 {
+				builder.WriteString(", ")
+			}
+			builder.WriteByte('?')
+			*args = append(*args, v.Index(k).Interface())
+		}
+		return
+	}
+	builder.WriteByte('?')
+	*args = append(*args, value)
+}
+
+/**
+ * 条件块的零值判断
+ */
+func isZeroTemplateValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
+
+func isTemplateParamStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isTemplateParamPart(c byte) bool {
+	return isTemplateParamStart(c) || (c >= '0' && c <= '9')
+}
+
+/**
+ * 是否为返回结果集的语句
+ */
+func isQuerySql(sql string) bool {
+	fields := strings.Fields(strings.TrimLeft(sql, "("))
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "SELECT", "WITH", "SHOW", "DESCRIBE", "DESC", "EXPLAIN", "VALUES":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,391 @@
+package db233
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+ * SQL Server 建表策略
+ *
+ * 标识符使用方括号，自增列使用 IDENTITY(1,1)，字符串使用 NVARCHAR（超过 4000 时为 NVARCHAR(MAX)），
+ * 表结构通过 INFORMATION_SCHEMA 查询（当前用户的默认 schema）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type SQLServerStrategy struct {
+	cm *CrudManager
+}
+
+/**
+ * 创建 SQL Server 策略实例
+ */
+func NewSQLServerStrategy(cm *CrudManager) *SQLServerStrategy {
+	return &SQLServerStrategy{cm: cm}
+}
+
+/**
+ * 获取数据库类型
+ */
+func (s *SQLServerStrategy) GetDatabaseType() EnumDatabaseType {
+	return EnumDatabaseTypeSQLServer
+}
+
+/**
+ * 生成建表 SQL（支持嵌入结构体）
+ */
+func (s *SQLServerStrategy) GenerateCreateTableSQL(tableName string, entityType reflect.Type, uidColumn string) (string, error) {
+	if tableName == "" {
+		return "", NewDb233Exception("无法获取表名")
+	}
+
+	var columns []string
+	var primaryKeys []string
+	var comments []string
+	s.collectFieldsForCreateTable(entityType, tableName, uidColumn, &columns, &primaryKeys, &comments)
+
+	if len(primaryKeys) > 0 {
+		columns = append(columns, fmt.Sprintf("CONSTRAINT [PK_%s] PRIMARY KEY (%s)", tableName, strings.Join(primaryKeys, ", ")))
+	}
+
+	for _, fk := range s.cm.GetForeignKeys(entityType, tableName) {
+		columns = append(columns, s.GenerateForeignKeyClause(fk))
+	}
+
+	if len(columns) == 0 {
+		return "", NewDb233Exception(fmt.Sprintf("表 %s 没有可用的列", tableName))
+	}
+
+	createSQL := fmt.Sprintf("CREATE TABLE [%s] (\n\t%s\n)", tableName, strings.Join(columns, ",\n\t"))
+	if len(comments) > 0 {
+		// 列注释写入 MS_Description 扩展属性，与建表语句同一批次执行
+		createSQL += ";\nDECLARE @schema sysname = SCHEMA_NAME();\n" + strings.Join(comments, "\n")
+	}
+
+	LogDebug("生成 SQL Server 建表SQL: 表=%s, SQL=%s", tableName, createSQL)
+	return createSQL, nil
+}
+
+/**
+ * 递归收集字段用于建表（支持嵌入结构体）
+ */
+func (s *SQLServerStrategy) collectFieldsForCreateTable(entityType reflect.Type, tableName string, uidColumn string, columns *[]string, primaryKeys *[]string, comments *[]string) {
+	for i := 0; i < entityType.NumField(); i++ {
+		field := entityType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				s.collectFieldsForCreateTable(embeddedType, tableName, uidColumn, columns, primaryKeys, comments)
+				continue
+			}
+		}
+
+		colName := s.cm.GetColumnName(field)
+		if colName == "" {
+			continue
+		}
+
+		isPrimaryKey := s.cm.IsPrimaryKey(field)
+		if uidColumn != "" && colName == uidColumn {
+			isPrimaryKey = true
+		}
+
+		*columns = append(*columns, s.columnDefinition(field, colName, isPrimaryKey))
+		if commentSQL := sqlServerColumnCommentSQL(tableName, colName, columnCommentTag(field)); commentSQL != "" {
+			*comments = append(*comments, commentSQL)
+		}
+
+		if isPrimaryKey {
+			*primaryKeys = append(*primaryKeys, fmt.Sprintf("[%s]", colName))
+		}
+	}
+}
+
+/**
+ * 生成列定义：[col] TYPE [IDENTITY(1,1)] NULL / NOT NULL [DEFAULT ...]
+ */
+func (s *SQLServerStrategy) columnDefinition(field reflect.StructField, colName string, isPrimaryKey bool) string {
+	colDef := fmt.Sprintf("[%s] %s", colName, s.GetSQLType(field))
+	if s.cm.IsAutoIncrement(field) {
+		colDef += " IDENTITY(1,1)"
+	}
+	// 默认允许为 NULL，除非明确标记为 not_null 或是主键
+	if strings.Contains(field.Tag.Get("db"), "not_null") || isPrimaryKey {
+		colDef += " NOT NULL"
+	} else {
+		colDef += " NULL"
+	}
+	return colDef + sqlServerColumnDefault(field)
+}
+
+/**
+ * 获取 SQL 类型
+ *
+ * SQL Server 没有无符号整数，无符号类型使用更宽的有符号类型；TINYINT 为 0~255，只用于 uint8
+ */
+func (s *SQLServerStrategy) GetSQLType(field reflect.StructField) string {
+	fieldType := field.Type
+
+	if dbTypeTag := field.Tag.Get("db_type"); dbTypeTag != "" {
+		// 没有原生 JSON 类型，JSON 文本存储在 NVARCHAR(MAX) 中
+		if strings.EqualFold(dbTypeTag, "JSON") {
+			return "NVARCHAR(MAX)"
+		}
+		return dbTypeTag
+	}
+	if typeTag := field.Tag.Get("type"); typeTag != "" {
+		return typeTag
+	}
+
+	// 加密字段存储密文，长度不固定
+	if IsEncryptedField(field) {
+		return "NVARCHAR(MAX)"
+	}
+
+	kind := fieldType.Kind()
+	if kind == reflect.Ptr {
+		fieldType = fieldType.Elem()
+		kind = fieldType.Kind()
+	}
+
+	// decimal 标签与 Decimal 字段使用 DECIMAL(p,s)
+	if sqlType := decimalColumnSQLType(field); sqlType != "" {
+		return sqlType
+	}
+
+	// 注册了类型转换器且声明了列类型的类型
+	if converter, exists := GetTypeConverterRegistryInstance().Get(fieldType); exists {
+		if sqlType := converter.GetSQLType(s.GetDatabaseType()); sqlType != "" {
+			return sqlType
+		}
+	}
+
+	// sql.NullString 等按其值类型建列
+	if valueType, ok := sqlNullValueType(fieldType); ok {
+		fieldType = valueType
+		kind = fieldType.Kind()
+	}
+
+	switch kind {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		// 复杂类型序列化为 JSON 存储
+		return "NVARCHAR(MAX)"
+	case reflect.Int, reflect.Int32:
+		return "INT"
+	case reflect.Int8, reflect.Int16:
+		return "SMALLINT"
+	case reflect.Int64:
+		return "BIGINT"
+	case reflect.Uint8:
+		return "TINYINT"
+	case reflect.Uint16:
+		return "INT"
+	case reflect.Uint, reflect.Uint32:
+		return "BIGINT"
+	case reflect.Uint64:
+		return "DECIMAL(20, 0)"
+	case reflect.Float32:
+		return "REAL"
+	case reflect.Float64:
+		return "FLOAT"
+	case reflect.String:
+		size := 255
+		if sizeTag := field.Tag.Get("size"); sizeTag != "" {
+			if s, err := strconv.Atoi(sizeTag); err == nil {
+				size = s
+			}
+		}
+		// NVARCHAR(n) 最大为 4000
+		if size > 4000 {
+			return "NVARCHAR(MAX)"
+		}
+		return fmt.Sprintf("NVARCHAR(%d)", size)
+	case reflect.Bool:
+		return "BIT"
+	case reflect.Struct:
+		if fieldType == reflect.TypeOf(time.Time{}) {
+			return "DATETIME2" + timeColumnPrecisionSuffix(field)
+		}
+		return "NVARCHAR(MAX)"
+	}
+
+	return "NVARCHAR(255)"
+}
+
+/**
+ * 检查表是否存在
+ */
+func (s *SQLServerStrategy) TableExists(db *Db, tableName string) (bool, error) {
+	query := "SELECT COUNT(*) FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = SCHEMA_NAME() AND TABLE_NAME = ?"
+	var count int
+	if err := db.DataSource.QueryRow(query, tableName).Scan(&count); err != nil {
+		return false, NewQueryExceptionWithCause(err, "检查表存在性失败")
+	}
+	return count > 0, nil
+}
+
+/**
+ * 获取现有表的列信息
+ */
+func (s *SQLServerStrategy) GetExistingColumns(db *Db, tableName string) (map[string]bool, error) {
+	query := "SELECT COLUMN_NAME FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = SCHEMA_NAME() AND TABLE_NAME = ?"
+	rows, err := db.DataSource.Query(query, tableName)
+	if err != nil {
+		return nil, NewQueryExceptionWithCause(err, "获取表列信息失败")
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var colName string
+		if err := rows.Scan(&colName); err != nil {
+			return nil, NewQueryExceptionWithCause(err, "扫描列名失败")
+		}
+		columns[colName] = true
+	}
+	return columns, nil
+}
+
+/**
+ * 获取表的所有列信息（类型带长度，如 nvarchar(255)、nvarchar(MAX)）
+ */
+func (s *SQLServerStrategy) GetTableColumns(db *Db, tableName string) (map[string]ColumnInfo, error) {
+	query := `
+		SELECT c.COLUMN_NAME, c.DATA_TYPE, c.CHARACTER_MAXIMUM_LENGTH, c.IS_NULLABLE, c.COLUMN_DEFAULT,
+			CASE WHEN pk.COLUMN_NAME IS NULL THEN 0 ELSE 1 END
+		FROM INFORMATION_SCHEMA.COLUMNS c
+		LEFT JOIN (
+			SELECT ku.TABLE_SCHEMA, ku.TABLE_NAME, ku.COLUMN_NAME
+			FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS tc
+			JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE ku
+				ON tc.CONSTRAINT_NAME = ku.CONSTRAINT_NAME AND tc.TABLE_SCHEMA = ku.TABLE_SCHEMA
+			WHERE tc.CONSTRAINT_TYPE = 'PRIMARY KEY'
+		) pk ON pk.TABLE_SCHEMA = c.TABLE_SCHEMA AND pk.TABLE_NAME = c.TABLE_NAME AND pk.COLUMN_NAME = c.COLUMN_NAME
+		WHERE c.TABLE_SCHEMA = SCHEMA_NAME() AND c.TABLE_NAME = ?
+		ORDER BY c.ORDINAL_POSITION
+	`
+
+	rows, err := db.DataSource.Query(query, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("查询表列信息失败: %w", err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]ColumnInfo)
+	for rows.Next() {
+		var colName, dataType, isNullable string
+		var maxLength sql.NullInt64
+		var columnDefault sql.NullString
+		var isPrimary int
+
+		if err := rows.Scan(&colName, &dataType, &maxLength, &isNullable, &columnDefault, &isPrimary); err != nil {
+			return nil, fmt.Errorf("扫描列信息失败: %w", err)
+		}
+
+		colType := dataType
+		if maxLength.Valid {
+			if maxLength.Int64 == -1 {
+				colType += "(MAX)"
+			} else {
+				colType += fmt.Sprintf("(%d)", maxLength.Int64)
+			}
+		}
+
+		info := ColumnInfo{
+			Name:       colName,
+			Type:       colType,
+			IsNullable: isNullable == "YES",
+			IsPrimary:  isPrimary == 1,
+		}
+		if columnDefault.Valid {
+			info.Default = columnDefault.String
+		}
+		columns[colName] = info
+	}
+	return columns, nil
+}
+
+/**
+ * 生成添加列的 SQL（SQL Server 的 ADD 不带 COLUMN 关键字）
+ */
+func (s *SQLServerStrategy) GenerateAddColumnSQL(tableName string, field reflect.StructField, colName string) (string, error) {
+	colDef := s.columnDefinition(field, colName, s.cm.IsPrimaryKey(field))
+	addSQL := fmt.Sprintf("ALTER TABLE [%s] ADD %s", tableName, colDef)
+	if commentSQL := sqlServerColumnCommentSQL(tableName, colName, columnCommentTag(field)); commentSQL != "" {
+		addSQL += ";\nDECLARE @schema sysname = SCHEMA_NAME();\n" + commentSQL
+	}
+	return addSQL, nil
+}
+
+/**
+ * 生成删除列的 SQL
+ */
+func (s *SQLServerStrategy) GenerateDropColumnSQL(tableName string, colName string) (string, error) {
+	return fmt.Sprintf("ALTER TABLE [%s] DROP COLUMN [%s]", tableName, colName), nil
+}
+
+/**
+ * 生成修改列的 SQL（ALTER COLUMN 不能修改 IDENTITY 属性与默认值约束，只修改类型与是否可空）
+ */
+func (s *SQLServerStrategy) GenerateModifyColumnSQL(tableName string, field reflect.StructField, colName string) (string, error) {
+	colDef := fmt.Sprintf("[%s] %s", colName, s.GetSQLType(field))
+
+	dbTag := field.Tag.Get("db")
+	if strings.Contains(dbTag, "not_null") || strings.Contains(dbTag, "primary_key") {
+		colDef += " NOT NULL"
+	} else {
+		colDef += " NULL"
+	}
+
+	return fmt.Sprintf("ALTER TABLE [%s] ALTER COLUMN %s", tableName, colDef), nil
+}
+
+/**
+ * 生成建表语句中的外键约束子句（SQL Server 没有 RESTRICT，按 NO ACTION 生成）
+ */
+func (s *SQLServerStrategy) GenerateForeignKeyClause(fk ForeignKeyDefinition) string {
+	return fmt.Sprintf("CONSTRAINT [%s] FOREIGN KEY ([%s]) REFERENCES [%s] ([%s]) ON DELETE %s ON UPDATE %s",
+		fk.Name, fk.Column, fk.RefTable, fk.RefColumn, equivalentForeignKeyAction(fk.OnDelete), equivalentForeignKeyAction(fk.OnUpdate))
+}
+
+/**
+ * 生成添加外键的 SQL
+ */
+func (s *SQLServerStrategy) GenerateAddForeignKeySQL(tableName string, fk ForeignKeyDefinition) string {
+	return fmt.Sprintf("ALTER TABLE [%s] ADD %s", tableName, s.GenerateForeignKeyClause(fk))
+}
+
+/**
+ * 生成删除外键的 SQL
+ */
+func (s *SQLServerStrategy) GenerateDropForeignKeySQL(tableName string, constraintName string) string {
+	return fmt.Sprintf("ALTER TABLE [%s] DROP CONSTRAINT [%s]", tableName, constraintName)
+}
+
+/**
+ * 查询表上已有的外键（引用动作如 SET_NULL 规范化为 SET NULL）
+ */
+func (s *SQLServerStrategy) GetForeignKeys(db *Db, tableName string) (map[string]ForeignKeyDefinition, error) {
+	query := `
+		SELECT fk.name, c.name, rt.name, rc.name, fk.delete_referential_action_desc, fk.update_referential_action_desc
+		FROM sys.foreign_keys fk
+		JOIN sys.foreign_key_columns fkc ON fkc.constraint_object_id = fk.object_id
+		JOIN sys.columns c ON c.object_id = fkc.parent_object_id AND c.column_id = fkc.parent_column_id
+		JOIN sys.tables rt ON rt.object_id = fkc.referenced_object_id
+		JOIN sys.columns rc ON rc.object_id = fkc.referenced_object_id AND rc.column_id = fkc.referenced_column_id
+		WHERE fk.parent_object_id = OBJECT_ID(?)
+	`
+	return scanForeignKeys(db, query, tableName)
+}
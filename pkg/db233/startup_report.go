@@ -0,0 +1,265 @@
+package db233
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+/**
+ * StartupReport - 启动报告
+ *
+ * 汇总已配置的子系统（数据库、连接池、已注册实体、插件、监控、迁移版本），
+ * 发布后查看一条日志即可确认配置是否生效：
+ *
+ *   dm := db233.GetInstance()
+ *   dm.SetStartupBannerEnabled(true)
+ *   dm.RegisterMigrationManager("game", migrationManager)  // 可选，报告中带上迁移版本
+ *   dm.InitByYourDiy(func(dm *db233.DbManager) error { ... }) // 初始化成功后输出启动报告
+ *
+ * 也可随时调用 dm.BuildStartupReport() 获取结构化报告（可 JSON 序列化）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type StartupReport struct {
+	GeneratedAt   time.Time              `json:"generatedAt"`
+	Databases     []StartupDatabaseInfo  `json:"databases"`
+	Entities      []StartupEntityInfo    `json:"entities"`
+	GlobalPlugins []string               `json:"globalPlugins"`
+	Migrations    []StartupMigrationInfo `json:"migrations"`
+	Warnings      []string               `json:"warnings,omitempty"`
+}
+
+/**
+ * StartupDatabaseInfo - 单个数据库的启动信息
+ */
+type StartupDatabaseInfo struct {
+	GroupName          string   `json:"groupName"`
+	DbId               int      `json:"dbId"`
+	DatabaseType       string   `json:"databaseType"`
+	MaxOpenConns       int      `json:"maxOpenConns"`
+	OpenConnections    int      `json:"openConnections"`
+	IdleConnections    int      `json:"idleConnections"`
+	StatementTimeout   string   `json:"statementTimeout"`
+	PerformanceMonitor bool     `json:"performanceMonitor"`
+	QueryAllowlist     bool     `json:"queryAllowlist"`
+	Maintenance        bool     `json:"maintenance"`
+	Plugins            []string `json:"plugins"`
+}
+
+/**
+ * StartupEntityInfo - 已注册实体（表）的启动信息
+ */
+type StartupEntityInfo struct {
+	TableName         string   `json:"tableName"`
+	ColumnCount       int      `json:"columnCount"`
+	PrimaryKeyColumns []string `json:"primaryKeyColumns"`
+}
+
+/**
+ * StartupMigrationInfo - 迁移版本信息
+ */
+type StartupMigrationInfo struct {
+	GroupName string `json:"groupName"`
+	Version   int64  `json:"version"`
+	Error     string `json:"error,omitempty"`
+}
+
+/**
+ * 开启 / 关闭启动报告（开启后 InitByYourDiy 成功时输出）
+ */
+func (dm *DbManager) SetStartupBannerEnabled(enabled bool) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	dm.startupBannerEnabled = enabled
+}
+
+/**
+ * 登记分组的迁移管理器，启动报告中带上当前迁移版本
+ */
+func (dm *DbManager) RegisterMigrationManager(groupName string, migrationManager *MigrationManager) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	if dm.groupNameToMigrationManager == nil {
+		dm.groupNameToMigrationManager = make(map[string]*MigrationManager)
+	}
+	dm.groupNameToMigrationManager[groupName] = migrationManager
+}
+
+/**
+ * 生成启动报告
+ *
+ * 迁移版本查询失败不会中断报告，错误记录在对应条目与 Warnings 中
+ */
+func (dm *DbManager) BuildStartupReport() *StartupReport {
+	report := &StartupReport{
+		GeneratedAt:   time.Now(),
+		Databases:     make([]StartupDatabaseInfo, 0),
+		Entities:      GetCrudManagerInstance().startupEntityInfos(),
+		GlobalPlugins: pluginNames(GetPluginManagerInstance().GetAll()),
+		Migrations:    make([]StartupMigrationInfo, 0),
+		Warnings:      make([]string, 0),
+	}
+
+	groups := dm.GetDbGroupCollection()
+	sort.Slice(groups, func(i, j int) bool { return groups[i].GroupName < groups[j].GroupName })
+	for _, group := range groups {
+		group.mu.Lock()
+		dbIds := make([]int, 0, len(group.DbMap))
+		for dbId := range group.DbMap {
+			dbIds = append(dbIds, dbId)
+		}
+		sort.Ints(dbIds)
+		dbs := make([]*Db, 0, len(dbIds))
+		for _, dbId := range dbIds {
+			dbs = append(dbs, group.DbMap[dbId])
+		}
+		group.mu.Unlock()
+
+		for _, db := range dbs {
+			report.Databases = append(report.Databases, startupDatabaseInfo(group.GroupName, db))
+		}
+	}
+
+	dm.mu.RLock()
+	migrationManagers := make(map[string]*MigrationManager, len(dm.groupNameToMigrationManager))
+	for groupName, migrationManager := range dm.groupNameToMigrationManager {
+		migrationManagers[groupName] = migrationManager
+	}
+	dm.mu.RUnlock()
+
+	groupNames := make([]string, 0, len(migrationManagers))
+	for groupName := range migrationManagers {
+		groupNames = append(groupNames, groupName)
+	}
+	sort.Strings(groupNames)
+	for _, groupName := range groupNames {
+		info := StartupMigrationInfo{GroupName: groupName}
+		version, err := migrationManagers[groupName].GetCurrentVersion()
+		if err != nil {
+			info.Error = err.Error()
+			report.Warnings = append(report.Warnings, fmt.Sprintf("获取迁移版本失败: 分组=%s, 错误=%v", groupName, err))
+		}
+		info.Version = version
+		report.Migrations = append(report.Migrations, info)
+	}
+
+	if len(report.Databases) == 0 {
+		report.Warnings = append(report.Warnings, "未注册任何数据库")
+	}
+	return report
+}
+
+/**
+ * 输出启动报告到日志
+ */
+func (dm *DbManager) LogStartupBanner() *StartupReport {
+	report := dm.BuildStartupReport()
+	LogInfo("%s", report.String())
+	for _, warning := range report.Warnings {
+		LogWarn("启动报告: %s", warning)
+	}
+	return report
+}
+
+/**
+ * 启动报告的文本形式
+ */
+func (r *StartupReport) String() string {
+	var builder strings.Builder
+	builder.WriteString("========== db233 启动报告 ==========\n")
+	builder.WriteString(fmt.Sprintf("数据库 (%d):\n", len(r.Databases)))
+	for _, db := range r.Databases {
+		builder.WriteString(fmt.Sprintf("  - %s#%d [%s] maxOpenConns=%d open=%d idle=%d timeout=%s monitor=%t allowlist=%t maintenance=%t plugins=%v\n",
+			db.GroupName, db.DbId, db.DatabaseType, db.MaxOpenConns, db.OpenConnections, db.IdleConnections,
+			db.StatementTimeout, db.PerformanceMonitor, db.QueryAllowlist, db.Maintenance, db.Plugins))
+	}
+	builder.WriteString(fmt.Sprintf("实体 (%d):\n", len(r.Entities)))
+	for _, entity := range r.Entities {
+		builder.WriteString(fmt.Sprintf("  - %s columns=%d pk=%v\n", entity.TableName, entity.ColumnCount, entity.PrimaryKeyColumns))
+	}
+	builder.WriteString(fmt.Sprintf("全局插件: %v\n", r.GlobalPlugins))
+	for _, migration := range r.Migrations {
+		if migration.Error != "" {
+			builder.WriteString(fmt.Sprintf("迁移版本: %s = 未知（见警告）\n", migration.GroupName))
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("迁移版本: %s = %d\n", migration.GroupName, migration.Version))
+	}
+	builder.WriteString("====================================")
+	return builder.String()
+}
+
+/**
+ * 启动报告的 JSON 形式
+ */
+func (r *StartupReport) ToJSON() (string, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+/**
+ * 采集单个 Db 的启动信息
+ */
+func startupDatabaseInfo(groupName string, db *Db) StartupDatabaseInfo {
+	info := StartupDatabaseInfo{
+		GroupName:        groupName,
+		DbId:             db.DbId,
+		DatabaseType:     string(db.DatabaseType),
+		StatementTimeout: db.statementTimeout().String(),
+		QueryAllowlist:   db.GetQueryAllowlist() != nil,
+		Maintenance:      db.IsInMaintenance(),
+		Plugins:          pluginNames(db.GetEffectivePlugins()),
+	}
+	if info.DatabaseType == "" {
+		info.DatabaseType = string(EnumDatabaseTypeMySQL)
+	}
+	if db.DataSource != nil {
+		stats := db.DataSource.Stats()
+		info.MaxOpenConns = stats.MaxOpenConnections
+		info.OpenConnections = stats.OpenConnections
+		info.IdleConnections = stats.Idle
+	}
+	if monitor := db.GetPerformanceMonitor(); monitor != nil {
+		monitor.mu.RLock()
+		info.PerformanceMonitor = monitor.enabled
+		monitor.mu.RUnlock()
+	}
+	return info
+}
+
+/**
+ * 已注册实体（按表名排序）
+ */
+func (cm *CrudManager) startupEntityInfos() []StartupEntityInfo {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	infos := make([]StartupEntityInfo, 0, len(cm.tableNameToColNameMap))
+	for tableName, columns := range cm.tableNameToColNameMap {
+		infos = append(infos, StartupEntityInfo{
+			TableName:         tableName,
+			ColumnCount:       len(columns),
+			PrimaryKeyColumns: append([]string(nil), cm.tableNamePkColNameListMap[tableName]...),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].TableName < infos[j].TableName })
+	return infos
+}
+
+/**
+ * 插件名称列表
+ */
+func pluginNames(plugins []Db233Plugin) []string {
+	names := make([]string, 0, len(plugins))
+	for _, plugin := range plugins {
+		names = append(names, plugin.GetPluginName())
+	}
+	return names
+}
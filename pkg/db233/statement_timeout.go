@@ -0,0 +1,262 @@
+package db233
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+/**
+ * 语句超时与慢语句终止 - Go 版
+ *
+ * 为 Db 提供默认语句超时和单次调用覆盖：
+ * - 超时后取消 context，释放调用方
+ * - MySQL 下额外发送 KILL QUERY，终止服务端仍在执行的语句
+ * - 超时事件记录到 PerformanceMonitor
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+
+/**
+ * KILL QUERY 自身的执行超时
+ */
+const killQueryTimeout = 5 * time.Second
+
+/**
+ * 连接 ID 缓存的最大条目数
+ */
+const maxCachedConnectionIds = 1024
+
+/**
+ * 设置默认语句超时
+ *
+ * @param timeout 超时时间，0 表示不限制
+ */
+func (db *Db) SetStatementTimeout(timeout time.Duration) {
+	if timeout < 0 {
+		timeout = 0
+	}
+	// 未覆盖超时的视图与原 Db 共用默认超时
+	if !db.statementTimeoutOverridden {
+		db = db.root()
+	}
+	db.StatementTimeout = timeout
+}
+
+/**
+ * 返回使用指定默认语句超时的 Db 视图（原 Db 不受影响）
+ *
+ * @param timeout 超时时间，0 表示不限制
+ */
+func (db *Db) WithStatementTimeout(timeout time.Duration) *Db {
+	if timeout < 0 {
+		timeout = 0
+	}
+	view := db.newView()
+	view.StatementTimeout = timeout
+	view.statementTimeoutOverridden = true
+	return view
+}
+
+/**
+ * 执行时生效的默认语句超时：视图未通过 WithStatementTimeout 覆盖时读取原 Db 的当前值
+ */
+func (db *Db) statementTimeout() time.Duration {
+	if db.statementTimeoutOverridden {
+		return db.StatementTimeout
+	}
+	return db.root().StatementTimeout
+}
+
+/**
+ * 设置性能监控器，用于记录超时事件
+ *
+ * @param monitor 性能监控器
+ */
+func (db *Db) SetPerformanceMonitor(monitor *PerformanceMonitor) {
+	db.root().performanceMonitor = monitor
+}
+
+/**
+ * 获取性能监控器
+ *
+ * @return *PerformanceMonitor 性能监控器，未设置时为 nil
+ */
+func (db *Db) GetPerformanceMonitor() *PerformanceMonitor {
+	return db.root().performanceMonitor
+}
+
+/**
+ * 使用指定超时执行查询（覆盖默认超时）
+ *
+ * @param query SQL 语句
+ * @param params 参数
+ * @param returnType 返回类型
+ * @param timeout 超时时间，<= 0 时使用 Db 默认超时
+ * @return []interface{} 结果列表
+ * @return error 执行错误，超时返回 *StatementTimeoutException，熔断、限流等异常原样返回
+ */
+func (db *Db) ExecuteQueryWithTimeout(query string, params []interface{}, returnType interface{}, timeout time.Duration) ([]interface{}, error) {
+	if err := db.checkQueryAllowed(query); err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		timeout = db.statementTimeout()
+	}
+	results, err := db.executeQueryOnce(query, params, returnType, timeout)
+	if err != nil {
+		return nil, wrapExecuteError(err, "查询执行失败: "+query)
+	}
+	return results, nil
+}
+
+/**
+ * 使用指定超时执行更新（覆盖默认超时）
+ *
+ * @param query SQL 语句
+ * @param params 参数
+ * @param timeout 超时时间，<= 0 时使用 Db 默认超时
+ * @return int 影响行数
+ * @return error 执行错误，超时返回 *StatementTimeoutException，熔断、限流等异常原样返回
+ */
+func (db *Db) ExecuteUpdateWithTimeout(query string, params []interface{}, timeout time.Duration) (int, error) {
+	if err := db.checkQueryAllowed(query); err != nil {
+		return 0, err
+	}
+	if timeout <= 0 {
+		timeout = db.statementTimeout()
+	}
+	affected, err := db.executeUpdateOnce(query, params, timeout)
+	if err != nil {
+		return 0, wrapExecuteError(err, "更新执行失败: "+query)
+	}
+	return int(affected), nil
+}
+
+/**
+ * 在独占连接上执行带超时的语句
+ *
+ * 超时后取消 context；MySQL 下使用另一条连接执行 KILL QUERY，
+ * 避免被取消的语句在服务端继续占用资源。timeout <= 0 时不限时（自动埋点用于测量获取连接的等待时间）
+ */
+func (db *Db) runWithStatementTimeout(query string, timeout time.Duration, fn func(ctx context.Context, conn *sql.Conn) error) error {
+	sampler := db.GetPoolEventSampler()
+	waited := sampler.beginWait()
+	acquireStart := time.Now()
+	conn, err := db.DataSource.Conn(context.Background())
+	sampler.endWait(waited, time.Since(acquireStart))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	detector := db.GetLeakDetector()
+	defer detector.untrack(detector.track(LeakKindConnection, db.DbId))
+	if monitor := db.instrumentationMonitor(); monitor != nil {
+		monitor.RecordConnectionAcquired(time.Since(acquireStart))
+		defer monitor.RecordConnectionReleased()
+	}
+	if timeout <= 0 {
+		return fn(context.Background(), conn)
+	}
+
+	connectionId, driverConn := db.lookupConnectionId(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err = fn(ctx, conn)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		// 被取消的连接通常会被驱动废弃，不再保留其连接 ID
+		db.forgetConnectionId(driverConn)
+		killed := db.killQuery(connectionId)
+		if monitor := db.root().performanceMonitor; monitor != nil {
+			monitor.RecordStatementTimeout(query, timeout, killed)
+		}
+		LogFields(WARN, "语句执行超时", "timeout", timeout, "connectionId", connectionId, "killed", killed, "sql", query)
+		return NewStatementTimeoutException(query, timeout, killed)
+	}
+	return err
+}
+
+/**
+ * 获取当前连接在服务端的连接 ID（仅 MySQL），失败返回 0
+ *
+ * 结果按驱动连接缓存在原 Db 上，连接池复用的连接不会每条语句都执行 SELECT CONNECTION_ID()。
+ * 同时返回驱动连接，供超时后清除缓存
+ */
+func (db *Db) lookupConnectionId(conn *sql.Conn) (int64, driver.Conn) {
+	if db.DatabaseType != "" && db.DatabaseType != EnumDatabaseTypeMySQL {
+		return 0, nil
+	}
+
+	var driverConn driver.Conn
+	conn.Raw(func(raw interface{}) error {
+		if c, ok := raw.(driver.Conn); ok && reflect.TypeOf(c).Comparable() {
+			driverConn = c
+		}
+		return nil
+	})
+	root := db.root()
+	if driverConn != nil {
+		root.connectionIdsMu.Lock()
+		connectionId, cached := root.connectionIds[driverConn]
+		root.connectionIdsMu.Unlock()
+		if cached {
+			return connectionId, driverConn
+		}
+	}
+
+	var connectionId int64
+	if err := conn.QueryRowContext(context.Background(), "SELECT CONNECTION_ID()").Scan(&connectionId); err != nil {
+		LogDebug("获取连接ID失败，超时后将无法终止服务端语句: %v", err)
+		return 0, driverConn
+	}
+	if driverConn != nil {
+		root.connectionIdsMu.Lock()
+		// 已关闭的连接无法感知，超过上限时整体清空，避免缓存无限增长
+		if root.connectionIds == nil || len(root.connectionIds) >= maxCachedConnectionIds {
+			root.connectionIds = make(map[driver.Conn]int64)
+		}
+		root.connectionIds[driverConn] = connectionId
+		root.connectionIdsMu.Unlock()
+	}
+	return connectionId, driverConn
+}
+
+/**
+ * 清除驱动连接的连接 ID 缓存
+ */
+func (db *Db) forgetConnectionId(driverConn driver.Conn) {
+	if driverConn == nil {
+		return
+	}
+	root := db.root()
+	root.connectionIdsMu.Lock()
+	delete(root.connectionIds, driverConn)
+	root.connectionIdsMu.Unlock()
+}
+
+/**
+ * 终止指定连接上正在执行的语句
+ *
+ * @return bool 是否成功发送 KILL QUERY
+ */
+func (db *Db) killQuery(connectionId int64) bool {
+	if connectionId <= 0 {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), killQueryTimeout)
+	defer cancel()
+
+	if _, err := db.DataSource.ExecContext(ctx, fmt.Sprintf("KILL QUERY %d", connectionId)); err != nil {
+		LogFields(ERROR, "终止超时语句失败", "connectionId", connectionId, "error", err)
+		return false
+	}
+	return true
+}
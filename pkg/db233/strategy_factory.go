@@ -23,6 +23,7 @@ func GetStrategyFactoryInstance() *TableCreationStrategyFactory {
 		// 初始化默认策略
 		cm := GetCrudManagerInstance()
 		strategyFactoryInstance.strategies[EnumDatabaseTypeMySQL] = NewMySQLStrategy(cm)
+		strategyFactoryInstance.strategies[EnumDatabaseTypeSQLServer] = NewSQLServerStrategy(cm)
 		// TODO: PostgreSQL 支持将在未来版本中实现
 		// strategyFactoryInstance.strategies[EnumDatabaseTypePostgreSQL] = NewPostgreSQLStrategy(cm)
 	}
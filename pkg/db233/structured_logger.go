@@ -0,0 +1,204 @@
+package db233
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+/**
+ * StructuredLogger - 结构化日志接口
+ *
+ * 库内所有日志（LogInfo / LogError 等及 LogFields）都经过当前注册的实现输出，
+ * 接入应用自己的 slog / zap / logrus 后库日志与业务日志合并在同一管道：
+ *
+ *   db233.SetStructuredLogger(db233.NewSlogLogger(slog.Default()))
+ *
+ *   db233.LogFields(db233.WARN, "慢查询", "group", "game", "duration", d, "sql", query)
+ *
+ * zap / logrus 只需实现该接口（keyvals 为 key1, value1, key2, value2... 交替排列）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type StructuredLogger interface {
+	/**
+	 * 该级别是否输出（用于跳过格式化开销）
+	 */
+	Enabled(level LogLevel) bool
+
+	/**
+	 * 输出日志
+	 *
+	 * @param level 日志级别
+	 * @param msg 日志消息
+	 * @param keyvals 结构化字段，键值交替排列
+	 */
+	Log(level LogLevel, msg string, keyvals ...interface{})
+}
+
+var (
+	structuredLogger   StructuredLogger = defaultLogger
+	structuredLoggerMu sync.RWMutex
+)
+
+/**
+ * 设置库使用的结构化日志实现（nil 恢复为默认的标准输出日志）
+ */
+func SetStructuredLogger(logger StructuredLogger) {
+	structuredLoggerMu.Lock()
+	defer structuredLoggerMu.Unlock()
+	if logger == nil {
+		logger = defaultLogger
+	}
+	structuredLogger = logger
+}
+
+/**
+ * 获取库当前使用的结构化日志实现
+ */
+func GetStructuredLogger() StructuredLogger {
+	structuredLoggerMu.RLock()
+	defer structuredLoggerMu.RUnlock()
+	return structuredLogger
+}
+
+/**
+ * 输出带结构化字段的日志
+ *
+ * @param level 日志级别
+ * @param msg 日志消息
+ * @param keyvals 结构化字段，键值交替排列
+ */
+func LogFields(level LogLevel, msg string, keyvals ...interface{}) {
+	logger := GetStructuredLogger()
+	if !logger.Enabled(level) {
+		return
+	}
+	logger.Log(level, msg, keyvals...)
+}
+
+/**
+ * 按格式输出日志（LogInfo 等便捷方法的统一出口）
+ */
+func logFormatted(level LogLevel, format string, args ...interface{}) {
+	logger := GetStructuredLogger()
+	if !logger.Enabled(level) {
+		return
+	}
+	logger.Log(level, fmt.Sprintf(format, args...))
+}
+
+/**
+ * 是否输出该级别
+ */
+func (l *Logger) Enabled(level LogLevel) bool {
+	return level >= l.level
+}
+
+/**
+ * 输出日志，结构化字段以 key=value 追加在消息后
+ */
+func (l *Logger) Log(level LogLevel, msg string, keyvals ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	l.logger.Printf("[%s] %s%s", logLevelNames[level], msg, formatKeyvals(keyvals))
+}
+
+/**
+ * 将键值对格式化为 " key=value key2=value2"（奇数个时最后一个值的键为 !BADKEY）
+ */
+func formatKeyvals(keyvals []interface{}) string {
+	if len(keyvals) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	for i := 0; i < len(keyvals); i += 2 {
+		if i+1 >= len(keyvals) {
+			builder.WriteString(fmt.Sprintf(" !BADKEY=%v", keyvals[i]))
+			break
+		}
+		builder.WriteString(fmt.Sprintf(" %v=%v", keyvals[i], keyvals[i+1]))
+	}
+	return builder.String()
+}
+
+/**
+ * SlogLogger - log/slog 适配器
+ *
+ * TRACE 映射为 slog.LevelDebug-4，FATAL 映射为 slog.LevelError+4
+ */
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+/**
+ * 创建 slog 适配器（logger 为 nil 时使用 slog.Default()）
+ */
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogLogger{logger: logger}
+}
+
+/**
+ * 是否输出该级别
+ */
+func (s *SlogLogger) Enabled(level LogLevel) bool {
+	return s.logger.Enabled(context.Background(), toSlogLevel(level))
+}
+
+/**
+ * 输出日志
+ */
+func (s *SlogLogger) Log(level LogLevel, msg string, keyvals ...interface{}) {
+	s.logger.Log(context.Background(), toSlogLevel(level), msg, keyvals...)
+}
+
+/**
+ * 日志级别转换为 slog 级别
+ */
+func toSlogLevel(level LogLevel) slog.Level {
+	switch level {
+	case TRACE:
+		return slog.LevelDebug - 4
+	case DEBUG:
+		return slog.LevelDebug
+	case INFO:
+		return slog.LevelInfo
+	case WARN:
+		return slog.LevelWarn
+	case ERROR:
+		return slog.LevelError
+	default:
+		return slog.LevelError + 4
+	}
+}
+
+/**
+ * NopLogger - 丢弃所有日志
+ */
+type NopLogger struct{}
+
+/**
+ * 创建丢弃所有日志的实现
+ */
+func NewNopLogger() *NopLogger {
+	return &NopLogger{}
+}
+
+/**
+ * 是否输出该级别（始终为 false）
+ */
+func (n *NopLogger) Enabled(level LogLevel) bool {
+	return false
+}
+
+/**
+ * 输出日志（丢弃）
+ */
+func (n *NopLogger) Log(level LogLevel, msg string, keyvals ...interface{}) {}
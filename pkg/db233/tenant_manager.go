@@ -0,0 +1,343 @@
+package db233
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+/**
+ * TenantManager - 多租户管理器
+ *
+ * 将租户 ID 映射到隔离方式：
+ * - 独立数据库：每个租户一个 Db
+ * - 独立 schema：表名改写为 schema.table
+ * - 共享表：所有语句自动附加 tenant_id 谓词，写入时强制填充租户列
+ *
+ * 当前租户通过可插拔的 TenantResolver 解析（context 值、HTTP 头、回调）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type TenantManager struct {
+	tenants  map[string]*TenantConfig
+	resolver TenantResolver
+	mu       sync.RWMutex
+}
+
+/**
+ * EnumTenantIsolation - 租户隔离方式
+ */
+type EnumTenantIsolation string
+
+const (
+	// EnumTenantIsolationDatabase 独立数据库
+	EnumTenantIsolationDatabase EnumTenantIsolation = "database"
+	// EnumTenantIsolationSchema 独立 schema
+	EnumTenantIsolationSchema EnumTenantIsolation = "schema"
+	// EnumTenantIsolationColumn 共享表 + 租户列
+	EnumTenantIsolationColumn EnumTenantIsolation = "column"
+)
+
+/**
+ * 默认租户列名
+ */
+const DefaultTenantColumn = "tenant_id"
+
+/**
+ * TenantConfig - 租户配置
+ */
+type TenantConfig struct {
+	TenantId  string
+	Isolation EnumTenantIsolation
+
+	// 独立数据库模式使用的 Db
+	Db *Db
+
+	// 独立 schema 模式使用的 schema 名
+	Schema string
+
+	// 共享表模式使用的租户列，默认 tenant_id
+	Column string
+}
+
+/**
+ * TenantResolver - 当前租户解析器
+ */
+type TenantResolver interface {
+	/**
+	 * 从来源（context.Context、*http.Request 等）解析租户 ID
+	 */
+	Resolve(source interface{}) (string, error)
+}
+
+var tenantManagerInstance *TenantManager
+var tenantManagerOnce sync.Once
+
+/**
+ * 获取多租户管理器单例
+ */
+func GetTenantManagerInstance() *TenantManager {
+	tenantManagerOnce.Do(func() {
+		tenantManagerInstance = &TenantManager{
+			tenants:  make(map[string]*TenantConfig),
+			resolver: NewContextTenantResolver(),
+		}
+	})
+	return tenantManagerInstance
+}
+
+/**
+ * 注册租户（同 ID 覆盖）
+ */
+func (tm *TenantManager) RegisterTenant(config *TenantConfig) error {
+	if config == nil || config.TenantId == "" {
+		return NewValidationException("租户 ID 不能为空")
+	}
+
+	switch config.Isolation {
+	case EnumTenantIsolationDatabase:
+		if config.Db == nil {
+			return NewConfigurationException(fmt.Sprintf("租户 %s 使用独立数据库模式，但未提供 Db", config.TenantId))
+		}
+	case EnumTenantIsolationSchema:
+		if config.Schema == "" {
+			return NewConfigurationException(fmt.Sprintf("租户 %s 使用独立 schema 模式，但未提供 schema", config.TenantId))
+		}
+	case EnumTenantIsolationColumn:
+		if config.Column == "" {
+			config.Column = DefaultTenantColumn
+		}
+	default:
+		return NewConfigurationException(fmt.Sprintf("租户 %s 的隔离方式不支持: %s", config.TenantId, config.Isolation))
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.tenants[config.TenantId] = config
+	LogInfo("租户已注册: ID=%s, 隔离方式=%s", config.TenantId, config.Isolation)
+	return nil
+}
+
+/**
+ * 注册独立数据库租户
+ */
+func (tm *TenantManager) RegisterDatabaseTenant(tenantId string, db *Db) error {
+	return tm.RegisterTenant(&TenantConfig{TenantId: tenantId, Isolation: EnumTenantIsolationDatabase, Db: db})
+}
+
+/**
+ * 注册独立 schema 租户
+ */
+func (tm *TenantManager) RegisterSchemaTenant(tenantId string, schema string) error {
+	return tm.RegisterTenant(&TenantConfig{TenantId: tenantId, Isolation: EnumTenantIsolationSchema, Schema: schema})
+}
+
+/**
+ * 注册共享表租户（使用默认租户列 tenant_id）
+ */
+func (tm *TenantManager) RegisterColumnTenant(tenantId string) error {
+	return tm.RegisterTenant(&TenantConfig{TenantId: tenantId, Isolation: EnumTenantIsolationColumn})
+}
+
+/**
+ * 注销租户
+ */
+func (tm *TenantManager) UnregisterTenant(tenantId string) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	delete(tm.tenants, tenantId)
+}
+
+/**
+ * 获取租户配置
+ */
+func (tm *TenantManager) GetTenant(tenantId string) (*TenantConfig, bool) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	config, exists := tm.tenants[tenantId]
+	return config, exists
+}
+
+/**
+ * 获取所有已注册租户 ID
+ */
+func (tm *TenantManager) GetTenantIds() []string {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	ids := make([]string, 0, len(tm.tenants))
+	for id := range tm.tenants {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+/**
+ * 设置当前租户解析器
+ */
+func (tm *TenantManager) SetResolver(resolver TenantResolver) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.resolver = resolver
+}
+
+/**
+ * 使用当前解析器解析租户 ID
+ */
+func (tm *TenantManager) Resolve(source interface{}) (string, error) {
+	tm.mu.RLock()
+	resolver := tm.resolver
+	tm.mu.RUnlock()
+
+	if resolver == nil {
+		return "", NewConfigurationException("未设置租户解析器")
+	}
+	return resolver.Resolve(source)
+}
+
+/**
+ * 为存储库绑定租户作用域
+ *
+ * @param repository 基础存储库
+ * @param tenantId 租户 ID
+ * @return *BaseCrudRepository 租户作用域存储库
+ */
+func (tm *TenantManager) ScopeRepository(repository *BaseCrudRepository, tenantId string) (*BaseCrudRepository, error) {
+	config, exists := tm.GetTenant(tenantId)
+	if !exists {
+		return nil, NewDb233ExceptionWithCode("TENANT_NOT_FOUND", fmt.Sprintf("租户未注册: %s", tenantId))
+	}
+
+	switch config.Isolation {
+	case EnumTenantIsolationDatabase:
+		return NewBaseCrudRepository(config.Db), nil
+	case EnumTenantIsolationSchema:
+		schema := config.Schema
+		return repository.WithScope(&RepositoryScope{
+			TableNameMapper: func(tableName string) string {
+				if strings.Contains(tableName, ".") {
+					return tableName
+				}
+				return schema + "." + tableName
+			},
+		}), nil
+	default:
+		return repository.WithScope(&RepositoryScope{
+			Predicates:    []ScopePredicate{{Sql: config.Column + " = ?", Params: []interface{}{tenantId}}},
+			ForcedColumns: map[string]interface{}{config.Column: tenantId},
+		}), nil
+	}
+}
+
+/**
+ * 为存储库绑定租户作用域（使用多租户管理器单例）
+ *
+ * 共享表模式下 Save 先按主键与租户列 UPDATE，未命中才 INSERT；主键被其他租户占用时返回唯一约束错误，不会覆盖其他租户的行
+ *
+ * @param tenantId 租户 ID
+ * @return *BaseCrudRepository 租户作用域存储库
+ */
+func (r *BaseCrudRepository) ForTenant(tenantId string) (*BaseCrudRepository, error) {
+	return GetTenantManagerInstance().ScopeRepository(r, tenantId)
+}
+
+/**
+ * context 中租户 ID 的 key 类型
+ */
+type tenantContextKey struct{}
+
+/**
+ * 把租户 ID 写入 context
+ */
+func WithTenantId(ctx context.Context, tenantId string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantId)
+}
+
+/**
+ * 从 context 读取租户 ID
+ */
+func TenantIdFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	tenantId, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenantId, ok && tenantId != ""
+}
+
+/**
+ * ContextTenantResolver - 从 context.Context 解析租户（默认解析器）
+ */
+type ContextTenantResolver struct{}
+
+/**
+ * 创建 context 租户解析器
+ */
+func NewContextTenantResolver() *ContextTenantResolver {
+	return &ContextTenantResolver{}
+}
+
+/**
+ * 解析租户 ID，来源必须为 context.Context
+ */
+func (r *ContextTenantResolver) Resolve(source interface{}) (string, error) {
+	ctx, ok := source.(context.Context)
+	if !ok {
+		return "", NewValidationException(fmt.Sprintf("context 租户解析器不支持来源类型: %T", source))
+	}
+	tenantId, ok := TenantIdFromContext(ctx)
+	if !ok {
+		return "", NewValidationException("context 中没有租户 ID")
+	}
+	return tenantId, nil
+}
+
+/**
+ * HeaderTenantResolver - 从 HTTP 请求头解析租户
+ */
+type HeaderTenantResolver struct {
+	header string
+}
+
+/**
+ * 创建请求头租户解析器
+ *
+ * @param header 请求头名，如 X-Tenant-Id
+ */
+func NewHeaderTenantResolver(header string) *HeaderTenantResolver {
+	return &HeaderTenantResolver{header: header}
+}
+
+/**
+ * 解析租户 ID，来源支持 *http.Request 和 http.Header
+ */
+func (r *HeaderTenantResolver) Resolve(source interface{}) (string, error) {
+	var header http.Header
+	switch s := source.(type) {
+	case *http.Request:
+		header = s.Header
+	case http.Header:
+		header = s
+	default:
+		return "", NewValidationException(fmt.Sprintf("请求头租户解析器不支持来源类型: %T", source))
+	}
+
+	tenantId := strings.TrimSpace(header.Get(r.header))
+	if tenantId == "" {
+		return "", NewValidationException("请求头中没有租户 ID: " + r.header)
+	}
+	return tenantId, nil
+}
+
+/**
+ * FuncTenantResolver - 回调租户解析器
+ */
+type FuncTenantResolver func(source interface{}) (string, error)
+
+/**
+ * 调用回调解析租户 ID
+ */
+func (f FuncTenantResolver) Resolve(source interface{}) (string, error) {
+	return f(source)
+}
@@ -0,0 +1,297 @@
+package db233
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * TimePolicy - 时间字段的时区与零值策略
+ *
+ * 不再依赖驱动的隐式行为（DSN 中的 loc / parseTime、会话时区）：
+ *   - 写入：time.Time 按 StoreUTC 转换为 UTC（或 Location）后再交给驱动，零值按 ZeroAsNull 写为 NULL
+ *   - 读取：驱动返回的 time.Time 转换到 Location；不带时区的文本按存储时区解析；
+ *           NULL 与 MySQL 的 0000-00-00 读为零值（*time.Time 为 nil）
+ *   - 精度：precision 标签或 db_type 中的 (n)（如 DATETIME(6)、TIMESTAMPTZ(3)）决定写入前截断到的小数位，
+ *           避免数据库四舍五入后与内存中的值不一致
+ *
+ *   db233.SetDefaultTimePolicy(db233.NewTimePolicy().SetLocation(shanghai))
+ *   db.SetTimePolicy(db233.NewTimePolicy().SetStoreUTC(false).SetLocation(shanghai))  // 单库覆盖
+ *
+ *   UpdatedAt time.Time  `db:"updated_at" precision:"6"`          // MySQL TIMESTAMP(6) / SQL Server DATETIME2(6)
+ *   DeletedAt *time.Time `db:"deleted_at" db_type:"TIMESTAMPTZ"`
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type TimePolicy struct {
+	// 写入前转换为 UTC；为 false 时转换为 Location
+	StoreUTC bool
+
+	// 读取后转换到的时区，nil 表示 UTC
+	Location *time.Location
+
+	// 零值 time.Time 写为 NULL
+	ZeroAsNull bool
+}
+
+var (
+	defaultTimePolicy   = NewTimePolicy()
+	defaultTimePolicyMu sync.RWMutex
+
+	timeTypePrecisionPattern = regexp.MustCompile(`\((\d)\)`)
+	timeType                 = reflect.TypeOf(time.Time{})
+	nullTimeType             = reflect.TypeOf(sql.NullTime{})
+)
+
+/**
+ * 创建时间策略（默认：以 UTC 存储、读取为 UTC、零值写为 NULL）
+ */
+func NewTimePolicy() *TimePolicy {
+	return &TimePolicy{StoreUTC: true, Location: time.UTC, ZeroAsNull: true}
+}
+
+/**
+ * 设置是否以 UTC 存储
+ */
+func (p *TimePolicy) SetStoreUTC(storeUTC bool) *TimePolicy {
+	p.StoreUTC = storeUTC
+	return p
+}
+
+/**
+ * 设置读取时转换到的时区
+ */
+func (p *TimePolicy) SetLocation(location *time.Location) *TimePolicy {
+	p.Location = location
+	return p
+}
+
+/**
+ * 设置零值是否写为 NULL
+ */
+func (p *TimePolicy) SetZeroAsNull(zeroAsNull bool) *TimePolicy {
+	p.ZeroAsNull = zeroAsNull
+	return p
+}
+
+/**
+ * 设置全局默认时间策略（未单独设置策略的 Db 使用）
+ */
+func SetDefaultTimePolicy(policy *TimePolicy) {
+	if policy == nil {
+		policy = NewTimePolicy()
+	}
+	defaultTimePolicyMu.Lock()
+	defer defaultTimePolicyMu.Unlock()
+	defaultTimePolicy = policy
+}
+
+/**
+ * 获取全局默认时间策略
+ */
+func GetDefaultTimePolicy() *TimePolicy {
+	defaultTimePolicyMu.RLock()
+	defer defaultTimePolicyMu.RUnlock()
+	return defaultTimePolicy
+}
+
+/**
+ * 设置该 Db 的时间策略（nil 表示使用全局默认策略）
+ */
+func (db *Db) SetTimePolicy(policy *TimePolicy) *Db {
+	db.root().timePolicy = policy
+	return db
+}
+
+/**
+ * 获取该 Db 生效的时间策略
+ */
+func (db *Db) GetTimePolicy() *TimePolicy {
+	if db != nil && db.root().timePolicy != nil {
+		return db.root().timePolicy
+	}
+	return GetDefaultTimePolicy()
+}
+
+func (p *TimePolicy) location() *time.Location {
+	if p.Location == nil {
+		return time.UTC
+	}
+	return p.Location
+}
+
+/**
+ * 存储时区（不带时区的文本按此解析）
+ */
+func (p *TimePolicy) storageLocation() *time.Location {
+	if p.StoreUTC {
+		return time.UTC
+	}
+	return p.location()
+}
+
+/**
+ * 是否为 time.Time / *time.Time / sql.NullTime
+ */
+func isTimeFieldType(t reflect.Type) bool {
+	return t == timeType || t == nullTimeType || (t.Kind() == reflect.Ptr && t.Elem() == timeType)
+}
+
+/**
+ * 时间列的小数秒精度：precision 标签优先，其次 db_type / type 中的 (n)；未声明返回 -1
+ */
+func timeColumnPrecision(field reflect.StructField) int {
+	if tag := field.Tag.Get("precision"); tag != "" {
+		if precision, err := strconv.Atoi(tag); err == nil && precision >= 0 && precision <= 9 {
+			return precision
+		}
+	}
+	for _, tag := range []string{field.Tag.Get("db_type"), field.Tag.Get("type")} {
+		if match := timeTypePrecisionPattern.FindStringSubmatch(tag); match != nil {
+			precision, _ := strconv.Atoi(match[1])
+			return precision
+		}
+	}
+	return -1
+}
+
+/**
+ * 带 precision 标签时的列类型后缀，如 (6)
+ */
+func timeColumnPrecisionSuffix(field reflect.StructField) string {
+	if field.Tag.Get("precision") == "" {
+		return ""
+	}
+	if precision := timeColumnPrecision(field); precision >= 0 {
+		return fmt.Sprintf("(%d)", precision)
+	}
+	return ""
+}
+
+/**
+ * 转换为写入驱动的值（零值 / nil 指针按策略写为 NULL）
+ *
+ * @param precision 小数秒位数，-1 表示不截断
+ */
+func (p *TimePolicy) toStorage(value interface{}, precision int) interface{} {
+	var t time.Time
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	case *time.Time:
+		if v == nil {
+			return sql.NullTime{}
+		}
+		t = *v
+	case sql.NullTime:
+		if !v.Valid {
+			return sql.NullTime{}
+		}
+		t = v.Time
+	default:
+		return value
+	}
+	if t.IsZero() {
+		if p.ZeroAsNull {
+			return sql.NullTime{}
+		}
+		return t
+	}
+	if p.StoreUTC {
+		t = t.UTC()
+	} else {
+		t = t.In(p.location())
+	}
+	if precision >= 0 && precision < 9 {
+		t = t.Truncate(time.Duration(pow10(9 - precision)))
+	}
+	return t
+}
+
+func pow10(n int) int64 {
+	result := int64(1)
+	for i := 0; i < n; i++ {
+		result *= 10
+	}
+	return result
+}
+
+/**
+ * 把驱动返回的值转换为 time.Time / *time.Time / sql.NullTime
+ */
+func (p *TimePolicy) fromStorage(source reflect.Value, targetType reflect.Type) (reflect.Value, error) {
+	if source.IsValid() && source.Kind() == reflect.Interface {
+		if source.IsNil() {
+			return reflect.Zero(targetType), nil
+		}
+		source = source.Elem()
+	}
+	if !source.IsValid() {
+		return reflect.Zero(targetType), nil
+	}
+
+	var t time.Time
+	switch v := source.Interface().(type) {
+	case time.Time:
+		t = v
+	case *time.Time:
+		if v == nil {
+			return reflect.Zero(targetType), nil
+		}
+		t = *v
+	case []byte:
+		parsed, err := p.parse(string(v))
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		t = parsed
+	case string:
+		parsed, err := p.parse(v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		t = parsed
+	default:
+		return reflect.Value{}, fmt.Errorf("无法转换类型: %s -> %s", source.Type(), targetType)
+	}
+
+	if t.IsZero() {
+		return reflect.Zero(targetType), nil
+	}
+	t = t.In(p.location())
+	if targetType == nullTimeType {
+		return reflect.ValueOf(sql.NullTime{Time: t, Valid: true}), nil
+	}
+	if targetType.Kind() == reflect.Ptr {
+		return reflect.ValueOf(&t), nil
+	}
+	return reflect.ValueOf(t), nil
+}
+
+/**
+ * 解析时间文本：带时区的按其时区，不带时区的按存储时区；空文本与 0000-00-00 为零值
+ */
+func (p *TimePolicy) parse(text string) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.HasPrefix(text, "0000-00-00") {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05Z07"} {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, text, p.storageLocation()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析时间字符串: %s", text)
+}
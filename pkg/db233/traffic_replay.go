@@ -0,0 +1,311 @@
+package db233
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * 流量回放 - Go 版
+ *
+ * SlowQueryRecorderPlugin 把慢语句以 JSON Lines 记录下来，
+ * TrafficReplayer 按可配置并发把记录的语句回放到目标 Db，
+ * 用于压测和执行计划变更验证（对比回放耗时与记录耗时）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+
+/**
+ * SlowQueryRecord - 慢语句记录（JSON Lines 每行一条）
+ */
+type SlowQueryRecord struct {
+	Sql        string        `json:"sql"`
+	Params     []interface{} `json:"params,omitempty"`
+	DurationMs float64       `json:"duration_ms"`
+	Timestamp  time.Time     `json:"timestamp"`
+}
+
+/**
+ * SlowQueryRecorderPlugin - 慢语句记录插件
+ *
+ * 执行耗时超过阈值的语句写入 JSON Lines
+ */
+type SlowQueryRecorderPlugin struct {
+	*AbstractDb233Plugin
+	threshold time.Duration
+	encoder   *json.Encoder
+	recorded  int64
+	mu        sync.Mutex
+}
+
+/**
+ * 创建慢语句记录插件
+ *
+ * @param w 输出目标
+ * @param threshold 慢语句阈值
+ */
+func NewSlowQueryRecorderPlugin(w io.Writer, threshold time.Duration) *SlowQueryRecorderPlugin {
+	return &SlowQueryRecorderPlugin{
+		AbstractDb233Plugin: NewAbstractDb233Plugin("slow-query-recorder-plugin"),
+		threshold:           threshold,
+		encoder:             json.NewEncoder(w),
+	}
+}
+
+/**
+ * SQL 执行后记录慢语句
+ */
+func (p *SlowQueryRecorderPlugin) PostExecuteSql(context *ExecuteSqlContext) {
+	if context.Duration < p.threshold {
+		return
+	}
+
+	record := &SlowQueryRecord{
+		Sql:        context.Sql,
+		Params:     context.Params,
+		DurationMs: float64(context.Duration) / float64(time.Millisecond),
+		Timestamp:  context.StartTime,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.encoder.Encode(record); err != nil {
+		LogError("记录慢语句失败: %v", err)
+		return
+	}
+	p.recorded++
+}
+
+/**
+ * 获取已记录的慢语句数量
+ */
+func (p *SlowQueryRecorderPlugin) GetRecordedCount() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.recorded
+}
+
+/**
+ * ReplayOptions - 回放选项
+ */
+type ReplayOptions struct {
+	// 并发数，默认 1
+	Concurrency int
+
+	// 是否回放写语句（默认只回放 SELECT 等只读语句）
+	AllowWrites bool
+
+	// 单条语句超时，0 表示不限制
+	Timeout time.Duration
+
+	// 最多回放条数，0 表示不限制
+	Limit int
+
+	// 回放耗时超过记录耗时的倍数时视为退化，默认 2
+	RegressionFactor float64
+}
+
+/**
+ * ReplayResult - 单条语句回放结果
+ */
+type ReplayResult struct {
+	Record         *SlowQueryRecord
+	ReplayDuration time.Duration
+	Error          error
+}
+
+/**
+ * ReplayReport - 回放报告
+ */
+type ReplayReport struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	Skipped   int
+
+	// 回放总耗时（墙钟时间）
+	Elapsed time.Duration
+
+	// 回放耗时退化的语句（按退化倍数降序）
+	Regressions []*ReplayResult
+
+	// 失败的语句
+	Failures []*ReplayResult
+}
+
+/**
+ * TrafficReplayer - 流量回放器
+ */
+type TrafficReplayer struct {
+	target *Db
+}
+
+/**
+ * 创建流量回放器
+ *
+ * @param target 回放目标库
+ */
+func NewTrafficReplayer(target *Db) *TrafficReplayer {
+	return &TrafficReplayer{target: target}
+}
+
+/**
+ * 回放 JSON Lines 格式的语句记录
+ *
+ * @param r 记录输入
+ * @param options 回放选项
+ * @return *ReplayReport 回放报告
+ */
+func (tr *TrafficReplayer) Replay(r io.Reader, options ReplayOptions) (*ReplayReport, error) {
+	if options.Concurrency <= 0 {
+		options.Concurrency = 1
+	}
+	if options.RegressionFactor <= 0 {
+		options.RegressionFactor = 2
+	}
+
+	records, skipped, err := tr.readRecords(r, options)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReplayReport{
+		Total:       len(records) + skipped,
+		Skipped:     skipped,
+		Regressions: make([]*ReplayResult, 0),
+		Failures:    make([]*ReplayResult, 0),
+	}
+
+	start := time.Now()
+	tasks := make(chan *SlowQueryRecord)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for record := range tasks {
+				result := tr.replayOne(record, options.Timeout)
+
+				mu.Lock()
+				if result.Error != nil {
+					report.Failed++
+					report.Failures = append(report.Failures, result)
+				} else {
+					report.Succeeded++
+					recorded := time.Duration(record.DurationMs * float64(time.Millisecond))
+					if recorded > 0 && float64(result.ReplayDuration) > float64(recorded)*options.RegressionFactor {
+						report.Regressions = append(report.Regressions, result)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, record := range records {
+		tasks <- record
+	}
+	close(tasks)
+	wg.Wait()
+	report.Elapsed = time.Since(start)
+
+	sort.Slice(report.Regressions, func(i, j int) bool {
+		return regressionRatio(report.Regressions[i]) > regressionRatio(report.Regressions[j])
+	})
+
+	LogInfo("流量回放完成: 总数=%d, 成功=%d, 失败=%d, 跳过=%d, 退化=%d, 耗时=%v",
+		report.Total, report.Succeeded, report.Failed, report.Skipped, len(report.Regressions), report.Elapsed)
+	return report, nil
+}
+
+/**
+ * 读取语句记录，过滤掉不允许回放的写语句
+ */
+func (tr *TrafficReplayer) readRecords(r io.Reader, options ReplayOptions) ([]*SlowQueryRecord, int, error) {
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	decoder.UseNumber()
+
+	records := make([]*SlowQueryRecord, 0)
+	skipped := 0
+	for {
+		if options.Limit > 0 && len(records) >= options.Limit {
+			break
+		}
+
+		record := &SlowQueryRecord{}
+		err := decoder.Decode(record)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, NewValidationExceptionWithCause(err, "解析语句记录失败")
+		}
+
+		if !options.AllowWrites && !isReadOnlyStatement(record.Sql) {
+			skipped++
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, skipped, nil
+}
+
+/**
+ * 回放单条语句（查询会读完所有行，以反映真实开销）
+ */
+func (tr *TrafficReplayer) replayOne(record *SlowQueryRecord, timeout time.Duration) *ReplayResult {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	result := &ReplayResult{Record: record}
+	start := time.Now()
+	if isReadOnlyStatement(record.Sql) {
+		rows, err := tr.target.DataSource.QueryContext(ctx, record.Sql, record.Params...)
+		if err == nil {
+			for rows.Next() {
+			}
+			err = rows.Err()
+			rows.Close()
+		}
+		result.Error = err
+	} else {
+		_, result.Error = tr.target.DataSource.ExecContext(ctx, record.Sql, record.Params...)
+	}
+	result.ReplayDuration = time.Since(start)
+	return result
+}
+
+/**
+ * 判断是否为只读语句
+ */
+func isReadOnlyStatement(query string) bool {
+	trimmed := strings.ToUpper(strings.TrimSpace(query))
+	for _, prefix := range []string{"SELECT", "SHOW", "EXPLAIN", "DESC", "WITH"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * 计算回放耗时相对记录耗时的倍数
+ */
+func regressionRatio(result *ReplayResult) float64 {
+	recorded := result.Record.DurationMs * float64(time.Millisecond)
+	if recorded <= 0 {
+		return 0
+	}
+	return float64(result.ReplayDuration) / recorded
+}
@@ -3,6 +3,7 @@ package db233
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"sync"
 	"time"
 )
@@ -24,8 +25,18 @@ type TransactionManager struct {
 	startTime time.Time
 	timeout   time.Duration
 
+	// 事务超时 context 的取消函数（事务结束时调用；提前取消会导致 database/sql 回滚事务）
+	cancel context.CancelFunc
+
 	// 保存点管理
-	savepoints []string
+	savepoints   []string
+	savepointSeq int
+
+	// 事务结束回调（见 OnCommit / OnRollback）
+	hooks []transactionHook
+
+	// 泄漏检测句柄（见 LeakDetector）
+	leakHandle int64
 
 	// 锁
 	mu sync.RWMutex
@@ -44,6 +55,15 @@ type TransactionOptions struct {
 	Timeout   time.Duration
 }
 
+/**
+ * 事务结束回调，savepoint 为注册时最内层的保存点（空表示不在保存点内）
+ */
+type transactionHook struct {
+	fn        func()
+	onCommit  bool
+	savepoint string
+}
+
 /**
  * 创建事务管理器
  */
@@ -84,17 +104,27 @@ func (tm *TransactionManager) Begin(opts ...TransactionOptions) error {
 
 	// 开始事务
 	ctx, cancel := context.WithTimeout(context.Background(), tm.timeout)
-	defer cancel()
 
+	sampler := tm.db.GetPoolEventSampler()
+	waited := sampler.beginWait()
+	acquireStart := time.Now()
 	tx, err := tm.db.DataSource.BeginTx(ctx, txOptions)
+	sampler.endWait(waited, time.Since(acquireStart))
 	if err != nil {
+		cancel()
 		return NewTransactionExceptionWithCause(err, "开始事务失败")
 	}
+	if monitor := tm.db.instrumentationMonitor(); monitor != nil {
+		monitor.RecordConnectionAcquired(time.Since(acquireStart))
+		monitor.RecordTransactionStart()
+	}
 
 	tm.tx = tx
+	tm.cancel = cancel
 	tm.isActive = true
 	tm.startTime = time.Now()
 	tm.savepoints = make([]string, 0)
+	tm.leakHandle = tm.db.GetLeakDetector().track(LeakKindTransaction, tm.db.DbId)
 
 	LogDebug("事务已开始，隔离级别: %v, 只读: %v", tm.isolation, tm.readOnly)
 	return nil
@@ -104,6 +134,10 @@ func (tm *TransactionManager) Begin(opts ...TransactionOptions) error {
  * 提交事务
  */
 func (tm *TransactionManager) Commit() error {
+	// 回调在释放锁之后执行，可以安全地访问事务管理器
+	var hooks []func()
+	phase := "提交"
+	defer func() { runTransactionHooks(phase, hooks) }()
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
@@ -111,13 +145,22 @@ func (tm *TransactionManager) Commit() error {
 		return NewTransactionException("没有活跃的事务")
 	}
 
+	// 提交失败后事务同样已经结束（无法再回滚），按回滚处理：执行回滚回调并释放事务状态
 	err := tm.tx.Commit()
-	if err != nil {
-		return NewTransactionExceptionWithCause(err, "提交事务失败")
+	committed := err == nil
+	if !committed {
+		phase = "回滚"
 	}
-
 	duration := time.Since(tm.startTime)
+	hooks = tm.takeHooks(committed, nil)
 	tm.reset()
+	if monitor := tm.db.instrumentationMonitor(); monitor != nil {
+		monitor.RecordTransactionEnd(duration, committed)
+		monitor.RecordConnectionReleased()
+	}
+	if err != nil {
+		return NewTransactionExceptionWithCause(err, "提交事务失败")
+	}
 
 	LogDebug("事务已提交，持续时间: %v", duration)
 	return nil
@@ -127,6 +170,8 @@ func (tm *TransactionManager) Commit() error {
  * 回滚事务
  */
 func (tm *TransactionManager) Rollback() error {
+	var hooks []func()
+	defer func() { runTransactionHooks("回滚", hooks) }()
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
@@ -134,14 +179,19 @@ func (tm *TransactionManager) Rollback() error {
 		return NewTransactionException("没有活跃的事务")
 	}
 
+	// 回滚失败时事务同样已经结束，照常执行回滚回调并释放事务状态
 	err := tm.tx.Rollback()
+	duration := time.Since(tm.startTime)
+	hooks = tm.takeHooks(false, nil)
+	tm.reset()
+	if monitor := tm.db.instrumentationMonitor(); monitor != nil {
+		monitor.RecordTransactionEnd(duration, false)
+		monitor.RecordConnectionReleased()
+	}
 	if err != nil {
 		return NewTransactionExceptionWithCause(err, "回滚事务失败")
 	}
 
-	duration := time.Since(tm.startTime)
-	tm.reset()
-
 	LogDebug("事务已回滚，持续时间: %v", duration)
 	return nil
 }
@@ -176,8 +226,12 @@ func (tm *TransactionManager) Savepoint(name string) error {
 
 /**
  * 回滚到保存点
+ *
+ * 保存点之后注册的 OnCommit 回调被丢弃，OnRollback 回调立即执行
  */
 func (tm *TransactionManager) RollbackToSavepoint(name string) error {
+	var hooks []func()
+	defer func() { runTransactionHooks("回滚到保存点", hooks) }()
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
@@ -203,6 +257,15 @@ func (tm *TransactionManager) RollbackToSavepoint(name string) error {
 		return NewTransactionExceptionWithCause(err, "回滚到保存点失败: "+name)
 	}
 
+	// 数据库会销毁该保存点之后创建的保存点，列表保持一致
+	for i := len(tm.savepoints) - 1; i >= 0; i-- {
+		if tm.savepoints[i] == name {
+			hooks = tm.takeHooks(false, tm.savepoints[i:])
+			tm.savepoints = tm.savepoints[:i+1]
+			break
+		}
+	}
+
 	LogDebug("已回滚到保存点: %s", name)
 	return nil
 }
@@ -223,9 +286,18 @@ func (tm *TransactionManager) ReleaseSavepoint(name string) error {
 		return NewTransactionExceptionWithCause(err, "释放保存点失败: "+name)
 	}
 
-	// 从列表中移除保存点
+	// 从列表中移除保存点，其中注册的回调归入上一层
 	for i, sp := range tm.savepoints {
 		if sp == name {
+			parent := ""
+			if i > 0 {
+				parent = tm.savepoints[i-1]
+			}
+			for j := range tm.hooks {
+				if tm.hooks[j].savepoint == name {
+					tm.hooks[j].savepoint = parent
+				}
+			}
 			tm.savepoints = append(tm.savepoints[:i], tm.savepoints[i+1:]...)
 			break
 		}
@@ -245,8 +317,18 @@ func (tm *TransactionManager) Query(query string, args ...interface{}) (*sql.Row
 	if !tm.isActive {
 		return nil, NewTransactionException("没有活跃的事务")
 	}
+	if err := tm.db.checkQueryAllowed(query); err != nil {
+		return nil, err
+	}
+	args, err := convertParamsToDb(args)
+	if err != nil {
+		return nil, NewValidationExceptionWithCause(err, "SQL 参数类型转换失败")
+	}
 
-	return tm.tx.Query(query, args...)
+	start := time.Now()
+	rows, err := tm.tx.Query(query, args...)
+	tm.db.recordInstrumentedQuery(query, start, err)
+	return rows, err
 }
 
 /**
@@ -259,8 +341,18 @@ func (tm *TransactionManager) QueryContext(ctx context.Context, query string, ar
 	if !tm.isActive {
 		return nil, NewTransactionException("没有活跃的事务")
 	}
+	if err := tm.db.checkQueryAllowed(query); err != nil {
+		return nil, err
+	}
+	args, err := convertParamsToDb(args)
+	if err != nil {
+		return nil, NewValidationExceptionWithCause(err, "SQL 参数类型转换失败")
+	}
 
-	return tm.tx.QueryContext(ctx, query, args...)
+	start := time.Now()
+	rows, err := tm.tx.QueryContext(ctx, query, args...)
+	tm.db.recordInstrumentedQuery(query, start, err)
+	return rows, err
 }
 
 /**
@@ -273,8 +365,18 @@ func (tm *TransactionManager) Exec(query string, args ...interface{}) (sql.Resul
 	if !tm.isActive {
 		return nil, NewTransactionException("没有活跃的事务")
 	}
+	if err := tm.db.checkQueryAllowed(query); err != nil {
+		return nil, err
+	}
+	args, err := convertParamsToDb(args)
+	if err != nil {
+		return nil, NewValidationExceptionWithCause(err, "SQL 参数类型转换失败")
+	}
 
-	return tm.tx.Exec(query, args...)
+	start := time.Now()
+	result, err := tm.tx.Exec(query, args...)
+	tm.db.recordInstrumentedQuery(query, start, err)
+	return result, err
 }
 
 /**
@@ -287,8 +389,18 @@ func (tm *TransactionManager) ExecContext(ctx context.Context, query string, arg
 	if !tm.isActive {
 		return nil, NewTransactionException("没有活跃的事务")
 	}
+	if err := tm.db.checkQueryAllowed(query); err != nil {
+		return nil, err
+	}
+	args, err := convertParamsToDb(args)
+	if err != nil {
+		return nil, NewValidationExceptionWithCause(err, "SQL 参数类型转换失败")
+	}
 
-	return tm.tx.ExecContext(ctx, query, args...)
+	start := time.Now()
+	result, err := tm.tx.ExecContext(ctx, query, args...)
+	tm.db.recordInstrumentedQuery(query, start, err)
+	return result, err
 }
 
 /**
@@ -330,10 +442,102 @@ func (tm *TransactionManager) GetSavepoints() []string {
  * 重置事务状态
  */
 func (tm *TransactionManager) reset() {
+	if tm.cancel != nil {
+		tm.cancel()
+		tm.cancel = nil
+	}
+	tm.db.GetLeakDetector().untrack(tm.leakHandle)
+	tm.leakHandle = 0
 	tm.tx = nil
 	tm.isActive = false
 	tm.startTime = time.Time{}
 	tm.savepoints = nil
+	tm.hooks = nil
+}
+
+/**
+ * 注册事务提交后的回调
+ *
+ * 用于缓存失效、事件发布等必须在事务确定提交后才能执行的副作用。
+ * 回调在提交成功后按注册顺序执行一次，事务回滚时被丢弃；
+ * 在保存点内注册的回调随该保存点的回滚一起丢弃。
+ * 嵌套的 WithTransaction 加入外层事务，回调在最外层提交时执行
+ *
+ * @return error 没有活跃的事务
+ */
+func (tm *TransactionManager) OnCommit(fn func()) error {
+	return tm.addHook(fn, true)
+}
+
+/**
+ * 注册事务回滚后的回调
+ *
+ * 回调在事务回滚后按注册顺序执行一次，事务提交时被丢弃；
+ * 在保存点内注册的回调在回滚到该保存点时执行
+ *
+ * @return error 没有活跃的事务
+ */
+func (tm *TransactionManager) OnRollback(fn func()) error {
+	return tm.addHook(fn, false)
+}
+
+func (tm *TransactionManager) addHook(fn func(), onCommit bool) error {
+	if fn == nil {
+		return NewValidationException("事务回调不能为空")
+	}
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if !tm.isActive {
+		return NewTransactionException("没有活跃的事务")
+	}
+	savepoint := ""
+	if len(tm.savepoints) > 0 {
+		savepoint = tm.savepoints[len(tm.savepoints)-1]
+	}
+	tm.hooks = append(tm.hooks, transactionHook{fn: fn, onCommit: onCommit, savepoint: savepoint})
+	return nil
+}
+
+/**
+ * 取出回调并返回需要执行的一类
+ *
+ * scope 为 nil 时取出全部回调（事务结束），否则只取出在这些保存点内注册的回调。调用方需持有锁
+ */
+func (tm *TransactionManager) takeHooks(committed bool, scope []string) []func() {
+	scoped := make(map[string]bool, len(scope))
+	for _, sp := range scope {
+		scoped[sp] = true
+	}
+	var run []func()
+	kept := tm.hooks[:0]
+	for _, hook := range tm.hooks {
+		if scope != nil && !scoped[hook.savepoint] {
+			kept = append(kept, hook)
+			continue
+		}
+		if hook.onCommit == committed {
+			run = append(run, hook.fn)
+		}
+	}
+	tm.hooks = kept
+	return run
+}
+
+/**
+ * 依次执行事务回调，单个回调 panic 只记录日志，不影响其余回调
+ */
+func runTransactionHooks(phase string, hooks []func()) {
+	for _, hook := range hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					LogError("事务%s回调 panic: %v", phase, r)
+				}
+			}()
+			hook()
+		}()
+	}
 }
 
 /**
@@ -361,10 +565,72 @@ func (tm *TransactionManager) ExecuteInTransaction(fn func(*TransactionManager)
 	return tm.Commit()
 }
 
+/**
+ * 在保存点内执行函数
+ *
+ * 创建保存点后执行 fn：成功时释放保存点；返回错误或 panic 时回滚到保存点并释放，
+ * 只撤销 fn 内的修改，外层事务继续有效（panic 在回滚后继续抛出）。
+ * name 为空时自动生成。替代手工的 Savepoint / RollbackToSavepoint / ReleaseSavepoint 组合：
+ *
+ *   err := tm.RunInSavepoint("deduct_stock", func(tm *db233.TransactionManager) error {
+ *       _, err := tm.Exec("UPDATE stock SET count = count - 1 WHERE id = ?", id)
+ *       return err
+ *   })
+ *
+ * @return error fn 返回的错误；fn 成功但释放保存点失败时返回释放错误
+ */
+func (tm *TransactionManager) RunInSavepoint(name string, fn func(*TransactionManager) error) error {
+	if name == "" {
+		tm.mu.Lock()
+		tm.savepointSeq++
+		name = fmt.Sprintf("db233_sp_%d", tm.savepointSeq)
+		tm.mu.Unlock()
+	}
+	if err := tm.Savepoint(name); err != nil {
+		return err
+	}
+
+	completed := false
+	defer func() {
+		if !completed {
+			tm.discardSavepoint(name)
+		}
+	}()
+
+	if err := fn(tm); err != nil {
+		completed = true
+		tm.discardSavepoint(name)
+		return err
+	}
+	completed = true
+	return tm.ReleaseSavepoint(name)
+}
+
+/**
+ * 回滚到保存点并释放（失败只记录日志，不覆盖业务错误）
+ */
+func (tm *TransactionManager) discardSavepoint(name string) {
+	if err := tm.RollbackToSavepoint(name); err != nil {
+		LogError("回滚到保存点失败: %v", err)
+		return
+	}
+	if err := tm.ReleaseSavepoint(name); err != nil {
+		LogError("释放保存点失败: %v", err)
+	}
+}
+
 /**
  * 声明式事务装饰器
+ *
+ * db 绑定了事务（见 ContextWithTx）时加入该事务，由外层负责提交或回滚
  */
 func WithTransaction(db *Db, fn func(*TransactionManager) error, opts ...TransactionOptions) error {
+	if db.tx != nil {
+		if !db.tx.IsActive() {
+			return NewTransactionException("绑定的事务已结束")
+		}
+		return fn(db.tx)
+	}
 	tm := NewTransactionManager(db)
 	return tm.ExecuteInTransaction(fn, opts...)
 }
@@ -0,0 +1,229 @@
+package db233
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+/**
+ * 声明式事务包装
+ *
+ * 用包装函数代替在服务方法中手写 ExecuteInTransaction：按传播行为决定加入 / 新开 / 不使用事务，
+ * 新开的事务遇到序列化失败（死锁、SQLSTATE 40001、TiDB 写冲突）时自动回滚并重试整个函数：
+ *
+ *   placeOrder := db233.Transactional(db, db233.TransactionalOptions{
+ *       TransactionOptions: db233.TransactionOptions{Isolation: sql.LevelSerializable},
+ *   })(func(ctx context.Context) error {
+ *       return orderRepo.WithContext(ctx).Save(order)
+ *   })
+ *   err := placeOrder(ctx)
+ *
+ * 服务结构体中 func 类型的字段可以批量包装：
+ *
+ *   db233.Transactional(db).Decorate(&service.PlaceOrder, &service.CancelOrder)
+ *
+ * 事务通过 context 传递（见 RunInTransaction），被包装的函数内部须使用收到的 ctx；
+ * 重试会重新执行整个函数，函数内不应有无法重复的副作用
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type EnumTxPropagation string
+
+const (
+	// ctx 中有同一 Db 的事务时加入，否则新开（默认）
+	TxPropagationRequired EnumTxPropagation = "REQUIRED"
+	// 总是新开独立事务（外层事务不受内层提交 / 回滚影响）
+	TxPropagationRequiresNew EnumTxPropagation = "REQUIRES_NEW"
+	// 有事务时加入，否则不使用事务
+	TxPropagationSupports EnumTxPropagation = "SUPPORTS"
+	// 必须在已有事务中执行，否则返回错误
+	TxPropagationMandatory EnumTxPropagation = "MANDATORY"
+	// 在事务外执行（ctx 中的事务不再传递给下层）
+	TxPropagationNotSupported EnumTxPropagation = "NOT_SUPPORTED"
+	// 不能在事务中执行，已有事务时返回错误
+	TxPropagationNever EnumTxPropagation = "NEVER"
+)
+
+const (
+	defaultTransactionalMaxRetries   = 3
+	defaultTransactionalRetryBackoff = 20 * time.Millisecond
+)
+
+/**
+ * TransactionalOptions - 声明式事务选项
+ */
+type TransactionalOptions struct {
+	// 隔离级别 / 只读 / 超时，只对新开的事务生效
+	TransactionOptions
+
+	// 传播行为，为空时为 TxPropagationRequired
+	Propagation EnumTxPropagation
+
+	// 序列化失败时的最大重试次数，0 为默认 3 次，负数不重试；加入外层事务时不重试（由外层重试）
+	MaxRetries int
+
+	// 首次重试前的等待时间，之后每次翻倍，0 为默认 20ms
+	RetryBackoff time.Duration
+
+	// 自定义是否重试，为 nil 时使用 IsSerializationFailure
+	RetryOn func(err error) bool
+}
+
+/**
+ * TransactionalDecorator - 把 func(ctx) error 包装为事务函数
+ */
+type TransactionalDecorator func(fn func(ctx context.Context) error) func(ctx context.Context) error
+
+/**
+ * 创建声明式事务包装器
+ */
+func Transactional(db *Db, opts ...TransactionalOptions) TransactionalDecorator {
+	options := TransactionalOptions{}
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	return func(fn func(ctx context.Context) error) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			return runTransactional(ctx, db, options, fn)
+		}
+	}
+}
+
+/**
+ * 包装有返回值的函数
+ */
+func (d TransactionalDecorator) WithResult(fn func(ctx context.Context) (interface{}, error)) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		var result interface{}
+		err := d(func(ctx context.Context) error {
+			var err error
+			result, err = fn(ctx)
+			return err
+		})(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+}
+
+/**
+ * 原地包装服务结构体中 func 类型的字段（字段为 nil 时跳过）
+ */
+func (d TransactionalDecorator) Decorate(methods ...*func(ctx context.Context) error) {
+	for _, method := range methods {
+		if method != nil && *method != nil {
+			*method = d(*method)
+		}
+	}
+}
+
+/**
+ * 是否为序列化失败（可以回滚后重试整个事务）
+ *
+ * MySQL 1213 / PostgreSQL 40P01 死锁，PostgreSQL 40001 序列化失败，TiDB 9007 写冲突，CockroachDB 重启事务
+ */
+func IsSerializationFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if deadlock, _ := classifyLockError(err); deadlock {
+		return true
+	}
+	message := err.Error()
+	return strings.Contains(message, "40001") ||
+		strings.Contains(message, "could not serialize access") ||
+		strings.Contains(message, "Error 9007") ||
+		strings.Contains(message, "Write conflict") ||
+		strings.Contains(message, "restart transaction")
+}
+
+/**
+ * 按传播行为执行
+ */
+func runTransactional(ctx context.Context, db *Db, options TransactionalOptions, fn func(ctx context.Context) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// 当前事务：db 绑定的事务优先，其次是 ctx 中同一 Db 的事务
+	existing := db.tx
+	if existing == nil {
+		if tm := TxFromContext(ctx); tm != nil && tm.db.root() == db.root() {
+			existing = tm
+		}
+	}
+	if existing != nil && !existing.IsActive() {
+		existing = nil
+	}
+
+	switch options.Propagation {
+	case TxPropagationRequiresNew:
+		return runInNewTransaction(ctx, db, options, fn)
+	case TxPropagationSupports:
+		if existing != nil {
+			return fn(ContextWithTx(ctx, existing))
+		}
+		return fn(ctx)
+	case TxPropagationMandatory:
+		if existing == nil {
+			return NewTransactionException("MANDATORY 传播行为需要已存在的事务")
+		}
+		return fn(ContextWithTx(ctx, existing))
+	case TxPropagationNotSupported:
+		return fn(ContextWithTx(ctx, nil))
+	case TxPropagationNever:
+		if existing != nil {
+			return NewTransactionException("NEVER 传播行为不能在事务中执行")
+		}
+		return fn(ctx)
+	case "", TxPropagationRequired:
+		if existing != nil {
+			return fn(ContextWithTx(ctx, existing))
+		}
+		return runInNewTransaction(ctx, db, options, fn)
+	default:
+		return NewValidationException("未知的事务传播行为: " + string(options.Propagation))
+	}
+}
+
+/**
+ * 新开事务执行，序列化失败时回滚并按退避重试
+ */
+func runInNewTransaction(ctx context.Context, db *Db, options TransactionalOptions, fn func(ctx context.Context) error) error {
+	base := db
+	if base.tx != nil {
+		base = base.newView()
+		base.tx = nil
+	}
+	maxRetries := options.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultTransactionalMaxRetries
+	}
+	backoff := options.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultTransactionalRetryBackoff
+	}
+	retryOn := options.RetryOn
+	if retryOn == nil {
+		retryOn = IsSerializationFailure
+	}
+
+	for attempt := 0; ; attempt++ {
+		tm := NewTransactionManager(base)
+		err := tm.ExecuteInTransaction(func(tm *TransactionManager) error {
+			return fn(ContextWithTx(ctx, tm))
+		}, options.TransactionOptions)
+		if err == nil || attempt >= maxRetries || !retryOn(err) {
+			return err
+		}
+		LogWarn("事务序列化失败，%v 后重试(%d/%d): %v", backoff, attempt+1, maxRetries, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,132 @@
+package db233
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+/**
+ * 通过 context 传递当前事务
+ *
+ * 服务层开启事务后把事务放入 context，下层存储库 WithContext(ctx) 后自动在该事务中执行，
+ * 组合多个存储库调用时不必逐层传递 *TransactionManager：
+ *
+ *   err := db233.RunInTransaction(ctx, db, func(ctx context.Context) error {
+ *       if err := orderRepo.WithContext(ctx).Save(order); err != nil {
+ *           return err   // 返回错误时回滚
+ *       }
+ *       return stockService.Deduct(ctx, order)   // 内部 repo.WithContext(ctx) 加入同一事务
+ *   })
+ *
+ * 手动管理事务时使用 ContextWithTx(ctx, tm)；ctx 中的事务只对同一个 Db（含其优先级视图）生效，
+ * 其他 Db 的存储库仍按原方式执行。事务结束后，绑定它的存储库执行语句返回 *TransactionException
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type txContextKey struct{}
+
+/**
+ * 返回携带事务的 context
+ */
+func ContextWithTx(ctx context.Context, tm *TransactionManager) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, txContextKey{}, tm)
+}
+
+/**
+ * 获取 context 中的事务，没有时返回 nil
+ */
+func TxFromContext(ctx context.Context) *TransactionManager {
+	if ctx == nil {
+		return nil
+	}
+	tm, _ := ctx.Value(txContextKey{}).(*TransactionManager)
+	return tm
+}
+
+/**
+ * 在事务中执行 fn（fn 收到携带事务的 context）
+ *
+ * ctx 中已有同一 Db 的活跃事务时直接加入，由外层负责提交或回滚；否则开启新事务，
+ * fn 返回错误时回滚，否则提交
+ */
+func RunInTransaction(ctx context.Context, db *Db, fn func(ctx context.Context) error, opts ...TransactionOptions) error {
+	if tm := TxFromContext(ctx); tm != nil && tm.IsActive() && tm.db.root() == db.root() {
+		return fn(ctx)
+	}
+	return WithTransaction(db, func(tm *TransactionManager) error {
+		return fn(ContextWithTx(ctx, tm))
+	}, opts...)
+}
+
+/**
+ * 返回在该事务中执行语句的 Db 视图
+ */
+func (tm *TransactionManager) GetDb() *Db {
+	if tm.db.tx == tm {
+		return tm.db
+	}
+	view := tm.db.newView()
+	view.tx = tm
+	return view
+}
+
+/**
+ * ctx 中有同一 Db 的事务时返回在该事务中执行语句的视图，ctx 带查询标签时视图带上该标签，否则返回自身
+ */
+func (db *Db) WithContext(ctx context.Context) *Db {
+	if tag, ok := QueryTagFromContext(ctx); ok {
+		db = db.WithQueryTag(tag)
+	}
+	tm := TxFromContext(ctx)
+	if tm == nil || tm == db.tx || tm.db.root() != db.root() {
+		return db
+	}
+	view := db.newView()
+	view.tx = tm
+	return view
+}
+
+/**
+ * 获取绑定的事务，未绑定时为 nil
+ */
+func (db *Db) GetTransaction() *TransactionManager {
+	return db.tx
+}
+
+/**
+ * 在绑定的事务中执行语句（超时只取消语句，不执行 KILL QUERY）
+ *
+ * @return bool 是否绑定了事务，未绑定时调用方按原方式执行
+ */
+func (db *Db) runInBoundTx(query string, timeout time.Duration, fn func(ctx context.Context, tx *sql.Tx) error) (bool, error) {
+	if db.tx == nil {
+		return false, nil
+	}
+	db.tx.mu.RLock()
+	tx, active := db.tx.tx, db.tx.isActive
+	db.tx.mu.RUnlock()
+	if !active {
+		return true, NewTransactionException("绑定的事务已结束")
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	err := fn(ctx, tx)
+	if timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		if monitor := db.root().performanceMonitor; monitor != nil {
+			monitor.RecordStatementTimeout(query, timeout, false)
+		}
+		return true, NewStatementTimeoutException(query, timeout, false)
+	}
+	return true, err
+}
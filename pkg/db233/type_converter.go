@@ -0,0 +1,226 @@
+package db233
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+/**
+ * TypeConverter - 自定义类型转换器
+ *
+ * 为无法（或不便）实现 driver.Valuer / sql.Scanner 的类型（第三方 decimal.Decimal、自定义 ID、枚举等）
+ * 注册与数据库值之间的转换，ORM 映射、保存、查询参数与建表类型推断统一使用：
+ *
+ *   db233.RegisterTypeConverter(reflect.TypeOf(decimal.Decimal{}),
+ *       func(v interface{}) (interface{}, error) { return v.(decimal.Decimal).String(), nil },
+ *       func(v interface{}) (interface{}, error) { return decimal.NewFromString(fmt.Sprintf("%s", v)) },
+ *   ).SetSQLType("DECIMAL(20, 4)")
+ *
+ * *T 字段同样适用（nil 写为 NULL，NULL 读为 nil）；普通字段的 NULL 读为零值，不调用 FromDb
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type TypeConverter struct {
+	GoType reflect.Type
+
+	// 转换为驱动支持的值（string、int64、float64、bool、[]byte、time.Time 或 nil）
+	ToDb func(value interface{}) (interface{}, error)
+
+	// 把驱动返回的值（[]byte、string、int64 等，非 NULL）转换为 GoType
+	FromDb func(value interface{}) (interface{}, error)
+
+	sqlType   string
+	sqlTypeBy map[EnumDatabaseType]string
+}
+
+/**
+ * TypeConverterRegistry - 类型转换器注册表
+ */
+type TypeConverterRegistry struct {
+	converters map[reflect.Type]*TypeConverter
+	mu         sync.RWMutex
+}
+
+var (
+	typeConverterRegistryInstance *TypeConverterRegistry
+	typeConverterRegistryOnce     sync.Once
+)
+
+/**
+ * 获取类型转换器注册表单例
+ */
+func GetTypeConverterRegistryInstance() *TypeConverterRegistry {
+	typeConverterRegistryOnce.Do(func() {
+		typeConverterRegistryInstance = &TypeConverterRegistry{
+			converters: make(map[reflect.Type]*TypeConverter),
+		}
+	})
+	return typeConverterRegistryInstance
+}
+
+/**
+ * 注册类型转换器（同一类型重复注册时覆盖）
+ *
+ * @param goType 自定义类型（非指针）
+ * @param toDb 写入时的转换
+ * @param fromDb 读取时的转换
+ */
+func RegisterTypeConverter(goType reflect.Type, toDb func(value interface{}) (interface{}, error), fromDb func(value interface{}) (interface{}, error)) *TypeConverter {
+	return GetTypeConverterRegistryInstance().Register(goType, toDb, fromDb)
+}
+
+/**
+ * 注册类型转换器
+ */
+func (r *TypeConverterRegistry) Register(goType reflect.Type, toDb func(value interface{}) (interface{}, error), fromDb func(value interface{}) (interface{}, error)) *TypeConverter {
+	if goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+	converter := &TypeConverter{GoType: goType, ToDb: toDb, FromDb: fromDb, sqlTypeBy: make(map[EnumDatabaseType]string)}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.converters[goType] = converter
+	return converter
+}
+
+/**
+ * 移除类型转换器
+ */
+func (r *TypeConverterRegistry) Unregister(goType reflect.Type) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.converters, goType)
+}
+
+/**
+ * 获取类型（或指针指向的类型）的转换器
+ */
+func (r *TypeConverterRegistry) Get(goType reflect.Type) (*TypeConverter, bool) {
+	if goType == nil {
+		return nil, false
+	}
+	if goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.converters) == 0 {
+		return nil, false
+	}
+	converter, exists := r.converters[goType]
+	return converter, exists
+}
+
+/**
+ * 设置建表时使用的列类型（所有数据库）
+ */
+func (c *TypeConverter) SetSQLType(sqlType string) *TypeConverter {
+	c.sqlType = sqlType
+	return c
+}
+
+/**
+ * 设置指定数据库的列类型（优先于 SetSQLType）
+ */
+func (c *TypeConverter) SetSQLTypeFor(dbType EnumDatabaseType, sqlType string) *TypeConverter {
+	c.sqlTypeBy[dbType] = sqlType
+	return c
+}
+
+/**
+ * 获取指定数据库的列类型，未设置时返回空字符串
+ */
+func (c *TypeConverter) GetSQLType(dbType EnumDatabaseType) string {
+	if sqlType, ok := c.sqlTypeBy[dbType]; ok {
+		return sqlType
+	}
+	return c.sqlType
+}
+
+/**
+ * 转换为数据库值（nil 指针为 NULL）
+ */
+func (c *TypeConverter) toDb(value interface{}) (interface{}, error) {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		value = v.Elem().Interface()
+	}
+	if c.ToDb == nil {
+		return value, nil
+	}
+	converted, err := c.ToDb(value)
+	if err != nil {
+		return nil, fmt.Errorf("类型 %s 转换为数据库值失败: %w", c.GoType, err)
+	}
+	return converted, nil
+}
+
+/**
+ * 从数据库值转换为目标类型（T 或 *T）
+ */
+func (c *TypeConverter) fromDb(source reflect.Value, targetType reflect.Type) (reflect.Value, error) {
+	if source.IsValid() && source.Kind() == reflect.Interface {
+		if source.IsNil() {
+			return reflect.Zero(targetType), nil
+		}
+		source = source.Elem()
+	}
+	if !source.IsValid() || c.FromDb == nil {
+		return reflect.Zero(targetType), nil
+	}
+
+	converted, err := c.FromDb(source.Interface())
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("数据库值转换为 %s 失败: %w", c.GoType, err)
+	}
+	value := reflect.ValueOf(converted)
+	if !value.IsValid() {
+		return reflect.Zero(targetType), nil
+	}
+	if value.Type() != c.GoType {
+		if !value.Type().ConvertibleTo(c.GoType) {
+			return reflect.Value{}, fmt.Errorf("转换器返回了 %s，期望 %s", value.Type(), c.GoType)
+		}
+		value = value.Convert(c.GoType)
+	}
+	if targetType.Kind() == reflect.Ptr {
+		ptr := reflect.New(c.GoType)
+		ptr.Elem().Set(value)
+		return ptr, nil
+	}
+	return value, nil
+}
+
+/**
+ * 转换 SQL 参数中注册了转换器的值（没有注册转换器时原样返回）
+ */
+func convertParamsToDb(params []interface{}) ([]interface{}, error) {
+	registry := GetTypeConverterRegistryInstance()
+	var converted []interface{}
+	for i, param := range params {
+		if param == nil {
+			continue
+		}
+		converter, exists := registry.Get(reflect.TypeOf(param))
+		if !exists {
+			continue
+		}
+		value, err := converter.toDb(param)
+		if err != nil {
+			return nil, err
+		}
+		if converted == nil {
+			converted = append([]interface{}(nil), params...)
+		}
+		converted[i] = value
+	}
+	if converted == nil {
+		return params, nil
+	}
+	return converted, nil
+}
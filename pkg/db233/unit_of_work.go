@@ -0,0 +1,437 @@
+package db233
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/**
+ * UnitOfWork - 工作单元
+ *
+ * 记录多个实体的 Save / Update / DeleteById，Commit 时在同一事务中按顺序批量执行：
+ *   1. Save：按外键依赖排序（被引用的表在前），同表、同列且带主键值的记录合并为多行 INSERT ... ON DUPLICATE KEY UPDATE；
+ *      自增主键为零值的记录逐条插入并回填主键；带作用域谓词的存储库逐条保存（先按作用域 UPDATE，未命中再 INSERT）
+ *   2. Update：按登记顺序逐条执行
+ *   3. DeleteById：按外键依赖逆序（引用方在前），同表单列主键合并为 DELETE ... WHERE pk IN (...)
+ *
+ * 钩子、校验、加密、作用域与 BaseCrudRepository 一致；Before* 钩子在事务开始前调用，AfterSave 在提交成功后调用。
+ * 提交成功后清空记录；失败时事务回滚、记录保留，可修正后重试或调用 Clear
+ *
+ *   uow := db233.NewUnitOfWork(db)
+ *   uow.Save(&Guild{Id: 1}).Save(&Player{Id: 10, GuildId: 1}).DeleteById(7, &Item{})
+ *   err := uow.Commit()
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type UnitOfWork struct {
+	repo       *BaseCrudRepository
+	operations []unitOfWorkOperation
+	batchSize  int
+	mu         sync.Mutex
+}
+
+type unitOfWorkOperationType int
+
+const (
+	unitOfWorkSave unitOfWorkOperationType = iota
+	unitOfWorkUpdate
+	unitOfWorkDelete
+)
+
+type unitOfWorkOperation struct {
+	kind   unitOfWorkOperationType
+	entity IDbEntity
+	id     interface{}
+}
+
+/**
+ * 待执行语句
+ */
+type unitOfWorkStatement struct {
+	tableName string
+	sql       string
+	values    []interface{}
+
+	// 需回填自增主键的实体与自增列（逐条插入时）
+	autoIncrementEntity IDbEntity
+	autoIncrementColumn string
+
+	// 带作用域谓词的保存（逐条按作用域 UPSERT 执行，sql 仅用于日志）
+	scopedPlan *insertPlan
+}
+
+const defaultUnitOfWorkBatchSize = 500
+
+/**
+ * 创建工作单元
+ */
+func NewUnitOfWork(db *Db) *UnitOfWork {
+	return NewBaseCrudRepository(db).NewUnitOfWork()
+}
+
+/**
+ * 基于存储库创建工作单元（继承作用域与 context）
+ */
+func (r *BaseCrudRepository) NewUnitOfWork() *UnitOfWork {
+	return &UnitOfWork{repo: r, batchSize: defaultUnitOfWorkBatchSize}
+}
+
+/**
+ * 设置单条批量语句的最大行数（默认 500）
+ */
+func (u *UnitOfWork) SetBatchSize(batchSize int) *UnitOfWork {
+	if batchSize > 0 {
+		u.batchSize = batchSize
+	}
+	return u
+}
+
+/**
+ * 登记保存
+ */
+func (u *UnitOfWork) Save(entity IDbEntity) *UnitOfWork {
+	return u.register(unitOfWorkOperation{kind: unitOfWorkSave, entity: entity})
+}
+
+/**
+ * 登记更新
+ */
+func (u *UnitOfWork) Update(entity IDbEntity) *UnitOfWork {
+	return u.register(unitOfWorkOperation{kind: unitOfWorkUpdate, entity: entity})
+}
+
+/**
+ * 登记按主键删除
+ */
+func (u *UnitOfWork) DeleteById(id interface{}, entityType IDbEntity) *UnitOfWork {
+	return u.register(unitOfWorkOperation{kind: unitOfWorkDelete, entity: entityType, id: id})
+}
+
+func (u *UnitOfWork) register(operation unitOfWorkOperation) *UnitOfWork {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.operations = append(u.operations, operation)
+	return u
+}
+
+/**
+ * 已登记的操作数
+ */
+func (u *UnitOfWork) Size() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return len(u.operations)
+}
+
+/**
+ * 清空已登记的操作
+ */
+func (u *UnitOfWork) Clear() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.operations = nil
+}
+
+/**
+ * 在一个事务中执行全部已登记的操作
+ */
+func (u *UnitOfWork) Commit(opts ...TransactionOptions) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.operations) == 0 {
+		return nil
+	}
+
+	statements, saved, err := u.prepareStatements()
+	if err != nil {
+		return err
+	}
+
+	type generatedKey struct {
+		entity IDbEntity
+		id     int64
+	}
+	var generatedKeys []generatedKey
+	err = WithTransaction(u.repo.db, func(tm *TransactionManager) error {
+		for _, statement := range statements {
+			var result sql.Result
+			var err error
+			executed := statement.sql
+			if plan := statement.scopedPlan; plan != nil {
+				result, executed, _, err = u.repo.scopedUpsert(tm.GetDb(), plan, plan.pkColumns, u.repo.saveUpdateColumns(plan))
+			} else if statement.autoIncrementEntity != nil {
+				result, err = tm.GetDb().execInsert(statement.sql, statement.values, statement.autoIncrementColumn)
+			} else {
+				result, err = tm.Exec(statement.sql, statement.values...)
+			}
+			if err != nil {
+				LogError("工作单元执行失败: 表=%s, 错误=%v, SQL=%s", statement.tableName, err, executed)
+				return NewQueryExceptionWithCause(err, fmt.Sprintf("工作单元写入表 %s 失败", statement.tableName))
+			}
+			if statement.autoIncrementEntity != nil {
+				if id, err := result.LastInsertId(); err == nil && id > 0 {
+					generatedKeys = append(generatedKeys, generatedKey{entity: statement.autoIncrementEntity, id: id})
+				}
+			}
+		}
+		return nil
+	}, opts...)
+	if err != nil {
+		return err
+	}
+
+	// 提交成功后回填自增主键并调用保存后钩子
+	for _, key := range generatedKeys {
+		u.repo.setPrimaryKeyValue(key.entity, key.id)
+	}
+	for _, entity := range saved {
+		u.repo.callAfterSave(entity)
+	}
+	LogDebug("工作单元提交成功: 操作数=%d, 语句数=%d", len(u.operations), len(statements))
+	u.operations = nil
+	return nil
+}
+
+/**
+ * 生成按执行顺序排列的语句（调用 Before* 钩子、校验与加密）
+ *
+ * @return []IDbEntity 保存 / 更新的实体（提交成功后调用 AfterSave）
+ */
+func (u *UnitOfWork) prepareStatements() ([]unitOfWorkStatement, []IDbEntity, error) {
+	var saves []*insertPlan
+	var saveEntities []IDbEntity
+	var updates []unitOfWorkStatement
+	var deletes []unitOfWorkOperation
+	var saved []IDbEntity
+	entityTypes := make(map[string]reflect.Type)
+
+	for _, operation := range u.operations {
+		if operation.entity == nil {
+			return nil, nil, NewValidationException("工作单元中的实体不能为 nil")
+		}
+		switch operation.kind {
+		case unitOfWorkSave:
+			plan, err := u.repo.prepareInsert(operation.entity)
+			if err != nil {
+				return nil, nil, err
+			}
+			saves = append(saves, plan)
+			saveEntities = append(saveEntities, operation.entity)
+			saved = append(saved, operation.entity)
+			entityTypes[plan.tableName] = reflect.TypeOf(operation.entity)
+		case unitOfWorkUpdate:
+			plan, err := u.repo.prepareUpdate(operation.entity, nil)
+			if err != nil {
+				return nil, nil, err
+			}
+			updates = append(updates, unitOfWorkStatement{tableName: plan.tableName, sql: plan.sql, values: plan.values})
+			saved = append(saved, operation.entity)
+		case unitOfWorkDelete:
+			if operation.id == nil {
+				return nil, nil, NewValidationException("删除ID不能为 nil")
+			}
+			if err := u.repo.callBeforeDelete(operation.id, operation.entity); err != nil {
+				return nil, nil, err
+			}
+			deletes = append(deletes, operation)
+			entityTypes[u.repo.getTableName(operation.entity)] = reflect.TypeOf(operation.entity)
+		}
+	}
+
+	ranks := unitOfWorkTableRanks(entityTypes)
+	statements := u.saveStatements(saves, saveEntities, ranks)
+	statements = append(statements, updates...)
+	deleteStatements, err := u.deleteStatements(deletes, ranks)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(statements, deleteStatements...), saved, nil
+}
+
+/**
+ * 生成保存语句：按表依赖排序，同表同列且带主键值的连续记录合并为多行语句
+ */
+func (u *UnitOfWork) saveStatements(plans []*insertPlan, entities []IDbEntity, ranks map[string]int) []unitOfWorkStatement {
+	order := make([]int, len(plans))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return ranks[plans[order[i]].tableName] < ranks[plans[order[j]].tableName]
+	})
+
+	var statements []unitOfWorkStatement
+	var batch []*insertPlan
+	batchKey := ""
+	flush := func() {
+		for start := 0; start < len(batch); start += u.batchSize {
+			end := start + u.batchSize
+			if end > len(batch) {
+				end = len(batch)
+			}
+			first := batch[start]
+			values := make([]interface{}, 0, len(first.columns)*(end-start))
+			for _, plan := range batch[start:end] {
+				values = append(values, alignedInsertValues(plan, first.columns)...)
+			}
+			statements = append(statements, unitOfWorkStatement{tableName: first.tableName, sql: u.repo.buildSaveSql(first, end-start), values: values})
+		}
+		batch, batchKey = nil, ""
+	}
+
+	for _, index := range order {
+		plan := plans[index]
+		if len(plan.scopePredicates) > 0 {
+			flush()
+			statement := unitOfWorkStatement{tableName: plan.tableName, sql: u.repo.buildSaveSql(plan, 1), values: plan.values, scopedPlan: plan}
+			if plan.skippedAutoIncrementColumn != "" {
+				statement.autoIncrementEntity = entities[index]
+				statement.autoIncrementColumn = plan.skippedAutoIncrementColumn
+			}
+			statements = append(statements, statement)
+			continue
+		}
+		if plan.skippedAutoIncrementColumn != "" {
+			flush()
+			statements = append(statements, unitOfWorkStatement{tableName: plan.tableName, sql: u.repo.buildSaveSql(plan, 1), values: plan.values,
+				autoIncrementEntity: entities[index], autoIncrementColumn: plan.skippedAutoIncrementColumn})
+			continue
+		}
+		key := insertPlanKey(plan)
+		if key != batchKey {
+			flush()
+			batchKey = key
+		}
+		batch = append(batch, plan)
+	}
+	flush()
+	return statements
+}
+
+/**
+ * 生成删除语句：按表依赖逆序，同表单列主键合并为 IN 条件
+ */
+func (u *UnitOfWork) deleteStatements(deletes []unitOfWorkOperation, ranks map[string]int) ([]unitOfWorkStatement, error) {
+	sort.SliceStable(deletes, func(i, j int) bool {
+		return ranks[u.repo.getTableName(deletes[i].entity)] > ranks[u.repo.getTableName(deletes[j].entity)]
+	})
+
+	var statements []unitOfWorkStatement
+	for start := 0; start < len(deletes); {
+		entityType := deletes[start].entity
+		tableName := u.repo.getTableName(entityType)
+		pkColumns := GetCrudManagerInstance().GetPrimaryKeyColumnNames(entityType)
+
+		// 复合主键或 map / 结构体形式的主键逐条删除
+		if len(pkColumns) != 1 || u.repo.extractKeyValues(deletes[start].id) != nil {
+			condition, params, err := u.repo.buildPrimaryKeyCondition(deletes[start].id, entityType)
+			if err != nil {
+				return nil, err
+			}
+			statement, err := u.deleteStatement(tableName, entityType, condition, params)
+			if err != nil {
+				return nil, err
+			}
+			statements = append(statements, statement)
+			start++
+			continue
+		}
+
+		end := start
+		ids := make([]interface{}, 0)
+		for end < len(deletes) && len(ids) < u.batchSize && u.repo.getTableName(deletes[end].entity) == tableName &&
+			u.repo.extractKeyValues(deletes[end].id) == nil {
+			ids = append(ids, deletes[end].id)
+			end++
+		}
+		condition := pkColumns[0] + " = ?"
+		if len(ids) > 1 {
+			condition = pkColumns[0] + " IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ") + ")"
+		}
+		statement, err := u.deleteStatement(tableName, entityType, condition, ids)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, statement)
+		start = end
+	}
+	return statements, nil
+}
+
+func (u *UnitOfWork) deleteStatement(tableName string, entityType IDbEntity, condition string, params []interface{}) (unitOfWorkStatement, error) {
+	where, params, err := u.repo.applyScope(entityType, condition, params)
+	if err != nil {
+		return unitOfWorkStatement{}, err
+	}
+	return unitOfWorkStatement{tableName: tableName, sql: "DELETE FROM " + tableName + " WHERE " + where, values: params}, nil
+}
+
+/**
+ * 插入计划的合并键（表名 + 排序后的列名）
+ */
+func insertPlanKey(plan *insertPlan) string {
+	columns := append([]string(nil), plan.columns...)
+	sort.Strings(columns)
+	return plan.tableName + "|" + strings.Join(columns, ",")
+}
+
+/**
+ * 按指定列顺序取出插入值（同组计划的列集合相同、顺序可能不同）
+ */
+func alignedInsertValues(plan *insertPlan, columns []string) []interface{} {
+	valueByColumn := make(map[string]interface{}, len(plan.columns))
+	for i, column := range plan.columns {
+		valueByColumn[column] = plan.values[i]
+	}
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		values[i] = valueByColumn[column]
+	}
+	return values
+}
+
+/**
+ * 按外键依赖计算表的层级（被引用的表层级更低，循环依赖时按登记顺序）
+ */
+func unitOfWorkTableRanks(entityTypes map[string]reflect.Type) map[string]int {
+	cm := GetCrudManagerInstance()
+	dependencies := make(map[string][]string, len(entityTypes))
+	for tableName, entityType := range entityTypes {
+		for _, fk := range cm.GetForeignKeys(entityType, tableName) {
+			if _, involved := entityTypes[fk.RefTable]; involved && fk.RefTable != tableName {
+				dependencies[tableName] = append(dependencies[tableName], fk.RefTable)
+			}
+		}
+	}
+
+	ranks := make(map[string]int, len(entityTypes))
+	visiting := make(map[string]bool)
+	var rankOf func(tableName string) int
+	rankOf = func(tableName string) int {
+		if rank, done := ranks[tableName]; done {
+			return rank
+		}
+		if visiting[tableName] {
+			return 0
+		}
+		visiting[tableName] = true
+		rank := 0
+		for _, parent := range dependencies[tableName] {
+			if parentRank := rankOf(parent) + 1; parentRank > rank {
+				rank = parentRank
+			}
+		}
+		visiting[tableName] = false
+		ranks[tableName] = rank
+		return rank
+	}
+	for tableName := range entityTypes {
+		rankOf(tableName)
+	}
+	return ranks
+}
@@ -0,0 +1,78 @@
+/**
+ * Package db233 (v2) - 稳定版 API 边界
+ *
+ * v2 提供整合后的 context 优先、泛型化的 API，底层完全复用 v1（pkg/db233）的实现，
+ * 两者可以在同一项目中共存，按模块逐步迁移：
+ *
+ *   import (
+ *       db233 "github.com/neko233-com/db233-go/pkg/db233"
+ *       db233v2 "github.com/neko233-com/db233-go/pkg/db233/v2"
+ *   )
+ *
+ *   repo := db233v2.NewRepository[Player](db)
+ *   player, err := repo.FindById(ctx, 1001)          // *Player，无需类型断言
+ *   if errors.Is(err, db233v2.ErrNotFound) { ... }
+ *
+ *   legacy := repo.V1()                                // 需要时退回 v1 的 BaseCrudRepository
+ *
+ * v2 中导出的类型与函数在 v2 生命周期内保持兼容；v1 新增的能力（作用域、行级安全策略、
+ * 关联加载等）通过类型别名与 V1() 直接可用
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+package db233
+
+import (
+	"context"
+	"errors"
+
+	v1 "github.com/neko233-com/db233-go/pkg/db233"
+)
+
+/**
+ * 与 v1 共享的类型（别名，可在两个版本之间直接传递）
+ */
+type (
+	Db               = v1.Db
+	Entity           = v1.IDbEntity
+	RepositoryScope  = v1.RepositoryScope
+	ScopePredicate   = v1.ScopePredicate
+	StructuredLogger = v1.StructuredLogger
+	TxManager        = v1.TransactionManager
+	TxOptions        = v1.TransactionOptions
+	UpsertOption     = v1.UpsertOption
+)
+
+/**
+ * Upsert 选项（与 v1 相同）
+ */
+var (
+	OnConflict = v1.OnConflict
+	DoUpdate   = v1.DoUpdate
+	DoNothing  = v1.DoNothing
+)
+
+/**
+ * 按主键查询不到记录（v1 返回 nil, nil）
+ */
+var ErrNotFound = errors.New("db233: 记录不存在")
+
+/**
+ * 在事务中执行（context 已取消时不开启事务）
+ *
+ * @param ctx 上下文，同时传给 fn
+ * @param db 数据库
+ * @param fn 事务函数，返回错误时回滚
+ */
+func WithTransaction(ctx context.Context, db *Db, fn func(ctx context.Context, tx *TxManager) error, opts ...TxOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return v1.WithTransaction(db, func(tx *v1.TransactionManager) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fn(ctx, tx)
+	}, opts...)
+}
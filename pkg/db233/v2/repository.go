@@ -0,0 +1,360 @@
+package db233
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/neko233-com/db233-go/pkg/db233"
+)
+
+/**
+ * 实体指针约束：*T 实现 Entity（v1 的实体方法均定义在指针接收者上）
+ */
+type EntityPtr[T any] interface {
+	*T
+	v1.IDbEntity
+}
+
+/**
+ * Repository - 泛型存储库
+ *
+ * 包装 v1 的 BaseCrudRepository：所有方法以 context 为第一个参数（用于实体钩子、行级安全策略），
+ * 返回 *T 而不是 IDbEntity
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type Repository[T any, PT EntityPtr[T]] struct {
+	repo *v1.BaseCrudRepository
+}
+
+/**
+ * 创建泛型存储库
+ *
+ * @param db 数据库
+ */
+func NewRepository[T any, PT EntityPtr[T]](db *Db) *Repository[T, PT] {
+	return &Repository[T, PT]{repo: v1.NewBaseCrudRepository(db)}
+}
+
+/**
+ * 从 v1 存储库创建（保留其作用域、关联、context 等配置）
+ */
+func FromV1[T any, PT EntityPtr[T]](repo *v1.BaseCrudRepository) *Repository[T, PT] {
+	return &Repository[T, PT]{repo: repo}
+}
+
+/**
+ * 获取底层的 v1 存储库
+ */
+func (r *Repository[T, PT]) V1() *v1.BaseCrudRepository {
+	return r.repo
+}
+
+/**
+ * 返回带作用域的副本
+ */
+func (r *Repository[T, PT]) WithScope(scope *RepositoryScope) *Repository[T, PT] {
+	return &Repository[T, PT]{repo: r.repo.WithScope(scope)}
+}
+
+/**
+ * 返回预加载关联的副本
+ */
+func (r *Repository[T, PT]) WithRelations(relations ...string) *Repository[T, PT] {
+	return &Repository[T, PT]{repo: r.repo.WithRelations(relations...)}
+}
+
+/**
+ * 保存（插入或按主键更新）
+ */
+func (r *Repository[T, PT]) Save(ctx context.Context, entity *T) error {
+	if entity == nil {
+		return v1.NewValidationException("实体不能为 nil")
+	}
+	return r.with(ctx).Save(PT(entity))
+}
+
+/**
+ * 批量保存
+ */
+func (r *Repository[T, PT]) SaveBatch(ctx context.Context, entities []*T) error {
+	batch, err := toV1Entities[T, PT](entities)
+	if err != nil {
+		return err
+	}
+	return r.with(ctx).SaveBatch(batch)
+}
+
+/**
+ * 插入，冲突时按选项更新或忽略（见 OnConflict / DoUpdate / DoNothing）
+ */
+func (r *Repository[T, PT]) Upsert(ctx context.Context, entity *T, options ...UpsertOption) error {
+	if entity == nil {
+		return v1.NewValidationException("实体不能为 nil")
+	}
+	return r.with(ctx).Upsert(PT(entity), options...)
+}
+
+/**
+ * 按主键更新
+ */
+func (r *Repository[T, PT]) Update(ctx context.Context, entity *T) error {
+	if entity == nil {
+		return v1.NewValidationException("实体不能为 nil")
+	}
+	return r.with(ctx).Update(PT(entity))
+}
+
+/**
+ * 按主键只更新指定列（列名或结构体字段名）
+ */
+func (r *Repository[T, PT]) UpdateColumns(ctx context.Context, entity *T, columns ...string) error {
+	if entity == nil {
+		return v1.NewValidationException("实体不能为 nil")
+	}
+	return r.with(ctx).UpdateColumns(PT(entity), columns...)
+}
+
+/**
+ * 按主键只更新非零值字段
+ */
+func (r *Repository[T, PT]) UpdateNonZero(ctx context.Context, entity *T) error {
+	if entity == nil {
+		return v1.NewValidationException("实体不能为 nil")
+	}
+	return r.with(ctx).UpdateNonZero(PT(entity))
+}
+
+/**
+ * 批量更新
+ */
+func (r *Repository[T, PT]) UpdateBatch(ctx context.Context, entities []*T) error {
+	batch, err := toV1Entities[T, PT](entities)
+	if err != nil {
+		return err
+	}
+	return r.with(ctx).UpdateBatch(batch)
+}
+
+/**
+ * 按主键删除
+ */
+func (r *Repository[T, PT]) DeleteById(ctx context.Context, id interface{}) error {
+	return r.with(ctx).DeleteById(id, r.entityType())
+}
+
+/**
+ * 按条件批量更新，返回影响行数
+ */
+func (r *Repository[T, PT]) UpdateWhere(ctx context.Context, setMap map[string]interface{}, condition string, params ...interface{}) (int64, error) {
+	return r.with(ctx).UpdateWhere(r.entityType(), setMap, condition, params)
+}
+
+/**
+ * 按条件批量删除，返回影响行数
+ */
+func (r *Repository[T, PT]) DeleteWhere(ctx context.Context, condition string, params ...interface{}) (int64, error) {
+	return r.with(ctx).DeleteWhere(r.entityType(), condition, params)
+}
+
+/**
+ * 按主键查询，不存在时返回 ErrNotFound
+ */
+func (r *Repository[T, PT]) FindById(ctx context.Context, id interface{}) (*T, error) {
+	entity, err := r.with(ctx).FindById(id, r.entityType())
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil {
+		return nil, ErrNotFound
+	}
+	return fromV1Entity[T, PT](entity)
+}
+
+/**
+ * 按主键批量查询（按 ids 顺序返回，不存在的跳过）
+ */
+func (r *Repository[T, PT]) FindByIds(ctx context.Context, ids ...interface{}) ([]*T, error) {
+	entities, err := r.with(ctx).FindByIds(ids, r.entityType())
+	if err != nil {
+		return nil, err
+	}
+	return fromV1Entities[T, PT](entities)
+}
+
+/**
+ * 查询全部
+ */
+func (r *Repository[T, PT]) FindAll(ctx context.Context) ([]*T, error) {
+	entities, err := r.with(ctx).FindAll(r.entityType())
+	if err != nil {
+		return nil, err
+	}
+	return fromV1Entities[T, PT](entities)
+}
+
+/**
+ * 按条件查询
+ *
+ * @param condition WHERE 条件（不含 WHERE 关键字），如 "level > ? AND status = ?"
+ * @param params 条件参数
+ */
+func (r *Repository[T, PT]) Find(ctx context.Context, condition string, params ...interface{}) ([]*T, error) {
+	entities, err := r.with(ctx).FindByCondition(condition, params, r.entityType())
+	if err != nil {
+		return nil, err
+	}
+	return fromV1Entities[T, PT](entities)
+}
+
+/**
+ * 分页查询（pageNo 从 1 开始）
+ */
+func (r *Repository[T, PT]) FindPage(ctx context.Context, pageNo int, pageSize int, condition string, params ...interface{}) ([]*T, error) {
+	entities, err := r.with(ctx).FindPage(condition, params, pageNo, pageSize, r.entityType())
+	if err != nil {
+		return nil, err
+	}
+	return fromV1Entities[T, PT](entities)
+}
+
+/**
+ * 游标分页结果
+ */
+type KeysetPage[T any] struct {
+	Items      []*T
+	NextCursor string
+	HasMore    bool
+}
+
+/**
+ * 游标（keyset）分页，首页 cursor 传空字符串，之后传上一页的 NextCursor
+ *
+ * @param orderBy 排序列，可带 ASC / DESC，为空时按主键升序
+ * @param condition WHERE 条件，可为空（同一游标的前后请求需使用相同条件）
+ */
+func (r *Repository[T, PT]) FindPageAfter(ctx context.Context, cursor string, pageSize int, orderBy string, condition string, params ...interface{}) (*KeysetPage[T], error) {
+	page, err := r.with(ctx).FindPageAfterByCondition(condition, params, r.entityType(), cursor, pageSize, orderBy)
+	if err != nil {
+		return nil, err
+	}
+	items, err := fromV1Entities[T, PT](page.Items)
+	if err != nil {
+		return nil, err
+	}
+	return &KeysetPage[T]{Items: items, NextCursor: page.NextCursor, HasMore: page.HasMore}, nil
+}
+
+/**
+ * 统计记录数
+ */
+func (r *Repository[T, PT]) Count(ctx context.Context) (int64, error) {
+	return r.with(ctx).Count(r.entityType())
+}
+
+/**
+ * 按条件统计记录数（条件为空时统计全表）
+ */
+func (r *Repository[T, PT]) CountWhere(ctx context.Context, condition string, params ...interface{}) (int64, error) {
+	return r.with(ctx).CountByCondition(r.entityType(), condition, params)
+}
+
+/**
+ * 按主键判断记录是否存在
+ */
+func (r *Repository[T, PT]) ExistsById(ctx context.Context, id interface{}) (bool, error) {
+	return r.with(ctx).ExistsById(id, r.entityType())
+}
+
+/**
+ * 判断是否存在满足条件的记录
+ */
+func (r *Repository[T, PT]) Exists(ctx context.Context, condition string, params ...interface{}) (bool, error) {
+	return r.with(ctx).ExistsByCondition(r.entityType(), condition, params)
+}
+
+/**
+ * 对列求和（列名或结构体字段名）
+ */
+func (r *Repository[T, PT]) Sum(ctx context.Context, column string, condition string, params ...interface{}) (float64, error) {
+	return r.with(ctx).SumColumn(r.entityType(), column, condition, params)
+}
+
+/**
+ * 对列求平均值
+ */
+func (r *Repository[T, PT]) Avg(ctx context.Context, column string, condition string, params ...interface{}) (float64, error) {
+	return r.with(ctx).AvgColumn(r.entityType(), column, condition, params)
+}
+
+/**
+ * 求列最大值，没有匹配行时返回 nil
+ */
+func (r *Repository[T, PT]) Max(ctx context.Context, column string, condition string, params ...interface{}) (interface{}, error) {
+	return r.with(ctx).MaxColumn(r.entityType(), column, condition, params)
+}
+
+/**
+ * 求列最小值，没有匹配行时返回 nil
+ */
+func (r *Repository[T, PT]) Min(ctx context.Context, column string, condition string, params ...interface{}) (interface{}, error) {
+	return r.with(ctx).MinColumn(r.entityType(), column, condition, params)
+}
+
+/**
+ * 绑定 context 的 v1 存储库
+ */
+func (r *Repository[T, PT]) with(ctx context.Context) *v1.BaseCrudRepository {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return r.repo.WithContext(ctx)
+}
+
+/**
+ * 实体类型样本（传给 v1 的 entityType 参数）
+ */
+func (r *Repository[T, PT]) entityType() v1.IDbEntity {
+	return PT(new(T))
+}
+
+/**
+ * *T 切片转换为 v1 实体切片
+ */
+func toV1Entities[T any, PT EntityPtr[T]](entities []*T) ([]v1.IDbEntity, error) {
+	result := make([]v1.IDbEntity, 0, len(entities))
+	for i, entity := range entities {
+		if entity == nil {
+			return nil, v1.NewValidationException(fmt.Sprintf("第 %d 个实体为 nil", i))
+		}
+		result = append(result, PT(entity))
+	}
+	return result, nil
+}
+
+/**
+ * v1 实体转换为 *T
+ */
+func fromV1Entity[T any, PT EntityPtr[T]](entity v1.IDbEntity) (*T, error) {
+	typed, ok := entity.(PT)
+	if !ok {
+		return nil, v1.NewDb233Exception(fmt.Sprintf("查询结果类型不匹配: 期望 %T, 得到 %T", new(T), entity))
+	}
+	return (*T)(typed), nil
+}
+
+/**
+ * v1 实体切片转换为 []*T
+ */
+func fromV1Entities[T any, PT EntityPtr[T]](entities []v1.IDbEntity) ([]*T, error) {
+	result := make([]*T, 0, len(entities))
+	for _, entity := range entities {
+		typed, err := fromV1Entity[T, PT](entity)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, typed)
+	}
+	return result, nil
+}
@@ -0,0 +1,361 @@
+package db233
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+/**
+ * Validator - 实体校验器
+ *
+ * Save / Update 写库前根据 validate 标签校验实体，汇总所有失败字段后
+ * 返回 ValidationError，避免脏数据进入数据库
+ *
+ * 内置规则（逗号分隔，如 validate:"required,max=255,email"）：
+ *   required   不能为零值（指针不能为 nil）
+ *   min=N      字符串 / 切片 / map 长度下限，数值下限
+ *   max=N      字符串 / 切片 / map 长度上限，数值上限
+ *   len=N      字符串 / 切片 / map 长度必须等于 N
+ *   email      合法邮箱地址（空字符串跳过，配合 required 使用）
+ *   oneof=a b  取值必须为空格分隔的候选值之一
+ *
+ * 实体实现 BeforeValidateHook 时，校验前先调用 BeforeValidate（可用于规范化字段）
+ *
+ * @author neko233-com
+ * @since 2026-10-16
+ */
+type Validator struct {
+	rules      map[string]ValidationRuleFunc
+	fieldRules map[reflect.Type][]validateField
+	mu         sync.RWMutex
+}
+
+/**
+ * ValidationRuleFunc - 校验规则函数，校验失败时返回错误描述
+ */
+type ValidationRuleFunc func(value reflect.Value, param string) error
+
+/**
+ * BeforeValidateHook - 校验前钩子（可选）
+ */
+type BeforeValidateHook interface {
+	BeforeValidate() error
+}
+
+/**
+ * 字段校验配置
+ */
+type validateField struct {
+	index  []int
+	name   string
+	column string
+	rules  []validateRule
+}
+
+/**
+ * 解析后的单条规则
+ */
+type validateRule struct {
+	name  string
+	param string
+}
+
+var (
+	validatorInstance *Validator
+	validatorOnce     sync.Once
+)
+
+/**
+ * 获取校验器单例
+ */
+func GetValidatorInstance() *Validator {
+	validatorOnce.Do(func() {
+		validatorInstance = &Validator{
+			rules:      make(map[string]ValidationRuleFunc),
+			fieldRules: make(map[reflect.Type][]validateField),
+		}
+		validatorInstance.registerBuiltinRules()
+	})
+	return validatorInstance
+}
+
+/**
+ * 注册自定义规则（同名覆盖内置规则）
+ */
+func (v *Validator) RegisterRule(name string, rule ValidationRuleFunc) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rules[name] = rule
+}
+
+/**
+ * 校验实体，全部通过时返回 nil，否则返回 *ValidationError
+ */
+func (v *Validator) Validate(entity interface{}) error {
+	return v.validate(entity, nil)
+}
+
+/**
+ * 只校验指定列（部分更新时使用），全部通过时返回 nil，否则返回 *ValidationError
+ *
+ * @param entity 实体
+ * @param columns 列名
+ */
+func (v *Validator) ValidateColumns(entity interface{}, columns []string) error {
+	if columns == nil {
+		columns = []string{}
+	}
+	return v.validate(entity, columns)
+}
+
+/**
+ * 校验实体，columns 为 nil 时校验全部字段
+ */
+func (v *Validator) validate(entity interface{}, columns []string) error {
+	if entity == nil {
+		return NewValidationException("实体不能为 nil")
+	}
+
+	if hook, ok := entity.(BeforeValidateHook); ok {
+		if err := hook.BeforeValidate(); err != nil {
+			return NewValidationExceptionWithCause(err, fmt.Sprintf("实体 %T 校验前钩子失败", entity))
+		}
+	}
+
+	value := reflect.ValueOf(entity)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return NewValidationException("实体不能为 nil")
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := v.getFieldRules(value.Type())
+	if len(fields) == 0 {
+		return nil
+	}
+
+	errors := make([]FieldError, 0)
+	for _, field := range fields {
+		if columns != nil && !containsColumn(columns, field.column) {
+			continue
+		}
+		fieldValue, err := value.FieldByIndexErr(field.index)
+		if err != nil {
+			// 嵌入的指针结构体为 nil，视为字段零值
+			fieldValue = reflect.Zero(value.Type().FieldByIndex(field.index).Type)
+		}
+
+		for _, rule := range field.rules {
+			if ruleErr := v.applyRule(rule, fieldValue); ruleErr != nil {
+				errors = append(errors, FieldError{
+					Field:   field.name,
+					Column:  field.column,
+					Rule:    rule.name,
+					Message: ruleErr.Error(),
+				})
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return NewValidationError(value.Type().Name(), errors)
+	}
+	return nil
+}
+
+/**
+ * 执行单条规则（非 required 规则对 nil 指针跳过）
+ */
+func (v *Validator) applyRule(rule validateRule, value reflect.Value) error {
+	if rule.name != "required" && value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	v.mu.RLock()
+	ruleFunc, exists := v.rules[rule.name]
+	v.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("未知的校验规则: %s", rule.name)
+	}
+	return ruleFunc(value, rule.param)
+}
+
+/**
+ * 获取类型的字段规则（按类型缓存）
+ */
+func (v *Validator) getFieldRules(t reflect.Type) []validateField {
+	v.mu.RLock()
+	fields, exists := v.fieldRules[t]
+	v.mu.RUnlock()
+	if exists {
+		return fields
+	}
+
+	fields = make([]validateField, 0)
+	collectValidateFieldsRecursive(t, nil, &fields)
+
+	v.mu.Lock()
+	v.fieldRules[t] = fields
+	v.mu.Unlock()
+	return fields
+}
+
+/**
+ * 递归收集带 validate 标签的字段（支持嵌入结构体）
+ */
+func collectValidateFieldsRecursive(t reflect.Type, parentIndex []int, fields *[]validateField) {
+	cm := GetCrudManagerInstance()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		index := append(append([]int(nil), parentIndex...), i)
+
+		if field.Anonymous {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				collectValidateFieldsRecursive(embeddedType, index, fields)
+				continue
+			}
+		}
+
+		tag := strings.TrimSpace(field.Tag.Get("validate"))
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		rules := make([]validateRule, 0)
+		for _, part := range strings.Split(tag, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			name, param, _ := strings.Cut(part, "=")
+			rules = append(rules, validateRule{name: strings.TrimSpace(name), param: strings.TrimSpace(param)})
+		}
+
+		column := cm.GetColumnName(field)
+		if column == "" {
+			column = field.Name
+		}
+		*fields = append(*fields, validateField{index: index, name: field.Name, column: column, rules: rules})
+	}
+}
+
+/**
+ * 注册内置规则
+ */
+func (v *Validator) registerBuiltinRules() {
+	v.rules["required"] = func(value reflect.Value, param string) error {
+		if !value.IsValid() || value.IsZero() {
+			return fmt.Errorf("不能为空")
+		}
+		return nil
+	}
+	v.rules["min"] = func(value reflect.Value, param string) error {
+		return compareValidateBound(value, param, "min")
+	}
+	v.rules["max"] = func(value reflect.Value, param string) error {
+		return compareValidateBound(value, param, "max")
+	}
+	v.rules["len"] = func(value reflect.Value, param string) error {
+		expected, err := strconv.Atoi(param)
+		if err != nil {
+			return fmt.Errorf("len 参数无效: %s", param)
+		}
+		length, ok := validateLength(value)
+		if !ok {
+			return fmt.Errorf("类型 %s 不支持 len 规则", value.Type())
+		}
+		if length != expected {
+			return fmt.Errorf("长度必须为 %d，实际为 %d", expected, length)
+		}
+		return nil
+	}
+	v.rules["email"] = func(value reflect.Value, param string) error {
+		if value.Kind() != reflect.String {
+			return fmt.Errorf("类型 %s 不支持 email 规则", value.Type())
+		}
+		email := value.String()
+		if email == "" {
+			return nil
+		}
+		if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+			return fmt.Errorf("邮箱格式不正确: %s", email)
+		}
+		return nil
+	}
+	v.rules["oneof"] = func(value reflect.Value, param string) error {
+		actual := fmt.Sprintf("%v", value.Interface())
+		for _, candidate := range strings.Fields(param) {
+			if actual == candidate {
+				return nil
+			}
+		}
+		return fmt.Errorf("取值必须为 [%s] 之一，实际为 %s", param, actual)
+	}
+}
+
+/**
+ * min / max 规则：字符串和容器比较长度，数值比较大小
+ */
+func compareValidateBound(value reflect.Value, param string, ruleName string) error {
+	bound, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fmt.Errorf("%s 参数无效: %s", ruleName, param)
+	}
+
+	var actual float64
+	var unit string
+	if length, ok := validateLength(value); ok {
+		actual = float64(length)
+		unit = "长度"
+	} else {
+		switch value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			actual = float64(value.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			actual = float64(value.Uint())
+		case reflect.Float32, reflect.Float64:
+			actual = value.Float()
+		default:
+			return fmt.Errorf("类型 %s 不支持 %s 规则", value.Type(), ruleName)
+		}
+		unit = "值"
+	}
+
+	if ruleName == "min" && actual < bound {
+		return fmt.Errorf("%s不能小于 %s，实际为 %v", unit, param, actual)
+	}
+	if ruleName == "max" && actual > bound {
+		return fmt.Errorf("%s不能大于 %s，实际为 %v", unit, param, actual)
+	}
+	return nil
+}
+
+/**
+ * 获取字符串（按字符数）/ 切片 / 数组 / map 的长度
+ */
+func validateLength(value reflect.Value) (int, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(value.String()), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return value.Len(), true
+	default:
+		return 0, false
+	}
+}
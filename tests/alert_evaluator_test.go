@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 值可调整的数据源
+type adjustableDataSource struct {
+	name   string
+	mu     sync.Mutex
+	values map[string]interface{}
+}
+
+func (s *adjustableDataSource) GetName() string { return s.name }
+
+func (s *adjustableDataSource) GetMetrics() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[string]interface{}, len(s.values))
+	for k, v := range s.values {
+		result[k] = v
+	}
+	return result
+}
+
+func (s *adjustableDataSource) set(metric string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[metric] = value
+}
+
+func activeAlertRuleIds(manager *db233.AlertManager) map[string]bool {
+	active := make(map[string]bool)
+	for _, alert := range manager.GetActiveAlerts() {
+		active[alert.RuleID] = true
+	}
+	return active
+}
+
+// 测试按最新值与速率表达式自动触发、解决告警
+func TestAlertEvaluator_LatestAndRate(t *testing.T) {
+	manager := db233.NewAlertManager("evaluator_test")
+	manager.AddAlertRule(db233.AlertRule{
+		ID: "pool_active", Name: "活跃连接过多", Metric: "pool.active",
+		Condition: db233.GreaterThan, Threshold: 10, Severity: db233.Warning, Enabled: true,
+	})
+	manager.AddAlertRule(db233.AlertRule{
+		ID: "query_rate", Name: "查询速率过高", Metric: "rate(pool.queries[1m])",
+		Condition: db233.GreaterThan, Threshold: 5.0, Severity: db233.Error, Enabled: true,
+	})
+	manager.AddAlertRule(db233.AlertRule{
+		ID: "disabled", Name: "已禁用", Metric: "pool.idle",
+		Condition: db233.GreaterThan, Threshold: 0, Enabled: false,
+	})
+
+	source := &adjustableDataSource{name: "pool", values: map[string]interface{}{"active": 12, "queries": int64(100), "idle": 3}}
+	evaluator := db233.NewAlertEvaluator("pool", manager).AddDataSource(source)
+
+	start := time.Now()
+	values := evaluator.Evaluate(start)
+	if values["pool.active"] != 12 {
+		t.Errorf("最新值不正确: %v", values)
+	}
+	if _, exists := values["rate(pool.queries[1m])"]; exists {
+		t.Error("采样不足两个时不应计算速率")
+	}
+	if _, exists := values["pool.idle"]; exists {
+		t.Error("禁用的规则不应评估")
+	}
+	if active := activeAlertRuleIds(manager); !active["pool_active"] || active["query_rate"] {
+		t.Errorf("应只触发活跃连接告警（int 阈值与 float64 值比较）: %v", active)
+	}
+
+	// 10 秒内增长 100，速率 10/s
+	source.set("queries", int64(200))
+	source.set("active", 4)
+	values = evaluator.Evaluate(start.Add(10 * time.Second))
+	if values["rate(pool.queries[1m])"] != 10 {
+		t.Errorf("速率不正确: %v", values)
+	}
+	if active := activeAlertRuleIds(manager); active["pool_active"] || !active["query_rate"] {
+		t.Errorf("活跃连接告警应解决，速率告警应触发: %v", active)
+	}
+
+	// 超出窗口的采样不参与计算（60 秒内增长 10）
+	source.set("queries", int64(210))
+	values = evaluator.Evaluate(start.Add(70 * time.Second))
+	if rate := values["rate(pool.queries[1m])"]; rate < 0.16 || rate > 0.17 {
+		t.Errorf("窗口外的采样不应参与速率计算: %v", values)
+	}
+	if active := activeAlertRuleIds(manager); active["query_rate"] {
+		t.Error("速率告警应解决")
+	}
+}
+
+// 测试窗口聚合与聚合器指标
+func TestAlertEvaluator_WindowAndAggregator(t *testing.T) {
+	manager := db233.NewAlertManager("evaluator_window_test")
+	manager.AddAlertRule(db233.AlertRule{
+		ID: "latency_avg", Name: "平均延迟过高", Metric: "avg(perf.latency_ms[5m])",
+		Condition: db233.GreaterThan, Threshold: 100.0, Enabled: true,
+	})
+	manager.AddAlertRule(db233.AlertRule{
+		ID: "latency_max", Name: "最大延迟", Metric: "max(perf.latency_ms[5m])",
+		Condition: db233.GreaterThanOrEqual, Threshold: 300, Enabled: true,
+	})
+	manager.AddAlertRule(db233.AlertRule{
+		ID: "total_errors", Name: "错误总数", Metric: "total_errors",
+		Condition: db233.GreaterThan, Threshold: 5.0, Enabled: true,
+	})
+
+	source := &adjustableDataSource{name: "perf", values: map[string]interface{}{"latency_ms": 50.0, "errors": 4}}
+	other := &adjustableDataSource{name: "replica", values: map[string]interface{}{"errors": 3}}
+	aggregator := db233.NewMetricsAggregator("errors")
+	aggregator.SetCacheDuration(0)
+	aggregator.AddDataSource(source)
+	aggregator.AddDataSource(other)
+	aggregator.AddAggregationRule("total_errors", db233.AggregationRule{MetricPattern: "errors", Aggregation: db233.Sum, Enabled: true})
+
+	evaluator := db233.NewAlertEvaluator("window", manager).AddDataSource(source).AddAggregator(aggregator)
+	start := time.Now()
+	evaluator.Evaluate(start)
+	source.set("latency_ms", 300.0)
+	values := evaluator.Evaluate(start.Add(time.Minute))
+
+	if values["avg(perf.latency_ms[5m])"] != 175 || values["max(perf.latency_ms[5m])"] != 300 {
+		t.Errorf("窗口聚合不正确: %v", values)
+	}
+	if values["total_errors"] != 7 {
+		t.Errorf("聚合器指标不正确: %v", values)
+	}
+	if active := activeAlertRuleIds(manager); !active["latency_avg"] || !active["latency_max"] || !active["total_errors"] {
+		t.Errorf("告警应触发: %v", active)
+	}
+	if status := evaluator.GetStatus(); status["evaluations"] != int64(2) {
+		t.Errorf("评估次数不正确: %v", status["evaluations"])
+	}
+}
+
+// 测试定期评估与停止
+func TestAlertEvaluator_StartStop(t *testing.T) {
+	manager := db233.NewAlertManager("evaluator_loop_test")
+	manager.AddAlertRule(db233.AlertRule{
+		ID: "pool_active", Name: "活跃连接过多", Metric: "pool.active",
+		Condition: db233.GreaterThan, Threshold: 10, Enabled: true,
+	})
+	source := &adjustableDataSource{name: "pool", values: map[string]interface{}{"active": 20}}
+	evaluator := db233.NewAlertEvaluator("loop", manager).AddDataSource(source).SetEvaluationInterval(10 * time.Millisecond)
+	evaluator.Start()
+	defer evaluator.Stop()
+
+	deadline := time.Now().Add(time.Second)
+	for len(manager.GetActiveAlerts()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(manager.GetActiveAlerts()) != 1 {
+		t.Fatal("定期评估应自动触发告警")
+	}
+	source.set("active", 1)
+	for len(manager.GetActiveAlerts()) != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(manager.GetActiveAlerts()) != 0 {
+		t.Error("定期评估应自动解决告警")
+	}
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试表达式校验
+func TestAlertExpression_Validate(t *testing.T) {
+	valid := []string{
+		"error_rate > 0.05 && qps > 100",
+		"pool.p99_ms > 500 for 5m",
+		"-a + 2 * (b - 1) >= 3 || !(c == 1)",
+		"rate(perf.total_queries [1m]) / 60 > 10",
+	}
+	for _, expression := range valid {
+		if err := db233.ValidateAlertExpression(expression); err != nil {
+			t.Errorf("表达式应有效: %s, 错误=%v", expression, err)
+		}
+	}
+	invalid := []string{"", "a >", "(a > 1", "a $ b", "rate(x) > 1", "a > 1 for abc", "a > 1 )"}
+	for _, expression := range invalid {
+		if err := db233.ValidateAlertExpression(expression); err == nil {
+			t.Errorf("表达式应无效: %q", expression)
+		}
+	}
+
+	manager := db233.NewAlertManager("expression_validate")
+	if err := manager.AddExpressionRule(db233.AlertRule{ID: "bad", Expression: "a >"}); err == nil {
+		t.Error("无效表达式不应添加")
+	}
+	if len(manager.GetAlertRules()) != 0 {
+		t.Error("无效表达式不应添加规则")
+	}
+}
+
+// 测试组合条件的触发、解决与缺失指标
+func TestAlertExpression_Composite(t *testing.T) {
+	manager := db233.NewAlertManager("expression_composite")
+	err := manager.AddExpressionRule(db233.AlertRule{
+		ID: "error_burst", Name: "错误突增", Severity: db233.Error, Enabled: true,
+		Expression: "error_rate > 0.05 && qps > 100",
+	})
+	if err != nil {
+		t.Fatalf("添加表达式规则失败: %v", err)
+	}
+	manager.AddAlertRule(db233.AlertRule{
+		ID: "precedence", Name: "优先级", Enabled: true,
+		Expression: "-a + 2 * 3 > 5 || !(b == 1)",
+	})
+
+	manager.CheckExpressions(map[string]float64{"error_rate": 0.1, "qps": 50, "a": 0, "b": 1})
+	if active := activeAlertRuleIds(manager); len(active) != 1 || !active["precedence"] {
+		t.Errorf("qps 不足时不应触发错误突增告警: %v", active)
+	}
+
+	manager.CheckExpressions(map[string]float64{"error_rate": 0.1, "qps": 150, "a": 2, "b": 1})
+	active := manager.GetActiveAlerts()
+	if len(active) != 1 || active[0].RuleID != "error_burst" {
+		t.Fatalf("错误突增告警应触发，优先级告警应解决: %v", activeAlertRuleIds(manager))
+	}
+	if operands, ok := active[0].Value.(map[string]float64); !ok || operands["qps"] != 150 || operands["error_rate"] != 0.1 {
+		t.Errorf("告警值应为引用的指标: %v", active[0].Value)
+	}
+
+	// 缺失指标时保持原状态
+	manager.CheckExpressions(map[string]float64{"error_rate": 0})
+	if !activeAlertRuleIds(manager)["error_burst"] {
+		t.Error("指标缺失时不应解决告警")
+	}
+	manager.CheckExpressions(map[string]float64{"error_rate": 0, "qps": 150})
+	if activeAlertRuleIds(manager)["error_burst"] {
+		t.Error("条件不满足时应解决告警")
+	}
+}
+
+// 测试 for 持续时间：抖动的指标不触发告警
+func TestAlertExpression_For(t *testing.T) {
+	manager := db233.NewAlertManager("expression_for")
+	if err := manager.AddExpressionRule(db233.AlertRule{ID: "slow_p99", Name: "P99 持续偏高", Enabled: true, Expression: "p99_ms > 500 for 5m"}); err != nil {
+		t.Fatalf("添加表达式规则失败: %v", err)
+	}
+	if rules := manager.GetAlertRules(); rules[0].For != 5*time.Minute {
+		t.Errorf("for 应设置 For: %v", rules[0].For)
+	}
+
+	start := time.Now()
+	check := func(offset time.Duration, p99 float64) bool {
+		manager.EvaluateExpressionRules(func(metric string) (float64, bool) { return p99, metric == "p99_ms" }, start.Add(offset))
+		return activeAlertRuleIds(manager)["slow_p99"]
+	}
+	if check(0, 800) {
+		t.Error("首次满足时应处于等待状态")
+	}
+	if check(2*time.Minute, 300) || check(3*time.Minute, 800) || check(7*time.Minute, 800) {
+		t.Error("条件中断后应重新计时")
+	}
+	if manager.GetStatus()["pending_alerts"] != 1 {
+		t.Errorf("应有一个等待中的告警: %v", manager.GetStatus()["pending_alerts"])
+	}
+	if !check(8*time.Minute, 800) {
+		t.Error("持续满足 5 分钟后应触发")
+	}
+	if check(9*time.Minute, 100) {
+		t.Error("条件不满足时应解决")
+	}
+
+	// 单指标规则同样支持 For
+	manager.AddAlertRule(db233.AlertRule{
+		ID: "active", Name: "活跃连接", Metric: "active", Condition: db233.GreaterThan,
+		Threshold: 10, Enabled: true, For: 30 * time.Millisecond,
+	})
+	manager.CheckMetric("active", 20)
+	if activeAlertRuleIds(manager)["active"] {
+		t.Error("单指标规则首次满足时应处于等待状态")
+	}
+	time.Sleep(40 * time.Millisecond)
+	manager.CheckMetric("active", 20)
+	if !activeAlertRuleIds(manager)["active"] {
+		t.Error("单指标规则持续满足后应触发")
+	}
+}
+
+// 测试评估器自动评估表达式规则
+func TestAlertExpression_Evaluator(t *testing.T) {
+	manager := db233.NewAlertManager("expression_evaluator")
+	manager.AddExpressionRule(db233.AlertRule{
+		ID: "busy_and_failing", Name: "高负载且失败", Enabled: true,
+		Expression: "rate(perf.queries[1m]) > 5 && perf.error_rate > 0.05",
+	})
+	source := &adjustableDataSource{name: "perf", values: map[string]interface{}{"queries": int64(0), "error_rate": 0.1}}
+	evaluator := db233.NewAlertEvaluator("expression", manager).AddDataSource(source)
+
+	start := time.Now()
+	evaluator.Evaluate(start)
+	source.set("queries", int64(100))
+	values := evaluator.Evaluate(start.Add(10 * time.Second))
+	if values["rate(perf.queries[1m])"] != 10 || values["perf.error_rate"] != 0.1 {
+		t.Errorf("表达式引用的指标值不正确: %v", values)
+	}
+	if !activeAlertRuleIds(manager)["busy_and_failing"] {
+		t.Error("评估器应触发表达式告警")
+	}
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 记录分组通知的通知器
+type groupRecordingNotifier struct {
+	mu     sync.Mutex
+	groups []*db233.AlertGroupNotification
+}
+
+func (n *groupRecordingNotifier) GetName() string { return "group_recording" }
+
+func (n *groupRecordingNotifier) Notify(alert *db233.Alert) error { return nil }
+
+func (n *groupRecordingNotifier) NotifyGroup(group *db233.AlertGroupNotification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.groups = append(n.groups, group)
+	return nil
+}
+
+func (n *groupRecordingNotifier) snapshot() []*db233.AlertGroupNotification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]*db233.AlertGroupNotification(nil), n.groups...)
+}
+
+func newGroupingTestDb(groupName string, dbId int) *db233.Db {
+	return &db233.Db{DbId: dbId, DbGroup: &db233.DbGroup{GroupName: groupName}}
+}
+
+// 测试同一 DbGroup 的多个库同时越过阈值时只通知一次
+func TestAlertGrouping_Aggregate(t *testing.T) {
+	manager := db233.NewAlertManager("grouping_test")
+	grouped := &groupRecordingNotifier{}
+	plain := &recordingAlertNotifier{}
+	manager.AddNotifier(grouped)
+	manager.AddNotifier(plain)
+	manager.SetGrouping(&db233.AlertGroupingConfig{
+		GroupBy:       []db233.EnumAlertGroupKey{db233.AlertGroupBySeverity, db233.AlertGroupByDbGroup},
+		GroupWait:     50 * time.Millisecond,
+		GroupInterval: time.Hour,
+	})
+
+	for i := 1; i <= 20; i++ {
+		manager.AddAlertRule(db233.AlertRule{
+			ID: fmt.Sprintf("lag_%d", i), Name: "复制延迟", Metric: fmt.Sprintf("orders.%d.lag", i),
+			Condition: db233.GreaterThan, Threshold: 10, Severity: db233.Critical, Enabled: true,
+			MaintenanceDb: newGroupingTestDb("orders", i),
+		})
+		manager.CheckMetric(fmt.Sprintf("orders.%d.lag", i), 30)
+	}
+	manager.CheckMetric("orders.1.lag", 40) // 冷却期内重复触发不重复计入
+
+	if manager.GetStatus()["pending_groups"] != 1 || len(grouped.snapshot()) != 0 {
+		t.Fatal("GroupWait 期间应等待收集同组告警")
+	}
+	time.Sleep(150 * time.Millisecond)
+
+	groups := grouped.snapshot()
+	if len(groups) != 1 {
+		t.Fatalf("20 个库应合并为一次通知: %d", len(groups))
+	}
+	group := groups[0]
+	if group.Key != "severity=critical,db_group=orders" || group.Labels["db_group"] != "orders" {
+		t.Errorf("分组键不正确: %s %v", group.Key, group.Labels)
+	}
+	if len(group.Alerts) != 20 || len(group.Targets) != 20 || group.Targets[0] != "orders/1" {
+		t.Errorf("分组应列出全部受影响目标: %v", group.Targets)
+	}
+	if plain.count() != 1 {
+		t.Errorf("不支持分组的通知器应收到一条汇总告警: %v", plain.notified)
+	}
+
+	// GroupInterval 内的新告警等待下一次发送，Stop 时立即发送
+	manager.AddAlertRule(db233.AlertRule{
+		ID: "lag_21", Name: "复制延迟", Metric: "orders.21.lag", Condition: db233.GreaterThan,
+		Threshold: 10, Severity: db233.Critical, Enabled: true, MaintenanceDb: newGroupingTestDb("orders", 21),
+	})
+	manager.CheckMetric("orders.21.lag", 30)
+	time.Sleep(80 * time.Millisecond)
+	if len(grouped.snapshot()) != 1 {
+		t.Error("GroupInterval 内不应再次通知")
+	}
+	manager.Stop()
+	groups = grouped.snapshot()
+	if len(groups) != 2 || len(groups[1].Targets) != 1 || groups[1].Targets[0] != "orders/21" {
+		t.Errorf("停止时应发送等待中的分组: %d", len(groups))
+	}
+}
+
+// 测试不同分组分别通知，静默的告警不进入分组
+func TestAlertGrouping_SeparateGroups(t *testing.T) {
+	manager := db233.NewAlertManager("grouping_separate")
+	grouped := &groupRecordingNotifier{}
+	manager.AddNotifier(grouped)
+	manager.SetGrouping(&db233.AlertGroupingConfig{GroupBy: []db233.EnumAlertGroupKey{db233.AlertGroupByRule}, GroupWait: time.Hour})
+
+	manager.AddAlertRule(db233.AlertRule{ID: "slow", Name: "慢查询", Metric: "latency", Condition: db233.GreaterThan, Threshold: 100, Severity: db233.Warning, Enabled: true})
+	manager.AddAlertRule(db233.AlertRule{ID: "errors", Name: "错误", Metric: "errors", Condition: db233.GreaterThan, Threshold: 0, Severity: db233.Error, Enabled: true, Target: "payment"})
+	manager.AddAlertRule(db233.AlertRule{ID: "muted", Name: "已静默", Metric: "muted", Condition: db233.GreaterThan, Threshold: 0, Enabled: true})
+	manager.Silence("muted", time.Now().Add(time.Hour))
+	manager.CheckMetric("latency", 200)
+	manager.CheckMetric("errors", 3)
+	manager.CheckMetric("muted", 1)
+
+	manager.FlushAlertGroups()
+	groups := grouped.snapshot()
+	if len(groups) != 2 {
+		t.Fatalf("不同规则应分别通知，静默告警不通知: %d", len(groups))
+	}
+	targets := map[string]string{}
+	for _, group := range groups {
+		targets[group.Labels["rule"]] = group.Targets[0]
+	}
+	if targets["slow"] != "latency" || targets["errors"] != "payment" {
+		t.Errorf("目标应取 Target 或指标名: %v", targets)
+	}
+
+	// 关闭分组后恢复逐条通知
+	manager.SetGrouping(nil)
+	if manager.GetStatus()["pending_groups"] != 0 {
+		t.Error("关闭分组后不应有等待中的分组")
+	}
+}
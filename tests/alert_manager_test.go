@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试告警确认与手动解决
+func TestAlertManager_AckAndResolveManually(t *testing.T) {
+	manager := db233.NewAlertManager("ack_test")
+	manager.AddAlertRule(db233.AlertRule{
+		ID:        "slow",
+		Name:      "慢查询",
+		Metric:    "latency_ms",
+		Condition: db233.GreaterThan,
+		Threshold: 100,
+		Severity:  db233.Warning,
+		Enabled:   true,
+	})
+
+	if err := manager.Ack("missing", "alice", ""); err == nil {
+		t.Error("确认不存在的告警应返回错误")
+	}
+
+	manager.CheckMetric("latency_ms", 200)
+	active := manager.GetActiveAlerts()
+	if len(active) != 1 {
+		t.Fatalf("应触发 1 个告警, 得到 %d", len(active))
+	}
+	alertID := active[0].ID
+
+	if err := manager.Ack(alertID, "alice", "正在排查"); err != nil {
+		t.Fatalf("确认告警失败: %v", err)
+	}
+	if active[0].Status != db233.Acknowledged || active[0].AcknowledgedBy != "alice" || active[0].AcknowledgedAt == nil {
+		t.Errorf("告警确认信息不正确: %+v", active[0])
+	}
+
+	// 已确认的告警再次触发时只刷新当前值
+	manager.CheckMetric("latency_ms", 300)
+	if active[0].Status != db233.Acknowledged || active[0].Value != 300 {
+		t.Errorf("已确认告警应保持确认状态并刷新值: %+v", active[0])
+	}
+	if stats := manager.GetAlertStats(); stats["acknowledged_alerts"] != 1 {
+		t.Errorf("已确认告警数量应为 1, 得到 %v", stats["acknowledged_alerts"])
+	}
+
+	if err := manager.ResolveManually(alertID); err != nil {
+		t.Fatalf("手动解决告警失败: %v", err)
+	}
+	if len(manager.GetActiveAlerts()) != 0 {
+		t.Error("手动解决后不应有活跃告警")
+	}
+	history := manager.GetAlertHistory(0)
+	if len(history) != 1 || history[0].Status != db233.Resolved || !history[0].ResolvedManually || history[0].AckNote != "正在排查" {
+		t.Errorf("告警历史应记录确认与手动解决信息: %+v", history)
+	}
+
+	generator := db233.NewMonitoringReportGenerator("ack_report")
+	generator.AddAlertManager("orders", manager)
+	alerts := generator.GenerateReportData().Details.Alerts
+	if len(alerts) != 1 || !alerts[0].ResolvedManually || alerts[0].AcknowledgedBy != "alice" {
+		t.Errorf("报告应包含告警处理信息: %+v", alerts)
+	}
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 记录告警平台请求的测试服务
+type alertPlatformRecorder struct {
+	mu       sync.Mutex
+	paths    []string
+	headers  []http.Header
+	bodies   []map[string]interface{}
+	response int
+}
+
+func newAlertPlatformServer(recorder *alertPlatformRecorder) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		recorder.mu.Lock()
+		recorder.paths = append(recorder.paths, r.URL.RequestURI())
+		recorder.headers = append(recorder.headers, r.Header.Clone())
+		recorder.bodies = append(recorder.bodies, body)
+		status := recorder.response
+		recorder.mu.Unlock()
+		if status == 0 {
+			status = http.StatusAccepted
+		}
+		w.WriteHeader(status)
+	}))
+}
+
+func (r *alertPlatformRecorder) waitFor(t *testing.T, count int) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		n := len(r.bodies)
+		r.mu.Unlock()
+		if n >= count {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("等待告警平台请求超时: 期望 %d 个", count)
+}
+
+// 测试 PagerDuty 事件：严重程度映射、去重键与自动解决
+func TestAlertNotifiers_PagerDuty(t *testing.T) {
+	recorder := &alertPlatformRecorder{}
+	server := newAlertPlatformServer(recorder)
+	defer server.Close()
+
+	manager := db233.NewAlertManager("pagerduty_test")
+	manager.AddNotifier(db233.NewPagerDutyAlertNotifier("routing-123").SetEndpoint(server.URL).SetTimeout(time.Second))
+	manager.AddAlertRule(db233.AlertRule{
+		ID: "pool_full", Name: "连接池耗尽", Description: "等待连接数过多", Metric: "pool.waiting",
+		Condition: db233.GreaterThan, Threshold: 0, Severity: db233.Critical, Enabled: true,
+		MaintenanceDb: &db233.Db{DbId: 3, DbGroup: &db233.DbGroup{GroupName: "orders"}},
+	})
+
+	manager.CheckMetric("pool.waiting", 5)
+	recorder.waitFor(t, 1)
+	trigger := recorder.bodies[0]
+	payload := trigger["payload"].(map[string]interface{})
+	if trigger["routing_key"] != "routing-123" || trigger["event_action"] != "trigger" || trigger["dedup_key"] != "pool_full_pool.waiting" {
+		t.Errorf("触发事件不正确: %v", trigger)
+	}
+	if payload["severity"] != "critical" || payload["source"] != "orders/3" || payload["group"] != "orders" {
+		t.Errorf("事件内容不正确: %v", payload)
+	}
+
+	manager.CheckMetric("pool.waiting", 0)
+	recorder.waitFor(t, 2)
+	resolve := recorder.bodies[1]
+	if resolve["event_action"] != "resolve" || resolve["dedup_key"] != "pool_full_pool.waiting" || resolve["payload"] != nil {
+		t.Errorf("解决事件不正确: %v", resolve)
+	}
+
+	recorder.mu.Lock()
+	recorder.response = http.StatusBadRequest
+	recorder.mu.Unlock()
+	if err := db233.NewPagerDutyAlertNotifier("k").SetEndpoint(server.URL).Notify(&db233.Alert{ID: "x"}); err == nil {
+		t.Error("非 2xx 响应应返回错误")
+	}
+}
+
+// 测试 OpsGenie 告警：优先级映射、鉴权头、按别名关闭与代理
+func TestAlertNotifiers_OpsGenie(t *testing.T) {
+	recorder := &alertPlatformRecorder{}
+	server := newAlertPlatformServer(recorder)
+	defer server.Close()
+
+	notifier := db233.NewOpsGenieAlertNotifier("genie-key").SetEndpoint(server.URL + "/v2/alerts").SetTags("db233").SetResponders("dba")
+	alert := &db233.Alert{ID: "slow_perf latency", Name: "慢查询", Severity: db233.Warning, Metric: "perf.latency", Target: "perf.latency", Status: db233.Active}
+	if err := notifier.Notify(alert); err != nil {
+		t.Fatalf("创建告警失败: %v", err)
+	}
+	alert.Status = db233.Resolved
+	if err := notifier.Notify(alert); err != nil {
+		t.Fatalf("关闭告警失败: %v", err)
+	}
+
+	if recorder.headers[0].Get("Authorization") != "GenieKey genie-key" {
+		t.Errorf("鉴权头不正确: %v", recorder.headers[0])
+	}
+	created := recorder.bodies[0]
+	if created["priority"] != "P3" || created["alias"] != "slow_perf latency" || created["message"] != "慢查询" {
+		t.Errorf("告警内容不正确: %v", created)
+	}
+	if recorder.paths[1] != "/v2/alerts/slow_perf%20latency/close?identifierType=alias" {
+		t.Errorf("应按别名关闭告警: %s", recorder.paths[1])
+	}
+
+	// 代理：请求经由代理服务转发
+	proxied := &alertPlatformRecorder{}
+	proxy := newAlertPlatformServer(proxied)
+	defer proxy.Close()
+	proxyUrl, _ := url.Parse(proxy.URL)
+	if err := db233.NewOpsGenieAlertNotifier("k").SetEndpoint("http://opsgenie.invalid/v2/alerts").SetProxy(proxyUrl).Notify(alert); err != nil {
+		t.Fatalf("经代理发送失败: %v", err)
+	}
+	if len(proxied.paths) != 1 || proxied.paths[0] != "/v2/alerts/slow_perf%20latency/close?identifierType=alias" {
+		t.Errorf("请求应经过代理: %v", proxied.paths)
+	}
+}
@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 记录通知的通知器
+type recordingAlertNotifier struct {
+	mu       sync.Mutex
+	notified []string
+}
+
+func (n *recordingAlertNotifier) GetName() string { return "recording" }
+
+func (n *recordingAlertNotifier) Notify(alert *db233.Alert) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.notified = append(n.notified, alert.RuleID)
+	return nil
+}
+
+func (n *recordingAlertNotifier) count() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.notified)
+}
+
+func newSilenceTestManager(name string) (*db233.AlertManager, *recordingAlertNotifier) {
+	manager := db233.NewAlertManager(name)
+	notifier := &recordingAlertNotifier{}
+	manager.AddNotifier(notifier)
+	manager.AddAlertRule(db233.AlertRule{
+		ID: "slow", Name: "慢查询", Metric: "perf.latency_ms", Condition: db233.GreaterThan,
+		Threshold: 100, Severity: db233.Warning, Enabled: true,
+	})
+	manager.AddAlertRule(db233.AlertRule{
+		ID: "pool_full", Name: "连接池耗尽", Metric: "pool.waiting", Condition: db233.GreaterThan,
+		Threshold: 0, Severity: db233.Critical, Enabled: true,
+	})
+	return manager, notifier
+}
+
+// 测试静默：告警照常记录但不通知，取消静默后恢复通知
+func TestAlertSilence_SilenceAndUnsilence(t *testing.T) {
+	manager, notifier := newSilenceTestManager("silence_test")
+
+	silenceID := manager.Silence("slow", time.Now().Add(time.Hour))
+	manager.SilenceMatching(db233.AlertMatcher{Metric: "pool.*", Severities: []db233.AlertSeverity{db233.Warning}}, time.Now().Add(time.Hour), "alice", "不匹配严重程度")
+	manager.CheckMetric("perf.latency_ms", 200)
+	manager.CheckMetric("pool.waiting", 3)
+
+	time.Sleep(20 * time.Millisecond)
+	if notifier.count() != 1 || notifier.notified[0] != "pool_full" {
+		t.Errorf("只有未静默的告警应通知: %v", notifier.notified)
+	}
+	alerts := make(map[string]*db233.Alert)
+	for _, alert := range manager.GetActiveAlerts() {
+		alerts[alert.RuleID] = alert
+	}
+	if alerts["slow"] == nil || !alerts["slow"].Silenced || alerts["slow"].SilencedBy != silenceID {
+		t.Errorf("静默的告警应照常记录并标记: %+v", alerts["slow"])
+	}
+	if alerts["pool_full"] == nil || alerts["pool_full"].Silenced {
+		t.Error("严重程度不匹配的静默不应生效")
+	}
+
+	stats := manager.GetAlertStats()
+	if stats["silenced_alerts"] != 1 || stats["active_silences"] != 2 {
+		t.Errorf("统计应包含静默状态: %v", stats)
+	}
+	generator := db233.NewMonitoringReportGenerator("silence_report")
+	generator.AddAlertManager("orders", manager)
+	report := generator.GenerateReportData()
+	if report.Summary.SilencedAlerts != 1 {
+		t.Errorf("报告摘要应包含静默告警数: %d", report.Summary.SilencedAlerts)
+	}
+	silencedInReport := false
+	for _, alert := range report.Details.Alerts {
+		silencedInReport = silencedInReport || (alert.Silenced && alert.SilencedBy == silenceID)
+	}
+	if !silencedInReport {
+		t.Errorf("报告应包含静默信息: %+v", report.Details.Alerts)
+	}
+
+	if !manager.Unsilence(silenceID) || manager.Unsilence(silenceID) {
+		t.Error("取消静默应只成功一次")
+	}
+	if len(manager.GetSilences()) != 1 {
+		t.Errorf("应剩余一个静默: %v", manager.GetSilences())
+	}
+	manager.ResolveManually(alerts["slow"].ID)
+	manager.CheckMetric("perf.latency_ms", 200)
+	time.Sleep(20 * time.Millisecond)
+	if notifier.count() != 2 {
+		t.Errorf("取消静默后应恢复通知: %v", notifier.notified)
+	}
+
+	// 过期的静默自动失效
+	manager.Silence("pool_full", time.Now().Add(-time.Second))
+	if len(manager.GetSilences()) != 1 {
+		t.Error("过期的静默应被清理")
+	}
+}
+
+// 测试维护窗口（一次性与周期）
+func TestAlertSilence_MaintenanceWindow(t *testing.T) {
+	if err := db233.NewAlertManager("invalid").AddMaintenanceWindow(db233.AlertMaintenanceWindow{Name: "bad"}); err == nil {
+		t.Error("没有时间范围的维护窗口应返回错误")
+	}
+
+	// 周日 23:00 起 2 小时，跨零点
+	weekly := db233.AlertMaintenanceWindow{
+		Name: "weekly", Weekdays: []time.Weekday{time.Sunday},
+		DailyStart: 23 * time.Hour, Duration: 2 * time.Hour, Location: time.UTC,
+	}
+	sunday := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	cases := map[time.Duration]bool{
+		22 * time.Hour:                    false,
+		23*time.Hour + 30*time.Minute:     true,
+		24*time.Hour + 30*time.Minute:     true, // 周一 00:30
+		25*time.Hour + time.Minute:        false,
+		7*24*time.Hour + 23*time.Hour + 1: true,
+	}
+	for offset, expected := range cases {
+		if weekly.IsActive(sunday.Add(offset)) != expected {
+			t.Errorf("周期窗口判断错误: %v 应为 %v", sunday.Add(offset), expected)
+		}
+	}
+
+	manager, notifier := newSilenceTestManager("maintenance_test")
+	err := manager.AddMaintenanceWindow(db233.AlertMaintenanceWindow{
+		Name:     "migration",
+		Matcher:  db233.AlertMatcher{RuleID: "pool_*"},
+		StartsAt: time.Now().Add(-time.Minute),
+		EndsAt:   time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("添加维护窗口失败: %v", err)
+	}
+	manager.CheckMetric("pool.waiting", 3)
+	manager.CheckMetric("perf.latency_ms", 200)
+	time.Sleep(20 * time.Millisecond)
+	if notifier.count() != 1 || notifier.notified[0] != "slow" {
+		t.Errorf("维护窗口内匹配的告警不应通知: %v", notifier.notified)
+	}
+	for _, alert := range manager.GetActiveAlerts() {
+		if alert.RuleID == "pool_full" && (!alert.Silenced || alert.SilencedBy != "maintenance:migration") {
+			t.Errorf("维护窗口内的告警应标记: %+v", alert)
+		}
+	}
+	if windows := manager.GetAlertStats()["active_maintenance_windows"].([]string); len(windows) != 1 || windows[0] != "migration" {
+		t.Errorf("统计应包含生效的维护窗口: %v", windows)
+	}
+
+	// Acknowledge 等同于不带备注的 Ack
+	alertID := manager.GetActiveAlerts()[0].ID
+	if err := manager.Acknowledge(alertID, "bob"); err != nil {
+		t.Fatalf("确认失败: %v", err)
+	}
+	manager.RemoveMaintenanceWindow("migration")
+	if len(manager.GetMaintenanceWindows()) != 0 {
+		t.Error("维护窗口应被移除")
+	}
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// AuditTestUser 带审计标签的测试实体
+type AuditTestUser struct {
+	ID       int    `db:"id,primary_key,auto_increment"`
+	Username string `db:"username" audit:"true"`
+	Email    string `db:"email" audit:"mask"`
+	Age      int    `db:"age" audit:"-"`
+}
+
+func (u *AuditTestUser) TableName() string {
+	return "test_user"
+}
+
+func (u *AuditTestUser) SerializeBeforeSaveDb() {}
+
+func (u *AuditTestUser) DeserializeAfterLoadDb() {}
+
+// 测试审计开关
+func TestAuditLogger_IsAudited(t *testing.T) {
+	logger := db233.NewAuditLogger(nil, db233.NewMemoryAuditSink())
+
+	if !logger.IsAudited(&AuditTestUser{}) {
+		t.Error("带 audit 标签的实体应开启审计")
+	}
+	if logger.IsAudited(&TestUser{}) {
+		t.Error("未注册且无 audit 标签的实体不应开启审计")
+	}
+	logger.RegisterEntity(&TestUser{})
+	if !logger.IsAudited(&TestUser{}) {
+		t.Error("注册后的实体应开启审计")
+	}
+
+	rules := db233.GetAuditRules(&AuditTestUser{})
+	if rules["email"] != "mask" || rules["age"] != "-" {
+		t.Errorf("审计标签解析错误: %v", rules)
+	}
+}
+
+// 测试审计记录（需要数据库）
+func TestAuditLogger_RecordChanges(t *testing.T) {
+	db := CreateTestDb(t)
+	defer db.Close()
+	if err := SetupTestTables(db); err != nil {
+		t.Fatalf("初始化测试表失败: %v", err)
+	}
+
+	sink := db233.NewMemoryAuditSink()
+	logger := db233.NewAuditLogger(db, sink)
+
+	user := &AuditTestUser{Username: "test_audit", Email: "a@example.com", Age: 20}
+	if err := logger.Save("admin", user); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	defer db.DataSource.Exec("DELETE FROM test_user WHERE id = ?", user.ID)
+
+	user.Username = "test_audit_renamed"
+	user.Email = "b@example.com"
+	user.Age = 21
+	if err := logger.Update("operator", user); err != nil {
+		t.Fatalf("更新失败: %v", err)
+	}
+	if err := logger.DeleteById("admin", user.ID, &AuditTestUser{}); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+
+	history, err := logger.GetChangeHistory(user.ID, &AuditTestUser{})
+	if err != nil {
+		t.Fatalf("查询审计历史失败: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("期望 3 条审计记录, 得到 %d", len(history))
+	}
+	if history[0].Action != db233.EnumAuditActionCreate || history[1].Action != db233.EnumAuditActionUpdate || history[2].Action != db233.EnumAuditActionDelete {
+		t.Errorf("审计操作顺序错误: %s, %s, %s", history[0].Action, history[1].Action, history[2].Action)
+	}
+	if history[1].Actor != "operator" {
+		t.Errorf("操作人错误: %s", history[1].Actor)
+	}
+
+	// 更新只改了 username（email 脱敏后相同，age 不参与审计）
+	if len(history[1].Changes) != 1 || history[1].Changes[0].Column != "username" {
+		t.Errorf("更新变更不正确: %+v", history[1].Changes)
+	}
+}
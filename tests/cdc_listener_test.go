@@ -0,0 +1,220 @@
+package tests
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type CdcPlayer struct {
+	Id        int64     `db:"id,primary_key"`
+	Name      string    `db:"name"`
+	Gold      int64     `db:"gold"`
+	CreatedAt time.Time `db:"created_at"`
+
+	loaded bool
+}
+
+func (e *CdcPlayer) TableName() string { return "cdc_player" }
+
+func (e *CdcPlayer) SerializeBeforeSaveDb() {}
+
+func (e *CdcPlayer) DeserializeAfterLoadDb() { e.loaded = true }
+
+// 内存位点存储
+type memoryCDCPositionStore struct {
+	positions map[string]string
+}
+
+func (s *memoryCDCPositionStore) Load(name string) (string, error) { return s.positions[name], nil }
+func (s *memoryCDCPositionStore) Save(name string, position string) error {
+	s.positions[name] = position
+	return nil
+}
+
+// 模拟 binlog 变更流：从位点之后开始投递
+func newFakeBinlogSource(changes []db233.CDCRowChange) db233.CDCSourceFunc {
+	return func(ctx context.Context, position string, handler func(change db233.CDCRowChange) error) error {
+		started := position == ""
+		for _, change := range changes {
+			if !started {
+				started = change.Position == position
+				continue
+			}
+			if err := handler(change); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// 测试类型化事件、未注册表过滤与位点推进
+func TestCDCListener_DispatchTypedEvents(t *testing.T) {
+	changes := []db233.CDCRowChange{
+		{Table: "cdc_player", Type: db233.EnumCDCChangeTypeInsert, Position: "binlog.000001:100",
+			After: map[string]interface{}{"id": int64(1), "name": []byte("alice"), "gold": "10", "created_at": "2026-10-16 08:00:00"}},
+		{Table: "other_table", Type: db233.EnumCDCChangeTypeInsert, Position: "binlog.000001:200",
+			After: map[string]interface{}{"id": int64(9)}},
+		{Table: "cdc_player", Type: db233.EnumCDCChangeTypeUpdate, Position: "binlog.000001:300",
+			Before: map[string]interface{}{"id": int64(1), "gold": float64(10)},
+			After:  map[string]interface{}{"id": int64(1), "gold": float64(25), "unknown_column": "x"}},
+		{Table: "cdc_player", Type: db233.EnumCDCChangeTypeDelete, Position: "binlog.000001:400",
+			Before: map[string]interface{}{"id": int64(1)}},
+	}
+	store := &memoryCDCPositionStore{positions: map[string]string{}}
+
+	var events []*db233.CDCEvent
+	listener := db233.NewCDCListener("cache_sync", newFakeBinlogSource(changes)).
+		SetPositionStore(store).
+		Register(&CdcPlayer{}, func(event *db233.CDCEvent) error {
+			events = append(events, event)
+			return nil
+		})
+
+	if err := listener.Run(context.Background()); err != nil {
+		t.Fatalf("运行监听失败: %v", err)
+	}
+	if len(events) != 3 || listener.GetEventCount() != 3 {
+		t.Fatalf("只应投递已注册表的 3 个事件: %d", len(events))
+	}
+
+	inserted := events[0].After.(*CdcPlayer)
+	if events[0].Before != nil || inserted.Name != "alice" || inserted.Gold != 10 || inserted.CreatedAt.Hour() != 8 || !inserted.loaded {
+		t.Errorf("插入事件映射不正确: %+v", inserted)
+	}
+	if events[1].Before.(*CdcPlayer).Gold != 10 || events[1].After.(*CdcPlayer).Gold != 25 {
+		t.Errorf("更新事件的前后镜像不正确: %+v %+v", events[1].Before, events[1].After)
+	}
+	if events[2].After != nil || events[2].Before.(*CdcPlayer).Id != 1 || events[2].Type != db233.EnumCDCChangeTypeDelete {
+		t.Errorf("删除事件不正确: %+v", events[2])
+	}
+	if store.positions["cache_sync"] != "binlog.000001:400" || listener.GetLastPosition() != "binlog.000001:400" {
+		t.Errorf("位点应推进到最后一个变更: %v", store.positions)
+	}
+}
+
+// 测试处理器失败时停止且不推进位点，重启后从上次位点之后重新投递
+func TestCDCListener_ResumeAfterHandlerError(t *testing.T) {
+	changes := []db233.CDCRowChange{
+		{Table: "cdc_player", Type: db233.EnumCDCChangeTypeInsert, Position: "p1", After: map[string]interface{}{"id": int64(1)}},
+		{Table: "cdc_player", Type: db233.EnumCDCChangeTypeInsert, Position: "p2", After: map[string]interface{}{"id": int64(2)}},
+		{Table: "cdc_player", Type: db233.EnumCDCChangeTypeInsert, Position: "p3", After: map[string]interface{}{"id": int64(3)}},
+	}
+	store := &memoryCDCPositionStore{positions: map[string]string{}}
+
+	failOn := int64(2)
+	var delivered []int64
+	handler := func(event *db233.CDCEvent) error {
+		id := event.After.(*CdcPlayer).Id
+		if id == failOn {
+			return errors.New("索引服务不可用")
+		}
+		delivered = append(delivered, id)
+		return nil
+	}
+
+	err := db233.NewCDCListener("index_sync", newFakeBinlogSource(changes)).SetPositionStore(store).Register(&CdcPlayer{}, handler).Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "索引服务不可用") {
+		t.Fatalf("处理器错误应返回: %v", err)
+	}
+	if store.positions["index_sync"] != "p1" {
+		t.Errorf("失败的变更不应推进位点: %v", store.positions)
+	}
+
+	failOn = 0
+	if err := db233.NewCDCListener("index_sync", newFakeBinlogSource(changes)).SetPositionStore(store).Register(&CdcPlayer{}, handler).Run(context.Background()); err != nil {
+		t.Fatalf("重启后运行失败: %v", err)
+	}
+	if !reflect.DeepEqual(delivered, []int64{1, 2, 3}) {
+		t.Errorf("重启后应从 p1 之后继续投递: %v", delivered)
+	}
+}
+
+// 模拟 PostgreSQL 复制槽：peek 返回 wal2json 输出，advance 推进已确认位点
+type cdcPgSlot struct {
+	mu       sync.Mutex
+	changes  [][]driver.Value
+	advanced []string
+}
+
+func newCdcPgDb(t *testing.T, slot *cdcPgSlot) *db233.Db {
+	fake := newFakeDriver()
+	fake.onExec = func(stmt fakeStatement) (driver.Result, error) {
+		slot.mu.Lock()
+		defer slot.mu.Unlock()
+		if strings.Contains(stmt.query, "pg_replication_slot_advance") {
+			lsn := stmt.args[1].(string)
+			slot.advanced = append(slot.advanced, lsn)
+			for len(slot.changes) > 0 && slot.changes[0][0].(string) <= lsn {
+				slot.changes = slot.changes[1:]
+			}
+		}
+		return driver.RowsAffected(1), nil
+	}
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		slot.mu.Lock()
+		defer slot.mu.Unlock()
+		if !strings.Contains(stmt.query, "pg_logical_slot_peek_changes") {
+			return nil, errors.New("未知查询: " + stmt.query)
+		}
+		values := slot.changes
+		if limit := int(stmt.args[1].(int64)); len(values) > limit {
+			values = values[:limit]
+		}
+		return fakeRowsOf([]string{"lsn", "data"}, append([][]driver.Value(nil), values...)...), nil
+	}
+	return db233.NewDb(fake.open(t, ""), 1, nil)
+}
+
+// 测试 wal2json 解析、按事务确认位点，截断的事务下次重新读取
+func TestCDCListener_PostgresLogicalReplication(t *testing.T) {
+	slot := &cdcPgSlot{changes: [][]driver.Value{
+		{"0/A1", `{"action":"B"}`},
+		{"0/A2", `{"action":"I","schema":"public","table":"cdc_player","timestamp":"2026-10-16 08:00:00.123456+08","columns":[{"name":"id","type":"bigint","value":1},{"name":"name","type":"text","value":"alice"}]}`},
+		{"0/A3", `{"action":"U","schema":"public","table":"cdc_player","columns":[{"name":"id","type":"bigint","value":1},{"name":"name","type":"text","value":"bob"}],"identity":[{"name":"id","type":"bigint","value":1},{"name":"name","type":"text","value":"alice"}]}`},
+		{"0/A4", `{"action":"C"}`},
+		{"0/B1", `{"action":"B"}`},
+		{"0/B2", `{"action":"D","schema":"public","table":"cdc_player","identity":[{"name":"id","type":"bigint","value":1}]}`},
+		{"0/B3", `{"action":"C"}`},
+	}}
+	source := db233.NewPostgresLogicalReplicationSource(newCdcPgDb(t, slot), "db233_cdc").
+		SetBatchSize(6).
+		SetPollInterval(10 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var events []*db233.CDCEvent
+	listener := db233.NewCDCListener("pg_sync", source).Register(&CdcPlayer{}, func(event *db233.CDCEvent) error {
+		events = append(events, event)
+		if len(events) == 3 {
+			cancel()
+		}
+		return nil
+	})
+	if err := listener.Run(ctx); err != nil {
+		t.Fatalf("运行监听失败: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("应收到 3 个事件: %d", len(events))
+	}
+	if events[0].After.(*CdcPlayer).Name != "alice" || events[0].Timestamp.IsZero() || events[0].Raw.Schema != "public" {
+		t.Errorf("插入事件不正确: %+v", events[0])
+	}
+	if events[1].Before.(*CdcPlayer).Name != "alice" || events[1].After.(*CdcPlayer).Name != "bob" {
+		t.Errorf("更新事件不正确: %+v", events[1])
+	}
+	if events[2].Type != db233.EnumCDCChangeTypeDelete || events[2].Before.(*CdcPlayer).Id != 1 {
+		t.Errorf("删除事件不正确: %+v", events[2])
+	}
+	if !reflect.DeepEqual(slot.advanced, []string{"0/A4", "0/B3"}) || listener.GetLastPosition() != "0/B3" {
+		t.Errorf("应按事务提交位点推进复制槽: %v, 最后位点=%s", slot.advanced, listener.GetLastPosition())
+	}
+}
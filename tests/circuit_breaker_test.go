@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type BreakerOrder struct {
+	Id int64 `db:"id,primary_key"`
+}
+
+func (e BreakerOrder) TableName() string { return "breaker_order" }
+
+func (e BreakerOrder) SerializeBeforeSaveDb() {}
+
+func (e BreakerOrder) DeserializeAfterLoadDb() {}
+
+// 测试失败率超过阈值后快速失败，半开探测成功后恢复
+func TestCircuitBreaker_OpenAndRecover(t *testing.T) {
+	// failing 为 1 时所有语句返回连接错误，到达驱动的语句由假驱动记录
+	failing := int32(1)
+	fake := newFakeDriver()
+	fake.onExec = func(stmt fakeStatement) (driver.Result, error) {
+		if atomic.LoadInt32(&failing) == 1 {
+			return nil, errors.New("dial tcp: connection refused")
+		}
+		return driver.RowsAffected(1), nil
+	}
+
+	breaker := db233.NewCircuitBreaker("orders", db233.CircuitBreakerConfig{
+		WindowSize:       10,
+		MinimumCalls:     4,
+		OpenDuration:     50 * time.Millisecond,
+		HalfOpenMaxCalls: 2,
+	})
+	transitions := make([]string, 0)
+	breaker.OnStateChange(func(breaker *db233.CircuitBreaker, from, to db233.EnumCircuitBreakerState) {
+		transitions = append(transitions, string(from)+"->"+string(to))
+	})
+	repo := db233.NewBaseCrudRepository(fake.newDb(t, db233.EnumDatabaseTypeMySQL).SetCircuitBreaker(breaker))
+
+	for i := 0; i < 4; i++ {
+		if err := repo.Save(&BreakerOrder{Id: int64(i + 1)}); err == nil {
+			t.Fatal("数据库不可用时保存应失败")
+		}
+	}
+	if breaker.GetState() != db233.EnumCircuitBreakerStateOpen {
+		t.Fatalf("失败率达到阈值应打开: %+v", breaker.GetSnapshot())
+	}
+
+	err := repo.Save(&BreakerOrder{Id: 5})
+	var openException *db233.CircuitOpenException
+	if !errors.As(err, &openException) || openException.BreakerName != "orders" {
+		t.Fatalf("打开期间应返回熔断异常: %v", err)
+	}
+	if _, err := repo.FindById(int64(1), &BreakerOrder{}); !errors.As(err, &openException) {
+		t.Errorf("打开期间 FindById 应返回熔断异常: %v", err)
+	}
+	if _, err := repo.FindByCondition("id > ?", []interface{}{0}, &BreakerOrder{}); !errors.As(err, &openException) {
+		t.Errorf("打开期间 FindByCondition 应返回熔断异常: %v", err)
+	}
+	if queries := len(fake.recorded()); queries != 4 {
+		t.Errorf("打开期间请求不应到达数据库: %d", queries)
+	}
+
+	// 半开探测失败重新打开
+	time.Sleep(60 * time.Millisecond)
+	repo.Save(&BreakerOrder{Id: 6})
+	if breaker.GetState() != db233.EnumCircuitBreakerStateOpen {
+		t.Errorf("半开探测失败应重新打开: %s", breaker.GetState())
+	}
+
+	// 数据库恢复后探测成功并闭合
+	atomic.StoreInt32(&failing, 0)
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if err := repo.Save(&BreakerOrder{Id: int64(i + 1)}); err != nil {
+			t.Fatalf("半开探测应放行: %v", err)
+		}
+	}
+	if breaker.GetState() != db233.EnumCircuitBreakerStateClosed {
+		t.Errorf("探测全部成功应闭合: %s", breaker.GetState())
+	}
+	expected := []string{"closed->open", "open->half_open", "half_open->open", "open->half_open", "half_open->closed"}
+	if len(transitions) != len(expected) {
+		t.Fatalf("状态变化不正确: %v", transitions)
+	}
+	for i := range expected {
+		if transitions[i] != expected[i] {
+			t.Errorf("状态变化不正确: %v", transitions)
+			break
+		}
+	}
+}
+
+// 测试慢调用率、不计入失败的错误、告警与监控快照
+func TestCircuitBreaker_SlowCallsAlertsAndSnapshot(t *testing.T) {
+	breaker := db233.NewCircuitBreaker("reports", db233.CircuitBreakerConfig{
+		SlowCallThreshold: 5 * time.Millisecond,
+		WindowSize:        4,
+		MinimumCalls:      4,
+		OpenDuration:      time.Minute,
+	})
+	alertManager := db233.NewAlertManager("breaker")
+	alertManager.AddCircuitBreakerRule(breaker)
+	dashboard := db233.NewMonitoringDashboard("breaker")
+	dashboard.AddCircuitBreaker("reports", breaker)
+
+	for i := 0; i < 3; i++ {
+		breaker.Execute(func() error { return sql.ErrNoRows })
+	}
+	if snapshot := breaker.GetSnapshot(); snapshot.State != db233.EnumCircuitBreakerStateClosed || snapshot.FailureRate != 0 || snapshot.Calls != 3 {
+		t.Errorf("ErrNoRows 不应计入失败: %+v", snapshot)
+	}
+	breaker.Execute(func() error { time.Sleep(10 * time.Millisecond); return nil })
+	breaker.Execute(func() error { time.Sleep(10 * time.Millisecond); return nil })
+	if breaker.GetState() != db233.EnumCircuitBreakerStateOpen {
+		t.Fatalf("慢调用率达到阈值应打开: %+v", breaker.GetSnapshot())
+	}
+	if err := breaker.Execute(func() error { return nil }); err == nil {
+		t.Error("打开期间应拒绝执行")
+	}
+
+	if alerts := alertManager.GetActiveAlerts(); len(alerts) != 1 || alerts[0].Severity != db233.Critical {
+		t.Errorf("打开时应产生严重告警: %+v", alerts)
+	}
+	snapshot := dashboard.GetCurrentSnapshot()
+	if state := snapshot.CircuitBreakers["reports"]; state.State != db233.EnumCircuitBreakerStateOpen || state.Rejected != 1 {
+		t.Errorf("监控快照应包含熔断器状态: %+v", state)
+	}
+	if _, ok := snapshot.Components["circuit_breaker_reports"]; !ok {
+		t.Error("监控组件应包含熔断器")
+	}
+
+	breaker.Reset()
+	if len(alertManager.GetActiveAlerts()) != 0 {
+		t.Error("闭合后告警应自动解决")
+	}
+}
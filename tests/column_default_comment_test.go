@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 带默认值与注释标签的测试实体
+type ColumnOptionPlayer struct {
+	Id        int64     `db:"id,primary_key,auto_increment" comment:"玩家 ID"`
+	Gold      int64     `db:"gold,not_null" default:"0" comment:"player gold balance"`
+	Nickname  string    `db:"nickname" size:"64" default:"new player" comment:"it's the nickname"`
+	Vip       bool      `db:"vip" default:"false"`
+	Bio       string    `db:"bio" size:"70000" default:"hello"`
+	Remark    string    `db:"remark" size:"32" default:""`
+	CreatedAt time.Time `db:"created_at" default:"CURRENT_TIMESTAMP"`
+}
+
+func (e *ColumnOptionPlayer) TableName() string { return "column_option_player" }
+
+func (e *ColumnOptionPlayer) SerializeBeforeSaveDb() {}
+
+func (e *ColumnOptionPlayer) DeserializeAfterLoadDb() {}
+
+func columnOptionField(t *testing.T, name string) reflect.StructField {
+	field, ok := reflect.TypeOf(ColumnOptionPlayer{}).FieldByName(name)
+	if !ok {
+		t.Fatalf("字段不存在: %s", name)
+	}
+	return field
+}
+
+// 测试 MySQL 建表 / 加列 / 改列 SQL 中的 DEFAULT 与 COMMENT
+func TestColumnDefaultComment_MySQL(t *testing.T) {
+	strategy := db233.GetStrategyFactoryInstance().GetStrategy(db233.EnumDatabaseTypeMySQL)
+
+	createSql, err := strategy.GenerateCreateTableSQL("column_option_player", reflect.TypeOf(ColumnOptionPlayer{}), "")
+	if err != nil {
+		t.Fatalf("生成建表 SQL 失败: %v", err)
+	}
+	for _, expected := range []string{
+		"`id` BIGINT AUTO_INCREMENT NOT NULL COMMENT '玩家 ID'",
+		"`gold` BIGINT NOT NULL DEFAULT 0 COMMENT 'player gold balance'",
+		"DEFAULT 'new player' COMMENT 'it''s the nickname'",
+		"NULL DEFAULT FALSE",
+		"DEFAULT ('hello')",
+		"NULL DEFAULT ''",
+		"NULL DEFAULT CURRENT_TIMESTAMP",
+	} {
+		if !strings.Contains(createSql, expected) {
+			t.Errorf("建表 SQL 缺少 %q:\n%s", expected, createSql)
+		}
+	}
+
+	addSql, err := strategy.GenerateAddColumnSQL("column_option_player", columnOptionField(t, "Gold"), "gold")
+	if err != nil {
+		t.Fatalf("生成加列 SQL 失败: %v", err)
+	}
+	if !strings.HasSuffix(addSql, "NOT NULL DEFAULT 0 COMMENT 'player gold balance'") {
+		t.Errorf("加列 SQL 应带默认值与注释: %s", addSql)
+	}
+
+	modifySql, err := strategy.GenerateModifyColumnSQL("column_option_player", columnOptionField(t, "Nickname"), "nickname")
+	if err != nil {
+		t.Fatalf("生成改列 SQL 失败: %v", err)
+	}
+	if !strings.Contains(modifySql, "DEFAULT 'new player' COMMENT 'it''s the nickname'") {
+		t.Errorf("改列 SQL 应保留默认值与注释: %s", modifySql)
+	}
+}
+
+// 测试 SQL Server 的 DEFAULT 与 MS_Description 列注释
+func TestColumnDefaultComment_SQLServer(t *testing.T) {
+	strategy := db233.GetStrategyFactoryInstance().GetStrategy(db233.EnumDatabaseTypeSQLServer)
+
+	createSql, err := strategy.GenerateCreateTableSQL("column_option_player", reflect.TypeOf(ColumnOptionPlayer{}), "")
+	if err != nil {
+		t.Fatalf("生成建表 SQL 失败: %v", err)
+	}
+	for _, expected := range []string{
+		"[gold] BIGINT NOT NULL DEFAULT 0",
+		"[nickname] NVARCHAR(64) NULL DEFAULT N'new player'",
+		"[vip] BIT NULL DEFAULT 0",
+		"[created_at] DATETIME2 NULL DEFAULT CURRENT_TIMESTAMP",
+		"DECLARE @schema sysname = SCHEMA_NAME();",
+		"@value = N'player gold balance'",
+		"@value = N'it''s the nickname'",
+		"@level2name = N'gold'",
+	} {
+		if !strings.Contains(createSql, expected) {
+			t.Errorf("建表 SQL 缺少 %q:\n%s", expected, createSql)
+		}
+	}
+	if strings.Contains(createSql, " COMMENT ") {
+		t.Errorf("SQL Server 不应生成 COMMENT 子句:\n%s", createSql)
+	}
+
+	addSql, err := strategy.GenerateAddColumnSQL("column_option_player", columnOptionField(t, "Vip"), "vip")
+	if err != nil {
+		t.Fatalf("生成加列 SQL 失败: %v", err)
+	}
+	if addSql != "ALTER TABLE [column_option_player] ADD [vip] BIT NULL DEFAULT 0" {
+		t.Errorf("无注释的加列 SQL 不应附加扩展属性: %s", addSql)
+	}
+}
+
+// 测试无 default / comment 标签时列定义保持不变
+func TestColumnDefaultComment_NoTags(t *testing.T) {
+	strategy := db233.GetStrategyFactoryInstance().GetStrategy(db233.EnumDatabaseTypeMySQL)
+	createSql, err := strategy.GenerateCreateTableSQL("sqlserver_order", reflect.TypeOf(SqlServerOrder{}), "")
+	if err != nil {
+		t.Fatalf("生成建表 SQL 失败: %v", err)
+	}
+	columnsSql := strings.Replace(createSql, " DEFAULT CHARSET", "", 1)
+	if strings.Contains(columnsSql, " DEFAULT ") || strings.Contains(columnsSql, " COMMENT ") {
+		t.Errorf("未声明标签时不应生成 DEFAULT / COMMENT:\n%s", createSql)
+	}
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// PlayerItemEntity 玩家物品（复合主键：region + player_id + item_id）
+type PlayerItemEntity struct {
+	Region   string `db:"region,primary_key"`
+	PlayerId int64  `db:"player_id,primary_key"`
+	ItemId   int    `db:"item_id,primary_key"`
+	Count    int    `db:"count"`
+}
+
+func (e *PlayerItemEntity) TableName() string {
+	return "player_item"
+}
+
+func (e *PlayerItemEntity) SerializeBeforeSaveDb() {}
+
+func (e *PlayerItemEntity) DeserializeAfterLoadDb() {}
+
+// 测试复合主键元数据
+func TestCompositePrimaryKey_Metadata(t *testing.T) {
+	cm := db233.GetCrudManagerInstance()
+	entity := &PlayerItemEntity{Region: "cn", PlayerId: 10, ItemId: 3, Count: 5}
+
+	columns := cm.GetPrimaryKeyColumnNames(entity)
+	if strings.Join(columns, ",") != "region,player_id,item_id" {
+		t.Fatalf("复合主键列不正确: %v", columns)
+	}
+	if !cm.IsCompositePrimaryKey(entity) {
+		t.Error("应识别为复合主键")
+	}
+
+	key, ok := cm.GetPrimaryKeyId(entity).(map[string]interface{})
+	if !ok || len(key) != 3 || key["region"] != "cn" || key["player_id"] != int64(10) || key["item_id"] != 3 {
+		t.Errorf("复合主键标识不正确: %v", cm.GetPrimaryKeyId(entity))
+	}
+
+	metadata, err := db233.GetEntityMetadataCacheInstance().GetOrBuild(entity)
+	if err != nil {
+		t.Fatalf("构建元数据失败: %v", err)
+	}
+	if metadata.PrimaryKeyColumn != "region" || len(metadata.PrimaryKeyColumns) != 3 {
+		t.Errorf("元数据主键不正确: %s %v", metadata.PrimaryKeyColumn, metadata.PrimaryKeyColumns)
+	}
+}
+
+// 测试复合主键建表 SQL
+func TestCompositePrimaryKey_CreateTableSQL(t *testing.T) {
+	strategy := db233.NewMySQLStrategy(db233.GetCrudManagerInstance())
+	sql, err := strategy.GenerateCreateTableSQL("player_item", reflect.TypeOf(PlayerItemEntity{}), "region")
+	if err != nil {
+		t.Fatalf("生成建表 SQL 失败: %v", err)
+	}
+	if !strings.Contains(sql, "PRIMARY KEY (`region`, `player_id`, `item_id`)") {
+		t.Errorf("建表 SQL 应包含复合主键: %s", sql)
+	}
+}
+
+// 测试复合主键参数校验（不访问数据库）
+func TestCompositePrimaryKey_Validation(t *testing.T) {
+	repo := db233.NewBaseCrudRepository(nil)
+
+	if _, err := repo.FindById(10, &PlayerItemEntity{}); err == nil {
+		t.Error("复合主键实体传入标量主键应返回错误")
+	}
+	if err := repo.DeleteById(map[string]interface{}{"region": "cn", "player_id": 10}, &PlayerItemEntity{}); err == nil {
+		t.Error("缺少主键列应返回错误")
+	}
+	if err := repo.Save(&PlayerItemEntity{Region: "cn", ItemId: 3}); err == nil {
+		t.Error("复合主键的任一列为零值时保存应失败")
+	}
+	if err := repo.Update(&PlayerItemEntity{Region: "cn", PlayerId: 10}); err == nil {
+		t.Error("复合主键的任一列为零值时更新应失败")
+	}
+}
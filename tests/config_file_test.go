@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+const yamlConfigContent = `
+databases:
+  main:
+    databaseType: mysql
+    host: 127.0.0.1
+    port: 3306
+    maxOpenConns: 20
+    connMaxLifetime: 1h
+monitoring:
+  slowQueryThreshold: 200ms
+settings:
+  file.feature: on
+`
+
+const jsonConfigContent = `{
+  "databases": {"main": {"host": "10.0.0.1", "port": 3307, "maxOpenConns": 30}},
+  "monitoring": {"slowQueryThreshold": "1s"},
+  "settings": {"file.feature": "json"}
+}`
+
+const tomlConfigContent = `
+[databases.main]
+host = "10.0.0.2"
+port = 3308
+maxOpenConns = 40
+connMaxIdleTime = "10m"
+
+[settings]
+"file.feature" = "toml"
+`
+
+func writeConfigFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+	return path
+}
+
+// 测试三种格式解析到同一结构
+func TestConfigManager_LoadFileFormats(t *testing.T) {
+	cm := db233.GetConfigManager()
+
+	config, err := cm.LoadFile(writeConfigFile(t, "db233.yaml", yamlConfigContent))
+	if err != nil {
+		t.Fatalf("加载 yaml 失败: %v", err)
+	}
+	main := config.Databases["main"]
+	if main == nil || main.DatabaseType != db233.EnumDatabaseTypeMySQL || main.Port != 3306 || main.MaxOpenConns != 20 || main.ConnMaxLifetime != time.Hour {
+		t.Errorf("yaml 数据库配置不正确: %+v", main)
+	}
+	if config.Monitoring.SlowQueryThreshold != 200*time.Millisecond {
+		t.Errorf("yaml 监控配置不正确: %+v", config.Monitoring)
+	}
+
+	config, err = cm.LoadFile(writeConfigFile(t, "db233.json", jsonConfigContent))
+	if err != nil {
+		t.Fatalf("加载 json 失败: %v", err)
+	}
+	if config.Databases["main"].Host != "10.0.0.1" || config.Monitoring.SlowQueryThreshold != time.Second {
+		t.Errorf("json 配置不正确: %+v", config)
+	}
+	if cm.GetString("file.feature", "") != "json" {
+		t.Errorf("settings 应合并进 ConfigManager, 得到 %v", cm.GetString("file.feature", ""))
+	}
+
+	config, err = cm.LoadFile(writeConfigFile(t, "db233.toml", tomlConfigContent))
+	if err != nil {
+		t.Fatalf("加载 toml 失败: %v", err)
+	}
+	if config.Databases["main"].Port != 3308 || config.Databases["main"].ConnMaxIdleTime != 10*time.Minute {
+		t.Errorf("toml 配置不正确: %+v", config.Databases["main"])
+	}
+
+	if _, err := cm.LoadFile(writeConfigFile(t, "db233.ini", "a=1")); err == nil {
+		t.Error("不支持的格式应报错")
+	}
+	if _, err := cm.LoadFile(writeConfigFile(t, "bad.yaml", "databases:\n  main:\n    port: abc\n")); err == nil {
+		t.Error("字段类型错误应报错")
+	}
+}
+
+// 测试热加载应用连接池大小并触发变更事件
+func TestConfigManager_WatchFile(t *testing.T) {
+	cm := db233.GetConfigManager()
+	path := writeConfigFile(t, "watch.yaml", yamlConfigContent)
+
+	dataSource, err := sql.Open("mysql", "root:root@tcp(127.0.0.1:1)/db233_go")
+	if err != nil {
+		t.Fatalf("创建数据源失败: %v", err)
+	}
+	defer dataSource.Close()
+
+	var mu sync.Mutex
+	changed := make(map[string]interface{})
+	cm.AddChangeListener(func(event db233.ConfigChangeEvent) {
+		if event.Path == path {
+			mu.Lock()
+			changed[event.Key] = event.NewValue
+			mu.Unlock()
+		}
+	})
+
+	watcher, err := cm.WatchFile(path, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("启动监视失败: %v", err)
+	}
+	defer watcher.Stop()
+
+	cm.BindDb("main", db233.NewDb(dataSource, 0, nil))
+	if got := dataSource.Stats().MaxOpenConnections; got != 20 {
+		t.Fatalf("绑定后应立即应用连接池大小, 得到 %d", got)
+	}
+
+	// 忽略首次加载相对于其他测试文件的差异
+	mu.Lock()
+	changed = make(map[string]interface{})
+	mu.Unlock()
+
+	updated := strings.Replace(yamlConfigContent, "maxOpenConns: 20", "maxOpenConns: 50", 1) + "  file.extra: 1\n"
+	if err := os.WriteFile(path, []byte(updated), 0o644); err != nil {
+		t.Fatalf("更新配置文件失败: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for dataSource.Stats().MaxOpenConnections != 50 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := dataSource.Stats().MaxOpenConnections; got != 50 {
+		t.Fatalf("热加载后连接池大小应为 50, 得到 %d", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if changed["databases.main.maxOpenConns"] != 50 {
+		t.Errorf("应触发 maxOpenConns 变更事件, 得到 %v", changed)
+	}
+	if _, exists := changed["settings.file.extra"]; !exists {
+		t.Errorf("应触发新增键的变更事件, 得到 %v", changed)
+	}
+	if _, exists := changed["databases.main.host"]; exists {
+		t.Error("未变化的键不应触发事件")
+	}
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试环境变量密钥提供者
+func TestEnvSecretProvider(t *testing.T) {
+	t.Setenv("DB233_SECRET_ORDER_DB_PASSWORD", "from-env")
+
+	provider := db233.NewEnvSecretProvider("DB233_SECRET_")
+	value, exists, err := provider.GetSecret("order.dbPassword")
+	if err != nil || !exists || value != "from-env" {
+		t.Fatalf("期望读取到 from-env，得到 %q, %v, %v", value, exists, err)
+	}
+
+	if _, exists, _ := provider.GetSecret("not.exists"); exists {
+		t.Error("不存在的密钥不应返回 exists")
+	}
+}
+
+// 测试文件密钥提供者
+func TestFileSecretProvider(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "file_db_password"), []byte("from-file\n"), 0o600); err != nil {
+		t.Fatalf("写入密钥文件失败: %v", err)
+	}
+
+	provider := db233.NewFileSecretProvider(dir)
+	value, exists, err := provider.GetSecret("file_db_password")
+	if err != nil || !exists || value != "from-file" {
+		t.Fatalf("期望读取到 from-file，得到 %q, %v, %v", value, exists, err)
+	}
+
+	if _, _, err := provider.GetSecret("../etc/passwd"); err == nil {
+		t.Error("包含路径分隔符的名称应被拒绝")
+	}
+}
+
+// 测试密钥引用解析
+func TestConfigManager_ResolveSecretRefs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "resolve_password"), []byte("s3cret"), 0o600); err != nil {
+		t.Fatalf("写入密钥文件失败: %v", err)
+	}
+	t.Setenv("RESOLVE_TEST_USER", "admin")
+
+	cm := db233.GetConfigManager()
+	cm.AddSecretProvider(db233.NewFileSecretProvider(dir))
+
+	resolved, err := cm.ResolveSecretRefs("${env:RESOLVE_TEST_USER}:${secret:resolve_password}")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if resolved != "admin:s3cret" {
+		t.Errorf("期望 admin:s3cret，得到 %s", resolved)
+	}
+
+	if plain, _ := cm.ResolveSecretRefs("plain"); plain != "plain" {
+		t.Errorf("无引用的值应原样返回，得到 %s", plain)
+	}
+
+	if _, err := cm.ResolveSecretRefs("${secret:resolve_missing}"); err == nil {
+		t.Error("缺失的密钥应返回错误")
+	}
+}
+
+// 测试配置文件中的密钥引用与 DB233_* 环境变量覆盖
+func TestConfigManager_LoadFileWithSecretsAndEnvOverrides(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "load_main_password"), []byte("pw-from-file"), 0o600); err != nil {
+		t.Fatalf("写入密钥文件失败: %v", err)
+	}
+	cm := db233.GetConfigManager()
+	cm.AddSecretProvider(db233.NewFileSecretProvider(dir))
+
+	t.Setenv("DB233_DATABASES_REPORT_PASSWORD", "pw-from-env")
+	t.Setenv("DB233_DATABASES_REPORT_MAX_OPEN_CONNS", "64")
+	t.Setenv("DB233_SETTINGS_SECRET_FEATURE", "env")
+
+	config, err := cm.LoadFile(writeConfigFile(t, "db233.yaml", `
+databases:
+  main:
+    host: 127.0.0.1
+    password: ${secret:load_main_password}
+  report:
+    host: 127.0.0.2
+    password: in-file
+settings:
+  secret.feature: file
+`))
+	if err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+
+	if config.Databases["main"].Password != "pw-from-file" {
+		t.Errorf("期望 main 密码来自密钥文件，得到 %s", config.Databases["main"].Password)
+	}
+	if config.Databases["report"].Password != "pw-from-env" {
+		t.Errorf("期望 report 密码被环境变量覆盖，得到 %s", config.Databases["report"].Password)
+	}
+	if config.Databases["report"].MaxOpenConns != 64 {
+		t.Errorf("期望文件中未写的 maxOpenConns 由环境变量提供，得到 %d", config.Databases["report"].MaxOpenConns)
+	}
+	if value := cm.GetString("secret.feature", ""); value != "env" {
+		t.Errorf("期望 settings 被环境变量覆盖，得到 %s", value)
+	}
+
+	if _, err := cm.LoadFile(writeConfigFile(t, "missing.yaml", `
+databases:
+  main:
+    password: ${secret:load_missing_password}
+`)); err == nil {
+		t.Error("引用不存在的密钥时加载应失败")
+	}
+}
+
+// 测试通用配置读取时的环境变量覆盖与 LoadFromEnv
+func TestConfigManager_EnvOverrideGetters(t *testing.T) {
+	cm := db233.GetConfigManager()
+	cm.Set("override.limit", 10)
+	cm.Set("override.enabled", false)
+
+	t.Setenv("DB233_OVERRIDE_LIMIT", "25")
+	t.Setenv("DB233_OVERRIDE_ENABLED", "true")
+
+	if value := cm.GetInt("override.limit", 0); value != 25 {
+		t.Errorf("期望 25，得到 %d", value)
+	}
+	if !cm.GetBool("override.enabled", false) {
+		t.Error("期望环境变量覆盖为 true")
+	}
+
+	t.Setenv("APPCFG_LOADED_NAME", "from-env")
+	cm.LoadFromEnv("APPCFG_")
+	if value := cm.GetAll()["loaded.name"]; value != "from-env" {
+		t.Errorf("期望 LoadFromEnv 写入 loaded.name，得到 %v", value)
+	}
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 聚合查询返回固定结果：COUNT 为 3，SUM 为 42.50，AVG 为 NULL；存在性查询参数为 404 时无记录
+func newAggregateRepo(t *testing.T) (*db233.BaseCrudRepository, *sqlRecordPlugin) {
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		switch {
+		case strings.HasPrefix(stmt.query, "SELECT COUNT(*)"):
+			return fakeRowsOf([]string{"count"}, []driver.Value{int64(3)}), nil
+		case strings.HasPrefix(stmt.query, "SELECT 1 "):
+			for _, arg := range stmt.args {
+				if arg == int64(404) {
+					return fakeRowsOf([]string{"1"}), nil
+				}
+			}
+			return fakeRowsOf([]string{"1"}, []driver.Value{int64(1)}), nil
+		case strings.HasPrefix(stmt.query, "SELECT SUM("):
+			return fakeRowsOf([]string{"sum"}, []driver.Value{[]byte("42.50")}), nil
+		case strings.HasPrefix(stmt.query, "SELECT AVG("):
+			return fakeRowsOf([]string{"avg"}, []driver.Value{nil}), nil
+		case strings.HasPrefix(stmt.query, "SELECT MAX("):
+			return fakeRowsOf([]string{"max"}, []driver.Value{[]byte("2026-10-16 10:00:00")}), nil
+		}
+		return nil, errors.New("未知查询: " + stmt.query)
+	}
+	db := fake.newDb(t, db233.EnumDatabaseTypeMySQL)
+	record := &sqlRecordPlugin{AbstractDb233Plugin: db233.NewAbstractDb233Plugin("record")}
+	db.AddPlugin(record, 0)
+	db233.GetCrudManagerInstance().AutoInitEntity(&PartialUpdateUser{})
+	return db233.NewBaseCrudRepository(db), record
+}
+
+// 测试条件计数与存在性判断
+func TestBaseCrudRepository_CountAndExists(t *testing.T) {
+	repo, record := newAggregateRepo(t)
+
+	count, err := repo.CountByCondition(&PartialUpdateUser{}, "age > ?", []interface{}{18})
+	if err != nil {
+		t.Fatalf("条件计数失败: %v", err)
+	}
+	if count != 3 || record.sqls[0] != "SELECT COUNT(*) FROM partial_update_user WHERE (age > ?)" {
+		t.Errorf("条件计数不正确: %d %s", count, record.sqls[0])
+	}
+
+	// 空条件统计全表，作用域谓词仍然生效
+	scoped := repo.WithScope(&db233.RepositoryScope{
+		Predicates: []db233.ScopePredicate{{Sql: "tenant_id = ?", Params: []interface{}{int64(3)}}},
+	})
+	if _, err := scoped.CountByCondition(&PartialUpdateUser{}, "", nil); err != nil {
+		t.Fatalf("条件计数失败: %v", err)
+	}
+	if record.sqls[1] != "SELECT COUNT(*) FROM partial_update_user WHERE tenant_id = ?" || !reflect.DeepEqual(record.params[1], []interface{}{int64(3)}) {
+		t.Errorf("作用域未生效: %s %v", record.sqls[1], record.params[1])
+	}
+
+	exists, err := repo.ExistsById(int64(7), &PartialUpdateUser{})
+	if err != nil || !exists {
+		t.Errorf("记录应存在: %v %v", exists, err)
+	}
+	if record.sqls[2] != "SELECT 1 FROM partial_update_user WHERE id = ? LIMIT 1" {
+		t.Errorf("存在性 SQL 不正确: %s", record.sqls[2])
+	}
+	exists, err = repo.ExistsById(int64(404), &PartialUpdateUser{})
+	if err != nil || exists {
+		t.Errorf("记录不应存在: %v %v", exists, err)
+	}
+
+	exists, err = scoped.ExistsByCondition(&PartialUpdateUser{}, "email = ?", []interface{}{"a@example.com"})
+	if err != nil || !exists {
+		t.Errorf("记录应存在: %v %v", exists, err)
+	}
+	if record.sqls[4] != "SELECT 1 FROM partial_update_user WHERE (email = ?) AND tenant_id = ? LIMIT 1" {
+		t.Errorf("存在性 SQL 不正确: %s", record.sqls[4])
+	}
+}
+
+// 测试聚合辅助方法
+func TestBaseCrudRepository_Aggregates(t *testing.T) {
+	repo, record := newAggregateRepo(t)
+
+	sum, err := repo.SumColumn(&PartialUpdateUser{}, "Age", "tenant_id = ?", []interface{}{int64(3)})
+	if err != nil {
+		t.Fatalf("求和失败: %v", err)
+	}
+	if sum != 42.5 || record.sqls[0] != "SELECT SUM(age) FROM partial_update_user WHERE (tenant_id = ?)" {
+		t.Errorf("求和不正确: %v %s", sum, record.sqls[0])
+	}
+
+	// 没有匹配行时 AVG 为 NULL，返回 0
+	avg, err := repo.AvgColumn(&PartialUpdateUser{}, "age", "", nil)
+	if err != nil || avg != 0 {
+		t.Errorf("平均值应为 0: %v %v", avg, err)
+	}
+	if record.sqls[1] != "SELECT AVG(age) FROM partial_update_user" {
+		t.Errorf("平均值 SQL 不正确: %s", record.sqls[1])
+	}
+
+	latest, err := repo.MaxColumn(&PartialUpdateUser{}, "login_at", "", nil)
+	if err != nil || latest != "2026-10-16 10:00:00" {
+		t.Errorf("最大值不正确: %#v %v", latest, err)
+	}
+
+	if _, err := repo.SumColumn(&PartialUpdateUser{}, "age) FROM x; --", "", nil); err == nil {
+		t.Error("未映射的列应返回错误")
+	}
+	if len(record.sqls) != 3 {
+		t.Errorf("校验失败时不应执行 SQL: %v", record.sqls)
+	}
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+func newBulkRepo(t *testing.T) (*db233.BaseCrudRepository, *sqlRecordPlugin) {
+	db := newFakeDriver().newDb(t, db233.EnumDatabaseTypeMySQL)
+	record := &sqlRecordPlugin{AbstractDb233Plugin: db233.NewAbstractDb233Plugin("record")}
+	db.AddPlugin(record, 0)
+	db233.GetCrudManagerInstance().AutoInitEntity(&PartialUpdateUser{})
+	return db233.NewBaseCrudRepository(db), record
+}
+
+// 测试按条件批量更新
+func TestBaseCrudRepository_UpdateWhere(t *testing.T) {
+	repo, record := newBulkRepo(t)
+
+	affected, err := repo.UpdateWhere(&PartialUpdateUser{}, map[string]interface{}{"email": "", "Age": 0}, "age > ?", []interface{}{100})
+	if err != nil {
+		t.Fatalf("条件更新失败: %v", err)
+	}
+	if affected != 1 {
+		t.Errorf("影响行数应为 1, 得到 %d", affected)
+	}
+	expected := "UPDATE partial_update_user SET age = ?, email = ? WHERE (age > ?)"
+	if record.sqls[0] != expected {
+		t.Errorf("SQL 不正确:\n期望 %s\n得到 %s", expected, record.sqls[0])
+	}
+	if !reflect.DeepEqual(record.params[0], []interface{}{0, "", 100}) {
+		t.Errorf("参数不正确: %v", record.params[0])
+	}
+
+	// 作用域谓词合并，强制列不能被改成其他值
+	scoped := repo.WithScope(&db233.RepositoryScope{
+		Predicates:    []db233.ScopePredicate{{Sql: "tenant_id = ?", Params: []interface{}{int64(3)}}},
+		ForcedColumns: map[string]interface{}{"tenant_id": int64(3)},
+	})
+	if _, err := scoped.UpdateWhere(&PartialUpdateUser{}, map[string]interface{}{"tenant_id": int64(9)}, "1 = 1", nil); err != nil {
+		t.Fatalf("条件更新失败: %v", err)
+	}
+	expected = "UPDATE partial_update_user SET tenant_id = ? WHERE (1 = 1) AND tenant_id = ?"
+	if record.sqls[1] != expected || !reflect.DeepEqual(record.params[1], []interface{}{int64(3), int64(3)}) {
+		t.Errorf("作用域未生效: %s %v", record.sqls[1], record.params[1])
+	}
+
+	if _, err := repo.UpdateWhere(&PartialUpdateUser{}, map[string]interface{}{"age = 0; DROP TABLE x; --": 1}, "1 = 1", nil); err == nil {
+		t.Error("未映射的列应返回错误")
+	}
+	if _, err := repo.UpdateWhere(&PartialUpdateUser{}, map[string]interface{}{"age": 1}, "", nil); err == nil {
+		t.Error("空条件应返回错误")
+	}
+	if _, err := repo.UpdateWhere(&PartialUpdateUser{}, nil, "1 = 1", nil); err == nil {
+		t.Error("没有更新列应返回错误")
+	}
+	if len(record.sqls) != 2 {
+		t.Errorf("校验失败时不应执行 SQL: %v", record.sqls)
+	}
+}
+
+// 测试按条件批量删除
+func TestBaseCrudRepository_DeleteWhere(t *testing.T) {
+	repo, record := newBulkRepo(t)
+
+	affected, err := repo.DeleteWhere(&PartialUpdateUser{}, "login_at < ?", []interface{}{"2026-01-01"})
+	if err != nil {
+		t.Fatalf("条件删除失败: %v", err)
+	}
+	if affected != 1 || record.sqls[0] != "DELETE FROM partial_update_user WHERE (login_at < ?)" {
+		t.Errorf("条件删除不正确: %d %s", affected, record.sqls[0])
+	}
+
+	if _, err := repo.DeleteWhere(&PartialUpdateUser{}, "", nil); err == nil {
+		t.Error("空条件应返回错误")
+	}
+}
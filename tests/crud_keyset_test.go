@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 记录 SQL 并返回预设结果
+type keysetRowsPlugin struct {
+	*db233.AbstractDb233Plugin
+	rows   []interface{}
+	sqls   []string
+	params [][]interface{}
+}
+
+func (p *keysetRowsPlugin) PreExecuteSql(context *db233.ExecuteSqlContext) {
+	p.sqls = append(p.sqls, context.Sql)
+	p.params = append(p.params, context.Params)
+	context.ShortCircuit(p.rows)
+}
+
+func newKeysetRepo(t *testing.T) (*db233.BaseCrudRepository, *keysetRowsPlugin) {
+	db := db233.NewDb(nil, 1, nil)
+	plugin := &keysetRowsPlugin{AbstractDb233Plugin: db233.NewAbstractDb233Plugin("keyset")}
+	db.AddPlugin(plugin, 0)
+	db233.GetCrudManagerInstance().AutoInitEntity(&PartialUpdateUser{})
+	return db233.NewBaseCrudRepository(db), plugin
+}
+
+// 测试按排序列 + 主键翻页
+func TestBaseCrudRepository_FindPageAfter(t *testing.T) {
+	repo, plugin := newKeysetRepo(t)
+	loginAt := time.Date(2026, 10, 16, 10, 0, 0, 123000000, time.UTC)
+	plugin.rows = []interface{}{
+		&PartialUpdateUser{Id: 9, LoginAt: loginAt.Add(time.Hour)},
+		&PartialUpdateUser{Id: 8, LoginAt: loginAt},
+		&PartialUpdateUser{Id: 7, LoginAt: loginAt},
+	}
+
+	page, err := repo.FindPageAfter(&PartialUpdateUser{}, "", 2, "LoginAt DESC")
+	if err != nil {
+		t.Fatalf("游标分页失败: %v", err)
+	}
+	expected := "SELECT * FROM partial_update_user ORDER BY login_at DESC, id DESC LIMIT 3"
+	if plugin.sqls[0] != expected {
+		t.Errorf("首页 SQL 不正确:\n期望 %s\n得到 %s", expected, plugin.sqls[0])
+	}
+	if len(page.Items) != 2 || !page.HasMore || page.NextCursor == "" {
+		t.Fatalf("首页结果不正确: %+v", page)
+	}
+
+	plugin.rows = []interface{}{&PartialUpdateUser{Id: 7, LoginAt: loginAt}}
+	page, err = repo.FindPageAfter(&PartialUpdateUser{}, page.NextCursor, 2, "login_at desc")
+	if err != nil {
+		t.Fatalf("游标分页失败: %v", err)
+	}
+	expected = "SELECT * FROM partial_update_user WHERE (login_at, id) < (?, ?) ORDER BY login_at DESC, id DESC LIMIT 3"
+	if plugin.sqls[1] != expected {
+		t.Errorf("下一页 SQL 不正确:\n期望 %s\n得到 %s", expected, plugin.sqls[1])
+	}
+	if !reflect.DeepEqual(plugin.params[1], []interface{}{loginAt, int64(8)}) {
+		t.Errorf("游标参数不正确: %#v", plugin.params[1])
+	}
+	if len(page.Items) != 1 || page.HasMore || page.NextCursor != "" {
+		t.Errorf("末页结果不正确: %+v", page)
+	}
+}
+
+// 测试条件、作用域与游标校验
+func TestBaseCrudRepository_FindPageAfterByCondition(t *testing.T) {
+	repo, plugin := newKeysetRepo(t)
+	plugin.rows = []interface{}{&PartialUpdateUser{Id: 1}, &PartialUpdateUser{Id: 2}}
+
+	scoped := repo.WithScope(&db233.RepositoryScope{
+		Predicates: []db233.ScopePredicate{{Sql: "tenant_id = ?", Params: []interface{}{int64(3)}}},
+	})
+	page, err := scoped.FindPageAfterByCondition("age > ?", []interface{}{18}, &PartialUpdateUser{}, "", 1, "")
+	if err != nil {
+		t.Fatalf("游标分页失败: %v", err)
+	}
+	if _, err := scoped.FindPageAfterByCondition("age > ?", []interface{}{18}, &PartialUpdateUser{}, page.NextCursor, 1, ""); err != nil {
+		t.Fatalf("游标分页失败: %v", err)
+	}
+	expected := "SELECT * FROM partial_update_user WHERE (age > ?) AND (id) > (?) AND tenant_id = ? ORDER BY id LIMIT 2"
+	if plugin.sqls[1] != expected {
+		t.Errorf("SQL 不正确:\n期望 %s\n得到 %s", expected, plugin.sqls[1])
+	}
+	if !reflect.DeepEqual(plugin.params[1], []interface{}{18, int64(1), int64(3)}) {
+		t.Errorf("参数不正确: %#v", plugin.params[1])
+	}
+
+	if _, err := repo.FindPageAfter(&PartialUpdateUser{}, page.NextCursor, 1, "age"); err == nil {
+		t.Error("排序变化后旧游标应被拒绝")
+	}
+	if _, err := repo.FindPageAfter(&PartialUpdateUser{}, "not-a-cursor", 1, ""); err == nil {
+		t.Error("无效游标应返回错误")
+	}
+	if _, err := repo.FindPageAfter(&PartialUpdateUser{}, "", 1, "age; DROP TABLE x"); err == nil {
+		t.Error("未映射的排序列应返回错误")
+	}
+	if _, err := repo.FindPageAfter(&PartialUpdateUser{}, "", 0, ""); err == nil {
+		t.Error("每页条数为 0 应返回错误")
+	}
+}
+
+// 测试 SQL Server 不支持行值比较，游标条件展开为 OR / AND
+func TestBaseCrudRepository_FindPageAfterSQLServer(t *testing.T) {
+	repo, plugin := newKeysetRepo(t)
+	repo.GetDb().DatabaseType = db233.EnumDatabaseTypeSQLServer
+	loginAt := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	plugin.rows = []interface{}{&PartialUpdateUser{Id: 9, LoginAt: loginAt}, &PartialUpdateUser{Id: 8, LoginAt: loginAt}}
+
+	page, err := repo.FindPageAfter(&PartialUpdateUser{}, "", 1, "login_at DESC")
+	if err != nil {
+		t.Fatalf("游标分页失败: %v", err)
+	}
+	if _, err := repo.FindPageAfter(&PartialUpdateUser{}, page.NextCursor, 1, "login_at DESC"); err != nil {
+		t.Fatalf("游标分页失败: %v", err)
+	}
+	expected := "SELECT TOP 2 * FROM partial_update_user WHERE (login_at < ? OR (login_at = ? AND id < ?)) ORDER BY login_at DESC, id DESC"
+	if plugin.sqls[1] != expected {
+		t.Errorf("SQL 不正确:\n期望 %s\n得到 %s", expected, plugin.sqls[1])
+	}
+	if !reflect.DeepEqual(plugin.params[1], []interface{}{loginAt, loginAt, int64(9)}) {
+		t.Errorf("游标参数不正确: %#v", plugin.params[1])
+	}
+}
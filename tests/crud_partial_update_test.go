@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type PartialUpdateUser struct {
+	Id       int64     `db:"id,primary_key,auto_increment"`
+	Name     string    `db:"name" validate:"required"`
+	Email    string    `db:"email"`
+	Age      int       `db:"age" validate:"max=150"`
+	LoginAt  time.Time `db:"login_at"`
+	TenantId int64     `db:"tenant_id"`
+}
+
+func (e *PartialUpdateUser) TableName() string {
+	return "partial_update_user"
+}
+
+func (e *PartialUpdateUser) SerializeBeforeSaveDb() {}
+
+func (e *PartialUpdateUser) DeserializeAfterLoadDb() {}
+
+func newPartialUpdateRepo(t *testing.T) (*db233.BaseCrudRepository, *sqlRecordPlugin) {
+	db := newFakeDriver().newDb(t, db233.EnumDatabaseTypeMySQL)
+	record := &sqlRecordPlugin{AbstractDb233Plugin: db233.NewAbstractDb233Plugin("record")}
+	db.AddPlugin(record, 0)
+	db233.GetCrudManagerInstance().AutoInitEntity(&PartialUpdateUser{})
+	return db233.NewBaseCrudRepository(db), record
+}
+
+// 测试只更新指定列
+func TestBaseCrudRepository_UpdateColumns(t *testing.T) {
+	repo, record := newPartialUpdateRepo(t)
+
+	// Name 为空不影响（只校验被更新的列），支持结构体字段名
+	user := &PartialUpdateUser{Id: 7, Age: 20, Email: "a@example.com"}
+	if err := repo.UpdateColumns(user, "age", "Email"); err != nil {
+		t.Fatalf("部分更新失败: %v", err)
+	}
+	expected := "UPDATE partial_update_user SET age = ?, email = ? WHERE id = ?"
+	if record.sqls[0] != expected {
+		t.Errorf("SQL 不正确:\n期望 %s\n得到 %s", expected, record.sqls[0])
+	}
+	if !reflect.DeepEqual(record.params[0], []interface{}{20, "a@example.com", int64(7)}) {
+		t.Errorf("参数不正确: %v", record.params[0])
+	}
+
+	if err := repo.UpdateColumns(user, "nickname"); err == nil {
+		t.Error("不存在的列应返回错误")
+	}
+	if err := repo.UpdateColumns(user, "id"); err == nil {
+		t.Error("主键列不能被更新")
+	}
+	user.Age = 200
+	if err := repo.UpdateColumns(user, "age"); err == nil {
+		t.Error("被更新的列仍需通过校验")
+	}
+	if len(record.sqls) != 1 {
+		t.Errorf("失败时不应执行 SQL: %v", record.sqls)
+	}
+}
+
+// 测试只更新非零值字段
+func TestBaseCrudRepository_UpdateNonZero(t *testing.T) {
+	repo, record := newPartialUpdateRepo(t)
+
+	loginAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	user := &PartialUpdateUser{Id: 7, Age: 21, LoginAt: loginAt}
+	if err := repo.UpdateNonZero(user); err != nil {
+		t.Fatalf("部分更新失败: %v", err)
+	}
+	expected := "UPDATE partial_update_user SET age = ?, login_at = ? WHERE id = ?"
+	if record.sqls[0] != expected {
+		t.Errorf("SQL 不正确:\n期望 %s\n得到 %s", expected, record.sqls[0])
+	}
+
+	// 作用域强制列始终写入
+	scoped := repo.WithScope(&db233.RepositoryScope{ForcedColumns: map[string]interface{}{"tenant_id": int64(3)}})
+	if err := scoped.UpdateNonZero(&PartialUpdateUser{Id: 7, Name: "neko"}); err != nil {
+		t.Fatalf("部分更新失败: %v", err)
+	}
+	expected = "UPDATE partial_update_user SET name = ?, tenant_id = ? WHERE id = ?"
+	if record.sqls[1] != expected {
+		t.Errorf("SQL 不正确:\n期望 %s\n得到 %s", expected, record.sqls[1])
+	}
+
+	if err := repo.UpdateNonZero(&PartialUpdateUser{Id: 7}); err == nil {
+		t.Error("没有非零值字段时应返回错误")
+	}
+}
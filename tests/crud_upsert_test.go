@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type UpsertApiUser struct {
+	Id        int64  `db:"id,primary_key,auto_increment"`
+	Email     string `db:"email"`
+	Age       int    `db:"age"`
+	UpdatedAt int64  `db:"updated_at"`
+}
+
+func (e *UpsertApiUser) TableName() string {
+	return "upsert_api_user"
+}
+
+func (e *UpsertApiUser) SerializeBeforeSaveDb() {}
+
+func (e *UpsertApiUser) DeserializeAfterLoadDb() {}
+
+// 记录执行的 SQL（不拦截执行）
+type sqlRecordPlugin struct {
+	*db233.AbstractDb233Plugin
+	sqls   []string
+	params [][]interface{}
+}
+
+func (p *sqlRecordPlugin) PreExecuteSql(context *db233.ExecuteSqlContext) {
+	p.sqls = append(p.sqls, context.Sql)
+	p.params = append(p.params, context.Params)
+}
+
+func newUpsertRepo(t *testing.T, dbType db233.EnumDatabaseType) (*db233.BaseCrudRepository, *sqlRecordPlugin) {
+	db := newFakeDriver().newDb(t, dbType)
+	record := &sqlRecordPlugin{AbstractDb233Plugin: db233.NewAbstractDb233Plugin("record")}
+	db.AddPlugin(record, 0)
+	db233.GetCrudManagerInstance().AutoInitEntity(&UpsertApiUser{})
+	return db233.NewBaseCrudRepository(db), record
+}
+
+// 测试显式 UPSERT 按方言生成冲突处理子句
+func TestBaseCrudRepository_Upsert(t *testing.T) {
+	user := &UpsertApiUser{Email: "a@example.com", Age: 18, UpdatedAt: 1}
+
+	repo, record := newUpsertRepo(t, db233.EnumDatabaseTypeMySQL)
+	if err := repo.Upsert(user, db233.OnConflict("email"), db233.DoUpdate("age", "updated_at")); err != nil {
+		t.Fatalf("UPSERT 失败: %v", err)
+	}
+	if sql := record.sqls[0]; !strings.HasSuffix(sql, " ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id), age = VALUES(age), updated_at = VALUES(updated_at)") {
+		t.Errorf("MySQL UPSERT SQL 不正确: %s", sql)
+	}
+
+	repo, record = newUpsertRepo(t, db233.EnumDatabaseTypePostgreSQL)
+	if err := repo.Upsert(user, db233.OnConflict("email"), db233.DoUpdate("age", "updated_at")); err != nil {
+		t.Fatalf("UPSERT 失败: %v", err)
+	}
+	if sql := record.sqls[0]; !strings.HasSuffix(sql, " ON CONFLICT (email) DO UPDATE SET age = EXCLUDED.age, updated_at = EXCLUDED.updated_at") {
+		t.Errorf("PostgreSQL UPSERT SQL 不正确: %s", sql)
+	}
+
+	if err := repo.Upsert(user, db233.OnConflict("email"), db233.DoNothing()); err != nil {
+		t.Fatalf("DoNothing 失败: %v", err)
+	}
+	if sql := record.sqls[1]; !strings.HasSuffix(sql, " ON CONFLICT (email) DO NOTHING") {
+		t.Errorf("DoNothing SQL 不正确: %s", sql)
+	}
+
+	// 默认更新冲突列之外的所有插入列
+	if err := repo.Upsert(user, db233.OnConflict("email")); err != nil {
+		t.Fatalf("UPSERT 失败: %v", err)
+	}
+	if sql := record.sqls[2]; !strings.Contains(sql, "age = EXCLUDED.age") || !strings.Contains(sql, "updated_at = EXCLUDED.updated_at") || strings.Contains(sql, "email = EXCLUDED.email") {
+		t.Errorf("默认更新列不正确: %s", sql)
+	}
+}
+
+// 测试 UPSERT 列校验
+func TestBaseCrudRepository_UpsertValidation(t *testing.T) {
+	repo, record := newUpsertRepo(t, db233.EnumDatabaseTypeMySQL)
+	user := &UpsertApiUser{Email: "a@example.com", Age: 18}
+
+	if err := repo.Upsert(user, db233.OnConflict("phone")); err == nil {
+		t.Error("不存在的冲突列应返回错误")
+	}
+	if err := repo.Upsert(user, db233.OnConflict("email"), db233.DoUpdate("nickname")); err == nil {
+		t.Error("不存在的更新列应返回错误")
+	}
+	if len(record.sqls) != 0 {
+		t.Errorf("校验失败时不应执行 SQL: %v", record.sqls)
+	}
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试运行时指标采集
+func TestRuntimeMetricsDataSource(t *testing.T) {
+	source := db233.NewRuntimeMetricsDataSource()
+	runtime.GC()
+
+	metrics := source.GetMetrics()
+	if metrics["goroutines"].(int) <= 0 {
+		t.Errorf("goroutine 数量应大于 0: %v", metrics["goroutines"])
+	}
+	if metrics["gc_count"].(uint32) == 0 {
+		t.Error("手动 GC 后 gc_count 应大于 0")
+	}
+	if _, ok := metrics["gc_pause_recent_max_ms"]; !ok {
+		t.Error("缺少 gc_pause_recent_max_ms 指标")
+	}
+
+	collector := db233.NewMetricsCollector("runtime_test")
+	collector.EnableRuntimeMetrics()
+	collector.EnableRuntimeMetrics()
+	if status := collector.GetStatus(); status["data_sources"] != 1 {
+		t.Errorf("重复启用只应注册一个数据源: %v", status["data_sources"])
+	}
+}
+
+// 测试仪表板路由与 pprof 开关
+func TestDashboardServer_Handler(t *testing.T) {
+	dashboard := db233.NewMonitoringDashboard("test")
+	dashboard.AddMetricsCollector("db", db233.NewMetricsCollector("db"))
+
+	server := httptest.NewServer(db233.NewDashboardServer(dashboard, db233.DashboardServerOptions{EnablePprof: true}).Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/dashboard/status")
+	if err != nil {
+		t.Fatalf("请求状态失败: %v", err)
+	}
+	var status map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&status)
+	resp.Body.Close()
+	if status["name"] != "test" {
+		t.Errorf("状态内容错误: %v", status)
+	}
+
+	for path, expected := range map[string]int{
+		"/metrics/db":      http.StatusOK,
+		"/metrics/missing": http.StatusNotFound,
+		"/debug/pprof/":    http.StatusOK,
+	} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("请求 %s 失败: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != expected {
+			t.Errorf("%s: 期望状态码 %d, 得到 %d", path, expected, resp.StatusCode)
+		}
+	}
+
+	noPprof := httptest.NewServer(db233.NewDashboardServer(dashboard, db233.DashboardServerOptions{}).Handler())
+	defer noPprof.Close()
+	resp, _ = http.Get(noPprof.URL + "/debug/pprof/")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("未开启 pprof 时应返回 404, 得到 %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// PiiTestUser 带 PII 标签的测试实体
+type PiiTestUser struct {
+	ID       int    `db:"id,primary_key,auto_increment"`
+	Username string `db:"username" pii:"faker:name"`
+	Email    string `db:"email" pii:"hash"`
+	Age      int    `db:"age" pii:"nullify"`
+}
+
+func (u *PiiTestUser) TableName() string {
+	return "test_user"
+}
+
+func (u *PiiTestUser) SerializeBeforeSaveDb() {}
+
+func (u *PiiTestUser) DeserializeAfterLoadDb() {}
+
+// 测试 PII 标签解析
+func TestGetPiiRules(t *testing.T) {
+	rules := db233.GetPiiRules(&PiiTestUser{})
+	if len(rules) != 3 {
+		t.Fatalf("期望 3 条 PII 规则, 得到 %d: %v", len(rules), rules)
+	}
+	if rules["username"] != "faker:name" || rules["email"] != "hash" || rules["age"] != "nullify" {
+		t.Errorf("PII 规则不正确: %v", rules)
+	}
+}
+
+// 测试内置脱敏器
+func TestBuiltinAnonymizers(t *testing.T) {
+	hash := db233.NewHashAnonymizer("salt")
+	h1 := hash.Anonymize("email", "a@b.com", "")
+	h2 := hash.Anonymize("email", "a@b.com", "")
+	if h1 != h2 || h1 == "a@b.com" {
+		t.Errorf("哈希脱敏应确定且不同于原值: %v", h1)
+	}
+
+	faker := db233.NewFakerAnonymizer()
+	email := faker.Anonymize("email", "real@corp.com", "email").(string)
+	if !strings.HasSuffix(email, "@example.com") {
+		t.Errorf("期望伪造邮箱, 得到 %s", email)
+	}
+	if faker.Anonymize("email", "real@corp.com", "email") != email {
+		t.Error("伪造数据应对相同输入保持一致")
+	}
+
+	if db233.NewNullifyAnonymizer().Anonymize("age", 18, "") != nil {
+		t.Error("置空脱敏器应返回 nil")
+	}
+}
+
+// 测试脱敏导出（需要数据库）
+func TestDataExporter_ExportAnonymized(t *testing.T) {
+	db := CreateTestDb(t)
+	defer db.Close()
+
+	if err := SetupTestTables(db); err != nil {
+		t.Fatalf("设置测试表失败: %v", err)
+	}
+	repo := db233.NewBaseCrudRepository(db)
+	if err := repo.Save(&TestUser{Username: "test_export", Email: "secret@corp.com", Age: 30}); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+
+	var buf bytes.Buffer
+	exporter := db233.NewDataExporter(db)
+	count, err := exporter.Export(&buf, &PiiTestUser{}, db233.ExportOptions{Format: db233.EnumExportFormatJSONL, Anonymize: true})
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	if count == 0 {
+		t.Fatal("期望导出至少 1 行")
+	}
+	if strings.Contains(buf.String(), "secret@corp.com") {
+		t.Error("脱敏导出中不应包含原始邮箱")
+	}
+}
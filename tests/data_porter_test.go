@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试导入时拒绝未知列
+func TestDataPorter_ImportRejectsUnknownColumns(t *testing.T) {
+	porter := db233.NewDataPorter(nil)
+
+	input := "id,username,password\n1,test_porter,secret\n"
+	result, err := porter.Import(strings.NewReader(input), &TestUser{}, db233.ImportOptions{})
+	if err == nil {
+		t.Fatal("包含未知列时应返回错误")
+	}
+	if result.Imported != 0 {
+		t.Errorf("不应写入任何行, 得到 %d", result.Imported)
+	}
+
+	if _, err := porter.Import(strings.NewReader(input), &TestUser{}, db233.ImportOptions{Format: "xml"}); err == nil {
+		t.Error("不支持的格式应返回错误")
+	}
+}
+
+// 测试导出过滤与导入冲突策略（需要数据库）
+func TestDataPorter_ExportAndImport(t *testing.T) {
+	db := CreateTestDb(t)
+	defer db.Close()
+	if err := SetupTestTables(db); err != nil {
+		t.Fatalf("初始化测试表失败: %v", err)
+	}
+	defer db.DataSource.Exec("DELETE FROM test_user WHERE username LIKE 'test_porter%'")
+
+	porter := db233.NewDataPorter(db)
+
+	input := `{"id": 910001, "username": "test_porter_1", "email": "p1@example.com", "age": 18}
+{"id": 910002, "username": "test_porter_2", "email": "p2@example.com", "age": 30}
+`
+	batches := 0
+	result, err := porter.Import(strings.NewReader(input), &TestUser{}, db233.ImportOptions{
+		Format:    db233.EnumExportFormatJSONL,
+		BatchSize: 1,
+		Progress:  func(result *db233.ImportResult) { batches++ },
+	})
+	if err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	if result.Imported != 2 || batches != 2 {
+		t.Errorf("期望写入 2 行 2 批, 得到 %d 行 %d 批", result.Imported, batches)
+	}
+
+	// 重复导入：中止策略报错，跳过策略全部跳过
+	if _, err := porter.Import(strings.NewReader(input), &TestUser{}, db233.ImportOptions{Format: db233.EnumExportFormatJSONL}); err == nil {
+		t.Error("中止策略下主键冲突应返回错误")
+	}
+	result, err = porter.Import(strings.NewReader(input), &TestUser{}, db233.ImportOptions{
+		Format:         db233.EnumExportFormatJSONL,
+		ConflictPolicy: db233.EnumImportConflictPolicySkip,
+	})
+	if err != nil || result.Skipped != 2 {
+		t.Errorf("跳过策略应跳过 2 行, 得到 %+v, %v", result, err)
+	}
+
+	var buf bytes.Buffer
+	count, err := porter.Export(&buf, &TestUser{}, db233.PortExportOptions{
+		Columns: []string{"id", "username"},
+		Where:   "username LIKE ? AND age > ?",
+		Params:  []interface{}{"test_porter%", 20},
+	})
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	if count != 1 || !strings.HasPrefix(buf.String(), "id,username\n") || !strings.Contains(buf.String(), "test_porter_2") {
+		t.Errorf("导出内容不正确: %d 行\n%s", count, buf.String())
+	}
+}
@@ -0,0 +1,39 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试数据库不可达时连通性失败、其余检查跳过（不访问数据库）
+func TestDbDoctor_Unreachable(t *testing.T) {
+	db := db233.NewDb(nil, 3, nil)
+	db.EnterMaintenance("升级中")
+	defer db.ExitMaintenance()
+
+	var output bytes.Buffer
+	report := db233.NewDbDoctor(db).SetOutput(&output).Run()
+
+	if !report.HasFailures() {
+		t.Fatal("数据源为空时应存在失败项")
+	}
+	if check, _ := report.GetCheck("connectivity"); check.Status != db233.DoctorStatusFail || check.Suggestion == "" {
+		t.Errorf("连通性检查应失败并给出建议: %+v", check)
+	}
+	if check, _ := report.GetCheck("maintenance"); check.Status != db233.DoctorStatusWarn {
+		t.Errorf("维护模式应报告警告: %+v", check)
+	}
+	for _, name := range []string{"permissions", "charset", "timezone", "max_connections", "primary_keys"} {
+		if check, exists := report.GetCheck(name); !exists || check.Status != db233.DoctorStatusSkip {
+			t.Errorf("检查项 %s 应被跳过: %+v", name, check)
+		}
+	}
+
+	text := output.String()
+	if !strings.Contains(text, "DbId=3") || !strings.Contains(text, "[FAIL] connectivity") || !strings.Contains(text, "->") {
+		t.Errorf("报告文本不完整:\n%s", text)
+	}
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试自动埋点记录语句、事务与连接等待
+func TestDbInstrumentation_RecordsAutomatically(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	monitor := db233.NewPerformanceMonitor("instrumented", db)
+	if db.IsInstrumented() {
+		t.Fatal("默认不应启用自动埋点")
+	}
+	if db233.NewInstrumentedDb(db, monitor) != db || !db.IsInstrumented() || db.GetPerformanceMonitor() != monitor {
+		t.Fatal("应在原 Db 上启用自动埋点")
+	}
+	repo := db233.NewBaseCrudRepository(db)
+
+	if _, err := db.ExecuteUpdateWithTimeout("UPDATE tx_stock SET count = 1", nil, 0); err != nil {
+		t.Fatalf("执行失败: %v", err)
+	}
+	if count, err := repo.Count(&TxStock{}); err != nil || count != 3 {
+		t.Fatalf("计数失败: %d %v", count, err)
+	}
+	db233.RunInTransaction(context.Background(), db, func(ctx context.Context) error {
+		return repo.WithContext(ctx).Save(&TxOrder{Id: 1, Amount: 100})
+	})
+	db233.RunInTransaction(context.Background(), db.WithPriority(db233.QueryPriorityBatch), func(ctx context.Context) error {
+		tm := db233.TxFromContext(ctx)
+		if _, err := tm.Exec("UPDATE tx_stock SET count = 2"); err != nil {
+			return err
+		}
+		return errors.New("回滚")
+	})
+
+	report := monitor.GetDetailedReport()
+	expected := map[string]interface{}{
+		"total_queries":            int64(4),
+		"successful_queries":       int64(4),
+		"total_transactions":       int64(2),
+		"active_transactions":      int64(0),
+		"committed_transactions":   int64(1),
+		"rolled_back_transactions": int64(1),
+		"connection_acquired":      int64(4),
+		"connection_released":      int64(4),
+	}
+	for key, value := range expected {
+		if report[key] != value {
+			t.Errorf("%s 期望 %v, 得到 %v", key, value, report[key])
+		}
+	}
+	if len(txContextStatements()) != 4 {
+		t.Errorf("自动埋点不应产生额外语句: %+v", txContextStatements())
+	}
+}
+
+// 测试未启用自动埋点时只记录显式调用
+func TestDbInstrumentation_Disabled(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	monitor := db233.NewPerformanceMonitor("manual", db)
+	db.SetPerformanceMonitor(monitor)
+
+	db.ExecuteUpdateWithTimeout("UPDATE tx_stock SET count = 1", nil, 0)
+	if report := monitor.GetDetailedReport(); report["total_queries"] != int64(0) || report["connection_acquired"] != int64(0) {
+		t.Errorf("未启用自动埋点时不应自动记录: %v", report["total_queries"])
+	}
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试维护模式下的健康检查（不访问数据库）
+func TestDbMaintenance_HealthCheck(t *testing.T) {
+	db := db233.NewDb(nil, 1, nil)
+	checker := db233.NewHealthChecker(db)
+
+	db.EnterMaintenance("升级 MySQL 8.0")
+	if !db.IsInMaintenance() {
+		t.Fatal("应处于维护模式")
+	}
+
+	result := checker.Check()
+	if result.GetStatus() != db233.HealthStatusMaintenance || result.Healthy || result.IsAlertWorthy() {
+		t.Errorf("维护中的健康检查结果不正确: %+v", result)
+	}
+	overall := checker.ComprehensiveCheck()["overall"]
+	if overall.GetStatus() != db233.HealthStatusMaintenance {
+		t.Errorf("综合检查应报告维护中, 得到 %s", overall.GetStatus())
+	}
+
+	db.ExitMaintenance()
+	if inMaintenance, _, _ := db.GetMaintenanceInfo(); inMaintenance {
+		t.Error("退出后不应处于维护模式")
+	}
+}
+
+// 测试维护期间暂停关联告警规则
+func TestDbMaintenance_PausesAlertRules(t *testing.T) {
+	db := db233.NewDb(nil, 1, nil)
+	manager := db233.NewAlertManager("maintenance_test")
+	manager.AddAlertRule(db233.AlertRule{
+		ID:            "error_rate",
+		Name:          "错误率过高",
+		Metric:        "error_rate",
+		Condition:     db233.GreaterThan,
+		Threshold:     0.1,
+		Enabled:       true,
+		MaintenanceDb: db,
+	})
+
+	db.EnterMaintenance("计划停机")
+	manager.CheckMetric("error_rate", 0.5)
+	if len(manager.GetActiveAlerts()) != 0 {
+		t.Error("维护期间不应触发告警")
+	}
+
+	db.ExitMaintenance()
+	manager.CheckMetric("error_rate", 0.5)
+	if len(manager.GetActiveAlerts()) != 1 {
+		t.Error("退出维护后应恢复告警评估")
+	}
+}
+
+// 测试维护期间的请求不计入 SLO
+func TestDbMaintenance_ExcludedFromSLO(t *testing.T) {
+	db := db233.NewDb(nil, 1, nil)
+	monitor := db233.NewPerformanceMonitor("orders", db)
+	manager := db233.NewAlertManager("maintenance_slo_test")
+	evaluator, err := manager.AddSLORules("orders", monitor, db233.DefaultSLOConfig())
+	if err != nil {
+		t.Fatalf("添加 SLO 规则失败: %v", err)
+	}
+
+	start := time.Now()
+	evaluator.Evaluate(start)
+
+	db.EnterMaintenance("计划停机")
+	for i := 0; i < 10; i++ {
+		monitor.RecordQuery("SELECT 1", 10*time.Millisecond, false, errors.New("connection refused"))
+	}
+	evaluator.Evaluate(start.Add(time.Minute))
+	if len(manager.GetActiveAlerts()) != 0 {
+		t.Error("维护期间不应触发 SLO 告警")
+	}
+	db.ExitMaintenance()
+
+	for i := 0; i < 10; i++ {
+		monitor.RecordQuery("SELECT 1", 10*time.Millisecond, true, nil)
+	}
+	metrics := evaluator.Evaluate(start.Add(2 * time.Minute))
+	if metrics["availability"] != 1 {
+		t.Errorf("维护期间的失败不应计入可用性, 得到 %v", metrics["availability"])
+	}
+}
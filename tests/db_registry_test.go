@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试注册、按名称查找与重复注册
+func TestDbRegistry_RegisterAndGet(t *testing.T) {
+	registry := db233.NewDbRegistry()
+	usersDb := db233.NewDb(nil, 1, nil)
+	logsDb := db233.NewDb(nil, 2, nil)
+
+	if err := registry.Register("users", usersDb); err != nil {
+		t.Fatalf("注册失败: %v", err)
+	}
+	if err := registry.Register("logs", logsDb); err != nil {
+		t.Fatalf("注册失败: %v", err)
+	}
+	if err := registry.Register("users", logsDb); err == nil {
+		t.Error("重复名称应返回错误")
+	}
+	if err := registry.Register("", usersDb); err == nil {
+		t.Error("空名称应返回错误")
+	}
+	if err := registry.Register("nil", nil); err == nil {
+		t.Error("nil Db 应返回错误")
+	}
+
+	if db, err := registry.Get("logs"); err != nil || db != logsDb {
+		t.Errorf("按名称获取的 Db 不正确: %v", err)
+	}
+	if _, err := registry.Get("missing"); err == nil {
+		t.Error("未注册的名称应返回错误")
+	} else if dbErr, ok := err.(*db233.Db233Exception); !ok || dbErr.Code != "DB_NOT_REGISTERED" {
+		t.Errorf("错误码不正确: %v", err)
+	}
+	if names := registry.GetNames(); len(names) != 2 || names[0] != "logs" || names[1] != "users" {
+		t.Errorf("名称列表不正确: %v", names)
+	}
+
+	if registry.Unregister("users") != usersDb || registry.Unregister("users") != nil {
+		t.Error("取消注册应返回被移除的 Db")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustGet 未注册的名称应 panic")
+		}
+	}()
+	registry.MustGet("users")
+}
+
+// 测试按名称构造存储库与迁移管理器
+func TestDbRegistry_ConstructByName(t *testing.T) {
+	db, fake := newNamingDb(t)
+	if err := db233.RegisterDb("registry_orders", db); err != nil {
+		t.Fatalf("注册失败: %v", err)
+	}
+	defer db233.GetDbRegistryInstance().Unregister("registry_orders")
+
+	if db233.MustGetDb("registry_orders") != db {
+		t.Error("全局注册表获取的 Db 不正确")
+	}
+	repo, err := db233.NewBaseCrudRepositoryByName("registry_orders")
+	if err != nil || repo == nil {
+		t.Fatalf("按名称创建存储库失败: %v", err)
+	}
+	if err := repo.Save(&NamingItem{Id: 1}); err != nil || len(fake.execSqls()) == 0 {
+		t.Errorf("存储库应使用注册的 Db: %v", err)
+	}
+	if manager, err := db233.NewMigrationManagerByName("registry_orders", "./migrations"); err != nil || manager == nil {
+		t.Errorf("按名称创建迁移管理器失败: %v", err)
+	}
+	if _, err := db233.NewBaseCrudRepositoryByName("registry_missing"); err == nil {
+		t.Error("未注册的名称应返回错误")
+	}
+}
+
+// 测试按配置打开失败时不注册任何 Db
+func TestDbRegistry_OpenFromConfigFailure(t *testing.T) {
+	registry := db233.NewDbRegistry()
+	if err := registry.OpenFromConfig(&db233.Db233FileConfig{}); err == nil {
+		t.Error("没有声明 databases 时应返回错误")
+	}
+
+	config := db233.NewDefaultMySQLConfig("127.0.0.1", 1, "root", "", "game")
+	config.ConnectTimeout = 200 * time.Millisecond
+	err := registry.OpenFromConfig(&db233.Db233FileConfig{
+		Databases: map[string]*db233.DbConnectionConfig{"unreachable": config},
+	})
+	if err == nil {
+		t.Fatal("连接失败时应返回错误")
+	}
+	if len(registry.GetNames()) != 0 {
+		t.Errorf("失败时不应注册 Db: %v", registry.GetNames())
+	}
+}
+
+// 测试停机编排器排空注册表中的所有 Db
+func TestDbRegistry_Lifecycle(t *testing.T) {
+	registry := db233.NewDbRegistry()
+	registry.Register("a", db233.NewDb(nil, 1, nil))
+	registry.Register("b", db233.NewDb(nil, 2, nil))
+
+	lifecycle := db233.NewLifecycleManager("registry")
+	if err := lifecycle.Register("dbs", registry); err != nil {
+		t.Fatalf("注册注册表失败: %v", err)
+	}
+	report, err := lifecycle.Shutdown(context.Background())
+	if err != nil || len(report.Stopped) != 2 {
+		t.Fatalf("应排空注册表中的所有 Db: %+v %v", report, err)
+	}
+	for _, db := range registry.GetAll() {
+		if !db.IsDraining() {
+			t.Errorf("Db 应处于排空状态: %d", db.DbId)
+		}
+	}
+}
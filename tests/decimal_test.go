@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type DecimalWallet struct {
+	Id      int64          `db:"id,primary_key"`
+	Balance db233.Decimal  `db:"balance" decimal:"19,4"`
+	Frozen  *db233.Decimal `db:"frozen"`
+	Rate    float64        `db:"rate" decimal:"9,6"`
+}
+
+// 值接收者：FindByCondition 返回值类型的实体
+func (e DecimalWallet) TableName() string { return "decimal_wallet" }
+
+func (e DecimalWallet) SerializeBeforeSaveDb() {}
+
+func (e DecimalWallet) DeserializeAfterLoadDb() {}
+
+// 测试 Decimal 运算与四舍五入没有浮点误差
+func TestDecimal_Arithmetic(t *testing.T) {
+	sum := db233.MustDecimal("0.1").Add(db233.MustDecimal("0.2"))
+	if sum.String() != "0.3" || !sum.Equal(db233.MustDecimal("0.30")) {
+		t.Errorf("0.1 + 0.2 应精确等于 0.3: %s", sum)
+	}
+	total := db233.MustDecimal("19.99").Mul(db233.NewDecimalFromInt(3))
+	if total.String() != "59.97" {
+		t.Errorf("乘法结果不正确: %s", total)
+	}
+	cases := map[string]string{"2.345": "2.35", "-2.345": "-2.35", "2.344": "2.34", "0.005": "0.01", "7": "7.00"}
+	for input, expected := range cases {
+		if rounded := db233.MustDecimal(input).Round(2).String(); rounded != expected {
+			t.Errorf("Round(%s) = %s，期望 %s", input, rounded, expected)
+		}
+	}
+	if db233.MustDecimal("-0.5").Sub(db233.MustDecimal("0.25")).String() != "-0.75" || db233.NewDecimal(5, 3).String() != "0.005" {
+		t.Error("减法或小数格式化不正确")
+	}
+	if db233.MustDecimal("1.5").Cmp(db233.MustDecimal("1.49")) != 1 || !db233.MustDecimal("0.00").IsZero() {
+		t.Error("比较或零值判断不正确")
+	}
+	for _, invalid := range []string{"", "1.2.3", "abc", "1e5", "-"} {
+		if _, err := db233.NewDecimalFromString(invalid); err == nil {
+			t.Errorf("非法文本应返回错误: %q", invalid)
+		}
+	}
+
+	data, _ := json.Marshal(map[string]db233.Decimal{"price": db233.MustDecimal("10.50")})
+	if string(data) != `{"price":"10.50"}` {
+		t.Errorf("JSON 应序列化为字符串: %s", data)
+	}
+	var decoded struct{ Price db233.Decimal }
+	if err := json.Unmarshal([]byte(`{"Price":12.345}`), &decoded); err != nil || decoded.Price.String() != "12.345" {
+		t.Errorf("JSON 数字应精确解析: %s %v", decoded.Price, err)
+	}
+}
+
+// 测试写入按标签小数位数四舍五入、读取 DECIMAL 文本不经过 float64
+func TestDecimal_SaveAndScan(t *testing.T) {
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		return fakeRowsOf([]string{"id", "balance", "frozen", "rate"},
+			[]driver.Value{int64(1), []byte("1234567890123.1000"), []byte("0.0300"), []byte("0.015000")}), nil
+	}
+	repo := db233.NewBaseCrudRepository(fake.newDb(t, db233.EnumDatabaseTypeMySQL))
+
+	frozen := db233.MustDecimal("0.123456")
+	if err := repo.Save(&DecimalWallet{Id: 1, Balance: db233.MustDecimal("12.34565"), Frozen: &frozen, Rate: 0.015}); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	params := fake.execArgs()[0]
+	values := map[interface{}]bool{}
+	for _, value := range params {
+		values[value] = true
+	}
+	if !values["12.3457"] || !values["0.123456"] {
+		t.Errorf("Decimal 应按列小数位数四舍五入后以文本写入: %#v", params)
+	}
+
+	results, err := repo.FindByCondition("id = ?", []interface{}{1}, DecimalWallet{})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("查询失败: %v %v", results, err)
+	}
+	wallet := results[0].(DecimalWallet)
+	if wallet.Balance.String() != "1234567890123.1000" || wallet.Frozen == nil || wallet.Frozen.String() != "0.0300" || wallet.Rate != 0.015 {
+		t.Errorf("DECIMAL 列映射不正确: %s %v %v", wallet.Balance, wallet.Frozen, wallet.Rate)
+	}
+}
+
+// 测试 decimal 标签与 Decimal 字段的列类型
+func TestDecimal_SqlType(t *testing.T) {
+	cm := db233.GetCrudManagerInstance()
+	walletType := reflect.TypeOf(DecimalWallet{})
+	expected := map[string]string{"Balance": "DECIMAL(19,4)", "Frozen": "DECIMAL(19,4)", "Rate": "DECIMAL(9,6)"}
+	for name, sqlType := range expected {
+		field, _ := walletType.FieldByName(name)
+		if actual := db233.NewMySQLStrategy(cm).GetSQLType(field); actual != sqlType {
+			t.Errorf("MySQL %s 列类型不正确: %s（期望 %s）", name, actual, sqlType)
+		}
+		if actual := db233.NewSQLServerStrategy(cm).GetSQLType(field); actual != sqlType {
+			t.Errorf("SQL Server %s 列类型不正确: %s（期望 %s）", name, actual, sqlType)
+		}
+	}
+}
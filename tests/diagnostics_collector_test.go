@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+const fakeInnodbStatus = `
+=====================================
+2026-10-16 10:00:00 INNODB MONITOR OUTPUT
+=====================================
+------------------------
+LATEST DETECTED DEADLOCK
+------------------------
+*** (1) TRANSACTION:
+UPDATE account SET balance = balance - 1 WHERE id = 1
+*** (2) TRANSACTION:
+UPDATE account SET balance = balance + 1 WHERE id = 2
+*** WE ROLL BACK TRANSACTION (2)
+------------
+TRANSACTIONS
+------------
+Trx id counter 1234
+`
+
+// 模拟死锁的驱动：UPDATE 返回 1213，诊断查询返回固定结果
+var lockDiagStatusQueries int64
+
+func lockDiagExec(stmt fakeStatement) (driver.Result, error) {
+	if strings.HasPrefix(stmt.query, "UPDATE") {
+		return nil, errors.New("Error 1213 (40001): Deadlock found when trying to get lock; try restarting transaction")
+	}
+	if strings.HasPrefix(stmt.query, "DELETE") {
+		return nil, errors.New("Error 1062 (23000): Duplicate entry '1' for key 'PRIMARY'")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func lockDiagQuery(stmt fakeStatement) (*fakeRows, error) {
+	switch {
+	case stmt.query == "SHOW ENGINE INNODB STATUS":
+		atomic.AddInt64(&lockDiagStatusQueries, 1)
+		return fakeRowsOf([]string{"Type", "Name", "Status"}, []driver.Value{"InnoDB", "", fakeInnodbStatus}), nil
+	case strings.Contains(stmt.query, "sys.innodb_lock_waits"):
+		return fakeRowsOf(
+			[]string{"waiting_pid", "waiting_query", "wait_age_secs", "blocking_pid", "blocking_query", "locked_table", "waiting_lock_mode"},
+			[]driver.Value{int64(11), "UPDATE account SET balance = 0 WHERE id = 1", 3.5, int64(12), nil, "`game`.`account`", "X"},
+		), nil
+	}
+	return nil, errors.New("未知查询: " + stmt.query)
+}
+
+func newLockDiagDb(t *testing.T) *db233.Db {
+	fake := newFakeDriver()
+	fake.noTx = true
+	fake.onExec = lockDiagExec
+	fake.onQuery = lockDiagQuery
+	return db233.NewDb(fake.open(t, ""), 1, nil)
+}
+
+// 测试锁错误识别
+func TestIsLockError(t *testing.T) {
+	cases := map[string]bool{
+		"Error 1213 (40001): Deadlock found when trying to get lock":          true,
+		"Error 1205 (HY000): Lock wait timeout exceeded; try restarting":      true,
+		"pq: deadlock detected (SQLSTATE 40P01)":                              true,
+		"ERROR: could not obtain lock on row in relation \"account\" (55P03)": true,
+		"Error 1062 (23000): Duplicate entry '1' for key 'PRIMARY'":           false,
+	}
+	for message, expected := range cases {
+		if db233.IsLockError(errors.New(message)) != expected {
+			t.Errorf("%q 识别结果应为 %v", message, expected)
+		}
+	}
+	if db233.IsLockError(nil) {
+		t.Error("nil 不是锁错误")
+	}
+}
+
+// 测试死锁错误自动采集快照并附加到错误上
+func TestDiagnosticsCollector_AttachToError(t *testing.T) {
+	db := newLockDiagDb(t)
+	collector := db233.NewDiagnosticsCollector(db)
+	db.SetDiagnosticsCollector(collector)
+	before := atomic.LoadInt64(&lockDiagStatusQueries)
+
+	_, err := db.ExecuteUpdateWithTimeout("UPDATE account SET balance = 0 WHERE id = ?", []interface{}{1}, 0)
+	if err == nil {
+		t.Fatal("期望死锁错误")
+	}
+	var exception *db233.LockDiagnosticsException
+	if !errors.As(err, &exception) || !exception.Deadlock || exception.GetCode() != "DEADLOCK" {
+		t.Fatalf("错误应包含死锁诊断异常: %v", err)
+	}
+
+	snapshot := db233.GetLockDiagnostics(err)
+	if snapshot == nil {
+		t.Fatal("错误上应附加快照")
+	}
+	if len(snapshot.LockWaits) != 1 || snapshot.LockWaits[0].BlockingPid != 12 || snapshot.LockWaits[0].LockedTable != "`game`.`account`" {
+		t.Errorf("锁等待关系不正确: %+v", snapshot.LockWaits)
+	}
+	if !strings.Contains(snapshot.LatestDeadlock, "WE ROLL BACK TRANSACTION (2)") || strings.Contains(snapshot.LatestDeadlock, "Trx id counter") {
+		t.Errorf("应只截取最近死锁段落: %q", snapshot.LatestDeadlock)
+	}
+	if !strings.Contains(snapshot.TriggerError, "1213") || len(snapshot.Errors) != 0 {
+		t.Errorf("快照触发信息不正确: %+v", snapshot)
+	}
+
+	// 间隔内复用快照
+	_, err = db.ExecuteUpdateWithTimeout("UPDATE account SET balance = 1 WHERE id = ?", []interface{}{2}, 0)
+	if db233.GetLockDiagnostics(err) != snapshot || atomic.LoadInt64(&lockDiagStatusQueries)-before != 1 {
+		t.Error("最小间隔内应复用上一次快照")
+	}
+
+	// 非锁错误保持原样
+	_, err = db.ExecuteUpdateWithTimeout("DELETE FROM account WHERE id = ?", []interface{}{1}, 0)
+	if err == nil || db233.GetLockDiagnostics(err) != nil {
+		t.Errorf("非锁错误不应附加快照: %v", err)
+	}
+}
+
+// 测试按需采集与监控报告展示
+func TestDiagnosticsCollector_CaptureAndReport(t *testing.T) {
+	db := newLockDiagDb(t)
+	collector := db233.NewDiagnosticsCollector(db)
+	collector.SetMaxSnapshots(2)
+
+	for i := 0; i < 3; i++ {
+		collector.Capture()
+	}
+	if len(collector.GetSnapshots()) != 2 {
+		t.Errorf("应只保留 2 个快照, 得到 %d", len(collector.GetSnapshots()))
+	}
+	if collector.GetLatestSnapshot().TriggerSql != "" {
+		t.Error("按需采集不应有触发语句")
+	}
+
+	generator := db233.NewMonitoringReportGenerator("lock_report")
+	generator.SetReportPeriod(time.Hour)
+	generator.AddDiagnosticsCollector("main", collector)
+
+	report := generator.GenerateReportData()
+	if len(report.Details.LockDiagnostics) != 1 || report.Details.LockDiagnostics[0].Database != "main" || len(report.Details.LockDiagnostics[0].Snapshots) != 2 {
+		t.Fatalf("报告详情应包含锁诊断: %+v", report.Details.LockDiagnostics)
+	}
+
+	text, err := generator.RenderReport("text")
+	if err != nil {
+		t.Fatalf("渲染报告失败: %v", err)
+	}
+	if !strings.Contains(string(text), "=== 锁诊断 ===") || !strings.Contains(string(text), "被 [12]") {
+		t.Errorf("文本报告缺少锁诊断段落: %s", text)
+	}
+
+	collector.Clear()
+	if collector.GetLatestSnapshot() != nil || len(generator.GenerateReportData().Details.LockDiagnostics) != 0 {
+		t.Error("清空后不应有快照")
+	}
+}
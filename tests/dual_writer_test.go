@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试镜像实体注册
+func TestDualWriter_RegisterEntity(t *testing.T) {
+	dw := db233.NewDualWriter(nil, nil, 0)
+	if dw.IsMirrored(&TestUser{}) {
+		t.Error("未注册的实体不应被镜像")
+	}
+
+	dw.RegisterEntity(&TestUser{})
+	if !dw.IsMirrored(&TestUser{}) {
+		t.Error("已注册的实体应被镜像")
+	}
+
+	dw.Start()
+	dw.Stop()
+	stats := dw.GetStats()
+	if stats["running"].(bool) {
+		t.Error("停止后不应处于运行状态")
+	}
+}
+
+// 测试镜像写入与失败隔离（需要数据库）
+func TestDualWriter_MirrorAndQuarantine(t *testing.T) {
+	primary := CreateTestDb(t)
+	defer primary.Close()
+	if err := SetupTestTables(primary); err != nil {
+		t.Fatalf("初始化测试表失败: %v", err)
+	}
+
+	// 主库和镜像库使用同一个库，验证镜像成功路径
+	dw := db233.NewDualWriter(primary, primary, 16)
+	dw.RegisterEntity(&TestUser{})
+	dw.Start()
+
+	user := &TestUser{Username: "test_dual_writer", Email: "dual@example.com", Age: 20}
+	if err := dw.Save(user); err != nil {
+		t.Fatalf("双写保存失败: %v", err)
+	}
+	dw.Stop()
+
+	stats := dw.GetStats()
+	if stats["mirrored_writes"].(int64) != 1 {
+		t.Errorf("期望镜像写入数为 1, 得到 %v", stats["mirrored_writes"])
+	}
+
+	// 镜像库不可用时写入进入隔离区
+	badSource, _ := sql.Open("mysql", "root:root@tcp(127.0.0.1:1)/db233_go")
+	defer badSource.Close()
+	failing := db233.NewDualWriter(primary, db233.NewDb(badSource, 0, nil), 16)
+	failing.RegisterEntity(&TestUser{})
+	failing.Start()
+
+	if err := failing.Update(user); err != nil {
+		t.Fatalf("主库更新失败: %v", err)
+	}
+	failing.Stop()
+
+	if len(failing.GetQuarantine()) != 1 {
+		t.Fatalf("期望隔离区有 1 条写入, 得到 %d", len(failing.GetQuarantine()))
+	}
+	if succeeded := failing.RetryQuarantine(); succeeded != 0 {
+		t.Errorf("镜像库仍不可用，重放不应成功: %d", succeeded)
+	}
+
+	primary.DataSource.Exec("DELETE FROM test_user WHERE id = ?", user.ID)
+}
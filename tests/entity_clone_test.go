@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 嵌入指针基类的实体
+type CloneBaseEntity struct {
+	PlayerID int64  `db:"player_id,primary_key"`
+	Server   string `db:"server"`
+}
+
+type cloneBagEntity struct {
+	*CloneBaseEntity
+	Items  []int          `db:"items"`
+	Extra  map[string]int `db:"extra"`
+	Level  int            `db:"level"`
+	Cached string         `db:"-"`
+}
+
+func (e *cloneBagEntity) TableName() string       { return "clone_bag" }
+func (e *cloneBagEntity) SerializeBeforeSaveDb()  {}
+func (e *cloneBagEntity) DeserializeAfterLoadDb() {}
+
+// 测试克隆实体不与原实体共享引用类型字段
+func TestCloneEntity_DeepCopy(t *testing.T) {
+	src := &cloneBagEntity{
+		CloneBaseEntity: &CloneBaseEntity{PlayerID: 1, Server: "s1"},
+		Items:           []int{1, 2},
+		Extra:           map[string]int{"gold": 10},
+		Level:           3,
+		Cached:          "cached",
+	}
+
+	cloned, err := db233.CloneEntity(src)
+	if err != nil {
+		t.Fatalf("克隆失败: %v", err)
+	}
+	clone := cloned.(*cloneBagEntity)
+	if !reflect.DeepEqual(src, clone) {
+		t.Fatalf("克隆结果应与原实体相等: %+v vs %+v", src, clone)
+	}
+
+	clone.Items[0] = 100
+	clone.Extra["gold"] = 0
+	clone.Server = "s2"
+	if src.Items[0] != 1 || src.Extra["gold"] != 10 || src.Server != "s1" {
+		t.Errorf("修改克隆体不应影响原实体: %+v %+v", src, src.CloneBaseEntity)
+	}
+
+	if _, err := db233.CloneEntity(nil); err == nil {
+		t.Error("克隆 nil 应报错")
+	}
+}
+
+// 测试只合并非零字段且跳过主键
+func TestMergeNonZero(t *testing.T) {
+	dst := &StrengthEntity{BasePlayerEntity: BasePlayerEntity{PlayerID: 1}, CurrentStrength: 10, LastUpdateTimeMs: 100}
+	patch := &StrengthEntity{BasePlayerEntity: BasePlayerEntity{PlayerID: 2}, CurrentStrength: 20, NoDbTag: "ignored"}
+
+	columns, err := db233.MergeNonZero(dst, patch)
+	if err != nil {
+		t.Fatalf("合并失败: %v", err)
+	}
+	if !reflect.DeepEqual(columns, []string{"current_strength"}) {
+		t.Errorf("合并列不正确: %v", columns)
+	}
+	if dst.CurrentStrength != 20 || dst.LastUpdateTimeMs != 100 || dst.PlayerID != 1 || dst.NoDbTag != "" {
+		t.Errorf("合并结果不正确: %+v", dst)
+	}
+
+	// 目标的嵌入指针为 nil 时自动补齐
+	bag := &cloneBagEntity{}
+	columns, err = db233.MergeNonZero(bag, &cloneBagEntity{CloneBaseEntity: &CloneBaseEntity{Server: "s1"}, Items: []int{1}})
+	if err != nil {
+		t.Fatalf("合并失败: %v", err)
+	}
+	if bag.CloneBaseEntity == nil || bag.Server != "s1" || len(bag.Items) != 1 || len(columns) != 2 {
+		t.Errorf("合并结果不正确: %+v, 列=%v", bag, columns)
+	}
+
+	if _, err := db233.MergeNonZero(dst, &TestUser{}); err == nil {
+		t.Error("类型不一致应报错")
+	}
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试快照字段级对比
+func TestDiffSnapshots(t *testing.T) {
+	oldSnapshot := map[string]interface{}{
+		"id":       float64(1),
+		"username": "alice",
+		"age":      float64(18),
+		"legacy":   "x",
+	}
+	newSnapshot := map[string]interface{}{
+		"id":       float64(1),
+		"username": "alice",
+		"age":      float64(19),
+		"email":    "alice@example.com",
+	}
+
+	changes := db233.DiffSnapshots(oldSnapshot, newSnapshot)
+	if len(changes) != 3 {
+		t.Fatalf("期望 3 个变更, 得到 %d: %+v", len(changes), changes)
+	}
+
+	// 按列名排序：age, email, legacy
+	if changes[0].Column != "age" || changes[0].OldValue != float64(18) || changes[0].NewValue != float64(19) {
+		t.Errorf("age 变更不正确: %+v", changes[0])
+	}
+	if changes[1].Column != "email" || changes[1].OldValue != nil {
+		t.Errorf("email 变更不正确: %+v", changes[1])
+	}
+	if changes[2].Column != "legacy" || changes[2].NewValue != nil {
+		t.Errorf("legacy 变更不正确: %+v", changes[2])
+	}
+}
+
+// 测试相同快照无变更
+func TestDiffSnapshots_NoChange(t *testing.T) {
+	snapshot := map[string]interface{}{"id": float64(1), "username": "bob"}
+	if changes := db233.DiffSnapshots(snapshot, snapshot); len(changes) != 0 {
+		t.Errorf("期望无变更, 得到 %+v", changes)
+	}
+}
+
+// 测试历史记录与版本对比（需要数据库）
+func TestEntityHistoryManager_DiffVersions(t *testing.T) {
+	db := CreateTestDb(t)
+	defer db.Close()
+
+	if err := SetupTestTables(db); err != nil {
+		t.Fatalf("设置测试表失败: %v", err)
+	}
+
+	hm := db233.NewEntityHistoryManager(db)
+	if err := hm.EnsureHistoryTable(&TestUser{}); err != nil {
+		t.Fatalf("创建历史表失败: %v", err)
+	}
+	defer db.DataSource.Exec("DROP TABLE IF EXISTS " + hm.GetHistoryTableName(&TestUser{}))
+
+	user := &TestUser{Username: "test_history", Email: "h1@example.com", Age: 20}
+	if err := hm.SaveWithHistory(user); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+
+	user.Age = 21
+	if err := hm.UpdateWithHistory(user); err != nil {
+		t.Fatalf("更新失败: %v", err)
+	}
+
+	history, err := hm.GetHistory(user.ID, db233.HistoryRange{}, &TestUser{})
+	if err != nil {
+		t.Fatalf("查询历史失败: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("期望 2 个版本, 得到 %d", len(history))
+	}
+
+	changes, err := hm.DiffVersions(user.ID, 1, 2, &TestUser{})
+	if err != nil {
+		t.Fatalf("对比版本失败: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Column != "age" {
+		t.Errorf("期望仅 age 变更, 得到 %+v", changes)
+	}
+}
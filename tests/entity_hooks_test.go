@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type hookContextKey struct{}
+
+// HookedTestUser 实现生命周期钩子的测试实体
+type HookedTestUser struct {
+	ID       int    `db:"id,primary_key,auto_increment"`
+	Username string `db:"username"`
+
+	serialized bool
+	seenValue  interface{}
+}
+
+func (u *HookedTestUser) TableName() string {
+	return "test_user"
+}
+
+func (u *HookedTestUser) SerializeBeforeSaveDb() {
+	u.serialized = true
+}
+
+func (u *HookedTestUser) DeserializeAfterLoadDb() {}
+
+// 用户名为空时否决写入
+func (u *HookedTestUser) BeforeSave(ctx context.Context) error {
+	u.seenValue = ctx.Value(hookContextKey{})
+	if u.Username == "" {
+		return errors.New("用户名不能为空")
+	}
+	return nil
+}
+
+// 测试 BeforeSave 钩子否决 Save / Update（否决时不访问数据库，也不调用序列化钩子）
+func TestEntityHooks_BeforeSaveVeto(t *testing.T) {
+	ctx := context.WithValue(context.Background(), hookContextKey{}, "trace-1")
+	repo := db233.NewBaseCrudRepository(nil).WithContext(ctx)
+
+	user := &HookedTestUser{}
+	if err := repo.Save(user); err == nil || err.Error() != "用户名不能为空" {
+		t.Fatalf("Save 应返回钩子错误, 得到 %v", err)
+	}
+	if user.serialized {
+		t.Error("钩子否决后不应调用 SerializeBeforeSaveDb")
+	}
+	if user.seenValue != "trace-1" {
+		t.Errorf("钩子应收到 WithContext 指定的 context, 得到 %v", user.seenValue)
+	}
+
+	if err := repo.Update(&HookedTestUser{ID: 1}); err == nil {
+		t.Error("Update 也应被 BeforeSave 钩子否决")
+	}
+}
+
+// 测试 context 在 WithScope 后保留
+func TestEntityHooks_ContextSurvivesScope(t *testing.T) {
+	ctx := context.WithValue(context.Background(), hookContextKey{}, "trace-2")
+	repo := db233.NewBaseCrudRepository(nil).WithContext(ctx).WithScope(nil)
+	if repo.GetContext().Value(hookContextKey{}) != "trace-2" {
+		t.Error("WithScope 应保留 context")
+	}
+	if db233.NewBaseCrudRepository(nil).GetContext() == nil {
+		t.Error("未指定 context 时应返回 context.Background()")
+	}
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// RelationTestItem 背包物品
+type RelationTestItem struct {
+	ID       int `db:"id,primary_key,auto_increment"`
+	PlayerId int `db:"player_id"`
+}
+
+func (i *RelationTestItem) TableName() string       { return "relation_test_item" }
+func (i *RelationTestItem) SerializeBeforeSaveDb()  {}
+func (i *RelationTestItem) DeserializeAfterLoadDb() {}
+
+// RelationTestGuild 公会
+type RelationTestGuild struct {
+	GuildId int `db:"guild_id,primary_key"`
+}
+
+func (g *RelationTestGuild) TableName() string       { return "relation_test_guild" }
+func (g *RelationTestGuild) SerializeBeforeSaveDb()  {}
+func (g *RelationTestGuild) DeserializeAfterLoadDb() {}
+
+// RelationTestPlayer 玩家（声明三种关联）
+type RelationTestPlayer struct {
+	ID        int                 `db:"id,primary_key,auto_increment"`
+	GuildId   int                 `db:"guild_id"`
+	Inventory []*RelationTestItem `rel:"hasMany,foreignKey=player_id"`
+	Strength  *RelationTestItem   `rel:"hasOne"`
+	Guild     RelationTestGuild   `rel:"belongsTo"`
+}
+
+func (p *RelationTestPlayer) TableName() string       { return "relation_test_player" }
+func (p *RelationTestPlayer) SerializeBeforeSaveDb()  {}
+func (p *RelationTestPlayer) DeserializeAfterLoadDb() {}
+
+// 测试 rel 标签解析及默认外键 / 被引用列
+func TestEntityRelations_Metadata(t *testing.T) {
+	metas, err := db233.GetRelationMetadata(reflect.TypeOf(&RelationTestPlayer{}))
+	if err != nil {
+		t.Fatalf("解析关联元数据失败: %v", err)
+	}
+	if len(metas) != 3 {
+		t.Fatalf("应解析出 3 个关联, 得到 %d", len(metas))
+	}
+
+	inventory := metas["Inventory"]
+	if inventory.Kind != db233.RelationHasMany || inventory.ForeignKey != "player_id" || inventory.References != "id" {
+		t.Errorf("Inventory 元数据不正确: %+v", inventory)
+	}
+	if inventory.TargetType != reflect.TypeOf(RelationTestItem{}) {
+		t.Errorf("Inventory 目标类型不正确: %s", inventory.TargetType)
+	}
+
+	strength := metas["Strength"]
+	if strength.Kind != db233.RelationHasOne || strength.ForeignKey != "relation_test_player_id" {
+		t.Errorf("Strength 默认外键不正确: %+v", strength)
+	}
+
+	guild := metas["Guild"]
+	if guild.Kind != db233.RelationBelongsTo || guild.ForeignKey != "guild_id" || guild.References != "guild_id" {
+		t.Errorf("Guild 元数据不正确: %+v", guild)
+	}
+}
+
+// 测试非法 rel 标签
+func TestEntityRelations_InvalidTag(t *testing.T) {
+	type badKind struct {
+		Items []*RelationTestItem `rel:"manyToMany"`
+	}
+	if _, err := db233.GetRelationMetadata(reflect.TypeOf(badKind{})); err == nil {
+		t.Error("未知关联类型应返回错误")
+	}
+
+	type badTarget struct {
+		Items *RelationTestItem `rel:"hasMany"`
+	}
+	if _, err := db233.GetRelationMetadata(reflect.TypeOf(badTarget{})); err == nil {
+		t.Error("hasMany 字段不是切片时应返回错误")
+	}
+}
+
+// 测试 LoadRelations 参数校验及空键（不访问数据库）
+func TestEntityRelations_LoadWithoutKeys(t *testing.T) {
+	repo := db233.NewBaseCrudRepository(nil)
+
+	if err := repo.LoadRelations(&RelationTestPlayer{}, "Unknown"); err == nil {
+		t.Error("未声明的关联应返回错误")
+	}
+
+	player := &RelationTestPlayer{Strength: &RelationTestItem{ID: 1}}
+	if err := repo.LoadRelations(player, "Inventory", "Strength", "Guild"); err != nil {
+		t.Fatalf("主键 / 外键为零值时不应查询数据库: %v", err)
+	}
+	if player.Inventory == nil || len(player.Inventory) != 0 {
+		t.Errorf("hasMany 关联应为空切片, 得到 %v", player.Inventory)
+	}
+	if player.Strength != nil {
+		t.Errorf("hasOne 关联应被清空, 得到 %v", player.Strength)
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 带种子数据的配置表实体
+type SeedItemCatalog struct {
+	ItemId int64  `db:"item_id,primary_key"`
+	Name   string `db:"name"`
+}
+
+func (e *SeedItemCatalog) TableName() string       { return "seed_item_catalog" }
+func (e *SeedItemCatalog) SerializeBeforeSaveDb()  {}
+func (e *SeedItemCatalog) DeserializeAfterLoadDb() {}
+
+func (e *SeedItemCatalog) SeedData() []db233.IDbEntity {
+	return []db233.IDbEntity{
+		&SeedItemCatalog{ItemId: 1001, Name: "小血瓶"},
+		&SeedItemCatalog{ItemId: 1002, Name: "大血瓶"},
+	}
+}
+
+// 缺少主键的种子数据
+type SeedMissingKey struct {
+	SeedItemCatalog
+}
+
+func (e *SeedMissingKey) SeedData() []db233.IDbEntity {
+	return []db233.IDbEntity{&SeedMissingKey{SeedItemCatalog{Name: "无主键"}}}
+}
+
+// 测试种子数据校验（不访问数据库）
+func TestSeedEntityData_Validation(t *testing.T) {
+	if count, err := db233.SeedEntityData(nil, &TestUser{}); err != nil || count != 0 {
+		t.Errorf("未实现 EntitySeeder 的实体应直接跳过, 得到 %d, %v", count, err)
+	}
+	if _, err := db233.SeedEntityData(nil, &SeedMissingKey{}); err == nil {
+		t.Error("种子数据缺少主键应报错")
+	}
+}
+
+// 测试建表后自动写入种子数据且重复执行不产生重复行
+func TestSeedEntityData_AfterAutoCreate(t *testing.T) {
+	db := CreateTestDb(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+	defer db.DataSource.Exec("DROP TABLE IF EXISTS seed_item_catalog")
+	db.DataSource.Exec("DROP TABLE IF EXISTS seed_item_catalog")
+
+	cm := db233.GetCrudManagerInstance()
+	if err := cm.AutoCreateTable(db, &SeedItemCatalog{}); err != nil {
+		t.Fatalf("建表失败: %v", err)
+	}
+	if err := cm.AutoMigrateTableSimple(db, &SeedItemCatalog{}); err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+
+	count, err := db233.NewBaseCrudRepository(db).Count(&SeedItemCatalog{})
+	if err != nil {
+		t.Fatalf("计数失败: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("种子数据应为 2 条, 得到 %d", count)
+	}
+}
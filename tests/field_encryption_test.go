@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// EncryptedTestUser 带加密字段的测试实体
+type EncryptedTestUser struct {
+	ID       int    `db:"id,primary_key,auto_increment"`
+	Username string `db:"username,encrypted,deterministic"`
+	Email    string `db:"email,encrypted"`
+	Age      int    `db:"age"`
+}
+
+func (u *EncryptedTestUser) TableName() string {
+	return "test_user"
+}
+
+func (u *EncryptedTestUser) SerializeBeforeSaveDb() {}
+
+func (u *EncryptedTestUser) DeserializeAfterLoadDb() {}
+
+// 设置测试密钥，测试结束后恢复
+func setupTestEncryptionKey(t *testing.T) *db233.StaticKeyProvider {
+	provider, err := db233.NewStaticKeyProvider("k1", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("创建密钥提供者失败: %v", err)
+	}
+	fem := db233.GetFieldEncryptionManagerInstance()
+	fem.SetKeyProvider(provider)
+	t.Cleanup(func() { fem.SetKeyProvider(nil) })
+	return provider
+}
+
+// 测试加解密、确定性加密与密钥轮换
+func TestFieldEncryption_EncryptDecrypt(t *testing.T) {
+	provider := setupTestEncryptionKey(t)
+	fem := db233.GetFieldEncryptionManagerInstance()
+
+	columns := fem.GetEncryptedColumns(&EncryptedTestUser{})
+	if len(columns) != 2 || !columns["username"].Deterministic || columns["email"].Deterministic {
+		t.Errorf("加密列解析错误: %+v", columns)
+	}
+
+	random1, _ := fem.Encrypt("123-45-6789", false)
+	random2, _ := fem.Encrypt("123-45-6789", false)
+	if random1 == random2 || !strings.HasPrefix(random1, "enc:k1:") {
+		t.Errorf("随机加密密文应不同且带密钥前缀: %s, %s", random1, random2)
+	}
+	if plain, err := fem.Decrypt(random1); err != nil || plain != "123-45-6789" {
+		t.Errorf("解密失败: %s, %v", plain, err)
+	}
+	if plain, _ := fem.Decrypt("plain text"); plain != "plain text" {
+		t.Error("明文应原样返回")
+	}
+
+	det1, _ := fem.Encrypt("alice", true)
+	det2, _ := fem.Encrypt("alice", true)
+	if det1 != det2 {
+		t.Error("确定性加密的密文应相同")
+	}
+
+	// 轮换后旧密文仍可解密，查询参数覆盖所有密钥
+	provider.AddKey("k2", []byte("fedcba9876543210fedcba9876543210"))
+	provider.Rotate("k2")
+	if plain, err := fem.Decrypt(det1); err != nil || plain != "alice" {
+		t.Errorf("轮换后解密旧密文失败: %s, %v", plain, err)
+	}
+	if !fem.NeedsRotation(det1) {
+		t.Error("旧密钥密文应需要轮换")
+	}
+	lookup, err := fem.EncryptForLookup("alice")
+	if err != nil || len(lookup) != 2 || lookup[0] != det1 {
+		t.Errorf("查询密文错误: %v, %v", lookup, err)
+	}
+
+	if _, err := db233.NewStaticKeyProvider("bad", []byte("short")); err == nil {
+		t.Error("长度不合法的密钥应返回错误")
+	}
+}
+
+// 测试以 enc: 开头的明文写入时仍被加密，确定性 nonce 不直接使用加密密钥做 HMAC
+func TestFieldEncryption_NoPrefixBypass(t *testing.T) {
+	setupTestEncryptionKey(t)
+	fem := db233.GetFieldEncryptionManagerInstance()
+
+	fake := newFakeDriver()
+	fake.onExec = func(stmt fakeStatement) (driver.Result, error) {
+		return fakeResult{lastInsertId: 1, rowsAffected: 1}, nil
+	}
+	repo := db233.NewBaseCrudRepository(fake.newDb(t, db233.EnumDatabaseTypeMySQL))
+
+	forged := "enc:k1:AAAA"
+	user := &EncryptedTestUser{Username: "alice", Email: forged}
+	if err := repo.Save(user); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	args := fake.execArgs()
+	if len(args) != 1 {
+		t.Fatalf("应执行 1 条写入, 得到 %v", fake.execSqls())
+	}
+	decrypted := false
+	for _, arg := range args[0] {
+		value, _ := arg.(string)
+		if value == forged {
+			t.Fatalf("以 enc: 开头的明文不应原样写入: %v", args[0])
+		}
+		if plain, err := fem.Decrypt(value); err == nil && plain == forged && value != plain {
+			decrypted = true
+		}
+	}
+	if !decrypted {
+		t.Errorf("写入参数中应包含可解密回原文的密文: %v", args[0])
+	}
+
+	det, err := fem.Encrypt("alice", true)
+	if err != nil {
+		t.Fatalf("确定性加密失败: %v", err)
+	}
+	payload, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(det, "enc:k1:"))
+	mac := hmac.New(sha256.New, []byte("0123456789abcdef0123456789abcdef"))
+	mac.Write([]byte("alice"))
+	if string(payload[:12]) == string(mac.Sum(nil)[:12]) {
+		t.Error("确定性 nonce 不应由加密密钥直接做 HMAC 派生")
+	}
+}
+
+// 测试保存加密、查询解密（需要数据库）
+func TestFieldEncryption_Repository(t *testing.T) {
+	db := CreateTestDb(t)
+	defer db.Close()
+	if err := SetupTestTables(db); err != nil {
+		t.Fatalf("初始化测试表失败: %v", err)
+	}
+	setupTestEncryptionKey(t)
+
+	repo := db233.NewBaseCrudRepository(db)
+	user := &EncryptedTestUser{Username: "enc_user", Email: "secret@example.com", Age: 30}
+	if err := repo.Save(user); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	defer db.DataSource.Exec("DELETE FROM test_user WHERE id = ?", user.ID)
+
+	var rawEmail string
+	db.DataSource.QueryRow("SELECT email FROM test_user WHERE id = ?", user.ID).Scan(&rawEmail)
+	if !db233.IsEncryptedValue(rawEmail) {
+		t.Errorf("数据库中应存储密文, 得到 %s", rawEmail)
+	}
+
+	found, err := repo.FindById(user.ID, &EncryptedTestUser{})
+	if err != nil || found == nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if found.(*EncryptedTestUser).Email != "secret@example.com" {
+		t.Errorf("查询结果应已解密, 得到 %s", found.(*EncryptedTestUser).Email)
+	}
+
+	lookup, _ := db233.GetFieldEncryptionManagerInstance().EncryptForLookup("enc_user")
+	results, err := repo.FindByCondition("username IN (?"+strings.Repeat(", ?", len(lookup)-1)+")", lookup, &EncryptedTestUser{})
+	if err != nil || len(results) != 1 {
+		t.Errorf("确定性加密等值查询失败: %d, %v", len(results), err)
+	}
+}
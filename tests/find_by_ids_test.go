@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试批量主键查询的参数校验（不访问数据库）
+func TestFindByIds_InvalidArguments(t *testing.T) {
+	repo := db233.NewBaseCrudRepository(db233.NewDb(nil, 1, nil))
+
+	entities, err := repo.FindByIds(nil, &TestUser{})
+	if err != nil || len(entities) != 0 {
+		t.Errorf("空主键列表应返回空结果, 得到 %v, %v", entities, err)
+	}
+	if _, err := repo.FindByIds([]interface{}{1}, nil); err == nil {
+		t.Error("实体类型为 nil 应报错")
+	}
+	if _, err := repo.FindByIds([]interface{}{1, nil}, &TestUser{}); err == nil {
+		t.Error("主键为 nil 应报错")
+	}
+	if _, err := repo.FindByIdsAsMap([]interface{}{map[string]interface{}{"id": 1}}, &TestUser{}); err == nil {
+		t.Error("map 变体传入复合主键应报错")
+	}
+}
+
+// 测试批量主键查询保持输入顺序
+func TestFindByIds_PreservesOrder(t *testing.T) {
+	db := CreateTestDb(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	if err := SetupTestTables(db); err != nil {
+		t.Fatalf("设置测试表失败: %v", err)
+	}
+	defer CleanupTestTables(db)
+
+	db233.GetCrudManagerInstance().AutoInitEntity(&TestUser{})
+	repo := db233.NewBaseCrudRepository(db)
+
+	users := []*TestUser{
+		{Username: "find_a", Email: "a@example.com", Age: 1},
+		{Username: "find_b", Email: "b@example.com", Age: 2},
+		{Username: "find_c", Email: "c@example.com", Age: 3},
+	}
+	for _, user := range users {
+		if err := repo.Save(user); err != nil {
+			t.Fatalf("保存用户失败: %v", err)
+		}
+	}
+
+	// 逆序 + 重复 + 不存在的主键
+	ids := []interface{}{users[2].ID, users[0].ID, -1, users[1].ID, users[2].ID}
+	entities, err := repo.FindByIds(ids, &TestUser{})
+	if err != nil {
+		t.Fatalf("批量查询失败: %v", err)
+	}
+
+	expected := []string{"find_c", "find_a", "find_b", "find_c"}
+	if len(entities) != len(expected) {
+		t.Fatalf("期望 %d 条结果, 得到 %d", len(expected), len(entities))
+	}
+	for i, entity := range entities {
+		if name := entity.(*TestUser).Username; name != expected[i] {
+			t.Errorf("第 %d 条: 期望 %s, 得到 %s", i, expected[i], name)
+		}
+	}
+
+	byId, err := repo.FindByIdsAsMap(ids, &TestUser{})
+	if err != nil {
+		t.Fatalf("批量查询（map）失败: %v", err)
+	}
+	if len(byId) != 3 || byId[users[1].ID].(*TestUser).Username != "find_b" {
+		t.Errorf("map 结果不正确: %v", byId)
+	}
+	if _, exists := byId[-1]; exists {
+		t.Error("不存在的主键不应出现在结果中")
+	}
+}
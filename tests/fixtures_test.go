@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+	"github.com/neko233-com/db233-go/pkg/db233/db233test"
+)
+
+// 外键查询返回 orders -> users、order_items -> orders
+func newFixtureDb(t *testing.T, dbType db233.EnumDatabaseType) (*db233.Db, *fakeDriver) {
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		if !strings.Contains(stmt.query, "information_schema") {
+			return nil, errors.New("未知查询: " + stmt.query)
+		}
+		return fakeRowsOf([]string{"child", "parent"},
+			[]driver.Value{"order_items", "orders"},
+			[]driver.Value{"orders", "users"},
+			[]driver.Value{"users", "users"},
+			[]driver.Value{"orders", "warehouses"},
+		), nil
+	}
+	return fake.newDb(t, dbType), fake
+}
+
+var fixtureFiles = fstest.MapFS{
+	"order_items.yml": {Data: []byte("- {id: 1, order_id: 10, qty: 2}\n")},
+	"orders.yaml":     {Data: []byte("- id: 10\n  user_id: 1\n  extra: {gift: true}\n  tags: [a, b]\n")},
+	"users.yml":       {Data: []byte("- id: 1\n  username: alice\n- id: 2\n  username: bob\n  email: null\n")},
+	"audit_log.yml":   {Data: []byte("")},
+	"README.md":       {Data: []byte("忽略")},
+}
+
+// 测试 MySQL 下按外键顺序清空并插入
+func TestLoadFixtures_MySQL(t *testing.T) {
+	db, fake := newFixtureDb(t, db233.EnumDatabaseTypeMySQL)
+
+	tables := db233test.MustLoadFixtures(t, db, fixtureFiles)
+	if !reflect.DeepEqual(tables, []string{"audit_log", "users", "orders", "order_items"}) {
+		t.Errorf("插入顺序不正确: %v", tables)
+	}
+
+	expected := []string{
+		"SET FOREIGN_KEY_CHECKS = 0",
+		"TRUNCATE TABLE order_items",
+		"TRUNCATE TABLE orders",
+		"TRUNCATE TABLE users",
+		"TRUNCATE TABLE audit_log",
+		"INSERT INTO users (id, username) VALUES (?, ?)",
+		"INSERT INTO users (email, id, username) VALUES (?, ?, ?)",
+		"INSERT INTO orders (extra, id, tags, user_id) VALUES (?, ?, ?, ?)",
+		"INSERT INTO order_items (id, order_id, qty) VALUES (?, ?, ?)",
+		"SET FOREIGN_KEY_CHECKS = 1",
+	}
+	if !reflect.DeepEqual(fake.execSqls(), expected) {
+		t.Errorf("执行语句不正确:\n%s", strings.Join(fake.execSqls(), "\n"))
+	}
+	if !reflect.DeepEqual(fake.execArgs()[7], []driver.Value{`{"gift":true}`, int64(10), `["a","b"]`, int64(1)}) {
+		t.Errorf("嵌套值应按 JSON 写入: %v", fake.execArgs()[7])
+	}
+	if fake.execArgs()[6][0] != nil {
+		t.Errorf("null 应写入 NULL: %v", fake.execArgs()[6])
+	}
+}
+
+// 测试 PostgreSQL 下一次清空全部夹具表
+func TestLoadFixtures_PostgreSQL(t *testing.T) {
+	db, fake := newFixtureDb(t, db233.EnumDatabaseTypePostgreSQL)
+
+	if _, err := db233test.LoadFixtures(db, fixtureFiles); err != nil {
+		t.Fatalf("加载夹具失败: %v", err)
+	}
+	if fake.execSqls()[0] != "TRUNCATE TABLE audit_log, users, orders, order_items RESTART IDENTITY" {
+		t.Errorf("清空语句不正确: %s", fake.execSqls()[0])
+	}
+
+	if _, err := db233test.LoadFixtures(db, fstest.MapFS{"users.yml": {Data: []byte("id: 1")}}); err == nil {
+		t.Error("格式错误的夹具文件应返回错误")
+	}
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 带外键的实体
+type FkMember struct {
+	Id      int64 `db:"id,primary_key,auto_increment"`
+	UserId  int64 `db:"user_id" fk:"fk_users(id),onDelete=CASCADE"`
+	TeamId  int64 `db:"team_id" fk:"fk_teams(id),onDelete=set_null,onUpdate=CASCADE,name=fk_member_team"`
+	GuildId int64 `db:"guild_id" fk:"fk_guilds(id)"`
+}
+
+func (e *FkMember) TableName() string { return "fk_member" }
+
+func (e *FkMember) SerializeBeforeSaveDb() {}
+
+func (e *FkMember) DeserializeAfterLoadDb() {}
+
+// 测试 fk 标签解析
+func TestParseForeignKeyTag(t *testing.T) {
+	fk, err := db233.ParseForeignKeyTag("orders", "user_id", "users(id), onDelete=set null, onUpdate=Restrict")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	expected := db233.ForeignKeyDefinition{Name: "fk_orders_user_id", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "SET NULL", OnUpdate: "RESTRICT"}
+	if fk != expected {
+		t.Errorf("解析结果不正确: %+v", fk)
+	}
+	for _, tag := range []string{"users", "users(id),onDelete=EXPLODE", "users(id),cascade", "users(id),unknown=1"} {
+		if _, err := db233.ParseForeignKeyTag("orders", "user_id", tag); err == nil {
+			t.Errorf("%q 应解析失败", tag)
+		}
+	}
+}
+
+// 测试建表时按方言生成外键约束
+func TestForeignKey_CreateTableSQL(t *testing.T) {
+	cm := db233.GetCrudManagerInstance()
+	entityType := reflect.TypeOf(FkMember{})
+
+	mysqlSql, err := db233.NewMySQLStrategy(cm).GenerateCreateTableSQL("fk_member", entityType, "")
+	if err != nil {
+		t.Fatalf("生成建表 SQL 失败: %v", err)
+	}
+	for _, expected := range []string{
+		"CONSTRAINT `fk_fk_member_guild_id` FOREIGN KEY (`guild_id`) REFERENCES `fk_guilds` (`id`) ON DELETE NO ACTION ON UPDATE NO ACTION",
+		"CONSTRAINT `fk_fk_member_user_id` FOREIGN KEY (`user_id`) REFERENCES `fk_users` (`id`) ON DELETE CASCADE ON UPDATE NO ACTION",
+		"CONSTRAINT `fk_member_team` FOREIGN KEY (`team_id`) REFERENCES `fk_teams` (`id`) ON DELETE SET NULL ON UPDATE CASCADE",
+	} {
+		if !strings.Contains(mysqlSql, expected) {
+			t.Errorf("MySQL 建表 SQL 缺少 %q:\n%s", expected, mysqlSql)
+		}
+	}
+
+	sqlServerSql, _ := db233.NewSQLServerStrategy(cm).GenerateCreateTableSQL("fk_member", entityType, "")
+	if !strings.Contains(sqlServerSql, "CONSTRAINT [fk_member_team] FOREIGN KEY ([team_id]) REFERENCES [fk_teams] ([id]) ON DELETE SET NULL ON UPDATE CASCADE") {
+		t.Errorf("SQL Server 建表 SQL 缺少外键:\n%s", sqlServerSql)
+	}
+}
+
+// 测试外键差异比对
+func TestDiffForeignKeys(t *testing.T) {
+	desired := db233.GetCrudManagerInstance().GetForeignKeys(reflect.TypeOf(FkMember{}), "fk_member")
+	existing := map[string]db233.ForeignKeyDefinition{
+		"FK_FK_MEMBER_USER_ID": {Name: "FK_FK_MEMBER_USER_ID", Column: "user_id", RefTable: "fk_users", RefColumn: "id", OnDelete: "CASCADE", OnUpdate: "RESTRICT"},
+		"fk_member_team":       {Name: "fk_member_team", Column: "team_id", RefTable: "fk_teams", RefColumn: "id", OnDelete: "CASCADE", OnUpdate: "CASCADE"},
+		"fk_legacy":            {Name: "fk_legacy", Column: "old_id", RefTable: "old", RefColumn: "id"},
+	}
+
+	missing, changed, undeclared := db233.DiffForeignKeys(existing, desired)
+	if len(missing) != 1 || missing[0].Name != "fk_fk_member_guild_id" {
+		t.Errorf("缺失的外键不正确: %+v", missing)
+	}
+	if len(changed) != 1 || changed[0].Name != "fk_member_team" {
+		t.Errorf("变更的外键不正确: %+v", changed)
+	}
+	if !reflect.DeepEqual(undeclared, []string{"fk_legacy"}) {
+		t.Errorf("未声明的外键不正确: %v", undeclared)
+	}
+}
+
+// 模拟已有 fk_member 表的驱动：外键查询返回一个变更的外键与一个未声明的外键
+func newFkDb(t *testing.T) (*db233.Db, *fakeDriver) {
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		switch {
+		case strings.Contains(stmt.query, "REFERENTIAL_CONSTRAINTS"):
+			return fakeRowsOf([]string{"n", "c", "rt", "rc", "d", "u"},
+				[]driver.Value{"fk_fk_member_user_id", "user_id", "fk_users", "id", "CASCADE", "NO ACTION"},
+				[]driver.Value{"fk_member_team", "team_id", "fk_teams", "id", "CASCADE", "CASCADE"},
+				[]driver.Value{"fk_legacy", "guild_id", "old_guilds", "id", "NO ACTION", "NO ACTION"},
+			), nil
+		case strings.Contains(stmt.query, "information_schema.tables"):
+			return fakeRowsOf([]string{"count"}, []driver.Value{int64(1)}), nil
+		case strings.Contains(stmt.query, "COLUMN_TYPE"):
+			values := make([][]driver.Value, 0, 4)
+			for _, column := range []string{"id", "user_id", "team_id", "guild_id"} {
+				values = append(values, []driver.Value{column, "bigint", "YES", "", nil})
+			}
+			return fakeRowsOf([]string{"name", "type", "nullable", "key", "default"}, values...), nil
+		}
+		return nil, errors.New("未知查询: " + stmt.query)
+	}
+	return fake.newDb(t, db233.EnumDatabaseTypeMySQL), fake
+}
+
+// 测试迁移时按权限处理外键差异
+func TestAutoMigrateTable_ForeignKeys(t *testing.T) {
+	db, fake := newFkDb(t)
+	cm := db233.GetCrudManagerInstance()
+	cm.AutoInitEntity(&FkMember{})
+
+	migrate := func(permissions *db233.AutoDbPermission) []string {
+		fake.reset()
+		if err := cm.AutoMigrateTable(db, &FkMember{}, permissions); err != nil {
+			t.Fatalf("迁移失败: %v", err)
+		}
+		return fake.execSqls()
+	}
+
+	safe := migrate(db233.NewSafeAutoDbPermission())
+	if !reflect.DeepEqual(safe, []string{
+		"ALTER TABLE `fk_member` ADD CONSTRAINT `fk_fk_member_guild_id` FOREIGN KEY (`guild_id`) REFERENCES `fk_guilds` (`id`) ON DELETE NO ACTION ON UPDATE NO ACTION",
+	}) {
+		t.Errorf("安全权限下只应添加缺失的外键:\n%s", strings.Join(safe, "\n"))
+	}
+
+	all := migrate(db233.NewDefaultAutoDbPermission())
+	expected := []string{
+		"ALTER TABLE `fk_member` DROP FOREIGN KEY `fk_legacy`",
+		"ALTER TABLE `fk_member` DROP FOREIGN KEY `fk_member_team`",
+		"ALTER TABLE `fk_member` ADD CONSTRAINT `fk_member_team` FOREIGN KEY (`team_id`) REFERENCES `fk_teams` (`id`) ON DELETE SET NULL ON UPDATE CASCADE",
+		"ALTER TABLE `fk_member` ADD CONSTRAINT `fk_fk_member_guild_id` FOREIGN KEY (`guild_id`) REFERENCES `fk_guilds` (`id`) ON DELETE NO ACTION ON UPDATE NO ACTION",
+	}
+	if !reflect.DeepEqual(all, expected) {
+		t.Errorf("默认权限下外键迁移不正确:\n%s", strings.Join(all, "\n"))
+	}
+}
@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 记录执行语句、在内存中维护 schema_migrations 的驱动
+type goMigrationStore struct {
+	mu      sync.Mutex
+	execs   []string
+	applied map[int64]string
+}
+
+func newGoMigrationDb(t *testing.T) (*db233.Db, *goMigrationStore) {
+	store := &goMigrationStore{applied: make(map[int64]string)}
+	fake := newFakeDriver()
+	fake.onExec = func(stmt fakeStatement) (driver.Result, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		switch {
+		case strings.HasPrefix(stmt.query, "INSERT INTO schema_migrations"):
+			store.applied[stmt.args[0].(int64)] = stmt.args[1].(string)
+		case strings.HasPrefix(stmt.query, "DELETE FROM schema_migrations"):
+			delete(store.applied, stmt.args[0].(int64))
+		default:
+			store.execs = append(store.execs, stmt.query)
+		}
+		return driver.RowsAffected(1), nil
+	}
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		var columns []string
+		var values [][]driver.Value
+		switch {
+		case strings.HasPrefix(stmt.query, "SELECT version, name, applied_at"):
+			columns = []string{"version", "name", "applied_at"}
+			for version, name := range store.applied {
+				values = append(values, []driver.Value{version, name, time.Now()})
+			}
+		case strings.HasPrefix(stmt.query, "SELECT version FROM"):
+			columns = []string{"version"}
+			for version := range store.applied {
+				values = append(values, []driver.Value{version})
+			}
+		default:
+			return nil, errors.New("未知查询: " + stmt.query)
+		}
+		// 按版本排序（与 ORDER BY version 一致）
+		sort.Slice(values, func(i, j int) bool { return values[i][0].(int64) < values[j][0].(int64) })
+		return fakeRowsOf(columns, values...), nil
+	}
+	return fake.newDb(t, db233.EnumDatabaseTypeMySQL), store
+}
+
+func (s *goMigrationStore) takeExecs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	execs := s.execs
+	s.execs = nil
+	return execs
+}
+
+// 测试 Go 迁移与 SQL 文件按版本交错执行、可回滚，钩子按顺序调用
+func TestGoMigration_InterleavedWithSqlFiles(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "1_create_player.up.sql"), []byte("CREATE TABLE player"), 0644)
+	os.WriteFile(filepath.Join(dir, "1_create_player.down.sql"), []byte("DROP TABLE player"), 0644)
+	os.WriteFile(filepath.Join(dir, "3_add_index.up.sql"), []byte("CREATE INDEX idx_player"), 0644)
+	os.WriteFile(filepath.Join(dir, "3_add_index.down.sql"), []byte("DROP INDEX idx_player"), 0644)
+
+	db, store := newGoMigrationDb(t)
+	mm := db233.NewMigrationManager(db, dir).SetLockMode(db233.EnumMigrationLockModeNone)
+
+	err := mm.RegisterGoMigration(2, "backfill_profile",
+		func(tm *db233.TransactionManager) error {
+			_, err := tm.Exec("UPDATE player SET profile = ?", `{"v":2}`)
+			return err
+		},
+		func(tm *db233.TransactionManager) error {
+			_, err := tm.Exec("UPDATE player SET profile = NULL")
+			return err
+		})
+	if err != nil {
+		t.Fatalf("注册 Go 迁移失败: %v", err)
+	}
+	if err := mm.RegisterGoMigration(2, "duplicate", func(*db233.TransactionManager) error { return nil }, nil); err == nil {
+		t.Error("重复版本应注册失败")
+	}
+
+	var hookLog []string
+	mm.AddBeforeMigrationHook(func(m db233.Migration, isUp bool) error {
+		hookLog = append(hookLog, "before:"+m.Name)
+		return nil
+	}).AddAfterMigrationHook(func(m db233.Migration, isUp bool, err error) {
+		hookLog = append(hookLog, "after:"+m.Name)
+	})
+
+	if err := mm.Up(0); err != nil {
+		t.Fatalf("上迁失败: %v", err)
+	}
+	if execs := store.takeExecs(); !reflect.DeepEqual(execs, []string{"CREATE TABLE player", "UPDATE player SET profile = ?", "CREATE INDEX idx_player"}) {
+		t.Errorf("上迁执行顺序不正确: %v", execs)
+	}
+	if !reflect.DeepEqual(hookLog, []string{
+		"before:create_player", "after:create_player",
+		"before:backfill_profile", "after:backfill_profile",
+		"before:add_index", "after:add_index",
+	}) {
+		t.Errorf("钩子调用顺序不正确: %v", hookLog)
+	}
+
+	if err := mm.Down(2); err != nil {
+		t.Fatalf("回滚失败: %v", err)
+	}
+	if execs := store.takeExecs(); !reflect.DeepEqual(execs, []string{"DROP INDEX idx_player", "UPDATE player SET profile = NULL"}) {
+		t.Errorf("回滚应按版本倒序执行: %v", execs)
+	}
+	if _, stillApplied := store.applied[1]; !stillApplied || len(store.applied) != 1 {
+		t.Errorf("只应保留版本 1: %v", store.applied)
+	}
+}
+
+// 测试前置钩子否决迁移
+func TestGoMigration_BeforeHookVeto(t *testing.T) {
+	db, store := newGoMigrationDb(t)
+	mm := db233.NewMigrationManager(db, filepath.Join(t.TempDir(), "missing")).
+		SetLockMode(db233.EnumMigrationLockModeNone)
+
+	ran := false
+	mm.RegisterGoMigration(10, "guarded", func(*db233.TransactionManager) error {
+		ran = true
+		return nil
+	}, nil)
+	var afterErr error
+	mm.AddBeforeMigrationHook(func(m db233.Migration, isUp bool) error {
+		return errors.New("维护窗口外禁止迁移")
+	}).AddAfterMigrationHook(func(m db233.Migration, isUp bool, err error) {
+		afterErr = err
+	})
+
+	if err := mm.Up(0); err == nil || !strings.Contains(err.Error(), "维护窗口外禁止迁移") {
+		t.Errorf("前置钩子的错误应返回: %v", err)
+	}
+	if ran || len(store.applied) != 0 || afterErr != nil {
+		t.Errorf("被否决的迁移不应执行: ran=%v, applied=%v, afterErr=%v", ran, store.applied, afterErr)
+	}
+}
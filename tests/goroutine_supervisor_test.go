@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// panic 的数据源，用于验证收集器协程被重启
+type panickingDataSource struct {
+	calls int64
+}
+
+func (s *panickingDataSource) GetName() string { return "panicking" }
+
+func (s *panickingDataSource) GetMetrics() map[string]interface{} {
+	atomic.AddInt64(&s.calls, 1)
+	panic("数据源故障")
+}
+
+// 测试 panic 后按退避重启，正常返回后停止监管
+func TestGoroutineSupervisor_RestartAfterPanic(t *testing.T) {
+	supervisor := db233.NewGoroutineSupervisor().SetBackoff(5*time.Millisecond, 20*time.Millisecond)
+	var hookCalls int64
+	supervisor.OnCrash(func(component string, recovered interface{}, stack []byte) {
+		if component != "worker" || len(stack) == 0 {
+			t.Errorf("崩溃回调参数不正确: %s, %v", component, recovered)
+		}
+		atomic.AddInt64(&hookCalls, 1)
+	})
+
+	var runs int64
+	done := supervisor.Go("worker", nil, func() {
+		if atomic.AddInt64(&runs, 1) <= 2 {
+			panic("故障")
+		}
+	})
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("正常返回后监管应结束")
+	}
+	if runs != 3 || supervisor.GetCrashCount("worker") != 2 || atomic.LoadInt64(&hookCalls) != 2 {
+		t.Errorf("运行 %d 次, 崩溃 %d 次, 回调 %d 次", runs, supervisor.GetCrashCount("worker"), hookCalls)
+	}
+
+	stats := supervisor.GetStats()
+	if len(stats) != 1 || stats[0].Restarts != 2 || stats[0].Running != 0 || stats[0].LastPanic != "故障" {
+		t.Errorf("监管统计不正确: %+v", stats)
+	}
+	metrics := supervisor.GetMetrics()
+	if metrics["worker.crashes"] != int64(2) || metrics["total_crashes"] != int64(2) {
+		t.Errorf("崩溃指标不正确: %v", metrics)
+	}
+}
+
+// 测试退避期间停止后不再重启
+func TestGoroutineSupervisor_StopDuringBackoff(t *testing.T) {
+	supervisor := db233.NewGoroutineSupervisor().SetBackoff(time.Hour, time.Hour)
+	stop := make(chan bool)
+	var runs int64
+	done := supervisor.Go("always_panics", stop, func() {
+		atomic.AddInt64(&runs, 1)
+		panic("故障")
+	})
+
+	time.Sleep(20 * time.Millisecond)
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("停止后监管应结束")
+	}
+	if runs != 1 {
+		t.Errorf("退避期间停止后不应重启: 运行 %d 次", runs)
+	}
+}
+
+// 测试收集器的数据源 panic 后收集协程被重启
+func TestGoroutineSupervisor_MetricsCollector(t *testing.T) {
+	collector := db233.NewMetricsCollector("supervised")
+	collector.SetCollectionInterval(5 * time.Millisecond)
+	source := &panickingDataSource{}
+	collector.AddDataSource(source)
+	collector.Start()
+	defer collector.Stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt64(&source.calls) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if atomic.LoadInt64(&source.calls) < 2 {
+		t.Fatal("收集协程 panic 后应被重启")
+	}
+	crashes := db233.GetGoroutineSupervisorInstance().GetCrashCount("metrics_collector:supervised")
+	if crashes < 1 {
+		t.Errorf("应记录收集器的崩溃次数: %d", crashes)
+	}
+}
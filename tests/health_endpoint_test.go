@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+func getHealthReport(t *testing.T, handler http.Handler, path string) (int, *db233.HealthProbeReport) {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+	report := &db233.HealthProbeReport{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), report); err != nil {
+		t.Fatalf("响应不是 JSON: %v, %s", err, recorder.Body.String())
+	}
+	return recorder.Code, report
+}
+
+func newUnreachableDb(t *testing.T) *db233.Db {
+	dataSource, err := sql.Open("mysql", "root:root@tcp(127.0.0.1:1)/health")
+	if err != nil {
+		t.Fatalf("打开数据源失败: %v", err)
+	}
+	t.Cleanup(func() { dataSource.Close() })
+	return db233.NewDb(dataSource, 1, nil)
+}
+
+// 测试数据库不可达时存活仍为 200，就绪为 503
+func TestHealthEndpoint_LivenessIndependentOfDatabase(t *testing.T) {
+	endpoint := db233.NewHealthEndpoint(db233.HealthEndpointOptions{})
+	checker := db233.NewHealthChecker(newUnreachableDb(t))
+	checker.SetTimeout(time.Second)
+	endpoint.AddChecker("main", checker)
+	handler := endpoint.Handler()
+
+	code, report := getHealthReport(t, handler, "/livez")
+	if code != http.StatusOK || report.Status != db233.HealthProbeUp || report.Probe != "liveness" {
+		t.Errorf("存活检查应为 200 UP，得到 %d %+v", code, report)
+	}
+
+	code, report = getHealthReport(t, handler, "/readyz")
+	if code != http.StatusServiceUnavailable || report.Status != db233.HealthProbeDown {
+		t.Fatalf("就绪检查应为 503 DOWN，得到 %d %+v", code, report)
+	}
+	if check := report.Checks["main.connection"]; check == nil || check.Status != db233.HealthStatusUnhealthy {
+		t.Errorf("期望 main.connection 不健康，得到 %+v", report.Checks)
+	}
+}
+
+// 测试维护中的数据库视为未就绪
+func TestHealthEndpoint_MaintenanceNotReady(t *testing.T) {
+	db := newUnreachableDb(t)
+	db.EnterMaintenance("升级")
+	defer db.ExitMaintenance()
+
+	endpoint := db233.NewHealthEndpoint(db233.HealthEndpointOptions{})
+	endpoint.AddChecker("main", db233.NewHealthChecker(db))
+
+	code, report := getHealthReport(t, endpoint.Handler(), "/readyz")
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("维护中应返回 503，得到 %d", code)
+	}
+	if check := report.Checks["main.connection"]; check == nil || check.Status != db233.HealthStatusMaintenance {
+		t.Errorf("期望 maintenance 状态，得到 %+v", report.Checks)
+	}
+	if failed := report.FailedChecks(); len(failed) != 1 || failed[0] != "main.connection" {
+		t.Errorf("未通过的检查项不正确: %v", failed)
+	}
+}
+
+// 测试就绪检查的连接池饱和与复制延迟（需要 MySQL）
+func TestHealthEndpoint_ReadinessWithDatabase(t *testing.T) {
+	db := CreateTestDb(t)
+
+	endpoint := db233.NewHealthEndpoint(db233.HealthEndpointOptions{MaxReplicationLag: 5 * time.Second})
+	endpoint.AddChecker("main", db233.NewHealthChecker(db))
+	lag := time.Second
+	endpoint.SetReplicationLagProbe("main", func(ctx context.Context) (time.Duration, error) {
+		return lag, nil
+	})
+	handler := endpoint.ReadinessHandler()
+
+	code, report := getHealthReport(t, handler, "/readyz")
+	if code != http.StatusOK {
+		t.Fatalf("期望就绪，得到 %d %+v", code, report.Checks)
+	}
+	if report.Checks["main.pool"] == nil || report.Checks["main.replication"] == nil {
+		t.Errorf("缺少连接池或复制检查: %+v", report.Checks)
+	}
+
+	lag = 10 * time.Second
+	if code, _ := getHealthReport(t, handler, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("复制延迟超过阈值应返回 503，得到 %d", code)
+	}
+
+	endpoint.SetReplicationLagProbe("main", func(ctx context.Context) (time.Duration, error) {
+		return 0, errors.New("复制已停止")
+	})
+	if code, _ := getHealthReport(t, handler, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("复制探测失败应返回 503，得到 %d", code)
+	}
+}
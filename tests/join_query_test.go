@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 按配置返回 JOIN 结果的驱动
+func newJoinQueryDb(t *testing.T, columns []string, rows [][]driver.Value) (*db233.Db, *fakeDriver) {
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		return fakeRowsOf(columns, rows...), nil
+	}
+	return fake.newDb(t, db233.EnumDatabaseTypeMySQL), fake
+}
+
+type joinOrderView struct {
+	TxOrder `db:"o"`
+	Stock   *TxStock `db:"s"`
+	Note    string   `db:"o.note"`
+	Total   int64    `db:"total"`
+	Ignored string
+}
+
+// 测试按 DTO 生成带别名的 SELECT 与 JOIN
+func TestJoinQuery_Build(t *testing.T) {
+	db, _ := newJoinQueryDb(t, nil, nil)
+	query, params, err := db233.NewJoinQuery(db).
+		From(&TxOrder{}, "o").
+		LeftJoin(&TxStock{}, "s", "s.id = o.id").
+		Join("tx_user", "u", "u.id = o.id").
+		SelectExpr("o.amount * 2", "total").
+		Where("o.amount > ?", 10).
+		Where("u.name = ?", "neko").
+		OrderBy("o.id DESC").
+		Limit(20).
+		Offset(40).
+		Build(&joinOrderView{})
+	if err != nil {
+		t.Fatalf("构建失败: %v", err)
+	}
+	expected := "SELECT o.amount * 2 AS total, o.id AS o__id, o.amount AS o__amount, s.id AS s__id, s.count AS s__count, o.note AS o__note " +
+		"FROM tx_order o LEFT JOIN tx_stock s ON s.id = o.id INNER JOIN tx_user u ON u.id = o.id " +
+		"WHERE o.amount > ? AND u.name = ? ORDER BY o.id DESC LIMIT 20 OFFSET 40"
+	if query != expected {
+		t.Errorf("SQL 不正确:\n%s", query)
+	}
+	if len(params) != 2 || params[0] != 10 || params[1] != "neko" {
+		t.Errorf("参数不正确: %v", params)
+	}
+
+	type duplicateView struct {
+		Stock TxStock `db:"s"`
+		Count int64   `db:"s.count"`
+	}
+	if _, _, err := db233.NewJoinQuery(db).From(&TxStock{}, "s").Build(&duplicateView{}); err == nil || !strings.Contains(err.Error(), "s__count") {
+		t.Errorf("重复的列应报错: %v", err)
+	}
+	if _, _, err := db233.NewJoinQuery(db).From(&TxOrder{}, "o").Join(&TxStock{}, "s", "").Build(&joinOrderView{}); err == nil {
+		t.Error("缺少 ON 条件应报错")
+	}
+	if _, _, err := db233.NewJoinQuery(db).From(&TxOrder{}, "o; DROP").Build(&joinOrderView{}); err == nil {
+		t.Error("无效的别名应报错")
+	}
+	if _, _, err := db233.NewJoinQuery(db).Build(&joinOrderView{}); err == nil {
+		t.Error("缺少主表应报错")
+	}
+}
+
+// 测试结果映射到嵌入实体、实体指针与单列字段，LEFT JOIN 未匹配时实体指针为 nil
+func TestJoinQuery_FindMapsDto(t *testing.T) {
+	columns := []string{"total", "o__id", "o__amount", "s__id", "s__count", "o__note"}
+	rows := [][]driver.Value{
+		{int64(200), int64(1), int64(100), int64(1), int64(7), []byte("first")},
+		{int64(400), int64(2), int64(200), nil, nil, nil},
+	}
+	db, fake := newJoinQueryDb(t, columns, rows)
+	query := db233.NewJoinQuery(db).
+		From(&TxOrder{}, "o").
+		LeftJoin(&TxStock{}, "s", "s.id = o.id").
+		SelectExpr("o.amount * 2", "total")
+
+	var views []joinOrderView
+	if err := query.Find(&views); err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if len(views) != 2 {
+		t.Fatalf("行数不正确: %d", len(views))
+	}
+	first, second := views[0], views[1]
+	if first.Id != 1 || first.Amount != 100 || first.Note != "first" || first.Total != 200 {
+		t.Errorf("第一行映射不正确: %+v", first)
+	}
+	if first.Stock == nil || first.Stock.Id != 1 || first.Stock.Count != 7 {
+		t.Errorf("关联实体映射不正确: %+v", first.Stock)
+	}
+	if second.Id != 2 || second.Stock != nil || second.Note != "" {
+		t.Errorf("LEFT JOIN 未匹配时实体指针应为 nil: %+v", second)
+	}
+
+	var pointers []*joinOrderView
+	if err := query.Find(&pointers); err != nil || len(pointers) != 2 || pointers[1].Amount != 200 {
+		t.Errorf("指针切片映射不正确: %+v %v", pointers, err)
+	}
+
+	var one joinOrderView
+	found, err := query.Where("o.id = ?", 1).FindOne(&one)
+	if err != nil || !found || one.Id != 1 {
+		t.Errorf("单行查询不正确: %+v %v", one, err)
+	}
+	queries := fake.querySqls()
+	last := queries[len(queries)-1]
+	if !strings.HasSuffix(last, "WHERE o.id = ? LIMIT 1") {
+		t.Errorf("FindOne 应限制一行: %s", last)
+	}
+
+	if err := query.Find(views); err == nil {
+		t.Error("非指针结果应报错")
+	}
+}
+
+// 测试主表与 JOIN 表各自带上作用域谓词与行级安全策略谓词，参数按出现顺序排列
+func TestJoinQuery_ScopeAndSecurityPolicy(t *testing.T) {
+	registry := db233.GetSecurityPolicyRegistryInstance()
+	registry.Register("tx_stock", func(ctx context.Context) (*db233.ScopePredicate, error) {
+		ownerId, ok := ctx.Value(securityTenantKey{}).(int64)
+		if !ok {
+			return nil, errors.New("缺少归属人信息")
+		}
+		return &db233.ScopePredicate{Sql: "owner_id = ?", Params: []interface{}{ownerId}}, nil
+	})
+	defer registry.Unregister("tx_stock")
+
+	db, fake := newJoinQueryDb(t, []string{"o__id"}, [][]driver.Value{{int64(1)}})
+	ctx := context.WithValue(context.Background(), securityTenantKey{}, int64(7))
+	repo := db233.NewBaseCrudRepository(db).WithContext(ctx).WithScope(&db233.RepositoryScope{
+		Predicates: []db233.ScopePredicate{{Sql: "tenant_id = ?", Params: []interface{}{int64(3)}}},
+	})
+
+	var views []joinOrderView
+	err := repo.NewJoinQuery().
+		From(&TxOrder{}, "o").
+		LeftJoin(&TxStock{}, "s", "s.id = o.id").
+		SelectExpr("o.amount * 2", "total").
+		Where("o.amount > ?", 10).
+		Find(&views)
+	if err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	statement := fake.recorded()[0]
+	expected := "FROM (SELECT * FROM tx_order WHERE tenant_id = ?) o " +
+		"LEFT JOIN (SELECT * FROM tx_stock WHERE tenant_id = ? AND (owner_id = ?)) s ON s.id = o.id WHERE o.amount > ?"
+	if !strings.HasSuffix(statement.query, expected) {
+		t.Errorf("SQL 不正确:\n%s", statement.query)
+	}
+	if !reflect.DeepEqual(statement.args, []driver.Value{int64(3), int64(3), int64(7), int64(10)}) {
+		t.Errorf("参数不正确: %v", statement.args)
+	}
+
+	// 缺少 context 时 JOIN 表的策略拒绝查询
+	fake.reset()
+	err = db233.NewJoinQuery(db).From(&TxOrder{}, "o").LeftJoin(&TxStock{}, "s", "s.id = o.id").Find(&views)
+	var denied *db233.SecurityPolicyDeniedException
+	if !errors.As(err, &denied) || len(fake.recorded()) != 0 {
+		t.Errorf("策略拒绝时不应执行查询: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type JsonProfileItem struct {
+	Id    int64  `json:"id"`
+	Label string `json:"label"`
+}
+
+type JsonProfile struct {
+	Level int               `json:"level"`
+	Items []JsonProfileItem `json:"items"`
+}
+
+type JsonPlayer struct {
+	Id         int64             `db:"id,primary_key"`
+	Profile    JsonProfile       `db:"profile" db_type:"JSON"`
+	Attributes map[string]int    `db:"attributes" db_type:"JSON"`
+	Tags       []string          `db:"tags" db_type:"JSON"`
+	Extra      *JsonProfile      `db:"extra"`
+	Labels     map[string]string `db:"labels"`
+}
+
+// 值接收者：FindByCondition 返回值类型的实体
+func (e JsonPlayer) TableName() string { return "json_player" }
+
+func (e JsonPlayer) SerializeBeforeSaveDb() {}
+
+func (e JsonPlayer) DeserializeAfterLoadDb() {}
+
+// 测试 db_type:"JSON" 建表类型
+func TestJsonColumn_SqlType(t *testing.T) {
+	field, _ := reflect.TypeOf(JsonPlayer{}).FieldByName("Profile")
+	cm := db233.GetCrudManagerInstance()
+	if sqlType := db233.NewMySQLStrategy(cm).GetSQLType(field); sqlType != "JSON" {
+		t.Errorf("MySQL 应使用原生 JSON 列: %s", sqlType)
+	}
+	if sqlType := db233.NewSQLServerStrategy(cm).GetSQLType(field); sqlType != "NVARCHAR(MAX)" {
+		t.Errorf("SQL Server 应使用 NVARCHAR(MAX): %s", sqlType)
+	}
+}
+
+// 测试加载时 JSON 列反序列化为结构体 / map / 切片，并按方言生成 JSON 条件
+func TestJsonColumn_LoadAndQuery(t *testing.T) {
+	// 查询返回一行 JSON 文本
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		return fakeRowsOf([]string{"id", "profile", "attributes", "tags", "extra", "labels"}, []driver.Value{
+			int64(1),
+			[]byte(`{"level":3,"items":[{"id":5,"label":"sword"}]}`),
+			[]byte(`{"str":10,"agi":7}`),
+			"[\"vip\",\"pvp\"]",
+			nil,
+			[]byte(""),
+		}), nil
+	}
+	db := fake.newDb(t, db233.EnumDatabaseTypeMySQL)
+	repo := db233.NewBaseCrudRepository(db)
+
+	condition, params, err := db233.NewConditionBuilder(db).
+		Where("id > ?", 0).
+		WhereJSONPath("profile", "$.items[0].id", 5).
+		WhereJSONContains("tags", []string{"vip"}).
+		Build()
+	if err != nil {
+		t.Fatalf("构建条件失败: %v", err)
+	}
+	results, err := repo.FindByCondition(condition, params, JsonPlayer{})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("查询失败: %v %v", results, err)
+	}
+
+	stmt := fake.recorded()[0]
+	query := stmt.query
+	if !strings.Contains(query, "(id > ?) AND (JSON_EXTRACT(profile, '$.items[0].id') = CAST(? AS JSON)) AND (JSON_CONTAINS(tags, ?))") {
+		t.Errorf("JSON 条件不正确: %s", query)
+	}
+	if !reflect.DeepEqual(stmt.args, []driver.Value{int64(0), "5", `["vip"]`}) {
+		t.Errorf("JSON 条件参数不正确: %#v", stmt.args)
+	}
+
+	player := results[0].(JsonPlayer)
+	if player.Profile.Level != 3 || len(player.Profile.Items) != 1 || player.Profile.Items[0].Label != "sword" {
+		t.Errorf("结构体 JSON 列未反序列化: %+v", player.Profile)
+	}
+	if player.Attributes["str"] != 10 || !reflect.DeepEqual(player.Tags, []string{"vip", "pvp"}) {
+		t.Errorf("map / 切片 JSON 列未反序列化: %v %v", player.Attributes, player.Tags)
+	}
+	if player.Extra != nil || player.Labels != nil {
+		t.Errorf("NULL / 空文本应为零值: %v %v", player.Extra, player.Labels)
+	}
+}
+
+// 测试各方言的 JSON 条件与非法输入
+func TestJsonColumn_DialectConditions(t *testing.T) {
+	build := func(dbType db233.EnumDatabaseType, fn func(b *db233.ConditionBuilder)) (string, []interface{}, error) {
+		b := db233.NewConditionBuilder(db233.NewDbWithType(nil, 1, nil, dbType))
+		fn(b)
+		return b.Build()
+	}
+
+	condition, params, err := build(db233.EnumDatabaseTypePostgreSQL, func(b *db233.ConditionBuilder) {
+		b.WhereJSONPath("profile", "$.items[0].id", 5).WhereJSONContains("tags", []string{"vip"})
+	})
+	if err != nil || condition != "((profile #>> '{items,0,id}') = ?) AND (tags::jsonb @> ?::jsonb)" || !reflect.DeepEqual(params, []interface{}{"5", `["vip"]`}) {
+		t.Errorf("PostgreSQL 条件不正确: %s %v %v", condition, params, err)
+	}
+
+	condition, params, err = build(db233.EnumDatabaseTypeSQLServer, func(b *db233.ConditionBuilder) {
+		b.WhereJSONPath("profile", "$.level", true).WhereJSONContains("attributes", map[string]int{"str": 10, "agi": 7})
+	})
+	if err != nil || condition != "(JSON_VALUE(profile, '$.level') = ?) AND ((JSON_VALUE(attributes, '$.agi') = ? AND JSON_VALUE(attributes, '$.str') = ?))" ||
+		!reflect.DeepEqual(params, []interface{}{"true", "7", "10"}) {
+		t.Errorf("SQL Server 条件不正确: %s %v %v", condition, params, err)
+	}
+
+	for _, path := range []string{"items[0]", "$.items') OR 1=1 --", "$"} {
+		if _, _, err := build(db233.EnumDatabaseTypeMySQL, func(b *db233.ConditionBuilder) { b.WhereJSONPath("profile", path, 1) }); err == nil {
+			t.Errorf("非法路径应返回错误: %s", path)
+		}
+	}
+	if _, _, err := build(db233.EnumDatabaseTypeMySQL, func(b *db233.ConditionBuilder) { b.WhereJSONContains("tags; DROP TABLE x", "vip") }); err == nil {
+		t.Error("非法列名应返回错误")
+	}
+}
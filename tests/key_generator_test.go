@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// GeneratedKeyEntity 使用生成主键的测试实体
+type GeneratedKeyEntity struct {
+	ID      string `db:"id,primary_key,gen=uuidv7"`
+	TraceId string `db:"trace_id,gen=ulid"`
+	Name    string `db:"name"`
+}
+
+func (e *GeneratedKeyEntity) TableName() string {
+	return "generated_key_entity"
+}
+
+func (e *GeneratedKeyEntity) SerializeBeforeSaveDb() {}
+
+func (e *GeneratedKeyEntity) DeserializeAfterLoadDb() {}
+
+var (
+	uuidV4Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	uuidV7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ulidPattern   = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+)
+
+// 测试内置生成器格式
+func TestKeyGenerator_Builtins(t *testing.T) {
+	v4, _ := db233.NewUUIDv4()
+	if !uuidV4Pattern.MatchString(v4) {
+		t.Errorf("UUIDv4 格式错误: %s", v4)
+	}
+
+	v7, _ := db233.NewUUIDv7()
+	if !uuidV7Pattern.MatchString(v7) {
+		t.Errorf("UUIDv7 格式错误: %s", v7)
+	}
+
+	ulid, _ := db233.NewULID()
+	if !ulidPattern.MatchString(ulid) {
+		t.Errorf("ULID 格式错误: %s", ulid)
+	}
+}
+
+// 测试按 gen 选项填充主键（已有值不覆盖）
+func TestKeyGenerator_PopulateKeys(t *testing.T) {
+	registry := db233.GetKeyGeneratorRegistryInstance()
+
+	entity := &GeneratedKeyEntity{TraceId: "fixed"}
+	if err := registry.PopulateKeys(entity); err != nil {
+		t.Fatalf("生成主键失败: %v", err)
+	}
+	if !uuidV7Pattern.MatchString(entity.ID) {
+		t.Errorf("ID 应生成 UUIDv7, 得到 %q", entity.ID)
+	}
+	if entity.TraceId != "fixed" {
+		t.Errorf("已有值不应被覆盖, 得到 %q", entity.TraceId)
+	}
+
+	registry.Register("ulid", db233.KeyGeneratorFunc(func() (string, error) { return "custom", nil }))
+	defer registry.Register("ulid", db233.KeyGeneratorFunc(db233.NewULID))
+	entity.TraceId = ""
+	if err := registry.PopulateKeys(entity); err != nil || entity.TraceId != "custom" {
+		t.Errorf("应使用自定义生成器: %q, %v", entity.TraceId, err)
+	}
+
+	type unknownGen struct {
+		ID string `db:"id,primary_key,gen=snowflake"`
+	}
+	if err := registry.PopulateKeys(&unknownGen{}); err == nil {
+		t.Error("未注册的生成器应返回错误")
+	}
+}
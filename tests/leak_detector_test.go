@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试长时间未提交的事务被标记并告警，提交后告警解决
+func TestLeakDetector_Transaction(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 3)
+	manager := db233.NewAlertManager("leak_test")
+	detector := db233.NewLeakDetector("main").SetDebug(true).SetTransactionThreshold(time.Minute).SetAlertManager(manager)
+	db.SetLeakDetector(detector)
+	if db.WithPriority(db233.QueryPriorityBatch).GetLeakDetector() != detector {
+		t.Fatal("视图应共享泄漏检测器")
+	}
+
+	tm := db233.NewTransactionManager(db)
+	if err := tm.Begin(); err != nil {
+		t.Fatalf("开始事务失败: %v", err)
+	}
+	if leaks := detector.Check(time.Now()); len(leaks) != 0 {
+		t.Fatalf("未超过阈值不应报告: %+v", leaks)
+	}
+	leaks := detector.Check(time.Now().Add(2 * time.Minute))
+	if len(leaks) != 1 || leaks[0].Kind != db233.LeakKindTransaction || leaks[0].DbId != 3 || leaks[0].Duration < 2*time.Minute {
+		t.Fatalf("应报告事务泄漏: %+v", leaks)
+	}
+	if !strings.Contains(leaks[0].Stack, "TestLeakDetector_Transaction") || strings.Contains(leaks[0].Stack, "LeakDetector).track") {
+		t.Errorf("调试模式应记录业务调用栈: %s", leaks[0].Stack)
+	}
+	if len(detector.Check(time.Now().Add(3*time.Minute))) != 0 {
+		t.Error("同一事务只应报告一次")
+	}
+	if !activeAlertRuleIds(manager)["leak_transaction"] || len(detector.GetActiveLeaks()) != 1 {
+		t.Fatalf("应触发事务泄漏告警: %v", activeAlertRuleIds(manager))
+	}
+
+	if err := tm.Commit(); err != nil {
+		t.Fatalf("提交失败: %v", err)
+	}
+	if activeAlertRuleIds(manager)["leak_transaction"] || len(detector.GetActiveLeaks()) != 0 {
+		t.Error("泄漏的事务提交后告警应解决")
+	}
+	status := detector.GetStatus()
+	if status["open_transactions"] != 0 || status["total_leaked_transactions"] != int64(1) {
+		t.Errorf("状态不正确: %v", status)
+	}
+}
+
+// 测试检出时间过长的连接，非调试模式不记录调用栈
+func TestLeakDetector_Connection(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	detector := db233.NewLeakDetector("main").SetConnectionThreshold(time.Second)
+	db.SetLeakDetector(detector)
+
+	var leaks []db233.LeakReport
+	err := db.ExecuteWithConnection(func(conn *sql.Conn) error {
+		if status := detector.GetStatus(); status["open_connections"] != 1 {
+			t.Errorf("应跟踪检出的连接: %v", status)
+		}
+		leaks = detector.Check(time.Now().Add(time.Minute))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("执行失败: %v", err)
+	}
+	if len(leaks) != 1 || leaks[0].Kind != db233.LeakKindConnection || leaks[0].Stack != "" {
+		t.Fatalf("应报告连接泄漏且不记录调用栈: %+v", leaks)
+	}
+	if status := detector.GetStatus(); status["open_connections"] != 0 || status["total_leaked_connections"] != int64(1) {
+		t.Errorf("归还后不应继续跟踪: %v", status)
+	}
+
+	db.ExecuteUpdateWithTimeout("UPDATE tx_stock SET count = 1", nil, time.Second)
+	if status := detector.GetStatus(); status["open_connections"] != 0 {
+		t.Errorf("语句执行完应归还连接: %v", status)
+	}
+}
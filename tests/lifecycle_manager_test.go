@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type LifecycleTask struct {
+	Id int64 `db:"id,primary_key"`
+}
+
+func (e *LifecycleTask) TableName() string { return "lifecycle_task" }
+
+func (e *LifecycleTask) SerializeBeforeSaveDb() {}
+
+func (e *LifecycleTask) DeserializeAfterLoadDb() {}
+
+type failingStopper struct{}
+
+func (failingStopper) Stop() error { return errors.New("端口已被占用") }
+
+// 测试组件逆序停止、失败与超时报告
+func TestLifecycleManager_Components(t *testing.T) {
+	lifecycle := db233.NewLifecycleManager("app")
+	var orderMu sync.Mutex
+	order := make([]string, 0)
+	record := func(name string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			orderMu.Lock()
+			defer orderMu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	collector := db233.NewMetricsCollector("lifecycle")
+	collector.Start()
+	alertManager := db233.NewAlertManager("lifecycle")
+	scheduler := db233.NewHealthCheckScheduler(time.Hour) // 未启动也能停止
+
+	lifecycle.Register("stuck", func(ctx context.Context) error { select {} })
+	lifecycle.Register("first", record("first"))
+	lifecycle.Register("collector", collector)
+	lifecycle.Register("alerts", alertManager)
+	lifecycle.Register("health", scheduler)
+	lifecycle.Register("broken", failingStopper{})
+	lifecycle.Register("last", record("last"))
+	if err := lifecycle.Register("unknown", 42); err == nil {
+		t.Error("不支持的组件类型应返回错误")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	report, err := lifecycle.Shutdown(ctx)
+	if err == nil || report.IsClean() {
+		t.Fatalf("存在失败与超时的组件时应返回错误: %+v", report)
+	}
+	orderMu.Lock()
+	if len(order) != 2 || order[0] != "last" || order[1] != "first" {
+		t.Errorf("组件应按注册的逆序停止: %v", order)
+	}
+	orderMu.Unlock()
+	expected := []string{"last", "health", "alerts", "collector", "first"}
+	if strings.Join(report.Stopped, ",") != strings.Join(expected, ",") {
+		t.Errorf("按时停止的组件不正确: %v", report.Stopped)
+	}
+	if _, ok := report.Failed["broken"]; !ok {
+		t.Errorf("应报告停止失败的组件: %+v", report.Failed)
+	}
+	if len(report.TimedOut) != 1 || report.TimedOut[0] != "stuck" {
+		t.Errorf("应报告未按时停止的组件: %v", report.TimedOut)
+	}
+	if _, err := lifecycle.Shutdown(context.Background()); err == nil {
+		t.Error("重复停机应返回错误")
+	}
+}
+
+// 测试停机时排空执行中的语句，拒绝新语句并关闭连接池
+func TestLifecycleManager_DrainDb(t *testing.T) {
+	// 写入阻塞到 release 关闭
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	fake := newFakeDriver()
+	fake.onExec = func(stmt fakeStatement) (driver.Result, error) {
+		started <- struct{}{}
+		<-release
+		return driver.RowsAffected(1), nil
+	}
+	dataSource := fake.open(t, "")
+	db := db233.NewDb(dataSource, 1, nil)
+	repo := db233.NewBaseCrudRepository(db)
+
+	saved := make(chan error, 1)
+	go func() { saved <- repo.Save(&LifecycleTask{Id: 1}) }()
+	<-started
+	if db.GetInFlightStatements() != 1 {
+		t.Fatalf("执行中的语句数不正确: %d", db.GetInFlightStatements())
+	}
+
+	lifecycle := db233.NewLifecycleManager("app")
+	lifecycle.Register("main", db)
+	done := make(chan *db233.ShutdownReport, 1)
+	go func() {
+		report, _ := lifecycle.Shutdown(context.Background())
+		done <- report
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for !db.IsDraining() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := repo.Save(&LifecycleTask{Id: 2}); err == nil || !strings.Contains(err.Error(), "停机") {
+		t.Errorf("排空期间应拒绝新语句: %v", err)
+	}
+	if found, err := repo.FindById(3, &LifecycleTask{}); err == nil || found != nil {
+		t.Errorf("排空期间 FindById 应返回错误而不是空结果: %v, %v", found, err)
+	}
+	if _, err := repo.FindAll(&LifecycleTask{}); err == nil {
+		t.Error("排空期间 FindAll 应返回错误")
+	}
+
+	close(release)
+	if err := <-saved; err != nil {
+		t.Errorf("执行中的语句应正常完成: %v", err)
+	}
+	report := <-done
+	if !report.IsClean() || len(report.Stopped) != 1 || report.Stopped[0] != "main" {
+		t.Errorf("Db 应排空后关闭: %+v", report)
+	}
+	if err := dataSource.Ping(); err == nil {
+		t.Error("连接池应已关闭")
+	}
+}
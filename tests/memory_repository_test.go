@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 业务服务只依赖 CrudRepository 接口
+func renamePlayer(repo db233.CrudRepository, id int64, name string) error {
+	found, err := repo.FindById(id, &PartialUpdateUser{})
+	if err != nil || found == nil {
+		return err
+	}
+	user := found.(*PartialUpdateUser)
+	user.Name = name
+	return repo.Update(user)
+}
+
+// 测试内存存储库的增删改查
+func TestMemoryRepository_Crud(t *testing.T) {
+	db233.GetCrudManagerInstance().AutoInitEntity(&PartialUpdateUser{})
+	repo := db233.NewMemoryRepository()
+
+	alice := &PartialUpdateUser{Name: "alice", Age: 20, TenantId: 1}
+	bob := &PartialUpdateUser{Name: "bob", Age: 35, TenantId: 1}
+	if err := repo.SaveBatch([]db233.IDbEntity{alice, bob}); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	if alice.Id != 1 || bob.Id != 2 {
+		t.Errorf("自增主键应被分配: %d %d", alice.Id, bob.Id)
+	}
+	if err := repo.Save(&PartialUpdateUser{Id: 10, Name: "carol", Age: 50, TenantId: 2}); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	dave := &PartialUpdateUser{Name: "dave", TenantId: 2}
+	repo.Save(dave)
+	if dave.Id != 11 {
+		t.Errorf("显式主键之后应继续分配: %d", dave.Id)
+	}
+	if err := repo.Save(&PartialUpdateUser{Age: 20}); err == nil {
+		t.Error("validate 校验应生效")
+	}
+
+	// 返回的是副本
+	found, err := repo.FindById(1, &PartialUpdateUser{})
+	if err != nil || found == nil || found.(*PartialUpdateUser).Name != "alice" {
+		t.Fatalf("按主键查找失败: %v %v", found, err)
+	}
+	found.(*PartialUpdateUser).Name = "changed"
+	again, _ := repo.FindById(int64(1), &PartialUpdateUser{})
+	if again.(*PartialUpdateUser).Name != "alice" {
+		t.Error("修改返回值不应影响已保存的数据")
+	}
+
+	if err := renamePlayer(repo, 2, "bobby"); err != nil {
+		t.Fatalf("更新失败: %v", err)
+	}
+	updated, _ := repo.FindById(2, &PartialUpdateUser{})
+	if updated.(*PartialUpdateUser).Name != "bobby" {
+		t.Errorf("更新未生效: %+v", updated)
+	}
+	if err := repo.Update(&PartialUpdateUser{Id: 404, Name: "ghost"}); err != nil {
+		t.Errorf("更新不存在的记录不应报错: %v", err)
+	}
+
+	if count, _ := repo.Count(&PartialUpdateUser{}); count != 4 {
+		t.Errorf("记录数应为 4, 得到 %d", count)
+	}
+	if err := repo.DeleteById(10, &PartialUpdateUser{}); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	all, _ := repo.FindAll(&PartialUpdateUser{})
+	if len(all) != 3 || all[0].(*PartialUpdateUser).Name != "alice" || all[2].(*PartialUpdateUser).Name != "dave" {
+		t.Errorf("FindAll 应按保存顺序返回: %v", all)
+	}
+	if missing, err := repo.FindById(10, &PartialUpdateUser{}); missing != nil || err != nil {
+		t.Errorf("删除后应查不到: %v %v", missing, err)
+	}
+
+	repo.Reset()
+	if count, _ := repo.Count(&PartialUpdateUser{}); count != 0 {
+		t.Error("Reset 后应为空")
+	}
+}
+
+// 测试内存存储库的条件查询
+func TestMemoryRepository_FindByCondition(t *testing.T) {
+	db233.GetCrudManagerInstance().AutoInitEntity(&PartialUpdateUser{})
+	repo := db233.NewMemoryRepository()
+	for _, user := range []*PartialUpdateUser{
+		{Name: "alice", Email: "alice@example.com", Age: 20, TenantId: 1},
+		{Name: "bob", Age: 35, TenantId: 1},
+		{Name: "carol", Email: "carol@test.com", Age: 50, TenantId: 2},
+	} {
+		repo.Save(user)
+	}
+
+	cases := []struct {
+		condition string
+		params    []interface{}
+		expected  int
+	}{
+		{"age > ?", []interface{}{30}, 2},
+		{"(tenant_id = ? AND age >= ?)", []interface{}{1, 20}, 2},
+		{"tenant_id = ? and age < ?", []interface{}{int64(1), 30}, 1},
+		{"name IN (?, ?)", []interface{}{"alice", "carol"}, 2},
+		{"name NOT IN (?)", []interface{}{"alice"}, 2},
+		{"email LIKE ?", []interface{}{"%@example.com"}, 1},
+		{"age <> ?", []interface{}{35}, 2},
+		{"1 = 1", nil, 3},
+	}
+	for _, c := range cases {
+		results, err := repo.FindByCondition(c.condition, c.params, &PartialUpdateUser{})
+		if err != nil {
+			t.Errorf("%s 查询失败: %v", c.condition, err)
+			continue
+		}
+		if len(results) != c.expected {
+			t.Errorf("%s 应返回 %d 条, 得到 %d", c.condition, c.expected, len(results))
+		}
+	}
+
+	if _, err := repo.FindByCondition("age > ? OR name = ?", []interface{}{1, "x"}, &PartialUpdateUser{}); err == nil {
+		t.Error("不支持的条件应返回错误")
+	}
+	if _, err := repo.FindByCondition("nickname = ?", []interface{}{"x"}, &PartialUpdateUser{}); err == nil {
+		t.Error("不存在的列应返回错误")
+	}
+
+	// 自定义条件匹配
+	repo.SetConditionMatcher(func(condition string, params []interface{}, fields map[string]interface{}) (bool, bool, error) {
+		if condition != "age > ? OR name = ?" {
+			return false, false, nil
+		}
+		return fields["age"].(int) > params[0].(int) || fields["name"] == params[1], true, nil
+	})
+	results, err := repo.FindByCondition("age > ? OR name = ?", []interface{}{40, "alice"}, &PartialUpdateUser{})
+	if err != nil || len(results) != 2 {
+		t.Errorf("自定义条件匹配应生效: %d %v", len(results), err)
+	}
+}
+
+// 测试 NewMockDb 不继承全局插件
+func TestNewMockDb_IsolatedPlugins(t *testing.T) {
+	global := &sqlCapturePlugin{AbstractDb233Plugin: db233.NewAbstractDb233Plugin("mock_db_global")}
+	manager := db233.GetPluginManagerInstance()
+	manager.AddGlobalPlugin(global)
+	defer manager.RemoveGlobalPluginByName("mock_db_global")
+
+	db := db233.NewMockDb(nil, db233.EnumDatabaseTypePostgreSQL)
+	if db.DatabaseType != db233.EnumDatabaseTypePostgreSQL || db.DbGroup != nil {
+		t.Errorf("Db 配置不正确: %+v", db)
+	}
+	if len(db.GetEffectivePlugins()) != 0 {
+		t.Errorf("不应继承全局插件: %v", db.GetEffectivePlugins())
+	}
+	local := &sqlCapturePlugin{AbstractDb233Plugin: db233.NewAbstractDb233Plugin("mock_db_local")}
+	db.AddPlugin(local, 0)
+	if plugins := db.GetEffectivePlugins(); len(plugins) != 1 || plugins[0] != local {
+		t.Errorf("应保留 Db 自己的插件: %v", plugins)
+	}
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试压缩导出与过滤
+func TestMetricsCollector_ExportWithOptions(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source.json")
+	content := `{"metrics": {
+		"pool.active": [{"Timestamp": "2026-01-01T00:00:00Z", "Value": 1}, {"Timestamp": "2026-01-02T00:00:00Z", "Value": 2}],
+		"query.total": [{"Timestamp": "2026-01-02T00:00:00Z", "Value": 3}]
+	}}`
+	if err := os.WriteFile(source, []byte(content), 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+
+	collector := db233.NewMetricsCollector("test_export")
+	if err := collector.ImportFromFile(source); err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+
+	if err := collector.ExportToFileWithOptions(filepath.Join(dir, "bad.json"), db233.MetricsExportOptions{Compression: "unknown"}); err == nil {
+		t.Error("未注册的压缩算法应返回错误")
+	}
+
+	target := filepath.Join(dir, "export.json.gz")
+	err := collector.ExportToFileWithOptions(target, db233.MetricsExportOptions{
+		Compression:    "gzip",
+		MetricPatterns: []string{"pool.*"},
+	})
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+
+	imported := db233.NewMetricsCollector("test_import")
+	if err := imported.ImportFromFile(target); err != nil {
+		t.Fatalf("导入压缩文件失败: %v", err)
+	}
+	names := imported.GetMetricNames()
+	if len(names) != 1 || names[0] != "pool.active" {
+		t.Errorf("过滤后应只有 pool.active, 得到 %v", names)
+	}
+}
+
+// 测试多节点快照按时间戳合并去重
+func TestMetricsCollector_ImportMerge(t *testing.T) {
+	dir := t.TempDir()
+	nodeA := filepath.Join(dir, "node_a.json")
+	nodeB := filepath.Join(dir, "node_b.json")
+	os.WriteFile(nodeA, []byte(`{"metrics": {"query.total": [
+		{"Timestamp": "2026-01-01T00:00:00Z", "Value": 1},
+		{"Timestamp": "2026-01-01T00:02:00Z", "Value": 3}
+	]}}`), 0644)
+	os.WriteFile(nodeB, []byte(`{"metrics": {"query.total": [
+		{"Timestamp": "2026-01-01T00:01:00Z", "Value": 2},
+		{"Timestamp": "2026-01-01T00:02:00Z", "Value": 30},
+		{"Timestamp": "2026-01-01T00:03:00Z", "Value": 4}
+	]}}`), 0644)
+
+	collector := db233.NewMetricsCollector("test_merge")
+	collector.SetMaxPoints(3)
+	if err := collector.ImportFromFile(nodeA); err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	if err := collector.ImportFromFile(nodeB); err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+
+	points := collector.GetMetricHistory("query.total", 100*365*24*time.Hour)
+	if len(points) != 3 {
+		t.Fatalf("合并去重并裁剪后应有 3 个数据点, 得到 %d", len(points))
+	}
+	expected := []float64{2, 30, 4}
+	for i, point := range points {
+		if point.Value.(float64) != expected[i] {
+			t.Errorf("第 %d 个数据点期望 %v, 得到 %v", i, expected[i], point.Value)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 创建带有测试数据的节点收集器
+func newFederationNodeCollector(t *testing.T, name string, content string) *db233.MetricsCollector {
+	file := filepath.Join(t.TempDir(), name+".json")
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+	collector := db233.NewMetricsCollector(name)
+	if err := collector.ImportFromFile(file); err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	return collector
+}
+
+// 测试抓取多个节点并按节点标签合并
+func TestMetricsFederation_ScrapeAll(t *testing.T) {
+	nodeA := newFederationNodeCollector(t, "node_a", `{"metrics": {"pool.active": [
+		{"Timestamp": "2026-01-01T00:00:00Z", "Value": 1},
+		{"Timestamp": "2026-01-01T00:01:00Z", "Value": 2}
+	]}}`)
+	nodeB := newFederationNodeCollector(t, "node_b", `{"metrics": {"pool.active": [
+		{"Timestamp": "2026-01-01T00:00:00Z", "Value": 5}
+	], "query.total": [
+		{"Timestamp": "2026-01-01T00:00:00Z", "Value": 9}
+	]}}`)
+
+	serverA := httptest.NewServer(nodeA.SnapshotHandler())
+	defer serverA.Close()
+	serverB := httptest.NewServer(nodeB.SnapshotHandler())
+	defer serverB.Close()
+
+	local := db233.NewMetricsCollector("fleet")
+	federation := db233.NewMetricsFederation(local)
+	federation.AddNode("a", serverA.URL)
+	federation.AddNode("b", serverB.URL)
+	federation.AddNode("down", "http://127.0.0.1:1")
+	federation.SetMetricPatterns("pool.*")
+
+	if err := federation.AddNode("a", serverA.URL); err == nil {
+		t.Error("重复节点应返回错误")
+	}
+
+	errs := federation.ScrapeAll()
+	if len(errs) != 1 || errs["down"] == nil {
+		t.Errorf("只有 down 节点应抓取失败, 得到 %v", errs)
+	}
+
+	history := local.GetMetricHistory("a.pool.active", 100*365*24*time.Hour)
+	if len(history) != 2 || history[0].Tags[db233.FederationNodeTag] != "a" {
+		t.Fatalf("节点 a 的数据合并错误: %+v", history)
+	}
+	if len(local.GetMetricHistory("b.query.total", 100*365*24*time.Hour)) != 0 {
+		t.Error("未匹配通配符的指标不应被抓取")
+	}
+
+	latest := federation.GetLatestByNode("pool.active")
+	if latest["a"].Value.(float64) != 2 || latest["b"].Value.(float64) != 5 {
+		t.Errorf("各节点最新值错误: %+v", latest)
+	}
+
+	// 再次抓取为增量抓取，重叠数据点去重
+	federation.ScrapeNode("a")
+	if history := local.GetMetricHistory("a.pool.active", 100*365*24*time.Hour); len(history) != 2 {
+		t.Errorf("重复抓取不应产生重复数据点, 得到 %d", len(history))
+	}
+
+	status := federation.GetStatus()
+	if status["node_count"] != 3 || status["healthy_nodes"] != 2 {
+		t.Errorf("联邦状态错误: %v", status)
+	}
+}
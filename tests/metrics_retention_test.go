@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 写入每 10 秒一个点、持续 hours 小时的快照，值为距起点的分钟数
+func writeRetentionSnapshot(t *testing.T, start time.Time, hours int) string {
+	var points []string
+	for offset := time.Duration(0); offset < time.Duration(hours)*time.Hour; offset += 10 * time.Second {
+		points = append(points, fmt.Sprintf(`{"Timestamp": %q, "Value": %d}`, start.Add(offset).Format(time.RFC3339), int(offset/time.Minute)))
+	}
+	filename := filepath.Join(t.TempDir(), "snapshot.json")
+	content := `{"metrics": {"db.qps": [` + strings.Join(points, ",") + `]}}`
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatalf("写入快照失败: %v", err)
+	}
+	return filename
+}
+
+// 测试分层配置校验
+func TestMetricsRetention_ValidateTiers(t *testing.T) {
+	collector := db233.NewMetricsCollector("retention_validate")
+	invalid := [][]db233.MetricsRetentionTier{
+		{{Resolution: time.Minute, Retention: time.Hour}},
+		{{Resolution: 0, Retention: 0}},
+		{{Resolution: 0, Retention: time.Hour}, {Resolution: 90 * time.Second, Retention: time.Hour}, {Resolution: 2 * time.Minute, Retention: time.Hour}},
+		{{Resolution: 0, Retention: time.Minute}, {Resolution: time.Hour, Retention: 24 * time.Hour}},
+	}
+	for _, tiers := range invalid {
+		if err := collector.SetRetentionTiers(tiers...); err == nil {
+			t.Errorf("分层配置应无效: %v", tiers)
+		}
+	}
+	if err := collector.SetRetentionTiers(db233.DefaultMetricsRetentionTiers()...); err != nil {
+		t.Errorf("默认分层应有效: %v", err)
+	}
+}
+
+// 测试降采样：原始数据过期后由分钟、小时汇总补齐历史
+func TestMetricsRetention_Downsample(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Hour)
+	start := now.Add(-3 * time.Hour)
+
+	collector := db233.NewMetricsCollector("retention_downsample")
+	collector.SetMaxPoints(5000)
+	collector.SetRetentionTiers(
+		db233.MetricsRetentionTier{Resolution: 0, Retention: time.Hour},
+		db233.MetricsRetentionTier{Resolution: time.Minute, Retention: 2 * time.Hour},
+		db233.MetricsRetentionTier{Resolution: time.Hour, Retention: 30 * 24 * time.Hour},
+	)
+	if err := collector.ImportFromFile(writeRetentionSnapshot(t, start, 3)); err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	collector.Downsample(now)
+
+	minutes := collector.GetMetricRollups("db.qps", time.Minute, 24*time.Hour)
+	if len(minutes) != 120 || minutes[0].Start != start.Add(time.Hour) {
+		t.Fatalf("分钟汇总应只保留最近 2 小时: %d", len(minutes))
+	}
+	if minutes[0].Count != 6 || minutes[0].Avg() != 60 || minutes[0].Min != 60 || minutes[0].Max != 60 {
+		t.Errorf("分钟汇总统计不正确: %+v", minutes[0])
+	}
+	hours := collector.GetMetricRollups("db.qps", time.Hour, 24*time.Hour)
+	if len(hours) != 3 || hours[0].Count != 360 || hours[0].Min != 0 || hours[0].Max != 59 || hours[0].Avg() != 29.5 {
+		t.Fatalf("小时汇总不正确: %+v", hours)
+	}
+
+	// 原始数据只保留 1 小时，更早的时间段由汇总补齐
+	history := collector.GetMetricHistory("db.qps", 4*time.Hour)
+	if len(history) != 1+60+359 {
+		t.Errorf("历史应由 1 个小时汇总、60 个分钟汇总和原始数据组成: %d", len(history))
+	}
+	if history[0].Tags["resolution"] != "1h0m0s" || history[1].Tags["resolution"] != "1m0s" || history[len(history)-1].Tags["resolution"] != "" {
+		t.Errorf("历史应从粗到细排列: %v %v", history[0].Tags, history[1].Tags)
+	}
+	for i := 1; i < len(history); i++ {
+		if !history[i].Timestamp.After(history[i-1].Timestamp) {
+			t.Fatalf("历史应按时间升序且不重叠: %v >= %v", history[i-1].Timestamp, history[i].Timestamp)
+		}
+	}
+	if stats := collector.GetMetricStats("db.qps", 4*time.Hour); stats["count"] != len(history) || stats["min"] != 29.5 {
+		t.Errorf("统计应包含汇总数据: %v", stats)
+	}
+
+	// 再次降采样不会重复汇总
+	collector.Downsample(now)
+	if len(collector.GetMetricRollups("db.qps", time.Hour, 24*time.Hour)) != 3 {
+		t.Error("重复降采样不应重复汇总")
+	}
+	status := collector.GetStatus()
+	if status["retention_tiers"] != 3 || status["rollup_points"] != 123 {
+		t.Errorf("状态应包含分层信息: %v", status)
+	}
+}
+
+// 测试收集循环自动降采样
+func TestMetricsRetention_Automatic(t *testing.T) {
+	collector := db233.NewMetricsCollector("retention_auto")
+	collector.AddDataSource(&adjustableDataSource{name: "perf", values: map[string]interface{}{"queries": int64(5)}})
+	collector.SetCollectionInterval(5 * time.Millisecond)
+	collector.SetRetentionTiers(
+		db233.MetricsRetentionTier{Resolution: 0, Retention: 50 * time.Millisecond},
+		db233.MetricsRetentionTier{Resolution: 20 * time.Millisecond, Retention: time.Minute},
+	)
+	collector.Start()
+	time.Sleep(200 * time.Millisecond)
+	collector.Stop()
+
+	if len(collector.GetMetricRollups("perf.queries", 20*time.Millisecond, time.Minute)) == 0 {
+		t.Error("收集循环应自动生成汇总")
+	}
+	if raw := collector.GetStatus()["total_data_points"].(int); raw > 15 {
+		t.Errorf("原始数据应按保留时长清理: %d", raw)
+	}
+}
@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 模拟多个实例共享的 MySQL：GET_LOCK 为全局互斥，schema_migrations 记录在内存中
+type migrationLockServer struct {
+	fake    *fakeDriver
+	mu      sync.Mutex
+	lock    chan struct{}
+	applied []int64
+	upRuns  int
+}
+
+func newMigrationLockServer() *migrationLockServer {
+	server := &migrationLockServer{fake: newFakeDriver(), lock: make(chan struct{}, 1)}
+	server.fake.onExec = func(stmt fakeStatement) (driver.Result, error) {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		switch {
+		case strings.HasPrefix(stmt.query, "INSERT INTO schema_migrations"):
+			server.applied = append(server.applied, stmt.args[0].(int64))
+		case strings.Contains(stmt.query, "CREATE TABLE lock_demo"):
+			server.upRuns++
+		}
+		return driver.RowsAffected(1), nil
+	}
+	server.fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		switch {
+		case strings.HasPrefix(stmt.query, "SELECT GET_LOCK"):
+			timeout := time.Duration(stmt.args[1].(int64)) * time.Second
+			select {
+			case server.lock <- struct{}{}:
+				return fakeRowsOf([]string{"v"}, []driver.Value{int64(1)}), nil
+			case <-time.After(timeout):
+				return fakeRowsOf([]string{"v"}, []driver.Value{int64(0)}), nil
+			}
+		case strings.HasPrefix(stmt.query, "SELECT RELEASE_LOCK"):
+			<-server.lock
+			return fakeRowsOf([]string{"v"}, []driver.Value{int64(1)}), nil
+		case strings.HasPrefix(stmt.query, "SELECT version FROM schema_migrations"):
+			server.mu.Lock()
+			defer server.mu.Unlock()
+			values := make([][]driver.Value, 0, len(server.applied))
+			for _, version := range server.applied {
+				values = append(values, []driver.Value{version})
+			}
+			return fakeRowsOf([]string{"v"}, values...), nil
+		}
+		return nil, errors.New("未知查询: " + stmt.query)
+	}
+	return server
+}
+
+// newManager 创建连接到该 MySQL 的实例
+func (s *migrationLockServer) newManager(t *testing.T, dir string) *db233.MigrationManager {
+	return db233.NewMigrationManager(db233.NewDb(s.fake.open(t, ""), 1, nil), dir)
+}
+
+func writeLockDemoMigration(t *testing.T) string {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "1_lock_demo.up.sql"), []byte("CREATE TABLE lock_demo (id BIGINT)"), 0644)
+	os.WriteFile(filepath.Join(dir, "1_lock_demo.down.sql"), []byte("DROP TABLE lock_demo"), 0644)
+	return dir
+}
+
+// 测试多个实例同时上迁时只有一个实例执行迁移
+func TestMigrationLock_ConcurrentUp(t *testing.T) {
+	server := newMigrationLockServer()
+	dir := writeLockDemoMigration(t)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		manager := server.newManager(t, dir).SetLockTimeout(5 * time.Second)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- manager.Up(0)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("上迁失败: %v", err)
+		}
+	}
+	if server.upRuns != 1 || len(server.applied) != 1 {
+		t.Errorf("迁移应只执行一次: 执行=%d, 记录=%v", server.upRuns, server.applied)
+	}
+}
+
+// 测试锁被其他实例持有时的等待超时与跳过
+func TestMigrationLock_HeldByOtherInstance(t *testing.T) {
+	server := newMigrationLockServer()
+	dir := writeLockDemoMigration(t)
+	server.lock <- struct{}{} // 其他实例持有锁
+
+	err := server.newManager(t, dir).SetLockTimeout(time.Second).Up(0)
+	var timeoutErr *db233.MigrationLockTimeoutException
+	if !errors.As(err, &timeoutErr) || timeoutErr.LockName != "db233_schema_migrations" {
+		t.Errorf("等待超时应返回 MigrationLockTimeoutException: %v", err)
+	}
+
+	if err := server.newManager(t, dir).SetLockMode(db233.EnumMigrationLockModeSkip).Up(0); err != nil {
+		t.Errorf("Skip 模式应跳过且不报错: %v", err)
+	}
+	if server.upRuns != 0 {
+		t.Errorf("未获取锁时不应执行迁移: %d", server.upRuns)
+	}
+
+	if err := server.newManager(t, dir).SetLockMode(db233.EnumMigrationLockModeNone).Up(0); err != nil {
+		t.Errorf("None 模式应不加锁直接迁移: %v", err)
+	}
+	if server.upRuns != 1 {
+		t.Errorf("None 模式应执行迁移: %d", server.upRuns)
+	}
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 返回多个结果集的驱动：tx_order 两行、tx_stock 一行、统计一行；语句包含 broken 时读取第二个结果集失败
+func newMultiResultDb(t *testing.T) (*db233.Db, *fakeDriver) {
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		stocks := fakeResultSet{columns: []string{"id", "count"}, values: [][]driver.Value{{int64(7), int64(3)}}}
+		if strings.Contains(stmt.query, "broken") {
+			stocks.err = errors.New("结果集读取失败")
+		}
+		return fakeResultSets(
+			fakeResultSet{columns: []string{"id", "amount"}, values: [][]driver.Value{{int64(1), int64(100)}, {int64(2), int64(200)}}},
+			stocks,
+			fakeResultSet{columns: []string{"total"}, values: [][]driver.Value{{int64(300)}}},
+		), nil
+	}
+	return fake.newDb(t, db233.EnumDatabaseTypeMySQL), fake
+}
+
+// 测试每个结果集按各自的返回类型映射，未指定类型的结果集被读完丢弃
+func TestExecuteMultiQuery_MapsEachResultSet(t *testing.T) {
+	db, fake := newMultiResultDb(t)
+
+	sets, err := db.ExecuteMultiQuery("CALL order_summary(?)", []interface{}{1}, &TxOrder{}, &TxStock{})
+	if err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if len(sets) != 3 {
+		t.Fatalf("应返回全部 3 个结果集: %d", len(sets))
+	}
+	if len(sets[0]) != 2 || sets[0][0].(TxOrder).Amount != 100 || sets[0][1].(TxOrder).Id != 2 {
+		t.Errorf("第一个结果集映射不正确: %+v", sets[0])
+	}
+	if len(sets[1]) != 1 || sets[1][0].(TxStock).Count != 3 || sets[1][0].(TxStock).Id != 7 {
+		t.Errorf("第二个结果集映射不正确: %+v", sets[1])
+	}
+	if sets[2] != nil {
+		t.Errorf("未指定返回类型的结果集应为 nil: %+v", sets[2])
+	}
+
+	sets, err = db.ExecuteMultiQuery("CALL order_summary(?)", []interface{}{1}, nil, &TxStock{})
+	if err != nil || sets[0] != nil || len(sets[1]) != 1 {
+		t.Errorf("nil 返回类型应跳过对应结果集: %+v %v", sets, err)
+	}
+
+	// ExecuteQuery 仍只映射第一个结果集
+	if results := db.ExecuteQuery("CALL order_summary(?)", [][]interface{}{{1}}, &TxOrder{}); len(results) != 2 {
+		t.Errorf("ExecuteQuery 应只返回第一个结果集: %d", len(results))
+	}
+
+	if fake.closedRows != 3 {
+		t.Errorf("每次查询后结果集都应关闭: %d", fake.closedRows)
+	}
+}
+
+// 测试读取结果集出错时返回已读取的结果与错误
+func TestExecuteMultiQuery_ResultSetError(t *testing.T) {
+	db, _ := newMultiResultDb(t)
+
+	sets, err := db.ExecuteMultiQuery("CALL broken()", nil, &TxOrder{}, &TxStock{})
+	if err == nil || !strings.Contains(err.Error(), "第 2 个结果集") {
+		t.Fatalf("应报告出错的结果集: %v", err)
+	}
+	if len(sets) != 2 || len(sets[0]) != 2 {
+		t.Errorf("应返回出错前已映射的结果集: %+v", sets)
+	}
+
+	db.SetQueryAllowlist(db233.NewQueryAllowlist(db233.QueryAllowlistModeStrict))
+	if _, err := db.ExecuteMultiQuery("CALL order_summary(?)", []interface{}{1}, &TxOrder{}); err == nil {
+		t.Error("白名单拦截的语句应返回错误")
+	}
+}
+
+// 测试插件收到全部结果集与总行数
+func TestExecuteMultiQuery_Plugins(t *testing.T) {
+	db, _ := newMultiResultDb(t)
+	plugin := &multiResultPlugin{AbstractDb233Plugin: db233.NewAbstractDb233Plugin("multi_result")}
+	db.AddPlugin(plugin, 0)
+
+	if _, err := db.ExecuteMultiQuery("CALL order_summary(?)", []interface{}{1}, &TxOrder{}, &TxStock{}); err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if plugin.sets != 3 || plugin.rows != 3 {
+		t.Errorf("插件应收到全部结果集: sets=%d rows=%d", plugin.sets, plugin.rows)
+	}
+}
+
+type multiResultPlugin struct {
+	*db233.AbstractDb233Plugin
+	sets int
+	rows int
+}
+
+func (p *multiResultPlugin) PostExecuteSql(ctx *db233.ExecuteSqlContext) {
+	if sets, ok := ctx.Result.([][]interface{}); ok {
+		p.sets = len(sets)
+	}
+	p.rows = ctx.AffectedRows
+}
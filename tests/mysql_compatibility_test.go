@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// TiDB AUTO_RANDOM 主键 + 分片注释实体
+type TidbOrder struct {
+	Id      int64  `db:"id,primary_key" auto_random:"5"`
+	OrderNo string `db:"order_no"`
+	Seq     int64  `db:"seq,auto_increment"`
+}
+
+func (e *TidbOrder) TableName() string { return "tidb_order" }
+
+func (e *TidbOrder) SerializeBeforeSaveDb() {}
+
+func (e *TidbOrder) DeserializeAfterLoadDb() {}
+
+func (e *TidbOrder) ShardingComment() string { return "/*T! PRE_SPLIT_REGIONS=2 */" }
+
+// 测试兼容模式下的建表与改表 SQL
+func TestMySQLCompatibility_Strategy(t *testing.T) {
+	cm := db233.GetCrudManagerInstance()
+	entityType := reflect.TypeOf(TidbOrder{})
+
+	tidb := db233.GetStrategyFactoryInstance().GetStrategyForDb(&db233.Db{DatabaseType: db233.EnumDatabaseTypeMySQL, CompatibilityMode: db233.EnumMySQLCompatibilityModeTiDB})
+	createSql, err := tidb.GenerateCreateTableSQL("tidb_order", entityType, "")
+	if err != nil {
+		t.Fatalf("生成建表 SQL 失败: %v", err)
+	}
+	for _, expected := range []string{
+		"`id` BIGINT AUTO_RANDOM(5) NOT NULL",
+		"PRIMARY KEY (`id`) /*T![clustered_index] CLUSTERED */",
+		"COLLATE=utf8mb4_unicode_ci /*T! PRE_SPLIT_REGIONS=2 */",
+	} {
+		if !strings.Contains(createSql, expected) {
+			t.Errorf("TiDB 建表 SQL 缺少 %q:\n%s", expected, createSql)
+		}
+	}
+
+	mysql := db233.GetStrategyFactoryInstance().GetStrategyForDb(&db233.Db{DatabaseType: db233.EnumDatabaseTypeMySQL})
+	createSql, _ = mysql.GenerateCreateTableSQL("tidb_order", entityType, "")
+	if !strings.Contains(createSql, "`id` BIGINT AUTO_INCREMENT NOT NULL") || strings.Contains(createSql, "CLUSTERED") {
+		t.Errorf("原生 MySQL 下 auto_random 应退化为 AUTO_INCREMENT:\n%s", createSql)
+	}
+	if !cm.IsAutoIncrement(entityType.Field(0)) {
+		t.Error("auto_random 主键应视为数据库生成")
+	}
+
+	field, _ := entityType.FieldByName("Seq")
+	vitess := db233.NewMySQLStrategyWithCompatibility(cm, db233.EnumMySQLCompatibilityModeVitess)
+	if sql, _ := vitess.GenerateAddColumnSQL("tidb_order", field, "seq"); sql != "ALTER TABLE `tidb_order` ADD COLUMN `seq` BIGINT NULL" {
+		t.Errorf("兼容模式下 ALTER 不应包含 AUTO_INCREMENT: %s", sql)
+	}
+	if sql, _ := tidb.GenerateModifyColumnSQL("tidb_order", field, "seq"); strings.Contains(sql, "AUTO_INCREMENT") {
+		t.Errorf("兼容模式下 ALTER 不应包含 AUTO_INCREMENT: %s", sql)
+	}
+	if sql, _ := mysql.GenerateAddColumnSQL("tidb_order", field, "seq"); !strings.Contains(sql, "AUTO_INCREMENT") {
+		t.Errorf("原生 MySQL 应保留 AUTO_INCREMENT: %s", sql)
+	}
+}
+
+// 测试按兼容模式识别可重试错误
+func TestMySQLCompatibility_RetryableErrors(t *testing.T) {
+	cases := []struct {
+		mode     db233.EnumMySQLCompatibilityMode
+		message  string
+		expected bool
+	}{
+		{db233.EnumMySQLCompatibilityModeNone, "Error 1213: Deadlock found when trying to get lock", true},
+		{db233.EnumMySQLCompatibilityModeNone, "driver: bad connection", true},
+		{db233.EnumMySQLCompatibilityModeNone, "Error 9007: Write conflict, txnStartTS=1", false},
+		{db233.EnumMySQLCompatibilityModeTiDB, "Error 9007 (HY000): Write conflict, txnStartTS=1", true},
+		{db233.EnumMySQLCompatibilityModeTiDB, "Error 9005: Region is unavailable", true},
+		{db233.EnumMySQLCompatibilityModeTiDB, "Error 1062: Duplicate entry '1' for key 'PRIMARY'", false},
+		{db233.EnumMySQLCompatibilityModeVitess, "target: ks.-80.primary: vttablet: rpc error: code = Unavailable desc = primary is not serving", true},
+		{db233.EnumMySQLCompatibilityModeVitess, "vttablet: rpc error: code = InvalidArgument desc = syntax error", false},
+	}
+	for _, c := range cases {
+		if got := db233.IsRetryableErrorForMode(errors.New(c.message), c.mode); got != c.expected {
+			t.Errorf("%q %s: 期望 %v, 得到 %v", c.mode, c.message, c.expected, got)
+		}
+	}
+
+	db := db233.NewDb(nil, 1, nil)
+	db.CompatibilityMode = db233.EnumMySQLCompatibilityModeTiDB
+	if !db.IsRetryableError(errors.New("Error 8028: Information schema is changed")) || db.IsRetryableError(nil) {
+		t.Error("Db.IsRetryableError 应使用 Db 的兼容模式")
+	}
+}
@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 遗留库实体：表名与类型名一致，列名与字段名一致，不写 db 标签
+type LegacyPlayerProfile struct {
+	Id         int64
+	PlayerName string
+	Level      int
+	cache      string
+}
+
+// 值接收者：FindByCondition 返回值类型的实体；返回空字符串表示按命名策略生成表名
+func (e LegacyPlayerProfile) TableName() string { return "" }
+
+func (e LegacyPlayerProfile) SerializeBeforeSaveDb() {}
+
+func (e LegacyPlayerProfile) DeserializeAfterLoadDb() {}
+
+type NamingItem struct {
+	Id int64 `db:"id,primary_key"`
+}
+
+func (e *NamingItem) TableName() string { return "" }
+
+func (e *NamingItem) SerializeBeforeSaveDb() {}
+
+func (e *NamingItem) DeserializeAfterLoadDb() {}
+
+// 查询返回按字段名命名的列
+func newNamingDb(t *testing.T) (*db233.Db, *fakeDriver) {
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		return fakeRowsOf([]string{"Id", "PlayerName", "Level"}, []driver.Value{int64(7), []byte("neko"), int64(42)}), nil
+	}
+	return fake.newDb(t, db233.EnumDatabaseTypeMySQL), fake
+}
+
+// 测试表名风格、复数与前缀
+func TestNamingStrategy_TableName(t *testing.T) {
+	cases := []struct {
+		strategy db233.NamingStrategy
+		typeName string
+		expected string
+	}{
+		{db233.NamingStrategy{}, "PlayerItem", "player_item"},
+		{db233.NamingStrategy{TableStyle: db233.NamingStylePassthrough}, "TableNamesLikeThis", "TableNamesLikeThis"},
+		{db233.NamingStrategy{PluralTables: true}, "PlayerItem", "player_items"},
+		{db233.NamingStrategy{PluralTables: true}, "Category", "categories"},
+		{db233.NamingStrategy{PluralTables: true}, "Day", "days"},
+		{db233.NamingStrategy{PluralTables: true}, "MailBox", "mail_boxes"},
+		{db233.NamingStrategy{TableStyle: db233.NamingStylePassthrough, PluralTables: true}, "Category", "Categories"},
+		{db233.NamingStrategy{TablePrefix: "game_", PluralTables: true}, "Player", "game_players"},
+	}
+	for _, c := range cases {
+		if name := c.strategy.TableName(c.typeName); name != c.expected {
+			t.Errorf("%+v: %s -> %s，期望 %s", c.strategy, c.typeName, name, c.expected)
+		}
+	}
+
+	snake := db233.NamingStrategy{ColumnStyle: db233.NamingStyleSnakeCase}
+	if snake.ColumnName("PlayerName") != "player_name" || (&db233.NamingStrategy{}).ColumnName("PlayerName") != "" {
+		t.Error("列名风格不正确")
+	}
+}
+
+// 测试全局策略下无标签实体的表名与列名
+func TestNamingStrategy_LegacySchema(t *testing.T) {
+	cm := db233.GetCrudManagerInstance()
+	cm.SetNamingStrategy(&db233.NamingStrategy{
+		TableStyle:  db233.NamingStylePassthrough,
+		ColumnStyle: db233.NamingStylePassthrough,
+	})
+	defer cm.SetNamingStrategy(nil)
+
+	if name := cm.GetTableName(reflect.TypeOf(LegacyPlayerProfile{})); name != "LegacyPlayerProfile" {
+		t.Errorf("表名应与类型名一致: %s", name)
+	}
+	field, _ := reflect.TypeOf(LegacyPlayerProfile{}).FieldByName("cache")
+	if cm.GetColumnName(field) != "" {
+		t.Error("未导出字段不应生成列名")
+	}
+
+	db, fake := newNamingDb(t)
+	repo := db233.NewBaseCrudRepository(db)
+
+	if err := repo.Save(LegacyPlayerProfile{Id: 7, PlayerName: "neko", Level: 42}); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	execs := fake.execSqls()
+	insert := execs[len(execs)-1]
+	if !strings.Contains(insert, "LegacyPlayerProfile") || !strings.Contains(insert, "PlayerName") || strings.Contains(insert, "cache") {
+		t.Errorf("插入语句应使用类型名与字段名: %s", insert)
+	}
+
+	results, err := repo.FindByCondition("Id = ?", []interface{}{7}, LegacyPlayerProfile{})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("查询失败: %v %v", results, err)
+	}
+	profile := results[0].(LegacyPlayerProfile)
+	if profile.Id != 7 || profile.PlayerName != "neko" || profile.Level != 42 {
+		t.Errorf("按字段名映射不正确: %+v", profile)
+	}
+}
+
+// 测试单个实体的表名策略优先于全局策略
+func TestNamingStrategy_EntityOverride(t *testing.T) {
+	cm := db233.GetCrudManagerInstance()
+	cm.SetEntityNamingStrategy(&NamingItem{}, &db233.NamingStrategy{TablePrefix: "game_", PluralTables: true})
+	defer cm.SetEntityNamingStrategy(&NamingItem{}, nil)
+
+	if name := cm.GetTableName(reflect.TypeOf(NamingItem{})); name != "game_naming_items" {
+		t.Errorf("应使用实体的命名策略: %s", name)
+	}
+
+	db, fake := newNamingDb(t)
+	if err := db233.NewBaseCrudRepository(db).Save(&NamingItem{Id: 1}); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	if execs := fake.execSqls(); !strings.Contains(execs[len(execs)-1], "game_naming_items") {
+		t.Errorf("插入语句应使用实体策略生成的表名: %v", execs)
+	}
+
+	cm.SetEntityNamingStrategy(&NamingItem{}, nil)
+	if name := cm.GetTableName(reflect.TypeOf(NamingItem{})); name != "naming_item" {
+		t.Errorf("移除实体策略后应恢复全局策略: %s", name)
+	}
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type NullValuesPlayer struct {
+	Id        int64           `db:"id,primary_key"`
+	Nickname  *string         `db:"nickname"`
+	Level     *int            `db:"level"`
+	Guild     sql.NullString  `db:"guild"`
+	Referrer  sql.NullInt64   `db:"referrer_id"`
+	Score     sql.NullFloat64 `db:"score"`
+	BannedAt  sql.NullTime    `db:"banned_at"`
+	Gold      int64           `db:"gold"`
+	Title     string          `db:"title,omitempty"`
+	VipLevel  int             `db:"vip_level,omitempty"`
+	Signature *string         `db:"signature,omitempty"`
+}
+
+// 值接收者：FindByCondition 返回值类型的实体
+func (e NullValuesPlayer) TableName() string { return "null_values_player" }
+
+func (e NullValuesPlayer) SerializeBeforeSaveDb() {}
+
+func (e NullValuesPlayer) DeserializeAfterLoadDb() {}
+
+// 记录 INSERT，查询返回一行全 NULL 与一行全非 NULL（MySQL 文本协议形式）
+func openNullValuesDb(t *testing.T) (*db233.Db, *fakeDriver) {
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		return fakeRowsOf([]string{"id", "nickname", "level", "guild", "referrer_id", "score", "banned_at", "gold"},
+			[]driver.Value{int64(1), nil, nil, nil, nil, nil, nil, nil},
+			[]driver.Value{[]byte("2"), []byte("alice"), []byte("7"), []byte("dragons"), []byte("1"), []byte("9.5"), []byte("2026-10-16 08:00:00"), []byte("100")},
+		), nil
+	}
+	return fake.newDb(t, db233.EnumDatabaseTypeMySQL), fake
+}
+
+// 测试 NULL 读取为 nil 指针 / Valid=false，非 NULL 读取为指针与 sql.Null* 的值
+func TestNullValues_Scan(t *testing.T) {
+	db, _ := openNullValuesDb(t)
+	results, err := db233.NewBaseCrudRepository(db).FindByCondition("1 = 1", nil, NullValuesPlayer{})
+	if err != nil || len(results) != 2 {
+		t.Fatalf("查询失败: %v %v", results, err)
+	}
+
+	empty := results[0].(NullValuesPlayer)
+	if empty.Nickname != nil || empty.Level != nil || empty.Guild.Valid || empty.Referrer.Valid || empty.Score.Valid || empty.BannedAt.Valid || empty.Gold != 0 {
+		t.Errorf("NULL 应映射为 nil / Valid=false / 零值: %+v", empty)
+	}
+
+	full := results[1].(NullValuesPlayer)
+	if full.Id != 2 || full.Nickname == nil || *full.Nickname != "alice" || full.Level == nil || *full.Level != 7 {
+		t.Errorf("指针字段映射不正确: %+v", full)
+	}
+	if full.Guild != (sql.NullString{String: "dragons", Valid: true}) || full.Referrer != (sql.NullInt64{Int64: 1, Valid: true}) || full.Score.Float64 != 9.5 {
+		t.Errorf("sql.Null* 字段映射不正确: %+v", full)
+	}
+	if !full.BannedAt.Valid || !full.BannedAt.Time.Equal(time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("sql.NullTime 映射不正确: %+v", full.BannedAt)
+	}
+}
+
+// 测试 nil 指针与 Valid=false 写为 NULL，omitempty 零值不出现在 INSERT 中
+func TestNullValues_InsertRoundTrip(t *testing.T) {
+	db, fake := openNullValuesDb(t)
+	nickname := "bob"
+	player := &NullValuesPlayer{Id: 3, Nickname: &nickname, Guild: sql.NullString{String: "dragons", Valid: true}, VipLevel: 2}
+	if err := db233.NewBaseCrudRepository(db).Save(player); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+
+	query, params := fake.execSqls()[0], fake.execArgs()[0]
+	if strings.Contains(query, "title") || strings.Contains(query, "signature") || !strings.Contains(query, "vip_level") {
+		t.Errorf("omitempty 零值列不应写入: %s", query)
+	}
+
+	columns := strings.Split(query[strings.Index(query, "(")+1:strings.Index(query, ")")], ",")
+	values := map[string]driver.Value{}
+	for i, column := range columns {
+		values[strings.Trim(strings.TrimSpace(column), "`")] = params[i]
+	}
+	expected := map[string]driver.Value{
+		"nickname": "bob", "level": nil, "guild": "dragons", "referrer_id": nil, "score": nil, "banned_at": nil, "gold": int64(0), "vip_level": int64(2),
+	}
+	for column, value := range expected {
+		if actual, ok := values[column]; !ok || !reflect.DeepEqual(actual, value) {
+			t.Errorf("列 %s 的写入值不正确: %#v（期望 %#v）", column, actual, value)
+		}
+	}
+}
+
+// 测试 sql.Null* 按值类型建列
+func TestNullValues_SqlType(t *testing.T) {
+	cm := db233.GetCrudManagerInstance()
+	strategy := db233.NewMySQLStrategy(cm)
+	for name, expected := range map[string]string{"Guild": "VARCHAR(255)", "Referrer": "BIGINT", "BannedAt": "TIMESTAMP"} {
+		field, _ := reflect.TypeOf(NullValuesPlayer{}).FieldByName(name)
+		if sqlType := strategy.GetSQLType(field); sqlType != expected {
+			t.Errorf("%s 的列类型不正确: %s（期望 %s）", name, sqlType, expected)
+		}
+	}
+}
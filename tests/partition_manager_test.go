@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 返回配置的分区名并记录执行的 DDL 的驱动
+func newPartitionDb(t *testing.T, dbType db233.EnumDatabaseType, partitions ...string) (*db233.Db, *fakeDriver) {
+	fake := newFakeDriver()
+	fake.onExec = func(stmt fakeStatement) (driver.Result, error) {
+		return driver.RowsAffected(0), nil
+	}
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		values := make([][]driver.Value, 0, len(partitions))
+		for _, partition := range partitions {
+			values = append(values, []driver.Value{partition})
+		}
+		return fakeRowsOf([]string{"name"}, values...), nil
+	}
+	return fake.newDb(t, dbType), fake
+}
+
+type PartitionLog struct {
+	Id        int64     `db:"id,primary_key"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (e *PartitionLog) TableName() string { return "partition_log" }
+
+func (e *PartitionLog) SerializeBeforeSaveDb() {}
+
+func (e *PartitionLog) DeserializeAfterLoadDb() {}
+
+var partitionNow = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+// 测试 MySQL 追加缺少的分区，过期分区在没有权限时只报告，开启权限后归档并删除
+func TestPartitionManager_MySQL(t *testing.T) {
+	db, fake := newPartitionDb(t, db233.EnumDatabaseTypeMySQL, "p_history", "p202606", "p202607", "p202608", "p202609", "p202610", "p202611")
+	pm := db233.NewPartitionManager(db)
+	if err := pm.Register(&PartitionLog{}, db233.NewMonthlyPartitionScheme("created_at").SetPremake(2).SetRetention(3).SetArchiveTable("partition_log_archive")); err != nil {
+		t.Fatalf("注册失败: %v", err)
+	}
+
+	result, err := pm.MaintainTable("partition_log", partitionNow)
+	if err != nil {
+		t.Fatalf("维护失败: %v", err)
+	}
+	if !reflect.DeepEqual(result.Created, []string{"p202612"}) || !reflect.DeepEqual(result.Skipped, []string{"DROP p202606"}) || len(result.Dropped) != 0 {
+		t.Errorf("安全权限下的维护结果不正确: %+v", result)
+	}
+	expected := []string{"ALTER TABLE partition_log ADD PARTITION (PARTITION p202612 VALUES LESS THAN ('2027-01-01'))"}
+	if execs := fake.execSqls(); !reflect.DeepEqual(execs, expected) {
+		t.Errorf("DDL 不正确: %v", execs)
+	}
+
+	pm.SetPermissions(db233.NewDefaultAutoDbPermission())
+	fake.reset()
+	results, err := pm.Maintain(partitionNow)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("维护失败: %v", err)
+	}
+	if !reflect.DeepEqual(results[0].Dropped, []string{"p202606"}) || !reflect.DeepEqual(results[0].Archived, []string{"p202606"}) {
+		t.Errorf("应归档并删除过期分区: %+v", results[0])
+	}
+	execs := fake.execSqls()
+	if len(execs) != 3 || execs[1] != "INSERT INTO partition_log_archive SELECT * FROM partition_log WHERE created_at >= ? AND created_at < ?" ||
+		execs[2] != "ALTER TABLE partition_log DROP PARTITION p202606" {
+		t.Errorf("归档与删除语句不正确: %v", execs)
+	}
+	archiveArgs := fake.execArgs()[1]
+	if !reflect.DeepEqual(archiveArgs, []driver.Value{"2026-06-01", "2026-07-01"}) {
+		t.Errorf("归档范围不正确: %v", archiveArgs)
+	}
+
+	partitions, err := pm.GetPartitions("partition_log")
+	if err != nil || len(partitions) != 6 || partitions[0].Name != "p202606" || !partitions[0].End.Equal(time.Date(2026, 7, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("受管理分区不正确: %+v %v", partitions, err)
+	}
+}
+
+// 测试 MySQL 表尚未分区时一次性转换为 RANGE COLUMNS 分区
+func TestPartitionManager_MySQLInitialPartitioning(t *testing.T) {
+	db, fake := newPartitionDb(t, db233.EnumDatabaseTypeMySQL)
+	pm := db233.NewPartitionManager(db)
+	if err := pm.Register("partition_log", db233.NewMonthlyPartitionScheme("created_at").SetPremake(1)); err != nil {
+		t.Fatalf("注册失败: %v", err)
+	}
+	result, err := pm.MaintainTable("partition_log", partitionNow)
+	if err != nil {
+		t.Fatalf("维护失败: %v", err)
+	}
+	if !reflect.DeepEqual(result.Created, []string{"p_history", "p202610", "p202611"}) {
+		t.Errorf("创建的分区不正确: %v", result.Created)
+	}
+	expected := "ALTER TABLE partition_log PARTITION BY RANGE COLUMNS(created_at) (PARTITION p_history VALUES LESS THAN ('2026-10-01'), " +
+		"PARTITION p202610 VALUES LESS THAN ('2026-11-01'), PARTITION p202611 VALUES LESS THAN ('2026-12-01'))"
+	if execs := fake.execSqls(); len(execs) != 1 || execs[0] != expected {
+		t.Errorf("DDL 不正确: %v", execs)
+	}
+
+	readOnly := db233.NewSafeAutoDbPermission()
+	readOnly.SetAllowed(db233.EnumAutoDbOperateTypeCreatePartition, false)
+	deniedDb, deniedFake := newPartitionDb(t, db233.EnumDatabaseTypeMySQL)
+	denied := db233.NewPartitionManager(deniedDb).SetPermissions(readOnly)
+	if err := denied.Register("partition_log", db233.NewMonthlyPartitionScheme("created_at").SetPremake(0)); err != nil {
+		t.Fatalf("注册失败: %v", err)
+	}
+	result, err = denied.MaintainTable("partition_log", partitionNow)
+	if err != nil || !reflect.DeepEqual(result.Skipped, []string{"CREATE p202610"}) || len(deniedFake.execSqls()) != 0 {
+		t.Errorf("没有创建权限时不应执行 DDL: %+v %v", result, err)
+	}
+}
+
+// 测试 PostgreSQL 按日创建子表分区并删除过期子表
+func TestPartitionManager_PostgreSQL(t *testing.T) {
+	db, fake := newPartitionDb(t, db233.EnumDatabaseTypePostgreSQL, "partition_log_p20261012", "partition_log_p20261016", "partition_log_default")
+	pm := db233.NewPartitionManager(db).SetPermissions(db233.NewDefaultAutoDbPermission())
+	if err := pm.Register(&PartitionLog{}, db233.NewPartitionScheme("created_at", db233.PartitionIntervalDay).SetPremake(1).SetRetention(2)); err != nil {
+		t.Fatalf("注册失败: %v", err)
+	}
+	result, err := pm.MaintainTable("partition_log", partitionNow)
+	if err != nil {
+		t.Fatalf("维护失败: %v", err)
+	}
+	expected := []string{
+		"CREATE TABLE IF NOT EXISTS partition_log_p20261017 PARTITION OF partition_log FOR VALUES FROM ('2026-10-17') TO ('2026-10-18')",
+		"DROP TABLE IF EXISTS partition_log_p20261012",
+	}
+	if execs := fake.execSqls(); !reflect.DeepEqual(execs, expected) {
+		t.Errorf("DDL 不正确: %v", execs)
+	}
+	if !reflect.DeepEqual(result.Created, []string{"partition_log_p20261017"}) || !reflect.DeepEqual(result.Dropped, []string{"partition_log_p20261012"}) {
+		t.Errorf("维护结果不正确: %+v", result)
+	}
+}
+
+// 测试注册时校验分区列、数据库类型与标识符
+func TestPartitionManager_RegisterValidation(t *testing.T) {
+	db, _ := newPartitionDb(t, db233.EnumDatabaseTypeMySQL)
+	pm := db233.NewPartitionManager(db)
+	if err := pm.Register(&PartitionLog{}, db233.NewMonthlyPartitionScheme("updated_at")); err == nil {
+		t.Error("分区列不存在应报错")
+	}
+	if err := pm.Register("partition_log; DROP", db233.NewMonthlyPartitionScheme("created_at")); err == nil {
+		t.Error("无效表名应报错")
+	}
+	if err := pm.Register("partition_log", db233.NewPartitionScheme("created_at", "week")); err == nil {
+		t.Error("无效分区周期应报错")
+	}
+	if _, err := pm.MaintainTable("unknown", partitionNow); err == nil {
+		t.Error("未声明的表应报错")
+	}
+	sqlServerDb, _ := newPartitionDb(t, db233.EnumDatabaseTypeSQLServer)
+	sqlServer := db233.NewPartitionManager(sqlServerDb)
+	if err := sqlServer.Register("partition_log", db233.NewMonthlyPartitionScheme("created_at")); err == nil {
+		t.Error("不支持的数据库类型应报错")
+	}
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type PgArrayRole string
+
+const (
+	PgArrayRoleAdmin  PgArrayRole = "admin"
+	PgArrayRoleMember PgArrayRole = "member"
+)
+
+type PgArrayPlayer struct {
+	Id     int64       `db:"id,primary_key"`
+	Tags   []string    `db:"tags" db_type:"TEXT[]"`
+	Scores []int       `db:"scores" db_type:"INTEGER[]"`
+	Role   PgArrayRole `db:"role" db_type:"pg_array_role"`
+}
+
+// 值接收者：FindByCondition 返回值类型的实体
+func (e PgArrayPlayer) TableName() string { return "pg_array_player" }
+
+func (e PgArrayPlayer) SerializeBeforeSaveDb() {}
+
+func (e PgArrayPlayer) DeserializeAfterLoadDb() {}
+
+// 记录 SQL 与参数，查询返回一行 PostgreSQL 数组文本
+func openPgArrayDb(t *testing.T, dbType db233.EnumDatabaseType) (*db233.Db, *fakeDriver) {
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		return fakeRowsOf([]string{"id", "tags", "scores", "role"},
+			[]driver.Value{int64(1), []byte(`{vip,"pvp \"x\"",NULL}`), "{1,2,3}", []byte("admin")}), nil
+	}
+	return fake.newDb(t, dbType), fake
+}
+
+// 测试 PostgreSQL 上数组列按数组字面量写入，其他数据库仍为 JSON
+func TestPgArray_SaveEncodesArrayLiteral(t *testing.T) {
+	player := &PgArrayPlayer{Id: 1, Tags: []string{"vip", `pvp "x"`}, Scores: []int{1, 2}, Role: PgArrayRoleAdmin}
+
+	db, fake := openPgArrayDb(t, db233.EnumDatabaseTypePostgreSQL)
+	if err := db233.NewBaseCrudRepository(db).Save(player); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	params := fake.execArgs()[0]
+	if !containsDriverValue(params, `{"vip","pvp \"x\""}`) || !containsDriverValue(params, "{1,2}") || !containsDriverValue(params, "admin") {
+		t.Errorf("PostgreSQL 数组参数不正确: %#v", params)
+	}
+
+	db, fake = openPgArrayDb(t, db233.EnumDatabaseTypeMySQL)
+	if err := db233.NewBaseCrudRepository(db).Save(player); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	if params := fake.execArgs()[0]; !containsDriverValue(params, `["vip","pvp \"x\""]`) {
+		t.Errorf("MySQL 应继续使用 JSON: %#v", params)
+	}
+}
+
+func containsDriverValue(values []driver.Value, expected string) bool {
+	for _, value := range values {
+		if value == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// 测试数组条件与数组字面量解析
+func TestPgArray_QueryAndScan(t *testing.T) {
+	db, _ := openPgArrayDb(t, db233.EnumDatabaseTypePostgreSQL)
+	condition, params, err := db233.NewConditionBuilder(db).
+		WhereArrayAny("tags", "vip").
+		WhereArrayContains("scores", []int{1, 2}).
+		WhereArrayOverlaps("tags", []string{"gm", "vip"}).
+		Build()
+	if err != nil {
+		t.Fatalf("构建条件失败: %v", err)
+	}
+	if condition != "(? = ANY(tags)) AND (scores @> ?) AND (tags && ?)" || !reflect.DeepEqual(params, []interface{}{"vip", "{1,2}", `{"gm","vip"}`}) {
+		t.Errorf("数组条件不正确: %s %v", condition, params)
+	}
+
+	results, err := db233.NewBaseCrudRepository(db).FindByCondition(condition, params, PgArrayPlayer{})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("查询失败: %v %v", results, err)
+	}
+	player := results[0].(PgArrayPlayer)
+	if !reflect.DeepEqual(player.Tags, []string{"vip", `pvp "x"`, ""}) || !reflect.DeepEqual(player.Scores, []int{1, 2, 3}) {
+		t.Errorf("数组列解析不正确: %#v %#v", player.Tags, player.Scores)
+	}
+	if player.Role != PgArrayRoleAdmin {
+		t.Errorf("枚举列解析不正确: %q", player.Role)
+	}
+
+	mysqlDb, _ := openPgArrayDb(t, db233.EnumDatabaseTypeMySQL)
+	_, _, err = db233.NewConditionBuilder(mysqlDb).WhereArrayAny("tags", "vip").Build()
+	if err == nil {
+		t.Error("MySQL 不支持数组条件，应返回错误")
+	}
+	_, _, err = db233.NewConditionBuilder(db).WhereArrayContains("tags", "vip").Build()
+	if err == nil {
+		t.Error("包含条件的值必须为切片")
+	}
+}
+
+// 测试枚举注册、建类型 SQL 与同步
+func TestEnumRegistry_SyncSQL(t *testing.T) {
+	registry := db233.NewEnumRegistry()
+	if err := registry.Register("pg_array_role", PgArrayRoleAdmin, PgArrayRoleMember); err != nil {
+		t.Fatalf("注册枚举失败: %v", err)
+	}
+	if err := registry.Register("pg_array_role", PgArrayRole("o'neil")); err != nil {
+		t.Fatalf("追加枚举值失败: %v", err)
+	}
+	if typeName, ok := registry.GetTypeName(reflect.TypeOf(PgArrayRoleAdmin)); !ok || typeName != "pg_array_role" {
+		t.Errorf("Go 类型映射不正确: %s", typeName)
+	}
+	if !registry.IsValidValue(PgArrayRoleMember) || registry.IsValidValue(PgArrayRole("guest")) {
+		t.Error("枚举值校验不正确")
+	}
+
+	statements := registry.GenerateSyncSQL()
+	expected := []string{
+		"DO $$ BEGIN CREATE TYPE pg_array_role AS ENUM ('admin', 'member', 'o''neil'); EXCEPTION WHEN duplicate_object THEN NULL; END $$",
+		"ALTER TYPE pg_array_role ADD VALUE IF NOT EXISTS 'admin'",
+		"ALTER TYPE pg_array_role ADD VALUE IF NOT EXISTS 'member'",
+		"ALTER TYPE pg_array_role ADD VALUE IF NOT EXISTS 'o''neil'",
+	}
+	if !reflect.DeepEqual(statements, expected) {
+		t.Errorf("同步 SQL 不正确: %v", statements)
+	}
+
+	db, fake := openPgArrayDb(t, db233.EnumDatabaseTypePostgreSQL)
+	if err := registry.Sync(db); err != nil || len(fake.recorded()) != 4 {
+		t.Errorf("同步应执行 4 条语句: %v %v", fake.recorded(), err)
+	}
+	mysqlDb, _ := openPgArrayDb(t, db233.EnumDatabaseTypeMySQL)
+	if err := registry.Sync(mysqlDb); err == nil {
+		t.Error("MySQL 不支持枚举同步，应返回错误")
+	}
+
+	for _, invalid := range [][]interface{}{{"Bad-Name", "a"}, {"role_x"}, {"role_y", 1}, {"role_z", PgArrayRoleAdmin, "member"}} {
+		if err := registry.Register(invalid[0].(string), invalid[1:]...); err == nil {
+			t.Errorf("非法注册应返回错误: %v", invalid)
+		}
+	}
+	if err := registry.Register("other_role", PgArrayRoleAdmin); err == nil || !strings.Contains(err.Error(), "已注册") {
+		t.Errorf("同一 Go 类型不能注册为两个枚举: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 获取插件名称列表
+func pluginNames(plugins []db233.Db233Plugin) []string {
+	names := make([]string, len(plugins))
+	for i, plugin := range plugins {
+		names[i] = plugin.GetPluginName()
+	}
+	return names
+}
+
+// 测试 Db / DbGroup 插件按优先级合并，且不影响其他 Db
+func TestPluginChain_ScopedPriority(t *testing.T) {
+	pm := db233.GetPluginManagerInstance()
+	pm.RemoveAll()
+	defer pm.RemoveAll()
+
+	group := &db233.DbGroup{GroupName: "tenant"}
+	tenantDb := db233.NewDb(nil, 1, group)
+	otherDb := db233.NewDb(nil, 2, nil)
+
+	pm.AddGlobalPluginWithPriority(db233.NewAbstractDb233Plugin("global-metrics"), 100)
+	group.AddPlugin(db233.NewAbstractDb233Plugin("group-audit"), 50)
+	tenantDb.AddPlugin(db233.NewAbstractDb233Plugin("tenant-masking"), 10)
+	tenantDb.AddPlugin(db233.NewAbstractDb233Plugin("db-logging"), 50)
+
+	got := pluginNames(tenantDb.GetEffectivePlugins())
+	expected := []string{"tenant-masking", "group-audit", "db-logging", "global-metrics"}
+	if len(got) != len(expected) {
+		t.Fatalf("插件数量不正确: %v", got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("执行顺序不正确: 期望 %v, 得到 %v", expected, got)
+		}
+	}
+
+	if other := pluginNames(otherDb.GetEffectivePlugins()); len(other) != 1 || other[0] != "global-metrics" {
+		t.Errorf("其他 Db 只应看到全局插件, 得到 %v", other)
+	}
+
+	tenantDb.RemovePlugin("tenant-masking")
+	if names := pluginNames(tenantDb.GetEffectivePlugins()); names[0] != "group-audit" {
+		t.Errorf("移除后顺序不正确: %v", names)
+	}
+}
+
+// 测试同名插件以范围更小的为准
+func TestPluginChain_NarrowerScopeOverrides(t *testing.T) {
+	pm := db233.GetPluginManagerInstance()
+	pm.RemoveAll()
+	defer pm.RemoveAll()
+
+	global := db233.NewAbstractDb233Plugin("masking")
+	local := db233.NewAbstractDb233Plugin("masking")
+	pm.AddGlobalPlugin(global)
+
+	db := db233.NewDb(nil, 1, nil)
+	db.AddPlugin(local, 0)
+
+	plugins := db.GetEffectivePlugins()
+	if len(plugins) != 1 || plugins[0] != db233.Db233Plugin(local) {
+		t.Errorf("Db 级同名插件应覆盖全局插件: %v", plugins)
+	}
+}
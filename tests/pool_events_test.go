@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 记录收到的连接池事件
+type poolEventRecorder struct {
+	mu     sync.Mutex
+	events []db233.PoolEvent
+}
+
+func (r *poolEventRecorder) OnPoolEvent(event db233.PoolEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// 按类型与来源统计事件，清空已记录的事件
+func (r *poolEventRecorder) take() map[string]int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := make(map[string]int64)
+	for _, event := range r.events {
+		counts[string(event.Type)+"/"+event.Source] += event.Count
+	}
+	r.events = nil
+	return counts
+}
+
+// 测试采样差值转换为连接创建、关闭与连接池已满事件
+func TestPoolEvents_Sampling(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	db.DataSource.SetMaxOpenConns(1)
+	recorder := &poolEventRecorder{}
+	sampler := db233.NewPoolEventSampler("main", db, time.Hour).AddListener(recorder)
+	if db.GetPoolEventSampler() != sampler || db.WithPriority(db233.QueryPriorityBatch).GetPoolEventSampler() != sampler {
+		t.Fatal("采样器应挂在 Db 上")
+	}
+
+	sampler.Sample()
+	if counts := recorder.take(); len(counts) != 1 || counts["stats_sampled/sample"] != 0 {
+		t.Fatalf("首次采样只应建立基线: %v", counts)
+	}
+
+	if _, err := db.ExecuteUpdateWithTimeout("UPDATE tx_stock SET count = 1", nil, 0); err != nil {
+		t.Fatalf("执行失败: %v", err)
+	}
+	sampler.Sample()
+	counts := recorder.take()
+	if counts["connection_created/sample"] != 1 || counts["max_reached/sample"] != 1 {
+		t.Fatalf("应发出连接创建与连接池已满事件: %v", counts)
+	}
+
+	db.DataSource.SetMaxIdleConns(0)
+	sampler.Sample()
+	if counts := recorder.take(); counts["connection_closed/sample"] != 1 || counts["max_reached/sample"] != 0 {
+		t.Fatalf("应发出连接关闭事件: %v", counts)
+	}
+}
+
+// 测试获取连接时的等待事件，采样不重复计数
+func TestPoolEvents_WrapperWait(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	db.DataSource.SetMaxOpenConns(1)
+	recorder := &poolEventRecorder{}
+	sampler := db233.NewPoolEventSampler("main", db, time.Hour).AddListener(recorder)
+	sampler.Sample()
+
+	held, err := db.DataSource.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("获取连接失败: %v", err)
+	}
+	done := make(chan error)
+	go func() {
+		_, err := db.ExecuteUpdateWithTimeout("UPDATE tx_stock SET count = 1", nil, 0)
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	held.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("执行失败: %v", err)
+	}
+
+	recorder.mu.Lock()
+	var waited time.Duration
+	for _, event := range recorder.events {
+		if event.Type == db233.PoolEventWaitEnded {
+			waited = event.WaitDuration
+		}
+	}
+	recorder.mu.Unlock()
+	if counts := recorder.take(); counts["wait_started/wrapper"] != 1 || counts["wait_ended/wrapper"] != 1 || waited < 40*time.Millisecond {
+		t.Fatalf("应发出带等待时间的等待事件: %v %v", counts, waited)
+	}
+	sampler.Sample()
+	if counts := recorder.take(); counts["wait_started/sample"] != 0 {
+		t.Errorf("包装层已报告的等待不应重复计数: %v", counts)
+	}
+}
+
+// 测试连接池监控器通过事件自动更新统计
+func TestPoolEvents_ConnectionPoolMonitor(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	db.DataSource.SetMaxOpenConns(4)
+	if db233.NewConnectionPoolMonitor("orphan", nil).StartPoolSampling(time.Hour) != nil {
+		t.Error("未关联 Db 时不应采样")
+	}
+	monitor := db233.NewConnectionPoolMonitor("main", db)
+	sampler := monitor.StartPoolSampling(time.Hour)
+	defer sampler.Stop()
+	time.Sleep(20 * time.Millisecond) // 启动时的首次采样建立基线
+
+	held, err := db.DataSource.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("获取连接失败: %v", err)
+	}
+	defer held.Close()
+	db.ExecuteUpdateWithTimeout("UPDATE tx_stock SET count = 1", nil, 0)
+	sampler.Sample()
+
+	report := monitor.GetReport()
+	expected := map[string]interface{}{
+		"total_connections":   int64(2),
+		"active_connections":  int64(1),
+		"idle_connections":    int64(1),
+		"max_connections":     int64(4),
+		"connections_created": int64(2),
+	}
+	for key, value := range expected {
+		if report[key] != value {
+			t.Errorf("%s 期望 %v, 得到 %v", key, value, report[key])
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type PriorityJob struct {
+	Id int64 `db:"id,primary_key"`
+}
+
+func (e *PriorityJob) TableName() string { return "priority_job" }
+
+func (e *PriorityJob) SerializeBeforeSaveDb() {}
+
+func (e *PriorityJob) DeserializeAfterLoadDb() {}
+
+func waitForWaiting(t *testing.T, gate *db233.PriorityConnectionGate, priority string) {
+	deadline := time.Now().Add(time.Second)
+	for gate.GetWaitStats()[priority]["waiting"] != int64(1) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s 请求未进入排队", priority)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// 测试优先级视图：语句按视图优先级计数，维护模式与原 Db 共享，监控报告包含分类等待时间
+func TestPriorityGate_DbView(t *testing.T) {
+	db := newFakeDriver().newDb(t, db233.EnumDatabaseTypeMySQL).SetPriorityGate(db233.NewPriorityConnectionGate(4))
+	batchDb := db.WithPriority(db233.QueryPriorityBatch)
+	if batchDb.GetPriority() != db233.QueryPriorityBatch || db.GetPriority() != db233.QueryPriorityInteractive {
+		t.Fatal("视图优先级不正确")
+	}
+	if batchDb.WithPriority(db233.QueryPriorityInteractive) != db {
+		t.Error("切回默认优先级应返回原 Db")
+	}
+
+	if err := db233.NewBaseCrudRepository(batchDb).Save(&PriorityJob{Id: 1}); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	if err := db233.NewBaseCrudRepository(db).Save(&PriorityJob{Id: 2}); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+
+	db.EnterMaintenance("升级")
+	if !batchDb.IsInMaintenance() {
+		t.Error("视图应与原 Db 共享维护模式")
+	}
+	db.ExitMaintenance()
+
+	report := db233.NewConnectionPoolMonitor("main", db).GetReport()
+	priorityWait, ok := report["priority_wait"].(map[string]map[string]interface{})
+	if !ok || priorityWait["batch"]["acquired"] != int64(1) || priorityWait["interactive"]["acquired"] != int64(1) {
+		t.Errorf("监控报告应按优先级统计: %v", report["priority_wait"])
+	}
+}
+
+// 测试视图创建后再配置原 Db，视图读取的是原 Db 的当前配置
+func TestPriorityGate_ViewSeesLaterConfig(t *testing.T) {
+	db := newFakeDriver().newDb(t, db233.EnumDatabaseTypeMySQL)
+	batchDb := db.WithPriority(db233.QueryPriorityBatch)
+
+	breaker := db233.NewCircuitBreaker("main", db233.DefaultCircuitBreakerConfig())
+	throttle := db233.NewQueryThrottle("main", db233.ThrottleLimit{})
+	allowlist := db233.NewQueryAllowlist(db233.QueryAllowlistModeStrict)
+	collector := db233.NewDiagnosticsCollector(db)
+	monitor := db233.NewPerformanceMonitor("main", db)
+	policy := db233.NewTimePolicy()
+	db.SetCircuitBreaker(breaker).SetQueryThrottle(throttle).SetTimePolicy(policy)
+	db.SetQueryAllowlist(allowlist)
+	db.SetDiagnosticsCollector(collector)
+	db.SetPerformanceMonitor(monitor)
+
+	if batchDb.GetCircuitBreaker() != breaker || batchDb.GetQueryThrottle() != throttle ||
+		batchDb.GetQueryAllowlist() != allowlist || batchDb.GetDiagnosticsCollector() != collector ||
+		batchDb.GetPerformanceMonitor() != monitor || batchDb.GetTimePolicy() != policy {
+		t.Error("视图应读取原 Db 之后设置的熔断器、限流器、白名单、诊断采集器、监控器与时间策略")
+	}
+
+	// 通过视图设置也作用于原 Db
+	batchDb.SetCircuitBreaker(nil)
+	if db.GetCircuitBreaker() != nil {
+		t.Error("通过视图关闭熔断器应作用于原 Db")
+	}
+}
@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试严格模式拒绝未注册的 SQL（不访问数据库）
+func TestQueryAllowlist_Strict(t *testing.T) {
+	allowlist := db233.NewQueryAllowlist(db233.QueryAllowlistModeStrict)
+	allowlist.RegisterTemplate("SELECT *  FROM player\n WHERE level > ?;")
+
+	if err := allowlist.Check("SELECT * FROM player WHERE level > ?"); err != nil {
+		t.Errorf("已注册模板（空白/分号差异）应放行: %v", err)
+	}
+	err := allowlist.Check("SELECT * FROM player WHERE name = 'x' OR 1=1")
+	if _, ok := err.(*db233.QueryNotAllowedException); !ok {
+		t.Fatalf("未注册 SQL 应返回 QueryNotAllowedException, 得到 %v", err)
+	}
+
+	stats := allowlist.GetStats()
+	if stats["allowed_count"].(int64) != 1 || stats["rejected_count"].(int64) != 1 || stats["mode"] != "strict" {
+		t.Errorf("统计不正确: %v", stats)
+	}
+}
+
+// 测试记录模式与关闭模式放行未注册 SQL
+func TestQueryAllowlist_LogAndOff(t *testing.T) {
+	allowlist := db233.NewQueryAllowlist(db233.QueryAllowlistModeLog)
+	if err := allowlist.Check("DELETE FROM player"); err != nil {
+		t.Errorf("记录模式应放行: %v", err)
+	}
+	if allowlist.GetStats()["rejected_count"].(int64) != 1 {
+		t.Error("记录模式应计入未注册次数")
+	}
+
+	allowlist.SetMode(db233.QueryAllowlistModeOff)
+	if err := allowlist.Check("DROP TABLE player"); err != nil {
+		t.Errorf("关闭模式应放行: %v", err)
+	}
+}
+
+// 测试 Db 入口在严格模式下拒绝执行（拒绝发生在访问数据源之前）
+func TestQueryAllowlist_DbEntrypoints(t *testing.T) {
+	db := db233.NewDb(nil, 1, nil)
+	db.SetQueryAllowlist(db233.NewQueryAllowlist(db233.QueryAllowlistModeStrict))
+
+	if results := db.ExecuteQuery("SELECT 1", [][]interface{}{{}}, nil); results != nil {
+		t.Errorf("未注册查询应返回空结果, 得到 %v", results)
+	}
+	if affected := db.ExecuteOriginalUpdate("DELETE FROM player", [][]interface{}{{}}); affected != 0 {
+		t.Errorf("未注册更新应返回 0, 得到 %d", affected)
+	}
+	if _, err := db.ExecuteQueryWithTimeout("SELECT 1", nil, nil, 0); err == nil {
+		t.Error("未注册查询应返回错误")
+	}
+	if _, err := db.ExecuteQueryE("SELECT 1", [][]interface{}{{}}, nil); !isQueryNotAllowed(err) {
+		t.Errorf("ExecuteQueryE 应返回 QueryNotAllowedException, 得到 %v", err)
+	}
+	if _, err := db.ExecuteOriginalUpdateE("DELETE FROM player", [][]interface{}{{}}); !isQueryNotAllowed(err) {
+		t.Errorf("ExecuteOriginalUpdateE 应返回 QueryNotAllowedException, 得到 %v", err)
+	}
+	stmt := &db233.SqlStatement{SqlList: []string{"DELETE FROM player"}}
+	if _, err := db.ExecuteUpdateByStatementE(stmt); !isQueryNotAllowed(err) {
+		t.Errorf("ExecuteUpdateByStatementE 应返回 QueryNotAllowedException, 得到 %v", err)
+	}
+
+	repo := db233.NewBaseCrudRepository(db)
+	if _, err := repo.FindByCondition("1=1 OR name = ?", []interface{}{"x"}, &TestUser{}); err == nil {
+		t.Error("未注册的 FindByCondition 条件应被拒绝")
+	}
+}
+
+func isQueryNotAllowed(err error) bool {
+	_, ok := err.(*db233.QueryNotAllowedException)
+	return ok
+}
+
+// 测试 ...E 入口返回执行错误：驱动错误包装为 QueryException，库自身的异常原样返回
+func TestQueryAllowlist_ErrorReturningEntrypoints(t *testing.T) {
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		return nil, errors.New("table not found")
+	}
+	db := fake.newDb(t, db233.EnumDatabaseTypeMySQL)
+
+	if _, err := db.ExecuteQueryE("SELECT * FROM player", [][]interface{}{{}}, nil); err == nil {
+		t.Error("查询失败应返回错误")
+	} else if _, ok := err.(*db233.QueryException); !ok {
+		t.Errorf("驱动错误应包装为 QueryException, 得到 %T", err)
+	}
+	if affected, err := db.ExecuteOriginalUpdateE("DELETE FROM player WHERE id = ?", [][]interface{}{{1}, {2}}); err != nil || affected != 2 {
+		t.Errorf("更新应执行: %d, %v", affected, err)
+	}
+
+	if err := db.Drain(context.Background()); err != nil {
+		t.Fatalf("排空失败: %v", err)
+	}
+	if _, err := db.ExecuteQueryE("SELECT * FROM player", [][]interface{}{{}}, nil); err == nil {
+		t.Error("排空后查询应返回错误")
+	} else if _, ok := err.(*db233.ConnectionException); !ok {
+		t.Errorf("排空拒绝应原样返回 ConnectionException, 得到 %T", err)
+	}
+}
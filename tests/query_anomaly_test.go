@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 送入一个窗口的查询并结束窗口
+func observeAnomalyWindow(aggregator *db233.MetricsAggregator, now time.Time, sql string, count int, latency time.Duration) []db233.QueryAnomaly {
+	for i := 0; i < count; i++ {
+		aggregator.ObserveQuery(sql, latency)
+	}
+	return aggregator.EvaluateQueryAnomalies(now)
+}
+
+// 测试延迟偏离基线时告警，恢复后自动解决
+func TestQueryAnomaly_LatencyRegression(t *testing.T) {
+	manager := db233.NewAlertManager("anomaly_test")
+	aggregator := db233.NewMetricsAggregator("anomaly_test")
+	aggregator.EnableQueryAnomalyDetection(manager, db233.QueryAnomalyConfig{Window: time.Hour, MinBaselineWindows: 5})
+
+	now := time.Now().Add(time.Minute)
+	for i := 0; i < 6; i++ {
+		latency := 2*time.Millisecond + time.Duration(i%2)*100*time.Microsecond
+		if anomalies := observeAnomalyWindow(aggregator, now, fmt.Sprintf("SELECT * FROM player WHERE id = %d", i), 10, latency); len(anomalies) != 0 {
+			t.Fatalf("基线窗口不应检测到异常: %+v", anomalies)
+		}
+		now = now.Add(time.Minute)
+	}
+	baselines := aggregator.GetQueryBaselines()
+	if len(baselines) != 1 || baselines[0].Windows != 6 || baselines[0].LatencyMeanMs < 2 || baselines[0].LatencyMeanMs > 2.1 {
+		t.Fatalf("同一指纹应共享基线: %+v", baselines)
+	}
+	key := ""
+	for _, rule := range manager.GetAlertRules() {
+		if rule.Target == baselines[0].Fingerprint && rule.Metric != "" {
+			key = rule.ID
+		}
+	}
+	if key == "" {
+		t.Fatal("基线学习完成后应添加告警规则")
+	}
+
+	anomalies := observeAnomalyWindow(aggregator, now, "SELECT * FROM player WHERE id = 99", 10, 20*time.Millisecond)
+	if len(anomalies) != 1 || anomalies[0].Kind != "latency" || anomalies[0].Current != 20 {
+		t.Fatalf("应检测到延迟回归: %+v", anomalies)
+	}
+	latencyRule := "sql_anomaly_latency_" + key[len(key)-8:]
+	if !activeAlertRuleIds(manager)[latencyRule] {
+		t.Fatalf("应触发延迟异常告警: %v", activeAlertRuleIds(manager))
+	}
+	if baseline := aggregator.GetQueryBaselines()[0]; baseline.LatencyMeanMs > 2.1 {
+		t.Errorf("异常窗口不应计入基线: %+v", baseline)
+	}
+
+	now = now.Add(time.Minute)
+	if anomalies := observeAnomalyWindow(aggregator, now, "SELECT * FROM player WHERE id = 1", 10, 2*time.Millisecond); len(anomalies) != 0 {
+		t.Fatalf("恢复后不应检测到异常: %+v", anomalies)
+	}
+	if activeAlertRuleIds(manager)[latencyRule] {
+		t.Error("恢复后告警应自动解决")
+	}
+	if status := aggregator.GetStatus(); status["anomaly_fingerprints"] != 1 {
+		t.Errorf("状态应包含指纹数: %v", status)
+	}
+}
+
+// 测试 QPS 骤降告警，未启用时忽略
+func TestQueryAnomaly_QpsDrop(t *testing.T) {
+	manager := db233.NewAlertManager("anomaly_qps_test")
+	aggregator := db233.NewMetricsAggregator("anomaly_qps_test")
+	aggregator.ObserveQuery("SELECT 1", time.Millisecond)
+	if len(aggregator.GetQueryBaselines()) != 0 || len(aggregator.EvaluateQueryAnomalies(time.Now())) != 0 {
+		t.Fatal("未启用时不应跟踪查询")
+	}
+	aggregator.EnableQueryAnomalyDetection(manager, db233.QueryAnomalyConfig{Window: time.Hour, MinBaselineWindows: 3, Severity: db233.Critical})
+
+	now := time.Now().Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		observeAnomalyWindow(aggregator, now, "UPDATE guild SET exp = exp + 1 WHERE id = 7", 60, time.Millisecond)
+		now = now.Add(time.Minute)
+	}
+	anomalies := aggregator.EvaluateQueryAnomalies(now)
+	if len(anomalies) != 1 || anomalies[0].Kind != "qps" || anomalies[0].Current != 0 || anomalies[0].Baseline < 0.99 {
+		t.Fatalf("应检测到 QPS 骤降: %+v", anomalies)
+	}
+	alerts := manager.GetActiveAlerts()
+	if len(alerts) != 1 || alerts[0].Severity != db233.Critical || alerts[0].Target != "update guild set exp = exp + ? where id = ?" {
+		t.Errorf("告警应带指纹与配置的严重程度: %+v", alerts)
+	}
+	if len(aggregator.GetQueryAnomalies()) != 1 {
+		t.Error("应保留最近窗口的异常")
+	}
+}
+
+// 测试插件把执行的语句送入异常检测
+func TestQueryAnomaly_Plugin(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	aggregator := db233.NewMetricsAggregator("anomaly_plugin_test")
+	aggregator.EnableQueryAnomalyDetection(nil, db233.QueryAnomalyConfig{Window: time.Hour})
+	db.AddPlugin(db233.NewQueryAnomalyPlugin(aggregator), 0)
+
+	db.ExecuteUpdateWithTimeout("UPDATE tx_stock SET count = 1", nil, 0)
+	db.ExecuteUpdateWithTimeout("UPDATE tx_stock SET count = 2", nil, 0)
+	aggregator.EvaluateQueryAnomalies(time.Now().Add(time.Second))
+	baselines := aggregator.GetQueryBaselines()
+	if len(baselines) != 1 || baselines[0].Windows != 1 || baselines[0].QpsMean <= 0 {
+		t.Errorf("插件应记录执行的语句: %+v", baselines)
+	}
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 模拟一次经过插件的查询，返回是否命中缓存
+func runCachedQuery(plugin *db233.QueryCachePlugin, sql string, params []interface{}, result []interface{}) ([]interface{}, bool) {
+	context := db233.NewExecuteSqlContext(sql, params)
+	context.ReturnType = TestUser{}
+	plugin.PreExecuteSql(context)
+	if context.IsShortCircuited() {
+		plugin.PostExecuteSql(context)
+		return context.Result.([]interface{}), true
+	}
+	context.SetResult(result, len(result))
+	plugin.PostExecuteSql(context)
+	return result, false
+}
+
+// 模拟一次经过插件的写语句
+func runWrite(plugin *db233.QueryCachePlugin, sql string) {
+	context := db233.NewExecuteSqlContext(sql, nil)
+	plugin.PreExecuteSql(context)
+	context.SetResult(nil, 1)
+	plugin.PostExecuteSql(context)
+}
+
+// 测试缓存命中与按表失效（不访问数据库）
+func TestQueryCachePlugin_HitAndInvalidate(t *testing.T) {
+	plugin := db233.NewQueryCachePlugin(time.Minute, 100)
+	query := "SELECT * FROM `game`.`player` WHERE id = ?"
+	rows := []interface{}{TestUser{ID: 1, Username: "alice"}}
+
+	if _, hit := runCachedQuery(plugin, query, []interface{}{1}, rows); hit {
+		t.Fatal("首次查询不应命中")
+	}
+	cached, hit := runCachedQuery(plugin, query, []interface{}{1}, nil)
+	if !hit || len(cached) != 1 || cached[0].(TestUser).Username != "alice" {
+		t.Fatalf("第二次查询应命中缓存, hit=%v, result=%v", hit, cached)
+	}
+	if _, hit := runCachedQuery(plugin, query, []interface{}{2}, rows); hit {
+		t.Error("不同参数不应命中")
+	}
+
+	runWrite(plugin, "UPDATE player SET username = ? WHERE id = ?")
+	if _, hit := runCachedQuery(plugin, query, []interface{}{1}, rows); hit {
+		t.Error("写入 player 后缓存应失效")
+	}
+
+	runWrite(plugin, "INSERT INTO orders (id) VALUES (?)")
+	if _, hit := runCachedQuery(plugin, query, []interface{}{1}, rows); !hit {
+		t.Error("写入其他表不应使 player 缓存失效")
+	}
+
+	metrics := plugin.GetMetrics()
+	if metrics["hits"].(int64) != 2 || metrics["misses"].(int64) != 3 || metrics["invalidations"].(int64) < 1 {
+		t.Errorf("指标不正确: %v", metrics)
+	}
+}
+
+// 测试 TTL 过期、加锁读不缓存、容量淘汰
+func TestQueryCachePlugin_TTLAndEviction(t *testing.T) {
+	plugin := db233.NewQueryCachePlugin(20*time.Millisecond, 1)
+	rows := []interface{}{TestUser{ID: 1}}
+
+	runCachedQuery(plugin, "SELECT * FROM player", nil, rows)
+	time.Sleep(30 * time.Millisecond)
+	if _, hit := runCachedQuery(plugin, "SELECT * FROM player", nil, rows); hit {
+		t.Error("过期条目不应命中")
+	}
+
+	runCachedQuery(plugin, "SELECT * FROM player WHERE id = 1 FOR UPDATE", nil, rows)
+	if _, hit := runCachedQuery(plugin, "SELECT * FROM player WHERE id = 1 FOR UPDATE", nil, rows); hit {
+		t.Error("加锁读不应缓存")
+	}
+
+	runCachedQuery(plugin, "SELECT * FROM orders", nil, rows)
+	if plugin.GetMetrics()["entries"].(int) != 1 || plugin.GetMetrics()["evictions"].(int64) != 1 {
+		t.Errorf("超过容量应淘汰: %v", plugin.GetMetrics())
+	}
+}
+
+// 测试指标接入 MetricsCollector
+func TestQueryCachePlugin_MetricsSource(t *testing.T) {
+	plugin := db233.NewQueryCachePlugin(time.Minute, 0)
+	runCachedQuery(plugin, "SELECT * FROM player", nil, []interface{}{TestUser{ID: 1}})
+	runCachedQuery(plugin, "SELECT * FROM player", nil, nil)
+
+	var source db233.MetricsDataSource = plugin
+	if source.GetName() != "query-cache-plugin" || plugin.GetHitRate() != 0.5 {
+		t.Errorf("指标数据源不正确: name=%s, hitRate=%v", source.GetName(), plugin.GetHitRate())
+	}
+}
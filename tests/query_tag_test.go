@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 记录插件看到的 SQL 与查询标签
+type queryTagRecordingPlugin struct {
+	*db233.AbstractDb233Plugin
+	sqls []string
+	tags []interface{}
+}
+
+func (p *queryTagRecordingPlugin) PreExecuteSql(context *db233.ExecuteSqlContext) {
+	p.sqls = append(p.sqls, context.Sql)
+	p.tags = append(p.tags, context.GetAttribute("query_tag"))
+}
+
+// 测试标签作为注释发往数据库，插件看到原语句
+func TestQueryTag_SqlComment(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	plugin := &queryTagRecordingPlugin{AbstractDb233Plugin: db233.NewAbstractDb233Plugin("query_tag_recording")}
+	db.AddPlugin(plugin, 0)
+
+	ctx := db233.WithQueryTag(context.Background(), "leaderboard.refresh")
+	if tag, ok := db233.QueryTagFromContext(ctx); !ok || tag != "leaderboard.refresh" {
+		t.Fatalf("context 中应携带标签: %s", tag)
+	}
+	tagged := db.WithContext(ctx)
+	if tagged.GetQueryTag() != "leaderboard.refresh" || db.GetQueryTag() != "" {
+		t.Fatal("WithContext 应返回带标签的视图，原 Db 不受影响")
+	}
+	if _, err := tagged.ExecuteUpdateWithTimeout("UPDATE rank SET score = 1;", nil, 0); err != nil {
+		t.Fatalf("执行失败: %v", err)
+	}
+	db.WithQueryTag("evil */ DROP TABLE rank; /*").ExecuteUpdateWithTimeout("UPDATE rank SET score = 2", nil, 0)
+	db.ExecuteUpdateWithTimeout("UPDATE rank SET score = 3", nil, 0)
+
+	statements := txContextStatements()
+	expected := []string{
+		"UPDATE rank SET score = 1 /* db233_tag='leaderboard.refresh' */;",
+		"UPDATE rank SET score = 2 /* db233_tag='evil__/_DROP_TABLE_rank__/_' */",
+		"UPDATE rank SET score = 3",
+	}
+	for i, query := range expected {
+		if statements[i].query != query {
+			t.Errorf("第 %d 条语句不正确: %s", i, statements[i].query)
+		}
+	}
+	if plugin.sqls[0] != "UPDATE rank SET score = 1;" || plugin.tags[0] != "leaderboard.refresh" || plugin.tags[2] != nil {
+		t.Errorf("插件应看到原语句与标签属性: %v %v", plugin.sqls, plugin.tags)
+	}
+
+	db.SetQueryTagComment(false)
+	tagged.ExecuteUpdateWithTimeout("UPDATE rank SET score = 4", nil, 0)
+	if last := txContextStatements()[3].query; strings.Contains(last, "db233_tag") {
+		t.Errorf("关闭后不应追加注释: %s", last)
+	}
+}
+
+// 测试标签随事务 context 传递到存储库
+func TestQueryTag_Repository(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	repo := db233.NewBaseCrudRepository(db)
+
+	ctx := db233.WithQueryTag(context.Background(), "order.create")
+	err := db233.RunInTransaction(ctx, db, func(ctx context.Context) error {
+		return repo.WithContext(ctx).Save(&TxOrder{Id: 1, Amount: 100})
+	})
+	if err != nil {
+		t.Fatalf("事务执行失败: %v", err)
+	}
+	statements := txContextStatements()
+	if len(statements) != 1 || !statements[0].inTx || !strings.HasSuffix(statements[0].query, "/* db233_tag='order.create' */") {
+		t.Errorf("事务内的语句应带标签: %+v", statements)
+	}
+}
+
+// 测试性能监控器按标签统计
+func TestQueryTag_PerformanceMonitor(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	monitor := db233.NewPerformanceMonitor("query_tag", db)
+	db.SetPerformanceMonitor(monitor)
+
+	refresh := db.WithQueryTag("leaderboard.refresh")
+	for i := 0; i < 3; i++ {
+		refresh.ExecuteUpdateWithTimeout("UPDATE rank SET score = 1", nil, 0)
+	}
+	db.ExecuteUpdateWithTimeout("UPDATE rank SET score = 1", nil, 0)
+	monitor.RecordTaggedQuery("order.create", "INSERT INTO tx_order", 2*time.Second, false, errors.New("死锁"))
+	monitor.RecordTaggedQuery("order.create", "INSERT INTO tx_order", 10*time.Millisecond, true, nil)
+
+	stats := monitor.GetQueryTagStats()
+	if len(stats) != 2 || stats[0].Tag != "order.create" || stats[1].Tag != "leaderboard.refresh" {
+		t.Fatalf("应按总耗时降序列出标签: %+v", stats)
+	}
+	if stats[0].QueryCount != 2 || stats[0].ErrorCount != 1 || stats[0].ErrorRate != 0.5 || stats[0].SlowCount != 1 || stats[0].MaxTime != 2*time.Second {
+		t.Errorf("标签统计不正确: %+v", stats[0])
+	}
+	if stats[1].QueryCount != 3 || stats[1].ErrorCount != 0 {
+		t.Errorf("自动记录的标签统计不正确: %+v", stats[1])
+	}
+	if report := monitor.GetDetailedReport(); report["total_queries"] != int64(2) || report["query_tags"] == nil {
+		t.Errorf("RecordTaggedQuery 应计入总体统计，报告应包含标签: %v", report["total_queries"])
+	}
+	if metrics := monitor.GetMetrics(); metrics["tag.leaderboard.refresh.query_count"] != int64(3) {
+		t.Errorf("指标应包含按标签的数据: %v", metrics)
+	}
+
+	monitor.Reset()
+	if len(monitor.GetQueryTagStats()) != 0 {
+		t.Error("重置后标签统计应清空")
+	}
+}
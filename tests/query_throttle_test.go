@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type ThrottleReport struct {
+	Id int64 `db:"id,primary_key"`
+}
+
+func (e *ThrottleReport) TableName() string { return "throttle_report" }
+
+func (e *ThrottleReport) SerializeBeforeSaveDb() {}
+
+func (e *ThrottleReport) DeserializeAfterLoadDb() {}
+
+type ThrottleOrder struct {
+	Id int64 `db:"id,primary_key"`
+}
+
+func (e *ThrottleOrder) TableName() string { return "throttle_order" }
+
+func (e *ThrottleOrder) SerializeBeforeSaveDb() {}
+
+func (e *ThrottleOrder) DeserializeAfterLoadDb() {}
+
+// 测试报表类别占满后被丢弃，在线写入不受影响
+func TestQueryThrottle_ClassIsolation(t *testing.T) {
+	// 报表表的写入阻塞到 release 关闭
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	fake := newFakeDriver()
+	fake.onExec = func(stmt fakeStatement) (driver.Result, error) {
+		if strings.Contains(stmt.query, "throttle_report") {
+			started <- struct{}{}
+			<-release
+		}
+		return driver.RowsAffected(1), nil
+	}
+
+	throttle := db233.NewQueryThrottle("main", db233.ThrottleLimit{MaxConcurrent: 10})
+	throttle.AddClass("report", func(sql string) bool { return strings.Contains(sql, "throttle_report") },
+		db233.ThrottleLimit{MaxConcurrent: 1, Policy: db233.ThrottlePolicyShed})
+	repo := db233.NewBaseCrudRepository(fake.newDb(t, db233.EnumDatabaseTypeMySQL).SetQueryThrottle(throttle))
+
+	done := make(chan error, 1)
+	go func() { done <- repo.Save(&ThrottleReport{Id: 1}) }()
+	<-started
+
+	err := repo.Save(&ThrottleReport{Id: 2})
+	var throttled *db233.QueryThrottledException
+	if !errors.As(err, &throttled) || throttled.Class != "report" {
+		t.Fatalf("报表类别并发已满应被拒绝: %v", err)
+	}
+	if err := repo.Save(&ThrottleOrder{Id: 1}); err != nil {
+		t.Errorf("其他语句不应受报表类别限制: %v", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("第一条报表写入应成功: %v", err)
+	}
+	status := throttle.GetStatus()
+	report := status["classes"].(map[string]interface{})["report"].(map[string]interface{})
+	if report["admitted"] != int64(1) || report["rejected"] != int64(1) {
+		t.Errorf("类别统计不正确: %v", report)
+	}
+}
+
+// 测试排队超时、排队等到许可、QPS 与指纹限制
+func TestQueryThrottle_QueueQpsAndFingerprint(t *testing.T) {
+	fingerprint := db233.SqlFingerprint("SELECT *  FROM a WHERE id = 5 AND name = 'x''y' AND k IN (1, 2,3);")
+	if fingerprint != "select * from a where id = ? and name = ? and k in (?)" {
+		t.Errorf("SQL 指纹不正确: %s", fingerprint)
+	}
+
+	throttle := db233.NewQueryThrottle("queue", db233.ThrottleLimit{MaxConcurrent: 1, QueueTimeout: 30 * time.Millisecond})
+	hold := make(chan struct{})
+	started := make(chan struct{})
+	go throttle.Execute("SELECT 1", func() error { close(started); <-hold; return nil })
+	<-started
+
+	if err := throttle.Execute("SELECT 2", func() error { return nil }); err == nil {
+		t.Error("排队超时应被拒绝")
+	}
+	go func() { time.Sleep(10 * time.Millisecond); close(hold) }()
+	if err := throttle.Execute("SELECT 3", func() error { return nil }); err != nil {
+		t.Errorf("超时前释放许可应放行: %v", err)
+	}
+
+	qps := db233.NewQueryThrottle("qps", db233.ThrottleLimit{})
+	qps.SetFingerprintLimit("SELECT * FROM player WHERE id = ?", db233.ThrottleLimit{MaxQPS: 3, Policy: db233.ThrottlePolicyShed})
+	admitted := 0
+	for i := 0; i < 5; i++ {
+		if qps.Execute("SELECT * FROM player WHERE id = "+string(rune('1'+i)), func() error { return nil }) == nil {
+			admitted++
+		}
+	}
+	if admitted != 3 {
+		t.Errorf("同一指纹每秒应只放行 3 条: %d", admitted)
+	}
+	if err := qps.Execute("SELECT * FROM guild WHERE id = 1", func() error { return nil }); err != nil {
+		t.Errorf("其他指纹不受限制: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 打开名为 name 的主库或从库，假驱动按数据源名称区分
+func openRywDb(t *testing.T, fake *fakeDriver, name string, dbId int) *db233.Db {
+	return db233.NewDbWithType(fake.open(t, name), dbId, nil, db233.EnumDatabaseTypeMySQL)
+}
+
+// 测试写入后的窗口内读主库，窗口过后恢复读从库
+func TestReadYourWrites_Window(t *testing.T) {
+	primary := db233.NewDb(nil, 0, nil)
+	replica := db233.NewDb(nil, 1, nil)
+	router := db233.NewReadWriteRouter(primary)
+	router.AddReplica("r1", replica)
+	router.SetReadYourWrites(db233.ReadYourWritesOptions{Window: 100 * time.Millisecond})
+
+	session := router.NewSession()
+	if session.ForRead() != replica {
+		t.Error("没有写入时应读从库")
+	}
+	if session.ForWrite() != primary {
+		t.Error("写请求应路由到主库")
+	}
+	if session.ForRead() != primary {
+		t.Error("写入后的窗口内应读主库")
+	}
+	if other := router.NewSession(); other.ForRead() != replica {
+		t.Error("其他会话不受影响，应读从库")
+	}
+
+	// 令牌跨请求传递
+	token, err := db233.ParseConsistencyToken(session.Token().String())
+	if err != nil || token.IsZero() {
+		t.Fatalf("令牌应可序列化与解析: %+v %v", token, err)
+	}
+	if router.ResumeSession(token).ForRead() != primary {
+		t.Error("恢复的会话在窗口内应读主库")
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if session.ForRead() != replica {
+		t.Error("窗口过后应恢复读从库")
+	}
+	if _, err := db233.ParseConsistencyToken("abc;1-2-3"); err == nil {
+		t.Error("无效令牌应返回错误")
+	}
+}
+
+// 测试 GTID 模式：已同步的从库可读，均未同步时回退到主库
+func TestReadYourWrites_WaitForGTID(t *testing.T) {
+	const gtid = "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-23"
+	var mu sync.Mutex
+	synced := map[string]bool{"r2": true}
+	// 主库返回 GTID 集合，从库按同步状态返回 WAIT_FOR_EXECUTED_GTID_SET 的结果
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		if strings.Contains(stmt.query, "gtid_executed") {
+			return fakeRowsOf([]string{"result"}, []driver.Value{gtid}), nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if synced[stmt.conn] {
+			return fakeRowsOf([]string{"result"}, []driver.Value{int64(0)}), nil
+		}
+		return fakeRowsOf([]string{"result"}, []driver.Value{int64(1)}), nil
+	}
+
+	primary := openRywDb(t, fake, "primary", 0)
+	replica1 := openRywDb(t, fake, "r1", 1)
+	replica2 := openRywDb(t, fake, "r2", 2)
+	router := db233.NewReadWriteRouter(primary)
+	router.AddReplica("r1", replica1)
+	router.AddReplica("r2", replica2)
+	router.SetReadYourWrites(db233.ReadYourWritesOptions{Window: time.Minute, WaitForGTID: true, GTIDWaitTimeout: 50 * time.Millisecond})
+
+	session := router.NewSession()
+	session.MarkWrite()
+	if session.ForRead() != replica2 {
+		t.Error("应读已同步 GTID 的从库")
+	}
+	if session.Token().GTIDSet != gtid {
+		t.Errorf("令牌应记录主库 GTID: %+v", session.Token())
+	}
+	var waitArgs [][]driver.Value
+	for _, stmt := range fake.recorded() {
+		if !strings.Contains(stmt.query, "gtid_executed") {
+			waitArgs = append(waitArgs, stmt.args)
+		}
+	}
+	if len(waitArgs) == 0 || waitArgs[0][0] != gtid {
+		t.Errorf("应在从库上等待主库 GTID: %#v", waitArgs)
+	}
+	mu.Lock()
+	synced = map[string]bool{}
+	mu.Unlock()
+
+	if session.ForRead() != primary {
+		t.Error("从库均未同步时应回退到主库")
+	}
+}
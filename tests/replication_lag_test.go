@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 可调的复制延迟探测
+type fakeLagProbe struct {
+	lagMs  int64
+	failed int32
+}
+
+func (p *fakeLagProbe) set(lag time.Duration) {
+	atomic.StoreInt64(&p.lagMs, lag.Milliseconds())
+	atomic.StoreInt32(&p.failed, 0)
+}
+
+func (p *fakeLagProbe) fail() {
+	atomic.StoreInt32(&p.failed, 1)
+}
+
+func (p *fakeLagProbe) probe(ctx context.Context) (time.Duration, error) {
+	if atomic.LoadInt32(&p.failed) == 1 {
+		return 0, errors.New("复制未运行")
+	}
+	return time.Duration(atomic.LoadInt64(&p.lagMs)) * time.Millisecond, nil
+}
+
+// 测试复制延迟检查结果
+func TestHealthChecker_CheckReplicationLag(t *testing.T) {
+	db := db233.NewDb(nil, 1, nil)
+	probe := &fakeLagProbe{}
+	checker := db233.NewHealthChecker(db)
+	checker.SetReplicationLagProbe(probe.probe)
+
+	probe.set(time.Second)
+	if result := checker.CheckReplicationLag(5 * time.Second); !result.Healthy || result.ReplicationLag != time.Second {
+		t.Errorf("延迟低于阈值应健康: %+v", result)
+	}
+
+	probe.set(10 * time.Second)
+	if result := checker.CheckReplicationLag(5 * time.Second); result.Healthy || result.Error != nil || result.ReplicationLag != 10*time.Second {
+		t.Errorf("延迟超过阈值应不健康且记录延迟: %+v", result)
+	}
+
+	probe.fail()
+	if result := checker.CheckReplicationLag(5 * time.Second); result.Healthy || result.Error == nil {
+		t.Errorf("探测失败应不健康并返回错误: %+v", result)
+	}
+
+	db.EnterMaintenance("升级")
+	if result := checker.CheckReplicationLag(5 * time.Second); !result.Maintenance {
+		t.Errorf("维护中应返回维护状态: %+v", result)
+	}
+}
+
+// 测试延迟过高的从库移出读轮换，恢复后重新加入
+func TestReadWriteRouter_LagRotation(t *testing.T) {
+	primary := db233.NewDb(nil, 0, nil)
+	replica1 := db233.NewDb(nil, 1, nil)
+	replica2 := db233.NewDb(nil, 2, nil)
+
+	probe1 := &fakeLagProbe{}
+	probe2 := &fakeLagProbe{}
+	router := db233.NewReadWriteRouter(primary)
+	router.AddReplica("r1", replica1).SetReplicationLagProbe(probe1.probe)
+	router.AddReplica("r2", replica2).SetReplicationLagProbe(probe2.probe)
+
+	if router.ForWrite() != primary {
+		t.Error("写请求应路由到主库")
+	}
+	seen := map[*db233.Db]bool{}
+	for i := 0; i < 4; i++ {
+		seen[router.ForRead()] = true
+	}
+	if !seen[replica1] || !seen[replica2] || seen[primary] {
+		t.Error("读请求应在从库间轮询")
+	}
+
+	options := db233.ReplicationLagMonitorOptions{MaxLag: 4 * time.Second}
+
+	// r1 延迟超过阈值被移出
+	probe1.set(6 * time.Second)
+	probe2.set(time.Second)
+	statuses := router.CheckReplicas(options)
+	if statuses[0].Name != "r1" || statuses[0].InRotation || statuses[0].Lag != 6*time.Second {
+		t.Errorf("r1 应移出轮换: %+v", statuses[0])
+	}
+	for i := 0; i < 4; i++ {
+		if db := router.ForRead(); db != replica2 {
+			t.Fatalf("读请求只应路由到 r2")
+		}
+	}
+
+	// 延迟回落但仍高于恢复阈值（默认 MaxLag/2），保持移出
+	probe1.set(3 * time.Second)
+	if statuses := router.CheckReplicas(options); statuses[0].InRotation {
+		t.Error("延迟未低于恢复阈值时不应重新加入")
+	}
+
+	probe1.set(time.Second)
+	if statuses := router.CheckReplicas(options); !statuses[0].InRotation {
+		t.Error("延迟恢复后应重新加入轮换")
+	}
+
+	// 探测失败与维护中都移出，全部不可用时回退主库
+	probe1.fail()
+	replica2.EnterMaintenance("升级")
+	statuses = router.CheckReplicas(options)
+	if statuses[0].InRotation || statuses[1].InRotation {
+		t.Errorf("探测失败或维护中的从库应移出轮换: %+v", statuses)
+	}
+	if router.ForRead() != primary {
+		t.Error("没有可用从库时读请求应回退到主库")
+	}
+
+	if err := router.SetReplicaInRotation("missing", true); err == nil {
+		t.Error("设置不存在的从库应返回错误")
+	}
+	router.RemoveReplica("r2")
+	if len(router.GetReplicaStatus()) != 1 {
+		t.Error("移除后应只剩 1 个从库")
+	}
+}
+
+// 测试复制延迟上报到 AlertManager
+func TestReadWriteRouter_LagAlerts(t *testing.T) {
+	probe := &fakeLagProbe{}
+	router := db233.NewReadWriteRouter(db233.NewDb(nil, 0, nil))
+	router.AddReplica("r1", db233.NewDb(nil, 1, nil)).SetReplicationLagProbe(probe.probe)
+
+	alertManager := db233.NewAlertManager("replication_lag_test")
+	alertManager.AddAlertRule(db233.AlertRule{
+		ID:        "replica_lag",
+		Name:      "从库延迟",
+		Metric:    db233.ReplicationLagMetricName("r1"),
+		Condition: db233.GreaterThan,
+		Threshold: 5.0,
+		Severity:  db233.Warning,
+		Enabled:   true,
+	})
+	alertManager.AddAlertRule(db233.AlertRule{
+		ID:        "max_lag",
+		Name:      "最大延迟",
+		Metric:    db233.ReplicationLagMetric,
+		Condition: db233.GreaterThan,
+		Threshold: 5.0,
+		Severity:  db233.Critical,
+		Enabled:   true,
+	})
+	options := db233.ReplicationLagMonitorOptions{MaxLag: 5 * time.Second, AlertManager: alertManager}
+
+	probe.set(2 * time.Second)
+	router.CheckReplicas(options)
+	if len(alertManager.GetActiveAlerts()) != 0 {
+		t.Error("延迟正常时不应告警")
+	}
+
+	probe.set(8 * time.Second)
+	router.CheckReplicas(options)
+	if active := alertManager.GetActiveAlerts(); len(active) != 2 {
+		t.Errorf("延迟过高应触发从库与最大延迟 2 个告警, 得到 %d", len(active))
+	}
+
+	probe.set(time.Second)
+	router.CheckReplicas(options)
+	if len(alertManager.GetActiveAlerts()) != 0 {
+		t.Error("延迟恢复后告警应解决")
+	}
+}
+
+// 测试后台延迟监控
+func TestReadWriteRouter_LagMonitor(t *testing.T) {
+	probe := &fakeLagProbe{}
+	probe.set(time.Minute)
+	router := db233.NewReadWriteRouter(db233.NewDb(nil, 0, nil))
+	router.AddReplica("r1", db233.NewDb(nil, 1, nil)).SetReplicationLagProbe(probe.probe)
+
+	router.StartLagMonitor(db233.ReplicationLagMonitorOptions{MaxLag: time.Second, Interval: 10 * time.Millisecond})
+	defer router.StopLagMonitor()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for router.GetReplicaStatus()[0].InRotation {
+		if time.Now().After(deadline) {
+			t.Fatal("监控应将延迟过高的从库移出轮换")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
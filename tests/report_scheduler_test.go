@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试 cron 表达式解析与下次触发时间
+func TestCronSchedule_Next(t *testing.T) {
+	base := time.Date(2026, 1, 1, 10, 7, 30, 0, time.UTC) // 周四
+
+	testCases := []struct {
+		expression string
+		expected   time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 1, 1, 10, 15, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC)},
+		{"30 8 * * 1", time.Date(2026, 1, 5, 8, 30, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"0 0 1 2 *", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 10m", base.Add(10 * time.Minute)},
+	}
+
+	for _, tc := range testCases {
+		schedule, err := db233.ParseCronExpression(tc.expression)
+		if err != nil {
+			t.Fatalf("解析 %s 失败: %v", tc.expression, err)
+		}
+		if next := schedule.Next(base); !next.Equal(tc.expected) {
+			t.Errorf("%s: 期望 %v, 得到 %v", tc.expression, tc.expected, next)
+		}
+	}
+
+	for _, invalid := range []string{"* * *", "60 * * * *", "*/0 * * * *", "@every abc"} {
+		if _, err := db233.ParseCronExpression(invalid); err == nil {
+			t.Errorf("无效表达式应返回错误: %s", invalid)
+		}
+	}
+}
+
+// 测试目录投递与保留策略
+func TestReportScheduler_DirectorySinkRetention(t *testing.T) {
+	dir := t.TempDir()
+
+	// 预置两份旧报告
+	for i, name := range []string{"daily_20250101_000000.json", "daily_20250102_000000.json"} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte("{}"), 0644)
+		old := time.Now().Add(-time.Duration(48-i) * time.Hour)
+		os.Chtimes(path, old, old)
+	}
+
+	scheduler := db233.NewReportScheduler(db233.NewMonitoringReportGenerator("test"))
+	sink := db233.NewDirectoryReportSink(dir, db233.ReportRetentionPolicy{MaxReports: 2})
+	if err := scheduler.AddSchedule("daily", "0 0 * * *", "json", sink); err != nil {
+		t.Fatalf("添加任务失败: %v", err)
+	}
+	if err := scheduler.RunNow("daily"); err != nil {
+		t.Fatalf("运行任务失败: %v", err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Fatalf("保留策略应只保留 2 份报告, 得到 %d", len(entries))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "daily_20250101_000000.json")); !os.IsNotExist(err) {
+		t.Error("最旧的报告应被删除")
+	}
+}
+
+// 测试 HTTP 投递
+func TestReportScheduler_HttpSink(t *testing.T) {
+	var received []byte
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		contentType = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	scheduler := db233.NewReportScheduler(db233.NewMonitoringReportGenerator("test"))
+	scheduler.AddSchedule("hourly", "@hourly", "text", db233.NewHttpPostReportSink(server.URL, nil))
+	if err := scheduler.RunNow("hourly"); err != nil {
+		t.Fatalf("运行任务失败: %v", err)
+	}
+	if len(received) == 0 || contentType != "text/plain; charset=utf-8" {
+		t.Errorf("HTTP 投递内容不正确: %q, %s", received, contentType)
+	}
+
+	if err := scheduler.AddSchedule("bad", "@hourly", "xml", db233.NewHttpPostReportSink(server.URL, nil)); err == nil {
+		t.Error("不支持的格式应返回错误")
+	}
+}
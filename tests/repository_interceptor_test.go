@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type InterceptedItem struct {
+	Id   int64  `db:"id,primary_key"`
+	Name string `db:"name"`
+}
+
+func (e *InterceptedItem) TableName() string { return "intercepted_item" }
+
+func (e *InterceptedItem) SerializeBeforeSaveDb() {}
+
+func (e *InterceptedItem) DeserializeAfterLoadDb() {}
+
+// 记录调用的拦截器
+type recordingInterceptor struct {
+	name       string
+	calls      *[]string
+	vetoSave   bool
+	vetoDelete bool
+}
+
+func (i *recordingInterceptor) GetInterceptorName() string { return i.name }
+
+func (i *recordingInterceptor) BeforeSaveEntity(ctx context.Context, info *db233.EntityOperationInfo, entity db233.IDbEntity) error {
+	*i.calls = append(*i.calls, fmt.Sprintf("%s:before_save:%s", i.name, info.TableName))
+	if i.vetoSave {
+		return errors.New("禁止写入")
+	}
+	return nil
+}
+
+func (i *recordingInterceptor) AfterSaveEntity(ctx context.Context, info *db233.EntityOperationInfo, entity db233.IDbEntity) {
+	*i.calls = append(*i.calls, fmt.Sprintf("%s:after_save:%s", i.name, info.TableName))
+}
+
+func (i *recordingInterceptor) AfterFindEntity(ctx context.Context, info *db233.EntityOperationInfo, entity db233.IDbEntity) {
+	*i.calls = append(*i.calls, fmt.Sprintf("%s:after_find:%s", i.name, entity.(*InterceptedItem).Name))
+}
+
+func (i *recordingInterceptor) OnDelete(ctx context.Context, info *db233.EntityOperationInfo, entity db233.IDbEntity) error {
+	*i.calls = append(*i.calls, fmt.Sprintf("%s:delete:%v:%s", i.name, info.Id, entity.(*InterceptedItem).Name))
+	if i.vetoDelete {
+		return errors.New("禁止删除")
+	}
+	return nil
+}
+
+// 只实现查询拦截的统计拦截器
+type findCountingInterceptor struct{ count int }
+
+func (i *findCountingInterceptor) GetInterceptorName() string { return "find_counter" }
+
+func (i *findCountingInterceptor) AfterFindEntity(ctx context.Context, info *db233.EntityOperationInfo, entity db233.IDbEntity) {
+	if info.Operation == db233.EntityOperationFind && info.EntityType.Name() == "InterceptedItem" {
+		i.count++
+	}
+}
+
+// 记录语句，查询时返回一行 (7, "sword") 的存储库
+func newInterceptorRepo(t *testing.T) (*db233.BaseCrudRepository, *fakeDriver) {
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		return fakeRowsOf([]string{"id", "name"}, []driver.Value{int64(7), "sword"}), nil
+	}
+	return db233.NewBaseCrudRepository(fake.newDb(t, db233.EnumDatabaseTypeMySQL)), fake
+}
+
+// 测试全局与存储库拦截器的调用顺序及收到的元数据
+func TestRepositoryInterceptor_Order(t *testing.T) {
+	var calls []string
+	manager := db233.GetRepositoryInterceptorManagerInstance()
+	manager.AddInterceptor(&recordingInterceptor{name: "audit", calls: &calls})
+	defer manager.RemoveInterceptor("audit")
+
+	var info *db233.EntityOperationInfo
+	capture := &infoCapturingInterceptor{capture: func(i *db233.EntityOperationInfo) { info = i }}
+	repo, _ := newInterceptorRepo(t)
+	repo = repo.WithInterceptors(&recordingInterceptor{name: "cache", calls: &calls}, capture)
+
+	if err := repo.Save(&InterceptedItem{Id: 1, Name: "shield"}); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	if _, err := repo.FindById(7, &InterceptedItem{}); err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+
+	expected := []string{
+		"audit:before_save:intercepted_item",
+		"cache:before_save:intercepted_item",
+		"audit:after_save:intercepted_item",
+		"cache:after_save:intercepted_item",
+		"audit:after_find:sword",
+		"cache:after_find:sword",
+	}
+	if strings.Join(calls, ",") != strings.Join(expected, ",") {
+		t.Errorf("调用顺序不正确:\n%v\n%v", calls, expected)
+	}
+	if info == nil || info.Operation != db233.EntityOperationSave || len(info.PrimaryKeyColumns) != 1 || info.PrimaryKeyColumns[0] != "id" || info.Db != repo.GetDb() {
+		t.Errorf("元数据不正确: %+v", info)
+	}
+}
+
+// 用于检查元数据的拦截器
+type infoCapturingInterceptor struct {
+	capture func(info *db233.EntityOperationInfo)
+}
+
+func (i *infoCapturingInterceptor) GetInterceptorName() string { return "capture" }
+
+func (i *infoCapturingInterceptor) BeforeSaveEntity(ctx context.Context, info *db233.EntityOperationInfo, entity db233.IDbEntity) error {
+	i.capture(info)
+	return nil
+}
+
+// 测试拦截器否决写入与删除（否决时不执行语句）
+func TestRepositoryInterceptor_Veto(t *testing.T) {
+	var calls []string
+	repo, fake := newInterceptorRepo(t)
+	repo = repo.WithInterceptors(&recordingInterceptor{name: "guard", calls: &calls, vetoSave: true, vetoDelete: true})
+
+	if err := repo.Save(&InterceptedItem{Id: 1}); err == nil || err.Error() != "禁止写入" {
+		t.Errorf("应返回拦截器错误: %v", err)
+	}
+	if err := repo.DeleteById(7, &InterceptedItem{}); err == nil || err.Error() != "禁止删除" {
+		t.Errorf("应返回拦截器错误: %v", err)
+	}
+	for _, statement := range fake.recorded() {
+		if !strings.HasPrefix(statement.query, "SELECT") {
+			t.Errorf("否决后不应执行写语句: %s", statement.query)
+		}
+	}
+	if len(calls) != 3 || calls[2] != "guard:delete:7:sword" {
+		t.Errorf("删除拦截器应收到删除前的实体: %v", calls)
+	}
+}
+
+// 测试只实现部分接口的拦截器，以及移除全局拦截器
+func TestRepositoryInterceptor_PartialAndRemove(t *testing.T) {
+	counter := &findCountingInterceptor{}
+	manager := db233.GetRepositoryInterceptorManagerInstance()
+	manager.AddInterceptor(counter)
+
+	repo, fake := newInterceptorRepo(t)
+	if err := repo.Save(&InterceptedItem{Id: 1}); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	if err := repo.DeleteById(7, &InterceptedItem{}); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	if statements := fake.recorded(); len(statements) != 2 {
+		t.Errorf("没有删除拦截器时不应预先加载实体: %v", statements)
+	}
+	repo.FindById(7, &InterceptedItem{})
+	if counter.count != 1 {
+		t.Errorf("查询拦截次数不正确: %d", counter.count)
+	}
+
+	manager.RemoveInterceptor("find_counter")
+	repo.FindById(7, &InterceptedItem{})
+	if counter.count != 1 || len(manager.GetAll()) != 0 {
+		t.Error("移除后不应再调用")
+	}
+}
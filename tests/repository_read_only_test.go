@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试只读存储库拒绝所有写操作且不发出语句
+func TestReadOnlyRepository_RejectsWrites(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	repo := db233.NewBaseCrudRepository(db)
+	readOnly := repo.ReadOnly()
+	if !readOnly.IsReadOnly() || repo.IsReadOnly() {
+		t.Fatal("只读副本不应影响原存储库")
+	}
+
+	order := &TxOrder{Id: 1, Amount: 100}
+	writes := map[string]func() error{
+		"Save":          func() error { return readOnly.Save(order) },
+		"SaveBatch":     func() error { return readOnly.SaveBatch([]db233.IDbEntity{order}) },
+		"Update":        func() error { return readOnly.Update(order) },
+		"UpdateBatch":   func() error { return readOnly.UpdateBatch([]db233.IDbEntity{order}) },
+		"UpdateColumns": func() error { return readOnly.UpdateColumns(order, "Amount") },
+		"UpdateNonZero": func() error { return readOnly.UpdateNonZero(order) },
+		"Upsert":        func() error { return readOnly.Upsert(order) },
+		"DeleteById":    func() error { return readOnly.DeleteById(1, &TxOrder{}) },
+		"UpdateWhere": func() error {
+			_, err := readOnly.UpdateWhere(&TxOrder{}, map[string]interface{}{"amount": 0}, "id = ?", []interface{}{1})
+			return err
+		},
+		"DeleteWhere": func() error {
+			_, err := readOnly.DeleteWhere(&TxOrder{}, "id = ?", []interface{}{1})
+			return err
+		},
+	}
+	for operation, write := range writes {
+		var readOnlyErr *db233.ReadOnlyRepositoryException
+		if err := write(); !errors.As(err, &readOnlyErr) || readOnlyErr.Operation != operation {
+			t.Errorf("%s 应被拒绝: %v", operation, err)
+		}
+	}
+	if len(txContextStatements()) != 0 {
+		t.Errorf("只读存储库不应发出写语句: %+v", txContextStatements())
+	}
+
+	if count, err := readOnly.Count(&TxStock{}); err != nil || count != 3 {
+		t.Errorf("只读存储库应允许读取: %d %v", count, err)
+	}
+	if err := repo.Save(order); err != nil {
+		t.Errorf("原存储库应仍可写入: %v", err)
+	}
+}
+
+// 测试只读存储库指向从库，使用更长超时与默认分页大小
+func TestReadOnlyRepository_ReplicaOptions(t *testing.T) {
+	resetTxContextFake()
+	primary := newTxContextDb(t, 1)
+	replica := newTxContextDb(t, 2)
+	primary.SetStatementTimeout(time.Second)
+	repo := db233.NewBaseCrudRepository(primary).WithContext(db233.WithQueryTag(context.Background(), "report.daily"))
+
+	reportRepo := repo.ReadOnly(db233.ReadOnlyRepositoryOptions{Db: replica, StatementTimeout: 5 * time.Minute, FetchSize: 2000})
+	reportDb := reportRepo.GetDb()
+	if reportDb.DbId != 2 || reportDb.StatementTimeout != 5*time.Minute || reportDb.GetQueryTag() != "report.daily" {
+		t.Fatalf("读取应使用从库、更长超时并保留 context: dbId=%d timeout=%v tag=%s", reportDb.DbId, reportDb.StatementTimeout, reportDb.GetQueryTag())
+	}
+	if replica.StatementTimeout != 0 || repo.GetDb().StatementTimeout != time.Second {
+		t.Error("原 Db 的超时不应被修改")
+	}
+
+	if _, err := reportRepo.FindPage("", nil, 1, 0, &TxStock{}); err != nil {
+		t.Fatalf("分页查询失败: %v", err)
+	}
+	statements := txContextStatements()
+	if last := statements[len(statements)-1].query; !strings.Contains(last, "2000") {
+		t.Errorf("未指定分页大小时应使用 FetchSize: %s", last)
+	}
+	if _, err := repo.FindPage("", nil, 1, 0, &TxStock{}); err == nil {
+		t.Error("普通存储库的分页大小仍必须大于 0")
+	}
+}
@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 按队列返回删除行数、按配置返回 COUNT(*) 的驱动，队列耗尽后删除返回错误
+type retentionFake struct {
+	*fakeDriver
+	mu       sync.Mutex
+	affected []int64
+	count    int64
+}
+
+func newRetentionDb(t *testing.T, dbType db233.EnumDatabaseType, affected ...int64) (*db233.Db, *retentionFake) {
+	fake := &retentionFake{fakeDriver: newFakeDriver(), affected: affected}
+	fake.onExec = func(stmt fakeStatement) (driver.Result, error) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		if len(fake.affected) == 0 {
+			return nil, errors.New("连接中断")
+		}
+		affected := fake.affected[0]
+		fake.affected = fake.affected[1:]
+		return driver.RowsAffected(affected), nil
+	}
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		return fakeRowsOf([]string{"count"}, []driver.Value{fake.count}), nil
+	}
+	return fake.newDb(t, dbType), fake
+}
+
+// queries 返回执行过的全部语句
+func (f *retentionFake) queries() []string {
+	var queries []string
+	for _, stmt := range f.recorded() {
+		queries = append(queries, stmt.query)
+	}
+	return queries
+}
+
+type RetentionLog struct {
+	Id        int64     `db:"id,primary_key"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (e *RetentionLog) TableName() string { return "retention_log" }
+
+func (e *RetentionLog) SerializeBeforeSaveDb() {}
+
+func (e *RetentionLog) DeserializeAfterLoadDb() {}
+
+// 测试分批删除直到单批不足批大小，并记录运行结果与指标
+func TestRetentionPurger_PurgeInBatches(t *testing.T) {
+	db, fake := newRetentionDb(t, db233.EnumDatabaseTypeMySQL, 100, 100, 30)
+	purger := db233.NewRetentionPurger(db)
+	if err := purger.Register(&RetentionLog{}, db233.NewRetentionPolicy("created_at", 24*time.Hour).SetBatchSize(100)); err != nil {
+		t.Fatalf("注册失败: %v", err)
+	}
+
+	before := time.Now()
+	result, err := purger.RunNow("retention_log")
+	if err != nil {
+		t.Fatalf("清理失败: %v", err)
+	}
+	if result.Deleted != 230 || result.Batches != 3 || result.DryRun {
+		t.Errorf("清理结果不正确: %+v", result)
+	}
+	queries := fake.queries()
+	if len(queries) != 3 || queries[0] != "DELETE FROM retention_log WHERE created_at < ? LIMIT 100" {
+		t.Errorf("删除语句不正确: %v", queries)
+	}
+	cutoff, _ := fake.recorded()[0].args[0].(time.Time)
+	if cutoff.Before(before.Add(-24*time.Hour)) || cutoff.After(before.Add(-24*time.Hour+time.Minute)) {
+		t.Errorf("截止时间不正确: %v", cutoff)
+	}
+
+	policies := purger.GetPolicies()
+	if len(policies) != 1 || policies[0].TotalDeleted != 230 || policies[0].RunCount != 1 || policies[0].LastResult != result {
+		t.Errorf("策略状态不正确: %+v", policies)
+	}
+	metrics := purger.GetMetrics()
+	if metrics["retention_log.deleted_total"] != int64(230) || metrics["retention_log.last_deleted"] != int64(230) || metrics["total_deleted"] != int64(230) {
+		t.Errorf("指标不正确: %v", metrics)
+	}
+
+	// 驱动不再返回结果时报错，已删除的行数保留在结果中
+	fake.mu.Lock()
+	fake.affected = []int64{100}
+	fake.mu.Unlock()
+	result, err = purger.RunNow("retention_log")
+	if err == nil || result.Deleted != 100 || result.Error != err {
+		t.Errorf("出错时应返回已删除的行数与错误: %+v %v", result, err)
+	}
+	if metrics := purger.GetMetrics(); metrics["retention_log.errors"] != int64(1) || metrics["retention_log.deleted_total"] != int64(330) {
+		t.Errorf("失败指标不正确: %v", metrics)
+	}
+}
+
+// 测试最大批数限制与各数据库的分批删除语句
+func TestRetentionPurger_MaxBatchesAndDialects(t *testing.T) {
+	db, fake := newRetentionDb(t, db233.EnumDatabaseTypePostgreSQL, 50, 50, 50)
+	purger := db233.NewRetentionPurger(db)
+	purger.Register("retention_log", db233.NewRetentionPolicy("created_at", time.Hour).SetBatchSize(50).SetMaxBatches(2).SetName("pg_logs"))
+	result, err := purger.RunNow("pg_logs")
+	if err != nil || result.Batches != 2 || result.Deleted != 100 {
+		t.Errorf("应在达到最大批数时停止: %+v %v", result, err)
+	}
+	if queries := fake.queries(); queries[0] != "DELETE FROM retention_log WHERE ctid IN (SELECT ctid FROM retention_log WHERE created_at < $1 LIMIT 50)" {
+		t.Errorf("PostgreSQL 删除语句不正确: %v", queries)
+	}
+
+	sqlServerDb, fake := newRetentionDb(t, db233.EnumDatabaseTypeSQLServer, 0)
+	sqlServer := db233.NewRetentionPurger(sqlServerDb)
+	sqlServer.Register("retention_log", db233.NewRetentionPolicy("created_at", time.Hour))
+	if _, err := sqlServer.RunNow("retention_log"); err != nil {
+		t.Fatalf("清理失败: %v", err)
+	}
+	if queries := fake.queries(); !reflect.DeepEqual(queries, []string{"DELETE TOP (1000) FROM retention_log WHERE created_at < ?"}) {
+		t.Errorf("SQL Server 删除语句不正确: %v", queries)
+	}
+}
+
+// 测试试运行只统计将被删除的行数
+func TestRetentionPurger_DryRun(t *testing.T) {
+	db, fake := newRetentionDb(t, db233.EnumDatabaseTypeMySQL)
+	fake.mu.Lock()
+	fake.count = 2500
+	fake.mu.Unlock()
+
+	purger := db233.NewRetentionPurger(db).SetDryRun(true)
+	purger.Register(&RetentionLog{}, db233.NewRetentionPolicy("created_at", time.Hour))
+	result, err := purger.RunNow("retention_log")
+	if err != nil || !result.DryRun || result.Deleted != 2500 || result.Batches != 3 {
+		t.Errorf("试运行结果不正确: %+v %v", result, err)
+	}
+	queries := fake.queries()
+	if len(queries) != 1 || !strings.HasPrefix(queries[0], "SELECT COUNT(*) FROM retention_log WHERE created_at < ?") {
+		t.Errorf("试运行不应执行删除: %v", queries)
+	}
+	if policies := purger.GetPolicies(); policies[0].TotalDeleted != 0 {
+		t.Errorf("试运行不应计入累计删除: %+v", policies[0])
+	}
+}
+
+// 测试注册校验
+func TestRetentionPurger_RegisterValidation(t *testing.T) {
+	db, _ := newRetentionDb(t, db233.EnumDatabaseTypeMySQL)
+	purger := db233.NewRetentionPurger(db)
+	cases := map[string]*db233.RetentionPolicy{
+		"时间列不存在":  db233.NewRetentionPolicy("updated_at", time.Hour),
+		"保留时长无效":  db233.NewRetentionPolicy("created_at", 0),
+		"批大小无效":   db233.NewRetentionPolicy("created_at", time.Hour).SetBatchSize(0),
+		"调度表达式无效": db233.NewRetentionPolicy("created_at", time.Hour).SetSchedule("bad"),
+	}
+	for name, policy := range cases {
+		if err := purger.Register(&RetentionLog{}, policy); err == nil {
+			t.Errorf("%s应报错", name)
+		}
+	}
+	if err := purger.Register("retention_log; DROP", db233.NewRetentionPolicy("created_at", time.Hour)); err == nil {
+		t.Error("无效表名应报错")
+	}
+	if _, err := purger.RunNow("unknown"); err == nil {
+		t.Error("不存在的策略应报错")
+	}
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type rowMapperOrderView struct {
+	*TxOrder
+	Label string
+}
+
+// 测试内置的结构体、Map 与切片映射器
+func TestQueryWithMapper_BuiltinMappers(t *testing.T) {
+	db, _ := newMultiResultDb(t)
+
+	rows, err := db.QueryWithMapper("SELECT id, amount FROM tx_order", nil, db233.NewStructMapper(&TxOrder{}).SetTimePolicy(db.GetTimePolicy()))
+	if err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if len(rows) != 2 || rows[0].(*TxOrder).Amount != 100 || rows[1].(*TxOrder).Id != 2 {
+		t.Errorf("结构体映射不正确: %+v", rows)
+	}
+
+	views, err := db.QueryWithMapper("SELECT id, amount FROM tx_order", nil, db233.NewStructMapper(rowMapperOrderView{}))
+	if err != nil || len(views) != 2 {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if view := views[1].(*rowMapperOrderView); view.TxOrder == nil || view.Amount != 200 {
+		t.Errorf("嵌入指针字段应被分配并映射: %+v", view)
+	}
+
+	maps, err := db.QueryWithMapper("SELECT id, amount FROM tx_order", nil, db233.NewMapMapper())
+	if err != nil || !reflect.DeepEqual(maps[0], map[string]interface{}{"id": int64(1), "amount": int64(100)}) {
+		t.Errorf("Map 映射不正确: %+v %v", maps, err)
+	}
+
+	slices, err := db.QueryWithMapper("SELECT id, amount FROM tx_order", nil, db233.NewSliceMapper())
+	if err != nil || !reflect.DeepEqual(slices[1], []interface{}{int64(2), int64(200)}) {
+		t.Errorf("切片映射不正确: %+v %v", slices, err)
+	}
+}
+
+// 测试函数映射器手工扫描与映射错误
+func TestQueryWithMapper_ManualScan(t *testing.T) {
+	db, fake := newMultiResultDb(t)
+
+	totals, err := db.QueryWithMapper("SELECT id, amount FROM tx_order", nil, db233.RowMapperFunc(func(columns []string, rows *sql.Rows, rowNum int) (interface{}, error) {
+		var id, amount int64
+		if err := rows.Scan(&id, &amount); err != nil {
+			return nil, err
+		}
+		return id*1000 + amount + int64(rowNum), nil
+	}))
+	if err != nil || !reflect.DeepEqual(totals, []interface{}{int64(1100), int64(2201)}) {
+		t.Errorf("手工扫描结果不正确: %v %v", totals, err)
+	}
+
+	_, err = db.QueryWithMapper("SELECT id, amount FROM tx_order", nil, db233.RowMapperFunc(func(columns []string, rows *sql.Rows, rowNum int) (interface{}, error) {
+		return nil, errors.New("形状不匹配")
+	}))
+	if err == nil || !strings.Contains(err.Error(), "形状不匹配") {
+		t.Errorf("映射错误应返回: %v", err)
+	}
+
+	if _, err := db.QueryWithMapper("SELECT 1", nil, nil); err == nil {
+		t.Error("映射器为空应报错")
+	}
+
+	if fake.closedRows != 2 {
+		t.Errorf("映射失败时结果集也应关闭: %d", fake.closedRows)
+	}
+}
@@ -0,0 +1,236 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 模拟多个库：连接名区分库名，状态表保存在内存中；语句包含 fail 时失败，flaky 在计数归零前失败
+var sagaFake struct {
+	mu            sync.Mutex
+	execs         []string
+	states        map[string][]driver.Value
+	flakyFailures int
+}
+
+var sagaStateColumns = []string{"saga_id", "saga_name", "status", "current_step", "data", "error_message"}
+
+func resetSagaFake() {
+	sagaFake.mu.Lock()
+	defer sagaFake.mu.Unlock()
+	sagaFake.execs = nil
+	sagaFake.states = make(map[string][]driver.Value)
+	sagaFake.flakyFailures = 0
+}
+
+func sagaFakeExec(stmt fakeStatement) (driver.Result, error) {
+	sagaFake.mu.Lock()
+	defer sagaFake.mu.Unlock()
+	args := stmt.args
+	switch {
+	case strings.HasPrefix(stmt.query, "INSERT INTO db233_saga_state"):
+		// saga_id, saga_name, status, current_step, data, error_message
+		sagaFake.states[args[0].(string)] = append([]driver.Value(nil), args[:6]...)
+	case strings.HasPrefix(stmt.query, "UPDATE db233_saga_state"):
+		state := sagaFake.states[args[5].(string)]
+		state[2], state[3], state[4], state[5] = args[0], args[1], args[2], args[3]
+	case strings.Contains(stmt.query, "fail"):
+		return nil, errors.New("模拟步骤失败")
+	case strings.Contains(stmt.query, "flaky") && sagaFake.flakyFailures > 0:
+		sagaFake.flakyFailures--
+		return nil, errors.New("模拟补偿暂时失败")
+	default:
+		sagaFake.execs = append(sagaFake.execs, stmt.conn+": "+stmt.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func sagaFakeQuery(stmt fakeStatement) (*fakeRows, error) {
+	sagaFake.mu.Lock()
+	defer sagaFake.mu.Unlock()
+	var values [][]driver.Value
+	switch {
+	case strings.Contains(stmt.query, "WHERE saga_id = ?"):
+		if state, exists := sagaFake.states[stmt.args[0].(string)]; exists {
+			values = append(values, state)
+		}
+	case strings.Contains(stmt.query, "WHERE status IN"):
+		for _, state := range sagaFake.states {
+			for _, status := range stmt.args {
+				if state[2] == status {
+					values = append(values, state)
+				}
+			}
+		}
+	default:
+		return nil, errors.New("未知查询: " + stmt.query)
+	}
+	return fakeRowsOf(sagaStateColumns, values...), nil
+}
+
+func takeSagaExecs() []string {
+	sagaFake.mu.Lock()
+	defer sagaFake.mu.Unlock()
+	execs := sagaFake.execs
+	sagaFake.execs = nil
+	return execs
+}
+
+func openSagaDb(t *testing.T, name string) *db233.Db {
+	fake := newFakeDriver()
+	fake.onExec = sagaFakeExec
+	fake.onQuery = sagaFakeQuery
+	return db233.NewDb(fake.open(t, name), 1, nil)
+}
+
+func sagaExec(query string) db233.SagaStepFunc {
+	return func(tm *db233.TransactionManager, saga *db233.SagaContext) error {
+		_, err := tm.Exec(query, saga.Get("orderId"))
+		return err
+	}
+}
+
+// 构造跨库的购买流程：shop 扣库存 → player 扣金币 → item 发道具（发道具的语句由参数决定）
+func newPurchaseSaga(t *testing.T, grantSql string) *db233.SagaExecutor {
+	shopDb, playerDb, itemDb := openSagaDb(t, "shop"), openSagaDb(t, "player"), openSagaDb(t, "item")
+	saga := db233.NewSagaDefinition("purchase").
+		AddStep("reserve_stock", shopDb, sagaExec("UPDATE stock SET count = count - 1 WHERE order_id = ?"), sagaExec("UPDATE stock SET count = count + 1 WHERE order_id = ?")).
+		AddStep("charge_gold", playerDb, func(tm *db233.TransactionManager, saga *db233.SagaContext) error {
+			saga.Set("charged", "100")
+			_, err := tm.Exec("UPDATE player SET gold = gold - 100 WHERE order_id = ?", saga.Get("orderId"))
+			return err
+		}, func(tm *db233.TransactionManager, saga *db233.SagaContext) error {
+			_, err := tm.Exec("UPDATE player SET gold = gold + "+saga.Get("charged")+" WHERE order_id = ?", saga.Get("orderId"))
+			return err
+		}).
+		AddStep("grant_item", itemDb, sagaExec(grantSql), nil)
+
+	executor := db233.NewSagaExecutor(openSagaDb(t, "state")).SetCompensationRetry(3, 0)
+	if err := executor.Register(saga); err != nil {
+		t.Fatalf("注册 Saga 失败: %v", err)
+	}
+	return executor
+}
+
+// 测试全部步骤成功
+func TestSagaExecutor_Completed(t *testing.T) {
+	resetSagaFake()
+	executor := newPurchaseSaga(t, "INSERT INTO item (order_id) VALUES (?)")
+
+	saga, err := executor.Execute("purchase", map[string]string{"orderId": "1001"})
+	if err != nil {
+		t.Fatalf("执行 Saga 失败: %v", err)
+	}
+	if execs := takeSagaExecs(); !reflect.DeepEqual(execs, []string{
+		"shop: UPDATE stock SET count = count - 1 WHERE order_id = ?",
+		"player: UPDATE player SET gold = gold - 100 WHERE order_id = ?",
+		"item: INSERT INTO item (order_id) VALUES (?)",
+	}) {
+		t.Errorf("执行顺序不正确: %v", execs)
+	}
+
+	state, err := executor.GetState(saga.SagaId)
+	if err != nil || state == nil {
+		t.Fatalf("查询状态失败: %v", err)
+	}
+	if state.Status != db233.EnumSagaStatusCompleted || state.Data["charged"] != "100" {
+		t.Errorf("状态不正确: %+v", state)
+	}
+}
+
+// 测试步骤失败时逆序补偿已完成的步骤
+func TestSagaExecutor_CompensateOnFailure(t *testing.T) {
+	resetSagaFake()
+	executor := newPurchaseSaga(t, "INSERT INTO fail_item (order_id) VALUES (?)")
+
+	saga, err := executor.Execute("purchase", map[string]string{"orderId": "1002"})
+	var aborted *db233.SagaAbortedException
+	if !errors.As(err, &aborted) || !aborted.Compensated || aborted.FailedStep != "grant_item" {
+		t.Fatalf("应返回已补偿的 SagaAbortedException: %v", err)
+	}
+	if execs := takeSagaExecs(); !reflect.DeepEqual(execs, []string{
+		"shop: UPDATE stock SET count = count - 1 WHERE order_id = ?",
+		"player: UPDATE player SET gold = gold - 100 WHERE order_id = ?",
+		"player: UPDATE player SET gold = gold + 100 WHERE order_id = ?",
+		"shop: UPDATE stock SET count = count + 1 WHERE order_id = ?",
+	}) {
+		t.Errorf("补偿顺序不正确: %v", execs)
+	}
+	if state, _ := executor.GetState(saga.SagaId); state == nil || state.Status != db233.EnumSagaStatusCompensated {
+		t.Errorf("状态应为 COMPENSATED: %+v", state)
+	}
+}
+
+// 测试崩溃后恢复：RUNNING 的 Saga 从执行中的步骤开始补偿，补偿暂时失败时重试
+func TestSagaExecutor_Recover(t *testing.T) {
+	resetSagaFake()
+	executor := newPurchaseSaga(t, "INSERT INTO item (order_id) VALUES (?)")
+
+	// 上次进程在 charge_gold 执行时崩溃
+	sagaFake.states["crashed"] = []driver.Value{"crashed", "purchase", "RUNNING", int64(1), `{"orderId":"1003","charged":"100"}`, ""}
+	sagaFake.states["done"] = []driver.Value{"done", "purchase", "COMPLETED", int64(3), `{}`, ""}
+	sagaFake.states["unknown"] = []driver.Value{"unknown", "removed_saga", "RUNNING", int64(0), `{}`, ""}
+	sagaFake.flakyFailures = 2
+
+	executor.Register(db233.NewSagaDefinition("flaky").AddStep("only", openSagaDb(t, "shop"),
+		sagaExec("SELECT 1"), sagaExec("UPDATE flaky SET ok = 1 WHERE order_id = ?")))
+	sagaFake.states["flaky"] = []driver.Value{"flaky", "flaky", "FAILED", int64(0), `{}`, "补偿失败"}
+
+	recovered, err := executor.Recover()
+	if err != nil || recovered != 2 {
+		t.Fatalf("应恢复 2 个 Saga: recovered=%d, err=%v", recovered, err)
+	}
+	execs := takeSagaExecs()
+	crashed := []string{
+		"player: UPDATE player SET gold = gold + 100 WHERE order_id = ?",
+		"shop: UPDATE stock SET count = count + 1 WHERE order_id = ?",
+	}
+	if len(execs) != 3 || !containsSubsequence(execs, crashed) || !containsSubsequence(execs, []string{"shop: UPDATE flaky SET ok = 1 WHERE order_id = ?"}) {
+		t.Errorf("恢复时的补偿不正确: %v", execs)
+	}
+	for id, expected := range map[string]string{"crashed": "COMPENSATED", "flaky": "COMPENSATED", "done": "COMPLETED", "unknown": "RUNNING"} {
+		if state, _ := executor.GetState(id); state == nil || string(state.Status) != expected {
+			t.Errorf("%s 的状态应为 %s: %+v", id, expected, state)
+		}
+	}
+}
+
+// 测试补偿重试耗尽后记为 FAILED
+func TestSagaExecutor_CompensationFailed(t *testing.T) {
+	resetSagaFake()
+	db := openSagaDb(t, "shop")
+	executor := db233.NewSagaExecutor(openSagaDb(t, "state")).SetCompensationRetry(1, time.Millisecond)
+	executor.Register(db233.NewSagaDefinition("broken").
+		AddStep("first", db, sagaExec("UPDATE a SET x = 1 WHERE id = ?"), sagaExec("UPDATE flaky SET x = 0 WHERE id = ?")).
+		AddStep("second", db, sagaExec("UPDATE fail SET x = 1 WHERE id = ?"), nil))
+	sagaFake.flakyFailures = 5
+
+	saga, err := executor.Execute("broken", nil)
+	var aborted *db233.SagaAbortedException
+	if !errors.As(err, &aborted) || aborted.Compensated {
+		t.Fatalf("补偿失败时应返回未补偿的异常: %v", err)
+	}
+	if sagaFake.flakyFailures != 3 {
+		t.Errorf("应尝试 1 次并重试 1 次: 剩余失败次数=%d", sagaFake.flakyFailures)
+	}
+	if state, _ := executor.GetState(saga.SagaId); state == nil || state.Status != db233.EnumSagaStatusFailed || state.CurrentStep != 0 {
+		t.Errorf("状态应为 FAILED 并停在步骤 0: %+v", state)
+	}
+}
+
+func containsSubsequence(values []string, expected []string) bool {
+	next := 0
+	for _, value := range values {
+		if next < len(expected) && value == expected[next] {
+			next++
+		}
+	}
+	return next == len(expected)
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+func savepointQueries() []string {
+	var queries []string
+	for _, statement := range txContextStatements() {
+		queries = append(queries, statement.query)
+	}
+	return queries
+}
+
+// 测试 RunInSavepoint 成功时释放保存点，失败时回滚到保存点并释放
+func TestRunInSavepoint_ReleaseAndRollback(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	tm := db233.NewTransactionManager(db)
+	if err := tm.Begin(); err != nil {
+		t.Fatalf("开始事务失败: %v", err)
+	}
+	defer tm.Rollback()
+
+	if err := tm.RunInSavepoint("step1", func(tm *db233.TransactionManager) error {
+		_, err := tm.Exec("UPDATE tx_order SET amount = 1")
+		return err
+	}); err != nil {
+		t.Fatalf("执行失败: %v", err)
+	}
+
+	businessErr := errors.New("库存不足")
+	err := tm.RunInSavepoint("step2", func(tm *db233.TransactionManager) error {
+		if err := tm.Savepoint("inner"); err != nil {
+			return err
+		}
+		return businessErr
+	})
+	if err != businessErr {
+		t.Errorf("应返回业务错误: %v", err)
+	}
+
+	expected := []string{
+		"SAVEPOINT step1", "UPDATE tx_order SET amount = 1", "RELEASE SAVEPOINT step1",
+		"SAVEPOINT step2", "SAVEPOINT inner", "ROLLBACK TO SAVEPOINT step2", "RELEASE SAVEPOINT step2",
+	}
+	if queries := savepointQueries(); !reflect.DeepEqual(queries, expected) {
+		t.Errorf("语句不正确: %v", queries)
+	}
+	if savepoints := tm.GetSavepoints(); len(savepoints) != 0 {
+		t.Errorf("保存点应全部移除: %v", savepoints)
+	}
+	if !tm.IsActive() {
+		t.Error("保存点失败不应结束外层事务")
+	}
+}
+
+// 测试 panic 时回滚到保存点后继续抛出，名称为空时自动生成
+func TestRunInSavepoint_PanicAndGeneratedName(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	tm := db233.NewTransactionManager(db)
+	if err := tm.Begin(); err != nil {
+		t.Fatalf("开始事务失败: %v", err)
+	}
+	defer tm.Rollback()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("panic 应继续抛出")
+			}
+		}()
+		tm.RunInSavepoint("", func(tm *db233.TransactionManager) error {
+			panic("boom")
+		})
+	}()
+
+	expected := []string{"SAVEPOINT db233_sp_1", "ROLLBACK TO SAVEPOINT db233_sp_1", "RELEASE SAVEPOINT db233_sp_1"}
+	if queries := savepointQueries(); !reflect.DeepEqual(queries, expected) {
+		t.Errorf("语句不正确: %v", queries)
+	}
+	if err := tm.RunInSavepoint("", func(tm *db233.TransactionManager) error { return nil }); err != nil {
+		t.Errorf("自动生成的保存点应可再次使用: %v", err)
+	}
+
+	inactive := db233.NewTransactionManager(db)
+	called := false
+	if err := inactive.RunInSavepoint("sp", func(tm *db233.TransactionManager) error {
+		called = true
+		return nil
+	}); err == nil || called {
+		t.Error("没有活跃事务时应返回错误且不执行函数")
+	}
+}
@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 已存在的表：线上多出 legacy_flag 列、缺少 gold 列与外键
+type SchemaDiffPlayer struct {
+	Id      int64  `db:"id,primary_key,auto_increment"`
+	Name    string `db:"name" size:"64"`
+	Gold    int64  `db:"gold,not_null" default:"0"`
+	GuildId int64  `db:"guild_id" fk:"schema_diff_guild(id)"`
+}
+
+func (e *SchemaDiffPlayer) TableName() string { return "schema_diff_player" }
+
+func (e *SchemaDiffPlayer) SerializeBeforeSaveDb() {}
+
+func (e *SchemaDiffPlayer) DeserializeAfterLoadDb() {}
+
+// 不存在的表
+type SchemaDiffGuild struct {
+	Id   int64  `db:"id,primary_key,auto_increment"`
+	Name string `db:"name" size:"64"`
+}
+
+func (e *SchemaDiffGuild) TableName() string { return "schema_diff_guild" }
+
+func (e *SchemaDiffGuild) SerializeBeforeSaveDb() {}
+
+func (e *SchemaDiffGuild) DeserializeAfterLoadDb() {}
+
+// 模拟线上库的只读驱动：schema_diff_player 存在（id、name、guild_id、legacy_flag），schema_diff_guild 不存在
+func openSchemaDiffDb(t *testing.T) *db233.Db {
+	fake := newFakeDriver()
+	fake.onExec = func(stmt fakeStatement) (driver.Result, error) {
+		return nil, errors.New("SchemaDiffer 不应执行变更: " + stmt.query)
+	}
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		table := ""
+		if len(stmt.args) > 0 {
+			table, _ = stmt.args[0].(string)
+		}
+		switch {
+		case strings.Contains(stmt.query, "REFERENTIAL_CONSTRAINTS"):
+			return fakeRowsOf([]string{"n", "c", "rt", "rc", "d", "u"}), nil
+		case strings.Contains(stmt.query, "information_schema.tables"):
+			count := int64(0)
+			if table == "schema_diff_player" {
+				count = 1
+			}
+			return fakeRowsOf([]string{"count"}, []driver.Value{count}), nil
+		case strings.Contains(stmt.query, "COLUMN_TYPE"):
+			return fakeRowsOf([]string{"name", "type", "nullable", "key", "default"},
+				[]driver.Value{"id", "bigint", "NO", "PRI", nil},
+				[]driver.Value{"name", "varchar(64)", "YES", "", nil},
+				[]driver.Value{"guild_id", "bigint", "YES", "", nil},
+				[]driver.Value{"legacy_flag", "tinyint(1)", "NO", "", nil},
+			), nil
+		}
+		return nil, errors.New("未知查询: " + stmt.query)
+	}
+	return fake.newDb(t, db233.EnumDatabaseTypeMySQL)
+}
+
+// 测试安全权限下的差异：建表、加列、加外键，不删除多余列
+func TestSchemaDiffer_DiffSafe(t *testing.T) {
+	differ := db233.NewSchemaDiffer(openSchemaDiffDb(t), t.TempDir()).
+		AddEntities(&SchemaDiffGuild{}, &SchemaDiffPlayer{})
+
+	diff, err := differ.Diff()
+	if err != nil {
+		t.Fatalf("比对失败: %v", err)
+	}
+	descriptions := make([]string, 0, len(diff.Changes))
+	for _, change := range diff.Changes {
+		descriptions = append(descriptions, change.TableName+":"+change.Description)
+	}
+	expected := "schema_diff_guild:创建表,schema_diff_player:添加列 gold,schema_diff_player:添加外键 fk_schema_diff_player_guild_id"
+	if strings.Join(descriptions, ",") != expected {
+		t.Fatalf("变更不正确:\n%s", strings.Join(descriptions, "\n"))
+	}
+
+	up := diff.UpSQL()
+	if !strings.Contains(up, "CREATE TABLE `schema_diff_guild`") ||
+		!strings.Contains(up, "ALTER TABLE `schema_diff_player` ADD COLUMN `gold` BIGINT NOT NULL DEFAULT 0;") {
+		t.Errorf("上迁 SQL 不正确:\n%s", up)
+	}
+
+	down := diff.DownSQL()
+	dropFk := strings.Index(down, "DROP FOREIGN KEY `fk_schema_diff_player_guild_id`")
+	dropColumn := strings.Index(down, "DROP COLUMN `gold`")
+	dropTable := strings.Index(down, "DROP TABLE `schema_diff_guild`")
+	if dropFk < 0 || dropColumn < dropFk || dropTable < dropColumn {
+		t.Errorf("下迁 SQL 应按逆序撤销:\n%s", down)
+	}
+}
+
+// 测试开启删除权限时生成删除列及其结构还原
+func TestSchemaDiffer_DiffWithDelete(t *testing.T) {
+	diff, err := db233.NewSchemaDiffer(openSchemaDiffDb(t), t.TempDir()).
+		AddEntities(&SchemaDiffPlayer{}).
+		SetPermissions(db233.NewDefaultAutoDbPermission()).
+		Diff()
+	if err != nil {
+		t.Fatalf("比对失败: %v", err)
+	}
+	if !strings.Contains(diff.UpSQL(), "ALTER TABLE `schema_diff_player` DROP COLUMN `legacy_flag`;") {
+		t.Errorf("应删除实体未声明的列:\n%s", diff.UpSQL())
+	}
+	if !strings.Contains(diff.DownSQL(), "ALTER TABLE `schema_diff_player` ADD COLUMN `legacy_flag` tinyint(1) NOT NULL;") {
+		t.Errorf("下迁应还原被删除列的结构:\n%s", diff.DownSQL())
+	}
+}
+
+// 测试写入迁移文件，无差异时不生成
+func TestSchemaDiffer_WriteMigration(t *testing.T) {
+	dir := t.TempDir()
+	db := openSchemaDiffDb(t)
+
+	migration, err := db233.NewSchemaDiffer(db, dir).AddEntities(&SchemaDiffPlayer{}).WriteMigration("sync_player")
+	if err != nil {
+		t.Fatalf("写入迁移失败: %v", err)
+	}
+	if migration == nil {
+		t.Fatal("存在差异时应生成迁移")
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*_sync_player.*.sql"))
+	if len(files) != 2 {
+		t.Fatalf("应生成 up / down 两个文件: %v", files)
+	}
+	content, _ := os.ReadFile(files[1]) // 排序后 .down.sql 在前
+	if !strings.Contains(string(content), "-- Migration: sync_player") || !strings.Contains(string(content), "ADD COLUMN `gold`") {
+		t.Errorf("上迁文件内容不正确:\n%s", content)
+	}
+
+	// 没有差异时不生成文件（禁止所有变更）
+	readOnly := db233.NewSafeAutoDbPermission()
+	readOnly.SetAllowed(db233.EnumAutoDbOperateTypeCreateColumn, false)
+	none, err := db233.NewSchemaDiffer(db, dir).
+		SetPermissions(readOnly).
+		AddEntities(&SchemaDiffPlayer{}).
+		WriteMigration("nothing")
+	if err != nil || none != nil {
+		t.Errorf("无差异时应返回 nil: %v, %v", none, err)
+	}
+}
@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type securityTenantKey struct{}
+
+// 记录 SQL 并直接返回空结果的插件（不访问数据库）
+type sqlCapturePlugin struct {
+	*db233.AbstractDb233Plugin
+	sqls   []string
+	params [][]interface{}
+}
+
+func (p *sqlCapturePlugin) PreExecuteSql(context *db233.ExecuteSqlContext) {
+	p.sqls = append(p.sqls, context.Sql)
+	p.params = append(p.params, context.Params)
+	context.ShortCircuit([]interface{}{})
+}
+
+func registerTenantPolicy(t *testing.T) {
+	registry := db233.GetSecurityPolicyRegistryInstance()
+	registry.Register("test_user", func(ctx context.Context) (*db233.ScopePredicate, error) {
+		tenantId, ok := ctx.Value(securityTenantKey{}).(int)
+		if !ok {
+			return nil, errors.New("缺少租户信息")
+		}
+		return &db233.ScopePredicate{Sql: "tenant_id = ? OR shared = 1", Params: []interface{}{tenantId}}, nil
+	})
+	t.Cleanup(func() { registry.Unregister("test_user") })
+}
+
+// 测试策略谓词自动追加到查询
+func TestSecurityPolicy_InjectsPredicate(t *testing.T) {
+	registerTenantPolicy(t)
+
+	db := db233.NewDb(nil, 1, nil)
+	capture := &sqlCapturePlugin{AbstractDb233Plugin: db233.NewAbstractDb233Plugin("capture")}
+	db.AddPlugin(capture, 0)
+
+	ctx := context.WithValue(context.Background(), securityTenantKey{}, 7)
+	repo := db233.NewBaseCrudRepository(db).WithContext(ctx)
+
+	if _, err := repo.FindAll(&TestUser{}); err != nil {
+		t.Fatalf("FindAll 失败: %v", err)
+	}
+	if _, err := repo.FindByCondition("age > ?", []interface{}{18}, &TestUser{}); err != nil {
+		t.Fatalf("FindByCondition 失败: %v", err)
+	}
+
+	expected := []string{
+		"SELECT * FROM test_user WHERE (tenant_id = ? OR shared = 1)",
+		"SELECT * FROM test_user WHERE (age > ?) AND (tenant_id = ? OR shared = 1)",
+	}
+	if len(capture.sqls) != len(expected) {
+		t.Fatalf("期望执行 %d 条 SQL, 实际 %v", len(expected), capture.sqls)
+	}
+	for i, sql := range expected {
+		if capture.sqls[i] != sql {
+			t.Errorf("第 %d 条 SQL: 期望 %s, 得到 %s", i, sql, capture.sqls[i])
+		}
+	}
+	if params := capture.params[1]; len(params) != 2 || params[0] != 18 || params[1] != 7 {
+		t.Errorf("参数顺序不正确: %v", params)
+	}
+}
+
+// 测试策略拒绝与跳过
+func TestSecurityPolicy_DenyAndBypass(t *testing.T) {
+	registerTenantPolicy(t)
+
+	db := db233.NewDb(nil, 1, nil)
+	capture := &sqlCapturePlugin{AbstractDb233Plugin: db233.NewAbstractDb233Plugin("capture")}
+	db.AddPlugin(capture, 0)
+	repo := db233.NewBaseCrudRepository(db)
+
+	_, err := repo.FindAll(&TestUser{})
+	var denied *db233.SecurityPolicyDeniedException
+	if !errors.As(err, &denied) || denied.TableName != "test_user" {
+		t.Fatalf("缺少租户信息时应被拒绝, 得到 %v", err)
+	}
+	if len(capture.sqls) != 0 {
+		t.Errorf("被拒绝时不应执行 SQL: %v", capture.sqls)
+	}
+
+	if _, err := repo.WithContext(db233.BypassSecurityPolicy(context.Background())).FindAll(&TestUser{}); err != nil {
+		t.Fatalf("跳过策略后不应报错: %v", err)
+	}
+	if len(capture.sqls) != 1 || capture.sqls[0] != "SELECT * FROM test_user" {
+		t.Errorf("跳过策略后不应追加谓词: %v", capture.sqls)
+	}
+}
+
+type PolicyDoc struct {
+	Id      int64  `db:"id,primary_key"`
+	OwnerId int64  `db:"owner_id"`
+	Title   string `db:"title"`
+}
+
+func (e *PolicyDoc) TableName() string { return "policy_doc" }
+
+func (e *PolicyDoc) SerializeBeforeSaveDb() {}
+
+func (e *PolicyDoc) DeserializeAfterLoadDb() {}
+
+// 按归属人限制 policy_doc；fake 中 id = 1 的行属于其他人（UPDATE 不命中、存在性查询无结果）
+func newPolicyDocRepo(t *testing.T) (*db233.BaseCrudRepository, *fakeDriver) {
+	registry := db233.GetSecurityPolicyRegistryInstance()
+	registry.Register("policy_doc", func(ctx context.Context) (*db233.ScopePredicate, error) {
+		ownerId, ok := ctx.Value(securityTenantKey{}).(int64)
+		if !ok {
+			return nil, errors.New("缺少归属人信息")
+		}
+		return &db233.ScopePredicate{Sql: "owner_id = ?", Params: []interface{}{ownerId}}, nil
+	})
+	t.Cleanup(func() { registry.Unregister("policy_doc") })
+
+	fake := newFakeDriver()
+	fake.onExec = func(stmt fakeStatement) (driver.Result, error) {
+		if strings.HasPrefix(stmt.query, "UPDATE") && stmt.args[len(stmt.args)-2] == int64(1) {
+			return driver.RowsAffected(0), nil
+		}
+		if strings.HasPrefix(stmt.query, "INSERT") && strings.Contains(stmt.query, "ON DUPLICATE KEY UPDATE") {
+			return driver.RowsAffected(0), nil
+		}
+		if strings.HasPrefix(stmt.query, "INSERT") {
+			return nil, errors.New("Error 1062 (23000): Duplicate entry '1' for key 'PRIMARY'")
+		}
+		return driver.RowsAffected(1), nil
+	}
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		return fakeRowsOf([]string{"1"}), nil
+	}
+	db233.GetCrudManagerInstance().AutoInitEntity(&PolicyDoc{})
+	ctx := context.WithValue(context.Background(), securityTenantKey{}, int64(7))
+	return db233.NewBaseCrudRepository(fake.newDb(t, db233.EnumDatabaseTypeMySQL)).WithContext(ctx), fake
+}
+
+// 测试 Save 带上策略谓词：范围内的行被更新，范围外的行不会被覆盖
+func TestSecurityPolicy_Save(t *testing.T) {
+	repo, fake := newPolicyDocRepo(t)
+
+	if err := repo.Save(&PolicyDoc{Id: 2, OwnerId: 7, Title: "mine"}); err != nil {
+		t.Fatalf("保存范围内的行失败: %v", err)
+	}
+	statements := fake.recorded()
+	if len(statements) != 1 || !strings.HasPrefix(statements[0].query, "UPDATE policy_doc SET ") ||
+		!strings.HasSuffix(statements[0].query, " WHERE id = ? AND (owner_id = ?)") {
+		t.Fatalf("应按主键与策略谓词更新: %+v", statements)
+	}
+	if args := statements[0].args; !reflect.DeepEqual(args[len(args)-2:], []driver.Value{int64(2), int64(7)}) {
+		t.Errorf("更新参数不正确: %v", args)
+	}
+
+	fake.reset()
+	if err := repo.Save(&PolicyDoc{Id: 1, OwnerId: 7, Title: "steal"}); err == nil {
+		t.Error("主键被范围外的行占用时应保存失败")
+	}
+	if queries := fake.querySqls(); len(queries) != 1 || queries[0] != "SELECT 1 FROM policy_doc WHERE id = ? AND (owner_id = ?) LIMIT 1" {
+		t.Errorf("未命中时应按策略谓词确认可见行: %v", queries)
+	}
+	for _, sql := range fake.execSqls() {
+		if strings.Contains(sql, "ON DUPLICATE KEY UPDATE") {
+			t.Errorf("带策略谓词的保存不应使用 ON DUPLICATE KEY UPDATE: %s", sql)
+		}
+	}
+
+	// 缺少归属人信息时拒绝保存，不执行任何语句
+	fake.reset()
+	err := db233.NewBaseCrudRepository(repo.GetDb()).Save(&PolicyDoc{Id: 3, OwnerId: 7})
+	var denied *db233.SecurityPolicyDeniedException
+	if !errors.As(err, &denied) || len(fake.recorded()) != 0 {
+		t.Errorf("策略拒绝时应返回拒绝错误且不执行 SQL: %v %v", err, fake.execSqls())
+	}
+}
+
+// 测试 Upsert 带上策略谓词，DoNothing 不修改已有行仍使用冲突处理子句
+func TestSecurityPolicy_Upsert(t *testing.T) {
+	repo, fake := newPolicyDocRepo(t)
+
+	if err := repo.Upsert(&PolicyDoc{Id: 2, OwnerId: 7, Title: "mine"}, db233.DoUpdate("title")); err != nil {
+		t.Fatalf("UPSERT 范围内的行失败: %v", err)
+	}
+	if sqls := fake.execSqls(); len(sqls) != 1 || sqls[0] != "UPDATE policy_doc SET title = ? WHERE id = ? AND (owner_id = ?)" {
+		t.Errorf("应按主键与策略谓词更新: %v", sqls)
+	}
+
+	fake.reset()
+	if err := repo.Upsert(&PolicyDoc{Id: 1, OwnerId: 7, Title: "steal"}, db233.DoUpdate("title")); err == nil {
+		t.Error("冲突行在策略范围外时应 UPSERT 失败")
+	}
+
+	fake.reset()
+	if err := repo.Upsert(&PolicyDoc{Id: 1, OwnerId: 7, Title: "steal"}, db233.DoNothing()); err != nil {
+		t.Fatalf("DoNothing 失败: %v", err)
+	}
+	if sqls := fake.execSqls(); len(sqls) != 1 || !strings.HasSuffix(sqls[0], " ON DUPLICATE KEY UPDATE id = id") {
+		t.Errorf("DoNothing 应直接使用冲突处理子句: %v", sqls)
+	}
+
+	fake.reset()
+	err := db233.NewBaseCrudRepository(repo.GetDb()).Upsert(&PolicyDoc{Id: 3, OwnerId: 7}, db233.DoNothing())
+	var denied *db233.SecurityPolicyDeniedException
+	if !errors.As(err, &denied) || len(fake.recorded()) != 0 {
+		t.Errorf("策略拒绝时应返回拒绝错误且不执行 SQL: %v", err)
+	}
+}
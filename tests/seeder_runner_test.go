@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 记录执行语句的驱动：schema_seeds 表保存在内存中，包含 FAIL 的语句返回错误
+type seedFakeStore struct {
+	mu      sync.Mutex
+	execs   []string
+	applied []string
+}
+
+func newSeedDb(t *testing.T) (*db233.Db, *seedFakeStore) {
+	store := &seedFakeStore{}
+	fake := newFakeDriver()
+	fake.onExec = func(stmt fakeStatement) (driver.Result, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		if strings.Contains(stmt.query, "FAIL") {
+			return nil, errors.New("模拟执行失败")
+		}
+		if strings.HasPrefix(stmt.query, "INSERT INTO schema_seeds") {
+			store.applied = append(store.applied, stmt.args[0].(string))
+		} else if !strings.Contains(stmt.query, "CREATE TABLE") {
+			store.execs = append(store.execs, stmt.query)
+		}
+		return driver.RowsAffected(1), nil
+	}
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		values := make([][]driver.Value, 0, len(store.applied))
+		for _, name := range store.applied {
+			values = append(values, []driver.Value{name})
+		}
+		return fakeRowsOf([]string{"name"}, values...), nil
+	}
+	return fake.newDb(t, db233.EnumDatabaseTypeMySQL), store
+}
+
+// 测试按顺序、按环境执行并记录
+func TestSeederRunner_RunByEnvironment(t *testing.T) {
+	db, store := newSeedDb(t)
+	runner := db233.NewSeederRunner(db, "dev")
+	if err := runner.Init(); err != nil {
+		t.Fatalf("初始化失败: %v", err)
+	}
+
+	runner.Register(db233.NewSqlSeeder("001_roles", "INSERT INTO roles VALUES (1, 'admin;root');\n-- 注释; 不拆分\nINSERT INTO roles VALUES (2, 'guest');"))
+	runner.RegisterFunc("002_test_users", func(tx *db233.TransactionManager) error {
+		_, err := tx.Exec("INSERT INTO users VALUES (?)", 1)
+		return err
+	}, "dev", "test")
+	runner.RegisterFunc("003_staging_only", func(tx *db233.TransactionManager) error {
+		_, err := tx.Exec("INSERT INTO staging VALUES (1)")
+		return err
+	}, "staging")
+	if err := runner.Register(db233.NewSqlSeeder("001_roles", "")); err == nil {
+		t.Error("重复名称应返回错误")
+	}
+
+	applied, err := runner.Run()
+	if err != nil || applied != 2 {
+		t.Fatalf("执行结果不正确: %d %v", applied, err)
+	}
+	expected := []string{
+		"INSERT INTO roles VALUES (1, 'admin;root')",
+		"INSERT INTO roles VALUES (2, 'guest')",
+		"INSERT INTO users VALUES (?)",
+	}
+	if !reflect.DeepEqual(store.execs, expected) {
+		t.Errorf("执行语句不正确: %q", store.execs)
+	}
+	if !reflect.DeepEqual(store.applied, []string{"001_roles", "002_test_users"}) {
+		t.Errorf("执行记录不正确: %v", store.applied)
+	}
+
+	// 已执行的不再重复
+	if applied, err := runner.Run(); err != nil || applied != 0 {
+		t.Errorf("重复执行不应再写入: %d %v", applied, err)
+	}
+
+	statuses, err := runner.GetStatus()
+	if err != nil || len(statuses) != 3 {
+		t.Fatalf("获取状态失败: %v %v", statuses, err)
+	}
+	if !statuses[0].Applied || !statuses[1].Applied || statuses[2].Enabled || statuses[2].Applied {
+		t.Errorf("状态不正确: %+v", statuses)
+	}
+}
+
+// 测试失败时停止并且不记录
+func TestSeederRunner_StopOnFailure(t *testing.T) {
+	db, store := newSeedDb(t)
+	runner := db233.NewSeederRunner(db, "test")
+	runner.Register(db233.NewSqlSeeder("001_ok", "INSERT INTO a VALUES (1)"))
+	runner.Register(db233.NewSqlSeeder("002_broken", "INSERT INTO FAIL VALUES (1)"))
+	runner.Register(db233.NewSqlSeeder("003_after", "INSERT INTO b VALUES (1)"))
+
+	applied, err := runner.Run()
+	if err == nil || applied != 1 {
+		t.Fatalf("应在失败处停止: %d %v", applied, err)
+	}
+	if !reflect.DeepEqual(store.applied, []string{"001_ok"}) {
+		t.Errorf("失败的数据填充不应被记录: %v", store.applied)
+	}
+	pending, _ := runner.GetPendingSeeders()
+	if len(pending) != 2 || pending[0].GetName() != "002_broken" {
+		t.Errorf("待执行列表不正确: %v", pending)
+	}
+}
+
+// 测试从目录加载公共脚本与环境脚本
+func TestSeederRunner_LoadSqlDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"001_roles.sql":          "INSERT INTO roles VALUES (1);",
+		"003_items.sql":          "INSERT INTO items VALUES (1);",
+		"dev/002_test_users.sql": "INSERT INTO users VALUES (1);",
+		"prod/002_admins.sql":    "INSERT INTO admins VALUES (1);",
+		"README.md":              "忽略",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("写入脚本失败: %v", err)
+		}
+	}
+
+	db, store := newSeedDb(t)
+	runner := db233.NewSeederRunner(db, "dev")
+	loaded, err := runner.LoadSqlDir(dir)
+	if err != nil || loaded != 4 {
+		t.Fatalf("加载脚本失败: %d %v", loaded, err)
+	}
+	if _, err := runner.Run(); err != nil {
+		t.Fatalf("执行失败: %v", err)
+	}
+	if !reflect.DeepEqual(store.applied, []string{"001_roles", "dev/002_test_users", "003_items"}) {
+		t.Errorf("执行顺序不正确: %v", store.applied)
+	}
+}
@@ -0,0 +1,38 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试影子读比对（需要数据库）
+func TestShadowReader_Compare(t *testing.T) {
+	db := CreateTestDb(t)
+	defer db.Close()
+	if err := SetupTestTables(db); err != nil {
+		t.Fatalf("初始化测试表失败: %v", err)
+	}
+
+	reader := db233.NewShadowReader(db, db, 1)
+	reader.SetAsync(false)
+
+	// 同一个库，结果应一致
+	if _, err := reader.Query("SELECT 1 AS id", nil, &TestUser{}); err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	report := reader.GetReport()
+	if report["sampled_queries"].(int64) != 1 || report["matched_queries"].(int64) != 1 {
+		t.Errorf("期望 1 次采样且一致, 得到 %v", report)
+	}
+
+	// 采样率为 0 时不重放
+	reader.SetSampleRate(0)
+	reader.Query("SELECT 1 AS id", nil, &TestUser{})
+	if reader.GetReport()["sampled_queries"].(int64) != 1 {
+		t.Error("采样率为 0 时不应重放")
+	}
+	if len(reader.GetDivergences()) != 0 {
+		t.Errorf("不应有差异记录: %v", reader.GetDivergences())
+	}
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"math"
 	"testing"
 
 	"github.com/neko233-com/db233-go/pkg/db233"
@@ -58,3 +59,69 @@ func TestShardingDbStrategy100w_Singleton(t *testing.T) {
 		t.Error("单例实例应该相同")
 	}
 }
+
+func TestShardingDbStrategyByHash_CalculateDbId(t *testing.T) {
+	strategy := db233.NewShardingDbStrategyByHash(4)
+
+	testCases := map[int64]int{0: 0, 1: 1, 4: 0, 7: 3, -5: 1, math.MinInt64: 0, math.MaxInt64: 3}
+	for shardingId, expected := range testCases {
+		if result := strategy.CalculateDbId(shardingId); result != expected {
+			t.Errorf("CalculateDbId(%d) = %d, expected %d", shardingId, result, expected)
+		}
+	}
+
+	// |math.MinInt64| = 2^63，2^63 % 3 = 2
+	if result := db233.NewShardingDbStrategyByHash(3).CalculateDbId(math.MinInt64); result != 2 {
+		t.Errorf("CalculateDbId(MinInt64) = %d, expected 2", result)
+	}
+}
+
+func TestShardingDbStrategyByRange_CalculateDbId(t *testing.T) {
+	strategy := db233.NewShardingDbStrategyByRange(1000, 5000)
+
+	testCases := map[int64]int{0: 0, 999: 0, 1000: 1, 4999: 1, 5000: 2, 100000: 2}
+	for shardingId, expected := range testCases {
+		if result := strategy.CalculateDbId(shardingId); result != expected {
+			t.Errorf("CalculateDbId(%d) = %d, expected %d", shardingId, result, expected)
+		}
+	}
+}
+
+func TestShardingRouter_Route(t *testing.T) {
+	shard0 := &db233.Db{DbId: 0}
+	shard1 := &db233.Db{DbId: 1}
+	group := &db233.DbGroup{
+		GroupName:          "test_sharding",
+		ShardingDbStrategy: db233.NewShardingDbStrategyByHash(2),
+		DbMap:              map[int]*db233.Db{0: shard0, 1: shard1},
+	}
+
+	router := db233.NewShardingRouter(group)
+	if _, _, err := router.RouteEntity(&TestUser{ID: 1}); err == nil {
+		t.Error("未注册分片键的实体应返回错误")
+	}
+
+	router.RegisterShardKey(&TestUser{}, "id", nil)
+
+	db, dbId, err := router.RouteEntity(&TestUser{ID: 3})
+	if err != nil || db != shard1 || dbId != 1 {
+		t.Errorf("ID=3 应路由到分片 1, 得到 %d, %v", dbId, err)
+	}
+	db, dbId, err = router.RouteShardKey("10", &TestUser{})
+	if err != nil || db != shard0 || dbId != 0 {
+		t.Errorf("分片键 \"10\" 应路由到分片 0, 得到 %d, %v", dbId, err)
+	}
+
+	router.RegisterShardKey(&TestUser{}, "age", db233.NewShardingDbStrategyByRange(1, 2))
+	if _, _, err := router.RouteEntity(&TestUser{Age: 30}); err == nil {
+		t.Error("路由到不存在的分片应返回错误")
+	}
+
+	noStrategy := db233.NewShardingRouter(&db233.DbGroup{GroupName: "no_strategy", DbMap: map[int]*db233.Db{0: shard0}})
+	noStrategy.RegisterShardKey(&TestUser{}, "id", nil)
+	if _, _, err := noStrategy.RouteShardKey(1, &TestUser{}); err == nil {
+		t.Error("未配置分片策略应返回错误")
+	} else if _, ok := err.(*db233.ConfigurationException); !ok {
+		t.Errorf("未配置分片策略应返回 ConfigurationException, 得到 %T", err)
+	}
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试 SLO 规则生成与燃烧率告警
+func TestAlertManager_AddSLORules(t *testing.T) {
+	manager := db233.NewAlertManager("slo_test")
+	monitor := db233.NewPerformanceMonitor("orders", nil)
+
+	if _, err := manager.AddSLORules("orders", monitor, db233.SLOConfig{AvailabilityTarget: 1.5}); err == nil {
+		t.Error("非法的可用性目标应返回错误")
+	}
+
+	evaluator, err := manager.AddSLORules("orders", monitor, db233.DefaultSLOConfig())
+	if err != nil {
+		t.Fatalf("添加 SLO 规则失败: %v", err)
+	}
+	// 重复添加应替换同 ID 规则
+	if _, err := manager.AddSLORules("orders", monitor, db233.DefaultSLOConfig()); err != nil {
+		t.Fatalf("重复添加 SLO 规则失败: %v", err)
+	}
+	if rules := manager.GetAlertRules(); len(rules) != 4 {
+		t.Fatalf("应生成 4 条 SLO 规则, 得到 %d", len(rules))
+	}
+
+	start := time.Now()
+	evaluator.Evaluate(start)
+
+	// 1% 错误率，远超 99.9% 目标的错误预算
+	for i := 0; i < 99; i++ {
+		monitor.RecordQuery("SELECT 1", 10*time.Millisecond, true, nil)
+	}
+	monitor.RecordQuery("SELECT 1", 900*time.Millisecond, false, errors.New("boom"))
+
+	metrics := evaluator.Evaluate(start.Add(time.Minute))
+	if metrics["availability"] != 0.99 {
+		t.Errorf("可用性计算错误: %v", metrics["availability"])
+	}
+	if burn := metrics["burn_rate_1h"]; burn < 9.9 || burn > 10.1 {
+		t.Errorf("1 小时燃烧率应约为 10, 得到 %v", burn)
+	}
+
+	active := make(map[string]bool)
+	for _, alert := range manager.GetActiveAlerts() {
+		active[alert.RuleID] = true
+	}
+	if !active["slo_orders_availability"] || !active["slo_orders_burn_rate_6h"] || !active["slo_orders_latency_p99"] {
+		t.Errorf("可用性、慢速燃烧与 P99 告警应触发: %v", active)
+	}
+	if active["slo_orders_burn_rate_1h"] {
+		t.Error("燃烧率 10 不应触发快速燃烧告警（阈值 14.4）")
+	}
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// SnowflakeKeyEntity 使用雪花 ID 作为主键的测试实体
+type SnowflakeKeyEntity struct {
+	ID   int64  `db:"id,primary_key,gen=snowflake"`
+	Name string `db:"name"`
+}
+
+func (e *SnowflakeKeyEntity) TableName() string {
+	return "snowflake_key_entity"
+}
+
+func (e *SnowflakeKeyEntity) SerializeBeforeSaveDb() {}
+
+func (e *SnowflakeKeyEntity) DeserializeAfterLoadDb() {}
+
+// 测试 workerId 校验
+func TestSnowflakeIDGenerator_InvalidWorkerId(t *testing.T) {
+	if _, err := db233.NewSnowflakeIDGenerator(-1); err == nil {
+		t.Error("负数 workerId 应报错")
+	}
+	if _, err := db233.NewSnowflakeIDGenerator(db233.SnowflakeMaxWorkerId + 1); err == nil {
+		t.Error("超出范围的 workerId 应报错")
+	}
+}
+
+// 测试并发生成的 ID 唯一且可解析出 workerId
+func TestSnowflakeIDGenerator_UniqueConcurrent(t *testing.T) {
+	generator, err := db233.NewSnowflakeIDGenerator(42)
+	if err != nil {
+		t.Fatalf("创建生成器失败: %v", err)
+	}
+
+	const goroutines, perGoroutine = 8, 2000
+	ids := make(chan int64, goroutines*perGoroutine)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perGoroutine; j++ {
+				id, err := generator.NextId()
+				if err != nil {
+					t.Errorf("生成 ID 失败: %v", err)
+					return
+				}
+				ids <- id
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int64]bool, goroutines*perGoroutine)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("ID 重复: %d", id)
+		}
+		seen[id] = true
+
+		createdAt, workerId, _ := db233.ParseSnowflakeID(id)
+		if workerId != 42 {
+			t.Fatalf("解析 workerId 错误: %d", workerId)
+		}
+		if time.Since(createdAt) > time.Minute || time.Until(createdAt) > time.Second {
+			t.Fatalf("解析时间错误: %v", createdAt)
+		}
+	}
+}
+
+// 测试同一节点生成的 ID 单调递增
+func TestSnowflakeIDGenerator_Monotonic(t *testing.T) {
+	generator, _ := db233.NewSnowflakeIDGenerator(1)
+	last := int64(0)
+	for i := 0; i < 10000; i++ {
+		id, err := generator.NextId()
+		if err != nil {
+			t.Fatalf("生成 ID 失败: %v", err)
+		}
+		if id <= last {
+			t.Fatalf("ID 未递增: %d <= %d", id, last)
+		}
+		last = id
+	}
+}
+
+// 测试 gen=snowflake 填充整数主键
+func TestSnowflakeIDGenerator_PopulateKeys(t *testing.T) {
+	registry := db233.GetKeyGeneratorRegistryInstance()
+	if _, err := registry.RegisterSnowflake(7); err != nil {
+		t.Fatalf("注册雪花生成器失败: %v", err)
+	}
+
+	entity := &SnowflakeKeyEntity{Name: "player"}
+	if err := registry.PopulateKeys(entity); err != nil {
+		t.Fatalf("生成主键失败: %v", err)
+	}
+	if entity.ID <= 0 {
+		t.Fatalf("应生成正整数主键, 得到 %d", entity.ID)
+	}
+	if _, workerId, _ := db233.ParseSnowflakeID(entity.ID); workerId != 7 {
+		t.Errorf("workerId 应为 7, 得到 %d", workerId)
+	}
+
+	existing := &SnowflakeKeyEntity{ID: 100}
+	_ = registry.PopulateKeys(existing)
+	if existing.ID != 100 {
+		t.Errorf("已有主键不应被覆盖, 得到 %d", existing.ID)
+	}
+}
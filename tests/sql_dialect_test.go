@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试各方言的分页子句
+func TestSqlDialect_ApplyLimitOffset(t *testing.T) {
+	query := "SELECT * FROM player ORDER BY id"
+	cases := []struct {
+		dbType   db233.EnumDatabaseType
+		limit    int
+		offset   int
+		expected string
+	}{
+		{db233.EnumDatabaseTypeMySQL, 20, 40, query + " LIMIT 20 OFFSET 40"},
+		{db233.EnumDatabaseTypeMySQL, 20, 0, query + " LIMIT 20"},
+		{db233.EnumDatabaseTypeMySQL, 0, 10, query + " LIMIT 18446744073709551615 OFFSET 10"},
+		{db233.EnumDatabaseTypeMySQL, 0, 0, query},
+		{db233.EnumDatabaseTypePostgreSQL, 20, 40, query + " LIMIT 20 OFFSET 40"},
+		{db233.EnumDatabaseTypePostgreSQL, 0, 10, query + " OFFSET 10"},
+		{db233.EnumDatabaseTypeSQLServer, 20, 40, query + " OFFSET 40 ROWS FETCH NEXT 20 ROWS ONLY"},
+		{db233.EnumDatabaseTypeSQLServer, 20, 0, "SELECT TOP 20 * FROM player ORDER BY id"},
+		{db233.EnumDatabaseTypeSQLServer, 0, 10, query + " OFFSET 10 ROWS"},
+		{"unknown", 5, 0, query + " LIMIT 5"},
+	}
+
+	for _, c := range cases {
+		if got := db233.GetSqlDialect(c.dbType).ApplyLimitOffset(query, c.limit, c.offset); got != c.expected {
+			t.Errorf("%s limit=%d offset=%d: 期望 %q, 得到 %q", c.dbType, c.limit, c.offset, c.expected, got)
+		}
+	}
+}
+
+// 测试 SQL Server 分页对 DISTINCT 与未排序查询的处理
+func TestSqlDialect_SQLServerPaging(t *testing.T) {
+	dialect := db233.GetSqlDialect(db233.EnumDatabaseTypeSQLServer)
+	if got := dialect.ApplyLimitOffset("SELECT DISTINCT name FROM player", 5, 0); got != "SELECT DISTINCT TOP 5 name FROM player" {
+		t.Errorf("TOP 应放在 DISTINCT 之后: %s", got)
+	}
+	if got := dialect.ApplyLimitOffset("SELECT * FROM player", 5, 10); got != "SELECT * FROM player ORDER BY (SELECT NULL) OFFSET 10 ROWS FETCH NEXT 5 ROWS ONLY" {
+		t.Errorf("未排序查询应补 ORDER BY: %s", got)
+	}
+	subquery := "SELECT * FROM (SELECT TOP 100 * FROM player ORDER BY level) p"
+	if got := dialect.ApplyLimitOffset(subquery, 5, 10); got != subquery+" ORDER BY (SELECT NULL) OFFSET 10 ROWS FETCH NEXT 5 ROWS ONLY" {
+		t.Errorf("子查询中的 ORDER BY 不算外层排序: %s", got)
+	}
+}
+
+// 测试分页参数校验（不访问数据库）
+func TestFindPage_InvalidArguments(t *testing.T) {
+	repo := db233.NewBaseCrudRepository(db233.NewDb(nil, 1, nil))
+	if _, err := repo.FindPage("", nil, 0, 10, &TestUser{}); err == nil {
+		t.Error("页码为 0 应报错")
+	}
+	if _, err := repo.FindPage("", nil, 1, 0, &TestUser{}); err == nil {
+		t.Error("每页条数为 0 应报错")
+	}
+}
+
+// 测试各方言的冲突处理子句
+func TestSqlDialect_ApplyUpsert(t *testing.T) {
+	insert := "INSERT INTO user (id,email,age) VALUES (?,?,?)"
+	cases := []struct {
+		dbType   db233.EnumDatabaseType
+		clause   db233.UpsertClause
+		expected string
+	}{
+		{db233.EnumDatabaseTypeMySQL, db233.UpsertClause{ConflictColumns: []string{"email"}, UpdateColumns: []string{"age"}},
+			insert + " ON DUPLICATE KEY UPDATE age = VALUES(age)"},
+		{db233.EnumDatabaseTypeMySQL, db233.UpsertClause{ConflictColumns: []string{"email"}, DoNothing: true},
+			insert + " ON DUPLICATE KEY UPDATE email = email"},
+		{db233.EnumDatabaseTypeMySQL, db233.UpsertClause{ConflictColumns: []string{"email"}, UpdateColumns: []string{"age"}, AutoIncrementColumn: "id"},
+			insert + " ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id), age = VALUES(age)"},
+		{db233.EnumDatabaseTypePostgreSQL, db233.UpsertClause{ConflictColumns: []string{"email"}, UpdateColumns: []string{"age", "updated_at"}},
+			insert + " ON CONFLICT (email) DO UPDATE SET age = EXCLUDED.age, updated_at = EXCLUDED.updated_at"},
+		{db233.EnumDatabaseTypePostgreSQL, db233.UpsertClause{ConflictColumns: []string{"email"}, DoNothing: true},
+			insert + " ON CONFLICT (email) DO NOTHING"},
+		{db233.EnumDatabaseTypeSQLServer, db233.UpsertClause{ConflictColumns: []string{"email"}, UpdateColumns: []string{"age"}},
+			"MERGE INTO user WITH (HOLDLOCK) AS target USING (VALUES (?,?,?)) AS source (id, email, age) ON target.email = source.email" +
+				" WHEN MATCHED THEN UPDATE SET target.age = source.age" +
+				" WHEN NOT MATCHED THEN INSERT (id, email, age) VALUES (source.id, source.email, source.age);"},
+		{db233.EnumDatabaseTypeSQLServer, db233.UpsertClause{ConflictColumns: []string{"id", "email"}, DoNothing: true},
+			"MERGE INTO user WITH (HOLDLOCK) AS target USING (VALUES (?,?,?)) AS source (id, email, age) ON target.id = source.id AND target.email = source.email" +
+				" WHEN NOT MATCHED THEN INSERT (id, email, age) VALUES (source.id, source.email, source.age);"},
+	}
+	for _, c := range cases {
+		if got := db233.GetSqlDialect(c.dbType).ApplyUpsert(insert, c.clause); got != c.expected {
+			t.Errorf("%s %+v:\n期望 %s\n得到 %s", c.dbType, c.clause, c.expected, got)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试 INSERT 列与 WHERE 比较的参数脱敏
+func TestLoggingPlugin_RedactsSensitiveParams(t *testing.T) {
+	plugin := db233.NewLoggingPlugin().SetLogBoundParams(true)
+
+	insert := plugin.FormatStatement(
+		"INSERT INTO users (username, password, api_token) VALUES (?, ?, ?), (?, ?, ?)",
+		[]interface{}{"alice", "pw1", "tk1", "bob", "pw2", "tk2"})
+	expected := "INSERT INTO users (username, password, api_token) VALUES ('alice', '***', '***'), ('bob', '***', '***')"
+	if insert != expected {
+		t.Errorf("期望 %s\n得到 %s", expected, insert)
+	}
+
+	update := plugin.FormatStatement(
+		"UPDATE users SET `Password` = ?, nickname = ? WHERE id IN (?, ?) AND u.reset_token = ? AND note = 'why?'",
+		[]interface{}{"new", "O'Neil", 1, 2, "abc"})
+	expected = "UPDATE users SET `Password` = '***', nickname = 'O''Neil' WHERE id IN (1, 2) AND u.reset_token = '***' AND note = 'why?'"
+	if update != expected {
+		t.Errorf("期望 %s\n得到 %s", expected, update)
+	}
+}
+
+// 测试自定义规则、清空规则与默认输出格式
+func TestLoggingPlugin_RedactionRules(t *testing.T) {
+	plugin := db233.NewLoggingPlugin().AddRedactionRule("*id_card*")
+
+	formatted := plugin.FormatStatement("SELECT * FROM users WHERE id_card_no = ? AND age > ?", []interface{}{"110101", 18})
+	if formatted != "SELECT * FROM users WHERE id_card_no = ? AND age > ?, Params: [*** 18]" {
+		t.Errorf("自定义规则未生效: %s", formatted)
+	}
+
+	plugin.ClearRedactionRules()
+	formatted = plugin.FormatStatement("SELECT * FROM users WHERE password = ?", []interface{}{"plain"})
+	if !strings.Contains(formatted, "plain") {
+		t.Errorf("清空规则后不应脱敏: %s", formatted)
+	}
+}
+
+// 测试最大长度截断
+func TestLoggingPlugin_MaxLength(t *testing.T) {
+	plugin := db233.NewLoggingPlugin().SetLogBoundParams(true).SetMaxLength(20)
+
+	formatted := plugin.FormatStatement("SELECT * FROM users WHERE name = ?", []interface{}{strings.Repeat("x", 100)})
+	if !strings.HasPrefix(formatted, "SELECT * FROM users ...") || !strings.Contains(formatted, "已截断") {
+		t.Errorf("期望截断，得到 %s", formatted)
+	}
+}
@@ -0,0 +1,278 @@
+package tests
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+const stockMapperXml = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE mapper PUBLIC "-//mybatis.org//DTD Mapper 3.0//EN" "http://mybatis.org/dtd/mybatis-3-mapper.dtd">
+<mapper namespace="StockMapper">
+  <sql id="columns">id, count</sql>
+
+  <select id="findByCondition" resultType="Stock">
+    SELECT <include refid="columns"/> FROM tx_stock
+    <where>
+      <if test="minCount != null and minCount > 0">AND count &gt;= #{minCount}</if>
+      <if test="ids != null and ids.size() > 0">
+        AND id IN <foreach collection="ids" item="id" open="(" separator="," close=")">#{id}</foreach>
+      </if>
+    </where>
+    <choose>
+      <when test="sort == 'count'">ORDER BY count</when>
+      <otherwise>ORDER BY id</otherwise>
+    </choose>
+  </select>
+
+  <select id="findById" resultType="Stock">
+    SELECT id, count FROM tx_stock WHERE id = #{id} AND note &lt;&gt; 'a  b'
+  </select>
+
+  <update id="updateCount">
+    UPDATE tx_stock
+    <set>
+      <if test="count != null">count = #{count},</if>
+      <if test="note != null and note != ''">note = #{note},</if>
+    </set>
+    WHERE id = #{id}
+  </update>
+</mapper>
+`
+
+const stockMapperYaml = `
+namespace: StockYamlMapper
+fragments:
+  columns: s.id, s.count AS count
+statements:
+  findAll:
+    resultType: Stock
+    sql: SELECT <include refid="columns"/> FROM tx_stock s <where><if test="!includeEmpty">s.count > 0</if></where>
+  deleteById:
+    sql: |
+      DELETE FROM tx_stock WHERE id = #{id}
+`
+
+type TxStockUpdateParams struct {
+	Id    int64
+	Count *int64
+	Note  string
+}
+
+func newStockMapper(t *testing.T) *db233.SqlMapper {
+	mapper := db233.NewSqlMapper()
+	mapper.RegisterResultType("Stock", &TxStock{})
+	if err := mapper.LoadXml("stock.xml", []byte(stockMapperXml)); err != nil {
+		t.Fatalf("加载 XML 失败: %v", err)
+	}
+	if err := mapper.LoadYaml("stock.yaml", []byte(stockMapperYaml)); err != nil {
+		t.Fatalf("加载 YAML 失败: %v", err)
+	}
+	return mapper
+}
+
+// 测试 <where>/<if>/<foreach>/<choose>/<set> 的渲染
+func TestSqlMapper_Render(t *testing.T) {
+	mapper := newStockMapper(t)
+	expectedIds := []string{"StockMapper.findByCondition", "StockMapper.findById", "StockMapper.updateCount", "StockYamlMapper.deleteById", "StockYamlMapper.findAll"}
+	if ids := mapper.GetStatementIds(); !reflect.DeepEqual(ids, expectedIds) {
+		t.Fatalf("语句 ID 不正确: %v", ids)
+	}
+
+	stmt, err := mapper.GetStatement("StockMapper.findByCondition", map[string]interface{}{"minCount": 5, "ids": []int64{1, 2}, "sort": "count"})
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	expected := "SELECT id, count FROM tx_stock WHERE count >= ? AND id IN (?, ?) ORDER BY count"
+	if !stmt.IsQuery || stmt.SqlList[0] != expected {
+		t.Errorf("渲染结果不正确: %s", stmt.SqlList[0])
+	}
+	if !reflect.DeepEqual(stmt.Params, []interface{}{5, int64(1), int64(2)}) {
+		t.Errorf("参数不正确: %v", stmt.Params)
+	}
+	if _, ok := stmt.ReturnType.(*TxStock); !ok {
+		t.Errorf("应按 resultType 设置返回类型: %T", stmt.ReturnType)
+	}
+
+	stmt, _ = mapper.GetStatement("StockMapper.findByCondition", map[string]interface{}{"ids": []int64{}})
+	if stmt.SqlList[0] != "SELECT id, count FROM tx_stock ORDER BY id" {
+		t.Errorf("没有条件时不应输出 WHERE: %s", stmt.SqlList[0])
+	}
+
+	stmt, _ = mapper.GetStatement("StockMapper.findById", map[string]interface{}{"id": 3})
+	if stmt.SqlList[0] != "SELECT id, count FROM tx_stock WHERE id = ? AND note <> 'a  b'" {
+		t.Errorf("字符串字面量中的空白应保留: %s", stmt.SqlList[0])
+	}
+
+	count := int64(9)
+	update, err := mapper.GetStatement("StockMapper.updateCount", TxStockUpdateParams{Id: 3, Count: &count})
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	if update.IsQuery || update.SqlList[0] != "UPDATE tx_stock SET count = ? WHERE id = ?" {
+		t.Errorf("<set> 应去掉末尾逗号: %s", update.SqlList[0])
+	}
+	if !reflect.DeepEqual(update.Params, []interface{}{&count, int64(3)}) {
+		t.Errorf("结构体参数不正确: %v", update.Params)
+	}
+
+	if _, err := mapper.GetStatement("StockMapper.findById", map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), "id") {
+		t.Errorf("缺少参数应报错: %v", err)
+	}
+	if _, err := mapper.GetStatement("StockMapper.missing", nil); err == nil {
+		t.Error("不存在的语句应报错")
+	}
+	yamlStmt, _ := mapper.GetStatement("StockYamlMapper.findAll", map[string]interface{}{"includeEmpty": false})
+	if yamlStmt.SqlList[0] != "SELECT s.id, s.count AS count FROM tx_stock s WHERE s.count > 0" {
+		t.Errorf("YAML 语句渲染不正确: %s", yamlStmt.SqlList[0])
+	}
+}
+
+// 测试加载时的语法检查
+func TestSqlMapper_LoadErrors(t *testing.T) {
+	cases := map[string]string{
+		"缺少 namespace": `<mapper><select id="a">SELECT 1</select></mapper>`,
+		"标签未闭合":        `<mapper namespace="m"><select id="a">SELECT 1 <where><if test="x">AND x = #{x}</where></select></mapper>`,
+		"表达式无效":        `<mapper namespace="m"><select id="a">SELECT 1 <if test="x ==">AND 1</if></select></mapper>`,
+		"片段不存在":        `<mapper namespace="m"><select id="a">SELECT <include refid="cols"/> FROM t</select></mapper>`,
+		"字符串拼接":        `<mapper namespace="m"><select id="a">SELECT * FROM ${table}</select></mapper>`,
+		"不支持的标签":       `<mapper namespace="m"><select id="a"><bind name="p" value="x"/>SELECT 1</select></mapper>`,
+		"同文件重名":        `<mapper namespace="m"><select id="a">SELECT 1</select><update id="a">UPDATE t SET x = 1</update></mapper>`,
+		"循环引用":         `<mapper namespace="m"><sql id="a"><include refid="b"/></sql><sql id="b"><include refid="a"/></sql><select id="x">SELECT <include refid="a"/></select></mapper>`,
+	}
+	for name, content := range cases {
+		if err := db233.NewSqlMapper().LoadXml(name, []byte(content)); err == nil {
+			t.Errorf("%s 应加载失败", name)
+		}
+	}
+
+	mapper := db233.NewSqlMapper()
+	if err := mapper.LoadXml("a.xml", []byte(`<mapper namespace="m"><select id="a">SELECT 1</select></mapper>`)); err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+	if err := mapper.LoadYaml("b.yaml", []byte("namespace: m\nstatements:\n  a:\n    sql: SELECT 2\n")); err == nil {
+		t.Error("跨文件重名应报错")
+	}
+}
+
+// 测试启动校验：resultType、结果列与参数字段
+func TestSqlMapper_Validate(t *testing.T) {
+	mapper := newStockMapper(t)
+	if err := mapper.Validate(); err != nil {
+		t.Fatalf("合法的 Mapper 不应报错: %v", err)
+	}
+
+	err := mapper.LoadXml("bad.xml", []byte(`<mapper namespace="Bad">
+  <select id="wrongColumn" resultType="Stock">SELECT id, amount, COUNT(*) FROM tx_stock</select>
+  <select id="unknownType" resultType="Missing">SELECT * FROM tx_stock</select>
+</mapper>`))
+	if err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+	err = mapper.Validate()
+	if err == nil || !strings.Contains(err.Error(), "amount") || !strings.Contains(err.Error(), "Missing") {
+		t.Errorf("应报告无法映射的列与未注册的 resultType: %v", err)
+	}
+
+	db := newTxContextDb(t, 1)
+	var badParams struct {
+		UpdateCount func(params TxOrder) (int64, error)
+	}
+	if err := mapper.Bind(db, "StockMapper", &badParams); err == nil || !strings.Contains(err.Error(), "note") {
+		t.Errorf("参数结构体缺少字段应报错: %v", err)
+	}
+	if badParams.UpdateCount != nil {
+		t.Error("校验失败时不应绑定任何方法")
+	}
+
+	var badColumns struct {
+		WrongColumn func() ([]*TxStock, error)
+	}
+	if err := mapper.Bind(db, "Bad", &badColumns); err == nil || !strings.Contains(err.Error(), "amount") {
+		t.Errorf("结果列无法映射应报错: %v", err)
+	}
+
+	var badSignatures = []interface{}{
+		&struct{ Missing func() error }{},
+		&struct {
+			FindById func(map[string]interface{}) (int64, error)
+		}{},
+		&struct {
+			FindById func(map[string]interface{}) ([]*TxOrder, error)
+		}{},
+		&struct {
+			UpdateCount func(map[string]interface{}) ([]*TxStock, error)
+		}{},
+		&struct{ FindById func(int64) (*TxStock, error) }{},
+	}
+	for _, target := range badSignatures {
+		if err := mapper.Bind(db, "StockMapper", target); err == nil {
+			t.Errorf("%T 应绑定失败", target)
+		}
+	}
+}
+
+// 测试绑定的方法通过 Db 执行并映射结果
+func TestSqlMapper_BindAndExecute(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	mapper := newStockMapper(t)
+
+	var stocks struct {
+		FindByCondition func(params map[string]interface{}) ([]*TxStock, error)
+		FindOne         func(params map[string]interface{}) (TxStock, error) `mapper:"findById"`
+		UpdateCount     func(params TxStockUpdateParams) (int64, error)
+		Name            string
+	}
+	if err := mapper.Bind(db, "StockMapper", &stocks); err != nil {
+		t.Fatalf("绑定失败: %v", err)
+	}
+	var yamlStocks struct {
+		FindAll    func(params map[string]interface{}) ([]TxStock, error)
+		DeleteById func(params map[string]interface{}) error
+	}
+	if err := mapper.Bind(db, "StockYamlMapper", &yamlStocks); err != nil {
+		t.Fatalf("绑定失败: %v", err)
+	}
+
+	list, err := stocks.FindByCondition(map[string]interface{}{"minCount": 1})
+	if err != nil || len(list) != 1 || list[0].Count != 3 {
+		t.Fatalf("查询结果不正确: %v %v", list, err)
+	}
+	one, err := stocks.FindOne(map[string]interface{}{"id": 1})
+	if err != nil || one.Count != 3 {
+		t.Errorf("单行查询不正确: %+v %v", one, err)
+	}
+	count := int64(5)
+	if affected, err := stocks.UpdateCount(TxStockUpdateParams{Id: 1, Count: &count, Note: "n"}); err != nil || affected != 1 {
+		t.Errorf("更新结果不正确: %d %v", affected, err)
+	}
+	if err := yamlStocks.DeleteById(map[string]interface{}{"id": 1}); err != nil {
+		t.Errorf("删除失败: %v", err)
+	}
+	if _, err := yamlStocks.FindAll(nil); err != nil {
+		t.Errorf("查询失败: %v", err)
+	}
+	if _, err := stocks.FindOne(map[string]interface{}{}); err == nil {
+		t.Error("缺少参数时应返回错误")
+	}
+
+	statements := txContextStatements()
+	expected := []string{
+		"SELECT id, count FROM tx_stock WHERE count >= ? ORDER BY id",
+		"SELECT id, count FROM tx_stock WHERE id = ? AND note <> 'a  b'",
+		"UPDATE tx_stock SET count = ?, note = ? WHERE id = ?",
+		"DELETE FROM tx_stock WHERE id = ?",
+		"SELECT s.id, s.count AS count FROM tx_stock s WHERE s.count > 0",
+	}
+	if len(statements) != len(expected) {
+		t.Fatalf("执行的语句数不正确: %+v", statements)
+	}
+	for i, query := range expected {
+		if statements[i].query != query {
+			t.Errorf("第 %d 条语句不正确: %s", i, statements[i].query)
+		}
+	}
+}
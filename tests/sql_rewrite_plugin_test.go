@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试改写插件修改上下文中的 SQL 与参数（不访问数据库）
+func TestSqlRewritePlugin_RewritesContext(t *testing.T) {
+	plugin := db233.NewSqlRewritePlugin("table-prefix", func(sql string, params []interface{}) (string, []interface{}) {
+		return strings.Replace(sql, "FROM player", "FROM s1_player", 1), append(params, 10)
+	})
+
+	context := db233.NewExecuteSqlContext("SELECT * FROM player WHERE id = ?", []interface{}{1})
+	if context.IsRewritten() {
+		t.Fatal("未改写时 IsRewritten 应为 false")
+	}
+
+	plugin.PreExecuteSql(context)
+	if context.Sql != "SELECT * FROM s1_player WHERE id = ?" || len(context.Params) != 2 {
+		t.Errorf("改写结果不正确: %s %v", context.Sql, context.Params)
+	}
+	if context.OriginalSql != "SELECT * FROM player WHERE id = ?" || len(context.OriginalParams) != 1 {
+		t.Errorf("原始 SQL 不应被修改: %s %v", context.OriginalSql, context.OriginalParams)
+	}
+	if !context.IsRewritten() {
+		t.Error("改写后 IsRewritten 应为 true")
+	}
+}
+
+// 测试引擎执行改写后的 SQL
+func TestSqlRewritePlugin_ExecutesRewrittenSql(t *testing.T) {
+	db := CreateTestDb(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	if err := SetupTestTables(db); err != nil {
+		t.Fatalf("设置测试表失败: %v", err)
+	}
+	defer CleanupTestTables(db)
+
+	db233.GetCrudManagerInstance().AutoInitEntity(&TestUser{})
+	if err := db233.NewBaseCrudRepository(db).Save(&TestUser{Username: "find_rewrite", Email: "r@example.com", Age: 1}); err != nil {
+		t.Fatalf("保存用户失败: %v", err)
+	}
+
+	var executed string
+	db.AddPlugin(db233.NewSqlRewritePlugin("rewrite-username", func(sql string, params []interface{}) (string, []interface{}) {
+		return sql + " -- trace_id=t1", []interface{}{"find_rewrite"}
+	}), 0)
+	observer := &sqlObserverPlugin{AbstractDb233Plugin: db233.NewAbstractDb233Plugin("observer"), executed: &executed}
+	db.AddPlugin(observer, 10)
+
+	results := db.ExecuteQuery("SELECT * FROM test_user WHERE username = ?", [][]interface{}{{"not_exists"}}, TestUser{})
+	if len(results) != 1 || results[0].(TestUser).Username != "find_rewrite" {
+		t.Errorf("应按改写后的参数查询, 得到 %v", results)
+	}
+	if !strings.HasSuffix(executed, "-- trace_id=t1") {
+		t.Errorf("后续插件应看到改写后的 SQL, 得到 %s", executed)
+	}
+}
+
+// 记录插件看到的 SQL
+type sqlObserverPlugin struct {
+	*db233.AbstractDb233Plugin
+	executed *string
+}
+
+func (p *sqlObserverPlugin) PreExecuteSql(context *db233.ExecuteSqlContext) {
+	*p.executed = context.Sql
+}
@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+const orderTemplates = `-- 订单相关查询
+-- name: findOrders
+-- 按用户查询订单
+SELECT * FROM tx_order
+WHERE user_id = :userId
+-- #if status
+  AND status = :status
+-- #end
+-- #if !includeDeleted
+  AND deleted_at IS NULL
+-- #end
+-- #if ids
+  AND id IN (:ids)
+  -- #if minAmount
+  AND amount >= :minAmount
+  -- #end
+-- #end
+  AND note <> ':notParam' AND created_at::date = :day
+ORDER BY id;
+
+-- name: updateAmount
+UPDATE tx_order SET amount = :amount WHERE id = :id;
+`
+
+func writeSqlTemplate(t *testing.T, dir string, name string, content string) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("写入模板失败: %v", err)
+	}
+	return path
+}
+
+// 测试具名参数、条件块与切片展开
+func TestSqlTemplate_Render(t *testing.T) {
+	dir := t.TempDir()
+	writeSqlTemplate(t, dir, "orders.sql", orderTemplates)
+	writeSqlTemplate(t, dir, "readme.txt", "不是模板")
+	templates := db233.NewSqlTemplateManager()
+	if err := templates.LoadDir(dir); err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+	if names := templates.GetNames(); !reflect.DeepEqual(names, []string{"findOrders", "updateAmount"}) {
+		t.Fatalf("语句名不正确: %v", names)
+	}
+
+	stmt, err := templates.GetStatement("findOrders", map[string]interface{}{
+		"userId": 7, "status": "paid", "ids": []int64{1, 2}, "minAmount": 100, "day": "2026-10-16",
+	})
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	expected := "SELECT * FROM tx_order\nWHERE user_id = ?\n  AND status = ?\n  AND deleted_at IS NULL\n  AND id IN (?, ?)\n  AND amount >= ?\n  AND note <> ':notParam' AND created_at::date = ?\nORDER BY id"
+	if !stmt.IsQuery || stmt.SqlList[0] != expected {
+		t.Errorf("渲染结果不正确:\n%s", stmt.SqlList[0])
+	}
+	if !reflect.DeepEqual(stmt.Params, []interface{}{7, "paid", int64(1), int64(2), 100, "2026-10-16"}) {
+		t.Errorf("参数不正确: %v", stmt.Params)
+	}
+
+	stmt, err = templates.GetStatement("findOrders", map[string]interface{}{"userId": 7, "status": "", "includeDeleted": true, "minAmount": 100, "day": nil})
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	if strings.Contains(stmt.SqlList[0], "status") || strings.Contains(stmt.SqlList[0], "deleted_at") || strings.Contains(stmt.SqlList[0], "amount") || len(stmt.Params) != 2 {
+		t.Errorf("零值参数的条件块应被去掉: %s %v", stmt.SqlList[0], stmt.Params)
+	}
+
+	if _, err := templates.GetStatement("findOrders", map[string]interface{}{"day": nil}); err == nil || !strings.Contains(err.Error(), "userId") {
+		t.Errorf("缺少参数应报错: %v", err)
+	}
+	if _, err := templates.GetStatement("missing", nil); err == nil {
+		t.Error("不存在的语句应报错")
+	}
+	if update, _ := templates.GetStatement("updateAmount", map[string]interface{}{"amount": 5, "id": 1}); update.IsQuery || update.SqlList[0] != "UPDATE tx_order SET amount = ? WHERE id = ?" {
+		t.Errorf("更新语句不正确: %+v", update)
+	}
+}
+
+// 测试解析错误与跨文件重名
+func TestSqlTemplate_LoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	templates := db233.NewSqlTemplateManager()
+	cases := map[string]string{
+		"no_name.sql":    "SELECT 1",
+		"unclosed.sql":   "-- name: a\nSELECT 1\n-- #if x\nAND 1",
+		"extra_end.sql":  "-- name: a\nSELECT 1\n-- #end",
+		"duplicate.sql":  "-- name: a\nSELECT 1\n-- name: a\nSELECT 2",
+		"empty_body.sql": "-- name: a\n-- 只有注释",
+	}
+	for name, content := range cases {
+		if err := templates.LoadFile(writeSqlTemplate(t, dir, name, content)); err == nil {
+			t.Errorf("%s 应加载失败", name)
+		}
+	}
+
+	if err := templates.LoadFile(writeSqlTemplate(t, dir, "a.sql", "-- name: shared\nSELECT 1")); err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+	if err := templates.LoadFile(writeSqlTemplate(t, dir, "b.sql", "-- name: shared\nSELECT 2")); err == nil {
+		t.Error("跨文件重名应报错")
+	}
+}
+
+// 测试热加载只替换变化的文件，解析失败时保留原模板
+func TestSqlTemplate_HotReload(t *testing.T) {
+	dir := t.TempDir()
+	path := writeSqlTemplate(t, dir, "orders.sql", "-- name: count\nSELECT COUNT(*) FROM tx_order")
+	templates := db233.NewSqlTemplateManager()
+	if err := templates.LoadFile(path); err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+	if reloaded := templates.ReloadChanged(); len(reloaded) != 0 {
+		t.Errorf("文件未变化时不应重新加载: %v", reloaded)
+	}
+
+	writeSqlTemplate(t, dir, "orders.sql", "-- name: countPaid\nSELECT COUNT(*) FROM tx_order WHERE status = 'paid'")
+	if reloaded := templates.ReloadChanged(); len(reloaded) != 1 || !templates.Has("countPaid") || templates.Has("count") {
+		t.Fatalf("应重新加载变化的文件: %v %v", reloaded, templates.GetNames())
+	}
+
+	writeSqlTemplate(t, dir, "orders.sql", "-- name: broken\nSELECT 1\n-- #if x")
+	if reloaded := templates.ReloadChanged(); len(reloaded) != 0 || !templates.Has("countPaid") {
+		t.Errorf("解析失败时应保留原模板: %v", templates.GetNames())
+	}
+	if err := templates.StartHotReload(0); err == nil {
+		t.Error("轮询间隔必须大于 0")
+	}
+}
+
+// 测试从 fs.FS 加载并通过 Db 执行
+func TestSqlTemplate_LoadFSAndExecute(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	templates := db233.NewSqlTemplateManager()
+	err := templates.LoadFS(fstest.MapFS{"sql/orders.sql": {Data: []byte(orderTemplates)}}, "sql")
+	if err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+
+	stmt, _ := templates.GetStatement("updateAmount", map[string]interface{}{"amount": 5, "id": 1})
+	if affected := db.ExecuteUpdateByStatement(stmt); affected != 1 {
+		t.Errorf("更新应执行: %d", affected)
+	}
+	query, _ := templates.GetStatement("findOrders", map[string]interface{}{"userId": 7, "day": "2026-10-16"})
+	query.ReturnType = &TxStock{}
+	db.ExecuteQueryByStatement(query)
+
+	statements := txContextStatements()
+	if len(statements) != 2 || statements[0].query != "UPDATE tx_order SET amount = ? WHERE id = ?" || statements[1].query != query.SqlList[0] {
+		t.Errorf("应执行渲染后的语句: %+v", statements)
+	}
+}
@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// SQL Server 建表测试实体
+type SqlServerOrder struct {
+	Id        int64             `db:"id,primary_key,auto_increment"`
+	OrderNo   string            `db:"order_no,not_null" size:"64"`
+	Remark    string            `db:"remark" size:"8000"`
+	Paid      bool              `db:"paid"`
+	Amount    float64           `db:"amount"`
+	Flags     uint8             `db:"flags"`
+	Extra     map[string]string `db:"extra"`
+	CreatedAt time.Time         `db:"created_at"`
+}
+
+func (e *SqlServerOrder) TableName() string { return "sqlserver_order" }
+
+func (e *SqlServerOrder) SerializeBeforeSaveDb() {}
+
+func (e *SqlServerOrder) DeserializeAfterLoadDb() {}
+
+// 测试 SQL Server 建表与改表 SQL
+func TestSQLServerStrategy_GenerateSQL(t *testing.T) {
+	strategy := db233.GetStrategyFactoryInstance().GetStrategy(db233.EnumDatabaseTypeSQLServer)
+	if strategy.GetDatabaseType() != db233.EnumDatabaseTypeSQLServer {
+		t.Fatalf("应返回 SQL Server 策略: %s", strategy.GetDatabaseType())
+	}
+
+	createSql, err := strategy.GenerateCreateTableSQL("sqlserver_order", reflect.TypeOf(SqlServerOrder{}), "")
+	if err != nil {
+		t.Fatalf("生成建表 SQL 失败: %v", err)
+	}
+	for _, expected := range []string{
+		"CREATE TABLE [sqlserver_order] (",
+		"[id] BIGINT IDENTITY(1,1) NOT NULL",
+		"[order_no] NVARCHAR(64) NOT NULL",
+		"[remark] NVARCHAR(MAX) NULL",
+		"[paid] BIT NULL",
+		"[amount] FLOAT NULL",
+		"[flags] TINYINT NULL",
+		"[extra] NVARCHAR(MAX) NULL",
+		"[created_at] DATETIME2 NULL",
+		"CONSTRAINT [PK_sqlserver_order] PRIMARY KEY ([id])",
+	} {
+		if !strings.Contains(createSql, expected) {
+			t.Errorf("建表 SQL 缺少 %q:\n%s", expected, createSql)
+		}
+	}
+	if strings.Contains(createSql, "`") || strings.Contains(createSql, "AUTO_INCREMENT") {
+		t.Errorf("不应包含 MySQL 语法:\n%s", createSql)
+	}
+
+	field, _ := reflect.TypeOf(SqlServerOrder{}).FieldByName("Paid")
+	if sql, _ := strategy.GenerateAddColumnSQL("sqlserver_order", field, "paid"); sql != "ALTER TABLE [sqlserver_order] ADD [paid] BIT NULL" {
+		t.Errorf("加列 SQL 不正确: %s", sql)
+	}
+	field, _ = reflect.TypeOf(SqlServerOrder{}).FieldByName("OrderNo")
+	if sql, _ := strategy.GenerateModifyColumnSQL("sqlserver_order", field, "order_no"); sql != "ALTER TABLE [sqlserver_order] ALTER COLUMN [order_no] NVARCHAR(64) NOT NULL" {
+		t.Errorf("改列 SQL 不正确: %s", sql)
+	}
+	if sql, _ := strategy.GenerateDropColumnSQL("sqlserver_order", "paid"); sql != "ALTER TABLE [sqlserver_order] DROP COLUMN [paid]" {
+		t.Errorf("删列 SQL 不正确: %s", sql)
+	}
+}
+
+// 测试 SQL Server DSN 构建
+func TestDbConnectionConfig_SQLServerDSN(t *testing.T) {
+	config := db233.NewDefaultSQLServerConfig("localhost", 1433, "sa", "p@ss:word", "game")
+	config.ExtraParams["log"] = "1"
+
+	dsn := config.BuildDSN()
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("DSN 应为合法 URL: %s %v", dsn, err)
+	}
+	password, _ := parsed.User.Password()
+	if parsed.Scheme != "sqlserver" || parsed.Host != "localhost:1433" || parsed.User.Username() != "sa" || password != "p@ss:word" {
+		t.Errorf("DSN 基础部分不正确: %s", dsn)
+	}
+	query := parsed.Query()
+	if query.Get("database") != "game" || query.Get("encrypt") != "disable" || query.Get("connection timeout") != "10" ||
+		query.Get("app name") != "db233-go" || query.Get("log") != "1" {
+		t.Errorf("DSN 参数不正确: %s", dsn)
+	}
+	if !db233.EnumDatabaseTypeSQLServer.IsValid() {
+		t.Error("sqlserver 应为有效的数据库类型")
+	}
+}
+
+// SQL Server 驱动：OUTPUT INSERTED.id 返回 42，其余查询返回空结果
+func newSqlServerRepo(t *testing.T) (*db233.BaseCrudRepository, *fakeDriver) {
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		if strings.Contains(stmt.query, " OUTPUT INSERTED.id") {
+			return fakeRowsOf([]string{"id"}, []driver.Value{int64(42)}), nil
+		}
+		return fakeRowsOf([]string{"region", "player_id", "item_id", "count"}), nil
+	}
+	db233.GetCrudManagerInstance().AutoInitEntity(&SqlServerOrder{})
+	return db233.NewBaseCrudRepository(fake.newDb(t, db233.EnumDatabaseTypeSQLServer)), fake
+}
+
+// 测试 Save / Upsert / UnitOfWork 经由 MERGE 写入，自增主键通过 OUTPUT INSERTED 取回
+func TestSQLServer_RepositoryWrites(t *testing.T) {
+	repo, fake := newSqlServerRepo(t)
+
+	order := &SqlServerOrder{OrderNo: "A1"}
+	if err := repo.Save(order); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	if queries := fake.querySqls(); order.Id != 42 || len(queries) != 1 ||
+		!strings.HasPrefix(queries[0], "INSERT INTO sqlserver_order (") || !strings.Contains(queries[0], ") OUTPUT INSERTED.id VALUES (") {
+		t.Errorf("自增主键应通过 OUTPUT INSERTED 取回: id=%d, %v", order.Id, queries)
+	}
+
+	fake.reset()
+	if err := repo.Save(&SqlServerOrder{Id: 7, OrderNo: "A2"}); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	if execs := fake.execSqls(); len(execs) != 1 || !strings.HasPrefix(execs[0], "MERGE INTO sqlserver_order WITH (HOLDLOCK) AS target") ||
+		!strings.Contains(execs[0], " ON target.id = source.id WHEN MATCHED THEN UPDATE SET ") {
+		t.Errorf("有主键值时应使用 MERGE: %v", execs)
+	}
+
+	fake.reset()
+	upserted := &SqlServerOrder{OrderNo: "A3"}
+	if err := repo.Upsert(upserted, db233.OnConflict("order_no")); err != nil {
+		t.Fatalf("UPSERT 失败: %v", err)
+	}
+	if queries := fake.querySqls(); upserted.Id != 42 || len(queries) != 1 ||
+		!strings.HasPrefix(queries[0], "MERGE INTO sqlserver_order") || !strings.HasSuffix(queries[0], " OUTPUT INSERTED.id;") {
+		t.Errorf("MERGE 应通过 OUTPUT INSERTED 取回主键: id=%d, %v", upserted.Id, queries)
+	}
+
+	fake.reset()
+	generated := &SqlServerOrder{OrderNo: "A6"}
+	err := repo.NewUnitOfWork().
+		Save(&SqlServerOrder{Id: 8, OrderNo: "A4"}).
+		Save(&SqlServerOrder{Id: 9, OrderNo: "A5"}).
+		Save(generated).
+		Commit()
+	if err != nil {
+		t.Fatalf("工作单元提交失败: %v", err)
+	}
+	execs := fake.execSqls()
+	if len(execs) != 1 || !strings.HasPrefix(execs[0], "MERGE INTO sqlserver_order") || strings.Count(execs[0], "),(") != 1 {
+		t.Errorf("工作单元应合并为多行 MERGE: %v", execs)
+	}
+	if queries := fake.querySqls(); generated.Id != 42 || len(queries) != 1 || !strings.Contains(queries[0], " OUTPUT INSERTED.id VALUES ") {
+		t.Errorf("工作单元应通过 OUTPUT INSERTED 回填主键: id=%d, %v", generated.Id, queries)
+	}
+	for _, statement := range fake.recorded() {
+		if !statement.inTx {
+			t.Errorf("工作单元语句应在事务中执行: %s", statement.query)
+		}
+	}
+}
+
+// 测试复合主键批量查找展开为 OR / AND 条件
+func TestSQLServer_FindByCompositeIds(t *testing.T) {
+	repo, fake := newSqlServerRepo(t)
+	ids := []interface{}{
+		map[string]interface{}{"region": "cn", "player_id": int64(10), "item_id": 3},
+		map[string]interface{}{"region": "us", "player_id": int64(11), "item_id": 4},
+	}
+	if _, err := repo.FindByIds(ids, &PlayerItemEntity{}); err != nil {
+		t.Fatalf("批量查找失败: %v", err)
+	}
+	statement := fake.recorded()[0]
+	expected := "SELECT * FROM player_item WHERE ((region = ? AND player_id = ? AND item_id = ?) OR (region = ? AND player_id = ? AND item_id = ?))"
+	if statement.query != expected {
+		t.Errorf("SQL 不正确:\n期望 %s\n得到 %s", expected, statement.query)
+	}
+	if !reflect.DeepEqual(statement.args, []driver.Value{"cn", int64(10), int64(3), "us", int64(11), int64(4)}) {
+		t.Errorf("参数不正确: %v", statement.args)
+	}
+}
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type StartupReportEntity struct {
+	Id   int64  `db:"id,primary_key"`
+	Name string `db:"name"`
+}
+
+func (e *StartupReportEntity) TableName() string {
+	return "startup_report_entity"
+}
+
+func (e *StartupReportEntity) SerializeBeforeSaveDb() {}
+
+func (e *StartupReportEntity) DeserializeAfterLoadDb() {}
+
+// 测试启动报告汇总数据库、实体、插件与迁移版本
+func TestDbManager_StartupReport(t *testing.T) {
+	dataSource, err := sql.Open("mysql", "root:root@tcp(127.0.0.1:1)/startup_report")
+	if err != nil {
+		t.Fatalf("打开数据源失败: %v", err)
+	}
+	defer dataSource.Close()
+	dataSource.SetMaxOpenConns(12)
+
+	dbGroup, err := db233.NewDbGroup(&db233.DbGroupConfig{
+		GroupName:       "startup_report_group",
+		DbConfigFetcher: &MockDbConfigFetcher{},
+	})
+	if err != nil {
+		t.Fatalf("创建 DbGroup 失败: %v", err)
+	}
+
+	manager := db233.GetInstance()
+	defer manager.RemoveDbGroup("startup_report_group")
+	defer manager.SetStartupBannerEnabled(false)
+	manager.SetStartupBannerEnabled(true)
+
+	db := db233.NewDb(dataSource, 7, dbGroup)
+	db.SetPerformanceMonitor(db233.NewPerformanceMonitor("startup_report_group", db))
+	db.AddPlugin(db233.NewLoggingPlugin(), 0)
+	db233.GetCrudManagerInstance().AutoInitEntity(&StartupReportEntity{})
+
+	// 迁移表所在库不可达，版本查询失败只产生警告
+	manager.RegisterMigrationManager("startup_report_group", db233.NewMigrationManager(db, t.TempDir()))
+
+	err = manager.InitByYourDiy(func(dm *db233.DbManager) error {
+		if err := dm.AddDbGroup(dbGroup); err != nil {
+			return err
+		}
+		dbGroup.DbMap[7] = db
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("初始化失败: %v", err)
+	}
+
+	report := manager.BuildStartupReport()
+
+	var dbInfo *db233.StartupDatabaseInfo
+	for i := range report.Databases {
+		if report.Databases[i].GroupName == "startup_report_group" {
+			dbInfo = &report.Databases[i]
+		}
+	}
+	if dbInfo == nil {
+		t.Fatalf("报告中缺少数据库: %+v", report.Databases)
+	}
+	if dbInfo.DbId != 7 || dbInfo.MaxOpenConns != 12 || !dbInfo.PerformanceMonitor {
+		t.Errorf("数据库信息不正确: %+v", dbInfo)
+	}
+	if len(dbInfo.Plugins) == 0 {
+		t.Error("期望报告中包含 Db 插件")
+	}
+
+	foundEntity := false
+	for _, entity := range report.Entities {
+		if entity.TableName == "startup_report_entity" {
+			foundEntity = true
+			if entity.ColumnCount != 2 || len(entity.PrimaryKeyColumns) != 1 || entity.PrimaryKeyColumns[0] != "id" {
+				t.Errorf("实体信息不正确: %+v", entity)
+			}
+		}
+	}
+	if !foundEntity {
+		t.Error("报告中缺少已注册实体")
+	}
+
+	if len(report.Migrations) != 1 || report.Migrations[0].Error == "" || len(report.Warnings) == 0 {
+		t.Errorf("期望迁移版本查询失败记录为警告: %+v, %v", report.Migrations, report.Warnings)
+	}
+
+	if !strings.Contains(report.String(), "startup_report_group#7") {
+		t.Errorf("文本报告缺少数据库条目: %s", report.String())
+	}
+
+	data, err := report.ToJSON()
+	if err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &decoded); err != nil {
+		t.Fatalf("JSON 无效: %v", err)
+	}
+	if _, ok := decoded["databases"]; !ok {
+		t.Error("JSON 报告缺少 databases")
+	}
+}
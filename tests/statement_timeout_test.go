@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试超时事件记录到性能监控器
+func TestPerformanceMonitor_RecordStatementTimeout(t *testing.T) {
+	monitor := db233.NewPerformanceMonitor("test_db", nil)
+
+	monitor.RecordStatementTimeout("SELECT SLEEP(10)", time.Second, true)
+	monitor.RecordStatementTimeout("SELECT SLEEP(10)", time.Second, false)
+
+	report := monitor.GetDetailedReport()
+	if report["timed_out_queries"].(int64) != 2 {
+		t.Errorf("期望超时查询数为 2, 得到 %v", report["timed_out_queries"])
+	}
+	if report["killed_queries"].(int64) != 1 {
+		t.Errorf("期望终止查询数为 1, 得到 %v", report["killed_queries"])
+	}
+	if report["error_count"].(int) != 2 {
+		t.Errorf("期望错误记录数为 2, 得到 %v", report["error_count"])
+	}
+}
+
+// 测试超时异常信息
+func TestStatementTimeoutException(t *testing.T) {
+	err := db233.NewStatementTimeoutException("SELECT 1", 500*time.Millisecond, true)
+	if err.GetCode() != "STATEMENT_TIMEOUT" {
+		t.Errorf("期望错误码为 STATEMENT_TIMEOUT, 得到 %s", err.GetCode())
+	}
+	if !err.Killed || err.Timeout != 500*time.Millisecond {
+		t.Errorf("超时异常字段不正确: %+v", err)
+	}
+}
+
+// 测试 SqlStatement 更新使用默认语句超时，且连接 ID 按连接缓存
+func TestDb_StatementTimeoutConnectionIdCache(t *testing.T) {
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		return fakeRowsOf([]string{"id"}, []driver.Value{int64(42)}), nil
+	}
+	db := fake.newDb(t, db233.EnumDatabaseTypeMySQL)
+	db.StatementTimeout = time.Second
+
+	stmt := &db233.SqlStatement{SqlList: []string{"UPDATE t SET a = 1"}}
+	for i := 0; i < 3; i++ {
+		if affected := db.ExecuteUpdateByStatement(stmt); affected != 1 {
+			t.Fatalf("更新应执行: %d", affected)
+		}
+	}
+	lookups := 0
+	for _, query := range fake.querySqls() {
+		if strings.Contains(query, "CONNECTION_ID()") {
+			lookups++
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("带超时的更新应获取一次连接 ID 并复用缓存, 实际查询 %d 次", lookups)
+	}
+}
+
+// 测试视图执行时读取原 Db 的默认超时，熔断、排空等异常原样返回
+func TestDb_StatementTimeoutResolvedAtExecution(t *testing.T) {
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		return fakeRowsOf([]string{"id"}, []driver.Value{int64(42)}), nil
+	}
+	db := fake.newDb(t, db233.EnumDatabaseTypeMySQL)
+	view := db.WithQueryTag("report")
+	db.SetStatementTimeout(time.Second)
+
+	if _, err := view.ExecuteUpdateWithTimeout("UPDATE t SET a = 1", nil, 0); err != nil {
+		t.Fatalf("更新应执行: %v", err)
+	}
+	lookups := 0
+	for _, query := range fake.querySqls() {
+		if strings.Contains(query, "CONNECTION_ID()") {
+			lookups++
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("视图应使用创建后设置的默认超时, 连接 ID 查询 %d 次", lookups)
+	}
+	if overridden := view.WithStatementTimeout(0); overridden.StatementTimeout != 0 {
+		t.Errorf("WithStatementTimeout 应覆盖默认超时: %v", overridden.StatementTimeout)
+	}
+
+	if err := db.Drain(context.Background()); err != nil {
+		t.Fatalf("排空失败: %v", err)
+	}
+	if _, err := view.ExecuteQueryWithTimeout("SELECT 1", nil, &TestUser{}, 0); err == nil {
+		t.Error("排空期间查询应失败")
+	} else if _, ok := err.(*db233.ConnectionException); !ok {
+		t.Errorf("排空异常应原样返回, 得到 %T: %v", err, err)
+	}
+	if _, err := view.ExecuteUpdateWithTimeout("UPDATE t SET a = 1", nil, 0); err == nil {
+		t.Error("排空期间更新应失败")
+	} else if _, ok := err.(*db233.ConnectionException); !ok {
+		t.Errorf("排空异常应原样返回, 得到 %T: %v", err, err)
+	}
+}
+
+// 测试超时后取消并终止语句（需要数据库）
+func TestDb_ExecuteQueryWithTimeout(t *testing.T) {
+	db := CreateTestDb(t)
+	defer db.Close()
+
+	monitor := db233.NewPerformanceMonitor("test_db", db)
+	db.SetPerformanceMonitor(monitor)
+
+	start := time.Now()
+	_, err := db.ExecuteQueryWithTimeout("SELECT SLEEP(5)", nil, &TestUser{}, 200*time.Millisecond)
+	if err == nil {
+		t.Fatal("期望超时错误")
+	}
+	if _, ok := err.(*db233.StatementTimeoutException); !ok {
+		t.Fatalf("期望 StatementTimeoutException, 得到 %T: %v", err, err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Errorf("超时后未及时返回: %v", time.Since(start))
+	}
+
+	if monitor.GetDetailedReport()["timed_out_queries"].(int64) != 1 {
+		t.Error("期望超时事件已记录")
+	}
+
+	// 默认超时
+	db.SetStatementTimeout(200 * time.Millisecond)
+	if _, err := db.ExecuteUpdateWithTimeout("DO SLEEP(5)", nil, 0); err == nil {
+		t.Error("期望默认超时生效")
+	}
+}
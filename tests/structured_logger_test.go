@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type recordingLogger struct {
+	levels   []db233.LogLevel
+	messages []string
+	keyvals  [][]interface{}
+}
+
+func (r *recordingLogger) Enabled(level db233.LogLevel) bool {
+	return level >= db233.INFO
+}
+
+func (r *recordingLogger) Log(level db233.LogLevel, msg string, keyvals ...interface{}) {
+	r.levels = append(r.levels, level)
+	r.messages = append(r.messages, msg)
+	r.keyvals = append(r.keyvals, keyvals)
+}
+
+// 测试便捷日志方法经由自定义实现输出
+func TestStructuredLogger_RoutesLogCalls(t *testing.T) {
+	recorder := &recordingLogger{}
+	db233.SetStructuredLogger(recorder)
+	defer db233.SetStructuredLogger(nil)
+
+	db233.LogDebug("被过滤 %d", 1)
+	db233.LogInfo("连接成功: %s", "game")
+	db233.LogFields(db233.WARN, "慢查询", "group", "game", "rows", 3)
+
+	if len(recorder.messages) != 2 {
+		t.Fatalf("期望 2 条日志，得到 %v", recorder.messages)
+	}
+	if recorder.levels[0] != db233.INFO || recorder.messages[0] != "连接成功: game" {
+		t.Errorf("格式化日志不正确: %v %s", recorder.levels[0], recorder.messages[0])
+	}
+	if recorder.levels[1] != db233.WARN || len(recorder.keyvals[1]) != 4 || recorder.keyvals[1][1] != "game" {
+		t.Errorf("结构化字段不正确: %v", recorder.keyvals[1])
+	}
+
+	db233.SetStructuredLogger(nil)
+	if db233.GetStructuredLogger() != db233.StructuredLogger(db233.GetLogger()) {
+		t.Error("传入 nil 应恢复默认日志")
+	}
+}
+
+// 测试 slog 适配器
+func TestStructuredLogger_Slog(t *testing.T) {
+	var buffer bytes.Buffer
+	handler := slog.NewJSONHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelInfo})
+	db233.SetStructuredLogger(db233.NewSlogLogger(slog.New(handler)))
+	defer db233.SetStructuredLogger(nil)
+
+	db233.LogDebug("不输出")
+	db233.LogFields(db233.ERROR, "查询执行失败", "sql", "SELECT 1", "dbId", 2)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("期望 1 行日志，得到 %q", buffer.String())
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("日志不是 JSON: %v", err)
+	}
+	if record["level"] != "ERROR" || record["msg"] != "查询执行失败" || record["sql"] != "SELECT 1" || record["dbId"] != float64(2) {
+		t.Errorf("slog 记录不正确: %v", record)
+	}
+}
+
+// 测试 NopLogger 丢弃日志
+func TestStructuredLogger_Nop(t *testing.T) {
+	logger := db233.NewNopLogger()
+	if logger.Enabled(db233.FATAL) {
+		t.Error("NopLogger 不应启用任何级别")
+	}
+	db233.SetStructuredLogger(logger)
+	defer db233.SetStructuredLogger(nil)
+	db233.LogError("不会输出")
+}
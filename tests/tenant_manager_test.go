@@ -0,0 +1,212 @@
+package tests
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试租户解析器
+func TestTenantResolvers(t *testing.T) {
+	ctx := db233.WithTenantId(context.Background(), "tenant_a")
+	tenantId, err := db233.NewContextTenantResolver().Resolve(ctx)
+	if err != nil || tenantId != "tenant_a" {
+		t.Errorf("context 解析失败: %s, %v", tenantId, err)
+	}
+	if _, err := db233.NewContextTenantResolver().Resolve(context.Background()); err == nil {
+		t.Error("context 中没有租户 ID 时应返回错误")
+	}
+
+	req, _ := http.NewRequest("GET", "http://localhost/", nil)
+	req.Header.Set("X-Tenant-Id", "tenant_b")
+	tenantId, err = db233.NewHeaderTenantResolver("X-Tenant-Id").Resolve(req)
+	if err != nil || tenantId != "tenant_b" {
+		t.Errorf("请求头解析失败: %s, %v", tenantId, err)
+	}
+
+	resolver := db233.FuncTenantResolver(func(source interface{}) (string, error) {
+		return "tenant_c", nil
+	})
+	tenantId, err = resolver.Resolve(nil)
+	if err != nil || tenantId != "tenant_c" {
+		t.Errorf("回调解析失败: %s, %v", tenantId, err)
+	}
+}
+
+// 测试租户注册与作用域
+func TestTenantManagerScopeRepository(t *testing.T) {
+	tm := db233.GetTenantManagerInstance()
+	defer tm.UnregisterTenant("scope_schema")
+	defer tm.UnregisterTenant("scope_column")
+
+	if err := tm.RegisterDatabaseTenant("scope_db", nil); err == nil {
+		t.Error("独立数据库模式缺少 Db 时应返回错误")
+	}
+	if err := tm.RegisterSchemaTenant("scope_schema", "tenant_schema"); err != nil {
+		t.Fatalf("注册 schema 租户失败: %v", err)
+	}
+	if err := tm.RegisterColumnTenant("scope_column"); err != nil {
+		t.Fatalf("注册共享表租户失败: %v", err)
+	}
+
+	repo := db233.NewBaseCrudRepository(nil)
+
+	schemaRepo, err := repo.ForTenant("scope_schema")
+	if err != nil {
+		t.Fatalf("绑定 schema 租户失败: %v", err)
+	}
+	if mapped := schemaRepo.GetScope().TableNameMapper("test_user"); mapped != "tenant_schema.test_user" {
+		t.Errorf("表名改写错误: %s", mapped)
+	}
+
+	columnRepo, err := repo.ForTenant("scope_column")
+	if err != nil {
+		t.Fatalf("绑定共享表租户失败: %v", err)
+	}
+	scope := columnRepo.GetScope()
+	if len(scope.Predicates) != 1 || scope.Predicates[0].Sql != "tenant_id = ?" {
+		t.Errorf("租户谓词错误: %+v", scope.Predicates)
+	}
+	if scope.ForcedColumns["tenant_id"] != "scope_column" {
+		t.Errorf("租户强制列错误: %+v", scope.ForcedColumns)
+	}
+	if repo.GetScope() != nil {
+		t.Error("原存储库不应被修改")
+	}
+
+	if _, err := repo.ForTenant("unknown_tenant"); err == nil {
+		t.Error("未注册租户应返回错误")
+	}
+}
+
+type TenantOrder struct {
+	Id       int64  `db:"id,primary_key"`
+	TenantId string `db:"tenant_id"`
+	Amount   int64  `db:"amount"`
+}
+
+func (e *TenantOrder) TableName() string { return "tenant_order" }
+
+func (e *TenantOrder) SerializeBeforeSaveDb() {}
+
+func (e *TenantOrder) DeserializeAfterLoadDb() {}
+
+// 模拟共享表：按主键保存行，主键冲突时返回 1062
+type tenantOrderTable struct {
+	mu   sync.Mutex
+	rows map[int64]map[string]driver.Value
+}
+
+func newTenantOrderDb(t *testing.T) (*db233.Db, *fakeDriver, *tenantOrderTable) {
+	table := &tenantOrderTable{rows: make(map[int64]map[string]driver.Value)}
+	fake := newFakeDriver()
+	fake.onExec = func(stmt fakeStatement) (driver.Result, error) {
+		table.mu.Lock()
+		defer table.mu.Unlock()
+		switch {
+		case strings.HasPrefix(stmt.query, "INSERT INTO tenant_order ("):
+			columns := strings.Split(stmt.query[len("INSERT INTO tenant_order ("):strings.Index(stmt.query, ")")], ",")
+			row := make(map[string]driver.Value)
+			for i, column := range columns {
+				row[column] = stmt.args[i]
+			}
+			if _, exists := table.rows[row["id"].(int64)]; exists {
+				return nil, errors.New("Error 1062 (23000): Duplicate entry for key 'PRIMARY'")
+			}
+			table.rows[row["id"].(int64)] = row
+			return driver.RowsAffected(1), nil
+		case stmt.query == "UPDATE tenant_order SET amount = ? WHERE id = ? AND tenant_id = ?":
+			row, exists := table.rows[stmt.args[1].(int64)]
+			if !exists || row["tenant_id"] != stmt.args[2] {
+				return driver.RowsAffected(0), nil
+			}
+			row["amount"] = stmt.args[0]
+			return driver.RowsAffected(1), nil
+		}
+		return nil, errors.New("未知语句: " + stmt.query)
+	}
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		table.mu.Lock()
+		defer table.mu.Unlock()
+		if stmt.query != "SELECT 1 FROM tenant_order WHERE id = ? AND tenant_id = ? LIMIT 1" {
+			return nil, errors.New("未知查询: " + stmt.query)
+		}
+		if row, exists := table.rows[stmt.args[0].(int64)]; exists && row["tenant_id"] == stmt.args[1] {
+			return fakeRowsOf([]string{"1"}, []driver.Value{int64(1)}), nil
+		}
+		return fakeRowsOf([]string{"1"}), nil
+	}
+	db233.GetCrudManagerInstance().AutoInitEntity(&TenantOrder{})
+	return fake.newDb(t, db233.EnumDatabaseTypeMySQL), fake, table
+}
+
+// 测试共享表租户保存同一主键：不能覆盖其他租户的行
+func TestTenantScopedSave_SamePrimaryKey(t *testing.T) {
+	tm := db233.GetTenantManagerInstance()
+	defer tm.UnregisterTenant("save_tenant_a")
+	defer tm.UnregisterTenant("save_tenant_b")
+	if err := tm.RegisterColumnTenant("save_tenant_a"); err != nil {
+		t.Fatalf("注册租户失败: %v", err)
+	}
+	if err := tm.RegisterColumnTenant("save_tenant_b"); err != nil {
+		t.Fatalf("注册租户失败: %v", err)
+	}
+
+	db, fake, table := newTenantOrderDb(t)
+	repo := db233.NewBaseCrudRepository(db)
+	repoA, _ := repo.ForTenant("save_tenant_a")
+	repoB, _ := repo.ForTenant("save_tenant_b")
+
+	if err := repoA.Save(&TenantOrder{Id: 1, Amount: 100}); err != nil {
+		t.Fatalf("租户 A 首次保存失败: %v", err)
+	}
+	if err := repoA.Save(&TenantOrder{Id: 1, Amount: 120}); err != nil {
+		t.Fatalf("租户 A 更新失败: %v", err)
+	}
+	if row := table.rows[1]; row["tenant_id"] != "save_tenant_a" || row["amount"] != int64(120) {
+		t.Errorf("租户 A 的行不正确: %v", row)
+	}
+
+	// 租户 B 使用同一主键：UPDATE 不命中、可见行不存在，INSERT 由主键约束拒绝
+	if err := repoB.Save(&TenantOrder{Id: 1, Amount: 999}); err == nil {
+		t.Error("其他租户占用的主键应保存失败")
+	}
+	if fake.rollbacks != 1 {
+		t.Errorf("作用域保存失败时应回滚事务: %d", fake.rollbacks)
+	}
+	if err := repoB.NewUnitOfWork().Save(&TenantOrder{Id: 1, Amount: 999}).Commit(); err == nil {
+		t.Error("工作单元保存其他租户占用的主键应失败")
+	}
+	if fake.rollbacks != 2 {
+		t.Errorf("工作单元失败时应回滚: %d", fake.rollbacks)
+	}
+	if row := table.rows[1]; row["tenant_id"] != "save_tenant_a" || row["amount"] != int64(120) {
+		t.Errorf("租户 A 的行不应被覆盖: %v", row)
+	}
+
+	// 工作单元按租户作用域更新自己的行
+	if err := repoA.NewUnitOfWork().Save(&TenantOrder{Id: 1, Amount: 130}).Commit(); err != nil {
+		t.Fatalf("工作单元保存失败: %v", err)
+	}
+	if row := table.rows[1]; row["amount"] != int64(130) {
+		t.Errorf("工作单元应更新租户 A 的行: %v", row)
+	}
+
+	// UPDATE / SELECT / INSERT 在同一事务中执行，避免并发保存在步骤之间插入或删除行
+	for _, stmt := range fake.recorded() {
+		if !stmt.inTx {
+			t.Errorf("作用域保存的语句应在事务中执行: %s", stmt.query)
+		}
+	}
+	for _, sql := range fake.execSqls() {
+		if strings.Contains(sql, "ON DUPLICATE KEY UPDATE") {
+			t.Errorf("租户作用域保存不应使用 ON DUPLICATE KEY UPDATE: %s", sql)
+		}
+	}
+}
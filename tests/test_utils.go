@@ -1,7 +1,12 @@
 package tests
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
 	"testing"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -97,3 +102,254 @@ func (u *TestUser) SerializeBeforeSaveDb() {
 func (u *TestUser) DeserializeAfterLoadDb() {
 	// 测试中不需要特殊处理，留空即可
 }
+
+// fakeStatement 假驱动记录的一条语句
+type fakeStatement struct {
+	conn  string // 打开数据源时使用的名称，用于区分多个数据源
+	query string
+	args  []driver.Value
+	exec  bool // true 为 Exec，false 为 Query
+	inTx  bool
+}
+
+// fakeResultSet 假驱动返回的单个结果集，err 不为空时读取该结果集返回此错误
+type fakeResultSet struct {
+	columns []string
+	values  [][]driver.Value
+	err     error
+}
+
+// fakeResult 假驱动 Exec 的返回结果
+type fakeResult struct {
+	lastInsertId int64
+	rowsAffected int64
+}
+
+func (r fakeResult) LastInsertId() (int64, error) { return r.lastInsertId, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return r.rowsAffected, nil }
+
+// fakeDriver 测试共用的可配置记录型假驱动
+//
+// 记录所有执行过的语句；onExec / onQuery 定制返回结果，未设置时 Exec 返回影响 1 行、Query 返回错误。
+// 回调在不持有锁的情况下调用，可以在其中阻塞。
+type fakeDriver struct {
+	onExec  func(stmt fakeStatement) (driver.Result, error)
+	onQuery func(stmt fakeStatement) (*fakeRows, error)
+	// 为 true 时 Begin 返回错误
+	noTx bool
+	// 不为 nil 时 Commit 返回该错误
+	commitErr error
+
+	mu         sync.Mutex
+	statements []fakeStatement
+	begins     int
+	commits    int
+	rollbacks  int
+	closedRows int
+}
+
+type fakeConnector struct {
+	driver *fakeDriver
+	name   string
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+	name   string
+	inTx   bool
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+type fakeTx struct{ conn *fakeConn }
+
+// fakeRows 假驱动的结果集，支持多结果集
+type fakeRows struct {
+	driver *fakeDriver
+	sets   []fakeResultSet
+	set    int
+	row    int
+}
+
+// newFakeDriver 创建假驱动
+func newFakeDriver() *fakeDriver {
+	return &fakeDriver{}
+}
+
+// fakeRowsOf 构造单结果集
+func fakeRowsOf(columns []string, values ...[]driver.Value) *fakeRows {
+	return &fakeRows{sets: []fakeResultSet{{columns: columns, values: values}}}
+}
+
+// fakeResultSets 构造多结果集
+func fakeResultSets(sets ...fakeResultSet) *fakeRows {
+	return &fakeRows{sets: sets}
+}
+
+// open 打开名为 name 的数据源，测试结束时自动关闭
+func (f *fakeDriver) open(t *testing.T, name string) *sql.DB {
+	dataSource := sql.OpenDB(fakeConnector{driver: f, name: name})
+	t.Cleanup(func() { dataSource.Close() })
+	return dataSource
+}
+
+// newDb 基于假驱动创建指定类型的 Db
+func (f *fakeDriver) newDb(t *testing.T, dbType db233.EnumDatabaseType) *db233.Db {
+	return db233.NewDbWithType(f.open(t, ""), 1, nil, dbType)
+}
+
+// reset 清空记录的语句与事务计数
+func (f *fakeDriver) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statements = nil
+	f.begins, f.commits, f.rollbacks, f.closedRows = 0, 0, 0, 0
+}
+
+// recorded 返回记录的全部语句
+func (f *fakeDriver) recorded() []fakeStatement {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeStatement(nil), f.statements...)
+}
+
+// execSqls 返回通过 Exec 执行的 SQL
+func (f *fakeDriver) execSqls() []string {
+	return f.sqls(true)
+}
+
+// querySqls 返回通过 Query 执行的 SQL
+func (f *fakeDriver) querySqls() []string {
+	return f.sqls(false)
+}
+
+// execArgs 返回通过 Exec 执行的参数
+func (f *fakeDriver) execArgs() [][]driver.Value {
+	var args [][]driver.Value
+	for _, stmt := range f.recorded() {
+		if stmt.exec {
+			args = append(args, stmt.args)
+		}
+	}
+	return args
+}
+
+func (f *fakeDriver) sqls(exec bool) []string {
+	var sqls []string
+	for _, stmt := range f.recorded() {
+		if stmt.exec == exec {
+			sqls = append(sqls, stmt.query)
+		}
+	}
+	return sqls
+}
+
+func (f *fakeDriver) record(stmt fakeStatement) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statements = append(f.statements, stmt)
+}
+
+func (f *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{driver: f, name: name}, nil
+}
+
+func (c fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.name)
+}
+func (c fakeConnector) Driver() driver.Driver { return c.driver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+func (c *fakeConn) Close() error { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	if c.driver.noTx {
+		return nil, errors.New("不支持事务")
+	}
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	c.driver.begins++
+	c.inTx = true
+	return &fakeTx{conn: c}, nil
+}
+
+func (tx *fakeTx) Commit() error {
+	tx.conn.driver.mu.Lock()
+	defer tx.conn.driver.mu.Unlock()
+	tx.conn.driver.commits++
+	tx.conn.inTx = false
+	return tx.conn.driver.commitErr
+}
+func (tx *fakeTx) Rollback() error {
+	tx.conn.driver.mu.Lock()
+	defer tx.conn.driver.mu.Unlock()
+	tx.conn.driver.rollbacks++
+	tx.conn.inTx = false
+	return nil
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) statement(args []driver.Value, exec bool) fakeStatement {
+	s.conn.driver.mu.Lock()
+	inTx := s.conn.inTx
+	s.conn.driver.mu.Unlock()
+	return fakeStatement{conn: s.conn.name, query: s.query, args: args, exec: exec, inTx: inTx}
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	stmt := s.statement(args, true)
+	s.conn.driver.record(stmt)
+	if s.conn.driver.onExec == nil {
+		return driver.RowsAffected(1), nil
+	}
+	return s.conn.driver.onExec(stmt)
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	stmt := s.statement(args, false)
+	s.conn.driver.record(stmt)
+	if s.conn.driver.onQuery == nil {
+		return nil, errors.New("未知查询: " + s.query)
+	}
+	rows, err := s.conn.driver.onQuery(stmt)
+	if err != nil {
+		return nil, err
+	}
+	rows.driver = s.conn.driver
+	return rows, nil
+}
+
+func (r *fakeRows) Columns() []string { return r.sets[r.set].columns }
+func (r *fakeRows) Close() error {
+	r.driver.mu.Lock()
+	defer r.driver.mu.Unlock()
+	r.driver.closedRows++
+	return nil
+}
+func (r *fakeRows) Next(dest []driver.Value) error {
+	current := r.sets[r.set]
+	if current.err != nil {
+		return current.err
+	}
+	if r.row >= len(current.values) {
+		return io.EOF
+	}
+	copy(dest, current.values[r.row])
+	r.row++
+	return nil
+}
+func (r *fakeRows) HasNextResultSet() bool { return r.set+1 < len(r.sets) }
+func (r *fakeRows) NextResultSet() error {
+	if !r.HasNextResultSet() {
+		return io.EOF
+	}
+	r.set++
+	r.row = 0
+	return nil
+}
@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type TimePolicyOrder struct {
+	Id        int64      `db:"id,primary_key"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" precision:"3"`
+	PaidAt    *time.Time `db:"paid_at"`
+	ShippedAt time.Time  `db:"shipped_at" db_type:"DATETIME(6)"`
+	ClosedAt  time.Time  `db:"closed_at"`
+}
+
+// 值接收者：FindByCondition 返回值类型的实体
+func (e TimePolicyOrder) TableName() string { return "time_policy_order" }
+
+func (e TimePolicyOrder) SerializeBeforeSaveDb() {}
+
+func (e TimePolicyOrder) DeserializeAfterLoadDb() {}
+
+// 记录写入参数，查询返回驱动原生时间、无时区文本、MySQL 零日期与 NULL
+func openTimePolicyDb(t *testing.T) (*db233.Db, *fakeDriver) {
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		return fakeRowsOf([]string{"id", "created_at", "updated_at", "paid_at", "shipped_at", "closed_at"}, []driver.Value{
+			int64(1),
+			time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+			"2026-10-16 00:00:00.123",
+			[]byte("0000-00-00 00:00:00"),
+			[]byte("2026-10-16T08:00:00+08:00"),
+			nil,
+		}), nil
+	}
+	return fake.newDb(t, db233.EnumDatabaseTypeMySQL), fake
+}
+
+var timePolicyShanghai = time.FixedZone("CST", 8*3600)
+
+// 测试写入时转换为 UTC、按精度截断、零值与 nil 指针写为 NULL
+func TestTimePolicy_StoreAsUTC(t *testing.T) {
+	db, fake := openTimePolicyDb(t)
+	local := time.Date(2026, 10, 16, 8, 0, 0, 123456789, timePolicyShanghai)
+	order := &TimePolicyOrder{Id: 1, CreatedAt: local, UpdatedAt: local, ShippedAt: local}
+	if err := db233.NewBaseCrudRepository(db).Save(order); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+
+	params := fake.execArgs()[0]
+	var times []time.Time
+	nulls := 0
+	for _, value := range params {
+		switch v := value.(type) {
+		case time.Time:
+			if v.Location() != time.UTC || !v.Truncate(time.Second).Equal(local.Truncate(time.Second)) {
+				t.Errorf("应以 UTC 存储: %v", v)
+			}
+			times = append(times, v)
+		case nil:
+			nulls++
+		}
+	}
+	if len(times) != 3 || nulls != 2 {
+		t.Fatalf("应写入 3 个时间与 2 个 NULL: %#v", params)
+	}
+	nanos := map[int]bool{}
+	for _, v := range times {
+		nanos[v.Nanosecond()] = true
+	}
+	if !nanos[123456789] || !nanos[123000000] || !nanos[123456000] {
+		t.Errorf("precision 标签 / db_type 精度截断不正确: %v", nanos)
+	}
+
+	// 关闭 ZeroAsNull、按业务时区存储
+	db.SetTimePolicy(db233.NewTimePolicy().SetStoreUTC(false).SetLocation(timePolicyShanghai).SetZeroAsNull(false))
+	fake.reset()
+	utc := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	if err := db233.NewBaseCrudRepository(db).Save(&TimePolicyOrder{Id: 2, CreatedAt: utc}); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	params = fake.execArgs()[0]
+	zeros := 0
+	for _, value := range params {
+		if v, ok := value.(time.Time); ok {
+			if v.IsZero() {
+				zeros++
+			} else if v.Location() != timePolicyShanghai || v.Hour() != 8 {
+				t.Errorf("应转换为业务时区存储: %v", v)
+			}
+		}
+	}
+	if zeros != 3 {
+		t.Errorf("关闭 ZeroAsNull 后零值应原样写入: %#v", params)
+	}
+}
+
+// 测试读取时转换到配置时区，MySQL 零日期与 NULL 读为零值
+func TestTimePolicy_ScanToLocation(t *testing.T) {
+	db, _ := openTimePolicyDb(t)
+	db.SetTimePolicy(db233.NewTimePolicy().SetLocation(timePolicyShanghai))
+	results, err := db233.NewBaseCrudRepository(db).FindByCondition("id = ?", []interface{}{1}, TimePolicyOrder{})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("查询失败: %v %v", results, err)
+	}
+	order := results[0].(TimePolicyOrder)
+
+	if order.CreatedAt.Location() != timePolicyShanghai || order.CreatedAt.Hour() != 8 {
+		t.Errorf("驱动时间应转换到配置时区: %v", order.CreatedAt)
+	}
+	// 不带时区的文本按存储时区（UTC）解析
+	if order.UpdatedAt.Hour() != 8 || order.UpdatedAt.Nanosecond() != 123000000 {
+		t.Errorf("无时区文本应按 UTC 解析: %v", order.UpdatedAt)
+	}
+	if order.PaidAt != nil || !order.ClosedAt.IsZero() {
+		t.Errorf("零日期与 NULL 应为零值: %v %v", order.PaidAt, order.ClosedAt)
+	}
+	if !order.ShippedAt.Equal(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("带时区的文本应按其时区解析: %v", order.ShippedAt)
+	}
+
+	// 全局默认策略
+	db233.SetDefaultTimePolicy(db233.NewTimePolicy().SetLocation(timePolicyShanghai))
+	defer db233.SetDefaultTimePolicy(nil)
+	if db233.NewDb(nil, 2, nil).GetTimePolicy().Location != timePolicyShanghai {
+		t.Error("未单独设置策略的 Db 应使用全局默认策略")
+	}
+}
+
+// 测试 precision 标签生成带精度的列类型
+func TestTimePolicy_PrecisionColumnType(t *testing.T) {
+	cm := db233.GetCrudManagerInstance()
+	field, _ := reflect.TypeOf(TimePolicyOrder{}).FieldByName("UpdatedAt")
+	if sqlType := db233.NewMySQLStrategy(cm).GetSQLType(field); sqlType != "TIMESTAMP(3)" {
+		t.Errorf("MySQL 列类型不正确: %s", sqlType)
+	}
+	if sqlType := db233.NewSQLServerStrategy(cm).GetSQLType(field); sqlType != "DATETIME2(3)" {
+		t.Errorf("SQL Server 列类型不正确: %s", sqlType)
+	}
+	field, _ = reflect.TypeOf(TimePolicyOrder{}).FieldByName("CreatedAt")
+	if sqlType := db233.NewMySQLStrategy(cm).GetSQLType(field); sqlType != "TIMESTAMP" {
+		t.Errorf("未声明精度时保持原类型: %s", sqlType)
+	}
+}
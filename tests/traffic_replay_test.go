@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试慢语句记录插件
+func TestSlowQueryRecorderPlugin(t *testing.T) {
+	var buf bytes.Buffer
+	plugin := db233.NewSlowQueryRecorderPlugin(&buf, 100*time.Millisecond)
+
+	fast := db233.NewExecuteSqlContext("SELECT 1", nil)
+	fast.Duration = 10 * time.Millisecond
+	plugin.PostExecuteSql(fast)
+
+	slow := db233.NewExecuteSqlContext("SELECT * FROM test_user WHERE id = ?", []interface{}{1})
+	slow.Duration = 200 * time.Millisecond
+	plugin.PostExecuteSql(slow)
+
+	if plugin.GetRecordedCount() != 1 {
+		t.Fatalf("期望记录 1 条慢语句, 得到 %d", plugin.GetRecordedCount())
+	}
+	if !strings.Contains(buf.String(), `"duration_ms":200`) {
+		t.Errorf("记录内容不正确: %s", buf.String())
+	}
+}
+
+// 测试默认跳过写语句
+func TestTrafficReplayer_SkipsWrites(t *testing.T) {
+	input := `{"sql": "DELETE FROM test_user", "duration_ms": 10}
+{"sql": "UPDATE test_user SET age = 1", "duration_ms": 10}
+`
+	report, err := db233.NewTrafficReplayer(nil).Replay(strings.NewReader(input), db233.ReplayOptions{})
+	if err != nil {
+		t.Fatalf("回放失败: %v", err)
+	}
+	if report.Total != 2 || report.Skipped != 2 || report.Succeeded != 0 {
+		t.Errorf("写语句应全部跳过: %+v", report)
+	}
+}
+
+// 测试并发回放（需要数据库）
+func TestTrafficReplayer_Replay(t *testing.T) {
+	db := CreateTestDb(t)
+	defer db.Close()
+
+	input := `{"sql": "SELECT ?", "params": [1], "duration_ms": 1000}
+{"sql": "SELECT SLEEP(0.01)", "duration_ms": 1000}
+{"sql": "SELECT * FROM not_exists_table", "duration_ms": 5}
+`
+	report, err := db233.NewTrafficReplayer(db).Replay(strings.NewReader(input), db233.ReplayOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("回放失败: %v", err)
+	}
+	if report.Succeeded != 2 || report.Failed != 1 {
+		t.Errorf("期望成功 2 条、失败 1 条: %+v", report)
+	}
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试提交后只执行 OnCommit 回调，回滚后只执行 OnRollback 回调，且只执行一次
+func TestTransactionHooks_CommitAndRollback(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	var events []string
+	record := func(event string) func() { return func() { events = append(events, event) } }
+
+	tm := db233.NewTransactionManager(db)
+	if err := tm.OnCommit(record("commit")); err == nil {
+		t.Error("没有活跃事务时注册回调应报错")
+	}
+	if err := tm.Begin(); err != nil {
+		t.Fatalf("开始事务失败: %v", err)
+	}
+	tm.OnCommit(record("commit-1"))
+	tm.OnRollback(record("rollback-1"))
+	tm.OnCommit(func() { panic("回调异常") })
+	tm.OnCommit(record("commit-2"))
+	if err := tm.Commit(); err != nil {
+		t.Fatalf("提交失败: %v", err)
+	}
+	if !reflect.DeepEqual(events, []string{"commit-1", "commit-2"}) {
+		t.Errorf("提交回调不正确: %v", events)
+	}
+
+	events = nil
+	err := tm.ExecuteInTransaction(func(tm *db233.TransactionManager) error {
+		tm.OnCommit(record("commit"))
+		tm.OnRollback(record("rollback"))
+		return errors.New("业务失败")
+	})
+	if err == nil || !reflect.DeepEqual(events, []string{"rollback"}) {
+		t.Errorf("回滚回调不正确: %v %v", events, err)
+	}
+
+	events = nil
+	if err := tm.Begin(); err != nil {
+		t.Fatalf("开始事务失败: %v", err)
+	}
+	if err := tm.Commit(); err != nil || len(events) != 0 {
+		t.Errorf("上一个事务的回调不应再次执行: %v %v", events, err)
+	}
+}
+
+// 测试嵌套事务在最外层提交时执行回调，保存点回滚丢弃其中的提交回调
+func TestTransactionHooks_NestedAndSavepoint(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	var events []string
+	record := func(event string) func() { return func() { events = append(events, event) } }
+
+	err := db233.WithTransaction(db, func(tm *db233.TransactionManager) error {
+		bound := db.WithContext(db233.ContextWithTx(context.Background(), tm))
+		if err := db233.WithTransaction(bound, func(inner *db233.TransactionManager) error {
+			return inner.OnCommit(record("nested"))
+		}); err != nil {
+			return err
+		}
+		if len(events) != 0 {
+			t.Errorf("嵌套事务结束时不应执行回调: %v", events)
+		}
+
+		tm.RunInSavepoint("kept", func(tm *db233.TransactionManager) error {
+			return tm.OnCommit(record("kept"))
+		})
+		tm.RunInSavepoint("undone", func(tm *db233.TransactionManager) error {
+			tm.OnCommit(record("undone"))
+			tm.OnRollback(record("undone-rollback"))
+			return errors.New("保存点失败")
+		})
+		if !reflect.DeepEqual(events, []string{"undone-rollback"}) {
+			t.Errorf("回滚到保存点应执行其中的回滚回调: %v", events)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("事务失败: %v", err)
+	}
+	if !reflect.DeepEqual(events, []string{"undone-rollback", "nested", "kept"}) {
+		t.Errorf("提交回调不正确: %v", events)
+	}
+}
+
+// 测试提交失败时按回滚处理：执行 OnRollback 回调、丢弃 OnCommit 回调并释放事务状态
+func TestTransactionHooks_CommitFailure(t *testing.T) {
+	fake := newFakeDriver()
+	fake.commitErr = errors.New("serialization failure")
+	db := fake.newDb(t, db233.EnumDatabaseTypeMySQL)
+	detector := db233.NewLeakDetector("commit_failure")
+	db.SetLeakDetector(detector)
+	var events []string
+
+	tm := db233.NewTransactionManager(db)
+	err := tm.ExecuteInTransaction(func(tm *db233.TransactionManager) error {
+		tm.OnCommit(func() { events = append(events, "commit") })
+		tm.OnRollback(func() { events = append(events, "rollback") })
+		return nil
+	})
+	if err == nil {
+		t.Fatal("提交失败应返回错误")
+	}
+	if !reflect.DeepEqual(events, []string{"rollback"}) {
+		t.Errorf("提交失败应只执行回滚回调: %v", events)
+	}
+	if tm.IsActive() {
+		t.Error("提交失败后事务应已结束")
+	}
+	if open := detector.GetStatus()["open_transactions"]; open != 0 {
+		t.Errorf("提交失败后不应再跟踪事务: %v", open)
+	}
+
+	// 同一个管理器可以开启新事务，旧回调不会再次执行
+	fake.commitErr = nil
+	events = nil
+	if err := tm.ExecuteInTransaction(func(tm *db233.TransactionManager) error { return nil }); err != nil || len(events) != 0 {
+		t.Errorf("新事务应正常提交且不执行旧回调: %v %v", events, err)
+	}
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 测试序列化失败时回滚并重试整个函数
+func TestTransactional_RetryOnSerializationFailure(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	repo := db233.NewBaseCrudRepository(db)
+
+	attempts := 0
+	placeOrder := db233.Transactional(db, db233.TransactionalOptions{RetryBackoff: time.Millisecond})(func(ctx context.Context) error {
+		attempts++
+		if err := repo.WithContext(ctx).Save(&TxOrder{Id: 1, Amount: 100}); err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return errors.New("pq: could not serialize access due to concurrent update (SQLSTATE 40001)")
+		}
+		return nil
+	})
+	if err := placeOrder(context.Background()); err != nil {
+		t.Fatalf("重试后应成功: %v", err)
+	}
+	if attempts != 3 || txContextFake.begins != 3 || txContextFake.rollbacks != 2 || txContextFake.commits != 1 {
+		t.Errorf("重试次数不正确: attempts=%d begins=%d rollbacks=%d commits=%d", attempts, txContextFake.begins, txContextFake.rollbacks, txContextFake.commits)
+	}
+	for _, statement := range txContextStatements() {
+		if !statement.inTx {
+			t.Errorf("语句应在事务中执行: %s", statement.query)
+		}
+	}
+
+	// 非序列化失败、以及禁用重试时不重试
+	attempts = 0
+	expected := errors.New("余额不足")
+	err := db233.Transactional(db)(func(ctx context.Context) error {
+		attempts++
+		return expected
+	})(context.Background())
+	if !errors.Is(err, expected) || attempts != 1 {
+		t.Errorf("业务错误不应重试: attempts=%d err=%v", attempts, err)
+	}
+	attempts = 0
+	db233.Transactional(db, db233.TransactionalOptions{MaxRetries: -1})(func(ctx context.Context) error {
+		attempts++
+		return errors.New("Error 1213: Deadlock found when trying to get lock")
+	})(context.Background())
+	if attempts != 1 {
+		t.Errorf("MaxRetries 为负数时不应重试: %d", attempts)
+	}
+}
+
+// 测试传播行为
+func TestTransactional_Propagation(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	repo := db233.NewBaseCrudRepository(db)
+
+	var inner *db233.TransactionManager
+	required := db233.Transactional(db)(func(ctx context.Context) error {
+		inner = db233.TxFromContext(ctx)
+		return nil
+	})
+	requiresNew := db233.Transactional(db, db233.TransactionalOptions{Propagation: db233.TxPropagationRequiresNew})(func(ctx context.Context) error {
+		inner = db233.TxFromContext(ctx)
+		return nil
+	})
+	notSupported := db233.Transactional(db, db233.TransactionalOptions{Propagation: db233.TxPropagationNotSupported})(func(ctx context.Context) error {
+		return repo.WithContext(ctx).Save(&TxOrder{Id: 1})
+	})
+	never := db233.Transactional(db, db233.TransactionalOptions{Propagation: db233.TxPropagationNever})(func(ctx context.Context) error {
+		return nil
+	})
+
+	err := db233.RunInTransaction(context.Background(), db, func(ctx context.Context) error {
+		outer := db233.TxFromContext(ctx)
+		if err := required(ctx); err != nil || inner != outer {
+			t.Errorf("REQUIRED 应加入外层事务: %v", err)
+		}
+		if err := requiresNew(ctx); err != nil || inner == outer || inner == nil {
+			t.Errorf("REQUIRES_NEW 应新开事务: %v", err)
+		}
+		if err := notSupported(ctx); err != nil {
+			t.Errorf("NOT_SUPPORTED 执行失败: %v", err)
+		}
+		if err := never(ctx); err == nil {
+			t.Error("NEVER 在事务中应返回错误")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("事务执行失败: %v", err)
+	}
+	if txContextFake.begins != 2 || txContextFake.commits != 2 {
+		t.Errorf("应开启外层与 REQUIRES_NEW 两个事务: begins=%d commits=%d", txContextFake.begins, txContextFake.commits)
+	}
+	if statements := txContextStatements(); len(statements) != 1 || statements[0].inTx {
+		t.Errorf("NOT_SUPPORTED 的语句应在事务外执行: %+v", statements)
+	}
+
+	mandatory := db233.Transactional(db, db233.TransactionalOptions{Propagation: db233.TxPropagationMandatory})(func(ctx context.Context) error {
+		return nil
+	})
+	if err := mandatory(context.Background()); err == nil {
+		t.Error("MANDATORY 没有事务时应返回错误")
+	}
+	if err := never(context.Background()); err != nil {
+		t.Errorf("NEVER 没有事务时应执行: %v", err)
+	}
+}
+
+// 用于测试原地包装的服务
+type transactionalOrderService struct {
+	PlaceOrder  func(ctx context.Context) error
+	CancelOrder func(ctx context.Context) error
+}
+
+// 测试原地包装服务方法与有返回值的包装
+func TestTransactional_Decorate(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+
+	var seen []*db233.TransactionManager
+	service := &transactionalOrderService{
+		PlaceOrder: func(ctx context.Context) error {
+			seen = append(seen, db233.TxFromContext(ctx))
+			return nil
+		},
+	}
+	db233.Transactional(db).Decorate(&service.PlaceOrder, &service.CancelOrder)
+	if service.CancelOrder != nil {
+		t.Error("nil 字段不应被包装")
+	}
+	if err := service.PlaceOrder(context.Background()); err != nil || len(seen) != 1 || seen[0] == nil {
+		t.Fatalf("包装后应在事务中执行: %v", err)
+	}
+
+	countOrders := db233.Transactional(db).WithResult(func(ctx context.Context) (interface{}, error) {
+		return db233.NewBaseCrudRepository(db).WithContext(ctx).Count(&TxOrder{})
+	})
+	result, err := countOrders(context.Background())
+	if err != nil || result.(int64) != 3 {
+		t.Errorf("返回值不正确: %v %v", result, err)
+	}
+	if txContextFake.commits != 2 {
+		t.Errorf("提交次数不正确: %d", txContextFake.commits)
+	}
+}
@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type TxOrder struct {
+	Id     int64 `db:"id,primary_key"`
+	Amount int64 `db:"amount"`
+}
+
+func (e *TxOrder) TableName() string { return "tx_order" }
+
+func (e *TxOrder) SerializeBeforeSaveDb() {}
+
+func (e *TxOrder) DeserializeAfterLoadDb() {}
+
+type TxStock struct {
+	Id    int64 `db:"id,primary_key"`
+	Count int64 `db:"count"`
+}
+
+func (e *TxStock) TableName() string { return "tx_stock" }
+
+func (e *TxStock) SerializeBeforeSaveDb() {}
+
+func (e *TxStock) DeserializeAfterLoadDb() {}
+
+// 多个测试共用的假驱动：记录语句是否在事务中执行，查询返回 count = 3
+var txContextFake = newTxContextFake()
+
+func newTxContextFake() *fakeDriver {
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		return fakeRowsOf([]string{"count"}, []driver.Value{int64(3)}), nil
+	}
+	return fake
+}
+
+func resetTxContextFake() {
+	txContextFake.reset()
+}
+
+func newTxContextDb(t *testing.T, dbId int) *db233.Db {
+	return db233.NewDb(txContextFake.open(t, ""), dbId, nil)
+}
+
+func txContextStatements() []fakeStatement {
+	return txContextFake.recorded()
+}
+
+// 测试多个存储库通过 context 加入同一事务，嵌套调用不开启新事务
+func TestTxContext_RepositoriesJoinTransaction(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	orderRepo := db233.NewBaseCrudRepository(db)
+	stockRepo := db233.NewBaseCrudRepository(db)
+
+	deductStock := func(ctx context.Context) error {
+		return db233.RunInTransaction(ctx, db, func(ctx context.Context) error {
+			if count, err := stockRepo.WithContext(ctx).Count(&TxStock{}); err != nil || count != 3 {
+				return errors.New("计数失败")
+			}
+			return stockRepo.WithContext(ctx).Save(&TxStock{Id: 1, Count: 2})
+		})
+	}
+	err := db233.RunInTransaction(context.Background(), db, func(ctx context.Context) error {
+		if db233.TxFromContext(ctx) == nil {
+			t.Error("context 中应携带事务")
+		}
+		if err := orderRepo.WithContext(ctx).Save(&TxOrder{Id: 1, Amount: 100}); err != nil {
+			return err
+		}
+		if err := deductStock(ctx); err != nil {
+			return err
+		}
+		return orderRepo.WithContext(ctx).NewUnitOfWork().Save(&TxOrder{Id: 2, Amount: 50}).Commit()
+	})
+	if err != nil {
+		t.Fatalf("事务执行失败: %v", err)
+	}
+
+	statements := txContextStatements()
+	if len(statements) != 4 {
+		t.Fatalf("语句数不正确: %+v", statements)
+	}
+	for _, statement := range statements {
+		if !statement.inTx {
+			t.Errorf("语句应在事务中执行: %s", statement.query)
+		}
+	}
+	if txContextFake.begins != 1 || txContextFake.commits != 1 || txContextFake.rollbacks != 0 {
+		t.Errorf("应只开启并提交一个事务: begins=%d commits=%d rollbacks=%d", txContextFake.begins, txContextFake.commits, txContextFake.rollbacks)
+	}
+}
+
+// 测试返回错误时回滚
+func TestTxContext_RollbackOnError(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	repo := db233.NewBaseCrudRepository(db)
+
+	expected := errors.New("库存不足")
+	err := db233.RunInTransaction(context.Background(), db, func(ctx context.Context) error {
+		if err := repo.WithContext(ctx).Save(&TxOrder{Id: 1, Amount: 100}); err != nil {
+			return err
+		}
+		return expected
+	})
+	if !errors.Is(err, expected) {
+		t.Fatalf("应返回 fn 的错误: %v", err)
+	}
+	if txContextFake.commits != 0 || txContextFake.rollbacks != 1 {
+		t.Errorf("应回滚事务: commits=%d rollbacks=%d", txContextFake.commits, txContextFake.rollbacks)
+	}
+}
+
+// 测试事务只对同一个 Db 生效，事务结束后绑定的存储库返回错误
+func TestTxContext_Scope(t *testing.T) {
+	resetTxContextFake()
+	db := newTxContextDb(t, 1)
+	otherDb := newTxContextDb(t, 2)
+
+	tm := db233.NewTransactionManager(db)
+	if err := tm.Begin(); err != nil {
+		t.Fatalf("开始事务失败: %v", err)
+	}
+	ctx := db233.ContextWithTx(context.Background(), tm)
+
+	if db.WithContext(ctx).GetTransaction() != tm || db.WithPriority(db233.QueryPriorityBatch).WithContext(ctx).GetTransaction() != tm {
+		t.Error("同一 Db 及其优先级视图应绑定事务")
+	}
+	if otherDb.WithContext(ctx) != otherDb || db.WithContext(context.Background()) != db {
+		t.Error("其他 Db 或没有事务的 context 不应绑定事务")
+	}
+
+	boundRepo := db233.NewBaseCrudRepository(db).WithContext(ctx)
+	if err := db233.NewBaseCrudRepository(otherDb).WithContext(ctx).Save(&TxOrder{Id: 1}); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	if err := boundRepo.Save(&TxOrder{Id: 2}); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	statements := txContextStatements()
+	if len(statements) != 2 || statements[0].inTx || !statements[1].inTx {
+		t.Errorf("只有同一 Db 的语句应在事务中执行: %+v", statements)
+	}
+
+	if err := tm.Commit(); err != nil {
+		t.Fatalf("提交失败: %v", err)
+	}
+	if err := boundRepo.Save(&TxOrder{Id: 3}); err == nil {
+		t.Error("事务结束后绑定的存储库应返回错误")
+	}
+}
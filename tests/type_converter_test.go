@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// 模拟第三方定点数类型（无法为其实现 Valuer / Scanner）
+type ConverterMoney struct {
+	cents int64
+}
+
+func (m ConverterMoney) String() string { return fmt.Sprintf("%d.%02d", m.cents/100, m.cents%100) }
+
+func parseConverterMoney(text string) (ConverterMoney, error) {
+	parts := strings.SplitN(text, ".", 2)
+	units, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return ConverterMoney{}, err
+	}
+	cents := int64(0)
+	if len(parts) == 2 {
+		if cents, err = strconv.ParseInt((parts[1] + "00")[:2], 10, 64); err != nil {
+			return ConverterMoney{}, err
+		}
+	}
+	return ConverterMoney{cents: units*100 + cents}, nil
+}
+
+// 自定义 ID 类型，数据库中为 "P-" 前缀的字符串
+type ConverterPlayerId struct {
+	value int64
+}
+
+type ConverterAccount struct {
+	Id      ConverterPlayerId `db:"id,primary_key"`
+	Balance ConverterMoney    `db:"balance"`
+	Credit  *ConverterMoney   `db:"credit"`
+}
+
+// 值接收者：FindByCondition 返回值类型的实体
+func (e ConverterAccount) TableName() string { return "converter_account" }
+
+func (e ConverterAccount) SerializeBeforeSaveDb() {}
+
+func (e ConverterAccount) DeserializeAfterLoadDb() {}
+
+func registerConverterTestTypes(t *testing.T) {
+	moneyType := reflect.TypeOf(ConverterMoney{})
+	idType := reflect.TypeOf(ConverterPlayerId{})
+	db233.RegisterTypeConverter(moneyType,
+		func(v interface{}) (interface{}, error) { return v.(ConverterMoney).String(), nil },
+		func(v interface{}) (interface{}, error) { return parseConverterMoney(fmt.Sprintf("%s", v)) },
+	).SetSQLType("DECIMAL(20, 2)").SetSQLTypeFor(db233.EnumDatabaseTypeSQLServer, "DECIMAL(19, 2)")
+	db233.RegisterTypeConverter(idType,
+		func(v interface{}) (interface{}, error) { return fmt.Sprintf("P-%d", v.(ConverterPlayerId).value), nil },
+		func(v interface{}) (interface{}, error) {
+			id, err := strconv.ParseInt(strings.TrimPrefix(fmt.Sprintf("%s", v), "P-"), 10, 64)
+			return ConverterPlayerId{value: id}, err
+		},
+	).SetSQLType("VARCHAR(32)")
+	t.Cleanup(func() {
+		db233.GetTypeConverterRegistryInstance().Unregister(moneyType)
+		db233.GetTypeConverterRegistryInstance().Unregister(idType)
+	})
+}
+
+// 查询返回转换器格式的文本
+func openConverterDb(t *testing.T) (*db233.Db, *fakeDriver) {
+	fake := newFakeDriver()
+	fake.onQuery = func(stmt fakeStatement) (*fakeRows, error) {
+		return fakeRowsOf([]string{"id", "balance", "credit"},
+			[]driver.Value{[]byte("P-7"), []byte("12.34"), nil},
+			[]driver.Value{"P-8", "0.5", []byte("3.00")},
+		), nil
+	}
+	return fake.newDb(t, db233.EnumDatabaseTypeMySQL), fake
+}
+
+// 测试保存、查询参数与 ORM 映射统一使用转换器
+func TestTypeConverter_SaveQueryAndScan(t *testing.T) {
+	registerConverterTestTypes(t)
+	db, fake := openConverterDb(t)
+	repo := db233.NewBaseCrudRepository(db)
+
+	if err := repo.Save(&ConverterAccount{Id: ConverterPlayerId{value: 7}, Balance: ConverterMoney{cents: 1234}}); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	savedParams := fake.execArgs()[0]
+	saved := map[string]bool{}
+	for _, value := range savedParams {
+		saved[fmt.Sprint(value)] = true
+	}
+	if !saved["P-7"] || !saved["12.34"] || !saved["<nil>"] {
+		t.Errorf("保存时应使用转换器，nil 指针写为 NULL: %#v", savedParams)
+	}
+
+	condition, params, err := db233.NewConditionBuilder(db).Where("id IN (?, ?)", ConverterPlayerId{value: 7}, ConverterPlayerId{value: 8}).Build()
+	if err != nil || !reflect.DeepEqual(params, []interface{}{"P-7", "P-8"}) {
+		t.Fatalf("查询构建器应转换参数: %v %v", params, err)
+	}
+	results, err := repo.FindByCondition(condition, params, ConverterAccount{})
+	if err != nil || len(results) != 2 {
+		t.Fatalf("查询失败: %v %v", results, err)
+	}
+	first, second := results[0].(ConverterAccount), results[1].(ConverterAccount)
+	if first.Id.value != 7 || first.Balance.cents != 1234 || first.Credit != nil {
+		t.Errorf("第一行映射不正确: %+v", first)
+	}
+	if second.Id.value != 8 || second.Balance.cents != 50 || second.Credit == nil || second.Credit.cents != 300 {
+		t.Errorf("第二行映射不正确: %+v", second)
+	}
+
+	// 直接传给存储库的参数同样转换
+	fake.reset()
+	if _, err := repo.FindByCondition("id = ?", []interface{}{ConverterPlayerId{value: 9}}, ConverterAccount{}); err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if queryParams := fake.recorded()[0].args; !reflect.DeepEqual(queryParams, []driver.Value{"P-9"}) {
+		t.Errorf("存储库查询参数应转换: %#v", queryParams)
+	}
+}
+
+// 测试建表列类型
+func TestTypeConverter_SqlType(t *testing.T) {
+	registerConverterTestTypes(t)
+	cm := db233.GetCrudManagerInstance()
+	accountType := reflect.TypeOf(ConverterAccount{})
+	cases := []struct {
+		field    string
+		strategy db233.ITableCreationStrategy
+		expected string
+	}{
+		{"Balance", db233.NewMySQLStrategy(cm), "DECIMAL(20, 2)"},
+		{"Credit", db233.NewMySQLStrategy(cm), "DECIMAL(20, 2)"},
+		{"Balance", db233.NewSQLServerStrategy(cm), "DECIMAL(19, 2)"},
+		{"Id", db233.NewSQLServerStrategy(cm), "VARCHAR(32)"},
+	}
+	for _, c := range cases {
+		field, _ := accountType.FieldByName(c.field)
+		if sqlType := c.strategy.GetSQLType(field); sqlType != c.expected {
+			t.Errorf("%s 列类型不正确: %s（期望 %s）", c.field, sqlType, c.expected)
+		}
+	}
+}
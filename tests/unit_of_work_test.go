@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+type UowGuild struct {
+	Id   int64  `db:"id,primary_key"`
+	Name string `db:"name"`
+}
+
+func (e *UowGuild) TableName() string { return "uow_guild" }
+
+func (e *UowGuild) SerializeBeforeSaveDb() {}
+
+func (e *UowGuild) DeserializeAfterLoadDb() {}
+
+type UowPlayer struct {
+	Id      int64  `db:"id,primary_key"`
+	Name    string `db:"name"`
+	GuildId int64  `db:"guild_id" fk:"uow_guild(id)"`
+
+	afterSaveCalls int
+}
+
+func (e *UowPlayer) TableName() string { return "uow_player" }
+
+func (e *UowPlayer) SerializeBeforeSaveDb() {}
+
+func (e *UowPlayer) DeserializeAfterLoadDb() {}
+
+func (e *UowPlayer) AfterSave(ctx context.Context) { e.afterSaveCalls++ }
+
+type UowLog struct {
+	Id      int64  `db:"id,primary_key,auto_increment"`
+	Message string `db:"message"`
+}
+
+func (e *UowLog) TableName() string { return "uow_log" }
+
+func (e *UowLog) SerializeBeforeSaveDb() {}
+
+func (e *UowLog) DeserializeAfterLoadDb() {}
+
+// 记录事务内执行语句的驱动；语句包含 uow_fail 时返回错误，自增主键从 101 开始
+func newUnitOfWorkDb(t *testing.T) (*db233.Db, *fakeDriver) {
+	lastId := int64(100)
+	fake := newFakeDriver()
+	fake.onExec = func(stmt fakeStatement) (driver.Result, error) {
+		if strings.Contains(stmt.query, "uow_fail") {
+			return nil, errors.New("模拟写入失败")
+		}
+		lastId++
+		return fakeResult{lastInsertId: lastId, rowsAffected: 1}, nil
+	}
+	return fake.newDb(t, db233.EnumDatabaseTypeMySQL), fake
+}
+
+// 测试按外键依赖排序、同表合并批量语句并在一个事务中提交
+func TestUnitOfWork_OrderingAndBatching(t *testing.T) {
+	db, fake := newUnitOfWorkDb(t)
+
+	player1 := &UowPlayer{Id: 1, Name: "alice", GuildId: 9}
+	player2 := &UowPlayer{Id: 2, Name: "bob", GuildId: 9}
+	uow := db233.NewUnitOfWork(db).
+		Save(player1).
+		DeleteById(7, &UowGuild{}).
+		Save(&UowGuild{Id: 9, Name: "neko"}).
+		DeleteById(3, &UowPlayer{}).
+		Save(player2).
+		Update(&UowGuild{Id: 9, Name: "neko233"}).
+		DeleteById(4, &UowPlayer{})
+	if uow.Size() != 7 {
+		t.Fatalf("登记的操作数不正确: %d", uow.Size())
+	}
+
+	if err := uow.Commit(); err != nil {
+		t.Fatalf("提交失败: %v", err)
+	}
+
+	execs, args := fake.execSqls(), fake.execArgs()
+	if len(execs) != 5 {
+		t.Fatalf("应合并为 5 条语句: %v", execs)
+	}
+	if !strings.HasPrefix(execs[0], "INSERT INTO uow_guild") {
+		t.Errorf("被引用的表应先保存: %s", execs[0])
+	}
+	if !strings.HasPrefix(execs[1], "INSERT INTO uow_player") || strings.Count(execs[1], "(?,?,?)") != 2 {
+		t.Errorf("同表保存应合并为多行语句: %s", execs[1])
+	}
+	if !strings.HasPrefix(execs[2], "UPDATE uow_guild") {
+		t.Errorf("更新应在保存之后: %s", execs[2])
+	}
+	if execs[3] != "DELETE FROM uow_player WHERE id IN (?, ?)" || len(args[3]) != 2 {
+		t.Errorf("引用方应先删除并合并为 IN 条件: %s %v", execs[3], args[3])
+	}
+	if execs[4] != "DELETE FROM uow_guild WHERE id = ?" {
+		t.Errorf("被引用的表应最后删除: %s", execs[4])
+	}
+	if fake.commits != 1 || fake.rollbacks != 0 {
+		t.Errorf("应只提交一次事务: commits=%d, rollbacks=%d", fake.commits, fake.rollbacks)
+	}
+	if player1.afterSaveCalls != 1 || player2.afterSaveCalls != 1 {
+		t.Errorf("提交后应调用 AfterSave: %d %d", player1.afterSaveCalls, player2.afterSaveCalls)
+	}
+	if uow.Size() != 0 {
+		t.Errorf("提交成功后应清空操作: %d", uow.Size())
+	}
+}
+
+// 测试批量大小与自增主键逐条插入
+func TestUnitOfWork_BatchSizeAndAutoIncrement(t *testing.T) {
+	db, fake := newUnitOfWorkDb(t)
+
+	uow := db233.NewUnitOfWork(db).SetBatchSize(2)
+	for i := int64(1); i <= 5; i++ {
+		uow.Save(&UowGuild{Id: i, Name: "guild"})
+	}
+	log1 := &UowLog{Message: "first"}
+	log2 := &UowLog{Message: "second"}
+	uow.Save(log1).Save(log2)
+
+	if err := uow.Commit(); err != nil {
+		t.Fatalf("提交失败: %v", err)
+	}
+
+	guildInserts, logInserts := 0, 0
+	for _, sql := range fake.execSqls() {
+		switch {
+		case strings.HasPrefix(sql, "INSERT INTO uow_guild"):
+			guildInserts++
+		case strings.HasPrefix(sql, "INSERT INTO uow_log"):
+			logInserts++
+		}
+	}
+	if guildInserts != 3 {
+		t.Errorf("5 行按每批 2 行应拆为 3 条语句: %d", guildInserts)
+	}
+	if logInserts != 2 {
+		t.Errorf("自增主键的记录应逐条插入: %d", logInserts)
+	}
+	if log1.Id != 104 || log2.Id != 105 {
+		t.Errorf("提交后应回填自增主键: %d %d", log1.Id, log2.Id)
+	}
+}
+
+// 测试执行失败时回滚事务并保留操作
+func TestUnitOfWork_RollbackOnFailure(t *testing.T) {
+	db, fake := newUnitOfWorkDb(t)
+
+	player := &UowPlayer{Id: 1, Name: "alice", GuildId: 9}
+	uow := db233.NewUnitOfWork(db).
+		Save(player).
+		DeleteById(1, &uowFailEntity{})
+
+	if err := uow.Commit(); err == nil {
+		t.Fatal("写入失败时应返回错误")
+	}
+	if fake.commits != 0 || fake.rollbacks != 1 {
+		t.Errorf("失败时应回滚: commits=%d, rollbacks=%d", fake.commits, fake.rollbacks)
+	}
+	if player.afterSaveCalls != 0 {
+		t.Error("回滚后不应调用 AfterSave")
+	}
+	if uow.Size() != 2 {
+		t.Errorf("失败后应保留操作以便重试: %d", uow.Size())
+	}
+}
+
+type uowFailEntity struct {
+	Id int64 `db:"id,primary_key"`
+}
+
+func (e *uowFailEntity) TableName() string { return "uow_fail" }
+
+func (e *uowFailEntity) SerializeBeforeSaveDb() {}
+
+func (e *uowFailEntity) DeserializeAfterLoadDb() {}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+	db233v2 "github.com/neko233-com/db233-go/pkg/db233/v2"
+)
+
+// 返回固定结果的插件（不访问数据库）
+type fixedResultPlugin struct {
+	*db233.AbstractDb233Plugin
+	sqls    []string
+	results []interface{}
+}
+
+func (p *fixedResultPlugin) PreExecuteSql(context *db233.ExecuteSqlContext) {
+	p.sqls = append(p.sqls, context.Sql)
+	context.ShortCircuit(p.results)
+}
+
+func newFixedResultDb(results ...interface{}) (*db233.Db, *fixedResultPlugin) {
+	db := db233.NewDb(nil, 1, nil)
+	plugin := &fixedResultPlugin{AbstractDb233Plugin: db233.NewAbstractDb233Plugin("fixed-result"), results: results}
+	db.AddPlugin(plugin, 0)
+	return db, plugin
+}
+
+// 测试泛型存储库返回具体类型
+func TestV2Repository_TypedResults(t *testing.T) {
+	db, plugin := newFixedResultDb(&TestUser{ID: 1, Username: "alice"}, &TestUser{ID: 2, Username: "bob"})
+	repo := db233v2.NewRepository[TestUser](db)
+	ctx := context.Background()
+
+	users, err := repo.Find(ctx, "age > ?", 18)
+	if err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if len(users) != 2 || users[0].Username != "alice" || users[1].ID != 2 {
+		t.Errorf("结果不正确: %+v", users)
+	}
+	if plugin.sqls[0] != "SELECT * FROM test_user WHERE (age > ?)" {
+		t.Errorf("SQL 不正确: %s", plugin.sqls[0])
+	}
+
+	user, err := repo.FindById(ctx, 1)
+	if err != nil || user.Username != "alice" {
+		t.Errorf("FindById 结果不正确: %+v, %v", user, err)
+	}
+}
+
+// 测试查询不到时返回 ErrNotFound
+func TestV2Repository_NotFound(t *testing.T) {
+	db, _ := newFixedResultDb()
+	repo := db233v2.NewRepository[TestUser](db)
+
+	user, err := repo.FindById(context.Background(), 404)
+	if !errors.Is(err, db233v2.ErrNotFound) || user != nil {
+		t.Errorf("期望 ErrNotFound，得到 %+v, %v", user, err)
+	}
+
+	if err := repo.Save(context.Background(), nil); err == nil {
+		t.Error("保存 nil 应返回错误")
+	}
+	if err := repo.SaveBatch(context.Background(), []*TestUser{nil}); err == nil {
+		t.Error("批量保存包含 nil 应返回错误")
+	}
+}
+
+// 测试与 v1 的互相转换
+func TestV2Repository_V1Compatibility(t *testing.T) {
+	db, _ := newFixedResultDb()
+	legacy := db233.NewBaseCrudRepository(db).WithScope(&db233.RepositoryScope{})
+
+	repo := db233v2.FromV1[TestUser](legacy)
+	if repo.V1() != legacy {
+		t.Error("V1() 应返回原存储库")
+	}
+	if repo.V1().GetScope() == nil {
+		t.Error("FromV1 应保留作用域")
+	}
+
+	var shared *db233v2.Db = db
+	if shared.DbId != 1 {
+		t.Error("v2.Db 应为 v1.Db 的别名")
+	}
+}
+
+// 测试 context 已取消时不开启事务
+func TestV2WithTransaction_CanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	err := db233v2.WithTransaction(ctx, db233.NewDb(nil, 1, nil), func(ctx context.Context, tx *db233v2.TxManager) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || called {
+		t.Errorf("期望 context.Canceled 且不执行事务函数，得到 %v, called=%v", err, called)
+	}
+}
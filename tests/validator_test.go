@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neko233-com/db233-go/pkg/db233"
+)
+
+// ValidatedTestUser 带校验规则的测试实体
+type ValidatedTestUser struct {
+	ID       int     `db:"id,primary_key,auto_increment"`
+	Username string  `db:"username" validate:"required,max=8"`
+	Email    string  `db:"email" validate:"required,email"`
+	Age      int     `db:"age" validate:"min=0,max=150"`
+	Status   *string `db:"-" validate:"oneof=active banned"`
+}
+
+func (u *ValidatedTestUser) TableName() string {
+	return "test_user"
+}
+
+func (u *ValidatedTestUser) SerializeBeforeSaveDb() {}
+
+func (u *ValidatedTestUser) DeserializeAfterLoadDb() {}
+
+// 校验前规范化用户名
+func (u *ValidatedTestUser) BeforeValidate() error {
+	u.Username = strings.TrimSpace(u.Username)
+	return nil
+}
+
+// 测试标签规则校验
+func TestValidator_Validate(t *testing.T) {
+	validator := db233.GetValidatorInstance()
+
+	valid := &ValidatedTestUser{Username: "  alice  ", Email: "alice@example.com", Age: 20}
+	if err := validator.Validate(valid); err != nil {
+		t.Fatalf("合法实体不应校验失败: %v", err)
+	}
+	if valid.Username != "alice" {
+		t.Errorf("BeforeValidate 钩子未执行: %q", valid.Username)
+	}
+
+	status := "deleted"
+	invalid := &ValidatedTestUser{Username: "too_long_name", Email: "not-an-email", Age: -1, Status: &status}
+	err := validator.Validate(invalid)
+	var validationError *db233.ValidationError
+	if !errors.As(err, &validationError) {
+		t.Fatalf("应返回 ValidationError, 得到 %T: %v", err, err)
+	}
+	if len(validationError.Errors) != 4 {
+		t.Errorf("应有 4 个字段校验失败, 得到 %+v", validationError.Errors)
+	}
+	if fieldErrors := validationError.GetFieldErrors("email"); len(fieldErrors) != 1 || fieldErrors[0].Rule != "email" {
+		t.Errorf("email 字段错误信息不正确: %+v", fieldErrors)
+	}
+
+	missing := &ValidatedTestUser{}
+	err = validator.Validate(missing)
+	if !errors.As(err, &validationError) || len(validationError.Errors) != 2 {
+		t.Errorf("缺少必填字段应返回 2 个错误: %v", err)
+	}
+}
+
+// 测试自定义规则
+func TestValidator_RegisterRule(t *testing.T) {
+	type evenEntity struct {
+		Value int `db:"value" validate:"even"`
+	}
+
+	validator := db233.GetValidatorInstance()
+	if err := validator.Validate(&evenEntity{Value: 2}); err == nil {
+		t.Error("未注册的规则应返回错误")
+	}
+
+	validator.RegisterRule("even", func(value reflect.Value, param string) error {
+		if value.Int()%2 != 0 {
+			return errors.New("必须为偶数")
+		}
+		return nil
+	})
+	if err := validator.Validate(&evenEntity{Value: 2}); err != nil {
+		t.Errorf("偶数应通过校验: %v", err)
+	}
+	if err := validator.Validate(&evenEntity{Value: 3}); err == nil {
+		t.Error("奇数应校验失败")
+	}
+}
+
+// 测试 Save 前校验（校验失败时不访问数据库）
+func TestValidator_SaveRejectsInvalidEntity(t *testing.T) {
+	repo := db233.NewBaseCrudRepository(nil)
+	err := repo.Save(&ValidatedTestUser{Username: "bob"})
+	var validationError *db233.ValidationError
+	if !errors.As(err, &validationError) {
+		t.Errorf("Save 应返回 ValidationError, 得到 %v", err)
+	}
+}